@@ -10,6 +10,10 @@ const (
 	// Annotations like "kausality.io/trace-ticket" become Labels["ticket"] in the trace.
 	TraceMetadataPrefix = "kausality.io/trace-"
 
+	// TraceTicketAnnotation references an external ticket justifying the change.
+	// Becomes Labels["ticket"] in the trace; validated when ticket validation is enabled.
+	TraceTicketAnnotation = TraceMetadataPrefix + TraceTicketLabel
+
 	// ControllersAnnotation stores hashes of users who update parent status.
 	// Value: comma-separated 5-char base36 hashes (max 5).
 	ControllersAnnotation = "kausality.io/controllers"
@@ -38,12 +42,69 @@ const (
 	// Value: JSON Snooze object, or legacy RFC3339 timestamp.
 	SnoozeAnnotation = "kausality.io/snooze"
 
+	// SuppressAnnotation suppresses drift on a single child for a time window:
+	// drift is still reported, but admitted as if approved.
+	// Value: JSON Suppression object.
+	SuppressAnnotation = "kausality.io/suppress"
+
+	// OverrideAnnotation justifies a child mutation that would be denied as drift
+	// in enforce mode. Set by the acting user or tool on the mutation itself;
+	// the webhook removes it, so it is never persisted.
+	// Value: JSON Override object.
+	OverrideAnnotation = "kausality.io/override"
+
 	// ObservedGenerationAnnotation stores the generation observed by the controller.
 	// Written on status updates; used as fallback when status.observedGeneration is absent.
 	// Value: string representation of int64 generation.
 	ObservedGenerationAnnotation = "kausality.io/observedGeneration"
+
+	// OrphanedAnnotation records the deleted controller owner of an object
+	// orphaned by a deletion with orphan propagation, and its trace at that time.
+	// Value: JSON Orphan object.
+	OrphanedAnnotation = "kausality.io/orphaned"
+
+	// SummaryAnnotation is a human-readable one-line summary of the trace and
+	// drift history, shown by kubectl describe.
+	// Value: e.g. "updated by Deployment/web gen 7 via user alice, 2 drift incidents".
+	SummaryAnnotation = "kausality.io/summary"
+
+	// DriftCountAnnotation counts drift detected on children of a parent since
+	// the last quiet period. Written only when drift status is enabled.
+	// Value: string representation of an int.
+	DriftCountAnnotation = "kausality.io/drift-count"
+
+	// LastDriftTimeAnnotation records when drift was last detected on children
+	// of a parent. Written only when drift status is enabled.
+	// Value: RFC3339 timestamp.
+	LastDriftTimeAnnotation = "kausality.io/last-drift-time"
+
+	// ApprovedSpecAnnotation records the spec last written by the controller
+	// of a child without unapproved drift, which quarantine reverts the child
+	// to. Written only for children of policies with driftAction Quarantine.
+	// Value: JSON spec.
+	ApprovedSpecAnnotation = "kausality.io/approved-spec"
 )
 
+// BookkeepingAnnotations are written by kausality rather than by users. They
+// are removed on uninstall, and only kausality and the identities designated
+// by self-protection may set, change or remove them. Annotations set by
+// users, such as approvals, freezes and trace labels, are not bookkeeping.
+var BookkeepingAnnotations = []string{
+	TraceAnnotation,
+	ControllersAnnotation,
+	UpdatersAnnotation,
+	PhaseAnnotation,
+	ObservedGenerationAnnotation,
+	OrphanedAnnotation,
+	SummaryAnnotation,
+	DriftCountAnnotation,
+	LastDriftTimeAnnotation,
+	ApprovedSpecAnnotation,
+}
+
+// TraceTicketLabel is the trace label key derived from TraceTicketAnnotation.
+const TraceTicketLabel = "ticket"
+
 // Phase values for the PhaseAnnotation.
 const (
 	PhaseValueInitializing = "initializing"
@@ -3,6 +3,7 @@ package v1alpha1
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -23,6 +24,18 @@ type Approval struct {
 	// Mode determines approval validity and pruning behavior.
 	// One of: once, generation, always. Defaults to "once".
 	Mode string `json:"mode,omitempty"`
+	// Consumed records the use of a mode=once approval. Consumed approvals
+	// no longer approve anything and are pruned with their generation.
+	Consumed *ApprovalConsumption `json:"consumed,omitempty"`
+}
+
+// ApprovalConsumption records when and for which drift a mode=once approval
+// was used.
+type ApprovalConsumption struct {
+	// At is when the approval was used.
+	At metav1.Time `json:"at"`
+	// DriftID is the ID of the approved drift, as in drift reports.
+	DriftID string `json:"driftID,omitempty"`
 }
 
 // Rejection represents a rejection for a child resource mutation.
@@ -72,6 +85,28 @@ type Snooze struct {
 	Message string `json:"message,omitempty"`
 }
 
+// Suppression mutes drift on a child until it expires, e.g. during a known
+// noisy migration. Drift is still reported, marked as suppressed, but
+// admitted like approved drift.
+// Stored in the child's kausality.io/suppress annotation as JSON.
+type Suppression struct {
+	// Until is when the suppression expires.
+	Until metav1.Time `json:"until"`
+	// User who applied the suppression.
+	User string `json:"user,omitempty"`
+	// Message explaining why drift is suppressed.
+	Message string `json:"message,omitempty"`
+}
+
+// Override justifies a drifting child mutation in enforce mode.
+// Stored in the mutated child's kausality.io/override annotation as JSON.
+type Override struct {
+	// Justification explains why the mutation cannot wait for an approval.
+	Justification string `json:"justification"`
+	// Ticket references an external ticket tracking the override, e.g. "OPS-123".
+	Ticket string `json:"ticket"`
+}
+
 // matchChild checks if apiVersion/kind/name match the child.
 // Supports wildcards: "*" matches any value.
 func matchChild(apiVersion, kind, name string, child ChildRef) bool {
@@ -96,7 +131,11 @@ func (a *Approval) Matches(child ChildRef) bool {
 }
 
 // IsValid checks if this approval is valid for the given parent generation.
+// Consumed approvals are never valid.
 func (a *Approval) IsValid(parentGeneration int64) bool {
+	if a.Consumed != nil {
+		return false
+	}
 	mode := a.Mode
 	if mode == "" {
 		mode = ApprovalModeOnce // Default
@@ -153,6 +192,109 @@ func ParseRejections(annotationValue string) ([]Rejection, error) {
 	return rejections, nil
 }
 
+// ValidateApprovals checks an approvals annotation value strictly: besides
+// malformed JSON, it reports unknown fields, missing child references,
+// unknown modes and missing generations, which ParseApprovals accepts but
+// which never approve anything.
+func ValidateApprovals(annotationValue string) error {
+	if annotationValue == "" {
+		return nil
+	}
+
+	var approvals []Approval
+	if err := decodeStrict(annotationValue, &approvals); err != nil {
+		return fmt.Errorf("invalid approvals annotation: %w", err)
+	}
+	for i, a := range approvals {
+		if err := validateChildRef(a.APIVersion, a.Kind, a.Name); err != nil {
+			return fmt.Errorf("invalid approvals annotation: [%d]: %w", i, err)
+		}
+		switch a.Mode {
+		case "", ApprovalModeOnce, ApprovalModeGeneration:
+			if a.Generation <= 0 {
+				return fmt.Errorf("invalid approvals annotation: [%d]: generation is required for mode %q", i, defaultString(a.Mode, ApprovalModeOnce))
+			}
+		case ApprovalModeAlways:
+		default:
+			return fmt.Errorf("invalid approvals annotation: [%d]: unknown mode %q, must be %q, %q or %q", i, a.Mode, ApprovalModeOnce, ApprovalModeGeneration, ApprovalModeAlways)
+		}
+	}
+	return nil
+}
+
+// ValidateRejections checks a rejections annotation value strictly: besides
+// malformed JSON, it reports unknown fields, missing child references and
+// negative generations. Missing reasons are reported by RejectionWarnings.
+func ValidateRejections(annotationValue string) error {
+	if annotationValue == "" {
+		return nil
+	}
+
+	var rejections []Rejection
+	if err := decodeStrict(annotationValue, &rejections); err != nil {
+		return fmt.Errorf("invalid rejections annotation: %w", err)
+	}
+	for i, r := range rejections {
+		if err := validateChildRef(r.APIVersion, r.Kind, r.Name); err != nil {
+			return fmt.Errorf("invalid rejections annotation: [%d]: %w", i, err)
+		}
+		if r.Generation < 0 {
+			return fmt.Errorf("invalid rejections annotation: [%d]: generation must not be negative", i)
+		}
+	}
+	return nil
+}
+
+// RejectionWarnings returns warnings about a rejections annotation value that
+// is otherwise valid: rejections without a reason still block the child, but
+// leave the denial unexplained.
+func RejectionWarnings(annotationValue string) []string {
+	var rejections []Rejection
+	if annotationValue == "" || json.Unmarshal([]byte(annotationValue), &rejections) != nil {
+		return nil
+	}
+	var warnings []string
+	for i, r := range rejections {
+		if r.Reason == "" {
+			warnings = append(warnings, fmt.Sprintf("rejections annotation: [%d]: reason is missing, denials of %s %s will not say why", i, r.Kind, r.Name))
+		}
+	}
+	return warnings
+}
+
+// decodeStrict unmarshals a JSON annotation value, rejecting unknown fields.
+func decodeStrict(annotationValue string, v interface{}) error {
+	decoder := json.NewDecoder(strings.NewReader(annotationValue))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return fmt.Errorf("unexpected data after JSON array")
+	}
+	return nil
+}
+
+// validateChildRef checks that a child reference names apiVersion, kind and name.
+func validateChildRef(apiVersion, kind, name string) error {
+	switch {
+	case apiVersion == "":
+		return fmt.Errorf("apiVersion is required")
+	case kind == "":
+		return fmt.Errorf("kind is required")
+	case name == "":
+		return fmt.Errorf("name is required")
+	}
+	return nil
+}
+
+func defaultString(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
+
 // MarshalApprovals marshals approvals to JSON for annotation.
 func MarshalApprovals(approvals []Approval) (string, error) {
 	if len(approvals) == 0 {
@@ -264,3 +406,83 @@ func (s *Snooze) String() string {
 	}
 	return msg
 }
+
+// ParseSuppression parses the suppress annotation value.
+// Returns nil if the annotation is empty or not set.
+func ParseSuppression(annotationValue string) (*Suppression, error) {
+	if annotationValue == "" {
+		return nil, nil
+	}
+
+	var suppression Suppression
+	if err := json.Unmarshal([]byte(annotationValue), &suppression); err != nil {
+		return nil, fmt.Errorf("invalid suppress annotation: %w", err)
+	}
+	if suppression.Until.IsZero() {
+		return nil, fmt.Errorf("invalid suppress annotation: until is required")
+	}
+	return &suppression, nil
+}
+
+// MarshalSuppression marshals a suppression to JSON for annotation.
+func MarshalSuppression(suppression *Suppression) (string, error) {
+	if suppression == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(suppression)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// IsActive checks if the suppression is still active (not expired).
+func (s *Suppression) IsActive() bool {
+	if s == nil {
+		return false
+	}
+	return time.Now().Before(s.Until.Time)
+}
+
+// String returns a human-readable description of the suppression.
+func (s *Suppression) String() string {
+	if s == nil {
+		return ""
+	}
+	msg := fmt.Sprintf("suppressed until %s", s.Until.Format(time.RFC3339))
+	if s.User != "" {
+		msg += " by " + s.User
+	}
+	if s.Message != "" {
+		msg += ": " + s.Message
+	}
+	return msg
+}
+
+// ParseOverride parses the override annotation value.
+// Returns nil if the annotation is empty or not set.
+func ParseOverride(annotationValue string) (*Override, error) {
+	if annotationValue == "" {
+		return nil, nil
+	}
+
+	var override Override
+	if err := json.Unmarshal([]byte(annotationValue), &override); err != nil {
+		return nil, fmt.Errorf("invalid override annotation: %w", err)
+	}
+	if override.Justification == "" {
+		return nil, fmt.Errorf("invalid override annotation: justification is required")
+	}
+	if override.Ticket == "" {
+		return nil, fmt.Errorf("invalid override annotation: ticket is required")
+	}
+	return &override, nil
+}
+
+// String returns a human-readable description of the override.
+func (o *Override) String() string {
+	if o == nil {
+		return ""
+	}
+	return o.Ticket + ": " + o.Justification
+}
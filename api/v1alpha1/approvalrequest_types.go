@@ -0,0 +1,156 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ApprovalRequestDecision is the decision of an approver on an ApprovalRequest.
+// +kubebuilder:validation:Enum=Approve;Reject
+type ApprovalRequestDecision string
+
+const (
+	// ApprovalRequestDecisionApprove approves the drift with an approval of
+	// the requested mode on the parent.
+	ApprovalRequestDecisionApprove ApprovalRequestDecision = "Approve"
+	// ApprovalRequestDecisionReject rejects the drift with a rejection on
+	// the parent.
+	ApprovalRequestDecisionReject ApprovalRequestDecision = "Reject"
+)
+
+// ApprovalRequestPhase is the phase of an ApprovalRequest.
+type ApprovalRequestPhase string
+
+const (
+	// ApprovalRequestPhasePending means the request awaits a decision.
+	ApprovalRequestPhasePending ApprovalRequestPhase = "Pending"
+	// ApprovalRequestPhaseApproved means the approval was applied to the parent.
+	ApprovalRequestPhaseApproved ApprovalRequestPhase = "Approved"
+	// ApprovalRequestPhaseRejected means the rejection was applied to the parent.
+	ApprovalRequestPhaseRejected ApprovalRequestPhase = "Rejected"
+	// ApprovalRequestPhaseStale means the parent changed its generation, or
+	// was deleted, before a decision was applied. The denied drift belongs
+	// to a spec that no longer exists, so nothing is applied.
+	ApprovalRequestPhaseStale ApprovalRequestPhase = "Stale"
+	// ApprovalRequestPhaseInvalid means the request was not created by the
+	// webhook, or names a parent outside of its namespace. Nothing is
+	// applied.
+	ApprovalRequestPhaseInvalid ApprovalRequestPhase = "Invalid"
+)
+
+const (
+	// ApprovalRequestManagedByLabel marks the ApprovalRequests created by the
+	// webhook. Requests without it are ignored.
+	ApprovalRequestManagedByLabel = "app.kubernetes.io/managed-by"
+	// ApprovalRequestManagedByValue is the value of
+	// ApprovalRequestManagedByLabel.
+	ApprovalRequestManagedByValue = "kausality"
+)
+
+// ApprovalRequestObjectReference identifies the parent or the child of an
+// ApprovalRequest.
+type ApprovalRequestObjectReference struct {
+	// APIVersion of the object.
+	APIVersion string `json:"apiVersion"`
+	// Kind of the object.
+	Kind string `json:"kind"`
+	// Namespace of the object, empty for cluster-scoped objects.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// Name of the object.
+	Name string `json:"name"`
+}
+
+// ApprovalRequestSpec describes denied drift and the decision on it. The
+// drift it describes is immutable, so that approvers cannot redirect a
+// decision to another parent or child.
+// +kubebuilder:validation:XValidation:rule="!has(oldSelf.decision) || (has(self.decision) && self.decision == oldSelf.decision)",message="decision is immutable once set"
+// +kubebuilder:validation:XValidation:rule="self.parent == oldSelf.parent",message="parent is immutable"
+// +kubebuilder:validation:XValidation:rule="self.child == oldSelf.child",message="child is immutable"
+// +kubebuilder:validation:XValidation:rule="self.parentGeneration == oldSelf.parentGeneration",message="parentGeneration is immutable"
+type ApprovalRequestSpec struct {
+	// Parent is the parent whose controller's change was denied.
+	Parent ApprovalRequestObjectReference `json:"parent"`
+	// ParentGeneration is the generation of the parent when the drift was
+	// denied. Decisions only apply while the parent is at this generation.
+	ParentGeneration int64 `json:"parentGeneration"`
+	// Child is the object whose change was denied.
+	Child ApprovalRequestObjectReference `json:"child"`
+	// DriftID is the ID of the denied drift, as in drift reports and the
+	// causes of the denial.
+	DriftID string `json:"driftID"`
+	// User is the user whose change was denied, usually the controller.
+	// +optional
+	User string `json:"user,omitempty"`
+	// Diff is a unified diff of the child from before to after the denied
+	// change, without metadata and status. Long diffs are truncated.
+	// +optional
+	Diff string `json:"diff,omitempty"`
+
+	// Decision is set by an approver: Approve adds an approval of Mode for
+	// the child to the parent, Reject a rejection with Reason.
+	// +optional
+	Decision ApprovalRequestDecision `json:"decision,omitempty"`
+	// Mode is the mode of the approval: once, generation or always.
+	// Defaults to once.
+	// +optional
+	// +kubebuilder:validation:Enum=once;generation;always
+	Mode string `json:"mode,omitempty"`
+	// Reason explains the decision. It is the reason of rejections.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+}
+
+// ApprovalRequestStatus is the observed state of an ApprovalRequest.
+type ApprovalRequestStatus struct {
+	// Phase is Pending until the decision is applied to the parent, or the
+	// request becomes stale or invalid.
+	// +optional
+	Phase ApprovalRequestPhase `json:"phase,omitempty"`
+	// CompletedAt is when the request left the Pending phase.
+	// +optional
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+	// Message explains the phase.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// ApprovalRequest asks humans to approve drift denied in enforce mode.
+//
+// The webhook creates an ApprovalRequest for each distinct drift it denies,
+// so that controllers blocked by denials end up in a queue instead of
+// failing silently. Approvers set spec.decision, and the webhook applies the
+// corresponding approval or rejection annotation to the parent, which lets
+// the controller's next retry through or keeps denying it with a reason.
+// Requests live in the namespace of their parent, or in the configured
+// namespace for cluster-scoped parents.
+//
+// +genclient
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Namespaced
+// +kubebuilder:printcolumn:name="Parent",type=string,JSONPath=`.spec.parent.name`
+// +kubebuilder:printcolumn:name="Child-Kind",type=string,JSONPath=`.spec.child.kind`
+// +kubebuilder:printcolumn:name="Child",type=string,JSONPath=`.spec.child.name`
+// +kubebuilder:printcolumn:name="Decision",type=string,JSONPath=`.spec.decision`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+type ApprovalRequest struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ApprovalRequestSpec   `json:"spec,omitempty"`
+	Status ApprovalRequestStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ApprovalRequestList contains a list of ApprovalRequest resources.
+type ApprovalRequestList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ApprovalRequest `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ApprovalRequest{}, &ApprovalRequestList{})
+}
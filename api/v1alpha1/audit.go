@@ -0,0 +1,144 @@
+package v1alpha1
+
+// AuditSchemaVersion is the version of the audit annotation schema. Keys and
+// values of a version are only ever added, never renamed or removed.
+const AuditSchemaVersion = "v1"
+
+// DefaultAuditKeyPrefix is the default prefix of audit annotation keys.
+const DefaultAuditKeyPrefix = "kausality.io/"
+
+// Audit annotation keys of AdmissionResponse.AuditAnnotations, without prefix.
+// They appear in the Kubernetes audit log, not on the object.
+const (
+	// AuditKeyDecision is the admission decision.
+	AuditKeyDecision = "decision"
+	// AuditKeyDrift is whether drift was detected.
+	AuditKeyDrift = "drift"
+	// AuditKeyMode is the drift detection mode applied.
+	AuditKeyMode = "mode"
+	// AuditKeyLifecyclePhase is the lifecycle phase of the parent.
+	AuditKeyLifecyclePhase = "lifecycle-phase"
+	// AuditKeyDriftResolution is how detected drift was handled.
+	AuditKeyDriftResolution = "drift-resolution"
+	// AuditKeyTrace is the causal trace as JSON.
+	AuditKeyTrace = "trace"
+	// AuditKeyTicket is the validated ticket of an origin change.
+	AuditKeyTicket = "ticket"
+	// AuditKeyOverride is the override that allowed drift in enforce mode.
+	AuditKeyOverride = "override"
+	// AuditKeySuppression is the child's suppression that admitted drift.
+	AuditKeySuppression = "suppression"
+	// AuditKeySubresource is the tracked subresource.
+	AuditKeySubresource = "subresource"
+	// AuditKeyDecisionCache marks denials reused from the decision cache.
+	AuditKeyDecisionCache = "decision-cache"
+	// AuditKeyDriftExclusion is the policy whose drift exclusion skipped drift.
+	AuditKeyDriftExclusion = "drift-exclusion"
+	// AuditKeyError is the class of an error that prevented drift detection.
+	AuditKeyError = "error"
+	// AuditKeySchemaVersion is the AuditSchemaVersion of the annotations.
+	AuditKeySchemaVersion = "schema-version"
+	// AuditKeyDenialReason is the DenialReason of a denial.
+	AuditKeyDenialReason = "denial-reason"
+	// AuditKeyResourceFilter is why the resource filter admitted a request
+	// without evaluation.
+	AuditKeyResourceFilter = "resource-filter"
+)
+
+// Values of AuditKeyResourceFilter.
+const (
+	// ResourceFilterDenied means the resource is in the deny list.
+	ResourceFilterDenied = "denied"
+	// ResourceFilterUntracked means no policy tracks the resource.
+	ResourceFilterUntracked = "untracked"
+)
+
+// AuditAnnotation describes an audit annotation key of the schema.
+// +kubebuilder:object:generate=false
+type AuditAnnotation struct {
+	// Key is the key without prefix.
+	Key string
+	// Description describes the value and when the key is set.
+	Description string
+	// Values lists the possible values, if they are enumerable.
+	Values []string
+}
+
+// AuditAnnotations is the audit annotation schema of AuditSchemaVersion.
+var AuditAnnotations = []AuditAnnotation{
+	{
+		Key:         AuditKeyDecision,
+		Description: "Admission decision, derived from the response. Always set.",
+		Values:      []string{"allowed", "allowed-with-warning", "denied", "error"},
+	},
+	{
+		Key:         AuditKeyDrift,
+		Description: "Whether drift was detected. Set after drift detection runs.",
+		Values:      []string{"true", "false"},
+	},
+	{
+		Key:         AuditKeyMode,
+		Description: "Drift detection mode applied. Set once the object's policy is resolved, before drift detection.",
+		Values:      []string{string(ModeLog), string(ModeEnforce)},
+	},
+	{
+		Key:         AuditKeyLifecyclePhase,
+		Description: "Lifecycle phase of the parent, or of the object itself on status updates. Set when the object has a parent and on status updates.",
+		Values:      []string{"Initializing", "Initialized", "Deleting"},
+	},
+	{
+		Key:         AuditKeyDriftResolution,
+		Description: "How detected drift was handled. Set when drift is detected, unless the parent is frozen.",
+		Values:      []string{"approved", "rejected", "overridden", "suppressed", "unresolved"},
+	},
+	{
+		Key:         AuditKeyTrace,
+		Description: "Causal trace as JSON array of hops, the hops of the kausality.io/trace annotation. Set after trace propagation, and on denials of drift, rejected drift and frozen parents with the trace the mutation would have had.",
+	},
+	{
+		Key:         AuditKeyTicket,
+		Description: "Validated ticket ID of an origin change. Set when ticket validation accepts the change.",
+	},
+	{
+		Key:         AuditKeyOverride,
+		Description: "Override that allowed drift in enforce mode, as '<ticket>: <justification>'.",
+	},
+	{
+		Key:         AuditKeySuppression,
+		Description: "Suppression of the child that admitted drift, as 'suppressed until <time> by <user>: <message>'.",
+	},
+	{
+		Key:         AuditKeySubresource,
+		Description: "Subresource, e.g. scale or status. Set on tracked subresources not carrying the full object and on status updates.",
+	},
+	{
+		Key:         AuditKeyDecisionCache,
+		Description: "Set when a denial is reused from the decision cache.",
+		Values:      []string{"hit"},
+	},
+	{
+		Key:         AuditKeyDriftExclusion,
+		Description: "Name of the policy whose drift exclusion skipped detected drift.",
+	},
+	{
+		Key:         AuditKeyError,
+		Description: "Class of an error that prevented drift detection.",
+		Values:      []string{"ParentNotFound", "ParentForbidden", "DecodeError", "PolicyUnavailable", "Internal", "BudgetExhausted"},
+	},
+	{
+		Key:         AuditKeySchemaVersion,
+		Description: "Version of this schema. Always set.",
+		Values:      []string{AuditSchemaVersion},
+	},
+	{
+		Key:         AuditKeyDenialReason,
+		Description: "Reason of a denial, as in the kausality.io/reason cause of its status details. Set on denials other than those of the error handling, which set error.",
+		Values: []string{string(DenialReasonDrift), string(DenialReasonRejected), string(DenialReasonFrozen), string(DenialReasonTicket),
+			string(DenialReasonInvalidAnnotation), string(DenialReasonMissingParent), string(DenialReasonOwnerReference), string(DenialReasonProtected)},
+	},
+	{
+		Key:         AuditKeyResourceFilter,
+		Description: "Why the resourceFilter of the webhook config admitted the request without evaluation: its resource is denied, or tracked by no policy.",
+		Values:      []string{ResourceFilterDenied, ResourceFilterUntracked},
+	},
+}
@@ -0,0 +1,46 @@
+package v1alpha1
+
+// DenialReason classifies mutations denied by kausality. It is the message of
+// the DenialCauseReason cause of the denial's status details.
+type DenialReason string
+
+const (
+	// DenialReasonDrift is drift in enforce mode without approval.
+	DenialReasonDrift DenialReason = "Drift"
+	// DenialReasonRejected is drift matching a rejection on the parent.
+	DenialReasonRejected DenialReason = "Rejected"
+	// DenialReasonFrozen is a mutation of a child of a frozen parent.
+	DenialReasonFrozen DenialReason = "Frozen"
+	// DenialReasonTicket is an origin change without valid ticket.
+	DenialReasonTicket DenialReason = "Ticket"
+	// DenialReasonInvalidAnnotation is a write of invalid approvals or
+	// rejections to a parent in enforce mode.
+	DenialReasonInvalidAnnotation DenialReason = "InvalidAnnotation"
+	// DenialReasonMissingParent is a mutation of an object whose controller
+	// owner does not exist, by a policy with missingParent Deny.
+	DenialReasonMissingParent DenialReason = "MissingParent"
+	// DenialReasonOwnerReference is a controller owner reference added by
+	// another actor than the owner's controller, or not resolving to the
+	// owner, by a policy with ownerReferences Deny.
+	DenialReasonOwnerReference DenialReason = "OwnerReference"
+	// DenialReasonProtected is a change of kausality's own resources, or a
+	// removal of its bookkeeping annotations, by an identity not designated
+	// by self-protection.
+	DenialReasonProtected DenialReason = "Protected"
+)
+
+// Cause types of the status details of denials. Clients detect kausality
+// denials by the DenialCauseReason cause.
+const (
+	// DenialCauseReason carries the DenialReason.
+	DenialCauseReason = "kausality.io/reason"
+	// DenialCauseDriftID carries the ID of the drift report of the denial.
+	DenialCauseDriftID = "kausality.io/drift-id"
+	// DenialCauseParent carries the parent as "<apiVersion>/<kind>:<namespace>/<name>".
+	DenialCauseParent = "kausality.io/parent"
+	// DenialCauseApprovalExample carries an approval allowing the mutation,
+	// as JSON array for the ApprovalsAnnotation of the parent.
+	DenialCauseApprovalExample = "kausality.io/approval-example"
+	// DenialCauseDocs carries a URL of the documentation of the remediation.
+	DenialCauseDocs = "kausality.io/docs"
+)
@@ -0,0 +1,241 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/kausality-io/kausality/api/v1beta1"
+)
+
+// ConversionDataAnnotation keeps the v1beta1 fields of a Kausality that
+// v1alpha1 cannot represent, so that they survive a round trip through
+// v1alpha1 clients.
+const ConversionDataAnnotation = "kausality.io/conversion-data"
+
+// conversionData holds the v1beta1 fields lost in v1alpha1.
+type conversionData struct {
+	// FailurePolicies are the failure policies of the resource rules, by
+	// index. They are only restored if the number of rules is unchanged.
+	FailurePolicies []*v1beta1.FailurePolicyType `json:"failurePolicies,omitempty"`
+	// FieldPaths are the field paths of the resource rules, by index, like
+	// FailurePolicies.
+	FieldPaths [][]string             `json:"fieldPaths,omitempty"`
+	Schedules  []v1beta1.ModeSchedule `json:"schedules,omitempty"`
+	// ObjectSelectorMatch is set if it differs from the one a v1alpha1
+	// policy converts to.
+	ObjectSelectorMatch *v1beta1.ObjectSelectorMatchType `json:"objectSelectorMatch,omitempty"`
+	ControllerIdentity  *v1beta1.ControllerIdentity      `json:"controllerIdentity,omitempty"`
+	MissingParent       v1beta1.MissingParentAction      `json:"missingParent,omitempty"`
+	OwnerReferences     v1beta1.OwnerReferenceAction     `json:"ownerReferences,omitempty"`
+	DriftAction         v1beta1.DriftAction              `json:"driftAction,omitempty"`
+}
+
+// objectSelectorMatch returns the object selector match of a v1alpha1 policy
+// with the given object selector: v1alpha1 object selectors only match the
+// new object.
+func objectSelectorMatch(selector *metav1.LabelSelector) v1beta1.ObjectSelectorMatchType {
+	if selector == nil {
+		return ""
+	}
+	return v1beta1.ObjectSelectorMatchNewObject
+}
+
+// ConvertTo converts this Kausality to the hub version v1beta1.
+func (src *Kausality) ConvertTo(dstRaw conversion.Hub) error {
+	dst, ok := dstRaw.(*v1beta1.Kausality)
+	if !ok {
+		return fmt.Errorf("unsupported conversion to %T", dstRaw)
+	}
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	dst.Spec = v1beta1.KausalitySpec{
+		Namespaces:          convertNamespaceSelectorTo(src.Spec.Namespaces),
+		ObjectSelector:      src.Spec.ObjectSelector.DeepCopy(),
+		ObjectSelectorMatch: objectSelectorMatch(src.Spec.ObjectSelector),
+		Mode:                v1beta1.Mode(src.Spec.Mode),
+	}
+	for _, rule := range src.Spec.Resources {
+		out := v1beta1.ResourceRule{
+			APIGroups: append([]string(nil), rule.APIGroups...),
+			Resources: append([]string(nil), rule.Resources...),
+			Excluded:  append([]string(nil), rule.Excluded...),
+		}
+		for _, sub := range rule.Subresources {
+			out.Subresources = append(out.Subresources, v1beta1.SubresourceRule{Name: sub.Name, Handling: v1beta1.SubresourceHandling(sub.Handling)})
+		}
+		dst.Spec.Resources = append(dst.Spec.Resources, out)
+	}
+	for _, override := range src.Spec.Overrides {
+		dst.Spec.Overrides = append(dst.Spec.Overrides, v1beta1.ModeOverride{
+			APIGroups:         append([]string(nil), override.APIGroups...),
+			Resources:         append([]string(nil), override.Resources...),
+			Namespaces:        append([]string(nil), override.Namespaces...),
+			RolloutPercentage: copyInt32(override.RolloutPercentage),
+			Mode:              v1beta1.Mode(override.Mode),
+		})
+	}
+	for _, exclusion := range src.Spec.DriftExclusions {
+		dst.Spec.DriftExclusions = append(dst.Spec.DriftExclusions, v1beta1.DriftExclusion{
+			LabelSelector: exclusion.LabelSelector.DeepCopy(),
+			Annotations:   copyMap(exclusion.Annotations),
+		})
+	}
+	dst.Status = v1beta1.KausalityStatus{Conditions: src.Status.DeepCopy().Conditions}
+
+	data, ok := dst.Annotations[ConversionDataAnnotation]
+	if !ok {
+		return nil
+	}
+	delete(dst.Annotations, ConversionDataAnnotation)
+	if len(dst.Annotations) == 0 {
+		dst.Annotations = nil
+	}
+	var restored conversionData
+	if err := json.Unmarshal([]byte(data), &restored); err != nil {
+		return fmt.Errorf("invalid %s annotation: %w", ConversionDataAnnotation, err)
+	}
+	if len(restored.FailurePolicies) == len(dst.Spec.Resources) {
+		for i := range dst.Spec.Resources {
+			dst.Spec.Resources[i].FailurePolicy = restored.FailurePolicies[i]
+		}
+	}
+	if len(restored.FieldPaths) == len(dst.Spec.Resources) {
+		for i := range dst.Spec.Resources {
+			dst.Spec.Resources[i].FieldPaths = restored.FieldPaths[i]
+		}
+	}
+	dst.Spec.Schedules = restored.Schedules
+	if restored.ObjectSelectorMatch != nil {
+		dst.Spec.ObjectSelectorMatch = *restored.ObjectSelectorMatch
+	}
+	dst.Spec.ControllerIdentity = restored.ControllerIdentity
+	dst.Spec.MissingParent = restored.MissingParent
+	dst.Spec.OwnerReferences = restored.OwnerReferences
+	dst.Spec.DriftAction = restored.DriftAction
+	return nil
+}
+
+// ConvertFrom converts from the hub version v1beta1 to this Kausality.
+func (dst *Kausality) ConvertFrom(srcRaw conversion.Hub) error {
+	src, ok := srcRaw.(*v1beta1.Kausality)
+	if !ok {
+		return fmt.Errorf("unsupported conversion from %T", srcRaw)
+	}
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	dst.Spec = KausalitySpec{
+		Namespaces:     convertNamespaceSelectorFrom(src.Spec.Namespaces),
+		ObjectSelector: src.Spec.ObjectSelector.DeepCopy(),
+		Mode:           Mode(src.Spec.Mode),
+	}
+	var lost conversionData
+	hasFailurePolicies, hasFieldPaths := false, false
+	for _, rule := range src.Spec.Resources {
+		out := ResourceRule{
+			APIGroups: append([]string(nil), rule.APIGroups...),
+			Resources: append([]string(nil), rule.Resources...),
+			Excluded:  append([]string(nil), rule.Excluded...),
+		}
+		for _, sub := range rule.Subresources {
+			out.Subresources = append(out.Subresources, SubresourceRule{Name: sub.Name, Handling: SubresourceHandling(sub.Handling)})
+		}
+		dst.Spec.Resources = append(dst.Spec.Resources, out)
+		lost.FailurePolicies = append(lost.FailurePolicies, rule.FailurePolicy)
+		hasFailurePolicies = hasFailurePolicies || rule.FailurePolicy != nil
+		lost.FieldPaths = append(lost.FieldPaths, append([]string(nil), rule.FieldPaths...))
+		hasFieldPaths = hasFieldPaths || len(rule.FieldPaths) > 0
+	}
+	for _, override := range src.Spec.Overrides {
+		dst.Spec.Overrides = append(dst.Spec.Overrides, ModeOverride{
+			APIGroups:         append([]string(nil), override.APIGroups...),
+			Resources:         append([]string(nil), override.Resources...),
+			Namespaces:        append([]string(nil), override.Namespaces...),
+			RolloutPercentage: copyInt32(override.RolloutPercentage),
+			Mode:              Mode(override.Mode),
+		})
+	}
+	for _, exclusion := range src.Spec.DriftExclusions {
+		dst.Spec.DriftExclusions = append(dst.Spec.DriftExclusions, DriftExclusion{
+			LabelSelector: exclusion.LabelSelector.DeepCopy(),
+			Annotations:   copyMap(exclusion.Annotations),
+		})
+	}
+	dst.Status = KausalityStatus{Conditions: src.Status.DeepCopy().Conditions}
+
+	if !hasFailurePolicies {
+		lost.FailurePolicies = nil
+	}
+	if !hasFieldPaths {
+		lost.FieldPaths = nil
+	}
+	for _, schedule := range src.Spec.Schedules {
+		lost.Schedules = append(lost.Schedules, *schedule.DeepCopy())
+	}
+	if match := src.Spec.ObjectSelectorMatch; match != objectSelectorMatch(src.Spec.ObjectSelector) {
+		lost.ObjectSelectorMatch = &match
+	}
+	lost.ControllerIdentity = src.Spec.ControllerIdentity.DeepCopy()
+	lost.MissingParent = src.Spec.MissingParent
+	lost.OwnerReferences = src.Spec.OwnerReferences
+	lost.DriftAction = src.Spec.DriftAction
+	delete(dst.Annotations, ConversionDataAnnotation)
+	if lost.FailurePolicies == nil && lost.FieldPaths == nil && lost.Schedules == nil && lost.ObjectSelectorMatch == nil &&
+		lost.ControllerIdentity == nil && lost.MissingParent == "" && lost.OwnerReferences == "" &&
+		lost.DriftAction == "" {
+		if len(dst.Annotations) == 0 {
+			dst.Annotations = nil
+		}
+		return nil
+	}
+	data, err := json.Marshal(lost)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s annotation: %w", ConversionDataAnnotation, err)
+	}
+	if dst.Annotations == nil {
+		dst.Annotations = map[string]string{}
+	}
+	dst.Annotations[ConversionDataAnnotation] = string(data)
+	return nil
+}
+
+func convertNamespaceSelectorTo(in *NamespaceSelector) *v1beta1.NamespaceSelector {
+	if in == nil {
+		return nil
+	}
+	return &v1beta1.NamespaceSelector{
+		Names:    append([]string(nil), in.Names...),
+		Selector: in.Selector.DeepCopy(),
+		Excluded: append([]string(nil), in.Excluded...),
+	}
+}
+
+func convertNamespaceSelectorFrom(in *v1beta1.NamespaceSelector) *NamespaceSelector {
+	if in == nil {
+		return nil
+	}
+	return &NamespaceSelector{
+		Names:    append([]string(nil), in.Names...),
+		Selector: in.Selector.DeepCopy(),
+		Excluded: append([]string(nil), in.Excluded...),
+	}
+}
+
+func copyInt32(in *int32) *int32 {
+	if in == nil {
+		return nil
+	}
+	out := *in
+	return &out
+}
+
+func copyMap(in map[string]string) map[string]string {
+	if in == nil {
+		return nil
+	}
+	out := make(map[string]string, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,110 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/kausality-io/kausality/api/v1beta1"
+)
+
+func TestKausalityConversion(t *testing.T) {
+	hub := &v1beta1.Kausality{
+		ObjectMeta: metav1.ObjectMeta{Name: "apps", Annotations: map[string]string{"team": "platform"}},
+		Spec: v1beta1.KausalitySpec{
+			Resources: []v1beta1.ResourceRule{
+				{APIGroups: []string{"apps"}, Resources: []string{"*"}, Excluded: []string{"replicasets"}},
+				{
+					APIGroups:     []string{""},
+					Resources:     []string{"pods"},
+					Subresources:  []v1beta1.SubresourceRule{{Name: "exec", Handling: v1beta1.SubresourceHandlingTrack}},
+					FailurePolicy: ptr.To(v1beta1.FailurePolicyIgnore),
+					FieldPaths:    []string{"spec.containers[*].image"},
+				},
+			},
+			Namespaces:     &v1beta1.NamespaceSelector{Names: []string{"prod"}, Excluded: []string{"prod-sandbox"}},
+			ObjectSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "critical"}},
+			Mode:           v1beta1.ModeLog,
+			Schedules: []v1beta1.ModeSchedule{
+				{Days: []v1beta1.Weekday{"Monday"}, Start: "09:00", End: "17:00", TimeZone: "Europe/Berlin", Mode: v1beta1.ModeEnforce},
+			},
+			Overrides:       []v1beta1.ModeOverride{{Namespaces: []string{"prod"}, RolloutPercentage: ptr.To(int32(10)), Mode: v1beta1.ModeEnforce}},
+			DriftExclusions: []v1beta1.DriftExclusion{{Annotations: map[string]string{"example.com/dual-managed": ""}}},
+			ControllerIdentity: &v1beta1.ControllerIdentity{
+				Classifiers:     []v1beta1.ClassifierType{v1beta1.ClassifierServiceAccount, v1beta1.ClassifierUpdaterHash},
+				ServiceAccounts: []string{"system:serviceaccount:crossplane-system:*"},
+			},
+			MissingParent:   v1beta1.MissingParentDeny,
+			OwnerReferences: v1beta1.OwnerReferenceWarn,
+			DriftAction:     v1beta1.DriftActionQuarantine,
+		},
+		Status: v1beta1.KausalityStatus{Conditions: []metav1.Condition{{Type: "Ready", Status: metav1.ConditionTrue}}},
+	}
+
+	var spoke Kausality
+	require.NoError(t, spoke.ConvertFrom(hub.DeepCopy()))
+	assert.Equal(t, ModeLog, spoke.Spec.Mode)
+	assert.Equal(t, []string{"replicasets"}, spoke.Spec.Resources[0].Excluded)
+	assert.Equal(t, "platform", spoke.Annotations["team"])
+	assert.Contains(t, spoke.Annotations, ConversionDataAnnotation)
+
+	var roundTripped v1beta1.Kausality
+	require.NoError(t, spoke.ConvertTo(&roundTripped))
+	assert.Equal(t, hub, &roundTripped)
+
+	// Without v1beta1-only fields, no annotation is written. Object selectors
+	// of v1alpha1 only match the new object.
+	hub.Spec.Resources[1].FailurePolicy = nil
+	hub.Spec.Resources[1].FieldPaths = nil
+	hub.Spec.Schedules = nil
+	hub.Spec.ControllerIdentity = nil
+	hub.Spec.MissingParent = ""
+	hub.Spec.OwnerReferences = ""
+	hub.Spec.DriftAction = ""
+	hub.Spec.ObjectSelectorMatch = v1beta1.ObjectSelectorMatchNewObject
+	spoke = Kausality{}
+	require.NoError(t, spoke.ConvertFrom(hub.DeepCopy()))
+	assert.NotContains(t, spoke.Annotations, ConversionDataAnnotation)
+	roundTripped = v1beta1.Kausality{}
+	require.NoError(t, spoke.ConvertTo(&roundTripped))
+	assert.Equal(t, hub, &roundTripped)
+
+	// Failure policies and field paths of rules changed through v1alpha1 are dropped
+	hub.Spec.Resources[1].FailurePolicy = ptr.To(v1beta1.FailurePolicyIgnore)
+	hub.Spec.Resources[1].FieldPaths = []string{"spec.containers[*].image"}
+	spoke = Kausality{}
+	require.NoError(t, spoke.ConvertFrom(hub.DeepCopy()))
+	spoke.Spec.Resources = spoke.Spec.Resources[:1]
+	roundTripped = v1beta1.Kausality{}
+	require.NoError(t, spoke.ConvertTo(&roundTripped))
+	assert.Nil(t, roundTripped.Spec.Resources[0].FailurePolicy)
+	assert.Nil(t, roundTripped.Spec.Resources[0].FieldPaths)
+	assert.NotContains(t, roundTripped.Annotations, ConversionDataAnnotation)
+}
+
+func TestKausalityConversion_ObjectSelectorMatch(t *testing.T) {
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "critical"}}
+
+	// v1alpha1 object selectors only match the new object
+	var hub v1beta1.Kausality
+	require.NoError(t, (&Kausality{Spec: KausalitySpec{ObjectSelector: selector}}).ConvertTo(&hub))
+	assert.Equal(t, v1beta1.ObjectSelectorMatchNewObject, hub.Spec.ObjectSelectorMatch)
+	hub = v1beta1.Kausality{}
+	require.NoError(t, (&Kausality{}).ConvertTo(&hub))
+	assert.Empty(t, hub.Spec.ObjectSelectorMatch)
+
+	// Other matches are kept across a round trip
+	for _, match := range []v1beta1.ObjectSelectorMatchType{"", v1beta1.ObjectSelectorMatchOldOrNewObject} {
+		hub := &v1beta1.Kausality{Spec: v1beta1.KausalitySpec{ObjectSelector: selector, ObjectSelectorMatch: match}}
+		var spoke Kausality
+		require.NoError(t, spoke.ConvertFrom(hub.DeepCopy()))
+		assert.Contains(t, spoke.Annotations, ConversionDataAnnotation)
+		var roundTripped v1beta1.Kausality
+		require.NoError(t, spoke.ConvertTo(&roundTripped))
+		assert.Equal(t, match, roundTripped.Spec.ObjectSelectorMatch, "match %q", match)
+	}
+}
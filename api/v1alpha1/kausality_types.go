@@ -36,6 +36,44 @@ type ResourceRule struct {
 	// +optional
 	// +kubebuilder:validation:MaxItems=50
 	Excluded []string `json:"excluded,omitempty"`
+
+	// Subresources configures which subresources of the matched resources are
+	// intercepted and how. Entries override the default, which intercepts
+	// status with handling "controller". Other subresources are not intercepted
+	// unless listed.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MaxItems=20
+	Subresources []SubresourceRule `json:"subresources,omitempty"`
+}
+
+// SubresourceHandling defines how requests to a subresource are handled.
+// +kubebuilder:validation:Enum=controller;track;ignore
+type SubresourceHandling string
+
+const (
+	// SubresourceHandlingController records the user as controller of the
+	// object, like status updates. Only for subresources carrying the full object.
+	SubresourceHandlingController SubresourceHandling = "controller"
+
+	// SubresourceHandlingTrack treats requests as mutations of the object:
+	// they are checked for drift and traced. CONNECT subresources (exec,
+	// attach, portforward, proxy) are audited.
+	SubresourceHandlingTrack SubresourceHandling = "track"
+
+	// SubresourceHandlingIgnore does not intercept the subresource.
+	SubresourceHandlingIgnore SubresourceHandling = "ignore"
+)
+
+// SubresourceRule configures the handling of one subresource.
+type SubresourceRule struct {
+	// Name of the subresource, e.g. "status", "scale", "ephemeralcontainers" or "exec".
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Handling of requests to the subresource.
+	Handling SubresourceHandling `json:"handling"`
 }
 
 // NamespaceSelector defines which namespaces to track.
@@ -60,7 +98,7 @@ type NamespaceSelector struct {
 // ModeOverride allows fine-grained mode configuration for specific resources or namespaces.
 // Overrides are evaluated in order; first match wins.
 //
-// +kubebuilder:validation:XValidation:rule="size(self.apiGroups) > 0 || size(self.resources) > 0 || size(self.namespaces) > 0",message="override must have at least one filter (apiGroups, resources, or namespaces)"
+// +kubebuilder:validation:XValidation:rule="size(self.apiGroups) > 0 || size(self.resources) > 0 || size(self.namespaces) > 0 || has(self.rolloutPercentage)",message="override must have at least one filter (apiGroups, resources, namespaces, or rolloutPercentage)"
 type ModeOverride struct {
 	// APIGroups limits this override to specific API groups.
 	// +optional
@@ -77,10 +115,36 @@ type ModeOverride struct {
 	// +kubebuilder:validation:MaxItems=100
 	Namespaces []string `json:"namespaces,omitempty"`
 
+	// RolloutPercentage limits this override to a deterministic subset of
+	// objects: those whose hash of namespace/name modulo 100 is below it.
+	// Other objects fall through to the next override. Raising it ramps up
+	// the override, e.g. enforce mode, without re-selecting objects.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	RolloutPercentage *int32 `json:"rolloutPercentage,omitempty"`
+
 	// Mode is the drift detection mode for matching resources.
 	Mode Mode `json:"mode"`
 }
 
+// DriftExclusion selects objects that are traced but never evaluated for
+// drift, e.g. objects intentionally managed by two controllers.
+// Objects must match all of the given criteria.
+//
+// +kubebuilder:validation:XValidation:rule="has(self.labelSelector) || (has(self.annotations) && size(self.annotations) > 0)",message="exclusion must have a labelSelector or annotations"
+type DriftExclusion struct {
+	// LabelSelector matches objects by labels.
+	// +optional
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+
+	// Annotations matches objects carrying all of the given annotations.
+	// An empty value matches any value.
+	// +optional
+	// +kubebuilder:validation:MaxProperties=10
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
 // KausalitySpec defines the desired state of a Kausality policy.
 type KausalitySpec struct {
 	// Resources defines which resources to track.
@@ -106,6 +170,13 @@ type KausalitySpec struct {
 	// +optional
 	// +kubebuilder:validation:MaxItems=50
 	Overrides []ModeOverride `json:"overrides,omitempty"`
+
+	// DriftExclusions exclude objects from drift evaluation. Matching objects
+	// stay tracked and traced, but mutations are never drift, in any mode.
+	// An object is excluded if it matches any exclusion.
+	// +optional
+	// +kubebuilder:validation:MaxItems=20
+	DriftExclusions []DriftExclusion `json:"driftExclusions,omitempty"`
 }
 
 // KausalityStatus defines the observed state of a Kausality policy.
@@ -122,6 +193,8 @@ type KausalityStatus struct {
 // the same resource, specificity-based precedence resolves conflicts:
 // more specific namespace selectors and resource lists win over broader ones.
 //
+// +genclient
+// +genclient:nonNamespaced
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:scope=Cluster
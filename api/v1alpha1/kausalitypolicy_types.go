@@ -0,0 +1,59 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KausalityPolicySpec defines the desired state of a namespaced KausalityPolicy.
+type KausalityPolicySpec struct {
+	// Resources limits the policy to resources of the namespace. Resources must
+	// also be tracked by a cluster-scoped Kausality policy; the namespaced
+	// policy cannot track additional resources.
+	// If omitted, the policy applies to all tracked resources of the namespace.
+	// +optional
+	// +kubebuilder:validation:MaxItems=20
+	// +kubebuilder:validation:XValidation:rule="self.all(r, !has(r.subresources))",message="subresources are configured by cluster-scoped Kausality policies"
+	Resources []ResourceRule `json:"resources,omitempty"`
+
+	// ObjectSelector filters objects by labels.
+	// +optional
+	ObjectSelector *metav1.LabelSelector `json:"objectSelector,omitempty"`
+
+	// Mode is the drift detection mode for matching resources. It can only
+	// tighten the mode of the cluster-scoped policies: "enforce" enforces
+	// resources the cluster logs, "log" never loosens enforcement.
+	Mode Mode `json:"mode"`
+}
+
+// KausalityPolicy tightens drift detection for resources in its namespace.
+//
+// Tenants manage KausalityPolicies in their own namespaces without access to
+// the cluster-scoped Kausality policies, which remain the upper bound: a
+// KausalityPolicy only applies to resources they track, only in its own
+// namespace, and can only raise the mode from log to enforce.
+//
+// +genclient
+// +genclient:noStatus
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Namespaced
+// +kubebuilder:printcolumn:name="Mode",type=string,JSONPath=`.spec.mode`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+type KausalityPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec KausalityPolicySpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// KausalityPolicyList contains a list of KausalityPolicy resources.
+type KausalityPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KausalityPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KausalityPolicy{}, &KausalityPolicyList{})
+}
@@ -0,0 +1,50 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Orphan records that an object was orphaned: its controller owner was deleted
+// with orphan propagation and the garbage collector removed the owner reference.
+// Stored in the object's kausality.io/orphaned annotation as JSON.
+type Orphan struct {
+	// APIVersion of the deleted owner.
+	APIVersion string `json:"apiVersion"`
+	// Kind of the deleted owner.
+	Kind string `json:"kind"`
+	// Name of the deleted owner.
+	Name string `json:"name"`
+	// UID of the deleted owner.
+	UID types.UID `json:"uid"`
+	// At is when the owner reference was removed.
+	At metav1.Time `json:"at"`
+	// Trace is the object's trace when it was orphaned, i.e. the last change
+	// caused by the owner.
+	Trace Trace `json:"trace,omitempty"`
+}
+
+// ParseOrphan parses an Orphan from its JSON representation.
+func ParseOrphan(data string) (*Orphan, error) {
+	var orphan Orphan
+	if err := json.Unmarshal([]byte(data), &orphan); err != nil {
+		return nil, err
+	}
+	return &orphan, nil
+}
+
+// String returns the JSON representation of the orphan.
+func (o *Orphan) String() string {
+	data, err := json.Marshal(o)
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
+
+// Owner returns the deleted owner as "Kind/name".
+func (o *Orphan) Owner() string {
+	return o.Kind + "/" + o.Name
+}
@@ -1,15 +1,54 @@
 package v1alpha1
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 // Trace represents the causal chain of mutations through a resource hierarchy.
-// It is stored as a JSON array in the kausality.io/trace annotation.
+// It is stored in the kausality.io/trace annotation as a TraceEnvelope, see
+// Annotation. Drift reports, audit annotations and trace records carry it as
+// a JSON array of hops.
 type Trace []Hop
 
+// Trace annotation schema versions.
+const (
+	// TraceSchemaV1 is a plain JSON array of hops.
+	TraceSchemaV1 = "v1"
+	// TraceSchemaV2 is a TraceEnvelope.
+	TraceSchemaV2 = "v2"
+)
+
+// TraceEnvelope is the kausality.io/trace annotation of schema v2: the hops
+// of the trace with the schema version they are written with. Readers
+// ignore unknown fields, so that later versions can add fields.
+type TraceEnvelope struct {
+	// Version is the schema version, TraceSchemaV2.
+	Version string `json:"version"`
+	// TraceID identifies the causal chain by its origin, see Trace.ID.
+	TraceID string `json:"traceID,omitempty"`
+	// Hops are the hops of the trace, origin first.
+	Hops []Hop `json:"hops"`
+}
+
+// ActorKind classifies the actor of a hop.
+type ActorKind string
+
+const (
+	// ActorKindUser is an actor starting a trace, e.g. a human or CI.
+	ActorKindUser ActorKind = "User"
+	// ActorKindController is the controller of the parent, extending its
+	// trace.
+	ActorKindController ActorKind = "Controller"
+)
+
 // Hop represents a single hop in the trace - a resource that was mutated.
 type Hop struct {
 	// APIVersion of the resource (e.g., "apps/v1").
@@ -18,10 +57,23 @@ type Hop struct {
 	Kind string `json:"kind"`
 	// Name of the resource.
 	Name string `json:"name"`
+	// Namespace of the resource. Only set on signed hops, whose signatures
+	// bind them to their resource.
+	Namespace string `json:"namespace,omitempty"`
+	// UID of the resource. Only set on signed hops of existing resources:
+	// the apiserver assigns the UID of created resources after admission.
+	UID types.UID `json:"uid,omitempty"`
 	// Generation of the resource at mutation time.
 	Generation int64 `json:"generation"`
 	// User who made the mutation (human/CI at origin, service account for controllers).
 	User string `json:"user"`
+	// ActorKind tells whether User started the trace or extended it as the
+	// controller of the parent. Empty for hops of schema v1 and synthesized
+	// parent hops.
+	ActorKind ActorKind `json:"actorKind,omitempty"`
+	// Operation is the admission operation of the mutation: CREATE, UPDATE
+	// or DELETE. Empty for hops of schema v1.
+	Operation string `json:"operation,omitempty"`
 	// RequestUID is the unique identifier of the admission request that caused this mutation.
 	RequestUID string `json:"requestUID,omitempty"`
 	// Timestamp of the mutation.
@@ -30,19 +82,131 @@ type Hop struct {
 	// For example, "kausality.io/trace-ticket=JIRA-123" becomes Labels["ticket"]="JIRA-123".
 	// Each hop captures labels from its own object; labels are not inherited from parent.
 	Labels map[string]string `json:"labels,omitempty"`
+	// Ticket contains metadata of the validated kausality.io/trace-ticket reference.
+	// Only set on origin hops when ticket validation is enabled.
+	Ticket *TicketRef `json:"ticket,omitempty"`
+	// Reference is the JSONPath in the previous hop's object that references
+	// this hop's object, e.g. ".spec.writeConnectionSecretToRef". Empty for hops
+	// reached through a controller owner reference.
+	Reference string `json:"reference,omitempty"`
+	// Predecessor is the deleted object this hop's object replaced, if it was
+	// recreated by its controller. Only set on the hop creating the object.
+	Predecessor *Predecessor `json:"predecessor,omitempty"`
+	// ScheduledAt is the schedule time of a Job created by a CronJob.
+	ScheduledAt *metav1.Time `json:"scheduledAt,omitempty"`
+	// Event is the most relevant recent Kubernetes Event on the object or
+	// its controller owner when a controller started the trace, telling why
+	// it acted, e.g. a ScalingReplicaSet. Only set on origin hops of service
+	// accounts and system users when origin events are enabled.
+	Event *EventRef `json:"event,omitempty"`
+	// Signature is the webhook's signature of this hop and the signature of
+	// the previous hop, "<algorithm>:<base64>". Only set when the webhook
+	// signs hops.
+	Signature string `json:"signature,omitempty"`
 }
 
-// ParseTrace parses a trace from its JSON representation.
+// Predecessor identifies a deleted object that was recreated: an object of
+// the same kind and controller owner created shortly after with the same name
+// or template hash.
+type Predecessor struct {
+	// Name of the deleted object.
+	Name string `json:"name"`
+	// UID of the deleted object.
+	UID types.UID `json:"uid"`
+	// DeletedAt is when the deletion was admitted.
+	DeletedAt metav1.Time `json:"deletedAt"`
+}
+
+// EventRef records a Kubernetes Event explaining a hop.
+type EventRef struct {
+	// Kind of the object the Event is about, the hop's object or its
+	// controller owner.
+	Kind string `json:"kind"`
+	// Name of the object the Event is about.
+	Name string `json:"name"`
+	// Type is the Event type, Normal or Warning.
+	Type string `json:"type,omitempty"`
+	// Reason is the Event reason, e.g. "ScalingReplicaSet".
+	Reason string `json:"reason"`
+	// Message is the Event message, truncated.
+	Message string `json:"message,omitempty"`
+	// ReportingController is the component that reported the Event.
+	ReportingController string `json:"reportingController,omitempty"`
+	// LastSeen is when the Event last occurred.
+	LastSeen metav1.Time `json:"lastSeen"`
+}
+
+// TicketRef records an external ticket that was validated for a hop.
+type TicketRef struct {
+	// ID is the ticket identifier (e.g., "PROJ-123" or "org/repo#42").
+	ID string `json:"id"`
+	// State is the ticket state at validation time (e.g., "In Progress", "open").
+	State string `json:"state,omitempty"`
+	// URL links to the ticket in the external system.
+	URL string `json:"url,omitempty"`
+}
+
+// ParseTrace parses a trace from its JSON representation: an annotation of
+// any schema version, or a JSON array of hops.
 func ParseTrace(data string) (Trace, error) {
-	if data == "" {
-		return nil, nil
+	envelope, err := ParseTraceAnnotation(data)
+	if err != nil {
+		return nil, err
 	}
+	return envelope.Hops, nil
+}
 
-	var trace Trace
-	if err := json.Unmarshal([]byte(data), &trace); err != nil {
-		return nil, err
+// ParseTraceAnnotation parses a kausality.io/trace annotation. JSON arrays
+// of hops are returned as an envelope of version TraceSchemaV1. Envelopes of
+// later versions are parsed as far as they are known. An empty annotation
+// is an empty envelope.
+func ParseTraceAnnotation(data string) (TraceEnvelope, error) {
+	raw := bytes.TrimSpace([]byte(data))
+	if len(raw) == 0 {
+		return TraceEnvelope{}, nil
+	}
+
+	if raw[0] == '[' {
+		var hops []Hop
+		if err := json.Unmarshal(raw, &hops); err != nil {
+			return TraceEnvelope{}, err
+		}
+		return TraceEnvelope{Version: TraceSchemaV1, Hops: hops}, nil
+	}
+
+	var envelope TraceEnvelope
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return TraceEnvelope{}, err
+	}
+	if envelope.Version == "" {
+		return TraceEnvelope{}, fmt.Errorf("trace envelope without version")
+	}
+	return envelope, nil
+}
+
+// Annotation returns the kausality.io/trace annotation of the trace, a
+// TraceEnvelope of version TraceSchemaV2.
+func (t Trace) Annotation() string {
+	hops := []Hop(t)
+	if hops == nil {
+		hops = []Hop{}
+	}
+	// Hops only hold strings, numbers, times and string maps, which always marshal
+	data, _ := json.Marshal(TraceEnvelope{Version: TraceSchemaV2, TraceID: t.ID(), Hops: hops})
+	return string(data)
+}
+
+// ID identifies the causal chain of the trace by its origin hop, so that
+// all traces extending the same origin share it. It is "" for empty traces.
+func (t Trace) ID() string {
+	origin := t.Origin()
+	if origin == nil {
+		return ""
 	}
-	return trace, nil
+	// Timestamps are serialized with second precision
+	sum := sha256.Sum256(fmt.Appendf(nil, "%s\n%s\n%s\n%d\n%s\n%s", origin.APIVersion, origin.Kind, origin.Name,
+		origin.Generation, origin.RequestUID, origin.Timestamp.UTC().Format(time.RFC3339)))
+	return hex.EncodeToString(sum[:16])
 }
 
 // String returns the JSON representation of the trace.
@@ -12,6 +12,11 @@ import (
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Approval) DeepCopyInto(out *Approval) {
 	*out = *in
+	if in.Consumed != nil {
+		in, out := &in.Consumed, &out.Consumed
+		*out = new(ApprovalConsumption)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Approval.
@@ -24,6 +29,132 @@ func (in *Approval) DeepCopy() *Approval {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApprovalConsumption) DeepCopyInto(out *ApprovalConsumption) {
+	*out = *in
+	in.At.DeepCopyInto(&out.At)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApprovalConsumption.
+func (in *ApprovalConsumption) DeepCopy() *ApprovalConsumption {
+	if in == nil {
+		return nil
+	}
+	out := new(ApprovalConsumption)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApprovalRequest) DeepCopyInto(out *ApprovalRequest) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApprovalRequest.
+func (in *ApprovalRequest) DeepCopy() *ApprovalRequest {
+	if in == nil {
+		return nil
+	}
+	out := new(ApprovalRequest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ApprovalRequest) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApprovalRequestList) DeepCopyInto(out *ApprovalRequestList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ApprovalRequest, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApprovalRequestList.
+func (in *ApprovalRequestList) DeepCopy() *ApprovalRequestList {
+	if in == nil {
+		return nil
+	}
+	out := new(ApprovalRequestList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ApprovalRequestList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApprovalRequestObjectReference) DeepCopyInto(out *ApprovalRequestObjectReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApprovalRequestObjectReference.
+func (in *ApprovalRequestObjectReference) DeepCopy() *ApprovalRequestObjectReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ApprovalRequestObjectReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApprovalRequestSpec) DeepCopyInto(out *ApprovalRequestSpec) {
+	*out = *in
+	out.Parent = in.Parent
+	out.Child = in.Child
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApprovalRequestSpec.
+func (in *ApprovalRequestSpec) DeepCopy() *ApprovalRequestSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ApprovalRequestSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApprovalRequestStatus) DeepCopyInto(out *ApprovalRequestStatus) {
+	*out = *in
+	if in.CompletedAt != nil {
+		in, out := &in.CompletedAt, &out.CompletedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApprovalRequestStatus.
+func (in *ApprovalRequestStatus) DeepCopy() *ApprovalRequestStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ApprovalRequestStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChildRef) DeepCopyInto(out *ChildRef) {
 	*out = *in
@@ -39,6 +170,49 @@ func (in *ChildRef) DeepCopy() *ChildRef {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DriftExclusion) DeepCopyInto(out *DriftExclusion) {
+	*out = *in
+	if in.LabelSelector != nil {
+		in, out := &in.LabelSelector, &out.LabelSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DriftExclusion.
+func (in *DriftExclusion) DeepCopy() *DriftExclusion {
+	if in == nil {
+		return nil
+	}
+	out := new(DriftExclusion)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EventRef) DeepCopyInto(out *EventRef) {
+	*out = *in
+	in.LastSeen.DeepCopyInto(&out.LastSeen)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EventRef.
+func (in *EventRef) DeepCopy() *EventRef {
+	if in == nil {
+		return nil
+	}
+	out := new(EventRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Freeze) DeepCopyInto(out *Freeze) {
 	*out = *in
@@ -66,6 +240,25 @@ func (in *Hop) DeepCopyInto(out *Hop) {
 			(*out)[key] = val
 		}
 	}
+	if in.Ticket != nil {
+		in, out := &in.Ticket, &out.Ticket
+		*out = new(TicketRef)
+		**out = **in
+	}
+	if in.Predecessor != nil {
+		in, out := &in.Predecessor, &out.Predecessor
+		*out = new(Predecessor)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ScheduledAt != nil {
+		in, out := &in.ScheduledAt, &out.ScheduledAt
+		*out = (*in).DeepCopy()
+	}
+	if in.Event != nil {
+		in, out := &in.Event, &out.Event
+		*out = new(EventRef)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Hop.
@@ -137,6 +330,91 @@ func (in *KausalityList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KausalityPolicy) DeepCopyInto(out *KausalityPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KausalityPolicy.
+func (in *KausalityPolicy) DeepCopy() *KausalityPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(KausalityPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KausalityPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KausalityPolicyList) DeepCopyInto(out *KausalityPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KausalityPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KausalityPolicyList.
+func (in *KausalityPolicyList) DeepCopy() *KausalityPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(KausalityPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KausalityPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KausalityPolicySpec) DeepCopyInto(out *KausalityPolicySpec) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]ResourceRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ObjectSelector != nil {
+		in, out := &in.ObjectSelector, &out.ObjectSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KausalityPolicySpec.
+func (in *KausalityPolicySpec) DeepCopy() *KausalityPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KausalityPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KausalitySpec) DeepCopyInto(out *KausalitySpec) {
 	*out = *in
@@ -164,6 +442,13 @@ func (in *KausalitySpec) DeepCopyInto(out *KausalitySpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.DriftExclusions != nil {
+		in, out := &in.DriftExclusions, &out.DriftExclusions
+		*out = make([]DriftExclusion, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KausalitySpec.
@@ -216,6 +501,11 @@ func (in *ModeOverride) DeepCopyInto(out *ModeOverride) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.RolloutPercentage != nil {
+		in, out := &in.RolloutPercentage, &out.RolloutPercentage
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModeOverride.
@@ -258,6 +548,60 @@ func (in *NamespaceSelector) DeepCopy() *NamespaceSelector {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Orphan) DeepCopyInto(out *Orphan) {
+	*out = *in
+	in.At.DeepCopyInto(&out.At)
+	if in.Trace != nil {
+		in, out := &in.Trace, &out.Trace
+		*out = make(Trace, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Orphan.
+func (in *Orphan) DeepCopy() *Orphan {
+	if in == nil {
+		return nil
+	}
+	out := new(Orphan)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Override) DeepCopyInto(out *Override) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Override.
+func (in *Override) DeepCopy() *Override {
+	if in == nil {
+		return nil
+	}
+	out := new(Override)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Predecessor) DeepCopyInto(out *Predecessor) {
+	*out = *in
+	in.DeletedAt.DeepCopyInto(&out.DeletedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Predecessor.
+func (in *Predecessor) DeepCopy() *Predecessor {
+	if in == nil {
+		return nil
+	}
+	out := new(Predecessor)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Rejection) DeepCopyInto(out *Rejection) {
 	*out = *in
@@ -291,6 +635,11 @@ func (in *ResourceRule) DeepCopyInto(out *ResourceRule) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Subresources != nil {
+		in, out := &in.Subresources, &out.Subresources
+		*out = make([]SubresourceRule, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceRule.
@@ -319,6 +668,52 @@ func (in *Snooze) DeepCopy() *Snooze {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Suppression) DeepCopyInto(out *Suppression) {
+	*out = *in
+	in.Until.DeepCopyInto(&out.Until)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Suppression.
+func (in *Suppression) DeepCopy() *Suppression {
+	if in == nil {
+		return nil
+	}
+	out := new(Suppression)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SubresourceRule) DeepCopyInto(out *SubresourceRule) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SubresourceRule.
+func (in *SubresourceRule) DeepCopy() *SubresourceRule {
+	if in == nil {
+		return nil
+	}
+	out := new(SubresourceRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TicketRef) DeepCopyInto(out *TicketRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TicketRef.
+func (in *TicketRef) DeepCopy() *TicketRef {
+	if in == nil {
+		return nil
+	}
+	out := new(TicketRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in Trace) DeepCopyInto(out *Trace) {
 	{
@@ -339,3 +734,25 @@ func (in Trace) DeepCopy() Trace {
 	in.DeepCopyInto(out)
 	return *out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TraceEnvelope) DeepCopyInto(out *TraceEnvelope) {
+	*out = *in
+	if in.Hops != nil {
+		in, out := &in.Hops, &out.Hops
+		*out = make([]Hop, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TraceEnvelope.
+func (in *TraceEnvelope) DeepCopy() *TraceEnvelope {
+	if in == nil {
+		return nil
+	}
+	out := new(TraceEnvelope)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,8 @@
+// Package v1beta1 contains API types for the kausality.io API group.
+//
+// v1beta1 is the storage version of Kausality and the hub of conversions
+// from v1alpha1.
+//
+// +kubebuilder:object:generate=true
+// +groupName=kausality.io
+package v1beta1
@@ -0,0 +1,4 @@
+package v1beta1
+
+// Hub marks Kausality as the version other versions convert through.
+func (*Kausality) Hub() {}
@@ -0,0 +1,525 @@
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +kubebuilder:validation:Enum=log;enforce
+type Mode string
+
+const (
+	// ModeLog logs drift but does not block requests.
+	ModeLog Mode = "log"
+
+	// ModeEnforce blocks requests that would cause drift.
+	ModeEnforce Mode = "enforce"
+)
+
+// ResourceRule defines which resources to track within specific API groups.
+//
+// +kubebuilder:validation:XValidation:rule="self.apiGroups.all(g, g != '*')",message="apiGroups cannot contain '*', use explicit group names"
+// +kubebuilder:validation:XValidation:rule="!has(self.excluded) || size(self.excluded) == 0 || self.resources.exists(r, r == '*')",message="excluded can only be used when resources contains '*'"
+type ResourceRule struct {
+	// APIGroups is the list of API groups. Required, no "*" allowed.
+	// Use "" for the core API group.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=10
+	APIGroups []string `json:"apiGroups"`
+
+	// Resources is the list of resources. Use "*" to match all resources in the group.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=50
+	Resources []string `json:"resources"`
+
+	// Excluded subtracts resources from a wildcard resources list.
+	// Only applies when Resources contains "*".
+	// +optional
+	// +kubebuilder:validation:MaxItems=50
+	Excluded []string `json:"excluded,omitempty"`
+
+	// Subresources configures which subresources of the matched resources are
+	// intercepted and how. Entries override the default, which intercepts
+	// status with handling "controller". Other subresources are not intercepted
+	// unless listed.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MaxItems=20
+	Subresources []SubresourceRule `json:"subresources,omitempty"`
+
+	// FailurePolicy defines how the API server handles requests to the
+	// matched resources when the webhook is unavailable. Defaults to the
+	// failure policy of the webhook configuration, Fail.
+	// +optional
+	FailurePolicy *FailurePolicyType `json:"failurePolicy,omitempty"`
+
+	// FieldPaths limits drift detection to changes of these paths of the
+	// spec, e.g. spec.template.spec.containers[*].image. Updates of the
+	// matched resources changing other fields only are not checked for
+	// drift. "[*]" selects all list elements, "[N]" one. Defaults to the
+	// whole spec.
+	// +optional
+	// +kubebuilder:validation:MaxItems=20
+	// +kubebuilder:validation:items:Pattern=`^spec(\.[^.\[\]]+(\[(\*|[0-9]+)\])?)+$`
+	FieldPaths []string `json:"fieldPaths,omitempty"`
+}
+
+// FailurePolicyType defines how requests are handled when the webhook is unavailable.
+// +kubebuilder:validation:Enum=Fail;Ignore
+type FailurePolicyType string
+
+const (
+	// FailurePolicyFail rejects requests while the webhook is unavailable.
+	FailurePolicyFail FailurePolicyType = "Fail"
+
+	// FailurePolicyIgnore admits requests untracked while the webhook is unavailable.
+	FailurePolicyIgnore FailurePolicyType = "Ignore"
+)
+
+// ObjectSelectorMatchType defines which labels of an object an object
+// selector matches.
+// +kubebuilder:validation:Enum=OldOrNewObject;NewObject
+type ObjectSelectorMatchType string
+
+const (
+	// ObjectSelectorMatchOldOrNewObject matches the labels before or after
+	// the mutation.
+	ObjectSelectorMatchOldOrNewObject ObjectSelectorMatchType = "OldOrNewObject"
+
+	// ObjectSelectorMatchNewObject matches only the labels after the
+	// mutation.
+	ObjectSelectorMatchNewObject ObjectSelectorMatchType = "NewObject"
+)
+
+// MissingParentAction defines how mutations of objects whose controller
+// owner does not exist are handled.
+// +kubebuilder:validation:Enum=Allow;Warn;Deny
+type MissingParentAction string
+
+const (
+	// MissingParentAllow admits the mutation without warning.
+	MissingParentAllow MissingParentAction = "Allow"
+
+	// MissingParentWarn admits the mutation with a warning and reports the
+	// orphan.
+	MissingParentWarn MissingParentAction = "Warn"
+
+	// MissingParentDeny denies the mutation and reports the orphan.
+	MissingParentDeny MissingParentAction = "Deny"
+)
+
+// OwnerReferenceAction defines how controller owner references added to
+// objects are validated.
+// +kubebuilder:validation:Enum=Allow;Warn;Deny
+type OwnerReferenceAction string
+
+const (
+	// OwnerReferenceAllow admits added owner references without validation.
+	OwnerReferenceAllow OwnerReferenceAction = "Allow"
+
+	// OwnerReferenceWarn admits invalid owner references with a warning.
+	OwnerReferenceWarn OwnerReferenceAction = "Warn"
+
+	// OwnerReferenceDeny denies mutations adding invalid owner references.
+	OwnerReferenceDeny OwnerReferenceAction = "Deny"
+)
+
+// DriftAction defines how drift is handled in enforce mode.
+// +kubebuilder:validation:Enum=Deny;Quarantine
+type DriftAction string
+
+const (
+	// DriftActionDeny denies drift.
+	DriftActionDeny DriftAction = "Deny"
+
+	// DriftActionQuarantine denies drift and reverts the child to its last
+	// approved spec if it was changed since by someone else than its
+	// controller.
+	DriftActionQuarantine DriftAction = "Quarantine"
+)
+
+// SubresourceHandling defines how requests to a subresource are handled.
+// +kubebuilder:validation:Enum=controller;track;ignore
+type SubresourceHandling string
+
+const (
+	// SubresourceHandlingController records the user as controller of the
+	// object, like status updates. Only for subresources carrying the full object.
+	SubresourceHandlingController SubresourceHandling = "controller"
+
+	// SubresourceHandlingTrack treats requests as mutations of the object:
+	// they are checked for drift and traced. CONNECT subresources (exec,
+	// attach, portforward, proxy) are audited.
+	SubresourceHandlingTrack SubresourceHandling = "track"
+
+	// SubresourceHandlingIgnore does not intercept the subresource.
+	SubresourceHandlingIgnore SubresourceHandling = "ignore"
+)
+
+// SubresourceRule configures the handling of one subresource.
+type SubresourceRule struct {
+	// Name of the subresource, e.g. "status", "scale", "ephemeralcontainers" or "exec".
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Handling of requests to the subresource.
+	Handling SubresourceHandling `json:"handling"`
+}
+
+// NamespaceSelector defines which namespaces to track.
+//
+// +kubebuilder:validation:XValidation:rule="!(size(self.names) > 0 && has(self.selector))",message="names and selector are mutually exclusive"
+type NamespaceSelector struct {
+	// Names is an explicit list of namespace names to include.
+	// +optional
+	// +kubebuilder:validation:MaxItems=100
+	Names []string `json:"names,omitempty"`
+
+	// Selector matches namespaces by labels.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// Excluded namespaces are always skipped, even if they match names or selector.
+	// +optional
+	// +kubebuilder:validation:MaxItems=100
+	Excluded []string `json:"excluded,omitempty"`
+}
+
+// ModeOverride allows fine-grained mode configuration for specific resources or namespaces.
+// Overrides are evaluated in order; first match wins.
+//
+// +kubebuilder:validation:XValidation:rule="size(self.apiGroups) > 0 || size(self.resources) > 0 || size(self.namespaces) > 0 || has(self.rolloutPercentage)",message="override must have at least one filter (apiGroups, resources, namespaces, or rolloutPercentage)"
+type ModeOverride struct {
+	// APIGroups limits this override to specific API groups.
+	// +optional
+	// +kubebuilder:validation:MaxItems=10
+	APIGroups []string `json:"apiGroups,omitempty"`
+
+	// Resources limits this override to specific resources.
+	// +optional
+	// +kubebuilder:validation:MaxItems=50
+	Resources []string `json:"resources,omitempty"`
+
+	// Namespaces limits this override to specific namespaces.
+	// +optional
+	// +kubebuilder:validation:MaxItems=100
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// RolloutPercentage limits this override to a deterministic subset of
+	// objects: those whose hash of namespace/name modulo 100 is below it.
+	// Other objects fall through to the next override. Raising it ramps up
+	// the override, e.g. enforce mode, without re-selecting objects.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	RolloutPercentage *int32 `json:"rolloutPercentage,omitempty"`
+
+	// Mode is the drift detection mode for matching resources.
+	Mode Mode `json:"mode"`
+}
+
+// Weekday is a day of the week.
+// +kubebuilder:validation:Enum=Monday;Tuesday;Wednesday;Thursday;Friday;Saturday;Sunday
+type Weekday string
+
+// ModeSchedule sets the mode of a policy during a recurring time window,
+// e.g. enforce during business hours or log during a maintenance window.
+//
+// +kubebuilder:validation:XValidation:rule="self.start != self.end",message="start and end must differ"
+type ModeSchedule struct {
+	// Days on which the window opens. If omitted, it opens every day.
+	// +optional
+	// +listType=set
+	// +kubebuilder:validation:MaxItems=7
+	Days []Weekday `json:"days,omitempty"`
+
+	// Start is the time of day the window opens, as HH:MM.
+	// +kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+	Start string `json:"start"`
+
+	// End is the time of day the window closes, as HH:MM. An end before the
+	// start closes the window on the next day.
+	// +kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+	End string `json:"end"`
+
+	// TimeZone is the IANA time zone of start and end, e.g. "Europe/Berlin".
+	// Defaults to UTC.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// Mode is the drift detection mode while the window is open.
+	Mode Mode `json:"mode"`
+}
+
+// DriftExclusion selects objects that are traced but never evaluated for
+// drift, e.g. objects intentionally managed by two controllers.
+// Objects must match all of the given criteria.
+//
+// +kubebuilder:validation:XValidation:rule="has(self.labelSelector) || (has(self.annotations) && size(self.annotations) > 0)",message="exclusion must have a labelSelector or annotations"
+type DriftExclusion struct {
+	// LabelSelector matches objects by labels.
+	// +optional
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+
+	// Annotations matches objects carrying all of the given annotations.
+	// An empty value matches any value.
+	// +optional
+	// +kubebuilder:validation:MaxProperties=10
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// ClassifierType is a signal identifying the controller of a parent.
+// +kubebuilder:validation:Enum=UpdaterHash;FieldManager;ServiceAccount
+type ClassifierType string
+
+const (
+	// ClassifierUpdaterHash compares the user with the users updating the
+	// parent's status (kausality.io/controllers) and the child
+	// (kausality.io/updaters).
+	ClassifierUpdaterHash ClassifierType = "UpdaterHash"
+
+	// ClassifierFieldManager compares the field manager of the request with
+	// the field managers of the parent's status in its managedFields.
+	ClassifierFieldManager ClassifierType = "FieldManager"
+
+	// ClassifierServiceAccount matches the user against ServiceAccounts.
+	ClassifierServiceAccount ClassifierType = "ServiceAccount"
+)
+
+// ClassifierCombination defines how the results of several classifiers are
+// combined. Classifiers that cannot determine the controller are skipped.
+// +kubebuilder:validation:Enum=First;Any;All
+type ClassifierCombination string
+
+const (
+	// ClassifierCombinationFirst takes the result of the first classifier.
+	ClassifierCombinationFirst ClassifierCombination = "First"
+
+	// ClassifierCombinationAny identifies the controller if any classifier does.
+	ClassifierCombinationAny ClassifierCombination = "Any"
+
+	// ClassifierCombinationAll identifies the controller if all classifiers do.
+	ClassifierCombinationAll ClassifierCombination = "All"
+)
+
+// ControllerIdentity configures how the controller of a parent is told apart
+// from other actors mutating its children.
+type ControllerIdentity struct {
+	// Classifiers are the signals identifying the controller, in order.
+	// Defaults to UpdaterHash.
+	// +optional
+	// +kubebuilder:validation:MaxItems=3
+	Classifiers []ClassifierType `json:"classifiers,omitempty"`
+
+	// Combination defines how the results of the classifiers are combined.
+	// Defaults to First.
+	// +optional
+	Combination ClassifierCombination `json:"combination,omitempty"`
+
+	// ServiceAccounts are the user name patterns of controllers for the
+	// ServiceAccount classifier, e.g. system:serviceaccount:crossplane-system:*.
+	// '*' matches any characters.
+	// +optional
+	// +kubebuilder:validation:MaxItems=20
+	ServiceAccounts []string `json:"serviceAccounts,omitempty"`
+}
+
+// KausalitySpec defines the desired state of a Kausality policy.
+type KausalitySpec struct {
+	// Resources defines which resources to track.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=20
+	Resources []ResourceRule `json:"resources"`
+
+	// Namespaces defines which namespaces to track.
+	// If omitted, all namespaces are tracked (except system namespaces).
+	// +optional
+	Namespaces *NamespaceSelector `json:"namespaces,omitempty"`
+
+	// ObjectSelector filters objects by labels. By default, like the object
+	// selector of admission webhooks, an object is tracked if its labels
+	// before or after the mutation match, so removing a label does not escape
+	// the policy. See ObjectSelectorMatch.
+	// +optional
+	ObjectSelector *metav1.LabelSelector `json:"objectSelector,omitempty"`
+
+	// ObjectSelectorMatch defines which labels ObjectSelector matches:
+	// OldOrNewObject (the default) or NewObject. Policies created through
+	// v1alpha1 are converted with NewObject, the v1alpha1 behavior.
+	// +optional
+	ObjectSelectorMatch ObjectSelectorMatchType `json:"objectSelectorMatch,omitempty"`
+
+	// Mode is the default drift detection mode for resources matched by this policy.
+	Mode Mode `json:"mode"`
+
+	// Schedules replace Mode while one of their windows is open; the first
+	// open window wins. Overrides still take precedence.
+	// +optional
+	// +kubebuilder:validation:MaxItems=20
+	Schedules []ModeSchedule `json:"schedules,omitempty"`
+
+	// Overrides allows fine-grained mode configuration by namespace or resource.
+	// Overrides are evaluated in order; first match wins.
+	// +optional
+	// +kubebuilder:validation:MaxItems=50
+	Overrides []ModeOverride `json:"overrides,omitempty"`
+
+	// DriftExclusions exclude objects from drift evaluation. Matching objects
+	// stay tracked and traced, but mutations are never drift, in any mode.
+	// An object is excluded if it matches any exclusion.
+	// +optional
+	// +kubebuilder:validation:MaxItems=20
+	DriftExclusions []DriftExclusion `json:"driftExclusions,omitempty"`
+
+	// ControllerIdentity configures how the controller of a parent is
+	// identified. Defaults to the UpdaterHash classifier.
+	// +optional
+	ControllerIdentity *ControllerIdentity `json:"controllerIdentity,omitempty"`
+
+	// MissingParent defines how creates and updates of objects whose
+	// controller owner reference points to a non-existent parent are
+	// handled: Allow, Warn or Deny. Dangling owners are a sign of broken
+	// garbage collection or a spoofed owner reference. Warn and Deny send an
+	// OrphanDetected report. Deletes, e.g. by the garbage collector, and
+	// policies without MissingParent follow the errorHandling.parentNotFound
+	// action of the webhook.
+	// +optional
+	MissingParent MissingParentAction `json:"missingParent,omitempty"`
+
+	// OwnerReferences defines how controller owner references added by
+	// creates and updates are validated: Allow, Warn or Deny. A spoofed
+	// owner reference makes an object appear managed by a controller. Added
+	// references must resolve to an existing owner with the same UID, and
+	// be added by the controller of the owner. Defaults to Allow, which
+	// does not validate them.
+	// +optional
+	OwnerReferences OwnerReferenceAction `json:"ownerReferences,omitempty"`
+
+	// DriftAction defines how drift is handled in enforce mode: Deny or
+	// Quarantine. Denying the controller's correction of a manually changed
+	// child leaves the manual change in place. Quarantine also reverts the
+	// child to the spec last written by its controller without unapproved
+	// drift, and emits an event. Defaults to Deny.
+	// +optional
+	DriftAction DriftAction `json:"driftAction,omitempty"`
+}
+
+// CoverageChangeType is how a policy update changed the drift detection of
+// a resource in a namespace.
+// +kubebuilder:validation:Enum=Gained;Lost;ModeChanged
+type CoverageChangeType string
+
+const (
+	// CoverageGained indicates the resource became tracked.
+	CoverageGained CoverageChangeType = "Gained"
+
+	// CoverageLost indicates the resource is no longer tracked.
+	CoverageLost CoverageChangeType = "Lost"
+
+	// CoverageModeChanged indicates the resource remains tracked in another
+	// mode.
+	CoverageModeChanged CoverageChangeType = "ModeChanged"
+)
+
+// CoverageChange is the change of drift detection of a resource in a
+// namespace.
+type CoverageChange struct {
+	// Type is how drift detection of the resource changed.
+	Type CoverageChangeType `json:"type"`
+
+	// Resource is the resource as group/resource, or resource for the core
+	// group. "*" stands for the resources of the group not named otherwise.
+	Resource string `json:"resource"`
+
+	// Namespace is the namespace of the resource, empty for cluster-scoped
+	// resources.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Mode is the mode after the update, unset if tracking was lost.
+	// +optional
+	Mode Mode `json:"mode,omitempty"`
+
+	// PreviousMode is the mode before the update, unset if tracking was
+	// gained.
+	// +optional
+	PreviousMode Mode `json:"previousMode,omitempty"`
+}
+
+// PolicyEffect is the change of coverage caused by a change of the policy:
+// the resources and namespaces which gained or lost tracking, or changed
+// mode, evaluated for objects without labels.
+type PolicyEffect struct {
+	// ObservedGeneration is the generation of the policy the effect was
+	// computed for.
+	ObservedGeneration int64 `json:"observedGeneration"`
+
+	// Time is when the effect was computed.
+	Time metav1.Time `json:"time"`
+
+	// Gained is the number of resource and namespace pairs which became
+	// tracked.
+	Gained int32 `json:"gained"`
+
+	// Lost is the number of resource and namespace pairs which are no
+	// longer tracked.
+	Lost int32 `json:"lost"`
+
+	// ModeChanged is the number of tracked resource and namespace pairs
+	// whose mode changed.
+	ModeChanged int32 `json:"modeChanged"`
+
+	// Changes lists the changes, at most 100 of them.
+	// +optional
+	// +kubebuilder:validation:MaxItems=100
+	Changes []CoverageChange `json:"changes,omitempty"`
+}
+
+// KausalityStatus defines the observed state of a Kausality policy.
+type KausalityStatus struct {
+	// Conditions represent the current state of the policy.
+	// Known condition types: Ready, WebhookConfigured.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Effect is the change of coverage caused by the last change of the
+	// policy observed by the controller.
+	// +optional
+	Effect *PolicyEffect `json:"effect,omitempty"`
+}
+
+// Kausality configures drift detection for a set of Kubernetes resources.
+//
+// Multiple Kausality instances can coexist. When multiple policies match
+// the same resource, specificity-based precedence resolves conflicts:
+// more specific namespace selectors and resource lists win over broader ones.
+//
+// +genclient
+// +genclient:nonNamespaced
+// +kubebuilder:object:root=true
+// +kubebuilder:storageversion
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Mode",type=string,JSONPath=`.spec.mode`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+type Kausality struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KausalitySpec   `json:"spec,omitempty"`
+	Status KausalityStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// KausalityList contains a list of Kausality resources.
+type KausalityList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Kausality `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Kausality{}, &KausalityList{})
+}
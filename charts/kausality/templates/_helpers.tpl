@@ -205,3 +205,111 @@ Backend-tui service URL (for webhook to call)
 {{- define "kausality.backendTuiServiceURL" -}}
 {{- printf "http://%s.%s.svc.cluster.local:%d/webhook" (include "kausality.backendTuiFullname" .) .Release.Namespace (.Values.backendTui.service.port | int) }}
 {{- end }}
+
+{{/*
+API version of the MutatingWebhookConfiguration: v1beta1 on clusters not serving v1 yet
+*/}}
+{{- define "kausality.webhookConfigurationAPIVersion" -}}
+{{- if .Capabilities.APIVersions.Has "admissionregistration.k8s.io/v1/MutatingWebhookConfiguration" -}}
+admissionregistration.k8s.io/v1
+{{- else -}}
+admissionregistration.k8s.io/v1beta1
+{{- end }}
+{{- end }}
+
+{{/*
+Webhook rules of the standalone policies: resources with their status and listed subresources
+*/}}
+{{- define "kausality.standaloneWebhookRules" -}}
+{{- range .Values.standalone.policies }}
+{{- range .resources }}
+{{- $resources := .resources }}
+{{- $subresources := list }}
+{{- range $resources }}
+{{- $subresources = append $subresources (printf "%s/status" .) }}
+{{- end }}
+{{- range .subresources }}
+{{- if and (ne .name "status") (ne (.handling | default "") "ignore") }}
+{{- $name := .name }}
+{{- range $resources }}
+{{- $subresources = append $subresources (printf "%s/%s" . $name) }}
+{{- end }}
+{{- end }}
+{{- end }}
+- apiGroups: {{ toJson .apiGroups }}
+  apiVersions: ["*"]
+  resources: {{ toJson $resources }}
+  operations: ["CREATE", "UPDATE", "DELETE"]
+  scope: "*"
+- apiGroups: {{ toJson .apiGroups }}
+  apiVersions: ["*"]
+  resources: {{ toJson $subresources }}
+  operations: ["CREATE", "UPDATE", "CONNECT"]
+  scope: "*"
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{/*
+Self-protection webhook, receiving the requests of kausality's CRDs and of
+the Deployments in the release namespace. Its failure policy is Ignore, so
+that an unavailable webhook never blocks repairs of its own Deployment.
+Takes a dict with "root" and an optional "caBundle".
+*/}}
+{{- define "kausality.protectionWebhook" -}}
+{{- $root := .root -}}
+- name: protection.webhook.kausality.io
+  admissionReviewVersions: ["v1", "v1beta1"]
+  sideEffects: None
+  timeoutSeconds: 5
+  failurePolicy: Ignore
+  matchPolicy: Equivalent
+  clientConfig:
+    service:
+      name: {{ include "kausality.webhookServiceName" $root }}
+      namespace: {{ $root.Release.Namespace }}
+      path: /protect
+      port: {{ $root.Values.service.port }}
+    {{- with .caBundle }}
+    caBundle: {{ . }}
+    {{- end }}
+  rules:
+    - apiGroups: ["apiextensions.k8s.io"]
+      apiVersions: ["*"]
+      resources: ["customresourcedefinitions"]
+      operations: ["UPDATE", "DELETE"]
+      scope: Cluster
+    - apiGroups: ["apps"]
+      apiVersions: ["*"]
+      resources: ["deployments", "deployments/scale"]
+      operations: ["UPDATE", "DELETE"]
+      scope: Namespaced
+  # Applies to the Deployments; CRDs are cluster-scoped and always sent
+  namespaceSelector:
+    matchLabels:
+      kubernetes.io/metadata.name: {{ $root.Release.Namespace }}
+{{- if $root.Values.webhook.approvalRequests }}
+# ApprovalRequests are created by the webhook only, in any namespace
+- name: approvalrequests.protection.webhook.kausality.io
+  admissionReviewVersions: ["v1", "v1beta1"]
+  sideEffects: None
+  timeoutSeconds: 5
+  failurePolicy: Ignore
+  matchPolicy: Equivalent
+  clientConfig:
+    service:
+      name: {{ include "kausality.webhookServiceName" $root }}
+      namespace: {{ $root.Release.Namespace }}
+      path: /protect
+      port: {{ $root.Values.service.port }}
+    {{- with .caBundle }}
+    caBundle: {{ . }}
+    {{- end }}
+  rules:
+    - apiGroups: ["kausality.io"]
+      apiVersions: ["*"]
+      resources: ["approvalrequests"]
+      operations: ["CREATE"]
+      scope: Namespaced
+{{- end }}
+{{- end }}
@@ -134,7 +134,7 @@ func run(ctx context.Context, log logr.Logger, dataDir, bindAddress string, bind
 		BindPort:       bindPort,
 		Log:            log,
 		PolicyResolver: policyResolver,
-		Client:         nil, // No client needed for simple example
+		Client:         nil, // The server's loopback client
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create API server: %w", err)
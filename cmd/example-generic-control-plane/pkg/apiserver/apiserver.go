@@ -23,10 +23,10 @@ import (
 	"k8s.io/apiserver/pkg/storage/storagebackend"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
-	kausalityAdmission "github.com/kausality-io/kausality/cmd/example-generic-control-plane/pkg/admission"
 	examplev1alpha1 "github.com/kausality-io/kausality/cmd/example-generic-control-plane/pkg/apis/example/v1alpha1"
 	"github.com/kausality-io/kausality/cmd/example-generic-control-plane/pkg/registry/example/widget"
 	"github.com/kausality-io/kausality/cmd/example-generic-control-plane/pkg/registry/example/widgetset"
+	"github.com/kausality-io/kausality/pkg/embed"
 	"github.com/kausality-io/kausality/pkg/policy"
 )
 
@@ -53,7 +53,8 @@ type Config struct {
 	Log logr.Logger
 	// PolicyResolver is the kausality policy resolver.
 	PolicyResolver policy.Resolver
-	// Client is the controller-runtime client for kausality (can be nil for simple use).
+	// Client is the controller-runtime client for kausality. If nil, the
+	// server's loopback client is used.
 	Client client.Client
 }
 
@@ -84,14 +85,14 @@ func New(cfg Config) (*Server, error) {
 	}
 
 	// Configure secure serving with self-signed cert
-	secureServing := serveroptions.NewSecureServingOptions()
+	secureServing := serveroptions.NewSecureServingOptions().WithLoopback()
 	secureServing.Listener = listener
 	secureServing.ServerCert.GeneratedCert = nil // Will generate self-signed
 	if err := secureServing.MaybeDefaultWithSelfSignedCerts("localhost", nil, []net.IP{net.ParseIP("127.0.0.1")}); err != nil {
 		_ = listener.Close()
 		return nil, fmt.Errorf("failed to configure self-signed certs: %w", err)
 	}
-	if err := secureServing.ApplyTo(&genericConfig.SecureServing); err != nil {
+	if err := secureServing.ApplyTo(&genericConfig.SecureServing, &genericConfig.LoopbackClientConfig); err != nil {
 		_ = listener.Close()
 		return nil, fmt.Errorf("failed to apply secure serving: %w", err)
 	}
@@ -117,9 +118,25 @@ func New(cfg Config) (*Server, error) {
 	genericConfig.OpenAPIConfig.Info.Title = "Example Generic Control Plane"
 	genericConfig.OpenAPIConfig.Info.Version = "v1alpha1"
 
-	// Create kausality admission plugin
-	kausalityPlugin := kausalityAdmission.NewKausalityAdmission(cfg.Client, cfg.Log, cfg.PolicyResolver)
-	kausalityPlugin.SetScheme(Scheme)
+	// Create kausality admission plugin, reading parents and writing their
+	// annotations through the server itself
+	kausalityClient := cfg.Client
+	if kausalityClient == nil {
+		kausalityClient, err = client.New(genericConfig.LoopbackClientConfig, client.Options{Scheme: Scheme})
+		if err != nil {
+			_ = listener.Close()
+			return nil, fmt.Errorf("failed to create loopback client: %w", err)
+		}
+	}
+	kausalityPlugin, err := embed.NewAdmissionPlugin(embed.Config{
+		Client:         kausalityClient,
+		Log:            cfg.Log,
+		PolicyResolver: cfg.PolicyResolver,
+	})
+	if err != nil {
+		_ = listener.Close()
+		return nil, fmt.Errorf("failed to create kausality admission plugin: %w", err)
+	}
 
 	// Set up admission chain
 	genericConfig.AdmissionControl = admission.NewChainHandler(kausalityPlugin)
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/kausality-io/kausality/pkg/backend/alerting"
+)
+
+func main() {
+	var (
+		addr, keyFile, namespaces, kinds string
+		cfg                              alerting.Config
+	)
+
+	flag.StringVar(&addr, "addr", ":8080", "Address to listen on")
+	flag.StringVar(&cfg.Provider, "provider", alerting.ProviderPagerDuty, "Incident provider: pagerduty or opsgenie")
+	flag.StringVar(&keyFile, "key-file", "", "File containing the PagerDuty integration key or the Opsgenie API key (required)")
+	flag.StringVar(&cfg.URL, "url", "", "API endpoint of the provider (default: the public endpoint, e.g. https://api.eu.opsgenie.com for Opsgenie EU)")
+	flag.StringVar(&namespaces, "critical-namespaces", "", "Comma-separated namespace globs whose drift is critical (default: all)")
+	flag.StringVar(&kinds, "critical-kinds", "", "Comma-separated kinds whose drift is critical (default: all)")
+	flag.Parse()
+
+	log := zap.New()
+	cfg.Log = log.WithName("kausality-backend-alert")
+	cfg.Critical = alerting.Selector{Namespaces: splitList(namespaces), Kinds: splitList(kinds)}
+
+	if keyFile == "" {
+		fmt.Fprintln(os.Stderr, "--key-file is required")
+		os.Exit(1)
+	}
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read key: %v\n", err)
+		os.Exit(1)
+	}
+	cfg.Key = strings.TrimSpace(string(data))
+
+	sink, err := alerting.New(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           sink.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// Handle shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	log.Info("listening", "addr", addr, "provider", cfg.Provider,
+		"criticalNamespaces", cfg.Critical.Namespaces, "criticalKinds", cfg.Critical.Kinds)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		fmt.Fprintf(os.Stderr, "server error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// splitList splits a comma-separated list, dropping empty items.
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/kausality-io/kausality/pkg/backend/gatekeeper"
+)
+
+func main() {
+	var (
+		addr string
+		cfg  gatekeeper.Config
+	)
+
+	flag.StringVar(&addr, "addr", ":8080", "Address to listen on")
+	flag.StringVar(&cfg.Name, "constraint", "kausality-drift", "Name of the KausalityDrift constraint listing unresolved drift")
+	flag.StringVar(&cfg.EnforcementAction, "enforcement-action", gatekeeper.EnforcementActionDryRun, "Enforcement action of the constraint: dryrun or warn")
+	flag.DurationVar(&cfg.SyncInterval, "sync-interval", 10*time.Second, "How often drift changes are written to the constraint")
+	flag.Parse()
+
+	log := zap.New()
+	cfg.Log = log.WithName("kausality-backend-gatekeeper")
+
+	restConfig, err := ctrl.GetConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load kubeconfig: %v\n", err)
+		os.Exit(1)
+	}
+	cfg.Client, err = client.New(restConfig, client.Options{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create client: %v\n", err)
+		os.Exit(1)
+	}
+
+	exporter, err := gatekeeper.New(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           exporter.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// Handle shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		log.Info("listening", "addr", addr, "constraint", cfg.Name)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			fmt.Fprintf(os.Stderr, "server error: %v\n", err)
+			os.Exit(1)
+		}
+	}()
+
+	// Stop accepting reports before the final sync
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	if err := exporter.Start(ctx); err != nil {
+		log.Error(err, "exporter stopped")
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/kausality-io/kausality/pkg/backend/gitexport"
+)
+
+func main() {
+	var (
+		addr string
+		cfg  gitexport.Config
+	)
+
+	flag.StringVar(&addr, "addr", ":8080", "Address to listen on")
+	flag.StringVar(&cfg.URL, "repo", "", "Git repository to commit drift reports to (required)")
+	flag.StringVar(&cfg.Branch, "branch", "main", "Branch to commit to")
+	flag.StringVar(&cfg.Dir, "dir", "/tmp/kausality-ledger", "Local clone directory")
+	flag.StringVar(&cfg.Path, "path", "drifts", "Directory within the repository holding the ledger")
+	flag.StringVar(&cfg.AuthorName, "author-name", "kausality", "Commit author name")
+	flag.StringVar(&cfg.AuthorEmail, "author-email", "kausality@localhost", "Commit author email")
+	flag.StringVar(&cfg.ResolvedAction, "resolved", gitexport.ResolvedActionMove, "What to do with resolved drifts: move or delete")
+	flag.DurationVar(&cfg.FlushInterval, "flush-interval", 10*time.Second, "How often buffered drift reports are committed")
+	flag.Parse()
+
+	log := zap.New()
+	cfg.Log = log.WithName("kausality-backend-git")
+
+	exporter, err := gitexport.New(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           exporter.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// Handle shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		log.Info("listening", "addr", addr, "repo", cfg.URL, "branch", cfg.Branch)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			fmt.Fprintf(os.Stderr, "server error: %v\n", err)
+			os.Exit(1)
+		}
+	}()
+
+	// Stop accepting reports before the final flush
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	if err := exporter.Start(ctx); err != nil {
+		log.Error(err, "exporter stopped")
+		os.Exit(1)
+	}
+}
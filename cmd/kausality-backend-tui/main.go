@@ -7,22 +7,104 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/kausality-io/kausality/pkg/backend"
+	"github.com/kausality-io/kausality/pkg/trace"
 )
 
 func main() {
-	var addr string
+	var addr, digestURL string
+	var digestWindow, digestInterval time.Duration
+	var maxReports int
+	var evictionPolicy string
+	var authConfig backend.AuthConfig
+	var clientSecretFile, teamsFile, scopes, webhookTokenFile, traceStoreFile, traceVerificationKeyFile string
 
 	flag.StringVar(&addr, "addr", ":8080", "Address to listen on")
+	flag.StringVar(&traceStoreFile, "trace-store-file", "", "File keeping mirrored traces across restarts (default: traces are kept in memory only)")
+	flag.IntVar(&maxReports, "max-reports", backend.DefaultMaxReports, "Maximum number of open drift reports kept in memory")
+	flag.StringVar(&evictionPolicy, "eviction-policy", string(backend.EvictOldest), "Report dropped when --max-reports is reached: oldest (evict the report seen least recently) or newest (drop new drift)")
+	flag.StringVar(&digestURL, "digest-url", "", "URL to post periodic drift digests to, e.g. a Slack incoming webhook")
+	flag.DurationVar(&digestWindow, "digest-window", backend.DefaultDigestWindow, "Window aggregated by each drift digest")
+	flag.DurationVar(&digestInterval, "digest-interval", backend.DefaultDigestWindow, "Interval between drift digests")
+	flag.StringVar(&authConfig.IssuerURL, "oidc-issuer-url", "", "OIDC issuer URL; enables authentication and per-team views of the API")
+	flag.StringVar(&authConfig.ClientID, "oidc-client-id", "", "OIDC client ID, the audience of accepted ID tokens")
+	flag.StringVar(&clientSecretFile, "oidc-client-secret-file", "", "File containing the OIDC client secret, for browser logins")
+	flag.StringVar(&authConfig.RedirectURL, "oidc-redirect-url", "", "External URL of /oauth2/callback, for browser logins")
+	flag.StringVar(&scopes, "oidc-scopes", "profile,email,groups", "Comma-separated scopes requested at login in addition to openid")
+	flag.StringVar(&authConfig.GroupsClaim, "oidc-groups-claim", backend.DefaultGroupsClaim, "ID token claim holding the groups of a user")
+	flag.StringVar(&teamsFile, "teams-file", "", "YAML file mapping OIDC groups to the namespaces of teams (required with --oidc-issuer-url)")
+	flag.StringVar(&webhookTokenFile, "webhook-token-file", "", "File containing the bearer token the webhook sends with reports and traces (required with --oidc-issuer-url)")
+	flag.StringVar(&traceVerificationKeyFile, "trace-verification-key-file", "", "Key verifying the hop signatures of traces: PEM public key of the webhook's signing key, or the HMAC key")
 	flag.Parse()
+	if digestURL != "" && (digestWindow <= 0 || digestInterval <= 0) {
+		fmt.Fprintln(os.Stderr, "--digest-window and --digest-interval must be positive")
+		os.Exit(1)
+	}
+
+	if maxReports <= 0 {
+		fmt.Fprintln(os.Stderr, "--max-reports must be positive")
+		os.Exit(1)
+	}
+	switch backend.EvictionPolicy(evictionPolicy) {
+	case backend.EvictOldest, backend.EvictNewest:
+	default:
+		fmt.Fprintf(os.Stderr, "invalid --eviction-policy %q: must be %s or %s\n", evictionPolicy, backend.EvictOldest, backend.EvictNewest)
+		os.Exit(1)
+	}
 
 	// Create server
-	server := backend.NewServer()
+	server := backend.NewServer().WithStore(backend.NewStore(
+		backend.WithMaxReports(maxReports),
+		backend.WithEvictionPolicy(backend.EvictionPolicy(evictionPolicy)),
+	))
+	if traceStoreFile != "" {
+		traces, err := backend.NewFileTraceStore(traceStoreFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to open trace store: %v\n", err)
+			os.Exit(1)
+		}
+		defer func() { _ = traces.Close() }()
+		server.WithTraceStore(traces)
+	}
+	if traceVerificationKeyFile != "" {
+		verifier, err := trace.LoadVerifier(traceVerificationKeyFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load trace verification key: %v\n", err)
+			os.Exit(1)
+		}
+		server.WithTraceVerifier(verifier)
+	}
+	if authConfig.IssuerURL != "" {
+		auth, err := newAuth(authConfig, clientSecretFile, teamsFile, scopes)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid authentication configuration: %v\n", err)
+			os.Exit(1)
+		}
+		server.WithAuth(auth)
+		if webhookTokenFile == "" {
+			fmt.Fprintln(os.Stderr, "--webhook-token-file is required with --oidc-issuer-url")
+			os.Exit(1)
+		}
+	}
+	if webhookTokenFile != "" {
+		data, err := os.ReadFile(webhookTokenFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to read webhook token: %v\n", err)
+			os.Exit(1)
+		}
+		token := strings.TrimSpace(string(data))
+		if token == "" {
+			fmt.Fprintln(os.Stderr, "webhook token file is empty")
+			os.Exit(1)
+		}
+		server.WithWebhookToken(token)
+	}
 
 	httpServer := &http.Server{
 		Addr:              addr,
@@ -42,6 +124,13 @@ func main() {
 		}
 	}()
 
+	// Post drift digests in background. Errors are not printed, they would
+	// garble the TUI.
+	if digestURL != "" {
+		job := &backend.DigestJob{Store: server.Store(), URL: digestURL, Window: digestWindow, Interval: digestInterval}
+		go job.Run(ctx)
+	}
+
 	// Run TUI
 	model := backend.NewModel(server.Store(), addr)
 	p := tea.NewProgram(model, tea.WithAltScreen())
@@ -55,6 +144,32 @@ func main() {
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	_ = httpServer.Shutdown(shutdownCtx)
+}
 
-	_ = ctx // silence unused warning
+// newAuth completes cfg with the client secret, teams and scopes given by flags.
+func newAuth(cfg backend.AuthConfig, clientSecretFile, teamsFile, scopes string) (*backend.Auth, error) {
+	if teamsFile == "" {
+		return nil, fmt.Errorf("--teams-file is required")
+	}
+	teams, err := backend.LoadTeams(teamsFile)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Teams = teams
+	if clientSecretFile != "" {
+		data, err := os.ReadFile(clientSecretFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client secret: %w", err)
+		}
+		cfg.ClientSecret = strings.TrimSpace(string(data))
+	}
+	for _, scope := range strings.Split(scopes, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			cfg.Scopes = append(cfg.Scopes, scope)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return backend.NewAuth(ctx, cfg)
 }
@@ -1,21 +1,158 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/clientcmd"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+	"github.com/kausality-io/kausality/cmd/kausality-cli/pkg/bundle"
 	"github.com/kausality-io/kausality/cmd/kausality-cli/pkg/cli"
+	"github.com/kausality-io/kausality/cmd/kausality-cli/pkg/demo"
+	"github.com/kausality-io/kausality/cmd/kausality-cli/pkg/doctor"
+	"github.com/kausality-io/kausality/cmd/kausality-cli/pkg/importer"
+	"github.com/kausality-io/kausality/cmd/kausality-cli/pkg/install"
+	"github.com/kausality-io/kausality/cmd/kausality-cli/pkg/output"
+	"github.com/kausality-io/kausality/cmd/kausality-cli/pkg/plan"
+	"github.com/kausality-io/kausality/cmd/kausality-cli/pkg/policydiff"
+	"github.com/kausality-io/kausality/cmd/kausality-cli/pkg/policytest"
+	"github.com/kausality-io/kausality/pkg/approval"
+	"github.com/kausality-io/kausality/pkg/config"
+	"github.com/kausality-io/kausality/pkg/trace"
 )
 
+var (
+	scheme = runtime.NewScheme()
+)
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(kausalityv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(kausalityv1beta1.AddToScheme(scheme))
+}
+
 func main() {
+	if len(os.Args) > 2 && os.Args[1] == "trace" && os.Args[2] == "export" {
+		runTraceExport(os.Args[3:])
+		return
+	}
+	if len(os.Args) > 2 && os.Args[1] == "trace" && os.Args[2] == "show" {
+		runTraceShow(os.Args[3:])
+		return
+	}
+	if len(os.Args) > 2 && os.Args[1] == "trace" && os.Args[2] == "verify" {
+		runTraceVerify(os.Args[3:])
+		return
+	}
+	if len(os.Args) > 2 && os.Args[1] == "drift" && os.Args[2] == "list" {
+		runDriftList(os.Args[3:])
+		return
+	}
+	if len(os.Args) > 2 && os.Args[1] == "bundle" && os.Args[2] == "export" {
+		runBundleExport(os.Args[3:])
+		return
+	}
+	if len(os.Args) > 2 && os.Args[1] == "bundle" && os.Args[2] == "import" {
+		runBundleImport(os.Args[3:])
+		return
+	}
+	if len(os.Args) > 2 && os.Args[1] == "actor" && os.Args[2] == "changes" {
+		runActorChanges(os.Args[3:])
+		return
+	}
+	if len(os.Args) > 2 && os.Args[1] == "policy" && os.Args[2] == "test" {
+		runPolicyTest(os.Args[3:])
+		return
+	}
+	if len(os.Args) > 2 && os.Args[1] == "policy" && os.Args[2] == "diff" {
+		runPolicyDiff(os.Args[3:])
+		return
+	}
+	if len(os.Args) > 2 && os.Args[1] == "requests" && os.Args[2] == "list" {
+		runRequestsList(os.Args[3:])
+		return
+	}
+	if len(os.Args) > 2 && os.Args[1] == "requests" && os.Args[2] == "show" {
+		runRequestsShow(os.Args[3:])
+		return
+	}
+	if len(os.Args) > 2 && os.Args[1] == "requests" && os.Args[2] == "approve" {
+		runRequestsDecide(kausalityv1alpha1.ApprovalRequestDecisionApprove, os.Args[3:])
+		return
+	}
+	if len(os.Args) > 2 && os.Args[1] == "requests" && os.Args[2] == "reject" {
+		runRequestsDecide(kausalityv1alpha1.ApprovalRequestDecisionReject, os.Args[3:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "effective-mode" {
+		runEffectiveMode(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "plan" {
+		runPlan(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "demo" {
+		runDemo(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "doctor" {
+		runDoctor(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "install" {
+		runInstall(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "uninstall" {
+		runUninstall(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate-storage" {
+		runMigrateStorage(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		runImport(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "approve" {
+		runApprove(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "snooze" {
+		runSnooze(os.Args[2:])
+		return
+	}
+	runMonitor()
+}
+
+// runMonitor runs the interactive drift monitor TUI.
+func runMonitor() {
 	var (
 		kubeconfig string
 		namespace  string
@@ -28,62 +165,1207 @@ func main() {
 	flag.StringVar(&namespace, "namespace", "", "Namespace to watch (default: all namespaces)")
 	flag.StringVar(&group, "group", "", "API group of resources to monitor")
 	flag.StringVar(&version, "version", "v1", "API version of resources to monitor")
-	flag.StringVar(&kind, "kind", "", "Kind of resources to monitor (required)")
+	flag.StringVar(&kind, "kind", "", "Kind of resources to monitor (default: all resources tracked by Kausality policies)")
 	flag.Parse()
 
-	if kind == "" {
-		fmt.Fprintln(os.Stderr, "Error: --kind is required")
-		flag.Usage()
+	config, k8sClient := buildClient(kubeconfig)
+	ctx := context.Background()
+
+	// Determine kinds to monitor: explicit --kind, or everything tracked by policies
+	var kinds []schema.GroupVersionKind
+	if kind != "" {
+		kinds = []schema.GroupVersionKind{{Group: group, Version: version, Kind: kind}}
+	} else {
+		dc, err := discovery.NewDiscoveryClientForConfig(config)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating discovery client: %v\n", err)
+			os.Exit(1)
+		}
+		kinds, err = cli.DiscoverTrackedKinds(ctx, k8sClient, dc)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error discovering tracked resources: %v\n", err)
+			os.Exit(1)
+		}
+		if len(kinds) == 0 {
+			fmt.Fprintln(os.Stderr, "Error: no resources are tracked by Kausality policies; use --kind to monitor a specific kind")
+			os.Exit(1)
+		}
+	}
+
+	// Create CLI client
+	cliClient := cli.NewClient(k8sClient, namespace)
+
+	// Start watching drift-relevant annotations on all kinds
+	metaClient, err := metadata.NewForConfig(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating metadata client: %v\n", err)
 		os.Exit(1)
 	}
+	watcher := cli.NewWatcher(metaClient, k8sClient.RESTMapper(), namespace, kinds)
+	if err := watcher.Start(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Error watching drifts: %v\n", err)
+		os.Exit(1)
+	}
+	for _, skipped := range watcher.Skipped() {
+		fmt.Fprintf(os.Stderr, "Warning: not watching %s: %v\n", skipped.Kind.Kind, skipped.Err)
+	}
 
-	// Build kubeconfig
-	if kubeconfig == "" {
-		kubeconfig = os.Getenv("KUBECONFIG")
-		if kubeconfig == "" {
-			home, _ := os.UserHomeDir()
-			kubeconfig = home + "/.kube/config"
+	// Create model
+	model := cli.NewModel(cliClient, watcher)
+	model.SetItems(watcher.Items())
+
+	// Run TUI
+	p := tea.NewProgram(model, tea.WithAltScreen())
+	if _, err := p.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error running TUI: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runDriftList prints the drifts of the tracked kinds, or of --kind.
+func runDriftList(args []string) {
+	fs := flag.NewFlagSet("drift list", flag.ExitOnError)
+	kubeconfig := fs.String("kubeconfig", "", "Path to kubeconfig file (default: $KUBECONFIG or ~/.kube/config)")
+	namespace := fs.String("namespace", "", "Namespace to list (default: all namespaces)")
+	group := fs.String("group", "", "API group of resources to list")
+	version := fs.String("version", "v1", "API version of resources to list")
+	kind := fs.String("kind", "", "Kind of resources to list (default: all resources tracked by Kausality policies)")
+	format := fs.String("output", output.Text, "Output format: text, json, or yaml")
+	_ = fs.Parse(args)
+	validateOutput(*format)
+
+	config, k8sClient := buildClient(*kubeconfig)
+	ctx := context.Background()
+
+	kinds := []schema.GroupVersionKind{{Group: *group, Version: *version, Kind: *kind}}
+	if *kind == "" {
+		dc, err := discovery.NewDiscoveryClientForConfig(config)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating discovery client: %v\n", err)
+			os.Exit(1)
+		}
+		if kinds, err = cli.DiscoverTrackedKinds(ctx, k8sClient, dc); err != nil {
+			fmt.Fprintf(os.Stderr, "Error discovering tracked resources: %v\n", err)
+			os.Exit(1)
 		}
 	}
 
-	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	// Kinds that cannot be listed are reported, the others are still printed
+	items, listErr := cli.NewClient(k8sClient, *namespace).ListAllDrifts(ctx, kinds)
+	if *format == output.Text {
+		for _, item := range items {
+			fmt.Printf("%s\t%s/%s\t%s/%s\n", item.Phase, item.ParentKind, item.ParentName, item.ChildKind, item.ChildName)
+		}
+	} else {
+		writeOutput(*format, cli.NewDriftList(items))
+	}
+	if listErr != nil {
+		fmt.Fprintf(os.Stderr, "Error listing drifts: %v\n", listErr)
+		os.Exit(1)
+	}
+}
+
+// runActorChanges lists the objects an actor changed, grouped by namespace
+// and kind, from updaters annotations and the drift backend.
+func runActorChanges(args []string) {
+	fs := flag.NewFlagSet("actor changes", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: kausality-cli actor changes (--user NAME | --hash HASH) [flags]")
+		fs.PrintDefaults()
+	}
+	kubeconfig := fs.String("kubeconfig", "", "Path to kubeconfig file (default: $KUBECONFIG or ~/.kube/config)")
+	namespace := fs.String("namespace", "", "Namespace to search (default: all namespaces)")
+	group := fs.String("group", "", "API group of resources to search")
+	version := fs.String("version", "v1", "API version of resources to search")
+	kind := fs.String("kind", "", "Kind of resources to search (default: all resources tracked by Kausality policies)")
+	user := fs.String("user", "", "Username of the actor")
+	hash := fs.String("hash", "", "Updater hash of the actor, as in kausality.io/updaters")
+	since := fs.Duration("since", 24*time.Hour, "Time window of the changes")
+	backendURL := fs.String("backend-url", "", "Base URL of the drift backend to query for trace records and drift reports (default: skip)")
+	backendTokenFile := fs.String("backend-token-file", "", "File with a bearer token for the drift backend")
+	format := fs.String("output", output.Text, "Output format: text, json, or yaml")
+	_ = fs.Parse(args)
+
+	if (*user == "") == (*hash == "") || *since <= 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	validateOutput(*format)
+
+	config, k8sClient := buildClient(*kubeconfig)
+	ctx := context.Background()
+
+	kinds := []schema.GroupVersionKind{{Group: *group, Version: *version, Kind: *kind}}
+	if *kind == "" {
+		dc, err := discovery.NewDiscoveryClientForConfig(config)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating discovery client: %v\n", err)
+			os.Exit(1)
+		}
+		if kinds, err = cli.DiscoverTrackedKinds(ctx, k8sClient, dc); err != nil {
+			fmt.Fprintf(os.Stderr, "Error discovering tracked resources: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	var backend *cli.Backend
+	if *backendURL != "" {
+		backend = &cli.Backend{URL: *backendURL, HTTPClient: &http.Client{Timeout: 30 * time.Second}}
+		if *backendTokenFile != "" {
+			token, err := os.ReadFile(*backendTokenFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading backend token: %v\n", err)
+				os.Exit(1)
+			}
+			backend.Token = strings.TrimSpace(string(token))
+		}
+	}
+
+	// Sources that fail are reported, the others are still printed
+	q := cli.ActorQuery{User: *user, Hash: *hash, Since: time.Now().Add(-*since)}
+	changes, queryErr := cli.NewClient(k8sClient, *namespace).ActorChanges(ctx, kinds, q, backend)
+	if *format == output.Text {
+		actor := changes.User
+		if actor == "" {
+			actor = "hash " + changes.Hash
+		}
+		fmt.Printf("Changes by %s since %s\n", actor, changes.Since.Format(time.RFC3339))
+		for _, ns := range changes.Namespaces {
+			name := ns.Namespace
+			if name == "" {
+				name = "(cluster)"
+			}
+			fmt.Printf("%s\n", name)
+			for _, k := range ns.Kinds {
+				for _, o := range k.Objects {
+					fmt.Printf("  %s/%s\t%s", k.Kind, o.Name, strings.Join(o.Sources, ","))
+					if o.Mutations > 0 {
+						fmt.Printf("\t%d mutations", o.Mutations)
+					}
+					if len(o.DriftIDs) > 0 {
+						fmt.Printf("\tdrift %s", strings.Join(o.DriftIDs, ","))
+					}
+					fmt.Println()
+				}
+			}
+		}
+	} else {
+		writeOutput(*format, changes)
+	}
+	if queryErr != nil {
+		fmt.Fprintf(os.Stderr, "Error querying changes: %v\n", queryErr)
+		os.Exit(1)
+	}
+}
+
+// runTraceShow prints the causal chain of an object.
+func runTraceShow(args []string) {
+	fs := flag.NewFlagSet("trace show", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: kausality-cli trace show --kind KIND [flags] NAME")
+		fs.PrintDefaults()
+	}
+	kubeconfig := fs.String("kubeconfig", "", "Path to kubeconfig file (default: $KUBECONFIG or ~/.kube/config)")
+	namespace := fs.String("namespace", "default", "Namespace of the object")
+	group := fs.String("group", "", "API group of the object")
+	version := fs.String("version", "v1", "API version of the object")
+	kind := fs.String("kind", "", "Kind of the object (required)")
+	format := fs.String("output", output.Text, "Output format: text, json, or yaml")
+	_ = fs.Parse(args)
+
+	if *kind == "" || fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	validateOutput(*format)
+
+	_, k8sClient := buildClient(*kubeconfig)
+	gvk := schema.GroupVersionKind{Group: *group, Version: *version, Kind: *kind}
+	t, err := cli.NewClient(k8sClient, *namespace).ShowTrace(context.Background(), gvk, *namespace, fs.Arg(0))
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error building kubeconfig: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error reading trace: %v\n", err)
 		os.Exit(1)
 	}
+	if *format != output.Text {
+		writeOutput(*format, t)
+		return
+	}
+	for i, hop := range t.Hops {
+		fmt.Printf("%d. %s %s (generation %d) by %s\n", i+1, hop.Kind, hop.Name, hop.Generation, hop.User)
+	}
+}
+
+// runTraceVerify verifies the hop signatures of the causal chain of an
+// object. It exits with 1 if any hop has an invalid or missing signature.
+func runTraceVerify(args []string) {
+	fs := flag.NewFlagSet("trace verify", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: kausality-cli trace verify --kind KIND --key FILE [flags] NAME")
+		fs.PrintDefaults()
+	}
+	kubeconfig := fs.String("kubeconfig", "", "Path to kubeconfig file (default: $KUBECONFIG or ~/.kube/config)")
+	namespace := fs.String("namespace", "default", "Namespace of the object")
+	group := fs.String("group", "", "API group of the object")
+	version := fs.String("version", "v1", "API version of the object")
+	kind := fs.String("kind", "", "Kind of the object (required)")
+	keyFile := fs.String("key", "", "Verification key: PEM public key of the webhook's signing key, or the HMAC key (required)")
+	format := fs.String("output", output.Text, "Output format: text, json, or yaml")
+	_ = fs.Parse(args)
 
-	// Create client
-	k8sClient, err := client.New(config, client.Options{})
+	if *kind == "" || *keyFile == "" || fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	validateOutput(*format)
+
+	verifier, err := trace.LoadVerifier(*keyFile)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating client: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error loading key: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Create CLI client
-	cliClient := cli.NewClient(k8sClient, namespace)
+	_, k8sClient := buildClient(*kubeconfig)
+	gvk := schema.GroupVersionKind{Group: *group, Version: *version, Kind: *kind}
+	v, err := cli.NewClient(k8sClient, *namespace).VerifyTrace(context.Background(), gvk, *namespace, fs.Arg(0), verifier)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading trace: %v\n", err)
+		os.Exit(1)
+	}
+	if *format != output.Text {
+		writeOutput(*format, v)
+	} else {
+		for i, hop := range v.Hops {
+			fmt.Printf("%d. %s %s (generation %d) by %s: %s\n", i+1, hop.Kind, hop.Name, hop.Generation, hop.User, hop.Verification)
+		}
+		if v.Mismatch != "" {
+			fmt.Printf("not the trace of this object: %s\n", v.Mismatch)
+		}
+	}
+	if !v.Valid {
+		os.Exit(1)
+	}
+}
+
+// runEffectiveMode prints the drift detection mode of an object and where it comes from.
+func runEffectiveMode(args []string) {
+	fs := flag.NewFlagSet("effective-mode", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: kausality-cli effective-mode --kind KIND [flags] NAME")
+		fs.PrintDefaults()
+	}
+	kubeconfig := fs.String("kubeconfig", "", "Path to kubeconfig file (default: $KUBECONFIG or ~/.kube/config)")
+	namespace := fs.String("namespace", "default", "Namespace of the object (empty for cluster-scoped objects)")
+	group := fs.String("group", "", "API group of the object")
+	version := fs.String("version", "v1", "API version of the object")
+	kind := fs.String("kind", "", "Kind of the object (required)")
+	format := fs.String("output", output.Text, "Output format: text, json, or yaml")
+	_ = fs.Parse(args)
 
-	// Build GVK
-	gvk := schema.GroupVersionKind{
-		Group:   group,
-		Version: version,
-		Kind:    kind + "List",
+	if *kind == "" || fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
 	}
+	validateOutput(*format)
 
-	// Load initial items
-	items, err := cliClient.ListDrifts(context.Background(), gvk)
+	_, k8sClient := buildClient(*kubeconfig)
+	gvk := schema.GroupVersionKind{Group: *group, Version: *version, Kind: *kind}
+	mode, err := cli.NewClient(k8sClient, *namespace).EffectiveMode(context.Background(), gvk, *namespace, fs.Arg(0))
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error listing drifts: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error resolving mode: %v\n", err)
 		os.Exit(1)
 	}
+	if *format != output.Text {
+		writeOutput(*format, mode)
+		return
+	}
+	fmt.Printf("%s (%s", mode.Mode, mode.Source)
+	if mode.Policy != "" {
+		fmt.Printf(", policy %s", mode.Policy)
+	}
+	if mode.NamespacePolicy != "" {
+		fmt.Printf(", namespace policy %s", mode.NamespacePolicy)
+	}
+	fmt.Println(")")
+	if mode.DriftExclusion != "" {
+		fmt.Printf("drift not evaluated: excluded by policy %s\n", mode.DriftExclusion)
+	}
+}
 
-	// Create model
-	model := cli.NewModel(cliClient)
-	model.SetItems(items)
+// runPolicyTest runs declarative test cases against policy files offline.
+// It exits with 1 if any test fails.
+func runPolicyTest(args []string) {
+	fs := flag.NewFlagSet("policy test", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: kausality-cli policy test --policies FILE[,FILE...] [flags] TESTS")
+		fmt.Fprintln(fs.Output(), "Resolves the mode of each test case in TESTS from the Kausality policies and KausalityPolicies in the policy files, without a cluster.")
+		fs.PrintDefaults()
+	}
+	policyFiles := fs.String("policies", "", "Comma-separated YAML files with Kausality policies and KausalityPolicies, e.g. bundles (required)")
+	format := fs.String("output", output.Text, "Output format: text, json, or yaml")
+	_ = fs.Parse(args)
 
-	// Run TUI
-	p := tea.NewProgram(model, tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error running TUI: %v\n", err)
+	if *policyFiles == "" || fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	validateOutput(*format)
+
+	policies := &policytest.Policies{}
+	for _, file := range strings.Split(*policyFiles, ",") {
+		if err := readFile(file, policies.ReadPolicies); err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading policies %s: %v\n", file, err)
+			os.Exit(1)
+		}
+	}
+	var suite *policytest.Suite
+	err := readFile(fs.Arg(0), func(r io.Reader) (err error) {
+		suite, err = policytest.ReadSuite(r)
+		return err
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading tests %s: %v\n", fs.Arg(0), err)
+		os.Exit(1)
+	}
+
+	result := policytest.Run(policies, suite)
+	if *format != output.Text {
+		writeOutput(*format, result)
+	} else {
+		for _, t := range result.Tests {
+			if len(t.Failures) == 0 {
+				fmt.Printf("PASS %s\n", t.Name)
+				continue
+			}
+			fmt.Printf("FAIL %s\n", t.Name)
+			for _, f := range t.Failures {
+				fmt.Printf("    %s\n", f)
+			}
+		}
+		fmt.Printf("%d passed, %d failed\n", result.Passed, result.Failed)
+	}
+	if result.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// runPolicyDiff compares the effective policy of two clusters or bundles.
+// It exits with 1 if they differ.
+func runPolicyDiff(args []string) {
+	fs := flag.NewFlagSet("policy diff", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: kausality-cli policy diff (--cluster-a CONTEXT|--bundle-a FILE) (--cluster-b CONTEXT|--bundle-b FILE) [flags]")
+		fmt.Fprintln(fs.Output(), "Compares the mode and tracking the policies of two clusters or bundles resolve for every resource they name, in every namespace.")
+		fs.PrintDefaults()
+	}
+	kubeconfig := fs.String("kubeconfig", "", "Path to kubeconfig file (default: $KUBECONFIG or ~/.kube/config)")
+	clusterA := fs.String("cluster-a", "", "Kubeconfig context of the first cluster")
+	clusterB := fs.String("cluster-b", "", "Kubeconfig context of the second cluster")
+	bundleA := fs.String("bundle-a", "", "Bundle or policy file of the first side, instead of a cluster")
+	bundleB := fs.String("bundle-b", "", "Bundle or policy file of the second side, instead of a cluster")
+	format := fs.String("output", output.Text, "Output format: text, json, or yaml")
+	_ = fs.Parse(args)
+
+	if (*clusterA == "") == (*bundleA == "") || (*clusterB == "") == (*bundleB == "") || fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	validateOutput(*format)
+
+	ctx := context.Background()
+	readSide := func(kubeContext, file string) *policydiff.Side {
+		var side *policydiff.Side
+		var err error
+		if file != "" {
+			err = readFile(file, func(r io.Reader) (err error) {
+				side, err = policydiff.ReadFile(file, r)
+				return err
+			})
+		} else {
+			side, err = policydiff.ReadCluster(ctx, buildContextClient(*kubeconfig, kubeContext), kubeContext)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading policies of %s%s: %v\n", kubeContext, file, err)
+			os.Exit(1)
+		}
+		return side
+	}
+	diff := policydiff.Diff(readSide(*clusterA, *bundleA), readSide(*clusterB, *bundleB))
+
+	var err error
+	if *format != output.Text {
+		err = output.Write(os.Stdout, *format, diff)
+	} else {
+		err = policydiff.WriteText(os.Stdout, diff)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+		os.Exit(1)
+	}
+	if len(diff.Differences) > 0 {
+		os.Exit(1)
+	}
+}
+
+// runPlan predicts how the webhook would admit rendered manifests. It exits
+// with 1 if any change would be denied or could not be evaluated.
+func runPlan(args []string) {
+	fs := flag.NewFlagSet("plan", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: kausality-cli plan -f FILE|DIR[,FILE|DIR...] [flags]")
+		fmt.Fprintln(fs.Output(), "Predicts which objects of the manifests would be origin changes, drift or denied when applied, under the Kausality policies of the cluster. Nothing is written.")
+		fs.PrintDefaults()
+	}
+	kubeconfig := fs.String("kubeconfig", "", "Path to kubeconfig file (default: $KUBECONFIG or ~/.kube/config)")
+	files := fs.String("f", "", "Comma-separated manifest files or directories of .yaml, .yml and .json files, \"-\" for stdin (required)")
+	namespace := fs.String("namespace", "default", "Namespace of namespaced objects without one")
+	user := fs.String("user", "", "User applying the manifests, e.g. system:serviceaccount:ci:deployer (default: the kubeconfig's user)")
+	fieldManager := fs.String("field-manager", plan.DefaultFieldManager, "Field manager applying the manifests")
+	configFile := fs.String("config", "", "The webhook's config file, for parent profiles, lifecycle mappings and Flux (optional)")
+	format := fs.String("output", output.Text, "Output format: text, json, yaml, or markdown")
+	_ = fs.Parse(args)
+
+	if *files == "" || fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if err := output.Validate(*format, output.Text, output.JSON, output.YAML, output.Markdown); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	opts := plan.Options{User: *user, FieldManager: *fieldManager, Namespace: *namespace}
+	if *configFile != "" {
+		cfg, err := config.Load(*configFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+			os.Exit(1)
+		}
+		opts.DriftConfig = cfg
+	}
+	objects, err := plan.ReadFiles(strings.Split(*files, ",")...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading manifests: %v\n", err)
+		os.Exit(1)
+	}
+
+	_, k8sClient := buildClient(*kubeconfig)
+	ctx := context.Background()
+	if opts.User == "" {
+		if opts.User, err = plan.CurrentUser(ctx, k8sClient); err != nil {
+			fmt.Fprintf(os.Stderr, "Error determining the current user, set --user: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	result, err := plan.Run(ctx, k8sClient, objects, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch *format {
+	case output.Text:
+		err = plan.WriteText(os.Stdout, result)
+	case output.Markdown:
+		err = plan.WriteMarkdown(os.Stdout, result)
+	default:
+		err = output.Write(os.Stdout, *format, result)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+		os.Exit(1)
+	}
+	if result.Summary.Denied > 0 || result.Summary.Errors > 0 {
+		os.Exit(1)
+	}
+}
+
+// readFile calls read with the content of a file.
+func readFile(path string, read func(io.Reader) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	return read(f)
+}
+
+// runTraceExport prints the causal chain of an object as a diagram.
+func runTraceExport(args []string) {
+	fs := flag.NewFlagSet("trace export", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: kausality-cli trace export --kind KIND [flags] NAME")
+		fs.PrintDefaults()
+	}
+	kubeconfig := fs.String("kubeconfig", "", "Path to kubeconfig file (default: $KUBECONFIG or ~/.kube/config)")
+	namespace := fs.String("namespace", "default", "Namespace of the object")
+	group := fs.String("group", "", "API group of the object")
+	version := fs.String("version", "v1", "API version of the object")
+	kind := fs.String("kind", "", "Kind of the object (required)")
+	format := fs.String("format", trace.DiagramMermaid, "Diagram format: mermaid or dot")
+	_ = fs.Parse(args)
+
+	if *kind == "" || fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	_, k8sClient := buildClient(*kubeconfig)
+	cliClient := cli.NewClient(k8sClient, *namespace)
+
+	gvk := schema.GroupVersionKind{Group: *group, Version: *version, Kind: *kind}
+	diagram, err := cliClient.ExportTrace(context.Background(), gvk, *namespace, fs.Arg(0), *format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error exporting trace: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(diagram)
+}
+
+// runApprove approves drift of the selected children on their parent.
+func runApprove(args []string) {
+	fs := flag.NewFlagSet("approve", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: kausality-cli approve --parent RESOURCE/NAME --children KIND/PATTERN [flags]")
+		fs.PrintDefaults()
+	}
+	kubeconfig := fs.String("kubeconfig", "", "Path to kubeconfig file (default: $KUBECONFIG or ~/.kube/config)")
+	namespace := fs.String("namespace", "default", "Namespace of the parent and its children")
+	parentArg := fs.String("parent", "", "Parent object, e.g. deploy/web (required)")
+	childrenArg := fs.String("children", "", "Children by kind and name glob, e.g. 'ReplicaSet/*' (required)")
+	mode := fs.String("mode", approval.ModeOnce, "Approval mode: once, generation, or always")
+	dryRun := fs.Bool("dry-run", false, "Print the approvals that would be written without applying them")
+	format := fs.String("output", output.Text, "Output format: text, json, or yaml")
+	_ = fs.Parse(args)
+
+	if *parentArg == "" || *childrenArg == "" || fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	validateOutput(*format)
+	parentResource, parentName, err := cli.ParseObjectArg(*parentArg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --parent: %v\n", err)
+		os.Exit(1)
+	}
+	childResource, childPattern, err := cli.ParseObjectArg(*childrenArg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --children: %v\n", err)
+		os.Exit(1)
+	}
+
+	config, k8sClient := buildClient(*kubeconfig)
+	dc, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating discovery client: %v\n", err)
+		os.Exit(1)
+	}
+	mapper := restmapper.NewShortcutExpander(restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(dc)), dc, nil)
+
+	req := cli.BulkApproval{ParentName: parentName, ChildPattern: childPattern, Mode: *mode}
+	if req.Parent, err = cli.ResolveKind(mapper, parentResource); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --parent: %v\n", err)
+		os.Exit(1)
+	}
+	if req.Child, err = cli.ResolveKind(mapper, childResource); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --children: %v\n", err)
+		os.Exit(1)
+	}
+
+	cliClient := cli.NewClient(k8sClient, *namespace)
+	ctx := context.Background()
+	var plan *cli.ApprovalPlan
+	if *dryRun {
+		plan, err = cliClient.PlanApproval(ctx, req)
+	} else {
+		plan, err = cliClient.ApplyApproval(ctx, req)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error approving: %v\n", err)
+		os.Exit(1)
+	}
+	if *format != output.Text {
+		writeOutput(*format, plan.Output(req.Mode, !*dryRun))
+		return
+	}
+
+	fmt.Printf("%s/%s: approving %d %s (mode %s)\n", req.Parent.Kind, parentName, len(plan.Children), req.Child.Kind, req.Mode)
+	for _, child := range plan.Children {
+		fmt.Printf("  %s\n", child.Name)
+	}
+	if !*dryRun {
+		fmt.Println("applied")
+		return
+	}
+	data, err := json.MarshalIndent(plan.Approvals, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error marshaling approvals: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s (dry run, not applied):\n%s\n", approval.ApprovalsAnnotation, data)
+}
+
+// runRequestsList lists the ApprovalRequests of denied drift.
+func runRequestsList(args []string) {
+	fs := flag.NewFlagSet("requests list", flag.ExitOnError)
+	kubeconfig := fs.String("kubeconfig", "", "Path to kubeconfig file (default: $KUBECONFIG or ~/.kube/config)")
+	namespace := fs.String("namespace", "", "Namespace to list (default: all namespaces)")
+	all := fs.Bool("all", false, "Also list requests that were decided or became stale")
+	format := fs.String("output", output.Text, "Output format: text, json, or yaml")
+	_ = fs.Parse(args)
+	validateOutput(*format)
+
+	_, k8sClient := buildClient(*kubeconfig)
+	requests, err := cli.NewClient(k8sClient, *namespace).ListApprovalRequests(context.Background(), *all)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *format != output.Text {
+		writeOutput(*format, requests)
+		return
+	}
+	for _, r := range requests {
+		phase := string(r.Status.Phase)
+		if phase == "" {
+			phase = string(kausalityv1alpha1.ApprovalRequestPhasePending)
+		}
+		fmt.Printf("%s\t%s/%s\t%s/%s\t%s/%s\t%s\n", phase, r.Namespace, r.Name,
+			r.Spec.Parent.Kind, r.Spec.Parent.Name, r.Spec.Child.Kind, r.Spec.Child.Name, r.Spec.User)
+	}
+}
+
+// runRequestsShow prints an ApprovalRequest with the diff of its drift.
+func runRequestsShow(args []string) {
+	fs := flag.NewFlagSet("requests show", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: kausality-cli requests show NAME [flags]")
+		fs.PrintDefaults()
+	}
+	kubeconfig := fs.String("kubeconfig", "", "Path to kubeconfig file (default: $KUBECONFIG or ~/.kube/config)")
+	namespace := fs.String("namespace", "default", "Namespace of the request")
+	format := fs.String("output", output.Text, "Output format: text, json, or yaml")
+	name, args := leadingArg(args)
+	_ = fs.Parse(args)
+	if name == "" && fs.NArg() == 1 {
+		name = fs.Arg(0)
+	} else if fs.NArg() != 0 {
+		name = ""
+	}
+	if name == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+	validateOutput(*format)
+
+	_, k8sClient := buildClient(*kubeconfig)
+	r, err := cli.NewClient(k8sClient, *namespace).GetApprovalRequest(context.Background(), name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *format != output.Text {
+		writeOutput(*format, r)
+		return
+	}
+	phase := string(r.Status.Phase)
+	if phase == "" {
+		phase = string(kausalityv1alpha1.ApprovalRequestPhasePending)
+	}
+	fmt.Printf("Request:  %s/%s\n", r.Namespace, r.Name)
+	fmt.Printf("Parent:   %s %s (generation %d)\n", r.Spec.Parent.Kind, r.Spec.Parent.Name, r.Spec.ParentGeneration)
+	fmt.Printf("Child:    %s %s\n", r.Spec.Child.Kind, r.Spec.Child.Name)
+	fmt.Printf("User:     %s\n", r.Spec.User)
+	fmt.Printf("Drift ID: %s\n", r.Spec.DriftID)
+	fmt.Printf("Phase:    %s\n", phase)
+	if r.Status.Message != "" {
+		fmt.Printf("Message:  %s\n", r.Status.Message)
+	}
+	if r.Spec.Diff != "" {
+		fmt.Printf("\n%s", r.Spec.Diff)
+	}
+}
+
+// runRequestsDecide approves or rejects the drift of an ApprovalRequest.
+func runRequestsDecide(decision kausalityv1alpha1.ApprovalRequestDecision, args []string) {
+	verb := "approve"
+	if decision == kausalityv1alpha1.ApprovalRequestDecisionReject {
+		verb = "reject"
+	}
+	fs := flag.NewFlagSet("requests "+verb, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: kausality-cli requests %s NAME [flags]\n", verb)
+		fs.PrintDefaults()
+	}
+	kubeconfig := fs.String("kubeconfig", "", "Path to kubeconfig file (default: $KUBECONFIG or ~/.kube/config)")
+	namespace := fs.String("namespace", "default", "Namespace of the request")
+	mode := approval.ModeOnce
+	if decision == kausalityv1alpha1.ApprovalRequestDecisionApprove {
+		fs.StringVar(&mode, "mode", approval.ModeOnce, "Approval mode: once, generation, or always")
+	}
+	reason := fs.String("reason", "", "Why the drift is "+verb+"d, recorded with the decision")
+	name, args := leadingArg(args)
+	_ = fs.Parse(args)
+	if name == "" && fs.NArg() == 1 {
+		name = fs.Arg(0)
+	} else if fs.NArg() != 0 {
+		name = ""
+	}
+	if name == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	_, k8sClient := buildClient(*kubeconfig)
+	r, err := cli.NewClient(k8sClient, *namespace).DecideApprovalRequest(context.Background(), name, decision, mode, *reason)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s/%s: %sd, applying to %s %s\n", r.Namespace, r.Name, verb, r.Spec.Parent.Kind, r.Spec.Parent.Name)
+}
+
+// leadingArg splits off a positional argument given before the flags, as in
+// "requests approve NAME --mode always".
+func leadingArg(args []string) (string, []string) {
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		return args[0], args[1:]
+	}
+	return "", args
+}
+
+// runImport converts Gatekeeper constraints and Kyverno policies from files
+// (or stdin) into draft Kausality policies printed as YAML.
+// runSnooze suppresses drift on a single child for a time window.
+func runSnooze(args []string) {
+	fs := flag.NewFlagSet("snooze", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: kausality-cli snooze RESOURCE/NAME --for DURATION [flags]")
+		fs.PrintDefaults()
+	}
+	kubeconfig := fs.String("kubeconfig", "", "Path to kubeconfig file (default: $KUBECONFIG or ~/.kube/config)")
+	namespace := fs.String("namespace", "default", "Namespace of the child")
+	duration := fs.Duration("for", 0, "How long drift on the child is suppressed, e.g. 2h (required unless --clear)")
+	message := fs.String("message", "", "Why drift is suppressed")
+	user := fs.String("user", "", "Who suppresses drift, recorded with the suppression")
+	unsnooze := fs.Bool("clear", false, "End the suppression instead")
+
+	// The child may come before the flags, as in "snooze rs/web-abc --for 2h"
+	var childArg string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		childArg, args = args[0], args[1:]
+	}
+	_ = fs.Parse(args)
+	if childArg == "" && fs.NArg() == 1 {
+		childArg = fs.Arg(0)
+	} else if fs.NArg() != 0 {
+		childArg = ""
+	}
+	if childArg == "" || (*duration <= 0 && !*unsnooze) || (*duration > 0 && *unsnooze) {
+		fs.Usage()
+		os.Exit(1)
+	}
+	resource, name, err := cli.ParseObjectArg(childArg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	config, k8sClient := buildClient(*kubeconfig)
+	dc, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating discovery client: %v\n", err)
+		os.Exit(1)
+	}
+	mapper := restmapper.NewShortcutExpander(restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(dc)), dc, nil)
+	gvk, err := cli.ResolveKind(mapper, resource)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	cliClient := cli.NewClient(k8sClient, *namespace)
+	if *unsnooze {
+		if err := cliClient.ClearSuppression(context.Background(), gvk, name); err != nil {
+			fmt.Fprintf(os.Stderr, "Error clearing suppression: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s/%s: drift no longer suppressed\n", gvk.Kind, name)
+		return
+	}
+	if err := cliClient.Suppress(context.Background(), gvk, name, *duration, *user, *message); err != nil {
+		fmt.Fprintf(os.Stderr, "Error suppressing drift: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s/%s: drift suppressed for %s\n", gvk.Kind, name, *duration)
+}
+
+func runImport(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: kausality-cli import [FILE...]")
+		fmt.Fprintln(fs.Output(), "Reads Gatekeeper constraints and Kyverno policies from files, or stdin if none or \"-\" is given.")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	files := fs.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	result := &importer.Result{}
+	for _, file := range files {
+		in := os.Stdin
+		if file != "-" {
+			f, err := os.Open(file)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			defer func() { _ = f.Close() }()
+			in = f
+		}
+		r, err := importer.Convert(in)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error converting %s: %v\n", file, err)
+			os.Exit(1)
+		}
+		result.Drafts = append(result.Drafts, r.Drafts...)
+		result.Skipped = append(result.Skipped, r.Skipped...)
+	}
+
+	if err := result.WriteYAML(os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing policies: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "%d draft policies, %d skipped; review warnings before applying\n", len(result.Drafts), len(result.Skipped))
+}
+
+// runDoctor verifies the Kausality installation and prints a report.
+// It exits non-zero if any check fails.
+func runDoctor(args []string) {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	kubeconfig := fs.String("kubeconfig", "", "Path to kubeconfig file (default: $KUBECONFIG or ~/.kube/config)")
+	webhookName := fs.String("webhook-name", "kausality", "Name of the MutatingWebhookConfiguration")
+	canaryNamespace := fs.String("canary-namespace", "default", "Namespace for the dry-run canary ConfigMap")
+	backendURL := fs.String("backend-url", "", "Base URL of the drift backend to check (default: skip)")
+	format := fs.String("output", output.Text, "Output format: text, json, or yaml")
+	_ = fs.Parse(args)
+	validateOutput(*format)
+
+	_, k8sClient := buildClient(*kubeconfig)
+	report := doctor.New(k8sClient, doctor.Options{
+		WebhookName:     *webhookName,
+		CanaryNamespace: *canaryNamespace,
+		BackendURL:      *backendURL,
+	}).Run(context.Background())
+
+	var err error
+	switch *format {
+	case output.JSON:
+		err = report.WriteJSON(os.Stdout)
+	case output.YAML:
+		err = output.Write(os.Stdout, *format, report)
+	default:
+		err = report.WriteText(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+		os.Exit(1)
+	}
+	if report.Failed() {
+		os.Exit(1)
+	}
+}
+
+// runDemo runs the scripted drift scenario in a demo namespace.
+func runDemo(args []string) {
+	fs := flag.NewFlagSet("demo", flag.ExitOnError)
+	kubeconfig := fs.String("kubeconfig", "", "Path to kubeconfig file (default: $KUBECONFIG or ~/.kube/config)")
+	namespace := fs.String("namespace", demo.DefaultNamespace, "Namespace created for the demo")
+	image := fs.String("image", demo.DefaultImage, "Image of the sample Deployment")
+	timeout := fs.Duration("timeout", 2*time.Minute, "Timeout of each wait for the cluster")
+	backendURL := fs.String("backend-url", "", "Base URL of the drift backend receiving the webhook's reports (default: reports are not shown)")
+	backendTokenFile := fs.String("backend-token-file", "", "File with a bearer token for the drift backend")
+	keep := fs.Bool("keep", false, "Keep the namespace and policy after the demo")
+	interactive := fs.Bool("interactive", false, "Wait for Enter before each step")
+	_ = fs.Parse(args)
+
+	_, k8sClient := buildClient(*kubeconfig)
+	opts := demo.Options{Namespace: *namespace, Image: *image, Timeout: *timeout, Keep: *keep}
+	if *backendURL != "" {
+		opts.Backend = &cli.Backend{URL: *backendURL, HTTPClient: &http.Client{Timeout: 30 * time.Second}}
+		if *backendTokenFile != "" {
+			token, err := os.ReadFile(*backendTokenFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading backend token: %v\n", err)
+				os.Exit(1)
+			}
+			opts.Backend.Token = strings.TrimSpace(string(token))
+		}
+	}
+	if *interactive {
+		stdin := bufio.NewReader(os.Stdin)
+		opts.Pause = func() error {
+			fmt.Print("\n[press Enter to continue]")
+			_, err := stdin.ReadString('\n')
+			return err
+		}
+	}
+
+	// Interrupting the demo still cleans up
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	err := demo.New(k8sClient, os.Stdout, opts).Run(ctx)
+	stop()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runInstall deploys or updates Kausality from the manifests embedded in the CLI.
+func runInstall(args []string) {
+	fs := flag.NewFlagSet("install", flag.ExitOnError)
+	kubeconfig := fs.String("kubeconfig", "", "Path to kubeconfig file (default: $KUBECONFIG or ~/.kube/config)")
+	name := fs.String("name", "kausality", "Name of the installation, prefix of the installed objects")
+	namespace := fs.String("namespace", "kausality-system", "Namespace of the webhook and controller")
+	version := fs.String("version", install.DefaultVersion, "Image tag of the webhook and controller")
+	registry := fs.String("registry", install.DefaultRegistry, "Image registry")
+	skipPolicy := fs.Bool("skip-policy", false, "Do not install the default log-mode policy (prunes it if installed)")
+	prune := fs.Bool("prune", true, "Delete objects of a previous installation that are no longer installed")
+	dryRun := fs.Bool("dry-run", false, "Report changes without applying them")
+	diff := fs.Bool("diff", false, "Print a diff of each created or changed object")
+	format := fs.String("output", output.Text, "Output format: text, json, or yaml")
+	_ = fs.Parse(args)
+	validateOutput(*format)
+
+	_, k8sClient := buildClient(*kubeconfig)
+	result, err := install.New(k8sClient, install.Options{
+		Name:       *name,
+		Namespace:  *namespace,
+		Version:    *version,
+		Registry:   *registry,
+		SkipPolicy: *skipPolicy,
+		Prune:      *prune,
+		DryRun:     *dryRun,
+	}).Install(context.Background())
+	writeInstallResult(*format, result, *diff)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error installing: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runUninstall removes Kausality and the bookkeeping annotations of the webhook.
+func runUninstall(args []string) {
+	fs := flag.NewFlagSet("uninstall", flag.ExitOnError)
+	kubeconfig := fs.String("kubeconfig", "", "Path to kubeconfig file (default: $KUBECONFIG or ~/.kube/config)")
+	name := fs.String("name", "kausality", "Name of the installation, prefix of the installed objects")
+	namespace := fs.String("namespace", "kausality-system", "Namespace of the webhook and controller")
+	keepCRDs := fs.Bool("keep-crds", false, "Keep the CRDs and all policies")
+	dryRun := fs.Bool("dry-run", false, "Report changes without applying them")
+	format := fs.String("output", output.Text, "Output format: text, json, or yaml")
+	_ = fs.Parse(args)
+	validateOutput(*format)
+
+	_, k8sClient := buildClient(*kubeconfig)
+	result, err := install.New(k8sClient, install.Options{
+		Name:      *name,
+		Namespace: *namespace,
+		KeepCRDs:  *keepCRDs,
+		DryRun:    *dryRun,
+	}).Uninstall(context.Background())
+	writeInstallResult(*format, result, false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error uninstalling: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runMigrateStorage rewrites Kausality objects stored in old API versions.
+func runMigrateStorage(args []string) {
+	fs := flag.NewFlagSet("migrate-storage", flag.ExitOnError)
+	kubeconfig := fs.String("kubeconfig", "", "Path to kubeconfig file (default: $KUBECONFIG or ~/.kube/config)")
+	dryRun := fs.Bool("dry-run", false, "Report objects to migrate without rewriting them")
+	format := fs.String("output", output.Text, "Output format: text, json, or yaml")
+	_ = fs.Parse(args)
+	validateOutput(*format)
+
+	_, k8sClient := buildClient(*kubeconfig)
+	result, err := install.New(k8sClient, install.Options{DryRun: *dryRun}).MigrateStorage(context.Background())
+	writeInstallResult(*format, result, false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error migrating storage: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runBundleExport prints the policies of the cluster as a bundle.
+func runBundleExport(args []string) {
+	fs := flag.NewFlagSet("bundle export", flag.ExitOnError)
+	kubeconfig := fs.String("kubeconfig", "", "Path to kubeconfig file (default: $KUBECONFIG or ~/.kube/config)")
+	var configMaps []string
+	fs.Func("config-map", "NAMESPACE/NAME of a webhook configuration ConfigMap to include (repeatable)", func(s string) error {
+		configMaps = append(configMaps, s)
+		return nil
+	})
+	file := fs.String("file", "-", "File to write the bundle to, or \"-\" for stdout")
+	_ = fs.Parse(args)
+
+	opts := bundle.ExportOptions{}
+	for _, s := range configMaps {
+		namespace, name, ok := strings.Cut(s, "/")
+		if !ok || namespace == "" || name == "" {
+			fmt.Fprintf(os.Stderr, "Error: --config-map must be NAMESPACE/NAME, got %q\n", s)
+			os.Exit(1)
+		}
+		opts.ConfigMaps = append(opts.ConfigMaps, types.NamespacedName{Namespace: namespace, Name: name})
+	}
+
+	config, k8sClient := buildClient(*kubeconfig)
+	opts.Source = config.Host
+	b, err := bundle.Export(context.Background(), k8sClient, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error exporting bundle: %v\n", err)
+		os.Exit(1)
+	}
+
+	out := os.Stdout
+	if *file != "-" {
+		f, err := os.Create(*file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer func() { _ = f.Close() }()
+		out = f
+	}
+	if err := b.Write(out); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing bundle: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "exported %d objects\n", len(b.Objects))
+}
+
+// runBundleImport applies a bundle to the cluster.
+func runBundleImport(args []string) {
+	fs := flag.NewFlagSet("bundle import", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: kausality-cli bundle import [FILE] [flags]")
+		fmt.Fprintln(fs.Output(), "Reads the bundle from FILE, or stdin if none or \"-\" is given.")
+		fs.PrintDefaults()
+	}
+	kubeconfig := fs.String("kubeconfig", "", "Path to kubeconfig file (default: $KUBECONFIG or ~/.kube/config)")
+	prune := fs.Bool("prune", false, "Delete Kausality policies of the cluster that are not in the bundle")
+	dryRun := fs.Bool("dry-run", false, "Report changes without applying them")
+	diff := fs.Bool("diff", false, "Print a diff of each created or changed object")
+	format := fs.String("output", output.Text, "Output format: text, json, or yaml")
+	_ = fs.Parse(args)
+	validateOutput(*format)
+	if fs.NArg() > 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	in := os.Stdin
+	if file := fs.Arg(0); file != "" && file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer func() { _ = f.Close() }()
+		in = f
+	}
+	b, err := bundle.Read(in)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading bundle: %v\n", err)
+		os.Exit(1)
+	}
+
+	_, k8sClient := buildClient(*kubeconfig)
+	result, err := bundle.Import(context.Background(), k8sClient, b, bundle.ImportOptions{
+		Prune:  *prune,
+		DryRun: *dryRun,
+	})
+	writeInstallResult(*format, result, *diff)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error importing bundle: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// writeInstallResult writes the changes made before any error. Diffs are
+// part of JSON and YAML output regardless of diff.
+func writeInstallResult(format string, result *install.Result, diff bool) {
+	if result == nil {
+		return
+	}
+	if format != output.Text {
+		writeOutput(format, result)
+		return
+	}
+	if err := result.WriteText(os.Stdout, diff); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// validateOutput exits if format is not text, json, or yaml.
+func validateOutput(format string) {
+	if err := output.Validate(format, output.Text, output.JSON, output.YAML); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// writeOutput writes a document in a structured format, exiting on failure.
+func writeOutput(format string, doc interface{}) {
+	if err := output.Write(os.Stdout, format, doc); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// buildContextClient creates a controller-runtime client for a context of
+// a kubeconfig.
+func buildContextClient(kubeconfig, kubeContext string) client.Client {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = kubeconfig
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{CurrentContext: kubeContext}).ClientConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error building kubeconfig of context %s: %v\n", kubeContext, err)
+		os.Exit(1)
+	}
+
+	k8sClient, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating client: %v\n", err)
+		os.Exit(1)
+	}
+	return k8sClient
+}
+
+// buildClient creates a REST config and controller-runtime client from a kubeconfig path.
+func buildClient(kubeconfig string) (*rest.Config, client.Client) {
+	if kubeconfig == "" {
+		kubeconfig = os.Getenv("KUBECONFIG")
+		if kubeconfig == "" {
+			home, _ := os.UserHomeDir()
+			kubeconfig = home + "/.kube/config"
+		}
+	}
+
+	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error building kubeconfig: %v\n", err)
+		os.Exit(1)
+	}
+
+	k8sClient, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating client: %v\n", err)
 		os.Exit(1)
 	}
+	return config, k8sClient
 }
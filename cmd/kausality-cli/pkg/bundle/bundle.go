@@ -0,0 +1,286 @@
+// Package bundle exports the drift detection policy of a cluster as one
+// versioned YAML document and imports it into another cluster, so that
+// policy is promoted from staging to production as a unit.
+//
+// A bundle holds the Kausality policies, which carry the resource rules and
+// their exemptions (excluded namespaces, drift exclusions, mode overrides),
+// the namespaced KausalityPolicies, and optionally ConfigMaps with the
+// webhook configuration, which maps children to parents beyond owner
+// references (references, connectionSecrets). Objects are stripped of
+// cluster-specific metadata and status on export.
+//
+// Import server-side applies the objects with field manager FieldManager and
+// reports a diff per object, like install. Pruning deletes policies of the
+// target cluster that are not in the bundle; ConfigMaps are never pruned.
+package bundle
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+	"github.com/kausality-io/kausality/cmd/kausality-cli/pkg/install"
+)
+
+const (
+	// Version is the format version of bundles. Import rejects other versions.
+	Version = "kausality.io/bundle/v1"
+
+	// FieldManager is the field manager of imported objects.
+	FieldManager = "kausality-bundle"
+)
+
+// policyKinds are the kinds of policies in a bundle, in import order.
+var policyKinds = []schema.GroupVersionKind{
+	kausalityv1beta1.GroupVersion.WithKind("Kausality"),
+	kausalityv1alpha1.GroupVersion.WithKind("KausalityPolicy"),
+}
+
+// configMapKind is the kind of webhook configurations in a bundle.
+var configMapKind = schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+
+// removedMetadata are the metadata fields that are specific to the cluster
+// an object was exported from.
+var removedMetadata = []string{
+	"uid", "resourceVersion", "generation", "creationTimestamp",
+	"deletionTimestamp", "deletionGracePeriodSeconds", "managedFields", "selfLink", "ownerReferences",
+}
+
+// lastAppliedAnnotation is set by kubectl apply and not carried over.
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// Bundle is a versioned set of policy objects.
+type Bundle struct {
+	// Version is the format version, Version on export.
+	Version string `json:"version"`
+	// ExportedAt is when the bundle was exported.
+	ExportedAt metav1.Time `json:"exportedAt"`
+	// Source is the API server the bundle was exported from.
+	Source string `json:"source,omitempty"`
+	// Objects are the policy objects in import order.
+	Objects []unstructured.Unstructured `json:"objects"`
+}
+
+// ExportOptions configures the export.
+type ExportOptions struct {
+	// Source is recorded as the origin of the bundle, e.g. the API server URL.
+	Source string
+	// ConfigMaps are webhook configurations to include.
+	ConfigMaps []types.NamespacedName
+}
+
+// Export reads the policy objects of the cluster into a bundle. Policy kinds
+// not served by the cluster are skipped.
+func Export(ctx context.Context, c client.Client, opts ExportOptions) (*Bundle, error) {
+	b := &Bundle{Version: Version, ExportedAt: metav1.NewTime(time.Now().UTC()), Source: opts.Source}
+	for _, gvk := range policyKinds {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		err := c.List(ctx, list)
+		if meta.IsNoMatchError(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", gvk.Kind, err)
+		}
+		sort.Slice(list.Items, func(i, j int) bool {
+			return install.ObjectName(&list.Items[i]) < install.ObjectName(&list.Items[j])
+		})
+		for i := range list.Items {
+			obj := &list.Items[i]
+			if obj.GetDeletionTimestamp() != nil {
+				continue
+			}
+			obj.SetGroupVersionKind(gvk)
+			b.Objects = append(b.Objects, *sanitize(obj))
+		}
+	}
+
+	for _, key := range opts.ConfigMaps {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(configMapKind)
+		if err := c.Get(ctx, key, obj); err != nil {
+			return nil, fmt.Errorf("failed to get ConfigMap %s: %w", key, err)
+		}
+		b.Objects = append(b.Objects, *sanitize(obj))
+	}
+	return b, nil
+}
+
+// sanitize removes status and cluster-specific metadata from obj.
+func sanitize(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	obj = obj.DeepCopy()
+	delete(obj.Object, "status")
+	for _, field := range removedMetadata {
+		unstructured.RemoveNestedField(obj.Object, "metadata", field)
+	}
+	if annotations := obj.GetAnnotations(); annotations != nil {
+		delete(annotations, lastAppliedAnnotation)
+		if len(annotations) == 0 {
+			annotations = nil
+		}
+		obj.SetAnnotations(annotations)
+	}
+	return obj
+}
+
+// Write writes the bundle as YAML.
+func (b *Bundle) Write(w io.Writer) error {
+	data, err := yaml.Marshal(b)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// Read parses a bundle and validates its version and objects.
+func Read(r io.Reader) (*Bundle, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	b := &Bundle{}
+	if err := yaml.UnmarshalStrict(data, b); err != nil {
+		return nil, fmt.Errorf("invalid bundle: %w", err)
+	}
+	if b.Version != Version {
+		return nil, fmt.Errorf("unsupported bundle version %q, expected %q", b.Version, Version)
+	}
+	for i := range b.Objects {
+		obj := &b.Objects[i]
+		if !allowed(obj.GroupVersionKind()) {
+			return nil, fmt.Errorf("object %d: unsupported kind %s", i, obj.GroupVersionKind())
+		}
+		if obj.GetName() == "" {
+			return nil, fmt.Errorf("object %d: %s without name", i, obj.GetKind())
+		}
+	}
+	return b, nil
+}
+
+// allowed returns true for the kinds a bundle may hold.
+func allowed(gvk schema.GroupVersionKind) bool {
+	if gvk == configMapKind {
+		return true
+	}
+	for _, kind := range policyKinds {
+		if gvk.GroupKind() == kind.GroupKind() {
+			return true
+		}
+	}
+	return false
+}
+
+// ImportOptions configures the import.
+type ImportOptions struct {
+	// Prune deletes policies of the cluster that are not in the bundle.
+	Prune bool
+	// DryRun reports changes without applying them.
+	DryRun bool
+}
+
+// Import applies the objects of the bundle in order, and prunes policies
+// not in the bundle if configured.
+func Import(ctx context.Context, c client.Client, b *Bundle, opts ImportOptions) (*install.Result, error) {
+	result := &install.Result{DryRun: opts.DryRun}
+	for i := range b.Objects {
+		change, err := apply(ctx, c, &b.Objects[i], opts.DryRun)
+		if err != nil {
+			return result, fmt.Errorf("failed to apply %s: %w", install.ObjectName(&b.Objects[i]), err)
+		}
+		result.Changes = append(result.Changes, change)
+	}
+	if opts.Prune {
+		if err := prune(ctx, c, b, opts.DryRun, result); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// apply server-side applies obj and compares it with the live object.
+func apply(ctx context.Context, c client.Client, obj *unstructured.Unstructured, dryRun bool) (install.Change, error) {
+	change := install.Change{Object: install.ObjectName(obj)}
+
+	live := &unstructured.Unstructured{}
+	live.SetGroupVersionKind(obj.GroupVersionKind())
+	if err := c.Get(ctx, client.ObjectKeyFromObject(obj), live); apierrors.IsNotFound(err) {
+		live = nil
+	} else if err != nil {
+		return change, err
+	}
+
+	applied := obj.DeepCopy()
+	opts := []client.ApplyOption{client.FieldOwner(FieldManager), client.ForceOwnership}
+	if dryRun {
+		opts = append(opts, client.DryRunAll)
+	}
+	if err := c.Apply(ctx, client.ApplyConfigurationFromUnstructured(applied), opts...); err != nil {
+		return change, err
+	}
+
+	diff, err := install.Diff(obj, live, applied)
+	if err != nil {
+		return change, err
+	}
+	switch {
+	case live == nil:
+		change.Action = install.ActionCreated
+	case diff == "":
+		change.Action = install.ActionUnchanged
+	default:
+		change.Action = install.ActionConfigured
+	}
+	change.Diff = diff
+	return change, nil
+}
+
+// prune deletes the policies of the cluster that are not in the bundle.
+func prune(ctx context.Context, c client.Client, b *Bundle, dryRun bool, result *install.Result) error {
+	bundled := map[string]bool{}
+	for i := range b.Objects {
+		bundled[install.ObjectName(&b.Objects[i])] = true
+	}
+
+	var opts []client.DeleteOption
+	if dryRun {
+		opts = append(opts, client.DryRunAll)
+	}
+	for _, gvk := range policyKinds {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		err := c.List(ctx, list)
+		if meta.IsNoMatchError(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to list %s: %w", gvk.Kind, err)
+		}
+		for i := range list.Items {
+			obj := &list.Items[i]
+			obj.SetGroupVersionKind(gvk)
+			if bundled[install.ObjectName(obj)] {
+				continue
+			}
+			if err := c.Delete(ctx, obj, opts...); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("failed to prune %s: %w", install.ObjectName(obj), err)
+			}
+			result.Changes = append(result.Changes, install.Change{Object: install.ObjectName(obj), Action: install.ActionPruned})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package bundle
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+	"github.com/kausality-io/kausality/cmd/kausality-cli/pkg/install"
+)
+
+func newClient(objs ...client.Object) client.WithWatch {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(kausalityv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(kausalityv1beta1.AddToScheme(scheme))
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).WithInterceptorFuncs(interceptor.Funcs{
+		Apply: func(ctx context.Context, c client.WithWatch, obj runtime.ApplyConfiguration, opts ...client.ApplyOption) error {
+			// The fake client ignores dry runs of applies
+			if slices.Contains((&client.ApplyOptions{}).ApplyOptions(opts).DryRun, metav1.DryRunAll) {
+				return nil
+			}
+			return c.Apply(ctx, obj, opts...)
+		},
+	}).Build()
+}
+
+func TestExportImport(t *testing.T) {
+	ctx := context.Background()
+	staging := newClient(
+		&kausalityv1beta1.Kausality{
+			ObjectMeta: metav1.ObjectMeta{Name: "apps", UID: "uid-1", Annotations: map[string]string{lastAppliedAnnotation: "{}"}},
+			Spec: kausalityv1beta1.KausalitySpec{
+				Resources: []kausalityv1beta1.ResourceRule{{APIGroups: []string{"apps"}, Resources: []string{"deployments"}}},
+				Mode:      kausalityv1beta1.ModeEnforce,
+			},
+			Status: kausalityv1beta1.KausalityStatus{Conditions: []metav1.Condition{{Type: "Ready", Status: metav1.ConditionTrue, Reason: "Ready", LastTransitionTime: metav1.Now()}}},
+		},
+		&kausalityv1alpha1.KausalityPolicy{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "relaxed"},
+			Spec:       kausalityv1alpha1.KausalityPolicySpec{Mode: kausalityv1alpha1.ModeLog},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "kausality-system", Name: "kausality-webhook-config"},
+			Data:       map[string]string{"config.yaml": "references: []\n"},
+		},
+	)
+
+	b, err := Export(ctx, staging, ExportOptions{
+		Source:     "https://staging.example.com",
+		ConfigMaps: []types.NamespacedName{{Namespace: "kausality-system", Name: "kausality-webhook-config"}},
+	})
+	require.NoError(t, err)
+	var names []string
+	for i := range b.Objects {
+		names = append(names, install.ObjectName(&b.Objects[i]))
+	}
+	assert.Equal(t, []string{"Kausality apps", "KausalityPolicy team-a/relaxed", "ConfigMap kausality-system/kausality-webhook-config"}, names)
+	policy := b.Objects[0]
+	assert.Empty(t, policy.GetUID())
+	assert.Empty(t, policy.GetResourceVersion())
+	assert.Empty(t, policy.GetAnnotations())
+	assert.NotContains(t, policy.Object, "status")
+
+	var buf bytes.Buffer
+	require.NoError(t, b.Write(&buf))
+	read, err := Read(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, b.Objects, read.Objects)
+	assert.Equal(t, "https://staging.example.com", read.Source)
+
+	prod := newClient(&kausalityv1beta1.Kausality{
+		ObjectMeta: metav1.ObjectMeta{Name: "legacy"},
+		Spec:       kausalityv1beta1.KausalitySpec{Mode: kausalityv1beta1.ModeLog},
+	})
+
+	// A dry run reports the changes without applying them
+	result, err := Import(ctx, prod, read, ImportOptions{Prune: true, DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, []install.Change{
+		{Object: "Kausality apps", Action: install.ActionCreated},
+		{Object: "KausalityPolicy team-a/relaxed", Action: install.ActionCreated},
+		{Object: "ConfigMap kausality-system/kausality-webhook-config", Action: install.ActionCreated},
+		{Object: "Kausality legacy", Action: install.ActionPruned},
+	}, withoutDiffs(result.Changes))
+	assert.True(t, apierrors.IsNotFound(prod.Get(ctx, client.ObjectKey{Name: "apps"}, &kausalityv1beta1.Kausality{})))
+	require.NoError(t, prod.Get(ctx, client.ObjectKey{Name: "legacy"}, &kausalityv1beta1.Kausality{}))
+
+	result, err = Import(ctx, prod, read, ImportOptions{Prune: true})
+	require.NoError(t, err)
+	assert.Len(t, result.Changes, 4)
+	var imported kausalityv1beta1.Kausality
+	require.NoError(t, prod.Get(ctx, client.ObjectKey{Name: "apps"}, &imported))
+	assert.Equal(t, kausalityv1beta1.ModeEnforce, imported.Spec.Mode)
+	assert.True(t, apierrors.IsNotFound(prod.Get(ctx, client.ObjectKey{Name: "legacy"}, &kausalityv1beta1.Kausality{})))
+
+	// Importing again changes nothing
+	result, err = Import(ctx, prod, read, ImportOptions{Prune: true})
+	require.NoError(t, err)
+	for _, change := range result.Changes {
+		assert.Equal(t, install.ActionUnchanged, change.Action, change.Object)
+	}
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			data: "version: " + Version + "\nexportedAt: \"2026-01-02T03:04:05Z\"\nobjects:\n- apiVersion: kausality.io/v1beta1\n  kind: Kausality\n  metadata:\n    name: apps\n",
+		},
+		{
+			name:    "other version",
+			data:    "version: kausality.io/bundle/v2\nexportedAt: null\nobjects: []\n",
+			wantErr: "unsupported bundle version",
+		},
+		{
+			name:    "unsupported kind",
+			data:    "version: " + Version + "\nexportedAt: null\nobjects:\n- apiVersion: v1\n  kind: Secret\n  metadata:\n    name: creds\n",
+			wantErr: "unsupported kind",
+		},
+		{
+			name:    "without name",
+			data:    "version: " + Version + "\nexportedAt: null\nobjects:\n- apiVersion: kausality.io/v1beta1\n  kind: Kausality\n",
+			wantErr: "without name",
+		},
+		{
+			name:    "unknown field",
+			data:    "version: " + Version + "\nexportedAt: null\npolicies: []\n",
+			wantErr: "invalid bundle",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(strings.NewReader(tt.data))
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func withoutDiffs(changes []install.Change) []install.Change {
+	var result []install.Change
+	for _, c := range changes {
+		c.Diff = ""
+		result = append(result, c)
+	}
+	return result
+}
@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kausality-io/kausality/cmd/kausality-cli/pkg/output"
+	"github.com/kausality-io/kausality/pkg/controller"
+)
+
+// ActorQuery selects the changes of an actor.
+type ActorQuery struct {
+	// User is the actor's username.
+	User string
+	// Hash is the actor's updater hash, used if User is empty.
+	Hash string
+	// Since is the start of the time window.
+	Since time.Time
+}
+
+// hash returns the updater hash of the queried actor.
+func (q ActorQuery) hash() string {
+	if q.User != "" {
+		return controller.HashUsername(q.User)
+	}
+	return q.Hash
+}
+
+// ActorChanges returns the changes of the actor reported by the backend:
+// trace records of admitted mutations and drift reports.
+func (b *Backend) ActorChanges(ctx context.Context, q ActorQuery) (*output.ActorChanges, error) {
+	params := url.Values{"from": {q.Since.UTC().Format(time.RFC3339)}}
+	if q.User != "" {
+		params.Set("user", q.User)
+	} else {
+		params.Set("hash", q.Hash)
+	}
+	var changes output.ActorChanges
+	if err := b.get(ctx, "/api/v1/actors/changes?"+params.Encode(), &changes); err != nil {
+		return nil, err
+	}
+	return &changes, nil
+}
+
+// ActorChanges returns the objects of the given kinds that the actor
+// changed: objects whose kausality.io/updaters annotation contains the
+// actor's hash, and the changes reported by the backend, if not nil. The
+// annotation does not record when the actor changed an object, so objects
+// not modified by anyone since q.Since are left out. Kinds that cannot be
+// listed are skipped and reported in the returned error.
+func (c *Client) ActorChanges(ctx context.Context, kinds []schema.GroupVersionKind, q ActorQuery, backend *Backend) (*output.ActorChanges, error) {
+	hash := q.hash()
+	objects := make(map[string]*actorObject)
+	var errs []error
+	for _, gvk := range kinds {
+		list := &metav1.PartialObjectMetadataList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		var opts []client.ListOption
+		if c.namespace != "" {
+			opts = append(opts, client.InNamespace(c.namespace))
+		}
+		if err := c.k8s.List(ctx, list, opts...); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", gvk.Kind, err))
+			continue
+		}
+		for i := range list.Items {
+			obj := &list.Items[i]
+			updaters := controller.ParseHashes(obj.GetAnnotations()[controller.UpdatersAnnotation])
+			if !slices.Contains(updaters, hash) || modifiedBefore(obj, q.Since) {
+				continue
+			}
+			o := addActorObject(objects, obj.GetNamespace(), gvk.GroupVersion().String(), gvk.Kind, obj.GetName())
+			o.Sources = append(o.Sources, output.SourceUpdaters)
+		}
+	}
+
+	result := &output.ActorChanges{User: q.User, Hash: hash, Since: q.Since, Users: []string{}}
+	if backend != nil {
+		reported, err := backend.ActorChanges(ctx, q)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			result.Users = reported.Users
+			mergeActorChanges(objects, reported, c.namespace)
+		}
+	}
+	result.Namespaces = groupActorObjects(objects)
+	return result, errors.Join(errs...)
+}
+
+// modifiedBefore returns whether the last modification of obj recorded in
+// its managed fields is before t. Objects without times may be newer.
+func modifiedBefore(obj *metav1.PartialObjectMetadata, t time.Time) bool {
+	var last time.Time
+	for _, entry := range obj.GetManagedFields() {
+		if entry.Time != nil && entry.Time.After(last) {
+			last = entry.Time.Time
+		}
+	}
+	return !last.IsZero() && last.Before(t)
+}
+
+// actorObject is a changed object with its namespace and kind for grouping.
+type actorObject struct {
+	namespace  string
+	apiVersion string
+	kind       string
+	output.ChangedObject
+}
+
+func addActorObject(objects map[string]*actorObject, namespace, apiVersion, kind, name string) *actorObject {
+	key := kind + "/" + namespace + "/" + name
+	o, ok := objects[key]
+	if !ok {
+		o = &actorObject{namespace: namespace, apiVersion: apiVersion, kind: kind, ChangedObject: output.ChangedObject{Name: name}}
+		objects[key] = o
+	}
+	return o
+}
+
+// mergeActorChanges adds the objects reported by the backend in namespace,
+// or in all namespaces if empty.
+func mergeActorChanges(objects map[string]*actorObject, reported *output.ActorChanges, namespace string) {
+	for _, ns := range reported.Namespaces {
+		if namespace != "" && ns.Namespace != namespace {
+			continue
+		}
+		for _, kind := range ns.Kinds {
+			for _, r := range kind.Objects {
+				o := addActorObject(objects, ns.Namespace, kind.APIVersion, kind.Kind, r.Name)
+				o.Operations = r.Operations
+				o.Mutations = r.Mutations
+				o.DriftIDs = r.DriftIDs
+				o.FirstSeen, o.LastSeen = r.FirstSeen, r.LastSeen
+				for _, source := range r.Sources {
+					if !slices.Contains(o.Sources, source) {
+						o.Sources = append(o.Sources, source)
+					}
+				}
+			}
+		}
+	}
+}
+
+// groupActorObjects groups objects by namespace and kind, sorted by name.
+func groupActorObjects(objects map[string]*actorObject) []output.ActorNamespace {
+	sorted := make([]*actorObject, 0, len(objects))
+	for _, o := range objects {
+		sorted = append(sorted, o)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.namespace != b.namespace {
+			return a.namespace < b.namespace
+		}
+		if a.kind != b.kind {
+			return a.kind < b.kind
+		}
+		return a.Name < b.Name
+	})
+
+	namespaces := []output.ActorNamespace{}
+	for _, o := range sorted {
+		if n := len(namespaces); n == 0 || namespaces[n-1].Namespace != o.namespace {
+			namespaces = append(namespaces, output.ActorNamespace{Namespace: o.namespace})
+		}
+		ns := &namespaces[len(namespaces)-1]
+		if n := len(ns.Kinds); n == 0 || ns.Kinds[n-1].Kind != o.kind {
+			ns.Kinds = append(ns.Kinds, output.ActorKind{APIVersion: o.apiVersion, Kind: o.kind})
+		}
+		kind := &ns.Kinds[len(ns.Kinds)-1]
+		kind.Objects = append(kind.Objects, o.ChangedObject)
+	}
+	return namespaces
+}
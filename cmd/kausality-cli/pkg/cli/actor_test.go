@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kausality-io/kausality/cmd/kausality-cli/pkg/output"
+	"github.com/kausality-io/kausality/pkg/controller"
+)
+
+func TestActorChanges(t *testing.T) {
+	now := time.Now()
+	alice, bob := controller.HashUsername("alice"), controller.HashUsername("bob")
+	deployment := func(namespace, name, updaters string, modified time.Time) *appsv1.Deployment {
+		return &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+			Namespace:   namespace,
+			Name:        name,
+			Annotations: map[string]string{controller.UpdatersAnnotation: updaters},
+			ManagedFields: []metav1.ManagedFieldsEntry{{
+				Manager:    "kubectl",
+				Operation:  metav1.ManagedFieldsOperationUpdate,
+				APIVersion: "apps/v1",
+				Time:       &metav1.Time{Time: modified},
+				FieldsType: "FieldsV1",
+				FieldsV1:   &metav1.FieldsV1{Raw: []byte(`{"f:metadata":{"f:annotations":{}}}`)},
+			}},
+		}}
+	}
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	k8s := fake.NewClientBuilder().WithScheme(scheme).WithReturnManagedFields().WithObjects(
+		deployment("prod", "web", alice+","+bob, now.Add(-time.Hour)),
+		deployment("prod", "api", bob, now.Add(-time.Hour)),
+		deployment("dev", "web", alice, now.Add(-time.Minute)),
+		// Not modified by anyone in the window
+		deployment("prod", "old", alice, now.Add(-48*time.Hour)),
+	).Build()
+
+	var query string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		_ = json.NewEncoder(w).Encode(output.ActorChanges{
+			Users: []string{"alice"},
+			Namespaces: []output.ActorNamespace{
+				{Namespace: "prod", Kinds: []output.ActorKind{
+					{APIVersion: "apps/v1", Kind: "Deployment", Objects: []output.ChangedObject{
+						{Name: "web", Mutations: 2, DriftIDs: []string{"d1"}, Sources: []string{output.SourceTrace, output.SourceDrift}},
+					}},
+					{APIVersion: "v1", Kind: "ConfigMap", Objects: []output.ChangedObject{
+						{Name: "settings", Mutations: 1, Sources: []string{output.SourceTrace}},
+					}},
+				}},
+			},
+		})
+	}))
+	defer backend.Close()
+
+	q := ActorQuery{User: "alice", Since: now.Add(-24 * time.Hour)}
+	kinds := []schema.GroupVersionKind{appsv1.SchemeGroupVersion.WithKind("Deployment")}
+	changes, err := NewClient(k8s, "").ActorChanges(context.Background(), kinds, q, &Backend{URL: backend.URL})
+	require.NoError(t, err)
+	assert.Contains(t, query, "user=alice")
+	assert.Equal(t, alice, changes.Hash)
+	assert.Equal(t, []string{"alice"}, changes.Users)
+
+	require.Len(t, changes.Namespaces, 2)
+	assert.Equal(t, "dev", changes.Namespaces[0].Namespace)
+	prod := changes.Namespaces[1]
+	require.Len(t, prod.Kinds, 2)
+	assert.Equal(t, "ConfigMap", prod.Kinds[0].Kind)
+	assert.Equal(t, []output.ChangedObject{{
+		Name: "web", Mutations: 2, DriftIDs: []string{"d1"},
+		Sources: []string{output.SourceUpdaters, output.SourceTrace, output.SourceDrift},
+	}}, prod.Kinds[1].Objects)
+
+	// By hash, only in a namespace, without backend
+	q = ActorQuery{Hash: bob, Since: now.Add(-24 * time.Hour)}
+	changes, err = NewClient(k8s, "prod").ActorChanges(context.Background(), kinds, q, nil)
+	require.NoError(t, err)
+	require.Len(t, changes.Namespaces, 1)
+	require.Len(t, changes.Namespaces[0].Kinds, 1)
+	assert.Len(t, changes.Namespaces[0].Kinds[0].Objects, 2)
+
+	// A failing backend is reported with the cluster's changes
+	backend.Close()
+	changes, err = NewClient(k8s, "").ActorChanges(context.Background(), kinds, ActorQuery{User: "alice", Since: q.Since}, &Backend{URL: backend.URL})
+	assert.Error(t, err)
+	assert.Len(t, changes.Namespaces, 2)
+}
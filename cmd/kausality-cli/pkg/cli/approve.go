@@ -0,0 +1,188 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kausality-io/kausality/pkg/approval"
+)
+
+// BulkApproval selects the children of a parent to approve.
+type BulkApproval struct {
+	// Parent is the kind of the parent object.
+	Parent schema.GroupVersionKind
+	// ParentName is the name of the parent, in the client's namespace.
+	ParentName string
+	// Child is the kind of the children.
+	Child schema.GroupVersionKind
+	// ChildPattern is a glob (path.Match syntax) selecting children by name.
+	ChildPattern string
+	// Mode is the approval mode: once, generation, or always.
+	Mode string
+}
+
+// ApprovalPlan is the outcome of a bulk approval.
+type ApprovalPlan struct {
+	// Parent is the parent object as read before the change.
+	Parent *unstructured.Unstructured
+	// Children are the selected children.
+	Children []approval.ChildRef
+	// Approvals is the resulting kausality.io/approvals value.
+	Approvals []approval.Approval
+}
+
+// JSON returns the kausality.io/approvals annotation value of the plan.
+func (p *ApprovalPlan) JSON() (string, error) {
+	data, err := json.Marshal(p.Approvals)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal approvals: %w", err)
+	}
+	return string(data), nil
+}
+
+// ParseObjectArg splits a "resource/name" argument like "deploy/web".
+func ParseObjectArg(arg string) (resource, name string, err error) {
+	resource, name, ok := strings.Cut(arg, "/")
+	if !ok || resource == "" || name == "" {
+		return "", "", fmt.Errorf("invalid reference %q: expected RESOURCE/NAME", arg)
+	}
+	return resource, name, nil
+}
+
+// ResolveKind maps a resource or kind as typed by users ("deploy",
+// "deployments.apps", "ReplicaSet") to its kind.
+func ResolveKind(mapper meta.RESTMapper, resource string) (schema.GroupVersionKind, error) {
+	gr := schema.ParseGroupResource(strings.ToLower(resource))
+	gvk, err := mapper.KindFor(gr.WithVersion(""))
+	if err != nil {
+		return schema.GroupVersionKind{}, fmt.Errorf("unknown resource %q: %w", resource, err)
+	}
+	return gvk, nil
+}
+
+// PlanApproval computes the approvals for the selected children without
+// writing them, and verifies that the checker admits each child with them.
+func (c *Client) PlanApproval(ctx context.Context, req BulkApproval) (*ApprovalPlan, error) {
+	switch req.Mode {
+	case approval.ModeOnce, approval.ModeGeneration, approval.ModeAlways:
+	default:
+		return nil, fmt.Errorf("invalid mode %q: must be %q, %q, or %q", req.Mode, approval.ModeOnce, approval.ModeGeneration, approval.ModeAlways)
+	}
+	if _, err := path.Match(req.ChildPattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid children pattern %q: %w", req.ChildPattern, err)
+	}
+
+	parent := &unstructured.Unstructured{}
+	parent.SetGroupVersionKind(req.Parent)
+	if err := c.k8s.Get(ctx, client.ObjectKey{Namespace: c.namespace, Name: req.ParentName}, parent); err != nil {
+		return nil, fmt.Errorf("failed to get %s %s: %w", req.Parent.Kind, req.ParentName, err)
+	}
+
+	children, err := c.selectChildren(ctx, parent, req.Child, req.ChildPattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(children) == 0 {
+		return nil, fmt.Errorf("no %s controlled by %s %s matches %q", req.Child.Kind, req.Parent.Kind, req.ParentName, req.ChildPattern)
+	}
+
+	annotations := parent.GetAnnotations()
+	var existing []approval.Approval
+	if value := annotations[approval.ApprovalsAnnotation]; value != "" {
+		existing, err = approval.ParseApprovals(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse existing approvals: %w", err)
+		}
+	}
+
+	plan := &ApprovalPlan{
+		Parent:    parent,
+		Children:  children,
+		Approvals: approval.MergeApprovals(existing, children, req.Mode, parent.GetGeneration()),
+	}
+
+	// Verify the result the same way the webhook will read it
+	value, err := plan.JSON()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := approval.ParseApprovals(value); err != nil {
+		return nil, fmt.Errorf("invalid approvals: %w", err)
+	}
+	for _, child := range children {
+		result := approval.CheckFromAnnotations(value, annotations[approval.RejectionsAnnotation], child, parent.GetGeneration())
+		if !result.Approved {
+			return nil, fmt.Errorf("%s %s would not be approved: %s", child.Kind, child.Name, result.Reason)
+		}
+	}
+	return plan, nil
+}
+
+// ApplyApproval writes the approvals of PlanApproval to the parent. The plan
+// is recomputed from the latest parent on conflicts.
+func (c *Client) ApplyApproval(ctx context.Context, req BulkApproval) (*ApprovalPlan, error) {
+	var plan *ApprovalPlan
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var err error
+		plan, err = c.PlanApproval(ctx, req)
+		if err != nil {
+			return err
+		}
+		value, err := plan.JSON()
+		if err != nil {
+			return err
+		}
+
+		parent := plan.Parent.DeepCopy()
+		annotations := parent.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[approval.ApprovalsAnnotation] = value
+		parent.SetAnnotations(annotations)
+		return c.k8s.Update(ctx, parent)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// selectChildren returns the objects of the child kind controlled by the
+// parent whose names match the pattern, in list order.
+func (c *Client) selectChildren(ctx context.Context, parent *unstructured.Unstructured, childGVK schema.GroupVersionKind, pattern string) ([]approval.ChildRef, error) {
+	list := &unstructured.UnstructuredList{}
+	listGVK := childGVK
+	listGVK.Kind += "List"
+	list.SetGroupVersionKind(listGVK)
+	if err := c.k8s.List(ctx, list, client.InNamespace(parent.GetNamespace())); err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", childGVK.Kind, err)
+	}
+
+	var children []approval.ChildRef
+	for i := range list.Items {
+		item := &list.Items[i]
+		if owner := metav1.GetControllerOf(item); owner == nil || owner.UID != parent.GetUID() {
+			continue
+		}
+		if ok, _ := path.Match(pattern, item.GetName()); !ok {
+			continue
+		}
+		children = append(children, approval.ChildRef{
+			APIVersion: childGVK.GroupVersion().String(),
+			Kind:       childGVK.Kind,
+			Name:       item.GetName(),
+		})
+	}
+	return children, nil
+}
@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/kausality-io/kausality/pkg/approval"
+)
+
+var (
+	deploymentGVK = appsv1.SchemeGroupVersion.WithKind("Deployment")
+	replicaSetGVK = appsv1.SchemeGroupVersion.WithKind("ReplicaSet")
+)
+
+func newApproveTestClient(t *testing.T, funcs interceptor.Funcs, parentAnnotations map[string]string) client.Client {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+
+	parent := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "default", Name: "web", UID: "web-uid", Generation: 3, Annotations: parentAnnotations,
+	}}
+	owner := metav1.OwnerReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", UID: "web-uid", Controller: ptr.To(true)}
+	child := func(name string, owners ...metav1.OwnerReference) *appsv1.ReplicaSet {
+		return &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, OwnerReferences: owners}}
+	}
+
+	return fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(funcs).WithObjects(
+		parent,
+		child("web-abc", owner),
+		child("web-def", owner),
+		child("web-orphan"),
+		child("other-abc", metav1.OwnerReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "other", UID: "other-uid", Controller: ptr.To(true)}),
+	).Build()
+}
+
+func approvalsOf(t *testing.T, c client.Client) string {
+	var deploy appsv1.Deployment
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "web"}, &deploy))
+	return deploy.Annotations[approval.ApprovalsAnnotation]
+}
+
+func TestPlanApproval(t *testing.T) {
+	existing := `[{"apiVersion":"v1","kind":"ConfigMap","name":"config","mode":"always"}]`
+	c := NewClient(newApproveTestClient(t, interceptor.Funcs{}, map[string]string{approval.ApprovalsAnnotation: existing}), "default")
+
+	plan, err := c.PlanApproval(context.Background(), BulkApproval{
+		Parent: deploymentGVK, ParentName: "web", Child: replicaSetGVK, ChildPattern: "web-*", Mode: approval.ModeOnce,
+	})
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(plan.Children))
+	for _, child := range plan.Children {
+		names = append(names, child.Name)
+	}
+	assert.Equal(t, []string{"web-abc", "web-def"}, names, "only controlled children matching the pattern")
+
+	value, err := plan.JSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"apiVersion":"v1","kind":"ConfigMap","name":"config","mode":"always"},
+		{"apiVersion":"apps/v1","kind":"ReplicaSet","name":"web-abc","mode":"once","generation":3},
+		{"apiVersion":"apps/v1","kind":"ReplicaSet","name":"web-def","mode":"once","generation":3}
+	]`, value)
+	assert.Equal(t, existing, approvalsOf(t, c.k8s), "planning does not write")
+}
+
+func TestPlanApproval_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		req         BulkApproval
+		wantErr     string
+	}{
+		{
+			name:    "invalid mode",
+			req:     BulkApproval{ChildPattern: "*", Mode: "forever"},
+			wantErr: "invalid mode",
+		},
+		{
+			name:    "invalid pattern",
+			req:     BulkApproval{ChildPattern: "[", Mode: approval.ModeOnce},
+			wantErr: "invalid children pattern",
+		},
+		{
+			name:    "no matching children",
+			req:     BulkApproval{ChildPattern: "nope-*", Mode: approval.ModeOnce},
+			wantErr: "no ReplicaSet controlled by Deployment web matches",
+		},
+		{
+			name:        "corrupt existing approvals",
+			annotations: map[string]string{approval.ApprovalsAnnotation: `{not json`},
+			req:         BulkApproval{ChildPattern: "*", Mode: approval.ModeOnce},
+			wantErr:     "failed to parse existing approvals",
+		},
+		{
+			name:        "child is rejected",
+			annotations: map[string]string{approval.RejectionsAnnotation: `[{"apiVersion":"apps/v1","kind":"ReplicaSet","name":"web-abc","reason":"frozen"}]`},
+			req:         BulkApproval{ChildPattern: "*", Mode: approval.ModeOnce},
+			wantErr:     "ReplicaSet web-abc would not be approved",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClient(newApproveTestClient(t, interceptor.Funcs{}, tt.annotations), "default")
+			tt.req.Parent, tt.req.ParentName, tt.req.Child = deploymentGVK, "web", replicaSetGVK
+			_, err := c.PlanApproval(context.Background(), tt.req)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestApplyApproval_RetriesOnConflict(t *testing.T) {
+	// Fail the first update with a conflict, as if the controller raced us
+	var updates atomic.Int32
+	k8s := newApproveTestClient(t, interceptor.Funcs{
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			if updates.Add(1) == 1 {
+				return apierrors.NewConflict(schema.GroupResource{Group: "apps", Resource: "deployments"}, obj.GetName(), fmt.Errorf("conflict"))
+			}
+			return c.Update(ctx, obj, opts...)
+		},
+	}, nil)
+	c := NewClient(k8s, "default")
+
+	plan, err := c.ApplyApproval(context.Background(), BulkApproval{
+		Parent: deploymentGVK, ParentName: "web", Child: replicaSetGVK, ChildPattern: "*", Mode: approval.ModeGeneration,
+	})
+	require.NoError(t, err)
+	assert.Len(t, plan.Children, 2)
+	assert.Equal(t, int32(2), updates.Load())
+
+	approvals, err := approval.ParseApprovals(approvalsOf(t, k8s))
+	require.NoError(t, err)
+	require.Len(t, approvals, 2)
+	for _, a := range approvals {
+		assert.Equal(t, approval.ModeGeneration, a.Mode)
+		assert.Equal(t, int64(3), a.Generation)
+	}
+}
+
+func TestResolveKind(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{appsv1.SchemeGroupVersion})
+	mapper.Add(deploymentGVK, meta.RESTScopeNamespace)
+
+	for _, resource := range []string{"deployments", "deployment", "Deployment", "deployments.apps"} {
+		gvk, err := ResolveKind(mapper, resource)
+		require.NoError(t, err, resource)
+		assert.Equal(t, deploymentGVK, gvk, resource)
+	}
+
+	_, err := ResolveKind(mapper, "widgets")
+	assert.Error(t, err)
+}
+
+func TestParseObjectArg(t *testing.T) {
+	resource, name, err := ParseObjectArg("deploy/web")
+	require.NoError(t, err)
+	assert.Equal(t, "deploy", resource)
+	assert.Equal(t, "web", name)
+
+	for _, arg := range []string{"web", "/web", "deploy/"} {
+		_, _, err := ParseObjectArg(arg)
+		assert.Error(t, err, arg)
+	}
+}
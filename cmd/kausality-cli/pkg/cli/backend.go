@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+)
+
+// Backend queries a drift backend.
+type Backend struct {
+	// URL is the base URL of the backend.
+	URL string
+	// Token is sent as bearer token, if set.
+	Token      string
+	HTTPClient *http.Client
+}
+
+// OpenDrift is an unresolved drift reported to the backend.
+type OpenDrift struct {
+	Report *v1alpha1.DriftReport `json:"report"`
+	// Diff is a unified diff of the child's spec.
+	Diff string `json:"diff,omitempty"`
+	// Occurrences is the number of detections of the drift.
+	Occurrences int32 `json:"occurrences"`
+}
+
+// Drifts returns the unresolved drifts of the backend.
+func (b *Backend) Drifts(ctx context.Context) ([]OpenDrift, error) {
+	var list struct {
+		Items []OpenDrift `json:"items"`
+	}
+	if err := b.get(ctx, "/api/v1/drifts", &list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// get decodes the JSON response of a GET of path, relative to the base URL.
+func (b *Backend) get(ctx context.Context, path string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(b.URL, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	if b.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+b.Token)
+	}
+	httpClient := b.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to query backend: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to query backend: %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("invalid backend response: %w", err)
+	}
+	return nil
+}
@@ -2,8 +2,12 @@ package cli
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
 	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -42,47 +46,88 @@ func (c *Client) ListDrifts(ctx context.Context, gvk schema.GroupVersionKind) ([
 	}
 
 	var items []DriftItem
-	for _, obj := range list.Items {
-		annotations := obj.GetAnnotations()
-		if annotations == nil {
-			continue
-		}
+	for i := range list.Items {
+		items = append(items, driftItemsFromObject(list.Items[i].GroupVersionKind(), &list.Items[i])...)
+	}
 
-		// Check for pending approvals (indicates drift)
-		approvalsStr := annotations[approval.ApprovalsAnnotation]
-		if approvalsStr == "" {
-			continue
-		}
+	return items, nil
+}
 
-		approvals, err := approval.ParseApprovals(approvalsStr)
+// ListAllDrifts returns drift items across all given kinds, sorted by resource type.
+// Kinds that cannot be listed are skipped and reported in the returned error.
+func (c *Client) ListAllDrifts(ctx context.Context, kinds []schema.GroupVersionKind) ([]DriftItem, error) {
+	var items []DriftItem
+	var errs []error
+	for _, gvk := range kinds {
+		listGVK := gvk
+		listGVK.Kind += "List"
+		kindItems, err := c.ListDrifts(ctx, listGVK)
 		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", gvk.Kind, err))
 			continue
 		}
+		items = append(items, kindItems...)
+	}
 
-		// Each approval entry represents a drift that was approved
-		for _, appr := range approvals {
-			items = append(items, DriftItem{
-				ID:               generateItemID(obj, appr),
-				Phase:            "Detected",
-				ParentAPIVersion: obj.GetAPIVersion(),
-				ParentKind:       obj.GetKind(),
-				ParentNamespace:  obj.GetNamespace(),
-				ParentName:       obj.GetName(),
-				ChildAPIVersion:  appr.APIVersion,
-				ChildKind:        appr.Kind,
-				ChildNamespace:   obj.GetNamespace(),
-				ChildName:        appr.Name,
-				User:             "unknown",
-				Operation:        "UPDATE",
-				DetectedAt:       time.Now(),
-			})
-		}
+	sortDriftItems(items)
+	return items, errors.Join(errs...)
+}
+
+// driftItemsFromObject returns the drift items recorded in the annotations
+// of an object of the given kind.
+func driftItemsFromObject(gvk schema.GroupVersionKind, obj metav1.Object) []DriftItem {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		return nil
 	}
 
-	return items, nil
+	// Check for pending approvals (indicates drift)
+	approvalsStr := annotations[approval.ApprovalsAnnotation]
+	if approvalsStr == "" {
+		return nil
+	}
+
+	approvals, err := approval.ParseApprovals(approvalsStr)
+	if err != nil {
+		return nil
+	}
+
+	// Each approval entry represents a drift that was approved
+	var items []DriftItem
+	for _, appr := range approvals {
+		items = append(items, DriftItem{
+			ID:               generateItemID(obj, appr),
+			Phase:            "Detected",
+			ParentAPIVersion: gvk.GroupVersion().String(),
+			ParentKind:       gvk.Kind,
+			ParentNamespace:  obj.GetNamespace(),
+			ParentName:       obj.GetName(),
+			ChildAPIVersion:  appr.APIVersion,
+			ChildKind:        appr.Kind,
+			ChildNamespace:   obj.GetNamespace(),
+			ChildName:        appr.Name,
+			User:             "unknown",
+			Operation:        "UPDATE",
+			DetectedAt:       time.Now(),
+		})
+	}
+	return items
+}
+
+// sortDriftItems sorts items by resource type, namespace, and name.
+func sortDriftItems(items []DriftItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].ResourceType() != items[j].ResourceType() {
+			return items[i].ResourceType() < items[j].ResourceType()
+		}
+		if items[i].ParentNamespace != items[j].ParentNamespace {
+			return items[i].ParentNamespace < items[j].ParentNamespace
+		}
+		return items[i].ParentName < items[j].ParentName
+	})
 }
 
-func generateItemID(obj unstructured.Unstructured, appr approval.Approval) string {
+func generateItemID(obj metav1.Object, appr approval.Approval) string {
 	return obj.GetNamespace() + "/" + obj.GetName() + "/" + appr.Kind + "/" + appr.Name
 }
 
@@ -158,6 +203,31 @@ func (c *Client) Freeze(ctx context.Context, item DriftItem, reason string) erro
 	)
 }
 
+// Suppress mutes drift on a child in the client's namespace for the duration.
+func (c *Client) Suppress(ctx context.Context, gvk schema.GroupVersionKind, name string, duration time.Duration, user, message string) error {
+	return c.applier.ApplySuppression(ctx,
+		approval.ObjectRef{
+			APIVersion: gvk.GroupVersion().String(),
+			Kind:       gvk.Kind,
+			Namespace:  c.namespace,
+			Name:       name,
+		},
+		duration,
+		user,
+		message,
+	)
+}
+
+// ClearSuppression ends the suppression of drift on a child in the client's namespace.
+func (c *Client) ClearSuppression(ctx context.Context, gvk schema.GroupVersionKind, name string) error {
+	return c.applier.ClearSuppression(ctx, approval.ObjectRef{
+		APIVersion: gvk.GroupVersion().String(),
+		Kind:       gvk.Kind,
+		Namespace:  c.namespace,
+		Name:       name,
+	})
+}
+
 // Snooze applies a snooze duration on the parent
 func (c *Client) Snooze(ctx context.Context, item DriftItem, duration time.Duration, user, message string) error {
 	return c.applier.ApplySnooze(ctx,
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+	"github.com/kausality-io/kausality/pkg/policy"
+)
+
+// DiscoverTrackedKinds returns the kinds of all resources tracked by active
+// Kausality policies. Wildcard resource rules are expanded via discovery using
+// the server's preferred version of each resource.
+func DiscoverTrackedKinds(ctx context.Context, k8s client.Client, dc discovery.DiscoveryInterface) ([]schema.GroupVersionKind, error) {
+	var policies kausalityv1beta1.KausalityList
+	if err := k8s.List(ctx, &policies); err != nil {
+		return nil, fmt.Errorf("failed to list Kausality policies: %w", err)
+	}
+	if len(policies.Items) == 0 {
+		return nil, nil
+	}
+
+	store := policy.NewStore(k8s, logr.Discard())
+	store.Update(policies.Items)
+
+	resourceLists, err := dc.ServerPreferredResources()
+	if err != nil && len(resourceLists) == 0 {
+		// Discovery can return partial results with errors
+		return nil, fmt.Errorf("discovery failed: %w", err)
+	}
+
+	seen := make(map[schema.GroupVersionKind]bool)
+	var kinds []schema.GroupVersionKind
+	for _, resourceList := range resourceLists {
+		gv, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, r := range resourceList.APIResources {
+			// Skip subresources and resources we cannot list
+			if strings.Contains(r.Name, "/") || !slices.Contains(r.Verbs, "list") {
+				continue
+			}
+			if !store.TracksResource(gv.WithResource(r.Name)) {
+				continue
+			}
+			gvk := gv.WithKind(r.Kind)
+			if !seen[gvk] {
+				seen[gvk] = true
+				kinds = append(kinds, gvk)
+			}
+		}
+	}
+
+	sort.Slice(kinds, func(i, j int) bool {
+		if kinds[i].Group != kinds[j].Group {
+			return kinds[i].Group < kinds[j].Group
+		}
+		return kinds[i].Kind < kinds[j].Kind
+	})
+	return kinds, nil
+}
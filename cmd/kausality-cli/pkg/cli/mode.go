@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	"github.com/kausality-io/kausality/cmd/kausality-cli/pkg/output"
+	"github.com/kausality-io/kausality/pkg/policy"
+)
+
+// EffectiveMode resolves the drift detection mode of an object the way the
+// webhook does, and reports where the mode comes from.
+func (c *Client) EffectiveMode(ctx context.Context, gvk schema.GroupVersionKind, namespace, name string) (*output.EffectiveMode, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	if err := c.k8s.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, obj); err != nil {
+		return nil, fmt.Errorf("failed to get %s %s: %w", gvk.Kind, name, err)
+	}
+	mapping, err := c.k8s.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to map %s to a resource: %w", gvk.Kind, err)
+	}
+
+	// Cluster-scoped Crossplane objects inherit the namespace of their claim
+	policyNamespace := policy.EffectiveNamespace(obj.GetNamespace(), obj.GetLabels())
+	var nsLabels, nsAnnotations map[string]string
+	if policyNamespace != "" {
+		ns := &unstructured.Unstructured{}
+		ns.SetAPIVersion("v1")
+		ns.SetKind("Namespace")
+		if err := c.k8s.Get(ctx, client.ObjectKey{Name: policyNamespace}, ns); err != nil && !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to get namespace %q: %w", policyNamespace, err)
+		}
+		nsLabels, nsAnnotations = ns.GetLabels(), ns.GetAnnotations()
+	}
+
+	store := policy.NewStore(c.k8s, logr.Discard())
+	if err := store.Refresh(ctx); err != nil {
+		return nil, fmt.Errorf("failed to list Kausality policies: %w", err)
+	}
+	resourceCtx := policy.ResourceContext{
+		GVR:             mapping.Resource,
+		Namespace:       policyNamespace,
+		Name:            obj.GetName(),
+		NamespaceLabels: nsLabels,
+		ObjectLabels:    obj.GetLabels(),
+	}
+
+	result := &output.EffectiveMode{
+		Object:         objectReference(obj),
+		Mode:           store.ResolveMode(resourceCtx, obj.GetAnnotations(), nsAnnotations),
+		DriftExclusion: store.DriftExclusion(resourceCtx, obj.GetAnnotations()),
+		Tracked:        store.IsTracked(resourceCtx),
+	}
+	if p := store.MatchingPolicy(resourceCtx); p != nil {
+		result.Policy = p.Name
+	}
+	if p := store.TighteningPolicy(resourceCtx); p != nil {
+		result.NamespacePolicy = p.Name
+	}
+	switch {
+	case isMode(obj.GetAnnotations()[policy.ModeAnnotation]):
+		result.Source = output.SourceObjectAnnotation
+	case isMode(nsAnnotations[policy.ModeAnnotation]):
+		result.Source = output.SourceNamespaceAnnotation
+	case result.NamespacePolicy != "":
+		result.Source = output.SourceNamespacePolicy
+	case result.Policy != "":
+		result.Source = output.SourcePolicy
+	default:
+		result.Source = output.SourceDefault
+	}
+	return result, nil
+}
+
+// isMode returns true if an annotation value is a mode the webhook honors.
+func isMode(value string) bool {
+	return value == string(kausalityv1alpha1.ModeLog) || value == string(kausalityv1alpha1.ModeEnforce)
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+	"github.com/kausality-io/kausality/cmd/kausality-cli/pkg/output"
+	"github.com/kausality-io/kausality/pkg/policy"
+)
+
+func TestEffectiveMode(t *testing.T) {
+	logApps := &kausalityv1beta1.Kausality{
+		ObjectMeta: metav1.ObjectMeta{Name: "apps"},
+		Spec: kausalityv1beta1.KausalitySpec{
+			Resources: []kausalityv1beta1.ResourceRule{{APIGroups: []string{"apps"}, Resources: []string{"deployments"}}},
+			Mode:      kausalityv1beta1.ModeLog,
+		},
+	}
+	tenantEnforce := &kausalityv1alpha1.KausalityPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "team"},
+		Spec:       kausalityv1alpha1.KausalityPolicySpec{Mode: kausalityv1alpha1.ModeEnforce},
+	}
+	enforceApps := &kausalityv1beta1.Kausality{
+		ObjectMeta: metav1.ObjectMeta{Name: "apps"},
+		Spec: kausalityv1beta1.KausalitySpec{
+			Resources: []kausalityv1beta1.ResourceRule{{APIGroups: []string{"apps"}, Resources: []string{"deployments"}}},
+			Mode:      kausalityv1beta1.ModeEnforce,
+		},
+	}
+
+	tests := []struct {
+		name                 string
+		policies             []client.Object
+		objectAnnotations    map[string]string
+		namespaceAnnotations map[string]string
+		want                 output.EffectiveMode
+	}{
+		{
+			name: "default",
+			want: output.EffectiveMode{Mode: kausalityv1alpha1.ModeLog, Source: output.SourceDefault},
+		},
+		{
+			name:     "policy",
+			policies: []client.Object{enforceApps},
+			want:     output.EffectiveMode{Mode: kausalityv1alpha1.ModeEnforce, Source: output.SourcePolicy, Policy: "apps", Tracked: true},
+		},
+		{
+			name:     "namespace policy",
+			policies: []client.Object{logApps, tenantEnforce},
+			want: output.EffectiveMode{
+				Mode: kausalityv1alpha1.ModeEnforce, Source: output.SourceNamespacePolicy, Policy: "apps", NamespacePolicy: "team", Tracked: true,
+			},
+		},
+		{
+			name:                 "namespace annotation",
+			policies:             []client.Object{enforceApps},
+			namespaceAnnotations: map[string]string{policy.ModeAnnotation: "log"},
+			want:                 output.EffectiveMode{Mode: kausalityv1alpha1.ModeLog, Source: output.SourceNamespaceAnnotation, Policy: "apps", Tracked: true},
+		},
+		{
+			name:                 "object annotation",
+			objectAnnotations:    map[string]string{policy.ModeAnnotation: "enforce"},
+			namespaceAnnotations: map[string]string{policy.ModeAnnotation: "log"},
+			want:                 output.EffectiveMode{Mode: kausalityv1alpha1.ModeEnforce, Source: output.SourceObjectAnnotation},
+		},
+		{
+			name:              "invalid annotation",
+			policies:          []client.Object{enforceApps},
+			objectAnnotations: map[string]string{policy.ModeAnnotation: "strict"},
+			want:              output.EffectiveMode{Mode: kausalityv1alpha1.ModeEnforce, Source: output.SourcePolicy, Policy: "apps", Tracked: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			require.NoError(t, clientgoscheme.AddToScheme(scheme))
+			require.NoError(t, kausalityv1alpha1.AddToScheme(scheme))
+			require.NoError(t, kausalityv1beta1.AddToScheme(scheme))
+			mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{appsv1.SchemeGroupVersion})
+			mapper.Add(deploymentGVK, meta.RESTScopeNamespace)
+
+			k8s := fake.NewClientBuilder().WithScheme(scheme).WithRESTMapper(mapper).WithObjects(
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default", Annotations: tt.namespaceAnnotations}},
+				&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web", Annotations: tt.objectAnnotations}},
+			).WithObjects(tt.policies...).Build()
+
+			got, err := NewClient(k8s, "default").EffectiveMode(context.Background(), deploymentGVK, "default", "web")
+			require.NoError(t, err)
+			tt.want.Object = output.ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "web"}
+			assert.Equal(t, tt.want, *got)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package cli
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -31,6 +32,8 @@ type KeyMap struct {
 	Ignore      key.Binding
 	Freeze      key.Binding
 	Snooze      key.Binding
+	Namespace   key.Binding
+	Pause       key.Binding
 	Refresh     key.Binding
 	Quit        key.Binding
 }
@@ -74,6 +77,14 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("s"),
 			key.WithHelp("s", "snooze 1h"),
 		),
+		Namespace: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", "cycle namespace"),
+		),
+		Pause: key.NewBinding(
+			key.WithKeys("p"),
+			key.WithHelp("p", "pause/resume"),
+		),
 		Refresh: key.NewBinding(
 			key.WithKeys("r"),
 			key.WithHelp("r", "refresh"),
@@ -87,7 +98,7 @@ func DefaultKeyMap() KeyMap {
 
 // ShortHelp returns keybindings for short help
 func (k KeyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.Up, k.Down, k.Enter, k.ApproveOnce, k.Snooze, k.Quit}
+	return []key.Binding{k.Up, k.Down, k.Enter, k.ApproveOnce, k.Snooze, k.Pause, k.Quit}
 }
 
 // FullHelp returns keybindings for extended help
@@ -95,40 +106,50 @@ func (k KeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{k.Up, k.Down, k.Enter, k.Escape},
 		{k.ApproveOnce, k.ApproveGen, k.Ignore},
-		{k.Freeze, k.Snooze, k.Refresh, k.Quit},
+		{k.Freeze, k.Snooze, k.Namespace, k.Pause, k.Refresh, k.Quit},
 	}
 }
 
 // Model is the bubbletea model for the CLI
 type Model struct {
-	client *Client
-	items  []DriftItem
-	cursor int
-	view   viewState
-	keys   KeyMap
-	help   help.Model
-	width  int
-	height int
-	status string
-	err    error
+	client   *Client
+	watcher  *Watcher
+	items    []DriftItem
+	nsFilter string
+	cursor   int
+	view     viewState
+	keys     KeyMap
+	help     help.Model
+	width    int
+	height   int
+	status   string
+	err      error
+
+	// paused stops applying watch updates; pending counts updates received while paused
+	paused    bool
+	pending   int
+	updatedAt time.Time
+	now       time.Time
 }
 
-// NewModel creates a new CLI model
-func NewModel(client *Client) Model {
+// NewModel creates a new CLI model that streams drifts from the watcher.
+func NewModel(client *Client, watcher *Watcher) Model {
 	return Model{
-		client: client,
-		items:  []DriftItem{},
-		cursor: 0,
-		view:   viewList,
-		keys:   DefaultKeyMap(),
-		help:   help.New(),
-		status: "Loading...",
+		client:  client,
+		watcher: watcher,
+		items:   []DriftItem{},
+		cursor:  0,
+		view:    viewList,
+		keys:    DefaultKeyMap(),
+		help:    help.New(),
+		status:  "Loading...",
+		now:     time.Now(),
 	}
 }
 
 // Init initializes the model
 func (m Model) Init() tea.Cmd {
-	return m.loadDrifts
+	return tea.Batch(m.loadDrifts, m.waitForUpdate, tick())
 }
 
 // Messages
@@ -136,6 +157,10 @@ type driftsLoadedMsg struct {
 	items []DriftItem
 }
 
+type watchUpdateMsg struct{}
+
+type tickMsg time.Time
+
 type actionDoneMsg struct {
 	action string
 	err    error
@@ -146,9 +171,20 @@ type errMsg struct {
 }
 
 func (m Model) loadDrifts() tea.Msg {
-	// This would need to be configured with the GVK to watch
-	// For now, return empty - the real implementation will use the client
-	return driftsLoadedMsg{items: m.items}
+	return driftsLoadedMsg{items: m.watcher.Items()}
+}
+
+// waitForUpdate blocks until the watcher signals a change.
+func (m Model) waitForUpdate() tea.Msg {
+	<-m.watcher.Updates()
+	return watchUpdateMsg{}
+}
+
+// tick refreshes the staleness indicator once per second.
+func tick() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
+		return tickMsg(t)
+	})
 }
 
 // Update handles messages
@@ -164,17 +200,28 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.handleKey(msg)
 
 	case driftsLoadedMsg:
-		m.items = msg.items
-		m.status = fmt.Sprintf("%d drift(s) found", len(m.items))
+		m.SetItems(msg.items)
 		return m, nil
 
+	case watchUpdateMsg:
+		if m.paused {
+			m.pending++
+			return m, m.waitForUpdate
+		}
+		return m, tea.Batch(m.loadDrifts, m.waitForUpdate)
+
+	case tickMsg:
+		m.now = time.Time(msg)
+		return m, tick()
+
 	case actionDoneMsg:
 		if msg.err != nil {
 			m.status = fmt.Sprintf("Error: %v", msg.err)
 		} else {
 			m.status = fmt.Sprintf("Action '%s' completed", msg.action)
 		}
-		return m, m.loadDrifts
+		// The watch delivers the resulting annotation changes
+		return m, nil
 
 	case errMsg:
 		m.err = msg.err
@@ -205,13 +252,13 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case key.Matches(msg, m.keys.Down):
-		if m.cursor < len(m.items)-1 {
+		if m.cursor < len(m.visibleItems())-1 {
 			m.cursor++
 		}
 		return m, nil
 
 	case key.Matches(msg, m.keys.Enter):
-		if len(m.items) > 0 {
+		if len(m.visibleItems()) > 0 {
 			m.view = viewDetail
 		}
 		return m, nil
@@ -221,37 +268,101 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case key.Matches(msg, m.keys.ApproveOnce):
-		if len(m.items) > 0 {
-			return m, m.approveOnce(m.items[m.cursor])
+		if item, ok := m.selected(); ok {
+			return m, m.approveOnce(item)
 		}
 
 	case key.Matches(msg, m.keys.ApproveGen):
-		if len(m.items) > 0 {
-			return m, m.approveGeneration(m.items[m.cursor])
+		if item, ok := m.selected(); ok {
+			return m, m.approveGeneration(item)
 		}
 
 	case key.Matches(msg, m.keys.Ignore):
-		if len(m.items) > 0 {
-			return m, m.ignore(m.items[m.cursor])
+		if item, ok := m.selected(); ok {
+			return m, m.ignore(item)
 		}
 
 	case key.Matches(msg, m.keys.Freeze):
-		if len(m.items) > 0 {
-			return m, m.freeze(m.items[m.cursor])
+		if item, ok := m.selected(); ok {
+			return m, m.freeze(item)
 		}
 
 	case key.Matches(msg, m.keys.Snooze):
-		if len(m.items) > 0 {
-			return m, m.snooze(m.items[m.cursor])
+		if item, ok := m.selected(); ok {
+			return m, m.snooze(item)
 		}
 
+	case key.Matches(msg, m.keys.Namespace):
+		m.nsFilter = m.nextNamespace()
+		m.cursor = 0
+		return m, nil
+
+	case key.Matches(msg, m.keys.Pause):
+		m.paused = !m.paused
+		if !m.paused && m.pending > 0 {
+			m.pending = 0
+			return m, m.loadDrifts
+		}
+		return m, nil
+
 	case key.Matches(msg, m.keys.Refresh):
+		m.pending = 0
 		return m, m.loadDrifts
 	}
 
 	return m, nil
 }
 
+// visibleItems returns the items matching the namespace filter.
+func (m Model) visibleItems() []DriftItem {
+	if m.nsFilter == "" {
+		return m.items
+	}
+	var visible []DriftItem
+	for _, item := range m.items {
+		if item.ParentNamespace == m.nsFilter {
+			visible = append(visible, item)
+		}
+	}
+	return visible
+}
+
+// selected returns the item under the cursor.
+func (m Model) selected() (DriftItem, bool) {
+	visible := m.visibleItems()
+	if m.cursor < 0 || m.cursor >= len(visible) {
+		return DriftItem{}, false
+	}
+	return visible[m.cursor], true
+}
+
+// nextNamespace returns the namespace after the current filter, cycling
+// through all namespaces with drifts and back to "" (all namespaces).
+func (m Model) nextNamespace() string {
+	var namespaces []string
+	seen := make(map[string]bool)
+	for _, item := range m.items {
+		if !seen[item.ParentNamespace] {
+			seen[item.ParentNamespace] = true
+			namespaces = append(namespaces, item.ParentNamespace)
+		}
+	}
+	sort.Strings(namespaces)
+
+	if m.nsFilter == "" {
+		if len(namespaces) == 0 {
+			return ""
+		}
+		return namespaces[0]
+	}
+	for i, ns := range namespaces {
+		if ns == m.nsFilter && i+1 < len(namespaces) {
+			return namespaces[i+1]
+		}
+	}
+	return ""
+}
+
 // Action commands
 func (m Model) approveOnce(item DriftItem) tea.Cmd {
 	return func() tea.Msg {
@@ -300,15 +411,27 @@ func (m Model) viewListPage() string {
 	var b strings.Builder
 
 	// Title
-	b.WriteString(titleStyle.Render("Kausality Drift Monitor"))
+	title := "Kausality Drift Monitor"
+	if m.nsFilter != "" {
+		title += "  [namespace: " + m.nsFilter + "]"
+	}
+	b.WriteString(titleStyle.Render(title))
 	b.WriteString("\n\n")
 
-	// Items
-	if len(m.items) == 0 {
+	// Items, grouped by resource type
+	visible := m.visibleItems()
+	if len(visible) == 0 {
 		b.WriteString(itemStyle.Render("No drifts detected"))
 		b.WriteString("\n")
 	} else {
-		for i, item := range m.items {
+		group := ""
+		for i, item := range visible {
+			if rt := item.ResourceType(); rt != group {
+				group = rt
+				b.WriteString(groupHeaderStyle.Render(fmt.Sprintf("%s (%d)", rt, countResourceType(visible, rt))))
+				b.WriteString("\n")
+			}
+
 			cursor := "  "
 			style := itemStyle
 			if i == m.cursor {
@@ -327,6 +450,8 @@ func (m Model) viewListPage() string {
 	// Status bar
 	b.WriteString("\n")
 	b.WriteString(statusBarStyle.Render(m.status))
+	b.WriteString(" ")
+	b.WriteString(m.liveIndicator())
 	b.WriteString("\n")
 
 	// Help
@@ -336,12 +461,11 @@ func (m Model) viewListPage() string {
 }
 
 func (m Model) viewDetailPage() string {
-	if len(m.items) == 0 || m.cursor >= len(m.items) {
+	item, ok := m.selected()
+	if !ok {
 		return "No item selected"
 	}
 
-	item := m.items[m.cursor]
-
 	var b strings.Builder
 
 	// Title
@@ -389,8 +513,45 @@ func (m Model) viewDetailPage() string {
 	return modalStyle.Render(b.String())
 }
 
+// liveIndicator renders whether the view is live, paused, or stale.
+func (m Model) liveIndicator() string {
+	if m.paused {
+		return pausedStyle.Render(fmt.Sprintf("PAUSED (%d update(s) pending)", m.pending))
+	}
+	status := m.watcher.Status()
+	if status.Err != nil {
+		return staleStyle.Render(fmt.Sprintf("STALE: %v", status.Err))
+	}
+	if m.updatedAt.IsZero() {
+		return liveStyle.Render("LIVE")
+	}
+	age := m.now.Sub(m.updatedAt).Truncate(time.Second)
+	if age < 0 {
+		age = 0
+	}
+	return liveStyle.Render(fmt.Sprintf("LIVE (updated %s ago)", age))
+}
+
 // SetItems sets the drift items (used for external updates)
 func (m *Model) SetItems(items []DriftItem) {
 	m.items = items
-	m.status = fmt.Sprintf("%d drift(s) found", len(items))
+	m.updatedAt = time.Now()
+	if m.cursor >= len(m.visibleItems()) {
+		m.cursor = max(len(m.visibleItems())-1, 0)
+	}
+	m.status = fmt.Sprintf("%d drift(s) found across %d resource type(s)", len(items), len(m.watcher.Kinds()))
+	if skipped := len(m.watcher.Skipped()); skipped > 0 {
+		m.status += fmt.Sprintf(", %d skipped", skipped)
+	}
+}
+
+// countResourceType returns the number of items of the given resource type.
+func countResourceType(items []DriftItem, resourceType string) int {
+	n := 0
+	for _, item := range items {
+		if item.ResourceType() == resourceType {
+			n++
+		}
+	}
+	return n
 }
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"github.com/kausality-io/kausality/cmd/kausality-cli/pkg/output"
+)
+
+// objectReference returns the output reference of an object.
+func objectReference(obj interface {
+	GetAPIVersion() string
+	GetKind() string
+	GetNamespace() string
+	GetName() string
+}) output.ObjectReference {
+	return output.ObjectReference{
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Namespace:  obj.GetNamespace(),
+		Name:       obj.GetName(),
+	}
+}
+
+// NewDriftList returns the output document of drift items.
+func NewDriftList(items []DriftItem) output.DriftList {
+	list := output.DriftList{Items: make([]output.Drift, 0, len(items))}
+	for _, item := range items {
+		list.Items = append(list.Items, output.Drift{
+			ID:    item.ID,
+			Phase: item.Phase,
+			Parent: output.ObjectReference{
+				APIVersion: item.ParentAPIVersion,
+				Kind:       item.ParentKind,
+				Namespace:  item.ParentNamespace,
+				Name:       item.ParentName,
+			},
+			Child: output.ObjectReference{
+				APIVersion: item.ChildAPIVersion,
+				Kind:       item.ChildKind,
+				Namespace:  item.ChildNamespace,
+				Name:       item.ChildName,
+			},
+		})
+	}
+	return list
+}
+
+// Output returns the output document of the plan of a bulk approval.
+func (p *ApprovalPlan) Output(mode string, applied bool) output.Approval {
+	children := make([]string, 0, len(p.Children))
+	for _, child := range p.Children {
+		children = append(children, child.Name)
+	}
+	return output.Approval{
+		Parent:    objectReference(p.Parent),
+		Mode:      mode,
+		Children:  children,
+		Approvals: p.Approvals,
+		Applied:   applied,
+	}
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/approval"
+)
+
+// ListApprovalRequests returns the ApprovalRequests of the client's
+// namespace, or all namespaces if empty, oldest first. Only pending requests
+// are returned, unless all is set.
+func (c *Client) ListApprovalRequests(ctx context.Context, all bool) ([]kausalityv1alpha1.ApprovalRequest, error) {
+	list := &kausalityv1alpha1.ApprovalRequestList{}
+	var opts []client.ListOption
+	if c.namespace != "" {
+		opts = append(opts, client.InNamespace(c.namespace))
+	}
+	if err := c.k8s.List(ctx, list, opts...); err != nil {
+		return nil, fmt.Errorf("failed to list approval requests: %w", err)
+	}
+
+	var requests []kausalityv1alpha1.ApprovalRequest
+	for _, request := range list.Items {
+		if all || isPending(&request) {
+			requests = append(requests, request)
+		}
+	}
+	sort.SliceStable(requests, func(i, j int) bool {
+		return requests[i].CreationTimestamp.Before(&requests[j].CreationTimestamp)
+	})
+	return requests, nil
+}
+
+// GetApprovalRequest returns the ApprovalRequest name of the client's namespace.
+func (c *Client) GetApprovalRequest(ctx context.Context, name string) (*kausalityv1alpha1.ApprovalRequest, error) {
+	request := &kausalityv1alpha1.ApprovalRequest{}
+	if err := c.k8s.Get(ctx, client.ObjectKey{Namespace: c.namespace, Name: name}, request); err != nil {
+		return nil, fmt.Errorf("failed to get approval request %s: %w", name, err)
+	}
+	return request, nil
+}
+
+// DecideApprovalRequest records the decision on the ApprovalRequest name of
+// the client's namespace. The webhook then applies it to the parent. The
+// mode only applies to approvals. Deciding again the same way is a no-op,
+// deciding differently fails.
+func (c *Client) DecideApprovalRequest(ctx context.Context, name string, decision kausalityv1alpha1.ApprovalRequestDecision, mode, reason string) (*kausalityv1alpha1.ApprovalRequest, error) {
+	switch decision {
+	case kausalityv1alpha1.ApprovalRequestDecisionApprove:
+		switch mode {
+		case "", approval.ModeOnce, approval.ModeGeneration, approval.ModeAlways:
+		default:
+			return nil, fmt.Errorf("invalid mode %q: must be %q, %q, or %q", mode, approval.ModeOnce, approval.ModeGeneration, approval.ModeAlways)
+		}
+	case kausalityv1alpha1.ApprovalRequestDecisionReject:
+		mode = ""
+	default:
+		return nil, fmt.Errorf("invalid decision %q", decision)
+	}
+
+	var request *kausalityv1alpha1.ApprovalRequest
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var err error
+		if request, err = c.GetApprovalRequest(ctx, name); err != nil {
+			return err
+		}
+		switch request.Spec.Decision {
+		case decision:
+			return nil
+		case "":
+		default:
+			return fmt.Errorf("approval request %s is already decided: %s", name, request.Spec.Decision)
+		}
+		if !isPending(request) {
+			return fmt.Errorf("approval request %s is %s: %s", name, request.Status.Phase, request.Status.Message)
+		}
+		request.Spec.Decision = decision
+		request.Spec.Mode = mode
+		request.Spec.Reason = reason
+		return c.k8s.Update(ctx, request)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// isPending reports whether the request awaits its decision to be applied.
+func isPending(request *kausalityv1alpha1.ApprovalRequest) bool {
+	return request.Status.Phase == "" || request.Status.Phase == kausalityv1alpha1.ApprovalRequestPhasePending
+}
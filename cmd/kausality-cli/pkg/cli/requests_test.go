@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/approval"
+)
+
+func newRequestsTestClient(t *testing.T) client.Client {
+	scheme := runtime.NewScheme()
+	require.NoError(t, kausalityv1alpha1.AddToScheme(scheme))
+
+	created := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	request := func(name string, age time.Duration, phase kausalityv1alpha1.ApprovalRequestPhase) *kausalityv1alpha1.ApprovalRequest {
+		return &kausalityv1alpha1.ApprovalRequest{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, CreationTimestamp: metav1.NewTime(created.Add(-age))},
+			Spec: kausalityv1alpha1.ApprovalRequestSpec{
+				Parent: kausalityv1alpha1.ApprovalRequestObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "web"},
+				Child:  kausalityv1alpha1.ApprovalRequestObjectReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Namespace: "default", Name: "web-abc"},
+			},
+			Status: kausalityv1alpha1.ApprovalRequestStatus{Phase: phase},
+		}
+	}
+	return fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&kausalityv1alpha1.ApprovalRequest{}).WithObjects(
+		request("drift-new-1", time.Minute, ""),
+		request("drift-old-1", time.Hour, kausalityv1alpha1.ApprovalRequestPhasePending),
+		request("drift-done-1", 2*time.Hour, kausalityv1alpha1.ApprovalRequestPhaseApproved),
+	).Build()
+}
+
+func TestListApprovalRequests(t *testing.T) {
+	c := NewClient(newRequestsTestClient(t), "default")
+
+	requests, err := c.ListApprovalRequests(context.Background(), false)
+	require.NoError(t, err)
+	var names []string
+	for _, request := range requests {
+		names = append(names, request.Name)
+	}
+	assert.Equal(t, []string{"drift-old-1", "drift-new-1"}, names)
+
+	requests, err = c.ListApprovalRequests(context.Background(), true)
+	require.NoError(t, err)
+	assert.Len(t, requests, 3)
+	assert.Equal(t, "drift-done-1", requests[0].Name)
+}
+
+func TestDecideApprovalRequest(t *testing.T) {
+	ctx := context.Background()
+	c := NewClient(newRequestsTestClient(t), "default")
+
+	request, err := c.DecideApprovalRequest(ctx, "drift-new-1", kausalityv1alpha1.ApprovalRequestDecisionApprove, approval.ModeGeneration, "expected")
+	require.NoError(t, err)
+	assert.Equal(t, kausalityv1alpha1.ApprovalRequestDecisionApprove, request.Spec.Decision)
+	assert.Equal(t, approval.ModeGeneration, request.Spec.Mode)
+	assert.Equal(t, "expected", request.Spec.Reason)
+
+	// Deciding again the same way is a no-op, differently fails
+	_, err = c.DecideApprovalRequest(ctx, "drift-new-1", kausalityv1alpha1.ApprovalRequestDecisionApprove, "", "")
+	require.NoError(t, err)
+	_, err = c.DecideApprovalRequest(ctx, "drift-new-1", kausalityv1alpha1.ApprovalRequestDecisionReject, "", "")
+	assert.ErrorContains(t, err, "already decided: Approve")
+
+	// Rejections carry no mode
+	request, err = c.DecideApprovalRequest(ctx, "drift-old-1", kausalityv1alpha1.ApprovalRequestDecisionReject, approval.ModeAlways, "unexpected")
+	require.NoError(t, err)
+	assert.Empty(t, request.Spec.Mode)
+
+	_, err = c.DecideApprovalRequest(ctx, "drift-done-1", kausalityv1alpha1.ApprovalRequestDecisionApprove, "", "")
+	assert.ErrorContains(t, err, "is Approved")
+	_, err = c.DecideApprovalRequest(ctx, "drift-new-1", kausalityv1alpha1.ApprovalRequestDecisionApprove, "forever", "")
+	assert.ErrorContains(t, err, `invalid mode "forever"`)
+	_, err = c.DecideApprovalRequest(ctx, "missing", kausalityv1alpha1.ApprovalRequestDecisionApprove, "", "")
+	assert.Error(t, err)
+}
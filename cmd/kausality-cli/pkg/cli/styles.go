@@ -20,6 +20,11 @@ var (
 	itemStyle = lipgloss.NewStyle().
 			PaddingLeft(4)
 
+	groupHeaderStyle = lipgloss.NewStyle().
+				PaddingLeft(2).
+				Foreground(special).
+				Underline(true)
+
 	selectedItemStyle = lipgloss.NewStyle().
 				PaddingLeft(2).
 				Foreground(highlight).
@@ -60,4 +65,15 @@ var (
 	phaseResolvedStyle = lipgloss.NewStyle().
 				Foreground(special).
 				Bold(true)
+
+	liveStyle = lipgloss.NewStyle().
+			Foreground(special)
+
+	pausedStyle = lipgloss.NewStyle().
+			Foreground(warning).
+			Bold(true)
+
+	staleStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FF5F5F")).
+			Bold(true)
 )
@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kausality-io/kausality/cmd/kausality-cli/pkg/output"
+	"github.com/kausality-io/kausality/pkg/trace"
+)
+
+// ShowTrace returns the causal chain of an object.
+func (c *Client) ShowTrace(ctx context.Context, gvk schema.GroupVersionKind, namespace, name string) (*output.Trace, error) {
+	obj, t, err := c.getTrace(ctx, gvk, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	return &output.Trace{Object: objectReference(obj), Hops: t}, nil
+}
+
+// VerifyTrace verifies the hop signatures of the causal chain of an object,
+// and that its final hop is the object's: a trace copied from another object
+// is invalid.
+func (c *Client) VerifyTrace(ctx context.Context, gvk schema.GroupVersionKind, namespace, name string, verifier *trace.Verifier) (*output.TraceVerification, error) {
+	obj, t, err := c.getTrace(ctx, gvk, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	v := verifier.Verify(t, trace.Subject{
+		Kind:       gvk.Kind,
+		Namespace:  obj.GetNamespace(),
+		Name:       obj.GetName(),
+		UID:        obj.GetUID(),
+		Generation: obj.GetGeneration(),
+	})
+	result := &output.TraceVerification{Object: objectReference(obj), Valid: v.Valid, Mismatch: v.Mismatch}
+	for i, hop := range t {
+		result.Hops = append(result.Hops, output.VerifiedHop{Hop: hop, Verification: string(v.Hops[i])})
+	}
+	return result, nil
+}
+
+// ExportTrace renders the causal chain of an object as a diagram in the given
+// format ("mermaid" or "dot"). Objects of the same kind written by the same
+// parent reconcile are included as siblings.
+func (c *Client) ExportTrace(ctx context.Context, gvk schema.GroupVersionKind, namespace, name, format string) (string, error) {
+	obj, t, err := c.getTrace(ctx, gvk, namespace, name)
+	if err != nil {
+		return "", err
+	}
+
+	siblings, err := c.traceSiblings(ctx, obj, t)
+	if err != nil {
+		return "", err
+	}
+
+	return trace.RenderDiagram(format, t, siblings)
+}
+
+// getTrace returns an object and its trace. Objects without trace are an error.
+func (c *Client) getTrace(ctx context.Context, gvk schema.GroupVersionKind, namespace, name string) (*unstructured.Unstructured, trace.Trace, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	if err := c.k8s.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, obj); err != nil {
+		return nil, nil, fmt.Errorf("failed to get %s %s: %w", gvk.Kind, name, err)
+	}
+
+	t, err := trace.GetTraceFromObject(obj)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse trace: %w", err)
+	}
+	if len(t) == 0 {
+		return nil, nil, fmt.Errorf("%s %s has no %s annotation", gvk.Kind, name, trace.TraceAnnotation)
+	}
+	return obj, t, nil
+}
+
+// traceSiblings returns the last hop of each object with the same controller
+// owner whose trace passes through the same parent hop (same parent generation).
+func (c *Client) traceSiblings(ctx context.Context, obj *unstructured.Unstructured, t trace.Trace) ([]trace.Hop, error) {
+	if len(t) < 2 {
+		return nil, nil
+	}
+	owner := metav1.GetControllerOf(obj)
+	if owner == nil {
+		return nil, nil
+	}
+
+	list := &unstructured.UnstructuredList{}
+	listGVK := obj.GroupVersionKind()
+	listGVK.Kind += "List"
+	list.SetGroupVersionKind(listGVK)
+	if err := c.k8s.List(ctx, list, client.InNamespace(obj.GetNamespace())); err != nil {
+		return nil, fmt.Errorf("failed to list siblings: %w", err)
+	}
+
+	parentHop := t[len(t)-2]
+	var siblings []trace.Hop
+	for i := range list.Items {
+		item := &list.Items[i]
+		if item.GetName() == obj.GetName() {
+			continue
+		}
+		if ctrl := metav1.GetControllerOf(item); ctrl == nil || ctrl.UID != owner.UID {
+			continue
+		}
+		st, err := trace.GetTraceFromObject(item)
+		if err != nil || len(st) < 2 {
+			continue
+		}
+		p := st[len(st)-2]
+		if p.Kind == parentHop.Kind && p.Name == parentHop.Name && p.Generation == parentHop.Generation {
+			siblings = append(siblings, st[len(st)-1])
+		}
+	}
+	return siblings, nil
+}
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -40,3 +41,11 @@ func (d DriftItem) Description() string {
 	}
 	return fmt.Sprintf("%s  parent: %s/%s  by: %s", phase, d.ParentKind, d.ParentName, d.User)
 }
+
+// ResourceType returns the parent's resource type used for grouping, e.g. "Deployment.apps".
+func (d DriftItem) ResourceType() string {
+	if idx := strings.Index(d.ParentAPIVersion, "/"); idx >= 0 {
+		return d.ParentKind + "." + d.ParentAPIVersion[:idx]
+	}
+	return d.ParentKind
+}
@@ -0,0 +1,263 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/metadata/metadatainformer"
+	"k8s.io/client-go/tools/cache"
+)
+
+// DefaultSyncTimeout is how long Start waits for the initial list of a kind.
+const DefaultSyncTimeout = 30 * time.Second
+
+// WatchStatus describes the health of the watch streams.
+type WatchStatus struct {
+	// LastEvent is when the last add, update, or delete event was received.
+	LastEvent time.Time
+	// Err is the last watch error, cleared when the next event arrives.
+	Err error
+}
+
+// SkippedKind is a kind that could not be watched.
+type SkippedKind struct {
+	Kind schema.GroupVersionKind
+	Err  error
+}
+
+// Watcher streams drift items from metadata-only informers on the monitored
+// kinds. Drift items are derived from annotations, so object bodies are
+// never fetched or cached.
+type Watcher struct {
+	metadata    metadata.Interface
+	mapper      meta.RESTMapper
+	namespace   string
+	kinds       []schema.GroupVersionKind
+	syncTimeout time.Duration
+
+	mu        sync.Mutex
+	watched   []schema.GroupVersionKind
+	skipped   []SkippedKind
+	objects   map[string]watchedObject // gvk/namespace/name -> object
+	firstSeen map[string]time.Time     // drift item ID -> first time seen
+	status    WatchStatus
+
+	updates chan struct{}
+}
+
+// watchedObject is the cached metadata of an object of a watched kind.
+type watchedObject struct {
+	gvk schema.GroupVersionKind
+	obj metav1.Object
+}
+
+// NewWatcher creates a Watcher for the given kinds.
+// If namespace is empty, all namespaces are watched.
+func NewWatcher(client metadata.Interface, mapper meta.RESTMapper, namespace string, kinds []schema.GroupVersionKind) *Watcher {
+	return &Watcher{
+		metadata:    client,
+		mapper:      mapper,
+		namespace:   namespace,
+		kinds:       kinds,
+		syncTimeout: DefaultSyncTimeout,
+		objects:     make(map[string]watchedObject),
+		firstSeen:   make(map[string]time.Time),
+		updates:     make(chan struct{}, 1),
+	}
+}
+
+// Start starts informers for all kinds and waits for their caches to sync.
+// Kinds that cannot be mapped or do not sync within the sync timeout, e.g.
+// because the user may not list them, are skipped and reported by Skipped.
+// Start only fails if no kind can be watched.
+func (w *Watcher) Start(ctx context.Context) error {
+	for _, gvk := range w.kinds {
+		if err := w.startKind(ctx, gvk); err != nil {
+			w.mu.Lock()
+			w.skipped = append(w.skipped, SkippedKind{Kind: gvk, Err: err})
+			w.mu.Unlock()
+			continue
+		}
+		w.mu.Lock()
+		w.watched = append(w.watched, gvk)
+		w.mu.Unlock()
+	}
+
+	if len(w.Kinds()) == 0 && len(w.kinds) > 0 {
+		var errs []error
+		for _, s := range w.Skipped() {
+			errs = append(errs, fmt.Errorf("%s: %w", s.Kind.Kind, s.Err))
+		}
+		return fmt.Errorf("failed to watch any kind: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// startKind starts the informer for one kind and waits for it to sync.
+// On failure, the informer is stopped.
+func (w *Watcher) startKind(ctx context.Context, gvk schema.GroupVersionKind) error {
+	mapping, err := w.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return fmt.Errorf("failed to map to a resource: %w", err)
+	}
+
+	informer := metadatainformer.NewFilteredMetadataInformer(w.metadata, mapping.Resource, w.namespace, 0, cache.Indexers{}, nil).Informer()
+
+	// Errors before the initial sync explain a sync timeout; later errors
+	// mark the watch as stale.
+	var syncMu sync.Mutex
+	var syncErr error
+	synced := false
+	if err := informer.SetWatchErrorHandler(func(_ *cache.Reflector, err error) {
+		syncMu.Lock()
+		if !synced {
+			syncErr = err
+			syncMu.Unlock()
+			return
+		}
+		syncMu.Unlock()
+		w.setError(err)
+	}); err != nil {
+		return fmt.Errorf("failed to set watch error handler: %w", err)
+	}
+	if _, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { w.onUpsert(gvk, obj) },
+		UpdateFunc: func(_, obj interface{}) { w.onUpsert(gvk, obj) },
+		DeleteFunc: func(obj interface{}) { w.onDelete(gvk, obj) },
+	}); err != nil {
+		return fmt.Errorf("failed to add event handler: %w", err)
+	}
+
+	// The informer runs until ctx is done, or is stopped if it does not sync.
+	stopCh := make(chan struct{})
+	var stopOnce sync.Once
+	stop := func() { stopOnce.Do(func() { close(stopCh) }) }
+	context.AfterFunc(ctx, stop)
+	go informer.Run(stopCh)
+
+	syncCtx, syncCancel := context.WithTimeout(ctx, w.syncTimeout)
+	defer syncCancel()
+	if !cache.WaitForCacheSync(syncCtx.Done(), informer.HasSynced) {
+		stop()
+		syncMu.Lock()
+		defer syncMu.Unlock()
+		if syncErr != nil {
+			return fmt.Errorf("cache did not sync: %w", syncErr)
+		}
+		return fmt.Errorf("cache did not sync within %s", w.syncTimeout)
+	}
+	syncMu.Lock()
+	synced = true
+	syncMu.Unlock()
+	return nil
+}
+
+// Updates returns a channel that receives a signal whenever drift items may have changed.
+// Signals are coalesced: at most one is pending at a time.
+func (w *Watcher) Updates() <-chan struct{} {
+	return w.updates
+}
+
+// Items returns the current drift items, sorted by resource type.
+func (w *Watcher) Items() []DriftItem {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var items []DriftItem
+	seen := make(map[string]bool)
+	for _, o := range w.objects {
+		for _, item := range driftItemsFromObject(o.gvk, o.obj) {
+			if first, ok := w.firstSeen[item.ID]; ok {
+				item.DetectedAt = first
+			} else {
+				w.firstSeen[item.ID] = item.DetectedAt
+			}
+			seen[item.ID] = true
+			items = append(items, item)
+		}
+	}
+
+	// Forget resolved items so they are reported fresh if they reappear
+	for id := range w.firstSeen {
+		if !seen[id] {
+			delete(w.firstSeen, id)
+		}
+	}
+
+	sortDriftItems(items)
+	return items
+}
+
+// Kinds returns the kinds being watched. Before Start, it is empty.
+func (w *Watcher) Kinds() []schema.GroupVersionKind {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]schema.GroupVersionKind(nil), w.watched...)
+}
+
+// Skipped returns the kinds that could not be watched, with the reason.
+func (w *Watcher) Skipped() []SkippedKind {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]SkippedKind(nil), w.skipped...)
+}
+
+// Status returns the current watch status.
+func (w *Watcher) Status() WatchStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.status
+}
+
+func (w *Watcher) onUpsert(gvk schema.GroupVersionKind, obj interface{}) {
+	m, ok := obj.(metav1.Object)
+	if !ok {
+		return
+	}
+	w.mu.Lock()
+	w.objects[watchKey(gvk, m)] = watchedObject{gvk: gvk, obj: m}
+	w.status = WatchStatus{LastEvent: time.Now()}
+	w.mu.Unlock()
+	w.notify()
+}
+
+func (w *Watcher) onDelete(gvk schema.GroupVersionKind, obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	m, ok := obj.(metav1.Object)
+	if !ok {
+		return
+	}
+	w.mu.Lock()
+	delete(w.objects, watchKey(gvk, m))
+	w.status = WatchStatus{LastEvent: time.Now()}
+	w.mu.Unlock()
+	w.notify()
+}
+
+func (w *Watcher) setError(err error) {
+	w.mu.Lock()
+	w.status.Err = err
+	w.mu.Unlock()
+	w.notify()
+}
+
+// notify signals an update without blocking.
+func (w *Watcher) notify() {
+	select {
+	case w.updates <- struct{}{}:
+	default:
+	}
+}
+
+func watchKey(gvk schema.GroupVersionKind, obj metav1.Object) string {
+	return gvk.String() + "/" + obj.GetNamespace() + "/" + obj.GetName()
+}
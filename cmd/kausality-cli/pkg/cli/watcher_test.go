@@ -0,0 +1,188 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	metadatafake "k8s.io/client-go/metadata/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/kausality-io/kausality/pkg/approval"
+)
+
+var deploymentGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+func newPartialDeployment(name string, annotations map[string]string) *metav1.PartialObjectMetadata {
+	return &metav1.PartialObjectMetadata{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Annotations: annotations},
+	}
+}
+
+func newWatcherTestClient(objects ...runtime.Object) *metadatafake.FakeMetadataClient {
+	scheme := metadatafake.NewTestScheme()
+	for _, gvk := range []schema.GroupVersionKind{deploymentGVK, replicaSetGVK} {
+		scheme.AddKnownTypeWithName(gvk, &metav1.PartialObjectMetadata{})
+		scheme.AddKnownTypeWithName(gvk.GroupVersion().WithKind(gvk.Kind+"List"), &metav1.PartialObjectMetadataList{})
+	}
+	return metadatafake.NewSimpleMetadataClient(scheme, objects...)
+}
+
+func newWatcherTestMapper() meta.RESTMapper {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(deploymentGVK, meta.RESTScopeNamespace)
+	mapper.Add(replicaSetGVK, meta.RESTScopeNamespace)
+	return mapper
+}
+
+// waitForItems waits for the next update signal until the watcher reports n items.
+func waitForItems(t *testing.T, w *Watcher, n int) []DriftItem {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		if items := w.Items(); len(items) == n {
+			return items
+		}
+		select {
+		case <-w.Updates():
+		case <-timeout:
+			t.Fatalf("timed out waiting for %d drift item(s), have %d", n, len(w.Items()))
+		}
+	}
+}
+
+func TestWatcher_EventFlow(t *testing.T) {
+	client := newWatcherTestClient(newPartialDeployment("web", nil))
+	w := NewWatcher(client, newWatcherTestMapper(), "default", []schema.GroupVersionKind{deploymentGVK})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, w.Start(ctx))
+	assert.Equal(t, []schema.GroupVersionKind{deploymentGVK}, w.Kinds())
+	assert.Empty(t, w.Skipped())
+	assert.Empty(t, w.Items())
+
+	deployments := client.Resource(deploymentGVR).Namespace("default").(metadatafake.MetadataClient)
+
+	// Added: a new parent with an approval shows up
+	_, err := deployments.CreateFake(newPartialDeployment("api", map[string]string{
+		approval.ApprovalsAnnotation: `[{"apiVersion":"apps/v1","kind":"ReplicaSet","name":"api-abc","mode":"always"}]`,
+	}), metav1.CreateOptions{})
+	require.NoError(t, err)
+	items := waitForItems(t, w, 1)
+	assert.Equal(t, "Deployment", items[0].ParentKind)
+	assert.Equal(t, "apps/v1", items[0].ParentAPIVersion)
+	assert.Equal(t, "api", items[0].ParentName)
+	assert.Equal(t, "api-abc", items[0].ChildName)
+	firstSeen := items[0].DetectedAt
+
+	// Approved: another parent is updated with an approval
+	_, err = deployments.UpdateFake(newPartialDeployment("web", map[string]string{
+		approval.ApprovalsAnnotation: `[{"apiVersion":"apps/v1","kind":"ReplicaSet","name":"web-abc","mode":"always"}]`,
+	}), metav1.UpdateOptions{})
+	require.NoError(t, err)
+	items = waitForItems(t, w, 2)
+	assert.Equal(t, "api", items[0].ParentName)
+	assert.Equal(t, firstSeen, items[0].DetectedAt, "items keep the time they were first seen")
+	assert.Equal(t, "web", items[1].ParentName)
+
+	// Resolved: the approval is removed again
+	_, err = deployments.UpdateFake(newPartialDeployment("web", nil), metav1.UpdateOptions{})
+	require.NoError(t, err)
+	items = waitForItems(t, w, 1)
+	assert.Equal(t, "api", items[0].ParentName)
+
+	// Deleted
+	require.NoError(t, deployments.Delete(ctx, "api", metav1.DeleteOptions{}))
+	waitForItems(t, w, 0)
+
+	status := w.Status()
+	assert.NoError(t, status.Err)
+	assert.False(t, status.LastEvent.IsZero())
+}
+
+func TestWatcher_SkipsFailingKinds(t *testing.T) {
+	client := newWatcherTestClient()
+	client.PrependReactor("list", "replicasets", func(clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Group: "apps", Resource: "replicasets"}, "", errors.New("not allowed"))
+	})
+	unknownGVK := schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Unknown"}
+
+	w := NewWatcher(client, newWatcherTestMapper(), "", []schema.GroupVersionKind{unknownGVK, replicaSetGVK, deploymentGVK})
+	w.syncTimeout = 200 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, w.Start(ctx))
+
+	assert.Equal(t, []schema.GroupVersionKind{deploymentGVK}, w.Kinds())
+	skipped := w.Skipped()
+	require.Len(t, skipped, 2)
+	assert.Equal(t, unknownGVK, skipped[0].Kind)
+	assert.ErrorContains(t, skipped[0].Err, "failed to map")
+	assert.Equal(t, replicaSetGVK, skipped[1].Kind)
+	assert.True(t, apierrors.IsForbidden(skipped[1].Err), "got %v", skipped[1].Err)
+}
+
+func TestWatcher_FailsIfNoKindCanBeWatched(t *testing.T) {
+	client := newWatcherTestClient()
+	w := NewWatcher(client, newWatcherTestMapper(), "", []schema.GroupVersionKind{{Group: "example.com", Version: "v1", Kind: "Unknown"}})
+
+	err := w.Start(context.Background())
+	assert.ErrorContains(t, err, "failed to watch any kind")
+	assert.Empty(t, w.Kinds())
+}
+
+func TestModel_PauseResume(t *testing.T) {
+	w := NewWatcher(newWatcherTestClient(), newWatcherTestMapper(), "", nil)
+	w.onUpsert(deploymentGVK, newPartialDeployment("web", map[string]string{
+		approval.ApprovalsAnnotation: `[{"apiVersion":"apps/v1","kind":"ReplicaSet","name":"web-abc","mode":"always"}]`,
+	}))
+	var m tea.Model = NewModel(nil, w)
+	pause := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")}
+
+	m, _ = m.Update(pause)
+	assert.True(t, m.(Model).paused)
+
+	// Updates while paused are counted, not applied
+	m, _ = m.Update(watchUpdateMsg{})
+	m, _ = m.Update(watchUpdateMsg{})
+	assert.Equal(t, 2, m.(Model).pending)
+	assert.Empty(t, m.(Model).items)
+	assert.Contains(t, m.(Model).liveIndicator(), "PAUSED (2 update(s) pending)")
+
+	// Resuming reloads the pending changes
+	m, cmd := m.Update(pause)
+	assert.False(t, m.(Model).paused)
+	assert.Zero(t, m.(Model).pending)
+	require.NotNil(t, cmd)
+	m, _ = m.Update(cmd())
+	require.Len(t, m.(Model).items, 1)
+	assert.Equal(t, "web-abc", m.(Model).items[0].ChildName)
+}
+
+func TestModel_Staleness(t *testing.T) {
+	w := NewWatcher(newWatcherTestClient(), newWatcherTestMapper(), "", nil)
+	m := NewModel(nil, w)
+	m.SetItems(nil)
+	m.now = m.updatedAt.Add(3 * time.Second)
+	assert.Contains(t, m.liveIndicator(), "LIVE (updated 3s ago)")
+
+	w.setError(errors.New("connection refused"))
+	assert.Contains(t, m.liveIndicator(), "STALE: connection refused")
+
+	// The next event clears the error
+	w.onUpsert(deploymentGVK, newPartialDeployment("web", nil))
+	assert.Contains(t, m.liveIndicator(), "LIVE")
+}
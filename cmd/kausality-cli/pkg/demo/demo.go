@@ -0,0 +1,457 @@
+// Package demo runs a scripted drift scenario against a cluster running
+// Kausality and narrates it, as a reproducible demo and onboarding exercise:
+// a hand edit of a ReplicaSet makes the Deployment controller drift, the
+// drift is denied, approved, and resolved by the controller.
+package demo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+	"github.com/kausality-io/kausality/cmd/kausality-cli/pkg/cli"
+	"github.com/kausality-io/kausality/pkg/approval"
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/controller"
+	"github.com/kausality-io/kausality/pkg/drift"
+	"github.com/kausality-io/kausality/pkg/policy"
+	"github.com/kausality-io/kausality/pkg/trace"
+)
+
+// Defaults of Options.
+const (
+	DefaultNamespace = "kausality-demo"
+	DefaultImage     = "registry.k8s.io/pause:3.10"
+)
+
+// Name is the name of the demo's policy and Deployment.
+const Name = "kausality-demo"
+
+// editedReplicas is the scale of the hand edit of the ReplicaSet.
+const editedReplicas = 3
+
+// Options configures the demo.
+type Options struct {
+	// Namespace is created for the demo and deleted afterwards.
+	Namespace string
+
+	// Image of the sample Deployment's pods.
+	Image string
+
+	// Timeout bounds each wait for the cluster.
+	Timeout time.Duration
+
+	// DriftWait is how long the controller's reverts are watched being
+	// denied if neither a backend nor drift status reports the drift.
+	DriftWait time.Duration
+
+	// PollInterval is the interval of reading the cluster while waiting.
+	PollInterval time.Duration
+
+	// Backend is a drift backend receiving the webhook's drift reports.
+	// If nil, the reports are not shown.
+	Backend *cli.Backend
+
+	// Keep keeps the namespace and policy after the demo.
+	Keep bool
+
+	// Pause is called before each step, e.g. to wait for the presenter. If
+	// nil, steps run without pause.
+	Pause func() error
+}
+
+// Demo runs the scripted scenario.
+type Demo struct {
+	client client.Client
+	out    io.Writer
+	opts   Options
+
+	// replicaSet is the name of the Deployment's ReplicaSet.
+	replicaSet string
+	// driftID is the ID of the drift report, if a backend reported it.
+	driftID string
+	// created are the objects created by the demo, deleted on cleanup.
+	created []client.Object
+}
+
+// New creates a Demo narrating to out, with defaults applied to unset options.
+func New(c client.Client, out io.Writer, opts Options) *Demo {
+	if opts.Namespace == "" {
+		opts.Namespace = DefaultNamespace
+	}
+	if opts.Image == "" {
+		opts.Image = DefaultImage
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = 2 * time.Minute
+	}
+	if opts.DriftWait == 0 {
+		opts.DriftWait = 15 * time.Second
+	}
+	if opts.PollInterval == 0 {
+		opts.PollInterval = time.Second
+	}
+	return &Demo{client: c, out: out, opts: opts}
+}
+
+// step is a narrated part of the scenario.
+type step struct {
+	title string
+	run   func(ctx context.Context) error
+}
+
+// Run runs the scenario and cleans up, unless Keep is set, also if a step fails.
+func (d *Demo) Run(ctx context.Context) (err error) {
+	steps := []step{
+		{"Deploy a sample Deployment", d.deploy},
+		{"Edit its ReplicaSet by hand", d.edit},
+		{"Watch the controller drift", d.watchDrift},
+		{"Approve the drift", d.approve},
+	}
+	defer func() {
+		if d.opts.Keep && len(d.created) > 0 {
+			d.printf("\nKept namespace %q and policy %q; delete them with\n    kubectl delete namespace %s && kubectl delete kausality %s\n",
+				d.opts.Namespace, Name, d.opts.Namespace, Name)
+			return
+		}
+		err = errors.Join(err, d.cleanup(context.WithoutCancel(ctx)))
+	}()
+
+	for i, s := range steps {
+		if d.opts.Pause != nil {
+			if err := d.opts.Pause(); err != nil {
+				return err
+			}
+		}
+		d.printf("\n==> %d/%d %s\n", i+1, len(steps), s.title)
+		if err := s.run(ctx); err != nil {
+			return fmt.Errorf("%s: %w", strings.ToLower(s.title), err)
+		}
+	}
+	d.printf("\nDone: the drift was detected, blocked, approved and resolved.\n")
+	return nil
+}
+
+// deploy creates the namespace, an enforcing policy for it and the sample
+// Deployment, and waits until the webhook recorded the Deployment controller.
+func (d *Demo) deploy(ctx context.Context) error {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: d.opts.Namespace}}
+	if err := d.create(ctx, ns); err != nil {
+		return fmt.Errorf("failed to create namespace %q: %w", d.opts.Namespace, err)
+	}
+	d.say("Created namespace %s", d.opts.Namespace)
+
+	if err := d.create(ctx, d.policy()); err != nil {
+		return fmt.Errorf("failed to create policy: %w", err)
+	}
+	d.say("Created Kausality policy %s: Deployments and ReplicaSets in %s are in enforce mode", Name, d.opts.Namespace)
+	if err := d.poll(ctx, func(ctx context.Context) (bool, error) {
+		var p kausalityv1beta1.Kausality
+		if err := d.client.Get(ctx, client.ObjectKey{Name: Name}, &p); err != nil {
+			return false, err
+		}
+		return meta.IsStatusConditionTrue(p.Status.Conditions, policy.ConditionTypeReady), nil
+	}); err != nil {
+		return fmt.Errorf("policy %s not ready (is the kausality controller running?): %w", Name, err)
+	}
+
+	if err := d.client.Create(ctx, d.deployment()); err != nil {
+		return fmt.Errorf("failed to create Deployment: %w", err)
+	}
+	d.say("Created Deployment %s with 1 replica", Name)
+
+	var deploy appsv1.Deployment
+	if err := d.poll(ctx, func(ctx context.Context) (bool, error) {
+		if err := d.client.Get(ctx, d.key(Name), &deploy); err != nil {
+			return false, err
+		}
+		rs, err := d.currentReplicaSet(ctx, &deploy)
+		if err != nil || rs == nil {
+			return false, err
+		}
+		d.replicaSet = rs.Name
+		return deploy.Status.ObservedGeneration == deploy.Generation && deploy.Annotations[controller.ControllersAnnotation] != "", nil
+	}); err != nil {
+		return fmt.Errorf("deployment %s not rolled out with recorded controller (is the webhook running?): %w", Name, err)
+	}
+	d.say("The Deployment controller created ReplicaSet %s", d.replicaSet)
+	d.say("Kausality recorded it as the controller of the Deployment:\n        %s: %s",
+		controller.ControllersAnnotation, deploy.Annotations[controller.ControllersAnnotation])
+
+	var rs appsv1.ReplicaSet
+	if err := d.client.Get(ctx, d.key(d.replicaSet), &rs); err != nil {
+		return fmt.Errorf("failed to get ReplicaSet: %w", err)
+	}
+	if t, err := trace.Parse(rs.Annotations[trace.TraceAnnotation]); err == nil && len(t) > 0 {
+		d.say("The ReplicaSet's trace leads back to %s, who created the Deployment", t[0].User)
+	}
+	return nil
+}
+
+// edit scales the ReplicaSet by hand. Changes of other actors than the
+// controller are not drift.
+func (d *Demo) edit(ctx context.Context) error {
+	rs := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Namespace: d.opts.Namespace, Name: d.replicaSet}}
+	patch := client.RawPatch(types.MergePatchType, fmt.Appendf(nil, `{"spec":{"replicas":%d}}`, editedReplicas))
+	if err := d.client.Patch(ctx, rs, patch); err != nil {
+		return fmt.Errorf("failed to scale ReplicaSet %s: %w", d.replicaSet, err)
+	}
+	d.say("Scaled ReplicaSet %s to %d replicas, bypassing the Deployment", d.replicaSet, editedReplicas)
+	d.say("Kausality admitted it: you are not the controller, so this is not drift.")
+	if updaters := rs.Annotations[controller.UpdatersAnnotation]; updaters != "" {
+		d.say("It recorded you as an updater of the ReplicaSet:\n        %s: %s", controller.UpdatersAnnotation, updaters)
+	}
+	return nil
+}
+
+// watchDrift waits for the drift of the Deployment controller scaling the
+// ReplicaSet back although the Deployment did not change.
+func (d *Demo) watchDrift(ctx context.Context) error {
+	d.say("The Deployment controller scales the ReplicaSet back to 1 replica, although")
+	d.say("the Deployment's spec did not change: that is drift, denied in enforce mode.")
+
+	if d.opts.Backend != nil {
+		var report *cli.OpenDrift
+		if err := d.poll(ctx, func(ctx context.Context) (bool, error) {
+			var err error
+			report, err = d.openDrift(ctx)
+			return report != nil, err
+		}); err != nil {
+			return fmt.Errorf("no drift report of ReplicaSet %s at the backend: %w", d.replicaSet, err)
+		}
+		d.driftID = report.Report.Spec.ID
+		d.say("The webhook sent a drift report:")
+		d.say("    id:          %s", report.Report.Spec.ID)
+		d.say("    phase:       %s", report.Report.Spec.Phase)
+		d.say("    parent:      %s/%s", report.Report.Spec.Parent.Kind, report.Report.Spec.Parent.Name)
+		d.say("    child:       %s/%s", report.Report.Spec.Child.Kind, report.Report.Spec.Child.Name)
+		d.say("    occurrences: %d", max(report.Occurrences, 1))
+		for _, line := range strings.Split(strings.TrimSpace(report.Diff), "\n") {
+			if line != "" {
+				d.say("    %s", line)
+			}
+		}
+	} else {
+		// Without backend, watch the reverts fail, noting drift status if the
+		// webhook records it
+		if err := d.sleep(ctx, d.opts.DriftWait); err != nil {
+			return err
+		}
+		var deploy appsv1.Deployment
+		if err := d.client.Get(ctx, d.key(Name), &deploy); err != nil {
+			return fmt.Errorf("failed to get Deployment: %w", err)
+		}
+		if count := deploy.Annotations[drift.DriftCountAnnotation]; count != "" {
+			d.say("Kausality counted the drift on the Deployment: %s=%s", drift.DriftCountAnnotation, count)
+		}
+		d.say("(Pass --backend-url to see the drift report.)")
+	}
+
+	var rs appsv1.ReplicaSet
+	if err := d.client.Get(ctx, d.key(d.replicaSet), &rs); err != nil {
+		return fmt.Errorf("failed to get ReplicaSet: %w", err)
+	}
+	if ptr.Deref(rs.Spec.Replicas, 1) != editedReplicas {
+		return fmt.Errorf("the controller scaled ReplicaSet %s back to %d replicas: is the webhook enforcing policy %s?",
+			d.replicaSet, ptr.Deref(rs.Spec.Replicas, 1), Name)
+	}
+	d.say("ReplicaSet %s still has %d replicas: the controller's updates are denied.", d.replicaSet, editedReplicas)
+	return nil
+}
+
+// approve approves the drift once and waits until the controller resolved it.
+func (d *Demo) approve(ctx context.Context) error {
+	req := cli.BulkApproval{
+		Parent:       appsv1.SchemeGroupVersion.WithKind("Deployment"),
+		ParentName:   Name,
+		Child:        appsv1.SchemeGroupVersion.WithKind("ReplicaSet"),
+		ChildPattern: d.replicaSet,
+		Mode:         approval.ModeOnce,
+	}
+	plan, err := cli.NewClient(d.client, d.opts.Namespace).ApplyApproval(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to approve: %w", err)
+	}
+	value, err := plan.JSON()
+	if err != nil {
+		return err
+	}
+	d.say("Approved the drift of ReplicaSet %s once, on the Deployment:\n        %s: %s", d.replicaSet, approval.ApprovalsAnnotation, value)
+	d.say("    (kausality-cli approve --namespace %s --parent deploy/%s --children 'ReplicaSet/%s')", d.opts.Namespace, Name, d.replicaSet)
+
+	if err := d.poll(ctx, func(ctx context.Context) (bool, error) {
+		var rs appsv1.ReplicaSet
+		if err := d.client.Get(ctx, d.key(d.replicaSet), &rs); err != nil {
+			return false, err
+		}
+		return ptr.Deref(rs.Spec.Replicas, 1) == 1, nil
+	}); err != nil {
+		return fmt.Errorf("ReplicaSet %s not scaled back: %w", d.replicaSet, err)
+	}
+	d.say("The controller's next retry was admitted: ReplicaSet %s is back at 1 replica.", d.replicaSet)
+
+	var deploy appsv1.Deployment
+	if err := d.client.Get(ctx, d.key(Name), &deploy); err != nil {
+		return fmt.Errorf("failed to get Deployment: %w", err)
+	}
+	if !strings.Contains(deploy.Annotations[approval.ApprovalsAnnotation], d.replicaSet) {
+		d.say("The approval was consumed by the update it allowed.")
+	}
+
+	if d.opts.Backend != nil {
+		if err := d.poll(ctx, func(ctx context.Context) (bool, error) {
+			report, err := d.openDrift(ctx)
+			return report == nil, err
+		}); err != nil {
+			return fmt.Errorf("drift %s not resolved at the backend: %w", d.driftID, err)
+		}
+		d.say("The webhook sent a %s report: drift %s is closed at the backend.", v1alpha1.DriftReportPhaseResolved, d.driftID)
+	}
+	return nil
+}
+
+// create creates an object, remembering it for cleanup.
+func (d *Demo) create(ctx context.Context, obj client.Object) error {
+	if err := d.client.Create(ctx, obj); err != nil {
+		return err
+	}
+	d.created = append(d.created, obj)
+	return nil
+}
+
+// cleanup deletes the objects created by the demo, newest first. Deleting
+// the namespace deletes the Deployment.
+func (d *Demo) cleanup(ctx context.Context) error {
+	if len(d.created) == 0 {
+		return nil
+	}
+	d.printf("\n==> Cleaning up\n")
+	var errs []error
+	for i := len(d.created) - 1; i >= 0; i-- {
+		obj := d.created[i]
+		if err := d.client.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("failed to delete %s: %w", obj.GetName(), err))
+			continue
+		}
+		d.say("Deleted %s %s", kindOf(obj), obj.GetName())
+	}
+	return errors.Join(errs...)
+}
+
+// kindOf names the kind of an object created by the demo.
+func kindOf(obj client.Object) string {
+	switch obj.(type) {
+	case *corev1.Namespace:
+		return "namespace"
+	case *kausalityv1beta1.Kausality:
+		return "policy"
+	}
+	return fmt.Sprintf("%T", obj)
+}
+
+// policy returns the demo policy, enforcing on Deployments and ReplicaSets
+// in the demo namespace.
+func (d *Demo) policy() *kausalityv1beta1.Kausality {
+	return &kausalityv1beta1.Kausality{
+		ObjectMeta: metav1.ObjectMeta{Name: Name},
+		Spec: kausalityv1beta1.KausalitySpec{
+			Resources: []kausalityv1beta1.ResourceRule{
+				{APIGroups: []string{"apps"}, Resources: []string{"deployments", "replicasets"}},
+			},
+			Namespaces: &kausalityv1beta1.NamespaceSelector{Names: []string{d.opts.Namespace}},
+			Mode:       kausalityv1beta1.ModeEnforce,
+		},
+	}
+}
+
+// deployment returns the sample Deployment.
+func (d *Demo) deployment() *appsv1.Deployment {
+	labels := map[string]string{"app": Name}
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: d.opts.Namespace, Name: Name},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: ptr.To[int32](1),
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "app", Image: d.opts.Image}},
+				},
+			},
+		},
+	}
+}
+
+// currentReplicaSet returns the ReplicaSet controlled by the Deployment, or
+// nil if there is none yet.
+func (d *Demo) currentReplicaSet(ctx context.Context, deploy *appsv1.Deployment) (*appsv1.ReplicaSet, error) {
+	var list appsv1.ReplicaSetList
+	if err := d.client.List(ctx, &list, client.InNamespace(d.opts.Namespace)); err != nil {
+		return nil, err
+	}
+	for i := range list.Items {
+		if owner := metav1.GetControllerOf(&list.Items[i]); owner != nil && owner.UID == deploy.UID {
+			return &list.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// openDrift returns the unresolved drift of the ReplicaSet at the backend,
+// or nil.
+func (d *Demo) openDrift(ctx context.Context) (*cli.OpenDrift, error) {
+	drifts, err := d.opts.Backend.Drifts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i, o := range drifts {
+		if o.Report != nil && o.Report.Spec.Phase == v1alpha1.DriftReportPhaseDetected &&
+			o.Report.Spec.Child.Namespace == d.opts.Namespace && o.Report.Spec.Child.Name == d.replicaSet {
+			return &drifts[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// poll calls condition every PollInterval until it is done, fails, or the
+// Timeout passes.
+func (d *Demo) poll(ctx context.Context, condition wait.ConditionWithContextFunc) error {
+	return wait.PollUntilContextTimeout(ctx, d.opts.PollInterval, d.opts.Timeout, true, condition)
+}
+
+// sleep waits for the duration, or until ctx is done.
+func (d *Demo) sleep(ctx context.Context, duration time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(duration):
+		return nil
+	}
+}
+
+func (d *Demo) key(name string) client.ObjectKey {
+	return client.ObjectKey{Namespace: d.opts.Namespace, Name: name}
+}
+
+// say narrates a line of a step.
+func (d *Demo) say(format string, args ...interface{}) {
+	d.printf("    "+format+"\n", args...)
+}
+
+func (d *Demo) printf(format string, args ...interface{}) {
+	_, _ = fmt.Fprintf(d.out, format, args...)
+}
@@ -0,0 +1,205 @@
+package demo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+	"github.com/kausality-io/kausality/cmd/kausality-cli/pkg/cli"
+	"github.com/kausality-io/kausality/pkg/approval"
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/controller"
+	"github.com/kausality-io/kausality/pkg/policy"
+)
+
+// simulatedCluster plays the kausality controller, the Deployment controller
+// and the webhook for the demo.
+type simulatedCluster struct {
+	client   client.Client
+	resolved atomic.Bool
+	// revert makes the Deployment controller revert the hand edit, as
+	// without an enforcing webhook
+	revert bool
+}
+
+func newSimulatedCluster(t *testing.T, revert bool) *simulatedCluster {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, kausalityv1beta1.AddToScheme(scheme))
+	s := &simulatedCluster{revert: revert}
+	s.client = fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+		Create: s.create,
+		Patch:  s.patch,
+		Update: s.update,
+	}).Build()
+	return s
+}
+
+func (s *simulatedCluster) create(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+	switch o := obj.(type) {
+	case *kausalityv1beta1.Kausality:
+		o.Status.Conditions = []metav1.Condition{{Type: policy.ConditionTypeReady, Status: metav1.ConditionTrue, Reason: "AllResourcesDiscovered", LastTransitionTime: metav1.Now()}}
+	case *appsv1.Deployment:
+		o.UID = "deploy-uid"
+		o.Annotations = map[string]string{controller.ControllersAnnotation: controller.HashUsername("deployment-controller")}
+		if err := c.Create(ctx, o, opts...); err != nil {
+			return err
+		}
+		return c.Create(ctx, &appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: o.Namespace, Name: o.Name + "-abc", UID: "rs-uid",
+				OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: o.Name, UID: o.UID, Controller: ptr.To(true)}},
+				Annotations:     map[string]string{"kausality.io/trace": `[{"apiVersion":"apps/v1","kind":"Deployment","name":"kausality-demo","generation":1,"user":"admin"}]`},
+			},
+			Spec: appsv1.ReplicaSetSpec{Replicas: ptr.To[int32](1)},
+		})
+	}
+	return c.Create(ctx, obj, opts...)
+}
+
+func (s *simulatedCluster) patch(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := c.Patch(ctx, obj, patch, opts...); err != nil {
+		return err
+	}
+	if _, ok := obj.(*appsv1.ReplicaSet); ok && s.revert {
+		return s.scaleBack(ctx, c, obj.GetNamespace())
+	}
+	return nil
+}
+
+func (s *simulatedCluster) update(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+	if err := c.Update(ctx, obj, opts...); err != nil {
+		return err
+	}
+	// The approval admits the next retry of the controller, which consumes it
+	if obj.GetAnnotations()[approval.ApprovalsAnnotation] == "" {
+		return nil
+	}
+	var deploy appsv1.Deployment
+	if err := c.Get(ctx, client.ObjectKeyFromObject(obj), &deploy); err != nil {
+		return err
+	}
+	delete(deploy.Annotations, approval.ApprovalsAnnotation)
+	if err := c.Update(ctx, &deploy); err != nil {
+		return err
+	}
+	s.resolved.Store(true)
+	return s.scaleBack(ctx, c, obj.GetNamespace())
+}
+
+func (s *simulatedCluster) scaleBack(ctx context.Context, c client.WithWatch, namespace string) error {
+	var rs appsv1.ReplicaSet
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: Name + "-abc"}, &rs); err != nil {
+		return err
+	}
+	rs.Spec.Replicas = ptr.To[int32](1)
+	return c.Update(ctx, &rs)
+}
+
+// backend serves the drift report of the hand edit until it is resolved.
+func (s *simulatedCluster) backend(w http.ResponseWriter, _ *http.Request) {
+	items := []cli.OpenDrift{}
+	if !s.resolved.Load() {
+		items = append(items, cli.OpenDrift{
+			Report: &v1alpha1.DriftReport{Spec: v1alpha1.DriftReportSpec{
+				ID:     "a1b2c3d4e5f67890",
+				Phase:  v1alpha1.DriftReportPhaseDetected,
+				Parent: v1alpha1.ObjectReference{Kind: "Deployment", Namespace: DefaultNamespace, Name: Name},
+				Child:  v1alpha1.ObjectReference{Kind: "ReplicaSet", Namespace: DefaultNamespace, Name: Name + "-abc"},
+			}},
+			Diff:        "-  replicas: 3\n+  replicas: 1",
+			Occurrences: 4,
+		})
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"items": items, "count": len(items)})
+}
+
+func TestDemo_Run(t *testing.T) {
+	cluster := newSimulatedCluster(t, false)
+	backend := httptest.NewServer(http.HandlerFunc(cluster.backend))
+	defer backend.Close()
+
+	var out bytes.Buffer
+	pauses := 0
+	d := New(cluster.client, &out, Options{
+		Timeout:      time.Second,
+		DriftWait:    time.Millisecond,
+		PollInterval: time.Millisecond,
+		Backend:      &cli.Backend{URL: backend.URL},
+		Pause:        func() error { pauses++; return nil },
+	})
+	require.NoError(t, d.Run(context.Background()), out.String())
+
+	assert.Equal(t, 4, pauses)
+	for _, want := range []string{
+		"==> 1/4 Deploy a sample Deployment",
+		"The Deployment controller created ReplicaSet kausality-demo-abc",
+		"The ReplicaSet's trace leads back to admin",
+		"==> 2/4 Edit its ReplicaSet by hand",
+		"==> 3/4 Watch the controller drift",
+		"id:          a1b2c3d4e5f67890",
+		"occurrences: 4",
+		"+  replicas: 1",
+		"still has 3 replicas",
+		"==> 4/4 Approve the drift",
+		"The approval was consumed",
+		"drift a1b2c3d4e5f67890 is closed",
+		"Deleted namespace kausality-demo",
+		"Deleted policy kausality-demo",
+	} {
+		assert.Contains(t, out.String(), want)
+	}
+
+	// Everything created by the demo is deleted
+	err := cluster.client.Get(context.Background(), client.ObjectKey{Name: Name}, &kausalityv1beta1.Kausality{})
+	assert.True(t, apierrors.IsNotFound(err))
+	err = cluster.client.Get(context.Background(), client.ObjectKey{Name: DefaultNamespace}, &corev1.Namespace{})
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func TestDemo_NotEnforced(t *testing.T) {
+	cluster := newSimulatedCluster(t, true)
+	var out bytes.Buffer
+	d := New(cluster.client, &out, Options{Timeout: time.Second, DriftWait: time.Millisecond, PollInterval: time.Millisecond, Keep: true})
+	err := d.Run(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is the webhook enforcing policy kausality-demo?")
+	assert.Contains(t, out.String(), "Kept namespace")
+
+	// Kept objects are not deleted
+	require.NoError(t, cluster.client.Get(context.Background(), client.ObjectKey{Name: Name}, &kausalityv1beta1.Kausality{}))
+}
+
+func TestDemo_ExistingNamespace(t *testing.T) {
+	cluster := newSimulatedCluster(t, false)
+	require.NoError(t, cluster.client.Create(context.Background(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: DefaultNamespace}}))
+
+	var out bytes.Buffer
+	err := New(cluster.client, &out, Options{}).Run(context.Background())
+	require.Error(t, err)
+	assert.True(t, apierrors.IsAlreadyExists(err))
+
+	// A namespace the demo did not create is not deleted
+	require.NoError(t, cluster.client.Get(context.Background(), client.ObjectKey{Name: DefaultNamespace}, &corev1.Namespace{}))
+	assert.NotContains(t, out.String(), "Cleaning up")
+}
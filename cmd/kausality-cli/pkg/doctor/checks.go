@@ -0,0 +1,405 @@
+package doctor
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+	"github.com/kausality-io/kausality/pkg/controller"
+	"github.com/kausality-io/kausality/pkg/policy"
+	"github.com/kausality-io/kausality/pkg/trace"
+)
+
+// Check names, in report order.
+const (
+	checkWebhookConfiguration = "webhook-configuration"
+	checkWebhookService       = "webhook-service"
+	checkCertificate          = "certificate"
+	checkFailurePolicy        = "failure-policy"
+	checkPolicies             = "policies"
+	checkCanary               = "canary-roundtrip"
+	checkBackend              = "backend"
+)
+
+// certManagerInjectAnnotation marks webhook configurations whose caBundle is
+// injected (and rotated) by cert-manager's CA injector.
+const certManagerInjectAnnotation = "cert-manager.io/inject-ca-from"
+
+// maxWebhookTimeoutSeconds is the highest webhook timeout considered safe;
+// longer timeouts stall every tracked write while the webhook is unreachable.
+const maxWebhookTimeoutSeconds = 15
+
+// checkWebhookConfiguration verifies the MutatingWebhookConfiguration exists
+// and has rules populated by the policy controller.
+func (d *Doctor) checkWebhookConfiguration(ctx context.Context) (*admissionregistrationv1.MutatingWebhookConfiguration, Result) {
+	res := Result{Check: checkWebhookConfiguration}
+
+	var webhook admissionregistrationv1.MutatingWebhookConfiguration
+	if err := d.client.Get(ctx, client.ObjectKey{Name: d.opts.WebhookName}, &webhook); err != nil {
+		res.Status = StatusFail
+		if apierrors.IsNotFound(err) {
+			res.Message = fmt.Sprintf("MutatingWebhookConfiguration %q not found: the webhook is not installed", d.opts.WebhookName)
+		} else {
+			res.Message = fmt.Sprintf("failed to get MutatingWebhookConfiguration %q: %v", d.opts.WebhookName, err)
+		}
+		return nil, res
+	}
+
+	if len(webhook.Webhooks) == 0 {
+		res.Status = StatusFail
+		res.Message = fmt.Sprintf("MutatingWebhookConfiguration %q has no webhooks defined", d.opts.WebhookName)
+		return nil, res
+	}
+
+	if len(webhook.Webhooks[0].Rules) == 0 {
+		res.Status = StatusWarn
+		res.Message = "webhook has no rules: no policy has been reconciled by kausality-controller, nothing is intercepted"
+		return &webhook, res
+	}
+
+	res.Status = StatusOK
+	res.Message = fmt.Sprintf("%q has %d rule(s)", d.opts.WebhookName, len(webhook.Webhooks[0].Rules))
+	return &webhook, res
+}
+
+// checkWebhookService verifies the webhook's service exists, exposes the
+// configured port, and has ready endpoints.
+func (d *Doctor) checkWebhookService(ctx context.Context, webhook *admissionregistrationv1.MutatingWebhookConfiguration) Result {
+	res := Result{Check: checkWebhookService}
+	cc := webhook.Webhooks[0].ClientConfig
+
+	if cc.URL != nil {
+		res.Status = StatusWarn
+		res.Message = fmt.Sprintf("webhook uses URL %s; routability cannot be verified", *cc.URL)
+		return res
+	}
+	if cc.Service == nil {
+		res.Status = StatusFail
+		res.Message = "webhook clientConfig has neither service nor URL"
+		return res
+	}
+
+	ref := cc.Service
+	port := int32(443)
+	if ref.Port != nil {
+		port = *ref.Port
+	}
+	name := fmt.Sprintf("%s/%s:%d", ref.Namespace, ref.Name, port)
+
+	var svc corev1.Service
+	if err := d.client.Get(ctx, client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}, &svc); err != nil {
+		res.Status = StatusFail
+		res.Message = fmt.Sprintf("service %s/%s: %v", ref.Namespace, ref.Name, err)
+		return res
+	}
+	if !slices.ContainsFunc(svc.Spec.Ports, func(p corev1.ServicePort) bool { return p.Port == port }) {
+		res.Status = StatusFail
+		res.Message = fmt.Sprintf("service %s/%s does not expose port %d", ref.Namespace, ref.Name, port)
+		return res
+	}
+
+	var endpointSlices discoveryv1.EndpointSliceList
+	if err := d.client.List(ctx, &endpointSlices, client.InNamespace(ref.Namespace), client.MatchingLabels{discoveryv1.LabelServiceName: ref.Name}); err != nil {
+		res.Status = StatusFail
+		res.Message = fmt.Sprintf("failed to list endpoints of %s: %v", name, err)
+		return res
+	}
+	ready := 0
+	for _, slice := range endpointSlices.Items {
+		for _, ep := range slice.Endpoints {
+			if ep.Conditions.Ready == nil || *ep.Conditions.Ready {
+				ready++
+			}
+		}
+	}
+	if ready == 0 {
+		res.Status = StatusFail
+		res.Message = fmt.Sprintf("service %s has no ready endpoints: webhook pods are not running", name)
+		return res
+	}
+
+	res.Status = StatusOK
+	res.Message = fmt.Sprintf("service %s has %d ready endpoint(s)", name, ready)
+	return res
+}
+
+// checkCertificate verifies the CA bundle is valid and will be rotated.
+func (d *Doctor) checkCertificate(webhook *admissionregistrationv1.MutatingWebhookConfiguration) Result {
+	res := Result{Check: checkCertificate}
+	_, managed := webhook.Annotations[certManagerInjectAnnotation]
+
+	bundle := webhook.Webhooks[0].ClientConfig.CABundle
+	if len(bundle) == 0 {
+		res.Status = StatusFail
+		res.Message = "caBundle is empty: the API server cannot verify the webhook"
+		if managed {
+			res.Message += " (cert-manager has not injected the CA yet)"
+		}
+		return res
+	}
+
+	var certs []*x509.Certificate
+	for rest := bundle; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			res.Status = StatusFail
+			res.Message = fmt.Sprintf("caBundle contains an invalid certificate: %v", err)
+			return res
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		res.Status = StatusFail
+		res.Message = "caBundle contains no PEM certificates"
+		return res
+	}
+
+	now := d.now()
+	expiry := certs[0].NotAfter
+	for _, cert := range certs {
+		if now.Before(cert.NotBefore) {
+			res.Status = StatusFail
+			res.Message = fmt.Sprintf("CA certificate %q is not valid before %s", cert.Subject.CommonName, cert.NotBefore.Format(time.RFC3339))
+			return res
+		}
+		if cert.NotAfter.Before(expiry) {
+			expiry = cert.NotAfter
+		}
+	}
+
+	rotation := "not rotated automatically"
+	if managed {
+		rotation = "rotated by cert-manager"
+	}
+
+	switch {
+	case now.After(expiry):
+		res.Status = StatusFail
+		res.Message = fmt.Sprintf("CA certificate expired at %s (%s)", expiry.Format(time.RFC3339), rotation)
+	case expiry.Sub(now) < d.opts.CertExpiryWarning:
+		res.Status = StatusWarn
+		res.Message = fmt.Sprintf("CA certificate expires in %s (%s)", expiry.Sub(now).Round(time.Hour), rotation)
+	default:
+		res.Status = StatusOK
+		res.Message = fmt.Sprintf("CA certificate valid until %s (%s)", expiry.Format(time.RFC3339), rotation)
+	}
+	return res
+}
+
+// checkFailurePolicy verifies the webhook fails closed without being able to
+// lock itself out, and that dry-run requests are admitted.
+func (d *Doctor) checkFailurePolicy(webhook *admissionregistrationv1.MutatingWebhookConfiguration) Result {
+	res := Result{Check: checkFailurePolicy, Status: StatusOK}
+	wh := webhook.Webhooks[0]
+
+	var issues []string
+	raise := func(status Status, issue string) {
+		if status == StatusFail || res.Status == StatusOK {
+			res.Status = status
+		}
+		issues = append(issues, issue)
+	}
+
+	failurePolicy := admissionregistrationv1.Fail
+	if wh.FailurePolicy != nil {
+		failurePolicy = *wh.FailurePolicy
+	}
+	if failurePolicy == admissionregistrationv1.Ignore {
+		raise(StatusWarn, "failurePolicy=Ignore: writes are admitted untraced while the webhook is unavailable (fail-open)")
+	} else if wh.ClientConfig.Service != nil && !excludesNamespace(wh.NamespaceSelector, wh.ClientConfig.Service.Namespace) {
+		raise(StatusFail, fmt.Sprintf("failurePolicy=Fail but webhook namespace %q is not excluded: webhook pods cannot be recreated while the webhook is down", wh.ClientConfig.Service.Namespace))
+	}
+
+	if wh.SideEffects == nil || (*wh.SideEffects != admissionregistrationv1.SideEffectClassNone && *wh.SideEffects != admissionregistrationv1.SideEffectClassNoneOnDryRun) {
+		raise(StatusFail, "sideEffects must be None or NoneOnDryRun: dry-run requests are rejected")
+	}
+
+	if wh.TimeoutSeconds != nil && *wh.TimeoutSeconds > maxWebhookTimeoutSeconds {
+		raise(StatusWarn, fmt.Sprintf("timeoutSeconds=%d stalls tracked writes while the webhook is unreachable", *wh.TimeoutSeconds))
+	}
+
+	if len(issues) == 0 {
+		res.Message = fmt.Sprintf("failurePolicy=%s", failurePolicy)
+		return res
+	}
+	res.Message = strings.Join(issues, "; ")
+	return res
+}
+
+// excludesNamespace returns true if the selector excludes the namespace by name.
+func excludesNamespace(selector *metav1.LabelSelector, namespace string) bool {
+	if selector == nil {
+		return false
+	}
+	for _, expr := range selector.MatchExpressions {
+		if expr.Key == corev1.LabelMetadataName && expr.Operator == metav1.LabelSelectorOpNotIn && slices.Contains(expr.Values, namespace) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPolicies verifies Kausality policies exist and are reconciled.
+func (d *Doctor) checkPolicies(ctx context.Context) ([]kausalityv1beta1.Kausality, Result) {
+	res := Result{Check: checkPolicies}
+
+	var list kausalityv1beta1.KausalityList
+	if err := d.client.List(ctx, &list); err != nil {
+		res.Status = StatusFail
+		res.Message = fmt.Sprintf("failed to list Kausality policies (is the CRD installed?): %v", err)
+		return nil, res
+	}
+	if len(list.Items) == 0 {
+		res.Status = StatusWarn
+		res.Message = "no Kausality policies: no resources are tracked"
+		return nil, res
+	}
+
+	var problems []string
+	for _, p := range list.Items {
+		if len(p.Spec.Resources) == 0 {
+			problems = append(problems, fmt.Sprintf("%s: no resource rules", p.Name))
+			continue
+		}
+		cond := meta.FindStatusCondition(p.Status.Conditions, policy.ConditionTypeReady)
+		switch {
+		case cond == nil:
+			problems = append(problems, fmt.Sprintf("%s: not reconciled (is kausality-controller running?)", p.Name))
+		case cond.Status != metav1.ConditionTrue:
+			problems = append(problems, fmt.Sprintf("%s: %s: %s", p.Name, cond.Reason, cond.Message))
+		case cond.ObservedGeneration != 0 && cond.ObservedGeneration < p.Generation:
+			problems = append(problems, fmt.Sprintf("%s: generation %d not reconciled yet", p.Name, p.Generation))
+		}
+	}
+	if len(problems) > 0 {
+		res.Status = StatusFail
+		res.Message = strings.Join(problems, "; ")
+		return list.Items, res
+	}
+
+	res.Status = StatusOK
+	res.Message = fmt.Sprintf("%d policy(ies) ready", len(list.Items))
+	return list.Items, res
+}
+
+// checkCanary creates a ConfigMap with dry-run and verifies the webhook added
+// the kausality annotations. Nothing is persisted.
+func (d *Doctor) checkCanary(ctx context.Context, policies []kausalityv1beta1.Kausality) Result {
+	res := Result{Check: checkCanary}
+	ns := d.opts.CanaryNamespace
+
+	var namespace corev1.Namespace
+	if err := d.client.Get(ctx, client.ObjectKey{Name: ns}, &namespace); err != nil {
+		res.Status = StatusFail
+		res.Message = fmt.Sprintf("canary namespace %q: %v", ns, err)
+		return res
+	}
+
+	store := policy.NewStore(d.client, logr.Discard())
+	store.Update(policies)
+	tracked := store.IsTracked(policy.ResourceContext{
+		GVR:             schema.GroupVersionResource{Version: "v1", Resource: "configmaps"},
+		Namespace:       ns,
+		NamespaceLabels: namespace.Labels,
+	})
+	if !tracked {
+		res.Status = StatusSkip
+		res.Message = fmt.Sprintf("no policy tracks configmaps in namespace %q", ns)
+		return res
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "kausality-doctor-canary-",
+			Namespace:    ns,
+		},
+		Data: map[string]string{"canary": "true"},
+	}
+	if err := d.client.Create(ctx, cm, client.DryRunAll); err != nil {
+		res.Status = StatusFail
+		res.Message = fmt.Sprintf("dry-run create of canary ConfigMap failed: %v", err)
+		return res
+	}
+
+	annotations := cm.GetAnnotations()
+	raw, ok := annotations[trace.TraceAnnotation]
+	if !ok {
+		res.Status = StatusFail
+		res.Message = fmt.Sprintf("canary was admitted without %s: the webhook did not mutate the request (failing open?)", trace.TraceAnnotation)
+		return res
+	}
+	t, err := trace.Parse(raw)
+	if err != nil || len(t) == 0 {
+		res.Status = StatusFail
+		res.Message = fmt.Sprintf("canary has an invalid %s annotation: %q", trace.TraceAnnotation, raw)
+		return res
+	}
+	if annotations[controller.UpdatersAnnotation] == "" {
+		res.Status = StatusFail
+		res.Message = fmt.Sprintf("canary has a trace but no %s annotation", controller.UpdatersAnnotation)
+		return res
+	}
+
+	res.Status = StatusOK
+	res.Message = fmt.Sprintf("dry-run ConfigMap in %q was traced (%d hop(s), origin %s)", ns, len(t), t[0].User)
+	return res
+}
+
+// checkBackend verifies the drift backend answers its health endpoint.
+func (d *Doctor) checkBackend(ctx context.Context) Result {
+	res := Result{Check: checkBackend}
+	if d.opts.BackendURL == "" {
+		res.Status = StatusSkip
+		res.Message = "no backend URL given"
+		return res
+	}
+
+	url := strings.TrimSuffix(d.opts.BackendURL, "/") + "/healthz"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		res.Status = StatusFail
+		res.Message = fmt.Sprintf("invalid backend URL: %v", err)
+		return res
+	}
+	resp, err := d.opts.HTTPClient.Do(req)
+	if err != nil {
+		res.Status = StatusFail
+		res.Message = fmt.Sprintf("backend unreachable: %v", err)
+		return res
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		res.Status = StatusFail
+		res.Message = fmt.Sprintf("GET %s returned %s", url, resp.Status)
+		return res
+	}
+	res.Status = StatusOK
+	res.Message = fmt.Sprintf("%s is healthy", d.opts.BackendURL)
+	return res
+}
@@ -0,0 +1,137 @@
+// Package doctor verifies a Kausality installation and reports misconfigurations
+// that would otherwise make the webhook fail open silently.
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Status is the outcome of a single check.
+type Status string
+
+const (
+	StatusOK   Status = "OK"
+	StatusWarn Status = "WARN"
+	StatusFail Status = "FAIL"
+	StatusSkip Status = "SKIP"
+)
+
+// Result is the outcome of a single check.
+type Result struct {
+	Check   string `json:"check"`
+	Status  Status `json:"status"`
+	Message string `json:"message"`
+}
+
+// Report is the outcome of all checks, in execution order.
+type Report struct {
+	Results []Result `json:"results"`
+}
+
+// Failed returns true if any check failed.
+func (r *Report) Failed() bool {
+	for _, res := range r.Results {
+		if res.Status == StatusFail {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteText writes the report as an aligned table followed by a summary line.
+func (r *Report) WriteText(w io.Writer) error {
+	counts := make(map[Status]int)
+	for _, res := range r.Results {
+		counts[res.Status]++
+		if _, err := fmt.Fprintf(w, "[%-4s] %-22s %s\n", res.Status, res.Check, res.Message); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "\n%d ok, %d warning(s), %d failure(s), %d skipped\n",
+		counts[StatusOK], counts[StatusWarn], counts[StatusFail], counts[StatusSkip])
+	return err
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// Options configures the checks.
+type Options struct {
+	// WebhookName is the name of the MutatingWebhookConfiguration.
+	WebhookName string
+
+	// CanaryNamespace is where the dry-run canary ConfigMap is created.
+	CanaryNamespace string
+
+	// BackendURL is the base URL of a drift backend. Empty skips the backend check.
+	BackendURL string
+
+	// CertExpiryWarning warns if the CA bundle expires within this duration.
+	CertExpiryWarning time.Duration
+
+	// HTTPClient is used for the backend check. Defaults to a client with a 5s timeout.
+	HTTPClient *http.Client
+}
+
+// Doctor runs installation checks against a cluster.
+type Doctor struct {
+	client client.Client
+	opts   Options
+	now    func() time.Time
+}
+
+// New creates a Doctor with defaults applied to unset options.
+func New(c client.Client, opts Options) *Doctor {
+	if opts.WebhookName == "" {
+		opts.WebhookName = "kausality"
+	}
+	if opts.CanaryNamespace == "" {
+		opts.CanaryNamespace = "default"
+	}
+	if opts.CertExpiryWarning == 0 {
+		opts.CertExpiryWarning = 30 * 24 * time.Hour
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 5 * time.Second}
+	}
+	return &Doctor{client: c, opts: opts, now: time.Now}
+}
+
+// Run executes all checks and returns the report. Checks that depend on the
+// webhook configuration are skipped if it cannot be read.
+func (d *Doctor) Run(ctx context.Context) *Report {
+	report := &Report{}
+	add := func(results ...Result) {
+		report.Results = append(report.Results, results...)
+	}
+
+	webhook, res := d.checkWebhookConfiguration(ctx)
+	add(res)
+	if webhook != nil {
+		add(d.checkWebhookService(ctx, webhook))
+		add(d.checkCertificate(webhook))
+		add(d.checkFailurePolicy(webhook))
+	} else {
+		for _, check := range []string{checkWebhookService, checkCertificate, checkFailurePolicy} {
+			add(Result{Check: check, Status: StatusSkip, Message: "webhook configuration unavailable"})
+		}
+	}
+
+	policies, res := d.checkPolicies(ctx)
+	add(res)
+	add(d.checkCanary(ctx, policies))
+	add(d.checkBackend(ctx))
+
+	return report
+}
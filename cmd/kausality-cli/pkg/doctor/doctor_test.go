@@ -0,0 +1,235 @@
+package doctor
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+	"github.com/kausality-io/kausality/pkg/controller"
+	"github.com/kausality-io/kausality/pkg/policy"
+	"github.com/kausality-io/kausality/pkg/trace"
+)
+
+func testScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, kausalityv1alpha1.AddToScheme(scheme))
+	require.NoError(t, kausalityv1beta1.AddToScheme(scheme))
+	return scheme
+}
+
+func testCABundle(t *testing.T, notAfter time.Time) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kausality-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// healthyObjects returns a working installation: webhook, service, ready
+// endpoints, a ready policy tracking configmaps, and the canary namespace.
+func healthyObjects(t *testing.T) []client.Object {
+	fail := admissionregistrationv1.Fail
+	sideEffects := admissionregistrationv1.SideEffectClassNoneOnDryRun
+	return []client.Object{
+		&admissionregistrationv1.MutatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: "kausality"},
+			Webhooks: []admissionregistrationv1.MutatingWebhook{{
+				Name: "mutating.webhook.kausality.io",
+				ClientConfig: admissionregistrationv1.WebhookClientConfig{
+					Service:  &admissionregistrationv1.ServiceReference{Namespace: "kausality-system", Name: "kausality-webhook", Port: ptr.To(int32(443))},
+					CABundle: testCABundle(t, time.Now().Add(365*24*time.Hour)),
+				},
+				Rules:          []admissionregistrationv1.RuleWithOperations{{}},
+				FailurePolicy:  &fail,
+				SideEffects:    &sideEffects,
+				TimeoutSeconds: ptr.To(int32(10)),
+				NamespaceSelector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{{
+						Key:      corev1.LabelMetadataName,
+						Operator: metav1.LabelSelectorOpNotIn,
+						Values:   []string{"kausality-system"},
+					}},
+				},
+			}},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "kausality-system", Name: "kausality-webhook"},
+			Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 443}}},
+		},
+		&discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "kausality-system",
+				Name:      "kausality-webhook-abc",
+				Labels:    map[string]string{discoveryv1.LabelServiceName: "kausality-webhook"},
+			},
+			AddressType: discoveryv1.AddressTypeIPv4,
+			Endpoints: []discoveryv1.Endpoint{
+				{Addresses: []string{"10.0.0.1"}, Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(true)}},
+			},
+		},
+		&kausalityv1beta1.Kausality{
+			ObjectMeta: metav1.ObjectMeta{Name: "configmaps"},
+			Spec: kausalityv1beta1.KausalitySpec{
+				Resources: []kausalityv1beta1.ResourceRule{{APIGroups: []string{""}, Resources: []string{"configmaps"}}},
+				Mode:      kausalityv1beta1.ModeLog,
+			},
+			Status: kausalityv1beta1.KausalityStatus{
+				Conditions: []metav1.Condition{{Type: policy.ConditionTypeReady, Status: metav1.ConditionTrue, Reason: "Reconciled"}},
+			},
+		},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+	}
+}
+
+// mutatingCreate simulates the webhook by adding kausality annotations on create.
+var mutatingCreate = interceptor.Funcs{
+	Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+		obj.SetAnnotations(map[string]string{
+			trace.TraceAnnotation:         `[{"apiVersion":"v1","kind":"ConfigMap","name":"canary","generation":1,"user":"admin","timestamp":"2026-01-01T00:00:00Z"}]`,
+			controller.UpdatersAnnotation: "abc12",
+		})
+		return c.Create(ctx, obj, opts...)
+	},
+}
+
+func statuses(r *Report) map[string]Status {
+	result := make(map[string]Status)
+	for _, res := range r.Results {
+		result[res.Check] = res.Status
+	}
+	return result
+}
+
+func TestDoctor_Healthy(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	c := fake.NewClientBuilder().
+		WithScheme(testScheme(t)).
+		WithObjects(healthyObjects(t)...).
+		WithInterceptorFuncs(mutatingCreate).
+		Build()
+
+	report := New(c, Options{BackendURL: backend.URL}).Run(context.Background())
+
+	assert.Equal(t, map[string]Status{
+		checkWebhookConfiguration: StatusOK,
+		checkWebhookService:       StatusOK,
+		checkCertificate:          StatusOK,
+		checkFailurePolicy:        StatusOK,
+		checkPolicies:             StatusOK,
+		checkCanary:               StatusOK,
+		checkBackend:              StatusOK,
+	}, statuses(report))
+	assert.False(t, report.Failed())
+
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteText(&buf))
+	assert.Contains(t, buf.String(), "7 ok, 0 warning(s), 0 failure(s), 0 skipped")
+}
+
+func TestDoctor_WebhookMissing(t *testing.T) {
+	c := fake.NewClientBuilder().WithScheme(testScheme(t)).Build()
+
+	report := New(c, Options{}).Run(context.Background())
+
+	got := statuses(report)
+	assert.Equal(t, StatusFail, got[checkWebhookConfiguration])
+	assert.Equal(t, StatusSkip, got[checkWebhookService])
+	assert.Equal(t, StatusSkip, got[checkCertificate])
+	assert.Equal(t, StatusSkip, got[checkFailurePolicy])
+	assert.Equal(t, StatusWarn, got[checkPolicies])
+	assert.Equal(t, StatusFail, got[checkCanary], "canary namespace missing")
+	assert.Equal(t, StatusSkip, got[checkBackend])
+	assert.True(t, report.Failed())
+}
+
+func TestDoctor_Misconfigured(t *testing.T) {
+	objs := healthyObjects(t)
+	webhook := objs[0].(*admissionregistrationv1.MutatingWebhookConfiguration)
+	webhook.Webhooks[0].NamespaceSelector = nil
+	webhook.Webhooks[0].ClientConfig.CABundle = testCABundle(t, time.Now().Add(24*time.Hour))
+	objs[2].(*discoveryv1.EndpointSlice).Endpoints[0].Conditions.Ready = ptr.To(false)
+	objs[3].(*kausalityv1beta1.Kausality).Status.Conditions[0].Status = metav1.ConditionFalse
+
+	// No interceptor: the canary is admitted without annotations (fail-open)
+	c := fake.NewClientBuilder().WithScheme(testScheme(t)).WithObjects(objs...).Build()
+
+	report := New(c, Options{}).Run(context.Background())
+
+	assert.Equal(t, map[string]Status{
+		checkWebhookConfiguration: StatusOK,
+		checkWebhookService:       StatusFail,
+		checkCertificate:          StatusWarn,
+		checkFailurePolicy:        StatusFail,
+		checkPolicies:             StatusFail,
+		checkCanary:               StatusFail,
+		checkBackend:              StatusSkip,
+	}, statuses(report))
+}
+
+func TestCheckFailurePolicy(t *testing.T) {
+	ignore := admissionregistrationv1.Ignore
+	some := admissionregistrationv1.SideEffectClassSome
+
+	tests := []struct {
+		name   string
+		mutate func(wh *admissionregistrationv1.MutatingWebhook)
+		want   Status
+	}{
+		{name: "healthy", mutate: func(wh *admissionregistrationv1.MutatingWebhook) {}, want: StatusOK},
+		{name: "fail-open", mutate: func(wh *admissionregistrationv1.MutatingWebhook) { wh.FailurePolicy = &ignore }, want: StatusWarn},
+		{name: "side effects", mutate: func(wh *admissionregistrationv1.MutatingWebhook) { wh.SideEffects = &some }, want: StatusFail},
+		{name: "long timeout", mutate: func(wh *admissionregistrationv1.MutatingWebhook) { wh.TimeoutSeconds = ptr.To(int32(30)) }, want: StatusWarn},
+		{name: "fail wins over warn", mutate: func(wh *admissionregistrationv1.MutatingWebhook) {
+			wh.TimeoutSeconds = ptr.To(int32(30))
+			wh.NamespaceSelector = nil
+		}, want: StatusFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webhook := healthyObjects(t)[0].(*admissionregistrationv1.MutatingWebhookConfiguration)
+			tt.mutate(&webhook.Webhooks[0])
+			got := New(nil, Options{}).checkFailurePolicy(webhook)
+			assert.Equal(t, tt.want, got.Status, got.Message)
+		})
+	}
+}
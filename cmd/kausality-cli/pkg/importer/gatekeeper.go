@@ -0,0 +1,102 @@
+package importer
+
+import (
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+)
+
+// gatekeeperConstraintGroup is the API group of all Gatekeeper constraint kinds.
+const gatekeeperConstraintGroup = "constraints.gatekeeper.sh"
+
+// gatekeeperConstraint holds the fields of a Gatekeeper constraint relevant
+// for conversion. Parameters are template-specific and ignored.
+type gatekeeperConstraint struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              struct {
+		EnforcementAction string `json:"enforcementAction,omitempty"`
+		Match             struct {
+			Kinds []struct {
+				APIGroups []string `json:"apiGroups,omitempty"`
+				Kinds     []string `json:"kinds,omitempty"`
+			} `json:"kinds,omitempty"`
+			Scope              string                `json:"scope,omitempty"`
+			Namespaces         []string              `json:"namespaces,omitempty"`
+			ExcludedNamespaces []string              `json:"excludedNamespaces,omitempty"`
+			LabelSelector      *metav1.LabelSelector `json:"labelSelector,omitempty"`
+			NamespaceSelector  *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+			Name               string                `json:"name,omitempty"`
+		} `json:"match,omitempty"`
+	} `json:"spec,omitempty"`
+}
+
+// convertGatekeeper converts a constraint. Returns nil if no resources remain.
+func convertGatekeeper(obj *unstructured.Unstructured) (*Draft, error) {
+	var c gatekeeperConstraint
+	if err := fromUnstructured(obj.Object, &c); err != nil {
+		return nil, err
+	}
+
+	mode := kausalityv1alpha1.ModeLog
+	switch strings.ToLower(c.Spec.EnforcementAction) {
+	case "", "deny":
+		mode = kausalityv1alpha1.ModeEnforce
+	}
+	draft := newDraft("gatekeeper-"+c.Kind+"-"+c.Name, c.Kind+"/"+c.Name, mode)
+	if action := strings.ToLower(c.Spec.EnforcementAction); action != "" && action != "deny" && action != "dryrun" && action != "warn" {
+		draft.warn("enforcementAction %q converted to mode log", c.Spec.EnforcementAction)
+	}
+
+	match := c.Spec.Match
+	resources := make(resourceSet)
+	for _, k := range match.Kinds {
+		for _, group := range k.APIGroups {
+			if group == "*" {
+				draft.warn("apiGroups \"*\" not converted: Kausality requires explicit API groups")
+				continue
+			}
+			for _, kind := range k.Kinds {
+				resources.add(group, resourceForKind(kind))
+			}
+		}
+	}
+	if len(match.Kinds) == 0 {
+		draft.warn("constraint matches all kinds: not converted, list the tracked resources explicitly")
+	}
+	draft.Policy.Spec.Resources = resources.rules()
+	if len(draft.Policy.Spec.Resources) == 0 {
+		return nil, nil
+	}
+
+	names, wildcards := namespaceNames(match.Namespaces)
+	excluded, excludedWildcards := namespaceNames(match.ExcludedNamespaces)
+	if len(wildcards) > 0 {
+		draft.warn("namespace patterns %v not converted: Kausality matches exact names", wildcards)
+	}
+	if len(excludedWildcards) > 0 {
+		draft.warn("excluded namespace patterns %v not converted: Kausality matches exact names", excludedWildcards)
+	}
+	if len(names) > 0 || len(excluded) > 0 || match.NamespaceSelector != nil {
+		draft.Policy.Spec.Namespaces = &kausalityv1alpha1.NamespaceSelector{Names: names, Excluded: excluded}
+		if match.NamespaceSelector != nil {
+			if len(names) > 0 {
+				draft.warn("namespaceSelector not converted: Kausality does not combine namespace names and selector")
+			} else {
+				draft.Policy.Spec.Namespaces.Selector = match.NamespaceSelector
+			}
+		}
+	}
+
+	draft.Policy.Spec.ObjectSelector = match.LabelSelector
+	if match.Scope != "" && match.Scope != "*" {
+		draft.warn("scope %q not converted: Kausality matches namespaced and cluster-scoped resources", match.Scope)
+	}
+	if match.Name != "" {
+		draft.warn("name %q not converted: Kausality does not match objects by name", match.Name)
+	}
+	return draft, nil
+}
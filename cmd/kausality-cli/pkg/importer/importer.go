@@ -0,0 +1,278 @@
+// Package importer converts policies of other admission tools (Gatekeeper
+// constraints, Kyverno policies) into draft Kausality policies. Drafts carry
+// the tracked resources, namespaces and a suggested mode; anything that cannot
+// be expressed is reported as a warning for review.
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+)
+
+// Draft is a Kausality policy converted from another admission tool.
+type Draft struct {
+	// Policy is the converted policy.
+	Policy kausalityv1alpha1.Kausality
+	// Source names the converted policy, e.g. "K8sRequiredLabels/owner" or
+	// "ClusterPolicy/require-labels rule check-team".
+	Source string
+	// Warnings describe parts of the source that were not converted.
+	Warnings []string
+}
+
+// Result is the outcome of converting a set of documents.
+type Result struct {
+	// Drafts are the converted policies, in input order.
+	Drafts []Draft
+	// Skipped describes documents that were not converted.
+	Skipped []string
+}
+
+// Convert reads YAML or JSON documents (including List kinds) and converts
+// Gatekeeper constraints and Kyverno ClusterPolicies and Policies.
+func Convert(r io.Reader) (*Result, error) {
+	result := &Result{}
+	decoder := utilyaml.NewYAMLOrJSONDecoder(r, 4096)
+	for {
+		var obj map[string]interface{}
+		if err := decoder.Decode(&obj); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to decode document: %w", err)
+		}
+		if obj == nil {
+			continue
+		}
+		if err := result.add(&unstructured.Unstructured{Object: obj}); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (r *Result) add(obj *unstructured.Unstructured) error {
+	if obj.IsList() {
+		list, err := obj.ToList()
+		if err != nil {
+			return fmt.Errorf("invalid list: %w", err)
+		}
+		for i := range list.Items {
+			if err := r.add(&list.Items[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	gvk := obj.GroupVersionKind()
+	ref := gvk.Kind + "/" + obj.GetName()
+	switch {
+	case gvk.Group == gatekeeperConstraintGroup:
+		draft, err := convertGatekeeper(obj)
+		if err != nil {
+			return fmt.Errorf("%s: %w", ref, err)
+		}
+		if draft == nil {
+			r.Skipped = append(r.Skipped, ref+": no convertible resources")
+			return nil
+		}
+		r.Drafts = append(r.Drafts, *draft)
+	case gvk.Group == kyvernoGroup && (gvk.Kind == "ClusterPolicy" || gvk.Kind == "Policy"):
+		drafts, skipped, err := convertKyverno(obj)
+		if err != nil {
+			return fmt.Errorf("%s: %w", ref, err)
+		}
+		r.Drafts = append(r.Drafts, drafts...)
+		r.Skipped = append(r.Skipped, skipped...)
+	default:
+		r.Skipped = append(r.Skipped, ref+": not a Gatekeeper constraint or Kyverno policy")
+	}
+	return nil
+}
+
+// WriteYAML writes the drafts as a multi-document YAML stream. Each draft is
+// preceded by comments naming its source and listing its warnings.
+func (r *Result) WriteYAML(w io.Writer) error {
+	for _, skipped := range r.Skipped {
+		if _, err := fmt.Fprintf(w, "# skipped %s\n", skipped); err != nil {
+			return err
+		}
+	}
+	for i, draft := range r.Drafts {
+		obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&draft.Policy)
+		if err != nil {
+			return fmt.Errorf("failed to convert %s: %w", draft.Policy.Name, err)
+		}
+		// Drafts have no status and no server-populated metadata
+		delete(obj, "status")
+		unstructured.RemoveNestedField(obj, "metadata", "creationTimestamp")
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s: %w", draft.Policy.Name, err)
+		}
+		var b strings.Builder
+		if i > 0 || len(r.Skipped) > 0 {
+			b.WriteString("---\n")
+		}
+		fmt.Fprintf(&b, "# converted from %s\n", draft.Source)
+		for _, warning := range draft.Warnings {
+			fmt.Fprintf(&b, "# WARNING: %s\n", warning)
+		}
+		b.Write(data)
+		if _, err := io.WriteString(w, b.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fromUnstructured converts a map into a typed source policy.
+func fromUnstructured(obj map[string]interface{}, into interface{}) error {
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(obj, into)
+}
+
+// newDraft creates a draft policy with the given name and mode.
+func newDraft(name, source string, mode kausalityv1alpha1.Mode) *Draft {
+	return &Draft{
+		Policy: kausalityv1alpha1.Kausality{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: kausalityv1alpha1.GroupVersion.String(),
+				Kind:       "Kausality",
+			},
+			ObjectMeta: metav1.ObjectMeta{Name: policyName(name)},
+			Spec:       kausalityv1alpha1.KausalitySpec{Mode: mode},
+		},
+		Source: source,
+	}
+}
+
+// warn records a warning once.
+func (d *Draft) warn(format string, args ...interface{}) {
+	warning := fmt.Sprintf(format, args...)
+	if !slices.Contains(d.Warnings, warning) {
+		d.Warnings = append(d.Warnings, warning)
+	}
+}
+
+// resourceSet collects resources by API group.
+type resourceSet map[string]map[string]bool
+
+func (s resourceSet) add(group, resource string) {
+	if s[group] == nil {
+		s[group] = make(map[string]bool)
+	}
+	s[group][resource] = true
+}
+
+// rules returns one rule per API group, sorted. A wildcard resource in a group
+// subsumes the others.
+func (s resourceSet) rules() []kausalityv1alpha1.ResourceRule {
+	groups := make([]string, 0, len(s))
+	for group := range s {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	rules := make([]kausalityv1alpha1.ResourceRule, 0, len(groups))
+	for _, group := range groups {
+		var resources []string
+		if s[group]["*"] {
+			resources = []string{"*"}
+		} else {
+			for resource := range s[group] {
+				resources = append(resources, resource)
+			}
+			sort.Strings(resources)
+		}
+		rules = append(rules, kausalityv1alpha1.ResourceRule{APIGroups: []string{group}, Resources: resources})
+	}
+	return rules
+}
+
+// wellKnownGroups maps built-in kinds to their API group, for sources that
+// name kinds without a group.
+var wellKnownGroups = map[string]string{
+	"ConfigMap":               "",
+	"Endpoints":               "",
+	"LimitRange":              "",
+	"Namespace":               "",
+	"PersistentVolume":        "",
+	"PersistentVolumeClaim":   "",
+	"Pod":                     "",
+	"ResourceQuota":           "",
+	"Secret":                  "",
+	"Service":                 "",
+	"ServiceAccount":          "",
+	"DaemonSet":               "apps",
+	"Deployment":              "apps",
+	"ReplicaSet":              "apps",
+	"StatefulSet":             "apps",
+	"HorizontalPodAutoscaler": "autoscaling",
+	"CronJob":                 "batch",
+	"Job":                     "batch",
+	"Ingress":                 "networking.k8s.io",
+	"NetworkPolicy":           "networking.k8s.io",
+	"PodDisruptionBudget":     "policy",
+	"ClusterRole":             "rbac.authorization.k8s.io",
+	"ClusterRoleBinding":      "rbac.authorization.k8s.io",
+	"Role":                    "rbac.authorization.k8s.io",
+	"RoleBinding":             "rbac.authorization.k8s.io",
+}
+
+// resourceForKind derives the resource name of a kind, following the
+// Kubernetes pluralization rules for generated resource names.
+func resourceForKind(kind string) string {
+	if kind == "*" {
+		return "*"
+	}
+	lower := strings.ToLower(kind)
+	switch {
+	case lower == "endpoints":
+		return lower
+	case len(lower) > 1 && lower[len(lower)-1] == 'y' && !strings.ContainsRune("aeiou", rune(lower[len(lower)-2])):
+		return strings.TrimSuffix(lower, "y") + "ies"
+	case strings.HasSuffix(lower, "s"), strings.HasSuffix(lower, "x"), strings.HasSuffix(lower, "z"),
+		strings.HasSuffix(lower, "ch"), strings.HasSuffix(lower, "sh"):
+		return lower + "es"
+	default:
+		return lower + "s"
+	}
+}
+
+// namespaceNames returns the names usable as Kausality namespace names.
+// Names with wildcards cannot be expressed and are returned separately.
+func namespaceNames(names []string) (exact, wildcards []string) {
+	for _, name := range names {
+		if strings.Contains(name, "*") || strings.Contains(name, "?") {
+			wildcards = append(wildcards, name)
+			continue
+		}
+		exact = append(exact, name)
+	}
+	return exact, wildcards
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// policyName turns a source name into a valid Kausality name.
+func policyName(name string) string {
+	name = invalidNameChars.ReplaceAllString(strings.ToLower(name), "-")
+	if len(name) > 253 {
+		name = name[:253]
+	}
+	return strings.Trim(name, "-.")
+}
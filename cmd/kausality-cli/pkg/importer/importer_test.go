@@ -0,0 +1,238 @@
+package importer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+)
+
+func convertOne(t *testing.T, input string) Draft {
+	t.Helper()
+	result, err := Convert(strings.NewReader(input))
+	require.NoError(t, err)
+	require.Len(t, result.Drafts, 1, "skipped: %v", result.Skipped)
+	return result.Drafts[0]
+}
+
+func TestConvert_Gatekeeper(t *testing.T) {
+	draft := convertOne(t, `
+apiVersion: constraints.gatekeeper.sh/v1beta1
+kind: K8sRequiredLabels
+metadata:
+  name: must-have-owner
+spec:
+  match:
+    kinds:
+      - apiGroups: ["apps"]
+        kinds: ["Deployment", "StatefulSet"]
+      - apiGroups: ["", "*"]
+        kinds: ["ConfigMap"]
+    namespaces: ["prod", "team-*"]
+    excludedNamespaces: ["kube-system"]
+    labelSelector:
+      matchLabels: {protected: "true"}
+`)
+
+	assert.Equal(t, "gatekeeper-k8srequiredlabels-must-have-owner", draft.Policy.Name)
+	assert.Equal(t, "K8sRequiredLabels/must-have-owner", draft.Source)
+	want := kausalityv1alpha1.KausalitySpec{
+		Resources: []kausalityv1alpha1.ResourceRule{
+			{APIGroups: []string{""}, Resources: []string{"configmaps"}},
+			{APIGroups: []string{"apps"}, Resources: []string{"deployments", "statefulsets"}},
+		},
+		Namespaces:     &kausalityv1alpha1.NamespaceSelector{Names: []string{"prod"}, Excluded: []string{"kube-system"}},
+		ObjectSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"protected": "true"}},
+		Mode:           kausalityv1alpha1.ModeEnforce,
+	}
+	if diff := cmp.Diff(want, draft.Policy.Spec); diff != "" {
+		t.Errorf("spec mismatch (-want +got):\n%s", diff)
+	}
+	assert.Len(t, draft.Warnings, 2, "wildcard group and namespace pattern: %v", draft.Warnings)
+}
+
+func TestConvert_GatekeeperEnforcementAction(t *testing.T) {
+	tests := []struct {
+		action string
+		want   kausalityv1alpha1.Mode
+	}{
+		{action: "", want: kausalityv1alpha1.ModeEnforce},
+		{action: "deny", want: kausalityv1alpha1.ModeEnforce},
+		{action: "dryrun", want: kausalityv1alpha1.ModeLog},
+		{action: "warn", want: kausalityv1alpha1.ModeLog},
+	}
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			draft := convertOne(t, `
+apiVersion: constraints.gatekeeper.sh/v1beta1
+kind: K8sAllowedRepos
+metadata:
+  name: repos
+spec:
+  enforcementAction: "`+tt.action+`"
+  match:
+    kinds:
+      - apiGroups: ["batch"]
+        kinds: ["Job", "CronJob"]
+`)
+			assert.Equal(t, tt.want, draft.Policy.Spec.Mode)
+			assert.Nil(t, draft.Policy.Spec.Namespaces)
+		})
+	}
+}
+
+func TestConvert_Kyverno(t *testing.T) {
+	result, err := Convert(strings.NewReader(`
+apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: restrict-changes
+spec:
+  validationFailureAction: Audit
+  rules:
+    - name: block-updates
+      match:
+        any:
+          - resources:
+              kinds: ["Deployment", "networking.k8s.io/v1/Ingress", "Widget", "Pod/exec"]
+              operations: ["UPDATE"]
+              namespaces: ["prod"]
+          - resources:
+              kinds: ["Secret"]
+              operations: ["CONNECT"]
+      exclude:
+        any:
+          - resources:
+              namespaces: ["prod-canary"]
+      validate:
+        failureAction: Enforce
+    - name: audit-all
+      match:
+        resources:
+          kinds: ["ConfigMap"]
+      validate: {}
+    - name: add-labels
+      match:
+        resources:
+          kinds: ["Pod"]
+      mutate: {}
+`))
+	require.NoError(t, err)
+	require.Len(t, result.Drafts, 2)
+	assert.Equal(t, []string{"ClusterPolicy/restrict-changes rule add-labels: not a validate rule"}, result.Skipped)
+
+	blocking := result.Drafts[0]
+	assert.Equal(t, "restrict-changes-block-updates", blocking.Policy.Name)
+	want := kausalityv1alpha1.KausalitySpec{
+		Resources: []kausalityv1alpha1.ResourceRule{
+			{APIGroups: []string{"apps"}, Resources: []string{"deployments"}},
+			{APIGroups: []string{"networking.k8s.io"}, Resources: []string{"ingresses"}},
+		},
+		Namespaces: &kausalityv1alpha1.NamespaceSelector{Names: []string{"prod"}, Excluded: []string{"prod-canary"}},
+		Mode:       kausalityv1alpha1.ModeEnforce,
+	}
+	if diff := cmp.Diff(want, blocking.Policy.Spec); diff != "" {
+		t.Errorf("spec mismatch (-want +got):\n%s", diff)
+	}
+	assert.Len(t, blocking.Warnings, 2, "unknown kind and subresource: %v", blocking.Warnings)
+
+	audit := result.Drafts[1]
+	assert.Equal(t, kausalityv1alpha1.ModeLog, audit.Policy.Spec.Mode)
+	assert.Nil(t, audit.Policy.Spec.Namespaces)
+}
+
+func TestConvert_KyvernoNamespacedPolicy(t *testing.T) {
+	draft := convertOne(t, `
+apiVersion: kyverno.io/v1
+kind: Policy
+metadata:
+  name: team-policy
+  namespace: team-a
+spec:
+  validationFailureAction: enforce
+  rules:
+    - name: check
+      match:
+        any:
+          - resources:
+              kinds: ["apps/v1/Deployment"]
+      validate: {}
+`)
+	assert.Equal(t, &kausalityv1alpha1.NamespaceSelector{Names: []string{"team-a"}}, draft.Policy.Spec.Namespaces)
+	assert.Equal(t, kausalityv1alpha1.ModeEnforce, draft.Policy.Spec.Mode)
+}
+
+func TestConvert_Lists(t *testing.T) {
+	result, err := Convert(strings.NewReader(`
+apiVersion: v1
+kind: List
+items:
+  - apiVersion: constraints.gatekeeper.sh/v1beta1
+    kind: K8sRequiredLabels
+    metadata: {name: one}
+    spec: {match: {kinds: [{apiGroups: ["apps"], kinds: ["Deployment"]}]}}
+  - apiVersion: constraints.gatekeeper.sh/v1beta1
+    kind: K8sRequiredLabels
+    metadata: {name: all-kinds}
+    spec: {}
+  - apiVersion: v1
+    kind: ConfigMap
+    metadata: {name: unrelated}
+`))
+	require.NoError(t, err)
+	require.Len(t, result.Drafts, 1)
+	assert.Equal(t, []string{
+		"K8sRequiredLabels/all-kinds: no convertible resources",
+		"ConfigMap/unrelated: not a Gatekeeper constraint or Kyverno policy",
+	}, result.Skipped)
+}
+
+func TestConvert_InvalidInput(t *testing.T) {
+	_, err := Convert(strings.NewReader("kind: [unterminated"))
+	assert.Error(t, err)
+}
+
+func TestWriteYAML(t *testing.T) {
+	result, err := Convert(strings.NewReader(`
+apiVersion: constraints.gatekeeper.sh/v1beta1
+kind: K8sRequiredLabels
+metadata: {name: one}
+spec:
+  enforcementAction: dryrun
+  match: {kinds: [{apiGroups: ["apps"], kinds: ["Deployment"]}], name: web}
+`))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, result.WriteYAML(&buf))
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, "# converted from K8sRequiredLabels/one\n# WARNING: name \"web\" not converted"), out)
+	assert.NotContains(t, out, "status")
+	assert.NotContains(t, out, "creationTimestamp")
+
+	var policy kausalityv1alpha1.Kausality
+	require.NoError(t, yaml.Unmarshal(buf.Bytes(), &policy))
+	assert.Equal(t, "Kausality", policy.Kind)
+	assert.Equal(t, kausalityv1alpha1.ModeLog, policy.Spec.Mode)
+}
+
+func TestResourceForKind(t *testing.T) {
+	for kind, want := range map[string]string{
+		"Deployment":    "deployments",
+		"NetworkPolicy": "networkpolicies",
+		"Gateway":       "gateways",
+		"Ingress":       "ingresses",
+		"Endpoints":     "endpoints",
+		"*":             "*",
+	} {
+		assert.Equal(t, want, resourceForKind(kind), kind)
+	}
+}
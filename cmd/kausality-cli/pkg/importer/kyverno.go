@@ -0,0 +1,231 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+)
+
+// kyvernoGroup is the API group of Kyverno ClusterPolicy and Policy.
+const kyvernoGroup = "kyverno.io"
+
+// kyvernoPolicy holds the fields of a Kyverno policy relevant for conversion.
+type kyvernoPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              struct {
+		ValidationFailureAction string        `json:"validationFailureAction,omitempty"`
+		Rules                   []kyvernoRule `json:"rules,omitempty"`
+	} `json:"spec,omitempty"`
+}
+
+type kyvernoRule struct {
+	Name     string        `json:"name"`
+	Match    kyvernoMatch  `json:"match,omitempty"`
+	Exclude  *kyvernoMatch `json:"exclude,omitempty"`
+	Validate *struct {
+		FailureAction string `json:"failureAction,omitempty"`
+	} `json:"validate,omitempty"`
+}
+
+// kyvernoMatch is a match or exclude block. Resources is the legacy form of a
+// single filter.
+type kyvernoMatch struct {
+	Any       []kyvernoFilter   `json:"any,omitempty"`
+	All       []kyvernoFilter   `json:"all,omitempty"`
+	Resources *kyvernoResources `json:"resources,omitempty"`
+}
+
+type kyvernoFilter struct {
+	Resources    *kyvernoResources `json:"resources,omitempty"`
+	Subjects     []interface{}     `json:"subjects,omitempty"`
+	Roles        []string          `json:"roles,omitempty"`
+	ClusterRoles []string          `json:"clusterRoles,omitempty"`
+}
+
+type kyvernoResources struct {
+	Kinds             []string              `json:"kinds,omitempty"`
+	Name              string                `json:"name,omitempty"`
+	Names             []string              `json:"names,omitempty"`
+	Namespaces        []string              `json:"namespaces,omitempty"`
+	Operations        []string              `json:"operations,omitempty"`
+	Annotations       map[string]string     `json:"annotations,omitempty"`
+	Selector          *metav1.LabelSelector `json:"selector,omitempty"`
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+}
+
+// filters returns the filters of a match block.
+func (m *kyvernoMatch) filters() []kyvernoFilter {
+	filters := append(append([]kyvernoFilter{}, m.Any...), m.All...)
+	if m.Resources != nil {
+		filters = append(filters, kyvernoFilter{Resources: m.Resources})
+	}
+	return filters
+}
+
+// convertKyverno converts each validate rule of a policy into a draft.
+// Other rule types do not restrict mutations and are skipped.
+func convertKyverno(obj *unstructured.Unstructured) ([]Draft, []string, error) {
+	var p kyvernoPolicy
+	if err := fromUnstructured(obj.Object, &p); err != nil {
+		return nil, nil, err
+	}
+
+	var drafts []Draft
+	var skipped []string
+	for _, rule := range p.Spec.Rules {
+		ref := fmt.Sprintf("%s/%s rule %s", p.Kind, p.Name, rule.Name)
+		if rule.Validate == nil {
+			skipped = append(skipped, ref+": not a validate rule")
+			continue
+		}
+		draft := convertKyvernoRule(&p, &rule)
+		if draft == nil {
+			skipped = append(skipped, ref+": no convertible resources")
+			continue
+		}
+		drafts = append(drafts, *draft)
+	}
+	return drafts, skipped, nil
+}
+
+func convertKyvernoRule(p *kyvernoPolicy, rule *kyvernoRule) *Draft {
+	action := p.Spec.ValidationFailureAction
+	if rule.Validate.FailureAction != "" {
+		action = rule.Validate.FailureAction
+	}
+	mode := kausalityv1alpha1.ModeLog
+	if strings.EqualFold(action, "enforce") {
+		mode = kausalityv1alpha1.ModeEnforce
+	}
+	draft := newDraft(p.Name+"-"+rule.Name, fmt.Sprintf("%s/%s rule %s", p.Kind, p.Name, rule.Name), mode)
+
+	resources := make(resourceSet)
+	var names []string
+	unrestricted := false
+	var objectSelector, namespaceSelector *metav1.LabelSelector
+	first := true
+	for _, f := range rule.Match.filters() {
+		if len(f.Subjects) > 0 || len(f.Roles) > 0 || len(f.ClusterRoles) > 0 {
+			draft.warn("user matching not converted: Kausality tracks mutations of all users")
+		}
+		if f.Resources == nil {
+			continue
+		}
+		r := f.Resources
+		if !mutatesExisting(r.Operations) {
+			continue
+		}
+		for _, kind := range r.Kinds {
+			group, resource, err := kyvernoKind(kind)
+			if err != nil {
+				draft.warn("%v", err)
+				continue
+			}
+			resources.add(group, resource)
+		}
+		if r.Name != "" || len(r.Names) > 0 || len(r.Annotations) > 0 {
+			draft.warn("name and annotation matching not converted")
+		}
+
+		exact, wildcards := namespaceNames(r.Namespaces)
+		if len(wildcards) > 0 {
+			draft.warn("namespace patterns %v not converted: Kausality matches exact names", wildcards)
+		}
+		if len(exact) == 0 && r.NamespaceSelector == nil {
+			unrestricted = true
+		}
+		names = append(names, exact...)
+
+		if first {
+			objectSelector, namespaceSelector = r.Selector, r.NamespaceSelector
+			first = false
+		} else if !apiequality.Semantic.DeepEqual(objectSelector, r.Selector) || !apiequality.Semantic.DeepEqual(namespaceSelector, r.NamespaceSelector) {
+			draft.warn("filters with different selectors merged, using the selectors of the first filter")
+		}
+	}
+	draft.Policy.Spec.Resources = resources.rules()
+	if len(draft.Policy.Spec.Resources) == 0 {
+		return nil
+	}
+
+	var excluded []string
+	if rule.Exclude != nil {
+		for _, f := range rule.Exclude.filters() {
+			if f.Resources == nil || len(f.Resources.Kinds) > 0 || len(f.Subjects) > 0 || len(f.Roles) > 0 || len(f.ClusterRoles) > 0 {
+				draft.warn("exclude filters other than namespaces not converted")
+				continue
+			}
+			exact, wildcards := namespaceNames(f.Resources.Namespaces)
+			if len(wildcards) > 0 {
+				draft.warn("excluded namespace patterns %v not converted: Kausality matches exact names", wildcards)
+			}
+			excluded = append(excluded, exact...)
+		}
+	}
+
+	ns := &kausalityv1alpha1.NamespaceSelector{Excluded: excluded}
+	switch {
+	case p.Namespace != "":
+		// Namespaced Policies only apply to their own namespace
+		ns.Names = []string{p.Namespace}
+	case !unrestricted && len(names) > 0:
+		ns.Names = names
+		if namespaceSelector != nil {
+			draft.warn("namespaceSelector not converted: Kausality does not combine namespace names and selector")
+		}
+	case !unrestricted:
+		ns.Selector = namespaceSelector
+	}
+	if len(ns.Names) > 0 || ns.Selector != nil || len(ns.Excluded) > 0 {
+		draft.Policy.Spec.Namespaces = ns
+	}
+	draft.Policy.Spec.ObjectSelector = objectSelector
+	return draft
+}
+
+// mutatesExisting returns true if the operations include mutations Kausality
+// tracks. Kyverno matches CREATE and UPDATE if no operations are given.
+func mutatesExisting(operations []string) bool {
+	if len(operations) == 0 {
+		return true
+	}
+	for _, op := range operations {
+		switch strings.ToUpper(op) {
+		case "CREATE", "UPDATE", "DELETE":
+			return true
+		}
+	}
+	return false
+}
+
+// kyvernoKind parses a Kyverno kind reference ("Kind", "Version/Kind" or
+// "Group/Version/Kind") into an API group and resource.
+func kyvernoKind(ref string) (group, resource string, err error) {
+	parts := strings.Split(ref, "/")
+	kind := parts[len(parts)-1]
+	switch len(parts) {
+	case 1, 2:
+		if len(parts) == 2 && parts[0] != "" && parts[0][0] >= 'A' && parts[0][0] <= 'Z' {
+			return "", "", fmt.Errorf("subresource %q not converted: configure subresources on the resource rule", ref)
+		}
+		g, ok := wellKnownGroups[kind]
+		if !ok {
+			return "", "", fmt.Errorf("kind %q not converted: unknown API group, use Group/Version/Kind", ref)
+		}
+		group = g
+	case 3:
+		group = parts[0]
+	default:
+		return "", "", fmt.Errorf("kind %q not converted: unsupported format", ref)
+	}
+	if group == "*" {
+		return "", "", fmt.Errorf("kind %q not converted: Kausality requires explicit API groups", ref)
+	}
+	return group, resourceForKind(kind), nil
+}
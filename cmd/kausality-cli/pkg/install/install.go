@@ -0,0 +1,705 @@
+// Package install deploys Kausality into a cluster from embedded manifests,
+// for environments without Helm. The manifests are rendered from the chart
+// with controller-managed certificates by make gen, and extended by the
+// namespace and a default policy in log mode.
+//
+// Installation is idempotent: objects are server-side applied with field
+// manager FieldManager, and objects of a previous installation that are no
+// longer rendered are pruned. Fields owned by the controller, the rules of the
+// webhook configuration and of the webhook's resource access ClusterRole, are
+// not part of the manifests and survive re-installation.
+package install
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+)
+
+//go:embed manifests
+var manifests embed.FS
+
+const (
+	// FieldManager is the field manager of applied objects, and the value of
+	// ManagedByLabel on them.
+	FieldManager = "kausality-install"
+
+	// ManagedByLabel marks objects applied by the installer.
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+
+	// InstanceLabel carries the name of the installation.
+	InstanceLabel = "app.kubernetes.io/instance"
+
+	// DefaultVersion is the image tag installed by default, the appVersion of the chart.
+	DefaultVersion = "0.1.0"
+
+	// DefaultRegistry is the image registry installed from by default.
+	DefaultRegistry = "ghcr.io/kausality-io"
+)
+
+// listPageSize is the number of objects listed per request.
+const listPageSize = 500
+
+// manifestFiles are the templates of the installation, in apply order. CRDs
+// come first, so that the default policy can be applied.
+var manifestFiles = []string{"namespace.yaml", "chart.yaml", "policy.yaml"}
+
+// Action is what happened, or would happen in a dry run, to an object.
+type Action string
+
+const (
+	ActionCreated    Action = "created"
+	ActionConfigured Action = "configured"
+	ActionUnchanged  Action = "unchanged"
+	ActionPruned     Action = "pruned"
+	ActionDeleted    Action = "deleted"
+	ActionCleaned    Action = "cleaned"
+	ActionMigrated   Action = "migrated"
+)
+
+// Change is the action on a single object.
+type Change struct {
+	// Object is "<Kind> <namespace>/<name>", or "<Kind> <name>" for cluster-scoped objects.
+	Object string `json:"object"`
+	Action Action `json:"action"`
+	// Diff is a unified diff of the object for created and configured objects.
+	Diff string `json:"diff,omitempty"`
+}
+
+// Result is the outcome of an installation or uninstallation, in execution order.
+type Result struct {
+	DryRun  bool     `json:"dryRun,omitempty"`
+	Changes []Change `json:"changes"`
+}
+
+// WriteText writes one line per change, followed by the diffs if diff is set.
+func (r *Result) WriteText(w io.Writer, diff bool) error {
+	suffix := ""
+	if r.DryRun {
+		suffix = " (dry run)"
+	}
+	for _, c := range r.Changes {
+		if _, err := fmt.Fprintf(w, "%s %s%s\n", c.Object, c.Action, suffix); err != nil {
+			return err
+		}
+		if diff && c.Diff != "" {
+			if _, err := io.WriteString(w, c.Diff); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Options configures the installation.
+type Options struct {
+	// Name prefixes the names of installed objects and is the name of the
+	// webhook configuration. Defaults to "kausality".
+	Name string
+
+	// Namespace is where the webhook and controller run. Defaults to "kausality-system".
+	Namespace string
+
+	// Version is the image tag. Defaults to DefaultVersion.
+	Version string
+
+	// Registry is the image registry. Defaults to DefaultRegistry.
+	Registry string
+
+	// SkipPolicy skips the default policy, which is then pruned if installed before.
+	SkipPolicy bool
+
+	// Prune deletes objects of a previous installation that are no longer rendered.
+	// CRDs and the namespace are never pruned.
+	Prune bool
+
+	// KeepCRDs keeps the CRDs, and with them all policies, on uninstall.
+	KeepCRDs bool
+
+	// DryRun reports changes without applying them.
+	DryRun bool
+
+	// Timeout bounds waiting for CRDs to be served before applying the default
+	// policy. Defaults to one minute.
+	Timeout time.Duration
+}
+
+// Installer installs and uninstalls Kausality.
+type Installer struct {
+	client client.Client
+	opts   Options
+}
+
+// New creates an Installer with defaults applied to unset options.
+func New(c client.Client, opts Options) *Installer {
+	if opts.Name == "" {
+		opts.Name = "kausality"
+	}
+	if opts.Namespace == "" {
+		opts.Namespace = "kausality-system"
+	}
+	if opts.Version == "" {
+		opts.Version = DefaultVersion
+	}
+	if opts.Registry == "" {
+		opts.Registry = DefaultRegistry
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = time.Minute
+	}
+	return &Installer{client: c, opts: opts}
+}
+
+// Render returns the objects of the installation in apply order, labeled
+// with ManagedByLabel and InstanceLabel.
+func (i *Installer) Render() ([]*unstructured.Unstructured, error) {
+	crds, err := fs.Glob(manifests, "manifests/crds/*.yaml")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(crds)
+
+	var objs []*unstructured.Unstructured
+	for _, file := range crds {
+		data, err := manifests.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		decoded, err := decode(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		objs = append(objs, decoded...)
+	}
+
+	for _, file := range manifestFiles {
+		if file == "policy.yaml" && i.opts.SkipPolicy {
+			continue
+		}
+		tmpl, err := template.ParseFS(manifests, path.Join("manifests", file))
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, i.opts); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		decoded, err := decode(buf.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		objs = append(objs, decoded...)
+	}
+
+	for _, obj := range objs {
+		labels := obj.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[ManagedByLabel] = FieldManager
+		labels[InstanceLabel] = i.opts.Name
+		obj.SetLabels(labels)
+	}
+	return objs, nil
+}
+
+// decode splits a multi-document YAML manifest into objects.
+func decode(data []byte) ([]*unstructured.Unstructured, error) {
+	var objs []*unstructured.Unstructured
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := decoder.Decode(&obj.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				return objs, nil
+			}
+			return nil, err
+		}
+		if len(obj.Object) > 0 {
+			objs = append(objs, obj)
+		}
+	}
+}
+
+// Install applies the objects of the installation and prunes stale ones if
+// configured.
+func (i *Installer) Install(ctx context.Context) (*Result, error) {
+	objs, err := i.Render()
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{DryRun: i.opts.DryRun}
+	for _, obj := range objs {
+		change, err := i.apply(ctx, obj)
+		if err != nil {
+			return result, fmt.Errorf("failed to apply %s: %w", ObjectName(obj), err)
+		}
+		result.Changes = append(result.Changes, change)
+	}
+	if i.opts.Prune {
+		if err := i.prune(ctx, objs, result); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// apply server-side applies obj and compares it with the live object.
+func (i *Installer) apply(ctx context.Context, obj *unstructured.Unstructured) (Change, error) {
+	change := Change{Object: ObjectName(obj)}
+
+	live := &unstructured.Unstructured{}
+	live.SetGroupVersionKind(obj.GroupVersionKind())
+	if err := i.client.Get(ctx, client.ObjectKeyFromObject(obj), live); apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+		live = nil
+	} else if err != nil {
+		return change, err
+	}
+
+	applied := obj.DeepCopy()
+	opts := []client.ApplyOption{client.FieldOwner(FieldManager), client.ForceOwnership}
+	if i.opts.DryRun {
+		opts = append(opts, client.DryRunAll)
+	}
+	err := i.client.Apply(ctx, client.ApplyConfigurationFromUnstructured(applied), opts...)
+	switch {
+	case meta.IsNoMatchError(err) && i.opts.DryRun:
+		// The CRD is applied before, but not in a dry run
+		applied = obj.DeepCopy()
+	case meta.IsNoMatchError(err):
+		// Wait for the CRD applied before to be served
+		err = wait.PollUntilContextTimeout(ctx, time.Second, i.opts.Timeout, true, func(ctx context.Context) (bool, error) {
+			applied = obj.DeepCopy()
+			err := i.client.Apply(ctx, client.ApplyConfigurationFromUnstructured(applied), opts...)
+			if meta.IsNoMatchError(err) {
+				return false, nil
+			}
+			return err == nil, err
+		})
+	}
+	if err != nil {
+		return change, err
+	}
+
+	diff, err := Diff(obj, live, applied)
+	if err != nil {
+		return change, err
+	}
+	switch {
+	case live == nil:
+		change.Action = ActionCreated
+	case diff == "":
+		change.Action = ActionUnchanged
+	default:
+		change.Action = ActionConfigured
+	}
+	change.Diff = diff
+	return change, nil
+}
+
+// prune deletes objects of the installation that are not in objs.
+func (i *Installer) prune(ctx context.Context, objs []*unstructured.Unstructured, result *Result) error {
+	rendered := map[string]bool{}
+	var kinds []schema.GroupVersionKind
+	for _, obj := range objs {
+		rendered[ObjectName(obj)] = true
+		if gvk := obj.GroupVersionKind(); !containsKind(kinds, gvk) && gvk.Kind != "CustomResourceDefinition" && gvk.Kind != "Namespace" {
+			kinds = append(kinds, gvk)
+		}
+	}
+	// The default policy is pruned if skipped
+	if policy := kausalityv1beta1.GroupVersion.WithKind("Kausality"); !containsKind(kinds, policy) {
+		kinds = append(kinds, policy)
+	}
+
+	for _, gvk := range kinds {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		err := i.client.List(ctx, list, client.MatchingLabels{ManagedByLabel: FieldManager, InstanceLabel: i.opts.Name})
+		if meta.IsNoMatchError(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to list %s: %w", gvk.Kind, err)
+		}
+		for j := range list.Items {
+			obj := &list.Items[j]
+			if rendered[ObjectName(obj)] {
+				continue
+			}
+			if err := i.delete(ctx, obj); err != nil {
+				return fmt.Errorf("failed to prune %s: %w", ObjectName(obj), err)
+			}
+			result.Changes = append(result.Changes, Change{Object: ObjectName(obj), Action: ActionPruned})
+		}
+	}
+	return nil
+}
+
+// Uninstall deletes the objects of the installation. The resources the
+// webhook intercepts are collected first, then the webhook configuration is
+// deleted, and the bookkeeping annotations are removed from the objects of
+// these resources. The other objects, the policies among them, are only
+// deleted then, so that an uninstall failing halfway still finds the
+// resources when run again, after the webhook configuration is gone.
+func (i *Installer) Uninstall(ctx context.Context) (*Result, error) {
+	objs, err := i.Render()
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{DryRun: i.opts.DryRun}
+
+	webhook := &unstructured.Unstructured{}
+	webhook.SetGroupVersionKind(schema.GroupVersionKind{Group: "admissionregistration.k8s.io", Version: "v1", Kind: "MutatingWebhookConfiguration"})
+	err = i.client.Get(ctx, types.NamespacedName{Name: i.opts.Name}, webhook)
+	if apierrors.IsNotFound(err) {
+		webhook = nil
+	} else if err != nil {
+		return result, fmt.Errorf("failed to get webhook configuration: %w", err)
+	}
+
+	resources, err := i.interceptedResources(ctx, webhook, objs)
+	if err != nil {
+		return result, err
+	}
+	if webhook != nil {
+		if err := i.delete(ctx, webhook); err != nil {
+			return result, fmt.Errorf("failed to delete webhook configuration: %w", err)
+		}
+		result.Changes = append(result.Changes, Change{Object: ObjectName(webhook), Action: ActionDeleted})
+	}
+	for _, gr := range resources {
+		if err := i.cleanResource(ctx, gr, result); err != nil {
+			return result, err
+		}
+	}
+
+	for j := len(objs) - 1; j >= 0; j-- {
+		obj := objs[j]
+		if obj.GetKind() == "CustomResourceDefinition" && i.opts.KeepCRDs || obj.GetKind() == "MutatingWebhookConfiguration" {
+			continue
+		}
+		err := i.delete(ctx, obj)
+		if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			continue
+		}
+		if err != nil {
+			return result, fmt.Errorf("failed to delete %s: %w", ObjectName(obj), err)
+		}
+		result.Changes = append(result.Changes, Change{Object: ObjectName(obj), Action: ActionDeleted})
+	}
+	return result, nil
+}
+
+// MigrateStorage rewrites the objects of the installed CRDs still stored in
+// a version other than the storage version, e.g. Kausality policies created
+// before v1beta1, and then drops the old versions from the stored versions
+// of the CRD, so that they can be removed from the CRD in a later release.
+func (i *Installer) MigrateStorage(ctx context.Context) (*Result, error) {
+	objs, err := i.Render()
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{DryRun: i.opts.DryRun}
+	for _, obj := range objs {
+		if obj.GetKind() != "CustomResourceDefinition" {
+			continue
+		}
+		if err := i.migrateCRD(ctx, obj.GetName(), result); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// migrateCRD migrates the objects of the CRD name to its storage version.
+func (i *Installer) migrateCRD(ctx context.Context, name string, result *Result) error {
+	crd := &unstructured.Unstructured{}
+	crd.SetGroupVersionKind(schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"})
+	if err := i.client.Get(ctx, types.NamespacedName{Name: name}, crd); apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get %s: %w", ObjectName(crd), err)
+	}
+
+	group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+	kind, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "kind")
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	storage := ""
+	for _, v := range versions {
+		if stored, _, _ := unstructured.NestedBool(v.(map[string]interface{}), "storage"); stored {
+			storage, _, _ = unstructured.NestedString(v.(map[string]interface{}), "name")
+		}
+	}
+	if storage == "" {
+		return fmt.Errorf("%s has no storage version", ObjectName(crd))
+	}
+	stored, _, _ := unstructured.NestedStringSlice(crd.Object, "status", "storedVersions")
+	if len(stored) == 0 || len(stored) == 1 && stored[0] == storage {
+		return nil
+	}
+
+	var opts []client.UpdateOption
+	if i.opts.DryRun {
+		opts = append(opts, client.DryRunAll)
+	}
+	err := i.list(ctx, schema.GroupVersionKind{Group: group, Version: storage, Kind: kind + "List"}, func(obj *unstructured.Unstructured) error {
+		// An unchanged update writes the object in the storage version. A
+		// conflicting update has written it already.
+		if err := i.client.Update(ctx, obj, opts...); err != nil && !apierrors.IsConflict(err) && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to migrate %s: %w", ObjectName(obj), err)
+		}
+		result.Changes = append(result.Changes, Change{Object: ObjectName(obj), Action: ActionMigrated})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to migrate %s: %w", kind, err)
+	}
+
+	if !i.opts.DryRun {
+		if err := unstructured.SetNestedStringSlice(crd.Object, []string{storage}, "status", "storedVersions"); err != nil {
+			return err
+		}
+		if err := i.client.Status().Update(ctx, crd); err != nil {
+			return fmt.Errorf("failed to update stored versions of %s: %w", ObjectName(crd), err)
+		}
+	}
+	result.Changes = append(result.Changes, Change{Object: ObjectName(crd), Action: ActionMigrated})
+	return nil
+}
+
+// interceptedResources returns the resources whose objects may carry
+// bookkeeping annotations, sorted: those of the rules of the live webhook
+// configuration, if any, of the rendered one and of the Kausality policies.
+// Wildcard resources of policies are only known from the live rules, which
+// the controller expands.
+func (i *Installer) interceptedResources(ctx context.Context, webhook *unstructured.Unstructured, objs []*unstructured.Unstructured) ([]schema.GroupResource, error) {
+	seen := map[schema.GroupResource]bool{}
+	add := func(groups, resources []string) {
+		for _, group := range groups {
+			for _, resource := range resources {
+				// Subresources are not annotated
+				if group == "*" || resource == "*" || strings.Contains(resource, "/") {
+					continue
+				}
+				seen[schema.GroupResource{Group: group, Resource: resource}] = true
+			}
+		}
+	}
+	addRules := func(webhook *unstructured.Unstructured) {
+		webhooks, _, _ := unstructured.NestedSlice(webhook.Object, "webhooks")
+		for _, w := range webhooks {
+			rules, _, _ := unstructured.NestedSlice(w.(map[string]interface{}), "rules")
+			for _, r := range rules {
+				groups, _, _ := unstructured.NestedStringSlice(r.(map[string]interface{}), "apiGroups")
+				resources, _, _ := unstructured.NestedStringSlice(r.(map[string]interface{}), "resources")
+				add(groups, resources)
+			}
+		}
+	}
+
+	if webhook != nil {
+		addRules(webhook)
+	}
+	for _, obj := range objs {
+		if obj.GetKind() == "MutatingWebhookConfiguration" {
+			addRules(obj)
+		}
+	}
+	err := i.list(ctx, schema.GroupVersionKind{Group: kausalityv1alpha1.GroupVersion.Group, Version: "v1beta1", Kind: "KausalityList"}, func(policy *unstructured.Unstructured) error {
+		rules, _, _ := unstructured.NestedSlice(policy.Object, "spec", "resources")
+		for _, r := range rules {
+			groups, _, _ := unstructured.NestedStringSlice(r.(map[string]interface{}), "apiGroups")
+			resources, _, _ := unstructured.NestedStringSlice(r.(map[string]interface{}), "resources")
+			add(groups, resources)
+		}
+		return nil
+	})
+	if err != nil && !meta.IsNoMatchError(err) {
+		return nil, fmt.Errorf("failed to list policies: %w", err)
+	}
+
+	resources := make([]schema.GroupResource, 0, len(seen))
+	for gr := range seen {
+		resources = append(resources, gr)
+	}
+	sort.Slice(resources, func(a, b int) bool {
+		return resources[a].String() < resources[b].String()
+	})
+	return resources, nil
+}
+
+// cleanResource removes the bookkeeping annotations from all objects of gr,
+// in its preferred version.
+func (i *Installer) cleanResource(ctx context.Context, gr schema.GroupResource, result *Result) error {
+	gvk, err := i.client.RESTMapper().KindFor(gr.WithVersion(""))
+	if meta.IsNoMatchError(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to map %s: %w", gr, err)
+	}
+	err = i.list(ctx, gvk.GroupVersion().WithKind(gvk.Kind+"List"), func(obj *unstructured.Unstructured) error {
+		remove := map[string]interface{}{}
+		for _, key := range kausalityv1alpha1.BookkeepingAnnotations {
+			if _, ok := obj.GetAnnotations()[key]; ok {
+				remove[key] = nil
+			}
+		}
+		if len(remove) == 0 {
+			return nil
+		}
+		if !i.opts.DryRun {
+			patch, err := json.Marshal(map[string]interface{}{"metadata": map[string]interface{}{"annotations": remove}})
+			if err != nil {
+				return err
+			}
+			if err := i.client.Patch(ctx, obj, client.RawPatch(types.MergePatchType, patch)); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("failed to remove annotations of %s: %w", ObjectName(obj), err)
+			}
+		}
+		result.Changes = append(result.Changes, Change{Object: ObjectName(obj), Action: ActionCleaned})
+		return nil
+	})
+	if err != nil && !meta.IsNoMatchError(err) {
+		return fmt.Errorf("failed to clean %s: %w", gr, err)
+	}
+	return nil
+}
+
+// list calls fn for all objects of the list kind gvk, in pages of
+// listPageSize objects.
+func (i *Installer) list(ctx context.Context, gvk schema.GroupVersionKind, fn func(*unstructured.Unstructured) error) error {
+	var continueToken string
+	for {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk)
+		if err := i.client.List(ctx, list, client.Limit(listPageSize), client.Continue(continueToken)); err != nil {
+			return err
+		}
+		for j := range list.Items {
+			if err := fn(&list.Items[j]); err != nil {
+				return err
+			}
+		}
+		continueToken = list.GetContinue()
+		if continueToken == "" {
+			return nil
+		}
+	}
+}
+
+// delete deletes obj in the background, unless in a dry run.
+func (i *Installer) delete(ctx context.Context, obj *unstructured.Unstructured) error {
+	opts := []client.DeleteOption{client.PropagationPolicy("Background")}
+	if i.opts.DryRun {
+		opts = append(opts, client.DryRunAll)
+	}
+	return i.client.Delete(ctx, obj, opts...)
+}
+
+// Diff returns a unified diff from live to applied, restricted to the
+// fields of the rendered object: defaults and fields of other managers, like
+// the webhook rules of the controller, are not shown. live may be nil.
+func Diff(rendered, live, applied *unstructured.Unstructured) (string, error) {
+	from := ""
+	if live != nil {
+		data, err := yaml.Marshal(restrict(live.Object, rendered.Object))
+		if err != nil {
+			return "", err
+		}
+		from = string(data)
+	}
+	data, err := yaml.Marshal(restrict(applied.Object, rendered.Object))
+	if err != nil {
+		return "", err
+	}
+	if from == string(data) {
+		return "", nil
+	}
+	name := ObjectName(rendered)
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(from),
+		B:        difflib.SplitLines(string(data)),
+		FromFile: "live/" + name,
+		ToFile:   "installed/" + name,
+		Context:  3,
+	})
+}
+
+// restrict returns value without the map keys missing in shape. List
+// elements are restricted by the element of shape at the same index.
+func restrict(value, shape interface{}) interface{} {
+	switch shape := shape.(type) {
+	case map[string]interface{}:
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return value
+		}
+		restricted := make(map[string]interface{}, len(shape))
+		for key, s := range shape {
+			if v, ok := m[key]; ok {
+				restricted[key] = restrict(v, s)
+			}
+		}
+		return restricted
+	case []interface{}:
+		l, ok := value.([]interface{})
+		if !ok {
+			return value
+		}
+		restricted := make([]interface{}, len(l))
+		for i, v := range l {
+			if i < len(shape) {
+				v = restrict(v, shape[i])
+			}
+			restricted[i] = v
+		}
+		return restricted
+	default:
+		return value
+	}
+}
+
+// ObjectName returns "<Kind> <namespace>/<name>", or "<Kind> <name>" for cluster-scoped objects.
+func ObjectName(obj client.Object) string {
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	if obj.GetNamespace() == "" {
+		return kind + " " + obj.GetName()
+	}
+	return kind + " " + obj.GetNamespace() + "/" + obj.GetName()
+}
+
+func containsKind(kinds []schema.GroupVersionKind, gvk schema.GroupVersionKind) bool {
+	for _, k := range kinds {
+		if k == gvk {
+			return true
+		}
+	}
+	return false
+}
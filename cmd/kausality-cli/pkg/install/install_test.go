@@ -0,0 +1,324 @@
+package install
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/yaml"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+)
+
+func newScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(apiextensionsv1.AddToScheme(scheme))
+	utilruntime.Must(kausalityv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(kausalityv1beta1.AddToScheme(scheme))
+	return scheme
+}
+
+func TestRender(t *testing.T) {
+	objs, err := New(nil, Options{Namespace: "infra", Version: "1.2.3"}).Render()
+	require.NoError(t, err)
+
+	names := map[string]bool{}
+	for _, obj := range objs {
+		assert.False(t, names[ObjectName(obj)], "duplicate %s", ObjectName(obj))
+		names[ObjectName(obj)] = true
+		assert.Equal(t, FieldManager, obj.GetLabels()[ManagedByLabel])
+		assert.Equal(t, "kausality", obj.GetLabels()[InstanceLabel])
+	}
+	assert.Equal(t, "CustomResourceDefinition", objs[0].GetKind(), "CRDs are applied first")
+	assert.True(t, names["Deployment infra/kausality-webhook"])
+	assert.True(t, names["Deployment infra/kausality-controller"])
+	assert.True(t, names["MutatingWebhookConfiguration kausality"])
+	assert.True(t, names["Kausality kausality-default"])
+
+	for _, obj := range objs {
+		if obj.GetKind() == "Deployment" {
+			containers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+			require.Len(t, containers, 1)
+			assert.Contains(t, containers[0].(map[string]interface{})["image"], ":1.2.3")
+		}
+	}
+
+	objs, err = New(nil, Options{SkipPolicy: true}).Render()
+	require.NoError(t, err)
+	for _, obj := range objs {
+		assert.NotEqual(t, "Kausality", obj.GetKind())
+	}
+}
+
+func TestManifestsMatchChart(t *testing.T) {
+	chart := filepath.Join("..", "..", "..", "..", "charts", "kausality")
+	files, err := filepath.Glob(filepath.Join(chart, "crds", "*.yaml"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+	for _, file := range files {
+		want, err := os.ReadFile(file)
+		require.NoError(t, err)
+		got, err := manifests.ReadFile("manifests/crds/" + filepath.Base(file))
+		require.NoError(t, err, "run make gen")
+		assert.Equal(t, string(want), string(got), "%s is out of date, run make gen", filepath.Base(file))
+	}
+
+	data, err := os.ReadFile(filepath.Join(chart, "Chart.yaml"))
+	require.NoError(t, err)
+	var chartMeta struct {
+		AppVersion string `json:"appVersion"`
+	}
+	require.NoError(t, yaml.Unmarshal(data, &chartMeta))
+	assert.Equal(t, chartMeta.AppVersion, DefaultVersion)
+}
+
+func TestInstall(t *testing.T) {
+	ctx := context.Background()
+	c := fake.NewClientBuilder().WithScheme(newScheme()).WithInterceptorFuncs(interceptor.Funcs{
+		Apply: func(ctx context.Context, c client.WithWatch, obj runtime.ApplyConfiguration, opts ...client.ApplyOption) error {
+			// The fake client ignores dry runs of applies
+			if slices.Contains((&client.ApplyOptions{}).ApplyOptions(opts).DryRun, metav1.DryRunAll) {
+				return nil
+			}
+			return c.Apply(ctx, obj, opts...)
+		},
+	}).Build()
+
+	result, err := New(c, Options{}).Install(ctx)
+	require.NoError(t, err)
+	for _, change := range result.Changes {
+		assert.Equal(t, ActionCreated, change.Action, change.Object)
+	}
+
+	// Re-installing changes nothing
+	result, err = New(c, Options{Prune: true}).Install(ctx)
+	require.NoError(t, err)
+	for _, change := range result.Changes {
+		assert.Equal(t, ActionUnchanged, change.Action, change.Object)
+		assert.Empty(t, change.Diff, change.Object)
+	}
+
+	// A dry run reports the diff of a modified object without reverting it
+	deploy := &appsv1.Deployment{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "kausality-system", Name: "kausality-webhook"}, deploy))
+	deploy.Spec.Replicas = ptrTo(int32(3))
+	require.NoError(t, c.Update(ctx, deploy))
+
+	result, err = New(c, Options{DryRun: true}).Install(ctx)
+	require.NoError(t, err)
+	var configured []Change
+	for _, change := range result.Changes {
+		if change.Action != ActionUnchanged {
+			configured = append(configured, change)
+		}
+	}
+	require.Len(t, configured, 1)
+	assert.Equal(t, "Deployment kausality-system/kausality-webhook", configured[0].Object)
+	assert.Contains(t, configured[0].Diff, "-  replicas: 3")
+	assert.Contains(t, configured[0].Diff, "+  replicas: 1")
+
+	var buf bytes.Buffer
+	require.NoError(t, result.WriteText(&buf, true))
+	assert.Contains(t, buf.String(), "Deployment kausality-system/kausality-webhook configured (dry run)\n")
+
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(deploy), deploy))
+	assert.Equal(t, int32(3), *deploy.Spec.Replicas)
+
+	// Skipping the default policy prunes it
+	result, err = New(c, Options{SkipPolicy: true, Prune: true}).Install(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, result.Changes, Change{Object: "Kausality kausality-default", Action: ActionPruned})
+	err = c.Get(ctx, client.ObjectKey{Name: "kausality-default"}, &kausalityv1beta1.Kausality{})
+	assert.True(t, apierrors.IsNotFound(err), "policy pruned")
+}
+
+func TestUninstall(t *testing.T) {
+	ctx := context.Background()
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(appsv1.SchemeGroupVersion.WithKind("Deployment"), meta.RESTScopeNamespace)
+	c := fake.NewClientBuilder().WithScheme(newScheme()).WithRESTMapper(mapper).Build()
+	_, err := New(c, Options{}).Install(ctx)
+	require.NoError(t, err)
+
+	// Rules populated by the controller
+	webhook := &unstructured.Unstructured{}
+	webhook.SetAPIVersion("admissionregistration.k8s.io/v1")
+	webhook.SetKind("MutatingWebhookConfiguration")
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "kausality"}, webhook))
+	webhooks, _, _ := unstructured.NestedSlice(webhook.Object, "webhooks")
+	webhooks[0].(map[string]interface{})["rules"] = []interface{}{
+		map[string]interface{}{"apiGroups": []interface{}{"apps"}, "apiVersions": []interface{}{"*"}, "resources": []interface{}{"deployments", "deployments/status"}, "operations": []interface{}{"CREATE", "UPDATE"}},
+	}
+	require.NoError(t, unstructured.SetNestedSlice(webhook.Object, webhooks, "webhooks"))
+	require.NoError(t, c.Update(ctx, webhook))
+
+	web := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web", Annotations: map[string]string{
+		kausalityv1alpha1.TraceAnnotation:       `[{"apiVersion":"apps/v1","kind":"Deployment","name":"web","generation":1,"user":"alice"}]`,
+		kausalityv1alpha1.ControllersAnnotation: "abcde",
+		kausalityv1alpha1.ApprovalsAnnotation:   `[{"apiVersion":"apps/v1","kind":"ReplicaSet","name":"web-1","mode":"always"}]`,
+	}}}
+	require.NoError(t, c.Create(ctx, web))
+
+	// A dry run changes nothing
+	result, err := New(c, Options{DryRun: true}).Uninstall(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, result.Changes, Change{Object: "Deployment default/web", Action: ActionCleaned})
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(web), web))
+	assert.Contains(t, web.Annotations, kausalityv1alpha1.TraceAnnotation)
+
+	result, err = New(c, Options{KeepCRDs: true}).Uninstall(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, Change{Object: "MutatingWebhookConfiguration kausality", Action: ActionDeleted}, result.Changes[0])
+	assert.Contains(t, result.Changes, Change{Object: "Deployment kausality-system/kausality-controller", Action: ActionDeleted})
+
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(web), web))
+	assert.Equal(t, map[string]string{
+		kausalityv1alpha1.ApprovalsAnnotation: `[{"apiVersion":"apps/v1","kind":"ReplicaSet","name":"web-1","mode":"always"}]`,
+	}, web.Annotations, "bookkeeping annotations removed, approvals kept")
+
+	crds := &apiextensionsv1.CustomResourceDefinitionList{}
+	require.NoError(t, c.List(ctx, crds))
+	assert.Len(t, crds.Items, 3)
+	deploys := &appsv1.DeploymentList{}
+	require.NoError(t, c.List(ctx, deploys, client.InNamespace("kausality-system")))
+	assert.Empty(t, deploys.Items)
+}
+
+func TestUninstall_Rerun(t *testing.T) {
+	ctx := context.Background()
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(appsv1.SchemeGroupVersion.WithKind("Deployment"), meta.RESTScopeNamespace)
+	mapper.Add(kausalityv1beta1.GroupVersion.WithKind("Kausality"), meta.RESTScopeRoot)
+
+	// Lists return one object per page
+	var pages int
+	c := fake.NewClientBuilder().WithScheme(newScheme()).WithRESTMapper(mapper).WithInterceptorFuncs(interceptor.Funcs{
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			listOpts := (&client.ListOptions{}).ApplyOptions(opts)
+			if err := c.List(ctx, list, opts...); err != nil {
+				return err
+			}
+			u, ok := list.(*unstructured.UnstructuredList)
+			if !ok || listOpts.Limit == 0 {
+				return nil
+			}
+			pages++
+			offset := 0
+			if listOpts.Continue != "" {
+				offset = int(listOpts.Continue[0] - '0')
+			}
+			u.Items = u.Items[offset:]
+			u.SetContinue("")
+			if len(u.Items) > 1 {
+				u.Items = u.Items[:1]
+				u.SetContinue(string(rune('0' + offset + 1)))
+			}
+			return nil
+		},
+	}).Build()
+	_, err := New(c, Options{}).Install(ctx)
+	require.NoError(t, err)
+
+	// The policies still name the resources after the webhook configuration
+	// was deleted by a failed uninstall
+	policy := &kausalityv1beta1.Kausality{
+		ObjectMeta: metav1.ObjectMeta{Name: "apps"},
+		Spec: kausalityv1beta1.KausalitySpec{
+			Resources: []kausalityv1beta1.ResourceRule{{APIGroups: []string{"apps"}, Resources: []string{"deployments"}}},
+		},
+	}
+	require.NoError(t, c.Create(ctx, policy))
+	webhook := &unstructured.Unstructured{}
+	webhook.SetAPIVersion("admissionregistration.k8s.io/v1")
+	webhook.SetKind("MutatingWebhookConfiguration")
+	webhook.SetName("kausality")
+	require.NoError(t, c.Delete(ctx, webhook))
+
+	for _, name := range []string{"web", "api", "db"} {
+		require.NoError(t, c.Create(ctx, &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Annotations: map[string]string{
+			kausalityv1alpha1.ControllersAnnotation: "abcde",
+		}}}))
+	}
+
+	result, err := New(c, Options{KeepCRDs: true}).Uninstall(ctx)
+	require.NoError(t, err)
+	for _, name := range []string{"web", "api", "db"} {
+		assert.Contains(t, result.Changes, Change{Object: "Deployment default/" + name, Action: ActionCleaned})
+		deploy := &appsv1.Deployment{}
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "default", Name: name}, deploy))
+		assert.Empty(t, deploy.Annotations)
+	}
+	assert.Greater(t, pages, 3, "lists are paginated")
+}
+
+func TestMigrateStorage(t *testing.T) {
+	ctx := context.Background()
+	crd := &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "kausalities.kausality.io"},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: "kausality.io",
+			Names: apiextensionsv1.CustomResourceDefinitionNames{Kind: "Kausality", ListKind: "KausalityList", Plural: "kausalities"},
+			Scope: apiextensionsv1.ClusterScoped,
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+				{Name: "v1alpha1", Served: true},
+				{Name: "v1beta1", Served: true, Storage: true},
+			},
+		},
+		Status: apiextensionsv1.CustomResourceDefinitionStatus{StoredVersions: []string{"v1alpha1", "v1beta1"}},
+	}
+	policy := &kausalityv1beta1.Kausality{ObjectMeta: metav1.ObjectMeta{Name: "apps"}}
+	var updated []string
+	c := fake.NewClientBuilder().WithScheme(newScheme()).WithObjects(crd, policy).WithStatusSubresource(crd).WithInterceptorFuncs(interceptor.Funcs{
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			updated = append(updated, obj.GetName())
+			return c.Update(ctx, obj, opts...)
+		},
+	}).Build()
+
+	// A dry run rewrites nothing for real and keeps the stored versions
+	result, err := New(c, Options{DryRun: true}).MigrateStorage(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Object: "Kausality apps", Action: ActionMigrated},
+		{Object: "CustomResourceDefinition kausalities.kausality.io", Action: ActionMigrated},
+	}, result.Changes)
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(crd), crd))
+	assert.Equal(t, []string{"v1alpha1", "v1beta1"}, crd.Status.StoredVersions)
+
+	updated = nil
+	result, err = New(c, Options{}).MigrateStorage(ctx)
+	require.NoError(t, err)
+	assert.Len(t, result.Changes, 2)
+	assert.Equal(t, []string{"apps"}, updated)
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(crd), crd))
+	assert.Equal(t, []string{"v1beta1"}, crd.Status.StoredVersions)
+
+	// Nothing left to migrate
+	result, err = New(c, Options{}).MigrateStorage(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, result.Changes)
+}
+
+func ptrTo[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,313 @@
+// Package output writes kausality-cli results as text, JSON or YAML.
+//
+// JSON and YAML documents are the types of this package. They are objects, so
+// fields can be added without breaking consumers; existing fields are not
+// renamed or removed. Scripts should use them instead of parsing text output.
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/approval"
+)
+
+// Output formats.
+const (
+	Text = "text"
+	JSON = "json"
+	YAML = "yaml"
+	// Markdown is a report for pull request comments, written by "plan".
+	Markdown = "markdown"
+)
+
+// Validate returns an error if format is not one of allowed.
+func Validate(format string, allowed ...string) error {
+	if !slices.Contains(allowed, format) {
+		return fmt.Errorf("unknown output format %q: must be %s", format, strings.Join(allowed, ", "))
+	}
+	return nil
+}
+
+// Write writes a document as indented JSON or as YAML.
+func Write(w io.Writer, format string, doc interface{}) error {
+	switch format {
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(doc)
+	case YAML:
+		data, err := yaml.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	default:
+		return fmt.Errorf("format %q is not structured", format)
+	}
+}
+
+// ObjectReference identifies an object.
+type ObjectReference struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+}
+
+// DriftList is the output of "drift list".
+type DriftList struct {
+	Items []Drift `json:"items"`
+}
+
+// Drift is a child drifting from its parent.
+type Drift struct {
+	// ID identifies the drift as "namespace/parent/ChildKind/child".
+	ID string `json:"id"`
+	// Phase is "Detected" or "Resolved".
+	Phase  string          `json:"phase"`
+	Parent ObjectReference `json:"parent"`
+	Child  ObjectReference `json:"child"`
+}
+
+// Trace is the output of "trace show".
+type Trace struct {
+	Object ObjectReference `json:"object"`
+	// Hops is the causal chain, origin first, as in the kausality.io/trace annotation.
+	Hops []kausalityv1alpha1.Hop `json:"hops"`
+}
+
+// TraceVerification is the output of "trace verify".
+type TraceVerification struct {
+	Object ObjectReference `json:"object"`
+	// Valid is true if all hops have valid signatures and the final hop is
+	// the object's.
+	Valid bool          `json:"valid"`
+	Hops  []VerifiedHop `json:"hops"`
+	// Mismatch tells why the final hop is not the object's.
+	Mismatch string `json:"mismatch,omitempty"`
+}
+
+// VerifiedHop is a hop of a trace with the verification result of its
+// signature: "valid", "invalid" or "missing".
+type VerifiedHop struct {
+	kausalityv1alpha1.Hop `json:",inline"`
+	Verification          string `json:"verification"`
+}
+
+// Mode sources, in order of precedence.
+const (
+	SourceObjectAnnotation    = "object-annotation"
+	SourceNamespaceAnnotation = "namespace-annotation"
+	SourcePolicy              = "policy"
+	SourceNamespacePolicy     = "namespace-policy"
+	SourceDefault             = "default"
+)
+
+// EffectiveMode is the output of "effective-mode".
+type EffectiveMode struct {
+	Object ObjectReference `json:"object"`
+	// Mode is "log" or "enforce".
+	Mode kausalityv1alpha1.Mode `json:"mode"`
+	// Source is where the mode comes from: object-annotation,
+	// namespace-annotation, policy, namespace-policy or default.
+	Source string `json:"source"`
+	// Policy is the name of the most specific matching policy, if any.
+	Policy string `json:"policy,omitempty"`
+	// NamespacePolicy is the name of the KausalityPolicy tightening the mode
+	// of the policy, if any.
+	NamespacePolicy string `json:"namespacePolicy,omitempty"`
+	// DriftExclusion is the name of the policy excluding the object from
+	// drift evaluation, if any.
+	DriftExclusion string `json:"driftExclusion,omitempty"`
+	// Tracked is true if a policy tracks the object.
+	Tracked bool `json:"tracked"`
+}
+
+// PolicyTestResult is the output of "policy test".
+type PolicyTestResult struct {
+	Passed int              `json:"passed"`
+	Failed int              `json:"failed"`
+	Tests  []PolicyTestCase `json:"tests"`
+}
+
+// PolicyTestCase is the outcome of a test case of "policy test".
+type PolicyTestCase struct {
+	Name string `json:"name"`
+	// Mode is the resolved mode, "log" or "enforce".
+	Mode kausalityv1alpha1.Mode `json:"mode"`
+	// Tracked is true if a policy tracks the resource.
+	Tracked bool `json:"tracked"`
+	// Policy is the name of the most specific matching policy, if any.
+	Policy string `json:"policy,omitempty"`
+	// Failures describe the expectations that were not met.
+	Failures []string `json:"failures,omitempty"`
+}
+
+// PolicyDiff is the output of "policy diff".
+type PolicyDiff struct {
+	// A and B name the compared clusters or bundles.
+	A string `json:"a"`
+	B string `json:"b"`
+	// Compared is the number of resource and namespace combinations compared.
+	Compared int `json:"compared"`
+	// Differences are the combinations whose effective policy differs.
+	Differences []PolicyDifference `json:"differences"`
+}
+
+// PolicyDifference is a resource whose effective policy differs between
+// the sides of "policy diff".
+type PolicyDifference struct {
+	// Resource is "group/resource", or "resource" for the core group. "*"
+	// stands for the resources of the group not named by any policy.
+	Resource string `json:"resource"`
+	// Namespace is empty for cluster-scoped objects.
+	Namespace string          `json:"namespace,omitempty"`
+	A         EffectivePolicy `json:"a"`
+	B         EffectivePolicy `json:"b"`
+}
+
+// EffectivePolicy is the policy resolved for a resource in a namespace.
+type EffectivePolicy struct {
+	// Mode is "log" or "enforce".
+	Mode kausalityv1alpha1.Mode `json:"mode"`
+	// Tracked is true if a policy tracks the resource.
+	Tracked bool `json:"tracked"`
+	// Policy is the name of the most specific matching policy, if any.
+	Policy string `json:"policy,omitempty"`
+}
+
+// Sources of the objects of "actor changes".
+const (
+	// SourceUpdaters is the kausality.io/updaters annotation of an object
+	// in the cluster.
+	SourceUpdaters = "updaters"
+	// SourceTrace is a trace record of the backend.
+	SourceTrace = "trace"
+	// SourceDrift is a drift report of the backend.
+	SourceDrift = "drift"
+)
+
+// ActorChanges is the output of "actor changes".
+type ActorChanges struct {
+	User string `json:"user,omitempty"`
+	// Hash is the updater hash of the actor in kausality.io/updaters.
+	Hash  string    `json:"hash"`
+	Since time.Time `json:"since"`
+	// Users are the usernames of the changes reported by the backend, more
+	// than one if a hash matches several users.
+	Users      []string         `json:"users"`
+	Namespaces []ActorNamespace `json:"namespaces"`
+}
+
+// ActorNamespace are the objects changed in a namespace, "" for
+// cluster-scoped objects.
+type ActorNamespace struct {
+	Namespace string      `json:"namespace"`
+	Kinds     []ActorKind `json:"kinds"`
+}
+
+// ActorKind are the objects of a kind changed in a namespace.
+type ActorKind struct {
+	APIVersion string          `json:"apiVersion"`
+	Kind       string          `json:"kind"`
+	Objects    []ChangedObject `json:"objects"`
+}
+
+// ChangedObject is an object changed by the actor.
+type ChangedObject struct {
+	Name string `json:"name"`
+	// Operations are the admission operations reported by the backend.
+	Operations []string `json:"operations,omitempty"`
+	// Mutations is the number of admitted mutations reported by the backend.
+	Mutations int `json:"mutations,omitempty"`
+	// DriftIDs are the drift reports of the changes.
+	DriftIDs []string `json:"driftIDs,omitempty"`
+	// FirstSeen and LastSeen are the times of the changes reported by the
+	// backend. The updaters annotation does not record when.
+	FirstSeen *time.Time `json:"firstSeen,omitempty"`
+	LastSeen  *time.Time `json:"lastSeen,omitempty"`
+	// Sources are where the changes are known from: updaters, trace or drift.
+	Sources []string `json:"sources"`
+}
+
+// Approval is the output of "approve".
+type Approval struct {
+	Parent ObjectReference `json:"parent"`
+	// Mode is the approval mode: once, generation, or always.
+	Mode string `json:"mode"`
+	// Children are the names of the approved children.
+	Children []string `json:"children"`
+	// Approvals is the resulting kausality.io/approvals value.
+	Approvals []approval.Approval `json:"approvals"`
+	// Applied is false for dry runs.
+	Applied bool `json:"applied"`
+}
+
+// Outcomes of the changes of "plan".
+const (
+	// OutcomeOrigin is a change starting a new trace, e.g. by a user or CI.
+	OutcomeOrigin = "origin"
+	// OutcomePropagated is a change by the parent's controller while the
+	// parent reconciles, continuing its trace.
+	OutcomePropagated = "propagated"
+	// OutcomeDrift is drift that would be admitted, e.g. in log mode or
+	// approved.
+	OutcomeDrift = "drift"
+	// OutcomeDenied is a change that would be denied.
+	OutcomeDenied = "denied"
+	// OutcomeUnchanged is an update not changing the spec.
+	OutcomeUnchanged = "unchanged"
+	// OutcomeUntracked is an object no policy tracks.
+	OutcomeUntracked = "untracked"
+	// OutcomeError is an object that could not be evaluated.
+	OutcomeError = "error"
+)
+
+// Plan is the output of "plan".
+type Plan struct {
+	// User is the user the changes were evaluated for.
+	User    string          `json:"user"`
+	Summary PlanSummary     `json:"summary"`
+	Changes []PlannedChange `json:"changes"`
+}
+
+// PlanSummary counts the changes of a plan by outcome.
+type PlanSummary struct {
+	Origin     int `json:"origin"`
+	Propagated int `json:"propagated"`
+	Drift      int `json:"drift"`
+	Denied     int `json:"denied"`
+	Unchanged  int `json:"unchanged"`
+	Untracked  int `json:"untracked"`
+	Errors     int `json:"errors"`
+}
+
+// PlannedChange is the predicted admission of an object of "plan".
+type PlannedChange struct {
+	Object ObjectReference `json:"object"`
+	// Operation is CREATE or UPDATE. Empty for untracked objects and errors.
+	Operation string `json:"operation,omitempty"`
+	// Outcome is origin, propagated, drift, denied, unchanged, untracked or
+	// error.
+	Outcome string `json:"outcome"`
+	// Mode is the drift detection mode, "log" or "enforce".
+	Mode string `json:"mode,omitempty"`
+	// Parent is the parent as "<apiVersion>/<kind>:<namespace>/<name>".
+	Parent string `json:"parent,omitempty"`
+	// Message explains denials, drift and errors.
+	Message string `json:"message,omitempty"`
+	// DenialReason is the reason of a denial, e.g. Drift or Frozen.
+	DenialReason string `json:"denialReason,omitempty"`
+	// ApprovalStatus is how drift is handled: approved, rejected,
+	// overridden, suppressed or unresolved.
+	ApprovalStatus string `json:"approvalStatus,omitempty"`
+}
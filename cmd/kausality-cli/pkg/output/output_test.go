@@ -0,0 +1,54 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	list := DriftList{Items: []Drift{{
+		ID:     "default/web/ReplicaSet/web-abc",
+		Phase:  "Detected",
+		Parent: ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "web"},
+		Child:  ObjectReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Namespace: "default", Name: "web-abc"},
+	}}}
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, JSON, list))
+	assert.JSONEq(t, `{"items":[{
+		"id":"default/web/ReplicaSet/web-abc",
+		"phase":"Detected",
+		"parent":{"apiVersion":"apps/v1","kind":"Deployment","namespace":"default","name":"web"},
+		"child":{"apiVersion":"apps/v1","kind":"ReplicaSet","namespace":"default","name":"web-abc"}
+	}]}`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, Write(&buf, YAML, EffectiveMode{
+		Object: ObjectReference{APIVersion: "v1", Kind: "Namespace", Name: "prod"},
+		Mode:   "log",
+		Source: SourceDefault,
+	}))
+	assert.Equal(t, `mode: log
+object:
+  apiVersion: v1
+  kind: Namespace
+  name: prod
+source: default
+tracked: false
+`, buf.String())
+
+	// Empty lists are arrays, not null
+	buf.Reset()
+	require.NoError(t, Write(&buf, JSON, DriftList{Items: []Drift{}}))
+	assert.JSONEq(t, `{"items":[]}`, buf.String())
+
+	assert.Error(t, Write(&buf, Text, list))
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(YAML, Text, JSON, YAML))
+	assert.Error(t, Validate("xml", Text, JSON, YAML))
+}
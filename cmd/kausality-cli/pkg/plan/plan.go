@@ -0,0 +1,391 @@
+// Package plan predicts how the webhook would admit rendered manifests: for
+// each object, whether applying it is an origin change, drift, or denied
+// under the current policies and the state of its parent in the cluster. It
+// runs the webhook's admission handler as a dry run against the cluster and
+// writes nothing, so that CI can annotate pull requests before they are
+// applied.
+//
+// Objects are evaluated independently against the live cluster, e.g. a
+// Deployment and its ReplicaSet in the same manifests do not see each
+// other's changes.
+package plan
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-logr/logr"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	"github.com/kausality-io/kausality/cmd/kausality-cli/pkg/output"
+	"github.com/kausality-io/kausality/pkg/admission"
+	"github.com/kausality-io/kausality/pkg/config"
+	"github.com/kausality-io/kausality/pkg/policy"
+)
+
+// DefaultFieldManager is the field manager of kubectl apply --server-side.
+const DefaultFieldManager = "kubectl"
+
+// Options configure a plan.
+type Options struct {
+	// User is the username applying the manifests, e.g. the ServiceAccount
+	// of the CI pipeline.
+	User string
+	// FieldManager is the field manager applying them. Defaults to
+	// DefaultFieldManager.
+	FieldManager string
+	// Namespace is the namespace of namespaced objects without one.
+	Namespace string
+	// DriftConfig is the webhook's config file, for parent profiles,
+	// lifecycle mappings and Flux. If nil, the defaults are used.
+	DriftConfig *config.Config
+}
+
+// ReadManifests reads the objects of YAML or JSON documents, including
+// Lists. Empty documents are skipped.
+func ReadManifests(r io.Reader) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+	decoder := utilyaml.NewYAMLOrJSONDecoder(r, 4096)
+	for {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				return objects, nil
+			}
+			return nil, fmt.Errorf("failed to decode document: %w", err)
+		}
+		if len(raw) == 0 || string(raw) == "null" {
+			continue
+		}
+		obj, err := runtime.Decode(unstructured.UnstructuredJSONScheme, raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid object: %w", err)
+		}
+		switch obj := obj.(type) {
+		case *unstructured.Unstructured:
+			if obj.GetName() == "" {
+				return nil, fmt.Errorf("%s without name", obj.GetKind())
+			}
+			objects = append(objects, obj)
+		case *unstructured.UnstructuredList:
+			for i := range obj.Items {
+				objects = append(objects, &obj.Items[i])
+			}
+		}
+	}
+}
+
+// ReadFiles reads the objects of files, of the .yaml, .yml and .json files
+// in directories and their subdirectories, or of stdin for "-".
+func ReadFiles(paths ...string) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+	read := func(path string, r io.Reader) error {
+		objs, err := ReadManifests(r)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		objects = append(objects, objs...)
+		return nil
+	}
+	readFile := func(path string) error {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		return read(path, f)
+	}
+
+	for _, path := range paths {
+		if path == "-" {
+			if err := read("stdin", os.Stdin); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			if err := readFile(path); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		err = filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			switch strings.ToLower(filepath.Ext(file)) {
+			case ".yaml", ".yml", ".json":
+				return readFile(file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return objects, nil
+}
+
+// CurrentUser returns the user the client authenticates as. Clusters before
+// Kubernetes 1.28 may not serve SelfSubjectReviews.
+func CurrentUser(ctx context.Context, c client.Client) (string, error) {
+	review := &authenticationv1.SelfSubjectReview{}
+	if err := c.Create(ctx, review); err != nil {
+		return "", err
+	}
+	return review.Status.UserInfo.Username, nil
+}
+
+// Run predicts the outcome of applying each object, with the Kausality
+// policies of the cluster.
+func Run(ctx context.Context, c client.Client, objects []*unstructured.Unstructured, opts Options) (*output.Plan, error) {
+	if opts.User == "" {
+		return nil, errors.New("user is required")
+	}
+	if opts.FieldManager == "" {
+		opts.FieldManager = DefaultFieldManager
+	}
+	store := policy.NewStore(c, logr.Discard())
+	if err := store.Refresh(ctx); err != nil {
+		return nil, fmt.Errorf("failed to list Kausality policies: %w", err)
+	}
+	p := &planner{
+		client: c,
+		store:  store,
+		handler: admission.NewHandler(admission.Config{
+			Client:         c,
+			Log:            logr.Discard(),
+			DriftConfig:    opts.DriftConfig,
+			PolicyResolver: store,
+		}),
+		opts: opts,
+	}
+
+	result := &output.Plan{User: opts.User, Changes: []output.PlannedChange{}}
+	for _, obj := range objects {
+		change := p.plan(ctx, obj.DeepCopy())
+		result.Changes = append(result.Changes, change)
+		switch change.Outcome {
+		case output.OutcomeOrigin:
+			result.Summary.Origin++
+		case output.OutcomePropagated:
+			result.Summary.Propagated++
+		case output.OutcomeDrift:
+			result.Summary.Drift++
+		case output.OutcomeDenied:
+			result.Summary.Denied++
+		case output.OutcomeUnchanged:
+			result.Summary.Unchanged++
+		case output.OutcomeUntracked:
+			result.Summary.Untracked++
+		case output.OutcomeError:
+			result.Summary.Errors++
+		}
+	}
+	return result, nil
+}
+
+type planner struct {
+	client  client.Client
+	store   *policy.Store
+	handler *admission.Handler
+	opts    Options
+}
+
+// plan predicts the outcome of applying obj.
+func (p *planner) plan(ctx context.Context, obj *unstructured.Unstructured) output.PlannedChange {
+	gvk := obj.GroupVersionKind()
+	mapping, err := p.client.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return planError(obj, fmt.Errorf("failed to map %s to a resource: %w", gvk.Kind, err))
+	}
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace && obj.GetNamespace() == "" {
+		obj.SetNamespace(p.opts.Namespace)
+	}
+
+	// The webhook only intercepts objects of tracked resources. Cluster-scoped
+	// Crossplane objects inherit the namespace of their claim.
+	policyNamespace := policy.EffectiveNamespace(obj.GetNamespace(), obj.GetLabels())
+	var nsLabels map[string]string
+	if policyNamespace != "" {
+		ns := &unstructured.Unstructured{}
+		ns.SetAPIVersion("v1")
+		ns.SetKind("Namespace")
+		if err := p.client.Get(ctx, client.ObjectKey{Name: policyNamespace}, ns); err != nil && !apierrors.IsNotFound(err) {
+			return planError(obj, fmt.Errorf("failed to get namespace %q: %w", policyNamespace, err))
+		}
+		nsLabels = ns.GetLabels()
+	}
+	tracked := p.store.IsTracked(policy.ResourceContext{
+		GVR:             mapping.Resource,
+		Namespace:       policyNamespace,
+		Name:            obj.GetName(),
+		NamespaceLabels: nsLabels,
+		ObjectLabels:    obj.GetLabels(),
+	})
+	if !tracked {
+		return output.PlannedChange{Object: objectReference(obj), Outcome: output.OutcomeUntracked}
+	}
+
+	result, err := p.handler.WhatIf(ctx, admission.WhatIfRequest{Object: obj, User: p.opts.User, FieldManager: p.opts.FieldManager})
+	if err != nil {
+		return planError(obj, err)
+	}
+	change := output.PlannedChange{
+		Object:         objectReference(obj),
+		Operation:      string(result.Operation),
+		Outcome:        outcome(result),
+		Mode:           result.Mode,
+		Message:        result.Message,
+		DenialReason:   result.DenialReason,
+		ApprovalStatus: result.ApprovalStatus,
+	}
+	if d := result.Drift; d != nil {
+		change.Parent = d.Parent
+		if d.Detected && change.Message == "" {
+			change.Message = d.Reason
+		}
+	}
+	if len(result.Warnings) > 0 && change.Message == "" {
+		change.Message = strings.Join(result.Warnings, "; ")
+	}
+	return change
+}
+
+// outcome classifies the evaluation of a change.
+func outcome(result *admission.WhatIfResponse) string {
+	switch {
+	case !result.Allowed:
+		return output.OutcomeDenied
+	case result.Drift == nil:
+		// Updates without spec change are admitted before drift detection
+		return output.OutcomeUnchanged
+	case result.Drift.Detected:
+		return output.OutcomeDrift
+	}
+	// A change continuing the trace of its parent is caused by the parent's
+	// controller, any other change starts a new trace as origin
+	var hops []kausalityv1alpha1.Hop
+	if trace := result.AuditAnnotations[kausalityv1alpha1.DefaultAuditKeyPrefix+kausalityv1alpha1.AuditKeyTrace]; trace != "" {
+		_ = json.Unmarshal([]byte(trace), &hops)
+	}
+	if len(hops) > 1 {
+		return output.OutcomePropagated
+	}
+	return output.OutcomeOrigin
+}
+
+func planError(obj *unstructured.Unstructured, err error) output.PlannedChange {
+	return output.PlannedChange{Object: objectReference(obj), Outcome: output.OutcomeError, Message: err.Error()}
+}
+
+func objectReference(obj *unstructured.Unstructured) output.ObjectReference {
+	return output.ObjectReference{
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Namespace:  obj.GetNamespace(),
+		Name:       obj.GetName(),
+	}
+}
+
+// WriteText writes the plan as one line per change and a summary.
+func WriteText(w io.Writer, p *output.Plan) error {
+	for _, c := range p.Changes {
+		line := fmt.Sprintf("%-10s %s", strings.ToUpper(c.Outcome), reference(c.Object))
+		if c.Parent != "" {
+			line += " (parent " + c.Parent + ")"
+		}
+		if c.Message != "" {
+			line += ": " + c.Message
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(w, summary(p.Summary))
+	return err
+}
+
+// WriteMarkdown writes the plan as a Markdown table, e.g. for a pull
+// request comment. Unchanged and untracked objects are only counted.
+func WriteMarkdown(w io.Writer, p *output.Plan) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### Kausality plan\n\n%s, applied as `%s`.\n", summary(p.Summary), p.User)
+	rows := 0
+	for _, c := range p.Changes {
+		if c.Outcome == output.OutcomeUnchanged || c.Outcome == output.OutcomeUntracked {
+			continue
+		}
+		if rows == 0 {
+			b.WriteString("\n| Outcome | Object | Parent | Mode | Details |\n|---|---|---|---|---|\n")
+		}
+		rows++
+		fmt.Fprintf(&b, "| %s | `%s` | %s | %s | %s |\n", c.Outcome, reference(c.Object), code(c.Parent), c.Mode, markdownCell(c.Message))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// summary counts the changes by outcome, e.g. "1 origin, 2 denied".
+func summary(s output.PlanSummary) string {
+	var parts []string
+	for _, c := range []struct {
+		outcome string
+		count   int
+	}{
+		{output.OutcomeOrigin, s.Origin},
+		{output.OutcomePropagated, s.Propagated},
+		{output.OutcomeDrift, s.Drift},
+		{output.OutcomeDenied, s.Denied},
+		{output.OutcomeUnchanged, s.Unchanged},
+		{output.OutcomeUntracked, s.Untracked},
+		{output.OutcomeError, s.Errors},
+	} {
+		if c.count > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", c.count, c.outcome))
+		}
+	}
+	if len(parts) == 0 {
+		return "no changes"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// reference formats an object as "Kind namespace/name".
+func reference(o output.ObjectReference) string {
+	if o.Namespace == "" {
+		return o.Kind + " " + o.Name
+	}
+	return o.Kind + " " + o.Namespace + "/" + o.Name
+}
+
+func code(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + s + "`"
+}
+
+// markdownCell escapes a table cell.
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
@@ -0,0 +1,234 @@
+package plan
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+	"github.com/kausality-io/kausality/cmd/kausality-cli/pkg/output"
+	"github.com/kausality-io/kausality/pkg/approval"
+	"github.com/kausality-io/kausality/pkg/controller"
+)
+
+const (
+	deploymentController = "system:serviceaccount:kube-system:deployment-controller"
+	ciUser               = "system:serviceaccount:ci:deployer"
+)
+
+// newClient returns a client of a cluster enforcing on ReplicaSets, with
+// the stable Deployments "web" and "frozen" and their ReplicaSets of one
+// replica, last updated by the Deployment controller.
+func newClient(t *testing.T, webAnnotations map[string]string) client.Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, kausalityv1alpha1.AddToScheme(scheme))
+	require.NoError(t, kausalityv1beta1.AddToScheme(scheme))
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{appsv1.SchemeGroupVersion, corev1.SchemeGroupVersion})
+	mapper.Add(appsv1.SchemeGroupVersion.WithKind("Deployment"), meta.RESTScopeNamespace)
+	mapper.Add(appsv1.SchemeGroupVersion.WithKind("ReplicaSet"), meta.RESTScopeNamespace)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("ConfigMap"), meta.RESTScopeNamespace)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Namespace"), meta.RESTScopeRoot)
+
+	deployment := func(name string, annotations map[string]string) *appsv1.Deployment {
+		ann := map[string]string{
+			controller.PhaseAnnotation:       controller.PhaseValueInitialized,
+			controller.ControllersAnnotation: controller.HashUsername(deploymentController),
+		}
+		for k, v := range annotations {
+			ann[k] = v
+		}
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, UID: types.UID(name + "-uid"), Generation: 1, Annotations: ann},
+			Spec:       appsv1.DeploymentSpec{Replicas: ptr.To[int32](1)},
+			Status:     appsv1.DeploymentStatus{ObservedGeneration: 1},
+		}
+	}
+	replicaSet := func(name, parent string) *appsv1.ReplicaSet {
+		return &appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      name,
+				Annotations: map[string]string{
+					controller.UpdatersAnnotation: controller.HashUsername(deploymentController),
+				},
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: "apps/v1", Kind: "Deployment", Name: parent, UID: types.UID(parent + "-uid"), Controller: ptr.To(true),
+				}},
+			},
+			Spec: appsv1.ReplicaSetSpec{Replicas: ptr.To[int32](1), MinReadySeconds: 5},
+		}
+	}
+	policy := &kausalityv1beta1.Kausality{
+		ObjectMeta: metav1.ObjectMeta{Name: "replicasets"},
+		Spec: kausalityv1beta1.KausalitySpec{
+			Resources: []kausalityv1beta1.ResourceRule{{APIGroups: []string{"apps"}, Resources: []string{"replicasets"}}},
+			Mode:      kausalityv1beta1.ModeEnforce,
+		},
+	}
+	return fake.NewClientBuilder().WithScheme(scheme).WithRESTMapper(mapper).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		deployment("web", webAnnotations),
+		deployment("frozen", map[string]string{approval.FreezeAnnotation: `{"user":"admin","message":"incident"}`}),
+		replicaSet("web-abc", "web"),
+		replicaSet("web-def", "web"),
+		replicaSet("frozen-abc", "frozen"),
+		policy,
+	).Build()
+}
+
+const manifests = `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  key: value
+---
+# Scaled up
+apiVersion: apps/v1
+kind: ReplicaSet
+metadata:
+  name: web-abc
+spec:
+  replicas: 3
+---
+---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: apps/v1
+  kind: ReplicaSet
+  metadata:
+    name: web-def
+  spec:
+    replicas: 1
+- apiVersion: apps/v1
+  kind: ReplicaSet
+  metadata:
+    name: frozen-abc
+  spec:
+    replicas: 2
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+`
+
+func TestRun(t *testing.T) {
+	objects, err := ReadManifests(strings.NewReader(manifests))
+	require.NoError(t, err)
+	require.Len(t, objects, 5)
+	c := newClient(t, nil)
+
+	result, err := Run(context.Background(), c, objects, Options{User: ciUser, Namespace: "default"})
+	require.NoError(t, err)
+	assert.Equal(t, output.PlanSummary{Origin: 1, Denied: 1, Unchanged: 1, Untracked: 1, Errors: 1}, result.Summary)
+	require.Len(t, result.Changes, 5)
+
+	assert.Equal(t, output.PlannedChange{
+		Object:  output.ObjectReference{APIVersion: "v1", Kind: "ConfigMap", Namespace: "default", Name: "settings"},
+		Outcome: output.OutcomeUntracked,
+	}, result.Changes[0])
+	assert.Equal(t, output.PlannedChange{
+		Object:    output.ObjectReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Namespace: "default", Name: "web-abc"},
+		Operation: "UPDATE",
+		Outcome:   output.OutcomeOrigin,
+		Mode:      "enforce",
+		Parent:    "apps/v1/Deployment:default/web",
+	}, result.Changes[1], "changes of others than the controller are origins")
+	assert.Equal(t, output.OutcomeUnchanged, result.Changes[2].Outcome, "defaulted fields left out of the manifest are kept")
+	assert.Equal(t, output.OutcomeDenied, result.Changes[3].Outcome)
+	assert.Equal(t, "Frozen", result.Changes[3].DenialReason)
+	assert.Contains(t, result.Changes[3].Message, "incident")
+	assert.Equal(t, output.OutcomeError, result.Changes[4].Outcome)
+	assert.Contains(t, result.Changes[4].Message, "Widget")
+
+	// Nothing is written
+	rs := &appsv1.ReplicaSet{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "web-abc"}, rs))
+	assert.Equal(t, int32(1), *rs.Spec.Replicas)
+
+	_, err = Run(context.Background(), c, objects, Options{Namespace: "default"})
+	assert.Error(t, err, "user is required")
+}
+
+func TestRun_Drift(t *testing.T) {
+	objects, err := ReadManifests(strings.NewReader(`{"apiVersion":"apps/v1","kind":"ReplicaSet","metadata":{"namespace":"default","name":"web-abc"},"spec":{"replicas":3}}`))
+	require.NoError(t, err)
+
+	// The controller changing a child of its stable parent is drift
+	result, err := Run(context.Background(), newClient(t, nil), objects, Options{User: deploymentController})
+	require.NoError(t, err)
+	require.Len(t, result.Changes, 1)
+	assert.Equal(t, output.OutcomeDenied, result.Changes[0].Outcome)
+	assert.Equal(t, "Drift", result.Changes[0].DenialReason)
+	assert.Equal(t, "unresolved", result.Changes[0].ApprovalStatus)
+
+	// Approved drift is admitted
+	approved := newClient(t, map[string]string{
+		approval.ApprovalsAnnotation: `[{"apiVersion":"apps/v1","kind":"ReplicaSet","name":"web-abc","mode":"always"}]`,
+	})
+	result, err = Run(context.Background(), approved, objects, Options{User: deploymentController})
+	require.NoError(t, err)
+	assert.Equal(t, output.OutcomeDrift, result.Changes[0].Outcome)
+	assert.Equal(t, "approved", result.Changes[0].ApprovalStatus)
+	assert.Equal(t, output.PlanSummary{Drift: 1}, result.Summary)
+}
+
+func TestReadManifests(t *testing.T) {
+	_, err := ReadManifests(strings.NewReader("apiVersion: v1\nkind: ConfigMap\n"))
+	assert.ErrorContains(t, err, "ConfigMap without name")
+	_, err = ReadManifests(strings.NewReader("metadata:\n  name: nameless\n"))
+	assert.Error(t, err)
+
+	objects, err := ReadManifests(strings.NewReader("---\n# only comments\n"))
+	require.NoError(t, err)
+	assert.Empty(t, objects)
+}
+
+func TestWrite(t *testing.T) {
+	p := &output.Plan{
+		User:    ciUser,
+		Summary: output.PlanSummary{Origin: 1, Denied: 1, Unchanged: 1},
+		Changes: []output.PlannedChange{
+			{Object: output.ObjectReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Namespace: "default", Name: "web-abc"}, Outcome: output.OutcomeOrigin, Mode: "enforce", Parent: "apps/v1/Deployment:default/web"},
+			{Object: output.ObjectReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Namespace: "default", Name: "web-def"}, Outcome: output.OutcomeUnchanged},
+			{Object: output.ObjectReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Namespace: "default", Name: "frozen-abc"}, Outcome: output.OutcomeDenied, Mode: "enforce", Message: "parent frozen | incident"},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteText(&buf, p))
+	assert.Equal(t, `ORIGIN     ReplicaSet default/web-abc (parent apps/v1/Deployment:default/web)
+UNCHANGED  ReplicaSet default/web-def
+DENIED     ReplicaSet default/frozen-abc: parent frozen | incident
+1 origin, 1 denied, 1 unchanged
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteMarkdown(&buf, p))
+	assert.Equal(t, "### Kausality plan\n\n1 origin, 1 denied, 1 unchanged, applied as `"+ciUser+"`.\n\n"+
+		"| Outcome | Object | Parent | Mode | Details |\n|---|---|---|---|---|\n"+
+		"| origin | `ReplicaSet default/web-abc` | `apps/v1/Deployment:default/web` | enforce |  |\n"+
+		"| denied | `ReplicaSet default/frozen-abc` |  | enforce | parent frozen \\| incident |\n", buf.String())
+}
@@ -0,0 +1,264 @@
+// Package policydiff compares the effective drift detection policy of two
+// clusters or bundles, so that the policies of many clusters can be kept
+// consistent. Rather than comparing policy objects, which may be named and
+// split differently, it resolves the mode and tracking of every resource
+// named by the policies of either side in every namespace, the way the
+// webhook resolves them, and reports where the two sides disagree.
+//
+// Namespaces of clusters are compared with their labels and annotations;
+// bundles know no namespaces but those named by their policies. Namespaces
+// of only one cluster are not compared, and object selectors are evaluated
+// for objects without labels.
+package policydiff
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+	"github.com/kausality-io/kausality/cmd/kausality-cli/pkg/output"
+	"github.com/kausality-io/kausality/cmd/kausality-cli/pkg/policytest"
+	"github.com/kausality-io/kausality/pkg/policy"
+)
+
+// Namespace is a namespace of a cluster.
+type Namespace struct {
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+// Side is the policy of one cluster or bundle.
+type Side struct {
+	// Name identifies the side in the output, e.g. the kubeconfig context
+	// or the bundle file.
+	Name     string
+	Policies *policytest.Policies
+	// Namespaces are the namespaces of a cluster by name, nil for bundles.
+	Namespaces map[string]Namespace
+}
+
+// ReadFile reads the policies of a bundle or policy file.
+func ReadFile(name string, r io.Reader) (*Side, error) {
+	policies := &policytest.Policies{}
+	if err := policies.ReadPolicies(r); err != nil {
+		return nil, err
+	}
+	return &Side{Name: name, Policies: policies}, nil
+}
+
+// ReadCluster reads the policies and namespaces of a cluster. Policy kinds
+// not served by the cluster are skipped.
+func ReadCluster(ctx context.Context, c client.Client, name string) (*Side, error) {
+	side := &Side{Name: name, Policies: &policytest.Policies{}, Namespaces: map[string]Namespace{}}
+
+	kausalities := &kausalityv1beta1.KausalityList{}
+	if err := c.List(ctx, kausalities); err != nil && !meta.IsNoMatchError(err) {
+		return nil, fmt.Errorf("failed to list Kausality policies: %w", err)
+	}
+	side.Policies.Kausalities = kausalities.Items
+
+	namespacePolicies := &kausalityv1alpha1.KausalityPolicyList{}
+	if err := c.List(ctx, namespacePolicies); err != nil && !meta.IsNoMatchError(err) {
+		return nil, fmt.Errorf("failed to list KausalityPolicies: %w", err)
+	}
+	side.Policies.NamespacePolicies = namespacePolicies.Items
+
+	namespaces := &corev1.NamespaceList{}
+	if err := c.List(ctx, namespaces); err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	for _, ns := range namespaces.Items {
+		side.Namespaces[ns.Name] = Namespace{Labels: ns.Labels, Annotations: ns.Annotations}
+	}
+	return side, nil
+}
+
+// Diff resolves the effective policy of both sides for every resource and
+// namespace and returns those that differ in tracking, or in the mode of
+// tracked resources.
+func Diff(a, b *Side) *output.PolicyDiff {
+	now := time.Now()
+	storeA, storeB := newStore(a.Policies, now), newStore(b.Policies, now)
+	result := &output.PolicyDiff{A: a.Name, B: b.Name, Differences: []output.PolicyDifference{}}
+	names := namespaces(a, b)
+	for _, gr := range resources(a.Policies, b.Policies) {
+		for _, namespace := range names {
+			nsA, okA := a.namespace(namespace)
+			nsB, okB := b.namespace(namespace)
+			if !okA || !okB {
+				continue
+			}
+			result.Compared++
+			effA := resolve(storeA, gr, namespace, nsA)
+			effB := resolve(storeB, gr, namespace, nsB)
+			if effA.Tracked == effB.Tracked && (effA.Mode == effB.Mode || !effA.Tracked) {
+				continue
+			}
+			result.Differences = append(result.Differences, output.PolicyDifference{
+				Resource:  resourceName(gr),
+				Namespace: namespace,
+				A:         effA,
+				B:         effB,
+			})
+		}
+	}
+	return result
+}
+
+// newStore loads policies into a store resolving them at now.
+func newStore(policies *policytest.Policies, now time.Time) *policy.Store {
+	store := policy.NewStore(nil, logr.Discard())
+	kausalities := append([]kausalityv1beta1.Kausality(nil), policies.Kausalities...)
+	sort.Slice(kausalities, func(i, j int) bool {
+		return kausalities[i].Name < kausalities[j].Name
+	})
+	store.Update(kausalities)
+	store.UpdateNamespacePolicies(policies.NamespacePolicies)
+	store.SetClock(func() time.Time { return now })
+	return store
+}
+
+// resolve returns the effective policy of objects without labels of gr in
+// namespace.
+func resolve(store *policy.Store, gr schema.GroupResource, namespace string, ns Namespace) output.EffectivePolicy {
+	ctx := policy.ResourceContext{
+		GVR:             gr.WithVersion(""),
+		Namespace:       namespace,
+		NamespaceLabels: ns.Labels,
+	}
+	eff := output.EffectivePolicy{
+		Mode:    store.ResolveMode(ctx, nil, ns.Annotations),
+		Tracked: store.IsTracked(ctx),
+	}
+	if p := store.MatchingPolicy(ctx); p != nil {
+		eff.Policy = p.Name
+	}
+	return eff
+}
+
+// namespace returns the namespace of a side, and whether it exists. All
+// namespaces exist in bundles, without labels.
+func (s *Side) namespace(name string) (Namespace, bool) {
+	if name == "" || s.Namespaces == nil {
+		return Namespace{}, true
+	}
+	ns, ok := s.Namespaces[name]
+	return ns, ok
+}
+
+// resources returns the resources named by the policies of either side,
+// sorted. "*" stands for the resources of a group not named otherwise.
+func resources(sides ...*policytest.Policies) []schema.GroupResource {
+	set := map[schema.GroupResource]bool{}
+	addRules := func(groups, resources, excluded []string) {
+		for _, g := range groups {
+			for _, r := range append(append([]string(nil), resources...), excluded...) {
+				set[schema.GroupResource{Group: g, Resource: r}] = true
+			}
+		}
+	}
+	for _, p := range sides {
+		for _, k := range p.Kausalities {
+			for _, rule := range k.Spec.Resources {
+				addRules(rule.APIGroups, rule.Resources, rule.Excluded)
+			}
+			for _, o := range k.Spec.Overrides {
+				addRules(o.APIGroups, o.Resources, nil)
+			}
+		}
+		for _, kp := range p.NamespacePolicies {
+			for _, rule := range kp.Spec.Resources {
+				addRules(rule.APIGroups, rule.Resources, rule.Excluded)
+			}
+		}
+	}
+	result := make([]schema.GroupResource, 0, len(set))
+	for gr := range set {
+		result = append(result, gr)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return resourceName(result[i]) < resourceName(result[j])
+	})
+	return result
+}
+
+// namespaces returns the namespaces of both sides and those named by their
+// policies, sorted, led by "" for cluster-scoped objects.
+func namespaces(sides ...*Side) []string {
+	set := map[string]bool{}
+	for _, s := range sides {
+		for name := range s.Namespaces {
+			set[name] = true
+		}
+		for _, k := range s.Policies.Kausalities {
+			if sel := k.Spec.Namespaces; sel != nil {
+				for _, name := range append(append([]string(nil), sel.Names...), sel.Excluded...) {
+					set[name] = true
+				}
+			}
+			for _, o := range k.Spec.Overrides {
+				for _, name := range o.Namespaces {
+					set[name] = true
+				}
+			}
+		}
+		for _, kp := range s.Policies.NamespacePolicies {
+			set[kp.Namespace] = true
+		}
+	}
+	delete(set, "")
+	result := make([]string, 0, len(set)+1)
+	for name := range set {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return append([]string{""}, result...)
+}
+
+// resourceName returns "group/resource", or "resource" for the core group.
+func resourceName(gr schema.GroupResource) string {
+	if gr.Group == "" {
+		return gr.Resource
+	}
+	return gr.Group + "/" + gr.Resource
+}
+
+// WriteText writes one line per difference and a summary.
+func WriteText(w io.Writer, d *output.PolicyDiff) error {
+	for _, diff := range d.Differences {
+		namespace := diff.Namespace
+		if namespace == "" {
+			namespace = "(cluster-scoped)"
+		}
+		_, err := fmt.Fprintf(w, "%s %s: %s in %s, %s in %s\n",
+			diff.Resource, namespace, describe(diff.A), d.A, describe(diff.B), d.B)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d of %d resource and namespace combinations differ\n", len(d.Differences), d.Compared)
+	return err
+}
+
+// describe returns the mode and policy of an effective policy, or
+// "untracked".
+func describe(e output.EffectivePolicy) string {
+	if !e.Tracked {
+		return "untracked"
+	}
+	if e.Policy == "" {
+		return string(e.Mode)
+	}
+	return fmt.Sprintf("%s (policy %s)", e.Mode, e.Policy)
+}
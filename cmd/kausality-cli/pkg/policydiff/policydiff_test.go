@@ -0,0 +1,104 @@
+package policydiff
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+	"github.com/kausality-io/kausality/cmd/kausality-cli/pkg/output"
+)
+
+const bundlePolicies = `
+version: kausality.io/bundle/v1
+exportedAt: "2026-01-01T00:00:00Z"
+objects:
+- apiVersion: kausality.io/v1beta1
+  kind: Kausality
+  metadata:
+    name: apps
+  spec:
+    resources:
+    - apiGroups: ["apps"]
+      resources: ["deployments"]
+    mode: log
+    overrides:
+    - namespaces: ["prod"]
+      mode: enforce
+- apiVersion: kausality.io/v1beta1
+  kind: Kausality
+  metadata:
+    name: configmaps
+  spec:
+    resources:
+    - apiGroups: [""]
+      resources: ["configmaps"]
+    mode: log
+`
+
+func TestDiff(t *testing.T) {
+	ctx := context.Background()
+	a, err := ReadFile("staging.yaml", strings.NewReader(bundlePolicies))
+	require.NoError(t, err)
+
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(kausalityv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(kausalityv1beta1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&kausalityv1beta1.Kausality{
+			ObjectMeta: metav1.ObjectMeta{Name: "deployments"},
+			Spec: kausalityv1beta1.KausalitySpec{
+				Resources: []kausalityv1beta1.ResourceRule{{APIGroups: []string{"apps"}, Resources: []string{"deployments"}}},
+				Mode:      kausalityv1beta1.ModeLog,
+			},
+		},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "prod"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev"}},
+	).Build()
+	b, err := ReadCluster(ctx, c, "prod-eu")
+	require.NoError(t, err)
+
+	// The production override and the ConfigMap policy are missing in the cluster
+	d := Diff(a, b)
+	assert.Equal(t, 6, d.Compared, "2 resources in 2 namespaces and cluster-scoped")
+	assert.Equal(t, []output.PolicyDifference{
+		{Resource: "apps/deployments", Namespace: "prod",
+			A: output.EffectivePolicy{Mode: kausalityv1alpha1.ModeEnforce, Tracked: true, Policy: "apps"},
+			B: output.EffectivePolicy{Mode: kausalityv1alpha1.ModeLog, Tracked: true, Policy: "deployments"}},
+		{Resource: "configmaps",
+			A: output.EffectivePolicy{Mode: kausalityv1alpha1.ModeLog, Tracked: true, Policy: "configmaps"},
+			B: output.EffectivePolicy{Mode: kausalityv1alpha1.ModeLog}},
+		{Resource: "configmaps", Namespace: "dev",
+			A: output.EffectivePolicy{Mode: kausalityv1alpha1.ModeLog, Tracked: true, Policy: "configmaps"},
+			B: output.EffectivePolicy{Mode: kausalityv1alpha1.ModeLog}},
+		{Resource: "configmaps", Namespace: "prod",
+			A: output.EffectivePolicy{Mode: kausalityv1alpha1.ModeLog, Tracked: true, Policy: "configmaps"},
+			B: output.EffectivePolicy{Mode: kausalityv1alpha1.ModeLog}},
+	}, d.Differences)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteText(&buf, d))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 5)
+	assert.Equal(t, "apps/deployments prod: enforce (policy apps) in staging.yaml, log (policy deployments) in prod-eu", lines[0])
+	assert.Equal(t, "configmaps (cluster-scoped): log (policy configmaps) in staging.yaml, untracked in prod-eu", lines[1])
+	assert.Equal(t, "4 of 6 resource and namespace combinations differ", lines[4])
+
+	// Identical policies do not differ, whatever their names
+	d = Diff(a, a)
+	assert.Empty(t, d.Differences)
+	assert.Equal(t, 4, d.Compared, "2 resources in the namespace of the override and cluster-scoped")
+}
@@ -0,0 +1,264 @@
+// Package policytest tests Kausality policies offline: declarative test
+// cases describe a resource (kind, namespace, labels, annotations) and the
+// mode and tracking the policies must resolve for it, the way the webhook
+// resolves them. It is meant to gate policy changes in CI.
+//
+//	tests:
+//	- name: production Deployments are enforced
+//	  resource: apps/v1/deployments
+//	  namespace: prod
+//	  namespaceLabels: {env: production}
+//	  expect:
+//	    mode: enforce
+//	    tracked: true
+//	    policy: apps
+package policytest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+	"github.com/kausality-io/kausality/cmd/kausality-cli/pkg/output"
+	"github.com/kausality-io/kausality/pkg/policy"
+)
+
+// Suite is a file of test cases.
+type Suite struct {
+	Tests []Case `json:"tests"`
+}
+
+// Case is a resource and the outcome expected from the policies for it.
+type Case struct {
+	// Name describes the case in the results.
+	Name string `json:"name"`
+	// Resource is the resource as "group/version/resource", or
+	// "version/resource" for the core group, e.g. "apps/v1/deployments".
+	Resource string `json:"resource"`
+	// Namespace of the object, empty for cluster-scoped objects.
+	Namespace            string            `json:"namespace,omitempty"`
+	NamespaceLabels      map[string]string `json:"namespaceLabels,omitempty"`
+	NamespaceAnnotations map[string]string `json:"namespaceAnnotations,omitempty"`
+	// ObjectName is the object's name, selecting objects of rollouts.
+	ObjectName  string            `json:"objectName,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// At is the time mode schedules are evaluated at, default now.
+	At *time.Time `json:"at,omitempty"`
+	// Expect is the expected outcome. Fields left out are not checked.
+	Expect Expectation `json:"expect"`
+}
+
+// Expectation is the outcome of resolving the policies for a resource.
+type Expectation struct {
+	// Mode is "log" or "enforce".
+	Mode *kausalityv1alpha1.Mode `json:"mode,omitempty"`
+	// Tracked is whether any policy intercepts the resource.
+	Tracked *bool `json:"tracked,omitempty"`
+	// Policy is the name of the Kausality policy that applies, "" for none.
+	Policy *string `json:"policy,omitempty"`
+}
+
+// ReadSuite reads and validates test cases.
+func ReadSuite(r io.Reader) (*Suite, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	suite := &Suite{}
+	if err := yaml.UnmarshalStrict(data, suite); err != nil {
+		return nil, fmt.Errorf("invalid test file: %w", err)
+	}
+	if len(suite.Tests) == 0 {
+		return nil, errors.New("no tests")
+	}
+	for i, c := range suite.Tests {
+		if c.Name == "" {
+			return nil, fmt.Errorf("tests[%d]: name is required", i)
+		}
+		if _, err := parseResource(c.Resource); err != nil {
+			return nil, fmt.Errorf("test %q: %w", c.Name, err)
+		}
+		if m := c.Expect.Mode; m != nil && *m != kausalityv1alpha1.ModeLog && *m != kausalityv1alpha1.ModeEnforce {
+			return nil, fmt.Errorf("test %q: invalid mode %q: must be %q or %q", c.Name, *m, kausalityv1alpha1.ModeLog, kausalityv1alpha1.ModeEnforce)
+		}
+	}
+	return suite, nil
+}
+
+// parseResource parses "group/version/resource" or "version/resource".
+func parseResource(s string) (schema.GroupVersionResource, error) {
+	parts := strings.Split(s, "/")
+	switch {
+	case len(parts) == 3 && parts[0] != "" && parts[1] != "" && parts[2] != "":
+		return schema.GroupVersionResource{Group: parts[0], Version: parts[1], Resource: parts[2]}, nil
+	case len(parts) == 2 && parts[0] != "" && parts[1] != "":
+		return schema.GroupVersionResource{Version: parts[0], Resource: parts[1]}, nil
+	default:
+		return schema.GroupVersionResource{}, fmt.Errorf("invalid resource %q: must be group/version/resource or version/resource", s)
+	}
+}
+
+// Policies are the policies under test.
+type Policies struct {
+	Kausalities       []kausalityv1beta1.Kausality
+	NamespacePolicies []kausalityv1alpha1.KausalityPolicy
+}
+
+// ReadPolicies adds the Kausality policies and KausalityPolicies of YAML or
+// JSON documents, including Lists and bundles, to p. Kausality policies of
+// any served version are converted to the storage version. Other kinds are
+// ignored.
+func (p *Policies) ReadPolicies(r io.Reader) error {
+	decoder := utilyaml.NewYAMLOrJSONDecoder(r, 4096)
+	for {
+		var obj map[string]interface{}
+		if err := decoder.Decode(&obj); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to decode document: %w", err)
+		}
+		if obj == nil {
+			continue
+		}
+		// A bundle exported by "bundle export"
+		if objects, ok := obj["objects"].([]interface{}); ok && obj["kind"] == nil {
+			for _, o := range objects {
+				if o, ok := o.(map[string]interface{}); ok {
+					if err := p.add(&unstructured.Unstructured{Object: o}); err != nil {
+						return err
+					}
+				}
+			}
+			continue
+		}
+		if err := p.add(&unstructured.Unstructured{Object: obj}); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *Policies) add(obj *unstructured.Unstructured) error {
+	if obj.IsList() {
+		list, err := obj.ToList()
+		if err != nil {
+			return fmt.Errorf("invalid list: %w", err)
+		}
+		for i := range list.Items {
+			if err := p.add(&list.Items[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	gvk := obj.GroupVersionKind()
+	ref := gvk.Kind + "/" + obj.GetName()
+	switch gvk {
+	case kausalityv1beta1.GroupVersion.WithKind("Kausality"):
+		var k kausalityv1beta1.Kausality
+		if err := fromUnstructured(obj, &k); err != nil {
+			return fmt.Errorf("%s: %w", ref, err)
+		}
+		p.Kausalities = append(p.Kausalities, k)
+	case kausalityv1alpha1.GroupVersion.WithKind("Kausality"):
+		var k kausalityv1alpha1.Kausality
+		if err := fromUnstructured(obj, &k); err != nil {
+			return fmt.Errorf("%s: %w", ref, err)
+		}
+		var converted kausalityv1beta1.Kausality
+		if err := k.ConvertTo(&converted); err != nil {
+			return fmt.Errorf("%s: %w", ref, err)
+		}
+		p.Kausalities = append(p.Kausalities, converted)
+	case kausalityv1alpha1.GroupVersion.WithKind("KausalityPolicy"):
+		var k kausalityv1alpha1.KausalityPolicy
+		if err := fromUnstructured(obj, &k); err != nil {
+			return fmt.Errorf("%s: %w", ref, err)
+		}
+		p.NamespacePolicies = append(p.NamespacePolicies, k)
+	}
+	return nil
+}
+
+// fromUnstructured converts obj, rejecting unknown fields, e.g. typos.
+func fromUnstructured(obj *unstructured.Unstructured, into interface{}) error {
+	return runtime.DefaultUnstructuredConverter.FromUnstructuredWithValidation(obj.Object, into, true)
+}
+
+// Run resolves the policies for each case, like the webhook, and compares
+// the outcome with the expectation.
+func Run(policies *Policies, suite *Suite) *output.PolicyTestResult {
+	store := policy.NewStore(nil, logr.Discard())
+	kausalities := append([]kausalityv1beta1.Kausality(nil), policies.Kausalities...)
+	sort.Slice(kausalities, func(i, j int) bool {
+		return kausalities[i].Name < kausalities[j].Name
+	})
+	store.Update(kausalities)
+	store.UpdateNamespacePolicies(policies.NamespacePolicies)
+
+	result := &output.PolicyTestResult{}
+	for _, c := range suite.Tests {
+		r := run(store, c)
+		if len(r.Failures) == 0 {
+			result.Passed++
+		} else {
+			result.Failed++
+		}
+		result.Tests = append(result.Tests, r)
+	}
+	return result
+}
+
+func run(store *policy.Store, c Case) output.PolicyTestCase {
+	at := time.Now()
+	if c.At != nil {
+		at = *c.At
+	}
+	store.SetClock(func() time.Time { return at })
+
+	gvr, _ := parseResource(c.Resource)
+	// Cluster-scoped Crossplane objects inherit the namespace of their claim
+	namespace := policy.EffectiveNamespace(c.Namespace, c.Labels)
+	ctx := policy.ResourceContext{
+		GVR:             gvr,
+		Namespace:       namespace,
+		Name:            c.ObjectName,
+		NamespaceLabels: c.NamespaceLabels,
+		ObjectLabels:    c.Labels,
+	}
+
+	result := output.PolicyTestCase{
+		Name:    c.Name,
+		Mode:    store.ResolveMode(ctx, c.Annotations, c.NamespaceAnnotations),
+		Tracked: store.IsTracked(ctx),
+	}
+	if p := store.MatchingPolicy(ctx); p != nil {
+		result.Policy = p.Name
+	}
+
+	if e := c.Expect.Mode; e != nil && *e != result.Mode {
+		result.Failures = append(result.Failures, fmt.Sprintf("mode: expected %s, got %s", *e, result.Mode))
+	}
+	if e := c.Expect.Tracked; e != nil && *e != result.Tracked {
+		result.Failures = append(result.Failures, fmt.Sprintf("tracked: expected %t, got %t", *e, result.Tracked))
+	}
+	if e := c.Expect.Policy; e != nil && *e != result.Policy {
+		result.Failures = append(result.Failures, fmt.Sprintf("policy: expected %q, got %q", *e, result.Policy))
+	}
+	return result
+}
@@ -0,0 +1,175 @@
+package policytest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	"github.com/kausality-io/kausality/cmd/kausality-cli/pkg/output"
+)
+
+const testPolicies = `
+apiVersion: kausality.io/v1beta1
+kind: Kausality
+metadata:
+  name: apps
+spec:
+  resources:
+  - apiGroups: ["apps"]
+    resources: ["deployments"]
+  mode: log
+  overrides:
+  - namespaces: ["prod"]
+    mode: enforce
+  schedules:
+  - days: ["Saturday", "Sunday"]
+    start: "00:00"
+    end: "23:59"
+    mode: enforce
+---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: kausality.io/v1alpha1
+  kind: Kausality
+  metadata:
+    name: configmaps
+  spec:
+    resources:
+    - apiGroups: [""]
+      resources: ["configmaps"]
+    mode: log
+- apiVersion: kausality.io/v1alpha1
+  kind: KausalityPolicy
+  metadata:
+    name: critical
+    namespace: payments
+  spec:
+    objectSelector:
+      matchLabels:
+        tier: critical
+    mode: enforce
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ignored
+`
+
+const testSuite = `
+tests:
+- name: prod Deployments are enforced
+  resource: apps/v1/deployments
+  namespace: prod
+  expect: {mode: enforce, tracked: true, policy: apps}
+- name: dev Deployments are logged on weekdays
+  resource: apps/v1/deployments
+  namespace: dev
+  at: 2026-10-16T12:00:00Z
+  expect: {mode: log}
+- name: dev Deployments are enforced on weekends
+  resource: apps/v1/deployments
+  namespace: dev
+  at: 2026-10-17T12:00:00Z
+  expect: {mode: enforce}
+- name: critical ConfigMaps of payments are enforced
+  resource: v1/configmaps
+  namespace: payments
+  labels: {tier: critical}
+  expect: {mode: enforce, policy: configmaps}
+- name: namespace annotation takes precedence
+  resource: v1/configmaps
+  namespace: sandbox
+  namespaceAnnotations: {kausality.io/mode: enforce}
+  expect: {mode: enforce}
+- name: Secrets are not tracked
+  resource: v1/secrets
+  namespace: prod
+  expect: {tracked: false, policy: ""}
+- name: wrong expectation
+  resource: v1/secrets
+  namespace: prod
+  expect: {mode: enforce, tracked: true}
+`
+
+func TestRun(t *testing.T) {
+	policies := &Policies{}
+	require.NoError(t, policies.ReadPolicies(strings.NewReader(testPolicies)))
+	assert.Len(t, policies.Kausalities, 2)
+	assert.Len(t, policies.NamespacePolicies, 1)
+
+	suite, err := ReadSuite(strings.NewReader(testSuite))
+	require.NoError(t, err)
+
+	result := Run(policies, suite)
+	assert.Equal(t, 6, result.Passed)
+	assert.Equal(t, 1, result.Failed)
+	for _, c := range result.Tests[:6] {
+		assert.Empty(t, c.Failures, c.Name)
+	}
+	assert.Equal(t, output.PolicyTestCase{
+		Name: "wrong expectation",
+		Mode: kausalityv1alpha1.ModeLog,
+		Failures: []string{
+			"mode: expected enforce, got log",
+			"tracked: expected true, got false",
+		},
+	}, result.Tests[6])
+}
+
+func TestReadPolicies_Bundle(t *testing.T) {
+	policies := &Policies{}
+	require.NoError(t, policies.ReadPolicies(strings.NewReader(`
+version: kausality.io/bundle/v1
+exportedAt: "2026-10-16T12:00:00Z"
+objects:
+- apiVersion: kausality.io/v1beta1
+  kind: Kausality
+  metadata:
+    name: apps
+  spec:
+    resources:
+    - apiGroups: ["apps"]
+      resources: ["deployments"]
+    mode: enforce
+`)))
+	require.Len(t, policies.Kausalities, 1)
+	assert.Equal(t, "apps", policies.Kausalities[0].Name)
+}
+
+func TestReadPolicies_UnknownField(t *testing.T) {
+	err := (&Policies{}).ReadPolicies(strings.NewReader(`
+apiVersion: kausality.io/v1beta1
+kind: Kausality
+metadata:
+  name: apps
+spec:
+  resource:
+  - apiGroups: ["apps"]
+  mode: enforce
+`))
+	assert.ErrorContains(t, err, "Kausality/apps")
+}
+
+func TestReadSuite_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		suite string
+		want  string
+	}{
+		{name: "empty", suite: "tests: []", want: "no tests"},
+		{name: "unknown field", suite: "tests:\n- name: a\n  resource: v1/secrets\n  expected: {mode: log}", want: "invalid test file"},
+		{name: "missing name", suite: "tests:\n- resource: v1/secrets", want: "name is required"},
+		{name: "invalid resource", suite: "tests:\n- name: a\n  resource: secrets", want: "invalid resource"},
+		{name: "invalid mode", suite: "tests:\n- name: a\n  resource: v1/secrets\n  expect: {mode: warn}", want: "invalid mode"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadSuite(strings.NewReader(tt.suite))
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
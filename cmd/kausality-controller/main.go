@@ -4,19 +4,29 @@ package main
 
 import (
 	"flag"
+	"net"
 	"os"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/discovery"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
 	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+	"github.com/kausality-io/kausality/pkg/callback"
+	"github.com/kausality-io/kausality/pkg/certs"
+	kcontroller "github.com/kausality-io/kausality/pkg/controller"
 	"github.com/kausality-io/kausality/pkg/policy"
 )
 
@@ -24,9 +34,17 @@ var (
 	scheme = runtime.NewScheme()
 )
 
+// Values of --cert-management.
+const (
+	certManagementNone       = "none"
+	certManagementSelfSigned = "self-signed"
+	certManagementAuto       = "auto"
+)
+
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(kausalityv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(kausalityv1beta1.AddToScheme(scheme))
 	utilruntime.Must(apiextensionsv1.AddToScheme(scheme))
 }
 
@@ -38,6 +56,14 @@ func main() {
 		webhookName            string
 		webhookNamespace       string
 		webhookServiceName     string
+		webhookRoleName        string
+		certManagement         string
+		certSecretName         string
+		certExpiryWarning      time.Duration
+		webhookHealthProbe     bool
+		effectReportURL        string
+		effectReportCAFile     string
+		effectReportTokenFile  string
 	)
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address for the metrics endpoint")
@@ -46,6 +72,19 @@ func main() {
 	flag.StringVar(&webhookName, "webhook-name", "kausality", "Name of the MutatingWebhookConfiguration to manage")
 	flag.StringVar(&webhookNamespace, "webhook-namespace", "kausality-system", "Namespace of the webhook service")
 	flag.StringVar(&webhookServiceName, "webhook-service-name", "kausality-webhook", "Name of the webhook service")
+	flag.StringVar(&webhookRoleName, "webhook-role-name", "kausality-webhook-resources",
+		"Name of the ClusterRole granting the webhook access to tracked resources; empty disables RBAC management")
+	flag.StringVar(&certManagement, "cert-management", certManagementNone,
+		"Webhook certificate management: none (provisioned externally), self-signed (provisioned and rotated by the controller), "+
+			"or auto (self-signed unless cert-manager is installed)")
+	flag.StringVar(&certSecretName, "cert-secret-name", "kausality-webhook-cert", "Name of the webhook certificate secret in --webhook-namespace")
+	flag.DurationVar(&certExpiryWarning, "cert-expiry-warning", policy.DefaultCertExpiryWarning,
+		"How long before expiry the webhook certificates make the webhook unhealthy")
+	flag.BoolVar(&webhookHealthProbe, "webhook-health-probe", true, "Connect to the webhook service to check that it is reachable")
+	flag.StringVar(&effectReportURL, "effect-report-url", "",
+		"URL receiving the resources which gained or lost tracking, or changed mode, by policy changes; empty disables reports")
+	flag.StringVar(&effectReportCAFile, "effect-report-ca-file", "", "CA certificate file verifying the TLS certificate of --effect-report-url")
+	flag.StringVar(&effectReportTokenFile, "effect-report-token-file", "", "File containing a bearer token sent to --effect-report-url")
 
 	opts := zap.Options{
 		Development: true,
@@ -56,13 +95,30 @@ func main() {
 	log := zap.New(zap.UseFlagOptions(&opts))
 	ctrl.SetLogger(log)
 
+	switch certManagement {
+	case certManagementNone, certManagementSelfSigned, certManagementAuto:
+	default:
+		log.Error(nil, "invalid --cert-management", "value", certManagement)
+		os.Exit(1)
+	}
+
 	log.Info("starting kausality-controller",
 		"webhookName", webhookName,
 		"webhookNamespace", webhookNamespace,
 		"webhookServiceName", webhookServiceName,
+		"webhookRoleName", webhookRoleName,
+		"certManagement", certManagement,
 	)
 
+	// The webhook health is checked from the webhook's service, endpoints
+	// and certificate secret. Cache only these instead of all services and
+	// secrets in the cluster.
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+		Cache: cache.Options{ByObject: map[client.Object]cache.ByObject{
+			&corev1.Service{}:   webhookObject(webhookNamespace, webhookServiceName),
+			&corev1.Endpoints{}: webhookObject(webhookNamespace, webhookServiceName), //nolint:staticcheck // EndpointSlices are not served before Kubernetes 1.21
+			&corev1.Secret{}:    webhookObject(webhookNamespace, certSecretName),
+		}},
 		Scheme:                 scheme,
 		Metrics:                metricsserver.Options{BindAddress: metricsAddr},
 		HealthProbeBindAddress: healthProbeBindAddress,
@@ -81,20 +137,51 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Clusters before Kubernetes 1.16 only serve the v1beta1 webhook configuration
+	webhookAPIVersion, err := policy.DetectWebhookAPIVersion(discoveryClient)
+	if err != nil {
+		log.Error(err, "unable to detect webhook configuration API version")
+		os.Exit(1)
+	}
+	if webhookAPIVersion != policy.WebhookAPIVersionV1 {
+		log.Info("using admissionregistration.k8s.io/v1beta1 webhook configuration")
+	}
+
 	// Set up the policy controller
 	controller := &policy.Controller{
-		Client:          mgr.GetClient(),
-		Log:             log.WithName("controller"),
-		Scheme:          mgr.GetScheme(),
-		DiscoveryClient: discoveryClient,
-		WebhookName:     webhookName,
+		Client:            client.WithFieldOwner(mgr.GetClient(), kcontroller.FieldManager),
+		Log:               log.WithName("controller"),
+		Scheme:            mgr.GetScheme(),
+		DiscoveryClient:   discoveryClient,
+		WebhookName:       webhookName,
+		WebhookAPIVersion: webhookAPIVersion,
 		WebhookServiceRef: policy.WebhookServiceRef{
 			Namespace: webhookNamespace,
 			Name:      webhookServiceName,
 			Port:      443,
 			Path:      "/mutate",
 		},
-		ExcludedNamespaces: []string{"kube-system", "kube-public", "kube-node-lease"},
+		WebhookRoleName:       webhookRoleName,
+		ExcludedNamespaces:    []string{"kube-system", "kube-public", "kube-node-lease"},
+		WebhookCertSecretName: certSecretName,
+		CertExpiryWarning:     certExpiryWarning,
+		Recorder:              mgr.GetEventRecorder("kausality-controller"),
+	}
+	if webhookHealthProbe {
+		controller.WebhookDialer = (&net.Dialer{}).DialContext
+	}
+	if effectReportURL != "" {
+		sender, err := callback.NewPolicyEffectSender(callback.SenderConfig{
+			URL:       effectReportURL,
+			CAFile:    effectReportCAFile,
+			TokenFile: effectReportTokenFile,
+		})
+		if err != nil {
+			log.Error(err, "unable to create policy effect sender")
+			os.Exit(1)
+		}
+		controller.EffectReporter = sender
+		log.Info("policy effect reports enabled", "url", effectReportURL)
 	}
 
 	if err := controller.SetupWithManager(mgr); err != nil {
@@ -102,6 +189,38 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Set up webhook certificate provisioning
+	if certManagement == certManagementAuto {
+		installed, err := certs.CertManagerInstalled(discoveryClient)
+		if err != nil {
+			log.Error(err, "unable to detect cert-manager")
+			os.Exit(1)
+		}
+		if installed {
+			log.Info("cert-manager is installed, leaving webhook certificates to cert-manager")
+			certManagement = certManagementNone
+		}
+	}
+	if certManagement != certManagementNone {
+		// Use an uncached client to avoid caching all secrets in the cluster
+		directClient, err := client.New(mgr.GetConfig(), client.Options{Scheme: mgr.GetScheme()})
+		if err != nil {
+			log.Error(err, "unable to create client")
+			os.Exit(1)
+		}
+		certManager := certs.NewManager(client.WithFieldOwner(directClient, kcontroller.FieldManager), log, certs.Config{
+			SecretNamespace:  webhookNamespace,
+			SecretName:       certSecretName,
+			ServiceNamespace: webhookNamespace,
+			ServiceName:      webhookServiceName,
+			OnCABundleChange: controller.InjectCABundle,
+		})
+		if err := mgr.Add(certManager); err != nil {
+			log.Error(err, "unable to set up certificate manager")
+			os.Exit(1)
+		}
+	}
+
 	// Add health checks
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		log.Error(err, "unable to set up health check")
@@ -118,3 +237,12 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// webhookObject restricts the cache of a kind to the object of the webhook
+// with the given name.
+func webhookObject(namespace, name string) cache.ByObject {
+	return cache.ByObject{
+		Namespaces: map[string]cache.Config{namespace: {}},
+		Field:      fields.OneTermEqualSelector("metadata.name", name),
+	}
+}
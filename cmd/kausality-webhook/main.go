@@ -4,26 +4,45 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/go-logr/logr"
 
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/client-go/util/flowcontrol"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
 	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
 	"github.com/kausality-io/kausality/cmd/kausality-webhook/pkg/webhook"
+	"github.com/kausality-io/kausality/pkg/admission"
+	"github.com/kausality-io/kausality/pkg/approval"
+	"github.com/kausality-io/kausality/pkg/archive"
 	"github.com/kausality-io/kausality/pkg/callback"
 	"github.com/kausality-io/kausality/pkg/config"
+	"github.com/kausality-io/kausality/pkg/controller"
+	"github.com/kausality-io/kausality/pkg/drift"
+	"github.com/kausality-io/kausality/pkg/integrations"
 	"github.com/kausality-io/kausality/pkg/policy"
+	"github.com/kausality-io/kausality/pkg/trace"
 )
 
 var (
@@ -33,6 +52,7 @@ var (
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(kausalityv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(kausalityv1beta1.AddToScheme(scheme))
 }
 
 func main() {
@@ -43,6 +63,16 @@ func main() {
 		healthProbeBindAddress string
 		configFile             string
 		metricsAddr            string
+		pprofAddr              string
+		leaderElect            bool
+		recordJournalNamespace string
+		standalone             bool
+		annotationWriteQPS     float64
+		annotationWriteBurst   int
+		recordDelay            time.Duration
+		recordTrigger          string
+		selfUsers              string
+		whatIf                 bool
 	)
 
 	flag.StringVar(&host, "host", "", "The address to bind to (default: all interfaces)")
@@ -51,6 +81,25 @@ func main() {
 	flag.StringVar(&healthProbeBindAddress, "health-probe-bind-address", ":8081", "The address for health probes")
 	flag.StringVar(&configFile, "config", "", "Path to config file (optional, for drift callbacks)")
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8082", "The address for metrics endpoint")
+	flag.StringVar(&pprofAddr, "pprof-bind-address", "",
+		"The address for pprof profiling endpoints, e.g. localhost:8083 (default: disabled)")
+	flag.BoolVar(&leaderElect, "leader-elect", false,
+		"Elect a leader among webhook replicas to write parent annotations (required for more than one replica)")
+	flag.StringVar(&recordJournalNamespace, "record-journal-namespace", "",
+		"Namespace of the ConfigMaps persisting parent annotation records of each replica for the leader, so that records received by other replicas or pending on restart are not lost (default: disabled)")
+	flag.BoolVar(&standalone, "standalone", false,
+		"Read policies from the config file and reload them on change, instead of watching Kausality CRDs (no policy controller)")
+	flag.Float64Var(&annotationWriteQPS, "annotation-write-qps", 20,
+		"Client-side rate limit for annotation writes to parent objects, shared by all writers (0 disables it)")
+	flag.IntVar(&annotationWriteBurst, "annotation-write-burst", 40, "Burst of annotation writes above --annotation-write-qps")
+	flag.DurationVar(&recordDelay, "record-delay", 0,
+		"How long controller records wait before they are written to parents, or the maximum wait of --record-trigger=ObservedGeneration (default: none, 30s with ObservedGeneration)")
+	flag.StringVar(&recordTrigger, "record-trigger", string(controller.RecordTriggerDelay),
+		"When controller records are written: Delay, after --record-delay, or ObservedGeneration, once a status update reports the generation observed")
+	flag.StringVar(&selfUsers, "self-users", "",
+		"Comma-separated usernames of other kausality components, e.g. the controller's ServiceAccount, whose own writes are not evaluated (the webhook's own user is added)")
+	flag.BoolVar(&whatIf, "enable-whatif", false,
+		"Serve what-if queries at /v1/whatif on the webhook port, telling whether a change would be admitted without persisting anything")
 
 	opts := zap.Options{
 		Development: true,
@@ -67,8 +116,20 @@ func main() {
 		"certDir", certDir,
 		"healthProbeBindAddress", healthProbeBindAddress,
 		"configFile", configFile,
+		"standalone", standalone,
 	)
 
+	if standalone && configFile == "" {
+		log.Error(nil, "--standalone requires --config")
+		os.Exit(1)
+	}
+	switch controller.RecordTrigger(recordTrigger) {
+	case controller.RecordTriggerDelay, controller.RecordTriggerObservedGeneration:
+	default:
+		log.Error(nil, "--record-trigger must be Delay or ObservedGeneration", "trigger", recordTrigger)
+		os.Exit(1)
+	}
+
 	// Create controller manager for watch-based policy updates
 	mgr, err := manager.New(ctrl.GetConfigOrDie(), manager.Options{
 		Scheme: scheme,
@@ -76,6 +137,9 @@ func main() {
 			BindAddress: metricsAddr,
 		},
 		HealthProbeBindAddress: "", // We use our own health server
+		PprofBindAddress:       pprofAddr,
+		LeaderElection:         leaderElect,
+		LeaderElectionID:       "kausality-webhook",
 	})
 	if err != nil {
 		log.Error(err, "unable to create controller manager")
@@ -93,22 +157,47 @@ func main() {
 		log.Info("loaded config",
 			"backends", len(driftConfig.Backends),
 		)
+		if len(driftConfig.Policies) > 0 && !standalone {
+			log.Info("ignoring policies of the config file, they are only read with --standalone")
+		}
 	} else {
 		driftConfig = config.Default()
 		log.Info("using default config (no config file specified)")
 	}
+	// The apiserver prefixes audit annotation keys of webhook responses with
+	// the webhook name and drops keys with more than one '/', so the keys
+	// carry no prefix of their own unless configured.
+	if driftConfig.Audit == nil {
+		driftConfig.Audit = &config.AuditConfig{}
+	}
+	if driftConfig.Audit.KeyPrefix == nil {
+		driftConfig.Audit.KeyPrefix = new(string)
+	} else if strings.Contains(*driftConfig.Audit.KeyPrefix, "/") {
+		log.Info("audit annotations are dropped by the apiserver, keyPrefix must not contain '/' for webhooks",
+			"keyPrefix", *driftConfig.Audit.KeyPrefix)
+	}
 
 	// Create multi-sender if backends are configured
 	var callbackSender callback.ReportSender
 	if len(driftConfig.Backends) > 0 {
 		senderConfigs := make([]callback.SenderConfig, len(driftConfig.Backends))
 		for i, backend := range driftConfig.Backends {
+			route, err := backendRoute(backend.Route)
+			if err != nil {
+				log.Error(err, "invalid drift callback route", "backend", backend.URL)
+				os.Exit(1)
+			}
 			senderConfigs[i] = callback.SenderConfig{
+				Name:          backend.Name,
 				URL:           backend.URL,
 				CAFile:        backend.CAFile,
+				TokenFile:     backend.TokenFile,
 				Timeout:       backend.Timeout,
 				RetryCount:    backend.RetryCount,
 				RetryInterval: backend.RetryInterval,
+				BatchInterval: backend.BatchInterval,
+				Route:         route,
+				Failover:      backend.Failover,
 				Log:           log,
 			}
 		}
@@ -119,20 +208,287 @@ func main() {
 			os.Exit(1)
 		}
 		if multiSender != nil {
+			multiSender.WithNamespaceLabels(namespaceLabels(mgr.GetClient()))
+			if err := mgr.Add(multiSender); err != nil {
+				log.Error(err, "unable to set up drift callback senders")
+				os.Exit(1)
+			}
 			callbackSender = multiSender
 			log.Info("drift callbacks enabled", "backends", multiSender.Len())
 		}
 	}
 
+	// Create trace mirror if configured
+	var traceMirror callback.TraceMirror
+	if tb := driftConfig.TraceBackend; tb != nil {
+		traceSender, err := callback.NewTraceSender(callback.SenderConfig{
+			URL:           tb.URL,
+			CAFile:        tb.CAFile,
+			TokenFile:     tb.TokenFile,
+			Timeout:       tb.Timeout,
+			RetryCount:    tb.RetryCount,
+			RetryInterval: tb.RetryInterval,
+			Log:           log,
+		})
+		if err != nil {
+			log.Error(err, "unable to create trace mirror")
+			os.Exit(1)
+		}
+		if err := mgr.Add(traceSender); err != nil {
+			log.Error(err, "unable to set up trace mirror")
+			os.Exit(1)
+		}
+		traceMirror = traceSender
+		log.Info("trace mirroring enabled", "url", tb.URL)
+	}
+
+	// Create ticket validator if configured
+	var ticketValidator integrations.TicketValidator
+	if tv := driftConfig.TicketValidation; tv != nil {
+		ticketValidator, err = integrations.NewTicketValidator(integrations.TicketConfig{
+			Provider:      tv.Provider,
+			URL:           tv.URL,
+			Repository:    tv.Repository,
+			Repositories:  tv.Repositories,
+			Projects:      tv.Projects,
+			Username:      tv.Username,
+			TokenFile:     tv.TokenFile,
+			AllowedStates: tv.AllowedStates,
+			Timeout:       tv.Timeout,
+			CacheTTL:      tv.CacheTTL,
+		})
+		if err != nil {
+			log.Error(err, "unable to create ticket validator")
+			os.Exit(1)
+		}
+		log.Info("ticket validation enabled", "provider", tv.Provider, "allowedStates", tv.AllowedStates)
+	}
+
+	// Load the trace signing key if configured
+	var traceSigner *trace.Signer
+	if ts := driftConfig.TraceSigning; ts != nil {
+		traceSigner, err = trace.LoadSigner(ts.KeyFile)
+		if err != nil {
+			log.Error(err, "unable to load trace signing key")
+			os.Exit(1)
+		}
+		log.Info("trace signing enabled", "keyFile", ts.KeyFile)
+	}
+
 	// Create policy store (uses manager's client which has caching)
 	policyStore := policy.NewStore(mgr.GetClient(), log)
 
-	// Set up watch-driven policy watcher - updates store instantly on any policy change
-	if err := policy.SetupWatcher(mgr, policyStore, log); err != nil {
-		log.Error(err, "unable to set up policy watcher")
+	// Own writes carry kausality's field manager, so that the webhook
+	// recognizes them together with the users of kausality's components
+	ownClient := client.WithFieldOwner(mgr.GetClient(), controller.FieldManager)
+	self := splitList(selfUsers)
+	if user, err := ownUsername(mgr.GetConfig()); err != nil {
+		log.Info("unable to determine own user, own writes are only recognized for --self-users", "error", err.Error())
+	} else {
+		self = append(self, user)
+	}
+	log.Info("recognizing own writes", "users", self, "fieldManager", controller.FieldManager)
+
+	if standalone {
+		// Policies come from the config file, reloaded when it changes
+		fileWatcher := policy.NewFileWatcher(configFile, policyStore, log)
+		if _, err := fileWatcher.Reload(); err != nil {
+			log.Error(err, "unable to load policies", "path", configFile)
+			os.Exit(1)
+		}
+		if err := mgr.Add(fileWatcher); err != nil {
+			log.Error(err, "unable to set up policy file watcher")
+			os.Exit(1)
+		}
+		log.Info("standalone mode, policies reloaded from config file", "policies", len(driftConfig.Policies))
+	} else {
+		// Set up watch-driven policy watcher - updates store instantly on any policy change
+		if err := policy.SetupWatcher(mgr, policyStore, log); err != nil {
+			log.Error(err, "unable to set up policy watcher")
+			os.Exit(1)
+		}
+		log.Info("policy watcher configured (watch-driven, instant updates)")
+	}
+
+	// Annotation writes share one client-side rate limit, so that bookkeeping
+	// backs off together instead of adding to API server throttling
+	var writeLimiter flowcontrol.RateLimiter
+	if annotationWriteQPS > 0 {
+		writeLimiter = flowcontrol.NewTokenBucketRateLimiter(float32(annotationWriteQPS), annotationWriteBurst)
+	}
+
+	// Parent annotations are written by a leader-elected keeper, fed by the webhook
+	keeperOpts := []controller.KeeperOption{controller.WithDeferredRecords(controller.RecordTrigger(recordTrigger), recordDelay)}
+	if writeLimiter != nil {
+		keeperOpts = append(keeperOpts, controller.WithWriteLimiter(writeLimiter))
+	}
+	if recordJournalNamespace != "" {
+		replica, err := os.Hostname()
+		if err != nil {
+			log.Error(err, "unable to determine replica name for the record journal")
+			os.Exit(1)
+		}
+		journal := controller.NewJournal(ownClient, mgr.GetAPIReader(), recordJournalNamespace, replica, log)
+		if err := mgr.Add(journal); err != nil {
+			log.Error(err, "unable to set up record journal")
+			os.Exit(1)
+		}
+		keeperOpts = append(keeperOpts, controller.WithJournal(journal))
+		log.Info("record journal enabled", "namespace", recordJournalNamespace, "replica", replica)
+	}
+	keeper := controller.NewKeeper(ownClient, log, keeperOpts...)
+	if err := mgr.Add(keeper); err != nil {
+		log.Error(err, "unable to set up annotation keeper")
+		os.Exit(1)
+	}
+
+	// Create drift status writer if configured
+	var driftStatus drift.StatusRecorder
+	if ds := driftConfig.DriftStatus; ds != nil {
+		var statusOpts []drift.StatusWriterOption
+		if writeLimiter != nil {
+			statusOpts = append(statusOpts, drift.WithWriteLimiter(writeLimiter))
+		}
+		statusWriter := drift.NewStatusWriter(ownClient, ds.Window, log, statusOpts...)
+		if err := mgr.Add(statusWriter); err != nil {
+			log.Error(err, "unable to set up drift status writer")
+			os.Exit(1)
+		}
+		driftStatus = statusWriter
+		log.Info("drift status enabled", "window", ds.Window)
+	}
+
+	// Reverts children of policies quarantining drift when their drift is denied
+	reverter := drift.NewReverter(ownClient, mgr.GetEventRecorder("kausality-webhook"), log)
+	if err := mgr.Add(reverter); err != nil {
+		log.Error(err, "unable to set up quarantine reverter")
+		os.Exit(1)
+	}
+
+	// Record tombstones of deleted objects as Events if configured
+	var tombstones events.EventRecorder
+	if ts := driftConfig.Tombstones; ts != nil {
+		tombstones = mgr.GetEventRecorder("kausality-webhook")
+		log.Info("tombstones enabled", "excludeGarbageCollected", ts.ExcludeGarbageCollected)
+	}
+
+	// Archive the evidence of terminal events if configured
+	var traceArchive archive.Archiver
+	if ta := driftConfig.TraceArchive; ta != nil {
+		store, err := newTraceArchive(ta)
+		if err != nil {
+			log.Error(err, "unable to create trace archive")
+			os.Exit(1)
+		}
+		writer := archive.NewWriter(store, log, archive.WithPrefix(ta.Prefix), archive.WithRetry(ta.RetryCount, ta.RetryInterval))
+		if err := mgr.Add(writer); err != nil {
+			log.Error(err, "unable to set up trace archive")
+			os.Exit(1)
+		}
+		traceArchive = writer
+		log.Info("trace archive enabled", "prefix", ta.Prefix, "events", ta.Events)
+	}
+
+	// Attach the Events explaining origin changes of controllers if configured
+	var originEvents *trace.EventEnricher
+	if oe := driftConfig.OriginEvents; oe != nil {
+		originEvents = trace.NewEventEnricher(mgr.GetAPIReader(), oe.Window, oe.Timeout)
+		log.Info("origin events enabled", "window", oe.Window, "timeout", oe.Timeout)
+	}
+
+	// Request approvals of denied drift and apply their decisions if configured
+	var approvalRequester approval.Requester
+	if ar := driftConfig.ApprovalRequests; ar != nil {
+		requestWriter := approval.NewRequestWriter(ownClient, log)
+		if err := mgr.Add(requestWriter); err != nil {
+			log.Error(err, "unable to set up approval request writer")
+			os.Exit(1)
+		}
+		if err := approval.NewRequestReconciler(ownClient, ar.Namespace, ar.TTL, log).SetupWithManager(mgr); err != nil {
+			log.Error(err, "unable to set up approval request controller")
+			os.Exit(1)
+		}
+		approvalRequester = requestWriter
+		log.Info("approval requests enabled", "namespace", ar.Namespace)
+	}
+
+	// Lifecycle mappings of parent kinds, reloaded when the config file changes
+	lifecycle := drift.NewLifecycleRegistry(admission.LifecycleMappings(driftConfig)...)
+	if configFile != "" {
+		lifecycleWatcher := admission.NewLifecycleWatcher(configFile, lifecycle, log)
+		if _, err := lifecycleWatcher.Reload(); err != nil {
+			log.Error(err, "unable to load lifecycle mappings", "path", configFile)
+			os.Exit(1)
+		}
+		if err := mgr.Add(lifecycleWatcher); err != nil {
+			log.Error(err, "unable to set up lifecycle watcher")
+			os.Exit(1)
+		}
+	}
+
+	// Create reference index if configured, before the cache starts
+	var references trace.ReferenceFinder
+	if len(driftConfig.References) > 0 || len(driftConfig.ConnectionSecrets) > 0 {
+		var rules []trace.ReferenceRule
+		for _, ref := range driftConfig.References {
+			gv, err := schema.ParseGroupVersion(ref.APIVersion)
+			if err != nil {
+				log.Error(err, "invalid reference apiVersion", "apiVersion", ref.APIVersion)
+				os.Exit(1)
+			}
+			rules = append(rules, trace.ReferenceRule{
+				Referrer:   gv.WithKind(ref.Kind),
+				TargetKind: ref.TargetKind,
+				Paths:      ref.Paths,
+			})
+		}
+		for _, cs := range driftConfig.ConnectionSecrets {
+			gv, err := schema.ParseGroupVersion(cs.APIVersion)
+			if err != nil {
+				log.Error(err, "invalid connection secret apiVersion", "apiVersion", cs.APIVersion)
+				os.Exit(1)
+			}
+			rules = append(rules, trace.ConnectionSecretRule(gv.WithKind(cs.Kind)))
+		}
+		referenceIndex, err := trace.NewReferenceIndex(mgr.GetCache(), rules)
+		if err != nil {
+			log.Error(err, "unable to create reference index")
+			os.Exit(1)
+		}
+		if err := referenceIndex.RegisterIndexes(context.Background(), mgr.GetFieldIndexer()); err != nil {
+			log.Error(err, "unable to register reference indexes")
+			os.Exit(1)
+		}
+		references = referenceIndex
+		log.Info("reference tracing enabled", "rules", len(rules))
+	}
+
+	// Index Crossplane composites and Usages if configured, before the cache starts
+	var parentEdges drift.ParentEdges
+	if cp := driftConfig.Crossplane; cp != nil && (len(cp.Composites) > 0 || len(cp.Usages) > 0) {
+		gvks := func(kinds []config.CrossplaneKindConfig) []schema.GroupVersionKind {
+			var result []schema.GroupVersionKind
+			for _, k := range kinds {
+				gv, _ := schema.ParseGroupVersion(k.APIVersion)
+				result = append(result, gv.WithKind(k.Kind))
+			}
+			return result
+		}
+		crossplaneEdges := drift.NewCrossplaneEdges(mgr.GetCache(), gvks(cp.Composites), gvks(cp.Usages))
+		if err := crossplaneEdges.RegisterIndexes(context.Background(), mgr.GetFieldIndexer()); err != nil {
+			log.Error(err, "unable to register Crossplane indexes")
+			os.Exit(1)
+		}
+		parentEdges = crossplaneEdges
+		log.Info("Crossplane parent edges enabled", "composites", len(cp.Composites), "usages", len(cp.Usages))
+	}
+
+	// Track aggregated APIs, so that parents they serve are read with a timeout
+	aggregatedAPIs := policy.NewAggregatedIndex()
+	if err := policy.SetupAggregatedIndex(mgr, aggregatedAPIs, log); err != nil {
+		log.Error(err, "unable to set up aggregated API index")
 		os.Exit(1)
 	}
-	log.Info("policy watcher configured (watch-driven, instant updates)")
 
 	// Setup signal handling context
 	ctx, cancel := context.WithCancel(context.Background())
@@ -158,7 +514,7 @@ func main() {
 
 	// Create and start webhook server
 	server := webhook.NewServer(webhook.Config{
-		Client:                 mgr.GetClient(),
+		Client:                 ownClient,
 		Log:                    log,
 		Host:                   host,
 		Port:                   port,
@@ -166,7 +522,23 @@ func main() {
 		HealthProbeBindAddress: healthProbeBindAddress,
 		DriftConfig:            driftConfig,
 		CallbackSender:         callbackSender,
+		TraceMirror:            traceMirror,
 		PolicyResolver:         policyStore,
+		TicketValidator:        ticketValidator,
+		Recorder:               keeper,
+		DriftStatus:            driftStatus,
+		References:             references,
+		AggregatedAPIs:         aggregatedAPIs,
+		ParentEdges:            parentEdges,
+		TraceSigner:            traceSigner,
+		SelfUsers:              self,
+		Quarantiner:            reverter,
+		ApprovalRequester:      approvalRequester,
+		Tombstones:             tombstones,
+		TraceArchive:           traceArchive,
+		OriginEvents:           originEvents,
+		Lifecycle:              lifecycle,
+		WhatIf:                 whatIf,
 	})
 
 	server.Register()
@@ -179,6 +551,83 @@ func main() {
 	}
 }
 
+// ownUsername returns the user the webhook authenticates as, e.g. its
+// ServiceAccount. Clusters before Kubernetes 1.28 may not serve SelfSubjectReviews.
+func ownUsername(cfg *rest.Config) (string, error) {
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	review, err := clientset.AuthenticationV1().SelfSubjectReviews().Create(ctx, &authenticationv1.SelfSubjectReview{}, metav1.CreateOptions{})
+	if err != nil {
+		return "", err
+	}
+	return review.Status.UserInfo.Username, nil
+}
+
+// splitList splits a comma-separated list, dropping empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// backendRoute converts the route of a backend, nil if none.
+func backendRoute(cfg *config.BackendRouteConfig) (*callback.Route, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	route := &callback.Route{}
+	if cfg.MinSeverity != "" {
+		severity, err := callback.ParseSeverity(cfg.MinSeverity)
+		if err != nil {
+			return nil, err
+		}
+		route.MinSeverity = severity
+	}
+	if cfg.NamespaceSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(cfg.NamespaceSelector)
+		if err != nil {
+			return nil, err
+		}
+		route.NamespaceSelector = selector
+	}
+	return route, nil
+}
+
+// namespaceLabels returns the labels of namespaces read with c, for routing
+// drift reports. Namespaces are read like the handler reads them.
+func namespaceLabels(c client.Client) callback.NamespaceLabelsFunc {
+	return func(ctx context.Context, namespace string) (map[string]string, error) {
+		ns := &unstructured.Unstructured{}
+		ns.SetAPIVersion("v1")
+		ns.SetKind("Namespace")
+		if err := c.Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+			return nil, err
+		}
+		return ns.GetLabels(), nil
+	}
+}
+
+// newTraceArchive creates the backend of the trace archive.
+func newTraceArchive(cfg *config.TraceArchiveConfig) (archive.TraceArchive, error) {
+	switch {
+	case cfg.S3 != nil:
+		return archive.NewS3Archive(archive.S3Config{Bucket: cfg.S3.Bucket, Region: cfg.S3.Region, Endpoint: cfg.S3.Endpoint})
+	case cfg.GCS != nil:
+		return archive.NewGCSArchive(archive.GCSConfig{Bucket: cfg.GCS.Bucket, TokenFile: cfg.GCS.TokenFile})
+	case cfg.Filesystem != nil:
+		return archive.NewFilesystemArchive(cfg.Filesystem.Dir), nil
+	}
+	return nil, fmt.Errorf("no trace archive backend configured")
+}
+
 func handleSignals(ctx context.Context, cancel context.CancelFunc, log logr.Logger) {
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
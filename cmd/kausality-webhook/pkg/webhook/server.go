@@ -10,14 +10,22 @@ import (
 
 	"github.com/go-logr/logr"
 
+	"k8s.io/client-go/tools/events"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
 
 	"github.com/kausality-io/kausality/pkg/admission"
+	"github.com/kausality-io/kausality/pkg/approval"
+	"github.com/kausality-io/kausality/pkg/archive"
 	"github.com/kausality-io/kausality/pkg/callback"
 	"github.com/kausality-io/kausality/pkg/config"
+	"github.com/kausality-io/kausality/pkg/controller"
+	"github.com/kausality-io/kausality/pkg/drift"
+	"github.com/kausality-io/kausality/pkg/integrations"
 	"github.com/kausality-io/kausality/pkg/policy"
+	"github.com/kausality-io/kausality/pkg/trace"
 )
 
 // Config configures the webhook server.
@@ -44,10 +52,57 @@ type Config struct {
 	// CallbackSender sends drift reports to webhook endpoints.
 	// If nil, drift callbacks are disabled.
 	CallbackSender callback.ReportSender
+	// TraceMirror receives the trace of every admitted mutation.
+	// If nil, traces are not mirrored.
+	TraceMirror callback.TraceMirror
 	// PolicyResolver provides policy configuration for drift detection.
 	// Can be a *policy.Store (CRD-based) or *policy.StaticResolver (in-memory).
 	// If nil, falls back to DriftConfig.
 	PolicyResolver policy.Resolver
+	// TicketValidator validates kausality.io/trace-ticket references on origin changes.
+	// If nil, tickets are not validated.
+	TicketValidator integrations.TicketValidator
+	// Recorder records controller identity and lifecycle phase on parents.
+	// If nil, annotations are written in-process on each admission request.
+	Recorder controller.Recorder
+	// DriftStatus counts drift on parents.
+	// If nil, drift is not counted.
+	DriftStatus drift.StatusRecorder
+	// References finds objects referencing a mutated Secret or ConfigMap.
+	// If nil, traces only follow controller owner references.
+	References trace.ReferenceFinder
+	// AggregatedAPIs tells which parents are served by aggregated API servers.
+	// If nil, all parents are read like built-in resources.
+	AggregatedAPIs drift.AggregatedAPIs
+	// ParentEdges finds the parents of objects without controller owner reference.
+	// If nil, such objects have no parent.
+	ParentEdges drift.ParentEdges
+	// TraceSigner signs the hops appended to traces.
+	// If nil, hops are not signed.
+	TraceSigner *trace.Signer
+	// SelfUsers are the usernames of kausality's own components, whose
+	// writes with field manager controller.FieldManager are not evaluated.
+	SelfUsers []string
+	// Quarantiner reverts children of policies quarantining drift when their
+	// drift is denied. If nil, drift is only denied.
+	Quarantiner drift.Quarantiner
+	// ApprovalRequester requests the approval of drift denied in enforce
+	// mode. If nil, no approvals are requested.
+	ApprovalRequester approval.Requester
+	// Tombstones records Events on deleted objects if DriftConfig enables
+	// tombstones. If nil, deletions are only mirrored.
+	Tombstones events.EventRecorder
+	// TraceArchive archives the evidence of terminal events DriftConfig's
+	// traceArchive selects. If nil, nothing is archived.
+	TraceArchive archive.Archiver
+	// OriginEvents attaches recent Events to origin hops of controllers if
+	// DriftConfig enables origin events. If nil, they are not attached.
+	OriginEvents *trace.EventEnricher
+	// Lifecycle maps parent kinds to their lifecycle signals.
+	// If nil, the mappings of DriftConfig are used.
+	Lifecycle *drift.LifecycleRegistry
+	// WhatIf serves what-if queries at admission.WhatIfPath.
+	WhatIf bool
 }
 
 // Server is a standalone webhook server for drift detection.
@@ -92,18 +147,47 @@ func NewServer(cfg Config) *Server {
 	}
 }
 
-// Register registers the admission handler with the webhook server.
+// Register registers the admission handler, the what-if endpoint if
+// enabled, and the Kausality conversion webhook with the webhook server.
 func (s *Server) Register() {
 	handler := admission.NewHandler(admission.Config{
-		Client:         s.config.Client,
-		Log:            s.log,
-		DriftConfig:    s.config.DriftConfig,
-		CallbackSender: s.config.CallbackSender,
-		PolicyResolver: s.config.PolicyResolver,
+		Client:            s.config.Client,
+		Log:               s.log,
+		DriftConfig:       s.config.DriftConfig,
+		CallbackSender:    s.config.CallbackSender,
+		TraceMirror:       s.config.TraceMirror,
+		PolicyResolver:    s.config.PolicyResolver,
+		TicketValidator:   s.config.TicketValidator,
+		Recorder:          s.config.Recorder,
+		DriftStatus:       s.config.DriftStatus,
+		References:        s.config.References,
+		AggregatedAPIs:    s.config.AggregatedAPIs,
+		ParentEdges:       s.config.ParentEdges,
+		TraceSigner:       s.config.TraceSigner,
+		SelfUsers:         s.config.SelfUsers,
+		Quarantiner:       s.config.Quarantiner,
+		ApprovalRequester: s.config.ApprovalRequester,
+		Tombstones:        s.config.Tombstones,
+		TraceArchive:      s.config.TraceArchive,
+		OriginEvents:      s.config.OriginEvents,
+		Lifecycle:         s.config.Lifecycle,
 	})
 
-	s.webhookServer.Register("/mutate", &webhook.Admission{Handler: handler})
-	s.log.Info("registered kausality webhook", "path", "/mutate")
+	s.webhookServer.Register(admission.MutatePath, &webhook.Admission{Handler: handler})
+	s.log.Info("registered kausality webhook", "path", admission.MutatePath)
+
+	s.webhookServer.Register(admission.ProtectionPath, &webhook.Admission{Handler: handler.ProtectionHandler()})
+	s.log.Info("registered self-protection webhook", "path", admission.ProtectionPath)
+
+	if s.config.WhatIf {
+		s.webhookServer.Register(admission.WhatIfPath, handler.WhatIfHandler())
+		s.log.Info("registered what-if endpoint", "path", admission.WhatIfPath)
+	}
+
+	// Converts Kausality policies between API versions through their
+	// conversion.Hub, which needs both versions in the client's scheme
+	s.webhookServer.Register(policy.ConversionPath, conversion.NewWebhookHandler(s.config.Client.Scheme(), conversion.NewRegistry()))
+	s.log.Info("registered conversion webhook", "path", policy.ConversionPath)
 }
 
 // Start starts the webhook server and health server.
@@ -0,0 +1,33 @@
+// Command audit-schema writes the JSON schema of the kausality audit annotations.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/admission"
+)
+
+func main() {
+	var prefix, output string
+	flag.StringVar(&prefix, "prefix", kausalityv1alpha1.DefaultAuditKeyPrefix, "Audit annotation key prefix.")
+	flag.StringVar(&output, "output", "", "Output file. Defaults to stdout.")
+	flag.Parse()
+
+	data, err := admission.AuditAnnotationSchema(prefix)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if output == "" {
+		_, err = os.Stdout.Write(data)
+	} else {
+		err = os.WriteFile(output, data, 0o644) //nolint:gosec
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,192 @@
+// Command install-manifests renders the chart into the manifest template of
+// kausality-cli install, so that the installer deploys what the chart deploys
+// with controller-managed certificates.
+//
+// The chart is rendered with helm and placeholder names, namespace, registry
+// and version, which are then replaced by the template parameters of the
+// installer. Fields managed by the controller are removed, so that
+// re-installing keeps them.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// Placeholders are unique strings rendered by helm and replaced by the
+// template parameters of the installer. The release name contains the chart
+// name, so it is used as the fullname without suffix.
+const (
+	namePlaceholder      = "kausality-install-name"
+	namespacePlaceholder = "install-namespace"
+	registryPlaceholder  = "install-registry.invalid"
+	versionPlaceholder   = "install-version"
+)
+
+const header = "# Code generated by hack/install-manifests from charts/kausality. DO NOT EDIT.\n"
+
+// helmLabels change with every chart release or name the installing tool,
+// which the installer sets itself.
+var helmLabels = []string{"helm.sh/chart", "app.kubernetes.io/version", "app.kubernetes.io/managed-by"}
+
+// kindInfo describes a kind the chart may render. Namespaced kinds get the
+// namespace of the installation.
+type kindInfo struct {
+	kind       string
+	namespaced bool
+}
+
+// kindOrder is the apply order of the supported kinds, following helm.
+var kindOrder = []kindInfo{
+	{"ServiceAccount", true},
+	{"Secret", true},
+	{"ConfigMap", true},
+	{"ClusterRole", false},
+	{"ClusterRoleBinding", false},
+	{"Role", true},
+	{"RoleBinding", true},
+	{"Service", true},
+	{"Deployment", true},
+	{"MutatingWebhookConfiguration", false},
+}
+
+func main() {
+	var helm, chart, output string
+	flag.StringVar(&helm, "helm", "helm", "Path to the helm binary.")
+	flag.StringVar(&chart, "chart", "charts/kausality", "Path to the chart.")
+	flag.StringVar(&output, "output", "", "Output file. Defaults to stdout.")
+	flag.Parse()
+
+	rendered, err := render(helm, chart)
+	if err == nil {
+		var data []byte
+		if data, err = convert(rendered); err == nil {
+			if output == "" {
+				_, err = os.Stdout.Write(data)
+			} else {
+				err = os.WriteFile(output, data, 0o644) //nolint:gosec
+			}
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// render runs helm template with the values of the installation.
+func render(helm, chart string) ([]byte, error) {
+	cmd := exec.Command(helm, "template", namePlaceholder, chart, //nolint:gosec
+		"--namespace", namespacePlaceholder,
+		// Render the v1 webhook configuration without cluster access
+		"--api-versions", "admissionregistration.k8s.io/v1/MutatingWebhookConfiguration",
+		"--set", "certificates.selfSigned.enabled=false",
+		"--set", "certificates.controllerManaged.enabled=true",
+		"--set", "image.repository="+registryPlaceholder+"/kausality",
+		"--set", "image.tag="+versionPlaceholder,
+		"--set", "controller.image.repository="+registryPlaceholder+"/kausality-controller",
+		"--set", "controller.image.tag="+versionPlaceholder,
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("helm template failed: %w: %s", err, stderr.String())
+	}
+	return out, nil
+}
+
+// convert turns the rendered chart into the manifest template.
+func convert(rendered []byte) ([]byte, error) {
+	var objs []*unstructured.Unstructured
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(rendered), 4096)
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := decoder.Decode(&obj.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		if len(obj.Object) > 0 {
+			objs = append(objs, obj)
+		}
+	}
+
+	for _, obj := range objs {
+		order := kindIndex(obj.GetKind())
+		if order < 0 {
+			return nil, fmt.Errorf("unsupported kind %s of %s", obj.GetKind(), obj.GetName())
+		}
+		if kindOrder[order].namespaced {
+			obj.SetNamespace(namespacePlaceholder)
+		}
+		labels := obj.GetLabels()
+		for _, key := range helmLabels {
+			delete(labels, key)
+		}
+		obj.SetLabels(labels)
+		if err := removeControllerFields(obj); err != nil {
+			return nil, err
+		}
+	}
+	slices.SortStableFunc(objs, func(a, b *unstructured.Unstructured) int {
+		if d := kindIndex(a.GetKind()) - kindIndex(b.GetKind()); d != 0 {
+			return d
+		}
+		return strings.Compare(a.GetName(), b.GetName())
+	})
+
+	var buf bytes.Buffer
+	buf.WriteString(header)
+	for _, obj := range objs {
+		data, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString("---\n")
+		buf.Write(data)
+	}
+	return []byte(strings.NewReplacer(
+		namespacePlaceholder, "{{ .Namespace }}",
+		namePlaceholder, "{{ .Name }}",
+		registryPlaceholder, "{{ .Registry }}",
+		versionPlaceholder, "{{ .Version }}",
+	).Replace(buf.String())), nil
+}
+
+// removeControllerFields removes the rules of the webhook configuration and
+// of the webhook's resource access ClusterRole, which the controller manages.
+func removeControllerFields(obj *unstructured.Unstructured) error {
+	switch {
+	case obj.GetKind() == "MutatingWebhookConfiguration":
+		webhooks, _, err := unstructured.NestedSlice(obj.Object, "webhooks")
+		if err != nil {
+			return err
+		}
+		for _, w := range webhooks {
+			if w, ok := w.(map[string]interface{}); ok {
+				delete(w, "rules")
+			}
+		}
+		return unstructured.SetNestedSlice(obj.Object, webhooks, "webhooks")
+	case obj.GetKind() == "ClusterRole" && obj.GetName() == namePlaceholder+"-webhook-resources":
+		unstructured.RemoveNestedField(obj.Object, "rules")
+	}
+	return nil
+}
+
+func kindIndex(kind string) int {
+	return slices.IndexFunc(kindOrder, func(k kindInfo) bool { return k.kind == kind })
+}
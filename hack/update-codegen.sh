@@ -0,0 +1,42 @@
+#!/usr/bin/env bash
+
+# Generates the typed clientset, listers and informers of api/ into
+# pkg/client. Types are included with the +genclient marker.
+
+set -o errexit
+set -o nounset
+set -o pipefail
+
+ROOT="$(cd "$(dirname "${BASH_SOURCE[0]}")/.." && pwd)"
+BIN="${BIN:-${ROOT}/bin}"
+MODULE="github.com/kausality-io/kausality"
+OUTPUT_PKG="${MODULE}/pkg/client"
+OUTPUT_DIR="${ROOT}/pkg/client"
+BOILERPLATE="${ROOT}/hack/boilerplate.go.txt"
+
+rm -rf "${OUTPUT_DIR}/clientset" "${OUTPUT_DIR}/listers" "${OUTPUT_DIR}/informers"
+
+"${BIN}/client-gen" \
+  --go-header-file "${BOILERPLATE}" \
+  --clientset-name versioned \
+  --input-base "${MODULE}" \
+  --input api/v1alpha1 \
+  --input api/v1beta1 \
+  --output-dir "${OUTPUT_DIR}/clientset" \
+  --output-pkg "${OUTPUT_PKG}/clientset"
+
+"${BIN}/lister-gen" \
+  --go-header-file "${BOILERPLATE}" \
+  --output-dir "${OUTPUT_DIR}/listers" \
+  --output-pkg "${OUTPUT_PKG}/listers" \
+  "${MODULE}/api/v1alpha1" \
+  "${MODULE}/api/v1beta1"
+
+"${BIN}/informer-gen" \
+  --go-header-file "${BOILERPLATE}" \
+  --versioned-clientset-package "${OUTPUT_PKG}/clientset/versioned" \
+  --listers-package "${OUTPUT_PKG}/listers" \
+  --output-dir "${OUTPUT_DIR}/informers" \
+  --output-pkg "${OUTPUT_PKG}/informers" \
+  "${MODULE}/api/v1alpha1" \
+  "${MODULE}/api/v1beta1"
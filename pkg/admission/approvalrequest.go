@@ -0,0 +1,135 @@
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/pmezard/go-difflib/difflib"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"sigs.k8s.io/yaml"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/approval"
+	"github.com/kausality-io/kausality/pkg/drift"
+)
+
+// maxRequestDiffBytes bounds the diff of an ApprovalRequest, far below the
+// size limit of objects.
+const maxRequestDiffBytes = 16 * 1024
+
+// requestApproval requests the approval of denied drift of obj at the
+// parent generation it was checked against, unless the request is a dry
+// run. Requests are created in the namespace of the parent, or the
+// configured namespace for cluster-scoped parents, which the request
+// controller checks before applying decisions. It returns the note for the
+// denial message, or "".
+func (h *Handler) requestApproval(ctx context.Context, req admission.Request, obj client.Object, driftResult *drift.DriftResult, result approvalCheckResult, log logr.Logger) string {
+	if h.approvalRequester == nil || driftResult.ParentRef == nil || result.parent == nil {
+		return ""
+	}
+	if req.DryRun != nil && *req.DryRun {
+		return ""
+	}
+	// Requests of cluster-scoped parents go to the configured namespace
+	parent := driftResult.ParentRef
+	namespace := parent.Namespace
+	if namespace == "" && h.config.ApprovalRequests != nil {
+		namespace = h.config.ApprovalRequests.Namespace
+	}
+	if namespace == "" {
+		log.V(1).Info("no namespace for the approval request of a cluster-scoped parent")
+		return ""
+	}
+	driftID := detectedDriftID(req, obj, driftResult)
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	request := &kausalityv1alpha1.ApprovalRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      approval.RequestName(driftID, result.parent.Generation()),
+		},
+		Spec: kausalityv1alpha1.ApprovalRequestSpec{
+			Parent: kausalityv1alpha1.ApprovalRequestObjectReference{
+				APIVersion: parent.APIVersion,
+				Kind:       parent.Kind,
+				Namespace:  parent.Namespace,
+				Name:       parent.Name,
+			},
+			ParentGeneration: result.parent.Generation(),
+			Child: kausalityv1alpha1.ApprovalRequestObjectReference{
+				APIVersion: gvk.GroupVersion().String(),
+				Kind:       gvk.Kind,
+				Namespace:  obj.GetNamespace(),
+				Name:       obj.GetName(),
+			},
+			DriftID: driftID,
+			User:    req.UserInfo.Username,
+			Diff:    requestDiff(req, gvk.Kind+"/"+obj.GetName()),
+		},
+	}
+	log.V(1).Info("requesting approval", "approvalRequest", request.Name)
+	ctx, cancel := h.callbackBudget(ctx)
+	defer cancel()
+	h.approvalRequester.RequestApproval(ctx, request)
+	return " (approval requested: ApprovalRequest " + request.Name + ")"
+}
+
+// requestDiff returns a unified diff of the object of an admission request
+// from the old object, without metadata and status. Without old object, the
+// diff adds the whole object. Diffs longer than maxRequestDiffBytes are
+// truncated.
+func requestDiff(req admission.Request, name string) string {
+	to, ok := specYAML(req.Object.Raw)
+	if !ok {
+		return ""
+	}
+	from := ""
+	if req.Operation == admissionv1.Update {
+		if from, ok = specYAML(req.OldObject.Raw); !ok {
+			return ""
+		}
+	}
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(from),
+		B:        splitLines(to),
+		FromFile: "old/" + name,
+		ToFile:   "new/" + name,
+		Context:  3,
+	})
+	if err != nil {
+		return ""
+	}
+	if len(diff) > maxRequestDiffBytes {
+		diff = diff[:maxRequestDiffBytes] + "\n... (truncated)\n"
+	}
+	return diff
+}
+
+// specYAML returns a raw object as YAML without metadata and status.
+func specYAML(raw []byte) (string, bool) {
+	var obj map[string]interface{}
+	if len(raw) == 0 || json.Unmarshal(raw, &obj) != nil || obj == nil {
+		return "", false
+	}
+	delete(obj, "metadata")
+	delete(obj, "status")
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// splitLines splits text into lines for difflib, without the empty line
+// difflib.SplitLines adds after a trailing newline.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return difflib.SplitLines(strings.TrimSuffix(text, "\n"))
+}
@@ -0,0 +1,117 @@
+package admission
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+	"github.com/kausality-io/kausality/pkg/controller"
+	"github.com/kausality-io/kausality/pkg/policy"
+	"github.com/kausality-io/kausality/pkg/testing/scenario"
+)
+
+// recordingRequester records requested approvals.
+type recordingRequester struct {
+	requests []*kausalityv1alpha1.ApprovalRequest
+}
+
+func (r *recordingRequester) RequestApproval(_ context.Context, request *kausalityv1alpha1.ApprovalRequest) {
+	r.requests = append(r.requests, request)
+}
+
+func TestHandle_ApprovalRequest(t *testing.T) {
+	// A stable Deployment at generation 2 whose ReplicaSet is scaled by its controller
+	s := scenario.NewParentChild().
+		WithParent(scenario.DeploymentGVK, "web").
+		WithChild(scenario.ReplicaSetGVK, "web-abc").
+		StableParent().
+		WithControllers(controller.HashUsername(scenario.ControllerUser)).
+		ChildSpecChange(map[string]interface{}{"replicas": int64(3)})
+	newHandler := func(mode kausalityv1beta1.Mode) (*Handler, *recordingRequester) {
+		store := policy.NewStore(nil, logr.Discard())
+		store.Update([]kausalityv1beta1.Kausality{{
+			ObjectMeta: metav1.ObjectMeta{Name: "replicasets"},
+			Spec: kausalityv1beta1.KausalitySpec{
+				Resources: []kausalityv1beta1.ResourceRule{{APIGroups: []string{"apps"}, Resources: []string{"replicasets"}}},
+				Mode:      mode,
+			},
+		}})
+		requester := &recordingRequester{}
+		return NewHandler(Config{Client: s.Client(), Log: logr.Discard(), PolicyResolver: store, ApprovalRequester: requester}), requester
+	}
+
+	t.Run("enforce", func(t *testing.T) {
+		h, requester := newHandler(kausalityv1beta1.ModeEnforce)
+		resp := h.Handle(context.Background(), s.Request(scenario.ControllerUser))
+		require.False(t, resp.Allowed)
+		require.Len(t, requester.requests, 1)
+
+		request := requester.requests[0]
+		assert.Contains(t, resp.Result.Message, "approval requested: ApprovalRequest "+request.Name)
+		assert.Equal(t, "default", request.Namespace)
+		assert.Equal(t, kausalityv1alpha1.ApprovalRequestObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "web"}, request.Spec.Parent)
+		assert.Equal(t, int64(2), request.Spec.ParentGeneration)
+		assert.Equal(t, kausalityv1alpha1.ApprovalRequestObjectReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Namespace: "default", Name: "web-abc"}, request.Spec.Child)
+		assert.Equal(t, scenario.ControllerUser, request.Spec.User)
+		assert.NotEmpty(t, request.Spec.DriftID)
+		assert.Contains(t, request.Spec.Diff, "-  replicas: 1")
+		assert.Contains(t, request.Spec.Diff, "+  replicas: 3")
+		assert.Contains(t, resp.Result.Details.Causes, metav1.StatusCause{Type: kausalityv1alpha1.DenialCauseDriftID, Message: request.Spec.DriftID})
+
+		// Retries of the same change map to the same request
+		h.Handle(context.Background(), s.Request(scenario.ControllerUser))
+		require.Len(t, requester.requests, 2)
+		assert.Equal(t, request.Name, requester.requests[1].Name)
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		h, requester := newHandler(kausalityv1beta1.ModeEnforce)
+		req := s.Request(scenario.ControllerUser)
+		req.DryRun = ptr.To(true)
+		resp := h.Handle(context.Background(), req)
+		require.False(t, resp.Allowed)
+		assert.NotContains(t, resp.Result.Message, "approval requested")
+		assert.Empty(t, requester.requests)
+	})
+
+	t.Run("log mode", func(t *testing.T) {
+		h, requester := newHandler(kausalityv1beta1.ModeLog)
+		resp := h.Handle(context.Background(), s.Request(scenario.ControllerUser))
+		require.True(t, resp.Allowed)
+		assert.Empty(t, requester.requests)
+	})
+}
+
+func TestRequestDiff(t *testing.T) {
+	spec := func(replicas int64) map[string]interface{} {
+		return map[string]interface{}{"replicas": replicas}
+	}
+	rs := func() *scenario.Scenario {
+		return scenario.NewParentChild().
+			WithChild(scenario.ReplicaSetGVK, "web-abc").
+			WithChildAnnotations(map[string]string{"note": "ignored"})
+	}
+
+	diff := requestDiff(rs().ChildCreate(spec(1)).Request("alice"), "ReplicaSet/web-abc")
+	assert.Contains(t, diff, "+++ new/ReplicaSet/web-abc")
+	assert.Contains(t, diff, "+  replicas: 1")
+	assert.NotContains(t, diff, "note")
+
+	diff = requestDiff(rs().ChildSpecChange(spec(2)).Request("alice"), "ReplicaSet/web-abc")
+	assert.Contains(t, diff, "-  replicas: 1")
+	assert.Contains(t, diff, "+  replicas: 2")
+
+	large := spec(1)
+	large["data"] = string(make([]byte, 2*maxRequestDiffBytes))
+	diff = requestDiff(rs().ChildCreate(large).Request("alice"), "ReplicaSet/web-abc")
+	assert.Contains(t, diff, "... (truncated)")
+	assert.Less(t, len(diff), maxRequestDiffBytes+100)
+}
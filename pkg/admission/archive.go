@@ -0,0 +1,120 @@
+package admission
+
+import (
+	"encoding/json"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/trace"
+)
+
+// archives returns whether event of req is archived. Dry-run requests are
+// not archived since nothing is persisted.
+func (h *Handler) archives(req admission.Request, event v1alpha1.ArchiveEvent) bool {
+	return h.archiver != nil && h.config.TraceArchive.Archives(string(event)) && (req.DryRun == nil || !*req.DryRun)
+}
+
+// archiveDenial archives a denied mutation with the trace it would have had,
+// the denial status and the denied object, if configured. Server errors are
+// not denials and are not archived.
+func (h *Handler) archiveDenial(req admission.Request, resp admission.Response) {
+	if responseDecision(resp) != "denied" || !h.archives(req, v1alpha1.ArchiveEventDenied) {
+		return
+	}
+	raw := req.Object.Raw
+	if req.Operation == admissionv1.Delete {
+		raw = req.OldObject.Raw
+	}
+	var meta metav1.PartialObjectMetadata
+	_ = json.Unmarshal(raw, &meta)
+	name := req.Name
+	if name == "" {
+		name = meta.Name
+	}
+
+	record := &v1alpha1.ArchiveRecord{Spec: v1alpha1.ArchiveRecordSpec{
+		Event: v1alpha1.ArchiveEventDenied,
+		Object: v1alpha1.ObjectReference{
+			APIVersion: schema.GroupVersion{Group: req.Kind.Group, Version: req.Kind.Version}.String(),
+			Kind:       req.Kind.Kind,
+			Namespace:  req.Namespace,
+			Name:       name,
+			UID:        types.UID(meta.UID),
+			Generation: meta.Generation,
+		},
+		Request:   requestContext(req),
+		Timestamp: metav1.Now(),
+		Denial:    resp.Result,
+	}}
+	if t := resp.AuditAnnotations[kausalityv1alpha1.AuditKeyTrace]; t != "" {
+		record.Spec.Trace = &runtime.RawExtension{Raw: []byte(t)}
+	}
+	if req.Operation != admissionv1.Delete && len(raw) > 0 {
+		record.Spec.DeniedObject = &runtime.RawExtension{Raw: raw}
+	}
+	h.archiver.Archive(record)
+}
+
+// archiveResolution archives the Resolved drift report of req, if configured.
+func (h *Handler) archiveResolution(req admission.Request, report *v1alpha1.DriftReport) {
+	if !h.archives(req, v1alpha1.ArchiveEventResolved) {
+		return
+	}
+	spec := report.Spec
+	record := &v1alpha1.ArchiveRecord{Spec: v1alpha1.ArchiveRecordSpec{
+		Event:     v1alpha1.ArchiveEventResolved,
+		Object:    spec.Child,
+		Request:   spec.Request,
+		Timestamp: metav1.Now(),
+		Report:    &spec,
+	}}
+	if t := traceAnnotation(spec.NewObject.Raw); t != "" {
+		record.Spec.Trace = &runtime.RawExtension{Raw: []byte(t)}
+	}
+	h.archiver.Archive(record)
+}
+
+// archiveDeletion archives the final trace of an object deleted by req and
+// the deletion, if configured.
+func (h *Handler) archiveDeletion(req admission.Request, obj client.Object, t trace.Trace) {
+	if !h.archives(req, v1alpha1.ArchiveEventDeleted) {
+		return
+	}
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	record := &v1alpha1.ArchiveRecord{Spec: v1alpha1.ArchiveRecordSpec{
+		Event: v1alpha1.ArchiveEventDeleted,
+		Object: v1alpha1.ObjectReference{
+			APIVersion: gvk.GroupVersion().String(),
+			Kind:       gvk.Kind,
+			Namespace:  obj.GetNamespace(),
+			Name:       obj.GetName(),
+			UID:        obj.GetUID(),
+			Generation: obj.GetGeneration(),
+		},
+		Request:   requestContext(req),
+		Timestamp: metav1.Now(),
+		Deletion:  deletionOf(req, obj),
+	}}
+	if len(t) > 0 {
+		record.Spec.Trace = &runtime.RawExtension{Raw: []byte(t.String())}
+	}
+	h.archiver.Archive(record)
+}
+
+// traceAnnotation returns the kausality.io/trace annotation of a serialized
+// object, or "" if it has none.
+func traceAnnotation(raw []byte) string {
+	var meta metav1.PartialObjectMetadata
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		return ""
+	}
+	return meta.Annotations[kausalityv1alpha1.TraceAnnotation]
+}
@@ -0,0 +1,89 @@
+package admission
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/config"
+	"github.com/kausality-io/kausality/pkg/controller"
+)
+
+type recordingArchiver struct {
+	records []*v1alpha1.ArchiveRecord
+}
+
+func (a *recordingArchiver) Archive(record *v1alpha1.ArchiveRecord) {
+	a.records = append(a.records, record)
+}
+
+func TestHandle_Archive(t *testing.T) {
+	ctx := context.Background()
+	newHandler := func(events ...string) (*Handler, *recordingSender, *recordingArchiver) {
+		h, sender := newResolutionTestHandler(1, 1)
+		archiver := &recordingArchiver{}
+		h.archiver = archiver
+		h.config.TraceArchive = &config.TraceArchiveConfig{Events: events}
+		return h, sender, archiver
+	}
+
+	// Denials are archived with the trace the mutation would have had
+	h, _, archiver := newHandler()
+	req := driftRequest(nil)
+	resp := h.Handle(ctx, req)
+	require.False(t, resp.Allowed)
+	require.Len(t, archiver.records, 1)
+	denied := archiver.records[0].Spec
+	assert.Equal(t, v1alpha1.ArchiveEventDenied, denied.Event)
+	assert.Equal(t, v1alpha1.ObjectReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Namespace: "default", Name: "app-abc", UID: "rs-uid"}, denied.Object)
+	assert.Equal(t, deploymentController, denied.Request.User)
+	require.NotNil(t, denied.Denial)
+	assert.Equal(t, resp.Result.Message, denied.Denial.Message)
+	require.NotNil(t, denied.Trace)
+	assert.Equal(t, resp.AuditAnnotations[auditKeyTrace], string(denied.Trace.Raw))
+	require.NotNil(t, denied.DeniedObject)
+	assert.Equal(t, req.Object.Raw, denied.DeniedObject.Raw)
+
+	// Resolved drift is archived with its report
+	h, sender, archiver := newHandler()
+	driftChild(t, h, sender)
+	ctrlHash := controller.HashUsername(deploymentController)
+	require.True(t, h.Handle(ctx, buildAdmissionRequest(admissionv1.Update, childRS(1, ""), childRS(3, ctrlHash), "admin")).Allowed)
+	require.Len(t, archiver.records, 1)
+	resolved := archiver.records[0].Spec
+	assert.Equal(t, v1alpha1.ArchiveEventResolved, resolved.Event)
+	require.NotNil(t, resolved.Report)
+	assert.Equal(t, sender.last().Spec.ID, resolved.Report.ID)
+	assert.Equal(t, v1alpha1.ResolutionManuallyReverted, resolved.Report.Resolution.Kind)
+
+	// Deletions are archived with the final trace
+	h, _, archiver = newHandler(string(v1alpha1.ArchiveEventDeleted))
+	child := childRS(1, "")
+	req = buildAdmissionRequest(admissionv1.Delete, child, child, "admin")
+	require.True(t, h.Handle(ctx, req).Allowed)
+	require.Len(t, archiver.records, 1)
+	deleted := archiver.records[0].Spec
+	assert.Equal(t, v1alpha1.ArchiveEventDeleted, deleted.Event)
+	assert.Equal(t, &v1alpha1.Deletion{}, deleted.Deletion)
+	require.NotNil(t, deleted.Trace)
+	assert.Contains(t, string(deleted.Trace.Raw), `"user":"admin"`)
+
+	// Only the configured events are archived, and dry runs are not
+	require.False(t, h.Handle(ctx, driftRequest(nil)).Allowed)
+	assert.Len(t, archiver.records, 1)
+	req.DryRun = ptr.To(true)
+	require.True(t, h.Handle(ctx, req).Allowed)
+	assert.Len(t, archiver.records, 1)
+
+	// Without configuration, nothing is archived
+	h, _, archiver = newHandler()
+	h.config.TraceArchive = nil
+	require.False(t, h.Handle(ctx, driftRequest(nil)).Allowed)
+	assert.Empty(t, archiver.records)
+}
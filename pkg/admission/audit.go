@@ -1,20 +1,28 @@
 package admission
 
 import (
+	"maps"
+	"net/http"
+
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
-)
 
-// Audit annotation keys for admission response audit events.
-// These appear in the Kubernetes audit log, not on the object.
-const (
-	auditKeyDecision        = "kausality.io/decision"
-	auditKeyDrift           = "kausality.io/drift"
-	auditKeyMode            = "kausality.io/mode"
-	auditKeyLifecyclePhase  = "kausality.io/lifecycle-phase"
-	auditKeyDriftResolution = "kausality.io/drift-resolution"
-	auditKeyTrace           = "kausality.io/trace"
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
 )
 
+// prefixAuditAnnotations prefixes the audit annotation keys of an admission
+// response. Keys are kausalityv1alpha1.AuditKey* constants.
+func prefixAuditAnnotations(resp admission.Response, prefix string) admission.Response {
+	if prefix == "" || len(resp.AuditAnnotations) == 0 {
+		return resp
+	}
+	audit := make(map[string]string, len(resp.AuditAnnotations))
+	for key, value := range resp.AuditAnnotations {
+		audit[prefix+key] = value
+	}
+	resp.AuditAnnotations = audit
+	return resp
+}
+
 // withAuditAnnotations sets audit annotations on an admission response.
 func withAuditAnnotations(resp admission.Response, audit map[string]string) admission.Response {
 	if len(audit) > 0 {
@@ -23,6 +31,53 @@ func withAuditAnnotations(resp admission.Response, audit map[string]string) admi
 	return resp
 }
 
+// completeAuditAnnotations adds the keys every response carries to its audit
+// annotations: the schema version, the decision derived from the response,
+// and the reason of denials. Handlers of the individual paths only record
+// what they found out; the response is the single source of the decision, so
+// that it cannot disagree with the audit log.
+func completeAuditAnnotations(resp admission.Response) admission.Response {
+	audit := maps.Clone(resp.AuditAnnotations)
+	if audit == nil {
+		audit = map[string]string{}
+	}
+	audit[kausalityv1alpha1.AuditKeySchemaVersion] = kausalityv1alpha1.AuditSchemaVersion
+	audit[kausalityv1alpha1.AuditKeyDecision] = responseDecision(resp)
+	delete(audit, kausalityv1alpha1.AuditKeyDenialReason)
+	if reason := denialReason(resp); reason != "" {
+		audit[kausalityv1alpha1.AuditKeyDenialReason] = reason
+	}
+	resp.AuditAnnotations = audit
+	return resp
+}
+
+// responseDecision returns the decision of an admission response: "error"
+// for server errors, "denied" for other denials, and auditDecision of its
+// warnings if allowed.
+func responseDecision(resp admission.Response) string {
+	switch {
+	case resp.Allowed:
+		return auditDecision(resp.Warnings)
+	case resp.Result != nil && resp.Result.Code >= http.StatusInternalServerError:
+		return "error"
+	}
+	return "denied"
+}
+
+// denialReason returns the DenialReason of a denial by its status details,
+// or "" if it has none.
+func denialReason(resp admission.Response) string {
+	if resp.Allowed || resp.Result == nil || resp.Result.Details == nil {
+		return ""
+	}
+	for _, cause := range resp.Result.Details.Causes {
+		if cause.Type == kausalityv1alpha1.DenialCauseReason {
+			return cause.Message
+		}
+	}
+	return ""
+}
+
 // auditDecision returns "allowed-with-warning" if there are warnings, "allowed" otherwise.
 func auditDecision(warnings []string) string {
 	if len(warnings) > 0 {
@@ -3,7 +3,11 @@ package admission
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/stretchr/testify/assert"
@@ -19,7 +23,31 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+	"github.com/kausality-io/kausality/pkg/approval"
+	"github.com/kausality-io/kausality/pkg/config"
 	"github.com/kausality-io/kausality/pkg/controller"
+	"github.com/kausality-io/kausality/pkg/policy"
+	"github.com/kausality-io/kausality/pkg/testing/scenario"
+)
+
+// Audit annotation keys with the default prefix.
+const (
+	auditKeyDecision        = kausalityv1alpha1.DefaultAuditKeyPrefix + kausalityv1alpha1.AuditKeyDecision
+	auditKeyDrift           = kausalityv1alpha1.DefaultAuditKeyPrefix + kausalityv1alpha1.AuditKeyDrift
+	auditKeyMode            = kausalityv1alpha1.DefaultAuditKeyPrefix + kausalityv1alpha1.AuditKeyMode
+	auditKeyLifecyclePhase  = kausalityv1alpha1.DefaultAuditKeyPrefix + kausalityv1alpha1.AuditKeyLifecyclePhase
+	auditKeyDriftResolution = kausalityv1alpha1.DefaultAuditKeyPrefix + kausalityv1alpha1.AuditKeyDriftResolution
+	auditKeyTrace           = kausalityv1alpha1.DefaultAuditKeyPrefix + kausalityv1alpha1.AuditKeyTrace
+	auditKeyTicket          = kausalityv1alpha1.DefaultAuditKeyPrefix + kausalityv1alpha1.AuditKeyTicket
+	auditKeyOverride        = kausalityv1alpha1.DefaultAuditKeyPrefix + kausalityv1alpha1.AuditKeyOverride
+	auditKeySubresource     = kausalityv1alpha1.DefaultAuditKeyPrefix + kausalityv1alpha1.AuditKeySubresource
+	auditKeyDecisionCache   = kausalityv1alpha1.DefaultAuditKeyPrefix + kausalityv1alpha1.AuditKeyDecisionCache
+	auditKeyDriftExclusion  = kausalityv1alpha1.DefaultAuditKeyPrefix + kausalityv1alpha1.AuditKeyDriftExclusion
+	auditKeyError           = kausalityv1alpha1.DefaultAuditKeyPrefix + kausalityv1alpha1.AuditKeyError
+	auditKeySchemaVersion   = kausalityv1alpha1.DefaultAuditKeyPrefix + kausalityv1alpha1.AuditKeySchemaVersion
+	auditKeyDenialReason    = kausalityv1alpha1.DefaultAuditKeyPrefix + kausalityv1alpha1.AuditKeyDenialReason
 )
 
 var (
@@ -166,30 +194,12 @@ func TestAuditAnnotations_CreateWithoutOwner(t *testing.T) {
 
 func TestAuditAnnotations_UpdateNoDrift(t *testing.T) {
 	// Parent is reconciling (gen != obsGen) → child update is expected, not drift
-	parent := buildUnstructured(deploymentGVK, "default", "parent-deploy",
-		map[string]interface{}{"replicas": int64(1)},
-		withUID("parent-uid-1"),
-		withGeneration(2),
-		withStatus(map[string]interface{}{
-			"observedGeneration": int64(1), // gen != obsGen → reconciling
-		}),
-	)
+	s := scenario.NewParentChild().
+		ReconcilingParent().
+		ChildSpecChange(map[string]interface{}{"replicas": int64(2)})
 
-	h := newTestHandler(parent)
-	ctx := context.Background()
-
-	// Child with ownerRef to parent
-	child := buildUnstructured(replicaSetGVK, "default", "child-rs",
-		map[string]interface{}{"replicas": int64(1)},
-		withOwnerRef(deploymentGVK, "parent-deploy", "parent-uid-1"),
-	)
-	oldChild := buildUnstructured(replicaSetGVK, "default", "child-rs",
-		map[string]interface{}{"replicas": int64(2)}, // different spec → spec changed
-		withOwnerRef(deploymentGVK, "parent-deploy", "parent-uid-1"),
-	)
-
-	req := buildAdmissionRequest(admissionv1.Update, child, oldChild, "system:serviceaccount:kube-system:deployment-controller")
-	resp := h.Handle(ctx, req)
+	h := NewHandler(Config{Client: s.Client(), Log: logr.Discard()})
+	resp := h.Handle(context.Background(), s.Request(scenario.ControllerUser))
 
 	require.True(t, resp.Allowed)
 	audit := resp.AuditAnnotations
@@ -201,40 +211,15 @@ func TestAuditAnnotations_UpdateNoDrift(t *testing.T) {
 }
 
 func TestAuditAnnotations_DriftDetectedLogMode(t *testing.T) {
-	userHash := controller.HashUsername("system:serviceaccount:kube-system:deployment-controller")
-
-	// Parent stable (gen == obsGen) and initialized
-	parent := buildUnstructured(deploymentGVK, "default", "stable-deploy",
-		map[string]interface{}{"replicas": int64(1)},
-		withUID("stable-uid-1"),
-		withGeneration(1),
-		withAnnotations(map[string]string{
-			controller.PhaseAnnotation: controller.PhaseValueInitialized,
-		}),
-		withStatus(map[string]interface{}{
-			"observedGeneration": int64(1), // gen == obsGen → stable
-		}),
-	)
-
-	h := newTestHandler(parent)
-	ctx := context.Background()
-
-	// Child with updater hash matching current user (single updater = controller)
-	child := buildUnstructured(replicaSetGVK, "default", "drift-rs",
-		map[string]interface{}{"replicas": int64(3)},
-		withOwnerRef(deploymentGVK, "stable-deploy", "stable-uid-1"),
-	)
-	oldChild := buildUnstructured(replicaSetGVK, "default", "drift-rs",
-		map[string]interface{}{"replicas": int64(1)},
-		withOwnerRef(deploymentGVK, "stable-deploy", "stable-uid-1"),
-		withAnnotations(map[string]string{
-			controller.UpdatersAnnotation: userHash, // single updater = controller
-		}),
-	)
+	// Parent stable (gen == obsGen) and initialized, child with updater hash
+	// matching current user (single updater = controller)
+	s := scenario.NewParentChild().
+		StableParent().
+		WithUpdaters(controller.HashUsername(scenario.ControllerUser)).
+		ChildSpecChange(map[string]interface{}{"replicas": int64(3)})
 
-	req := buildAdmissionRequest(admissionv1.Update, child, oldChild,
-		"system:serviceaccount:kube-system:deployment-controller")
-	resp := h.Handle(ctx, req)
+	h := NewHandler(Config{Client: s.Client(), Log: logr.Discard()})
+	resp := h.Handle(context.Background(), s.Request(scenario.ControllerUser))
 
 	require.True(t, resp.Allowed, "log mode allows drift")
 	require.NotEmpty(t, resp.Warnings, "should have drift warning")
@@ -294,9 +279,10 @@ func TestAuditAnnotations_DriftDeniedEnforceMode(t *testing.T) {
 	assert.Equal(t, "enforce", audit[auditKeyMode])
 	assert.Equal(t, "Initialized", audit[auditKeyLifecyclePhase])
 	assert.Equal(t, "unresolved", audit[auditKeyDriftResolution])
+	assert.Equal(t, string(kausalityv1alpha1.DenialReasonDrift), audit[auditKeyDenialReason])
 
-	// Trace is NOT set on denied paths (mutation didn't happen, trace not computed)
-	assert.Empty(t, audit[auditKeyTrace])
+	// The mutation didn't happen: the audit log records the trace it would have had
+	assert.NotEmpty(t, audit[auditKeyTrace])
 }
 
 func TestAuditAnnotations_DeleteHasTrace(t *testing.T) {
@@ -321,7 +307,7 @@ func TestAuditAnnotations_DeleteHasTrace(t *testing.T) {
 	assert.NotEmpty(t, audit[auditKeyTrace], "DELETE should have trace in audit (can't patch object)")
 }
 
-func TestAuditAnnotations_NoAuditOnStatusUpdate(t *testing.T) {
+func TestAuditAnnotations_StatusUpdate(t *testing.T) {
 	h := newTestHandler()
 	ctx := context.Background()
 
@@ -336,8 +322,11 @@ func TestAuditAnnotations_NoAuditOnStatusUpdate(t *testing.T) {
 	resp := h.Handle(ctx, req)
 
 	require.True(t, resp.Allowed)
-	// Status updates don't set audit annotations
-	assert.Empty(t, resp.AuditAnnotations)
+	audit := resp.AuditAnnotations
+	assert.Equal(t, "allowed", audit[auditKeyDecision])
+	assert.Equal(t, "status", audit[auditKeySubresource])
+	assert.Equal(t, "Initializing", audit[auditKeyLifecyclePhase], "phase of the object itself")
+	assert.Empty(t, audit[auditKeyDrift], "status updates are not checked for drift")
 }
 
 func TestAuditAnnotations_FreezeDeniesMutation(t *testing.T) {
@@ -374,9 +363,9 @@ func TestAuditAnnotations_FreezeDeniesMutation(t *testing.T) {
 	audit := resp.AuditAnnotations
 	assert.Equal(t, "denied", audit[auditKeyDecision])
 	assert.NotEmpty(t, audit[auditKeyDrift]) // drift detection ran before freeze check
-
-	// Mode is NOT set (resolved after freeze check)
-	assert.Empty(t, audit[auditKeyMode])
+	assert.Equal(t, "log", audit[auditKeyMode], "mode is resolved before the freeze check")
+	assert.Equal(t, string(kausalityv1alpha1.DenialReasonFrozen), audit[auditKeyDenialReason])
+	assert.NotEmpty(t, audit[auditKeyTrace])
 }
 
 func TestAuditDecision(t *testing.T) {
@@ -385,6 +374,62 @@ func TestAuditDecision(t *testing.T) {
 	assert.Equal(t, "allowed-with-warning", auditDecision([]string{"drift detected"}))
 }
 
+func TestCompleteAuditAnnotations(t *testing.T) {
+	obj := buildUnstructured(configMapGVK, "default", "test-cm", nil)
+	serverError := admission.Errored(http.StatusInternalServerError, errors.New("boom"))
+	tests := []struct {
+		name string
+		resp admission.Response
+		want map[string]string
+	}{
+		{
+			name: "allowed",
+			resp: admission.Allowed(""),
+			want: map[string]string{kausalityv1alpha1.AuditKeyDecision: "allowed"},
+		},
+		{
+			name: "allowed with warning",
+			resp: withWarnings(admission.Allowed(""), []string{"careful"}),
+			want: map[string]string{kausalityv1alpha1.AuditKeyDecision: "allowed-with-warning"},
+		},
+		{
+			name: "denied with reason",
+			resp: denied(kausalityv1alpha1.DenialReasonFrozen, "frozen", admission.Request{}, obj, nil),
+			want: map[string]string{
+				kausalityv1alpha1.AuditKeyDecision:     "denied",
+				kausalityv1alpha1.AuditKeyDenialReason: string(kausalityv1alpha1.DenialReasonFrozen),
+			},
+		},
+		{
+			name: "denied by error handling",
+			resp: admission.Denied("DecodeError: boom"),
+			want: map[string]string{kausalityv1alpha1.AuditKeyDecision: "denied"},
+		},
+		{
+			name: "server error",
+			resp: serverError,
+			want: map[string]string{kausalityv1alpha1.AuditKeyDecision: "error"},
+		},
+		{
+			name: "recorded decision is overwritten by the response",
+			resp: withAuditAnnotations(admission.Allowed(""), map[string]string{
+				kausalityv1alpha1.AuditKeyDecision: "denied",
+				kausalityv1alpha1.AuditKeyMode:     "log",
+			}),
+			want: map[string]string{
+				kausalityv1alpha1.AuditKeyDecision: "allowed",
+				kausalityv1alpha1.AuditKeyMode:     "log",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.want[kausalityv1alpha1.AuditKeySchemaVersion] = kausalityv1alpha1.AuditSchemaVersion
+			assert.Equal(t, tt.want, completeAuditAnnotations(tt.resp).AuditAnnotations)
+		})
+	}
+}
+
 func TestWithAuditAnnotations(t *testing.T) {
 	resp := admission.Allowed("ok")
 
@@ -401,3 +446,359 @@ func TestWithAuditAnnotations(t *testing.T) {
 	result = withAuditAnnotations(resp, audit)
 	assert.Equal(t, audit, result.AuditAnnotations)
 }
+
+func TestAuditAnnotations_KeyPrefix(t *testing.T) {
+	obj := buildUnstructured(configMapGVK, "default", "test-cm",
+		map[string]interface{}{"data": "value"})
+	req := buildAdmissionRequest(admissionv1.Create, obj, nil, "admin")
+
+	for _, prefix := range []string{"", "audit.kausality.io/"} {
+		h := NewHandler(Config{
+			Client: fake.NewClientBuilder().Build(),
+			Log:    logr.Discard(),
+			DriftConfig: &config.Config{
+				DriftDetection: config.DriftDetectionConfig{DefaultMode: config.ModeLog},
+				Audit:          &config.AuditConfig{KeyPrefix: &prefix},
+			},
+		})
+		resp := h.Handle(context.Background(), req)
+
+		require.True(t, resp.Allowed)
+		assert.Equal(t, "allowed", resp.AuditAnnotations[prefix+kausalityv1alpha1.AuditKeyDecision], "prefix %q", prefix)
+		assert.NotContains(t, resp.AuditAnnotations, auditKeyDecision, "prefix %q", prefix)
+	}
+}
+
+// auditPolicyHandler returns a handler resolving the policy of ReplicaSets
+// from a Kausality with the given spec, with the app Deployment of
+// newResolutionTestHandler.
+func auditPolicyHandler(spec kausalityv1beta1.KausalitySpec) *Handler {
+	spec.Resources = []kausalityv1beta1.ResourceRule{{APIGroups: []string{"apps"}, Resources: []string{"replicasets"}}}
+	store := policy.NewStore(nil, logr.Discard())
+	store.Update([]kausalityv1beta1.Kausality{{ObjectMeta: metav1.ObjectMeta{Name: "replicasets"}, Spec: spec}})
+	parent := buildUnstructured(deploymentGVK, "default", "app", map[string]interface{}{"replicas": int64(1)},
+		withUID("app-uid"), withGeneration(1), withStatus(map[string]interface{}{"observedGeneration": int64(1)}))
+	c := fake.NewClientBuilder().WithScheme(runtime.NewScheme()).WithRuntimeObjects(parent).Build()
+	return NewHandler(Config{Client: c, Log: logr.Discard(), PolicyResolver: store})
+}
+
+// annotatedParentHandler returns the handler of newResolutionTestHandler
+// for a stable parent with the given additional annotations.
+func annotatedParentHandler(annotations map[string]string) *Handler {
+	ann := map[string]string{
+		controller.PhaseAnnotation:       controller.PhaseValueInitialized,
+		controller.ControllersAnnotation: controller.HashUsername(deploymentController),
+	}
+	for k, v := range annotations {
+		ann[k] = v
+	}
+	parent := buildUnstructured(deploymentGVK, "default", "app", map[string]interface{}{"replicas": int64(1)},
+		withUID("app-uid"), withGeneration(1), withAnnotations(ann), withStatus(map[string]interface{}{"observedGeneration": int64(1)}))
+	c := fake.NewClientBuilder().WithScheme(runtime.NewScheme()).WithRuntimeObjects(parent).Build()
+	return NewHandler(Config{Client: c, Log: logr.Discard()})
+}
+
+// assertAuditConformance checks audit annotations against the schema: all
+// keys are known, enumerated values are valid, and the keys every response
+// or decision carries are set.
+func assertAuditConformance(t *testing.T, audit map[string]string) {
+	t.Helper()
+	schema := map[string]kausalityv1alpha1.AuditAnnotation{}
+	for _, a := range kausalityv1alpha1.AuditAnnotations {
+		schema[kausalityv1alpha1.DefaultAuditKeyPrefix+a.Key] = a
+	}
+	for key, value := range audit {
+		a, ok := schema[key]
+		if !assert.True(t, ok, "key %s is not in the schema", key) {
+			continue
+		}
+		assert.NotEmpty(t, value, "key %s", key)
+		if len(a.Values) > 0 {
+			assert.Contains(t, a.Values, value, "key %s", key)
+		}
+	}
+	assert.Equal(t, kausalityv1alpha1.AuditSchemaVersion, audit[auditKeySchemaVersion])
+	require.NotEmpty(t, audit[auditKeyDecision])
+	if audit[auditKeyDecision] == "denied" && audit[auditKeyError] == "" {
+		assert.NotEmpty(t, audit[auditKeyDenialReason], "denials carry their reason")
+	}
+	if audit[auditKeyDecision] == "error" {
+		assert.NotEmpty(t, audit[auditKeyError], "errors carry their class")
+	}
+	if audit[auditKeyDrift] != "" && audit[auditKeyError] == "" {
+		assert.NotEmpty(t, audit[auditKeyMode], "drift is evaluated in a mode")
+	}
+	if audit[auditKeyDrift] == "true" && audit[auditKeyDenialReason] != string(kausalityv1alpha1.DenialReasonFrozen) {
+		assert.NotEmpty(t, audit[auditKeyDriftResolution], "drift is resolved")
+	}
+}
+
+// TestAuditAnnotations_Conformance covers every decision branch of the
+// handler: each response carries the complete annotations of its path.
+// Values "*" only need to be set.
+func TestAuditAnnotations_Conformance(t *testing.T) {
+	ctrlHash := controller.HashUsername(deploymentController)
+	stableDrift := func() admission.Request {
+		return buildAdmissionRequest(admissionv1.Update, childRS(3, ""), childRS(1, ctrlHash), deploymentController)
+	}
+	configMap := func(annotations map[string]string) *unstructured.Unstructured {
+		return buildUnstructured(configMapGVK, "default", "test-cm", map[string]interface{}{"data": "value"}, withAnnotations(annotations))
+	}
+	replicaSetRequest := func(op admissionv1.Operation) admission.Request {
+		rs := buildUnstructured(replicaSetGVK, "default", "web-abc", map[string]interface{}{"replicas": int64(2)},
+			withOwnerRef(deploymentGVK, "web", "web-uid"))
+		req := buildAdmissionRequest(op, rs, nil, "alice")
+		req.Resource = metav1.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"}
+		return req
+	}
+
+	tests := []struct {
+		name   string
+		handle func(t *testing.T) admission.Response
+		want   map[string]string
+	}{
+		{
+			name: "irrelevant operation",
+			handle: func(t *testing.T) admission.Response {
+				return newTestHandler().Handle(context.Background(), buildAdmissionRequest(admissionv1.Connect, configMap(nil), nil, "alice"))
+			},
+			want: map[string]string{auditKeyDecision: "allowed"},
+		},
+		{
+			name: "own write",
+			handle: func(t *testing.T) admission.Response {
+				const self = "system:serviceaccount:kausality-system:kausality-controller"
+				h := NewHandler(Config{Client: fake.NewClientBuilder().Build(), Log: logr.Discard(), SelfUsers: []string{self}})
+				req := buildAdmissionRequest(admissionv1.Create, configMap(nil), nil, self)
+				req.Options = runtime.RawExtension{Raw: []byte(`{"fieldManager":"` + controller.FieldManager + `"}`)}
+				return h.Handle(context.Background(), req)
+			},
+			want: map[string]string{auditKeyDecision: "allowed"},
+		},
+		{
+			name: "no spec change",
+			handle: func(t *testing.T) admission.Response {
+				h, _ := newResolutionTestHandler(1, 1)
+				return h.Handle(context.Background(), buildAdmissionRequest(admissionv1.Update, childRS(1, ""), childRS(1, ctrlHash), deploymentController))
+			},
+			want: map[string]string{auditKeyDecision: "allowed"},
+		},
+		{
+			name: "status update",
+			handle: func(t *testing.T) admission.Response {
+				deploy := buildUnstructured(deploymentGVK, "default", "app", map[string]interface{}{"replicas": int64(1)})
+				req := buildAdmissionRequest(admissionv1.Update, deploy, deploy, deploymentController)
+				req.SubResource = "status"
+				return newTestHandler().Handle(context.Background(), req)
+			},
+			want: map[string]string{auditKeyDecision: "allowed", auditKeySubresource: "status", auditKeyLifecyclePhase: "Initializing"},
+		},
+		{
+			name: "connect",
+			handle: func(t *testing.T) admission.Response {
+				req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+					UID:         "connect-1",
+					Operation:   admissionv1.Connect,
+					Kind:        metav1.GroupVersionKind{Version: "v1", Kind: "PodExecOptions"},
+					Resource:    metav1.GroupVersionResource{Version: "v1", Resource: "pods"},
+					SubResource: "exec",
+					Namespace:   "default",
+					Name:        "web-0",
+					UserInfo:    testUserInfo("alice"),
+				}}
+				return newTestHandler().Handle(context.Background(), req)
+			},
+			want: map[string]string{auditKeyDecision: "allowed", auditKeySubresource: "exec"},
+		},
+		{
+			name: "create without parent",
+			handle: func(t *testing.T) admission.Response {
+				return newTestHandler().Handle(context.Background(), buildAdmissionRequest(admissionv1.Create, configMap(nil), nil, "alice"))
+			},
+			want: map[string]string{auditKeyDecision: "allowed", auditKeyDrift: "false", auditKeyMode: "log", auditKeyTrace: "*"},
+		},
+		{
+			name: "delete",
+			handle: func(t *testing.T) admission.Response {
+				req := buildAdmissionRequest(admissionv1.Delete, configMap(nil), nil, "alice")
+				req.OldObject, req.Object = req.Object, runtime.RawExtension{}
+				return newTestHandler().Handle(context.Background(), req)
+			},
+			want: map[string]string{auditKeyDecision: "allowed", auditKeyDrift: "false", auditKeyMode: "log", auditKeyTrace: "*"},
+		},
+		{
+			name: "drift in log mode",
+			handle: func(t *testing.T) admission.Response {
+				h, _ := newResolutionTestHandler(1, 1)
+				return h.Handle(context.Background(), stableDrift())
+			},
+			want: map[string]string{
+				auditKeyDecision: "allowed-with-warning", auditKeyDrift: "true", auditKeyMode: "log",
+				auditKeyLifecyclePhase: "Initialized", auditKeyDriftResolution: "unresolved", auditKeyTrace: "*",
+			},
+		},
+		{
+			name: "drift approved",
+			handle: func(t *testing.T) admission.Response {
+				h := annotatedParentHandler(map[string]string{approval.ApprovalsAnnotation: `[{"apiVersion":"apps/v1","kind":"ReplicaSet","name":"app-abc","mode":"always"}]`})
+				return h.Handle(context.Background(), stableDrift())
+			},
+			want: map[string]string{
+				auditKeyDecision: "allowed", auditKeyDrift: "true", auditKeyMode: "log",
+				auditKeyLifecyclePhase: "Initialized", auditKeyDriftResolution: "approved", auditKeyTrace: "*",
+			},
+		},
+		{
+			name: "drift overridden",
+			handle: func(t *testing.T) admission.Response {
+				h, _ := newResolutionTestHandler(1, 1)
+				return h.Handle(context.Background(), driftRequest(map[string]string{
+					approval.OverrideAnnotation: `{"justification":"controller is right","ticket":"OPS-42"}`,
+				}))
+			},
+			want: map[string]string{
+				auditKeyDecision: "allowed-with-warning", auditKeyDrift: "true", auditKeyMode: "enforce",
+				auditKeyLifecyclePhase: "Initialized", auditKeyDriftResolution: "overridden",
+				auditKeyOverride: "OPS-42: controller is right", auditKeyTrace: "*",
+			},
+		},
+		{
+			name: "drift denied",
+			handle: func(t *testing.T) admission.Response {
+				h, _ := newResolutionTestHandler(1, 1)
+				return h.Handle(context.Background(), driftRequest(nil))
+			},
+			want: map[string]string{
+				auditKeyDecision: "denied", auditKeyDenialReason: "Drift", auditKeyDrift: "true", auditKeyMode: "enforce",
+				auditKeyLifecyclePhase: "Initialized", auditKeyDriftResolution: "unresolved", auditKeyTrace: "*",
+			},
+		},
+		{
+			name: "drift denied from the decision cache",
+			handle: func(t *testing.T) admission.Response {
+				h, _ := newResolutionTestHandler(1, 1)
+				h.decisions = newDecisionCache(time.Minute)
+				require.False(t, h.Handle(context.Background(), driftRequest(nil)).Allowed)
+				return h.Handle(context.Background(), driftRequest(nil))
+			},
+			want: map[string]string{
+				auditKeyDecision: "denied", auditKeyDenialReason: "Drift", auditKeyDrift: "true", auditKeyMode: "enforce",
+				auditKeyLifecyclePhase: "Initialized", auditKeyDriftResolution: "unresolved", auditKeyTrace: "*",
+				auditKeyDecisionCache: "hit",
+			},
+		},
+		{
+			name: "drift rejected",
+			handle: func(t *testing.T) admission.Response {
+				h := annotatedParentHandler(map[string]string{approval.RejectionsAnnotation: `[{"apiVersion":"apps/v1","kind":"ReplicaSet","name":"app-abc","reason":"no"}]`})
+				return h.Handle(context.Background(), driftRequest(nil))
+			},
+			want: map[string]string{
+				auditKeyDecision: "denied", auditKeyDenialReason: "Rejected", auditKeyDrift: "true", auditKeyMode: "enforce",
+				auditKeyLifecyclePhase: "Initialized", auditKeyDriftResolution: "rejected", auditKeyTrace: "*",
+			},
+		},
+		{
+			name: "frozen",
+			handle: func(t *testing.T) admission.Response {
+				h := annotatedParentHandler(map[string]string{approval.FreezeAnnotation: `{"user":"admin","message":"emergency"}`})
+				return h.Handle(context.Background(), driftRequest(nil))
+			},
+			want: map[string]string{
+				auditKeyDecision: "denied", auditKeyDenialReason: "Frozen", auditKeyDrift: "true", auditKeyMode: "enforce",
+				auditKeyLifecyclePhase: "Initialized", auditKeyTrace: "*",
+			},
+		},
+		{
+			name: "ticket denied",
+			handle: func(t *testing.T) admission.Response {
+				h := newTestHandler()
+				h.ticketValidator = &fakeTicketValidator{}
+				return h.Handle(context.Background(), buildAdmissionRequest(admissionv1.Create, configMap(map[string]string{config.ModeAnnotation: "enforce"}), nil, "alice"))
+			},
+			want: map[string]string{
+				auditKeyDecision: "denied", auditKeyDenialReason: "Ticket", auditKeyDrift: "false", auditKeyMode: "enforce", auditKeyTrace: "*",
+			},
+		},
+		{
+			name: "invalid annotation denied",
+			handle: func(t *testing.T) admission.Response {
+				h, _ := newResolutionTestHandler(1, 1)
+				oldParent := annotatedParent(map[string]string{config.ModeAnnotation: "enforce"})
+				parent := annotatedParent(map[string]string{config.ModeAnnotation: "enforce", approval.ApprovalsAnnotation: invalidApprovals})
+				return h.Handle(context.Background(), buildAdmissionRequest(admissionv1.Update, parent, oldParent, "alice"))
+			},
+			want: map[string]string{auditKeyDecision: "denied", auditKeyDenialReason: "InvalidAnnotation", auditKeyMode: "enforce"},
+		},
+		{
+			name: "owner reference denied",
+			handle: func(t *testing.T) admission.Response {
+				h := auditPolicyHandler(kausalityv1beta1.KausalitySpec{Mode: kausalityv1beta1.ModeLog, OwnerReferences: kausalityv1beta1.OwnerReferenceDeny})
+				rs := buildUnstructured(replicaSetGVK, "default", "app-abc", map[string]interface{}{"replicas": int64(1)},
+					withOwnerRef(deploymentGVK, "app", "other-uid"))
+				req := buildAdmissionRequest(admissionv1.Create, rs, nil, "mallory")
+				req.Resource = metav1.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"}
+				return h.Handle(context.Background(), req)
+			},
+			want: map[string]string{auditKeyDecision: "denied", auditKeyDenialReason: "OwnerReference", auditKeyMode: "log"},
+		},
+		{
+			name: "missing parent denied",
+			handle: func(t *testing.T) admission.Response {
+				h := auditPolicyHandler(kausalityv1beta1.KausalitySpec{Mode: kausalityv1beta1.ModeLog, MissingParent: kausalityv1beta1.MissingParentDeny})
+				return h.Handle(context.Background(), replicaSetRequest(admissionv1.Create))
+			},
+			want: map[string]string{auditKeyDecision: "denied", auditKeyDenialReason: "MissingParent", auditKeyError: "ParentNotFound", auditKeyMode: "log"},
+		},
+		{
+			name: "missing parent allowed by error handling",
+			handle: func(t *testing.T) admission.Response {
+				h := auditPolicyHandler(kausalityv1beta1.KausalitySpec{Mode: kausalityv1beta1.ModeLog})
+				return h.Handle(context.Background(), replicaSetRequest(admissionv1.Create))
+			},
+			want: map[string]string{auditKeyDecision: "allowed-with-warning", auditKeyError: "ParentNotFound", auditKeyMode: "log"},
+		},
+		{
+			name: "decode error denied by error handling",
+			handle: func(t *testing.T) admission.Response {
+				req := buildAdmissionRequest(admissionv1.Create, configMap(nil), nil, "alice")
+				req.Object.Raw = []byte("{")
+				return newTestHandler().Handle(context.Background(), req)
+			},
+			want: map[string]string{auditKeyDecision: "denied", auditKeyError: "DecodeError"},
+		},
+		{
+			name: "decode error failing with server error",
+			handle: func(t *testing.T) admission.Response {
+				h := NewHandler(Config{
+					Client: fake.NewClientBuilder().Build(),
+					Log:    logr.Discard(),
+					DriftConfig: &config.Config{
+						DriftDetection: config.DriftDetectionConfig{DefaultMode: config.ModeLog},
+						ErrorHandling:  config.ErrorHandlingConfig{DecodeError: config.ErrorActionError},
+					},
+				})
+				req := buildAdmissionRequest(admissionv1.Create, configMap(nil), nil, "alice")
+				req.Object.Raw = []byte("{")
+				return h.Handle(context.Background(), req)
+			},
+			want: map[string]string{auditKeyDecision: "error", auditKeyError: "DecodeError"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			audit := tt.handle(t).AuditAnnotations
+			assertAuditConformance(t, audit)
+
+			got := maps.Clone(audit)
+			delete(got, auditKeySchemaVersion)
+			for key, value := range tt.want {
+				if value == "*" {
+					assert.NotEmpty(t, got[key], "key %s", key)
+					got[key] = "*"
+				}
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package admission
+
+import (
+	"encoding/json"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+)
+
+// AuditAnnotationSchema returns the JSON schema of the audit annotations of
+// an audit event with the given key prefix, for audit pipelines mapping them
+// to fields.
+func AuditAnnotationSchema(prefix string) ([]byte, error) {
+	type property struct {
+		Type        string   `json:"type"`
+		Description string   `json:"description"`
+		Enum        []string `json:"enum,omitempty"`
+	}
+	properties := make(map[string]property, len(kausalityv1alpha1.AuditAnnotations))
+	for _, a := range kausalityv1alpha1.AuditAnnotations {
+		properties[prefix+a.Key] = property{Type: "string", Description: a.Description, Enum: a.Values}
+	}
+	schema := struct {
+		Schema      string              `json:"$schema"`
+		ID          string              `json:"$id"`
+		Title       string              `json:"title"`
+		Description string              `json:"description"`
+		Type        string              `json:"type"`
+		Properties  map[string]property `json:"properties"`
+		Required    []string            `json:"required"`
+	}{
+		Schema:      "https://json-schema.org/draft/2020-12/schema",
+		ID:          "https://kausality.io/schemas/audit-annotations-" + kausalityv1alpha1.AuditSchemaVersion + ".json",
+		Title:       "kausality audit annotations " + kausalityv1alpha1.AuditSchemaVersion,
+		Description: "Annotations of Kubernetes audit events added by kausality, with key prefix \"" + prefix + "\".",
+		Type:        "object",
+		Properties:  properties,
+		Required:    []string{prefix + kausalityv1alpha1.AuditKeyDecision, prefix + kausalityv1alpha1.AuditKeySchemaVersion},
+	}
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,43 @@
+package admission
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/drift"
+)
+
+func TestAuditAnnotationSchema_UpToDate(t *testing.T) {
+	want, err := AuditAnnotationSchema(kausalityv1alpha1.DefaultAuditKeyPrefix)
+	require.NoError(t, err)
+	got, err := os.ReadFile("../../doc/schemas/audit-annotations-" + kausalityv1alpha1.AuditSchemaVersion + ".json")
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got), "run 'make gen' to update the schema")
+}
+
+func TestAuditAnnotationSchema_Values(t *testing.T) {
+	data, err := AuditAnnotationSchema("audit.kausality.io/")
+	require.NoError(t, err)
+	var schema struct {
+		Properties map[string]struct {
+			Enum []string `json:"enum"`
+		} `json:"properties"`
+		Required []string `json:"required"`
+	}
+	require.NoError(t, json.Unmarshal(data, &schema))
+	assert.Equal(t, []string{"audit.kausality.io/decision", "audit.kausality.io/schema-version"}, schema.Required)
+
+	errors := schema.Properties["audit.kausality.io/error"].Enum
+	for _, class := range drift.ErrorClasses {
+		assert.Contains(t, errors, string(class))
+	}
+	phases := schema.Properties["audit.kausality.io/lifecycle-phase"].Enum
+	for _, phase := range []drift.LifecyclePhase{drift.PhaseInitializing, drift.PhaseInitialized, drift.PhaseDeleting} {
+		assert.Contains(t, phases, string(phase))
+	}
+}
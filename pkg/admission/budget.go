@@ -0,0 +1,65 @@
+package admission
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/kausality-io/kausality/pkg/drift"
+)
+
+// withinBudget evaluates a request within the configured latency budget. A
+// request exceeding it is answered with the errorHandling.budgetExhausted
+// decision, while its evaluation is canceled in the background. Without
+// budget, evaluate is called directly.
+func (h *Handler) withinBudget(ctx context.Context, req admission.Request, evaluate func(context.Context) admission.Response) admission.Response {
+	budget := h.config.Budget
+	if budget == nil {
+		return evaluate(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, budget.Timeout)
+	defer cancel()
+
+	done := make(chan admission.Response, 1)
+	go func() {
+		done <- evaluate(ctx)
+	}()
+	select {
+	case resp := <-done:
+		return resp
+	case <-ctx.Done():
+	}
+
+	log := h.log.WithValues("operation", req.Operation, "kind", req.Kind.String(), "namespace", req.Namespace, "name", req.Name)
+	err := fmt.Errorf("admission request exceeded its budget of %s: %w", budget.Timeout, ctx.Err())
+	return h.errorResponse(drift.NewError(drift.ErrorBudgetExhausted, err), nil, log)
+}
+
+// parentBudget returns a context bounding the resolution of a parent by the
+// configured budget.
+func (h *Handler) parentBudget(ctx context.Context) (context.Context, context.CancelFunc) {
+	if h.config.Budget == nil {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, h.config.Budget.Parent())
+}
+
+// callbackBudget returns a context bounding the dispatch of drift reports
+// and approval requests by the configured budget.
+func (h *Handler) callbackBudget(ctx context.Context) (context.Context, context.CancelFunc) {
+	if h.config.Budget == nil {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, h.config.Budget.Callback())
+}
+
+// budgetError classifies errors of exceeded deadlines as ErrorBudgetExhausted
+// if a budget is configured.
+func (h *Handler) budgetError(err error) error {
+	if h.config.Budget != nil && errors.Is(err, context.DeadlineExceeded) {
+		return drift.NewError(drift.ErrorBudgetExhausted, err)
+	}
+	return err
+}
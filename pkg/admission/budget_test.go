@@ -0,0 +1,106 @@
+package admission
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/kausality-io/kausality/pkg/config"
+	"github.com/kausality-io/kausality/pkg/drift"
+)
+
+func TestHandle_Budget(t *testing.T) {
+	ctx := context.Background()
+	child := buildUnstructured(replicaSetGVK, "default", "web-abc", map[string]interface{}{"replicas": int64(2)},
+		withOwnerRef(deploymentGVK, "web", "web-uid"))
+	oldChild := buildUnstructured(replicaSetGVK, "default", "web-abc", map[string]interface{}{"replicas": int64(1)},
+		withOwnerRef(deploymentGVK, "web", "web-uid"))
+
+	// slowHandler reads parents with get, within a budget of 100ms
+	slowHandler := func(eh config.ErrorHandlingConfig, get func(ctx context.Context) error) *Handler {
+		c := fake.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				return get(ctx)
+			},
+		}).Build()
+		h := NewHandler(Config{Client: c, Log: logr.Discard()})
+		h.config = &config.Config{ErrorHandling: eh, Budget: &config.BudgetConfig{Timeout: 100 * time.Millisecond}}
+		return h
+	}
+	waitForDeadline := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	t.Run("slow parent is allowed with warning", func(t *testing.T) {
+		h := slowHandler(config.ErrorHandlingConfig{}, waitForDeadline)
+		start := time.Now()
+		resp := h.Handle(ctx, buildAdmissionRequest(admissionv1.Update, child, oldChild, "admin"))
+		assert.Less(t, time.Since(start), 2*time.Second)
+		assert.True(t, resp.Allowed)
+		require.Len(t, resp.Warnings, 1)
+		assert.Contains(t, resp.Warnings[0], "drift detection skipped: BudgetExhausted")
+		assert.Equal(t, string(drift.ErrorBudgetExhausted), resp.AuditAnnotations[auditKeyError])
+	})
+
+	t.Run("configured fallback", func(t *testing.T) {
+		h := slowHandler(config.ErrorHandlingConfig{BudgetExhausted: config.ErrorActionDeny}, waitForDeadline)
+		resp := h.Handle(ctx, buildAdmissionRequest(admissionv1.Update, child, oldChild, "admin"))
+		assert.False(t, resp.Allowed)
+		assert.Equal(t, "denied", resp.AuditAnnotations[auditKeyDecision])
+		assert.Equal(t, string(drift.ErrorBudgetExhausted), resp.AuditAnnotations[auditKeyError])
+	})
+
+	t.Run("reads ignoring the deadline", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+		h := slowHandler(config.ErrorHandlingConfig{}, func(context.Context) error {
+			<-release
+			return context.Canceled
+		})
+		start := time.Now()
+		resp := h.Handle(ctx, buildAdmissionRequest(admissionv1.Update, child, oldChild, "admin"))
+		assert.Less(t, time.Since(start), 2*time.Second)
+		assert.True(t, resp.Allowed)
+		assert.Equal(t, string(drift.ErrorBudgetExhausted), resp.AuditAnnotations[auditKeyError])
+	})
+
+	t.Run("deadlines without budget are internal errors", func(t *testing.T) {
+		h := slowHandler(config.ErrorHandlingConfig{}, func(context.Context) error {
+			return context.DeadlineExceeded
+		})
+		h.config.Budget = nil
+		resp := h.Handle(ctx, buildAdmissionRequest(admissionv1.Update, child, oldChild, "admin"))
+		assert.False(t, resp.Allowed)
+		assert.Equal(t, string(drift.ErrorInternal), resp.AuditAnnotations[auditKeyError])
+	})
+}
+
+func TestParentBudget(t *testing.T) {
+	h := newTestHandler()
+	ctx, cancel := h.parentBudget(context.Background())
+	defer cancel()
+	_, ok := ctx.Deadline()
+	assert.False(t, ok, "no deadline without budget")
+
+	h.config = &config.Config{Budget: &config.BudgetConfig{Timeout: 2 * time.Second, CallbackTimeout: 100 * time.Millisecond}}
+	ctx, cancel = h.parentBudget(context.Background())
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	require.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Second), deadline, 100*time.Millisecond)
+	ctx, cancel = h.callbackBudget(context.Background())
+	defer cancel()
+	deadline, ok = ctx.Deadline()
+	require.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(100*time.Millisecond), deadline, 50*time.Millisecond)
+}
@@ -0,0 +1,137 @@
+package admission
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kausality-io/kausality/pkg/approval"
+	"github.com/kausality-io/kausality/pkg/drift"
+)
+
+// DefaultDecisionTTL is how long drift denials are reused by default.
+const DefaultDecisionTTL = 5 * time.Second
+
+// maxDecisions bounds the number of remembered denials.
+const maxDecisions = 10000
+
+// decisionKey identifies a repeated attempt: the same spec of the same object
+// by the same user against the same parent generation and decision
+// annotations. Any parent change that can change the decision changes the key,
+// so a denial is never reused after it, whichever replica admitted the change.
+type decisionKey struct {
+	uid      types.UID
+	specHash string
+	userHash string
+	// fieldManager identifies the controller for the FieldManager classifier
+	fieldManager     string
+	parentUID        types.UID
+	parentGeneration int64
+	annotationsHash  string
+}
+
+// decision is a remembered drift denial.
+type decision struct {
+	parentKind string
+	parentName string
+	result     *metav1.Status
+	audit      map[string]string
+	expires    time.Time
+}
+
+// decisionCache remembers drift denials for a short time. A controller
+// retrying a blocked update, e.g. while fighting another actor, gets the
+// previous denial without approval checks and callbacks. Changes outside the
+// parent, e.g. of the mode, take effect after the TTL.
+type decisionCache struct {
+	ttl     time.Duration
+	now     func() time.Time
+	mu      sync.Mutex
+	entries map[decisionKey]decision
+}
+
+func newDecisionCache(ttl time.Duration) *decisionCache {
+	return &decisionCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[decisionKey]decision),
+	}
+}
+
+// newDecisionKey returns the key of an attempt to set spec of the child with
+// the given UID under parent, or false if the child has no UID yet (CREATE).
+func newDecisionKey(uid types.UID, spec []byte, userHash string, parent client.Object) (decisionKey, bool) {
+	if uid == "" {
+		return decisionKey{}, false
+	}
+	specSum := sha256.Sum256(spec)
+	annotationsSum := sha256.New()
+	for _, key := range decisionAnnotations {
+		value, ok := parent.GetAnnotations()[key]
+		// Separate absent from empty values and the values from each other
+		fmt.Fprintf(annotationsSum, "%t:%d:%s;", ok, len(value), value)
+	}
+	return decisionKey{
+		uid:              uid,
+		specHash:         hex.EncodeToString(specSum[:]),
+		userHash:         userHash,
+		parentUID:        parent.GetUID(),
+		parentGeneration: parent.GetGeneration(),
+		annotationsHash:  hex.EncodeToString(annotationsSum.Sum(nil)),
+	}, true
+}
+
+// get returns the remembered denial of an attempt.
+func (c *decisionCache) get(key decisionKey) (decision, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	d, ok := c.entries[key]
+	if !ok {
+		return decision{}, false
+	}
+	if !c.now().Before(d.expires) {
+		delete(c.entries, key)
+		return decision{}, false
+	}
+	return d, true
+}
+
+// put remembers the denial of an attempt. When full, expired denials are
+// dropped; if none expired, the denial is not remembered.
+func (c *decisionCache) put(key decisionKey, parent *drift.ParentRef, result *metav1.Status, audit map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if len(c.entries) >= maxDecisions {
+		for k, d := range c.entries {
+			if !now.Before(d.expires) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= maxDecisions {
+			return
+		}
+	}
+	c.entries[key] = decision{
+		parentKind: parent.Kind,
+		parentName: parent.Name,
+		result:     result.DeepCopy(),
+		audit:      maps.Clone(audit),
+		expires:    now.Add(c.ttl),
+	}
+}
+
+// decisionAnnotations are the annotations of a parent that change decisions
+// about its children without changing its generation.
+var decisionAnnotations = []string{
+	approval.ApprovalsAnnotation,
+	approval.RejectionsAnnotation,
+	approval.FreezeAnnotation,
+}
@@ -0,0 +1,115 @@
+package admission
+
+import (
+	"context"
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kausality-io/kausality/pkg/approval"
+	"github.com/kausality-io/kausality/pkg/controller"
+	"github.com/kausality-io/kausality/pkg/drift"
+)
+
+func TestDecisionCache_RepeatedDenial(t *testing.T) {
+	h, sender := newResolutionTestHandler(1, 1)
+	h.decisions = newDecisionCache(time.Minute)
+	ctx := context.Background()
+
+	resp := h.Handle(ctx, driftRequest(nil))
+	require.False(t, resp.Allowed)
+	assert.Empty(t, resp.AuditAnnotations[auditKeyDecisionCache])
+	require.Len(t, sender.reports, 1)
+
+	// The controller retries the same update
+	cached := h.Handle(ctx, driftRequest(nil))
+	require.False(t, cached.Allowed)
+	assert.Equal(t, resp.Result.Message, cached.Result.Message)
+	assert.Equal(t, "hit", cached.AuditAnnotations[auditKeyDecisionCache])
+	assert.Equal(t, "denied", cached.AuditAnnotations[auditKeyDecision])
+	assert.Len(t, sender.reports, 1, "cached denials are not reported again")
+
+	// A different spec is evaluated
+	oldChild := enforcedChild(1, map[string]string{controller.UpdatersAnnotation: controller.HashUsername(deploymentController)})
+	resp = h.Handle(ctx, buildAdmissionRequest(admissionv1.Update, enforcedChild(4, nil), oldChild, deploymentController))
+	require.False(t, resp.Allowed)
+	assert.Empty(t, resp.AuditAnnotations[auditKeyDecisionCache])
+	assert.Len(t, sender.reports, 2)
+
+	// An override is never answered from the cache
+	resp = h.Handle(ctx, driftRequest(map[string]string{
+		approval.OverrideAnnotation: `{"justification":"controller is right","ticket":"OPS-42"}`,
+	}))
+	assert.True(t, resp.Allowed)
+}
+
+func TestDecisionCache_ParentChange(t *testing.T) {
+	tests := []struct {
+		name        string
+		generation  int64
+		annotations map[string]string
+		wantMiss    bool
+	}{
+		{name: "spec change", generation: 2, wantMiss: true},
+		{name: "freeze added", generation: 1, annotations: map[string]string{approval.FreezeAnnotation: `{"user":"admin"}`}, wantMiss: true},
+		{name: "rejection added", generation: 1, annotations: map[string]string{approval.RejectionsAnnotation: `[{"apiVersion":"apps/v1","kind":"ReplicaSet","name":"app-abc","reason":"no"}]`}, wantMiss: true},
+		{name: "empty approvals added", generation: 1, annotations: map[string]string{approval.ApprovalsAnnotation: ""}, wantMiss: true},
+		{name: "unrelated annotation", generation: 1, annotations: map[string]string{"kausality.io/drift-count": "1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newResolutionTestHandler(1, 1)
+			h.decisions = newDecisionCache(time.Minute)
+			ctx := context.Background()
+
+			require.False(t, h.Handle(ctx, driftRequest(nil)).Allowed)
+
+			// The parent is changed through another webhook replica
+			parent := &unstructured.Unstructured{}
+			parent.SetGroupVersionKind(deploymentGVK)
+			require.NoError(t, h.client.Get(ctx, client.ObjectKey{Namespace: "default", Name: "app"}, parent))
+			parent.SetGeneration(tt.generation)
+			annotations := parent.GetAnnotations()
+			maps.Copy(annotations, tt.annotations)
+			parent.SetAnnotations(annotations)
+			require.NoError(t, h.client.Update(ctx, parent))
+
+			resp := h.Handle(ctx, driftRequest(nil))
+			if tt.wantMiss {
+				assert.Empty(t, resp.AuditAnnotations[auditKeyDecisionCache])
+			} else {
+				assert.Equal(t, "hit", resp.AuditAnnotations[auditKeyDecisionCache])
+			}
+		})
+	}
+}
+
+func TestDecisionCache_Expiry(t *testing.T) {
+	c := newDecisionCache(time.Second)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	parentObj := buildUnstructured(deploymentGVK, "default", "app", nil, withUID("app-uid"), withGeneration(1))
+	key, ok := newDecisionKey("uid", []byte(`{"replicas":3}`), "hash", parentObj)
+	require.True(t, ok)
+	_, ok = newDecisionKey("", []byte(`{"replicas":3}`), "hash", parentObj)
+	assert.False(t, ok, "objects without UID are not cached")
+
+	parent := &drift.ParentRef{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "app"}
+	c.put(key, parent, &metav1.Status{Message: "denied"}, map[string]string{auditKeyDecision: "denied"})
+	_, ok = c.get(key)
+	assert.True(t, ok)
+
+	now = now.Add(time.Second)
+	_, ok = c.get(key)
+	assert.False(t, ok)
+	assert.Empty(t, c.entries)
+}
@@ -0,0 +1,104 @@
+package admission
+
+import (
+	"encoding/json"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/callback"
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/drift"
+)
+
+// DocsURL is the base URL of the documentation linked from denials.
+const DocsURL = "https://github.com/kausality-io/kausality/blob/main/doc/design/"
+
+// denialRetryAfterSeconds is the retry hint of denials. Retries are denied
+// until the mutation is approved or the parent unfrozen, so controllers
+// should back off rather than retry immediately.
+const denialRetryAfterSeconds = 30
+
+// denialDocs maps denial reasons to the documentation of their remediation.
+var denialDocs = map[kausalityv1alpha1.DenialReason]string{
+	kausalityv1alpha1.DenialReasonDrift:             DocsURL + "APPROVALS.md#approval-and-rejection-annotations",
+	kausalityv1alpha1.DenialReasonRejected:          DocsURL + "APPROVALS.md#rejection-priority",
+	kausalityv1alpha1.DenialReasonFrozen:            DocsURL + "APPROVALS.md#freeze-and-snooze",
+	kausalityv1alpha1.DenialReasonTicket:            DocsURL + "TRACING.md#ticket-validation",
+	kausalityv1alpha1.DenialReasonInvalidAnnotation: DocsURL + "APPROVALS.md#annotation-validation",
+	kausalityv1alpha1.DenialReasonMissingParent:     DocsURL + "DRIFT_DETECTION.md#missing-parents",
+	kausalityv1alpha1.DenialReasonOwnerReference:    DocsURL + "DRIFT_DETECTION.md#owner-reference-validation",
+	kausalityv1alpha1.DenialReasonProtected:         DocsURL + "DEPLOYMENT.md#self-protection",
+}
+
+// denied returns a denial of obj whose status details describe the reason
+// and remediation as causes, and when to retry. driftResult may be nil.
+func denied(reason kausalityv1alpha1.DenialReason, msg string, req admission.Request, obj client.Object, driftResult *drift.DriftResult) admission.Response {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	details := &metav1.StatusDetails{
+		Name:              obj.GetName(),
+		Group:             gvk.Group,
+		Kind:              gvk.Kind,
+		UID:               obj.GetUID(),
+		RetryAfterSeconds: denialRetryAfterSeconds,
+		Causes: []metav1.StatusCause{
+			{Type: kausalityv1alpha1.DenialCauseReason, Message: string(reason)},
+		},
+	}
+
+	if driftResult != nil && driftResult.ParentRef != nil {
+		details.Causes = append(details.Causes,
+			metav1.StatusCause{Type: kausalityv1alpha1.DenialCauseParent, Message: driftResult.ParentRef.String()},
+			metav1.StatusCause{Type: kausalityv1alpha1.DenialCauseDriftID, Message: detectedDriftID(req, obj, driftResult)},
+		)
+		if reason == kausalityv1alpha1.DenialReasonDrift {
+			if example := approvalExample(obj, driftResult); example != "" {
+				details.Causes = append(details.Causes, metav1.StatusCause{
+					Type:    kausalityv1alpha1.DenialCauseApprovalExample,
+					Message: example,
+					Field:   "metadata.annotations[" + kausalityv1alpha1.ApprovalsAnnotation + "]",
+				})
+			}
+		}
+	}
+	details.Causes = append(details.Causes, metav1.StatusCause{Type: kausalityv1alpha1.DenialCauseDocs, Message: denialDocs[reason]})
+
+	resp := admission.Denied(msg)
+	resp.Result.Details = details
+	return resp
+}
+
+// detectedDriftID returns the ID of the drift report of the mutation of obj,
+// empty without parent.
+func detectedDriftID(req admission.Request, obj client.Object, driftResult *drift.DriftResult) string {
+	if driftResult == nil || driftResult.ParentRef == nil {
+		return ""
+	}
+	parent := driftResult.ParentRef
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	parentRef := v1alpha1.ObjectReference{APIVersion: parent.APIVersion, Kind: parent.Kind, Namespace: parent.Namespace, Name: parent.Name}
+	childRef := v1alpha1.ObjectReference{APIVersion: gvk.GroupVersion().String(), Kind: gvk.Kind, Namespace: obj.GetNamespace(), Name: obj.GetName()}
+	return callback.GenerateDriftID(parentRef, childRef, computeSpecDiff(req))
+}
+
+// approvalExample returns a once approval of the mutation of obj by the
+// parent's current generation, as value of the approvals annotation.
+func approvalExample(obj client.Object, driftResult *drift.DriftResult) string {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	approval := kausalityv1alpha1.Approval{
+		APIVersion: gvk.GroupVersion().String(),
+		Kind:       gvk.Kind,
+		Name:       obj.GetName(),
+		Mode:       kausalityv1alpha1.ApprovalModeOnce,
+	}
+	if driftResult.ParentState != nil {
+		approval.Generation = driftResult.ParentState.Generation
+	}
+	data, err := json.Marshal([]kausalityv1alpha1.Approval{approval})
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
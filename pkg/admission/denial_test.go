@@ -0,0 +1,67 @@
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	admissionv1 "k8s.io/api/admission/v1"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/config"
+	"github.com/kausality-io/kausality/pkg/controller"
+)
+
+func TestDenied_Details(t *testing.T) {
+	const deploymentController = "system:serviceaccount:kube-system:deployment-controller"
+	parent := buildUnstructured(deploymentGVK, "default", "web",
+		map[string]interface{}{"replicas": int64(1)},
+		withUID("web-uid"),
+		withGeneration(4),
+		withAnnotations(map[string]string{controller.PhaseAnnotation: controller.PhaseValueInitialized}),
+		withStatus(map[string]interface{}{"observedGeneration": int64(4)}),
+	)
+	h := newTestHandler(parent)
+
+	child := buildUnstructured(replicaSetGVK, "default", "web-rs",
+		map[string]interface{}{"replicas": int64(3)},
+		withUID("web-rs-uid"),
+		withOwnerRef(deploymentGVK, "web", "web-uid"),
+		withAnnotations(map[string]string{config.ModeAnnotation: "enforce"}),
+	)
+	oldChild := buildUnstructured(replicaSetGVK, "default", "web-rs",
+		map[string]interface{}{"replicas": int64(1)},
+		withUID("web-rs-uid"),
+		withOwnerRef(deploymentGVK, "web", "web-uid"),
+		withAnnotations(map[string]string{
+			controller.UpdatersAnnotation: controller.HashUsername(deploymentController),
+			config.ModeAnnotation:         "enforce",
+		}),
+	)
+
+	resp := h.Handle(context.Background(), buildAdmissionRequest(admissionv1.Update, child, oldChild, deploymentController))
+	require.False(t, resp.Allowed)
+	details := resp.Result.Details
+	require.NotNil(t, details)
+	assert.Equal(t, "web-rs", details.Name)
+	assert.Equal(t, "ReplicaSet", details.Kind)
+	assert.Equal(t, int32(denialRetryAfterSeconds), details.RetryAfterSeconds)
+
+	causes := map[string]string{}
+	for _, c := range details.Causes {
+		causes[string(c.Type)] = c.Message
+	}
+	assert.Equal(t, string(kausalityv1alpha1.DenialReasonDrift), causes[kausalityv1alpha1.DenialCauseReason])
+	assert.Equal(t, "apps/v1/Deployment:default/web", causes[kausalityv1alpha1.DenialCauseParent])
+	assert.Len(t, causes[kausalityv1alpha1.DenialCauseDriftID], 16)
+	assert.Contains(t, causes[kausalityv1alpha1.DenialCauseDocs], "APPROVALS.md")
+
+	var approvals []kausalityv1alpha1.Approval
+	require.NoError(t, json.Unmarshal([]byte(causes[kausalityv1alpha1.DenialCauseApprovalExample]), &approvals))
+	assert.Equal(t, []kausalityv1alpha1.Approval{
+		{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-rs", Generation: 4, Mode: kausalityv1alpha1.ApprovalModeOnce},
+	}, approvals)
+}
@@ -552,16 +552,16 @@ func TestApprovalConsumed_ModeOnce(t *testing.T) {
 			return false, fmt.Sprintf("failed to get deployment: %v", err)
 		}
 		afterApprovals := deploy.GetAnnotations()[approval.ApprovalsAnnotation]
-		if afterApprovals == "" {
-			return true, "approval consumed"
-		}
 		remaining, _ := approval.ParseApprovals(afterApprovals)
 		for _, a := range remaining {
 			if a.Name == rs.Name && a.Mode == approval.ModeOnce {
-				return false, fmt.Sprintf("mode=once approval still exists: %v", remaining)
+				if a.Consumed == nil || a.Consumed.DriftID == "" {
+					return false, fmt.Sprintf("mode=once approval not marked consumed: %s", afterApprovals)
+				}
+				return true, "approval consumed"
 			}
 		}
-		return true, "approval consumed"
+		return false, fmt.Sprintf("mode=once approval missing: %s", afterApprovals)
 	}, ktesting.Timeout, ktesting.PollInterval, "waiting for approval consumption")
 }
 
@@ -186,6 +186,15 @@ func TestCallback_DriftReportSentOnDetection(t *testing.T) {
 	if report.Spec.Request.Operation != "UPDATE" {
 		t.Errorf("expected operation=UPDATE, got %s", report.Spec.Request.Operation)
 	}
+	if report.Spec.ParentSnapshot == nil {
+		t.Fatal("expected parent snapshot")
+	}
+	if report.Spec.ParentSnapshot.ResourceVersion == "" {
+		t.Error("expected parent snapshot to carry the parent's resourceVersion")
+	}
+	if report.Spec.ParentSnapshot.Generation != deploy.Generation {
+		t.Errorf("expected parent snapshot generation=%d, got %d", deploy.Generation, report.Spec.ParentSnapshot.Generation)
+	}
 }
 
 // =============================================================================
@@ -345,6 +354,9 @@ func TestCallback_ResolvedSentOnApproval(t *testing.T) {
 		t.Logf("Received report: phase=%s, id=%s", report.Spec.Phase, report.Spec.ID)
 		if report.Spec.Phase == v1alpha1.DriftReportPhaseResolved {
 			foundResolved = true
+			if report.Spec.Resolution == nil || report.Spec.Resolution.Kind != v1alpha1.ResolutionApproved {
+				t.Errorf("expected resolution kind %q, got %+v", v1alpha1.ResolutionApproved, report.Spec.Resolution)
+			}
 		}
 	}
 
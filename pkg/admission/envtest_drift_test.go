@@ -10,8 +10,11 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
 
 	"github.com/kausality-io/kausality/pkg/controller"
 	"github.com/kausality-io/kausality/pkg/drift"
@@ -618,3 +621,148 @@ func TestAnnotationOnlyChanges_AllowedDuringDriftProtection(t *testing.T) {
 // =============================================================================
 // Test: Approval - Mode Always
 // =============================================================================
+
+// =============================================================================
+// Test: Drift Detection - Cluster-Scoped Parents
+// =============================================================================
+
+// installXNetworkCRD installs a cluster-scoped kind standing in for a
+// cluster-scoped Crossplane composite resource.
+func installXNetworkCRD(t *testing.T) {
+	t.Helper()
+	preserveUnknownFields := true
+	crd := &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "xnetworks.test.kausality.io"},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: "test.kausality.io",
+			Scope: apiextensionsv1.ClusterScoped,
+			Names: apiextensionsv1.CustomResourceDefinitionNames{
+				Plural:   "xnetworks",
+				Singular: "xnetwork",
+				Kind:     "XNetwork",
+				ListKind: "XNetworkList",
+			},
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{{
+				Name:         "v1",
+				Served:       true,
+				Storage:      true,
+				Subresources: &apiextensionsv1.CustomResourceSubresources{Status: &apiextensionsv1.CustomResourceSubresourceStatus{}},
+				Schema: &apiextensionsv1.CustomResourceValidation{OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
+					Type:                   "object",
+					XPreserveUnknownFields: &preserveUnknownFields,
+				}},
+			}},
+		},
+	}
+	if _, err := envtest.InstallCRDs(cfgUnit, envtest.CRDInstallOptions{CRDs: []*apiextensionsv1.CustomResourceDefinition{crd}}); err != nil {
+		t.Fatalf("failed to install XNetwork CRD: %v", err)
+	}
+}
+
+func TestDriftDetection_ClusterScopedParent(t *testing.T) {
+	ctx := context.Background()
+	installXNetworkCRD(t)
+	testCounter++
+
+	// Stable cluster-scoped parent
+	xr := &unstructured.Unstructured{}
+	xr.SetAPIVersion("test.kausality.io/v1")
+	xr.SetKind("XNetwork")
+	xr.SetName(fmt.Sprintf("xnetwork-%d", testCounter))
+	xr.SetAnnotations(map[string]string{controller.PhaseAnnotation: controller.PhaseValueInitialized})
+	if err := unstructured.SetNestedField(xr.Object, "10.0.0.0/16", "spec", "cidr"); err != nil {
+		t.Fatalf("failed to set spec: %v", err)
+	}
+	if err := k8sClientUnit.Create(ctx, xr); err != nil {
+		t.Fatalf("failed to create XNetwork: %v", err)
+	}
+	t.Cleanup(func() { _ = k8sClientUnit.Delete(context.Background(), xr) })
+	if err := unstructured.SetNestedField(xr.Object, xr.GetGeneration(), "status", "observedGeneration"); err != nil {
+		t.Fatalf("failed to set status: %v", err)
+	}
+	if err := k8sClientUnit.Status().Update(ctx, xr); err != nil {
+		t.Fatalf("failed to update XNetwork status: %v", err)
+	}
+
+	// Namespaced child, as a namespaced managed resource of the composite
+	trueVal := true
+	child := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Namespace: testNSUnit,
+		Name:      fmt.Sprintf("xnetwork-child-%d", testCounter),
+		OwnerReferences: []metav1.OwnerReference{{
+			APIVersion: "test.kausality.io/v1",
+			Kind:       "XNetwork",
+			Name:       xr.GetName(),
+			UID:        xr.GetUID(),
+			Controller: &trueVal,
+		}},
+	}}
+	if err := k8sClientUnit.Create(ctx, child); err != nil {
+		t.Fatalf("failed to create child: %v", err)
+	}
+
+	parentState, err := drift.NewParentResolver(k8sClientUnit).ResolveParent(ctx, child)
+	if err != nil {
+		t.Fatalf("failed to resolve parent: %v", err)
+	}
+	if parentState == nil {
+		t.Fatal("expected parent state, got nil")
+	}
+	if parentState.Ref.Namespace != "" || parentState.Ref.Name != xr.GetName() {
+		t.Errorf("expected cluster-scoped parent %s, got %s", xr.GetName(), parentState.Ref.String())
+	}
+	if !parentState.HasObservedGeneration || parentState.ObservedGeneration != xr.GetGeneration() {
+		t.Errorf("expected observedGeneration %d, got %d", xr.GetGeneration(), parentState.ObservedGeneration)
+	}
+
+	// gen == obsGen - drift should be detected
+	result, err := drift.NewDetector(k8sClientUnit).Detect(ctx, child, "test-user", nil)
+	if err != nil {
+		t.Fatalf("drift detection failed: %v", err)
+	}
+	if !result.DriftDetected {
+		t.Errorf("expected driftDetected=true for stable cluster-scoped parent, reason: %s", result.Reason)
+	}
+}
+
+func TestDriftDetection_NamespaceParent(t *testing.T) {
+	ctx := context.Background()
+	testCounter++
+
+	ns := &corev1.Namespace{}
+	if err := k8sClientUnit.Get(ctx, client.ObjectKey{Name: testNSUnit}, ns); err != nil {
+		t.Fatalf("failed to get namespace: %v", err)
+	}
+
+	trueVal := true
+	child := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Namespace: testNSUnit,
+		Name:      fmt.Sprintf("ns-child-%d", testCounter),
+		OwnerReferences: []metav1.OwnerReference{{
+			APIVersion: "v1",
+			Kind:       "Namespace",
+			Name:       ns.Name,
+			UID:        ns.UID,
+			Controller: &trueVal,
+		}},
+	}}
+	if err := k8sClientUnit.Create(ctx, child); err != nil {
+		t.Fatalf("failed to create child: %v", err)
+	}
+
+	parentState, err := drift.NewParentResolver(k8sClientUnit).ResolveParent(ctx, child)
+	if err != nil {
+		t.Fatalf("failed to resolve parent: %v", err)
+	}
+	if parentState == nil || parentState.Ref.Kind != "Namespace" || parentState.Ref.Namespace != "" {
+		t.Fatalf("expected Namespace parent, got %+v", parentState)
+	}
+
+	result, err := drift.NewDetector(k8sClientUnit).Detect(ctx, child, "test-user", nil)
+	if err != nil {
+		t.Fatalf("drift detection failed: %v", err)
+	}
+	if !result.Allowed {
+		t.Errorf("expected allowed=true, reason: %s", result.Reason)
+	}
+}
@@ -0,0 +1,97 @@
+package admission
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/config"
+	"github.com/kausality-io/kausality/pkg/drift"
+)
+
+// errorRetryAfterSeconds is the retry hint of "error" responses.
+const errorRetryAfterSeconds = 1
+
+// admissionErrors counts errors preventing drift detection by class and action.
+var admissionErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "kausality_admission_errors_total",
+	Help: "Errors preventing drift detection, by error class and resulting action.",
+}, []string{"class", "action"})
+
+func init() {
+	metrics.Registry.MustRegister(admissionErrors)
+}
+
+// defaultErrorActions are used for error classes without configured action.
+// Missing or unreadable parents and policies must not block workloads, while
+// unknown errors are likely transient and retried.
+var defaultErrorActions = map[drift.ErrorClass]string{
+	drift.ErrorParentNotFound:    config.ErrorActionAllow,
+	drift.ErrorParentForbidden:   config.ErrorActionAllow,
+	drift.ErrorDecode:            config.ErrorActionDeny,
+	drift.ErrorPolicyUnavailable: config.ErrorActionAllow,
+	drift.ErrorInternal:          config.ErrorActionError,
+	drift.ErrorBudgetExhausted:   config.ErrorActionAllow,
+}
+
+// errorAction returns the configured action for an error class.
+func (h *Handler) errorAction(class drift.ErrorClass) string {
+	eh := h.config.ErrorHandling
+	var action string
+	switch class {
+	case drift.ErrorParentNotFound:
+		action = eh.ParentNotFound
+	case drift.ErrorParentForbidden:
+		action = eh.ParentForbidden
+	case drift.ErrorDecode:
+		action = eh.DecodeError
+	case drift.ErrorPolicyUnavailable:
+		action = eh.PolicyUnavailable
+	case drift.ErrorInternal:
+		action = eh.Internal
+	case drift.ErrorBudgetExhausted:
+		action = eh.BudgetExhausted
+	}
+	if action == "" {
+		return defaultErrorActions[class]
+	}
+	return action
+}
+
+// errorResponse maps an error preventing drift detection to an admission
+// response according to the action configured for its class.
+func (h *Handler) errorResponse(err error, audit map[string]string, log logr.Logger) admission.Response {
+	err = h.budgetError(err)
+	class := drift.ClassOf(err)
+	action := h.errorAction(class)
+	admissionErrors.WithLabelValues(string(class), action).Inc()
+	log.Error(err, "drift detection failed", "errorClass", class, "action", action)
+
+	if audit == nil {
+		audit = map[string]string{}
+	}
+	audit[kausalityv1alpha1.AuditKeyError] = string(class)
+	msg := fmt.Sprintf("%s: %v", class, err)
+
+	switch action {
+	case config.ErrorActionAllow:
+		warnings := []string{fmt.Sprintf("[kausality] drift detection skipped: %s", msg)}
+		return withAuditAnnotations(withWarnings(admission.Allowed(msg), warnings), audit)
+	case config.ErrorActionDeny:
+		return withAuditAnnotations(admission.Denied(msg), audit)
+	default:
+		// Clients retry server errors with a retry hint
+		resp := admission.Errored(http.StatusInternalServerError, errors.New(msg))
+		resp.Result.Reason = metav1.StatusReasonInternalError
+		resp.Result.Details = &metav1.StatusDetails{RetryAfterSeconds: errorRetryAfterSeconds}
+		return withAuditAnnotations(resp, audit)
+	}
+}
@@ -0,0 +1,69 @@
+package admission
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kausality-io/kausality/pkg/trace"
+)
+
+func TestHandle_OriginEvents(t *testing.T) {
+	event := &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Namespace: "default", Name: "scaled"},
+		InvolvedObject: corev1.ObjectReference{Kind: "ConfigMap", Name: "scaled-cm", UID: "cm-uid"},
+		Type:           corev1.EventTypeNormal,
+		Reason:         "Scaled",
+		Message:        "scaled by the autoscaler",
+		Source:         corev1.EventSource{Component: "autoscaler"},
+		LastTimestamp:  metav1.Now(),
+	}
+	events := fake.NewClientBuilder().WithObjects(event).
+		WithIndex(&corev1.Event{}, trace.EventInvolvedObjectUIDField, func(o client.Object) []string {
+			return []string{string(o.(*corev1.Event).InvolvedObject.UID)}
+		}).Build()
+
+	tests := []struct {
+		name       string
+		user       string
+		wantReason string
+	}{
+		{name: "controller origin carries the event", user: "system:serviceaccount:kube-system:autoscaler", wantReason: "Scaled"},
+		{name: "human origin carries no event", user: "admin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler()
+			h.originEvents = trace.NewEventEnricher(events, time.Minute, 0)
+
+			old := buildUnstructured(configMapGVK, "default", "scaled-cm",
+				map[string]interface{}{"data": "old"}, withUID("cm-uid"))
+			obj := buildUnstructured(configMapGVK, "default", "scaled-cm",
+				map[string]interface{}{"data": "new"}, withUID("cm-uid"))
+			resp := h.Handle(context.Background(), buildAdmissionRequest(admissionv1.Update, obj, old, tt.user))
+			require.True(t, resp.Allowed)
+
+			tr, err := trace.Parse(resp.AuditAnnotations[auditKeyTrace])
+			require.NoError(t, err)
+			require.Len(t, tr, 1)
+			if tt.wantReason == "" {
+				assert.Nil(t, tr[0].Event)
+				return
+			}
+			require.NotNil(t, tr[0].Event)
+			assert.Equal(t, tt.wantReason, tr[0].Event.Reason)
+			assert.Equal(t, "ConfigMap", tr[0].Event.Kind)
+			assert.Equal(t, "autoscaler", tr[0].Event.ReportingController)
+		})
+	}
+}
@@ -5,27 +5,37 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
+	"maps"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	jsonpatch "gomodules.xyz/jsonpatch/v2"
 
 	admissionv1 "k8s.io/api/admission/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
 	"github.com/kausality-io/kausality/pkg/approval"
+	"github.com/kausality-io/kausality/pkg/archive"
 	"github.com/kausality-io/kausality/pkg/callback"
 	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
 	"github.com/kausality-io/kausality/pkg/config"
 	"github.com/kausality-io/kausality/pkg/controller"
 	"github.com/kausality-io/kausality/pkg/drift"
+	"github.com/kausality-io/kausality/pkg/fieldpath"
+	"github.com/kausality-io/kausality/pkg/integrations"
 	"github.com/kausality-io/kausality/pkg/policy"
 	"github.com/kausality-io/kausality/pkg/trace"
 )
@@ -38,10 +48,25 @@ type Handler struct {
 	propagator        *trace.Propagator
 	approvalChecker   *approval.Checker
 	callbackSender    callback.ReportSender
-	controllerTracker *controller.Tracker
+	traceMirror       callback.TraceMirror
+	driftStatus       drift.StatusRecorder
+	resolutions       *callback.ResolutionTracker
+	decisions         *decisionCache
+	recreations       *trace.RecreationIndex
+	traceSigner       *trace.Signer
+	traceSampler      *traceSampler
+	controllerTracker controller.Recorder
 	lifecycleDetector *drift.LifecycleDetector
+	lifecycle         *drift.LifecycleRegistry
 	config            *config.Config
 	policyResolver    policy.Resolver
+	ticketValidator   integrations.TicketValidator
+	selfUsers         []string
+	quarantiner       drift.Quarantiner
+	approvalRequester approval.Requester
+	tombstones        events.EventRecorder
+	archiver          archive.Archiver
+	originEvents      *trace.EventEnricher
 	log               logr.Logger
 }
 
@@ -60,6 +85,67 @@ type Config struct {
 	// CallbackSender sends drift reports to webhook endpoints.
 	// If nil, drift callbacks are disabled.
 	CallbackSender callback.ReportSender
+	// TraceMirror receives the trace of every admitted mutation, so traces
+	// outlive the objects carrying them. If nil, traces are not mirrored.
+	TraceMirror callback.TraceMirror
+	// TicketValidator validates kausality.io/trace-ticket references on origin changes.
+	// If set, origin changes in enforce mode require a valid ticket.
+	// If nil, tickets are not validated.
+	TicketValidator integrations.TicketValidator
+	// Recorder records controller identity and lifecycle phase on parents.
+	// Use a leader-elected *controller.Keeper when running multiple replicas.
+	// If nil, an in-process *controller.Tracker writes annotations immediately.
+	// Records are deferred with WithDeferredRecords for a Keeper, and with
+	// WithRecordDelay or WithRecordTrigger for a Tracker.
+	Recorder controller.Recorder
+	// DriftStatus counts drift on parents, e.g. a *drift.StatusWriter.
+	// If nil, drift is not counted.
+	DriftStatus drift.StatusRecorder
+	// References finds objects referencing a mutated Secret or ConfigMap,
+	// e.g. a *trace.ReferenceIndex, so that traces extend across references.
+	// If it also implements drift.ReferenceParentFinder, referencing parents
+	// are used for drift detection, e.g. of Crossplane connection Secrets.
+	// If nil, traces only follow controller owner references.
+	References trace.ReferenceFinder
+	// AggregatedAPIs tells which parents are served by aggregated API
+	// servers, e.g. a *policy.AggregatedIndex, so that they are read with
+	// a timeout. If nil, all parents are read like built-in resources.
+	AggregatedAPIs drift.AggregatedAPIs
+	// ParentEdges finds the parents of objects without controller owner
+	// reference, e.g. a *drift.CrossplaneEdges for composed resources and
+	// Usages. If nil, such objects have no parent.
+	ParentEdges drift.ParentEdges
+	// TraceSigner signs the hops appended to traces, so that forged hops can
+	// be detected. If nil, hops are not signed.
+	TraceSigner *trace.Signer
+	// SelfUsers are the usernames of kausality's own components, e.g. the
+	// ServiceAccounts of the webhook and the controller. Their writes with
+	// field manager controller.FieldManager are admitted without evaluation.
+	SelfUsers []string
+	// Quarantiner reverts children of policies with driftAction Quarantine
+	// to their approved spec when their drift is denied, e.g. a
+	// *drift.Reverter. If nil, drift is only denied.
+	Quarantiner drift.Quarantiner
+	// ApprovalRequester requests the approval of drift denied in enforce
+	// mode, e.g. an *approval.RequestWriter creating ApprovalRequests. If
+	// nil, denied drift is approved by annotating the parent.
+	ApprovalRequester approval.Requester
+	// Tombstones records the final trace and deleting actor of deleted
+	// objects as Events on them if DriftConfig enables tombstones, e.g. the
+	// manager's event recorder. If nil, deletions are only mirrored.
+	Tombstones events.EventRecorder
+	// TraceArchive archives the trace chains and drift evidence of the
+	// terminal events DriftConfig's traceArchive selects, e.g. an
+	// *archive.Writer. If nil, nothing is archived.
+	TraceArchive archive.Archiver
+	// OriginEvents attaches the most relevant recent Event to origin hops of
+	// controllers, e.g. an EventEnricher reading Events with the manager's
+	// API reader. If nil, origin hops only record the user.
+	OriginEvents *trace.EventEnricher
+	// Lifecycle maps parent kinds to their lifecycle signals, e.g. a
+	// registry kept up to date by a LifecycleWatcher. If nil, the mappings
+	// of DriftConfig are used.
+	Lifecycle *drift.LifecycleRegistry
 }
 
 // NewHandler creates a new admission Handler.
@@ -69,22 +155,172 @@ func NewHandler(cfg Config) *Handler {
 		driftConfig = config.Default()
 	}
 	log := cfg.Log.WithName("kausality-admission")
+	recorder := cfg.Recorder
+	if recorder == nil {
+		recorder = controller.NewTracker(cfg.Client, log)
+	}
+	var resolutions *callback.ResolutionTracker
+	if cfg.CallbackSender != nil {
+		resolutions = callback.NewResolutionTracker()
+	}
+	var decisions *decisionCache
+	if dc := driftConfig.DecisionCache; dc != nil {
+		ttl := dc.TTL
+		if ttl == 0 {
+			ttl = DefaultDecisionTTL
+		}
+		decisions = newDecisionCache(ttl)
+	}
+	if cfg.Lifecycle == nil {
+		cfg.Lifecycle = drift.NewLifecycleRegistry(LifecycleMappings(driftConfig)...)
+	}
+	if f := driftConfig.Flux; f != nil && f.Enabled {
+		cfg.ParentEdges = drift.ChainEdges(cfg.ParentEdges, drift.FluxEdges{})
+	}
+	var recreations *trace.RecreationIndex
+	if rc := driftConfig.Recreation; rc != nil {
+		recreations = trace.NewRecreationIndex(rc.Window)
+	}
 	return &Handler{
 		client:            cfg.Client,
-		detector:          drift.NewDetector(cfg.Client),
-		propagator:        trace.NewPropagator(cfg.Client),
+		detector:          newDetector(cfg, profiles(driftConfig)),
+		propagator:        newPropagator(cfg, profiles(driftConfig)),
 		approvalChecker:   approval.NewChecker(),
 		callbackSender:    cfg.CallbackSender,
-		controllerTracker: controller.NewTracker(cfg.Client, log),
+		traceMirror:       cfg.TraceMirror,
+		resolutions:       resolutions,
+		decisions:         decisions,
+		recreations:       recreations,
+		traceSigner:       cfg.TraceSigner,
+		traceSampler:      newTraceSampler(driftConfig.TraceSampling),
+		controllerTracker: recorder,
 		lifecycleDetector: drift.NewLifecycleDetector(),
+		lifecycle:         cfg.Lifecycle,
 		config:            driftConfig,
 		policyResolver:    cfg.PolicyResolver,
+		ticketValidator:   cfg.TicketValidator,
+		driftStatus:       cfg.DriftStatus,
+		selfUsers:         cfg.SelfUsers,
+		quarantiner:       cfg.Quarantiner,
+		approvalRequester: cfg.ApprovalRequester,
+		tombstones:        cfg.Tombstones,
+		archiver:          cfg.TraceArchive,
+		originEvents:      cfg.OriginEvents,
 		log:               log,
 	}
 }
 
+// newDetector creates the drift detector, checking objects referenced by
+// their parent if configured.
+func newDetector(cfg Config, profiles []drift.Profile) *drift.Detector {
+	opts := []drift.DetectorOption{drift.WithProfiles(profiles...), drift.WithLifecycleRegistry(cfg.Lifecycle)}
+	if parents, ok := cfg.References.(drift.ReferenceParentFinder); ok {
+		opts = append(opts, drift.WithReferenceParents(parents))
+	}
+	if cfg.AggregatedAPIs != nil {
+		opts = append(opts, drift.WithAggregatedAPIs(cfg.AggregatedAPIs))
+	}
+	if cfg.ParentEdges != nil {
+		opts = append(opts, drift.WithParentEdges(cfg.ParentEdges))
+	}
+	return drift.NewDetectorWithOptions(cfg.Client, opts...)
+}
+
+// newPropagator creates the trace propagator, following references if configured.
+func newPropagator(cfg Config, profiles []drift.Profile) *trace.Propagator {
+	opts := []trace.PropagatorOption{trace.WithProfiles(profiles...), trace.WithLifecycleRegistry(cfg.Lifecycle)}
+	if cfg.References != nil {
+		opts = append(opts, trace.WithReferenceFinder(cfg.References))
+	}
+	if cfg.AggregatedAPIs != nil {
+		opts = append(opts, trace.WithAggregatedAPIs(cfg.AggregatedAPIs))
+	}
+	if cfg.ParentEdges != nil {
+		opts = append(opts, trace.WithParentEdges(cfg.ParentEdges))
+	}
+	return trace.NewPropagatorWithOptions(cfg.Client, opts...)
+}
+
+// profiles converts the configured parent profiles, adding the Flux
+// profiles if Flux is enabled.
+func profiles(cfg *config.Config) []drift.Profile {
+	var result []drift.Profile
+	for _, p := range cfg.Profiles {
+		gv, _ := schema.ParseGroupVersion(p.APIVersion)
+		profile := drift.Profile{
+			Group:             gv.Group,
+			Kind:              p.Kind,
+			PhasePath:         p.PhasePath,
+			ProgressingPhases: p.ProgressingPhases,
+			DoneConditions:    p.DoneConditions,
+			ChildAnnotations:  p.ChildAnnotations,
+		}
+		for _, paths := range p.RevisionPaths {
+			profile.RevisionPaths = append(profile.RevisionPaths, [2]string{paths[0], paths[1]})
+		}
+		if r := p.Refresh; r != nil {
+			profile.Refresh = &drift.RefreshProfile{
+				IntervalPath:     r.IntervalPath,
+				DefaultInterval:  r.DefaultInterval,
+				LastRefreshPath:  r.LastRefreshPath,
+				ChildAnnotations: r.ChildAnnotations,
+				FieldManagers:    r.FieldManagers,
+			}
+		}
+		result = append(result, profile)
+	}
+	if f := cfg.Flux; f != nil && f.Enabled {
+		result = append(result, drift.FluxProfiles(f.DefaultServiceAccount)...)
+	}
+	return result
+}
+
 // Handle processes an admission request for drift detection and tracing.
+// Every response carries the complete audit annotations of its path, with
+// keys carrying the configured prefix.
 func (h *Handler) Handle(ctx context.Context, req admission.Request) admission.Response {
+	resp := completeAuditAnnotations(h.admit(ctx, req))
+	h.archiveDenial(req, resp)
+	return prefixAuditAnnotations(resp, h.config.AuditKeyPrefix())
+}
+
+// admit validates the annotations and owner references of a request before
+// handling it, within the configured latency budget. Own writes and
+// resources skipped by the resource filter are admitted without evaluation;
+// tamper attempts are denied before the resource filter applies.
+func (h *Handler) admit(ctx context.Context, req admission.Request) admission.Response {
+	if h.isOwnWrite(req) {
+		ownWrites.WithLabelValues(string(req.Operation)).Inc()
+		return admission.Allowed("own write")
+	}
+	if denial := h.protect(req); denial != nil {
+		return *denial
+	}
+	if reason := h.filterResource(req); reason != "" {
+		return filteredResponse(req, reason)
+	}
+	return h.withinBudget(withParentCache(ctx), req, func(ctx context.Context) admission.Response {
+		return h.evaluate(ctx, req)
+	})
+}
+
+// evaluate validates and handles a request.
+func (h *Handler) evaluate(ctx context.Context, req admission.Request) admission.Response {
+	warnings, denial := h.validateApprovalAnnotations(ctx, req)
+	if denial == nil {
+		var ownerWarnings []string
+		ownerWarnings, denial = h.validateOwnerReferences(ctx, req)
+		warnings = append(warnings, ownerWarnings...)
+	}
+	if denial != nil {
+		return withWarnings(*denial, warnings)
+	}
+	resp := h.handle(ctx, req)
+	observePatchSize(resp)
+	return withWarnings(resp, warnings)
+}
+
+func (h *Handler) handle(ctx context.Context, req admission.Request) admission.Response {
 	log := h.log.WithValues(
 		"operation", req.Operation,
 		"kind", req.Kind.String(),
@@ -94,14 +330,32 @@ func (h *Handler) Handle(ctx context.Context, req admission.Request) admission.R
 		"subresource", req.SubResource,
 	)
 
+	// Handle subresources by their configured handling
+	if req.SubResource != "" {
+		switch handling := h.subresourceHandling(req); {
+		case handling == kausalityv1alpha1.SubresourceHandlingController:
+			// e.g. status updates - record controller identity
+			return h.handleStatusUpdate(ctx, req, log)
+		case handling != kausalityv1alpha1.SubresourceHandlingTrack:
+			return admission.Allowed("subresource not tracked")
+		case req.Operation == admissionv1.Connect:
+			return h.handleConnect(req, log)
+		case !carriesObject(req):
+			return h.handleProxySubresource(ctx, req, log)
+		}
+		// Tracked subresources carrying the object itself, e.g.
+		// pods/ephemeralcontainers, are handled like mutations of the object
+	}
+
 	// Handle CREATE, UPDATE, and DELETE (DELETE just sets deletionTimestamp)
 	if req.Operation != admissionv1.Create && req.Operation != admissionv1.Update && req.Operation != admissionv1.Delete {
 		return admission.Allowed("operation not relevant for tracing")
 	}
 
-	// Handle status subresource updates - record controller identity
-	if req.SubResource == "status" {
-		return h.handleStatusUpdate(ctx, req, log)
+	// admission.k8s.io/v1beta1 reviews of clusters before Kubernetes 1.15 carry
+	// no old object for DELETE: there is nothing to trace
+	if req.Operation == admissionv1.Delete && len(req.OldObject.Raw) == 0 {
+		return admission.Allowed("deleted object not provided")
 	}
 
 	// For UPDATE, check if spec changed - ignore status/metadata-only changes
@@ -109,8 +363,13 @@ func (h *Handler) Handle(ctx context.Context, req admission.Request) admission.R
 	if req.Operation == admissionv1.Update {
 		specChanged, err := h.hasSpecChanged(req)
 		if err != nil {
-			log.Error(err, "failed to check spec change")
-			return admission.Errored(http.StatusBadRequest, fmt.Errorf("failed to check spec change: %w", err))
+			return h.errorResponse(drift.NewError(drift.ErrorDecode, fmt.Errorf("failed to check spec change: %w", err)), nil, log)
+		}
+		// Field-scoped policies only track changes of their field paths
+		if specChanged {
+			if specChanged, err = h.fieldPathsChanged(ctx, req); err != nil {
+				return h.errorResponse(err, nil, log)
+			}
 		}
 		if !specChanged {
 			// No spec change: preserve all kausality annotations (regardless of actor)
@@ -119,6 +378,9 @@ func (h *Handler) Handle(ctx context.Context, req admission.Request) admission.R
 				if err := json.Unmarshal(req.Object.Raw, &newObj); err == nil {
 					// specChanged=false means newTrace/newUpdaters are unused
 					merged := computeAnnotationsForUser(oldObj.GetAnnotations(), newObj.GetAnnotations(), false, "", "")
+					// Overrides only apply to the mutation carrying them
+					delete(merged, approval.OverrideAnnotation)
+					h.trackOrphan(ctx, req, &oldObj, &newObj, merged, log)
 					newObj.SetAnnotations(merged)
 					if modified, err := json.Marshal(newObj.Object); err == nil {
 						log.V(1).Info("no spec change, preserving annotations")
@@ -135,18 +397,24 @@ func (h *Handler) Handle(ctx context.Context, req admission.Request) admission.R
 	audit := map[string]string{}
 
 	// Parse the object from the request
+	decodeStart := time.Now()
 	obj, err := h.parseObject(req)
+	decodeDuration.Observe(time.Since(decodeStart).Seconds())
 	if err != nil {
-		log.Error(err, "failed to parse object from request")
-		return admission.Errored(http.StatusBadRequest, fmt.Errorf("failed to parse object: %w", err))
+		return h.errorResponse(drift.NewError(drift.ErrorDecode, fmt.Errorf("failed to parse object: %w", err)), audit, log)
 	}
 
-	// Get existing updaters from OldObject (for UPDATE) or empty (for CREATE)
+	// Get existing updaters and labels from OldObject (for UPDATE) or empty (for CREATE)
 	var childUpdaters []string
+	var oldLabels map[string]string
 	if req.Operation == admissionv1.Update && len(req.OldObject.Raw) > 0 {
 		oldObj := &unstructured.Unstructured{}
 		if err := runtime.DecodeInto(unstructured.UnstructuredJSONScheme, req.OldObject.Raw, oldObj); err == nil {
 			childUpdaters = drift.ParseUpdaterHashes(oldObj)
+			oldLabels = oldObj.GetLabels()
+			if oldLabels == nil {
+				oldLabels = map[string]string{}
+			}
 		}
 	}
 
@@ -162,17 +430,47 @@ func (h *Handler) Handle(ctx context.Context, req admission.Request) admission.R
 		childUpdaters = append(childUpdaters, userHash)
 	}
 
-	// Detect drift using user hash tracking
-	driftResult, err := h.detector.Detect(ctx, obj, userID, childUpdaters)
+	// A controller retrying a denied update gets the same denial
+	decisionKey, cacheable := h.decisionKey(ctx, req, obj, userHash)
+	if cacheable {
+		if d, ok := h.decisions.get(decisionKey); ok {
+			log.V(1).Info("DRIFT DENIED (cached)", "parentKind", d.parentKind, "parentName", d.parentName)
+			cached := maps.Clone(d.audit)
+			cached[kausalityv1alpha1.AuditKeyDecisionCache] = "hit"
+			resp := admission.Denied("")
+			resp.Result = d.result.DeepCopy()
+			return withAuditAnnotations(resp, cached)
+		}
+	}
+
+	// Detect drift, identifying the controller with the policy's classifier.
+	// Errors resolving the parent take precedence over policy errors.
+	objPolicy, policyErr := h.resolveObjectPolicy(ctx, obj, oldLabels)
+	if policyErr == nil {
+		audit[kausalityv1alpha1.AuditKeyMode] = objPolicy.mode
+	}
+	actor := drift.Actor{Username: userID, FieldManager: extractFieldManager(req), ChildUpdaters: childUpdaters}
+	detectStart := time.Now()
+	detectCtx, cancel := h.parentBudget(ctx)
+	driftResult, err := h.detector.DetectActor(detectCtx, obj, actor, objPolicy.classifier)
+	cancel()
+	detectionDuration.Observe(time.Since(detectStart).Seconds())
 	if err != nil {
-		log.Error(err, "drift detection failed")
-		return admission.Errored(http.StatusInternalServerError, fmt.Errorf("drift detection failed: %w", err))
+		if resp, ok := h.missingParentResponse(ctx, req, obj, err, objPolicy.missingParent, audit, log); ok {
+			return resp
+		}
+		if drift.ClassOf(err) == drift.ErrorParentNotFound {
+			// The garbage collector deletes dependents after their owner is gone
+			h.recordOwnerlessDeletion(req, obj, log)
+		}
+		return h.errorResponse(err, audit, log)
 	}
+	captureWhatIf(ctx, driftResult)
 
 	// Record drift detection in audit annotations
-	audit[auditKeyDrift] = strconv.FormatBool(driftResult.DriftDetected)
+	audit[kausalityv1alpha1.AuditKeyDrift] = strconv.FormatBool(driftResult.DriftDetected)
 	if driftResult.LifecyclePhase != "" {
-		audit[auditKeyLifecyclePhase] = string(driftResult.LifecyclePhase)
+		audit[kausalityv1alpha1.AuditKeyLifecyclePhase] = string(driftResult.LifecyclePhase)
 	}
 
 	// Log drift detection result
@@ -193,8 +491,8 @@ func (h *Handler) Handle(ctx context.Context, req admission.Request) admission.R
 		if frozen, freeze := h.checkFreeze(ctx, driftResult.ParentRef, obj.GetNamespace(), log); frozen {
 			freezeMsg := fmt.Sprintf("mutation blocked: parent %s", freeze.String())
 			log.Info("MUTATION FROZEN", append(logFields, "freezeUser", freeze.User, "freezeMessage", freeze.Message)...)
-			audit[auditKeyDecision] = "denied"
-			return withAuditAnnotations(admission.Denied(freezeMsg), audit)
+			h.auditDeniedTrace(ctx, req, obj, userID, childUpdaters, audit, log)
+			return withAuditAnnotations(denied(kausalityv1alpha1.DenialReasonFrozen, freezeMsg, req, obj, driftResult), audit)
 		}
 	}
 
@@ -208,7 +506,7 @@ func (h *Handler) Handle(ctx context.Context, req admission.Request) admission.R
 			if err != nil {
 				log.V(1).Info("failed to fetch parent for phase recording", "error", err)
 			} else if parent != nil {
-				h.controllerTracker.RecordPhaseAsync(ctx, parent, controller.PhaseValueInitialized)
+				h.controllerTracker.RecordPhase(ctx, parent, controller.PhaseValueInitialized)
 			}
 		}
 	}
@@ -216,43 +514,17 @@ func (h *Handler) Handle(ctx context.Context, req admission.Request) admission.R
 	// Track warnings to add to the response
 	var warnings []string
 
-	// Build resource context for mode matching
-	gvk := obj.GetObjectKind().GroupVersionKind()
-	resourceCtx := config.ResourceContext{
-		GVK:          gvk,
-		Namespace:    obj.GetNamespace(),
-		ObjectLabels: obj.GetLabels(),
-	}
-
-	// Fetch namespace metadata if needed for selector matching and annotation resolution
-	var nsAnnotations map[string]string
-	if obj.GetNamespace() != "" {
-		nsLabels, nsAnns, err := h.getNamespaceMetadata(ctx, obj.GetNamespace())
-		if err != nil {
-			log.V(1).Info("failed to get namespace metadata", "error", err)
-			// Continue without namespace metadata - selectors won't match
-		} else {
-			resourceCtx.NamespaceLabels = nsLabels
-			nsAnnotations = nsAnns
-		}
-	}
-
-	// Determine enforce mode using annotation-based resolution
-	// Precedence: object annotation > namespace annotation > CRD policy > legacy config
-	objAnnotations := obj.GetAnnotations()
-	if objAnnotations == nil {
-		objAnnotations = map[string]string{}
+	if policyErr != nil {
+		return h.errorResponse(policyErr, audit, log)
 	}
-	if nsAnnotations == nil {
-		nsAnnotations = map[string]string{}
-	}
-	driftMode := h.resolveMode(gvk, obj.GetNamespace(), resourceCtx.NamespaceLabels, obj.GetLabels(), objAnnotations, nsAnnotations)
+	driftMode := objPolicy.mode
 	enforceMode := driftMode == string(kausalityv1alpha1.ModeEnforce)
-	audit[auditKeyMode] = driftMode
+	excludeDrift(driftResult, objPolicy, audit, log)
 
 	if driftResult.DriftDetected {
 		// Check for approvals when drift is detected
 		approvalResult := h.checkApprovals(ctx, driftResult, obj, log)
+		h.recordDriftStatus(ctx, req, approvalResult.parent)
 		logFields = append(logFields,
 			"approved", approvalResult.Approved,
 			"rejected", approvalResult.Rejected,
@@ -262,46 +534,84 @@ func (h *Handler) Handle(ctx context.Context, req admission.Request) admission.R
 		if approvalResult.Rejected {
 			rejectMsg := fmt.Sprintf("drift rejected: %s", approvalResult.Reason)
 			log.Info("DRIFT REJECTED", append(logFields, "rejectReason", approvalResult.Reason)...)
-			audit[auditKeyDriftResolution] = "rejected"
+			audit[kausalityv1alpha1.AuditKeyDriftResolution] = "rejected"
 			if enforceMode {
-				audit[auditKeyDecision] = "denied"
-				return withAuditAnnotations(admission.Denied(rejectMsg), audit)
+				h.auditDeniedTrace(ctx, req, obj, userID, childUpdaters, audit, log)
+				rejectMsg += h.quarantine(ctx, req, objPolicy.driftAction, log)
+				resp := denied(kausalityv1alpha1.DenialReasonRejected, rejectMsg, req, obj, driftResult)
+				if cacheable {
+					h.decisions.put(decisionKey, driftResult.ParentRef, resp.Result, audit)
+				}
+				return withAuditAnnotations(resp, audit)
 			}
 			// Non-enforce mode: add warning but allow
 			warnings = append(warnings, fmt.Sprintf("[kausality] %s (would be blocked in enforce mode)", rejectMsg))
 		} else if approvalResult.Approved {
-			audit[auditKeyDriftResolution] = "approved"
+			audit[kausalityv1alpha1.AuditKeyDriftResolution] = "approved"
 			log.Info("DRIFT APPROVED", append(logFields, "approvalReason", approvalResult.Reason)...)
 			// Consume mode=once approvals and prune stale ones
-			h.consumeApproval(ctx, approvalResult, log)
+			h.consumeApproval(ctx, req, obj, driftResult, approvalResult, log)
 			// Send resolved notification
-			h.sendDriftCallback(ctx, req, obj, driftResult, approvalResult.parent, v1alpha1.DriftReportPhaseResolved, log)
+			h.sendResolvedCallback(ctx, req, obj, driftResult, approvalResult.parent, v1alpha1.ResolutionApproved, log)
+		} else if suppression := activeSuppression(childAnnotations(req, obj), log); suppression != nil {
+			audit[kausalityv1alpha1.AuditKeyDriftResolution] = "suppressed"
+			audit[kausalityv1alpha1.AuditKeySuppression] = suppression.String()
+			log.Info("DRIFT SUPPRESSED", append(logFields, "suppression", suppression.String())...)
+			// Still reported, marked as suppressed
+			h.openResolution(req, h.sendDriftCallback(ctx, req, obj, driftResult, approvalResult.parent, nil, suppression, log))
 		} else {
 			driftMsg := "drift detected: no approval found for this mutation"
+			override, overrideErr := h.checkOverride(ctx, obj)
+			if override != nil {
+				logFields = append(logFields, "override", override.String())
+			}
 			log.Info("DRIFT DETECTED - no approval found", logFields...)
-			audit[auditKeyDriftResolution] = "unresolved"
-			// Send drift detected notification
-			h.sendDriftCallback(ctx, req, obj, driftResult, approvalResult.parent, v1alpha1.DriftReportPhaseDetected, log)
-			if enforceMode {
-				audit[auditKeyDecision] = "denied"
-				return withAuditAnnotations(admission.Denied(driftMsg), audit)
+			audit[kausalityv1alpha1.AuditKeyDriftResolution] = "unresolved"
+			// Send drift detected notification and watch the child for its resolution
+			h.openResolution(req, h.sendDriftCallback(ctx, req, obj, driftResult, approvalResult.parent, override, nil, log))
+			if overrideErr != nil {
+				driftMsg = fmt.Sprintf("%s (%v)", driftMsg, overrideErr)
+			}
+			switch {
+			case enforceMode && override != nil:
+				// Allowed with audit: the acting user or tool takes responsibility
+				log.Info("DRIFT OVERRIDDEN", "ticket", override.Ticket, "justification", override.Justification)
+				audit[kausalityv1alpha1.AuditKeyDriftResolution] = "overridden"
+				audit[kausalityv1alpha1.AuditKeyOverride] = override.String()
+				warnings = append(warnings, fmt.Sprintf("[kausality] %s (allowed by override: %s)", driftMsg, override))
+			case enforceMode:
+				h.auditDeniedTrace(ctx, req, obj, userID, childUpdaters, audit, log)
+				driftMsg += h.quarantine(ctx, req, objPolicy.driftAction, log)
+				driftMsg += h.requestApproval(ctx, req, obj, driftResult, approvalResult, log)
+				resp := denied(kausalityv1alpha1.DenialReasonDrift, driftMsg, req, obj, driftResult)
+				if cacheable {
+					h.decisions.put(decisionKey, driftResult.ParentRef, resp.Result, audit)
+				}
+				return withAuditAnnotations(resp, audit)
+			default:
+				// Non-enforce mode: add warning but allow
+				warnings = append(warnings, fmt.Sprintf("[kausality] %s (would be blocked in enforce mode)", driftMsg))
 			}
-			// Non-enforce mode: add warning but allow
-			warnings = append(warnings, fmt.Sprintf("[kausality] %s (would be blocked in enforce mode)", driftMsg))
 		}
 	} else {
 		log.V(1).Info("drift check passed", logFields...)
+		h.checkResolution(ctx, req, obj, driftResult, actor, objPolicy.classifier, log)
+		if objPolicy.exclusion == "" {
+			h.prewarmParent(ctx, req, obj, driftResult, userID, log)
+		}
 	}
 
 	// Propagate trace
-	traceResult, err := h.propagator.Propagate(ctx, obj, userID, childUpdaters, string(req.UID))
+	traceResult, err := h.propagator.PropagateAt(ctx, obj, admittedGeneration(req, obj), userID, childUpdaters, string(req.UID))
 	if err != nil {
 		log.Error(err, "trace propagation failed")
 		// Don't fail the request on trace errors - just log and continue
-		audit[auditKeyDecision] = auditDecision(warnings)
 		return withAuditAnnotations(withWarnings(admission.Allowed(driftResult.Reason), warnings), audit)
 	}
 
+	// The hop of this request records its operation
+	traceResult.Trace[len(traceResult.Trace)-1].Operation = string(req.Operation)
+
 	// Log trace info
 	if traceResult.IsOrigin {
 		log.Info("trace: new origin", "traceLen", len(traceResult.Trace))
@@ -309,11 +619,65 @@ func (h *Handler) Handle(ctx context.Context, req admission.Request) admission.R
 		log.V(1).Info("trace: extended", "traceLen", len(traceResult.Trace), "parentTraceLen", len(traceResult.ParentTrace))
 	}
 
+	// Validate the ticket reference for origin changes in enforce mode
+	if traceResult.IsOrigin && enforceMode && h.ticketValidator != nil && req.Operation != admissionv1.Delete {
+		ticket, err := h.validateTicket(ctx, traceResult.Trace)
+		if err != nil {
+			log.Info("TICKET REJECTED", "error", err.Error())
+			audit[kausalityv1alpha1.AuditKeyTrace] = traceResult.Trace.String()
+			return withAuditAnnotations(denied(kausalityv1alpha1.DenialReasonTicket, err.Error(), req, obj, nil), audit)
+		}
+		traceResult.Trace[0].Ticket = ticket
+		audit[kausalityv1alpha1.AuditKeyTicket] = ticket.ID
+		log.V(1).Info("ticket validated", "ticket", ticket.ID, "state", ticket.State)
+	}
+
+	// Origin changes of controllers carry the Event explaining them
+	if traceResult.IsOrigin && h.originEvents != nil {
+		if err := h.originEvents.Enrich(ctx, obj, traceResult.Trace); err != nil {
+			log.V(1).Info("failed to read origin events", "error", err.Error())
+		}
+	}
+
+	// Recreated children continue the trace of their predecessor
+	if h.recreations != nil && (req.DryRun == nil || !*req.DryRun) {
+		switch req.Operation {
+		case admissionv1.Delete:
+			h.recreations.Deleted(obj)
+		case admissionv1.Create:
+			if predecessor := h.recreations.Link(obj, traceResult); predecessor != nil {
+				log.V(1).Info("trace: recreation", "predecessor", predecessor.Name, "predecessorUID", predecessor.UID)
+			}
+		}
+	}
+
+	// Objects of sampled resources outside the sample keep no trace, which
+	// bounds annotation writes and mirrored traces of high-cardinality kinds
+	traced := h.traceSampler.sample(req, obj, driftResult.ParentState)
+	if !traced {
+		log.V(1).Info("trace: not sampled")
+	}
+
+	// Sign the hops of this request once they are final
+	if traced && h.traceSigner != nil {
+		subject := trace.Subject{Namespace: obj.GetNamespace(), UID: obj.GetUID()}
+		if err := h.traceSigner.Sign(traceResult.Trace, traceResult.NewHops, subject); err != nil {
+			log.Error(err, "trace signing failed")
+		}
+	}
+
+	if traced {
+		h.mirrorTrace(req, obj, traceResult.Trace)
+	}
+
 	// For DELETE, we can't patch (no new object), just allow after logging
 	if req.Operation == admissionv1.Delete {
-		log.V(1).Info("delete operation traced", "trace", traceResult.Trace.String())
-		audit[auditKeyTrace] = traceResult.Trace.String()
-		audit[auditKeyDecision] = auditDecision(warnings)
+		if traced {
+			log.V(1).Info("delete operation traced", "trace", traceResult.Trace.String())
+			audit[kausalityv1alpha1.AuditKeyTrace] = traceResult.Trace.String()
+			h.recordTombstone(req, obj, traceResult.Trace, log)
+			h.archiveDeletion(req, obj, traceResult.Trace)
+		}
 		return withAuditAnnotations(withWarnings(admission.Allowed(driftResult.Reason), warnings), audit)
 	}
 
@@ -334,8 +698,25 @@ func (h *Handler) Handle(ctx context.Context, req admission.Request) admission.R
 		}
 	}
 
-	newTrace := traceResult.Trace.String()
+	// Written with the current schema, upgrading annotations of earlier ones
+	newTrace := traceResult.Trace.Annotation()
 	newUpdaters := addHash(annotations[controller.UpdatersAnnotation], userHash)
+	newSummary := computeSummary(req, traceResult.Trace, driftResult.DriftDetected)
+
+	// System annotations set by the patch, in patch order
+	systemValues := []struct{ key, value string }{
+		{trace.TraceAnnotation, newTrace},
+		{controller.UpdatersAnnotation, newUpdaters},
+		{trace.SummaryAnnotation, newSummary},
+	}
+	if !traced {
+		// Updaters identify the controller and are always recorded
+		systemValues = systemValues[1:2]
+	}
+	approvedSpec, recordApprovedSpec := h.approvedSpec(req, unstrObj, objPolicy, driftResult, actor, audit)
+	if recordApprovedSpec {
+		systemValues = append(systemValues, struct{ key, value string }{drift.ApprovedSpecAnnotation, approvedSpec})
+	}
 
 	// Build patches - need to handle case where annotations don't exist
 	var patches []jsonpatch.JsonPatchOperation
@@ -344,45 +725,50 @@ func (h *Handler) Handle(ctx context.Context, req admission.Request) admission.R
 	originalAnnotations, _, _ := unstructured.NestedStringMap(unstrObj.Object, "metadata", "annotations")
 	if len(originalAnnotations) == 0 {
 		// No annotations exist - add the whole annotations object
+		value := make(map[string]string, len(systemValues))
+		for _, v := range systemValues {
+			value[v.key] = v.value
+		}
 		patches = append(patches, jsonpatch.JsonPatchOperation{
 			Operation: "add",
 			Path:      "/metadata/annotations",
-			Value: map[string]string{
-				trace.TraceAnnotation:         newTrace,
-				controller.UpdatersAnnotation: newUpdaters,
-			},
+			Value:     value,
 		})
 	} else {
 		// Annotations exist - use replace for existing keys, add for new ones
-		tracePath := "/metadata/annotations/" + strings.ReplaceAll(trace.TraceAnnotation, "/", "~1")
-		updatersPath := "/metadata/annotations/" + strings.ReplaceAll(controller.UpdatersAnnotation, "/", "~1")
-
-		// Check if keys exist to decide add vs replace
-		traceOp := "add"
-		if _, exists := originalAnnotations[trace.TraceAnnotation]; exists {
-			traceOp = "replace"
-		}
-		updatersOp := "add"
-		if _, exists := originalAnnotations[controller.UpdatersAnnotation]; exists {
-			updatersOp = "replace"
+		for _, v := range systemValues {
+			op := "add"
+			if _, exists := originalAnnotations[v.key]; exists {
+				op = "replace"
+			}
+			patches = append(patches, jsonpatch.JsonPatchOperation{
+				Operation: op,
+				Path:      "/metadata/annotations/" + strings.ReplaceAll(v.key, "/", "~1"),
+				Value:     v.value,
+			})
 		}
+	}
 
+	// Overrides only apply to the mutation carrying them, never persist them
+	if _, ok := originalAnnotations[approval.OverrideAnnotation]; ok {
 		patches = append(patches, jsonpatch.JsonPatchOperation{
-			Operation: traceOp,
-			Path:      tracePath,
-			Value:     newTrace,
+			Operation: "remove",
+			Path:      "/metadata/annotations/" + strings.ReplaceAll(approval.OverrideAnnotation, "/", "~1"),
 		})
+	}
+	// Approved specs are only written by the webhook
+	if _, ok := originalAnnotations[drift.ApprovedSpecAnnotation]; ok && !recordApprovedSpec {
 		patches = append(patches, jsonpatch.JsonPatchOperation{
-			Operation: updatersOp,
-			Path:      updatersPath,
-			Value:     newUpdaters,
+			Operation: "remove",
+			Path:      "/metadata/annotations/" + strings.ReplaceAll(drift.ApprovedSpecAnnotation, "/", "~1"),
 		})
 	}
 
 	// Build response manually to ensure patch is serialized correctly
 	patchType := admissionv1.PatchTypeJSONPatch
-	audit[auditKeyTrace] = newTrace
-	audit[auditKeyDecision] = auditDecision(warnings)
+	if traced {
+		audit[kausalityv1alpha1.AuditKeyTrace] = traceResult.Trace.String()
+	}
 	resp := admission.Response{
 		Patches: patches,
 		AdmissionResponse: admissionv1.AdmissionResponse{
@@ -394,18 +780,51 @@ func (h *Handler) Handle(ctx context.Context, req admission.Request) admission.R
 	return withAuditAnnotations(withWarnings(resp, warnings), audit)
 }
 
+// computeSummary renders the summary annotation for an admitted mutation.
+// The drift count is carried over from the old object, so a request cannot
+// reset it, and incremented if this mutation is drift.
+func computeSummary(req admission.Request, t trace.Trace, driftDetected bool) string {
+	verb := "created"
+	driftIncidents := 0
+	if req.Operation == admissionv1.Update {
+		verb = "updated"
+		oldObj := &unstructured.Unstructured{}
+		if err := runtime.DecodeInto(unstructured.UnstructuredJSONScheme, req.OldObject.Raw, oldObj); err == nil {
+			driftIncidents = trace.DriftIncidents(oldObj.GetAnnotations()[trace.SummaryAnnotation])
+		}
+	}
+	if driftDetected {
+		driftIncidents++
+	}
+	return trace.Summary(t, verb, driftIncidents)
+}
+
+// auditDeniedTrace records the trace a denied mutation would have had in the
+// audit annotations: the object is not modified, so the audit log is the only
+// record of it. Denials write no annotations, so trace sampling does not
+// apply. Propagation errors leave the trace unset.
+func (h *Handler) auditDeniedTrace(ctx context.Context, req admission.Request, obj client.Object, userID string, childUpdaters []string, audit map[string]string, log logr.Logger) {
+	traceResult, err := h.propagator.PropagateAt(ctx, obj, admittedGeneration(req, obj), userID, childUpdaters, string(req.UID))
+	if err != nil {
+		log.V(1).Info("trace propagation of denied mutation failed", "error", err)
+		return
+	}
+	audit[kausalityv1alpha1.AuditKeyTrace] = traceResult.Trace.String()
+}
+
 // handleStatusUpdate handles status subresource updates to record controller identity.
 // It also protects our annotations from being overwritten by stale controller caches.
 func (h *Handler) handleStatusUpdate(ctx context.Context, req admission.Request, log logr.Logger) admission.Response {
+	audit := map[string]string{kausalityv1alpha1.AuditKeySubresource: req.SubResource}
 	if req.Operation != admissionv1.Update {
-		return admission.Allowed("status subresource: only UPDATE is relevant")
+		return withAuditAnnotations(admission.Allowed("status subresource: only UPDATE is relevant"), audit)
 	}
 
 	// Parse the object for controller tracking
 	obj, err := h.parseObject(req)
 	if err != nil {
 		log.Error(err, "failed to parse object from status update request")
-		return admission.Allowed("failed to parse object")
+		return withAuditAnnotations(admission.Allowed("failed to parse object"), audit)
 	}
 
 	// Get user identifier (username if available, UID as fallback)
@@ -414,14 +833,18 @@ func (h *Handler) handleStatusUpdate(ctx context.Context, req admission.Request,
 	log.V(1).Info("status update", "userHash", userHash)
 
 	// Record controller asynchronously as backup (in case sync patch fails)
-	h.controllerTracker.RecordControllerAsync(ctx, obj, userID)
+	h.controllerTracker.RecordController(ctx, obj, userID)
 
 	// Record phase async (status update may have changed conditions)
 	parentState := extractParentStateFromObject(obj)
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		parentState.Lifecycle = h.lifecycle.Signals(u)
+	}
 	phase := h.lifecycleDetector.DetectPhase(parentState)
 	if phase != drift.PhaseDeleting {
-		h.controllerTracker.RecordPhaseAsync(ctx, obj, string(phase))
+		h.controllerTracker.RecordPhase(ctx, obj, string(phase))
 	}
+	audit[kausalityv1alpha1.AuditKeyLifecyclePhase] = string(phase)
 
 	// Compute annotations: preserve kausality annotations, add user to controllers, record observed generation
 	var oldObj, newObj unstructured.Unstructured
@@ -431,12 +854,12 @@ func (h *Handler) handleStatusUpdate(ctx context.Context, req admission.Request,
 			newObj.SetAnnotations(merged)
 			if modified, err := json.Marshal(newObj.Object); err == nil {
 				log.V(1).Info("status update, added controller hash and preserved annotations")
-				return admission.PatchResponseFromRaw(req.Object.Raw, modified)
+				return withAuditAnnotations(admission.PatchResponseFromRaw(req.Object.Raw, modified), audit)
 			}
 		}
 	}
 
-	return admission.Allowed("status update recorded")
+	return withAuditAnnotations(admission.Allowed("status update recorded"), audit)
 }
 
 // withWarnings adds warnings to an admission response.
@@ -471,6 +894,7 @@ var systemAnnotations = map[string]bool{
 	controller.UpdatersAnnotation:           true,
 	controller.ControllersAnnotation:        true,
 	controller.ObservedGenerationAnnotation: true,
+	trace.SummaryAnnotation:                 true,
 }
 
 // isSystemAnnotation returns true for annotations that get special handling.
@@ -600,6 +1024,64 @@ func (h *Handler) InjectDecoder(d admission.Decoder) error {
 	return nil
 }
 
+// validateTicket checks the origin hop's ticket label against the ticket system.
+func (h *Handler) validateTicket(ctx context.Context, t trace.Trace) (*trace.TicketRef, error) {
+	origin := t.Origin()
+	ref := ""
+	if origin != nil {
+		ref = origin.Labels[trace.TicketLabel]
+	}
+	if ref == "" {
+		return nil, fmt.Errorf("origin change requires a %s annotation", trace.TicketAnnotation)
+	}
+	ticket, err := h.ticketValidator.Validate(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("ticket %q rejected: %w", ref, err)
+	}
+	return ticket, nil
+}
+
+// checkOverride returns the override carried by the mutated object, if any.
+// The ticket is validated when ticket validation is enabled.
+func (h *Handler) checkOverride(ctx context.Context, obj client.Object) (*approval.Override, error) {
+	override, err := approval.ParseOverride(obj.GetAnnotations()[approval.OverrideAnnotation])
+	if err != nil || override == nil {
+		return nil, err
+	}
+	if h.ticketValidator != nil {
+		if _, err := h.ticketValidator.Validate(ctx, override.Ticket); err != nil {
+			return nil, fmt.Errorf("override ticket %q rejected: %w", override.Ticket, err)
+		}
+	}
+	return override, nil
+}
+
+// admittedGeneration returns the generation obj is persisted with. The
+// apiserver sets it after mutating admission: 1 on CREATE, incremented on spec
+// changes and on deletions deferred by finalizers. Kinds without generation
+// keep 0.
+func admittedGeneration(req admission.Request, obj client.Object) int64 {
+	switch req.Operation {
+	case admissionv1.Create:
+		if u, ok := obj.(*unstructured.Unstructured); ok && obj.GetGeneration() == 0 {
+			if _, hasSpec := u.Object["spec"]; hasSpec {
+				return 1
+			}
+		}
+	case admissionv1.Update:
+		// Only spec changes are traced
+		oldObj := &unstructured.Unstructured{}
+		if err := runtime.DecodeInto(unstructured.UnstructuredJSONScheme, req.OldObject.Raw, oldObj); err == nil && oldObj.GetGeneration() > 0 {
+			return oldObj.GetGeneration() + 1
+		}
+	case admissionv1.Delete:
+		if obj.GetGeneration() > 0 && obj.GetDeletionTimestamp() == nil && len(obj.GetFinalizers()) > 0 {
+			return obj.GetGeneration() + 1
+		}
+	}
+	return obj.GetGeneration()
+}
+
 // hasSpecChanged checks if the spec field changed between old and new object.
 func (h *Handler) hasSpecChanged(req admission.Request) (bool, error) {
 	if len(req.OldObject.Raw) == 0 || len(req.Object.Raw) == 0 {
@@ -622,6 +1104,38 @@ func (h *Handler) hasSpecChanged(req admission.Request) (bool, error) {
 	return !equalSpec(oldSpec, newSpec), nil
 }
 
+// fieldPathsChanged checks if an update changes the field paths its policy
+// limits drift detection to. Without field paths, any spec change counts.
+// Failing to read the namespace for the policy is an ErrorPolicyUnavailable.
+func (h *Handler) fieldPathsChanged(ctx context.Context, req admission.Request) (bool, error) {
+	if h.policyResolver == nil {
+		return true, nil
+	}
+	oldObj := &unstructured.Unstructured{}
+	if err := runtime.DecodeInto(unstructured.UnstructuredJSONScheme, req.OldObject.Raw, oldObj); err != nil {
+		return false, drift.NewError(drift.ErrorDecode, fmt.Errorf("failed to decode old object: %w", err))
+	}
+	newObj := &unstructured.Unstructured{}
+	if err := runtime.DecodeInto(unstructured.UnstructuredJSONScheme, req.Object.Raw, newObj); err != nil {
+		return false, drift.NewError(drift.ErrorDecode, fmt.Errorf("failed to decode new object: %w", err))
+	}
+
+	namespace := policy.EffectiveNamespace(newObj.GetNamespace(), newObj.GetLabels())
+	var nsLabels map[string]string
+	if namespace != "" {
+		labels, _, err := h.getNamespaceMetadata(ctx, namespace)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return false, drift.NewError(drift.ErrorPolicyUnavailable, fmt.Errorf("failed to get namespace %q: %w", namespace, err))
+		}
+		nsLabels = labels
+	}
+	paths := h.policyResolver.FieldPaths(policyContext(newObj.GroupVersionKind(), namespace, newObj.GetName(), nsLabels, newObj.GetLabels(), rawLabels(req.OldObject.Raw)))
+	if len(paths) == 0 {
+		return true, nil
+	}
+	return fieldpath.Changed(paths, oldObj.Object, newObj.Object), nil
+}
+
 // equalSpec compares two spec values for equality.
 func equalSpec(a, b interface{}) bool {
 	if a == nil && b == nil {
@@ -647,8 +1161,7 @@ func equalSpec(a, b interface{}) bool {
 // approvalCheckResult extends approval.CheckResult with parent info for pruning.
 type approvalCheckResult struct {
 	approval.CheckResult
-	parent           client.Object
-	parentGeneration int64
+	parent *ParentContext
 }
 
 // checkApprovals checks if the drift is approved or rejected.
@@ -658,7 +1171,7 @@ func (h *Handler) checkApprovals(ctx context.Context, driftResult *drift.DriftRe
 	}
 
 	// Fetch parent object to read approval annotations
-	parent, err := h.fetchParent(ctx, driftResult.ParentRef, obj.GetNamespace())
+	parent, err := h.parentContext(ctx, driftResult.ParentRef, obj.GetNamespace())
 	if err != nil {
 		log.Error(err, "failed to fetch parent for approval check")
 		return approvalCheckResult{CheckResult: approval.CheckResult{Reason: "failed to fetch parent: " + err.Error()}}
@@ -673,17 +1186,19 @@ func (h *Handler) checkApprovals(ctx context.Context, driftResult *drift.DriftRe
 	}
 
 	// Check approvals on parent
-	result := h.approvalChecker.Check(parent, childRef, parent.GetGeneration())
 	return approvalCheckResult{
-		CheckResult:      result,
-		parent:           parent,
-		parentGeneration: parent.GetGeneration(),
+		CheckResult: parent.Check(h.approvalChecker, childRef),
+		parent:      parent,
 	}
 }
 
-// consumeApproval removes a mode=once approval and prunes stale approvals from the parent.
-func (h *Handler) consumeApproval(ctx context.Context, result approvalCheckResult, log logr.Logger) {
-	if result.parent == nil || result.MatchedApproval == nil {
+// consumeApproval marks a used mode=once approval as consumed and prunes
+// stale approvals from the parent. The parent is patched with an optimistic
+// lock, so that concurrent changes to its approvals are not overwritten; on
+// conflicts the latest parent is read and the approval marked again. Dry
+// runs consume nothing.
+func (h *Handler) consumeApproval(ctx context.Context, req admission.Request, obj client.Object, driftResult *drift.DriftResult, result approvalCheckResult, log logr.Logger) {
+	if result.parent == nil || result.MatchedApproval == nil || (req.DryRun != nil && *req.DryRun) {
 		return
 	}
 
@@ -696,71 +1211,125 @@ func (h *Handler) consumeApproval(ctx context.Context, result approvalCheckResul
 		return
 	}
 
-	annotations := result.parent.GetAnnotations()
-	if annotations == nil {
+	consumption := approval.ApprovalConsumption{
+		At:      metav1.Now(),
+		DriftID: detectedDriftID(req, obj, driftResult),
+	}
+	var parent client.Object = result.parent.Parent
+	var patched client.Object
+	var removed int
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if parent == nil {
+			latest := result.parent.Parent.DeepCopy()
+			if err := h.client.Get(ctx, client.ObjectKeyFromObject(latest), latest); err != nil {
+				return err
+			}
+			parent = latest
+		}
+		var err error
+		patched, removed, err = consumedApprovals(parent, result.MatchedApproval, result.parent.Generation(), consumption)
+		if err != nil || patched == nil {
+			return err
+		}
+		if err := h.client.Patch(ctx, patched, client.MergeFromWithOptions(parent, client.MergeFromWithOptimisticLock{})); err != nil {
+			parent = nil
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error(err, "failed to patch parent with consumed approval", "removedCount", removed)
 		return
 	}
-
-	approvalsStr := annotations[approval.ApprovalsAnnotation]
-	if approvalsStr == "" {
+	if patched == nil {
 		return
 	}
 
+	log.Info("consumed approval on parent",
+		"driftID", consumption.DriftID,
+		"removedCount", removed)
+}
+
+// consumedApprovals returns a copy of the parent with the used approval
+// marked as consumed and stale approvals pruned, and the number of pruned
+// approvals. It returns nil if the approvals do not change, e.g. because
+// the approval was consumed concurrently.
+func consumedApprovals(parent client.Object, used *approval.Approval, parentGeneration int64, consumption approval.ApprovalConsumption) (client.Object, int, error) {
+	approvalsStr := parent.GetAnnotations()[approval.ApprovalsAnnotation]
+	if approvalsStr == "" {
+		return nil, 0, nil
+	}
 	approvals, err := approval.ParseApprovals(approvalsStr)
 	if err != nil {
-		log.Error(err, "failed to parse approvals for pruning")
-		return
+		return nil, 0, fmt.Errorf("failed to parse approvals: %w", err)
 	}
 
-	// Prune the consumed approval and any stale ones
 	pruner := approval.NewPruner()
-	pruneResult := pruner.Prune(approvals, result.MatchedApproval, result.parentGeneration)
-
-	if !pruneResult.Changed {
-		return
+	approvals, marked := pruner.MarkConsumed(approvals, used, consumption)
+	pruned := pruner.PruneStale(approvals, parentGeneration)
+	if !marked && len(pruned) == len(approvals) {
+		return nil, 0, nil
 	}
 
-	// Update the parent's annotations
-	newAnnotations := make(map[string]string)
-	for k, v := range annotations {
-		newAnnotations[k] = v
-	}
-
-	if len(pruneResult.Approvals) == 0 {
-		delete(newAnnotations, approval.ApprovalsAnnotation)
+	parentCopy := parent.DeepCopyObject().(client.Object)
+	annotations := parentCopy.GetAnnotations()
+	if len(pruned) == 0 {
+		delete(annotations, approval.ApprovalsAnnotation)
 	} else {
-		newApprovalsStr, err := approval.MarshalApprovals(pruneResult.Approvals)
+		newApprovalsStr, err := approval.MarshalApprovals(pruned)
 		if err != nil {
-			log.Error(err, "failed to marshal pruned approvals")
-			return
+			return nil, 0, fmt.Errorf("failed to marshal approvals: %w", err)
 		}
-		newAnnotations[approval.ApprovalsAnnotation] = newApprovalsStr
+		annotations[approval.ApprovalsAnnotation] = newApprovalsStr
 	}
+	parentCopy.SetAnnotations(annotations)
+	return parentCopy, len(approvals) - len(pruned), nil
+}
 
-	// Update the parent object
-	parentCopy := result.parent.DeepCopyObject().(client.Object)
-	parentCopy.SetAnnotations(newAnnotations)
-
-	if err := h.client.Update(ctx, parentCopy); err != nil {
-		log.Error(err, "failed to update parent with pruned approvals",
-			"removedCount", pruneResult.RemovedCount)
-		return
+// decisionKey returns the key of a request in the decision cache, or false if
+// its decision must not be reused: the cache is disabled, the object is
+// created or deleted, the request carries an override, the child is
+// suppressed, or its controller parent cannot be read.
+func (h *Handler) decisionKey(ctx context.Context, req admission.Request, obj client.Object, userHash string) (decisionKey, bool) {
+	if h.decisions == nil || req.Operation != admissionv1.Update {
+		return decisionKey{}, false
 	}
-
-	log.Info("pruned approvals from parent",
-		"removedCount", pruneResult.RemovedCount,
-		"remaining", len(pruneResult.Approvals))
+	if _, ok := obj.GetAnnotations()[approval.OverrideAnnotation]; ok {
+		return decisionKey{}, false
+	}
+	if _, ok := rawAnnotations(req.OldObject.Raw)[approval.SuppressAnnotation]; ok {
+		return decisionKey{}, false
+	}
+	owner := metav1.GetControllerOf(obj)
+	if owner == nil {
+		return decisionKey{}, false
+	}
+	ref := drift.ParentRefFromOwnerRef(*owner, obj.GetNamespace())
+	parent, err := h.fetchParent(ctx, &ref, obj.GetNamespace())
+	if err != nil || parent.GetUID() != owner.UID {
+		return decisionKey{}, false
+	}
+	key, ok := newDecisionKey(obj.GetUID(), specJSON(req.Object.Raw), userHash, parent)
+	key.fieldManager = extractFieldManager(req)
+	return key, ok
 }
 
-// fetchParent fetches the parent object by reference.
+// fetchParent fetches a copy of the parent object by reference. Parents are
+// read once per admission request.
 func (h *Handler) fetchParent(ctx context.Context, ref *drift.ParentRef, childNamespace string) (client.Object, error) {
-	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	p, err := h.parentContext(ctx, ref, childNamespace)
 	if err != nil {
-		return nil, fmt.Errorf("invalid parent API version: %w", err)
+		return nil, err
 	}
+	return p.Parent.DeepCopy(), nil
+}
 
-	parent := &unstructured.Unstructured{}
-	parent.SetGroupVersionKind(gv.WithKind(ref.Kind))
+// parentKey returns the GVK and key of the parent object by reference.
+func parentKey(ref *drift.ParentRef, childNamespace string) (schema.GroupVersionKind, client.ObjectKey, error) {
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return schema.GroupVersionKind{}, client.ObjectKey{}, fmt.Errorf("invalid parent API version: %w", err)
+	}
 
 	key := client.ObjectKey{
 		Namespace: ref.Namespace,
@@ -770,48 +1339,23 @@ func (h *Handler) fetchParent(ctx context.Context, ref *drift.ParentRef, childNa
 	if key.Namespace == "" && childNamespace != "" {
 		key.Namespace = childNamespace
 	}
-
-	if err := h.client.Get(ctx, key, parent); err != nil {
-		return nil, err
-	}
-
-	return parent, nil
+	return gv.WithKind(ref.Kind), key, nil
 }
 
 // checkFreeze checks if the parent has a freeze annotation.
 // Freeze blocks ALL mutations, not just drift - it's an emergency lockdown.
 // Returns the parsed Freeze struct with user/message/timestamp info.
 func (h *Handler) checkFreeze(ctx context.Context, ref *drift.ParentRef, childNamespace string, log logr.Logger) (frozen bool, freeze *approval.Freeze) {
-	parent, err := h.fetchParent(ctx, ref, childNamespace)
+	parent, err := h.parentContext(ctx, ref, childNamespace)
 	if err != nil {
 		log.V(1).Info("failed to fetch parent for freeze check", "error", err)
 		return false, nil
 	}
-
-	annotations := parent.GetAnnotations()
-	if annotations == nil {
-		return false, nil
-	}
-
-	freezeValue, ok := annotations[approval.FreezeAnnotation]
-	if !ok || freezeValue == "" {
-		return false, nil
-	}
-
-	// Support "false" to explicitly disable
-	if freezeValue == "false" {
-		return false, nil
-	}
-
-	// Parse the structured freeze annotation
-	freeze, err = approval.ParseFreeze(freezeValue)
-	if err != nil {
-		log.V(1).Info("invalid freeze annotation", "value", freezeValue, "error", err)
+	if parent.FreezeErr != nil {
 		// Treat invalid JSON as frozen (fail closed) with no metadata
-		return true, &approval.Freeze{}
+		log.V(1).Info("invalid freeze annotation", "value", parent.Parent.GetAnnotations()[approval.FreezeAnnotation], "error", parent.FreezeErr)
 	}
-
-	return true, freeze
+	return parent.Frozen, parent.Freeze
 }
 
 // extractFieldManager extracts the fieldManager from admission request options.
@@ -836,66 +1380,211 @@ func extractFieldManager(req admission.Request) string {
 	return ""
 }
 
-// sendDriftCallback sends a drift report to the configured webhook endpoint.
+// sendDriftCallback sends a Detected drift report to the configured webhook endpoint.
 // If the parent has an active snooze annotation, the callback is suppressed.
-func (h *Handler) sendDriftCallback(ctx context.Context, req admission.Request, obj client.Object, driftResult *drift.DriftResult, parent client.Object, phase v1alpha1.DriftReportPhase, log logr.Logger) {
+// Returns the report, or nil if none was sent.
+func (h *Handler) sendDriftCallback(ctx context.Context, req admission.Request, obj client.Object, driftResult *drift.DriftResult, parent *ParentContext, override *approval.Override, suppression *approval.Suppression, log logr.Logger) *v1alpha1.DriftReport {
 	if h.callbackSender == nil || !h.callbackSender.IsEnabled() {
-		return
+		return nil
 	}
 
 	// Check for snooze annotation on parent
 	if parent != nil {
-		if snooze := h.isParentSnoozed(parent, log); snooze != nil {
-			log.V(1).Info("drift callback suppressed", "phase", phase, "snooze", snooze.String())
-			return
+		if snooze := activeSnooze(parent, log); snooze != nil {
+			log.V(1).Info("drift callback suppressed", "phase", v1alpha1.DriftReportPhaseDetected, "snooze", snooze.String())
+			return nil
 		}
 	}
 
-	report := h.buildDriftReport(req, obj, driftResult, phase)
+	report := h.buildDriftReport(req, obj, driftResult, parent.object(), v1alpha1.DriftReportPhaseDetected)
 	if report == nil {
-		return
+		return nil
+	}
+	report.Spec.Labels = h.reportLabels(ctx, obj, parent.object(), log)
+	if override != nil {
+		report.Spec.Override = &v1alpha1.Override{Justification: override.Justification, Ticket: override.Ticket}
+	}
+	if suppression != nil {
+		report.Spec.Suppression = &v1alpha1.Suppression{Until: suppression.Until, User: suppression.User, Message: suppression.Message}
 	}
 
 	// Send asynchronously to avoid blocking admission
+	ctx, cancel := h.callbackBudget(ctx)
+	defer cancel()
 	h.callbackSender.SendAsync(ctx, report)
-	log.V(1).Info("drift callback sent", "phase", phase, "id", report.Spec.ID)
+	log.V(1).Info("drift callback sent", "phase", v1alpha1.DriftReportPhaseDetected, "id", report.Spec.ID)
+	return report
 }
 
-// isParentSnoozed checks if the parent has an active snooze annotation.
-// Returns the parsed Snooze struct if active, nil otherwise.
-func (h *Handler) isParentSnoozed(parent client.Object, log logr.Logger) *approval.Snooze {
-	if parent == nil {
-		return nil
+// openResolution watches the child of a sent Detected report for the
+// resolution of its drift.
+func (h *Handler) openResolution(req admission.Request, report *v1alpha1.DriftReport) {
+	if report == nil || h.resolutions == nil {
+		return
 	}
+	var baseline []byte
+	if req.Operation == admissionv1.Update {
+		baseline = specJSON(req.OldObject.Raw)
+	}
+	h.resolutions.Open(report, baseline)
+}
 
-	annotations := parent.GetAnnotations()
-	if annotations == nil {
+// sendResolvedCallback sends a Resolved drift report and closes the child's open drift.
+// A snooze on the parent only suppresses resolutions of drift that was never reported,
+// so receivers are not left with dangling Detected reports.
+func (h *Handler) sendResolvedCallback(ctx context.Context, req admission.Request, obj client.Object, driftResult *drift.DriftResult, parent *ParentContext, kind v1alpha1.ResolutionKind, log logr.Logger) {
+	if h.callbackSender == nil || !h.callbackSender.IsEnabled() {
+		return
+	}
+
+	report := h.buildDriftReport(req, obj, driftResult, parent.object(), v1alpha1.DriftReportPhaseResolved)
+	if report == nil {
+		return
+	}
+	report.Spec.Labels = h.reportLabels(ctx, obj, parent.object(), log)
+	report.Spec.Resolution = &v1alpha1.Resolution{Kind: kind}
+	if h.resolutions != nil {
+		report.Spec.Resolution.DetectedIDs = h.resolutions.Close(report.Spec.Child)
+	}
+	h.archiveResolution(req, report)
+
+	if parent != nil && len(report.Spec.Resolution.DetectedIDs) == 0 {
+		if snooze := activeSnooze(parent, log); snooze != nil {
+			log.V(1).Info("drift callback suppressed", "phase", v1alpha1.DriftReportPhaseResolved, "snooze", snooze.String())
+			return
+		}
+	}
+
+	// Allow the same drift to be reported again if it recurs
+	for _, id := range report.Spec.Resolution.DetectedIDs {
+		h.callbackSender.MarkResolved(id)
+	}
+
+	ctx, cancel := h.callbackBudget(ctx)
+	defer cancel()
+	h.callbackSender.SendAsync(ctx, report)
+	log.V(1).Info("drift callback sent", "phase", v1alpha1.DriftReportPhaseResolved, "id", report.Spec.ID, "resolution", kind)
+}
+
+// checkResolution sends a Resolved report if this mutation, which is not drift,
+// resolves open drift on the child: the child is deleted, the controller
+// reconciles it after the parent changed, or its spec is restored to what it
+// was before the drift.
+func (h *Handler) checkResolution(ctx context.Context, req admission.Request, obj client.Object, driftResult *drift.DriftResult, actor drift.Actor, classifier drift.Classifier, log logr.Logger) {
+	if h.resolutions == nil || h.resolutions.Len() == 0 {
+		return
+	}
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	child := v1alpha1.ObjectReference{APIVersion: gvk.GroupVersion().String(), Kind: gvk.Kind, Namespace: obj.GetNamespace(), Name: obj.GetName()}
+	if !h.resolutions.IsOpen(child) {
+		return
+	}
+
+	// The parent may already be gone when its children are garbage collected
+	if driftResult.ParentRef == nil {
+		ownerRef := metav1.GetControllerOf(obj)
+		if ownerRef == nil || req.Operation != admissionv1.Delete {
+			return
+		}
+		parentRef := drift.ParentRefFromOwnerRef(*ownerRef, obj.GetNamespace())
+		driftResult = &drift.DriftResult{ParentRef: &parentRef}
+	}
+
+	isController := false
+	reconciling := false
+	if state := driftResult.ParentState; state != nil {
+		isController, _ = classifier.IsController(state, actor)
+		reconciling = state.Generation != state.ObservedGeneration || state.Progressing != ""
+	}
+
+	var kind v1alpha1.ResolutionKind
+	switch {
+	case req.Operation == admissionv1.Delete:
+		kind = v1alpha1.ResolutionObjectDeleted
+	case isController && reconciling:
+		kind = v1alpha1.ResolutionControllerCorrected
+	case req.Operation == admissionv1.Update && h.resolutions.Converged(child, specJSON(req.Object.Raw)):
+		kind = v1alpha1.ResolutionManuallyReverted
+		if isController {
+			kind = v1alpha1.ResolutionControllerCorrected
+		}
+	default:
+		return
+	}
+
+	parent, err := h.parentContext(ctx, driftResult.ParentRef, obj.GetNamespace())
+	if err != nil {
+		log.V(1).Info("failed to fetch parent for drift resolution", "error", err)
+		parent = nil
+	}
+	log.Info("DRIFT RESOLVED", "resolution", kind)
+	h.sendResolvedCallback(ctx, req, obj, driftResult, parent, kind, log)
+}
+
+// specJSON returns the JSON encoded spec of a raw object, nil if it has none.
+func specJSON(raw []byte) []byte {
+	obj := &unstructured.Unstructured{}
+	if err := runtime.DecodeInto(unstructured.UnstructuredJSONScheme, raw, obj); err != nil {
 		return nil
 	}
+	spec, found, _ := unstructured.NestedFieldCopy(obj.Object, "spec")
+	if !found {
+		return nil
+	}
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil
+	}
+	return data
+}
 
-	snoozeValue, ok := annotations[approval.SnoozeAnnotation]
-	if !ok || snoozeValue == "" {
+// childAnnotations returns the annotations of the child as persisted before
+// the request: a suppression applies to the live object, not to what a
+// controller writes. Created children have none, their annotations are
+// copied from the parent.
+func childAnnotations(req admission.Request, obj client.Object) map[string]string {
+	switch req.Operation {
+	case admissionv1.Create:
 		return nil
+	case admissionv1.Update:
+		return rawAnnotations(req.OldObject.Raw)
 	}
+	return obj.GetAnnotations()
+}
 
-	// Parse the structured snooze annotation
-	snooze, err := approval.ParseSnooze(snoozeValue)
+// activeSuppression returns the active suppression of a child's annotations,
+// nil if it has none or it expired.
+func activeSuppression(annotations map[string]string, log logr.Logger) *approval.Suppression {
+	value := annotations[approval.SuppressAnnotation]
+	if value == "" {
+		return nil
+	}
+	suppression, err := approval.ParseSuppression(value)
 	if err != nil {
-		log.V(1).Info("invalid snooze annotation", "value", snoozeValue, "error", err)
+		log.V(1).Info("invalid suppress annotation", "value", value, "error", err)
+		return nil
+	}
+	if !suppression.IsActive() {
 		return nil
 	}
+	return suppression
+}
 
-	// Check if snooze is still active
-	if snooze.IsActive() {
+// activeSnooze returns the active snooze of the parent, nil if it has none.
+func activeSnooze(parent *ParentContext, log logr.Logger) *approval.Snooze {
+	if parent.SnoozeErr != nil {
+		log.V(1).Info("invalid snooze annotation", "value", parent.Parent.GetAnnotations()[approval.SnoozeAnnotation], "error", parent.SnoozeErr)
+		return nil
+	}
+	snooze := parent.ActiveSnooze()
+	if snooze != nil {
 		log.V(1).Info("parent is snoozed", "snooze", snooze.String())
-		return snooze
 	}
-
-	return nil
+	return snooze
 }
 
 // buildDriftReport constructs a DriftReport from the admission context.
-func (h *Handler) buildDriftReport(req admission.Request, obj client.Object, driftResult *drift.DriftResult, phase v1alpha1.DriftReportPhase) *v1alpha1.DriftReport {
+func (h *Handler) buildDriftReport(req admission.Request, obj client.Object, driftResult *drift.DriftResult, parent client.Object, phase v1alpha1.DriftReportPhase) *v1alpha1.DriftReport {
 	if driftResult.ParentRef == nil {
 		return nil
 	}
@@ -937,28 +1626,22 @@ func (h *Handler) buildDriftReport(req admission.Request, obj client.Object, dri
 		id = callback.GenerateResolutionID(parentRef, childRef)
 	}
 
-	// Build request context
-	reqCtx := v1alpha1.RequestContext{
-		User:         req.UserInfo.Username,
-		Groups:       req.UserInfo.Groups,
-		UID:          string(req.UID),
-		FieldManager: extractFieldManager(req),
-		Operation:    string(req.Operation),
-		DryRun:       req.DryRun != nil && *req.DryRun,
-	}
-
 	report := &v1alpha1.DriftReport{
 		Spec: v1alpha1.DriftReportSpec{
 			ID:      id,
 			Phase:   phase,
 			Parent:  parentRef,
 			Child:   childRef,
-			Request: reqCtx,
+			Request: requestContext(req),
 		},
 	}
+	report.Spec.ParentSnapshot = snapshotParent(parent, driftResult.ParentState, time.Now())
 
-	// Include objects in report
+	// Include objects in report; for DELETE the new object is the deleted one
 	report.Spec.NewObject = runtime.RawExtension{Raw: req.Object.Raw}
+	if req.Operation == admissionv1.Delete {
+		report.Spec.NewObject = runtime.RawExtension{Raw: req.OldObject.Raw}
+	}
 	if req.Operation == admissionv1.Update && len(req.OldObject.Raw) > 0 {
 		report.Spec.OldObject = &runtime.RawExtension{Raw: req.OldObject.Raw}
 	}
@@ -966,6 +1649,89 @@ func (h *Handler) buildDriftReport(req admission.Request, obj client.Object, dri
 	return report
 }
 
+// requestContext describes the admission request for callbacks.
+func requestContext(req admission.Request) v1alpha1.RequestContext {
+	return v1alpha1.RequestContext{
+		User:         req.UserInfo.Username,
+		Groups:       req.UserInfo.Groups,
+		UID:          string(req.UID),
+		FieldManager: extractFieldManager(req),
+		Operation:    string(req.Operation),
+		DryRun:       req.DryRun != nil && *req.DryRun,
+	}
+}
+
+// mirrorTrace sends the trace written by this request to the trace mirror, if configured.
+// Dry-run requests are not mirrored since nothing is persisted. The record
+// carries the generation the object is persisted with, as its hop does.
+func (h *Handler) mirrorTrace(req admission.Request, obj client.Object, t trace.Trace) {
+	if h.traceMirror == nil || (req.DryRun != nil && *req.DryRun) {
+		return
+	}
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	h.traceMirror.Mirror(&v1alpha1.TraceRecord{
+		Spec: v1alpha1.TraceRecordSpec{
+			Object: v1alpha1.ObjectReference{
+				APIVersion: gvk.GroupVersion().String(),
+				Kind:       gvk.Kind,
+				Namespace:  obj.GetNamespace(),
+				Name:       obj.GetName(),
+				UID:        obj.GetUID(),
+				Generation: admittedGeneration(req, obj),
+			},
+			Trace:     runtime.RawExtension{Raw: []byte(t.String())},
+			Request:   requestContext(req),
+			Timestamp: metav1.Now(),
+			Deletion:  deletionOf(req, obj),
+		},
+	})
+}
+
+// recordDriftStatus counts the detected drift on the parent, if configured.
+// Dry-run requests are not counted since nothing is persisted.
+func (h *Handler) recordDriftStatus(ctx context.Context, req admission.Request, parent *ParentContext) {
+	if h.driftStatus == nil || parent == nil || (req.DryRun != nil && *req.DryRun) {
+		return
+	}
+	h.driftStatus.RecordDrift(ctx, parent.Parent)
+}
+
+// snapshotParent captures the parent's state at decision time. Returns nil if
+// neither the parent object nor its state is available.
+func snapshotParent(parent client.Object, state *drift.ParentState, now time.Time) *v1alpha1.ParentSnapshot {
+	if parent == nil && state == nil {
+		return nil
+	}
+
+	snapshot := &v1alpha1.ParentSnapshot{CapturedAt: metav1.NewTime(now)}
+	if state != nil {
+		snapshot.Generation = state.Generation
+		if state.HasObservedGeneration {
+			snapshot.ObservedGeneration = ptr.To(state.ObservedGeneration)
+		}
+		snapshot.DeletionTimestamp = state.DeletionTimestamp
+		snapshot.Conditions = state.Conditions
+	}
+	if parent != nil {
+		snapshot.UID = parent.GetUID()
+		snapshot.ResourceVersion = parent.GetResourceVersion()
+		if state == nil {
+			snapshot.Generation = parent.GetGeneration()
+			snapshot.DeletionTimestamp = parent.GetDeletionTimestamp()
+		}
+		for k, v := range parent.GetAnnotations() {
+			if !isKausalityAnnotation(k) {
+				continue
+			}
+			if snapshot.Annotations == nil {
+				snapshot.Annotations = make(map[string]string)
+			}
+			snapshot.Annotations[k] = v
+		}
+	}
+	return snapshot
+}
+
 // computeSpecDiff computes a hash-able representation of the spec change.
 func computeSpecDiff(req admission.Request) []byte {
 	if req.Operation != admissionv1.Update {
@@ -998,6 +1764,83 @@ func computeSpecDiff(req admission.Request) []byte {
 	return diffBytes
 }
 
+// objectPolicy is the policy resolved for an object.
+type objectPolicy struct {
+	// mode is the drift detection mode
+	mode string
+	// exclusion names the policy excluding the object from drift evaluation
+	exclusion string
+	// classifier identifies the controller of the object's parent
+	classifier drift.Classifier
+	// missingParent is the action for a missing controller owner, "" for the
+	// webhook's error handling
+	missingParent kausalityv1beta1.MissingParentAction
+	// ownerReferences is the validation of added controller owner
+	// references, "" if they are not validated
+	ownerReferences kausalityv1beta1.OwnerReferenceAction
+	// driftAction is how drift is handled in enforce mode, "" to deny it
+	driftAction kausalityv1beta1.DriftAction
+}
+
+// resolveObjectPolicy determines the drift detection mode, drift exclusion and
+// controller classifier of an object, fetching its namespace metadata for selectors and annotations.
+// Cluster-scoped Crossplane XRs and managed resources inherit the namespace of their Claim.
+// oldLabels are the labels before an update, matched by object selectors too.
+// Failing to read the namespace is an ErrorPolicyUnavailable.
+func (h *Handler) resolveObjectPolicy(ctx context.Context, obj client.Object, oldLabels map[string]string) (objectPolicy, error) {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	policyNamespace := policy.EffectiveNamespace(obj.GetNamespace(), obj.GetLabels())
+
+	// Fetch namespace metadata if needed for selector matching and annotation resolution.
+	// A missing namespace, e.g. a stale claim namespace label, matches no selectors.
+	var nsLabels, nsAnnotations map[string]string
+	if policyNamespace != "" {
+		labels, annotations, err := h.getNamespaceMetadata(ctx, policyNamespace)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return objectPolicy{}, drift.NewError(drift.ErrorPolicyUnavailable, fmt.Errorf("failed to get namespace %q: %w", policyNamespace, err))
+		}
+		nsLabels = labels
+		nsAnnotations = annotations
+	}
+
+	// Precedence: object annotation > namespace annotation > CRD policy > legacy config
+	objAnnotations := obj.GetAnnotations()
+	if objAnnotations == nil {
+		objAnnotations = map[string]string{}
+	}
+	if nsAnnotations == nil {
+		nsAnnotations = map[string]string{}
+	}
+	result := objectPolicy{
+		mode: h.resolveMode(gvk, policyNamespace, obj.GetName(), nsLabels, obj.GetLabels(), oldLabels, objAnnotations, nsAnnotations),
+	}
+	if h.policyResolver != nil {
+		policyCtx := policyContext(gvk, policyNamespace, obj.GetName(), nsLabels, obj.GetLabels(), oldLabels)
+		result.exclusion = h.policyResolver.DriftExclusion(policyCtx, objAnnotations)
+		result.classifier = h.policyResolver.Classifier(policyCtx)
+		result.missingParent = h.policyResolver.MissingParent(policyCtx)
+		result.ownerReferences = h.policyResolver.OwnerReferences(policyCtx)
+		result.driftAction = h.policyResolver.DriftAction(policyCtx)
+	}
+	if result.classifier == nil {
+		result.classifier = drift.HashClassifier{}
+	}
+	return result, nil
+}
+
+// excludeDrift clears detected drift of an object excluded from drift
+// evaluation by its policy, recording the policy in the audit annotations.
+func excludeDrift(result *drift.DriftResult, p objectPolicy, audit map[string]string, log logr.Logger) {
+	if !result.DriftDetected || p.exclusion == "" {
+		return
+	}
+	log.V(1).Info("drift excluded by policy", "policy", p.exclusion, "reason", result.Reason)
+	result.DriftDetected = false
+	result.Reason = fmt.Sprintf("drift not evaluated: excluded by policy %s", p.exclusion)
+	audit[kausalityv1alpha1.AuditKeyDrift] = "false"
+	audit[kausalityv1alpha1.AuditKeyDriftExclusion] = p.exclusion
+}
+
 // getNamespaceMetadata fetches labels and annotations from a namespace.
 func (h *Handler) getNamespaceMetadata(ctx context.Context, namespace string) (labels, annotations map[string]string, err error) {
 	ns := &unstructured.Unstructured{}
@@ -1011,21 +1854,10 @@ func (h *Handler) getNamespaceMetadata(ctx context.Context, namespace string) (l
 
 // resolveMode determines the drift detection mode for a resource.
 // Precedence: object annotation > namespace annotation > CRD policy > legacy config.
-func (h *Handler) resolveMode(gvk schema.GroupVersionKind, namespace string, nsLabels, objLabels, objAnnotations, nsAnnotations map[string]string) string {
+func (h *Handler) resolveMode(gvk schema.GroupVersionKind, namespace, name string, nsLabels, objLabels, oldObjLabels, objAnnotations, nsAnnotations map[string]string) string {
 	// If policy resolver is available, use it
 	if h.policyResolver != nil {
-		// Convert Kind to resource (lowercase plural)
-		resource := kindToResource(gvk.Kind)
-		policyCtx := policy.ResourceContext{
-			GVR: schema.GroupVersionResource{
-				Group:    gvk.Group,
-				Version:  gvk.Version,
-				Resource: resource,
-			},
-			Namespace:       namespace,
-			NamespaceLabels: nsLabels,
-			ObjectLabels:    objLabels,
-		}
+		policyCtx := policyContext(gvk, namespace, name, nsLabels, objLabels, oldObjLabels)
 		mode := h.policyResolver.ResolveMode(policyCtx, objAnnotations, nsAnnotations)
 		return string(mode)
 	}
@@ -1040,6 +1872,23 @@ func (h *Handler) resolveMode(gvk schema.GroupVersionKind, namespace string, nsL
 	return h.config.ResolveModeWithAnnotations(objAnnotations, nsAnnotations, resourceCtx)
 }
 
+// policyContext returns the policy resource context of an object.
+func policyContext(gvk schema.GroupVersionKind, namespace, name string, nsLabels, objLabels, oldObjLabels map[string]string) policy.ResourceContext {
+	return policy.ResourceContext{
+		GVR: schema.GroupVersionResource{
+			Group:   gvk.Group,
+			Version: gvk.Version,
+			// Convert Kind to resource (lowercase plural)
+			Resource: kindToResource(gvk.Kind),
+		},
+		Namespace:       namespace,
+		Name:            name,
+		NamespaceLabels: nsLabels,
+		ObjectLabels:    objLabels,
+		OldObjectLabels: oldObjLabels,
+	}
+}
+
 // kindToResource converts a Kind to the conventional resource name.
 func kindToResource(kind string) string {
 	// Simple lowercase + 's' suffix (works for most resources)
@@ -1081,24 +1930,16 @@ func extractParentStateFromObject(obj client.Object) *drift.ParentState {
 	}
 
 	// Fallback: kausality.io/observedGeneration annotation (synthetic observedGeneration)
-	if !state.HasObservedGeneration {
-		if annotations := obj.GetAnnotations(); annotations != nil {
-			if obsGenStr, ok := annotations[controller.ObservedGenerationAnnotation]; ok {
-				if obsGen, err := strconv.ParseInt(obsGenStr, 10, 64); err == nil {
-					state.ObservedGeneration = obsGen
-					state.HasObservedGeneration = true
-				}
-			}
-		}
+	parent := NewParentContext(unstrObj)
+	if !state.HasObservedGeneration && parent.HasObservedGeneration {
+		state.ObservedGeneration = parent.ObservedGeneration
+		state.HasObservedGeneration = true
 	}
 
 	// Check phase annotation
-	if annotations := obj.GetAnnotations(); annotations != nil {
-		state.PhaseFromAnnotation = annotations[controller.PhaseAnnotation]
-		if state.PhaseFromAnnotation == controller.PhaseValueInitialized {
-			state.IsInitialized = true
-		}
-	}
+	state.PhaseFromAnnotation = parent.Phase
+	state.IsInitialized = parent.Phase == controller.PhaseValueInitialized
+	state.Controllers = parent.Controllers
 
 	return state
 }
@@ -1,15 +1,39 @@
 package admission
 
 import (
+	"context"
 	"encoding/json"
+	"net/http"
+	"strconv"
 	"testing"
+	"time"
 
+	"github.com/go-logr/logr"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	admissionv1 "k8s.io/api/admission/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+	"github.com/kausality-io/kausality/pkg/approval"
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/config"
+	"github.com/kausality-io/kausality/pkg/controller"
+	"github.com/kausality-io/kausality/pkg/drift"
+	"github.com/kausality-io/kausality/pkg/policy"
+	"github.com/kausality-io/kausality/pkg/trace"
 )
 
 func TestHasSpecChanged(t *testing.T) {
@@ -162,6 +186,52 @@ func TestHasSpecChanged(t *testing.T) {
 	}
 }
 
+func TestAdmittedGeneration(t *testing.T) {
+	raw := func(obj map[string]interface{}) runtime.RawExtension {
+		data, err := json.Marshal(obj)
+		require.NoError(t, err)
+		return runtime.RawExtension{Raw: data}
+	}
+	deployment := func(generation int64, finalizers ...interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": "web", "generation": generation, "finalizers": finalizers},
+			"spec":       map[string]interface{}{"replicas": int64(1)},
+		}
+	}
+	configMap := map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]interface{}{"name": "settings"}}
+
+	tests := []struct {
+		name      string
+		operation admissionv1.Operation
+		object    map[string]interface{}
+		oldObject map[string]interface{}
+		want      int64
+	}{
+		{name: "create", operation: admissionv1.Create, object: deployment(0), want: 1},
+		{name: "create without spec", operation: admissionv1.Create, object: configMap, want: 0},
+		{name: "spec update", operation: admissionv1.Update, object: deployment(4), oldObject: deployment(4), want: 5},
+		{name: "update without generation", operation: admissionv1.Update, object: configMap, oldObject: configMap, want: 0},
+		{name: "delete with finalizer", operation: admissionv1.Delete, oldObject: deployment(4, "example.org/cleanup"), want: 5},
+		{name: "delete", operation: admissionv1.Delete, oldObject: deployment(4), want: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{Operation: tt.operation}}
+			if tt.object != nil {
+				req.Object = raw(tt.object)
+			}
+			if tt.oldObject != nil {
+				req.OldObject = raw(tt.oldObject)
+			}
+			obj, err := (&Handler{}).parseObject(req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, admittedGeneration(req, obj))
+		})
+	}
+}
+
 func TestComputeAnnotationsForController(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -403,3 +473,589 @@ func TestComputeAnnotationsForStatusUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestSnapshotParent(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	parent := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+		Name:            "app",
+		UID:             "parent-uid",
+		ResourceVersion: "42",
+		Generation:      5,
+		Annotations: map[string]string{
+			"kausality.io/controllers": "abc12",
+			"kausality.io/phase":       "initialized",
+			"other.io/annotation":      "ignored",
+		},
+	}}
+	state := &drift.ParentState{
+		Generation:            5,
+		ObservedGeneration:    4,
+		HasObservedGeneration: true,
+		Conditions:            []metav1.Condition{{Type: "Ready", Status: metav1.ConditionTrue}},
+	}
+
+	got := snapshotParent(parent, state, now)
+	want := &v1alpha1.ParentSnapshot{
+		CapturedAt:         metav1.NewTime(now),
+		UID:                "parent-uid",
+		ResourceVersion:    "42",
+		Generation:         5,
+		ObservedGeneration: ptr.To[int64](4),
+		Conditions:         []metav1.Condition{{Type: "Ready", Status: metav1.ConditionTrue}},
+		Annotations:        map[string]string{"kausality.io/controllers": "abc12", "kausality.io/phase": "initialized"},
+	}
+	assert.Equal(t, want, got)
+
+	// Without observedGeneration, it is left unset rather than reported as 0
+	state.HasObservedGeneration = false
+	assert.Nil(t, snapshotParent(parent, state, now).ObservedGeneration)
+
+	// Parent fetch failed: state still captured
+	got = snapshotParent(nil, state, now)
+	require.NotNil(t, got)
+	assert.Equal(t, int64(5), got.Generation)
+	assert.Empty(t, got.ResourceVersion)
+
+	assert.Nil(t, snapshotParent(nil, nil, now))
+}
+
+func TestComputeSummary(t *testing.T) {
+	tr := trace.Trace{
+		{Kind: "Deployment", Name: "web", Generation: 7, User: "alice"},
+		{Kind: "ReplicaSet", Name: "web-abc", User: "deployment-controller"},
+	}
+	oldWithSummary := func(summary string) runtime.RawExtension {
+		obj := map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "ReplicaSet",
+			"metadata": map[string]interface{}{
+				"name":        "web-abc",
+				"annotations": map[string]interface{}{trace.SummaryAnnotation: summary},
+			},
+		}
+		raw, err := json.Marshal(obj)
+		require.NoError(t, err)
+		return runtime.RawExtension{Raw: raw}
+	}
+
+	tests := []struct {
+		name  string
+		req   admissionv1.AdmissionRequest
+		drift bool
+		want  string
+	}{
+		{
+			name: "create",
+			req:  admissionv1.AdmissionRequest{Operation: admissionv1.Create},
+			want: "created by Deployment/web gen 7 via user alice",
+		},
+		{
+			name:  "drift increments count",
+			req:   admissionv1.AdmissionRequest{Operation: admissionv1.Update, OldObject: oldWithSummary("updated by user bob, 1 drift incident")},
+			drift: true,
+			want:  "updated by Deployment/web gen 7 via user alice, 2 drift incidents",
+		},
+		{
+			name: "count carried over",
+			req:  admissionv1.AdmissionRequest{Operation: admissionv1.Update, OldObject: oldWithSummary("updated by user bob, 3 drift incidents")},
+			want: "updated by Deployment/web gen 7 via user alice, 3 drift incidents",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := computeSummary(admission.Request{AdmissionRequest: tt.req}, tr, tt.drift)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+type recordingMirror struct {
+	records []*v1alpha1.TraceRecord
+}
+
+func (m *recordingMirror) Mirror(record *v1alpha1.TraceRecord) {
+	m.records = append(m.records, record)
+}
+
+func TestHandle_MirrorsTrace(t *testing.T) {
+	mirror := &recordingMirror{}
+	h := newTestHandler()
+	h.traceMirror = mirror
+	ctx := context.Background()
+
+	obj := buildUnstructured(configMapGVK, "default", "test-cm",
+		map[string]interface{}{"data": "value"}, withUID("cm-uid"))
+	oldObj := buildUnstructured(configMapGVK, "default", "test-cm",
+		map[string]interface{}{"data": "old"}, withUID("cm-uid"))
+
+	resp := h.Handle(ctx, buildAdmissionRequest(admissionv1.Update, obj, oldObj, "admin"))
+	require.True(t, resp.Allowed)
+	require.Len(t, mirror.records, 1)
+
+	record := mirror.records[0].Spec
+	assert.Equal(t, v1alpha1.ObjectReference{APIVersion: "v1", Kind: "ConfigMap", Namespace: "default", Name: "test-cm", UID: "cm-uid"}, record.Object)
+	assert.Equal(t, "UPDATE", record.Request.Operation)
+	assert.Equal(t, "admin", record.Request.User)
+	assert.False(t, record.Timestamp.IsZero())
+
+	recorded, err := trace.Parse(string(record.Trace.Raw))
+	require.NoError(t, err)
+	require.Len(t, recorded, 1)
+	assert.Equal(t, "admin", recorded[0].User)
+
+	// Dry-run requests are not persisted, so not mirrored
+	req := buildAdmissionRequest(admissionv1.Update, obj, oldObj, "admin")
+	req.DryRun = ptr.To(true)
+	require.True(t, h.Handle(ctx, req).Allowed)
+	assert.Len(t, mirror.records, 1)
+}
+
+func TestHandle_SignsTrace(t *testing.T) {
+	mirror := &recordingMirror{}
+	h := newTestHandler()
+	h.traceMirror = mirror
+	h.traceSigner = trace.NewHMACSigner([]byte("cluster-key"))
+
+	obj := buildUnstructured(configMapGVK, "default", "test-cm", map[string]interface{}{"data": "value"})
+	resp := h.Handle(context.Background(), buildAdmissionRequest(admissionv1.Create, obj, nil, "admin"))
+	require.True(t, resp.Allowed)
+	require.Len(t, mirror.records, 1)
+
+	recorded, err := trace.Parse(string(mirror.records[0].Spec.Trace.Raw))
+	require.NoError(t, err)
+	require.Len(t, recorded, 1)
+	object := mirror.records[0].Spec.Object
+	assert.Equal(t, "default", recorded[0].Namespace)
+	assert.True(t, trace.NewHMACVerifier([]byte("cluster-key")).Verify(recorded, trace.Subject{
+		Kind: object.Kind, Namespace: object.Namespace, Name: object.Name, UID: "assigned-later", Generation: object.Generation,
+	}).Valid)
+
+	// The annotation patch carries the signed trace
+	var patched string
+	for _, p := range resp.Patches {
+		if value, ok := p.Value.(map[string]string); ok {
+			patched = value[trace.TraceAnnotation]
+		}
+	}
+	assert.Equal(t, recorded.Annotation(), patched)
+	assert.Equal(t, recorded.String(), resp.AuditAnnotations[auditKeyTrace], "audit carries the hops")
+	assert.Equal(t, "CREATE", recorded[0].Operation)
+	assert.Equal(t, trace.ActorKindUser, recorded[0].ActorKind)
+}
+
+func TestHandle_CrossplaneInheritsClaimNamespaceMode(t *testing.T) {
+	ns := buildUnstructured(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, "", "team-a", nil,
+		withAnnotations(map[string]string{"kausality.io/mode": "enforce"}))
+	h := newTestHandler(ns)
+
+	xrGVK := schema.GroupVersionKind{Group: "example.org", Version: "v1alpha1", Kind: "XDatabase"}
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   string
+	}{
+		{
+			name:   "XR bound to claim",
+			labels: map[string]string{policy.ClaimNamespaceLabel: "team-a", policy.ClaimNameLabel: "db"},
+			want:   "enforce",
+		},
+		{
+			name: "XR without claim",
+			want: "log",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			xr := buildUnstructured(xrGVK, "", "db-x7k2p", map[string]interface{}{"size": "small"})
+			xr.SetLabels(tt.labels)
+
+			resp := h.Handle(context.Background(), buildAdmissionRequest(admissionv1.Create, xr, nil, "admin"))
+			require.True(t, resp.Allowed)
+			assert.Equal(t, tt.want, resp.AuditAnnotations[auditKeyMode])
+		})
+	}
+}
+
+type recordingStatus struct {
+	parents []string
+}
+
+func (s *recordingStatus) RecordDrift(_ context.Context, parent client.Object) {
+	s.parents = append(s.parents, parent.GetName())
+}
+
+func TestHandle_RecordsDriftStatus(t *testing.T) {
+	h, _ := newResolutionTestHandler(1, 1)
+	status := &recordingStatus{}
+	h.driftStatus = status
+	ctx := context.Background()
+	ctrlHash := controller.HashUsername(deploymentController)
+
+	// Controller changes the child while the parent is stable: drift
+	resp := h.Handle(ctx, buildAdmissionRequest(admissionv1.Update, childRS(3, ""), childRS(1, ctrlHash), deploymentController))
+	require.True(t, resp.Allowed)
+	assert.Equal(t, []string{"app"}, status.parents)
+
+	// Dry-run requests are not persisted, so not counted
+	req := buildAdmissionRequest(admissionv1.Update, childRS(4, ""), childRS(3, ctrlHash), deploymentController)
+	req.DryRun = ptr.To(true)
+	require.True(t, h.Handle(ctx, req).Allowed)
+	assert.Len(t, status.parents, 1)
+
+	// Changes by others are not drift
+	resp = h.Handle(ctx, buildAdmissionRequest(admissionv1.Update, childRS(5, ""), childRS(3, ctrlHash), "admin"))
+	require.True(t, resp.Allowed)
+	assert.Len(t, status.parents, 1)
+}
+
+func TestHandle_ErrorClasses(t *testing.T) {
+	ctx := context.Background()
+	orphan := buildUnstructured(replicaSetGVK, "default", "web-abc", map[string]interface{}{"replicas": int64(2)},
+		withOwnerRef(deploymentGVK, "web", "web-uid"))
+	oldOrphan := buildUnstructured(replicaSetGVK, "default", "web-abc", map[string]interface{}{"replicas": int64(1)},
+		withOwnerRef(deploymentGVK, "web", "web-uid"))
+
+	t.Run("parent not found is allowed with warning", func(t *testing.T) {
+		h := newTestHandler()
+		resp := h.Handle(ctx, buildAdmissionRequest(admissionv1.Update, orphan, oldOrphan, "admin"))
+		assert.True(t, resp.Allowed)
+		require.Len(t, resp.Warnings, 1)
+		assert.Contains(t, resp.Warnings[0], "drift detection skipped: ParentNotFound")
+		assert.Equal(t, string(drift.ErrorParentNotFound), resp.AuditAnnotations[auditKeyError])
+	})
+
+	t.Run("configured action", func(t *testing.T) {
+		h := newTestHandler()
+		h.config = &config.Config{ErrorHandling: config.ErrorHandlingConfig{ParentNotFound: config.ErrorActionDeny}}
+		resp := h.Handle(ctx, buildAdmissionRequest(admissionv1.Update, orphan, oldOrphan, "admin"))
+		assert.False(t, resp.Allowed)
+		assert.Equal(t, "denied", resp.AuditAnnotations[auditKeyDecision])
+	})
+
+	t.Run("internal error is retryable", func(t *testing.T) {
+		c := fake.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				return apierrors.NewServiceUnavailable("etcd down")
+			},
+		}).Build()
+		h := NewHandler(Config{Client: c, Log: logr.Discard()})
+		resp := h.Handle(ctx, buildAdmissionRequest(admissionv1.Update, orphan, oldOrphan, "admin"))
+		assert.False(t, resp.Allowed)
+		assert.Equal(t, int32(http.StatusInternalServerError), resp.Result.Code)
+		require.NotNil(t, resp.Result.Details)
+		assert.Positive(t, resp.Result.Details.RetryAfterSeconds)
+		assert.Equal(t, string(drift.ErrorInternal), resp.AuditAnnotations[auditKeyError])
+	})
+
+	t.Run("undecodable object is denied", func(t *testing.T) {
+		h := newTestHandler()
+		req := buildAdmissionRequest(admissionv1.Create, orphan, nil, "admin")
+		req.Object.Raw = []byte(`{"kind":`)
+		resp := h.Handle(ctx, req)
+		assert.False(t, resp.Allowed)
+		assert.Equal(t, string(drift.ErrorDecode), resp.AuditAnnotations[auditKeyError])
+	})
+}
+
+func TestHandle_DriftExclusion(t *testing.T) {
+	store := policy.NewStore(nil, logr.Discard())
+	store.Update([]kausalityv1beta1.Kausality{{
+		ObjectMeta: metav1.ObjectMeta{Name: "apps"},
+		Spec: kausalityv1beta1.KausalitySpec{
+			Resources: []kausalityv1beta1.ResourceRule{{APIGroups: []string{"apps"}, Resources: []string{"replicasets"}}},
+			Mode:      kausalityv1beta1.ModeEnforce,
+			DriftExclusions: []kausalityv1beta1.DriftExclusion{{
+				LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"canary.example.com/managed": "true"}},
+			}},
+		},
+	}})
+	ctrlHash := controller.HashUsername(deploymentController)
+
+	tests := []struct {
+		name        string
+		labels      map[string]string
+		wantAllowed bool
+	}{
+		{name: "excluded", labels: map[string]string{"canary.example.com/managed": "true"}, wantAllowed: true},
+		{name: "evaluated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, sender := newResolutionTestHandler(1, 1)
+			h.policyResolver = store
+
+			child, oldChild := childRS(3, ""), childRS(1, ctrlHash)
+			child.SetLabels(tt.labels)
+			oldChild.SetLabels(tt.labels)
+			resp := h.Handle(context.Background(), buildAdmissionRequest(admissionv1.Update, child, oldChild, deploymentController))
+
+			assert.Equal(t, tt.wantAllowed, resp.Allowed)
+			if !tt.wantAllowed {
+				assert.Equal(t, "true", resp.AuditAnnotations[auditKeyDrift])
+				return
+			}
+			assert.Equal(t, "false", resp.AuditAnnotations[auditKeyDrift])
+			assert.Equal(t, "apps", resp.AuditAnnotations[auditKeyDriftExclusion])
+			assert.Nil(t, sender.last(), "excluded objects are not reported")
+			assert.NotEmpty(t, resp.Patches, "excluded objects are traced")
+		})
+	}
+}
+
+func TestHandle_FieldPaths(t *testing.T) {
+	ctrlHash := controller.HashUsername(deploymentController)
+
+	tests := []struct {
+		name       string
+		fieldPaths []string
+		wantDrift  bool
+	}{
+		{name: "whole spec", wantDrift: true},
+		{name: "changed path", fieldPaths: []string{"spec.template.spec.containers[*].image", "spec.replicas"}, wantDrift: true},
+		{name: "other paths", fieldPaths: []string{"spec.template.spec.containers[*].image"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := policy.NewStore(nil, logr.Discard())
+			store.Update([]kausalityv1beta1.Kausality{{
+				ObjectMeta: metav1.ObjectMeta{Name: "apps"},
+				Spec: kausalityv1beta1.KausalitySpec{
+					Resources: []kausalityv1beta1.ResourceRule{{APIGroups: []string{"apps"}, Resources: []string{"replicasets"}, FieldPaths: tt.fieldPaths}},
+					Mode:      kausalityv1beta1.ModeEnforce,
+				},
+			}})
+			h, sender := newResolutionTestHandler(1, 1)
+			h.policyResolver = store
+
+			resp := h.Handle(context.Background(), buildAdmissionRequest(admissionv1.Update, childRS(3, ""), childRS(1, ctrlHash), deploymentController))
+			if tt.wantDrift {
+				assert.False(t, resp.Allowed)
+				assert.Equal(t, "true", resp.AuditAnnotations[auditKeyDrift])
+				return
+			}
+			assert.True(t, resp.Allowed)
+			assert.Empty(t, resp.AuditAnnotations[auditKeyDrift], "drift is not evaluated")
+			assert.Nil(t, sender.last())
+		})
+	}
+}
+
+func TestHandle_ControllerIdentity(t *testing.T) {
+	ctrlHash := controller.HashUsername(deploymentController)
+	const operator = "system:serviceaccount:ops:operator"
+
+	tests := []struct {
+		name      string
+		identity  *kausalityv1beta1.ControllerIdentity
+		user      string
+		wantDrift bool
+	}{
+		{name: "default", user: deploymentController, wantDrift: true},
+		{name: "default, other actor", user: operator},
+		{
+			name:     "service account, other controller",
+			identity: &kausalityv1beta1.ControllerIdentity{Classifiers: []kausalityv1beta1.ClassifierType{kausalityv1beta1.ClassifierServiceAccount}, ServiceAccounts: []string{"system:serviceaccount:ops:*"}},
+			user:     deploymentController,
+		},
+		{
+			name:      "service account, matching controller",
+			identity:  &kausalityv1beta1.ControllerIdentity{Classifiers: []kausalityv1beta1.ClassifierType{kausalityv1beta1.ClassifierServiceAccount}, ServiceAccounts: []string{"system:serviceaccount:ops:*"}},
+			user:      operator,
+			wantDrift: true,
+		},
+		{
+			name: "any classifier",
+			identity: &kausalityv1beta1.ControllerIdentity{
+				Classifiers:     []kausalityv1beta1.ClassifierType{kausalityv1beta1.ClassifierServiceAccount, kausalityv1beta1.ClassifierUpdaterHash},
+				Combination:     kausalityv1beta1.ClassifierCombinationAny,
+				ServiceAccounts: []string{"system:serviceaccount:ops:*"},
+			},
+			user:      deploymentController,
+			wantDrift: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := policy.NewStore(nil, logr.Discard())
+			store.Update([]kausalityv1beta1.Kausality{{
+				ObjectMeta: metav1.ObjectMeta{Name: "apps"},
+				Spec: kausalityv1beta1.KausalitySpec{
+					Resources:          []kausalityv1beta1.ResourceRule{{APIGroups: []string{"apps"}, Resources: []string{"replicasets"}}},
+					Mode:               kausalityv1beta1.ModeEnforce,
+					ControllerIdentity: tt.identity,
+				},
+			}})
+			h, _ := newResolutionTestHandler(1, 1)
+			h.policyResolver = store
+
+			resp := h.Handle(context.Background(), buildAdmissionRequest(admissionv1.Update, childRS(3, ""), childRS(1, ctrlHash), tt.user))
+			assert.Equal(t, strconv.FormatBool(tt.wantDrift), resp.AuditAnnotations[auditKeyDrift])
+			assert.Equal(t, !tt.wantDrift, resp.Allowed)
+		})
+	}
+}
+
+func TestHandle_RecreatedChildContinuesTrace(t *testing.T) {
+	parent := buildUnstructured(deploymentGVK, "default", "app", map[string]interface{}{"replicas": int64(1)},
+		withUID("app-uid"), withGeneration(1), withStatus(map[string]interface{}{"observedGeneration": int64(1)}))
+	cfg := config.Default()
+	cfg.Recreation = &config.RecreationConfig{}
+	h := NewHandler(Config{
+		Client:      fake.NewClientBuilder().WithScheme(runtime.NewScheme()).WithRuntimeObjects(parent).Build(),
+		Log:         logr.Discard(),
+		DriftConfig: cfg,
+	})
+	ctx := context.Background()
+
+	origin := trace.Trace{trace.NewHop("apps/v1", "Deployment", "app", 1, "alice", "req-1"), trace.NewHop("apps/v1", "ReplicaSet", "app-abc", 1, deploymentController, "req-2")}
+	deleted := childRS(1, "")
+	deleted.SetAnnotations(map[string]string{trace.TraceAnnotation: origin.String()})
+	require.True(t, h.Handle(ctx, buildAdmissionRequest(admissionv1.Delete, deleted, deleted, deploymentController)).Allowed)
+
+	created := childRS(1, "")
+	created.SetUID("")
+	resp := h.Handle(ctx, buildAdmissionRequest(admissionv1.Create, created, nil, deploymentController))
+	require.True(t, resp.Allowed)
+
+	recorded, err := trace.Parse(resp.AuditAnnotations[auditKeyTrace])
+	require.NoError(t, err)
+	require.Len(t, recorded, 2)
+	assert.Equal(t, "alice", recorded.Origin().User)
+	require.NotNil(t, recorded[1].Predecessor)
+	assert.Equal(t, types.UID("rs-uid"), recorded[1].Predecessor.UID)
+}
+
+func TestHandle_SuppressedChild(t *testing.T) {
+	store := policy.NewStore(nil, logr.Discard())
+	store.Update([]kausalityv1beta1.Kausality{{
+		ObjectMeta: metav1.ObjectMeta{Name: "apps"},
+		Spec: kausalityv1beta1.KausalitySpec{
+			Resources: []kausalityv1beta1.ResourceRule{{APIGroups: []string{"apps"}, Resources: []string{"replicasets"}}},
+			Mode:      kausalityv1beta1.ModeEnforce,
+		},
+	}})
+	ctrlHash := controller.HashUsername(deploymentController)
+
+	tests := []struct {
+		name        string
+		until       time.Time
+		wantAllowed bool
+	}{
+		{name: "active", until: time.Now().Add(time.Hour), wantAllowed: true},
+		{name: "expired", until: time.Now().Add(-time.Hour)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, sender := newResolutionTestHandler(1, 1)
+			h.policyResolver = store
+
+			suppression, err := approval.MarshalSuppression(&approval.Suppression{Until: metav1.NewTime(tt.until), User: "ops@example.com", Message: "storage migration"})
+			require.NoError(t, err)
+			child, oldChild := childRS(3, ""), childRS(1, ctrlHash)
+			oldChild.SetAnnotations(map[string]string{approval.SuppressAnnotation: suppression})
+			resp := h.Handle(context.Background(), buildAdmissionRequest(admissionv1.Update, child, oldChild, deploymentController))
+
+			assert.Equal(t, tt.wantAllowed, resp.Allowed)
+			assert.Equal(t, "true", resp.AuditAnnotations[auditKeyDrift])
+			if !tt.wantAllowed {
+				assert.Equal(t, "unresolved", resp.AuditAnnotations[auditKeyDriftResolution])
+				return
+			}
+			assert.Equal(t, "suppressed", resp.AuditAnnotations[auditKeyDriftResolution])
+			report := sender.last()
+			require.NotNil(t, report, "suppressed drift is still reported")
+			require.NotNil(t, report.Spec.Suppression)
+			assert.Equal(t, "ops@example.com", report.Spec.Suppression.User)
+			assert.Equal(t, "storage migration", report.Spec.Suppression.Message)
+		})
+	}
+}
+
+func TestHandle_ConsumesOnceApproval(t *testing.T) {
+	parent := buildUnstructured(deploymentGVK, "default", "app", map[string]interface{}{"replicas": int64(1)},
+		withUID("app-uid"),
+		withGeneration(1),
+		withAnnotations(map[string]string{
+			controller.PhaseAnnotation:       controller.PhaseValueInitialized,
+			controller.ControllersAnnotation: controller.HashUsername(deploymentController),
+			approval.ApprovalsAnnotation:     `[{"apiVersion":"apps/v1","kind":"ReplicaSet","name":"app-abc","generation":1,"mode":"once"}]`,
+		}),
+		withStatus(map[string]interface{}{"observedGeneration": int64(1)}),
+	)
+	c := fake.NewClientBuilder().WithScheme(runtime.NewScheme()).WithRuntimeObjects(parent).Build()
+	h := NewHandler(Config{Client: c, Log: logr.Discard()})
+	ctx := context.Background()
+	ctrlHash := controller.HashUsername(deploymentController)
+
+	resp := h.Handle(ctx, buildAdmissionRequest(admissionv1.Update, childRS(3, ""), childRS(1, ctrlHash), deploymentController))
+	require.True(t, resp.Allowed)
+	assert.Equal(t, "approved", resp.AuditAnnotations[auditKeyDriftResolution])
+
+	got := &unstructured.Unstructured{}
+	got.SetGroupVersionKind(deploymentGVK)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "app"}, got))
+	approvals, err := approval.ParseApprovals(got.GetAnnotations()[approval.ApprovalsAnnotation])
+	require.NoError(t, err)
+	require.Len(t, approvals, 1, "consumed approvals are kept until stale")
+	require.NotNil(t, approvals[0].Consumed)
+	assert.Len(t, approvals[0].Consumed.DriftID, 16)
+	assert.False(t, approvals[0].Consumed.At.IsZero())
+
+	// The same mutation again is not approved
+	resp = h.Handle(ctx, buildAdmissionRequest(admissionv1.Update, childRS(3, ""), childRS(1, ctrlHash), deploymentController))
+	require.True(t, resp.Allowed)
+	assert.Equal(t, "unresolved", resp.AuditAnnotations[auditKeyDriftResolution])
+}
+
+func TestHandle_ConsumesOnceApprovalOnConflict(t *testing.T) {
+	const onceApproval = `{"apiVersion":"apps/v1","kind":"ReplicaSet","name":"app-abc","generation":1,"mode":"once"}`
+	const concurrentApproval = `{"apiVersion":"v1","kind":"ConfigMap","name":"config","mode":"always"}`
+	parent := buildUnstructured(deploymentGVK, "default", "app", map[string]interface{}{"replicas": int64(1)},
+		withUID("app-uid"),
+		withGeneration(1),
+		withAnnotations(map[string]string{
+			controller.PhaseAnnotation:       controller.PhaseValueInitialized,
+			controller.ControllersAnnotation: controller.HashUsername(deploymentController),
+			approval.ApprovalsAnnotation:     "[" + onceApproval + "]",
+		}),
+		withStatus(map[string]interface{}{"observedGeneration": int64(1)}),
+	)
+	patches := 0
+	c := fake.NewClientBuilder().WithScheme(runtime.NewScheme()).WithRuntimeObjects(parent).WithInterceptorFuncs(interceptor.Funcs{
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			patches++
+			if patches == 1 {
+				// Someone adds an approval before the consumption is written
+				latest := &unstructured.Unstructured{}
+				latest.SetGroupVersionKind(deploymentGVK)
+				if err := c.Get(ctx, client.ObjectKeyFromObject(obj), latest); err != nil {
+					return err
+				}
+				annotations := latest.GetAnnotations()
+				annotations[approval.ApprovalsAnnotation] = "[" + onceApproval + "," + concurrentApproval + "]"
+				latest.SetAnnotations(annotations)
+				if err := c.Update(ctx, latest); err != nil {
+					return err
+				}
+			}
+			return c.Patch(ctx, obj, patch, opts...)
+		},
+	}).Build()
+	h := NewHandler(Config{Client: c, Log: logr.Discard()})
+	ctx := context.Background()
+	ctrlHash := controller.HashUsername(deploymentController)
+
+	resp := h.Handle(ctx, buildAdmissionRequest(admissionv1.Update, childRS(3, ""), childRS(1, ctrlHash), deploymentController))
+	require.True(t, resp.Allowed)
+	assert.Equal(t, "approved", resp.AuditAnnotations[auditKeyDriftResolution])
+
+	got := &unstructured.Unstructured{}
+	got.SetGroupVersionKind(deploymentGVK)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "app"}, got))
+	approvals, err := approval.ParseApprovals(got.GetAnnotations()[approval.ApprovalsAnnotation])
+	require.NoError(t, err)
+	require.Len(t, approvals, 2, "the concurrent approval is kept")
+	assert.NotNil(t, approvals[0].Consumed)
+	assert.Nil(t, approvals[1].Consumed)
+}
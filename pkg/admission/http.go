@@ -0,0 +1,136 @@
+package admission
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
+
+	"github.com/kausality-io/kausality/pkg/policy"
+)
+
+const (
+	// MutatePath is the path of the admission webhook.
+	MutatePath = "/mutate"
+	// HealthzPath is the path of the liveness check.
+	HealthzPath = "/healthz"
+	// ReadyzPath is the path of the readiness check.
+	ReadyzPath = "/readyz"
+)
+
+// HTTPConfig configures an HTTPHandler.
+type HTTPConfig struct {
+	Config
+	// CertFile and KeyFile are the serving certificate and key of the
+	// server mounting the handler. If set, they are reloaded on change and
+	// served by TLSConfig, and the handler is not ready once the
+	// certificate expired. If empty, the server manages its own TLS.
+	CertFile string
+	KeyFile  string
+	// WhatIf serves what-if queries at WhatIfPath.
+	WhatIf bool
+}
+
+// HTTPHandler serves the admission webhook, the Kausality conversion webhook
+// and health checks on its own mux, so that kausality can be mounted into an
+// existing webhook server instead of running as a separate Deployment:
+//
+//	h, err := admission.NewHTTPHandler(cfg)
+//	mux.Handle("/kausality/", http.StripPrefix("/kausality", h))
+//
+// The MutatingWebhookConfiguration then points at /kausality/mutate.
+type HTTPHandler struct {
+	mux         *http.ServeMux
+	certWatcher *certwatcher.CertWatcher
+}
+
+// NewHTTPHandler creates an HTTPHandler. It fails if the certificate cannot
+// be loaded.
+func NewHTTPHandler(cfg HTTPConfig) (*HTTPHandler, error) {
+	if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+		return nil, errors.New("both or neither of CertFile and KeyFile must be set")
+	}
+	h := &HTTPHandler{mux: http.NewServeMux()}
+	if cfg.CertFile != "" {
+		watcher, err := certwatcher.New(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load certificate: %w", err)
+		}
+		h.certWatcher = watcher
+	}
+
+	handler := NewHandler(cfg.Config)
+	h.mux.Handle(MutatePath, &webhook.Admission{Handler: handler})
+	h.mux.Handle(ProtectionPath, &webhook.Admission{Handler: handler.ProtectionHandler()})
+	if cfg.WhatIf {
+		h.mux.Handle(WhatIfPath, handler.WhatIfHandler())
+	}
+	// Converts Kausality policies between API versions through their
+	// conversion.Hub, which needs both versions in the client's scheme
+	h.mux.Handle(policy.ConversionPath, conversion.NewWebhookHandler(cfg.Client.Scheme(), conversion.NewRegistry()))
+
+	h.handleChecks(HealthzPath, map[string]healthz.Checker{"ping": healthz.Ping})
+	readyz := map[string]healthz.Checker{"ping": healthz.Ping}
+	if h.certWatcher != nil {
+		readyz["certificate"] = h.checkCertificate
+	}
+	h.handleChecks(ReadyzPath, readyz)
+	return h, nil
+}
+
+// handleChecks serves the aggregated checks at path, and each check at a
+// subpath named after it.
+func (h *HTTPHandler) handleChecks(path string, checks map[string]healthz.Checker) {
+	handler := http.StripPrefix(path, &healthz.Handler{Checks: checks})
+	h.mux.Handle(path, handler)
+	h.mux.Handle(path+"/", handler)
+}
+
+// ServeHTTP implements http.Handler.
+func (h *HTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// Start reloads the certificate on change until ctx is done. Without
+// certificate, it returns immediately.
+func (h *HTTPHandler) Start(ctx context.Context) error {
+	if h.certWatcher == nil {
+		return nil
+	}
+	return h.certWatcher.Start(ctx)
+}
+
+// TLSConfig returns a TLS configuration serving the current certificate, for
+// the server mounting the handler. It is nil without certificate.
+func (h *HTTPHandler) TLSConfig() *tls.Config {
+	if h.certWatcher == nil {
+		return nil
+	}
+	return &tls.Config{
+		GetCertificate: h.certWatcher.GetCertificate,
+		MinVersion:     tls.VersionTLS12,
+	}
+}
+
+// checkCertificate fails once the current certificate expired, e.g. because
+// its rotation is not picked up.
+func (h *HTTPHandler) checkCertificate(_ *http.Request) error {
+	cert, err := h.certWatcher.GetCertificate(nil)
+	if err != nil {
+		return err
+	}
+	if cert == nil || cert.Leaf == nil {
+		return errors.New("no certificate loaded")
+	}
+	if time.Now().After(cert.Leaf.NotAfter) {
+		return fmt.Errorf("certificate expired at %s", cert.Leaf.NotAfter.Format(time.RFC3339))
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package admission
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kausality-io/kausality/pkg/certs"
+	"github.com/kausality-io/kausality/pkg/policy"
+)
+
+func writeCert(t *testing.T, dir string, validity time.Duration) {
+	t.Helper()
+	ca, err := certs.GenerateCA("test-ca", time.Hour, time.Now())
+	require.NoError(t, err)
+	pair, err := certs.GenerateServingCert(ca, []string{"webhook.example.com"}, validity, time.Now())
+	require.NoError(t, err)
+	// The certificate is reloaded when it changes, so write it last
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tls.key"), pair.Key, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tls.crt"), pair.Cert, 0o600))
+}
+
+func newTestHTTPConfig() HTTPConfig {
+	return HTTPConfig{Config: Config{
+		Client: fake.NewClientBuilder().WithScheme(runtime.NewScheme()).Build(),
+		Log:    logr.Discard(),
+	}}
+}
+
+func TestHTTPHandler_Mounted(t *testing.T) {
+	h, err := NewHTTPHandler(newTestHTTPConfig())
+	require.NoError(t, err)
+	assert.Nil(t, h.TLSConfig())
+
+	mux := http.NewServeMux()
+	mux.Handle("/kausality/", http.StripPrefix("/kausality", h))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	obj := buildUnstructured(configMapGVK, "default", "test-cm", map[string]interface{}{"data": "value"})
+	req := buildAdmissionRequest(admissionv1.Create, obj, nil, "admin")
+	review := admissionv1.AdmissionReview{Request: &req.AdmissionRequest}
+	review.SetGroupVersionKind(admissionv1.SchemeGroupVersion.WithKind("AdmissionReview"))
+	body, err := json.Marshal(review)
+	require.NoError(t, err)
+
+	resp, err := http.Post(server.URL+"/kausality"+MutatePath, "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var got admissionv1.AdmissionReview
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	require.NotNil(t, got.Response)
+	assert.True(t, got.Response.Allowed)
+	assert.Equal(t, req.UID, got.Response.UID)
+
+	for _, path := range []string{HealthzPath, ReadyzPath} {
+		resp, err := http.Get(server.URL + "/kausality" + path)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode, path)
+	}
+
+	// The conversion webhook is served, and rejects malformed reviews
+	resp, err = http.Post(server.URL+"/kausality"+policy.ConversionPath, "application/json", bytes.NewReader([]byte("{")))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestHTTPHandler_Certificate(t *testing.T) {
+	dir := t.TempDir()
+	cfg := newTestHTTPConfig()
+	cfg.CertFile = filepath.Join(dir, "tls.crt")
+	cfg.KeyFile = filepath.Join(dir, "tls.key")
+
+	_, err := NewHTTPHandler(cfg)
+	require.Error(t, err, "missing certificate")
+	_, err = NewHTTPHandler(HTTPConfig{Config: cfg.Config, CertFile: cfg.CertFile})
+	require.Error(t, err, "missing key")
+
+	writeCert(t, dir, -time.Minute)
+	h, err := NewHTTPHandler(cfg)
+	require.NoError(t, err)
+	require.NotNil(t, h.TLSConfig())
+
+	ready := func() int {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, ReadyzPath, nil))
+		return rec.Code
+	}
+	assert.Equal(t, http.StatusInternalServerError, ready(), "expired certificate")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = h.Start(ctx) }()
+	// Let the watcher start, otherwise the rotation is only picked up by polling
+	time.Sleep(200 * time.Millisecond)
+
+	// A rotated certificate is picked up
+	writeCert(t, dir, time.Hour)
+	require.Eventually(t, func() bool { return ready() == http.StatusOK }, 15*time.Second, 100*time.Millisecond)
+	cert, err := h.TLSConfig().GetCertificate(nil)
+	require.NoError(t, err)
+	assert.True(t, cert.Leaf.NotAfter.After(time.Now()))
+}
@@ -0,0 +1,115 @@
+package admission
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kausality-io/kausality/pkg/config"
+	"github.com/kausality-io/kausality/pkg/drift"
+)
+
+// DefaultLifecycleCheckInterval is how often a LifecycleWatcher checks the
+// config file.
+const DefaultLifecycleCheckInterval = 10 * time.Second
+
+// LifecycleMappings converts the configured lifecycle mappings.
+func LifecycleMappings(cfg *config.Config) []drift.LifecycleMapping {
+	signals := func(configs []config.LifecycleSignalConfig) []drift.LifecycleSignal {
+		var result []drift.LifecycleSignal
+		for _, s := range configs {
+			result = append(result, drift.LifecycleSignal{
+				Condition:  s.Condition,
+				Status:     metav1.ConditionStatus(s.Status),
+				Reasons:    s.Reasons,
+				Path:       s.Path,
+				Values:     s.Values,
+				EqualsPath: s.EqualsPath,
+			})
+		}
+		return result
+	}
+	var result []drift.LifecycleMapping
+	for _, l := range cfg.Lifecycle {
+		result = append(result, drift.LifecycleMapping{
+			Group:       l.Group,
+			Kind:        l.Kind,
+			Ready:       signals(l.Ready),
+			Reconciling: signals(l.Reconciling),
+			Deleting:    signals(l.Deleting),
+		})
+	}
+	return result
+}
+
+// LifecycleWatcher keeps a LifecycleRegistry in sync with the lifecycle
+// mappings of a config file. It polls the file, so it follows ConfigMap
+// updates swapping the mounted file. It implements manager.Runnable and runs
+// on every replica.
+type LifecycleWatcher struct {
+	path     string
+	registry *drift.LifecycleRegistry
+	log      logr.Logger
+	interval time.Duration
+	// data is the content of the file last loaded into the registry
+	data []byte
+}
+
+// NewLifecycleWatcher creates a LifecycleWatcher checking the file every
+// DefaultLifecycleCheckInterval.
+func NewLifecycleWatcher(path string, registry *drift.LifecycleRegistry, log logr.Logger) *LifecycleWatcher {
+	return &LifecycleWatcher{
+		path:     path,
+		registry: registry,
+		log:      log.WithName("lifecycle-watcher"),
+		interval: DefaultLifecycleCheckInterval,
+	}
+}
+
+// NeedLeaderElection returns false: every replica detects lifecycle phases.
+func (w *LifecycleWatcher) NeedLeaderElection() bool {
+	return false
+}
+
+// Start reloads the mappings whenever the file changes, until the context
+// is cancelled. An invalid file is logged and the previous mappings are kept.
+func (w *LifecycleWatcher) Start(ctx context.Context) error {
+	for {
+		if _, err := w.Reload(); err != nil {
+			w.log.Error(err, "failed to reload lifecycle mappings, keeping previous mappings", "path", w.path)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(w.interval):
+		}
+	}
+}
+
+// Reload loads the mappings of the file into the registry if the file
+// changed since the last successful reload, and returns whether it did.
+func (w *LifecycleWatcher) Reload() (bool, error) {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if w.data != nil && bytes.Equal(data, w.data) {
+		return false, nil
+	}
+
+	cfg, err := config.Parse(data)
+	if err != nil {
+		return false, err
+	}
+	w.registry.Update(LifecycleMappings(cfg))
+	w.data = data
+	w.log.Info("loaded lifecycle mappings", "path", w.path, "mappings", len(cfg.Lifecycle))
+	return true, nil
+}
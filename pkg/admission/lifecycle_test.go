@@ -0,0 +1,63 @@
+package admission
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/kausality-io/kausality/pkg/drift"
+)
+
+func TestLifecycleWatcher_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(content string) {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+	widgets := schema.GroupKind{Group: "example.org", Kind: "Widget"}
+
+	write(`
+lifecycle:
+  - group: example.org
+    kind: Widget
+    ready:
+      - condition: Synced
+    deleting:
+      - path: .status.phase
+        values: [Terminating]
+`)
+	registry := drift.NewLifecycleRegistry()
+	w := NewLifecycleWatcher(path, registry, logr.Discard())
+
+	reloaded, err := w.Reload()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+	mapping := registry.MappingFor(widgets)
+	require.NotNil(t, mapping)
+	assert.Equal(t, []drift.LifecycleSignal{{Condition: "Synced"}}, mapping.Ready)
+	assert.Equal(t, []drift.LifecycleSignal{{Path: ".status.phase", Values: []string{"Terminating"}}}, mapping.Deleting)
+
+	reloaded, err = w.Reload()
+	require.NoError(t, err)
+	assert.False(t, reloaded, "unchanged file")
+
+	// An invalid file keeps the previous mappings
+	write(`
+lifecycle:
+  - kind: Widget
+`)
+	_, err = w.Reload()
+	require.Error(t, err)
+	assert.NotNil(t, registry.MappingFor(widgets))
+
+	write("lifecycle: []\n")
+	reloaded, err = w.Reload()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+	assert.Nil(t, registry.MappingFor(widgets))
+}
@@ -0,0 +1,106 @@
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// Results of parent cache lookups.
+const (
+	parentCacheHit  = "hit"
+	parentCacheMiss = "miss"
+)
+
+// Self-metrics of the handler, to catch performance regressions in soak
+// tests before they show as admission latency.
+var (
+	parentCacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kausality_admission_parent_cache_lookups_total",
+		Help: "Parent reads of admission requests, by result: hit if the parent was already read for the request, miss if it was read from the API server.",
+	}, []string{"result"})
+	decodeDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "kausality_admission_decode_duration_seconds",
+		Help:    "Time decoding the object of admission requests.",
+		Buckets: prometheus.ExponentialBuckets(0.0001, 2, 14),
+	})
+	detectionDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "kausality_admission_detection_duration_seconds",
+		Help:    "Time detecting drift of admission requests, including resolving the parent.",
+		Buckets: prometheus.ExponentialBuckets(0.0005, 2, 14),
+	})
+	annotationPatchBytes = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "kausality_admission_annotation_patch_bytes",
+		Help:    "Size of the JSON patches of admission responses.",
+		Buckets: prometheus.ExponentialBuckets(64, 2, 12),
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(parentCacheLookups, decodeDuration, detectionDuration, annotationPatchBytes)
+}
+
+// observePatchSize records the size of the patch of a response, if any.
+func observePatchSize(resp admission.Response) {
+	if len(resp.Patches) == 0 {
+		return
+	}
+	if patch, err := json.Marshal(resp.Patches); err == nil {
+		annotationPatchBytes.Observe(float64(len(patch)))
+	}
+}
+
+// parentCache remembers the parents read during one admission request, which
+// reads the same parent for the decision cache, freezes, approvals and phase
+// recording. Parents are parsed once into their ParentContext. Parents written
+// by the request are re-read where it matters, e.g. on conflicts when
+// consuming approvals.
+type parentCache struct {
+	mu      sync.Mutex
+	parents map[parentCacheKey]*ParentContext
+}
+
+type parentCacheKey struct {
+	gvk schema.GroupVersionKind
+	key client.ObjectKey
+}
+
+type parentCacheContextKey struct{}
+
+// withParentCache returns a context caching the parents read with it.
+func withParentCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, parentCacheContextKey{}, &parentCache{parents: make(map[parentCacheKey]*ParentContext)})
+}
+
+// parentCacheFrom returns the parent cache of the context, or nil.
+func parentCacheFrom(ctx context.Context) *parentCache {
+	c, _ := ctx.Value(parentCacheContextKey{}).(*parentCache)
+	return c
+}
+
+// get returns a cached parent context, shared by the readers of the request.
+func (c *parentCache) get(gvk schema.GroupVersionKind, key client.ObjectKey) (*ParentContext, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	parent, ok := c.parents[parentCacheKey{gvk: gvk, key: key}]
+	if !ok {
+		parentCacheLookups.WithLabelValues(parentCacheMiss).Inc()
+		return nil, false
+	}
+	parentCacheLookups.WithLabelValues(parentCacheHit).Inc()
+	return parent, true
+}
+
+// put caches a parent context.
+func (c *parentCache) put(gvk schema.GroupVersionKind, key client.ObjectKey, parent *ParentContext) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.parents[parentCacheKey{gvk: gvk, key: key}] = parent
+}
@@ -0,0 +1,76 @@
+package admission
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	jsonpatch "gomodules.xyz/jsonpatch/v2"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/kausality-io/kausality/pkg/drift"
+)
+
+func TestFetchParent_ParentCache(t *testing.T) {
+	rs := buildUnstructured(replicaSetGVK, "default", "web-abc", map[string]interface{}{"replicas": int64(1)})
+	gets := 0
+	c := fake.NewClientBuilder().WithScheme(runtime.NewScheme()).WithRuntimeObjects(rs).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				gets++
+				return c.Get(ctx, key, obj, opts...)
+			},
+		}).Build()
+	h := NewHandler(Config{Client: c, Log: logr.Discard()})
+	ref := &drift.ParentRef{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-abc"}
+
+	// Without a request context, every read goes to the API server
+	for range 2 {
+		_, err := h.fetchParent(context.Background(), ref, "default")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, gets)
+
+	hits := testutil.ToFloat64(parentCacheLookups.WithLabelValues(parentCacheHit))
+	ctx := withParentCache(context.Background())
+	first, err := h.fetchParent(ctx, ref, "default")
+	require.NoError(t, err)
+	first.SetAnnotations(map[string]string{"changed": "true"})
+	second, err := h.fetchParent(ctx, ref, "default")
+	require.NoError(t, err)
+	assert.Equal(t, 3, gets)
+	assert.Equal(t, hits+1, testutil.ToFloat64(parentCacheLookups.WithLabelValues(parentCacheHit)))
+	assert.Empty(t, second.GetAnnotations(), "cached parents are copies")
+
+	// Errors are not cached
+	missing := &drift.ParentRef{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "missing"}
+	for range 2 {
+		_, err := h.fetchParent(ctx, missing, "default")
+		require.Error(t, err)
+	}
+	assert.Equal(t, 5, gets)
+}
+
+func TestObservePatchSize(t *testing.T) {
+	samples := func() uint64 {
+		var m dto.Metric
+		require.NoError(t, annotationPatchBytes.Write(&m))
+		return m.GetHistogram().GetSampleCount()
+	}
+	before := samples()
+	observePatchSize(admission.Allowed(""))
+	assert.Equal(t, before, samples())
+	observePatchSize(admission.Response{Patches: []jsonpatch.JsonPatchOperation{
+		{Operation: "add", Path: "/metadata/annotations/kausality.io~1trace", Value: "[]"},
+	}})
+	assert.Equal(t, before+1, samples())
+}
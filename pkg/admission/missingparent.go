@@ -0,0 +1,106 @@
+package admission
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+	"github.com/kausality-io/kausality/pkg/callback"
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/drift"
+)
+
+// missingParents counts mutations of objects whose controller owner does not
+// exist, handled by the missingParent action of their policy.
+var missingParents = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "kausality_admission_missing_parents_total",
+	Help: "Mutations of objects whose controller owner does not exist, by the missingParent action of their policy.",
+}, []string{"action"})
+
+func init() {
+	metrics.Registry.MustRegister(missingParents)
+}
+
+// missingParentResponse handles a mutation of an object whose controller
+// owner does not exist by the missingParent action of its policy. ok is false
+// if the webhook's error handling applies instead: without action, for other
+// errors, and for deletes, so that the garbage collector can delete the
+// dependents of deleted owners.
+func (h *Handler) missingParentResponse(ctx context.Context, req admission.Request, obj client.Object, err error,
+	action kausalityv1beta1.MissingParentAction, audit map[string]string, log logr.Logger) (admission.Response, bool) {
+	if action == "" || req.Operation == admissionv1.Delete || drift.ClassOf(err) != drift.ErrorParentNotFound {
+		return admission.Response{}, false
+	}
+	owner := metav1.GetControllerOf(obj)
+	if owner == nil {
+		return admission.Response{}, false
+	}
+	missingParents.WithLabelValues(string(action)).Inc()
+	audit[kausalityv1alpha1.AuditKeyError] = string(drift.ErrorParentNotFound)
+	msg := fmt.Sprintf("controller owner %s %s does not exist", owner.Kind, owner.Name)
+
+	switch action {
+	case kausalityv1beta1.MissingParentDeny:
+		log.Info("MISSING PARENT DENIED", "ownerKind", owner.Kind, "ownerName", owner.Name)
+		h.sendOrphanCallback(ctx, req, obj, owner, log)
+		return withAuditAnnotations(denied(kausalityv1alpha1.DenialReasonMissingParent, "mutation blocked: "+msg, req, obj, nil), audit), true
+	case kausalityv1beta1.MissingParentWarn:
+		log.Info("MISSING PARENT", "ownerKind", owner.Kind, "ownerName", owner.Name)
+		h.sendOrphanCallback(ctx, req, obj, owner, log)
+		warnings := []string{fmt.Sprintf("[kausality] %s: it was deleted without its dependents, or the owner reference is spoofed", msg)}
+		return withAuditAnnotations(withWarnings(admission.Allowed(msg), warnings), audit), true
+	default:
+		log.V(1).Info("missing parent allowed by policy", "ownerKind", owner.Kind, "ownerName", owner.Name)
+		return withAuditAnnotations(admission.Allowed(msg), audit), true
+	}
+}
+
+// sendOrphanCallback sends an OrphanDetected report of a mutation of obj,
+// whose controller owner does not exist. The missing owner is assumed to be
+// in the namespace of obj.
+func (h *Handler) sendOrphanCallback(ctx context.Context, req admission.Request, obj client.Object, owner *metav1.OwnerReference, log logr.Logger) {
+	if h.callbackSender == nil || !h.callbackSender.IsEnabled() {
+		return
+	}
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	parentRef := v1alpha1.ObjectReference{
+		APIVersion: owner.APIVersion,
+		Kind:       owner.Kind,
+		Namespace:  obj.GetNamespace(),
+		Name:       owner.Name,
+		UID:        owner.UID,
+	}
+	childRef := v1alpha1.ObjectReference{
+		APIVersion: gvk.GroupVersion().String(),
+		Kind:       gvk.Kind,
+		Namespace:  obj.GetNamespace(),
+		Name:       obj.GetName(),
+		UID:        obj.GetUID(),
+		Generation: obj.GetGeneration(),
+	}
+	report := &v1alpha1.DriftReport{Spec: v1alpha1.DriftReportSpec{
+		ID:        callback.GenerateOrphanID(parentRef, childRef),
+		Phase:     v1alpha1.DriftReportPhaseOrphanDetected,
+		Parent:    parentRef,
+		Child:     childRef,
+		NewObject: runtime.RawExtension{Raw: req.Object.Raw},
+		Request:   requestContext(req),
+	}}
+	if req.Operation == admissionv1.Update && len(req.OldObject.Raw) > 0 {
+		report.Spec.OldObject = &runtime.RawExtension{Raw: req.OldObject.Raw}
+	}
+	report.Spec.Labels = h.reportLabels(ctx, obj, nil, log)
+	h.callbackSender.SendAsync(ctx, report)
+	log.V(1).Info("drift callback sent", "phase", v1alpha1.DriftReportPhaseOrphanDetected, "id", report.Spec.ID)
+}
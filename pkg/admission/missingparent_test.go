@@ -0,0 +1,104 @@
+package admission
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/policy"
+)
+
+func TestHandle_MissingParent(t *testing.T) {
+	newHandler := func(action kausalityv1beta1.MissingParentAction) (*Handler, *recordingSender) {
+		store := policy.NewStore(nil, logr.Discard())
+		store.Update([]kausalityv1beta1.Kausality{{
+			ObjectMeta: metav1.ObjectMeta{Name: "replicasets"},
+			Spec: kausalityv1beta1.KausalitySpec{
+				Resources:     []kausalityv1beta1.ResourceRule{{APIGroups: []string{"apps"}, Resources: []string{"replicasets"}}},
+				Mode:          kausalityv1beta1.ModeLog,
+				MissingParent: action,
+			},
+		}})
+		sender := &recordingSender{}
+		c := fake.NewClientBuilder().WithScheme(runtime.NewScheme()).Build()
+		return NewHandler(Config{Client: c, Log: logr.Discard(), PolicyResolver: store, CallbackSender: sender}), sender
+	}
+	rs := buildUnstructured(replicaSetGVK, "default", "web-abc", map[string]interface{}{"replicas": int64(1)},
+		withOwnerRef(deploymentGVK, "web", "web-uid"))
+	// A spec change, so that updates are evaluated
+	changed := rs.DeepCopy()
+	changed.Object["spec"] = map[string]interface{}{"replicas": int64(2)}
+	request := func(op admissionv1.Operation) admission.Request {
+		var req admission.Request
+		switch op {
+		case admissionv1.Create:
+			req = buildAdmissionRequest(op, rs, nil, "alice")
+		case admissionv1.Update:
+			req = buildAdmissionRequest(op, changed, rs, "alice")
+		default:
+			req = buildAdmissionRequest(op, rs, rs, "alice")
+		}
+		req.Resource = metav1.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"}
+		return req
+	}
+
+	t.Run("deny", func(t *testing.T) {
+		h, sender := newHandler(kausalityv1beta1.MissingParentDeny)
+		resp := h.Handle(context.Background(), request(admissionv1.Update))
+		require.False(t, resp.Allowed)
+		assert.Contains(t, resp.Result.Message, "controller owner Deployment web does not exist")
+		assert.Equal(t, string(kausalityv1alpha1.DenialReasonMissingParent), resp.Result.Details.Causes[0].Message)
+		assert.Equal(t, "ParentNotFound", resp.AuditAnnotations[auditKeyError])
+		report := sender.last()
+		require.NotNil(t, report)
+		assert.Equal(t, v1alpha1.DriftReportPhaseOrphanDetected, report.Spec.Phase)
+		assert.Equal(t, "web", report.Spec.Parent.Name)
+		assert.Equal(t, "web-abc", report.Spec.Child.Name)
+		assert.NotNil(t, report.Spec.OldObject)
+
+		// Deletes follow the error handling, so that the garbage collector can delete dependents
+		resp = h.Handle(context.Background(), request(admissionv1.Delete))
+		assert.True(t, resp.Allowed)
+		assert.Len(t, sender.reports, 1)
+	})
+
+	t.Run("warn", func(t *testing.T) {
+		h, sender := newHandler(kausalityv1beta1.MissingParentWarn)
+		resp := h.Handle(context.Background(), request(admissionv1.Create))
+		require.True(t, resp.Allowed)
+		require.Len(t, resp.Warnings, 1)
+		assert.Contains(t, resp.Warnings[0], "owner reference is spoofed")
+		assert.Equal(t, "allowed-with-warning", resp.AuditAnnotations[auditKeyDecision])
+		require.Len(t, sender.reports, 1)
+		assert.Equal(t, v1alpha1.DriftReportPhaseOrphanDetected, sender.reports[0].Spec.Phase)
+	})
+
+	t.Run("allow", func(t *testing.T) {
+		h, sender := newHandler(kausalityv1beta1.MissingParentAllow)
+		resp := h.Handle(context.Background(), request(admissionv1.Update))
+		require.True(t, resp.Allowed)
+		assert.Empty(t, resp.Warnings)
+		assert.Empty(t, sender.reports)
+	})
+
+	t.Run("unset uses the error handling", func(t *testing.T) {
+		h, sender := newHandler("")
+		resp := h.Handle(context.Background(), request(admissionv1.Update))
+		require.True(t, resp.Allowed)
+		require.Len(t, resp.Warnings, 1)
+		assert.Contains(t, resp.Warnings[0], "drift detection skipped")
+		assert.Empty(t, sender.reports)
+	})
+}
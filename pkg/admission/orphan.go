@@ -0,0 +1,100 @@
+package admission
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/drift"
+	"github.com/kausality-io/kausality/pkg/trace"
+)
+
+// trackOrphan updates the kausality.io/orphaned annotation of an object whose
+// owner references change without spec change. When the garbage collector
+// orphans the object, the deleted owner and the object's trace are recorded,
+// so later mutations are attributed to the orphan instead of a dangling owner
+// reference. When another controller adopts the object, the record is dropped.
+func (h *Handler) trackOrphan(ctx context.Context, req admission.Request, oldObj, newObj *unstructured.Unstructured, annotations map[string]string, log logr.Logger) {
+	if ref := drift.ReleasedOwner(oldObj, newObj); ref != nil {
+		orphan := h.orphanOf(ctx, oldObj, ref, log)
+		if orphan == nil {
+			return
+		}
+		annotations[drift.OrphanedAnnotation] = orphan.String()
+		log.Info("object orphaned", "ownerKind", ref.Kind, "ownerName", ref.Name)
+		h.mirrorOrphan(req, newObj, orphan)
+		return
+	}
+
+	if _, ok := annotations[drift.OrphanedAnnotation]; !ok {
+		return
+	}
+	if owner := metav1.GetControllerOf(newObj); owner != nil {
+		if orphan := drift.OrphanOf(newObj); orphan == nil || orphan.UID != owner.UID {
+			log.V(1).Info("orphan adopted", "ownerKind", owner.Kind, "ownerName", owner.Name)
+			delete(annotations, drift.OrphanedAnnotation)
+		}
+	}
+}
+
+// orphanOf returns the orphan record of an object released by its controller
+// owner, or nil if the owner is not being deleted with orphan propagation,
+// e.g. because a controller released the object.
+func (h *Handler) orphanOf(ctx context.Context, obj *unstructured.Unstructured, ref *metav1.OwnerReference, log logr.Logger) *drift.Orphan {
+	owner, err := h.fetchParent(ctx, &drift.ParentRef{APIVersion: ref.APIVersion, Kind: ref.Kind, Name: ref.Name}, obj.GetNamespace())
+	if err != nil {
+		log.V(1).Info("failed to fetch released owner", "error", err)
+		return nil
+	}
+	if owner.GetUID() != ref.UID || !drift.IsOrphaning(owner) {
+		return nil
+	}
+	t, err := trace.GetTraceFromObject(obj)
+	if err != nil {
+		log.V(1).Info("failed to parse trace of orphan", "error", err)
+	}
+	return &drift.Orphan{
+		APIVersion: ref.APIVersion,
+		Kind:       ref.Kind,
+		Name:       ref.Name,
+		UID:        ref.UID,
+		At:         metav1.Now(),
+		Trace:      t,
+	}
+}
+
+// mirrorOrphan reports an orphaned object with its last trace to the trace
+// mirror, if configured. Dry-run requests are not mirrored.
+func (h *Handler) mirrorOrphan(req admission.Request, obj *unstructured.Unstructured, orphan *drift.Orphan) {
+	if h.traceMirror == nil || (req.DryRun != nil && *req.DryRun) {
+		return
+	}
+	h.traceMirror.Mirror(&v1alpha1.TraceRecord{
+		Spec: v1alpha1.TraceRecordSpec{
+			Object: v1alpha1.ObjectReference{
+				APIVersion: obj.GetAPIVersion(),
+				Kind:       obj.GetKind(),
+				Namespace:  obj.GetNamespace(),
+				Name:       obj.GetName(),
+				UID:        obj.GetUID(),
+				Generation: obj.GetGeneration(),
+			},
+			Trace:     runtime.RawExtension{Raw: []byte(orphan.Trace.String())},
+			Request:   requestContext(req),
+			Timestamp: orphan.At,
+			OrphanedBy: &v1alpha1.ObjectReference{
+				APIVersion: orphan.APIVersion,
+				Kind:       orphan.Kind,
+				Namespace:  obj.GetNamespace(),
+				Name:       orphan.Name,
+				UID:        orphan.UID,
+			},
+		},
+	})
+}
@@ -0,0 +1,127 @@
+package admission
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gomodules.xyz/jsonpatch/v2"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/drift"
+	"github.com/kausality-io/kausality/pkg/trace"
+)
+
+const orphanedPath = "/metadata/annotations/kausality.io~1orphaned"
+
+// webParent returns the parent Deployment, deleted with orphan propagation if
+// orphaning is true.
+func webParent(orphaning bool) *unstructured.Unstructured {
+	parent := buildUnstructured(deploymentGVK, "default", "web", map[string]interface{}{"replicas": int64(1)}, withUID("deploy-uid"))
+	if orphaning {
+		parent.SetDeletionTimestamp(&metav1.Time{Time: metav1.Now().Time})
+		parent.SetFinalizers([]string{metav1.FinalizerOrphanDependents})
+	}
+	return parent
+}
+
+// webChild returns the ReplicaSet carrying a trace, owned by the given owner
+// UID, or unowned if ownerUID is empty.
+func webChild(ownerUID types.UID, annotations map[string]string) *unstructured.Unstructured {
+	ann := map[string]string{trace.TraceAnnotation: `[{"apiVersion":"apps/v1","kind":"Deployment","name":"web","generation":1,"user":"alice"}]`}
+	for k, v := range annotations {
+		ann[k] = v
+	}
+	extras := []func(*unstructured.Unstructured){withUID("rs-uid"), withAnnotations(ann)}
+	if ownerUID != "" {
+		extras = append(extras, withOwnerRef(deploymentGVK, "web", ownerUID))
+	}
+	return buildUnstructured(replicaSetGVK, "default", "web-abc", map[string]interface{}{"replicas": int64(1)}, extras...)
+}
+
+// orphanPatch returns the patch of the orphaned annotation, if any.
+func orphanPatch(patches []jsonpatch.JsonPatchOperation) *jsonpatch.JsonPatchOperation {
+	for i := range patches {
+		if patches[i].Path == orphanedPath {
+			return &patches[i]
+		}
+	}
+	return nil
+}
+
+func TestHandle_GarbageCollectorOrphansChild(t *testing.T) {
+	mirror := &recordingMirror{}
+	h := newTestHandler(webParent(true))
+	h.traceMirror = mirror
+
+	req := buildAdmissionRequest(admissionv1.Update, webChild("", nil), webChild("deploy-uid", nil), garbageCollectorUser)
+	resp := h.Handle(context.Background(), req)
+	require.True(t, resp.Allowed)
+
+	patch := orphanPatch(resp.Patches)
+	require.NotNil(t, patch, "orphaned annotation must be added")
+	assert.Equal(t, "add", patch.Operation)
+	orphan, err := kausalityv1alpha1.ParseOrphan(patch.Value.(string))
+	require.NoError(t, err)
+	assert.Equal(t, "Deployment/web", orphan.Owner())
+	assert.Equal(t, types.UID("deploy-uid"), orphan.UID)
+	require.Len(t, orphan.Trace, 1)
+	assert.Equal(t, "alice", orphan.Trace[0].User)
+
+	require.Len(t, mirror.records, 1)
+	record := mirror.records[0].Spec
+	require.NotNil(t, record.OrphanedBy)
+	assert.Equal(t, "web", record.OrphanedBy.Name)
+	assert.Equal(t, "web-abc", record.Object.Name)
+}
+
+func TestHandle_ReleasedChildNotOrphaned(t *testing.T) {
+	tests := []struct {
+		name    string
+		parents []*unstructured.Unstructured
+	}{
+		{name: "owner not deleted", parents: []*unstructured.Unstructured{webParent(false)}},
+		{name: "owner gone"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var objs []runtime.Object
+			for _, p := range tt.parents {
+				objs = append(objs, p)
+			}
+			h := newTestHandler(objs...)
+
+			req := buildAdmissionRequest(admissionv1.Update, webChild("", nil), webChild("deploy-uid", nil), deploymentController)
+			resp := h.Handle(context.Background(), req)
+			require.True(t, resp.Allowed)
+			assert.Nil(t, orphanPatch(resp.Patches))
+		})
+	}
+}
+
+func TestHandle_AdoptionClearsOrphan(t *testing.T) {
+	orphaned := map[string]string{drift.OrphanedAnnotation: `{"apiVersion":"apps/v1","kind":"Deployment","name":"web","uid":"deploy-uid","at":null}`}
+	h := newTestHandler()
+
+	// Adopted by a new owner
+	req := buildAdmissionRequest(admissionv1.Update, webChild("new-uid", orphaned), webChild("", orphaned), deploymentController)
+	resp := h.Handle(context.Background(), req)
+	require.True(t, resp.Allowed)
+	patch := orphanPatch(resp.Patches)
+	require.NotNil(t, patch)
+	assert.Equal(t, "remove", patch.Operation)
+
+	// Unrelated metadata changes keep the orphan record
+	child := webChild("", orphaned)
+	child.SetLabels(map[string]string{"team": "a"})
+	resp = h.Handle(context.Background(), buildAdmissionRequest(admissionv1.Update, child, webChild("", orphaned), "admin"))
+	require.True(t, resp.Allowed)
+	assert.Nil(t, orphanPatch(resp.Patches))
+}
@@ -0,0 +1,121 @@
+package admission
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gomodules.xyz/jsonpatch/v2"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/kausality-io/kausality/pkg/approval"
+	"github.com/kausality-io/kausality/pkg/controller"
+	"github.com/kausality-io/kausality/pkg/trace"
+)
+
+var removeOverridePatch = jsonpatch.JsonPatchOperation{Operation: "remove", Path: "/metadata/annotations/kausality.io~1override"}
+
+// enforcedChild returns the child in enforce mode with the given extra annotations.
+func enforcedChild(replicas int64, annotations map[string]string) *unstructured.Unstructured {
+	child := childRS(replicas, "")
+	ann := map[string]string{"kausality.io/mode": "enforce"}
+	for k, v := range annotations {
+		ann[k] = v
+	}
+	child.SetAnnotations(ann)
+	return child
+}
+
+// driftRequest makes the controller change the child's replicas from 1 to 3
+// while the parent is stable, with the given annotations on the new object.
+func driftRequest(annotations map[string]string) admission.Request {
+	oldChild := enforcedChild(1, map[string]string{controller.UpdatersAnnotation: controller.HashUsername(deploymentController)})
+	return buildAdmissionRequest(admissionv1.Update, enforcedChild(3, annotations), oldChild, deploymentController)
+}
+
+func TestOverride_AllowsDriftInEnforceMode(t *testing.T) {
+	h, sender := newResolutionTestHandler(1, 1)
+
+	resp := h.Handle(context.Background(), driftRequest(map[string]string{
+		approval.OverrideAnnotation: `{"justification":"controller is right","ticket":"OPS-42"}`,
+	}))
+
+	require.True(t, resp.Allowed, "override allows drift in enforce mode")
+	require.Len(t, resp.Warnings, 1)
+	assert.Contains(t, resp.Warnings[0], "allowed by override: OPS-42: controller is right")
+	assert.Equal(t, "allowed-with-warning", resp.AuditAnnotations[auditKeyDecision])
+	assert.Equal(t, "overridden", resp.AuditAnnotations[auditKeyDriftResolution])
+	assert.Equal(t, "OPS-42: controller is right", resp.AuditAnnotations[auditKeyOverride])
+	assert.Contains(t, resp.Patches, removeOverridePatch, "override must not persist")
+
+	report := sender.last()
+	require.NotNil(t, report)
+	require.NotNil(t, report.Spec.Override)
+	assert.Equal(t, "OPS-42", report.Spec.Override.Ticket)
+	assert.Equal(t, "controller is right", report.Spec.Override.Justification)
+}
+
+func TestOverride_Invalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		override  string
+		validator *fakeTicketValidator
+		wantMsg   string
+	}{
+		{
+			name:     "missing ticket",
+			override: `{"justification":"controller is right"}`,
+			wantMsg:  "ticket is required",
+		},
+		{
+			name:      "ticket rejected",
+			override:  `{"justification":"controller is right","ticket":"OPS-1"}`,
+			validator: &fakeTicketValidator{tickets: map[string]string{"OPS-42": "In Progress"}},
+			wantMsg:   `override ticket "OPS-1" rejected`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, sender := newResolutionTestHandler(1, 1)
+			if tt.validator != nil {
+				h.ticketValidator = tt.validator
+			}
+
+			resp := h.Handle(context.Background(), driftRequest(map[string]string{approval.OverrideAnnotation: tt.override}))
+
+			require.False(t, resp.Allowed)
+			assert.Contains(t, resp.Result.Message, tt.wantMsg)
+			assert.Equal(t, "unresolved", resp.AuditAnnotations[auditKeyDriftResolution])
+			require.NotNil(t, sender.last())
+			assert.Nil(t, sender.last().Spec.Override)
+		})
+	}
+}
+
+func TestOverride_ValidTicket(t *testing.T) {
+	h, _ := newResolutionTestHandler(1, 1)
+	h.ticketValidator = &fakeTicketValidator{tickets: map[string]string{"OPS-42": "In Progress"}}
+
+	resp := h.Handle(context.Background(), driftRequest(map[string]string{
+		approval.OverrideAnnotation: `{"justification":"controller is right","ticket":"OPS-42"}`,
+		trace.TicketAnnotation:      "OPS-42",
+	}))
+	require.True(t, resp.Allowed, resp.Result)
+}
+
+func TestOverride_NotPersistedOnMetadataUpdate(t *testing.T) {
+	h, _ := newResolutionTestHandler(1, 1)
+
+	// Only the annotation is added, no spec change
+	oldChild := enforcedChild(1, nil)
+	newChild := enforcedChild(1, map[string]string{approval.OverrideAnnotation: `{"justification":"later","ticket":"OPS-42"}`})
+	resp := h.Handle(context.Background(), buildAdmissionRequest(admissionv1.Update, newChild, oldChild, "admin"))
+	require.True(t, resp.Allowed)
+
+	assert.Contains(t, resp.Patches, removeOverridePatch, "override must not persist")
+}
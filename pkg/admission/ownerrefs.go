@@ -0,0 +1,99 @@
+package admission
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+	"github.com/kausality-io/kausality/pkg/controller"
+	"github.com/kausality-io/kausality/pkg/drift"
+)
+
+// ownerReferenceViolations counts added controller owner references failing
+// validation.
+var ownerReferenceViolations = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "kausality_admission_owner_reference_violations_total",
+	Help: "Controller owner references added by creates and updates that failed validation, by violation and the ownerReferences action of their policy.",
+}, []string{"violation", "action"})
+
+func init() {
+	metrics.Registry.MustRegister(ownerReferenceViolations)
+}
+
+// validateOwnerReferences checks a controller owner reference added by a
+// create or update if the object's policy validates owner references: it
+// must resolve to an existing owner with the same UID, and be added by the
+// controller of the owner. A spoofed reference makes the object appear
+// managed by the owner's controller, and hides changes by others from drift
+// detection. Errors reading the owner are left to drift detection. It returns
+// the warnings, or a denial.
+func (h *Handler) validateOwnerReferences(ctx context.Context, req admission.Request) ([]string, *admission.Response) {
+	if req.SubResource != "" || (req.Operation != admissionv1.Create && req.Operation != admissionv1.Update) {
+		return nil, nil
+	}
+	obj, err := h.parseObject(req)
+	if err != nil {
+		return nil, nil
+	}
+	var oldObj client.Object
+	var childUpdaters []string
+	if req.Operation == admissionv1.Update {
+		old := &unstructured.Unstructured{}
+		if err := runtime.DecodeInto(unstructured.UnstructuredJSONScheme, req.OldObject.Raw, old); err != nil {
+			return nil, nil
+		}
+		oldObj = old
+		childUpdaters = drift.ParseUpdaterHashes(old)
+	}
+	ref := drift.AddedOwner(oldObj, obj)
+	if ref == nil {
+		return nil, nil
+	}
+
+	log := h.log.WithValues("operation", req.Operation, "kind", req.Kind.String(), "namespace", req.Namespace, "name", req.Name,
+		"user", req.UserInfo.Username, "ownerKind", ref.Kind, "ownerName", ref.Name)
+	objPolicy, err := h.resolveObjectPolicy(ctx, obj, rawLabels(req.OldObject.Raw))
+	if err != nil {
+		log.V(1).Info("failed to resolve policy for owner reference validation", "error", err)
+		return nil, nil
+	}
+	action := objPolicy.ownerReferences
+	if action == "" || action == kausalityv1beta1.OwnerReferenceAllow {
+		return nil, nil
+	}
+
+	userID := controller.UserIdentifier(req.UserInfo.Username, req.UserInfo.UID)
+	if userHash := controller.HashUsername(userID); !controller.ContainsHash(childUpdaters, userHash) {
+		childUpdaters = append(childUpdaters, userHash)
+	}
+	actor := drift.Actor{Username: userID, FieldManager: extractFieldManager(req), ChildUpdaters: childUpdaters}
+	violation, reason, err := h.detector.CheckOwnerReference(ctx, obj, *ref, actor, objPolicy.classifier)
+	if err != nil {
+		log.V(1).Info("failed to validate owner reference", "error", err)
+		return nil, nil
+	}
+	if violation == "" {
+		return nil, nil
+	}
+	ownerReferenceViolations.WithLabelValues(string(violation), string(action)).Inc()
+	msg := fmt.Sprintf("invalid controller owner reference to %s %s: %s", ref.Kind, ref.Name, reason)
+
+	if action != kausalityv1beta1.OwnerReferenceDeny {
+		log.Info("INVALID OWNER REFERENCE", "violation", violation, "reason", reason)
+		return []string{"[kausality] " + msg}, nil
+	}
+	log.Info("INVALID OWNER REFERENCE DENIED", "violation", violation, "reason", reason)
+	audit := map[string]string{kausalityv1alpha1.AuditKeyMode: objPolicy.mode}
+	resp := withAuditAnnotations(denied(kausalityv1alpha1.DenialReasonOwnerReference, msg, req, obj, nil), audit)
+	return nil, &resp
+}
@@ -0,0 +1,120 @@
+package admission
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+	"github.com/kausality-io/kausality/pkg/controller"
+	"github.com/kausality-io/kausality/pkg/policy"
+	"github.com/kausality-io/kausality/pkg/testing/scenario"
+)
+
+func TestHandle_OwnerReferences(t *testing.T) {
+	// A ReplicaSet owned by the Deployment "web"
+	owned := func() *scenario.Scenario {
+		return scenario.NewParentChild().
+			WithParent(scenario.DeploymentGVK, "web").
+			WithChild(scenario.ReplicaSetGVK, "web-abc").
+			WithControllers(controller.HashUsername(scenario.ControllerUser))
+	}
+	// The ReplicaSet's owner reference names "web" with another UID
+	otherOwner := func(s *scenario.Scenario) *scenario.Scenario {
+		ref := scenario.OwnerReference(s.Parent())
+		ref.UID = "other-uid"
+		s.Child().SetOwnerReferences([]metav1.OwnerReference{ref})
+		s.OldChild().SetOwnerReferences([]metav1.OwnerReference{ref})
+		return s
+	}
+	newHandler := func(action kausalityv1beta1.OwnerReferenceAction) *Handler {
+		store := policy.NewStore(nil, logr.Discard())
+		store.Update([]kausalityv1beta1.Kausality{{
+			ObjectMeta: metav1.ObjectMeta{Name: "replicasets"},
+			Spec: kausalityv1beta1.KausalitySpec{
+				Resources:       []kausalityv1beta1.ResourceRule{{APIGroups: []string{"apps"}, Resources: []string{"replicasets"}}},
+				Mode:            kausalityv1beta1.ModeLog,
+				OwnerReferences: action,
+			},
+		}})
+		return NewHandler(Config{Client: owned().Client(), Log: logr.Discard(), PolicyResolver: store})
+	}
+	spec := map[string]interface{}{"replicas": int64(1)}
+	adopted := owned()
+	adopted.OldChild().SetOwnerReferences(nil)
+
+	tests := []struct {
+		name   string
+		action kausalityv1beta1.OwnerReferenceAction
+		req    admission.Request
+		denied bool
+		warn   string
+	}{
+		{
+			name:   "created by the controller",
+			action: kausalityv1beta1.OwnerReferenceDeny,
+			req:    owned().ChildCreate(spec).Request(scenario.ControllerUser),
+		},
+		{
+			name:   "created by another user",
+			action: kausalityv1beta1.OwnerReferenceDeny,
+			req:    owned().ChildCreate(spec).Request("mallory"),
+			denied: true,
+		},
+		{
+			name:   "adopted by another user",
+			action: kausalityv1beta1.OwnerReferenceWarn,
+			req:    adopted.Request("mallory"),
+			warn:   "added by different actor than the controller of Deployment web",
+		},
+		{
+			name:   "UID of another owner",
+			action: kausalityv1beta1.OwnerReferenceDeny,
+			req:    otherOwner(owned()).ChildCreate(spec).Request(scenario.ControllerUser),
+			denied: true,
+		},
+		{
+			name:   "unchanged owner",
+			action: kausalityv1beta1.OwnerReferenceDeny,
+			req:    otherOwner(owned()).Request("mallory"),
+		},
+		{
+			name:   "not validated",
+			action: kausalityv1beta1.OwnerReferenceAllow,
+			req:    otherOwner(owned()).ChildCreate(spec).Request("mallory"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := newHandler(tt.action).Handle(context.Background(), tt.req)
+			if tt.denied {
+				require.False(t, resp.Allowed)
+				assert.Contains(t, resp.Result.Message, "invalid controller owner reference to Deployment web")
+				assert.Equal(t, string(kausalityv1alpha1.DenialReasonOwnerReference), resp.Result.Details.Causes[0].Message)
+				assert.Equal(t, "denied", resp.AuditAnnotations[auditKeyDecision])
+				return
+			}
+			require.True(t, resp.Allowed, resp.Result)
+			var warnings []string
+			for _, w := range resp.Warnings {
+				if strings.Contains(w, "owner reference") {
+					warnings = append(warnings, w)
+				}
+			}
+			if tt.warn == "" {
+				assert.Empty(t, warnings)
+				return
+			}
+			require.Len(t, warnings, 1)
+			assert.Contains(t, warnings[0], tt.warn)
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package admission
+
+import (
+	"context"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/approval"
+	"github.com/kausality-io/kausality/pkg/controller"
+	"github.com/kausality-io/kausality/pkg/drift"
+	"github.com/kausality-io/kausality/pkg/policy"
+	"github.com/kausality-io/kausality/pkg/trace"
+)
+
+// ParentContext is the kausality state of a parent, parsed once from its
+// annotations. The handler reads it once per admission request, so that
+// freezes, approvals, snoozes and drift reports see the same parent.
+type ParentContext struct {
+	// Parent is the parent object. It is shared by the readers of the
+	// request and must not be modified.
+	Parent *unstructured.Unstructured
+
+	// Approvals are the approvals and rejections of the children.
+	Approvals approval.ParentApprovals
+
+	// Frozen is whether the parent is frozen. Freeze is its freeze, empty
+	// if the freeze annotation is invalid: invalid freezes fail closed.
+	Frozen bool
+	Freeze *approval.Freeze
+	// FreezeErr is the error parsing the freeze annotation.
+	FreezeErr error
+
+	// Snooze is the snooze of the parent, nil if it has none. It may have
+	// expired, see ActiveSnooze.
+	Snooze *approval.Snooze
+	// SnoozeErr is the error parsing the snooze annotation.
+	SnoozeErr error
+
+	// Controllers are the user hashes of the parent's controllers.
+	Controllers []string
+	// Phase is the recorded lifecycle phase of the parent.
+	Phase string
+	// ObservedGeneration is the synthetic observedGeneration annotation,
+	// valid if HasObservedGeneration.
+	ObservedGeneration    int64
+	HasObservedGeneration bool
+
+	// Mode is the drift detection mode annotation of the parent, "" if it
+	// has no valid one.
+	Mode string
+
+	// Trace is the trace of the parent.
+	Trace trace.Trace
+	// TraceErr is the error parsing the trace annotation.
+	TraceErr error
+}
+
+// NewParentContext parses the kausality annotations of a parent.
+func NewParentContext(parent *unstructured.Unstructured) *ParentContext {
+	p := &ParentContext{Parent: parent}
+	annotations := parent.GetAnnotations()
+
+	p.Approvals = approval.ParseParentApprovals(annotations)
+
+	// "false" explicitly disables a freeze
+	if value := annotations[approval.FreezeAnnotation]; value != "" && value != "false" {
+		p.Frozen = true
+		if p.Freeze, p.FreezeErr = approval.ParseFreeze(value); p.FreezeErr != nil {
+			p.Freeze = &approval.Freeze{}
+		}
+	}
+
+	if value := annotations[approval.SnoozeAnnotation]; value != "" {
+		p.Snooze, p.SnoozeErr = approval.ParseSnooze(value)
+	}
+
+	if value := annotations[controller.ControllersAnnotation]; value != "" {
+		p.Controllers = controller.ParseHashes(value)
+	}
+	p.Phase = annotations[controller.PhaseAnnotation]
+	if value, ok := annotations[controller.ObservedGenerationAnnotation]; ok {
+		if gen, err := strconv.ParseInt(value, 10, 64); err == nil {
+			p.ObservedGeneration, p.HasObservedGeneration = gen, true
+		}
+	}
+
+	switch mode := annotations[policy.ModeAnnotation]; mode {
+	case string(kausalityv1alpha1.ModeLog), string(kausalityv1alpha1.ModeEnforce):
+		p.Mode = mode
+	}
+
+	if value := annotations[trace.TraceAnnotation]; value != "" {
+		p.Trace, p.TraceErr = trace.Parse(value)
+	}
+	return p
+}
+
+// Generation is the generation of the parent.
+func (p *ParentContext) Generation() int64 {
+	return p.Parent.GetGeneration()
+}
+
+// Check checks if a mutation of a child is approved or rejected.
+func (p *ParentContext) Check(checker *approval.Checker, child approval.ChildRef) approval.CheckResult {
+	if p.Parent.GetAnnotations() == nil {
+		return approval.CheckResult{Reason: "no approvals or rejections on parent"}
+	}
+	return checker.CheckParsed(p.Approvals, child, p.Generation())
+}
+
+// ActiveSnooze returns the snooze of the parent if it is active, nil
+// otherwise.
+func (p *ParentContext) ActiveSnooze() *approval.Snooze {
+	if p.Snooze == nil || !p.Snooze.IsActive() {
+		return nil
+	}
+	return p.Snooze
+}
+
+// object returns the parent object, nil without a parent.
+func (p *ParentContext) object() client.Object {
+	if p == nil {
+		return nil
+	}
+	return p.Parent
+}
+
+// parentContext returns the parsed context of a parent. Parents are read
+// and parsed once per admission request.
+func (h *Handler) parentContext(ctx context.Context, ref *drift.ParentRef, childNamespace string) (*ParentContext, error) {
+	gvk, key, err := parentKey(ref, childNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	cache := parentCacheFrom(ctx)
+	if cache != nil {
+		if cached, ok := cache.get(gvk, key); ok {
+			return cached, nil
+		}
+	}
+	parent := &unstructured.Unstructured{}
+	parent.SetGroupVersionKind(gvk)
+	getCtx, cancel := h.parentBudget(ctx)
+	defer cancel()
+	if err := h.client.Get(getCtx, key, parent); err != nil {
+		return nil, err
+	}
+	p := NewParentContext(parent)
+	if cache != nil {
+		cache.put(gvk, key, p)
+	}
+	return p, nil
+}
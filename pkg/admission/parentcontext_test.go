@@ -0,0 +1,142 @@
+package admission
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/kausality-io/kausality/pkg/approval"
+	"github.com/kausality-io/kausality/pkg/controller"
+	"github.com/kausality-io/kausality/pkg/drift"
+	"github.com/kausality-io/kausality/pkg/policy"
+	"github.com/kausality-io/kausality/pkg/trace"
+)
+
+func parentWithAnnotations(annotations map[string]string) *unstructured.Unstructured {
+	parent := buildUnstructured(deploymentGVK, "default", "web", map[string]interface{}{"replicas": int64(1)})
+	parent.SetGeneration(3)
+	parent.SetAnnotations(annotations)
+	return parent
+}
+
+func TestNewParentContext(t *testing.T) {
+	expiry := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	p := NewParentContext(parentWithAnnotations(map[string]string{
+		approval.ApprovalsAnnotation:            `[{"apiVersion":"apps/v1","kind":"ReplicaSet","name":"web-abc","mode":"always"}]`,
+		approval.RejectionsAnnotation:           `[{"apiVersion":"apps/v1","kind":"ReplicaSet","name":"web-def","reason":"no"}]`,
+		approval.FreezeAnnotation:               `{"user":"alice","message":"incident"}`,
+		approval.SnoozeAnnotation:               `{"expiry":"` + expiry + `","user":"bob"}`,
+		controller.ControllersAnnotation:        "abc12345,def67890",
+		controller.PhaseAnnotation:              controller.PhaseValueInitialized,
+		controller.ObservedGenerationAnnotation: "2",
+		policy.ModeAnnotation:                   "enforce",
+		trace.TraceAnnotation:                   `[{"apiVersion":"apps/v1","kind":"Deployment","name":"web","generation":3,"user":"alice"}]`,
+	}))
+
+	assert.Len(t, p.Approvals.Approvals, 1)
+	assert.Len(t, p.Approvals.Rejections, 1)
+	assert.True(t, p.Frozen)
+	assert.Equal(t, "alice", p.Freeze.User)
+	require.NotNil(t, p.ActiveSnooze())
+	assert.Equal(t, "bob", p.ActiveSnooze().User)
+	assert.Equal(t, []string{"abc12345", "def67890"}, p.Controllers)
+	assert.Equal(t, controller.PhaseValueInitialized, p.Phase)
+	assert.True(t, p.HasObservedGeneration)
+	assert.Equal(t, int64(2), p.ObservedGeneration)
+	assert.Equal(t, "enforce", p.Mode)
+	require.Len(t, p.Trace, 1)
+	assert.Equal(t, "alice", p.Trace[0].User)
+	assert.Equal(t, int64(3), p.Generation())
+
+	approved := p.Check(approval.NewChecker(), approval.ChildRef{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-abc"})
+	assert.True(t, approved.Approved)
+	rejected := p.Check(approval.NewChecker(), approval.ChildRef{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-def"})
+	assert.True(t, rejected.Rejected)
+}
+
+func TestNewParentContext_Invalid(t *testing.T) {
+	p := NewParentContext(parentWithAnnotations(map[string]string{
+		approval.ApprovalsAnnotation:            "not json",
+		approval.FreezeAnnotation:               "not json",
+		approval.SnoozeAnnotation:               "not json",
+		controller.ObservedGenerationAnnotation: "two",
+		policy.ModeAnnotation:                   "audit",
+		trace.TraceAnnotation:                   "not json",
+	}))
+
+	assert.Error(t, p.Approvals.ApprovalsErr)
+	assert.True(t, p.Frozen, "invalid freezes fail closed")
+	assert.Equal(t, &approval.Freeze{}, p.Freeze)
+	assert.Error(t, p.FreezeErr)
+	assert.Error(t, p.SnoozeErr)
+	assert.Nil(t, p.ActiveSnooze())
+	assert.Nil(t, activeSnooze(p, logr.Discard()))
+	assert.False(t, p.HasObservedGeneration)
+	assert.Empty(t, p.Mode)
+	assert.Error(t, p.TraceErr)
+
+	result := p.Check(approval.NewChecker(), approval.ChildRef{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-abc"})
+	assert.False(t, result.Approved)
+	assert.Contains(t, result.Reason, "failed to parse approvals")
+}
+
+func TestNewParentContext_Empty(t *testing.T) {
+	p := NewParentContext(parentWithAnnotations(nil))
+	assert.False(t, p.Frozen)
+	assert.Nil(t, p.Snooze)
+	assert.Empty(t, p.Controllers)
+	assert.Equal(t, "no approvals or rejections on parent",
+		p.Check(approval.NewChecker(), approval.ChildRef{Kind: "ReplicaSet", Name: "web-abc"}).Reason)
+
+	// Unfrozen explicitly, and expired snoozes
+	expired := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	p = NewParentContext(parentWithAnnotations(map[string]string{
+		approval.FreezeAnnotation: "false",
+		approval.SnoozeAnnotation: `{"expiry":"` + expired + `"}`,
+	}))
+	assert.False(t, p.Frozen)
+	assert.NotNil(t, p.Snooze)
+	assert.Nil(t, p.ActiveSnooze())
+}
+
+func TestParentContext_ParsedOncePerRequest(t *testing.T) {
+	parent := parentWithAnnotations(map[string]string{approval.FreezeAnnotation: `{"user":"alice"}`})
+	gets := 0
+	c := fake.NewClientBuilder().WithScheme(runtime.NewScheme()).WithRuntimeObjects(parent).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				gets++
+				return c.Get(ctx, key, obj, opts...)
+			},
+		}).Build()
+	h := NewHandler(Config{Client: c, Log: logr.Discard()})
+	ref := &drift.ParentRef{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"}
+
+	ctx := withParentCache(context.Background())
+	frozen, freeze := h.checkFreeze(ctx, ref, "default", logr.Discard())
+	assert.True(t, frozen)
+	assert.Equal(t, "alice", freeze.User)
+	first, err := h.parentContext(ctx, ref, "default")
+	require.NoError(t, err)
+	second, err := h.parentContext(ctx, ref, "default")
+	require.NoError(t, err)
+	assert.Same(t, first, second, "the parent is parsed once")
+	assert.Equal(t, 1, gets)
+
+	// Copies of the parent can be modified
+	copied, err := h.fetchParent(ctx, ref, "default")
+	require.NoError(t, err)
+	copied.SetAnnotations(nil)
+	assert.True(t, first.Frozen)
+	assert.NotEmpty(t, first.Parent.GetAnnotations())
+}
@@ -0,0 +1,60 @@
+package admission
+
+import (
+	"context"
+	"slices"
+
+	"github.com/go-logr/logr"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/kausality-io/kausality/pkg/controller"
+	"github.com/kausality-io/kausality/pkg/drift"
+)
+
+// prewarmParent records the creator of a child as the controller of its
+// controller owner, and the owner's lifecycle phase, if the owner has no
+// controllers yet. Controllers are otherwise only recorded from status
+// updates of the parent, so until the controller first writes the parent's
+// status, later mutations of the child are classified by the updaters of
+// the child alone. Creators whose field manager does not own the parent's
+// status, e.g. users creating children by hand, are not recorded. Records
+// are written asynchronously by the controller tracker.
+func (h *Handler) prewarmParent(ctx context.Context, req admission.Request, obj client.Object, driftResult *drift.DriftResult, userID string, log logr.Logger) {
+	prewarmer, ok := h.controllerTracker.(controller.Prewarmer)
+	if !ok || req.Operation != admissionv1.Create || (req.DryRun != nil && *req.DryRun) {
+		return
+	}
+	ref, state := driftResult.ParentRef, driftResult.ParentState
+	if ref == nil || state == nil || len(state.Controllers) > 0 || slices.Contains(state.Appliers, userID) ||
+		driftResult.LifecyclePhase == drift.PhaseDeleting {
+		return
+	}
+	owner := metav1.GetControllerOf(obj)
+	if owner == nil || owner.Kind != ref.Kind || owner.Name != ref.Name {
+		return
+	}
+	if fm := extractFieldManager(req); fm != "" && len(state.StatusManagers) > 0 && !slices.Contains(state.StatusManagers, fm) {
+		log.V(1).Info("not prewarming parent, creator does not manage its status", "fieldManager", fm)
+		return
+	}
+
+	parent := &unstructured.Unstructured{}
+	parent.SetAPIVersion(ref.APIVersion)
+	parent.SetKind(ref.Kind)
+	parent.SetNamespace(ref.Namespace)
+	parent.SetName(ref.Name)
+	prewarmer.PrewarmController(ctx, parent, userID)
+	if state.PhaseFromAnnotation == "" {
+		phase := controller.PhaseValueInitializing
+		if driftResult.LifecyclePhase == drift.PhaseInitialized {
+			phase = controller.PhaseValueInitialized
+		}
+		h.controllerTracker.RecordPhase(ctx, parent, phase)
+	}
+	log.V(1).Info("prewarming parent controller", "parentKind", ref.Kind, "parentName", ref.Name)
+}
@@ -0,0 +1,64 @@
+package admission
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/kausality-io/kausality/pkg/controller"
+	"github.com/kausality-io/kausality/pkg/drift"
+)
+
+func TestHandle_PrewarmsParent(t *testing.T) {
+	ctx := context.Background()
+	parentAnnotations := func(h *Handler) map[string]string {
+		parent := &unstructured.Unstructured{}
+		parent.SetGroupVersionKind(deploymentGVK)
+		require.NoError(t, h.client.Get(ctx, client.ObjectKey{Namespace: "default", Name: "web"}, parent))
+		return parent.GetAnnotations()
+	}
+	create := func(fieldManager string) admission.Request {
+		req := buildAdmissionRequest(admissionv1.Create, webChild("deploy-uid", nil), nil, deploymentController)
+		if fieldManager != "" {
+			req.Options = runtime.RawExtension{Raw: []byte(`{"fieldManager":"` + fieldManager + `"}`)}
+		}
+		return req
+	}
+
+	// The creator of the child is recorded as the parent's controller
+	h := newTestHandler(webParent(false))
+	require.True(t, h.Handle(ctx, create("")).Allowed)
+	annotations := parentAnnotations(h)
+	assert.Equal(t, controller.HashUsername(deploymentController), annotations[controller.ControllersAnnotation])
+	assert.Empty(t, annotations[controller.ObservedGenerationAnnotation], "no generation is observed")
+
+	// Dry runs are not recorded
+	h = newTestHandler(webParent(false))
+	req := create("")
+	req.DryRun = ptr.To(true)
+	require.True(t, h.Handle(ctx, req).Allowed)
+	assert.Empty(t, parentAnnotations(h)[controller.ControllersAnnotation])
+
+	// Creators not managing the parent's status are not recorded. The fake
+	// client drops managedFields, so the parent's state is given.
+	h = newTestHandler(webParent(false))
+	result := &drift.DriftResult{
+		ParentRef:      &drift.ParentRef{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "web"},
+		ParentState:    &drift.ParentState{StatusManagers: []string{"deployment-controller"}},
+		LifecyclePhase: drift.PhaseInitialized,
+	}
+	h.prewarmParent(ctx, create("kubectl-create"), webChild("deploy-uid", nil), result, "alice", logr.Discard())
+	assert.Empty(t, parentAnnotations(h)[controller.ControllersAnnotation])
+	h.prewarmParent(ctx, create("deployment-controller"), webChild("deploy-uid", nil), result, deploymentController, logr.Discard())
+	assert.Equal(t, controller.HashUsername(deploymentController), parentAnnotations(h)[controller.ControllersAnnotation])
+}
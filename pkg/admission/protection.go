@@ -0,0 +1,197 @@
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/config"
+	"github.com/kausality-io/kausality/pkg/controller"
+	"github.com/kausality-io/kausality/pkg/drift"
+	"github.com/kausality-io/kausality/pkg/trace"
+)
+
+// ProtectionPath is the path of the self-protection webhook, which receives
+// the requests of kausality's own CRDs and Deployments.
+const ProtectionPath = "/protect"
+
+// Targets of tamper attempts.
+const (
+	tamperTargetCRD        = "CustomResourceDefinition"
+	tamperTargetDeployment = "Deployment"
+	tamperTargetAnnotation = "annotation"
+	tamperTargetRequest    = "ApprovalRequest"
+)
+
+// tamperAttempts counts changes denied by self-protection.
+var tamperAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "kausality_tamper_attempts_total",
+	Help: "Changes of kausality's own resources and of its bookkeeping annotations denied by self-protection, by target and operation.",
+}, []string{"target", "operation"})
+
+func init() {
+	metrics.Registry.MustRegister(tamperAttempts)
+}
+
+// ProtectionHandler returns the handler of the self-protection webhook. It
+// denies tamper attempts like the admission webhook and admits all other
+// requests without evaluation.
+func (h *Handler) ProtectionHandler() admission.Handler {
+	return admission.HandlerFunc(func(_ context.Context, req admission.Request) admission.Response {
+		resp := admission.Allowed("not protected")
+		if denial := h.protect(req); denial != nil {
+			resp = *denial
+		}
+		resp = completeAuditAnnotations(resp)
+		h.archiveDenial(req, resp)
+		return prefixAuditAnnotations(resp, h.config.AuditKeyPrefix())
+	})
+}
+
+// protect denies changes of kausality's own CRDs and Deployments, and
+// changes of its bookkeeping annotations, by identities other than
+// kausality and those designated by self-protection, whatever the policies.
+// An attacker would disable kausality or erase its records first. It returns
+// nil if self-protection is disabled or the request is no tamper attempt.
+func (h *Handler) protect(req admission.Request) *admission.Response {
+	sp := h.config.SelfProtection
+	if sp == nil || h.designated(sp, req.UserInfo) {
+		return nil
+	}
+	target, msg := tamperTarget(sp, req)
+	if target == "" {
+		return nil
+	}
+
+	tamperAttempts.WithLabelValues(target, string(req.Operation)).Inc()
+	h.log.Info("TAMPER ATTEMPT DENIED",
+		"operation", req.Operation,
+		"kind", req.Kind.String(),
+		"namespace", req.Namespace,
+		"name", req.Name,
+		"subresource", req.SubResource,
+		"user", req.UserInfo.Username,
+		"target", target,
+	)
+	obj, err := h.parseObject(req)
+	if err != nil {
+		resp := admission.Denied(msg)
+		return &resp
+	}
+	resp := denied(kausalityv1alpha1.DenialReasonProtected, msg, req, obj, nil)
+	return &resp
+}
+
+// designated returns whether user is kausality itself or designated by
+// self-protection.
+func (h *Handler) designated(sp *config.SelfProtectionConfig, user authenticationv1.UserInfo) bool {
+	if slices.Contains(h.selfUsers, user.Username) || slices.Contains(sp.Users, user.Username) {
+		return true
+	}
+	return slices.ContainsFunc(user.Groups, func(group string) bool {
+		return slices.Contains(sp.Groups, group)
+	})
+}
+
+// tamperTarget returns the target of a tamper attempt and the denial
+// message, or an empty target if req is none: spec changes and deletions of
+// the kausality.io CRDs and of kausality's Deployments, scaling included,
+// changes of bookkeeping annotations, and creations of ApprovalRequests,
+// whose decisions kausality applies to parents. Status updates are never
+// tamper attempts.
+func tamperTarget(sp *config.SelfProtectionConfig, req admission.Request) (string, string) {
+	if gr := req.Resource; req.Operation == admissionv1.Create && gr.Group == kausalityv1alpha1.GroupVersion.Group && gr.Resource == "approvalrequests" {
+		return tamperTargetRequest, fmt.Sprintf("[kausality] ApprovalRequest %s/%s is protected: only kausality and designated identities may create ApprovalRequests", req.Namespace, req.Name)
+	}
+	if req.Operation != admissionv1.Update && req.Operation != admissionv1.Delete {
+		return "", ""
+	}
+	changed := req.Operation == admissionv1.Delete || specChanged(req)
+
+	switch gr := req.Resource; {
+	case gr.Group == "apiextensions.k8s.io" && gr.Resource == "customresourcedefinitions" &&
+		strings.HasSuffix(req.Name, "."+kausalityv1alpha1.GroupVersion.Group) && req.SubResource == "" && changed:
+		return tamperTargetCRD, fmt.Sprintf("[kausality] CustomResourceDefinition %s is protected: only kausality and designated identities may change or delete it", req.Name)
+	case gr.Group == "apps" && gr.Resource == "deployments" && sp.Namespace != "" && req.Namespace == sp.Namespace &&
+		slices.Contains(sp.Deployments, req.Name) && (req.SubResource == "" || req.SubResource == "scale") && changed:
+		return tamperTargetDeployment, fmt.Sprintf("[kausality] Deployment %s/%s is protected: only kausality and designated identities may change, scale or delete it", req.Namespace, req.Name)
+	}
+
+	if req.Operation != admissionv1.Update || req.SubResource != "" {
+		return "", ""
+	}
+	annotations := changedBookkeeping(req)
+	if len(annotations) == 0 {
+		return "", ""
+	}
+	return tamperTargetAnnotation, fmt.Sprintf("[kausality] annotations %s are protected: only kausality and designated identities may set, change or remove them", strings.Join(annotations, ", "))
+}
+
+// changedBookkeeping returns the bookkeeping annotations an update adds,
+// changes or removes, sorted. Values kausality patched in earlier in the
+// same admission, which a reinvocation of the webhook sees, are no changes.
+func changedBookkeeping(req admission.Request) []string {
+	oldAnnotations := rawAnnotations(req.OldObject.Raw)
+	newAnnotations := rawAnnotations(req.Object.Raw)
+	var changed []string
+	for _, key := range kausalityv1alpha1.BookkeepingAnnotations {
+		oldValue, hadOld := oldAnnotations[key]
+		newValue, hasNew := newAnnotations[key]
+		if hadOld == hasNew && oldValue == newValue {
+			continue
+		}
+		if hasNew && admittedValue(req, key, oldValue, newValue, newAnnotations) {
+			continue
+		}
+		changed = append(changed, key)
+	}
+	slices.Sort(changed)
+	return changed
+}
+
+// admittedValue returns whether value is the one the mutating webhook writes
+// for key in req: traces whose final hop was appended for req, summaries
+// along with them, updaters extended by the requester and approved specs of
+// the object. Request UIDs are assigned by the API server and stay the same
+// when the webhook is reinvoked, so they cannot be forged in advance.
+func admittedValue(req admission.Request, key, oldValue, value string, annotations map[string]string) bool {
+	switch key {
+	case kausalityv1alpha1.TraceAnnotation, kausalityv1alpha1.SummaryAnnotation:
+		tr, err := trace.Parse(annotations[kausalityv1alpha1.TraceAnnotation])
+		return err == nil && len(tr) > 0 && req.UID != "" && tr[len(tr)-1].RequestUID == string(req.UID)
+	case kausalityv1alpha1.UpdatersAnnotation:
+		userID := controller.UserIdentifier(req.UserInfo.Username, req.UserInfo.UID)
+		return value == addHash(oldValue, controller.HashUsername(userID))
+	case kausalityv1alpha1.ApprovedSpecAnnotation:
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(req.Object.Raw); err != nil {
+			return false
+		}
+		spec, err := drift.ApprovedSpec(obj)
+		return err == nil && value == spec
+	}
+	return false
+}
+
+// specChanged returns whether an update changes the spec of the object.
+func specChanged(req admission.Request) bool {
+	var newObj, oldObj struct {
+		Spec interface{} `json:"spec"`
+	}
+	if json.Unmarshal(req.Object.Raw, &newObj) != nil || json.Unmarshal(req.OldObject.Raw, &oldObj) != nil {
+		return true
+	}
+	return !reflect.DeepEqual(newObj.Spec, oldObj.Spec)
+}
@@ -0,0 +1,247 @@
+package admission
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/config"
+	"github.com/kausality-io/kausality/pkg/controller"
+	"github.com/kausality-io/kausality/pkg/testing/scenario"
+	"github.com/kausality-io/kausality/pkg/trace"
+)
+
+var (
+	crdGVK         = schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"}
+	crdResource    = metav1.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+	deployResource = metav1.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+)
+
+// protectionRequest builds a request of resource changing oldSpec to
+// newSpec, or deleting the object if newSpec is nil.
+func protectionRequest(resource metav1.GroupVersionResource, gvk schema.GroupVersionKind, namespace, name string, oldSpec, newSpec map[string]interface{}, user string, groups ...string) admission.Request {
+	old := scenario.Object(gvk, namespace, name, oldSpec)
+	op := admissionv1.Update
+	obj := scenario.Object(gvk, namespace, name, newSpec)
+	if newSpec == nil {
+		op = admissionv1.Delete
+		obj = old
+	}
+	req := buildAdmissionRequest(op, obj, old, user)
+	if op == admissionv1.Delete {
+		req.Object = runtime.RawExtension{}
+	}
+	req.Resource = resource
+	req.UserInfo.Groups = groups
+	return req
+}
+
+func TestHandle_SelfProtection(t *testing.T) {
+	sp := &config.SelfProtectionConfig{
+		Namespace:   "kausality-system",
+		Deployments: []string{"kausality-webhook"},
+		Users:       []string{"ci"},
+		Groups:      []string{"break-glass"},
+	}
+	replicas := func(n int64) map[string]interface{} { return map[string]interface{}{"replicas": n} }
+	createRequest := func(user string) admission.Request {
+		gvk := kausalityv1alpha1.GroupVersion.WithKind("ApprovalRequest")
+		req := buildAdmissionRequest(admissionv1.Create, scenario.Object(gvk, "prod", "drift-abc-4", nil), nil, user)
+		req.Resource = metav1.GroupVersionResource{Group: gvk.Group, Version: gvk.Version, Resource: "approvalrequests"}
+		return req
+	}
+
+	tests := []struct {
+		name       string
+		req        admission.Request
+		wantDenied bool
+	}{
+		{
+			name:       "kausality CRD deletion",
+			req:        protectionRequest(crdResource, crdGVK, "", "kausalities.kausality.io", replicas(1), nil, "mallory"),
+			wantDenied: true,
+		},
+		{
+			name:       "kausality CRD spec change",
+			req:        protectionRequest(crdResource, crdGVK, "", "kausalities.kausality.io", replicas(1), replicas(2), "mallory"),
+			wantDenied: true,
+		},
+		{
+			name: "kausality CRD metadata change",
+			req:  protectionRequest(crdResource, crdGVK, "", "kausalities.kausality.io", replicas(1), replicas(1), "mallory"),
+		},
+		{
+			name: "other CRD deletion",
+			req:  protectionRequest(crdResource, crdGVK, "", "widgets.example.com", replicas(1), nil, "mallory"),
+		},
+		{
+			name:       "scaling the webhook down",
+			req:        protectionRequest(deployResource, scenario.DeploymentGVK, "kausality-system", "kausality-webhook", replicas(2), replicas(0), "mallory"),
+			wantDenied: true,
+		},
+		{
+			name: "scaling the webhook by a designated user",
+			req:  protectionRequest(deployResource, scenario.DeploymentGVK, "kausality-system", "kausality-webhook", replicas(2), replicas(0), "ci"),
+		},
+		{
+			name: "deleting the webhook by a designated group",
+			req:  protectionRequest(deployResource, scenario.DeploymentGVK, "kausality-system", "kausality-webhook", replicas(2), nil, "admin", "break-glass"),
+		},
+		{
+			name: "other Deployment in the namespace",
+			req:  protectionRequest(deployResource, scenario.DeploymentGVK, "kausality-system", "backend", replicas(2), nil, "mallory"),
+		},
+		{
+			name:       "forged ApprovalRequest",
+			req:        createRequest("mallory"),
+			wantDenied: true,
+		},
+		{
+			name: "ApprovalRequest by a designated user",
+			req:  createRequest("ci"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler()
+			h.config.SelfProtection = sp
+
+			resp := h.ProtectionHandler().Handle(context.Background(), tt.req)
+			require.NotNil(t, resp.Result)
+			assert.Equal(t, !tt.wantDenied, resp.Allowed, "response: %+v", resp.Result)
+			if tt.wantDenied {
+				assert.Equal(t, string(kausalityv1alpha1.DenialReasonProtected), resp.AuditAnnotations[auditKeyDenialReason])
+			}
+		})
+	}
+}
+
+func TestHandle_SelfProtectionAnnotations(t *testing.T) {
+	// Traces with a final hop of the admitted request, "test-uid-1", or another one
+	admitted := trace.Trace{trace.NewHop("v1", "ConfigMap", "cm", 1, "mallory", "test-uid-1")}.String()
+	forged := trace.Trace{trace.NewHop("v1", "ConfigMap", "cm", 1, "alice", "other-request")}.String()
+	mallory := controller.HashUsername(controller.UserIdentifier("mallory", "mallory-uid"))
+
+	tests := []struct {
+		name       string
+		old        map[string]string
+		new        map[string]string
+		user       string
+		protection bool
+		wantDenied string
+	}{
+		{
+			name:       "removing the trace is denied",
+			old:        map[string]string{kausalityv1alpha1.TraceAnnotation: "[]", kausalityv1alpha1.FreezeAnnotation: "true"},
+			new:        map[string]string{kausalityv1alpha1.FreezeAnnotation: "true"},
+			user:       "mallory",
+			protection: true,
+			wantDenied: kausalityv1alpha1.TraceAnnotation,
+		},
+		{
+			name:       "removing user annotations is allowed",
+			old:        map[string]string{kausalityv1alpha1.TraceAnnotation: "[]", kausalityv1alpha1.FreezeAnnotation: "true"},
+			new:        map[string]string{kausalityv1alpha1.TraceAnnotation: "[]"},
+			user:       "mallory",
+			protection: true,
+		},
+		{
+			name:       "forging the controllers is denied",
+			old:        map[string]string{kausalityv1alpha1.ControllersAnnotation: "abc"},
+			new:        map[string]string{kausalityv1alpha1.ControllersAnnotation: mallory},
+			user:       "mallory",
+			protection: true,
+			wantDenied: kausalityv1alpha1.ControllersAnnotation,
+		},
+		{
+			name:       "setting a phase is denied",
+			new:        map[string]string{kausalityv1alpha1.PhaseAnnotation: "ready"},
+			user:       "mallory",
+			protection: true,
+			wantDenied: kausalityv1alpha1.PhaseAnnotation,
+		},
+		{
+			name:       "forging the trace is denied",
+			old:        map[string]string{kausalityv1alpha1.TraceAnnotation: "[]"},
+			new:        map[string]string{kausalityv1alpha1.TraceAnnotation: forged},
+			user:       "mallory",
+			protection: true,
+			wantDenied: kausalityv1alpha1.TraceAnnotation,
+		},
+		{
+			name:       "forging updaters is denied",
+			old:        map[string]string{kausalityv1alpha1.UpdatersAnnotation: "abc"},
+			new:        map[string]string{kausalityv1alpha1.UpdatersAnnotation: "def"},
+			user:       "mallory",
+			protection: true,
+			wantDenied: kausalityv1alpha1.UpdatersAnnotation,
+		},
+		{
+			name:       "forging an approved spec is denied",
+			new:        map[string]string{kausalityv1alpha1.ApprovedSpecAnnotation: `{"replicas":3}`},
+			user:       "mallory",
+			protection: true,
+			wantDenied: kausalityv1alpha1.ApprovedSpecAnnotation,
+		},
+		{
+			name: "values patched in earlier in the admission are allowed",
+			old:  map[string]string{kausalityv1alpha1.TraceAnnotation: "[]", kausalityv1alpha1.UpdatersAnnotation: "abc"},
+			new: map[string]string{
+				kausalityv1alpha1.TraceAnnotation:    admitted,
+				kausalityv1alpha1.SummaryAnnotation:  "origin: ConfigMap/cm",
+				kausalityv1alpha1.UpdatersAnnotation: "abc," + mallory,
+			},
+			user:       "mallory",
+			protection: true,
+		},
+		{
+			name:       "designated users may remove bookkeeping",
+			old:        map[string]string{kausalityv1alpha1.UpdatersAnnotation: "abc"},
+			user:       "ci",
+			protection: true,
+		},
+		{
+			name:       "designated users may change bookkeeping",
+			old:        map[string]string{kausalityv1alpha1.ControllersAnnotation: "abc"},
+			new:        map[string]string{kausalityv1alpha1.ControllersAnnotation: "def"},
+			user:       "ci",
+			protection: true,
+		},
+		{
+			name: "without self-protection removals are allowed",
+			old:  map[string]string{kausalityv1alpha1.UpdatersAnnotation: "abc"},
+			user: "mallory",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler()
+			if tt.protection {
+				h.config.SelfProtection = &config.SelfProtectionConfig{Users: []string{"ci"}}
+			}
+
+			old := scenario.Object(configMapGVK, "default", "cm", map[string]interface{}{"data": "value"})
+			old.SetAnnotations(tt.old)
+			obj := scenario.Object(configMapGVK, "default", "cm", map[string]interface{}{"data": "value"})
+			obj.SetAnnotations(tt.new)
+			resp := h.Handle(context.Background(), buildAdmissionRequest(admissionv1.Update, obj, old, tt.user))
+
+			assert.Equal(t, tt.wantDenied == "", resp.Allowed, "response: %+v", resp.Result)
+			if tt.wantDenied != "" {
+				assert.Equal(t, fmt.Sprintf("[kausality] annotations %s are protected: only kausality and designated identities may set, change or remove them", tt.wantDenied), resp.Result.Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package admission
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+	"github.com/kausality-io/kausality/pkg/drift"
+)
+
+// quarantines counts children quarantined on denied drift.
+var quarantines = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "kausality_admission_quarantines_total",
+	Help: "Children reverted to their approved spec because their drift was denied after someone else than their controller changed them.",
+})
+
+func init() {
+	metrics.Registry.MustRegister(quarantines)
+}
+
+// quarantine reverts the child of denied drift to its approved spec if its
+// policy quarantines drift and someone else than its controller changed the
+// child since. It returns the note for the denial message, or "".
+func (h *Handler) quarantine(ctx context.Context, req admission.Request, action kausalityv1beta1.DriftAction, log logr.Logger) string {
+	if action != kausalityv1beta1.DriftActionQuarantine || h.quarantiner == nil || req.Operation != admissionv1.Update {
+		return ""
+	}
+	if req.DryRun != nil && *req.DryRun {
+		return ""
+	}
+	oldObj := &unstructured.Unstructured{}
+	if err := runtime.DecodeInto(unstructured.UnstructuredJSONScheme, req.OldObject.Raw, oldObj); err != nil {
+		return ""
+	}
+	if !drift.DivergesFromApprovedSpec(oldObj) {
+		return ""
+	}
+	quarantines.Inc()
+	log.Info("DRIFT QUARANTINED")
+	h.quarantiner.Quarantine(ctx, oldObj)
+	return " (quarantined: reverting to the last approved spec)"
+}
+
+// approvedSpec returns the value of the approved spec annotation of an
+// admitted mutation, and false to remove it. For policies quarantining
+// drift, it is the spec written by the child's controller without
+// unapproved drift, or else the old value, so that others cannot change it.
+func (h *Handler) approvedSpec(req admission.Request, obj *unstructured.Unstructured, p objectPolicy,
+	driftResult *drift.DriftResult, actor drift.Actor, audit map[string]string) (string, bool) {
+	if p.driftAction != kausalityv1beta1.DriftActionQuarantine || driftResult.ParentState == nil {
+		return "", false
+	}
+	switch audit[kausalityv1alpha1.AuditKeyDriftResolution] {
+	case "", "approved", "overridden":
+		if isController, _ := p.classifier.IsController(driftResult.ParentState, actor); isController {
+			if spec, err := drift.ApprovedSpec(obj); err == nil && spec != "" {
+				return spec, true
+			}
+		}
+	}
+	if req.Operation != admissionv1.Update {
+		return "", false
+	}
+	oldObj := &unstructured.Unstructured{}
+	if err := runtime.DecodeInto(unstructured.UnstructuredJSONScheme, req.OldObject.Raw, oldObj); err != nil {
+		return "", false
+	}
+	old, ok := oldObj.GetAnnotations()[drift.ApprovedSpecAnnotation]
+	return old, ok
+}
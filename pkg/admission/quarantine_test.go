@@ -0,0 +1,140 @@
+package admission
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	jsonpatch "gomodules.xyz/jsonpatch/v2"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+	"github.com/kausality-io/kausality/pkg/controller"
+	"github.com/kausality-io/kausality/pkg/drift"
+	"github.com/kausality-io/kausality/pkg/policy"
+)
+
+// recordingQuarantiner records quarantined children.
+type recordingQuarantiner struct {
+	children []client.Object
+}
+
+func (q *recordingQuarantiner) Quarantine(_ context.Context, child client.Object) {
+	q.children = append(q.children, child)
+}
+
+// approvedSpecPatch returns the patch of the approved spec annotation, or nil.
+func approvedSpecPatch(patches []jsonpatch.JsonPatchOperation) *jsonpatch.JsonPatchOperation {
+	for i := range patches {
+		if patches[i].Path == "/metadata/annotations/"+strings.ReplaceAll(drift.ApprovedSpecAnnotation, "/", "~1") {
+			return &patches[i]
+		}
+		if value, ok := patches[i].Value.(map[string]string); ok {
+			if spec, ok := value[drift.ApprovedSpecAnnotation]; ok {
+				return &jsonpatch.JsonPatchOperation{Operation: "add", Path: patches[i].Path, Value: spec}
+			}
+		}
+	}
+	return nil
+}
+
+func TestHandle_Quarantine(t *testing.T) {
+	const rsController = "system:serviceaccount:kube-system:deployment-controller"
+	// A Deployment at generation, observed at generation 1
+	deploy := func(generation int64) *unstructured.Unstructured {
+		return buildUnstructured(deploymentGVK, "default", "web", map[string]interface{}{"replicas": int64(1)},
+			withUID("web-uid"), withGeneration(generation), withStatus(map[string]interface{}{"observedGeneration": int64(1)}),
+			withAnnotations(map[string]string{
+				controller.ControllersAnnotation: controller.HashUsername(rsController),
+				controller.PhaseAnnotation:       controller.PhaseValueInitialized,
+			}))
+	}
+	newHandler := func(action kausalityv1beta1.DriftAction, generation int64) (*Handler, *recordingQuarantiner) {
+		store := policy.NewStore(nil, logr.Discard())
+		store.Update([]kausalityv1beta1.Kausality{{
+			ObjectMeta: metav1.ObjectMeta{Name: "replicasets"},
+			Spec: kausalityv1beta1.KausalitySpec{
+				Resources:   []kausalityv1beta1.ResourceRule{{APIGroups: []string{"apps"}, Resources: []string{"replicasets"}}},
+				Mode:        kausalityv1beta1.ModeEnforce,
+				DriftAction: action,
+			},
+		}})
+		quarantiner := &recordingQuarantiner{}
+		c := fake.NewClientBuilder().WithScheme(runtime.NewScheme()).WithRuntimeObjects(deploy(generation)).Build()
+		return NewHandler(Config{Client: c, Log: logr.Discard(), PolicyResolver: store, Quarantiner: quarantiner}), quarantiner
+	}
+	rs := func(replicas int64, approvedSpec string) *unstructured.Unstructured {
+		opts := []func(*unstructured.Unstructured){withOwnerRef(deploymentGVK, "web", "web-uid")}
+		if approvedSpec != "" {
+			opts = append(opts, withAnnotations(map[string]string{drift.ApprovedSpecAnnotation: approvedSpec}))
+		}
+		return buildUnstructured(replicaSetGVK, "default", "web-abc", map[string]interface{}{"replicas": int64(replicas)}, opts...)
+	}
+
+	t.Run("quarantine", func(t *testing.T) {
+		ctx := context.Background()
+
+		// The controller's writes while reconciling record the approved spec
+		reconciling, _ := newHandler(kausalityv1beta1.DriftActionQuarantine, 2)
+		resp := reconciling.Handle(ctx, buildAdmissionRequest(admissionv1.Create, rs(1, ""), nil, rsController))
+		require.True(t, resp.Allowed)
+		patch := approvedSpecPatch(resp.Patches)
+		require.NotNil(t, patch)
+		assert.Equal(t, `{"replicas":1}`, patch.Value)
+
+		h, quarantiner := newHandler(kausalityv1beta1.DriftActionQuarantine, 1)
+
+		// Others cannot change it
+		resp = h.Handle(ctx, buildAdmissionRequest(admissionv1.Update, rs(3, `{"replicas":3}`), rs(1, `{"replicas":1}`), "alice"))
+		require.True(t, resp.Allowed)
+		patch = approvedSpecPatch(resp.Patches)
+		require.NotNil(t, patch)
+		assert.Equal(t, "replace", patch.Operation)
+		assert.Equal(t, `{"replicas":1}`, patch.Value)
+
+		// The controller's correction is drift: denied, and the child reverted
+		resp = h.Handle(ctx, buildAdmissionRequest(admissionv1.Update, rs(1, `{"replicas":1}`), rs(3, `{"replicas":1}`), rsController))
+		require.False(t, resp.Allowed)
+		assert.Contains(t, resp.Result.Message, "quarantined: reverting to the last approved spec")
+		require.Len(t, quarantiner.children, 1)
+		assert.Equal(t, "web-abc", quarantiner.children[0].GetName())
+
+		// Drift of a child with its approved spec is only denied
+		resp = h.Handle(ctx, buildAdmissionRequest(admissionv1.Update, rs(2, `{"replicas":1}`), rs(1, `{"replicas":1}`), rsController))
+		require.False(t, resp.Allowed)
+		assert.NotContains(t, resp.Result.Message, "quarantined")
+		assert.Len(t, quarantiner.children, 1)
+	})
+
+	t.Run("deny", func(t *testing.T) {
+		ctx := context.Background()
+
+		reconciling, _ := newHandler("", 2)
+		resp := reconciling.Handle(ctx, buildAdmissionRequest(admissionv1.Create, rs(1, ""), nil, rsController))
+		require.True(t, resp.Allowed)
+		assert.Nil(t, approvedSpecPatch(resp.Patches))
+
+		h, quarantiner := newHandler("", 1)
+
+		// Approved specs are removed
+		resp = h.Handle(ctx, buildAdmissionRequest(admissionv1.Update, rs(3, `{"replicas":3}`), rs(1, ""), "alice"))
+		require.True(t, resp.Allowed)
+		patch := approvedSpecPatch(resp.Patches)
+		require.NotNil(t, patch)
+		assert.Equal(t, "remove", patch.Operation)
+
+		resp = h.Handle(ctx, buildAdmissionRequest(admissionv1.Update, rs(1, ""), rs(3, `{"replicas":1}`), rsController))
+		require.False(t, resp.Allowed)
+		assert.NotContains(t, resp.Result.Message, "quarantined")
+		assert.Empty(t, quarantiner.children)
+	})
+}
@@ -0,0 +1,61 @@
+package admission
+
+import (
+	"context"
+	"path"
+
+	"github.com/go-logr/logr"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+)
+
+// reportLabels returns the labels of the namespace and the parent allowlisted
+// by the reportLabels configuration, or nil if none are configured or
+// match. The namespace is the child's, or the parent's for cluster-scoped
+// children. Reports are sent without namespace labels if the namespace
+// cannot be read.
+func (h *Handler) reportLabels(ctx context.Context, child, parent client.Object, log logr.Logger) *v1alpha1.ReportLabels {
+	cfg := h.config.ReportLabels
+	if cfg == nil {
+		return nil
+	}
+	labels := &v1alpha1.ReportLabels{}
+	namespace := child.GetNamespace()
+	if namespace == "" && parent != nil {
+		namespace = parent.GetNamespace()
+	}
+	if len(cfg.Namespace) > 0 && namespace != "" {
+		nsLabels, _, err := h.getNamespaceMetadata(ctx, namespace)
+		if err != nil {
+			log.V(1).Info("failed to get namespace labels for drift report", "namespace", namespace, "error", err)
+		}
+		labels.Namespace = selectLabels(nsLabels, cfg.Namespace)
+	}
+	if parent != nil {
+		labels.Parent = selectLabels(parent.GetLabels(), cfg.Parent)
+	}
+	if labels.Namespace == nil && labels.Parent == nil {
+		return nil
+	}
+	return labels
+}
+
+// selectLabels returns the labels whose keys match one of the patterns of
+// path.Match, or nil if none do.
+func selectLabels(labels map[string]string, patterns []string) map[string]string {
+	var selected map[string]string
+	for key, value := range labels {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, key); ok {
+				if selected == nil {
+					selected = make(map[string]string)
+				}
+				selected[key] = value
+				break
+			}
+		}
+	}
+	return selected
+}
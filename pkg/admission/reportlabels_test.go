@@ -0,0 +1,59 @@
+package admission
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/config"
+	"github.com/kausality-io/kausality/pkg/testing/scenario"
+)
+
+func TestReportLabels(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "payments", Labels: map[string]string{
+		"team": "payments", "env": "prod", "kubernetes.io/metadata.name": "payments",
+	}}}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(ns).Build()
+	h := NewHandler(Config{Client: c, Log: logr.Discard()})
+
+	s := scenario.NewParentChild().
+		WithParent(scenario.DeploymentGVK, "api").
+		WithChild(scenario.ReplicaSetGVK, "api-abc").
+		InNamespace("payments")
+	parent, child := s.Parent(), s.Child()
+	parent.SetLabels(map[string]string{"app": "api", "example.com/cost-center": "cc-42"})
+
+	// Without allowlist, no labels are reported
+	assert.Nil(t, h.reportLabels(context.Background(), child, parent, logr.Discard()))
+
+	h.config = &config.Config{ReportLabels: &config.ReportLabelsConfig{
+		Namespace: []string{"team", "env"},
+		Parent:    []string{"example.com/*"},
+	}}
+	want := &v1alpha1.ReportLabels{
+		Namespace: map[string]string{"team": "payments", "env": "prod"},
+		Parent:    map[string]string{"example.com/cost-center": "cc-42"},
+	}
+	assert.Equal(t, want, h.reportLabels(context.Background(), child, parent, logr.Discard()))
+
+	// Cluster-scoped children use the namespace of the parent; orphans have no parent
+	clusterChild := &unstructured.Unstructured{}
+	clusterChild.SetName("node-config")
+	assert.Equal(t, want, h.reportLabels(context.Background(), clusterChild, parent, logr.Discard()))
+	assert.Equal(t, &v1alpha1.ReportLabels{Namespace: want.Namespace}, h.reportLabels(context.Background(), child, nil, logr.Discard()))
+
+	// A missing namespace and no matching parent labels report nothing
+	other := scenario.Object(scenario.ReplicaSetGVK, "missing", "web-abc", nil)
+	assert.Nil(t, h.reportLabels(context.Background(), other, nil, logr.Discard()))
+}
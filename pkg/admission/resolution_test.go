@@ -0,0 +1,161 @@
+package admission
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/controller"
+)
+
+const deploymentController = "system:serviceaccount:kube-system:deployment-controller"
+
+// recordingSender records drift reports instead of sending them.
+type recordingSender struct {
+	mu       sync.Mutex
+	reports  []*v1alpha1.DriftReport
+	resolved []string
+}
+
+func (s *recordingSender) SendAsync(_ context.Context, report *v1alpha1.DriftReport) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reports = append(s.reports, report)
+}
+
+func (s *recordingSender) IsEnabled() bool { return true }
+
+func (s *recordingSender) MarkResolved(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resolved = append(s.resolved, id)
+}
+
+func (s *recordingSender) StartCleanup(time.Duration) func() { return func() {} }
+
+func (s *recordingSender) last() *v1alpha1.DriftReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.reports) == 0 {
+		return nil
+	}
+	return s.reports[len(s.reports)-1]
+}
+
+// newResolutionTestHandler creates a handler with a parent Deployment whose
+// generation and observedGeneration are given.
+func newResolutionTestHandler(generation, observedGeneration int64) (*Handler, *recordingSender) {
+	parent := buildUnstructured(deploymentGVK, "default", "app",
+		map[string]interface{}{"replicas": int64(1)},
+		withUID("app-uid"),
+		withGeneration(generation),
+		withAnnotations(map[string]string{
+			controller.PhaseAnnotation:       controller.PhaseValueInitialized,
+			controller.ControllersAnnotation: controller.HashUsername(deploymentController),
+		}),
+		withStatus(map[string]interface{}{"observedGeneration": observedGeneration}),
+	)
+	sender := &recordingSender{}
+	c := fake.NewClientBuilder().WithScheme(runtime.NewScheme()).WithRuntimeObjects(parent).Build()
+	return NewHandler(Config{Client: c, Log: logr.Discard(), CallbackSender: sender}), sender
+}
+
+func childRS(replicas int64, updaters string) *unstructured.Unstructured {
+	extras := []func(*unstructured.Unstructured){withOwnerRef(deploymentGVK, "app", "app-uid"), withUID("rs-uid")}
+	if updaters != "" {
+		extras = append(extras, withAnnotations(map[string]string{controller.UpdatersAnnotation: updaters}))
+	}
+	return buildUnstructured(replicaSetGVK, "default", "app-abc", map[string]interface{}{"replicas": replicas}, extras...)
+}
+
+// driftChild makes the controller change the child's replicas from 1 to 3
+// while the parent is stable, and returns the Detected report.
+func driftChild(t *testing.T, h *Handler, sender *recordingSender) *v1alpha1.DriftReport {
+	t.Helper()
+	ctrlHash := controller.HashUsername(deploymentController)
+	resp := h.Handle(context.Background(), buildAdmissionRequest(admissionv1.Update, childRS(3, ""), childRS(1, ctrlHash), deploymentController))
+	require.True(t, resp.Allowed)
+	detected := sender.last()
+	require.NotNil(t, detected)
+	require.Equal(t, v1alpha1.DriftReportPhaseDetected, detected.Spec.Phase)
+	return detected
+}
+
+func TestResolution_ManuallyReverted(t *testing.T) {
+	h, sender := newResolutionTestHandler(1, 1)
+	ctx := context.Background()
+	detected := driftChild(t, h, sender)
+	ctrlHash := controller.HashUsername(deploymentController)
+
+	// An unrelated change by someone else leaves the drift open
+	resp := h.Handle(ctx, buildAdmissionRequest(admissionv1.Update, childRS(4, ""), childRS(3, ctrlHash), "admin"))
+	require.True(t, resp.Allowed)
+	assert.Len(t, sender.reports, 1)
+
+	// Restoring the spec from before the drift resolves it
+	resp = h.Handle(ctx, buildAdmissionRequest(admissionv1.Update, childRS(1, ""), childRS(4, ctrlHash), "admin"))
+	require.True(t, resp.Allowed)
+	require.Len(t, sender.reports, 2)
+
+	resolved := sender.last()
+	assert.Equal(t, v1alpha1.DriftReportPhaseResolved, resolved.Spec.Phase)
+	require.NotNil(t, resolved.Spec.Resolution)
+	assert.Equal(t, v1alpha1.ResolutionManuallyReverted, resolved.Spec.Resolution.Kind)
+	assert.Equal(t, []string{detected.Spec.ID}, resolved.Spec.Resolution.DetectedIDs)
+	assert.Equal(t, "admin", resolved.Spec.Request.User)
+	assert.Equal(t, []string{detected.Spec.ID}, sender.resolved)
+
+	// Resolved only once
+	resp = h.Handle(ctx, buildAdmissionRequest(admissionv1.Update, childRS(2, ""), childRS(1, ctrlHash), "admin"))
+	require.True(t, resp.Allowed)
+	resp = h.Handle(ctx, buildAdmissionRequest(admissionv1.Update, childRS(1, ""), childRS(2, ctrlHash), "admin"))
+	require.True(t, resp.Allowed)
+	assert.Len(t, sender.reports, 2)
+}
+
+func TestResolution_ObjectDeleted(t *testing.T) {
+	h, sender := newResolutionTestHandler(1, 1)
+	detected := driftChild(t, h, sender)
+
+	req := buildAdmissionRequest(admissionv1.Delete, childRS(3, ""), nil, "admin")
+	req.OldObject = req.Object
+	req.Object = runtime.RawExtension{}
+	require.True(t, h.Handle(context.Background(), req).Allowed)
+
+	resolved := sender.last()
+	require.Equal(t, v1alpha1.DriftReportPhaseResolved, resolved.Spec.Phase)
+	assert.Equal(t, v1alpha1.ResolutionObjectDeleted, resolved.Spec.Resolution.Kind)
+	assert.Equal(t, []string{detected.Spec.ID}, resolved.Spec.Resolution.DetectedIDs)
+	assert.Equal(t, req.OldObject.Raw, resolved.Spec.NewObject.Raw, "deleted object is reported")
+}
+
+func TestResolution_ControllerCorrected(t *testing.T) {
+	// Parent changed since the drift: the controller reconciles the child
+	h, sender := newResolutionTestHandler(2, 1)
+	detected := &v1alpha1.DriftReport{Spec: v1alpha1.DriftReportSpec{
+		ID:    "detected-id",
+		Child: v1alpha1.ObjectReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Namespace: "default", Name: "app-abc"},
+	}}
+	h.resolutions.Open(detected, []byte(`{"replicas":1}`))
+
+	ctrlHash := controller.HashUsername(deploymentController)
+	resp := h.Handle(context.Background(), buildAdmissionRequest(admissionv1.Update, childRS(5, ""), childRS(3, ctrlHash), deploymentController))
+	require.True(t, resp.Allowed)
+
+	resolved := sender.last()
+	require.NotNil(t, resolved)
+	assert.Equal(t, v1alpha1.DriftReportPhaseResolved, resolved.Spec.Phase)
+	assert.Equal(t, v1alpha1.ResolutionControllerCorrected, resolved.Spec.Resolution.Kind)
+	assert.Equal(t, []string{"detected-id"}, resolved.Spec.Resolution.DetectedIDs)
+}
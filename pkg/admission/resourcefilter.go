@@ -0,0 +1,50 @@
+package admission
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+)
+
+// filteredRequests counts requests admitted without evaluation by the resource filter.
+var filteredRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "kausality_admission_filtered_requests_total",
+	Help: "Requests admitted without drift detection by the resource filter, by API group, resource and reason (denied, untracked).",
+}, []string{"group", "resource", "reason"})
+
+func init() {
+	metrics.Registry.MustRegister(filteredRequests)
+}
+
+// filterResource returns why the configured resource filter admits a
+// request without evaluation, "" if the request is evaluated. Webhook rules
+// are derived from policies, but requests of other resources still arrive,
+// e.g. of versions converted by matchPolicy Equivalent or with stale
+// webhook configurations. Resolving their parents would act on resources
+// kausality does not understand.
+func (h *Handler) filterResource(req admission.Request) string {
+	filter := h.config.ResourceFilter
+	if filter == nil {
+		return ""
+	}
+	gvr := schema.GroupVersionResource(req.Resource)
+	if filter.Denies(gvr) || (req.RequestResource != nil && filter.Denies(schema.GroupVersionResource(*req.RequestResource))) {
+		return kausalityv1alpha1.ResourceFilterDenied
+	}
+	if filter.RequireTracked && h.policyResolver != nil && !filter.Allows(gvr) && !h.policyResolver.TracksResource(gvr) {
+		return kausalityv1alpha1.ResourceFilterUntracked
+	}
+	return ""
+}
+
+// filteredResponse admits a request the resource filter skips, marking it
+// in the audit annotations.
+func filteredResponse(req admission.Request, reason string) admission.Response {
+	filteredRequests.WithLabelValues(req.Resource.Group, req.Resource.Resource, reason).Inc()
+	return withAuditAnnotations(admission.Allowed("resource not handled: "+reason),
+		map[string]string{kausalityv1alpha1.AuditKeyResourceFilter: reason})
+}
@@ -0,0 +1,81 @@
+package admission
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/config"
+	"github.com/kausality-io/kausality/pkg/policy"
+	"github.com/kausality-io/kausality/pkg/testing/scenario"
+)
+
+// appsResolver tracks the resources of the apps group.
+type appsResolver struct {
+	policy.StaticResolver
+}
+
+func (appsResolver) TracksResource(gvr schema.GroupVersionResource) bool {
+	return gvr.Group == "apps"
+}
+
+func TestHandle_ResourceFilter(t *testing.T) {
+	c := fake.NewClientBuilder().WithScheme(runtime.NewScheme()).Build()
+	h := NewHandler(Config{Client: c, Log: logr.Discard(), PolicyResolver: &appsResolver{StaticResolver: policy.StaticResolver{Mode: kausalityv1alpha1.ModeLog}}})
+	rs := scenario.Object(scenario.ReplicaSetGVK, "default", "web-abc", map[string]interface{}{"replicas": int64(1)})
+	cm := scenario.Object(configMapGVK, "default", "cm", map[string]interface{}{"data": "value"})
+
+	request := func(obj *unstructured.Unstructured, resource metav1.GroupVersionResource, requestResource *metav1.GroupVersionResource) admission.Response {
+		req := buildAdmissionRequest(admissionv1.Create, obj, nil, "admin")
+		req.Resource = resource
+		req.RequestResource = requestResource
+		return h.Handle(context.Background(), req)
+	}
+	replicaSets := metav1.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"}
+	configMaps := metav1.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	filtered := func(resp admission.Response) string {
+		return resp.AuditAnnotations[kausalityv1alpha1.DefaultAuditKeyPrefix+kausalityv1alpha1.AuditKeyResourceFilter]
+	}
+
+	// Without filter, everything is evaluated
+	assert.NotEmpty(t, request(cm, configMaps, nil).Patches)
+
+	h.config = &config.Config{ResourceFilter: &config.ResourceFilterConfig{
+		RequireTracked: true,
+		Deny:           []config.ResourceRuleConfig{{APIGroups: []string{"extensions"}, Versions: []string{"v1beta1"}, Resources: []string{"replicasets"}}},
+	}}
+	before := testutil.ToFloat64(filteredRequests.WithLabelValues("", "configmaps", kausalityv1alpha1.ResourceFilterUntracked))
+	resp := request(cm, configMaps, nil)
+	assert.True(t, resp.Allowed)
+	assert.Empty(t, resp.Patches, "untracked resources are not evaluated")
+	assert.Equal(t, kausalityv1alpha1.ResourceFilterUntracked, filtered(resp))
+	assert.Equal(t, before+1, testutil.ToFloat64(filteredRequests.WithLabelValues("", "configmaps", kausalityv1alpha1.ResourceFilterUntracked)))
+
+	resp = request(rs, replicaSets, nil)
+	assert.NotEmpty(t, resp.Patches, "tracked resources are evaluated")
+	assert.Empty(t, filtered(resp))
+
+	// Requests converted from denied resources by matchPolicy Equivalent
+	resp = request(rs, replicaSets, &metav1.GroupVersionResource{Group: "extensions", Version: "v1beta1", Resource: "replicasets"})
+	assert.True(t, resp.Allowed)
+	assert.Empty(t, resp.Patches)
+	assert.Equal(t, kausalityv1alpha1.ResourceFilterDenied, filtered(resp))
+
+	// Allowed resources are evaluated although untracked, unless denied
+	h.config.ResourceFilter.Allow = []config.ResourceRuleConfig{{APIGroups: []string{""}, Resources: []string{"*"}}}
+	assert.NotEmpty(t, request(cm, configMaps, nil).Patches)
+	h.config.ResourceFilter.Deny = append(h.config.ResourceFilter.Deny, config.ResourceRuleConfig{APIGroups: []string{""}, Resources: []string{"configmaps"}})
+	assert.Equal(t, kausalityv1alpha1.ResourceFilterDenied, filtered(request(cm, configMaps, nil)))
+}
@@ -0,0 +1,82 @@
+package admission
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// TestHandle_AdmissionReviewV1beta1 sends admission.k8s.io/v1beta1 reviews,
+// as served by clusters still registering v1beta1 webhooks, over HTTP.
+func TestHandle_AdmissionReviewV1beta1(t *testing.T) {
+	deployGVK := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	deploy := buildUnstructured(deployGVK, "default", "web", map[string]interface{}{"replicas": int64(1)})
+	raw, err := json.Marshal(deploy.Object)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(&webhook.Admission{Handler: newTestHandler()})
+	defer server.Close()
+
+	review := func(t *testing.T, req *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionReview {
+		t.Helper()
+		body, err := json.Marshal(admissionv1beta1.AdmissionReview{
+			TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1beta1", Kind: "AdmissionReview"},
+			Request:  req,
+		})
+		require.NoError(t, err)
+		resp, err := http.Post(server.URL, "application/json", bytes.NewReader(body))
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var got admissionv1beta1.AdmissionReview
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+		assert.Equal(t, "admission.k8s.io/v1beta1", got.APIVersion)
+		require.NotNil(t, got.Response)
+		assert.Equal(t, req.UID, got.Response.UID)
+		return &got
+	}
+
+	t.Run("create is traced", func(t *testing.T) {
+		got := review(t, &admissionv1beta1.AdmissionRequest{
+			UID:       "v1beta1-create",
+			Kind:      metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+			Resource:  metav1.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			Namespace: "default",
+			Name:      "web",
+			Operation: admissionv1beta1.Create,
+			UserInfo:  authenticationv1.UserInfo{Username: "alice"},
+			Object:    runtime.RawExtension{Raw: raw},
+		})
+		assert.True(t, got.Response.Allowed)
+		require.NotNil(t, got.Response.PatchType)
+		assert.Equal(t, admissionv1beta1.PatchTypeJSONPatch, *got.Response.PatchType)
+		assert.Contains(t, string(got.Response.Patch), `"kausality.io/trace"`)
+	})
+
+	t.Run("delete without old object is allowed", func(t *testing.T) {
+		got := review(t, &admissionv1beta1.AdmissionRequest{
+			UID:       "v1beta1-delete",
+			Kind:      metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+			Resource:  metav1.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			Namespace: "default",
+			Name:      "web",
+			Operation: admissionv1beta1.Delete,
+			UserInfo:  authenticationv1.UserInfo{Username: "alice"},
+		})
+		assert.True(t, got.Response.Allowed)
+		assert.Empty(t, got.Response.Patch)
+	})
+}
@@ -0,0 +1,86 @@
+package admission
+
+import (
+	"hash/fnv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/kausality-io/kausality/pkg/config"
+	"github.com/kausality-io/kausality/pkg/drift"
+)
+
+// Trace sampling decisions.
+const (
+	samplingSampled     = "sampled"
+	samplingRecentDrift = "recent-drift"
+	samplingSkipped     = "skipped"
+)
+
+// traceSamplingDecisions counts sampling decisions of sampled resources.
+var traceSamplingDecisions = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "kausality_trace_sampling_decisions_total",
+	Help: "Trace sampling decisions of sampled resources, by resource and decision (sampled, recent-drift, skipped).",
+}, []string{"resource", "decision"})
+
+func init() {
+	metrics.Registry.MustRegister(traceSamplingDecisions)
+}
+
+// traceSampler decides which objects of high-cardinality resources are traced.
+type traceSampler struct {
+	configs []config.TraceSamplingConfig
+	now     func() time.Time
+}
+
+func newTraceSampler(configs []config.TraceSamplingConfig) *traceSampler {
+	if len(configs) == 0 {
+		return nil
+	}
+	return &traceSampler{configs: configs, now: time.Now}
+}
+
+// sample returns whether the mutation of obj is traced. Resources without
+// sampling configuration are always traced. Otherwise objects whose parent
+// had recent drift are traced, and others by the sample rate.
+func (s *traceSampler) sample(req admission.Request, obj client.Object, parent *drift.ParentState) bool {
+	if s == nil {
+		return true
+	}
+	gvr := schema.GroupVersionResource{Group: req.Resource.Group, Version: req.Resource.Version, Resource: req.Resource.Resource}
+	for i := range s.configs {
+		cfg := &s.configs[i]
+		if !cfg.Matches(gvr) {
+			continue
+		}
+		decision := samplingSkipped
+		switch {
+		case cfg.RecentDrift > 0 && parent != nil && !parent.LastDriftTime.IsZero() && s.now().Sub(parent.LastDriftTime) <= cfg.RecentDrift:
+			decision = samplingRecentDrift
+		case sampleKey(req, obj) < cfg.Rate:
+			decision = samplingSampled
+		}
+		traceSamplingDecisions.WithLabelValues(gvr.GroupResource().String(), decision).Inc()
+		return decision != samplingSkipped
+	}
+	return true
+}
+
+// sampleKey maps the object's namespace and name uniformly to [0, 1), so
+// that an object is sampled on every mutation and by every replica alike.
+// Objects without name yet fall back to the request UID.
+func sampleKey(req admission.Request, obj client.Object) float64 {
+	key := obj.GetNamespace() + "/" + obj.GetName()
+	if obj.GetName() == "" {
+		key = string(req.UID)
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	// The top 53 bits are exact in a float64
+	return float64(h.Sum64()>>11) / (1 << 53)
+}
@@ -0,0 +1,132 @@
+package admission
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/kausality-io/kausality/pkg/config"
+	"github.com/kausality-io/kausality/pkg/controller"
+	"github.com/kausality-io/kausality/pkg/drift"
+	"github.com/kausality-io/kausality/pkg/testing/scenario"
+	"github.com/kausality-io/kausality/pkg/trace"
+)
+
+var (
+	podGVK      = schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+	podResource = metav1.GroupVersionResource{Version: "v1", Resource: "pods"}
+)
+
+func podRequest(name string) admission.Request {
+	pod := scenario.Object(podGVK, "default", name, map[string]interface{}{"nodeName": ""})
+	req := buildAdmissionRequest(admissionv1.Create, pod, nil, "admin")
+	req.Resource = podResource
+	return req
+}
+
+func TestTraceSampler(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	pods := config.TraceSamplingConfig{APIGroups: []string{""}, Resources: []string{"pods"}, RecentDrift: 10 * time.Minute}
+	sampler := func(rate float64) *traceSampler {
+		cfg := pods
+		cfg.Rate = rate
+		s := newTraceSampler([]config.TraceSamplingConfig{cfg})
+		s.now = func() time.Time { return now }
+		return s
+	}
+
+	req := podRequest("web-abc-x2k8p")
+	obj := scenario.Object(podGVK, "default", "web-abc-x2k8p", nil)
+	assert.True(t, sampler(1).sample(req, obj, nil))
+	assert.False(t, sampler(0).sample(req, obj, nil))
+
+	// Children of parents with recent drift are traced whatever the rate
+	assert.True(t, sampler(0).sample(req, obj, &drift.ParentState{LastDriftTime: now.Add(-5 * time.Minute)}))
+	assert.False(t, sampler(0).sample(req, obj, &drift.ParentState{LastDriftTime: now.Add(-time.Hour)}))
+	assert.False(t, sampler(0).sample(req, obj, &drift.ParentState{}))
+
+	// Other resources are always traced
+	cm := scenario.Object(configMapGVK, "default", "cfg", nil)
+	cmReq := buildAdmissionRequest(admissionv1.Create, cm, nil, "admin")
+	cmReq.Resource = metav1.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	assert.True(t, sampler(0).sample(cmReq, cm, nil))
+	var none *traceSampler
+	assert.True(t, none.sample(req, obj, nil))
+
+	// Objects are sampled at about the rate, consistently
+	s := sampler(0.1)
+	sampled := 0
+	for i := range 10000 {
+		obj := scenario.Object(podGVK, "default", fmt.Sprintf("web-%d", i), nil)
+		if s.sample(req, obj, nil) {
+			sampled++
+			assert.True(t, s.sample(req, obj, nil))
+		}
+	}
+	assert.InDelta(t, 1000, sampled, 150)
+}
+
+func TestHandle_TraceSampling(t *testing.T) {
+	// A ReplicaSet creating a Pod
+	s := scenario.NewParentChild().
+		WithParent(scenario.ReplicaSetGVK, "web-abc").
+		WithChild(podGVK, "web-abc-x2k8p").
+		ChildCreate(map[string]interface{}{"nodeName": ""})
+
+	newHandler := func(parentAnnotations map[string]string) (*Handler, *recordingMirror) {
+		parent := s.Parent().DeepCopy()
+		parent.SetAnnotations(parentAnnotations)
+		h := newTestHandler(parent)
+		h.config = &config.Config{
+			DriftStatus: &config.DriftStatusConfig{},
+			TraceSampling: []config.TraceSamplingConfig{
+				{APIGroups: []string{""}, Resources: []string{"pods"}, RecentDrift: time.Hour},
+			},
+		}
+		h.traceSampler = newTraceSampler(h.config.TraceSampling)
+		mirror := &recordingMirror{}
+		h.traceMirror = mirror
+		return h, mirror
+	}
+	patched := func(resp admission.Response) map[string]string {
+		for _, p := range resp.Patches {
+			if value, ok := p.Value.(map[string]string); ok {
+				return value
+			}
+		}
+		return nil
+	}
+	req := s.Request("admin")
+	req.Resource = podResource
+
+	t.Run("not sampled", func(t *testing.T) {
+		h, mirror := newHandler(nil)
+		resp := h.Handle(context.Background(), req)
+		require.True(t, resp.Allowed)
+		annotations := patched(resp)
+		assert.NotContains(t, annotations, trace.TraceAnnotation)
+		assert.NotContains(t, annotations, trace.SummaryAnnotation)
+		assert.Contains(t, annotations, controller.UpdatersAnnotation)
+		assert.NotContains(t, resp.AuditAnnotations, auditKeyTrace)
+		assert.Empty(t, mirror.records)
+	})
+
+	t.Run("parent with recent drift", func(t *testing.T) {
+		h, mirror := newHandler(map[string]string{
+			drift.LastDriftTimeAnnotation: time.Now().Add(-time.Minute).UTC().Format(time.RFC3339),
+		})
+		resp := h.Handle(context.Background(), req)
+		require.True(t, resp.Allowed)
+		assert.Contains(t, patched(resp), trace.TraceAnnotation)
+		assert.Len(t, mirror.records, 1)
+	})
+}
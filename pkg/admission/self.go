@@ -0,0 +1,34 @@
+package admission
+
+import (
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/kausality-io/kausality/pkg/controller"
+)
+
+// ownWrites counts writes of kausality's own components admitted without evaluation.
+var ownWrites = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "kausality_admission_own_writes_total",
+	Help: "Writes of kausality's own components admitted without drift detection, by operation.",
+}, []string{"operation"})
+
+func init() {
+	metrics.Registry.MustRegister(ownWrites)
+}
+
+// isOwnWrite returns whether a request is a write of kausality itself, e.g.
+// of controller or drift status annotations on a parent, or of approvals
+// consumed by the webhook. Evaluating them would preserve the annotations
+// they change and recurse into bookkeeping of kausality's own writes. Both
+// the user and the field manager must match, so that other writes of the
+// same ServiceAccount, e.g. by a debugging session, are still evaluated.
+func (h *Handler) isOwnWrite(req admission.Request) bool {
+	return len(h.selfUsers) > 0 &&
+		slices.Contains(h.selfUsers, req.UserInfo.Username) &&
+		extractFieldManager(req) == controller.FieldManager
+}
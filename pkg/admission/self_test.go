@@ -0,0 +1,46 @@
+package admission
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/kausality-io/kausality/pkg/controller"
+)
+
+func TestHandle_OwnWrites(t *testing.T) {
+	const self = "system:serviceaccount:kausality-system:kausality-controller"
+	c := fake.NewClientBuilder().WithScheme(runtime.NewScheme()).Build()
+	h := NewHandler(Config{Client: c, Log: logr.Discard(), SelfUsers: []string{self}})
+	rs := buildUnstructured(replicaSetGVK, "default", "web-abc", map[string]interface{}{"replicas": int64(1)})
+
+	request := func(h *Handler, user, fieldManager string) admission.Response {
+		req := buildAdmissionRequest(admissionv1.Create, rs, nil, user)
+		req.Options = runtime.RawExtension{Raw: []byte(`{"fieldManager":"` + fieldManager + `"}`)}
+		return h.Handle(context.Background(), req)
+	}
+
+	before := testutil.ToFloat64(ownWrites.WithLabelValues(string(admissionv1.Create)))
+	resp := request(h, self, controller.FieldManager)
+	assert.True(t, resp.Allowed)
+	assert.Empty(t, resp.Patches, "own writes are not evaluated")
+	assert.Equal(t, before+1, testutil.ToFloat64(ownWrites.WithLabelValues(string(admissionv1.Create))))
+
+	// The same user with another field manager, or another user with
+	// kausality's field manager, is evaluated
+	assert.NotEmpty(t, request(h, self, "kubectl").Patches)
+	assert.NotEmpty(t, request(h, "alice", controller.FieldManager).Patches)
+
+	// Without self users, nothing is an own write
+	unconfigured := NewHandler(Config{Client: c, Log: logr.Discard()})
+	assert.NotEmpty(t, request(unconfigured, self, controller.FieldManager).Patches)
+	assert.Equal(t, before+1, testutil.ToFloat64(ownWrites.WithLabelValues(string(admissionv1.Create))))
+}
@@ -0,0 +1,154 @@
+package admission
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/controller"
+	"github.com/kausality-io/kausality/pkg/drift"
+	"github.com/kausality-io/kausality/pkg/policy"
+)
+
+// subresourceHandling returns the configured handling of the request's subresource.
+func (h *Handler) subresourceHandling(req admission.Request) kausalityv1alpha1.SubresourceHandling {
+	if h.policyResolver == nil {
+		return policy.DefaultSubresourceHandling(req.SubResource)
+	}
+	return h.policyResolver.SubresourceHandling(policy.ResourceContext{
+		GVR: schema.GroupVersionResource{
+			Group:    req.Resource.Group,
+			Version:  req.Resource.Version,
+			Resource: req.Resource.Resource,
+		},
+		Namespace: req.Namespace,
+	}, req.SubResource)
+}
+
+// carriesObject returns true if the request object of a subresource is the
+// object itself (e.g. pods/ephemeralcontainers), rather than a different kind
+// (e.g. Scale for deployments/scale, Eviction for pods/eviction).
+func carriesObject(req admission.Request) bool {
+	if req.Kind.Group != req.Resource.Group {
+		return false
+	}
+	// Resource names are lowercase plurals of the kind, e.g. NetworkPolicy -> networkpolicies
+	singular := strings.TrimSuffix(strings.ToLower(req.Kind.Kind), "y")
+	return singular != "" && strings.HasPrefix(req.Resource.Resource, singular)
+}
+
+// handleConnect audits CONNECT requests to tracked subresources, e.g. exec
+// into a pod. There is no object to patch or check for drift; the request is
+// always allowed.
+func (h *Handler) handleConnect(req admission.Request, log logr.Logger) admission.Response {
+	log.Info("CONNECT", "subresource", req.SubResource)
+	audit := map[string]string{kausalityv1alpha1.AuditKeySubresource: req.SubResource}
+	return withAuditAnnotations(admission.Allowed("connect audited"), audit)
+}
+
+// handleProxySubresource checks drift for tracked subresources whose request
+// object is not the object itself, e.g. scale. Drift is detected on the live
+// object. Annotations cannot be changed through the subresource, so nothing
+// is traced and no drift callbacks are sent.
+func (h *Handler) handleProxySubresource(ctx context.Context, req admission.Request, log logr.Logger) admission.Response {
+	audit := map[string]string{kausalityv1alpha1.AuditKeySubresource: req.SubResource}
+
+	obj, err := h.fetchRequestObject(ctx, req)
+	if err != nil {
+		return h.errorResponse(fmt.Errorf("failed to fetch object for subresource request: %w", err), audit, log)
+	}
+
+	userID := controller.UserIdentifier(req.UserInfo.Username, req.UserInfo.UID)
+	userHash := controller.HashUsername(userID)
+	childUpdaters := drift.ParseUpdaterHashes(obj)
+	if !controller.ContainsHash(childUpdaters, userHash) {
+		childUpdaters = append(childUpdaters, userHash)
+	}
+
+	// Errors resolving the parent take precedence over policy errors
+	objPolicy, policyErr := h.resolveObjectPolicy(ctx, obj, nil)
+	if policyErr == nil {
+		audit[kausalityv1alpha1.AuditKeyMode] = objPolicy.mode
+	}
+	actor := drift.Actor{Username: userID, FieldManager: extractFieldManager(req), ChildUpdaters: childUpdaters}
+	driftResult, err := h.detector.DetectActor(ctx, obj, actor, objPolicy.classifier)
+	if err != nil {
+		return h.errorResponse(err, audit, log)
+	}
+	audit[kausalityv1alpha1.AuditKeyDrift] = strconv.FormatBool(driftResult.DriftDetected)
+	if driftResult.LifecyclePhase != "" {
+		audit[kausalityv1alpha1.AuditKeyLifecyclePhase] = string(driftResult.LifecyclePhase)
+	}
+
+	if policyErr != nil {
+		return h.errorResponse(policyErr, audit, log)
+	}
+	driftMode := objPolicy.mode
+	enforceMode := driftMode == string(kausalityv1alpha1.ModeEnforce)
+	excludeDrift(driftResult, objPolicy, audit, log)
+
+	if !driftResult.DriftDetected {
+		log.V(1).Info("subresource drift check passed", "subresource", req.SubResource)
+		return withAuditAnnotations(admission.Allowed(driftResult.Reason), audit)
+	}
+
+	var driftMsg string
+	reason := kausalityv1alpha1.DenialReasonDrift
+	approvalResult := h.checkApprovals(ctx, driftResult, obj, log)
+	suppression := activeSuppression(obj.GetAnnotations(), log)
+	switch {
+	case approvalResult.Rejected:
+		driftMsg = fmt.Sprintf("drift rejected: %s", approvalResult.Reason)
+		reason = kausalityv1alpha1.DenialReasonRejected
+		audit[kausalityv1alpha1.AuditKeyDriftResolution] = "rejected"
+	case approvalResult.Approved:
+		log.Info("DRIFT APPROVED", "subresource", req.SubResource, "approvalReason", approvalResult.Reason)
+		h.consumeApproval(ctx, req, obj, driftResult, approvalResult, log)
+		audit[kausalityv1alpha1.AuditKeyDriftResolution] = "approved"
+		return withAuditAnnotations(admission.Allowed(approvalResult.Reason), audit)
+	case suppression != nil:
+		log.Info("DRIFT SUPPRESSED", "subresource", req.SubResource, "suppression", suppression.String())
+		audit[kausalityv1alpha1.AuditKeyDriftResolution] = "suppressed"
+		audit[kausalityv1alpha1.AuditKeySuppression] = suppression.String()
+		return withAuditAnnotations(admission.Allowed(suppression.String()), audit)
+	default:
+		driftMsg = "drift detected: no approval found for this mutation"
+		audit[kausalityv1alpha1.AuditKeyDriftResolution] = "unresolved"
+	}
+
+	driftMsg = fmt.Sprintf("%s (subresource %s)", driftMsg, req.SubResource)
+	log.Info("DRIFT DETECTED", "subresource", req.SubResource, "driftMode", driftMode, "reason", driftMsg)
+	if enforceMode {
+		return withAuditAnnotations(denied(reason, driftMsg, req, obj, driftResult), audit)
+	}
+	warnings := []string{fmt.Sprintf("[kausality] %s (would be blocked in enforce mode)", driftMsg)}
+	return withAuditAnnotations(withWarnings(admission.Allowed(driftResult.Reason), warnings), audit)
+}
+
+// fetchRequestObject fetches the live object a subresource request refers to.
+func (h *Handler) fetchRequestObject(ctx context.Context, req admission.Request) (client.Object, error) {
+	gvk, err := h.client.RESTMapper().KindFor(schema.GroupVersionResource{
+		Group:    req.Resource.Group,
+		Version:  req.Resource.Version,
+		Resource: req.Resource.Resource,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to map resource %s: %w", req.Resource.Resource, err)
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	if err := h.client.Get(ctx, client.ObjectKey{Namespace: req.Namespace, Name: req.Name}, obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
@@ -0,0 +1,148 @@
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+	"github.com/kausality-io/kausality/pkg/controller"
+	"github.com/kausality-io/kausality/pkg/policy"
+)
+
+func TestCarriesObject(t *testing.T) {
+	tests := []struct {
+		name     string
+		kind     metav1.GroupVersionKind
+		resource metav1.GroupVersionResource
+		want     bool
+	}{
+		{"ephemeralcontainers", metav1.GroupVersionKind{Version: "v1", Kind: "Pod"}, metav1.GroupVersionResource{Version: "v1", Resource: "pods"}, true},
+		{"status of irregular plural", metav1.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "NetworkPolicy"}, metav1.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"}, true},
+		{"scale", metav1.GroupVersionKind{Group: "autoscaling", Version: "v1", Kind: "Scale"}, metav1.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, false},
+		{"binding", metav1.GroupVersionKind{Version: "v1", Kind: "Binding"}, metav1.GroupVersionResource{Version: "v1", Resource: "pods"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{Kind: tt.kind, Resource: tt.resource}}
+			assert.Equal(t, tt.want, carriesObject(req))
+		})
+	}
+}
+
+func TestHandle_ConnectIsAudited(t *testing.T) {
+	h := newTestHandler()
+
+	req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		UID:         "connect-1",
+		Operation:   admissionv1.Connect,
+		Kind:        metav1.GroupVersionKind{Version: "v1", Kind: "PodExecOptions"},
+		Resource:    metav1.GroupVersionResource{Version: "v1", Resource: "pods"},
+		SubResource: "exec",
+		Namespace:   "default",
+		Name:        "web-0",
+		UserInfo:    testUserInfo("alice"),
+		Object:      runtime.RawExtension{Raw: []byte(`{"kind":"PodExecOptions","apiVersion":"v1","command":["sh"]}`)},
+	}}
+	resp := h.Handle(context.Background(), req)
+
+	require.True(t, resp.Allowed)
+	assert.Empty(t, resp.Patches)
+	assert.Equal(t, "exec", resp.AuditAnnotations[auditKeySubresource])
+	assert.Equal(t, "allowed", resp.AuditAnnotations[auditKeyDecision])
+}
+
+func TestHandle_IgnoredSubresource(t *testing.T) {
+	store := policy.NewStore(nil, logr.Discard())
+	store.Update([]kausalityv1beta1.Kausality{{
+		ObjectMeta: metav1.ObjectMeta{Name: "quiet-status"},
+		Spec: kausalityv1beta1.KausalitySpec{
+			Resources: []kausalityv1beta1.ResourceRule{{
+				APIGroups:    []string{"apps"},
+				Resources:    []string{"deployments"},
+				Subresources: []kausalityv1beta1.SubresourceRule{{Name: "status", Handling: kausalityv1beta1.SubresourceHandlingIgnore}},
+			}},
+			Mode: kausalityv1beta1.ModeLog,
+		},
+	}})
+	h := NewHandler(Config{Client: fake.NewClientBuilder().Build(), Log: logr.Discard(), PolicyResolver: store})
+
+	obj := buildUnstructured(deploymentGVK, "default", "app", map[string]interface{}{"replicas": int64(1)})
+	req := buildAdmissionRequest(admissionv1.Update, obj, obj, deploymentController)
+	req.SubResource = "status"
+	req.Resource = metav1.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	resp := h.Handle(context.Background(), req)
+
+	require.True(t, resp.Allowed)
+	assert.Equal(t, "subresource not tracked", resp.Result.Message)
+	assert.Empty(t, resp.Patches, "controller identity is not recorded")
+}
+
+func TestHandle_ScaleSubresourceDrift(t *testing.T) {
+	parent := buildUnstructured(deploymentGVK, "default", "app",
+		map[string]interface{}{"replicas": int64(1)},
+		withUID("app-uid"),
+		withGeneration(1),
+		withAnnotations(map[string]string{
+			controller.PhaseAnnotation:       controller.PhaseValueInitialized,
+			controller.ControllersAnnotation: controller.HashUsername(deploymentController),
+		}),
+		withStatus(map[string]interface{}{"observedGeneration": int64(1)}),
+	)
+	child := childRS(1, controller.HashUsername(deploymentController))
+	child.SetAnnotations(map[string]string{
+		controller.UpdatersAnnotation: controller.HashUsername(deploymentController),
+		"kausality.io/mode":           "enforce",
+	})
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(replicaSetGVK, meta.RESTScopeNamespace)
+	c := fake.NewClientBuilder().WithScheme(runtime.NewScheme()).WithRESTMapper(mapper).WithRuntimeObjects(parent, child).Build()
+	h := NewHandler(Config{Client: c, Log: logr.Discard()})
+
+	scaleRequest := func(user string) admission.Request {
+		scale, _ := json.Marshal(map[string]interface{}{
+			"apiVersion": "autoscaling/v1",
+			"kind":       "Scale",
+			"metadata":   map[string]interface{}{"name": "app-abc", "namespace": "default"},
+			"spec":       map[string]interface{}{"replicas": 3},
+		})
+		return admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			UID:         "scale-1",
+			Operation:   admissionv1.Update,
+			Kind:        metav1.GroupVersionKind{Group: "autoscaling", Version: "v1", Kind: "Scale"},
+			Resource:    metav1.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"},
+			SubResource: "scale",
+			Namespace:   "default",
+			Name:        "app-abc",
+			UserInfo:    testUserInfo(user),
+			Object:      runtime.RawExtension{Raw: scale},
+			OldObject:   runtime.RawExtension{Raw: scale},
+		}}
+	}
+
+	// The controller scales the child while the parent is stable
+	resp := h.Handle(context.Background(), scaleRequest(deploymentController))
+	require.False(t, resp.Allowed, "enforce mode denies drift via scale")
+	assert.Contains(t, resp.Result.Message, "subresource scale")
+	assert.Equal(t, "scale", resp.AuditAnnotations[auditKeySubresource])
+	assert.Equal(t, "true", resp.AuditAnnotations[auditKeyDrift])
+	assert.Equal(t, "denied", resp.AuditAnnotations[auditKeyDecision])
+
+	// A different actor (e.g. an autoscaler or human) is not drift
+	resp = h.Handle(context.Background(), scaleRequest("system:serviceaccount:kube-system:horizontal-pod-autoscaler"))
+	require.True(t, resp.Allowed)
+	assert.Equal(t, "false", resp.AuditAnnotations[auditKeyDrift])
+	assert.Empty(t, resp.Patches)
+}
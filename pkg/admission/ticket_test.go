@@ -0,0 +1,92 @@
+package admission
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	admissionv1 "k8s.io/api/admission/v1"
+
+	"github.com/kausality-io/kausality/pkg/integrations"
+	"github.com/kausality-io/kausality/pkg/trace"
+)
+
+// fakeTicketValidator accepts tickets present in the map and rejects others.
+type fakeTicketValidator struct {
+	tickets map[string]string // id -> state
+}
+
+func (f *fakeTicketValidator) Validate(_ context.Context, ref string) (*trace.TicketRef, error) {
+	state, ok := f.tickets[ref]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", integrations.ErrTicketNotFound, ref)
+	}
+	return &trace.TicketRef{ID: ref, State: state}, nil
+}
+
+func TestHandle_TicketValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		wantAllowed bool
+		wantTicket  string
+	}{
+		{
+			name:        "log mode skips validation",
+			annotations: map[string]string{},
+			wantAllowed: true,
+		},
+		{
+			name:        "enforce mode without ticket is denied",
+			annotations: map[string]string{"kausality.io/mode": "enforce"},
+			wantAllowed: false,
+		},
+		{
+			name: "enforce mode with unknown ticket is denied",
+			annotations: map[string]string{
+				"kausality.io/mode":    "enforce",
+				trace.TicketAnnotation: "PROJ-999",
+			},
+			wantAllowed: false,
+		},
+		{
+			name: "enforce mode with valid ticket is allowed",
+			annotations: map[string]string{
+				"kausality.io/mode":    "enforce",
+				trace.TicketAnnotation: "PROJ-123",
+			},
+			wantAllowed: true,
+			wantTicket:  "PROJ-123",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler()
+			h.ticketValidator = &fakeTicketValidator{tickets: map[string]string{"PROJ-123": "In Progress"}}
+
+			obj := buildUnstructured(configMapGVK, "default", "ticket-cm",
+				map[string]interface{}{"data": "value"},
+				withAnnotations(tt.annotations),
+			)
+			req := buildAdmissionRequest(admissionv1.Create, obj, nil, "admin")
+			resp := h.Handle(context.Background(), req)
+
+			require.Equal(t, tt.wantAllowed, resp.Allowed, "response: %+v", resp.Result)
+			assert.Equal(t, tt.wantTicket, resp.AuditAnnotations[auditKeyTicket])
+			if tt.wantTicket == "" {
+				return
+			}
+
+			tr, err := trace.Parse(resp.AuditAnnotations[auditKeyTrace])
+			require.NoError(t, err)
+			require.Len(t, tr, 1)
+			require.NotNil(t, tr[0].Ticket)
+			assert.Equal(t, "PROJ-123", tr[0].Ticket.ID)
+			assert.Equal(t, "In Progress", tr[0].Ticket.State)
+		})
+	}
+}
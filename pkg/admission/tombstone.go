@@ -0,0 +1,118 @@
+package admission
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-logr/logr"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/trace"
+)
+
+const (
+	// garbageCollectorUser is the user of the Kubernetes garbage collector,
+	// deleting the dependents of deleted owners.
+	garbageCollectorUser = "system:serviceaccount:kube-system:generic-garbage-collector"
+
+	// TombstoneReason is the reason of the Events recorded for deleted objects.
+	TombstoneReason = "Deleted"
+
+	// maxTombstoneNote is the maximum length of the note of an Event.
+	maxTombstoneNote = 1024
+)
+
+// deletionOf describes the deletion of obj by req, or returns nil if req is
+// not a deletion. Objects deleted by the garbage collector are attributed to
+// their owner, whose deletion caused theirs.
+func deletionOf(req admission.Request, obj client.Object) *v1alpha1.Deletion {
+	if req.Operation != admissionv1.Delete {
+		return nil
+	}
+	deletion := &v1alpha1.Deletion{GarbageCollected: req.UserInfo.Username == garbageCollectorUser}
+	if len(req.Options.Raw) > 0 {
+		var opts metav1.DeleteOptions
+		if err := json.Unmarshal(req.Options.Raw, &opts); err == nil && opts.PropagationPolicy != nil {
+			deletion.PropagationPolicy = string(*opts.PropagationPolicy)
+		}
+	}
+	if !deletion.GarbageCollected {
+		return deletion
+	}
+	owner := metav1.GetControllerOf(obj)
+	if refs := obj.GetOwnerReferences(); owner == nil && len(refs) > 0 {
+		owner = &refs[0]
+	}
+	if owner != nil {
+		deletion.Owner = &v1alpha1.ObjectReference{
+			APIVersion: owner.APIVersion,
+			Kind:       owner.Kind,
+			Namespace:  obj.GetNamespace(),
+			Name:       owner.Name,
+			UID:        owner.UID,
+		}
+	}
+	return deletion
+}
+
+// recordTombstone records the final trace and the deleting actor of a
+// deleted object as an Event on it, if configured. Dry-run requests are not
+// recorded.
+func (h *Handler) recordTombstone(req admission.Request, obj client.Object, t trace.Trace, log logr.Logger) {
+	deletion := deletionOf(req, obj)
+	if h.tombstones == nil || h.config.Tombstones == nil || deletion == nil || (req.DryRun != nil && *req.DryRun) {
+		return
+	}
+	if deletion.GarbageCollected && h.config.Tombstones.ExcludeGarbageCollected {
+		return
+	}
+
+	note := "Deleted by " + req.UserInfo.Username
+	var related runtime.Object
+	if owner := deletion.Owner; owner != nil {
+		note = fmt.Sprintf("Deleted by the garbage collector after its owner %s %s was deleted", owner.Kind, owner.Name)
+		ownerObj := &unstructured.Unstructured{}
+		ownerObj.SetAPIVersion(owner.APIVersion)
+		ownerObj.SetKind(owner.Kind)
+		ownerObj.SetNamespace(owner.Namespace)
+		ownerObj.SetName(owner.Name)
+		ownerObj.SetUID(owner.UID)
+		related = ownerObj
+	}
+	if deletion.PropagationPolicy != "" {
+		note += fmt.Sprintf(" (propagation %s)", deletion.PropagationPolicy)
+	}
+	if len(t) > 0 {
+		note += "; trace: " + t.String()
+	}
+	if len(note) > maxTombstoneNote {
+		note = note[:maxTombstoneNote-3] + "..."
+	}
+	h.tombstones.Eventf(obj, related, corev1.EventTypeNormal, TombstoneReason, "Delete", "%s", note)
+	log.V(1).Info("tombstone recorded", "garbageCollected", deletion.GarbageCollected)
+}
+
+// recordOwnerlessDeletion mirrors, records and archives the deletion of an object
+// whose owner does not exist anymore, e.g. by the garbage collector after
+// the background deletion of the owner. Without owner, the trace cannot be
+// extended, so the object's last trace is recorded.
+func (h *Handler) recordOwnerlessDeletion(req admission.Request, obj client.Object, log logr.Logger) {
+	if req.Operation != admissionv1.Delete || !h.traceSampler.sample(req, obj, nil) {
+		return
+	}
+	t, err := trace.GetTraceFromObject(obj)
+	if err != nil {
+		log.V(1).Info("failed to parse trace of deleted object", "error", err)
+	}
+	h.mirrorTrace(req, obj, t)
+	h.recordTombstone(req, obj, t, log)
+	h.archiveDeletion(req, obj, t)
+}
@@ -0,0 +1,74 @@
+package admission
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/utils/ptr"
+
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/config"
+)
+
+func TestHandle_Tombstone(t *testing.T) {
+	ctx := context.Background()
+	newHandler := func(cfg *config.TombstonesConfig, objs ...runtime.Object) (*Handler, *recordingMirror, *events.FakeRecorder) {
+		h := newTestHandler(objs...)
+		mirror := &recordingMirror{}
+		recorder := events.NewFakeRecorder(10)
+		h.traceMirror = mirror
+		h.tombstones = recorder
+		h.config = &config.Config{Tombstones: cfg}
+		return h, mirror, recorder
+	}
+
+	// Deleted by a user: the final trace is recorded with the user
+	h, mirror, recorder := newHandler(&config.TombstonesConfig{}, webParent(false))
+	child := webChild("deploy-uid", nil)
+	req := buildAdmissionRequest(admissionv1.Delete, child, child, "bob")
+	req.Options = runtime.RawExtension{Raw: []byte(`{"kind":"DeleteOptions","apiVersion":"meta.k8s.io/v1","propagationPolicy":"Foreground"}`)}
+	require.True(t, h.Handle(ctx, req).Allowed)
+	require.Len(t, mirror.records, 1)
+	assert.Equal(t, &v1alpha1.Deletion{PropagationPolicy: "Foreground"}, mirror.records[0].Spec.Deletion)
+	require.Len(t, recorder.Events, 1)
+	event := <-recorder.Events
+	assert.Contains(t, event, "Normal Deleted Deleted by bob (propagation Foreground); trace: ")
+	assert.Contains(t, event, `"user":"bob"`)
+
+	// Deleted by the garbage collector after a background deletion of the
+	// owner: the last trace is recorded with the owner as cause
+	h, mirror, recorder = newHandler(&config.TombstonesConfig{})
+	req = buildAdmissionRequest(admissionv1.Delete, child, child, garbageCollectorUser)
+	require.True(t, h.Handle(ctx, req).Allowed)
+	require.Len(t, mirror.records, 1)
+	want := &v1alpha1.Deletion{GarbageCollected: true, Owner: &v1alpha1.ObjectReference{
+		APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "web", UID: "deploy-uid",
+	}}
+	assert.Equal(t, want, mirror.records[0].Spec.Deletion)
+	require.Len(t, recorder.Events, 1)
+	event = <-recorder.Events
+	assert.Contains(t, event, "Deleted by the garbage collector after its owner Deployment web was deleted; trace: ")
+	assert.Contains(t, event, `"user":"alice"`)
+
+	// Garbage-collected deletions can be excluded, and dry runs are not recorded
+	h, mirror, recorder = newHandler(&config.TombstonesConfig{ExcludeGarbageCollected: true})
+	require.True(t, h.Handle(ctx, req).Allowed)
+	assert.Len(t, mirror.records, 1, "deletions are mirrored regardless")
+	assert.Empty(t, recorder.Events)
+	h, mirror, recorder = newHandler(&config.TombstonesConfig{})
+	req.DryRun = ptr.To(true)
+	require.True(t, h.Handle(ctx, req).Allowed)
+	assert.Empty(t, mirror.records)
+	assert.Empty(t, recorder.Events)
+
+	// Without configuration, no Events are recorded
+	h, _, recorder = newHandler(nil, webParent(false))
+	require.True(t, h.Handle(ctx, buildAdmissionRequest(admissionv1.Delete, child, child, "bob")).Allowed)
+	assert.Empty(t, recorder.Events)
+}
@@ -0,0 +1,123 @@
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/approval"
+)
+
+// annotationValidators validate the annotations of parents naming children,
+// and the suppression of children.
+// Values that are valid may still be warned about.
+var annotationValidators = []struct {
+	key      string
+	validate func(string) error
+	warn     func(string) []string
+}{
+	{approval.ApprovalsAnnotation, approval.ValidateApprovals, nil},
+	{approval.RejectionsAnnotation, approval.ValidateRejections, approval.RejectionWarnings},
+	{approval.SuppressAnnotation, validateSuppression, nil},
+}
+
+// validateSuppression checks the suppress annotation of a child.
+func validateSuppression(value string) error {
+	_, err := approval.ParseSuppression(value)
+	return err
+}
+
+// validateApprovalAnnotations checks approvals and rejections written to an
+// object by an update; on creation they are dropped anyway. Invalid values
+// are ignored by the approval checker and silently leave drift unapproved,
+// so writes introducing them are denied in enforce mode and warned about
+// otherwise. Replacing a value that was invalid already is only warned
+// about, so that cleanups, e.g. by the approval pruner, are never blocked.
+// Valid values may still be warned about, e.g. rejections without a reason.
+// It returns the warnings, or a denial.
+func (h *Handler) validateApprovalAnnotations(ctx context.Context, req admission.Request) ([]string, *admission.Response) {
+	if req.SubResource != "" || req.Operation != admissionv1.Update {
+		return nil, nil
+	}
+	newAnnotations := rawAnnotations(req.Object.Raw)
+	oldAnnotations := rawAnnotations(req.OldObject.Raw)
+
+	var errs, warnings []string
+	introduced := false
+	for _, v := range annotationValidators {
+		value := newAnnotations[v.key]
+		if value == oldAnnotations[v.key] {
+			continue
+		}
+		if err := v.validate(value); err != nil {
+			errs = append(errs, err.Error())
+			introduced = introduced || v.validate(oldAnnotations[v.key]) == nil
+			continue
+		}
+		if v.warn != nil {
+			for _, w := range v.warn(value) {
+				warnings = append(warnings, "[kausality] "+w)
+			}
+		}
+	}
+	if len(errs) == 0 {
+		return warnings, nil
+	}
+	msg := strings.Join(errs, "; ")
+	log := h.log.WithValues("operation", req.Operation, "kind", req.Kind.String(), "namespace", req.Namespace, "name", req.Name, "user", req.UserInfo.Username)
+
+	obj, err := h.parseObject(req)
+	if err != nil {
+		return append(warnings, "[kausality] "+msg), nil
+	}
+	if !introduced {
+		log.Info("invalid approval annotations kept", "error", msg)
+		return append(warnings, "[kausality] "+msg), nil
+	}
+	objPolicy, err := h.resolveObjectPolicy(ctx, obj, rawLabels(req.OldObject.Raw))
+	if err != nil {
+		log.V(1).Info("failed to resolve mode for annotation validation", "error", err)
+	}
+	if objPolicy.mode != string(kausalityv1alpha1.ModeEnforce) {
+		log.Info("INVALID APPROVAL ANNOTATIONS", "error", msg)
+		return append(warnings, fmt.Sprintf("[kausality] %s (would be blocked in enforce mode)", msg)), nil
+	}
+
+	log.Info("INVALID APPROVAL ANNOTATIONS DENIED", "error", msg)
+	audit := map[string]string{kausalityv1alpha1.AuditKeyMode: objPolicy.mode}
+	resp := withWarnings(withAuditAnnotations(denied(kausalityv1alpha1.DenialReasonInvalidAnnotation, msg, req, obj, nil), audit), warnings)
+	return nil, &resp
+}
+
+// rawObjectMeta is the part of a raw object read for annotation validation.
+type rawObjectMeta struct {
+	Metadata struct {
+		Labels      map[string]string `json:"labels"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"metadata"`
+}
+
+// rawAnnotations returns the annotations of a raw object, nil if it is empty
+// or malformed.
+func rawAnnotations(raw []byte) map[string]string {
+	var obj rawObjectMeta
+	if len(raw) == 0 || json.Unmarshal(raw, &obj) != nil {
+		return nil
+	}
+	return obj.Metadata.Annotations
+}
+
+// rawLabels returns the labels of a raw object, an empty map if it has none.
+func rawLabels(raw []byte) map[string]string {
+	var obj rawObjectMeta
+	_ = json.Unmarshal(raw, &obj)
+	if obj.Metadata.Labels == nil {
+		return map[string]string{}
+	}
+	return obj.Metadata.Labels
+}
@@ -0,0 +1,103 @@
+package admission
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/approval"
+	"github.com/kausality-io/kausality/pkg/config"
+)
+
+const (
+	validApprovals   = `[{"apiVersion":"apps/v1","kind":"ReplicaSet","name":"app-abc","mode":"always"}]`
+	invalidApprovals = `[{"apiVersion":"apps/v1","kind":"ReplicaSet","name":"app-abc","mode":"forever"}]`
+)
+
+// annotatedParent returns the parent Deployment with the given annotations.
+func annotatedParent(ann map[string]string) *unstructured.Unstructured {
+	return buildUnstructured(deploymentGVK, "default", "app", map[string]interface{}{"replicas": int64(1)},
+		withUID("app-uid"), withGeneration(1), withAnnotations(ann))
+}
+
+func TestValidateApprovalAnnotations_DeniedInEnforceMode(t *testing.T) {
+	h, _ := newResolutionTestHandler(1, 1)
+	oldParent := annotatedParent(map[string]string{config.ModeAnnotation: "enforce"})
+	parent := annotatedParent(map[string]string{config.ModeAnnotation: "enforce", approval.ApprovalsAnnotation: invalidApprovals})
+
+	resp := h.Handle(context.Background(), buildAdmissionRequest(admissionv1.Update, parent, oldParent, "alice"))
+	require.False(t, resp.Allowed)
+	assert.Contains(t, resp.Result.Message, `unknown mode "forever"`)
+	require.NotNil(t, resp.Result.Details)
+	causes := map[string]string{}
+	for _, c := range resp.Result.Details.Causes {
+		causes[string(c.Type)] = c.Message
+	}
+	assert.Equal(t, string(kausalityv1alpha1.DenialReasonInvalidAnnotation), causes[kausalityv1alpha1.DenialCauseReason])
+	assert.Contains(t, causes[kausalityv1alpha1.DenialCauseDocs], "APPROVALS.md#annotation-validation")
+	assert.Equal(t, "denied", resp.AuditAnnotations[auditKeyDecision])
+}
+
+func TestValidateApprovalAnnotations_WarnedInLogMode(t *testing.T) {
+	h, _ := newResolutionTestHandler(1, 1)
+	oldParent := annotatedParent(nil)
+	parent := annotatedParent(map[string]string{approval.RejectionsAnnotation: `[{"apiVersion":"apps/v1","kind":"ReplicaSet","reason":"x"}]`})
+
+	resp := h.Handle(context.Background(), buildAdmissionRequest(admissionv1.Update, parent, oldParent, "alice"))
+	require.True(t, resp.Allowed)
+	require.Len(t, resp.Warnings, 1)
+	assert.Contains(t, resp.Warnings[0], "name is required")
+	assert.Contains(t, resp.Warnings[0], "would be blocked in enforce mode")
+}
+
+func TestValidateApprovalAnnotations_MissingReasonWarned(t *testing.T) {
+	h, _ := newResolutionTestHandler(1, 1)
+	oldParent := annotatedParent(map[string]string{config.ModeAnnotation: "enforce"})
+	parent := annotatedParent(map[string]string{
+		config.ModeAnnotation:         "enforce",
+		approval.RejectionsAnnotation: `[{"apiVersion":"apps/v1","kind":"ReplicaSet","name":"app-abc"}]`,
+	})
+
+	resp := h.Handle(context.Background(), buildAdmissionRequest(admissionv1.Update, parent, oldParent, "alice"))
+	require.True(t, resp.Allowed, "a missing reason is not denied in enforce mode")
+	require.Len(t, resp.Warnings, 1)
+	assert.Contains(t, resp.Warnings[0], "reason is missing")
+	assert.NotContains(t, resp.Warnings[0], "would be blocked")
+}
+
+func TestValidateApprovalAnnotations_PreviouslyInvalid(t *testing.T) {
+	h, _ := newResolutionTestHandler(1, 1)
+	oldParent := annotatedParent(map[string]string{config.ModeAnnotation: "enforce", approval.ApprovalsAnnotation: `not json`})
+	parent := annotatedParent(map[string]string{config.ModeAnnotation: "enforce", approval.ApprovalsAnnotation: invalidApprovals})
+
+	resp := h.Handle(context.Background(), buildAdmissionRequest(admissionv1.Update, parent, oldParent, "alice"))
+	require.True(t, resp.Allowed, "replacing an invalid value is not blocked")
+	require.Len(t, resp.Warnings, 1)
+	assert.Contains(t, resp.Warnings[0], `unknown mode "forever"`)
+	assert.NotContains(t, resp.Warnings[0], "would be blocked")
+}
+
+func TestValidateApprovalAnnotations_Unchanged(t *testing.T) {
+	h, _ := newResolutionTestHandler(1, 1)
+	ann := map[string]string{config.ModeAnnotation: "enforce", approval.ApprovalsAnnotation: invalidApprovals}
+
+	resp := h.Handle(context.Background(), buildAdmissionRequest(admissionv1.Update, annotatedParent(ann), annotatedParent(ann), "alice"))
+	require.True(t, resp.Allowed)
+	assert.Empty(t, resp.Warnings)
+}
+
+func TestValidateApprovalAnnotations_Valid(t *testing.T) {
+	h, _ := newResolutionTestHandler(1, 1)
+	oldParent := annotatedParent(map[string]string{config.ModeAnnotation: "enforce"})
+	parent := annotatedParent(map[string]string{config.ModeAnnotation: "enforce", approval.ApprovalsAnnotation: validApprovals})
+
+	resp := h.Handle(context.Background(), buildAdmissionRequest(admissionv1.Update, parent, oldParent, "alice"))
+	require.True(t, resp.Allowed)
+	assert.Empty(t, resp.Warnings)
+}
@@ -0,0 +1,309 @@
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/drift"
+)
+
+// WhatIfPath is the path of the what-if endpoint.
+const WhatIfPath = "/v1/whatif"
+
+// errInvalidWhatIf is wrapped by errors of invalid what-if requests.
+var errInvalidWhatIf = errors.New("invalid what-if request")
+
+// maxWhatIfBodyBytes bounds what-if requests like the apiserver bounds
+// request bodies.
+const maxWhatIfBodyBytes = 3 * 1024 * 1024
+
+// WhatIfRequest asks whether a change would be admitted.
+type WhatIfRequest struct {
+	// Object is the object after the change, or the object to delete.
+	Object *unstructured.Unstructured `json:"object"`
+	// Operation is CREATE, UPDATE or DELETE. Defaults to UPDATE if the
+	// object exists, and CREATE otherwise.
+	Operation admissionv1.Operation `json:"operation,omitempty"`
+	// User is the username making the change, e.g. the ServiceAccount of a
+	// CI pipeline.
+	User string `json:"user"`
+	// FieldManager is the field manager of the change, e.g. kubectl.
+	FieldManager string `json:"fieldManager,omitempty"`
+}
+
+// WhatIfResponse is the evaluation of a WhatIfRequest.
+type WhatIfResponse struct {
+	// Allowed is whether the change would be admitted.
+	Allowed bool `json:"allowed"`
+	// Operation is the evaluated operation.
+	Operation admissionv1.Operation `json:"operation"`
+	// Message is the message of the denial.
+	Message string `json:"message,omitempty"`
+	// DenialReason is the reason of the denial, if kausality denies it.
+	DenialReason string `json:"denialReason,omitempty"`
+	// Warnings are the warnings returned with the admission.
+	Warnings []string `json:"warnings,omitempty"`
+	// Mode is the drift detection mode applied.
+	Mode string `json:"mode,omitempty"`
+	// ApprovalStatus is how drift is handled: approved, rejected,
+	// overridden, suppressed or unresolved. Empty without drift.
+	ApprovalStatus string `json:"approvalStatus,omitempty"`
+	// Drift is the result of drift detection, if it ran.
+	Drift *WhatIfDrift `json:"drift,omitempty"`
+	// AuditAnnotations are the audit annotations the admission would carry.
+	AuditAnnotations map[string]string `json:"auditAnnotations,omitempty"`
+}
+
+// WhatIfDrift is the drift.DriftResult of a what-if query.
+type WhatIfDrift struct {
+	// Detected is whether the change is drift.
+	Detected bool `json:"detected"`
+	// Reason explains the result.
+	Reason string `json:"reason,omitempty"`
+	// Parent is the parent as "<apiVersion>/<kind>:<namespace>/<name>".
+	Parent string `json:"parent,omitempty"`
+	// LifecyclePhase is the lifecycle phase of the parent.
+	LifecyclePhase string `json:"lifecyclePhase,omitempty"`
+}
+
+type whatIfContextKey struct{}
+
+// whatIfCapture receives the drift result of a what-if query.
+type whatIfCapture struct {
+	drift *drift.DriftResult
+}
+
+// captureWhatIf records the drift result if ctx belongs to a what-if query.
+func captureWhatIf(ctx context.Context, result *drift.DriftResult) {
+	if c, ok := ctx.Value(whatIfContextKey{}).(*whatIfCapture); ok {
+		c.drift = result
+	}
+}
+
+// WhatIf evaluates a change with the full admission pipeline, as a dry run
+// that persists nothing: no drift reports or mirrored traces are sent, no
+// approvals consumed, no annotations recorded on parents and no decisions
+// cached.
+func (h *Handler) WhatIf(ctx context.Context, q WhatIfRequest) (*WhatIfResponse, error) {
+	if q.Object == nil || q.Object.GetKind() == "" || q.Object.GetName() == "" {
+		return nil, fmt.Errorf("%w: object with apiVersion, kind and name is required", errInvalidWhatIf)
+	}
+	if q.User == "" {
+		return nil, fmt.Errorf("%w: user is required", errInvalidWhatIf)
+	}
+
+	// The live object is the old object of updates and deletes
+	live := &unstructured.Unstructured{}
+	live.SetGroupVersionKind(q.Object.GroupVersionKind())
+	err := h.client.Get(ctx, client.ObjectKeyFromObject(q.Object), live)
+	switch {
+	case apierrors.IsNotFound(err):
+		live = nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to get %s %s: %w", q.Object.GetKind(), q.Object.GetName(), err)
+	}
+	op := q.Operation
+	switch {
+	case op == "" && live == nil:
+		op = admissionv1.Create
+	case op == "":
+		op = admissionv1.Update
+	case op != admissionv1.Create && op != admissionv1.Update && op != admissionv1.Delete:
+		return nil, fmt.Errorf("%w: unsupported operation %q", errInvalidWhatIf, op)
+	}
+	if live == nil && op != admissionv1.Create {
+		return nil, fmt.Errorf("%w: %s %s does not exist", errInvalidWhatIf, q.Object.GetKind(), q.Object.GetName())
+	}
+
+	req, err := h.whatIfAdmissionRequest(q, op, live)
+	if err != nil {
+		return nil, err
+	}
+	capture := &whatIfCapture{}
+	resp := h.whatIfHandler().Handle(context.WithValue(ctx, whatIfContextKey{}, capture), req)
+
+	// Audit annotations carry the configured prefix
+	prefix := h.config.AuditKeyPrefix()
+	audit := resp.AuditAnnotations
+	result := &WhatIfResponse{
+		Allowed:          resp.Allowed,
+		Operation:        op,
+		DenialReason:     audit[prefix+kausalityv1alpha1.AuditKeyDenialReason],
+		Warnings:         resp.Warnings,
+		Mode:             audit[prefix+kausalityv1alpha1.AuditKeyMode],
+		ApprovalStatus:   audit[prefix+kausalityv1alpha1.AuditKeyDriftResolution],
+		AuditAnnotations: audit,
+	}
+	if !resp.Allowed && resp.Result != nil {
+		result.Message = resp.Result.Message
+	}
+	if d := capture.drift; d != nil {
+		result.Drift = &WhatIfDrift{Detected: d.DriftDetected, Reason: d.Reason, LifecyclePhase: string(d.LifecyclePhase)}
+		if d.ParentRef != nil {
+			result.Drift.Parent = d.ParentRef.String()
+		}
+	}
+	return result, nil
+}
+
+// whatIfAdmissionRequest builds the dry-run admission request of a what-if
+// query, as the apiserver would send it.
+func (h *Handler) whatIfAdmissionRequest(q WhatIfRequest, op admissionv1.Operation, live *unstructured.Unstructured) (admission.Request, error) {
+	gvk := q.Object.GroupVersionKind()
+	resource := kindToResource(gvk.Kind)
+	if mapping, err := h.client.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); err == nil {
+		resource = mapping.Resource.Resource
+	}
+	obj := q.Object
+	if op == admissionv1.Update {
+		obj = appliedObject(live, q.Object)
+	}
+	dryRun := true
+	req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		UID:       types.UID(uuid.NewUUID()),
+		Kind:      metav1.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind},
+		Resource:  metav1.GroupVersionResource{Group: gvk.Group, Version: gvk.Version, Resource: resource},
+		Name:      q.Object.GetName(),
+		Namespace: q.Object.GetNamespace(),
+		Operation: op,
+		UserInfo:  authenticationv1.UserInfo{Username: q.User},
+		DryRun:    &dryRun,
+	}}
+	if op != admissionv1.Delete {
+		raw, err := json.Marshal(obj.Object)
+		if err != nil {
+			return admission.Request{}, err
+		}
+		req.Object = runtime.RawExtension{Raw: raw}
+	}
+	if live != nil && op != admissionv1.Create {
+		raw, err := json.Marshal(live.Object)
+		if err != nil {
+			return admission.Request{}, err
+		}
+		req.OldObject = runtime.RawExtension{Raw: raw}
+	}
+	if q.FieldManager != "" && op != admissionv1.Delete {
+		raw, err := json.Marshal(map[string]string{"fieldManager": q.FieldManager})
+		if err != nil {
+			return admission.Request{}, err
+		}
+		req.Options = runtime.RawExtension{Raw: raw}
+	}
+	return req, nil
+}
+
+// appliedObject returns the live object with a change applied like by
+// kubectl apply: fields set by the change replace those of the live object,
+// objects are merged, and fields the change leaves out, e.g. defaulted
+// ones, are kept. Status is not applied.
+func appliedObject(live, change *unstructured.Unstructured) *unstructured.Unstructured {
+	obj := live.DeepCopy()
+	for field, value := range change.Object {
+		switch field {
+		case "status":
+		case "metadata":
+			labels := obj.GetLabels()
+			if labels == nil {
+				labels = map[string]string{}
+			}
+			maps.Copy(labels, change.GetLabels())
+			obj.SetLabels(labels)
+			annotations := obj.GetAnnotations()
+			if annotations == nil {
+				annotations = map[string]string{}
+			}
+			maps.Copy(annotations, change.GetAnnotations())
+			obj.SetAnnotations(annotations)
+		default:
+			obj.Object[field] = mergeValue(obj.Object[field], value)
+		}
+	}
+	return obj
+}
+
+// mergeValue merges the objects of a change into the live ones. Other
+// values, including lists, are replaced.
+func mergeValue(live, change interface{}) interface{} {
+	liveMap, ok := live.(map[string]interface{})
+	changeMap, ok2 := change.(map[string]interface{})
+	if !ok || !ok2 {
+		return runtime.DeepCopyJSONValue(change)
+	}
+	for k, v := range changeMap {
+		liveMap[k] = mergeValue(liveMap[k], v)
+	}
+	return liveMap
+}
+
+// whatIfHandler returns a copy of the handler without the collaborators
+// that record or send anything.
+func (h *Handler) whatIfHandler() *Handler {
+	c := *h
+	c.callbackSender = nil
+	c.traceMirror = nil
+	c.driftStatus = nil
+	c.resolutions = nil
+	c.decisions = nil
+	c.recreations = nil
+	c.traceSigner = nil
+	c.quarantiner = nil
+	c.approvalRequester = nil
+	c.archiver = nil
+	c.controllerTracker = nopRecorder{}
+	return &c
+}
+
+// nopRecorder records nothing.
+type nopRecorder struct{}
+
+func (nopRecorder) RecordController(context.Context, client.Object, string) {}
+func (nopRecorder) RecordPhase(context.Context, client.Object, string)      {}
+
+// WhatIfHandler serves what-if queries: POST a WhatIfRequest as JSON to get
+// the WhatIfResponse. It is read-only, but reveals the state of parents to
+// its callers, so the webhook port should only be reachable by the
+// apiserver and trusted clients like CI pipelines.
+func (h *Handler) WhatIfHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var q WhatIfRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxWhatIfBodyBytes)).Decode(&q); err != nil {
+			http.Error(w, fmt.Sprintf("%v: %v", errInvalidWhatIf, err), http.StatusBadRequest)
+			return
+		}
+		result, err := h.WhatIf(r.Context(), q)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, errInvalidWhatIf) {
+				status = http.StatusBadRequest
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			h.log.Error(err, "failed to write what-if response")
+		}
+	})
+}
@@ -0,0 +1,151 @@
+package admission
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kausality-io/kausality/pkg/approval"
+	"github.com/kausality-io/kausality/pkg/controller"
+	"github.com/kausality-io/kausality/pkg/testing/scenario"
+)
+
+// newWhatIfTestHandler returns a handler with the parent "app" and its live
+// child "app-abc" updated last by the controller.
+func newWhatIfTestHandler(parentAnnotations map[string]string, child *unstructured.Unstructured) (*Handler, client.Client, *recordingSender) {
+	ann := map[string]string{
+		controller.PhaseAnnotation:       controller.PhaseValueInitialized,
+		controller.ControllersAnnotation: controller.HashUsername(deploymentController),
+	}
+	for k, v := range parentAnnotations {
+		ann[k] = v
+	}
+	parent := scenario.Object(scenario.DeploymentGVK, "default", "app", map[string]interface{}{"replicas": int64(1)})
+	parent.SetUID("app-uid")
+	parent.SetGeneration(1)
+	parent.SetAnnotations(ann)
+	parent.Object["status"] = map[string]interface{}{"observedGeneration": int64(1)}
+	sender := &recordingSender{}
+	c := fake.NewClientBuilder().WithScheme(runtime.NewScheme()).WithRuntimeObjects(parent, child).Build()
+	return NewHandler(Config{Client: c, Log: logr.Discard(), CallbackSender: sender}), c, sender
+}
+
+func TestWhatIf_DriftDeniedInEnforceMode(t *testing.T) {
+	ctrlHash := controller.HashUsername(deploymentController)
+	h, _, sender := newWhatIfTestHandler(nil, enforcedChild(1, map[string]string{controller.UpdatersAnnotation: ctrlHash}))
+
+	// The change carries only the fields applied, the live object the rest
+	change := scenario.Object(scenario.ReplicaSetGVK, "default", "app-abc", map[string]interface{}{"replicas": int64(3)})
+	result, err := h.WhatIf(context.Background(), WhatIfRequest{Object: change, User: deploymentController})
+	require.NoError(t, err)
+
+	assert.False(t, result.Allowed)
+	assert.Equal(t, admissionv1.Update, result.Operation)
+	assert.Contains(t, result.Message, "drift detected")
+	assert.Equal(t, "Drift", result.DenialReason)
+	assert.Equal(t, "enforce", result.Mode)
+	assert.Equal(t, "unresolved", result.ApprovalStatus)
+	require.NotNil(t, result.Drift)
+	assert.True(t, result.Drift.Detected)
+	assert.Equal(t, "apps/v1/Deployment:default/app", result.Drift.Parent)
+	assert.Equal(t, "Initialized", result.Drift.LifecyclePhase)
+	assert.Equal(t, "denied", result.AuditAnnotations[auditKeyDecision])
+	assert.Empty(t, sender.reports, "what-if queries send no drift reports")
+}
+
+func TestWhatIf_PersistsNothing(t *testing.T) {
+	ctrlHash := controller.HashUsername(deploymentController)
+	h, c, sender := newWhatIfTestHandler(map[string]string{
+		approval.ApprovalsAnnotation: `[{"apiVersion":"apps/v1","kind":"ReplicaSet","name":"app-abc","generation":1,"mode":"once"}]`,
+	}, childRS(1, ctrlHash))
+	ctx := context.Background()
+
+	for range 2 {
+		result, err := h.WhatIf(ctx, WhatIfRequest{Object: childRS(3, ""), User: deploymentController})
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, "approved", result.ApprovalStatus, "the approval is not consumed")
+	}
+	assert.Empty(t, sender.reports)
+
+	parent := &unstructured.Unstructured{}
+	parent.SetGroupVersionKind(deploymentGVK)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "app"}, parent))
+	approvals, err := approval.ParseApprovals(parent.GetAnnotations()[approval.ApprovalsAnnotation])
+	require.NoError(t, err)
+	require.Len(t, approvals, 1)
+	assert.Nil(t, approvals[0].Consumed)
+
+	child := &unstructured.Unstructured{}
+	child.SetGroupVersionKind(replicaSetGVK)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "app-abc"}, child))
+	replicas, _, _ := unstructured.NestedInt64(child.Object, "spec", "replicas")
+	assert.Equal(t, int64(1), replicas, "the child is not changed")
+}
+
+func TestWhatIf_Operation(t *testing.T) {
+	ctrlHash := controller.HashUsername(deploymentController)
+	h, _, _ := newWhatIfTestHandler(nil, childRS(1, ctrlHash))
+	ctx := context.Background()
+	configMap := scenario.Object(configMapGVK, "default", "new", map[string]interface{}{"data": "value"})
+
+	result, err := h.WhatIf(ctx, WhatIfRequest{Object: configMap, User: "alice"})
+	require.NoError(t, err)
+	assert.Equal(t, admissionv1.Create, result.Operation, "objects that do not exist are created")
+	assert.True(t, result.Allowed)
+
+	result, err = h.WhatIf(ctx, WhatIfRequest{Object: childRS(1, ""), Operation: admissionv1.Delete, User: "alice"})
+	require.NoError(t, err)
+	assert.Equal(t, admissionv1.Delete, result.Operation)
+	assert.True(t, result.Allowed)
+
+	_, err = h.WhatIf(ctx, WhatIfRequest{Object: configMap, Operation: admissionv1.Update, User: "alice"})
+	assert.ErrorIs(t, err, errInvalidWhatIf)
+	_, err = h.WhatIf(ctx, WhatIfRequest{Object: configMap, Operation: admissionv1.Connect, User: "alice"})
+	assert.ErrorIs(t, err, errInvalidWhatIf)
+	_, err = h.WhatIf(ctx, WhatIfRequest{Object: configMap})
+	assert.ErrorIs(t, err, errInvalidWhatIf)
+}
+
+func TestWhatIfHandler(t *testing.T) {
+	ctrlHash := controller.HashUsername(deploymentController)
+	h, _, _ := newWhatIfTestHandler(nil, enforcedChild(1, map[string]string{controller.UpdatersAnnotation: ctrlHash}))
+	server := h.WhatIfHandler()
+
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, WhatIfPath, bytes.NewBufferString(body)))
+		return rec
+	}
+
+	rec := post(`{"object":{"apiVersion":"apps/v1","kind":"ReplicaSet","metadata":{"namespace":"default","name":"app-abc"},"spec":{"replicas":3}},"user":"` + deploymentController + `"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var result WhatIfResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+	assert.False(t, result.Allowed)
+	assert.Equal(t, "Drift", result.DenialReason)
+
+	rec = post(`{"object":`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = post(`{"object":{"apiVersion":"apps/v1","kind":"ReplicaSet","metadata":{"namespace":"default","name":"other"}},"operation":"DELETE","user":"alice"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, WhatIfPath, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, http.MethodPost, rec.Header().Get("Allow"))
+}
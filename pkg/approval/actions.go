@@ -33,10 +33,6 @@ func NewActionApplier(c client.Client) *ActionApplier {
 // ApplyApproval adds an approval annotation to the parent object.
 // The mode can be "once", "generation", or "always".
 func (a *ActionApplier) ApplyApproval(ctx context.Context, parent ObjectRef, child ChildRef, mode string) error {
-	if mode == "" {
-		mode = ModeOnce
-	}
-
 	// Fetch the parent object
 	parentObj, err := a.fetchObject(ctx, parent)
 	if err != nil {
@@ -57,31 +53,47 @@ func (a *ActionApplier) ApplyApproval(ctx context.Context, parent ObjectRef, chi
 		}
 	}
 
-	// Check if approval already exists
-	for i, app := range approvals {
-		if app.Matches(child) {
-			// Update existing approval
-			approvals[i].Mode = mode
-			if mode != ModeAlways {
-				approvals[i].Generation = parentObj.GetGeneration()
-			}
-			return a.updateApprovals(ctx, parentObj, annotations, approvals)
-		}
-	}
+	approvals = MergeApprovals(approvals, []ChildRef{child}, mode, parentObj.GetGeneration())
+	return a.updateApprovals(ctx, parentObj, annotations, approvals)
+}
 
-	// Add new approval
-	approval := Approval{
-		APIVersion: child.APIVersion,
-		Kind:       child.Kind,
-		Name:       child.Name,
-		Mode:       mode,
+// MergeApprovals adds approvals for the children with the given mode. An
+// existing approval matching a child is updated instead, and no longer
+// consumed. Approvals other than ModeAlways are bound to the parent
+// generation.
+func MergeApprovals(approvals []Approval, children []ChildRef, mode string, generation int64) []Approval {
+	if mode == "" {
+		mode = ModeOnce
 	}
+	var gen int64
 	if mode != ModeAlways {
-		approval.Generation = parentObj.GetGeneration()
+		gen = generation
+	}
+
+	for _, child := range children {
+		found := false
+		for i := range approvals {
+			if approvals[i].Matches(child) {
+				approvals[i].Mode = mode
+				approvals[i].Consumed = nil
+				if mode != ModeAlways {
+					approvals[i].Generation = gen
+				}
+				found = true
+				break
+			}
+		}
+		if !found {
+			approvals = append(approvals, Approval{
+				APIVersion: child.APIVersion,
+				Kind:       child.Kind,
+				Name:       child.Name,
+				Mode:       mode,
+				Generation: gen,
+			})
+		}
 	}
-	approvals = append(approvals, approval)
-
-	return a.updateApprovals(ctx, parentObj, annotations, approvals)
+	return approvals
 }
 
 // ApplyRejection adds a rejection annotation to the parent object.
@@ -110,27 +122,28 @@ func (a *ActionApplier) ApplyRejection(ctx context.Context, parent ObjectRef, ch
 		}
 	}
 
-	// Check if rejection already exists
+	rejections = MergeRejections(rejections, child, reason, parentObj.GetGeneration())
+	return a.updateRejections(ctx, parentObj, annotations, rejections)
+}
+
+// MergeRejections adds a rejection of the child with the reason, bound to
+// the parent generation. An existing rejection matching the child is updated
+// instead.
+func MergeRejections(rejections []Rejection, child ChildRef, reason string, generation int64) []Rejection {
 	for i, rej := range rejections {
 		if rej.Matches(child) {
-			// Update existing rejection
 			rejections[i].Reason = reason
-			rejections[i].Generation = parentObj.GetGeneration()
-			return a.updateRejections(ctx, parentObj, annotations, rejections)
+			rejections[i].Generation = generation
+			return rejections
 		}
 	}
-
-	// Add new rejection
-	rejection := Rejection{
+	return append(rejections, Rejection{
 		APIVersion: child.APIVersion,
 		Kind:       child.Kind,
 		Name:       child.Name,
 		Reason:     reason,
-		Generation: parentObj.GetGeneration(),
-	}
-	rejections = append(rejections, rejection)
-
-	return a.updateRejections(ctx, parentObj, annotations, rejections)
+		Generation: generation,
+	})
 }
 
 // ApplySnooze sets the snooze annotation on the parent object.
@@ -323,6 +336,60 @@ func (a *ActionApplier) ClearSnooze(ctx context.Context, parent ObjectRef) error
 	return nil
 }
 
+// ApplySuppression sets the suppress annotation on a child object, muting
+// its drift for the given duration.
+func (a *ActionApplier) ApplySuppression(ctx context.Context, child ObjectRef, duration time.Duration, user, message string) error {
+	childObj, err := a.fetchObject(ctx, child)
+	if err != nil {
+		return fmt.Errorf("failed to fetch child: %w", err)
+	}
+
+	annotations := childObj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	suppression := &Suppression{
+		Until:   metav1.Time{Time: time.Now().Add(duration).UTC()},
+		User:    user,
+		Message: message,
+	}
+	suppressionValue, err := MarshalSuppression(suppression)
+	if err != nil {
+		return fmt.Errorf("failed to marshal suppression: %w", err)
+	}
+	annotations[SuppressAnnotation] = suppressionValue
+
+	childObj.SetAnnotations(annotations)
+	if err := a.client.Update(ctx, childObj); err != nil {
+		return fmt.Errorf("failed to update child: %w", err)
+	}
+
+	return nil
+}
+
+// ClearSuppression removes the suppress annotation from a child object.
+func (a *ActionApplier) ClearSuppression(ctx context.Context, child ObjectRef) error {
+	childObj, err := a.fetchObject(ctx, child)
+	if err != nil {
+		return fmt.Errorf("failed to fetch child: %w", err)
+	}
+
+	annotations := childObj.GetAnnotations()
+	if annotations == nil || annotations[SuppressAnnotation] == "" {
+		return nil // No suppression to clear
+	}
+
+	delete(annotations, SuppressAnnotation)
+	childObj.SetAnnotations(annotations)
+
+	if err := a.client.Update(ctx, childObj); err != nil {
+		return fmt.Errorf("failed to update child: %w", err)
+	}
+
+	return nil
+}
+
 // fetchObject fetches an object by reference.
 func (a *ActionApplier) fetchObject(ctx context.Context, ref ObjectRef) (*unstructured.Unstructured, error) {
 	gv, err := schema.ParseGroupVersion(ref.APIVersion)
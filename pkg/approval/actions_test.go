@@ -275,6 +275,42 @@ func TestActionApplier_ClearSnooze(t *testing.T) {
 	assert.Empty(t, annotations[SnoozeAnnotation])
 }
 
+func TestActionApplier_ApplySuppression(t *testing.T) {
+	child := createTestParent(1, nil)
+	fakeClient := fake.NewClientBuilder().WithObjects(child).Build()
+
+	applier := NewActionApplier(fakeClient)
+	childRef := ObjectRef{
+		APIVersion: "example.com/v1alpha1",
+		Kind:       "TestParent",
+		Namespace:  "default",
+		Name:       "test-parent",
+	}
+
+	before := time.Now()
+	err := applier.ApplySuppression(context.Background(), childRef, 2*time.Hour, "admin@example.com", "migrating storage class")
+	require.NoError(t, err)
+
+	updated := &unstructured.Unstructured{}
+	updated.SetGroupVersionKind(child.GroupVersionKind())
+	err = fakeClient.Get(context.Background(), client.ObjectKeyFromObject(child), updated)
+	require.NoError(t, err)
+
+	suppression, err := ParseSuppression(updated.GetAnnotations()[SuppressAnnotation])
+	require.NoError(t, err)
+	require.NotNil(t, suppression)
+	assert.Equal(t, "admin@example.com", suppression.User)
+	assert.Equal(t, "migrating storage class", suppression.Message)
+	assert.True(t, suppression.Until.After(before.Add(119*time.Minute)))
+	assert.True(t, suppression.IsActive())
+
+	err = applier.ClearSuppression(context.Background(), childRef)
+	require.NoError(t, err)
+	err = fakeClient.Get(context.Background(), client.ObjectKeyFromObject(child), updated)
+	require.NoError(t, err)
+	assert.Empty(t, updated.GetAnnotations()[SuppressAnnotation])
+}
+
 func TestActionApplier_ApplyFreeze(t *testing.T) {
 	parent := createTestParent(1, nil)
 	fakeClient := fake.NewClientBuilder().WithObjects(parent).Build()
@@ -354,6 +390,30 @@ func TestActionApplier_FetchObjectNotFound(t *testing.T) {
 	assert.Contains(t, err.Error(), "failed to fetch parent")
 }
 
+func TestMergeApprovals(t *testing.T) {
+	existing := []Approval{
+		{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-a", Mode: ModeOnce, Generation: 1},
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "config", Mode: ModeAlways},
+	}
+	children := []ChildRef{
+		{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-a"},
+		{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-b"},
+	}
+
+	got := MergeApprovals(existing, children, ModeGeneration, 3)
+	assert.Equal(t, []Approval{
+		{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-a", Mode: ModeGeneration, Generation: 3},
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "config", Mode: ModeAlways},
+		{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-b", Mode: ModeGeneration, Generation: 3},
+	}, got)
+
+	got = MergeApprovals(nil, children[:1], "", 2)
+	assert.Equal(t, []Approval{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-a", Mode: ModeOnce, Generation: 2}}, got)
+
+	got = MergeApprovals(nil, children[:1], ModeAlways, 2)
+	assert.Equal(t, []Approval{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-a", Mode: ModeAlways}}, got)
+}
+
 func createTestParent(generation int64, annotations map[string]string) *unstructured.Unstructured {
 	parent := &unstructured.Unstructured{
 		Object: map[string]interface{}{
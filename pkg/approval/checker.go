@@ -27,6 +27,29 @@ func NewChecker() *Checker {
 	return &Checker{}
 }
 
+// ParentApprovals are the approvals and rejections of a parent, parsed from
+// its annotations.
+type ParentApprovals struct {
+	Approvals  []Approval
+	Rejections []Rejection
+	// ApprovalsErr and RejectionsErr are the errors parsing the annotations.
+	ApprovalsErr  error
+	RejectionsErr error
+}
+
+// ParseParentApprovals parses the approvals and rejections annotations of a
+// parent. Missing annotations parse to no approvals or rejections.
+func ParseParentApprovals(annotations map[string]string) ParentApprovals {
+	var p ParentApprovals
+	if value := annotations[ApprovalsAnnotation]; value != "" {
+		p.Approvals, p.ApprovalsErr = ParseApprovals(value)
+	}
+	if value := annotations[RejectionsAnnotation]; value != "" {
+		p.Rejections, p.RejectionsErr = ParseRejections(value)
+	}
+	return p
+}
+
 // Check checks if a mutation to the given child is approved or rejected.
 // It reads approvals/rejections from the parent's annotations.
 //
@@ -41,30 +64,29 @@ func (c *Checker) Check(parent client.Object, child ChildRef, parentGeneration i
 			Reason: "no approvals or rejections on parent",
 		}
 	}
+	return c.CheckParsed(ParseParentApprovals(annotations), child, parentGeneration)
+}
 
+// CheckParsed checks if a mutation to the given child is approved or
+// rejected by already parsed approvals and rejections, with the priority of
+// Check. Rejections that failed to parse reject nothing.
+func (c *Checker) CheckParsed(p ParentApprovals, child ChildRef, parentGeneration int64) CheckResult {
 	// Check rejections first (rejection wins)
-	if result := c.checkRejections(annotations, child, parentGeneration); result.Rejected {
+	if result := c.checkRejections(p.Rejections, child, parentGeneration); result.Rejected {
 		return result
 	}
 
 	// Check approvals
-	return c.checkApprovals(annotations, child, parentGeneration)
-}
-
-// checkRejections checks if the child is rejected.
-func (c *Checker) checkRejections(annotations map[string]string, child ChildRef, parentGeneration int64) CheckResult {
-	rejectionsStr := annotations[RejectionsAnnotation]
-	if rejectionsStr == "" {
-		return CheckResult{}
-	}
-
-	rejections, err := ParseRejections(rejectionsStr)
-	if err != nil {
+	if p.ApprovalsErr != nil {
 		return CheckResult{
-			Reason: "failed to parse rejections: " + err.Error(),
+			Reason: "failed to parse approvals: " + p.ApprovalsErr.Error(),
 		}
 	}
+	return c.checkApprovals(p.Approvals, child, parentGeneration)
+}
 
+// checkRejections checks if the child is rejected.
+func (c *Checker) checkRejections(rejections []Rejection, child ChildRef, parentGeneration int64) CheckResult {
 	for i := range rejections {
 		r := &rejections[i]
 		if r.Matches(child) && r.IsActive(parentGeneration) {
@@ -80,24 +102,16 @@ func (c *Checker) checkRejections(annotations map[string]string, child ChildRef,
 }
 
 // checkApprovals checks if the child is approved.
-func (c *Checker) checkApprovals(annotations map[string]string, child ChildRef, parentGeneration int64) CheckResult {
-	approvalsStr := annotations[ApprovalsAnnotation]
-	if approvalsStr == "" {
-		return CheckResult{
-			Reason: "no approval found for child",
-		}
-	}
-
-	approvals, err := ParseApprovals(approvalsStr)
-	if err != nil {
-		return CheckResult{
-			Reason: "failed to parse approvals: " + err.Error(),
-		}
-	}
-
+func (c *Checker) checkApprovals(approvals []Approval, child ChildRef, parentGeneration int64) CheckResult {
+	consumed := false
 	for i := range approvals {
 		a := &approvals[i]
 		if a.Matches(child) {
+			if a.Consumed != nil {
+				// Used already; a later approval may still match
+				consumed = true
+				continue
+			}
 			if a.IsValid(parentGeneration) {
 				return CheckResult{
 					Approved:        true,
@@ -112,6 +126,11 @@ func (c *Checker) checkApprovals(annotations map[string]string, child ChildRef,
 		}
 	}
 
+	if consumed {
+		return CheckResult{
+			Reason: "approval found but already consumed",
+		}
+	}
 	return CheckResult{
 		Reason: "no approval found for child",
 	}
@@ -120,16 +139,9 @@ func (c *Checker) checkApprovals(annotations map[string]string, child ChildRef,
 // CheckFromAnnotations is a convenience function that checks approvals
 // directly from annotation strings.
 func CheckFromAnnotations(approvalsStr, rejectionsStr string, child ChildRef, parentGeneration int64) CheckResult {
-	c := &Checker{}
 	annotations := map[string]string{
 		ApprovalsAnnotation:  approvalsStr,
 		RejectionsAnnotation: rejectionsStr,
 	}
-
-	// Check rejections first (rejection wins)
-	if result := c.checkRejections(annotations, child, parentGeneration); result.Rejected {
-		return result
-	}
-
-	return c.checkApprovals(annotations, child, parentGeneration)
+	return (&Checker{}).CheckParsed(ParseParentApprovals(annotations), child, parentGeneration)
 }
@@ -75,6 +75,24 @@ func TestChecker_Check(t *testing.T) {
 			wantApproved:     true,
 			wantRejected:     false,
 		},
+		{
+			name: "consumed once approval",
+			annotations: map[string]string{
+				ApprovalsAnnotation: `[{"apiVersion":"v1","kind":"ConfigMap","name":"test-cm","generation":5,"mode":"once","consumed":{"at":"2026-01-24T10:30:00Z","driftID":"3f2a9c1d0b7e4a65"}}]`,
+			},
+			parentGeneration: 5,
+			wantApproved:     false,
+			wantRejected:     false,
+		},
+		{
+			name: "approval after consumed one",
+			annotations: map[string]string{
+				ApprovalsAnnotation: `[{"apiVersion":"v1","kind":"ConfigMap","name":"test-cm","generation":5,"mode":"once","consumed":{"at":"2026-01-24T10:30:00Z"}},{"apiVersion":"v1","kind":"ConfigMap","name":"*","mode":"always"}]`,
+			},
+			parentGeneration: 5,
+			wantApproved:     true,
+			wantRejected:     false,
+		},
 		{
 			name: "no matching approval",
 			annotations: map[string]string{
@@ -262,6 +280,29 @@ func TestCheckFromAnnotations(t *testing.T) {
 	}
 }
 
+func TestParseParentApprovals(t *testing.T) {
+	child := ChildRef{APIVersion: "v1", Kind: "Secret", Name: "creds"}
+
+	p := ParseParentApprovals(map[string]string{
+		ApprovalsAnnotation:  `[{"apiVersion":"v1","kind":"Secret","name":"creds","mode":"always"}]`,
+		RejectionsAnnotation: "not json",
+	})
+	assert.Len(t, p.Approvals, 1)
+	assert.NoError(t, p.ApprovalsErr)
+	assert.Error(t, p.RejectionsErr)
+	assert.True(t, NewChecker().CheckParsed(p, child, 1).Approved, "invalid rejections reject nothing")
+
+	p = ParseParentApprovals(map[string]string{ApprovalsAnnotation: "not json"})
+	result := NewChecker().CheckParsed(p, child, 1)
+	assert.False(t, result.Approved)
+	assert.Contains(t, result.Reason, "failed to parse approvals")
+
+	p = ParseParentApprovals(nil)
+	assert.Empty(t, p.Approvals)
+	assert.Empty(t, p.Rejections)
+	assert.Equal(t, "no approval found for child", NewChecker().CheckParsed(p, child, 1).Reason)
+}
+
 // toInterfaceMap converts map[string]string to map[string]interface{} for unstructured.
 func toInterfaceMap(m map[string]string) map[string]interface{} {
 	if m == nil {
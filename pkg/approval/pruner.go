@@ -41,9 +41,39 @@ func (p *Pruner) ConsumeOnce(approvals []Approval, consumed *Approval) ([]Approv
 	return result, found
 }
 
+// MarkConsumed records the use of a mode=once approval in the list instead
+// of removing it, so that the consumption stays visible until the approval
+// is pruned as stale. Returns the updated list and true if an approval was
+// marked.
+func (p *Pruner) MarkConsumed(approvals []Approval, consumed *Approval, consumption ApprovalConsumption) ([]Approval, bool) {
+	if consumed == nil || consumed.Consumed != nil {
+		return approvals, false
+	}
+
+	mode := consumed.Mode
+	if mode == "" {
+		mode = ModeOnce
+	}
+	if mode != ModeOnce {
+		return approvals, false
+	}
+
+	result := make([]Approval, len(approvals))
+	copy(result, approvals)
+	for i, a := range result {
+		if a.Consumed == nil && a.APIVersion == consumed.APIVersion && a.Kind == consumed.Kind && a.Name == consumed.Name &&
+			a.Generation == consumed.Generation && a.Mode == consumed.Mode {
+			result[i].Consumed = &consumption
+			return result, true
+		}
+	}
+
+	return approvals, false
+}
+
 // PruneStale removes approvals that are stale due to parent generation change.
-// Removes mode=once and mode=generation approvals where approval.generation < parentGeneration.
-// mode=always approvals are never pruned.
+// Removes mode=once and mode=generation approvals where approval.generation < parentGeneration,
+// consumed or not. mode=always approvals are never pruned.
 func (p *Pruner) PruneStale(approvals []Approval, parentGeneration int64) []Approval {
 	result := make([]Approval, 0, len(approvals))
 
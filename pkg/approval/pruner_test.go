@@ -2,8 +2,12 @@ package approval
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestPruner_ConsumeOnce(t *testing.T) {
@@ -72,6 +76,37 @@ func TestPruner_ConsumeOnce(t *testing.T) {
 	}
 }
 
+func TestPruner_MarkConsumed(t *testing.T) {
+	pruner := NewPruner()
+	consumption := ApprovalConsumption{At: metav1.NewTime(time.Date(2026, 1, 24, 10, 30, 0, 0, time.UTC)), DriftID: "3f2a9c1d0b7e4a65"}
+
+	approvals := []Approval{
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "a", Generation: 5, Mode: ModeOnce},
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "b", Generation: 5},
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "c", Mode: ModeAlways},
+	}
+
+	result, changed := pruner.MarkConsumed(approvals, &approvals[1], consumption)
+	require.True(t, changed)
+	require.Len(t, result, 3)
+	assert.Nil(t, result[0].Consumed)
+	require.NotNil(t, result[1].Consumed)
+	assert.Equal(t, "3f2a9c1d0b7e4a65", result[1].Consumed.DriftID)
+	assert.Nil(t, approvals[1].Consumed, "input is not modified")
+	assert.False(t, result[1].IsValid(5), "consumed approvals are not valid")
+
+	_, changed = pruner.MarkConsumed(result, &result[1], consumption)
+	assert.False(t, changed, "consumed approvals are not consumed again")
+	_, changed = pruner.MarkConsumed(approvals, &approvals[2], consumption)
+	assert.False(t, changed, "mode=always approvals are not consumed")
+	_, changed = pruner.MarkConsumed(approvals, nil, consumption)
+	assert.False(t, changed)
+
+	// Consumed approvals are pruned with their generation
+	assert.Len(t, pruner.PruneStale(result, 5), 3)
+	assert.Len(t, pruner.PruneStale(result, 6), 1)
+}
+
 func TestPruner_PruneStale(t *testing.T) {
 	pruner := NewPruner()
 
@@ -0,0 +1,162 @@
+package approval
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/go-logr/logr"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kausality-io/kausality/api/v1alpha1"
+)
+
+const (
+	// requestWorkers is the number of concurrent workers creating requests.
+	requestWorkers = 2
+	// requestMaxRetries is the number of attempts before a request is dropped.
+	requestMaxRetries = 5
+)
+
+// Requester requests approvals of denied drift.
+type Requester interface {
+	RequestApproval(ctx context.Context, request *v1alpha1.ApprovalRequest)
+}
+
+var _ Requester = &RequestWriter{}
+
+// RequestName returns the name of the ApprovalRequest of a drift denied at
+// a parent generation. Retries of the same change map to the same request.
+func RequestName(driftID string, parentGeneration int64) string {
+	return fmt.Sprintf("drift-%s-%d", driftID, parentGeneration)
+}
+
+// RequestWriter creates ApprovalRequests in the background, so that
+// admission does not wait for them. Requests are labeled with
+// ApprovalRequestManagedByLabel for the RequestReconciler, and must be
+// created with the field manager controller.FieldManager. Requests are queued by name and created
+// by workers; a request that already exists is left alone, so repeated
+// denials of the same drift leave one request. RequestWriter does not need
+// leader election: creating is idempotent.
+type RequestWriter struct {
+	client client.Client
+	log    logr.Logger
+	queue  workqueue.TypedRateLimitingInterface[types.NamespacedName]
+
+	mu      sync.Mutex
+	pending map[types.NamespacedName]*v1alpha1.ApprovalRequest
+}
+
+// NewRequestWriter creates a RequestWriter. Add it to a manager to start
+// its workers.
+func NewRequestWriter(c client.Client, log logr.Logger) *RequestWriter {
+	return &RequestWriter{
+		client: c,
+		log:    log.WithName("approval-requests"),
+		queue: workqueue.NewTypedRateLimitingQueueWithConfig(
+			workqueue.DefaultTypedControllerRateLimiter[types.NamespacedName](),
+			workqueue.TypedRateLimitingQueueConfig[types.NamespacedName]{Name: "approval-requests"},
+		),
+		pending: make(map[types.NamespacedName]*v1alpha1.ApprovalRequest),
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Every
+// replica requests the approvals of the drift it denies.
+func (w *RequestWriter) NeedLeaderElection() bool {
+	return false
+}
+
+// Start runs the workers until the context is cancelled.
+func (w *RequestWriter) Start(ctx context.Context) error {
+	w.log.Info("starting approval request writer", "workers", requestWorkers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < requestWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for w.processNext(ctx) {
+			}
+		}()
+	}
+
+	<-ctx.Done()
+	w.queue.ShutDown()
+	wg.Wait()
+	return nil
+}
+
+// RequestApproval enqueues creating the request.
+func (w *RequestWriter) RequestApproval(ctx context.Context, request *v1alpha1.ApprovalRequest) {
+	request = request.DeepCopy()
+	key := client.ObjectKeyFromObject(request)
+	w.mu.Lock()
+	w.pending[key] = request
+	w.mu.Unlock()
+	w.queue.Add(key)
+}
+
+// Len returns the number of requests waiting to be created.
+func (w *RequestWriter) Len() int {
+	return w.queue.Len()
+}
+
+func (w *RequestWriter) processNext(ctx context.Context) bool {
+	key, shutdown := w.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer w.queue.Done(key)
+
+	log := w.log.WithValues("namespace", key.Namespace, "name", key.Name)
+	err := w.create(ctx, key)
+	switch {
+	case err == nil:
+		w.queue.Forget(key)
+		w.forget(key)
+	case w.queue.NumRequeues(key) >= requestMaxRetries:
+		log.Error(err, "giving up creating approval request", "attempts", requestMaxRetries)
+		w.queue.Forget(key)
+		w.forget(key)
+	default:
+		log.Error(err, "failed to create approval request, retrying")
+		w.queue.AddRateLimited(key)
+	}
+	return true
+}
+
+// create creates the pending request of key, unless it exists.
+func (w *RequestWriter) create(ctx context.Context, key types.NamespacedName) error {
+	w.mu.Lock()
+	request, ok := w.pending[key]
+	w.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	request = request.DeepCopy()
+	if request.Labels == nil {
+		request.Labels = make(map[string]string)
+	}
+	request.Labels[v1alpha1.ApprovalRequestManagedByLabel] = v1alpha1.ApprovalRequestManagedByValue
+	err := w.client.Create(ctx, request)
+	switch {
+	case apierrors.IsAlreadyExists(err):
+		return nil
+	case err != nil:
+		return err
+	}
+	w.log.Info("requested approval", "namespace", key.Namespace, "name", key.Name,
+		"child", request.Spec.Child.Kind+"/"+request.Spec.Child.Name, "driftID", request.Spec.DriftID)
+	return nil
+}
+
+func (w *RequestWriter) forget(key types.NamespacedName) {
+	w.mu.Lock()
+	delete(w.pending, key)
+	w.mu.Unlock()
+}
@@ -0,0 +1,298 @@
+package approval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kausality-io/kausality/api/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/controller"
+)
+
+const (
+	// DefaultRequestTTL is how long requests are kept after they completed.
+	DefaultRequestTTL = 24 * time.Hour
+	// pendingRecheckInterval is how often pending requests are checked for
+	// parents that moved on to another generation.
+	pendingRecheckInterval = 10 * time.Minute
+)
+
+// errStale is returned when the parent no longer is at the generation of a
+// request.
+var errStale = errors.New("stale")
+
+// RequestReconciler applies the decisions of ApprovalRequests to their
+// parents: an approval or a rejection of the child, bound to the parent
+// generation of the denied drift. Requests whose parent was deleted or
+// changed its generation before a decision are marked stale. Completed
+// requests are deleted after a TTL.
+//
+// Whoever may decide requests in a namespace may approve drift of the
+// parents they name, so only requests created by the webhook are acted on,
+// and only for parents in the namespace of the request, or cluster-scoped
+// parents in the configured namespace. Other requests carrying the webhook's
+// label are marked invalid.
+type RequestReconciler struct {
+	client    client.Client
+	namespace string
+	ttl       time.Duration
+	log       logr.Logger
+	now       func() time.Time
+}
+
+// NewRequestReconciler creates a RequestReconciler keeping completed
+// requests for ttl, DefaultRequestTTL if zero. Requests of cluster-scoped
+// parents are accepted in namespace. Parents are written with c.
+func NewRequestReconciler(c client.Client, namespace string, ttl time.Duration, log logr.Logger) *RequestReconciler {
+	if ttl == 0 {
+		ttl = DefaultRequestTTL
+	}
+	return &RequestReconciler{
+		client:    c,
+		namespace: namespace,
+		ttl:       ttl,
+		log:       log.WithName("approval-request-controller"),
+		now:       time.Now,
+	}
+}
+
+// SetupWithManager registers the reconciler with the manager.
+func (r *RequestReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("approval-request").
+		For(&v1alpha1.ApprovalRequest{}).
+		Complete(r)
+}
+
+// Reconcile applies the decision of an ApprovalRequest, or expires it.
+func (r *RequestReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	request := &v1alpha1.ApprovalRequest{}
+	if err := r.client.Get(ctx, req.NamespacedName, request); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	log := r.log.WithValues("namespace", request.Namespace, "name", request.Name)
+
+	if request.Labels[v1alpha1.ApprovalRequestManagedByLabel] != v1alpha1.ApprovalRequestManagedByValue {
+		log.V(1).Info("ignoring approval request not created by kausality")
+		return ctrl.Result{}, nil
+	}
+	if request.Status.Phase != "" && request.Status.Phase != v1alpha1.ApprovalRequestPhasePending {
+		return r.expire(ctx, request)
+	}
+	if msg := r.invalid(request); msg != "" {
+		log.Info("approval request is invalid", "reason", msg)
+		return r.complete(ctx, request, v1alpha1.ApprovalRequestPhaseInvalid, msg)
+	}
+
+	var err error
+	switch request.Spec.Decision {
+	case v1alpha1.ApprovalRequestDecisionApprove:
+		err = r.updateParent(ctx, request, func(annotations map[string]string) error {
+			return addRequestApproval(annotations, request)
+		})
+	case v1alpha1.ApprovalRequestDecisionReject:
+		err = r.updateParent(ctx, request, func(annotations map[string]string) error {
+			return addRequestRejection(annotations, request)
+		})
+	default:
+		// Undecided: only check that the parent did not move on
+		err = r.checkParent(ctx, request)
+	}
+
+	switch {
+	case errors.Is(err, errStale):
+		log.Info("approval request is stale", "reason", err.Error())
+		return r.complete(ctx, request, v1alpha1.ApprovalRequestPhaseStale, err.Error())
+	case err != nil:
+		return ctrl.Result{}, err
+	case request.Spec.Decision == v1alpha1.ApprovalRequestDecisionApprove:
+		log.Info("applied approval", "parent", request.Spec.Parent.Kind+"/"+request.Spec.Parent.Name, "mode", request.Spec.Mode)
+		return r.complete(ctx, request, v1alpha1.ApprovalRequestPhaseApproved,
+			fmt.Sprintf("approval applied to %s %s", request.Spec.Parent.Kind, request.Spec.Parent.Name))
+	case request.Spec.Decision == v1alpha1.ApprovalRequestDecisionReject:
+		log.Info("applied rejection", "parent", request.Spec.Parent.Kind+"/"+request.Spec.Parent.Name)
+		return r.complete(ctx, request, v1alpha1.ApprovalRequestPhaseRejected,
+			fmt.Sprintf("rejection applied to %s %s", request.Spec.Parent.Kind, request.Spec.Parent.Name))
+	}
+
+	if request.Status.Phase != v1alpha1.ApprovalRequestPhasePending {
+		request.Status.Phase = v1alpha1.ApprovalRequestPhasePending
+		request.Status.Message = "awaiting a decision"
+		if err := r.client.Status().Update(ctx, request); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{RequeueAfter: pendingRecheckInterval}, nil
+}
+
+// invalid returns why the request must not be acted on, or "": it was not
+// created by the webhook, or names a parent outside of its namespace.
+func (r *RequestReconciler) invalid(request *v1alpha1.ApprovalRequest) string {
+	if !createdByWebhook(request) {
+		return fmt.Sprintf("not created by %s", controller.FieldManager)
+	}
+	switch parentNamespace := request.Spec.Parent.Namespace; {
+	case parentNamespace == "" && (r.namespace == "" || request.Namespace != r.namespace):
+		return fmt.Sprintf("requests of cluster-scoped parents must be in namespace %q", r.namespace)
+	case parentNamespace != "" && parentNamespace != request.Namespace:
+		return fmt.Sprintf("parent namespace %q differs from the namespace of the request", parentNamespace)
+	}
+	return ""
+}
+
+// createdByWebhook returns whether the parent of the request was set by the
+// webhook's field manager. The parent is immutable, so it was set on
+// creation.
+func createdByWebhook(request *v1alpha1.ApprovalRequest) bool {
+	for _, entry := range request.ManagedFields {
+		if entry.Manager != controller.FieldManager || entry.FieldsV1 == nil {
+			continue
+		}
+		var fields struct {
+			Spec map[string]json.RawMessage `json:"f:spec"`
+		}
+		if json.Unmarshal(entry.FieldsV1.Raw, &fields) == nil && fields.Spec["f:parent"] != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// updateParent updates the annotations of the parent with update, retrying
+// on conflicts. Only the changed annotations are patched, conditional on the
+// resourceVersion the generation was checked at. It returns errStale if the
+// parent moved on.
+func (r *RequestReconciler) updateParent(ctx context.Context, request *v1alpha1.ApprovalRequest, update func(map[string]string) error) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		parent, err := r.fetchParent(ctx, request)
+		if err != nil {
+			return err
+		}
+		patch := client.MergeFromWithOptions(parent.DeepCopy(), client.MergeFromWithOptimisticLock{})
+		annotations := parent.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		if err := update(annotations); err != nil {
+			return err
+		}
+		parent.SetAnnotations(annotations)
+		return r.client.Patch(ctx, parent, patch)
+	})
+}
+
+// checkParent returns errStale if the parent moved on.
+func (r *RequestReconciler) checkParent(ctx context.Context, request *v1alpha1.ApprovalRequest) error {
+	_, err := r.fetchParent(ctx, request)
+	return err
+}
+
+// fetchParent returns the parent of the request at its generation, or
+// errStale.
+func (r *RequestReconciler) fetchParent(ctx context.Context, request *v1alpha1.ApprovalRequest) (*unstructured.Unstructured, error) {
+	ref := request.Spec.Parent
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid parent API version: %w", err)
+	}
+	parent := &unstructured.Unstructured{}
+	parent.SetGroupVersionKind(gv.WithKind(ref.Kind))
+	err = r.client.Get(ctx, client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}, parent)
+	switch {
+	case apierrors.IsNotFound(err):
+		return nil, fmt.Errorf("%w: parent %s %s was deleted", errStale, ref.Kind, ref.Name)
+	case err != nil:
+		return nil, fmt.Errorf("failed to get parent: %w", err)
+	}
+	if gen := parent.GetGeneration(); gen != request.Spec.ParentGeneration {
+		return nil, fmt.Errorf("%w: parent %s %s moved on from generation %d to %d", errStale, ref.Kind, ref.Name, request.Spec.ParentGeneration, gen)
+	}
+	return parent, nil
+}
+
+// complete moves the request to a final phase and requeues its expiry.
+func (r *RequestReconciler) complete(ctx context.Context, request *v1alpha1.ApprovalRequest, phase v1alpha1.ApprovalRequestPhase, message string) (ctrl.Result, error) {
+	now := metav1.NewTime(r.now())
+	request.Status.Phase = phase
+	request.Status.Message = message
+	request.Status.CompletedAt = &now
+	if err := r.client.Status().Update(ctx, request); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: r.ttl}, nil
+}
+
+// expire deletes a completed request after the TTL.
+func (r *RequestReconciler) expire(ctx context.Context, request *v1alpha1.ApprovalRequest) (ctrl.Result, error) {
+	completed := request.CreationTimestamp.Time
+	if request.Status.CompletedAt != nil {
+		completed = request.Status.CompletedAt.Time
+	}
+	if remaining := completed.Add(r.ttl).Sub(r.now()); remaining > 0 {
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+	if err := r.client.Delete(ctx, request); client.IgnoreNotFound(err) != nil {
+		return ctrl.Result{}, err
+	}
+	r.log.V(1).Info("deleted expired approval request", "namespace", request.Namespace, "name", request.Name)
+	return ctrl.Result{}, nil
+}
+
+// addRequestApproval adds the approval of the request to the annotations of
+// its parent.
+func addRequestApproval(annotations map[string]string, request *v1alpha1.ApprovalRequest) error {
+	var approvals []Approval
+	if existing := annotations[ApprovalsAnnotation]; existing != "" {
+		var err error
+		if approvals, err = ParseApprovals(existing); err != nil {
+			return fmt.Errorf("failed to parse existing approvals: %w", err)
+		}
+	}
+	approvals = MergeApprovals(approvals, []ChildRef{requestChild(request)}, request.Spec.Mode, request.Spec.ParentGeneration)
+	data, err := json.Marshal(approvals)
+	if err != nil {
+		return fmt.Errorf("failed to marshal approvals: %w", err)
+	}
+	annotations[ApprovalsAnnotation] = string(data)
+	return nil
+}
+
+// addRequestRejection adds the rejection of the request to the annotations
+// of its parent.
+func addRequestRejection(annotations map[string]string, request *v1alpha1.ApprovalRequest) error {
+	var rejections []Rejection
+	if existing := annotations[RejectionsAnnotation]; existing != "" {
+		var err error
+		if rejections, err = ParseRejections(existing); err != nil {
+			return fmt.Errorf("failed to parse existing rejections: %w", err)
+		}
+	}
+	reason := request.Spec.Reason
+	if reason == "" {
+		reason = "rejected by approval request " + request.Name
+	}
+	rejections = MergeRejections(rejections, requestChild(request), reason, request.Spec.ParentGeneration)
+	data, err := json.Marshal(rejections)
+	if err != nil {
+		return fmt.Errorf("failed to marshal rejections: %w", err)
+	}
+	annotations[RejectionsAnnotation] = string(data)
+	return nil
+}
+
+// requestChild returns the child of the request.
+func requestChild(request *v1alpha1.ApprovalRequest) ChildRef {
+	return ChildRef{APIVersion: request.Spec.Child.APIVersion, Kind: request.Spec.Child.Kind, Name: request.Spec.Child.Name}
+}
@@ -0,0 +1,251 @@
+package approval
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/kausality-io/kausality/api/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/controller"
+)
+
+func requestParent(generation int64) *unstructured.Unstructured {
+	parent := &unstructured.Unstructured{}
+	parent.SetAPIVersion("apps/v1")
+	parent.SetKind("Deployment")
+	parent.SetNamespace("default")
+	parent.SetName("web")
+	parent.SetGeneration(generation)
+	return parent
+}
+
+func testRequest(decision v1alpha1.ApprovalRequestDecision) *v1alpha1.ApprovalRequest {
+	return &v1alpha1.ApprovalRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      RequestName("abc", 4),
+			Labels:    map[string]string{v1alpha1.ApprovalRequestManagedByLabel: v1alpha1.ApprovalRequestManagedByValue},
+		},
+		Spec: v1alpha1.ApprovalRequestSpec{
+			Parent:           v1alpha1.ApprovalRequestObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "web"},
+			ParentGeneration: 4,
+			Child:            v1alpha1.ApprovalRequestObjectReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Namespace: "default", Name: "web-abc"},
+			DriftID:          "abc",
+			Decision:         decision,
+		},
+	}
+}
+
+// newRequestClient returns a client with objs, creating ApprovalRequests
+// with the webhook's field manager.
+func newRequestClient(t *testing.T, objs ...client.Object) client.Client {
+	t.Helper()
+	return newRequestClientWithInterceptor(t, interceptor.Funcs{}, objs...)
+}
+
+func newRequestClientWithInterceptor(t *testing.T, funcs interceptor.Funcs, objs ...client.Object) client.Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithReturnManagedFields().
+		WithStatusSubresource(&v1alpha1.ApprovalRequest{}).WithInterceptorFuncs(funcs).Build()
+	for _, obj := range objs {
+		obj = obj.DeepCopyObject().(client.Object)
+		if _, ok := obj.(*v1alpha1.ApprovalRequest); ok {
+			require.NoError(t, client.WithFieldOwner(c, controller.FieldManager).Create(context.Background(), obj))
+		} else {
+			require.NoError(t, c.Create(context.Background(), obj))
+		}
+	}
+	return c
+}
+
+func TestRequestWriter(t *testing.T) {
+	ctx := context.Background()
+	c := newRequestClient(t)
+	w := NewRequestWriter(c, logr.Discard())
+
+	request := testRequest("")
+	w.RequestApproval(ctx, request)
+	w.RequestApproval(ctx, request)
+	assert.Equal(t, 1, w.Len(), "requests of a drift are merged")
+	require.True(t, w.processNext(ctx))
+
+	got := &v1alpha1.ApprovalRequest{}
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(request), got))
+	assert.Equal(t, request.Spec, got.Spec)
+	assert.Equal(t, v1alpha1.ApprovalRequestManagedByValue, got.Labels[v1alpha1.ApprovalRequestManagedByLabel])
+
+	// Existing requests are left alone
+	got.Spec.Decision = v1alpha1.ApprovalRequestDecisionApprove
+	require.NoError(t, c.Update(ctx, got))
+	w.RequestApproval(ctx, request)
+	require.True(t, w.processNext(ctx))
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(request), got))
+	assert.Equal(t, v1alpha1.ApprovalRequestDecisionApprove, got.Spec.Decision)
+	assert.Equal(t, 0, w.Len())
+}
+
+func TestRequestReconciler(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	reconcile := func(t *testing.T, c client.Client, request *v1alpha1.ApprovalRequest) (ctrl.Result, *v1alpha1.ApprovalRequest) {
+		t.Helper()
+		r := NewRequestReconciler(c, "kausality-system", time.Hour, logr.Discard())
+		r.now = func() time.Time { return now }
+		key := client.ObjectKeyFromObject(request)
+		result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+		require.NoError(t, err)
+		got := &v1alpha1.ApprovalRequest{}
+		err = c.Get(context.Background(), key, got)
+		if apierrors.IsNotFound(err) {
+			return result, nil
+		}
+		require.NoError(t, err)
+		return result, got
+	}
+	parentAnnotations := func(t *testing.T, c client.Client) map[string]string {
+		t.Helper()
+		parent := requestParent(0)
+		require.NoError(t, c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "web"}, parent))
+		return parent.GetAnnotations()
+	}
+
+	t.Run("pending", func(t *testing.T) {
+		c := newRequestClient(t, requestParent(4), testRequest(""))
+		result, got := reconcile(t, c, testRequest(""))
+		assert.Equal(t, pendingRecheckInterval, result.RequeueAfter)
+		assert.Equal(t, v1alpha1.ApprovalRequestPhasePending, got.Status.Phase)
+		assert.Empty(t, parentAnnotations(t, c))
+	})
+
+	t.Run("approve", func(t *testing.T) {
+		request := testRequest(v1alpha1.ApprovalRequestDecisionApprove)
+		request.Spec.Mode = ModeGeneration
+		c := newRequestClient(t, requestParent(4), request)
+		result, got := reconcile(t, c, request)
+		assert.Equal(t, time.Hour, result.RequeueAfter)
+		assert.Equal(t, v1alpha1.ApprovalRequestPhaseApproved, got.Status.Phase)
+		require.NotNil(t, got.Status.CompletedAt)
+
+		approvals, err := ParseApprovals(parentAnnotations(t, c)[ApprovalsAnnotation])
+		require.NoError(t, err)
+		assert.Equal(t, []Approval{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-abc", Generation: 4, Mode: ModeGeneration}}, approvals)
+	})
+
+	t.Run("approve patches only the annotation", func(t *testing.T) {
+		request := testRequest(v1alpha1.ApprovalRequestDecisionApprove)
+		var patches []string
+		c := newRequestClientWithInterceptor(t, interceptor.Funcs{
+			Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+				data, err := patch.Data(obj)
+				require.NoError(t, err)
+				assert.Equal(t, types.MergePatchType, patch.Type())
+				patches = append(patches, string(data))
+				return c.Patch(ctx, obj, patch, opts...)
+			},
+		}, requestParent(4), request)
+		_, got := reconcile(t, c, request)
+		assert.Equal(t, v1alpha1.ApprovalRequestPhaseApproved, got.Status.Phase)
+		require.Len(t, patches, 1)
+		assert.Regexp(t, `^\{"metadata":\{"annotations":\{"kausality.io/approvals":"[^"]*(\\"[^"]*)*"\},"resourceVersion":"\d+"\}\}$`, patches[0])
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		request := testRequest(v1alpha1.ApprovalRequestDecisionReject)
+		c := newRequestClient(t, requestParent(4), request)
+		_, got := reconcile(t, c, request)
+		assert.Equal(t, v1alpha1.ApprovalRequestPhaseRejected, got.Status.Phase)
+
+		rejections, err := ParseRejections(parentAnnotations(t, c)[RejectionsAnnotation])
+		require.NoError(t, err)
+		require.Len(t, rejections, 1)
+		assert.Equal(t, "web-abc", rejections[0].Name)
+		assert.Equal(t, int64(4), rejections[0].Generation)
+		assert.Equal(t, "rejected by approval request "+request.Name, rejections[0].Reason)
+	})
+
+	t.Run("stale", func(t *testing.T) {
+		request := testRequest(v1alpha1.ApprovalRequestDecisionApprove)
+		c := newRequestClient(t, requestParent(5), request)
+		_, got := reconcile(t, c, request)
+		assert.Equal(t, v1alpha1.ApprovalRequestPhaseStale, got.Status.Phase)
+		assert.Contains(t, got.Status.Message, "moved on from generation 4 to 5")
+		assert.Empty(t, parentAnnotations(t, c))
+
+		// Deleted parents make undecided requests stale
+		c = newRequestClient(t, testRequest(""))
+		_, got = reconcile(t, c, testRequest(""))
+		assert.Equal(t, v1alpha1.ApprovalRequestPhaseStale, got.Status.Phase)
+		assert.Contains(t, got.Status.Message, "was deleted")
+	})
+
+	t.Run("not created by kausality", func(t *testing.T) {
+		// Requests without the label are ignored
+		request := testRequest(v1alpha1.ApprovalRequestDecisionApprove)
+		request.Labels = nil
+		c := newRequestClient(t, requestParent(4))
+		require.NoError(t, c.Create(context.Background(), request.DeepCopy()))
+		result, got := reconcile(t, c, request)
+		assert.Equal(t, ctrl.Result{}, result)
+		assert.Empty(t, got.Status.Phase)
+		assert.Empty(t, parentAnnotations(t, c))
+
+		// Labeled requests created by others are invalid
+		request = testRequest(v1alpha1.ApprovalRequestDecisionApprove)
+		c = newRequestClient(t, requestParent(4))
+		require.NoError(t, client.WithFieldOwner(c, "kubectl").Create(context.Background(), request.DeepCopy()))
+		_, got = reconcile(t, c, request)
+		assert.Equal(t, v1alpha1.ApprovalRequestPhaseInvalid, got.Status.Phase)
+		assert.Equal(t, "not created by kausality", got.Status.Message)
+		assert.Empty(t, parentAnnotations(t, c))
+	})
+
+	t.Run("parent outside of the namespace", func(t *testing.T) {
+		request := testRequest(v1alpha1.ApprovalRequestDecisionApprove)
+		request.Namespace = "team-a"
+		c := newRequestClient(t, requestParent(4), request)
+		_, got := reconcile(t, c, request)
+		assert.Equal(t, v1alpha1.ApprovalRequestPhaseInvalid, got.Status.Phase)
+		assert.Equal(t, `parent namespace "default" differs from the namespace of the request`, got.Status.Message)
+		assert.Empty(t, parentAnnotations(t, c))
+
+		// Cluster-scoped parents are only accepted in the configured namespace
+		request = testRequest(v1alpha1.ApprovalRequestDecisionApprove)
+		request.Spec.Parent.Namespace = ""
+		c = newRequestClient(t, request)
+		_, got = reconcile(t, c, request)
+		assert.Equal(t, v1alpha1.ApprovalRequestPhaseInvalid, got.Status.Phase)
+		assert.Equal(t, `requests of cluster-scoped parents must be in namespace "kausality-system"`, got.Status.Message)
+	})
+
+	t.Run("expire", func(t *testing.T) {
+		request := testRequest(v1alpha1.ApprovalRequestDecisionApprove)
+		request.Status = v1alpha1.ApprovalRequestStatus{
+			Phase:       v1alpha1.ApprovalRequestPhaseApproved,
+			CompletedAt: &metav1.Time{Time: now.Add(-30 * time.Minute)},
+		}
+		c := newRequestClient(t, request)
+		result, got := reconcile(t, c, request)
+		assert.Equal(t, 30*time.Minute, result.RequeueAfter)
+		require.NotNil(t, got)
+
+		request.Status.CompletedAt = &metav1.Time{Time: now.Add(-2 * time.Hour)}
+		c = newRequestClient(t, request)
+		_, got = reconcile(t, c, request)
+		assert.Nil(t, got, "expired requests are deleted")
+	})
+}
@@ -11,6 +11,8 @@ const (
 	RejectionsAnnotation = v1alpha1.RejectionsAnnotation
 	FreezeAnnotation     = v1alpha1.FreezeAnnotation
 	SnoozeAnnotation     = v1alpha1.SnoozeAnnotation
+	SuppressAnnotation   = v1alpha1.SuppressAnnotation
+	OverrideAnnotation   = v1alpha1.OverrideAnnotation
 )
 
 // Approval modes - re-exported from api/v1alpha1.
@@ -22,20 +24,29 @@ const (
 
 // Types - re-exported from api/v1alpha1.
 type (
-	Approval  = v1alpha1.Approval
-	Rejection = v1alpha1.Rejection
-	ChildRef  = v1alpha1.ChildRef
-	Freeze    = v1alpha1.Freeze
-	Snooze    = v1alpha1.Snooze
+	Approval            = v1alpha1.Approval
+	ApprovalConsumption = v1alpha1.ApprovalConsumption
+	Rejection           = v1alpha1.Rejection
+	ChildRef            = v1alpha1.ChildRef
+	Freeze              = v1alpha1.Freeze
+	Snooze              = v1alpha1.Snooze
+	Suppression         = v1alpha1.Suppression
+	Override            = v1alpha1.Override
 )
 
 // Functions - re-exported from api/v1alpha1.
 var (
-	ParseApprovals   = v1alpha1.ParseApprovals
-	ParseRejections  = v1alpha1.ParseRejections
-	MarshalApprovals = v1alpha1.MarshalApprovals
-	ParseFreeze      = v1alpha1.ParseFreeze
-	MarshalFreeze    = v1alpha1.MarshalFreeze
-	ParseSnooze      = v1alpha1.ParseSnooze
-	MarshalSnooze    = v1alpha1.MarshalSnooze
+	ParseApprovals     = v1alpha1.ParseApprovals
+	ParseRejections    = v1alpha1.ParseRejections
+	ValidateApprovals  = v1alpha1.ValidateApprovals
+	ValidateRejections = v1alpha1.ValidateRejections
+	RejectionWarnings  = v1alpha1.RejectionWarnings
+	MarshalApprovals   = v1alpha1.MarshalApprovals
+	ParseFreeze        = v1alpha1.ParseFreeze
+	MarshalFreeze      = v1alpha1.MarshalFreeze
+	ParseSnooze        = v1alpha1.ParseSnooze
+	MarshalSnooze      = v1alpha1.MarshalSnooze
+	ParseSuppression   = v1alpha1.ParseSuppression
+	MarshalSuppression = v1alpha1.MarshalSuppression
+	ParseOverride      = v1alpha1.ParseOverride
 )
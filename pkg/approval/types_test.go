@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -338,6 +339,78 @@ func TestParseRejections(t *testing.T) {
 	}
 }
 
+func TestValidateApprovals(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "empty string", input: ""},
+		{name: "always", input: `[{"apiVersion":"v1","kind":"ConfigMap","name":"a","mode":"always"}]`},
+		{name: "once with generation", input: `[{"apiVersion":"v1","kind":"ConfigMap","name":"a","generation":2}]`},
+		{name: "generation mode", input: `[{"apiVersion":"v1","kind":"ConfigMap","name":"a","mode":"generation","generation":3}]`},
+		{name: "invalid json", input: `[{`, wantErr: "invalid approvals annotation"},
+		{name: "trailing data", input: `[] []`, wantErr: "unexpected data"},
+		{name: "unknown field", input: `[{"apiVersion":"v1","kind":"ConfigMap","name":"a","mode":"always","generaton":2}]`, wantErr: "unknown field"},
+		{name: "missing kind", input: `[{"apiVersion":"v1","name":"a","mode":"always"}]`, wantErr: "[0]: kind is required"},
+		{name: "unknown mode", input: `[{"apiVersion":"v1","kind":"ConfigMap","name":"a","mode":"forever"}]`, wantErr: `unknown mode "forever"`},
+		{name: "once without generation", input: `[{"apiVersion":"v1","kind":"ConfigMap","name":"a","mode":"once"}]`, wantErr: `generation is required for mode "once"`},
+		{name: "default mode without generation", input: `[{"apiVersion":"v1","kind":"ConfigMap","name":"a","mode":"always"},{"apiVersion":"v1","kind":"Secret","name":"b"}]`, wantErr: `[1]: generation is required for mode "once"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateApprovals(tt.input)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestValidateRejections(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "empty string", input: ""},
+		{name: "valid", input: `[{"apiVersion":"v1","kind":"Pod","name":"a","generation":2,"reason":"dangerous"}]`},
+		{name: "any generation", input: `[{"apiVersion":"v1","kind":"Pod","name":"a","reason":"dangerous"}]`},
+		{name: "invalid json", input: `{broken`, wantErr: "invalid rejections annotation"},
+		{name: "unknown field", input: `[{"apiVersion":"v1","kind":"Pod","name":"a","reason":"x","mode":"always"}]`, wantErr: "unknown field"},
+		{name: "missing name", input: `[{"apiVersion":"v1","kind":"Pod","reason":"x"}]`, wantErr: "[0]: name is required"},
+		{name: "negative generation", input: `[{"apiVersion":"v1","kind":"Pod","name":"a","generation":-1,"reason":"x"}]`, wantErr: "generation must not be negative"},
+		{name: "missing reason", input: `[{"apiVersion":"v1","kind":"Pod","name":"a"}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRejections(tt.input)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestRejectionWarnings(t *testing.T) {
+	assert.Empty(t, RejectionWarnings(""))
+	assert.Empty(t, RejectionWarnings(`{broken`))
+	assert.Empty(t, RejectionWarnings(`[{"apiVersion":"v1","kind":"Pod","name":"a","reason":"dangerous"}]`))
+
+	warnings := RejectionWarnings(`[{"apiVersion":"v1","kind":"Pod","name":"a","reason":"dangerous"},{"apiVersion":"v1","kind":"Pod","name":"b"}]`)
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "[1]: reason is missing")
+	assert.Contains(t, warnings[0], "Pod b")
+}
+
 func TestParseFreeze(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -474,6 +547,55 @@ func TestSnooze_IsActive(t *testing.T) {
 	}
 }
 
+func TestParseSuppression(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantNil  bool
+		wantUser string
+		wantErr  bool
+	}{
+		{
+			name:    "empty string",
+			input:   "",
+			wantNil: true,
+		},
+		{
+			name:     "structured JSON",
+			input:    `{"until":"2026-01-25T12:00:00Z","user":"ops@example.com","message":"migrating storage class"}`,
+			wantUser: "ops@example.com",
+		},
+		{
+			name:    "missing until",
+			input:   `{"user":"ops@example.com"}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid JSON",
+			input:   `{broken`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSuppression(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			if tt.wantNil {
+				assert.Nil(t, got)
+				return
+			}
+			assert.NotNil(t, got)
+			assert.Equal(t, tt.wantUser, got.User)
+			assert.False(t, got.IsActive(), "expired")
+		})
+	}
+}
+
 func TestMarshalApprovals(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -512,3 +634,38 @@ func TestMarshalApprovals(t *testing.T) {
 		})
 	}
 }
+
+func TestParseOverride(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    *Override
+		wantErr string
+	}{
+		{name: "empty", input: ""},
+		{
+			name:  "valid",
+			input: `{"justification":"controller is right, parent fix takes a release","ticket":"OPS-42"}`,
+			want:  &Override{Justification: "controller is right, parent fix takes a release", Ticket: "OPS-42"},
+		},
+		{name: "missing justification", input: `{"ticket":"OPS-42"}`, wantErr: "justification is required"},
+		{name: "missing ticket", input: `{"justification":"urgent"}`, wantErr: "ticket is required"},
+		{name: "invalid JSON", input: `urgent`, wantErr: "invalid override annotation"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOverride(tt.input)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	override := &Override{Justification: "urgent", Ticket: "OPS-42"}
+	assert.Equal(t, "OPS-42: urgent", override.String())
+}
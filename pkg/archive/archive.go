@@ -0,0 +1,214 @@
+// Package archive writes the trace chains and drift evidence of terminal
+// events, denials, resolved drift and deletions, to long-term storage that
+// outlives the cluster, e.g. an S3 or GCS bucket.
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+)
+
+const (
+	// queueSize bounds the number of records waiting to be archived.
+	// Records are dropped when the archive cannot keep up, so admission
+	// never blocks on archiving.
+	queueSize = 1000
+
+	// defaultRetryCount is the default number of retries of failed writes.
+	defaultRetryCount = 3
+
+	// defaultRetryInterval is the default interval between retries.
+	defaultRetryInterval = time.Second
+)
+
+var archivedRecords = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "kausality_archive_records_total",
+	Help: "Records of terminal events written to the trace archive, by event and result (archived, failed, dropped).",
+}, []string{"event", "result"})
+
+func init() {
+	metrics.Registry.MustRegister(archivedRecords)
+}
+
+// TraceArchive stores archived records. Implementations write data under
+// key, a slash-separated path, replacing what is stored under it.
+type TraceArchive interface {
+	Put(ctx context.Context, key string, data []byte) error
+}
+
+// Archiver receives the records of terminal events to archive.
+type Archiver interface {
+	Archive(record *v1alpha1.ArchiveRecord)
+}
+
+// WriterOption configures a Writer.
+type WriterOption func(*Writer)
+
+// WithPrefix prepends prefix to the keys of archived records, e.g. the
+// name of the cluster followed by a slash.
+func WithPrefix(prefix string) WriterOption {
+	return func(w *Writer) {
+		w.prefix = prefix
+	}
+}
+
+// WithRetry sets the number of retries of failed writes and the interval
+// between them. Zero values keep the defaults of 3 retries, one second apart.
+func WithRetry(count int, interval time.Duration) WriterOption {
+	return func(w *Writer) {
+		if count > 0 {
+			w.retryCount = count
+		}
+		if interval > 0 {
+			w.retryInterval = interval
+		}
+	}
+}
+
+// Writer writes records to a TraceArchive. Records are queued and written
+// in order by a single worker, which runs until the context passed to Start
+// is canceled.
+type Writer struct {
+	archive       TraceArchive
+	prefix        string
+	retryCount    int
+	retryInterval time.Duration
+	queue         chan *v1alpha1.ArchiveRecord
+	log           logr.Logger
+}
+
+// NewWriter creates a Writer writing records to archive.
+func NewWriter(archive TraceArchive, log logr.Logger, opts ...WriterOption) *Writer {
+	if log.GetSink() == nil {
+		log = logr.Discard()
+	}
+	w := &Writer{
+		archive:       archive,
+		retryCount:    defaultRetryCount,
+		retryInterval: defaultRetryInterval,
+		queue:         make(chan *v1alpha1.ArchiveRecord, queueSize),
+		log:           log.WithName("trace-archive"),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Archive queues a record for writing. It never blocks; if the queue is
+// full the record is dropped and logged.
+func (w *Writer) Archive(record *v1alpha1.ArchiveRecord) {
+	select {
+	case w.queue <- record:
+	default:
+		archivedRecords.WithLabelValues(string(record.Spec.Event), "dropped").Inc()
+		w.log.Info("trace archive queue full, dropping record",
+			"event", record.Spec.Event,
+			"kind", record.Spec.Object.Kind,
+			"namespace", record.Spec.Object.Namespace,
+			"name", record.Spec.Object.Name,
+		)
+	}
+}
+
+// Start writes queued records until ctx is canceled.
+func (w *Writer) Start(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case record := <-w.queue:
+			if err := w.Write(ctx, record); err != nil && ctx.Err() == nil {
+				w.log.Error(err, "failed to archive record",
+					"event", record.Spec.Event,
+					"kind", record.Spec.Object.Kind,
+					"namespace", record.Spec.Object.Namespace,
+					"name", record.Spec.Object.Name,
+				)
+			}
+		}
+	}
+}
+
+// NeedLeaderElection returns false; every webhook replica archives the
+// requests it handles.
+func (w *Writer) NeedLeaderElection() bool {
+	return false
+}
+
+// Write writes a record to the archive, retrying on failure. This is a
+// blocking call; use Archive for non-blocking behavior.
+func (w *Writer) Write(ctx context.Context, record *v1alpha1.ArchiveRecord) error {
+	record.TypeMeta = metav1.TypeMeta{
+		APIVersion: v1alpha1.GroupName + "/" + v1alpha1.Version,
+		Kind:       "ArchiveRecord",
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal archive record: %w", err)
+	}
+	key := w.prefix + Key(record)
+
+	var lastErr error
+	for attempt := 0; attempt <= w.retryCount; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(w.retryInterval):
+			}
+		}
+
+		lastErr = w.archive.Put(ctx, key, data)
+		if lastErr == nil {
+			archivedRecords.WithLabelValues(string(record.Spec.Event), "archived").Inc()
+			return nil
+		}
+	}
+	archivedRecords.WithLabelValues(string(record.Spec.Event), "failed").Inc()
+	return fmt.Errorf("failed to write %s: %w", key, lastErr)
+}
+
+// Key returns the key of a record, ordered by the day and event archived:
+//
+//	<yyyy>/<mm>/<dd>/<event>/<namespace>/<kind>[.<group>]/<name>/<time>-<request uid>.json
+//
+// Cluster-scoped objects have the namespace "_cluster", and objects without
+// name, e.g. denied creations with generateName, the name "_unnamed".
+func Key(record *v1alpha1.ArchiveRecord) string {
+	spec := record.Spec
+	ts := spec.Timestamp.UTC()
+	namespace := spec.Object.Namespace
+	if namespace == "" {
+		namespace = "_cluster"
+	}
+	kind := spec.Object.Kind
+	if gv, err := schema.ParseGroupVersion(spec.Object.APIVersion); err == nil && gv.Group != "" {
+		kind += "." + gv.Group
+	}
+	name := spec.Object.Name
+	if name == "" {
+		name = "_unnamed"
+	}
+	return path.Join(
+		ts.Format("2006/01/02"),
+		strings.ToLower(string(spec.Event)),
+		namespace,
+		kind,
+		name,
+		ts.Format("20060102T150405.000000000Z")+"-"+spec.Request.UID+".json",
+	)
+}
@@ -0,0 +1,109 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+)
+
+// memoryArchive stores records in memory, failing the first failures puts.
+type memoryArchive struct {
+	mu       sync.Mutex
+	failures int
+	objects  map[string][]byte
+}
+
+func (a *memoryArchive) Put(_ context.Context, key string, data []byte) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.failures > 0 {
+		a.failures--
+		return errors.New("unavailable")
+	}
+	if a.objects == nil {
+		a.objects = map[string][]byte{}
+	}
+	a.objects[key] = data
+	return nil
+}
+
+func testRecord(event v1alpha1.ArchiveEvent) *v1alpha1.ArchiveRecord {
+	return &v1alpha1.ArchiveRecord{Spec: v1alpha1.ArchiveRecordSpec{
+		Event:     event,
+		Object:    v1alpha1.ObjectReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Namespace: "default", Name: "web-abc"},
+		Request:   v1alpha1.RequestContext{User: "alice", UID: "req-1", Operation: "DELETE"},
+		Timestamp: metav1.NewTime(time.Date(2026, 3, 4, 5, 6, 7, 8, time.UTC)),
+	}}
+}
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, "2026/03/04/deleted/default/ReplicaSet.apps/web-abc/20260304T050607.000000008Z-req-1.json",
+		Key(testRecord(v1alpha1.ArchiveEventDeleted)))
+
+	record := testRecord(v1alpha1.ArchiveEventDenied)
+	record.Spec.Object = v1alpha1.ObjectReference{APIVersion: "v1", Kind: "Namespace"}
+	assert.Equal(t, "2026/03/04/denied/_cluster/Namespace/_unnamed/20260304T050607.000000008Z-req-1.json", Key(record))
+}
+
+func TestWriter_Write(t *testing.T) {
+	ctx := context.Background()
+	archive := &memoryArchive{failures: 2}
+	w := NewWriter(archive, logr.Discard(), WithPrefix("prod/"), WithRetry(2, time.Millisecond))
+
+	require.NoError(t, w.Write(ctx, testRecord(v1alpha1.ArchiveEventDeleted)))
+	data := archive.objects["prod/2026/03/04/deleted/default/ReplicaSet.apps/web-abc/20260304T050607.000000008Z-req-1.json"]
+	require.NotNil(t, data, "written after retries")
+	var record v1alpha1.ArchiveRecord
+	require.NoError(t, json.Unmarshal(data, &record))
+	assert.Equal(t, "ArchiveRecord", record.Kind)
+	assert.Equal(t, v1alpha1.ArchiveEventDeleted, record.Spec.Event)
+
+	archive.failures = 3
+	assert.ErrorContains(t, w.Write(ctx, testRecord(v1alpha1.ArchiveEventDenied)), "unavailable")
+}
+
+func TestWriter_Archive(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	archive := &memoryArchive{}
+	w := NewWriter(archive, logr.Discard())
+	go func() { _ = w.Start(ctx) }()
+
+	w.Archive(testRecord(v1alpha1.ArchiveEventResolved))
+	assert.Eventually(t, func() bool {
+		archive.mu.Lock()
+		defer archive.mu.Unlock()
+		return len(archive.objects) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestFilesystemArchive_Put(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	a := NewFilesystemArchive(dir)
+
+	require.NoError(t, a.Put(ctx, "2026/03/04/denied/record.json", []byte("first")))
+	require.NoError(t, a.Put(ctx, "2026/03/04/denied/record.json", []byte("second")))
+	data, err := os.ReadFile(filepath.Join(dir, "2026", "03", "04", "denied", "record.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(data))
+	entries, err := os.ReadDir(filepath.Join(dir, "2026", "03", "04", "denied"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary files are left")
+
+	assert.Error(t, a.Put(ctx, "../escape.json", nil))
+	assert.Error(t, a.Put(ctx, "/absolute.json", nil))
+}
@@ -0,0 +1,49 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// FilesystemArchive stores records as files below a directory, e.g. a
+// persistent volume or a directory synced to object storage by a sidecar.
+type FilesystemArchive struct {
+	dir string
+}
+
+// NewFilesystemArchive creates a FilesystemArchive storing records below dir.
+func NewFilesystemArchive(dir string) *FilesystemArchive {
+	return &FilesystemArchive{dir: dir}
+}
+
+// Put writes data to the file of key below the directory. The file is
+// written to a temporary file first, so readers never see partial records.
+func (a *FilesystemArchive) Put(_ context.Context, key string, data []byte) error {
+	rel := filepath.FromSlash(key)
+	if !filepath.IsLocal(rel) {
+		return fmt.Errorf("invalid key %q: must be a relative path below the archive", key)
+	}
+	name := filepath.Join(a.dir, rel)
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmp.Name(), err)
+	}
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return fmt.Errorf("failed to rename %s: %w", tmp.Name(), err)
+	}
+	return nil
+}
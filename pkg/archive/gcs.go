@@ -0,0 +1,149 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultGCSEndpoint is the endpoint of the GCS JSON API.
+	defaultGCSEndpoint = "https://storage.googleapis.com"
+
+	// defaultGCSTokenURL is the metadata server endpoint returning access
+	// tokens of the default service account, e.g. the Google service
+	// account bound by GKE Workload Identity.
+	defaultGCSTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+	// tokenExpiryMargin is how long before their expiry tokens are refreshed.
+	tokenExpiryMargin = time.Minute
+)
+
+// GCSConfig configures a GCSArchive.
+type GCSConfig struct {
+	// Bucket is the name of the bucket.
+	Bucket string
+	// Endpoint is the URL of the GCS JSON API. Default is
+	// https://storage.googleapis.com.
+	Endpoint string
+	// TokenFile is the path to a file containing an OAuth2 access token,
+	// read on every upload so that it can be rotated. If empty, tokens are
+	// requested from the metadata server.
+	TokenFile string
+	// TokenURL is the metadata server endpoint returning access tokens.
+	// Default is the endpoint of the default service account.
+	TokenURL string
+	// Client sends the requests. Default is a client with a 30 second timeout.
+	Client *http.Client
+}
+
+// GCSArchive stores records as objects in a GCS bucket, uploaded with the
+// JSON API.
+type GCSArchive struct {
+	config GCSConfig
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// NewGCSArchive creates a GCSArchive with the given configuration.
+func NewGCSArchive(cfg GCSConfig) (*GCSArchive, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("gcs bucket is required")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = defaultGCSEndpoint
+	}
+	if cfg.TokenURL == "" {
+		cfg.TokenURL = defaultGCSTokenURL
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &GCSArchive{config: cfg}, nil
+}
+
+// Put uploads data as the object of key.
+func (a *GCSArchive) Put(ctx context.Context, key string, data []byte) error {
+	token, err := a.accessToken(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get gcs access token: %w", err)
+	}
+	u := fmt.Sprintf("%s/upload/storage/v1/b/%s/o?uploadType=media&name=%s",
+		strings.TrimSuffix(a.config.Endpoint, "/"), url.PathEscape(a.config.Bucket), url.QueryEscape(key))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := a.config.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("gcs returned status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// accessToken returns the token of the token file, or else a token of the
+// metadata server, cached until shortly before it expires.
+func (a *GCSArchive) accessToken(ctx context.Context) (string, error) {
+	if a.config.TokenFile != "" {
+		token, err := os.ReadFile(a.config.TokenFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read token file: %w", err)
+		}
+		if len(strings.TrimSpace(string(token))) == 0 {
+			return "", fmt.Errorf("token file %s is empty", a.config.TokenFile)
+		}
+		return strings.TrimSpace(string(token)), nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token != "" && time.Now().Before(a.expiry) {
+		return a.token, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.config.TokenURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := a.config.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("token request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return "", fmt.Errorf("metadata server returned status %d: %s", resp.StatusCode, string(body))
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode token: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("metadata server returned no access token")
+	}
+	a.token = token.AccessToken
+	a.expiry = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - tokenExpiryMargin)
+	return a.token, nil
+}
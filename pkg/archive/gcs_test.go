@@ -0,0 +1,55 @@
+package archive
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGCSArchive_Put(t *testing.T) {
+	tokenRequests := 0
+	var uploads []*http.Request
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			tokenRequests++
+			assert.Equal(t, "Google", r.Header.Get("Metadata-Flavor"))
+			_, _ = w.Write([]byte(`{"access_token":"metadata-token","expires_in":3600,"token_type":"Bearer"}`))
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		uploads, body = append(uploads, r), string(data)
+	}))
+	defer server.Close()
+
+	// Tokens of the metadata server are cached
+	a, err := NewGCSArchive(GCSConfig{Bucket: "archive", Endpoint: server.URL, TokenURL: server.URL + "/token"})
+	require.NoError(t, err)
+	require.NoError(t, a.Put(context.Background(), "prod/2026/record.json", []byte(`{"kind":"ArchiveRecord"}`)))
+	require.NoError(t, a.Put(context.Background(), "prod/2026/other.json", nil))
+	assert.Equal(t, 1, tokenRequests)
+	require.Len(t, uploads, 2)
+	upload := uploads[0]
+	assert.Equal(t, http.MethodPost, upload.Method)
+	assert.Equal(t, "/upload/storage/v1/b/archive/o", upload.URL.Path)
+	assert.Equal(t, "media", upload.URL.Query().Get("uploadType"))
+	assert.Equal(t, "prod/2026/record.json", upload.URL.Query().Get("name"))
+	assert.Equal(t, "Bearer metadata-token", upload.Header.Get("Authorization"))
+	assert.Empty(t, body)
+
+	// Token files are read on every upload
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("file-token\n"), 0o600))
+	a, err = NewGCSArchive(GCSConfig{Bucket: "archive", Endpoint: server.URL, TokenFile: tokenFile})
+	require.NoError(t, err)
+	require.NoError(t, a.Put(context.Background(), "record.json", nil))
+	assert.Equal(t, "Bearer file-token", uploads[2].Header.Get("Authorization"))
+	assert.Equal(t, 1, tokenRequests)
+}
@@ -0,0 +1,228 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Credentials are the credentials S3 requests are signed with.
+type S3Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is the token of temporary credentials, if any.
+	SessionToken string
+}
+
+// S3Config configures an S3Archive.
+type S3Config struct {
+	// Bucket is the name of the bucket.
+	Bucket string
+	// Region is the region of the bucket, e.g. "eu-west-1". Default is
+	// "us-east-1".
+	Region string
+	// Endpoint is the URL of an S3-compatible service, e.g. MinIO or the
+	// XML API of GCS with HMAC keys. Buckets are then addressed by path.
+	// Default is AWS S3 in Region, with buckets addressed by host.
+	Endpoint string
+	// Credentials returns the credentials of each request. Default reads
+	// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN from
+	// the environment, or else assumes AWS_ROLE_ARN with the web identity
+	// token of AWS_WEB_IDENTITY_TOKEN_FILE, e.g. of EKS IAM roles for
+	// service accounts, refreshing the credentials before they expire.
+	// Other sources of the AWS SDKs, like shared config files and instance
+	// profiles, are not supported.
+	Credentials func() (S3Credentials, error)
+	// STSEndpoint is the URL of the AWS STS API web identities are
+	// exchanged at. Default is the regional endpoint in Region.
+	STSEndpoint string
+	// Client sends the requests. Default is a client with a 30 second timeout.
+	Client *http.Client
+}
+
+// S3Archive stores records as objects in an S3 bucket. Requests are signed
+// with AWS Signature Version 4.
+type S3Archive struct {
+	config S3Config
+	now    func() time.Time
+	// env provides the credentials if Credentials is not set
+	env *envCredentials
+}
+
+// NewS3Archive creates an S3Archive with the given configuration.
+func NewS3Archive(cfg S3Config) (*S3Archive, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("s3 bucket is required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.Endpoint != "" {
+		if _, err := url.Parse(cfg.Endpoint); err != nil {
+			return nil, fmt.Errorf("invalid s3 endpoint %q: %w", cfg.Endpoint, err)
+		}
+	}
+	if cfg.STSEndpoint == "" {
+		cfg.STSEndpoint = fmt.Sprintf("https://sts.%s.amazonaws.com", cfg.Region)
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 30 * time.Second}
+	}
+	a := &S3Archive{config: cfg, now: time.Now}
+	if cfg.Credentials == nil {
+		env, err := newEnvCredentials(cfg.STSEndpoint, cfg.Client)
+		if err != nil {
+			return nil, err
+		}
+		a.env = env
+	}
+	return a, nil
+}
+
+// credentials returns the credentials of a request.
+func (a *S3Archive) credentials(ctx context.Context) (S3Credentials, error) {
+	if a.config.Credentials != nil {
+		return a.config.Credentials()
+	}
+	return a.env.get(ctx, a.now())
+}
+
+// objectURL returns the URL of the object of key.
+func (a *S3Archive) objectURL(key string) string {
+	if a.config.Endpoint == "" {
+		return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", a.config.Bucket, a.config.Region, uriEncode(key, false))
+	}
+	return strings.TrimSuffix(a.config.Endpoint, "/") + "/" + uriEncode(a.config.Bucket, true) + "/" + uriEncode(key, false)
+}
+
+// Put uploads data as the object of key.
+func (a *S3Archive) Put(ctx context.Context, key string, data []byte) error {
+	creds, err := a.credentials(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get s3 credentials: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, a.objectURL(key), bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	payloadHash := hex.EncodeToString(sum[:])
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	signV4(req, payloadHash, creds, a.config.Region, "s3", a.now())
+
+	resp, err := a.config.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("s3 returned status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// signV4 signs req with AWS Signature Version 4, signing the host and all
+// X-Amz-* headers. payloadHash is the hex SHA-256 of the request body.
+func signV4(req *http.Request, payloadHash string, creds S3Credentials, region, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		if name = strings.ToLower(name); strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalURI := req.URL.EscapedPath()
+	if canonicalURI == "" {
+		canonicalURI = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI,
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// canonicalQuery returns the query of a request in canonical form: sorted
+// by key and value, URI-encoded.
+func canonicalQuery(query url.Values) string {
+	var params []string
+	for key, values := range query {
+		for _, value := range values {
+			params = append(params, uriEncode(key, true)+"="+uriEncode(value, true))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// uriEncode percent-encodes all but the unreserved characters of s, and
+// slashes unless encodeSlash is set.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '.', c == '_', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
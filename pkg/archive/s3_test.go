@@ -0,0 +1,72 @@
+package archive
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var exampleCredentials = S3Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+
+func TestSignV4(t *testing.T) {
+	// The get-vanilla case of the AWS Signature Version 4 test suite
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	require.NoError(t, err)
+	emptyHash := "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	signV4(req, emptyHash, exampleCredentials, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	assert.Equal(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+	assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, "+
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		req.Header.Get("Authorization"))
+}
+
+func TestS3Archive_Put(t *testing.T) {
+	var got *http.Request
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		got, body = r, string(data)
+		if strings.Contains(r.URL.Path, "fail") {
+			http.Error(w, "AccessDenied", http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	a, err := NewS3Archive(S3Config{
+		Bucket:      "archive",
+		Region:      "eu-west-1",
+		Endpoint:    server.URL,
+		Credentials: func() (S3Credentials, error) { return exampleCredentials, nil },
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, a.Put(context.Background(), "prod/2026/record.json", []byte(`{"kind":"ArchiveRecord"}`)))
+	assert.Equal(t, http.MethodPut, got.Method)
+	assert.Equal(t, "/archive/prod/2026/record.json", got.URL.Path)
+	assert.Equal(t, `{"kind":"ArchiveRecord"}`, body)
+	assert.NotEmpty(t, got.Header.Get("X-Amz-Content-Sha256"))
+	assert.True(t, strings.HasPrefix(got.Header.Get("Authorization"),
+		"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/"), got.Header.Get("Authorization"))
+	assert.Contains(t, got.Header.Get("Authorization"), "/eu-west-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, ")
+
+	assert.ErrorContains(t, a.Put(context.Background(), "fail.json", nil), "status 403")
+}
+
+func TestS3Archive_ObjectURL(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", exampleCredentials.AccessKeyID)
+	t.Setenv("AWS_SECRET_ACCESS_KEY", exampleCredentials.SecretAccessKey)
+	a, err := NewS3Archive(S3Config{Bucket: "archive", Region: "eu-west-1"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://archive.s3.eu-west-1.amazonaws.com/prod/a%20b.json", a.objectURL("prod/a b.json"))
+
+	_, err = NewS3Archive(S3Config{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,133 @@
+package archive
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// envCredentials provides S3 credentials from the standard AWS environment
+// variables: static credentials, or else those of a role assumed with a web
+// identity token, cached until shortly before they expire.
+type envCredentials struct {
+	roleARN     string
+	tokenFile   string
+	sessionName string
+	stsEndpoint string
+	client      *http.Client
+
+	mu     sync.Mutex
+	creds  S3Credentials
+	expiry time.Time
+}
+
+// newEnvCredentials returns the credentials of the environment, or an error
+// if it has neither static credentials nor a web identity.
+func newEnvCredentials(stsEndpoint string, client *http.Client) (*envCredentials, error) {
+	e := &envCredentials{
+		roleARN:     os.Getenv("AWS_ROLE_ARN"),
+		tokenFile:   os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE"),
+		sessionName: os.Getenv("AWS_ROLE_SESSION_NAME"),
+		stsEndpoint: stsEndpoint,
+		client:      client,
+	}
+	if e.sessionName == "" {
+		e.sessionName = "kausality-archive"
+	}
+	if _, ok := e.static(); ok {
+		return e, nil
+	}
+	if e.roleARN == "" || e.tokenFile == "" {
+		return nil, fmt.Errorf("no s3 credentials: set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY, or AWS_ROLE_ARN and AWS_WEB_IDENTITY_TOKEN_FILE")
+	}
+	return e, nil
+}
+
+// static returns the static credentials of the environment, if any.
+func (e *envCredentials) static() (S3Credentials, bool) {
+	creds := S3Credentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	return creds, creds.AccessKeyID != "" && creds.SecretAccessKey != ""
+}
+
+// get returns the static credentials, or else the cached credentials of the
+// web identity, assuming the role again if they expire soon.
+func (e *envCredentials) get(ctx context.Context, now time.Time) (S3Credentials, error) {
+	if creds, ok := e.static(); ok {
+		return creds, nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.creds.AccessKeyID != "" && now.Before(e.expiry) {
+		return e.creds, nil
+	}
+	creds, expiration, err := e.assumeRole(ctx)
+	if err != nil {
+		return S3Credentials{}, err
+	}
+	e.creds = creds
+	e.expiry = expiration.Add(-tokenExpiryMargin)
+	return creds, nil
+}
+
+// assumeRoleResponse is the response of AssumeRoleWithWebIdentity.
+type assumeRoleResponse struct {
+	Credentials struct {
+		AccessKeyID     string    `xml:"AccessKeyId"`
+		SecretAccessKey string    `xml:"SecretAccessKey"`
+		SessionToken    string    `xml:"SessionToken"`
+		Expiration      time.Time `xml:"Expiration"`
+	} `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+}
+
+// assumeRole exchanges the web identity token for temporary credentials of
+// the role with AWS STS. The token file is read on every exchange, as it is
+// rotated, e.g. by the kubelet.
+func (e *envCredentials) assumeRole(ctx context.Context) (S3Credentials, time.Time, error) {
+	token, err := os.ReadFile(e.tokenFile)
+	if err != nil {
+		return S3Credentials{}, time.Time{}, fmt.Errorf("failed to read web identity token: %w", err)
+	}
+	form := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {"2011-06-15"},
+		"RoleArn":          {e.roleARN},
+		"RoleSessionName":  {e.sessionName},
+		"WebIdentityToken": {strings.TrimSpace(string(token))},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(e.stsEndpoint, "/")+"/", strings.NewReader(form.Encode()))
+	if err != nil {
+		return S3Credentials{}, time.Time{}, fmt.Errorf("failed to create sts request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return S3Credentials{}, time.Time{}, fmt.Errorf("sts request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return S3Credentials{}, time.Time{}, fmt.Errorf("sts returned status %d: %s", resp.StatusCode, string(body))
+	}
+	var result assumeRoleResponse
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return S3Credentials{}, time.Time{}, fmt.Errorf("failed to decode sts response: %w", err)
+	}
+	c := result.Credentials
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return S3Credentials{}, time.Time{}, fmt.Errorf("sts returned no credentials")
+	}
+	return S3Credentials{AccessKeyID: c.AccessKeyID, SecretAccessKey: c.SecretAccessKey, SessionToken: c.SessionToken}, c.Expiration, nil
+}
@@ -0,0 +1,99 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setWebIdentityEnv configures a web identity with the token in a file and
+// no static credentials. It returns the token file.
+func setWebIdentityEnv(t *testing.T, token string) string {
+	t.Helper()
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte(token+"\n"), 0o600))
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	t.Setenv("AWS_ROLE_ARN", "arn:aws:iam::123456789012:role/kausality")
+	t.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", tokenFile)
+	t.Setenv("AWS_ROLE_SESSION_NAME", "")
+	return tokenFile
+}
+
+func TestS3Archive_WebIdentity(t *testing.T) {
+	tokenFile := setWebIdentityEnv(t, "token-1")
+	expiration := time.Now().Add(time.Hour).UTC()
+
+	var tokens []string
+	sts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "AssumeRoleWithWebIdentity", r.PostForm.Get("Action"))
+		assert.Equal(t, "arn:aws:iam::123456789012:role/kausality", r.PostForm.Get("RoleArn"))
+		assert.Equal(t, "kausality-archive", r.PostForm.Get("RoleSessionName"))
+		tokens = append(tokens, r.PostForm.Get("WebIdentityToken"))
+		_, _ = fmt.Fprintf(w, `<AssumeRoleWithWebIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleWithWebIdentityResult>
+    <Credentials>
+      <AccessKeyId>ASIA%d</AccessKeyId>
+      <SecretAccessKey>secret</SecretAccessKey>
+      <SessionToken>session</SessionToken>
+      <Expiration>%s</Expiration>
+    </Credentials>
+  </AssumeRoleWithWebIdentityResult>
+</AssumeRoleWithWebIdentityResponse>`, len(tokens), expiration.Format(time.RFC3339))
+	}))
+	defer sts.Close()
+
+	var got *http.Request
+	s3 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+	}))
+	defer s3.Close()
+
+	a, err := NewS3Archive(S3Config{Bucket: "archive", Endpoint: s3.URL, STSEndpoint: sts.URL})
+	require.NoError(t, err)
+	now := time.Now()
+	a.now = func() time.Time { return now }
+
+	require.NoError(t, a.Put(context.Background(), "record.json", nil))
+	assert.True(t, strings.HasPrefix(got.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=ASIA1/"), got.Header.Get("Authorization"))
+	assert.Equal(t, "session", got.Header.Get("X-Amz-Security-Token"))
+
+	// The credentials are cached
+	require.NoError(t, a.Put(context.Background(), "record.json", nil))
+	assert.Equal(t, []string{"token-1"}, tokens)
+
+	// and refreshed with the rotated token before they expire
+	require.NoError(t, os.WriteFile(tokenFile, []byte("token-2"), 0o600))
+	now = expiration.Add(-30 * time.Second)
+	require.NoError(t, a.Put(context.Background(), "record.json", nil))
+	assert.Equal(t, []string{"token-1", "token-2"}, tokens)
+	assert.True(t, strings.HasPrefix(got.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=ASIA2/"), got.Header.Get("Authorization"))
+}
+
+func TestS3Archive_WebIdentityErrors(t *testing.T) {
+	setWebIdentityEnv(t, "token")
+	sts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "<ErrorResponse><Error><Code>InvalidIdentityToken</Code></Error></ErrorResponse>", http.StatusBadRequest)
+	}))
+	defer sts.Close()
+
+	a, err := NewS3Archive(S3Config{Bucket: "archive", Endpoint: "http://127.0.0.1:0", STSEndpoint: sts.URL})
+	require.NoError(t, err)
+	assert.ErrorContains(t, a.Put(context.Background(), "record.json", nil), "sts returned status 400")
+
+	// Without credentials, the archive is not created
+	t.Setenv("AWS_ROLE_ARN", "")
+	_, err = NewS3Archive(S3Config{Bucket: "archive"})
+	assert.ErrorContains(t, err, "no s3 credentials")
+}
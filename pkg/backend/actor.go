@@ -0,0 +1,185 @@
+package backend
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/controller"
+)
+
+// DefaultActorWindow is the window of an actor query without from.
+const DefaultActorWindow = 24 * time.Hour
+
+// Sources of changed objects.
+const (
+	// SourceTrace is a mirrored trace record of an admitted mutation.
+	SourceTrace = "trace"
+	// SourceDrift is a drift report.
+	SourceDrift = "drift"
+)
+
+// ActorQuery selects the changes of an actor: by username, or by the hash
+// of kausality.io/updaters annotations.
+type ActorQuery struct {
+	User string
+	// Hash is the updater hash of the actor, used if User is empty. Other
+	// users with the same hash match as well.
+	Hash string
+	From time.Time
+	To   time.Time
+}
+
+// matches returns whether user is the queried actor.
+func (q ActorQuery) matches(user string) bool {
+	if q.User != "" {
+		return user == q.User
+	}
+	return controller.HashUsername(user) == q.Hash
+}
+
+// ActorChanges are the objects an actor changed in a time window, grouped by
+// namespace and kind.
+type ActorChanges struct {
+	User string    `json:"user,omitempty"`
+	Hash string    `json:"hash"`
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// Users are the usernames of the changes, more than one if a hash
+	// matches several users.
+	Users      []string           `json:"users"`
+	Namespaces []NamespaceChanges `json:"namespaces"`
+}
+
+// NamespaceChanges are the objects changed in a namespace, "" for
+// cluster-scoped objects.
+type NamespaceChanges struct {
+	Namespace string        `json:"namespace"`
+	Kinds     []KindChanges `json:"kinds"`
+}
+
+// KindChanges are the objects of a kind changed in a namespace.
+type KindChanges struct {
+	APIVersion string          `json:"apiVersion"`
+	Kind       string          `json:"kind"`
+	Objects    []ChangedObject `json:"objects"`
+}
+
+// ChangedObject is an object the actor changed.
+type ChangedObject struct {
+	Name string    `json:"name"`
+	UID  types.UID `json:"uid,omitempty"`
+	// Operations are the admission operations, e.g. CREATE and UPDATE.
+	Operations []string `json:"operations"`
+	// Mutations is the number of admitted mutations with a mirrored trace.
+	Mutations int `json:"mutations"`
+	// DriftIDs are the drift reports of the changes.
+	DriftIDs  []string  `json:"driftIDs,omitempty"`
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+	// Sources are where the changes are known from: trace or drift.
+	Sources []string `json:"sources"`
+}
+
+// QueryActor returns the objects changed by the queried actor, from the
+// drift detected and the trace records admitted in the query window.
+func QueryActor(q ActorQuery, drifts []*StoredReport, records []*v1alpha1.TraceRecord) *ActorChanges {
+	result := &ActorChanges{User: q.User, Hash: q.Hash, From: q.From, To: q.To, Users: []string{}, Namespaces: []NamespaceChanges{}}
+	if q.User != "" {
+		result.Hash = controller.HashUsername(q.User)
+	}
+
+	objects := make(map[string]*changedObject)
+	change := func(ref v1alpha1.ObjectReference, request v1alpha1.RequestContext, at time.Time, source string) *ChangedObject {
+		if !slices.Contains(result.Users, request.User) {
+			result.Users = append(result.Users, request.User)
+		}
+		key := objectKey(ref)
+		o, ok := objects[key]
+		if !ok {
+			o = &changedObject{namespace: ref.Namespace, apiVersion: ref.APIVersion, kind: ref.Kind,
+				ChangedObject: ChangedObject{Name: ref.Name, Operations: []string{}, FirstSeen: at, LastSeen: at}}
+			objects[key] = o
+		}
+		if o.UID == "" {
+			o.UID = ref.UID
+		}
+		if request.Operation != "" && !slices.Contains(o.Operations, request.Operation) {
+			o.Operations = append(o.Operations, request.Operation)
+		}
+		if !slices.Contains(o.Sources, source) {
+			o.Sources = append(o.Sources, source)
+		}
+		if at.Before(o.FirstSeen) {
+			o.FirstSeen = at
+		}
+		if at.After(o.LastSeen) {
+			o.LastSeen = at
+		}
+		return &o.ChangedObject
+	}
+
+	for _, stored := range drifts {
+		spec := stored.Report.Spec
+		if stored.ReceivedAt.Before(q.From) || !stored.ReceivedAt.Before(q.To) || !q.matches(spec.Request.User) {
+			continue
+		}
+		o := change(spec.Child, spec.Request, stored.ReceivedAt, SourceDrift)
+		o.DriftIDs = append(o.DriftIDs, spec.ID)
+	}
+	for _, record := range records {
+		spec := record.Spec
+		if spec.Timestamp.Time.Before(q.From) || !spec.Timestamp.Time.Before(q.To) || !q.matches(spec.Request.User) {
+			continue
+		}
+		o := change(spec.Object, spec.Request, spec.Timestamp.Time, SourceTrace)
+		o.Mutations++
+	}
+
+	sort.Strings(result.Users)
+	result.Namespaces = groupChanges(objects)
+	return result
+}
+
+// changedObject is a ChangedObject with its namespace and kind for grouping.
+type changedObject struct {
+	namespace  string
+	apiVersion string
+	kind       string
+	ChangedObject
+}
+
+// groupChanges groups objects by namespace and kind, sorted by name.
+func groupChanges(objects map[string]*changedObject) []NamespaceChanges {
+	sorted := make([]*changedObject, 0, len(objects))
+	for _, o := range objects {
+		sorted = append(sorted, o)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.namespace != b.namespace {
+			return a.namespace < b.namespace
+		}
+		if a.kind != b.kind {
+			return a.kind < b.kind
+		}
+		return a.Name < b.Name
+	})
+
+	namespaces := []NamespaceChanges{}
+	for _, o := range sorted {
+		if n := len(namespaces); n == 0 || namespaces[n-1].Namespace != o.namespace {
+			namespaces = append(namespaces, NamespaceChanges{Namespace: o.namespace})
+		}
+		ns := &namespaces[len(namespaces)-1]
+		if n := len(ns.Kinds); n == 0 || ns.Kinds[n-1].Kind != o.kind {
+			ns.Kinds = append(ns.Kinds, KindChanges{APIVersion: o.apiVersion, Kind: o.kind})
+		}
+		kind := &ns.Kinds[len(ns.Kinds)-1]
+		kind.Objects = append(kind.Objects, o.ChangedObject)
+	}
+	return namespaces
+}
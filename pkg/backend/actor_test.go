@@ -0,0 +1,123 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/controller"
+)
+
+func actorRecord(namespace, kind, name, user, operation string, at time.Time) *v1alpha1.TraceRecord {
+	return &v1alpha1.TraceRecord{Spec: v1alpha1.TraceRecordSpec{
+		Object:    v1alpha1.ObjectReference{APIVersion: "v1", Kind: kind, Namespace: namespace, Name: name, UID: types.UID("uid-" + name)},
+		Request:   v1alpha1.RequestContext{User: user, Operation: operation},
+		Timestamp: metav1.NewTime(at),
+	}}
+}
+
+func actorData(now time.Time) (*Store, *MemoryTraceStore) {
+	store := NewStore()
+	addDetection(store, "old", "prod", "Deployment", "alice", now.Add(-2*DefaultActorWindow))
+	addDetection(store, "web", "prod", "Deployment", "alice", now.Add(-time.Hour))
+	addDetection(store, "db", "prod", "StatefulSet", "bob", now.Add(-time.Hour))
+
+	traces := NewMemoryTraceStore()
+	for _, r := range []*v1alpha1.TraceRecord{
+		actorRecord("prod", "Deployment", "web", "alice", "UPDATE", now.Add(-time.Hour)),
+		actorRecord("prod", "Deployment", "web", "alice", "UPDATE", now.Add(-30*time.Minute)),
+		actorRecord("prod", "ConfigMap", "settings", "alice", "CREATE", now.Add(-2*time.Hour)),
+		actorRecord("", "Namespace", "sandbox", "alice", "CREATE", now.Add(-3*time.Hour)),
+		actorRecord("dev", "ConfigMap", "settings", "bob", "UPDATE", now.Add(-time.Hour)),
+		actorRecord("dev", "ConfigMap", "stale", "alice", "UPDATE", now.Add(-2*DefaultActorWindow)),
+	} {
+		_ = traces.AddTrace(r)
+	}
+	return store, traces
+}
+
+func TestQueryActor(t *testing.T) {
+	now := time.Now()
+	store, traces := actorData(now)
+	q := ActorQuery{User: "alice", From: now.Add(-DefaultActorWindow), To: now}
+	records, err := traces.Records(q.From, q.To)
+	require.NoError(t, err)
+	require.Len(t, records, 5)
+
+	changes := QueryActor(q, store.Detections(q.From), records)
+	assert.Equal(t, controller.HashUsername("alice"), changes.Hash)
+	assert.Equal(t, []string{"alice"}, changes.Users)
+	require.Len(t, changes.Namespaces, 2)
+
+	cluster := changes.Namespaces[0]
+	assert.Equal(t, "", cluster.Namespace)
+	require.Len(t, cluster.Kinds, 1)
+	assert.Equal(t, "Namespace", cluster.Kinds[0].Kind)
+
+	prod := changes.Namespaces[1]
+	assert.Equal(t, "prod", prod.Namespace)
+	require.Len(t, prod.Kinds, 2)
+	assert.Equal(t, "ConfigMap", prod.Kinds[0].Kind)
+	require.Len(t, prod.Kinds[1].Objects, 1)
+	web := prod.Kinds[1].Objects[0]
+	assert.Equal(t, "web", web.Name)
+	assert.Equal(t, 2, web.Mutations)
+	assert.Equal(t, []string{"web"}, web.DriftIDs)
+	assert.Equal(t, []string{"UPDATE"}, web.Operations)
+	assert.ElementsMatch(t, []string{SourceDrift, SourceTrace}, web.Sources)
+	assert.Equal(t, now.Add(-time.Hour).Unix(), web.FirstSeen.Unix())
+	assert.Equal(t, now.Add(-30*time.Minute).Unix(), web.LastSeen.Unix())
+
+	// By updater hash
+	byHash := QueryActor(ActorQuery{Hash: controller.HashUsername("bob"), From: q.From, To: q.To}, store.Detections(q.From), records)
+	assert.Equal(t, []string{"bob"}, byHash.Users)
+	require.Len(t, byHash.Namespaces, 2)
+	assert.Equal(t, "dev", byHash.Namespaces[0].Namespace)
+	assert.Equal(t, "StatefulSet", byHash.Namespaces[1].Kinds[0].Kind)
+}
+
+func TestServer_ActorChanges(t *testing.T) {
+	now := time.Now()
+	s := NewServer()
+	s.store, s.traces = actorData(now)
+	handler := s.Handler()
+
+	get := func(query url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/actors/changes?"+query.Encode(), nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get(url.Values{"user": {"alice"}})
+	require.Equal(t, http.StatusOK, rec.Code)
+	var changes ActorChanges
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &changes))
+	assert.Len(t, changes.Namespaces, 2)
+
+	// The window excludes older changes
+	rec = get(url.Values{"user": {"alice"}, "from": {now.Add(-90 * time.Minute).UTC().Format(time.RFC3339)}})
+	require.Equal(t, http.StatusOK, rec.Code)
+	changes = ActorChanges{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &changes))
+	require.Len(t, changes.Namespaces, 1)
+	assert.Len(t, changes.Namespaces[0].Kinds, 1)
+
+	for _, query := range []url.Values{
+		{},
+		{"user": {"alice"}, "hash": {"abcde"}},
+		{"user": {"alice"}, "from": {"yesterday"}},
+	} {
+		assert.Equal(t, http.StatusBadRequest, get(query).Code, query.Encode())
+	}
+}
@@ -0,0 +1,190 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// defaultTimeout bounds requests to providers.
+const defaultTimeout = 10 * time.Second
+
+const (
+	// pagerDutyURL is the PagerDuty Events API v2 endpoint.
+	pagerDutyURL = "https://events.pagerduty.com"
+	// opsgenieURL is the Opsgenie Alert API endpoint.
+	opsgenieURL = "https://api.opsgenie.com"
+
+	// pagerDutyMaxSummary and opsgenieMaxMessage are the limits of the
+	// one-line descriptions of the providers.
+	pagerDutyMaxSummary = 1024
+	opsgenieMaxMessage  = 130
+)
+
+// pagerDuty sends trigger and resolve events to the PagerDuty Events API v2.
+// Events with the same dedup key belong to the same incident.
+type pagerDuty struct {
+	client *http.Client
+	url    string
+	key    string
+}
+
+func newPagerDuty(cfg Config) *pagerDuty {
+	endpoint := cfg.URL
+	if endpoint == "" {
+		endpoint = pagerDutyURL
+	}
+	return &pagerDuty{client: cfg.Client, url: strings.TrimSuffix(endpoint, "/"), key: cfg.Key}
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	Component     string            `json:"component,omitempty"`
+	Class         string            `json:"class,omitempty"`
+	CustomDetails map[string]string `json:"custom_details,omitempty"`
+}
+
+func (p *pagerDuty) Trigger(ctx context.Context, alert *Alert) error {
+	return p.send(ctx, &pagerDutyEvent{
+		RoutingKey:  p.key,
+		EventAction: "trigger",
+		DedupKey:    alert.DedupKey,
+		Payload: &pagerDutyPayload{
+			Summary:       truncate(alert.Summary, pagerDutyMaxSummary),
+			Source:        alert.Source,
+			Severity:      string(SeverityCritical),
+			Component:     "kausality",
+			Class:         "drift",
+			CustomDetails: alert.Details,
+		},
+	})
+}
+
+func (p *pagerDuty) Resolve(ctx context.Context, dedupKey string) error {
+	return p.send(ctx, &pagerDutyEvent{RoutingKey: p.key, EventAction: "resolve", DedupKey: dedupKey})
+}
+
+func (p *pagerDuty) send(ctx context.Context, event *pagerDutyEvent) error {
+	return postJSON(ctx, p.client, p.url+"/v2/enqueue", nil, event)
+}
+
+// opsgenie creates and closes Opsgenie alerts. Alerts with the same alias
+// are deduplicated into one.
+type opsgenie struct {
+	client *http.Client
+	url    string
+	key    string
+}
+
+func newOpsgenie(cfg Config) *opsgenie {
+	endpoint := cfg.URL
+	if endpoint == "" {
+		endpoint = opsgenieURL
+	}
+	return &opsgenie{client: cfg.Client, url: strings.TrimSuffix(endpoint, "/"), key: cfg.Key}
+}
+
+type opsgenieAlert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias"`
+	Description string            `json:"description,omitempty"`
+	Source      string            `json:"source"`
+	Entity      string            `json:"entity,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Details     map[string]string `json:"details,omitempty"`
+	Priority    string            `json:"priority"`
+}
+
+type opsgenieClose struct {
+	Source string `json:"source"`
+	Note   string `json:"note,omitempty"`
+}
+
+func (o *opsgenie) Trigger(ctx context.Context, alert *Alert) error {
+	return postJSON(ctx, o.client, o.url+"/v2/alerts", o.headers(), &opsgenieAlert{
+		Message:     truncate(alert.Summary, opsgenieMaxMessage),
+		Alias:       alert.DedupKey,
+		Description: alert.Summary,
+		Source:      "kausality",
+		Entity:      alert.Source,
+		Tags:        []string{"kausality", "drift"},
+		Details:     alert.Details,
+		Priority:    "P1",
+	})
+}
+
+func (o *opsgenie) Resolve(ctx context.Context, dedupKey string) error {
+	endpoint := o.url + "/v2/alerts/" + url.PathEscape(dedupKey) + "/close?identifierType=alias"
+	err := postJSON(ctx, o.client, endpoint, o.headers(), &opsgenieClose{Source: "kausality", Note: "drift resolved"})
+	var statusErr *statusError
+	if errors.As(err, &statusErr) && statusErr.code == http.StatusNotFound {
+		// Never opened, or already deleted
+		return nil
+	}
+	return err
+}
+
+func (o *opsgenie) headers() map[string]string {
+	return map[string]string{"Authorization": "GenieKey " + o.key}
+}
+
+// statusError is returned for responses other than 2xx.
+type statusError struct {
+	code int
+	body string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("status %d: %s", e.code, e.body)
+}
+
+// postJSON posts body as JSON to endpoint and fails unless the response is 2xx.
+func postJSON(ctx context.Context, client *http.Client, endpoint string, headers map[string]string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return &statusError{code: resp.StatusCode, body: strings.TrimSpace(string(respBody))}
+	}
+	return nil
+}
+
+// truncate shortens s to at most n bytes, marking it with an ellipsis.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-3] + "..."
+}
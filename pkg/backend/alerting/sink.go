@@ -0,0 +1,329 @@
+// Package alerting pages on-call for critical drift through incident
+// management services, PagerDuty or Opsgenie.
+//
+// The sink receives DriftReports like any backend. A Detected report of
+// critical severity opens an incident, and the Resolved report of the drift
+// resolves it. Incidents are deduplicated by drift ID: repeated reports of
+// the same drift, e.g. a controller retrying a change denied in enforce
+// mode, keep updating one incident, and a resolution resolves the incidents
+// of all drift IDs it resolves. Severity is derived from the report: only
+// real, unsuppressed drift of the configured namespaces and kinds is
+// critical.
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/go-logr/logr"
+
+	"github.com/kausality-io/kausality/pkg/callback"
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+)
+
+// Providers of incidents.
+const (
+	// ProviderPagerDuty sends events to the PagerDuty Events API v2.
+	ProviderPagerDuty = "pagerduty"
+	// ProviderOpsgenie creates and closes Opsgenie alerts.
+	ProviderOpsgenie = "opsgenie"
+)
+
+// Severity is the severity of a drift report.
+type Severity string
+
+const (
+	// SeverityCritical drift opens an incident.
+	SeverityCritical Severity = "critical"
+	// SeverityWarning drift is only logged.
+	SeverityWarning Severity = "warning"
+)
+
+// Config configures the Sink.
+type Config struct {
+	// Provider is ProviderPagerDuty or ProviderOpsgenie.
+	Provider string
+	// Key is the PagerDuty integration (routing) key or the Opsgenie API key.
+	Key string
+	// URL overrides the API endpoint of the provider, e.g. the Opsgenie EU
+	// endpoint https://api.eu.opsgenie.com.
+	URL string
+	// Critical selects the critical drift.
+	Critical Selector
+	// Client sends requests to the provider. Defaults to a client with a 10s timeout.
+	Client *http.Client
+	// Log receives opened and resolved incidents.
+	Log logr.Logger
+}
+
+// Selector selects drift by the namespace and kind of the drifted child.
+// Empty lists select everything.
+type Selector struct {
+	// Namespaces are glob patterns of namespaces, e.g. "prod-*". Drift of
+	// cluster-scoped children matches the namespace of the parent.
+	Namespaces []string
+	// Kinds are kinds of children, e.g. "Deployment".
+	Kinds []string
+}
+
+// Matches reports whether the selector selects the drift of a report.
+func (s Selector) Matches(report *v1alpha1.DriftReport) bool {
+	if len(s.Kinds) > 0 && !slices.Contains(s.Kinds, report.Spec.Child.Kind) {
+		return false
+	}
+	if len(s.Namespaces) == 0 {
+		return true
+	}
+	namespace := report.Spec.Child.Namespace
+	if namespace == "" {
+		namespace = report.Spec.Parent.Namespace
+	}
+	for _, pattern := range s.Namespaces {
+		if ok, _ := path.Match(pattern, namespace); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Alert is an incident of a drift.
+type Alert struct {
+	// DedupKey identifies the incident of the drift across reports.
+	DedupKey string
+	// Summary is a one-line description.
+	Summary string
+	// Source is the drifted child.
+	Source string
+	// Details are shown with the incident.
+	Details map[string]string
+}
+
+// Provider opens and resolves incidents.
+type Provider interface {
+	// Trigger opens the incident of the alert, or updates it if open.
+	Trigger(ctx context.Context, alert *Alert) error
+	// Resolve resolves the incident of dedupKey. Resolving an incident that
+	// is not open succeeds.
+	Resolve(ctx context.Context, dedupKey string) error
+}
+
+// Sink opens incidents for critical drift and resolves them when the drift
+// is resolved.
+type Sink struct {
+	cfg      Config
+	provider Provider
+	log      logr.Logger
+
+	mu sync.Mutex
+	// open are the drift IDs with open incidents, by resolution ID of the
+	// parent and child. Resolutions usually name the drift IDs they resolve;
+	// open resolves the incidents of those that do not.
+	open map[string][]string
+}
+
+// New creates a Sink for the configured provider.
+func New(cfg Config) (*Sink, error) {
+	if cfg.Key == "" {
+		return nil, errors.New("key is required")
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: defaultTimeout}
+	}
+	var provider Provider
+	switch cfg.Provider {
+	case ProviderPagerDuty:
+		provider = newPagerDuty(cfg)
+	case ProviderOpsgenie:
+		provider = newOpsgenie(cfg)
+	default:
+		return nil, fmt.Errorf("invalid provider %q: must be %q or %q", cfg.Provider, ProviderPagerDuty, ProviderOpsgenie)
+	}
+	return NewWithProvider(cfg, provider), nil
+}
+
+// NewWithProvider creates a Sink sending to provider.
+func NewWithProvider(cfg Config, provider Provider) *Sink {
+	return &Sink{
+		cfg:      cfg,
+		provider: provider,
+		log:      cfg.Log,
+		open:     make(map[string][]string),
+	}
+}
+
+// Handler returns the HTTP handler receiving DriftReports.
+func (s *Sink) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /webhook", s.handleWebhook)
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"status":"ok","incidents":%d}`, s.Len())
+	})
+	return mux
+}
+
+// handleWebhook forwards a DriftReport to the provider. Reports are only
+// acknowledged once the provider accepted them, so the webhook retries
+// reports the provider failed.
+func (s *Sink) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	var report v1alpha1.DriftReport
+	if err := json.Unmarshal(body, &report); err != nil || report.Spec.ID == "" {
+		http.Error(w, "invalid DriftReport", http.StatusBadRequest)
+		return
+	}
+	if err := s.Add(r.Context(), &report); err != nil {
+		s.log.Error(err, "failed to forward drift report", "id", report.Spec.ID, "provider", s.cfg.Provider)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	response := v1alpha1.DriftReportResponse{Acknowledged: true}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// Add opens an incident for a Detected report of critical severity, and
+// resolves the incidents of the drift a Resolved report resolves. Other
+// reports are ignored.
+func (s *Sink) Add(ctx context.Context, report *v1alpha1.DriftReport) error {
+	switch report.Spec.Phase {
+	case v1alpha1.DriftReportPhaseDetected:
+		if s.Severity(report) != SeverityCritical {
+			s.log.V(1).Info("ignoring drift below critical severity", "id", report.Spec.ID)
+			return nil
+		}
+		return s.trigger(ctx, report)
+	case v1alpha1.DriftReportPhaseResolved:
+		return s.resolve(ctx, report)
+	}
+	return nil
+}
+
+// Severity returns the severity of a Detected report: critical for drift
+// selected by Config.Critical that actually changed the child and was
+// neither suppressed nor overridden with a justification, warning otherwise.
+func (s *Sink) Severity(report *v1alpha1.DriftReport) Severity {
+	spec := report.Spec
+	if spec.Request.DryRun || spec.Suppression != nil || spec.Override != nil {
+		return SeverityWarning
+	}
+	if !s.cfg.Critical.Matches(report) {
+		return SeverityWarning
+	}
+	return SeverityCritical
+}
+
+func (s *Sink) trigger(ctx context.Context, report *v1alpha1.DriftReport) error {
+	if err := s.provider.Trigger(ctx, alert(report)); err != nil {
+		return fmt.Errorf("failed to open incident: %w", err)
+	}
+
+	key := callback.GenerateResolutionID(report.Spec.Parent, report.Spec.Child)
+	s.mu.Lock()
+	if !slices.Contains(s.open[key], report.Spec.ID) {
+		s.open[key] = append(s.open[key], report.Spec.ID)
+	}
+	s.mu.Unlock()
+
+	s.log.Info("opened incident", "id", report.Spec.ID, "child", objectName(report.Spec.Child), "provider", s.cfg.Provider)
+	return nil
+}
+
+// resolve resolves the incidents of the drift IDs named by the resolution
+// and of those opened for its parent and child. Drift IDs of incidents that
+// were never opened are resolved too: providers ignore them, and incidents
+// opened before a restart are still resolved.
+func (s *Sink) resolve(ctx context.Context, report *v1alpha1.DriftReport) error {
+	key := callback.GenerateResolutionID(report.Spec.Parent, report.Spec.Child)
+
+	s.mu.Lock()
+	ids := slices.Clone(s.open[key])
+	s.mu.Unlock()
+	if report.Spec.Resolution != nil {
+		for _, id := range report.Spec.Resolution.DetectedIDs {
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	for _, id := range ids {
+		if err := s.provider.Resolve(ctx, DedupKey(id)); err != nil {
+			return fmt.Errorf("failed to resolve incident of drift %s: %w", id, err)
+		}
+	}
+
+	s.mu.Lock()
+	delete(s.open, key)
+	s.mu.Unlock()
+
+	if len(ids) > 0 {
+		s.log.Info("resolved incidents", "ids", ids, "child", objectName(report.Spec.Child), "provider", s.cfg.Provider)
+	}
+	return nil
+}
+
+// Len returns the number of children with open incidents.
+func (s *Sink) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.open)
+}
+
+// DedupKey returns the key deduplicating the incidents of a drift ID.
+func DedupKey(driftID string) string {
+	return "kausality-" + driftID
+}
+
+// alert returns the alert of a Detected report.
+func alert(report *v1alpha1.DriftReport) *Alert {
+	spec := report.Spec
+	summary := fmt.Sprintf("Drift: %s of %s %s", strings.ToLower(spec.Request.Operation), spec.Child.Kind, objectName(spec.Child))
+	if spec.Request.User != "" {
+		summary += " by " + spec.Request.User
+	}
+	summary += fmt.Sprintf(", not requested by %s %s", spec.Parent.Kind, objectName(spec.Parent))
+
+	details := map[string]string{
+		"driftID":   spec.ID,
+		"child":     spec.Child.APIVersion + " " + spec.Child.Kind + " " + objectName(spec.Child),
+		"parent":    spec.Parent.APIVersion + " " + spec.Parent.Kind + " " + objectName(spec.Parent),
+		"operation": spec.Request.Operation,
+	}
+	if spec.Request.User != "" {
+		details["user"] = spec.Request.User
+	}
+	if spec.Request.FieldManager != "" {
+		details["fieldManager"] = spec.Request.FieldManager
+	}
+	if spec.Occurrences != nil {
+		details["occurrences"] = fmt.Sprint(spec.Occurrences.Count)
+	}
+	return &Alert{
+		DedupKey: DedupKey(spec.ID),
+		Summary:  summary,
+		Source:   spec.Child.Kind + "/" + objectName(spec.Child),
+		Details:  details,
+	}
+}
+
+func objectName(ref v1alpha1.ObjectReference) string {
+	if ref.Namespace == "" {
+		return ref.Name
+	}
+	return ref.Namespace + "/" + ref.Name
+}
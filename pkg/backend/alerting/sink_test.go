@@ -0,0 +1,243 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kausality-io/kausality/pkg/callback"
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+)
+
+// recordingProvider records triggered and resolved dedup keys.
+type recordingProvider struct {
+	triggered []*Alert
+	resolved  []string
+	err       error
+}
+
+func (p *recordingProvider) Trigger(_ context.Context, alert *Alert) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.triggered = append(p.triggered, alert)
+	return nil
+}
+
+func (p *recordingProvider) Resolve(_ context.Context, dedupKey string) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.resolved = append(p.resolved, dedupKey)
+	return nil
+}
+
+func testReport(phase v1alpha1.DriftReportPhase, namespace, change string) *v1alpha1.DriftReport {
+	parent := v1alpha1.ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Namespace: namespace, Name: "web"}
+	child := v1alpha1.ObjectReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Namespace: namespace, Name: "web-abc"}
+	id := callback.GenerateResolutionID(parent, child)
+	if phase == v1alpha1.DriftReportPhaseDetected {
+		id = callback.GenerateDriftID(parent, child, []byte(change))
+	}
+	return &v1alpha1.DriftReport{Spec: v1alpha1.DriftReportSpec{
+		ID:      id,
+		Phase:   phase,
+		Parent:  parent,
+		Child:   child,
+		Request: v1alpha1.RequestContext{User: "system:serviceaccount:kube-system:deployment-controller", Operation: "UPDATE"},
+	}}
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(Config{Provider: ProviderPagerDuty})
+	assert.ErrorContains(t, err, "key is required")
+	_, err = New(Config{Provider: "email", Key: "key"})
+	assert.ErrorContains(t, err, `invalid provider "email"`)
+	_, err = New(Config{Provider: ProviderOpsgenie, Key: "key"})
+	assert.NoError(t, err)
+}
+
+func TestSelector(t *testing.T) {
+	prod := testReport(v1alpha1.DriftReportPhaseDetected, "prod-eu", "a")
+	dev := testReport(v1alpha1.DriftReportPhaseDetected, "dev", "a")
+	clusterScoped := testReport(v1alpha1.DriftReportPhaseDetected, "prod-us", "a")
+	clusterScoped.Spec.Child.Namespace = ""
+
+	assert.True(t, Selector{}.Matches(dev))
+	s := Selector{Namespaces: []string{"prod-*"}}
+	assert.True(t, s.Matches(prod))
+	assert.False(t, s.Matches(dev))
+	assert.True(t, s.Matches(clusterScoped), "cluster-scoped children match the namespace of the parent")
+	assert.False(t, Selector{Kinds: []string{"Deployment"}}.Matches(prod))
+	assert.True(t, Selector{Namespaces: []string{"prod-*"}, Kinds: []string{"ReplicaSet"}}.Matches(prod))
+}
+
+func TestSeverity(t *testing.T) {
+	s := NewWithProvider(Config{Critical: Selector{Namespaces: []string{"prod"}}}, &recordingProvider{})
+
+	assert.Equal(t, SeverityCritical, s.Severity(testReport(v1alpha1.DriftReportPhaseDetected, "prod", "a")))
+	assert.Equal(t, SeverityWarning, s.Severity(testReport(v1alpha1.DriftReportPhaseDetected, "dev", "a")))
+
+	dryRun := testReport(v1alpha1.DriftReportPhaseDetected, "prod", "a")
+	dryRun.Spec.Request.DryRun = true
+	assert.Equal(t, SeverityWarning, s.Severity(dryRun))
+	overridden := testReport(v1alpha1.DriftReportPhaseDetected, "prod", "a")
+	overridden.Spec.Override = &v1alpha1.Override{Justification: "hotfix", Ticket: "OPS-1"}
+	assert.Equal(t, SeverityWarning, s.Severity(overridden))
+	suppressed := testReport(v1alpha1.DriftReportPhaseDetected, "prod", "a")
+	suppressed.Spec.Suppression = &v1alpha1.Suppression{}
+	assert.Equal(t, SeverityWarning, s.Severity(suppressed))
+}
+
+func TestSink_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	provider := &recordingProvider{}
+	s := NewWithProvider(Config{Critical: Selector{Namespaces: []string{"prod"}}, Log: logr.Discard()}, provider)
+
+	first := testReport(v1alpha1.DriftReportPhaseDetected, "prod", "a")
+	second := testReport(v1alpha1.DriftReportPhaseDetected, "prod", "b")
+	require.NoError(t, s.Add(ctx, first))
+	require.NoError(t, s.Add(ctx, first))
+	require.NoError(t, s.Add(ctx, second))
+	require.NoError(t, s.Add(ctx, testReport(v1alpha1.DriftReportPhaseDetected, "dev", "a")))
+	require.Len(t, provider.triggered, 3, "repeated reports update the incident of their drift ID")
+	assert.Equal(t, DedupKey(first.Spec.ID), provider.triggered[0].DedupKey)
+	assert.Equal(t, DedupKey(first.Spec.ID), provider.triggered[1].DedupKey)
+	assert.Equal(t, DedupKey(second.Spec.ID), provider.triggered[2].DedupKey)
+	assert.Contains(t, provider.triggered[0].Summary, "update of ReplicaSet prod/web-abc")
+	assert.Equal(t, first.Spec.ID, provider.triggered[0].Details["driftID"])
+	assert.Equal(t, 1, s.Len())
+
+	// The resolution resolves the incidents of all drift IDs of the child
+	resolved := testReport(v1alpha1.DriftReportPhaseResolved, "prod", "")
+	resolved.Spec.Resolution = &v1alpha1.Resolution{Kind: v1alpha1.ResolutionControllerCorrected, DetectedIDs: []string{second.Spec.ID}}
+	require.NoError(t, s.Add(ctx, resolved))
+	assert.ElementsMatch(t, []string{DedupKey(first.Spec.ID), DedupKey(second.Spec.ID)}, provider.resolved)
+	assert.Equal(t, 0, s.Len())
+
+	// Incidents opened before a restart are resolved by the drift IDs of the resolution
+	provider.resolved = nil
+	restarted := NewWithProvider(Config{Log: logr.Discard()}, provider)
+	require.NoError(t, restarted.Add(ctx, resolved))
+	assert.Equal(t, []string{DedupKey(second.Spec.ID)}, provider.resolved)
+}
+
+func TestSink_Handler(t *testing.T) {
+	provider := &recordingProvider{}
+	s := NewWithProvider(Config{Log: logr.Discard()}, provider)
+	server := httptest.NewServer(s.Handler())
+	defer server.Close()
+
+	post := func(body []byte) *http.Response {
+		resp, err := http.Post(server.URL+"/webhook", "application/json", bytes.NewReader(body))
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return resp
+	}
+	body, err := json.Marshal(testReport(v1alpha1.DriftReportPhaseDetected, "prod", "a"))
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, post(body).StatusCode)
+	assert.Len(t, provider.triggered, 1)
+	assert.Equal(t, http.StatusBadRequest, post([]byte(`{}`)).StatusCode)
+
+	// Failures are not acknowledged, so the webhook retries
+	provider.err = errors.New("unavailable")
+	assert.Equal(t, http.StatusBadGateway, post(body).StatusCode)
+}
+
+// capturingServer records the requests of a provider API.
+type capturingServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []capturedRequest
+	status   int
+}
+
+type capturedRequest struct {
+	path          string
+	authorization string
+	body          map[string]interface{}
+}
+
+func newCapturingServer(t *testing.T) *capturingServer {
+	s := &capturingServer{status: http.StatusAccepted}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]interface{}
+		_ = json.Unmarshal(data, &body)
+		s.mu.Lock()
+		s.requests = append(s.requests, capturedRequest{path: r.URL.RequestURI(), authorization: r.Header.Get("Authorization"), body: body})
+		status := s.status
+		s.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestPagerDuty(t *testing.T) {
+	ctx := context.Background()
+	server := newCapturingServer(t)
+	s, err := New(Config{Provider: ProviderPagerDuty, Key: "routing-key", URL: server.URL, Log: logr.Discard()})
+	require.NoError(t, err)
+
+	detected := testReport(v1alpha1.DriftReportPhaseDetected, "prod", "a")
+	require.NoError(t, s.Add(ctx, detected))
+	require.NoError(t, s.Add(ctx, testReport(v1alpha1.DriftReportPhaseResolved, "prod", "")))
+
+	require.Len(t, server.requests, 2)
+	trigger := server.requests[0]
+	assert.Equal(t, "/v2/enqueue", trigger.path)
+	assert.Equal(t, "routing-key", trigger.body["routing_key"])
+	assert.Equal(t, "trigger", trigger.body["event_action"])
+	assert.Equal(t, DedupKey(detected.Spec.ID), trigger.body["dedup_key"])
+	payload := trigger.body["payload"].(map[string]interface{})
+	assert.Equal(t, "critical", payload["severity"])
+	assert.Equal(t, "ReplicaSet/prod/web-abc", payload["source"])
+
+	resolve := server.requests[1]
+	assert.Equal(t, "resolve", resolve.body["event_action"])
+	assert.Equal(t, DedupKey(detected.Spec.ID), resolve.body["dedup_key"])
+
+	server.status = http.StatusTooManyRequests
+	assert.ErrorContains(t, s.Add(ctx, detected), "status 429")
+}
+
+func TestOpsgenie(t *testing.T) {
+	ctx := context.Background()
+	server := newCapturingServer(t)
+	s, err := New(Config{Provider: ProviderOpsgenie, Key: "api-key", URL: server.URL, Log: logr.Discard()})
+	require.NoError(t, err)
+
+	detected := testReport(v1alpha1.DriftReportPhaseDetected, "prod", "a")
+	require.NoError(t, s.Add(ctx, detected))
+	require.NoError(t, s.Add(ctx, testReport(v1alpha1.DriftReportPhaseResolved, "prod", "")))
+
+	require.Len(t, server.requests, 2)
+	create := server.requests[0]
+	assert.Equal(t, "/v2/alerts", create.path)
+	assert.Equal(t, "GenieKey api-key", create.authorization)
+	assert.Equal(t, DedupKey(detected.Spec.ID), create.body["alias"])
+	assert.Equal(t, "P1", create.body["priority"])
+	assert.LessOrEqual(t, len(create.body["message"].(string)), opsgenieMaxMessage)
+
+	closed := server.requests[1]
+	assert.Equal(t, "/v2/alerts/"+DedupKey(detected.Spec.ID)+"/close?identifierType=alias", closed.path)
+
+	// Closing alerts that do not exist succeeds
+	server.status = http.StatusNotFound
+	resolved := testReport(v1alpha1.DriftReportPhaseResolved, "prod", "")
+	resolved.Spec.Resolution = &v1alpha1.Resolution{DetectedIDs: []string{detected.Spec.ID}}
+	assert.NoError(t, s.Add(ctx, resolved))
+}
@@ -0,0 +1,396 @@
+package backend
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// SessionCookie holds the session ID of users logged in with a browser.
+	SessionCookie = "kausality_session"
+	// stateCookie holds the state of a pending login.
+	stateCookie = "kausality_state"
+	// DefaultGroupsClaim is the ID token claim holding the groups of a user.
+	DefaultGroupsClaim = "groups"
+	// AllNamespaces grants a team all namespaces, including drift of
+	// cluster-scoped objects.
+	AllNamespaces = "*"
+	// maxSessions bounds the number of browser sessions kept in memory.
+	maxSessions = 10000
+)
+
+// Team grants the members of OIDC groups access to the drift in namespaces.
+type Team struct {
+	Name       string   `json:"name"`
+	Groups     []string `json:"groups"`
+	Namespaces []string `json:"namespaces"`
+}
+
+// LoadTeams reads teams from a YAML file with a top-level teams list.
+func LoadTeams(path string) ([]Team, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read teams file: %w", err)
+	}
+	var file struct {
+		Teams []Team `json:"teams"`
+	}
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse teams file: %w", err)
+	}
+	for i, team := range file.Teams {
+		if len(team.Groups) == 0 || len(team.Namespaces) == 0 {
+			return nil, fmt.Errorf("team %d (%q) needs groups and namespaces", i, team.Name)
+		}
+	}
+	return file.Teams, nil
+}
+
+// AuthConfig configures OIDC authentication of the API.
+type AuthConfig struct {
+	// IssuerURL is the URL of the OIDC issuer, used for discovery.
+	IssuerURL string
+	ClientID  string
+	// ClientSecret is used to exchange the code of browser logins.
+	ClientSecret string
+	// RedirectURL is the external URL of the backend's /oauth2/callback.
+	RedirectURL string
+	// Scopes are requested at login in addition to openid, e.g. groups with Dex.
+	Scopes []string
+	// GroupsClaim is the claim holding the groups of a user. Defaults to DefaultGroupsClaim.
+	GroupsClaim string
+	// Teams map the groups of users to the namespaces they see.
+	Teams []Team
+}
+
+// Auth authenticates API requests with OIDC ID tokens and scopes them to the
+// namespaces of the user's teams. Tokens are taken from the Authorization
+// bearer header. Browsers log in with the flow at /oauth2/login instead,
+// which keeps the verified groups in a server-side session until the ID
+// token expires; the session cookie only holds an opaque session ID.
+// Sessions are kept in memory, so users log in again after a restart.
+type Auth struct {
+	verifier    *oidc.IDTokenVerifier
+	oauth2      oauth2.Config
+	groupsClaim string
+	teams       []Team
+	now         func() time.Time
+
+	mu       sync.Mutex
+	sessions map[string]session
+}
+
+// session is a browser login.
+type session struct {
+	groups []string
+	expiry time.Time
+}
+
+// NewAuth discovers the OIDC issuer of cfg.
+func NewAuth(ctx context.Context, cfg AuthConfig) (*Auth, error) {
+	if cfg.IssuerURL == "" || cfg.ClientID == "" {
+		return nil, errors.New("OIDC issuer URL and client ID are required")
+	}
+	provider, err := oidc.NewProvider(ctx, cfg.IssuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover OIDC issuer: %w", err)
+	}
+	return newAuth(cfg, provider.Verifier(&oidc.Config{ClientID: cfg.ClientID}), provider.Endpoint()), nil
+}
+
+func newAuth(cfg AuthConfig, verifier *oidc.IDTokenVerifier, endpoint oauth2.Endpoint) *Auth {
+	groupsClaim := cfg.GroupsClaim
+	if groupsClaim == "" {
+		groupsClaim = DefaultGroupsClaim
+	}
+	return &Auth{
+		verifier: verifier,
+		oauth2: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Endpoint:     endpoint,
+			Scopes:       append([]string{oidc.ScopeOpenID}, cfg.Scopes...),
+		},
+		groupsClaim: groupsClaim,
+		teams:       cfg.Teams,
+		now:         time.Now,
+		sessions:    make(map[string]session),
+	}
+}
+
+// register adds the login flow to mux.
+func (a *Auth) register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /oauth2/login", a.handleLogin)
+	mux.HandleFunc("GET /oauth2/callback", a.handleCallback)
+	mux.HandleFunc("GET /oauth2/logout", a.handleLogout)
+}
+
+// scope is the set of namespaces visible to a request.
+type scope struct {
+	all        bool
+	namespaces map[string]bool
+}
+
+// allows returns whether drift in namespace is visible. Drift of
+// cluster-scoped objects is only visible with access to all namespaces.
+func (s *scope) allows(namespace string) bool {
+	return s == nil || s.all || s.namespaces[namespace]
+}
+
+type scopeKey struct{}
+
+// scopeFrom returns the scope of an authenticated request, or nil if
+// authentication is disabled.
+func scopeFrom(ctx context.Context) *scope {
+	s, _ := ctx.Value(scopeKey{}).(*scope)
+	return s
+}
+
+// scopeOf returns the scope of the teams of groups, or nil if the groups
+// belong to no team.
+func (a *Auth) scopeOf(groups []string) *scope {
+	var s *scope
+	for _, team := range a.teams {
+		if !intersects(team.Groups, groups) {
+			continue
+		}
+		if s == nil {
+			s = &scope{namespaces: map[string]bool{}}
+		}
+		for _, ns := range team.Namespaces {
+			if ns == AllNamespaces {
+				s.all = true
+			}
+			s.namespaces[ns] = true
+		}
+	}
+	return s
+}
+
+func intersects(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// authenticate rejects requests without valid ID token of a team member and
+// passes the scope of the user's teams to next.
+func (a *Auth) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var groups []string
+		if raw, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			token, err := a.verifier.Verify(r.Context(), raw)
+			if err == nil {
+				groups, err = a.groupsOf(token)
+			}
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="kausality", error="invalid_token"`)
+				http.Error(w, "unauthorized: "+err.Error(), http.StatusUnauthorized)
+				return
+			}
+		} else {
+			cookie, err := r.Cookie(SessionCookie)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="kausality"`)
+				http.Error(w, "unauthorized, log in at /oauth2/login", http.StatusUnauthorized)
+				return
+			}
+			if groups, ok = a.session(cookie.Value); !ok {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="kausality"`)
+				http.Error(w, "session expired, log in at /oauth2/login", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		s := a.scopeOf(groups)
+		if s == nil {
+			http.Error(w, "forbidden: not a member of any team", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), scopeKey{}, s)))
+	})
+}
+
+// groupsOf returns the groups of a verified ID token.
+func (a *Auth) groupsOf(token *oidc.IDToken) ([]string, error) {
+	var claims map[string]interface{}
+	if err := token.Claims(&claims); err != nil {
+		return nil, err
+	}
+	// Groups are a list, or a single string with some issuers
+	switch v := claims[a.groupsClaim].(type) {
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		groups := make([]string, 0, len(v))
+		for _, g := range v {
+			if s, ok := g.(string); ok {
+				groups = append(groups, s)
+			}
+		}
+		return groups, nil
+	}
+	return nil, nil
+}
+
+// handleLogin redirects to the issuer. The redirect query parameter is the
+// path returned to after login.
+func (a *Auth) handleLogin(w http.ResponseWriter, r *http.Request) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	state := base64.RawURLEncoding.EncodeToString(b)
+	http.SetCookie(w, &http.Cookie{
+		Name:     stateCookie,
+		Value:    state + ":" + url.QueryEscape(localPath(r.URL.Query().Get("redirect"))),
+		Path:     "/oauth2/",
+		MaxAge:   int((10 * time.Minute).Seconds()),
+		HttpOnly: true,
+		Secure:   a.secure(),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, a.oauth2.AuthCodeURL(state), http.StatusFound)
+}
+
+// handleCallback exchanges the code for an ID token and starts a session
+// lasting until the token expires.
+func (a *Auth) handleCallback(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(stateCookie)
+	if err != nil {
+		http.Error(w, "login expired", http.StatusBadRequest)
+		return
+	}
+	state, redirect, _ := strings.Cut(cookie.Value, ":")
+	redirect, err = url.QueryUnescape(redirect)
+	if err != nil || r.URL.Query().Get("state") != state {
+		http.Error(w, "invalid state", http.StatusBadRequest)
+		return
+	}
+	if msg := r.URL.Query().Get("error"); msg != "" {
+		http.Error(w, "login failed: "+msg, http.StatusUnauthorized)
+		return
+	}
+
+	token, err := a.oauth2.Exchange(r.Context(), r.URL.Query().Get("code"))
+	if err != nil {
+		http.Error(w, "login failed: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+	raw, ok := token.Extra("id_token").(string)
+	if !ok {
+		http.Error(w, "login failed: no ID token", http.StatusUnauthorized)
+		return
+	}
+	idToken, err := a.verifier.Verify(r.Context(), raw)
+	if err == nil {
+		var groups []string
+		if groups, err = a.groupsOf(idToken); err == nil {
+			raw, err = a.startSession(groups, idToken.Expiry)
+		}
+	}
+	if err != nil {
+		http.Error(w, "login failed: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{Name: stateCookie, Path: "/oauth2/", MaxAge: -1})
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookie,
+		Value:    raw,
+		Path:     "/",
+		Expires:  idToken.Expiry,
+		HttpOnly: true,
+		Secure:   a.secure(),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, localPath(redirect), http.StatusFound)
+}
+
+// handleLogout ends the session and removes the session cookie.
+func (a *Auth) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(SessionCookie); err == nil {
+		a.mu.Lock()
+		delete(a.sessions, cookie.Value)
+		a.mu.Unlock()
+	}
+	http.SetCookie(w, &http.Cookie{Name: SessionCookie, Path: "/", MaxAge: -1})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// startSession stores the groups of a login until expiry and returns the
+// session ID. Expired sessions are dropped when the limit is reached.
+func (a *Auth) startSession(groups []string, expiry time.Time) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	id := base64.RawURLEncoding.EncodeToString(b)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.sessions) >= maxSessions {
+		now := a.now()
+		for k, s := range a.sessions {
+			if !now.Before(s.expiry) {
+				delete(a.sessions, k)
+			}
+		}
+		if len(a.sessions) >= maxSessions {
+			return "", errors.New("too many sessions")
+		}
+	}
+	a.sessions[id] = session{groups: groups, expiry: expiry}
+	return id, nil
+}
+
+// session returns the groups of an unexpired session.
+func (a *Auth) session(id string) ([]string, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	s, ok := a.sessions[id]
+	if !ok {
+		return nil, false
+	}
+	if !a.now().Before(s.expiry) {
+		delete(a.sessions, id)
+		return nil, false
+	}
+	return s.groups, true
+}
+
+// secure returns whether cookies are restricted to HTTPS, i.e. whether the
+// backend is served behind HTTPS.
+func (a *Auth) secure() bool {
+	return strings.HasPrefix(a.oauth2.RedirectURL, "https://")
+}
+
+// localPath returns path if it is a path on this server, "/api/v1/drifts"
+// otherwise, so logins don't redirect to other sites.
+func localPath(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, "/\\") {
+		return "/api/v1/drifts"
+	}
+	return path
+}
@@ -0,0 +1,237 @@
+package backend
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+)
+
+const testIssuer = "https://issuer.example.org"
+
+// signToken returns an RS256 ID token for the test client with groups.
+func signToken(t *testing.T, key *rsa.PrivateKey, groups ...string) string {
+	t.Helper()
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	require.NoError(t, err)
+	claims, err := json.Marshal(map[string]interface{}{
+		"iss":    testIssuer,
+		"aud":    "kausality",
+		"sub":    "alice",
+		"exp":    time.Now().Add(time.Hour).Unix(),
+		"iat":    time.Now().Unix(),
+		"groups": groups,
+	})
+	require.NoError(t, err)
+	payload := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(payload))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func newTestAuth(t *testing.T, endpoint oauth2.Endpoint) (*Auth, *rsa.PrivateKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	verifier := oidc.NewVerifier(testIssuer, &oidc.StaticKeySet{PublicKeys: []crypto.PublicKey{key.Public()}}, &oidc.Config{ClientID: "kausality"})
+	auth := newAuth(AuthConfig{
+		ClientID:    "kausality",
+		RedirectURL: "https://kausality.example.org/oauth2/callback",
+		Teams: []Team{
+			{Name: "payments", Groups: []string{"payments-devs"}, Namespaces: []string{"payments"}},
+			{Name: "platform", Groups: []string{"platform"}, Namespaces: []string{AllNamespaces}},
+		},
+	}, verifier, endpoint)
+	return auth, key
+}
+
+func driftIn(id, namespace string) *v1alpha1.DriftReport {
+	return &v1alpha1.DriftReport{Spec: v1alpha1.DriftReportSpec{
+		ID:     id,
+		Phase:  v1alpha1.DriftReportPhaseDetected,
+		Parent: v1alpha1.ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Namespace: namespace, Name: "web"},
+		Child:  v1alpha1.ObjectReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Namespace: namespace, Name: "web-1"},
+	}}
+}
+
+func TestAuth_TeamScopes(t *testing.T) {
+	auth, key := newTestAuth(t, oauth2.Endpoint{})
+	server := NewServer().WithAuth(auth)
+	server.Store().Add(driftIn("pay-1", "payments"))
+	server.Store().Add(driftIn("shop-1", "shop"))
+	handler := server.Handler()
+
+	do := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	listed := func(rec *httptest.ResponseRecorder) []string {
+		var body struct {
+			Items []StoredReport `json:"items"`
+		}
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+		var ids []string
+		for _, item := range body.Items {
+			ids = append(ids, item.Report.Spec.ID)
+		}
+		return ids
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/api/v1/drifts", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/api/v1/drifts", "invalid").Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/api/v1/drifts", signToken(t, key, "other")).Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/healthz", "").Code)
+
+	payments := signToken(t, key, "payments-devs")
+	assert.Equal(t, []string{"pay-1"}, listed(do(http.MethodGet, "/api/v1/drifts", payments)))
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/api/v1/drifts/pay-1", payments).Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/api/v1/drifts/shop-1", payments).Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/api/v1/drifts/shop-1/trace", payments).Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, "/api/v1/drifts/shop-1", payments).Code)
+
+	var digest Digest
+	require.NoError(t, json.NewDecoder(do(http.MethodGet, "/api/v1/digest", payments).Body).Decode(&digest))
+	assert.Equal(t, 1, digest.Total)
+
+	platform := signToken(t, key, "platform")
+	assert.ElementsMatch(t, []string{"pay-1", "shop-1"}, listed(do(http.MethodGet, "/api/v1/drifts", platform)))
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/api/v1/drifts/shop-1", platform).Code)
+	_, ok := server.Store().Get("shop-1")
+	assert.False(t, ok)
+}
+
+func TestAuth_Login(t *testing.T) {
+	var idToken string
+	issuer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "the-code", r.PostForm.Get("code"))
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"id_token":     idToken,
+		})
+	}))
+	defer issuer.Close()
+
+	auth, key := newTestAuth(t, oauth2.Endpoint{AuthURL: testIssuer + "/auth", TokenURL: issuer.URL + "/token"})
+	idToken = signToken(t, key, "payments-devs")
+	handler := NewServer().WithAuth(auth).Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/oauth2/login?redirect=/api/v1/digest", nil))
+	require.Equal(t, http.StatusFound, rec.Code)
+	location, err := url.Parse(rec.Header().Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, "kausality", location.Query().Get("client_id"))
+	state := location.Query().Get("state")
+	require.NotEmpty(t, state)
+	stateCookies := rec.Result().Cookies()
+
+	// A forged state is rejected
+	req := httptest.NewRequest(http.MethodGet, "/oauth2/callback?code=the-code&state=forged", nil)
+	for _, c := range stateCookies {
+		req.AddCookie(c)
+	}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/oauth2/callback?code=the-code&state="+state, nil)
+	for _, c := range stateCookies {
+		req.AddCookie(c)
+	}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusFound, rec.Code, rec.Body.String())
+	assert.Equal(t, "/api/v1/digest", rec.Header().Get("Location"))
+
+	var session *http.Cookie
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == SessionCookie {
+			session = c
+		}
+	}
+	require.NotNil(t, session)
+	assert.True(t, session.HttpOnly)
+	assert.True(t, session.Secure)
+	assert.NotEqual(t, idToken, session.Value, "the cookie holds a session ID, not the token")
+	assert.Len(t, session.Value, 43)
+
+	get := func(cookie *http.Cookie) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/drifts", nil)
+		req.AddCookie(cookie)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	assert.Equal(t, http.StatusOK, get(session))
+	assert.Equal(t, http.StatusUnauthorized, get(&http.Cookie{Name: SessionCookie, Value: idToken}))
+
+	// Sessions end with the ID token
+	auth.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	assert.Equal(t, http.StatusUnauthorized, get(session))
+	auth.now = time.Now
+
+	// Logout ends the session
+	req = httptest.NewRequest(http.MethodGet, "/oauth2/callback?code=the-code&state="+state, nil)
+	for _, c := range stateCookies {
+		req.AddCookie(c)
+	}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusFound, rec.Code)
+	session = rec.Result().Cookies()[1]
+	require.Equal(t, SessionCookie, session.Name)
+	assert.Equal(t, http.StatusOK, get(session))
+
+	req = httptest.NewRequest(http.MethodGet, "/oauth2/logout", nil)
+	req.AddCookie(session)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, http.StatusUnauthorized, get(session))
+}
+
+func TestLocalPath(t *testing.T) {
+	assert.Equal(t, "/api/v1/digest?format=markdown", localPath("/api/v1/digest?format=markdown"))
+	assert.Equal(t, "/api/v1/drifts", localPath(""))
+	assert.Equal(t, "/api/v1/drifts", localPath("https://evil.example.org"))
+	assert.Equal(t, "/api/v1/drifts", localPath("//evil.example.org"))
+}
+
+func TestLoadTeams(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "teams.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`teams:
+- name: payments
+  groups: [payments-devs]
+  namespaces: [payments, payments-staging]
+`), 0o600))
+	teams, err := LoadTeams(path)
+	require.NoError(t, err)
+	assert.Equal(t, []Team{{Name: "payments", Groups: []string{"payments-devs"}, Namespaces: []string{"payments", "payments-staging"}}}, teams)
+
+	require.NoError(t, os.WriteFile(path, []byte("teams:\n- name: empty\n"), 0o600))
+	_, err = LoadTeams(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,68 @@
+package backend
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"sigs.k8s.io/yaml"
+
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+)
+
+// specDiff returns a unified diff from the old to the new object of a
+// report, without metadata and status, so that it shows the fields the
+// mutation changed. Without an old object, e.g. for creations, the diff
+// adds the whole object. It is empty if the report has no new object, the
+// objects cannot be decoded, or nothing but metadata or status changed.
+func specDiff(report *v1alpha1.DriftReport) string {
+	to, ok := specYAML(report.Spec.NewObject.Raw)
+	if !ok {
+		return ""
+	}
+	from := ""
+	if report.Spec.OldObject != nil {
+		if from, ok = specYAML(report.Spec.OldObject.Raw); !ok {
+			return ""
+		}
+	}
+	if from == to {
+		return ""
+	}
+	name := report.Spec.Child.Kind + "/" + report.Spec.Child.Name
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(from),
+		B:        splitLines(to),
+		FromFile: "old/" + name,
+		ToFile:   "new/" + name,
+		Context:  3,
+	})
+	if err != nil {
+		return ""
+	}
+	return diff
+}
+
+// specYAML returns a raw object as YAML without metadata and status.
+func specYAML(raw []byte) (string, bool) {
+	var obj map[string]interface{}
+	if len(raw) == 0 || json.Unmarshal(raw, &obj) != nil || obj == nil {
+		return "", false
+	}
+	delete(obj, "metadata")
+	delete(obj, "status")
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// splitLines splits text into lines for difflib, without the empty line
+// difflib.SplitLines adds after a trailing newline.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return difflib.SplitLines(strings.TrimSuffix(text, "\n"))
+}
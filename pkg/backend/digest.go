@@ -0,0 +1,261 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultDigestWindow is the window of a weekly digest
+const DefaultDigestWindow = 7 * 24 * time.Hour
+
+// digestTopN is the number of entries per dimension in the markdown digest
+const digestTopN = 10
+
+// Digest aggregates drift over a window and compares it with the window before.
+type Digest struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// Total is the number of drift incidents in the window
+	Total int `json:"total"`
+	// PreviousTotal is the number of drift incidents in the previous window
+	PreviousTotal int `json:"previousTotal"`
+
+	Namespaces []DigestEntry `json:"namespaces"`
+	Kinds      []DigestEntry `json:"kinds"`
+	Actors     []DigestEntry `json:"actors"`
+	// NewSources are the sources drifting in the window that did not drift
+	// in the previous window
+	NewSources []DigestSource `json:"newSources"`
+}
+
+// DigestEntry counts drift of one namespace, kind or actor, most drift first.
+type DigestEntry struct {
+	Name     string `json:"name"`
+	Count    int    `json:"count"`
+	Previous int    `json:"previous"`
+}
+
+// DigestSource is an actor mutating a kind in a namespace.
+type DigestSource struct {
+	Actor     string `json:"actor"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Count     int    `json:"count"`
+}
+
+// Digest aggregates the drift detected in the window ending at now by
+// namespace, kind and actor, and compares it with the previous window.
+func (s *Store) Digest(now time.Time, window time.Duration) *Digest {
+	from := now.Add(-window)
+	d := &Digest{
+		From:       from,
+		To:         now,
+		Namespaces: []DigestEntry{},
+		Kinds:      []DigestEntry{},
+		Actors:     []DigestEntry{},
+		NewSources: []DigestSource{},
+	}
+
+	namespaces := map[string]*DigestEntry{}
+	kinds := map[string]*DigestEntry{}
+	actors := map[string]*DigestEntry{}
+	sources := map[DigestSource]int{}
+	previousSources := map[DigestSource]bool{}
+
+	for _, r := range s.Detections(from.Add(-window)) {
+		if !r.ReceivedAt.Before(now) {
+			continue
+		}
+		current := !r.ReceivedAt.Before(from)
+		source := DigestSource{
+			Actor:     r.Report.Spec.Request.User,
+			Kind:      r.Report.Spec.Child.Kind,
+			Namespace: reportNamespace(r),
+		}
+		if current {
+			d.Total++
+			sources[source]++
+		} else {
+			d.PreviousTotal++
+			previousSources[source] = true
+		}
+		count(namespaces, source.Namespace, current)
+		count(kinds, source.Kind, current)
+		count(actors, source.Actor, current)
+	}
+
+	d.Namespaces = sortedEntries(namespaces)
+	d.Kinds = sortedEntries(kinds)
+	d.Actors = sortedEntries(actors)
+	for source, n := range sources {
+		if previousSources[source] {
+			continue
+		}
+		source.Count = n
+		d.NewSources = append(d.NewSources, source)
+	}
+	sort.Slice(d.NewSources, func(i, j int) bool {
+		a, b := d.NewSources[i], d.NewSources[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Actor+"/"+a.Namespace+"/"+a.Kind < b.Actor+"/"+b.Namespace+"/"+b.Kind
+	})
+	return d
+}
+
+// count increments the current or previous count of the named entry.
+func count(entries map[string]*DigestEntry, name string, current bool) {
+	if name == "" {
+		name = "(none)"
+	}
+	e, ok := entries[name]
+	if !ok {
+		e = &DigestEntry{Name: name}
+		entries[name] = e
+	}
+	if current {
+		e.Count++
+	} else {
+		e.Previous++
+	}
+}
+
+// sortedEntries returns the entries drifting in the current window, most
+// drift first.
+func sortedEntries(entries map[string]*DigestEntry) []DigestEntry {
+	result := []DigestEntry{}
+	for _, e := range entries {
+		if e.Count > 0 {
+			result = append(result, *e)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// Markdown renders the digest for email or chat.
+func (d *Digest) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Drift digest %s – %s\n\n", d.From.Format(time.DateOnly), d.To.Format(time.DateOnly))
+	fmt.Fprintf(&b, "**%d** drift incidents (%s vs. %d in the previous period)\n", d.Total, change(d.Total, d.PreviousTotal), d.PreviousTotal)
+
+	if len(d.NewSources) > 0 {
+		b.WriteString("\n## New drift sources\n\n| Actor | Kind | Namespace | Drift |\n|---|---|---|---|\n")
+		for i, s := range d.NewSources {
+			if i == digestTopN {
+				break
+			}
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %d |\n", s.Actor, s.Kind, s.Namespace, s.Count)
+		}
+	}
+	writeEntries(&b, "Namespace", d.Namespaces)
+	writeEntries(&b, "Kind", d.Kinds)
+	writeEntries(&b, "Actor", d.Actors)
+	return b.String()
+}
+
+// writeEntries renders the top entries of a dimension as a table.
+func writeEntries(b *strings.Builder, dimension string, entries []DigestEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n## By %s\n\n| %s | Drift | Change |\n|---|---|---|\n", strings.ToLower(dimension), dimension)
+	for i, e := range entries {
+		if i == digestTopN {
+			fmt.Fprintf(b, "| … %d more | | |\n", len(entries)-digestTopN)
+			break
+		}
+		fmt.Fprintf(b, "| %s | %d | %s |\n", e.Name, e.Count, change(e.Count, e.Previous))
+	}
+}
+
+// change renders the difference to the previous window.
+func change(current, previous int) string {
+	switch {
+	case previous == 0 && current > 0:
+		return "new"
+	case current > previous:
+		return fmt.Sprintf("+%d", current-previous)
+	case current < previous:
+		return fmt.Sprintf("-%d", previous-current)
+	}
+	return "±0"
+}
+
+// DigestMessage is posted by the DigestJob. Text is the markdown digest,
+// which Slack incoming webhooks display as the message.
+type DigestMessage struct {
+	Text   string  `json:"text"`
+	Digest *Digest `json:"digest"`
+}
+
+// DigestJob periodically posts a drift digest to a URL.
+type DigestJob struct {
+	Store    *Store
+	URL      string
+	Window   time.Duration
+	Interval time.Duration
+	Client   *http.Client
+	// OnError is called when posting a digest fails, if set
+	OnError func(error)
+}
+
+// Run posts a digest every interval until the context is cancelled.
+func (j *DigestJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := j.Post(ctx); err != nil && j.OnError != nil {
+				j.OnError(err)
+			}
+		}
+	}
+}
+
+// Post posts the digest of the window ending now.
+func (j *DigestJob) Post(ctx context.Context) error {
+	window := j.Window
+	if window == 0 {
+		window = DefaultDigestWindow
+	}
+	digest := j.Store.Digest(time.Now(), window)
+	body, err := json.Marshal(DigestMessage{Text: digest.Markdown(), Digest: digest})
+	if err != nil {
+		return fmt.Errorf("failed to marshal digest: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, j.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := j.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post digest: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to post digest: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+)
+
+// addDetection adds a detected report received at the given time. Reports
+// must be added in the order received.
+func addDetection(s *Store, id, namespace, kind, user string, at time.Time) {
+	s.Add(&v1alpha1.DriftReport{Spec: v1alpha1.DriftReportSpec{
+		ID:      id,
+		Phase:   v1alpha1.DriftReportPhaseDetected,
+		Child:   v1alpha1.ObjectReference{Kind: kind, Namespace: namespace, Name: id},
+		Request: v1alpha1.RequestContext{User: user},
+	}})
+	stored, _ := s.Get(id)
+	stored.ReceivedAt = at
+}
+
+func TestStore_Digest(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	week := DefaultDigestWindow
+	store := NewStore()
+
+	// Before the previous week, ignored
+	addDetection(store, "a", "dev", "Secret", "carol", now.Add(-3*week))
+	// Previous week
+	addDetection(store, "b", "prod", "Deployment", "alice", now.Add(-week-2*time.Hour))
+	addDetection(store, "c", "prod", "Deployment", "alice", now.Add(-week-time.Hour))
+	// This week
+	addDetection(store, "d", "prod", "ConfigMap", "bob", now.Add(-3*time.Hour))
+	addDetection(store, "e", "prod", "ConfigMap", "bob", now.Add(-2*time.Hour))
+	addDetection(store, "f", "prod", "Deployment", "alice", now.Add(-time.Hour))
+	// Repeated report of an open drift is not a new incident
+	store.Add(&v1alpha1.DriftReport{Spec: v1alpha1.DriftReportSpec{ID: "d", Phase: v1alpha1.DriftReportPhaseDetected}})
+	// Resolved drift still counts
+	store.Add(&v1alpha1.DriftReport{Spec: v1alpha1.DriftReportSpec{ID: "f", Phase: v1alpha1.DriftReportPhaseResolved}})
+
+	d := store.Digest(now, week)
+	assert.Equal(t, 3, d.Total)
+	assert.Equal(t, 2, d.PreviousTotal)
+	assert.Equal(t, []DigestEntry{{Name: "prod", Count: 3, Previous: 2}}, d.Namespaces)
+	assert.Equal(t, []DigestEntry{{Name: "ConfigMap", Count: 2}, {Name: "Deployment", Count: 1, Previous: 2}}, d.Kinds)
+	assert.Equal(t, []DigestEntry{{Name: "bob", Count: 2}, {Name: "alice", Count: 1, Previous: 2}}, d.Actors)
+	assert.Equal(t, []DigestSource{{Actor: "bob", Kind: "ConfigMap", Namespace: "prod", Count: 2}}, d.NewSources)
+
+	assert.Equal(t, `# Drift digest 2026-10-09 – 2026-10-16
+
+**3** drift incidents (+1 vs. 2 in the previous period)
+
+## New drift sources
+
+| Actor | Kind | Namespace | Drift |
+|---|---|---|---|
+| `+"`bob`"+` | ConfigMap | prod | 2 |
+
+## By namespace
+
+| Namespace | Drift | Change |
+|---|---|---|
+| prod | 3 | +1 |
+
+## By kind
+
+| Kind | Drift | Change |
+|---|---|---|
+| ConfigMap | 2 | new |
+| Deployment | 1 | -1 |
+
+## By actor
+
+| Actor | Drift | Change |
+|---|---|---|
+| bob | 2 | new |
+| alice | 1 | -1 |
+`, d.Markdown())
+}
+
+func TestServer_Digest(t *testing.T) {
+	server := NewServer()
+	addDetection(server.Store(), "a", "prod", "Deployment", "alice", time.Now().Add(-time.Hour))
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/digest?window=24h", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var digest Digest
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &digest))
+	assert.Equal(t, 1, digest.Total)
+
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/digest?format=markdown", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "# Drift digest")
+
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/digest?window=-1h", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestDigestJob_Post(t *testing.T) {
+	var got DigestMessage
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+	}))
+	defer target.Close()
+
+	store := NewStore()
+	addDetection(store, "a", "prod", "Deployment", "alice", time.Now().Add(-time.Hour))
+
+	job := &DigestJob{Store: store, URL: target.URL}
+	require.NoError(t, job.Post(context.Background()))
+	assert.Contains(t, got.Text, "**1** drift incidents")
+	require.NotNil(t, got.Digest)
+	assert.Equal(t, 1, got.Digest.Total)
+
+	job.URL = target.URL + "/missing"
+	target.Config.Handler = http.NotFoundHandler()
+	assert.Error(t, job.Post(context.Background()))
+}
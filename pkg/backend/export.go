@@ -0,0 +1,245 @@
+package backend
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+)
+
+// DefaultExportWindow is the window of an export without from, a quarter.
+const DefaultExportWindow = 92 * 24 * time.Hour
+
+// Export formats.
+const (
+	ExportCSV     = "csv"
+	ExportParquet = "parquet"
+)
+
+// Export decisions, the outcome of a drifting mutation as reported.
+const (
+	// DecisionDetected is drift admitted with a warning in log mode or
+	// denied in enforce mode.
+	DecisionDetected = "detected"
+	// DecisionOverridden is drift admitted by a kausality.io/override.
+	DecisionOverridden = "overridden"
+	// DecisionSuppressed is drift admitted within a kausality.io/suppress window.
+	DecisionSuppressed = "suppressed"
+	// DecisionApproved is a mutation approved on the parent before it was
+	// reported as drift.
+	DecisionApproved = "approved"
+)
+
+// ExportRow is one drift incident in an export.
+type ExportRow struct {
+	ID               string
+	DetectedAt       time.Time
+	LastSeen         time.Time
+	Occurrences      int64
+	Namespace        string
+	ParentAPIVersion string
+	ParentKind       string
+	ParentName       string
+	ChildAPIVersion  string
+	ChildKind        string
+	ChildName        string
+	Actor            string
+	Operation        string
+	Decision         string
+	// ApprovalReference is the ticket of an override, or the parent whose
+	// kausality.io/approvals approved the drift.
+	ApprovalReference string
+	Justification     string
+	Resolution        string
+	ResolvedAt        *time.Time
+}
+
+// exportColumns are the column names of an export, in ExportRow order.
+var exportColumns = []string{
+	"id", "detected_at", "last_seen", "occurrences", "namespace",
+	"parent_api_version", "parent_kind", "parent_name",
+	"child_api_version", "child_kind", "child_name",
+	"actor", "operation", "decision", "approval_reference", "justification",
+	"resolution", "resolved_at",
+}
+
+// Export returns the drift detected in [from, to), oldest first, with its
+// resolution if known, and approvals of mutations resolved before they were
+// reported. Resolutions are looked up in the bounded history of resolved
+// reports.
+func (s *Store) Export(from, to time.Time) []ExportRow {
+	resolvedBy := map[string]*StoredReport{}
+	var rows []ExportRow
+	for _, resolved := range s.History() {
+		r := resolved.Report.Spec.Resolution
+		if r == nil {
+			continue
+		}
+		for _, id := range resolvedIDs(resolved.Report) {
+			if _, ok := resolvedBy[id]; !ok {
+				resolvedBy[id] = resolved
+			}
+		}
+		// Approved before it was reported, the resolution closed no drift
+		if resolved.Occurrences == 0 && r.Kind == v1alpha1.ResolutionApproved && inRange(resolved.ReceivedAt, from, to) {
+			row := exportRow(resolved, resolved)
+			row.Decision = DecisionApproved
+			row.Occurrences = 0
+			rows = append(rows, row)
+		}
+	}
+
+	for _, detected := range s.Detections(from) {
+		if !detected.ReceivedAt.Before(to) {
+			continue
+		}
+		id := detected.Report.Spec.ID
+		// Occurrences are folded into the latest report of the incident
+		incident := detected
+		if current, ok := s.Get(id); ok {
+			incident = current
+		} else if resolved, ok := resolvedBy[id]; ok {
+			incident = resolved
+		}
+		row := exportRow(detected, resolvedBy[id])
+		row.Occurrences = int64(incident.Occurrences)
+		row.DetectedAt, row.LastSeen = incident.FirstSeen, incident.LastSeen
+		rows = append(rows, row)
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].DetectedAt.Before(rows[j].DetectedAt)
+	})
+	return rows
+}
+
+func inRange(t, from, to time.Time) bool {
+	return !t.Before(from) && t.Before(to)
+}
+
+// exportRow returns the row of a detected report and its resolution, if any.
+func exportRow(detected, resolved *StoredReport) ExportRow {
+	spec := detected.Report.Spec
+	row := ExportRow{
+		ID:               spec.ID,
+		DetectedAt:       detected.ReceivedAt,
+		LastSeen:         detected.ReceivedAt,
+		Occurrences:      1,
+		Namespace:        reportNamespace(detected),
+		ParentAPIVersion: spec.Parent.APIVersion,
+		ParentKind:       spec.Parent.Kind,
+		ParentName:       spec.Parent.Name,
+		ChildAPIVersion:  spec.Child.APIVersion,
+		ChildKind:        spec.Child.Kind,
+		ChildName:        spec.Child.Name,
+		Actor:            spec.Request.User,
+		Operation:        spec.Request.Operation,
+		Decision:         DecisionDetected,
+	}
+	switch {
+	case spec.Override != nil:
+		row.Decision = DecisionOverridden
+		row.ApprovalReference = spec.Override.Ticket
+		row.Justification = spec.Override.Justification
+	case spec.Suppression != nil:
+		row.Decision = DecisionSuppressed
+		row.Justification = spec.Suppression.Message
+	}
+	if resolved != nil && resolved.Report.Spec.Resolution != nil {
+		kind := resolved.Report.Spec.Resolution.Kind
+		row.Resolution = string(kind)
+		row.ResolvedAt = resolved.ResolvedAt
+		if kind == v1alpha1.ResolutionApproved && row.ApprovalReference == "" {
+			row.ApprovalReference = approvalReference(resolved.Report)
+		}
+	}
+	return row
+}
+
+// approvalReference names the parent annotation that approved a drift.
+func approvalReference(report *v1alpha1.DriftReport) string {
+	parent := report.Spec.Parent
+	ref := fmt.Sprintf("%s/%s", parent.Kind, parent.Name)
+	if parent.Namespace != "" {
+		ref = fmt.Sprintf("%s/%s/%s", parent.Kind, parent.Namespace, parent.Name)
+	}
+	if parent.Generation != 0 {
+		ref = fmt.Sprintf("%s@%d", ref, parent.Generation)
+	}
+	return ref + " " + kausalityv1alpha1.ApprovalsAnnotation
+}
+
+// values returns the row's columns as strings, in exportColumns order.
+// Times are RFC 3339 in UTC.
+func (r *ExportRow) values() []string {
+	resolvedAt := ""
+	if r.ResolvedAt != nil {
+		resolvedAt = formatExportTime(*r.ResolvedAt)
+	}
+	return []string{
+		r.ID, formatExportTime(r.DetectedAt), formatExportTime(r.LastSeen), strconv.FormatInt(r.Occurrences, 10), r.Namespace,
+		r.ParentAPIVersion, r.ParentKind, r.ParentName,
+		r.ChildAPIVersion, r.ChildKind, r.ChildName,
+		r.Actor, r.Operation, r.Decision, r.ApprovalReference, r.Justification,
+		r.Resolution, resolvedAt,
+	}
+}
+
+func formatExportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// WriteExportCSV writes rows as CSV with a header line.
+func WriteExportCSV(w io.Writer, rows []ExportRow) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(exportColumns); err != nil {
+		return err
+	}
+	for i := range rows {
+		if err := cw.Write(rows[i].values()); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteExportParquet writes rows as a Parquet file. Times are timestamps in
+// milliseconds, resolved_at is null for unresolved drift.
+func WriteExportParquet(w io.Writer, rows []ExportRow) error {
+	columns := make([]parquetColumn, len(exportColumns))
+	for i, name := range exportColumns {
+		columns[i] = parquetColumn{name: name, physical: parquetByteArray, converted: parquetUTF8}
+		switch name {
+		case "occurrences":
+			columns[i] = parquetColumn{name: name, physical: parquetInt64, converted: parquetNoConversion}
+		case "detected_at", "last_seen", "resolved_at":
+			columns[i] = parquetColumn{name: name, physical: parquetInt64, converted: parquetTimestampMillis, optional: true}
+		}
+	}
+	for _, r := range rows {
+		for i, v := range r.values() {
+			col := &columns[i]
+			switch {
+			case col.name == "occurrences":
+				col.values = append(col.values, r.Occurrences)
+			case col.converted == parquetTimestampMillis && v == "":
+				col.values = append(col.values, nil)
+			case col.converted == parquetTimestampMillis:
+				t, _ := time.Parse(time.RFC3339, v)
+				col.values = append(col.values, t.UnixMilli())
+			default:
+				col.values = append(col.values, v)
+			}
+		}
+	}
+	return writeParquet(w, len(rows), columns)
+}
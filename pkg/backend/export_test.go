@@ -0,0 +1,440 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+)
+
+func exportStore(now time.Time) *Store {
+	store := NewStore()
+	addDetection(store, "old", "prod", "ConfigMap", "carol", now.Add(-2*DefaultExportWindow))
+	addDetection(store, "a", "prod", "Deployment", "alice", now.Add(-2*time.Hour))
+	a, _ := store.Get("a")
+	a.Report.Spec.Parent = v1alpha1.ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "prod", Name: "web"}
+	addDetection(store, "b", "prod", "ConfigMap", "bob", now.Add(-time.Hour))
+	b, _ := store.Get("b")
+	b.Report.Spec.Override = &v1alpha1.Override{Justification: "hotfix", Ticket: "INC-42"}
+	// a was approved on its parent
+	store.Add(&v1alpha1.DriftReport{Spec: v1alpha1.DriftReportSpec{
+		ID:         "a-resolved",
+		Phase:      v1alpha1.DriftReportPhaseResolved,
+		Parent:     v1alpha1.ObjectReference{Kind: "Deployment", Namespace: "prod", Name: "web", Generation: 3},
+		Resolution: &v1alpha1.Resolution{Kind: v1alpha1.ResolutionApproved, DetectedIDs: []string{"a"}},
+	}})
+	return store
+}
+
+func TestStore_Export(t *testing.T) {
+	now := time.Now()
+	rows := exportStore(now).Export(now.Add(-DefaultExportWindow), now)
+	require.Len(t, rows, 2)
+
+	a := rows[0]
+	assert.Equal(t, "a", a.ID)
+	assert.Equal(t, "alice", a.Actor)
+	assert.Equal(t, DecisionDetected, a.Decision)
+	assert.Equal(t, "Deployment", a.ParentKind)
+	assert.Equal(t, "web", a.ParentName)
+	assert.Equal(t, string(v1alpha1.ResolutionApproved), a.Resolution)
+	assert.Equal(t, "Deployment/prod/web@3 kausality.io/approvals", a.ApprovalReference)
+	assert.NotNil(t, a.ResolvedAt)
+	assert.Equal(t, int64(1), a.Occurrences)
+
+	b := rows[1]
+	assert.Equal(t, DecisionOverridden, b.Decision)
+	assert.Equal(t, "INC-42", b.ApprovalReference)
+	assert.Equal(t, "hotfix", b.Justification)
+	assert.Empty(t, b.Resolution)
+	assert.Nil(t, b.ResolvedAt)
+
+	assert.Empty(t, exportStore(now).Export(now, now.Add(time.Hour)))
+}
+
+func TestWriteExportCSV(t *testing.T) {
+	at := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	require.NoError(t, WriteExportCSV(&buf, []ExportRow{{
+		ID: "a", DetectedAt: at, LastSeen: at, Occurrences: 2, Namespace: "prod",
+		ChildKind: "Deployment", ChildName: "web", Actor: "alice", Decision: DecisionDetected,
+	}}))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, exportColumns, records[0])
+	assert.Equal(t, []string{
+		"a", "2026-10-16T09:00:00Z", "2026-10-16T09:00:00Z", "2", "prod",
+		"", "", "", "", "Deployment", "web", "alice", "", "detected", "", "", "", "",
+	}, records[1])
+}
+
+// updateGolden rewrites the golden files of the tests instead of comparing.
+var updateGolden = flag.Bool("update", false, "update golden files")
+
+func TestWriteExportParquet(t *testing.T) {
+	at := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	require.NoError(t, WriteExportParquet(&buf, []ExportRow{
+		{ID: "a", DetectedAt: at, LastSeen: at, Occurrences: 2, Actor: "alice", ResolvedAt: &at},
+		{ID: "b", DetectedAt: at, LastSeen: at.Add(time.Minute), Occurrences: 1, Actor: "bob"},
+	}))
+
+	// The encoding is pinned by a golden file, so that changes of the
+	// writer are reviewed as changes of the file
+	golden := filepath.Join("testdata", "export.parquet")
+	if *updateGolden {
+		require.NoError(t, os.WriteFile(golden, buf.Bytes(), 0o644))
+	}
+	want, err := os.ReadFile(golden)
+	require.NoError(t, err)
+	assert.Equal(t, want, buf.Bytes(), "run go test -update to update %s", golden)
+
+	// and its rows read back
+	file, err := readParquet(want)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), file.numRows)
+	require.Len(t, file.columns, len(exportColumns))
+	columns := map[string]parquetReadColumn{}
+	for i, col := range file.columns {
+		assert.Equal(t, exportColumns[i], col.name)
+		columns[col.name] = col
+	}
+	ms := at.UnixMilli()
+	assert.Equal(t, parquetReadColumn{name: "id", physical: parquetByteArray, values: []any{"a", "b"}}, columns["id"])
+	assert.Equal(t, parquetReadColumn{name: "actor", physical: parquetByteArray, values: []any{"alice", "bob"}}, columns["actor"])
+	assert.Equal(t, parquetReadColumn{name: "namespace", physical: parquetByteArray, values: []any{"", ""}}, columns["namespace"])
+	assert.Equal(t, parquetReadColumn{name: "occurrences", physical: parquetInt64, values: []any{int64(2), int64(1)}}, columns["occurrences"])
+	assert.Equal(t, parquetReadColumn{name: "last_seen", physical: parquetInt64, optional: true, values: []any{ms, ms + 60000}}, columns["last_seen"])
+	assert.Equal(t, parquetReadColumn{name: "resolved_at", physical: parquetInt64, optional: true, values: []any{ms, nil}}, columns["resolved_at"])
+
+	// Truncated files are errors, not panics
+	for n := 0; n < len(want); n += 7 {
+		_, err := readParquet(append(append([]byte{}, want[:n]...), want[len(want)-8:]...))
+		assert.Error(t, err, "truncated to %d bytes", n)
+	}
+}
+
+// parquetFile is a flat Parquet file read by readParquet.
+type parquetFile struct {
+	numRows int64
+	columns []parquetReadColumn
+}
+
+// parquetReadColumn is a column of a flat Parquet file, with nil for nulls.
+type parquetReadColumn struct {
+	name     string
+	physical int64
+	optional bool
+	values   []any
+}
+
+// readParquet reads flat Parquet files of one row group with one
+// PLAIN-encoded data page per column, following parquet-format rather than
+// the writer.
+func readParquet(data []byte) (*parquetFile, error) {
+	if len(data) < 12 || string(data[:4]) != parquetMagic || string(data[len(data)-4:]) != parquetMagic {
+		return nil, fmt.Errorf("not a parquet file")
+	}
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	if footerLen > len(data)-12 {
+		return nil, fmt.Errorf("footer length %d exceeds file", footerLen)
+	}
+	meta, err := (&thriftReader{buf: data[len(data)-8-footerLen : len(data)-8]}).readStruct()
+	if err != nil {
+		return nil, fmt.Errorf("file metadata: %w", err)
+	}
+
+	file := &parquetFile{}
+	var schema, rowGroups, chunks []any
+	if err := fields(meta, field(3, &file.numRows), field(2, &schema), field(4, &rowGroups)); err != nil {
+		return nil, fmt.Errorf("file metadata: %w", err)
+	}
+	if len(rowGroups) != 1 {
+		return nil, fmt.Errorf("%d row groups", len(rowGroups))
+	}
+	if err := fields(rowGroups[0], field(1, &chunks)); err != nil {
+		return nil, fmt.Errorf("row group: %w", err)
+	}
+	if len(schema) != len(chunks)+1 {
+		return nil, fmt.Errorf("%d schema elements for %d columns", len(schema), len(chunks))
+	}
+
+	for i, chunk := range chunks {
+		var col parquetReadColumn
+		var name []byte
+		var repetition int64
+		if err := fields(schema[i+1], field(4, &name), field(1, &col.physical), field(3, &repetition)); err != nil {
+			return nil, fmt.Errorf("schema element %d: %w", i+1, err)
+		}
+		col.name, col.optional = string(name), repetition == parquetOptional
+
+		var columnMeta map[int16]any
+		var offset int64
+		if err := fields(chunk, field(3, &columnMeta)); err != nil {
+			return nil, fmt.Errorf("column %s: %w", col.name, err)
+		}
+		if err := fields(columnMeta, field(9, &offset)); err != nil {
+			return nil, fmt.Errorf("column %s: %w", col.name, err)
+		}
+		if offset < 4 || offset >= int64(len(data)) {
+			return nil, fmt.Errorf("column %s: page offset %d out of file", col.name, offset)
+		}
+		page := &thriftReader{buf: data[offset:]}
+		header, err := page.readStruct()
+		if err != nil {
+			return nil, fmt.Errorf("column %s: page header: %w", col.name, err)
+		}
+		var size, numValues int64
+		var dataPage map[int16]any
+		if err := fields(header, field(3, &size), field(5, &dataPage)); err != nil {
+			return nil, fmt.Errorf("column %s: page header: %w", col.name, err)
+		}
+		if err := fields(dataPage, field(1, &numValues)); err != nil {
+			return nil, fmt.Errorf("column %s: data page header: %w", col.name, err)
+		}
+		start := offset + int64(page.pos)
+		if size < 0 || start+size > int64(len(data)) {
+			return nil, fmt.Errorf("column %s: page of %d bytes exceeds file", col.name, size)
+		}
+		col.values, err = readPlainPage(data[start:start+size], col.physical, col.optional, int(numValues))
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", col.name, err)
+		}
+		file.columns = append(file.columns, col)
+	}
+	return file, nil
+}
+
+// readPlainPage decodes the definition levels of optional columns, RLE runs
+// of bit width 1, and the PLAIN-encoded values of a data page.
+func readPlainPage(page []byte, physical int64, optional bool, numValues int) ([]any, error) {
+	defined := make([]bool, 0, numValues)
+	if optional {
+		if len(page) < 4 {
+			return nil, fmt.Errorf("truncated definition levels")
+		}
+		n := int(binary.LittleEndian.Uint32(page))
+		if n > len(page)-4 {
+			return nil, fmt.Errorf("definition levels of %d bytes exceed page", n)
+		}
+		levels := &thriftReader{buf: page[4 : 4+n]}
+		for levels.pos < len(levels.buf) {
+			header, err := levels.uvarint()
+			if err != nil {
+				return nil, err
+			}
+			if header&1 != 0 {
+				return nil, fmt.Errorf("bit-packed definition levels")
+			}
+			if levels.pos >= len(levels.buf) {
+				return nil, fmt.Errorf("truncated definition level run")
+			}
+			level := levels.buf[levels.pos]
+			levels.pos++
+			for range header >> 1 {
+				defined = append(defined, level == 1)
+			}
+		}
+		page = page[4+n:]
+	} else {
+		for range numValues {
+			defined = append(defined, true)
+		}
+	}
+	if len(defined) != numValues {
+		return nil, fmt.Errorf("%d definition levels for %d values", len(defined), numValues)
+	}
+
+	values := make([]any, numValues)
+	for i := range values {
+		if !defined[i] {
+			continue
+		}
+		switch physical {
+		case parquetByteArray:
+			if len(page) < 4 || int(binary.LittleEndian.Uint32(page)) > len(page)-4 {
+				return nil, fmt.Errorf("truncated value %d", i)
+			}
+			n := int(binary.LittleEndian.Uint32(page))
+			values[i], page = string(page[4:4+n]), page[4+n:]
+		case parquetInt64:
+			if len(page) < 8 {
+				return nil, fmt.Errorf("truncated value %d", i)
+			}
+			values[i], page = int64(binary.LittleEndian.Uint64(page)), page[8:]
+		default:
+			return nil, fmt.Errorf("unsupported physical type %d", physical)
+		}
+	}
+	if len(page) != 0 {
+		return nil, fmt.Errorf("%d bytes after the values", len(page))
+	}
+	return values, nil
+}
+
+// thriftReader decodes Thrift compact structs into maps of field ids to
+// int64, []byte, []any or nested maps.
+type thriftReader struct {
+	buf []byte
+	pos int
+}
+
+func (r *thriftReader) uvarint() (uint64, error) {
+	v, n := binary.Uvarint(r.buf[r.pos:])
+	if n <= 0 {
+		return 0, fmt.Errorf("invalid varint at %d", r.pos)
+	}
+	r.pos += n
+	return v, nil
+}
+
+func (r *thriftReader) byte() (byte, error) {
+	if r.pos >= len(r.buf) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	r.pos++
+	return r.buf[r.pos-1], nil
+}
+
+func (r *thriftReader) value(typ byte) (any, error) {
+	switch typ {
+	case thriftI32, thriftI64:
+		v, err := r.uvarint()
+		return int64(v>>1) ^ -int64(v&1), err
+	case thriftBinary:
+		n, err := r.uvarint()
+		if err != nil {
+			return nil, err
+		}
+		if n > uint64(len(r.buf)-r.pos) {
+			return nil, fmt.Errorf("binary of %d bytes exceeds buffer", n)
+		}
+		r.pos += int(n)
+		return r.buf[r.pos-int(n) : r.pos], nil
+	case thriftStruct:
+		return r.readStruct()
+	case 9:
+		header, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		size := uint64(header >> 4)
+		if size == 15 {
+			if size, err = r.uvarint(); err != nil {
+				return nil, err
+			}
+		}
+		if size > uint64(len(r.buf)-r.pos) {
+			return nil, fmt.Errorf("list of %d elements exceeds buffer", size)
+		}
+		list := make([]any, size)
+		for i := range list {
+			if list[i], err = r.value(header & 0x0f); err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	}
+	return nil, fmt.Errorf("unsupported thrift type %d", typ)
+}
+
+func (r *thriftReader) readStruct() (map[int16]any, error) {
+	fields := map[int16]any{}
+	var id int16
+	for {
+		b, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		if b == 0 {
+			return fields, nil
+		}
+		if delta := int16(b >> 4); delta != 0 {
+			id += delta
+		} else {
+			v, err := r.uvarint()
+			if err != nil {
+				return nil, err
+			}
+			id = int16(v>>1) ^ -int16(v&1)
+		}
+		if fields[id], err = r.value(b & 0x0f); err != nil {
+			return nil, fmt.Errorf("field %d: %w", id, err)
+		}
+	}
+}
+
+// structField assigns a field of a decoded struct to a typed target.
+type structField func(map[int16]any) error
+
+// field returns a structField assigning field id to target.
+func field[T any](id int16, target *T) structField {
+	return func(s map[int16]any) error {
+		v, ok := s[id].(T)
+		if !ok {
+			return fmt.Errorf("field %d is %T, not %T", id, s[id], *target)
+		}
+		*target = v
+		return nil
+	}
+}
+
+// fields assigns the fields of s, a decoded struct.
+func fields(s any, assign ...structField) error {
+	m, ok := s.(map[int16]any)
+	if !ok {
+		return fmt.Errorf("%T is no struct", s)
+	}
+	for _, a := range assign {
+		if err := a(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestServer_ExportDrifts(t *testing.T) {
+	now := time.Now()
+	s := NewServer()
+	s.store = exportStore(now)
+	handler := s.Handler()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/drifts/export", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Header().Get("Content-Disposition"), `.csv"`)
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	require.NoError(t, err)
+	assert.Len(t, records, 3)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/drifts/export?format=parquet&from="+now.Add(-90*time.Minute).UTC().Format(time.RFC3339), nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/vnd.apache.parquet", rec.Header().Get("Content-Type"))
+	assert.Equal(t, parquetMagic, rec.Body.String()[:4])
+
+	for _, query := range []string{"format=xlsx", "from=yesterday", "to=2026-10-16", "from=2026-10-16T00:00:00Z&to=2026-10-15T00:00:00Z"} {
+		req = httptest.NewRequest(http.MethodGet, "/api/v1/drifts/export?"+query, nil)
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
@@ -0,0 +1,150 @@
+package backend
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+)
+
+// SortMode determines the order of reports in the TUI list
+type SortMode int
+
+const (
+	// SortByReceived orders reports newest first
+	SortByReceived SortMode = iota
+	// SortBySeverity orders reports by severity, then newest first
+	SortBySeverity
+)
+
+// String returns the display name of the sort mode
+func (s SortMode) String() string {
+	if s == SortBySeverity {
+		return "severity"
+	}
+	return "received"
+}
+
+// Filter selects the drift reports shown in the TUI.
+// Empty fields match everything.
+type Filter struct {
+	Namespace string
+	Kind      string
+	User      string
+	// Query is a case-insensitive free-text search over names, kinds, users and the report ID
+	Query string
+}
+
+// Active returns true if any filter field is set
+func (f Filter) Active() bool {
+	return f.Namespace != "" || f.Kind != "" || f.User != "" || f.Query != ""
+}
+
+// Matches returns true if the report passes the filter
+func (f Filter) Matches(r *StoredReport) bool {
+	spec := r.Report.Spec
+	if f.Namespace != "" && reportNamespace(r) != f.Namespace {
+		return false
+	}
+	if f.Kind != "" && spec.Child.Kind != f.Kind {
+		return false
+	}
+	if f.User != "" && spec.Request.User != f.User {
+		return false
+	}
+	if f.Query != "" {
+		haystack := strings.ToLower(strings.Join([]string{
+			spec.ID,
+			spec.Child.Kind, spec.Child.Namespace, spec.Child.Name,
+			spec.Parent.Kind, spec.Parent.Namespace, spec.Parent.Name,
+			spec.Request.User, spec.Request.FieldManager, spec.Request.Operation,
+		}, " "))
+		if !strings.Contains(haystack, strings.ToLower(f.Query)) {
+			return false
+		}
+	}
+	return true
+}
+
+// filterAndSort returns the reports matching the filter in the given order.
+// The input slice is not modified.
+func filterAndSort(items []*StoredReport, f Filter, mode SortMode) []*StoredReport {
+	result := make([]*StoredReport, 0, len(items))
+	for _, r := range items {
+		if f.Matches(r) {
+			result = append(result, r)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if mode == SortBySeverity {
+			if sa, sb := severity(a.Report), severity(b.Report); sa != sb {
+				return sa > sb
+			}
+		}
+		if !a.ReceivedAt.Equal(b.ReceivedAt) {
+			return a.ReceivedAt.After(b.ReceivedAt)
+		}
+		return a.Report.Spec.ID < b.Report.Spec.ID
+	})
+	return result
+}
+
+// severity ranks a drift report: deletions outrank updates, which outrank
+// creations. Dry-run requests rank below all real mutations.
+func severity(report *v1alpha1.DriftReport) int {
+	var s int
+	switch report.Spec.Request.Operation {
+	case "DELETE":
+		s = 3
+	case "UPDATE":
+		s = 2
+	case "CREATE":
+		s = 1
+	}
+	if !report.Spec.Request.DryRun {
+		s += 10
+	}
+	return s
+}
+
+// reportNamespace returns the namespace of the drifting child, falling back to the parent's.
+func reportNamespace(r *StoredReport) string {
+	if ns := r.Report.Spec.Child.Namespace; ns != "" {
+		return ns
+	}
+	return r.Report.Spec.Parent.Namespace
+}
+
+// distinctValues returns the sorted, non-empty values of fn over the reports.
+func distinctValues(items []*StoredReport, fn func(*StoredReport) string) []string {
+	seen := make(map[string]bool)
+	var values []string
+	for _, r := range items {
+		v := fn(r)
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		values = append(values, v)
+	}
+	sort.Strings(values)
+	return values
+}
+
+// nextValue cycles through values: "" (all) -> values[0] -> ... -> "" again.
+func nextValue(values []string, current string) string {
+	if current == "" {
+		if len(values) == 0 {
+			return ""
+		}
+		return values[0]
+	}
+	for i, v := range values {
+		if v == current && i+1 < len(values) {
+			return values[i+1]
+		}
+	}
+	return ""
+}
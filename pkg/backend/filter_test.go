@@ -0,0 +1,92 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+)
+
+func testReport(id, ns, kind, user, op string, dryRun bool, received time.Time) *StoredReport {
+	return &StoredReport{
+		Report: &v1alpha1.DriftReport{
+			Spec: v1alpha1.DriftReportSpec{
+				ID:     id,
+				Phase:  v1alpha1.DriftReportPhaseDetected,
+				Parent: v1alpha1.ObjectReference{Kind: "Deployment", Namespace: ns, Name: "web"},
+				Child:  v1alpha1.ObjectReference{Kind: kind, Namespace: ns, Name: id},
+				Request: v1alpha1.RequestContext{
+					User:      user,
+					Operation: op,
+					DryRun:    dryRun,
+				},
+			},
+		},
+		ReceivedAt: received,
+	}
+}
+
+func ids(items []*StoredReport) []string {
+	var result []string
+	for _, r := range items {
+		result = append(result, r.Report.Spec.ID)
+	}
+	return result
+}
+
+func TestFilterAndSort(t *testing.T) {
+	now := time.Now()
+	items := []*StoredReport{
+		testReport("a", "prod", "ReplicaSet", "alice", "UPDATE", false, now.Add(-3*time.Minute)),
+		testReport("b", "prod", "ConfigMap", "bob", "DELETE", false, now.Add(-2*time.Minute)),
+		testReport("c", "staging", "ReplicaSet", "alice", "CREATE", false, now.Add(-1*time.Minute)),
+		testReport("d", "staging", "ConfigMap", "bob", "DELETE", true, now),
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		sort   SortMode
+		want   []string
+	}{
+		{name: "no filter, newest first", want: []string{"d", "c", "b", "a"}},
+		{name: "by severity", sort: SortBySeverity, want: []string{"b", "a", "c", "d"}},
+		{name: "namespace", filter: Filter{Namespace: "prod"}, want: []string{"b", "a"}},
+		{name: "kind", filter: Filter{Kind: "ConfigMap"}, want: []string{"d", "b"}},
+		{name: "user", filter: Filter{User: "alice"}, want: []string{"c", "a"}},
+		{name: "combined", filter: Filter{Namespace: "staging", User: "bob"}, want: []string{"d"}},
+		{name: "query is case-insensitive", filter: Filter{Query: "STAGING"}, want: []string{"d", "c"}},
+		{name: "query matches operation", filter: Filter{Query: "delete"}, want: []string{"d", "b"}},
+		{name: "no match", filter: Filter{Query: "nothing"}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filterAndSort(items, tt.filter, tt.sort)
+			assert.Equal(t, tt.want, ids(got))
+		})
+	}
+
+	// Input is not reordered
+	assert.Equal(t, []string{"a", "b", "c", "d"}, ids(items))
+}
+
+func TestNextValue(t *testing.T) {
+	values := []string{"a", "b"}
+	assert.Equal(t, "a", nextValue(values, ""))
+	assert.Equal(t, "b", nextValue(values, "a"))
+	assert.Equal(t, "", nextValue(values, "b"), "wraps around to all")
+	assert.Equal(t, "", nextValue(values, "gone"), "unknown value resets")
+	assert.Equal(t, "", nextValue(nil, ""))
+}
+
+func TestDistinctValues(t *testing.T) {
+	items := []*StoredReport{
+		testReport("a", "prod", "ReplicaSet", "alice", "UPDATE", false, time.Now()),
+		testReport("b", "", "ConfigMap", "bob", "UPDATE", false, time.Now()),
+		testReport("c", "dev", "ReplicaSet", "alice", "UPDATE", false, time.Now()),
+	}
+	assert.Equal(t, []string{"dev", "prod"}, distinctValues(items, reportNamespace))
+}
@@ -0,0 +1,529 @@
+// Package gatekeeper publishes unresolved drift as Gatekeeper constraint
+// violations, so clusters aggregating Gatekeeper audit results in their
+// compliance dashboards see drift in the same place.
+//
+// The exporter maintains the KausalityDrift ConstraintTemplate and one
+// constraint listing the children with unresolved drift in its parameters.
+// Gatekeeper's audit reports each of them as a violation: in the
+// constraint's status, in its violation metrics and in audit exports. The
+// constraint's enforcement action is dryrun or warn, so admission is not
+// affected. The constraint is the exporter's state: unresolved drift is
+// kept across restarts.
+package gatekeeper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/yaml"
+
+	"github.com/kausality-io/kausality/pkg/callback"
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+)
+
+// Enforcement actions of the constraint.
+const (
+	// EnforcementActionDryRun reports drift in audit results only.
+	EnforcementActionDryRun = "dryrun"
+	// EnforcementActionWarn also warns when drifted objects are written.
+	EnforcementActionWarn = "warn"
+)
+
+// ConstraintKind is the kind of the constraint defined by the template.
+const ConstraintKind = "KausalityDrift"
+
+var (
+	// TemplateGVK is the kind of Gatekeeper constraint templates.
+	TemplateGVK = schema.GroupVersionKind{Group: "templates.gatekeeper.sh", Version: "v1", Kind: "ConstraintTemplate"}
+	// ConstraintGVK is the kind of the drift constraint.
+	ConstraintGVK = schema.GroupVersionKind{Group: "constraints.gatekeeper.sh", Version: "v1beta1", Kind: ConstraintKind}
+)
+
+const (
+	// templateName is the name Gatekeeper requires: the lowercase constraint kind.
+	templateName = "kausalitydrift"
+
+	// maxDrifts bounds the drifts in the constraint, keeping it well below
+	// the object size limit. The oldest drifts are dropped first.
+	maxDrifts = 1000
+)
+
+// template is the ConstraintTemplate. A drift is a violation of the object
+// it names; the group is compared, not the version, as Gatekeeper audits
+// the preferred version of a resource.
+const template = `
+apiVersion: templates.gatekeeper.sh/v1
+kind: ConstraintTemplate
+metadata:
+  name: kausalitydrift
+  labels:
+    app.kubernetes.io/managed-by: kausality
+  annotations:
+    description: Unresolved drift detected by kausality, published by kausality-backend-gatekeeper.
+spec:
+  crd:
+    spec:
+      names:
+        kind: KausalityDrift
+      validation:
+        openAPIV3Schema:
+          type: object
+          properties:
+            drifts:
+              type: array
+              items:
+                type: object
+                properties:
+                  id: {type: string}
+                  apiVersion: {type: string}
+                  kind: {type: string}
+                  namespace: {type: string}
+                  name: {type: string}
+                  parent:
+                    type: object
+                    properties:
+                      apiVersion: {type: string}
+                      kind: {type: string}
+                      namespace: {type: string}
+                      name: {type: string}
+                  user: {type: string}
+                  operation: {type: string}
+                  detectedAt: {type: string}
+                  message: {type: string}
+  targets:
+  - target: admission.k8s.gatekeeper.sh
+    rego: |
+      package kausalitydrift
+
+      violation[{"msg": drift.message, "details": {"id": drift.id, "parent": drift.parent, "user": drift.user}}] {
+        drift := input.parameters.drifts[_]
+        obj := input.review.object
+        drift.kind == obj.kind
+        drift.name == obj.metadata.name
+        drift.namespace == object.get(obj.metadata, "namespace", "")
+        group(drift.apiVersion) == group(obj.apiVersion)
+      }
+
+      group(apiVersion) = g {
+        contains(apiVersion, "/")
+        g := split(apiVersion, "/")[0]
+      }
+
+      group(apiVersion) = "" {
+        not contains(apiVersion, "/")
+      }
+`
+
+// Config configures the Exporter.
+type Config struct {
+	// Client writes the ConstraintTemplate and the constraint.
+	Client client.Client
+	// Name is the name of the constraint. Defaults to "kausality-drift".
+	Name string
+	// EnforcementAction is EnforcementActionDryRun (default) or EnforcementActionWarn.
+	EnforcementAction string
+	// SyncInterval is how often changes are written to the constraint. Defaults to 10s.
+	SyncInterval time.Duration
+	// Log receives errors and sync notifications.
+	Log logr.Logger
+}
+
+// ObjectReference identifies an object in the constraint parameters.
+type ObjectReference struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+}
+
+// Drift is an unresolved drift in the constraint parameters, a violation
+// of the drifted child.
+type Drift struct {
+	// ID is the ID of the last drift report.
+	ID string `json:"id"`
+	ObjectReference
+	Parent ObjectReference `json:"parent"`
+	// User and Operation are the request of the last drift report.
+	User      string `json:"user,omitempty"`
+	Operation string `json:"operation,omitempty"`
+	// DetectedAt is when the drift was first reported, in RFC 3339.
+	DetectedAt string `json:"detectedAt"`
+	// Message is the violation message.
+	Message string `json:"message"`
+}
+
+// resolutionID returns the ID of the resolutions of the drift.
+func (d *Drift) resolutionID() string {
+	return callback.GenerateResolutionID(d.Parent.ref(), d.ref())
+}
+
+func (r ObjectReference) ref() v1alpha1.ObjectReference {
+	return v1alpha1.ObjectReference{APIVersion: r.APIVersion, Kind: r.Kind, Namespace: r.Namespace, Name: r.Name}
+}
+
+func reference(ref v1alpha1.ObjectReference) ObjectReference {
+	return ObjectReference{APIVersion: ref.APIVersion, Kind: ref.Kind, Namespace: ref.Namespace, Name: ref.Name}
+}
+
+// Exporter tracks unresolved drift from DriftReports and writes it to a
+// Gatekeeper constraint.
+type Exporter struct {
+	cfg Config
+	log logr.Logger
+	now func() time.Time
+
+	mu sync.Mutex
+	// drifts are the unresolved drifts by resolution ID, one per child and parent.
+	drifts map[string]*Drift
+	// dirty is set when drifts changed since the last sync.
+	dirty bool
+	// loaded is set once the drifts of an existing constraint were read.
+	loaded bool
+	// resolved are the drifts resolved before loaded, by resolution ID.
+	resolved map[string]bool
+}
+
+// New creates an Exporter with defaults applied.
+func New(cfg Config) (*Exporter, error) {
+	if cfg.Client == nil {
+		return nil, errors.New("client is required")
+	}
+	if cfg.Name == "" {
+		cfg.Name = "kausality-drift"
+	}
+	switch cfg.EnforcementAction {
+	case "":
+		cfg.EnforcementAction = EnforcementActionDryRun
+	case EnforcementActionDryRun, EnforcementActionWarn:
+	default:
+		return nil, fmt.Errorf("invalid enforcement action %q: must be %q or %q", cfg.EnforcementAction, EnforcementActionDryRun, EnforcementActionWarn)
+	}
+	if cfg.SyncInterval == 0 {
+		cfg.SyncInterval = 10 * time.Second
+	}
+
+	return &Exporter{
+		cfg:      cfg,
+		log:      cfg.Log,
+		now:      time.Now,
+		drifts:   make(map[string]*Drift),
+		dirty:    true,
+		resolved: make(map[string]bool),
+	}, nil
+}
+
+// Handler returns the HTTP handler receiving DriftReports.
+func (e *Exporter) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /webhook", e.handleWebhook)
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"status":"ok","drifts":%d}`, e.Len())
+	})
+	return mux
+}
+
+// handleWebhook records a DriftReport. Reports are acknowledged once
+// recorded; changes not yet synced are lost if the exporter is killed.
+func (e *Exporter) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	var report v1alpha1.DriftReport
+	if err := json.Unmarshal(body, &report); err != nil || report.Spec.ID == "" {
+		http.Error(w, "invalid DriftReport", http.StatusBadRequest)
+		return
+	}
+	e.Add(&report)
+
+	response := v1alpha1.DriftReportResponse{Acknowledged: true}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// Add records a drift report: detected drift becomes a violation of the
+// child until a resolution for the parent and child is added.
+func (e *Exporter) Add(report *v1alpha1.DriftReport) {
+	spec := report.Spec
+	key := callback.GenerateResolutionID(spec.Parent, spec.Child)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if spec.Phase == v1alpha1.DriftReportPhaseResolved {
+		if !e.loaded {
+			e.resolved[key] = true
+		}
+		if _, ok := e.drifts[key]; ok {
+			delete(e.drifts, key)
+			e.dirty = true
+		}
+		return
+	}
+
+	detectedAt := e.now().UTC().Format(time.RFC3339)
+	if existing, ok := e.drifts[key]; ok {
+		detectedAt = existing.DetectedAt
+	}
+	d := &Drift{
+		ID:              spec.ID,
+		ObjectReference: reference(spec.Child),
+		Parent:          reference(spec.Parent),
+		User:            spec.Request.User,
+		Operation:       spec.Request.Operation,
+		DetectedAt:      detectedAt,
+	}
+	d.Message = message(d)
+	e.drifts[key] = d
+	e.dirty = true
+	delete(e.resolved, key)
+}
+
+// message is the violation message of a drift.
+func message(d *Drift) string {
+	msg := fmt.Sprintf("unresolved drift %s: %s of %s %s", d.ID, d.Operation, d.Kind, objectName(d.ObjectReference))
+	if d.User != "" {
+		msg += " by " + d.User
+	}
+	return msg + fmt.Sprintf(", not requested by parent %s %s", d.Parent.Kind, objectName(d.Parent))
+}
+
+func objectName(ref ObjectReference) string {
+	if ref.Namespace == "" {
+		return ref.Name
+	}
+	return ref.Namespace + "/" + ref.Name
+}
+
+// Len returns the number of unresolved drifts.
+func (e *Exporter) Len() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.drifts)
+}
+
+// Start syncs the constraint every SyncInterval until the context is
+// cancelled, then syncs once more.
+func (e *Exporter) Start(ctx context.Context) error {
+	ticker := time.NewTicker(e.cfg.SyncInterval)
+	defer ticker.Stop()
+	for {
+		if err := e.Sync(ctx); err != nil {
+			e.log.Error(err, "failed to export drift to Gatekeeper, will retry", "drifts", e.Len())
+		}
+		select {
+		case <-ctx.Done():
+			syncCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			return e.Sync(syncCtx)
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sync writes the ConstraintTemplate and, if the drifts changed, the
+// constraint. The first sync reads the drifts of an existing constraint
+// first. The constraint can only be written once Gatekeeper has created
+// its CRD from the template; until then Sync fails and is retried.
+func (e *Exporter) Sync(ctx context.Context) error {
+	if err := e.applyTemplate(ctx); err != nil {
+		return fmt.Errorf("failed to write constraint template: %w", err)
+	}
+	if err := e.load(ctx); err != nil {
+		return fmt.Errorf("failed to read constraint: %w", err)
+	}
+
+	e.mu.Lock()
+	if !e.dirty {
+		e.mu.Unlock()
+		return nil
+	}
+	drifts := e.sortedDrifts()
+	e.dirty = false
+	e.mu.Unlock()
+
+	if err := e.applyConstraint(ctx, drifts); err != nil {
+		e.mu.Lock()
+		e.dirty = true
+		e.mu.Unlock()
+		return fmt.Errorf("failed to write constraint: %w", err)
+	}
+	e.log.Info("exported drift to Gatekeeper", "constraint", e.cfg.Name, "drifts", len(drifts))
+	return nil
+}
+
+// sortedDrifts returns the newest maxDrifts drifts sorted by detection time,
+// kind and name.
+// Must be called with mu held.
+func (e *Exporter) sortedDrifts() []*Drift {
+	drifts := make([]*Drift, 0, len(e.drifts))
+	for _, d := range e.drifts {
+		drifts = append(drifts, d)
+	}
+	sort.Slice(drifts, func(i, j int) bool {
+		if drifts[i].DetectedAt != drifts[j].DetectedAt {
+			return drifts[i].DetectedAt < drifts[j].DetectedAt
+		}
+		if drifts[i].Kind != drifts[j].Kind {
+			return drifts[i].Kind < drifts[j].Kind
+		}
+		return objectName(drifts[i].ObjectReference) < objectName(drifts[j].ObjectReference)
+	})
+	if len(drifts) > maxDrifts {
+		e.log.Info("dropping oldest drifts from the constraint", "dropped", len(drifts)-maxDrifts)
+		drifts = drifts[len(drifts)-maxDrifts:]
+	}
+	return drifts
+}
+
+// load reads the drifts of an existing constraint once, so unresolved drift
+// survives restarts. Drifts reported or resolved since the exporter started win.
+func (e *Exporter) load(ctx context.Context) error {
+	e.mu.Lock()
+	loaded := e.loaded
+	e.mu.Unlock()
+	if loaded {
+		return nil
+	}
+
+	constraint := &unstructured.Unstructured{}
+	constraint.SetGroupVersionKind(ConstraintGVK)
+	err := e.cfg.Client.Get(ctx, client.ObjectKey{Name: e.cfg.Name}, constraint)
+	if apierrors.IsNotFound(err) {
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+	existing, err := constraintDrifts(constraint)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, d := range existing {
+		key := d.resolutionID()
+		if _, ok := e.drifts[key]; !ok && !e.resolved[key] {
+			e.drifts[key] = d
+		}
+	}
+	e.loaded = true
+	e.resolved = nil
+	return nil
+}
+
+// constraintDrifts returns the drifts in the parameters of a constraint.
+func constraintDrifts(constraint *unstructured.Unstructured) ([]*Drift, error) {
+	raw, found, err := unstructured.NestedSlice(constraint.Object, "spec", "parameters", "drifts")
+	if err != nil || !found {
+		return nil, err
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var drifts []*Drift
+	if err := json.Unmarshal(data, &drifts); err != nil {
+		return nil, fmt.Errorf("invalid drifts: %w", err)
+	}
+	return drifts, nil
+}
+
+func (e *Exporter) applyTemplate(ctx context.Context) error {
+	desired := &unstructured.Unstructured{}
+	if err := yaml.Unmarshal([]byte(template), &desired.Object); err != nil {
+		return err
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(TemplateGVK)
+	obj.SetName(templateName)
+	_, err := controllerutil.CreateOrUpdate(ctx, e.cfg.Client, obj, func() error {
+		obj.SetLabels(desired.GetLabels())
+		obj.SetAnnotations(desired.GetAnnotations())
+		obj.Object["spec"] = desired.Object["spec"]
+		return nil
+	})
+	return err
+}
+
+func (e *Exporter) applyConstraint(ctx context.Context, drifts []*Drift) error {
+	spec, err := constraintSpec(drifts, e.cfg.EnforcementAction)
+	if err != nil {
+		return err
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(ConstraintGVK)
+	obj.SetName(e.cfg.Name)
+	_, err = controllerutil.CreateOrUpdate(ctx, e.cfg.Client, obj, func() error {
+		obj.SetLabels(map[string]string{"app.kubernetes.io/managed-by": "kausality"})
+		obj.Object["spec"] = spec
+		return nil
+	})
+	return err
+}
+
+// constraintSpec returns the spec of the constraint. It matches the kinds of
+// the drifted children only, so Gatekeeper does not audit other objects
+// against it. Without drift it matches KausalityPolicies, of which there
+// are few: an empty match would audit every object.
+func constraintSpec(drifts []*Drift, enforcementAction string) (map[string]interface{}, error) {
+	kindsByGroup := make(map[string][]string)
+	for _, d := range drifts {
+		group := schema.FromAPIVersionAndKind(d.APIVersion, d.Kind).Group
+		kindsByGroup[group] = append(kindsByGroup[group], d.Kind)
+	}
+	if len(kindsByGroup) == 0 {
+		kindsByGroup["kausality.io"] = []string{"KausalityPolicy"}
+	}
+	groups := make([]string, 0, len(kindsByGroup))
+	for group := range kindsByGroup {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	match := make([]interface{}, 0, len(groups))
+	for _, group := range groups {
+		kinds := kindsByGroup[group]
+		slices.Sort(kinds)
+		var items []interface{}
+		for _, kind := range slices.Compact(kinds) {
+			items = append(items, kind)
+		}
+		match = append(match, map[string]interface{}{
+			"apiGroups": []interface{}{group},
+			"kinds":     items,
+		})
+	}
+
+	data, err := json.Marshal(drifts)
+	if err != nil {
+		return nil, err
+	}
+	params := []interface{}{}
+	if err := json.Unmarshal(data, &params); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"enforcementAction": enforcementAction,
+		"match":             map[string]interface{}{"kinds": match},
+		"parameters":        map[string]interface{}{"drifts": params},
+	}, nil
+}
@@ -0,0 +1,161 @@
+package gatekeeper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kausality-io/kausality/pkg/callback"
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+)
+
+func newFakeClient() client.Client {
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{TemplateGVK.GroupVersion(), ConstraintGVK.GroupVersion()})
+	mapper.Add(TemplateGVK, meta.RESTScopeRoot)
+	mapper.Add(ConstraintGVK, meta.RESTScopeRoot)
+	return fake.NewClientBuilder().WithScheme(runtime.NewScheme()).WithRESTMapper(mapper).Build()
+}
+
+func newTestExporter(t *testing.T, c client.Client) *Exporter {
+	t.Helper()
+	e, err := New(Config{Client: c, Log: logr.Discard()})
+	require.NoError(t, err)
+	return e
+}
+
+func testReport(phase v1alpha1.DriftReportPhase, childKind, childName string) *v1alpha1.DriftReport {
+	parent := v1alpha1.ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "app"}
+	child := v1alpha1.ObjectReference{APIVersion: "apps/v1", Kind: childKind, Namespace: "default", Name: childName}
+	id := callback.GenerateResolutionID(parent, child)
+	if phase == v1alpha1.DriftReportPhaseDetected {
+		id = callback.GenerateDriftID(parent, child, []byte(childName))
+	}
+	return &v1alpha1.DriftReport{Spec: v1alpha1.DriftReportSpec{
+		ID:      id,
+		Phase:   phase,
+		Parent:  parent,
+		Child:   child,
+		Request: v1alpha1.RequestContext{User: "admin", Operation: "UPDATE"},
+	}}
+}
+
+func getConstraint(t *testing.T, c client.Client) *unstructured.Unstructured {
+	t.Helper()
+	constraint := &unstructured.Unstructured{}
+	constraint.SetGroupVersionKind(ConstraintGVK)
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Name: "kausality-drift"}, constraint))
+	return constraint
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(Config{})
+	assert.ErrorContains(t, err, "client is required")
+	_, err = New(Config{Client: newFakeClient(), EnforcementAction: "deny"})
+	assert.ErrorContains(t, err, "invalid enforcement action")
+
+	e, err := New(Config{Client: newFakeClient()})
+	require.NoError(t, err)
+	assert.Equal(t, "kausality-drift", e.cfg.Name)
+	assert.Equal(t, EnforcementActionDryRun, e.cfg.EnforcementAction)
+}
+
+func TestExporter_DetectAndResolve(t *testing.T) {
+	c := newFakeClient()
+	e := newTestExporter(t, c)
+	ctx := context.Background()
+
+	// Without drift, the constraint exists but matches policies only
+	require.NoError(t, e.Sync(ctx))
+	template := &unstructured.Unstructured{}
+	template.SetGroupVersionKind(TemplateGVK)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: templateName}, template))
+	kind, _, _ := unstructured.NestedString(template.Object, "spec", "crd", "spec", "names", "kind")
+	assert.Equal(t, ConstraintKind, kind)
+	constraint := getConstraint(t, c)
+	action, _, _ := unstructured.NestedString(constraint.Object, "spec", "enforcementAction")
+	assert.Equal(t, EnforcementActionDryRun, action)
+	drifts, err := constraintDrifts(constraint)
+	require.NoError(t, err)
+	assert.Empty(t, drifts)
+
+	e.Add(testReport(v1alpha1.DriftReportPhaseDetected, "ReplicaSet", "app-abc"))
+	e.Add(testReport(v1alpha1.DriftReportPhaseDetected, "ReplicaSet", "app-def"))
+	e.Add(testReport(v1alpha1.DriftReportPhaseDetected, "StatefulSet", "db"))
+	require.NoError(t, e.Sync(ctx))
+
+	constraint = getConstraint(t, c)
+	drifts, err = constraintDrifts(constraint)
+	require.NoError(t, err)
+	require.Len(t, drifts, 3)
+	assert.Contains(t, drifts[0].Message, "UPDATE of ReplicaSet default/app-abc by admin, not requested by parent Deployment default/app")
+	match, _, _ := unstructured.NestedSlice(constraint.Object, "spec", "match", "kinds")
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"apiGroups": []interface{}{"apps"},
+		"kinds":     []interface{}{"ReplicaSet", "StatefulSet"},
+	}}, match)
+
+	e.Add(testReport(v1alpha1.DriftReportPhaseResolved, "ReplicaSet", "app-abc"))
+	require.NoError(t, e.Sync(ctx))
+	drifts, err = constraintDrifts(getConstraint(t, c))
+	require.NoError(t, err)
+	require.Len(t, drifts, 2)
+	assert.Equal(t, "app-def", drifts[0].Name)
+	assert.Equal(t, 2, e.Len())
+}
+
+func TestExporter_KeepsDriftAcrossRestarts(t *testing.T) {
+	c := newFakeClient()
+	ctx := context.Background()
+	e := newTestExporter(t, c)
+	e.Add(testReport(v1alpha1.DriftReportPhaseDetected, "ReplicaSet", "app-abc"))
+	e.Add(testReport(v1alpha1.DriftReportPhaseDetected, "ReplicaSet", "app-def"))
+	require.NoError(t, e.Sync(ctx))
+
+	restarted := newTestExporter(t, c)
+	restarted.Add(testReport(v1alpha1.DriftReportPhaseResolved, "ReplicaSet", "app-abc"))
+	require.NoError(t, restarted.Sync(ctx))
+	assert.Equal(t, 1, restarted.Len())
+
+	// The resolution received before the constraint was read is not lost
+	drifts, err := constraintDrifts(getConstraint(t, c))
+	require.NoError(t, err)
+	require.Len(t, drifts, 1)
+	assert.Equal(t, "app-def", drifts[0].Name)
+}
+
+func TestExporter_Handler(t *testing.T) {
+	e := newTestExporter(t, newFakeClient())
+	handler := e.Handler()
+
+	body, err := json.Marshal(testReport(v1alpha1.DriftReportPhaseDetected, "ReplicaSet", "app-abc"))
+	require.NoError(t, err)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp v1alpha1.DriftReportResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.True(t, resp.Acknowledged)
+	assert.Equal(t, 1, e.Len())
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader([]byte(`{"spec":{}}`))))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.JSONEq(t, `{"status":"ok","drifts":1}`, rec.Body.String())
+}
@@ -0,0 +1,392 @@
+// Package gitexport records DriftReports in a Git repository, giving an
+// auditable, diffable ledger of drift that fits GitOps review workflows.
+//
+// Each detected drift is written to <path>/active/<id>.yaml. When the drift
+// is resolved, its file is moved to <path>/resolved/<id>.yaml with the
+// resolution recorded, or removed if ResolvedAction is "delete". Reports are
+// batched and committed every FlushInterval.
+package gitexport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+
+	"github.com/kausality-io/kausality/pkg/callback"
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+)
+
+// Resolved actions.
+const (
+	// ResolvedActionMove moves resolved drifts to <path>/resolved.
+	ResolvedActionMove = "move"
+	// ResolvedActionDelete removes resolved drifts from the ledger.
+	ResolvedActionDelete = "delete"
+)
+
+const (
+	activeDir   = "active"
+	resolvedDir = "resolved"
+
+	// maxPending bounds the reports buffered while the remote is unreachable.
+	maxPending = 10000
+	// maxPushAttempts is the number of sync/commit/push rounds per flush,
+	// covering pushes rejected because the remote branch moved.
+	maxPushAttempts = 3
+)
+
+// Config configures the Exporter.
+type Config struct {
+	// URL is the remote repository, in any form git accepts.
+	URL string
+	// Branch is the branch drift reports are committed to. Defaults to "main".
+	Branch string
+	// Dir is the local clone. It is created if missing.
+	Dir string
+	// Path is the directory within the repository holding the ledger. Defaults to "drifts".
+	Path string
+	// AuthorName and AuthorEmail identify the commits. Default to "kausality" and "kausality@localhost".
+	AuthorName  string
+	AuthorEmail string
+	// ResolvedAction is ResolvedActionMove (default) or ResolvedActionDelete.
+	ResolvedAction string
+	// FlushInterval is how often buffered reports are committed. Defaults to 10s.
+	FlushInterval time.Duration
+	// Log receives errors and commit notifications.
+	Log logr.Logger
+}
+
+// Entry is the content of a ledger file.
+type Entry struct {
+	// DetectedAt is when the drift report was received.
+	// Unset for resolutions without a recorded detection, e.g. approved drift.
+	DetectedAt *metav1.Time `json:"detectedAt,omitempty"`
+	// ResolvedAt is when the resolution was received.
+	ResolvedAt *metav1.Time `json:"resolvedAt,omitempty"`
+	// Resolution is the request that resolved the drift.
+	Resolution *v1alpha1.RequestContext `json:"resolution,omitempty"`
+	// Report is the drift report.
+	Report v1alpha1.DriftReport `json:"report"`
+}
+
+// Exporter buffers DriftReports and commits them to a Git repository.
+type Exporter struct {
+	cfg  Config
+	repo *repo
+	log  logr.Logger
+	now  func() time.Time
+
+	mu      sync.Mutex
+	pending []pendingReport
+}
+
+// pendingReport is a report waiting to be committed.
+type pendingReport struct {
+	report     *v1alpha1.DriftReport
+	receivedAt time.Time
+}
+
+// New creates an Exporter with defaults applied.
+func New(cfg Config) (*Exporter, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("repository URL is required")
+	}
+	if cfg.Dir == "" {
+		return nil, errors.New("clone directory is required")
+	}
+	if cfg.Branch == "" {
+		cfg.Branch = "main"
+	}
+	if cfg.Path == "" {
+		cfg.Path = "drifts"
+	}
+	if cfg.AuthorName == "" {
+		cfg.AuthorName = "kausality"
+	}
+	if cfg.AuthorEmail == "" {
+		cfg.AuthorEmail = "kausality@localhost"
+	}
+	switch cfg.ResolvedAction {
+	case "":
+		cfg.ResolvedAction = ResolvedActionMove
+	case ResolvedActionMove, ResolvedActionDelete:
+	default:
+		return nil, fmt.Errorf("invalid resolved action %q: must be %q or %q", cfg.ResolvedAction, ResolvedActionMove, ResolvedActionDelete)
+	}
+	if cfg.FlushInterval == 0 {
+		cfg.FlushInterval = 10 * time.Second
+	}
+	if filepath.IsAbs(cfg.Path) || strings.HasPrefix(filepath.Clean(cfg.Path), "..") {
+		return nil, fmt.Errorf("path %q must be relative to the repository root", cfg.Path)
+	}
+
+	return &Exporter{
+		cfg: cfg,
+		repo: &repo{
+			dir:         cfg.Dir,
+			url:         cfg.URL,
+			branch:      cfg.Branch,
+			authorName:  cfg.AuthorName,
+			authorEmail: cfg.AuthorEmail,
+		},
+		log: cfg.Log,
+		now: time.Now,
+	}, nil
+}
+
+// Handler returns the HTTP handler receiving DriftReports.
+func (e *Exporter) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /webhook", e.handleWebhook)
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"status":"ok","pending":%d}`, e.Pending())
+	})
+	return mux
+}
+
+// handleWebhook buffers a DriftReport. Reports are acknowledged once
+// buffered; reports not yet committed are lost if the exporter is killed.
+func (e *Exporter) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	var report v1alpha1.DriftReport
+	if err := json.Unmarshal(body, &report); err != nil || !validDriftID(report.Spec.ID) {
+		http.Error(w, "invalid DriftReport", http.StatusBadRequest)
+		return
+	}
+	e.Add(&report)
+
+	response := v1alpha1.DriftReportResponse{Acknowledged: true}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// Add buffers a report for the next flush. The oldest reports are dropped
+// if too many are buffered. Reports with an invalid ID are dropped.
+func (e *Exporter) Add(report *v1alpha1.DriftReport) {
+	if !validDriftID(report.Spec.ID) {
+		e.log.Info("dropping drift report with invalid ID", "id", report.Spec.ID)
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.pending = append(e.pending, pendingReport{report: report, receivedAt: e.now()})
+	if len(e.pending) > maxPending {
+		e.log.Info("dropping buffered drift reports", "dropped", len(e.pending)-maxPending)
+		e.pending = e.pending[len(e.pending)-maxPending:]
+	}
+}
+
+// validDriftID returns true if id can be used as a file name in the ledger.
+// Drift IDs are hex strings; anything but a DNS label, e.g. a path, is rejected.
+func validDriftID(id string) bool {
+	return len(validation.IsDNS1123Label(id)) == 0
+}
+
+// Pending returns the number of buffered reports.
+func (e *Exporter) Pending() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.pending)
+}
+
+// Start clones the repository and flushes buffered reports every
+// FlushInterval until the context is cancelled, then flushes once more.
+func (e *Exporter) Start(ctx context.Context) error {
+	if err := e.repo.open(ctx); err != nil {
+		return fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	ticker := time.NewTicker(e.cfg.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			return e.Flush(flushCtx)
+		case <-ticker.C:
+			if err := e.Flush(ctx); err != nil {
+				e.log.Error(err, "failed to export drift reports, will retry", "pending", e.Pending())
+			}
+		}
+	}
+}
+
+// Flush commits and pushes the buffered reports. The clone is reset to the
+// remote branch first, so a push rejected because the branch moved is
+// retried on top of the new remote state. On failure the reports stay buffered.
+func (e *Exporter) Flush(ctx context.Context) error {
+	e.mu.Lock()
+	batch := e.pending
+	e.pending = nil
+	e.mu.Unlock()
+	if len(batch) == 0 {
+		return nil
+	}
+
+	err := e.commit(ctx, batch)
+	if err != nil {
+		e.mu.Lock()
+		e.pending = append(batch, e.pending...)
+		e.mu.Unlock()
+	}
+	return err
+}
+
+func (e *Exporter) commit(ctx context.Context, batch []pendingReport) error {
+	var err error
+	for attempt := 1; attempt <= maxPushAttempts; attempt++ {
+		if err = e.repo.sync(ctx); err != nil {
+			return err
+		}
+		for _, p := range batch {
+			if err := e.apply(p); err != nil {
+				return fmt.Errorf("failed to write drift %s: %w", p.report.Spec.ID, err)
+			}
+		}
+
+		var committed bool
+		committed, err = e.repo.commitAndPush(ctx, e.cfg.Path, commitMessage(batch))
+		if err == nil {
+			if committed {
+				e.log.Info("exported drift reports", "reports", len(batch), "branch", e.cfg.Branch)
+			}
+			return nil
+		}
+		e.log.V(1).Info("push failed, retrying", "attempt", attempt, "error", err.Error())
+	}
+	return err
+}
+
+// apply writes a report into the working tree.
+func (e *Exporter) apply(p pendingReport) error {
+	root := filepath.Join(e.cfg.Dir, e.cfg.Path)
+	at := metav1.NewTime(p.receivedAt.UTC().Truncate(time.Second))
+
+	if p.report.Spec.Phase != v1alpha1.DriftReportPhaseResolved {
+		file := filepath.Join(root, activeDir, p.report.Spec.ID+".yaml")
+		entry := &Entry{DetectedAt: &at, Report: *p.report}
+		// Keep the first detection time for repeated reports
+		if existing, err := readEntry(file); err == nil && existing.DetectedAt != nil {
+			entry.DetectedAt = existing.DetectedAt
+		}
+		return writeEntry(file, entry)
+	}
+
+	resolved, err := e.activeEntriesFor(p.report)
+	if err != nil {
+		return err
+	}
+	if len(resolved) == 0 {
+		if e.cfg.ResolvedAction == ResolvedActionDelete {
+			return nil
+		}
+		// Resolved without a recorded detection, e.g. drift that was approved up front
+		resolved = map[string]*Entry{p.report.Spec.ID: {Report: *p.report}}
+	}
+
+	for file, entry := range resolved {
+		if err := os.Remove(file); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		if e.cfg.ResolvedAction == ResolvedActionDelete {
+			continue
+		}
+		entry.ResolvedAt = &at
+		entry.Resolution = &p.report.Spec.Request
+		if err := writeEntry(filepath.Join(root, resolvedDir, entry.Report.Spec.ID+".yaml"), entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// activeEntriesFor returns the active entries, keyed by file, that a
+// resolution refers to. Resolutions carry an ID derived from the parent and
+// child only, so the active entries are matched by recomputing it.
+func (e *Exporter) activeEntriesFor(resolution *v1alpha1.DriftReport) (map[string]*Entry, error) {
+	files, err := filepath.Glob(filepath.Join(e.cfg.Dir, e.cfg.Path, activeDir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]*Entry)
+	for _, file := range files {
+		entry, err := readEntry(file)
+		if err != nil {
+			e.log.Error(err, "skipping unreadable ledger file", "file", file)
+			continue
+		}
+		spec := entry.Report.Spec
+		if callback.GenerateResolutionID(spec.Parent, spec.Child) == resolution.Spec.ID {
+			result[file] = entry
+		}
+	}
+	return result, nil
+}
+
+// commitMessage summarizes a batch, one line per report.
+func commitMessage(batch []pendingReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Record %d drift report(s)\n\n", len(batch))
+	for _, p := range batch {
+		spec := p.report.Spec
+		fmt.Fprintf(&b, "- %s %s: %s %s %s by %s (parent %s %s)\n",
+			spec.Phase, spec.ID, spec.Request.Operation, spec.Child.Kind, objectName(spec.Child),
+			spec.Request.User, spec.Parent.Kind, objectName(spec.Parent))
+	}
+	return b.String()
+}
+
+func objectName(ref v1alpha1.ObjectReference) string {
+	if ref.Namespace == "" {
+		return ref.Name
+	}
+	return ref.Namespace + "/" + ref.Name
+}
+
+func readEntry(file string) (*Entry, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var entry Entry
+	if err := yaml.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+	return &entry, nil
+}
+
+func writeEntry(file string, entry *Entry) error {
+	data, err := yaml.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0o644)
+}
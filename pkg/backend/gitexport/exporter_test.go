@@ -0,0 +1,251 @@
+package gitexport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kausality-io/kausality/pkg/callback"
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+)
+
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, "git %v: %s", args, out)
+	return strings.TrimSpace(string(out))
+}
+
+// newTestExporter creates an exporter pushing to a fresh bare repository.
+func newTestExporter(t *testing.T, cfg Config) (*Exporter, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	remote := filepath.Join(t.TempDir(), "ledger.git")
+	git(t, t.TempDir(), "init", "--quiet", "--bare", remote)
+
+	cfg.URL = remote
+	cfg.Dir = filepath.Join(t.TempDir(), "clone")
+	cfg.Log = logr.Discard()
+	e, err := New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, e.repo.open(context.Background()))
+	return e, remote
+}
+
+// remoteFiles lists the files on the remote branch.
+func remoteFiles(t *testing.T, remote, branch string) []string {
+	t.Helper()
+	out := git(t, remote, "ls-tree", "-r", "--name-only", branch)
+	if out == "" {
+		return nil
+	}
+	return strings.Split(out, "\n")
+}
+
+func remoteEntry(t *testing.T, remote, branch, path string) *Entry {
+	t.Helper()
+	dir := t.TempDir()
+	file := filepath.Join(dir, "entry.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(git(t, remote, "show", branch+":"+path)), 0o644))
+	entry, err := readEntry(file)
+	require.NoError(t, err)
+	return entry
+}
+
+func testReport(phase v1alpha1.DriftReportPhase, childName string) *v1alpha1.DriftReport {
+	parent := v1alpha1.ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "app"}
+	child := v1alpha1.ObjectReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Namespace: "default", Name: childName}
+	id := callback.GenerateResolutionID(parent, child)
+	if phase == v1alpha1.DriftReportPhaseDetected {
+		id = callback.GenerateDriftID(parent, child, []byte(childName))
+	}
+	return &v1alpha1.DriftReport{Spec: v1alpha1.DriftReportSpec{
+		ID:      id,
+		Phase:   phase,
+		Parent:  parent,
+		Child:   child,
+		Request: v1alpha1.RequestContext{User: "admin", Operation: "UPDATE"},
+	}}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr string
+	}{
+		{name: "valid", cfg: Config{URL: "git@example.com:org/ledger.git", Dir: "/tmp/ledger"}},
+		{name: "missing URL", cfg: Config{Dir: "/tmp/ledger"}, wantErr: "URL is required"},
+		{name: "missing dir", cfg: Config{URL: "git@example.com:org/ledger.git"}, wantErr: "directory is required"},
+		{name: "invalid action", cfg: Config{URL: "u", Dir: "d", ResolvedAction: "archive"}, wantErr: "invalid resolved action"},
+		{name: "path escapes repository", cfg: Config{URL: "u", Dir: "d", Path: "../elsewhere"}, wantErr: "must be relative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := New(tt.cfg)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "main", e.cfg.Branch)
+			assert.Equal(t, "drifts", e.cfg.Path)
+			assert.Equal(t, ResolvedActionMove, e.cfg.ResolvedAction)
+		})
+	}
+}
+
+func TestExporter_DetectAndResolve(t *testing.T) {
+	e, remote := newTestExporter(t, Config{})
+	ctx := context.Background()
+
+	detected := testReport(v1alpha1.DriftReportPhaseDetected, "app-abc")
+	other := testReport(v1alpha1.DriftReportPhaseDetected, "app-def")
+	e.Add(detected)
+	e.Add(other)
+	require.NoError(t, e.Flush(ctx))
+	assert.Equal(t, 0, e.Pending())
+
+	assert.ElementsMatch(t, []string{
+		"drifts/active/" + detected.Spec.ID + ".yaml",
+		"drifts/active/" + other.Spec.ID + ".yaml",
+	}, remoteFiles(t, remote, "main"))
+	entry := remoteEntry(t, remote, "main", "drifts/active/"+detected.Spec.ID+".yaml")
+	assert.NotNil(t, entry.DetectedAt)
+	assert.Equal(t, detected.Spec, entry.Report.Spec)
+
+	subject := git(t, remote, "log", "-1", "--format=%s", "main")
+	assert.Equal(t, "Record 2 drift report(s)", subject)
+
+	// Resolution moves only the matching drift
+	e.Add(testReport(v1alpha1.DriftReportPhaseResolved, "app-abc"))
+	require.NoError(t, e.Flush(ctx))
+
+	assert.ElementsMatch(t, []string{
+		"drifts/active/" + other.Spec.ID + ".yaml",
+		"drifts/resolved/" + detected.Spec.ID + ".yaml",
+	}, remoteFiles(t, remote, "main"))
+	entry = remoteEntry(t, remote, "main", "drifts/resolved/"+detected.Spec.ID+".yaml")
+	assert.NotNil(t, entry.DetectedAt)
+	assert.NotNil(t, entry.ResolvedAt)
+	require.NotNil(t, entry.Resolution)
+	assert.Equal(t, "admin", entry.Resolution.User)
+	assert.Equal(t, "2", git(t, remote, "rev-list", "--count", "main"))
+}
+
+func TestExporter_ResolvedDelete(t *testing.T) {
+	e, remote := newTestExporter(t, Config{ResolvedAction: ResolvedActionDelete, Path: "ledger/prod"})
+	ctx := context.Background()
+
+	e.Add(testReport(v1alpha1.DriftReportPhaseDetected, "app-abc"))
+	require.NoError(t, e.Flush(ctx))
+	e.Add(testReport(v1alpha1.DriftReportPhaseResolved, "app-abc"))
+	require.NoError(t, e.Flush(ctx))
+
+	assert.Empty(t, remoteFiles(t, remote, "main"))
+	assert.Equal(t, "2", git(t, remote, "rev-list", "--count", "main"))
+}
+
+func TestExporter_NothingToCommit(t *testing.T) {
+	e, remote := newTestExporter(t, Config{ResolvedAction: ResolvedActionDelete})
+
+	// Resolution without detection changes nothing when resolved drifts are deleted
+	e.Add(testReport(v1alpha1.DriftReportPhaseResolved, "app-abc"))
+	require.NoError(t, e.Flush(context.Background()))
+
+	out, err := exec.Command("git", "-C", remote, "rev-parse", "--verify", "--quiet", "main").CombinedOutput()
+	assert.Error(t, err, "no commit expected, got %s", out)
+}
+
+func TestExporter_RemoteMoved(t *testing.T) {
+	e, remote := newTestExporter(t, Config{})
+	ctx := context.Background()
+
+	e.Add(testReport(v1alpha1.DriftReportPhaseDetected, "app-abc"))
+	require.NoError(t, e.Flush(ctx))
+
+	// Someone else pushes to the branch
+	other := filepath.Join(t.TempDir(), "other")
+	git(t, t.TempDir(), "clone", "--quiet", "--branch", "main", remote, other)
+	require.NoError(t, os.WriteFile(filepath.Join(other, "README.md"), []byte("ledger\n"), 0o644))
+	git(t, other, "add", "README.md")
+	git(t, other, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "Add README")
+	git(t, other, "push", "--quiet", "origin", "HEAD:main")
+
+	e.Add(testReport(v1alpha1.DriftReportPhaseDetected, "app-def"))
+	require.NoError(t, e.Flush(ctx))
+
+	files := remoteFiles(t, remote, "main")
+	assert.Contains(t, files, "README.md")
+	assert.Len(t, files, 3)
+}
+
+func TestExporter_FlushFailureKeepsReports(t *testing.T) {
+	e, _ := newTestExporter(t, Config{})
+	e.repo.url = filepath.Join(t.TempDir(), "missing.git")
+	git(t, e.cfg.Dir, "remote", "set-url", "origin", e.repo.url)
+
+	e.Add(testReport(v1alpha1.DriftReportPhaseDetected, "app-abc"))
+	require.Error(t, e.Flush(context.Background()))
+	assert.Equal(t, 1, e.Pending())
+}
+
+func TestExporter_AddDropsInvalidID(t *testing.T) {
+	e, err := New(Config{URL: "u", Dir: "d"})
+	require.NoError(t, err)
+
+	report := testReport(v1alpha1.DriftReportPhaseDetected, "app-abc")
+	report.Spec.ID = "../escape"
+	e.Add(report)
+	assert.Equal(t, 0, e.Pending())
+}
+
+func TestExporter_Handler(t *testing.T) {
+	e, err := New(Config{URL: "u", Dir: "d"})
+	require.NoError(t, err)
+	handler := e.Handler()
+
+	body, err := json.Marshal(testReport(v1alpha1.DriftReportPhaseDetected, "app-abc"))
+	require.NoError(t, err)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 1, e.Pending())
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader("{}")))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// IDs are used as file names and must not escape the ledger
+	for _, id := range []string{"../../.git/hooks/post-commit", "a/b", "..", "ABC"} {
+		report := testReport(v1alpha1.DriftReportPhaseDetected, "app-abc")
+		report.Spec.ID = id
+		body, err := json.Marshal(report)
+		require.NoError(t, err)
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(body)))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, "id %q", id)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.JSONEq(t, `{"status":"ok","pending":1}`, rec.Body.String())
+}
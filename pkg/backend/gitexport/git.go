@@ -0,0 +1,109 @@
+package gitexport
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// repo runs git commands against a local clone of the ledger repository.
+// The git binary handles transport and authentication, so SSH keys,
+// credential helpers and GIT_* environment variables work as usual.
+type repo struct {
+	dir         string
+	url         string
+	branch      string
+	authorName  string
+	authorEmail string
+}
+
+// run executes git in the clone and returns its trimmed stdout.
+func (r *repo) run(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = r.dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// open clones the repository into dir unless a clone already exists.
+// An empty remote or missing branch starts a new branch.
+func (r *repo) open(ctx context.Context) error {
+	if _, err := os.Stat(filepath.Join(r.dir, ".git")); err == nil {
+		_, err := r.run(ctx, "remote", "set-url", "origin", r.url)
+		return err
+	}
+	if err := os.MkdirAll(r.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", r.dir, err)
+	}
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"remote", "add", "origin", r.url},
+		{"checkout", "--quiet", "-b", r.branch},
+	} {
+		if _, err := r.run(ctx, args...); err != nil {
+			return err
+		}
+	}
+	return r.sync(ctx)
+}
+
+// sync resets the clone to the remote branch, discarding local changes.
+// A branch that does not exist on the remote yet is left as is.
+func (r *repo) sync(ctx context.Context) error {
+	out, err := r.run(ctx, "ls-remote", "--heads", "origin", r.branch)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		return nil
+	}
+	if _, err := r.run(ctx, "fetch", "--quiet", "origin", r.branch); err != nil {
+		return err
+	}
+	_, err = r.run(ctx, "reset", "--quiet", "--hard", "FETCH_HEAD")
+	return err
+}
+
+// commitAndPush commits all changes below path and pushes them. Returns
+// false if there was nothing to commit.
+func (r *repo) commitAndPush(ctx context.Context, path, message string) (bool, error) {
+	// git rejects pathspecs that match nothing, an empty directory is fine
+	if err := os.MkdirAll(filepath.Join(r.dir, path), 0o755); err != nil {
+		return false, err
+	}
+	if _, err := r.run(ctx, "add", "--all", "--", path); err != nil {
+		return false, err
+	}
+	if _, err := r.run(ctx, "diff", "--cached", "--quiet"); err == nil {
+		return false, nil
+	} else if !isExitError(err) {
+		return false, err
+	}
+
+	if _, err := r.run(ctx, "-c", "user.name="+r.authorName, "-c", "user.email="+r.authorEmail,
+		"commit", "--quiet", "-m", message); err != nil {
+		return false, err
+	}
+	if _, err := r.run(ctx, "push", "--quiet", "origin", "HEAD:refs/heads/"+r.branch); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// isExitError returns true if err carries a non-zero exit status, as opposed
+// to git not being runnable at all.
+func isExitError(err error) bool {
+	var exitErr *exec.ExitError
+	return errors.As(err, &exitErr)
+}
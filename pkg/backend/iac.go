@@ -0,0 +1,244 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+)
+
+// ExternalNameAnnotation holds the cloud identifier of a Crossplane managed resource.
+const ExternalNameAnnotation = "crossplane.io/external-name"
+
+// IaC report formats accepted by ParseIaCReport.
+const (
+	IaCSourceTerraform = "terraform"
+	IaCSourceDriftctl  = "driftctl"
+)
+
+// idAttributes are the resource attributes that may carry a cloud identifier
+// matching an external name.
+var idAttributes = []string{"id", "arn", "name"}
+
+// IaCResource is a cloud resource reported by an IaC tool.
+type IaCResource struct {
+	// Source is the tool that reported the resource, "terraform" or "driftctl".
+	Source string `json:"source"`
+	// Address is the resource address, e.g. "aws_s3_bucket.logs".
+	Address string `json:"address,omitempty"`
+	// Type is the provider resource type, e.g. "aws_s3_bucket".
+	Type string `json:"type"`
+	// IDs are the cloud identifiers of the resource.
+	IDs []string `json:"ids"`
+	// Drifted is true if the tool sees the resource diverge from its code.
+	Drifted bool `json:"drifted"`
+	// Actions are the planned terraform actions, e.g. ["update"].
+	Actions []string `json:"actions,omitempty"`
+}
+
+// Correlation relates drift seen at the IaC layer to open DriftReports.
+type Correlation struct {
+	// Matched are drifted IaC resources with open DriftReports for the same cloud resource.
+	Matched []CorrelatedDrift `json:"matched"`
+	// IaCOnly are drifted IaC resources without an open DriftReport.
+	IaCOnly []IaCResource `json:"iacOnly"`
+	// K8sOnly are open DriftReports for managed resources the IaC tool does not see drifting.
+	K8sOnly []*StoredReport `json:"k8sOnly"`
+}
+
+// CorrelatedDrift is drift visible at both layers.
+type CorrelatedDrift struct {
+	ExternalName string          `json:"externalName"`
+	Resource     IaCResource     `json:"resource"`
+	Reports      []*StoredReport `json:"reports"`
+}
+
+// ParseIaCReport parses `terraform show -json` plan output or `driftctl scan
+// --output json://` output.
+func ParseIaCReport(data []byte) ([]IaCResource, error) {
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	switch {
+	case probe["resource_changes"] != nil || probe["resource_drift"] != nil:
+		return parseTerraformPlan(data)
+	case probe["differences"] != nil || probe["summary"] != nil:
+		return parseDriftctl(data)
+	default:
+		return nil, fmt.Errorf("unrecognized format: expected terraform plan JSON or driftctl JSON output")
+	}
+}
+
+type terraformResourceChange struct {
+	Address string `json:"address"`
+	Mode    string `json:"mode"`
+	Type    string `json:"type"`
+	Change  struct {
+		Actions []string               `json:"actions"`
+		Before  map[string]interface{} `json:"before"`
+		After   map[string]interface{} `json:"after"`
+	} `json:"change"`
+}
+
+// parseTerraformPlan treats changes made outside of terraform (resource_drift)
+// and planned changes as drift; no-op resources are reported as in sync.
+func parseTerraformPlan(data []byte) ([]IaCResource, error) {
+	var plan struct {
+		ResourceDrift   []terraformResourceChange `json:"resource_drift"`
+		ResourceChanges []terraformResourceChange `json:"resource_changes"`
+	}
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("invalid terraform plan: %w", err)
+	}
+
+	var resources []IaCResource
+	seen := make(map[string]int)
+	add := func(rc terraformResourceChange, drifted bool) {
+		if rc.Mode == "data" {
+			return
+		}
+		if i, ok := seen[rc.Address]; ok {
+			resources[i].Drifted = resources[i].Drifted || drifted
+			if !isNoOp(rc.Change.Actions) {
+				resources[i].Actions = rc.Change.Actions
+			}
+			return
+		}
+		res := IaCResource{
+			Source:  IaCSourceTerraform,
+			Address: rc.Address,
+			Type:    rc.Type,
+			IDs:     resourceIDs(rc.Change.Before, rc.Change.After),
+			Drifted: drifted,
+		}
+		if !isNoOp(rc.Change.Actions) {
+			res.Actions = rc.Change.Actions
+		}
+		seen[rc.Address] = len(resources)
+		resources = append(resources, res)
+	}
+
+	for _, rc := range plan.ResourceDrift {
+		add(rc, true)
+	}
+	for _, rc := range plan.ResourceChanges {
+		add(rc, !isNoOp(rc.Change.Actions))
+	}
+	return resources, nil
+}
+
+func isNoOp(actions []string) bool {
+	return len(actions) == 0 || (len(actions) == 1 && (actions[0] == "no-op" || actions[0] == "read"))
+}
+
+// resourceIDs collects the identifying attributes of the resource before and after the change.
+func resourceIDs(states ...map[string]interface{}) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, state := range states {
+		for _, attr := range idAttributes {
+			if id, ok := state[attr].(string); ok && id != "" && !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// parseDriftctl reports the resources driftctl found to differ from the state.
+// Unmanaged and missing resources are not drift of managed infrastructure and are ignored.
+func parseDriftctl(data []byte) ([]IaCResource, error) {
+	var scan struct {
+		Differences []struct {
+			Res struct {
+				ID   string `json:"id"`
+				Type string `json:"type"`
+			} `json:"res"`
+		} `json:"differences"`
+	}
+	if err := json.Unmarshal(data, &scan); err != nil {
+		return nil, fmt.Errorf("invalid driftctl output: %w", err)
+	}
+
+	resources := make([]IaCResource, 0, len(scan.Differences))
+	for _, d := range scan.Differences {
+		resources = append(resources, IaCResource{
+			Source:  IaCSourceDriftctl,
+			Address: d.Res.Type + "." + d.Res.ID,
+			Type:    d.Res.Type,
+			IDs:     []string{d.Res.ID},
+			Drifted: true,
+		})
+	}
+	return resources, nil
+}
+
+// Correlate matches drifted IaC resources against open DriftReports by the
+// crossplane.io/external-name annotation of the drifting child.
+func (s *Store) Correlate(resources []IaCResource) *Correlation {
+	byExternalName := make(map[string][]*StoredReport)
+	var names []string
+	for _, r := range s.List() {
+		name := externalName(r.Report)
+		if name == "" {
+			continue
+		}
+		if _, ok := byExternalName[name]; !ok {
+			names = append(names, name)
+		}
+		byExternalName[name] = append(byExternalName[name], r)
+	}
+	for _, reports := range byExternalName {
+		sort.Slice(reports, func(i, j int) bool {
+			return reports[i].ReceivedAt.Before(reports[j].ReceivedAt)
+		})
+	}
+
+	result := &Correlation{
+		Matched: []CorrelatedDrift{},
+		IaCOnly: []IaCResource{},
+		K8sOnly: []*StoredReport{},
+	}
+	matched := make(map[string]bool)
+	for _, res := range resources {
+		if !res.Drifted {
+			continue
+		}
+		found := false
+		for _, id := range res.IDs {
+			if reports, ok := byExternalName[id]; ok && !matched[id] {
+				matched[id] = true
+				found = true
+				result.Matched = append(result.Matched, CorrelatedDrift{ExternalName: id, Resource: res, Reports: reports})
+				break
+			}
+		}
+		if !found {
+			result.IaCOnly = append(result.IaCOnly, res)
+		}
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		if !matched[name] {
+			result.K8sOnly = append(result.K8sOnly, byExternalName[name]...)
+		}
+	}
+	return result
+}
+
+// externalName returns the external name of the drifting child, from the
+// new object or, for deletions, the old object.
+func externalName(report *v1alpha1.DriftReport) string {
+	if name := annotation(report.Spec.NewObject.Raw, ExternalNameAnnotation); name != "" {
+		return name
+	}
+	if report.Spec.OldObject != nil {
+		return annotation(report.Spec.OldObject.Raw, ExternalNameAnnotation)
+	}
+	return ""
+}
@@ -0,0 +1,133 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+)
+
+const terraformPlan = `{
+  "format_version": "1.2",
+  "resource_drift": [
+    {"address": "aws_s3_bucket.logs", "mode": "managed", "type": "aws_s3_bucket",
+     "change": {"actions": ["update"], "before": {"id": "acme-logs"}, "after": {"id": "acme-logs"}}}
+  ],
+  "resource_changes": [
+    {"address": "aws_s3_bucket.logs", "mode": "managed", "type": "aws_s3_bucket",
+     "change": {"actions": ["no-op"], "before": {"id": "acme-logs"}, "after": {"id": "acme-logs"}}},
+    {"address": "aws_db_instance.orders", "mode": "managed", "type": "aws_db_instance",
+     "change": {"actions": ["update"], "before": {"id": "db-123", "arn": "arn:aws:rds:eu-west-1:1:db:orders"}, "after": {"id": "db-123"}}},
+    {"address": "aws_sqs_queue.jobs", "mode": "managed", "type": "aws_sqs_queue",
+     "change": {"actions": ["no-op"], "before": {"id": "https://sqs/jobs", "name": "jobs"}, "after": {"id": "https://sqs/jobs", "name": "jobs"}}},
+    {"address": "data.aws_caller_identity.current", "mode": "data", "type": "aws_caller_identity",
+     "change": {"actions": ["read"], "after": {"id": "1"}}}
+  ]
+}`
+
+const driftctlOutput = `{
+  "summary": {"total_resources": 3, "total_changed": 1},
+  "differences": [
+    {"res": {"id": "acme-logs", "type": "aws_s3_bucket"}, "changelog": [{"type": "update", "path": ["versioning"]}]}
+  ],
+  "unmanaged": [{"id": "stray", "type": "aws_s3_bucket"}]
+}`
+
+func TestParseIaCReport_Terraform(t *testing.T) {
+	resources, err := ParseIaCReport([]byte(terraformPlan))
+	require.NoError(t, err)
+	require.Len(t, resources, 3)
+
+	assert.Equal(t, IaCResource{Source: IaCSourceTerraform, Address: "aws_s3_bucket.logs", Type: "aws_s3_bucket", IDs: []string{"acme-logs"}, Drifted: true, Actions: []string{"update"}}, resources[0])
+	assert.Equal(t, []string{"db-123", "arn:aws:rds:eu-west-1:1:db:orders"}, resources[1].IDs)
+	assert.True(t, resources[1].Drifted)
+	assert.Equal(t, []string{"https://sqs/jobs", "jobs"}, resources[2].IDs)
+	assert.False(t, resources[2].Drifted)
+}
+
+func TestParseIaCReport_Driftctl(t *testing.T) {
+	resources, err := ParseIaCReport([]byte(driftctlOutput))
+	require.NoError(t, err)
+	assert.Equal(t, []IaCResource{
+		{Source: IaCSourceDriftctl, Address: "aws_s3_bucket.acme-logs", Type: "aws_s3_bucket", IDs: []string{"acme-logs"}, Drifted: true},
+	}, resources)
+}
+
+func TestParseIaCReport_Invalid(t *testing.T) {
+	_, err := ParseIaCReport([]byte("not json"))
+	require.Error(t, err)
+
+	_, err = ParseIaCReport([]byte(`{"items": []}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unrecognized format")
+}
+
+// managedDrift returns a drift report for a managed resource with the given external name.
+func managedDrift(id, name, externalName string) *v1alpha1.DriftReport {
+	raw := []byte(`{"metadata":{"name":"` + name + `"}}`)
+	if externalName != "" {
+		raw = []byte(`{"metadata":{"name":"` + name + `","annotations":{"` + ExternalNameAnnotation + `":"` + externalName + `"}}}`)
+	}
+	return &v1alpha1.DriftReport{
+		Spec: v1alpha1.DriftReportSpec{
+			ID:        id,
+			Phase:     v1alpha1.DriftReportPhaseDetected,
+			Parent:    v1alpha1.ObjectReference{APIVersion: "example.org/v1alpha1", Kind: "XDatabase", Name: "orders-x7k2p"},
+			Child:     v1alpha1.ObjectReference{APIVersion: "rds.aws.upbound.io/v1beta1", Kind: "Instance", Name: name},
+			NewObject: runtime.RawExtension{Raw: raw},
+		},
+	}
+}
+
+func TestStore_Correlate(t *testing.T) {
+	store := NewStore()
+	store.Add(managedDrift("drift-db", "orders", "db-123"))
+	store.Add(managedDrift("drift-queue", "jobs", "jobs"))
+	store.Add(managedDrift("drift-cm", "config", ""))
+
+	resources, err := ParseIaCReport([]byte(terraformPlan))
+	require.NoError(t, err)
+	result := store.Correlate(resources)
+
+	require.Len(t, result.Matched, 1)
+	assert.Equal(t, "db-123", result.Matched[0].ExternalName)
+	assert.Equal(t, "aws_db_instance.orders", result.Matched[0].Resource.Address)
+	require.Len(t, result.Matched[0].Reports, 1)
+	assert.Equal(t, "drift-db", result.Matched[0].Reports[0].Report.Spec.ID)
+
+	require.Len(t, result.IaCOnly, 1)
+	assert.Equal(t, "aws_s3_bucket.logs", result.IaCOnly[0].Address)
+
+	// The queue is in sync at the IaC layer; the ConfigMap has no external name
+	require.Len(t, result.K8sOnly, 1)
+	assert.Equal(t, "drift-queue", result.K8sOnly[0].Report.Spec.ID)
+}
+
+func TestServer_CorrelateIaC(t *testing.T) {
+	server := NewServer()
+	server.Store().Add(managedDrift("drift-bucket", "logs", "acme-logs"))
+	handler := server.Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/iac/correlate", bytes.NewReader([]byte(driftctlOutput))))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var result Correlation
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+	require.Len(t, result.Matched, 1)
+	assert.Equal(t, "acme-logs", result.Matched[0].ExternalName)
+	assert.Empty(t, result.IaCOnly)
+	assert.Empty(t, result.K8sOnly)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/iac/correlate", bytes.NewReader([]byte("{}"))))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,279 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// This file writes flat, uncompressed Parquet files: one row group, one
+// PLAIN-encoded data page per column, and file metadata in the Thrift compact
+// protocol. That is all exports need, without a dependency on a full Parquet
+// implementation. See https://github.com/apache/parquet-format.
+
+// parquetMagic starts and ends every Parquet file.
+const parquetMagic = "PAR1"
+
+// Parquet physical types, repetition types, converted types, encodings and
+// page types of parquet.thrift.
+const (
+	parquetInt64     = 2
+	parquetByteArray = 6
+
+	parquetRequired = 0
+	parquetOptional = 1
+
+	parquetNoConversion      = -1
+	parquetUTF8              = 0
+	parquetTimestampMillis   = 9
+	parquetEncodingPlain     = 0
+	parquetEncodingRLE       = 3
+	parquetDataPage          = 0
+	parquetCodecUncompressed = 0
+)
+
+// parquetColumn is a column of a flat Parquet file.
+type parquetColumn struct {
+	name      string
+	physical  int32
+	converted int32
+	optional  bool
+	// values are string for byte arrays and int64 otherwise. Nil values
+	// are nulls of optional columns.
+	values []any
+}
+
+// writeParquet writes the columns, which all have numRows values, as a
+// Parquet file.
+func writeParquet(w io.Writer, numRows int, columns []parquetColumn) error {
+	var file bytes.Buffer
+	file.WriteString(parquetMagic)
+
+	type chunk struct {
+		offset int64
+		size   int64
+	}
+	chunks := make([]chunk, len(columns))
+	for i := range columns {
+		col := &columns[i]
+		if len(col.values) != numRows {
+			return fmt.Errorf("column %s has %d values, expected %d", col.name, len(col.values), numRows)
+		}
+		page, err := col.page()
+		if err != nil {
+			return err
+		}
+		var header thriftWriter
+		header.i32(1, parquetDataPage)
+		header.i32(2, int32(len(page)))
+		header.i32(3, int32(len(page)))
+		header.beginStruct(5)
+		header.i32(1, int32(numRows))
+		header.i32(2, parquetEncodingPlain)
+		header.i32(3, parquetEncodingRLE)
+		header.i32(4, parquetEncodingRLE)
+		header.endStruct()
+		header.stop()
+
+		chunks[i] = chunk{offset: int64(file.Len()), size: int64(header.buf.Len() + len(page))}
+		file.Write(header.buf.Bytes())
+		file.Write(page)
+	}
+
+	var meta thriftWriter
+	meta.i32(1, 1)
+	meta.listHeader(2, thriftStruct, len(columns)+1)
+	meta.beginElement()
+	meta.binary(4, []byte("schema"))
+	meta.i32(5, int32(len(columns)))
+	meta.endStruct()
+	for _, col := range columns {
+		meta.beginElement()
+		meta.i32(1, col.physical)
+		meta.i32(3, col.repetition())
+		meta.binary(4, []byte(col.name))
+		if col.converted != parquetNoConversion {
+			meta.i32(6, col.converted)
+		}
+		meta.endStruct()
+	}
+	meta.i64(3, int64(numRows))
+	meta.listHeader(4, thriftStruct, 1)
+	meta.beginElement()
+	meta.listHeader(1, thriftStruct, len(columns))
+	var totalSize int64
+	for i, col := range columns {
+		meta.beginElement()
+		meta.i64(2, chunks[i].offset)
+		meta.beginStruct(3)
+		meta.i32(1, col.physical)
+		meta.listHeader(2, thriftI32, 2)
+		meta.varint(parquetEncodingPlain)
+		meta.varint(parquetEncodingRLE)
+		meta.listHeader(3, thriftBinary, 1)
+		meta.bytes([]byte(col.name))
+		meta.i32(4, parquetCodecUncompressed)
+		meta.i64(5, int64(numRows))
+		meta.i64(6, chunks[i].size)
+		meta.i64(7, chunks[i].size)
+		meta.i64(9, chunks[i].offset)
+		meta.endStruct()
+		meta.endStruct()
+		totalSize += chunks[i].size
+	}
+	meta.i64(2, totalSize)
+	meta.i64(3, int64(numRows))
+	meta.endStruct()
+	meta.binary(6, []byte("kausality-backend"))
+	meta.stop()
+
+	file.Write(meta.buf.Bytes())
+	if err := binary.Write(&file, binary.LittleEndian, uint32(meta.buf.Len())); err != nil {
+		return err
+	}
+	file.WriteString(parquetMagic)
+	_, err := w.Write(file.Bytes())
+	return err
+}
+
+func (c *parquetColumn) repetition() int32 {
+	if c.optional {
+		return parquetOptional
+	}
+	return parquetRequired
+}
+
+// page returns the data of the column's data page: the definition levels of
+// optional columns, followed by the PLAIN-encoded non-null values.
+func (c *parquetColumn) page() ([]byte, error) {
+	var page bytes.Buffer
+	if c.optional {
+		levels := rleLevels(c.values)
+		_ = binary.Write(&page, binary.LittleEndian, uint32(len(levels)))
+		page.Write(levels)
+	}
+	for _, v := range c.values {
+		switch v := v.(type) {
+		case nil:
+			if !c.optional {
+				return nil, fmt.Errorf("null value in required column %s", c.name)
+			}
+		case string:
+			_ = binary.Write(&page, binary.LittleEndian, uint32(len(v)))
+			page.WriteString(v)
+		case int64:
+			_ = binary.Write(&page, binary.LittleEndian, v)
+		default:
+			return nil, fmt.Errorf("unsupported value %T in column %s", v, c.name)
+		}
+	}
+	return page.Bytes(), nil
+}
+
+// rleLevels encodes the definition levels of values, 0 for null and 1
+// otherwise, as RLE runs of bit width 1.
+func rleLevels(values []any) []byte {
+	var buf []byte
+	for i := 0; i < len(values); {
+		level := byte(1)
+		if values[i] == nil {
+			level = 0
+		}
+		run := 1
+		for i+run < len(values) && (values[i+run] == nil) == (level == 0) {
+			run++
+		}
+		buf = binary.AppendUvarint(buf, uint64(run)<<1)
+		buf = append(buf, level)
+		i += run
+	}
+	return buf
+}
+
+// Thrift compact protocol types.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftStruct = 12
+)
+
+// thriftWriter writes structs in the Thrift compact protocol. Fields must be
+// written in ascending id order.
+type thriftWriter struct {
+	buf bytes.Buffer
+	// lastIDs are the ids of the last fields written in the enclosing structs
+	lastIDs []int16
+	lastID  int16
+}
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	if delta := id - t.lastID; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		t.varint(int64(id))
+	}
+	t.lastID = id
+}
+
+// varint writes a zigzag varint, the encoding of i16, i32 and i64.
+func (t *thriftWriter) varint(v int64) {
+	t.buf.Write(binary.AppendUvarint(nil, uint64((v<<1)^(v>>63))))
+}
+
+// bytes writes a length-prefixed binary, e.g. a list element.
+func (t *thriftWriter) bytes(b []byte) {
+	t.buf.Write(binary.AppendUvarint(nil, uint64(len(b))))
+	t.buf.Write(b)
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.varint(int64(v))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.varint(v)
+}
+
+func (t *thriftWriter) binary(id int16, b []byte) {
+	t.field(id, thriftBinary)
+	t.bytes(b)
+}
+
+// listHeader starts a list field of size elements.
+func (t *thriftWriter) listHeader(id int16, elemType byte, size int) {
+	t.field(id, 9)
+	if size < 15 {
+		t.buf.WriteByte(byte(size)<<4 | elemType)
+		return
+	}
+	t.buf.WriteByte(0xf0 | elemType)
+	t.buf.Write(binary.AppendUvarint(nil, uint64(size)))
+}
+
+// beginStruct starts a struct field; beginElement a struct list element.
+func (t *thriftWriter) beginStruct(id int16) {
+	t.field(id, thriftStruct)
+	t.beginElement()
+}
+
+func (t *thriftWriter) beginElement() {
+	t.lastIDs = append(t.lastIDs, t.lastID)
+	t.lastID = 0
+}
+
+// endStruct ends the struct started last.
+func (t *thriftWriter) endStruct() {
+	t.stop()
+	t.lastID = t.lastIDs[len(t.lastIDs)-1]
+	t.lastIDs = t.lastIDs[:len(t.lastIDs)-1]
+}
+
+// stop ends the top-level struct.
+func (t *thriftWriter) stop() {
+	t.buf.WriteByte(0)
+}
@@ -1,26 +1,74 @@
 package backend
 
 import (
+	"bytes"
+	cryptosubtle "crypto/subtle"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 
+	"k8s.io/apimachinery/pkg/types"
+
 	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/trace"
 )
 
 // Server handles DriftReport webhooks and serves the API
 type Server struct {
-	store *Store
+	store        *Store
+	traces       TraceStore
+	auth         *Auth
+	webhookToken string
+	verifier     *trace.Verifier
 }
 
 // NewServer creates a new backend server
 func NewServer() *Server {
 	return &Server{
-		store: NewStore(),
+		store:  NewStore(),
+		traces: NewMemoryTraceStore(),
 	}
 }
 
+// WithStore replaces the drift report store, e.g. by one with other bounds.
+func (s *Server) WithStore(store *Store) *Server {
+	s.store = store
+	return s
+}
+
+// WithTraceStore replaces the in-memory trace store
+func (s *Server) WithTraceStore(traces TraceStore) *Server {
+	s.traces = traces
+	return s
+}
+
+// WithAuth requires OIDC authentication for reading and deleting drift, and
+// restricts each user to the namespaces of their teams. Receiving reports and
+// traces is authenticated separately, see WithWebhookToken.
+func (s *Server) WithAuth(auth *Auth) *Server {
+	s.auth = auth
+	return s
+}
+
+// WithWebhookToken requires the webhook to send token as bearer token when
+// posting reports and traces.
+func (s *Server) WithWebhookToken(token string) *Server {
+	s.webhookToken = token
+	return s
+}
+
+// WithTraceVerifier verifies the hop signatures of the traces returned by
+// GET /api/v1/traces/{uid}, flagging forged or unsigned hops.
+func (s *Server) WithTraceVerifier(verifier *trace.Verifier) *Server {
+	s.verifier = verifier
+	return s
+}
+
 // Store returns the underlying store
 func (s *Server) Store() *Store {
 	return s.store
@@ -31,12 +79,24 @@ func (s *Server) Handler() http.Handler {
 	mux := http.NewServeMux()
 
 	// Webhook endpoint - receives DriftReports
-	mux.HandleFunc("POST /webhook", s.handleWebhook)
+	mux.Handle("POST /webhook", s.ingest(s.handleWebhook))
 
 	// API endpoints
-	mux.HandleFunc("GET /api/v1/drifts", s.handleListDrifts)
-	mux.HandleFunc("GET /api/v1/drifts/{id}", s.handleGetDrift)
-	mux.HandleFunc("DELETE /api/v1/drifts/{id}", s.handleDeleteDrift)
+	mux.Handle("GET /api/v1/drifts", s.authenticated(s.handleListDrifts))
+	mux.Handle("GET /api/v1/drifts/export", s.authenticated(s.handleExportDrifts))
+	mux.Handle("GET /api/v1/drifts/{id}", s.authenticated(s.handleGetDrift))
+	mux.Handle("DELETE /api/v1/drifts/{id}", s.authenticated(s.handleDeleteDrift))
+	mux.Handle("GET /api/v1/drifts/{id}/trace", s.authenticated(s.handleGetDriftTrace))
+	mux.Handle("POST /api/v1/traces", s.ingest(s.handleAddTrace))
+	mux.Handle("GET /api/v1/traces/{uid}", s.authenticated(s.handleGetTraces))
+	mux.Handle("POST /api/v1/iac/correlate", s.authenticated(s.handleCorrelateIaC))
+	mux.Handle("GET /api/v1/digest", s.authenticated(s.handleDigest))
+	mux.Handle("GET /api/v1/actors/changes", s.authenticated(s.handleActorChanges))
+
+	// Login endpoints
+	if s.auth != nil {
+		s.auth.register(mux)
+	}
 
 	// Health endpoint
 	mux.HandleFunc("GET /healthz", s.handleHealth)
@@ -44,6 +104,49 @@ func (s *Server) Handler() http.Handler {
 	return mux
 }
 
+// authenticated wraps h with authentication, if enabled.
+func (s *Server) authenticated(h http.HandlerFunc) http.Handler {
+	if s.auth == nil {
+		return h
+	}
+	return s.auth.authenticate(h)
+}
+
+// ingest wraps h with the webhook token check, if enabled.
+func (s *Server) ingest(h http.HandlerFunc) http.Handler {
+	if s.webhookToken == "" {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || cryptosubtle.ConstantTimeCompare([]byte(token), []byte(s.webhookToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="kausality-webhook"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		h(w, r)
+	})
+}
+
+// visibleStore returns the store restricted to the namespaces of the
+// request's user.
+func (s *Server) visibleStore(r *http.Request) *Store {
+	sc := scopeFrom(r.Context())
+	if sc == nil || sc.all {
+		return s.store
+	}
+	return s.store.Visible(sc.allows)
+}
+
+// getVisible returns a report by ID if the request's user may see it.
+func (s *Server) getVisible(r *http.Request, id string) (*StoredReport, bool) {
+	stored, ok := s.store.Get(id)
+	if !ok || !scopeFrom(r.Context()).allows(reportNamespace(stored)) {
+		return nil, false
+	}
+	return stored, true
+}
+
 // handleWebhook receives DriftReports
 func (s *Server) handleWebhook(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
@@ -69,7 +172,7 @@ func (s *Server) handleWebhook(w http.ResponseWriter, r *http.Request) {
 
 // handleListDrifts returns all stored drift reports
 func (s *Server) handleListDrifts(w http.ResponseWriter, r *http.Request) {
-	reports := s.store.List()
+	reports := s.visibleStore(r).List()
 
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(map[string]interface{}{
@@ -86,7 +189,7 @@ func (s *Server) handleGetDrift(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	report, ok := s.store.Get(id)
+	report, ok := s.getVisible(r, id)
 	if !ok {
 		http.Error(w, "not found", http.StatusNotFound)
 		return
@@ -96,6 +199,233 @@ func (s *Server) handleGetDrift(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(report)
 }
 
+// handleGetDriftTrace renders the causal chain of a drift report as a diagram.
+// The format query parameter selects "mermaid" (default) or "dot".
+func (s *Server) handleGetDriftTrace(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		http.Error(w, "missing id", http.StatusBadRequest)
+		return
+	}
+
+	stored, ok := s.getVisible(r, id)
+	if !ok {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = trace.DiagramMermaid
+	}
+
+	diagram, err := trace.RenderDiagram(format, DriftTrace(stored.Report), s.visibleStore(r).DriftSiblings(stored.Report))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte(diagram))
+}
+
+// handleAddTrace receives TraceRecords mirrored by the webhook
+func (s *Server) handleAddTrace(w http.ResponseWriter, r *http.Request) {
+	var record v1alpha1.TraceRecord
+	if err := json.NewDecoder(r.Body).Decode(&record); err != nil {
+		http.Error(w, "invalid TraceRecord", http.StatusBadRequest)
+		return
+	}
+	if record.Spec.Object.Kind == "" || record.Spec.Object.Name == "" {
+		http.Error(w, "object kind and name are required", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.traces.AddTrace(&record); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// handleGetTraces returns the trace history of an object by UID, oldest first
+func (s *Server) handleGetTraces(w http.ResponseWriter, r *http.Request) {
+	uid := types.UID(r.PathValue("uid"))
+	if uid == "" {
+		http.Error(w, "missing uid", http.StatusBadRequest)
+		return
+	}
+
+	records, err := s.traces.Traces(uid)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sc := scopeFrom(r.Context())
+	records = slices.DeleteFunc(records, func(record *v1alpha1.TraceRecord) bool {
+		return !sc.allows(record.Spec.Object.Namespace)
+	})
+	if len(records) == 0 {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	response := map[string]interface{}{
+		"uid":   uid,
+		"items": records,
+		"count": len(records),
+	}
+	// Verification results, in the order of items. Records whose trace
+	// can't be parsed have no valid hops; traces whose final hop is not the
+	// hop of the record's object are invalid.
+	if s.verifier != nil {
+		verifications := make([]trace.Verification, len(records))
+		for i, record := range records {
+			t, _ := trace.Parse(string(record.Spec.Trace.Raw))
+			object := record.Spec.Object
+			verifications[i] = s.verifier.Verify(t, trace.Subject{
+				Kind:       object.Kind,
+				Namespace:  object.Namespace,
+				Name:       object.Name,
+				UID:        object.UID,
+				Generation: object.Generation,
+			})
+		}
+		response["verification"] = verifications
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// handleCorrelateIaC correlates a terraform plan or driftctl report with open drift reports
+func (s *Server) handleCorrelateIaC(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	resources, err := ParseIaCReport(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(s.visibleStore(r).Correlate(resources))
+}
+
+// handleDigest aggregates drift over a window. The window query parameter is
+// a duration (default one week), the format parameter selects "json"
+// (default) or "markdown".
+func (s *Server) handleDigest(w http.ResponseWriter, r *http.Request) {
+	window := DefaultDigestWindow
+	if v := r.URL.Query().Get("window"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			http.Error(w, "invalid window", http.StatusBadRequest)
+			return
+		}
+		window = d
+	}
+
+	digest := s.visibleStore(r).Digest(time.Now(), window)
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(digest)
+	case "markdown":
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		_, _ = w.Write([]byte(digest.Markdown()))
+	default:
+		http.Error(w, "unsupported format "+format, http.StatusBadRequest)
+	}
+}
+
+// handleExportDrifts exports the drift detected between the from and to
+// query parameters (RFC 3339, by default the last DefaultExportWindow) for
+// compliance evidence. The format parameter selects "csv" (default) or
+// "parquet".
+func (s *Server) handleExportDrifts(w http.ResponseWriter, r *http.Request) {
+	from, to, err := queryWindow(r, DefaultExportWindow)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	var contentType string
+	var write func(io.Writer, []ExportRow) error
+	switch format {
+	case "", ExportCSV:
+		format, contentType, write = ExportCSV, "text/csv; charset=utf-8", WriteExportCSV
+	case ExportParquet:
+		contentType, write = "application/vnd.apache.parquet", WriteExportParquet
+	default:
+		http.Error(w, "unsupported format "+format, http.StatusBadRequest)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := write(&buf, s.visibleStore(r).Export(from, to)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	const stamp = "20060102T150405Z"
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="kausality-drift-%s-%s.%s"`,
+		from.UTC().Format(stamp), to.UTC().Format(stamp), format))
+	_, _ = w.Write(buf.Bytes())
+}
+
+// queryWindow parses the from and to query parameters (RFC 3339). To
+// defaults to now, from to window before to.
+func queryWindow(r *http.Request, window time.Duration) (from, to time.Time, err error) {
+	to = time.Now()
+	if v := r.URL.Query().Get("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339, v); err != nil {
+			return from, to, errors.New("invalid to")
+		}
+	}
+	from = to.Add(-window)
+	if v := r.URL.Query().Get("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339, v); err != nil || !from.Before(to) {
+			return from, to, errors.New("invalid from")
+		}
+	}
+	return from, to, nil
+}
+
+// handleActorChanges lists the objects changed by the actor named by the
+// user or hash query parameter between from and to (RFC 3339, by default
+// the last DefaultActorWindow), from drift reports and trace records.
+func (s *Server) handleActorChanges(w http.ResponseWriter, r *http.Request) {
+	q := ActorQuery{User: r.URL.Query().Get("user"), Hash: r.URL.Query().Get("hash")}
+	if (q.User == "") == (q.Hash == "") {
+		http.Error(w, "either user or hash is required", http.StatusBadRequest)
+		return
+	}
+	var err error
+	if q.From, q.To, err = queryWindow(r, DefaultActorWindow); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	records, err := s.traces.Records(q.From, q.To)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sc := scopeFrom(r.Context())
+	records = slices.DeleteFunc(records, func(record *v1alpha1.TraceRecord) bool {
+		return !sc.allows(record.Spec.Object.Namespace)
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(QueryActor(q, s.visibleStore(r).Detections(q.From), records))
+}
+
 // handleDeleteDrift removes a drift report
 func (s *Server) handleDeleteDrift(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
@@ -103,6 +433,12 @@ func (s *Server) handleDeleteDrift(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "missing id", http.StatusBadRequest)
 		return
 	}
+	if _, exists := s.store.Get(id); exists {
+		if _, ok := s.getVisible(r, id); !ok {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+	}
 
 	s.store.Remove(id)
 	w.WriteHeader(http.StatusNoContent)
@@ -114,6 +450,7 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(map[string]interface{}{
 		"status":     "ok",
 		"driftCount": s.store.Count(),
+		"evicted":    s.store.Evicted(),
 		"time":       time.Now().Format(time.RFC3339),
 	})
 }
@@ -3,18 +3,25 @@ package backend
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 
 	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/trace"
 )
 
 func TestServer_Webhook_ReceivesDriftReport(t *testing.T) {
@@ -299,6 +306,69 @@ func TestServer_GetDrift_NotFound(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, rec.Code)
 }
 
+func TestServer_GetDriftTrace(t *testing.T) {
+	server := NewServer()
+	handler := server.Handler()
+
+	parent := v1alpha1.ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "prod", Name: "app", Generation: 3}
+	childTrace := `[{"apiVersion":"apps/v1","kind":"Deployment","name":"app","generation":3,"user":"alice"},` +
+		`{"apiVersion":"v1","kind":"ConfigMap","name":"app-config","generation":1,"user":"deployment-controller"}]`
+	reports := []v1alpha1.DriftReport{
+		{
+			Spec: v1alpha1.DriftReportSpec{
+				ID:        "trace-test",
+				Phase:     v1alpha1.DriftReportPhaseDetected,
+				Parent:    parent,
+				Child:     v1alpha1.ObjectReference{APIVersion: "v1", Kind: "ConfigMap", Namespace: "prod", Name: "app-config", Generation: 2},
+				NewObject: runtime.RawExtension{Raw: []byte(`{"metadata":{"annotations":{"kausality.io/trace":` + strconv.Quote(childTrace) + `}}}`)},
+				Request:   v1alpha1.RequestContext{User: "deployment-controller", UID: "req-1"},
+			},
+		},
+		{
+			Spec: v1alpha1.DriftReportSpec{
+				ID:      "sibling",
+				Phase:   v1alpha1.DriftReportPhaseDetected,
+				Parent:  parent,
+				Child:   v1alpha1.ObjectReference{APIVersion: "v1", Kind: "Secret", Namespace: "prod", Name: "app-secret"},
+				Request: v1alpha1.RequestContext{User: "deployment-controller", UID: "req-2"},
+			},
+		},
+	}
+	for i := range reports {
+		server.Store().Add(&reports[i])
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/drifts/trace-test/trace", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `flowchart TD
+    h0["Deployment/app<br/>gen 3<br/>alice"]
+    h1["ConfigMap/app-config<br/>gen 2<br/>deployment-controller"]
+    h0 --> h1
+    s0["Secret/app-secret<br/>gen 0<br/>deployment-controller"]
+    h0 -.-> s0
+    style h1 stroke-width:3px
+`, rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/drifts/trace-test/trace?format=dot", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "digraph trace {")
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/drifts/trace-test/trace?format=svg", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/drifts/missing/trace", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
 func TestServer_DeleteDrift(t *testing.T) {
 	server := NewServer()
 	handler := server.Handler()
@@ -346,6 +416,7 @@ func TestServer_Health(t *testing.T) {
 	var health struct {
 		Status     string `json:"status"`
 		DriftCount int    `json:"driftCount"`
+		Evicted    int64  `json:"evicted"`
 		Time       string `json:"time"`
 	}
 	err := json.Unmarshal(rec.Body.Bytes(), &health)
@@ -439,3 +510,176 @@ func TestServer_FullWorkflow(t *testing.T) {
 	_ = json.Unmarshal(body, &listResult)
 	assert.Equal(t, 0, listResult.Count)
 }
+
+func TestServer_Traces(t *testing.T) {
+	server := NewServer()
+	handler := server.Handler()
+
+	post := func(record *v1alpha1.TraceRecord) int {
+		body, err := json.Marshal(record)
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/traces", bytes.NewReader(body)))
+		return rec.Code
+	}
+
+	created := traceRecord("app-abc", "", "CREATE")
+	created.Spec.Trace = runtime.RawExtension{Raw: []byte(`[{"apiVersion":"apps/v1","kind":"Deployment","name":"app","generation":1,"user":"alice"}]`)}
+	assert.Equal(t, http.StatusCreated, post(created))
+	assert.Equal(t, http.StatusCreated, post(traceRecord("app-abc", "uid-1", "DELETE")))
+	assert.Equal(t, http.StatusBadRequest, post(&v1alpha1.TraceRecord{}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/traces/uid-1", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var response struct {
+		UID   string                 `json:"uid"`
+		Items []v1alpha1.TraceRecord `json:"items"`
+		Count int                    `json:"count"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, "uid-1", response.UID)
+	require.Equal(t, 2, response.Count)
+	assert.Equal(t, "CREATE", response.Items[0].Spec.Request.Operation)
+	assert.JSONEq(t, string(created.Spec.Trace.Raw), string(response.Items[0].Spec.Trace.Raw))
+	assert.Equal(t, "DELETE", response.Items[1].Spec.Request.Operation)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/traces/unknown", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestServer_TracesVerification(t *testing.T) {
+	key := []byte("cluster-key")
+	handler := NewServer().WithTraceVerifier(trace.NewHMACVerifier(key)).Handler()
+
+	sign := func(uid types.UID) trace.Trace {
+		tr := trace.Trace{trace.NewHop("apps/v1", "ReplicaSet", "app-abc", 1, "alice", "req-1")}
+		require.NoError(t, trace.NewHMACSigner(key).Sign(tr, 1, trace.Subject{Namespace: "default", UID: uid}))
+		return tr
+	}
+	signed := sign("uid-1")
+	forged := signed.Append(trace.NewHop("apps/v1", "ReplicaSet", "app-abc", 1, "mallory", ""))
+	copied := sign("uid-2")
+	for _, tr := range []trace.Trace{signed, forged, copied} {
+		record := traceRecord("app-abc", "uid-1", "UPDATE")
+		record.Spec.Object.Generation = 1
+		record.Spec.Trace = runtime.RawExtension{Raw: []byte(tr.String())}
+		body, err := json.Marshal(record)
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/traces", bytes.NewReader(body)))
+		require.Equal(t, http.StatusCreated, rec.Code)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/traces/uid-1", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var response struct {
+		Verification []trace.Verification `json:"verification"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, []trace.Verification{
+		{Valid: true, Hops: []trace.HopSignature{trace.HopSignatureValid}},
+		{Valid: false, Hops: []trace.HopSignature{trace.HopSignatureValid, trace.HopSignatureMissing}, Mismatch: `final hop is in namespace "", not "default"`},
+		{Valid: false, Hops: []trace.HopSignature{trace.HopSignatureValid}, Mismatch: `final hop is of UID "uid-2", not "uid-1"`},
+	}, response.Verification)
+}
+
+func TestServer_WebhookToken(t *testing.T) {
+	handler := NewServer().WithWebhookToken("s3cret").Handler()
+
+	post := func(path string, body interface{}, token string) int {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	report := driftIn("drift-1", "default")
+	record := traceRecord("app-abc", "uid-1", "UPDATE")
+	assert.Equal(t, http.StatusUnauthorized, post("/webhook", report, ""))
+	assert.Equal(t, http.StatusUnauthorized, post("/webhook", report, "wrong"))
+	assert.Equal(t, http.StatusUnauthorized, post("/api/v1/traces", record, ""))
+	assert.Equal(t, http.StatusOK, post("/webhook", report, "s3cret"))
+	assert.Equal(t, http.StatusCreated, post("/api/v1/traces", record, "s3cret"))
+}
+
+// ingestLoad posts n drift reports from 8 writers to the server while 4
+// readers list drift until the writers are done. It returns the number of
+// failed requests.
+func ingestLoad(tb testing.TB, handler http.Handler, n int) int64 {
+	bodies := make([][]byte, n)
+	for i := range bodies {
+		body, err := json.Marshal(v1alpha1.DriftReport{Spec: v1alpha1.DriftReportSpec{
+			ID:    fmt.Sprintf("load-%d", i),
+			Phase: v1alpha1.DriftReportPhaseDetected,
+			Child: v1alpha1.ObjectReference{APIVersion: "v1", Kind: "ConfigMap", Namespace: "default", Name: fmt.Sprintf("cm-%d", i)},
+		}})
+		require.NoError(tb, err)
+		bodies[i] = body
+	}
+
+	var failed, next atomic.Int64
+	var writers, readers sync.WaitGroup
+	done := make(chan struct{})
+	for range 8 {
+		writers.Go(func() {
+			for i := next.Add(1) - 1; i < int64(n); i = next.Add(1) - 1 {
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(bodies[i])))
+				if rec.Code != http.StatusOK {
+					failed.Add(1)
+				}
+			}
+		})
+	}
+	for range 4 {
+		readers.Go(func() {
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/drifts", nil))
+				if rec.Code != http.StatusOK {
+					failed.Add(1)
+				}
+			}
+		})
+	}
+	writers.Wait()
+	close(done)
+	readers.Wait()
+	return failed.Load()
+}
+
+func TestServer_Load(t *testing.T) {
+	server := NewServer().WithStore(NewStore(WithMaxReports(1000)))
+
+	start := time.Now()
+	assert.Zero(t, ingestLoad(t, server.Handler(), 5000))
+	t.Logf("ingested 5000 reports in %s", time.Since(start))
+
+	assert.LessOrEqual(t, server.Store().Count(), 1000)
+	assert.Equal(t, int64(5000-server.Store().Count()), server.Store().Evicted())
+	_, ok := server.Store().Get("load-4999")
+	assert.True(t, ok, "recent drift is kept")
+}
+
+func BenchmarkServer_Ingest(b *testing.B) {
+	const n = 1000
+	for b.Loop() {
+		server := NewServer()
+		require.Zero(b, ingestLoad(b, server.Handler(), n))
+	}
+	b.ReportMetric(float64(n*b.N)/b.Elapsed().Seconds(), "reports/s")
+}
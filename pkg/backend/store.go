@@ -1,81 +1,389 @@
 package backend
 
 import (
+	"hash/fnv"
+	"slices"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
 )
 
+// maxHistory is the number of resolved reports kept in the history
+const maxHistory = 100
+
+// maxDetections is the number of detected reports kept for trend analysis
+const maxDetections = 10000
+
 // StoredReport wraps a DriftReport with metadata
 type StoredReport struct {
 	Report     *v1alpha1.DriftReport `json:"report"`
 	ReceivedAt time.Time             `json:"receivedAt"`
+	ResolvedAt *time.Time            `json:"resolvedAt,omitempty"`
+	// Diff is a unified diff of the child from the old to the new object,
+	// without metadata and status.
+	Diff string `json:"diff,omitempty"`
+	// Occurrences is the number of detections of the drift, folding repeated
+	// reports of the same ID into one incident. FirstSeen and LastSeen are
+	// when the first and the last of them were detected.
+	Occurrences int32     `json:"occurrences"`
+	FirstSeen   time.Time `json:"firstSeen"`
+	LastSeen    time.Time `json:"lastSeen"`
+}
+
+// addOccurrences adds the detections a report carries to the incident.
+// Reports without occurrences count once, when they were received.
+func (r *StoredReport) addOccurrences(report *v1alpha1.DriftReport, receivedAt time.Time) {
+	count, first, last := int32(1), receivedAt, receivedAt
+	if o := report.Spec.Occurrences; o != nil && o.Count > 0 {
+		count, first, last = o.Count, o.FirstSeen.Time, o.LastSeen.Time
+	}
+	r.merge(&StoredReport{Occurrences: count, FirstSeen: first, LastSeen: last})
+}
+
+// merge adds the occurrences of another incident of the drift.
+func (r *StoredReport) merge(other *StoredReport) {
+	r.Occurrences += other.Occurrences
+	if r.FirstSeen.IsZero() || (!other.FirstSeen.IsZero() && other.FirstSeen.Before(r.FirstSeen)) {
+		r.FirstSeen = other.FirstSeen
+	}
+	if other.LastSeen.After(r.LastSeen) {
+		r.LastSeen = other.LastSeen
+	}
+}
+
+// storeShards is the number of shards of the open reports of a store, so
+// that concurrent webhooks and readers mostly take different locks.
+const storeShards = 32
+
+// DefaultMaxReports is the default number of open reports kept in a store.
+const DefaultMaxReports = 50000
+
+// EvictionPolicy decides which report a full store drops.
+type EvictionPolicy string
+
+const (
+	// EvictOldest evicts the open report seen least recently to keep a new one.
+	EvictOldest EvictionPolicy = "oldest"
+	// EvictNewest drops new drift while the store is full. Repeated reports
+	// of stored drift and resolutions are still kept.
+	EvictNewest EvictionPolicy = "newest"
+)
+
+// StoreOption configures a Store.
+type StoreOption func(*Store)
+
+// WithMaxReports bounds the number of open reports. The bound is split over
+// the shards of the store, so a store may evict reports before holding
+// n of them if their IDs are unevenly distributed.
+func WithMaxReports(n int) StoreOption {
+	return func(s *Store) {
+		s.maxReports = n
+	}
+}
+
+// WithEvictionPolicy sets the report dropped by a full store.
+func WithEvictionPolicy(policy EvictionPolicy) StoreOption {
+	return func(s *Store) {
+		s.eviction = policy
+	}
+}
+
+// WithMaxDetections sets the number of detected reports kept for trend
+// analysis.
+func WithMaxDetections(n int) StoreOption {
+	return func(s *Store) {
+		s.detections = newRing[*StoredReport](n)
+	}
 }
 
-// Store holds drift reports in memory
+// Store holds drift reports in memory, bounded in the number of open
+// reports, resolved reports and detections. It is safe for concurrent use.
 type Store struct {
+	shards     [storeShards]storeShard
+	maxReports int
+	eviction   EvictionPolicy
+	evicted    atomic.Int64
+
+	mu      sync.RWMutex
+	history *ring[*StoredReport] // resolved reports
+	// detections are all detected reports, including those resolved or
+	// removed since
+	detections *ring[*StoredReport]
+}
+
+// storeShard holds the open reports whose IDs hash to it.
+type storeShard struct {
 	mu      sync.RWMutex
 	reports map[string]*StoredReport // keyed by report ID
 }
 
 // NewStore creates a new in-memory store
-func NewStore() *Store {
-	return &Store{
-		reports: make(map[string]*StoredReport),
+func NewStore(opts ...StoreOption) *Store {
+	s := &Store{
+		maxReports: DefaultMaxReports,
+		eviction:   EvictOldest,
+		history:    newRing[*StoredReport](maxHistory),
+		detections: newRing[*StoredReport](maxDetections),
+	}
+	for i := range s.shards {
+		s.shards[i].reports = make(map[string]*StoredReport)
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
-// Add adds or updates a drift report
-func (s *Store) Add(report *v1alpha1.DriftReport) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// shard returns the shard holding the report of the given ID.
+func (s *Store) shard(id string) *storeShard {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(id))
+	return &s.shards[h.Sum32()%storeShards]
+}
 
-	id := report.Spec.ID
+// shardLimit is the number of open reports kept per shard, at least one.
+func (s *Store) shardLimit() int {
+	return max(1, s.maxReports/storeShards)
+}
 
-	// If phase is Resolved, remove from store
+// Add adds or updates a drift report
+func (s *Store) Add(report *v1alpha1.DriftReport) {
+	// If phase is Resolved, move the reports it closes from open reports to history
 	if report.Spec.Phase == v1alpha1.DriftReportPhaseResolved {
-		delete(s.reports, id)
+		now := time.Now()
+		resolved := &StoredReport{Report: report, ReceivedAt: now, ResolvedAt: &now, Diff: specDiff(report)}
+		for _, closedID := range resolvedIDs(report) {
+			if prev, ok := s.shard(closedID).take(closedID); ok {
+				if prev.ReceivedAt.Before(resolved.ReceivedAt) {
+					resolved.ReceivedAt = prev.ReceivedAt
+				}
+				// Resolutions keep the diff and occurrences of the drift they close
+				if prev.Diff != "" {
+					resolved.Diff = prev.Diff
+				}
+				resolved.merge(prev)
+			}
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.history.push(resolved)
 		return
 	}
 
-	s.reports[id] = &StoredReport{
+	stored := &StoredReport{
 		Report:     report,
 		ReceivedAt: time.Now(),
+		Diff:       specDiff(report),
+	}
+	added, evicted := s.shard(report.Spec.ID).put(stored, s.shardLimit(), s.eviction)
+	if evicted {
+		s.evicted.Add(1)
+	}
+	if !added {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.detections.push(stored)
+}
+
+// put stores a detected report, and returns whether it is new drift and
+// whether a report was evicted for it or it was dropped.
+func (sh *storeShard) put(stored *StoredReport, limit int, policy EvictionPolicy) (added, evicted bool) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	id := stored.Report.Spec.ID
+	prev, repeated := sh.reports[id]
+	// Repeated reports of an open drift are the same incident. Stored
+	// reports are shared with readers, so the incident is copied.
+	if repeated {
+		stored.merge(prev)
+	} else if len(sh.reports) >= limit {
+		if policy == EvictNewest {
+			return false, true
+		}
+		delete(sh.reports, sh.leastRecentlySeen())
+		evicted = true
+	}
+	stored.addOccurrences(stored.Report, stored.ReceivedAt)
+	sh.reports[id] = stored
+	return !repeated, evicted
+}
+
+// leastRecentlySeen returns the ID of the report of the shard seen least
+// recently.
+func (sh *storeShard) leastRecentlySeen() string {
+	var oldestID string
+	var oldest time.Time
+	for id, r := range sh.reports {
+		if oldestID == "" || r.LastSeen.Before(oldest) {
+			oldestID, oldest = id, r.LastSeen
+		}
+	}
+	return oldestID
+}
+
+// take removes and returns the report of the given ID.
+func (sh *storeShard) take(id string) (*StoredReport, bool) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	r, ok := sh.reports[id]
+	delete(sh.reports, id)
+	return r, ok
+}
+
+// resolvedIDs returns the IDs of the reports a resolution closes: the
+// Detected reports it names, or the resolution ID itself for senders that
+// don't name them.
+func resolvedIDs(report *v1alpha1.DriftReport) []string {
+	ids := []string{report.Spec.ID}
+	if r := report.Spec.Resolution; r != nil {
+		ids = append(ids, r.DetectedIDs...)
 	}
+	return ids
 }
 
 // Get retrieves a report by ID
 func (s *Store) Get(id string) (*StoredReport, bool) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	sh := s.shard(id)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
 
-	r, ok := s.reports[id]
+	r, ok := sh.reports[id]
 	return r, ok
 }
 
 // List returns all stored reports
 func (s *Store) List() []*StoredReport {
+	result := make([]*StoredReport, 0, s.Count())
+	for i := range s.shards {
+		sh := &s.shards[i]
+		sh.mu.RLock()
+		for _, r := range sh.reports {
+			result = append(result, r)
+		}
+		sh.mu.RUnlock()
+	}
+	return result
+}
+
+// History returns resolved reports, most recently resolved first
+func (s *Store) History() []*StoredReport {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	result := make([]*StoredReport, 0, len(s.reports))
-	for _, r := range s.reports {
-		result = append(result, r)
-	}
-	return result
+	items := s.history.items()
+	slices.Reverse(items)
+	return items
+}
+
+// Detections returns the reports detected since the given time, oldest first.
+// Resolved and removed reports are included; the oldest detections are
+// dropped beyond a fixed limit.
+func (s *Store) Detections(since time.Time) []*StoredReport {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i := sort.Search(s.detections.len(), func(i int) bool {
+		return !s.detections.at(i).ReceivedAt.Before(since)
+	})
+	return s.detections.items()[i:]
 }
 
 // Remove removes a report by ID
 func (s *Store) Remove(id string) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	delete(s.reports, id)
+	s.shard(id).take(id)
 }
 
 // Count returns the number of stored reports
 func (s *Store) Count() int {
+	count := 0
+	for i := range s.shards {
+		sh := &s.shards[i]
+		sh.mu.RLock()
+		count += len(sh.reports)
+		sh.mu.RUnlock()
+	}
+	return count
+}
+
+// Evicted returns the number of open reports evicted or dropped because the
+// store was full.
+func (s *Store) Evicted() int64 {
+	return s.evicted.Load()
+}
+
+// Visible returns a snapshot of the store holding the reports whose namespace
+// allow accepts.
+func (s *Store) Visible(allow func(namespace string) bool) *Store {
+	visible := NewStore(WithMaxReports(s.maxReports), WithEvictionPolicy(s.eviction),
+		WithMaxDetections(s.detections.cap()))
+	for i := range s.shards {
+		sh, into := &s.shards[i], &visible.shards[i]
+		sh.mu.RLock()
+		for id, r := range sh.reports {
+			if allow(reportNamespace(r)) {
+				into.reports[id] = r
+			}
+		}
+		sh.mu.RUnlock()
+	}
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return len(s.reports)
+	for _, r := range s.history.items() {
+		if allow(reportNamespace(r)) {
+			visible.history.push(r)
+		}
+	}
+	for _, r := range s.detections.items() {
+		if allow(reportNamespace(r)) {
+			visible.detections.push(r)
+		}
+	}
+	return visible
+}
+
+// ring is a fixed-capacity buffer dropping its oldest items when full.
+type ring[T any] struct {
+	buf   []T
+	start int // index of the oldest item
+	n     int
+}
+
+func newRing[T any](capacity int) *ring[T] {
+	return &ring[T]{buf: make([]T, max(1, capacity))}
+}
+
+// push appends an item, dropping the oldest one if the ring is full.
+func (r *ring[T]) push(item T) {
+	if r.n < len(r.buf) {
+		r.buf[(r.start+r.n)%len(r.buf)] = item
+		r.n++
+		return
+	}
+	r.buf[r.start] = item
+	r.start = (r.start + 1) % len(r.buf)
+}
+
+// at returns the i-th item, oldest first.
+func (r *ring[T]) at(i int) T {
+	return r.buf[(r.start+i)%len(r.buf)]
+}
+
+func (r *ring[T]) len() int { return r.n }
+
+func (r *ring[T]) cap() int { return len(r.buf) }
+
+// items returns a copy of the items, oldest first.
+func (r *ring[T]) items() []T {
+	result := make([]T, 0, r.n)
+	for i := range r.n {
+		result = append(result, r.at(i))
+	}
+	return result
 }
@@ -1,12 +1,16 @@
 package backend
 
 import (
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 
 	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
 )
@@ -78,6 +82,52 @@ func TestStore_Add_Resolved_Removes(t *testing.T) {
 
 	_, ok := store.Get("drift-to-resolve")
 	assert.False(t, ok)
+
+	// Resolved report is kept in history
+	history := store.History()
+	require.Len(t, history, 1)
+	assert.Equal(t, "drift-to-resolve", history[0].Report.Spec.ID)
+	assert.NotNil(t, history[0].ResolvedAt)
+}
+
+func TestStore_Add_Resolved_ClosesDetectedIDs(t *testing.T) {
+	store := NewStore()
+	for _, id := range []string{"drift-a", "drift-b", "drift-other"} {
+		store.Add(&v1alpha1.DriftReport{Spec: v1alpha1.DriftReportSpec{ID: id, Phase: v1alpha1.DriftReportPhaseDetected}})
+	}
+
+	store.Add(&v1alpha1.DriftReport{Spec: v1alpha1.DriftReportSpec{
+		ID:    "resolution-id",
+		Phase: v1alpha1.DriftReportPhaseResolved,
+		Resolution: &v1alpha1.Resolution{
+			Kind:        v1alpha1.ResolutionManuallyReverted,
+			DetectedIDs: []string{"drift-a", "drift-b"},
+		},
+	}})
+
+	assert.Equal(t, 1, store.Count())
+	_, ok := store.Get("drift-other")
+	assert.True(t, ok)
+	history := store.History()
+	require.Len(t, history, 1)
+	assert.Equal(t, v1alpha1.ResolutionManuallyReverted, history[0].Report.Spec.Resolution.Kind)
+}
+
+func TestStore_History_Capped(t *testing.T) {
+	store := NewStore()
+	for i := 0; i < maxHistory+5; i++ {
+		store.Add(&v1alpha1.DriftReport{
+			Spec: v1alpha1.DriftReportSpec{
+				ID:    fmt.Sprintf("drift-%d", i),
+				Phase: v1alpha1.DriftReportPhaseResolved,
+			},
+		})
+	}
+
+	history := store.History()
+	require.Len(t, history, maxHistory)
+	assert.Equal(t, fmt.Sprintf("drift-%d", maxHistory+4), history[0].Report.Spec.ID, "most recent first")
+	assert.Equal(t, "drift-5", history[maxHistory-1].Report.Spec.ID, "oldest dropped")
 }
 
 func TestStore_List(t *testing.T) {
@@ -192,3 +242,203 @@ func TestStore_Update_Existing(t *testing.T) {
 	require.True(t, ok)
 	assert.Equal(t, "user-2", stored.Report.Spec.Request.User)
 }
+
+func TestStore_Occurrences(t *testing.T) {
+	store := NewStore()
+	first := time.Date(2026, 1, 15, 10, 30, 0, 0, time.UTC)
+	detected := func(count int32, firstSeen, lastSeen time.Time) *v1alpha1.DriftReport {
+		return &v1alpha1.DriftReport{Spec: v1alpha1.DriftReportSpec{
+			ID:    "drift-fight",
+			Phase: v1alpha1.DriftReportPhaseDetected,
+			Occurrences: &v1alpha1.Occurrences{
+				Count:     count,
+				FirstSeen: metav1.NewTime(firstSeen),
+				LastSeen:  metav1.NewTime(lastSeen),
+			},
+		}}
+	}
+
+	store.Add(detected(1, first, first))
+	store.Add(detected(412, first.Add(time.Second), first.Add(30*time.Second)))
+	// Another replica reporting the same drift
+	store.Add(detected(3, first.Add(10*time.Second), first.Add(20*time.Second)))
+
+	stored, ok := store.Get("drift-fight")
+	require.True(t, ok)
+	assert.Equal(t, int32(416), stored.Occurrences)
+	assert.Equal(t, first, stored.FirstSeen)
+	assert.Equal(t, first.Add(30*time.Second), stored.LastSeen)
+	assert.Len(t, store.Detections(time.Time{}), 1, "repeated reports are one incident")
+
+	// Reports without occurrences count once
+	store.Add(&v1alpha1.DriftReport{Spec: v1alpha1.DriftReportSpec{ID: "drift-fight", Phase: v1alpha1.DriftReportPhaseDetected}})
+	stored, ok = store.Get("drift-fight")
+	require.True(t, ok)
+	assert.Equal(t, int32(417), stored.Occurrences)
+	assert.Equal(t, first, stored.FirstSeen)
+	assert.Equal(t, stored.ReceivedAt, stored.LastSeen)
+
+	// Resolutions keep the occurrences of the drift they close
+	store.Add(&v1alpha1.DriftReport{Spec: v1alpha1.DriftReportSpec{ID: "drift-fight", Phase: v1alpha1.DriftReportPhaseResolved}})
+	history := store.History()
+	require.Len(t, history, 1)
+	assert.Equal(t, int32(417), history[0].Occurrences)
+	assert.Equal(t, first, history[0].FirstSeen)
+}
+
+func TestStore_Diff(t *testing.T) {
+	store := NewStore()
+	report := &v1alpha1.DriftReport{Spec: v1alpha1.DriftReportSpec{
+		ID:        "drift-diff",
+		Phase:     v1alpha1.DriftReportPhaseDetected,
+		Child:     v1alpha1.ObjectReference{Kind: "ReplicaSet", Name: "app-abc"},
+		OldObject: &runtime.RawExtension{Raw: []byte(`{"metadata":{"resourceVersion":"1"},"spec":{"replicas":3,"paused":false},"status":{"replicas":3}}`)},
+		NewObject: runtime.RawExtension{Raw: []byte(`{"metadata":{"resourceVersion":"2"},"spec":{"replicas":5,"paused":false},"status":{"replicas":5}}`)},
+	}}
+	store.Add(report)
+
+	stored, ok := store.Get("drift-diff")
+	require.True(t, ok)
+	assert.Equal(t, `--- old/ReplicaSet/app-abc
++++ new/ReplicaSet/app-abc
+@@ -1,3 +1,3 @@
+ spec:
+   paused: false
+-  replicas: 3
++  replicas: 5
+`, stored.Diff)
+
+	// Resolutions keep the diff of the drift they close
+	store.Add(&v1alpha1.DriftReport{Spec: v1alpha1.DriftReportSpec{ID: "drift-diff", Phase: v1alpha1.DriftReportPhaseResolved}})
+	history := store.History()
+	require.Len(t, history, 1)
+	assert.Equal(t, stored.Diff, history[0].Diff)
+
+	// Metadata and status changes are not shown
+	report.Spec.ID = "drift-status"
+	report.Spec.NewObject.Raw = []byte(`{"metadata":{"resourceVersion":"2"},"spec":{"replicas":3,"paused":false},"status":{"replicas":5}}`)
+	store.Add(report)
+	stored, ok = store.Get("drift-status")
+	require.True(t, ok)
+	assert.Empty(t, stored.Diff)
+
+	// Without an old object, the whole object is added
+	report.Spec.ID = "drift-create"
+	report.Spec.OldObject = nil
+	store.Add(report)
+	stored, ok = store.Get("drift-create")
+	require.True(t, ok)
+	assert.Contains(t, stored.Diff, "+  replicas: 3\n")
+}
+
+func detected(id string) *v1alpha1.DriftReport {
+	return &v1alpha1.DriftReport{Spec: v1alpha1.DriftReportSpec{ID: id, Phase: v1alpha1.DriftReportPhaseDetected}}
+}
+
+func TestStore_Eviction(t *testing.T) {
+	const n = 10 * storeShards
+
+	t.Run("oldest", func(t *testing.T) {
+		store := NewStore(WithMaxReports(storeShards))
+		for i := range n {
+			store.Add(detected(fmt.Sprintf("drift-%d", i)))
+		}
+		assert.LessOrEqual(t, store.Count(), storeShards)
+		assert.Equal(t, int64(n-store.Count()), store.Evicted())
+		_, ok := store.Get(fmt.Sprintf("drift-%d", n-1))
+		assert.True(t, ok, "the newest drift is kept")
+
+		// Detections keep evicted reports
+		assert.Len(t, store.Detections(time.Time{}), n)
+	})
+
+	t.Run("newest", func(t *testing.T) {
+		store := NewStore(WithMaxReports(storeShards), WithEvictionPolicy(EvictNewest))
+		for i := range n {
+			store.Add(detected(fmt.Sprintf("drift-%d", i)))
+		}
+		assert.LessOrEqual(t, store.Count(), storeShards)
+		assert.Equal(t, int64(n-store.Count()), store.Evicted())
+		_, ok := store.Get("drift-0")
+		assert.True(t, ok, "the oldest drift is kept")
+		assert.Len(t, store.Detections(time.Time{}), store.Count(), "dropped drift is not detected")
+
+		// Repeated reports of stored drift are kept
+		store.Add(detected("drift-0"))
+		stored, _ := store.Get("drift-0")
+		assert.Equal(t, int32(2), stored.Occurrences)
+	})
+}
+
+func TestStore_Detections_Capped(t *testing.T) {
+	store := NewStore(WithMaxDetections(3))
+	for i := range 5 {
+		store.Add(detected(fmt.Sprintf("drift-%d", i)))
+	}
+	detections := store.Detections(time.Time{})
+	require.Len(t, detections, 3)
+	assert.Equal(t, "drift-2", detections[0].Report.Spec.ID, "oldest dropped")
+	assert.Equal(t, "drift-4", detections[2].Report.Spec.ID)
+	assert.Equal(t, 5, store.Count())
+}
+
+func TestStore_Concurrent(t *testing.T) {
+	store := NewStore(WithMaxReports(100))
+	var wg sync.WaitGroup
+	for w := range 8 {
+		wg.Go(func() {
+			for i := range 200 {
+				id := fmt.Sprintf("drift-%d-%d", w, i%50)
+				store.Add(detected(id))
+				if i%10 == 0 {
+					store.Add(&v1alpha1.DriftReport{Spec: v1alpha1.DriftReportSpec{ID: id, Phase: v1alpha1.DriftReportPhaseResolved}})
+				}
+			}
+		})
+	}
+	for range 4 {
+		wg.Go(func() {
+			for range 200 {
+				store.List()
+				store.History()
+				store.Detections(time.Time{})
+				store.Visible(func(string) bool { return true })
+			}
+		})
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, store.Count(), 100)
+	assert.Len(t, store.History(), maxHistory)
+}
+
+func BenchmarkStore_Add(b *testing.B) {
+	store := NewStore()
+	reports := make([]*v1alpha1.DriftReport, 100000)
+	for i := range reports {
+		reports[i] = detected(fmt.Sprintf("drift-%d", i))
+	}
+	i := 0
+	for b.Loop() {
+		store.Add(reports[i%len(reports)])
+		i++
+	}
+}
+
+func BenchmarkStore_AddWithReaders(b *testing.B) {
+	store := NewStore()
+	for i := range 1000 {
+		store.Add(detected(fmt.Sprintf("drift-%d", i)))
+	}
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			// One in ten operations reads
+			if i%10 == 0 {
+				store.Get(fmt.Sprintf("drift-%d", i%1000))
+			} else {
+				store.Add(detected(fmt.Sprintf("drift-%d", i%100000)))
+			}
+			i++
+		}
+	})
+}
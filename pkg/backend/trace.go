@@ -0,0 +1,93 @@
+package backend
+
+import (
+	"encoding/json"
+
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/trace"
+)
+
+// DriftTrace returns the causal chain leading to a drift report: the chain
+// recorded on the child up to its parent, followed by a hop for the drifting request.
+func DriftTrace(report *v1alpha1.DriftReport) trace.Trace {
+	spec := report.Spec
+
+	// Recorded trace on the child, without the child's own previous hop
+	var prefix trace.Trace
+	if recorded := recordedTrace(spec.NewObject.Raw); len(recorded) > 0 {
+		last := recorded[len(recorded)-1]
+		if last.Kind == spec.Child.Kind && last.Name == spec.Child.Name {
+			prefix = recorded[:len(recorded)-1]
+		}
+	}
+
+	if len(prefix) == 0 || !hopRefersTo(prefix[len(prefix)-1], spec.Parent) {
+		prefix = prefix.Append(trace.Hop{
+			APIVersion: spec.Parent.APIVersion,
+			Kind:       spec.Parent.Kind,
+			Name:       spec.Parent.Name,
+			Generation: spec.Parent.Generation,
+		})
+	}
+
+	return prefix.Append(driftHop(report))
+}
+
+// DriftSiblings returns hops for other stored drift reports with the same parent.
+func (s *Store) DriftSiblings(report *v1alpha1.DriftReport) []trace.Hop {
+	var siblings []trace.Hop
+	for _, r := range s.List() {
+		other := r.Report.Spec
+		if other.ID == report.Spec.ID || !sameObject(other.Parent, report.Spec.Parent) {
+			continue
+		}
+		siblings = append(siblings, driftHop(r.Report))
+	}
+	return siblings
+}
+
+// driftHop returns the hop for the mutation that caused the drift report.
+func driftHop(report *v1alpha1.DriftReport) trace.Hop {
+	spec := report.Spec
+	return trace.Hop{
+		APIVersion: spec.Child.APIVersion,
+		Kind:       spec.Child.Kind,
+		Name:       spec.Child.Name,
+		Generation: spec.Child.Generation,
+		User:       spec.Request.User,
+		RequestUID: spec.Request.UID,
+	}
+}
+
+// recordedTrace extracts the kausality.io/trace annotation from a raw object.
+func recordedTrace(raw []byte) trace.Trace {
+	t, err := trace.Parse(annotation(raw, trace.TraceAnnotation))
+	if err != nil {
+		return nil
+	}
+	return t
+}
+
+// annotation extracts an annotation from a raw object.
+func annotation(raw []byte, key string) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var obj struct {
+		Metadata struct {
+			Annotations map[string]string `json:"annotations"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return ""
+	}
+	return obj.Metadata.Annotations[key]
+}
+
+func hopRefersTo(hop trace.Hop, ref v1alpha1.ObjectReference) bool {
+	return hop.Kind == ref.Kind && hop.Name == ref.Name
+}
+
+func sameObject(a, b v1alpha1.ObjectReference) bool {
+	return a.APIVersion == b.APIVersion && a.Kind == b.Kind && a.Namespace == b.Namespace && a.Name == b.Name
+}
@@ -0,0 +1,310 @@
+package backend
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+)
+
+const (
+	// maxTraceObjects is the number of objects whose traces are kept in memory
+	maxTraceObjects = 10000
+	// maxTraceRecords is the number of records kept per object
+	maxTraceRecords = 100
+	// minCompactRecords is the number of records appended to a trace file
+	// before it is compacted at the earliest
+	minCompactRecords = 10000
+)
+
+// TraceStore persists mirrored trace records keyed by object UID.
+// Implementations must be safe for concurrent use.
+type TraceStore interface {
+	// AddTrace stores a trace record. Records without a UID (from CREATE)
+	// are attached to the object once a later record carries its UID.
+	AddTrace(record *v1alpha1.TraceRecord) error
+	// Traces returns the records for an object UID, oldest first.
+	Traces(uid types.UID) ([]*v1alpha1.TraceRecord, error)
+	// Records returns the records admitted from (inclusive) to (exclusive),
+	// oldest first.
+	Records(from, to time.Time) ([]*v1alpha1.TraceRecord, error)
+}
+
+// MemoryTraceStore holds trace records in memory, bounded to the most
+// recently traced objects. Records are lost when the backend restarts; use
+// a FileTraceStore to keep them.
+type MemoryTraceStore struct {
+	mu      sync.Mutex
+	records map[types.UID][]*v1alpha1.TraceRecord
+	order   []types.UID // oldest first, for eviction
+	// pending holds records without a UID, keyed by object
+	pending      map[string][]*v1alpha1.TraceRecord
+	pendingOrder []string
+}
+
+var _ TraceStore = &MemoryTraceStore{}
+
+// NewMemoryTraceStore creates a new in-memory trace store
+func NewMemoryTraceStore() *MemoryTraceStore {
+	return &MemoryTraceStore{
+		records: make(map[types.UID][]*v1alpha1.TraceRecord),
+		pending: make(map[string][]*v1alpha1.TraceRecord),
+	}
+}
+
+// AddTrace stores a trace record
+func (s *MemoryTraceStore) AddTrace(record *v1alpha1.TraceRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := objectKey(record.Spec.Object)
+	uid := record.Spec.Object.UID
+	if uid == "" {
+		if _, ok := s.pending[key]; !ok {
+			s.pendingOrder = append(s.pendingOrder, key)
+		}
+		s.pending[key] = appendBounded(s.pending[key], record)
+		if len(s.pendingOrder) > maxTraceObjects {
+			delete(s.pending, s.pendingOrder[0])
+			s.pendingOrder = s.pendingOrder[1:]
+		}
+		return nil
+	}
+
+	existing, ok := s.records[uid]
+	if !ok {
+		s.order = append(s.order, uid)
+	}
+	// Claim records from before the UID was known
+	if pending, ok := s.pending[key]; ok {
+		existing = append(existing, pending...)
+		delete(s.pending, key)
+		s.pendingOrder = removeString(s.pendingOrder, key)
+	}
+	s.records[uid] = appendBounded(existing, record)
+	if len(s.order) > maxTraceObjects {
+		delete(s.records, s.order[0])
+		s.order = s.order[1:]
+	}
+	return nil
+}
+
+// Traces returns the records for an object UID, oldest first
+func (s *MemoryTraceStore) Traces(uid types.UID) ([]*v1alpha1.TraceRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := s.records[uid]
+	result := make([]*v1alpha1.TraceRecord, len(records))
+	copy(result, records)
+	return result, nil
+}
+
+// Records returns the records admitted from (inclusive) to (exclusive),
+// oldest first
+func (s *MemoryTraceStore) Records(from, to time.Time) ([]*v1alpha1.TraceRecord, error) {
+	var result []*v1alpha1.TraceRecord
+	for _, record := range s.snapshot() {
+		if t := record.Spec.Timestamp.Time; !t.Before(from) && t.Before(to) {
+			result = append(result, record)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Spec.Timestamp.Before(&result[j].Spec.Timestamp)
+	})
+	return result, nil
+}
+
+// snapshot returns the stored records in an order that restores the store
+// when added again: per object oldest first, records without UID last.
+func (s *MemoryTraceStore) snapshot() []*v1alpha1.TraceRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var records []*v1alpha1.TraceRecord
+	for _, uid := range s.order {
+		records = append(records, s.records[uid]...)
+	}
+	for _, key := range s.pendingOrder {
+		records = append(records, s.pending[key]...)
+	}
+	return records
+}
+
+// FileTraceStore keeps trace records in memory like MemoryTraceStore, with
+// the same bounds, and appends them to a file from which they are restored
+// on restart. Records evicted from memory are dropped from the file when it
+// is compacted, once it holds more than twice the retained records.
+type FileTraceStore struct {
+	memory *MemoryTraceStore
+	path   string
+
+	mu       sync.Mutex
+	file     *os.File
+	appended int // records in the file
+	retained int // records in memory at the last compaction
+}
+
+var _ TraceStore = &FileTraceStore{}
+
+// NewFileTraceStore opens the trace file at path, restoring its records.
+// The file is created if it does not exist.
+func NewFileTraceStore(path string) (*FileTraceStore, error) {
+	s := &FileTraceStore{memory: NewMemoryTraceStore(), path: path}
+
+	f, err := os.Open(path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, fmt.Errorf("failed to open trace file: %w", err)
+	default:
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			var record v1alpha1.TraceRecord
+			if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+				// A partially written last line, e.g. after a crash
+				continue
+			}
+			_ = s.memory.AddTrace(&record)
+			s.appended++
+		}
+		_ = f.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read trace file: %w", err)
+		}
+	}
+
+	// Rewrite the file, dropping evicted and partially written records
+	if err := s.compact(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// AddTrace stores a trace record and appends it to the file.
+func (s *FileTraceStore) AddTrace(record *v1alpha1.TraceRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return fmt.Errorf("trace file %s is not open", s.path)
+	}
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write trace file: %w", err)
+	}
+	s.appended++
+	if err := s.memory.AddTrace(record); err != nil {
+		return err
+	}
+	if s.appended > max(2*s.retained, minCompactRecords) {
+		return s.compact()
+	}
+	return nil
+}
+
+// Traces returns the records for an object UID, oldest first.
+func (s *FileTraceStore) Traces(uid types.UID) ([]*v1alpha1.TraceRecord, error) {
+	return s.memory.Traces(uid)
+}
+
+// Records returns the records admitted from (inclusive) to (exclusive),
+// oldest first.
+func (s *FileTraceStore) Records(from, to time.Time) ([]*v1alpha1.TraceRecord, error) {
+	return s.memory.Records(from, to)
+}
+
+// Close closes the trace file.
+func (s *FileTraceStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// compact replaces the file with the records in memory and reopens it for
+// appending. The caller must hold s.mu, unless s is not shared yet.
+func (s *FileTraceStore) compact() error {
+	records := s.memory.snapshot()
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to compact trace file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, record := range records {
+		if err := enc.Encode(record); err != nil {
+			_ = tmp.Close()
+			return fmt.Errorf("failed to compact trace file: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to compact trace file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to compact trace file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to compact trace file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to compact trace file: %w", err)
+	}
+	// The previous file is replaced now, stop appending to it either way
+	if s.file != nil {
+		_ = s.file.Close()
+		s.file = nil
+	}
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open trace file: %w", err)
+	}
+	s.file = f
+	s.appended = len(records)
+	s.retained = len(records)
+	return nil
+}
+
+// objectKey identifies an object independent of its API version.
+func objectKey(ref v1alpha1.ObjectReference) string {
+	return ref.Kind + "/" + ref.Namespace + "/" + ref.Name
+}
+
+// appendBounded appends a record, dropping the oldest beyond maxTraceRecords.
+func appendBounded(records []*v1alpha1.TraceRecord, record *v1alpha1.TraceRecord) []*v1alpha1.TraceRecord {
+	records = append(records, record)
+	if len(records) > maxTraceRecords {
+		records = records[len(records)-maxTraceRecords:]
+	}
+	return records
+}
+
+func removeString(list []string, s string) []string {
+	for i, v := range list {
+		if v == s {
+			return append(list[:i], list[i+1:]...)
+		}
+	}
+	return list
+}
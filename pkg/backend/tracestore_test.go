@@ -0,0 +1,147 @@
+package backend
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+)
+
+func traceRecord(name string, uid types.UID, operation string) *v1alpha1.TraceRecord {
+	return &v1alpha1.TraceRecord{
+		Spec: v1alpha1.TraceRecordSpec{
+			Object: v1alpha1.ObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "ReplicaSet",
+				Namespace:  "default",
+				Name:       name,
+				UID:        uid,
+			},
+			Request: v1alpha1.RequestContext{User: "alice", Operation: operation},
+		},
+	}
+}
+
+func operations(records []*v1alpha1.TraceRecord) []string {
+	var ops []string
+	for _, r := range records {
+		ops = append(ops, r.Spec.Request.Operation)
+	}
+	return ops
+}
+
+func TestMemoryTraceStore_History(t *testing.T) {
+	store := NewMemoryTraceStore()
+
+	// CREATE has no UID yet, the first UPDATE claims it
+	require.NoError(t, store.AddTrace(traceRecord("app-abc", "", "CREATE")))
+	require.NoError(t, store.AddTrace(traceRecord("app-abc", "uid-1", "UPDATE")))
+	require.NoError(t, store.AddTrace(traceRecord("app-abc", "uid-1", "DELETE")))
+	require.NoError(t, store.AddTrace(traceRecord("app-def", "uid-2", "UPDATE")))
+
+	records, err := store.Traces("uid-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"CREATE", "UPDATE", "DELETE"}, operations(records))
+
+	records, err = store.Traces("uid-2")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"UPDATE"}, operations(records))
+
+	records, err = store.Traces("missing")
+	require.NoError(t, err)
+	assert.Empty(t, records)
+}
+
+func TestMemoryTraceStore_RecreatedObject(t *testing.T) {
+	store := NewMemoryTraceStore()
+
+	require.NoError(t, store.AddTrace(traceRecord("app-abc", "", "CREATE")))
+	require.NoError(t, store.AddTrace(traceRecord("app-abc", "uid-1", "DELETE")))
+	// Same name, new object
+	require.NoError(t, store.AddTrace(traceRecord("app-abc", "", "CREATE")))
+	require.NoError(t, store.AddTrace(traceRecord("app-abc", "uid-2", "UPDATE")))
+
+	records, err := store.Traces("uid-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"CREATE", "DELETE"}, operations(records))
+
+	records, err = store.Traces("uid-2")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"CREATE", "UPDATE"}, operations(records))
+}
+
+func TestMemoryTraceStore_Bounds(t *testing.T) {
+	store := NewMemoryTraceStore()
+
+	for i := 0; i < maxTraceRecords+5; i++ {
+		require.NoError(t, store.AddTrace(traceRecord("app-abc", "uid-1", "UPDATE")))
+	}
+	records, err := store.Traces("uid-1")
+	require.NoError(t, err)
+	assert.Len(t, records, maxTraceRecords)
+
+	for i := 0; i < maxTraceObjects; i++ {
+		require.NoError(t, store.AddTrace(traceRecord("app", types.UID(fmt.Sprintf("obj-%d", i)), "UPDATE")))
+	}
+	records, err = store.Traces("uid-1")
+	require.NoError(t, err)
+	assert.Empty(t, records, "oldest object should be evicted")
+}
+
+func TestFileTraceStore_Restore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	store, err := NewFileTraceStore(path)
+	require.NoError(t, err)
+
+	require.NoError(t, store.AddTrace(traceRecord("app-abc", "", "CREATE")))
+	require.NoError(t, store.AddTrace(traceRecord("app-abc", "uid-1", "UPDATE")))
+	require.NoError(t, store.AddTrace(traceRecord("app-def", "", "CREATE")))
+	require.NoError(t, store.Close())
+
+	// A partially written record, e.g. after a crash, is skipped
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"spec":{"object":`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	store, err = NewFileTraceStore(path)
+	require.NoError(t, err)
+	defer func() { _ = store.Close() }()
+	records, err := store.Traces("uid-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"CREATE", "UPDATE"}, operations(records))
+
+	// Records without UID are still claimed after the restart
+	require.NoError(t, store.AddTrace(traceRecord("app-def", "uid-2", "DELETE")))
+	records, err = store.Traces("uid-2")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"CREATE", "DELETE"}, operations(records))
+}
+
+func TestFileTraceStore_Compaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	store, err := NewFileTraceStore(path)
+	require.NoError(t, err)
+	defer func() { _ = store.Close() }()
+
+	// Only the last maxTraceRecords records of the object are retained
+	for i := 0; i <= minCompactRecords; i++ {
+		require.NoError(t, store.AddTrace(traceRecord("app-abc", "uid-1", "UPDATE")))
+	}
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, maxTraceRecords, strings.Count(string(data), "\n"))
+
+	records, err := store.Traces("uid-1")
+	require.NoError(t, err)
+	assert.Len(t, records, maxTraceRecords)
+}
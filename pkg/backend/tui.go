@@ -19,6 +19,7 @@ var (
 	highlight = lipgloss.AdaptiveColor{Light: "#874BFD", Dark: "#7D56F4"}
 	special   = lipgloss.AdaptiveColor{Light: "#43BF6D", Dark: "#73F59F"}
 	warning   = lipgloss.AdaptiveColor{Light: "#FFA500", Dark: "#FFB347"}
+	danger    = lipgloss.AdaptiveColor{Light: "#D7263D", Dark: "#FF6B6B"}
 )
 
 // Styles
@@ -71,8 +72,27 @@ var (
 	phaseResolvedStyle = lipgloss.NewStyle().
 				Foreground(special).
 				Bold(true)
+
+	filterStyle = lipgloss.NewStyle().
+			Foreground(warning).
+			PaddingLeft(4)
+
+	diffAddedStyle = lipgloss.NewStyle().
+			Foreground(special)
+
+	diffRemovedStyle = lipgloss.NewStyle().
+				Foreground(danger)
+
+	diffHunkStyle = lipgloss.NewStyle().
+			Foreground(highlight)
+
+	diffHeaderStyle = lipgloss.NewStyle().
+			Foreground(subtle)
 )
 
+// maxDiffLines is the number of diff lines shown on the detail page.
+const maxDiffLines = 30
+
 // View state
 type viewState int
 
@@ -89,6 +109,14 @@ type KeyMap struct {
 	Escape key.Binding
 	Delete key.Binding
 	Quit   key.Binding
+
+	FilterNamespace key.Binding
+	FilterKind      key.Binding
+	FilterUser      key.Binding
+	Search          key.Binding
+	ClearFilters    key.Binding
+	Sort            key.Binding
+	History         key.Binding
 }
 
 // DefaultKeyMap returns the default keybindings
@@ -118,19 +146,48 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("q", "ctrl+c"),
 			key.WithHelp("q", "quit"),
 		),
+		FilterNamespace: key.NewBinding(
+			key.WithKeys("N"),
+			key.WithHelp("N", "namespace"),
+		),
+		FilterKind: key.NewBinding(
+			key.WithKeys("K"),
+			key.WithHelp("K", "kind"),
+		),
+		FilterUser: key.NewBinding(
+			key.WithKeys("U"),
+			key.WithHelp("U", "user"),
+		),
+		Search: key.NewBinding(
+			key.WithKeys("/"),
+			key.WithHelp("/", "search"),
+		),
+		ClearFilters: key.NewBinding(
+			key.WithKeys("c"),
+			key.WithHelp("c", "clear filters"),
+		),
+		Sort: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "sort"),
+		),
+		History: key.NewBinding(
+			key.WithKeys("h"),
+			key.WithHelp("h", "history"),
+		),
 	}
 }
 
 // ShortHelp returns keybindings for short help
 func (k KeyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.Up, k.Down, k.Enter, k.Delete, k.Quit}
+	return []key.Binding{k.Up, k.Down, k.Enter, k.Search, k.Sort, k.History, k.Delete, k.Quit}
 }
 
 // FullHelp returns keybindings for extended help
 func (k KeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{k.Up, k.Down, k.Enter, k.Escape},
-		{k.Delete, k.Quit},
+		{k.FilterNamespace, k.FilterKind, k.FilterUser, k.Search, k.ClearFilters},
+		{k.Sort, k.History, k.Delete, k.Quit},
 	}
 }
 
@@ -141,15 +198,22 @@ type DriftReportMsg struct {
 
 // Model is the bubbletea model for the backend TUI
 type Model struct {
-	store  *Store
-	items  []*StoredReport
-	cursor int
-	view   viewState
-	keys   KeyMap
-	help   help.Model
-	width  int
-	height int
-	addr   string
+	store   *Store
+	reports []*StoredReport // active reports
+	history []*StoredReport // resolved reports
+	items   []*StoredReport // visible reports after filtering and sorting
+	cursor  int
+	view    viewState
+	keys    KeyMap
+	help    help.Model
+	width   int
+	height  int
+	addr    string
+
+	filter      Filter
+	sort        SortMode
+	searching   bool // typing a search query
+	showHistory bool // list resolved reports instead of active ones
 }
 
 // NewModel creates a new TUI model
@@ -162,6 +226,7 @@ func NewModel(store *Store, addr string) Model {
 		keys:   DefaultKeyMap(),
 		help:   help.New(),
 		addr:   addr,
+		sort:   SortByReceived,
 	}
 }
 
@@ -183,11 +248,29 @@ func (m Model) tickRefresh() tea.Cmd {
 type tickMsg struct{}
 
 func (m Model) refreshItems() tea.Msg {
-	return refreshMsg{items: m.store.List()}
+	return refreshMsg{items: m.store.List(), history: m.store.History()}
 }
 
 type refreshMsg struct {
-	items []*StoredReport
+	items   []*StoredReport
+	history []*StoredReport
+}
+
+// applyView recomputes the visible items from the current source, filter and sort mode.
+func (m *Model) applyView() {
+	m.items = filterAndSort(m.source(), m.filter, m.sort)
+	// Adjust cursor if needed
+	if m.cursor >= len(m.items) {
+		m.cursor = max(len(m.items)-1, 0)
+	}
+}
+
+// source returns the unfiltered reports of the current pane.
+func (m Model) source() []*StoredReport {
+	if m.showHistory {
+		return m.history
+	}
+	return m.reports
 }
 
 // Update handles messages
@@ -206,11 +289,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, tea.Batch(m.refreshItems, m.tickRefresh())
 
 	case refreshMsg:
-		m.items = msg.items
-		// Adjust cursor if needed
-		if m.cursor >= len(m.items) && len(m.items) > 0 {
-			m.cursor = len(m.items) - 1
-		}
+		m.reports = msg.items
+		m.history = msg.history
+		m.applyView()
 		return m, nil
 
 	case DriftReportMsg:
@@ -222,6 +303,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.searching {
+		return m.handleSearchKey(msg)
+	}
+
 	// Handle escape in detail view
 	if m.view == viewDetail {
 		if key.Matches(msg, m.keys.Escape) {
@@ -256,8 +341,54 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.view = viewList
 		return m, nil
 
+	case key.Matches(msg, m.keys.FilterNamespace):
+		m.filter.Namespace = nextValue(distinctValues(m.source(), reportNamespace), m.filter.Namespace)
+		m.applyView()
+		return m, nil
+
+	case key.Matches(msg, m.keys.FilterKind):
+		m.filter.Kind = nextValue(distinctValues(m.source(), func(r *StoredReport) string {
+			return r.Report.Spec.Child.Kind
+		}), m.filter.Kind)
+		m.applyView()
+		return m, nil
+
+	case key.Matches(msg, m.keys.FilterUser):
+		m.filter.User = nextValue(distinctValues(m.source(), func(r *StoredReport) string {
+			return r.Report.Spec.Request.User
+		}), m.filter.User)
+		m.applyView()
+		return m, nil
+
+	case key.Matches(msg, m.keys.Search):
+		m.searching = true
+		m.view = viewList
+		return m, nil
+
+	case key.Matches(msg, m.keys.ClearFilters):
+		m.filter = Filter{}
+		m.applyView()
+		return m, nil
+
+	case key.Matches(msg, m.keys.Sort):
+		if m.sort == SortByReceived {
+			m.sort = SortBySeverity
+		} else {
+			m.sort = SortByReceived
+		}
+		m.applyView()
+		return m, nil
+
+	case key.Matches(msg, m.keys.History):
+		m.showHistory = !m.showHistory
+		m.view = viewList
+		m.cursor = 0
+		m.applyView()
+		return m, nil
+
 	case key.Matches(msg, m.keys.Delete):
-		if len(m.items) > 0 && m.cursor < len(m.items) {
+		// Resolved reports in the history cannot be dismissed
+		if !m.showHistory && len(m.items) > 0 && m.cursor < len(m.items) {
 			id := m.items[m.cursor].Report.Spec.ID
 			m.store.Remove(id)
 			return m, m.refreshItems
@@ -267,6 +398,32 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// handleSearchKey edits the free-text search query. Enter keeps the query,
+// escape clears it.
+func (m Model) handleSearchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	case tea.KeyEnter:
+		m.searching = false
+	case tea.KeyEsc:
+		m.searching = false
+		m.filter.Query = ""
+	case tea.KeyBackspace:
+		if q := []rune(m.filter.Query); len(q) > 0 {
+			m.filter.Query = string(q[:len(q)-1])
+		}
+	case tea.KeySpace:
+		m.filter.Query += " "
+	case tea.KeyRunes:
+		m.filter.Query += string(msg.Runes)
+	default:
+		return m, nil
+	}
+	m.applyView()
+	return m, nil
+}
+
 // View renders the UI
 func (m Model) View() string {
 	if m.view == viewDetail {
@@ -279,14 +436,30 @@ func (m Model) viewListPage() string {
 	var b strings.Builder
 
 	// Title
-	b.WriteString(titleStyle.Render("Kausality Backend"))
+	title := "Kausality Backend"
+	if m.showHistory {
+		title += " - Resolved History"
+	}
+	b.WriteString(titleStyle.Render(title))
 	b.WriteString("\n")
 	b.WriteString(itemStyle.Render(fmt.Sprintf("Listening on %s", m.addr)))
-	b.WriteString("\n\n")
+	b.WriteString("\n")
+	if line := m.filterLine(); line != "" {
+		b.WriteString(filterStyle.Render(line))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
 
 	// Items
 	if len(m.items) == 0 {
-		b.WriteString(itemStyle.Render("Waiting for drift reports..."))
+		switch {
+		case len(m.source()) > 0:
+			b.WriteString(itemStyle.Render("No reports match the current filters."))
+		case m.showHistory:
+			b.WriteString(itemStyle.Render("No resolved drift reports yet."))
+		default:
+			b.WriteString(itemStyle.Render("Waiting for drift reports..."))
+		}
 		b.WriteString("\n")
 	} else {
 		for i, item := range m.items {
@@ -299,16 +472,24 @@ func (m Model) viewListPage() string {
 
 			report := item.Report
 			title := fmt.Sprintf("%s/%s", report.Spec.Child.Kind, report.Spec.Child.Name)
+			if item.Occurrences > 1 {
+				title += fmt.Sprintf(" (x%d)", item.Occurrences)
+			}
 			line := fmt.Sprintf("%s%s", cursor, title)
 			b.WriteString(style.Render(line))
 			b.WriteString("\n")
 
 			phase := phaseDetectedStyle.Render("DRIFT")
 			if report.Spec.Phase == v1alpha1.DriftReportPhaseResolved {
-				phase = phaseResolvedStyle.Render("RESOLVED")
+				label := "RESOLVED"
+				if r := report.Spec.Resolution; r != nil {
+					label += " (" + string(r.Kind) + ")"
+				}
+				phase = phaseResolvedStyle.Render(label)
 			}
-			desc := fmt.Sprintf("   %s  parent: %s/%s  by: %s",
+			desc := fmt.Sprintf("   %s  %s  parent: %s/%s  by: %s",
 				phase,
+				report.Spec.Request.Operation,
 				report.Spec.Parent.Kind,
 				report.Spec.Parent.Name,
 				report.Spec.Request.User,
@@ -320,7 +501,18 @@ func (m Model) viewListPage() string {
 
 	// Status bar
 	b.WriteString("\n")
-	status := fmt.Sprintf("%d drift(s)", len(m.items))
+	noun := "drift(s)"
+	if m.showHistory {
+		noun = "resolved"
+	}
+	status := fmt.Sprintf("%d %s", len(m.items), noun)
+	if m.filter.Active() {
+		status = fmt.Sprintf("%d/%d %s", len(m.items), len(m.source()), noun)
+	}
+	status += fmt.Sprintf("  sort: %s", m.sort)
+	if !m.showHistory {
+		status += fmt.Sprintf("  history: %d", len(m.history))
+	}
 	b.WriteString(statusBarStyle.Render(status))
 	b.WriteString("\n")
 
@@ -330,6 +522,29 @@ func (m Model) viewListPage() string {
 	return b.String()
 }
 
+// filterLine describes the active filters and search query.
+func (m Model) filterLine() string {
+	var parts []string
+	if m.filter.Namespace != "" {
+		parts = append(parts, "namespace="+m.filter.Namespace)
+	}
+	if m.filter.Kind != "" {
+		parts = append(parts, "kind="+m.filter.Kind)
+	}
+	if m.filter.User != "" {
+		parts = append(parts, "user="+m.filter.User)
+	}
+	if m.searching {
+		parts = append(parts, "search: "+m.filter.Query+"█")
+	} else if m.filter.Query != "" {
+		parts = append(parts, fmt.Sprintf("search: %q", m.filter.Query))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "Filter: " + strings.Join(parts, "  ")
+}
+
 func (m Model) viewDetailPage() string {
 	if len(m.items) == 0 || m.cursor >= len(m.items) {
 		return "No item selected"
@@ -344,6 +559,11 @@ func (m Model) viewDetailPage() string {
 	b.WriteString(modalTitleStyle.Render("Drift Details"))
 	b.WriteString("\n\n")
 
+	resolved := "-"
+	if item.ResolvedAt != nil {
+		resolved = item.ResolvedAt.Format(time.RFC3339)
+	}
+
 	// Fields
 	fields := []struct {
 		label string
@@ -352,6 +572,8 @@ func (m Model) viewDetailPage() string {
 		{"ID", report.Spec.ID},
 		{"Phase", string(report.Spec.Phase)},
 		{"Received", item.ReceivedAt.Format(time.RFC3339)},
+		{"Resolved", resolved},
+		{"Occurrences", occurrences(item)},
 		{"", ""},
 		{"Parent", fmt.Sprintf("%s/%s", report.Spec.Parent.Kind, report.Spec.Parent.Name)},
 		{"Parent NS", report.Spec.Parent.Namespace},
@@ -365,6 +587,26 @@ func (m Model) viewDetailPage() string {
 		{"Operation", report.Spec.Request.Operation},
 		{"Field Manager", report.Spec.Request.FieldManager},
 	}
+	if snap := report.Spec.ParentSnapshot; snap != nil {
+		observed := "-"
+		if snap.ObservedGeneration != nil {
+			observed = fmt.Sprintf("%d", *snap.ObservedGeneration)
+		}
+		conditions := make([]string, 0, len(snap.Conditions))
+		for _, c := range snap.Conditions {
+			conditions = append(conditions, fmt.Sprintf("%s=%s", c.Type, c.Status))
+		}
+		fields = append(fields, []struct {
+			label string
+			value string
+		}{
+			{"", ""},
+			{"Snapshot At", snap.CapturedAt.Format(time.RFC3339)},
+			{"Parent RV", snap.ResourceVersion},
+			{"Generation", fmt.Sprintf("%d (observed %s)", snap.Generation, observed)},
+			{"Conditions", strings.Join(conditions, ", ")},
+		}...)
+	}
 
 	for _, f := range fields {
 		if f.label == "" {
@@ -380,8 +622,50 @@ func (m Model) viewDetailPage() string {
 		b.WriteString("\n")
 	}
 
+	if item.Diff != "" {
+		b.WriteString("\n")
+		b.WriteString(labelStyle.Render("Diff:"))
+		b.WriteString("\n")
+		b.WriteString(renderDiff(item.Diff))
+	}
+
 	b.WriteString("\n")
 	b.WriteString(helpStyle.Render("Press ESC to go back, d to dismiss"))
 
 	return modalStyle.Render(b.String())
 }
+
+// occurrences describes how often and when a drift was detected.
+func occurrences(item *StoredReport) string {
+	if item.Occurrences == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d (first %s, last %s)", item.Occurrences,
+		item.FirstSeen.Format(time.RFC3339), item.LastSeen.Format(time.RFC3339))
+}
+
+// renderDiff colors a unified diff, truncated to maxDiffLines.
+func renderDiff(diff string) string {
+	lines := strings.Split(strings.TrimSuffix(diff, "\n"), "\n")
+	var b strings.Builder
+	for i, line := range lines {
+		if i == maxDiffLines {
+			b.WriteString(diffHeaderStyle.Render(fmt.Sprintf("... %d more lines", len(lines)-i)))
+			b.WriteString("\n")
+			break
+		}
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			line = diffHeaderStyle.Render(line)
+		case strings.HasPrefix(line, "@@"):
+			line = diffHunkStyle.Render(line)
+		case strings.HasPrefix(line, "+"):
+			line = diffAddedStyle.Render(line)
+		case strings.HasPrefix(line, "-"):
+			line = diffRemovedStyle.Render(line)
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
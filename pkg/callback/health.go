@@ -0,0 +1,77 @@
+package callback
+
+import (
+	"sync"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// unhealthyThreshold is the number of consecutive reports a backend failed
+// to receive, after all retries, before it is unhealthy.
+const unhealthyThreshold = 3
+
+var (
+	backendHealthy = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kausality_callback_backend_healthy",
+		Help: "Whether a drift report backend received the last reports sent to it (1) or failed the last few (0).",
+	}, []string{"backend"})
+	backendFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kausality_callback_backend_failures_total",
+		Help: "Drift reports a backend failed to receive after all retries.",
+	}, []string{"backend"})
+	backendFailovers = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kausality_callback_backend_failovers_total",
+		Help: "Drift reports of a backend sent to its failover backend instead.",
+	}, []string{"backend"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(backendHealthy, backendFailures, backendFailovers)
+}
+
+// health tracks whether a backend receives the reports sent to it. A
+// backend is unhealthy after unhealthyThreshold consecutive failed reports,
+// and healthy again with the next report it receives.
+type health struct {
+	name string
+
+	mu       sync.Mutex
+	failures int
+}
+
+func newHealth(name string) *health {
+	backendHealthy.WithLabelValues(name).Set(1)
+	return &health{name: name}
+}
+
+func (h *health) healthy() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.failures < unhealthyThreshold
+}
+
+// succeeded records a report received by the backend.
+func (h *health) succeeded(log logr.Logger) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.failures >= unhealthyThreshold {
+		log.Info("drift report backend recovered")
+	}
+	h.failures = 0
+	backendHealthy.WithLabelValues(h.name).Set(1)
+}
+
+// failed records a report the backend failed to receive.
+func (h *health) failed(log logr.Logger, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.failures++
+	backendFailures.WithLabelValues(h.name).Inc()
+	if h.failures == unhealthyThreshold {
+		log.Error(err, "drift report backend is unhealthy", "failedReports", h.failures)
+		backendHealthy.WithLabelValues(h.name).Set(0)
+	}
+}
@@ -29,6 +29,13 @@ func GenerateResolutionID(parent, child v1alpha1.ObjectReference) string {
 	return hex.EncodeToString(h.Sum(nil))[:16]
 }
 
+// GenerateOrphanID generates an ID for an OrphanDetected notification. It
+// only depends on the child and its missing owner, so that repeated
+// mutations of the child are folded into one report.
+func GenerateOrphanID(parent, child v1alpha1.ObjectReference) string {
+	return GenerateDriftID(parent, child, []byte(v1alpha1.DriftReportPhaseOrphanDetected))
+}
+
 // hashObjectRef writes an object reference to a hash with null-byte separators.
 func hashObjectRef(h hash.Hash, ref v1alpha1.ObjectReference) {
 	for _, field := range []string{ref.APIVersion, ref.Kind, ref.Namespace, ref.Name} {
@@ -0,0 +1,83 @@
+package callback
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+)
+
+// DefaultBatchInterval is the default interval at which repeated drift
+// reports are sent as one update per drift.
+const DefaultBatchInterval = 30 * time.Second
+
+// incidents folds repeated Detected reports of the same drift, which a
+// controller fight produces by the thousands, into one pending update per
+// drift ID.
+type incidents struct {
+	mu      sync.Mutex
+	pending map[string]*incident
+}
+
+// incident is a drift with repeated detections not sent yet.
+type incident struct {
+	// report is the latest of the repeated reports.
+	report      v1alpha1.DriftReport
+	occurrences v1alpha1.Occurrences
+}
+
+func newIncidents() *incidents {
+	return &incidents{pending: make(map[string]*incident)}
+}
+
+// fold records a repeated detection of the report's drift at now.
+func (in *incidents) fold(report *v1alpha1.DriftReport, now time.Time) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	inc, ok := in.pending[report.Spec.ID]
+	if !ok {
+		inc = &incident{occurrences: v1alpha1.Occurrences{FirstSeen: metav1.NewTime(now)}}
+		in.pending[report.Spec.ID] = inc
+	}
+	inc.report = *report
+	inc.occurrences.Count++
+	inc.occurrences.LastSeen = metav1.NewTime(now)
+}
+
+// take returns one report per drift with pending detections, carrying their
+// occurrences, and clears them.
+func (in *incidents) take() []*v1alpha1.DriftReport {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	reports := make([]*v1alpha1.DriftReport, 0, len(in.pending))
+	for id, inc := range in.pending {
+		report := inc.report
+		occurrences := inc.occurrences
+		report.Spec.Occurrences = &occurrences
+		reports = append(reports, &report)
+		delete(in.pending, id)
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].Spec.Occurrences.FirstSeen.Before(&reports[j].Spec.Occurrences.FirstSeen)
+	})
+	return reports
+}
+
+// remove drops the pending detections of a drift.
+func (in *incidents) remove(id string) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	delete(in.pending, id)
+}
+
+// size returns the number of drifts with pending detections.
+func (in *incidents) size() int {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return len(in.pending)
+}
@@ -2,6 +2,8 @@ package callback
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -11,9 +13,17 @@ import (
 
 // MultiSender wraps multiple Sender instances and fans out reports to all of them.
 // Each sender has independent deduplication tracking.
+//
+// Reports are only sent to the backends whose Route selects them. A backend
+// with a Failover backend hands the reports it failed to receive to the
+// failover backend, and while it is unhealthy sends all its reports to the
+// failover backend too, still trying itself to notice its recovery.
 type MultiSender struct {
 	senders []*Sender
-	log     logr.Logger
+	// failovers are the failover senders by sender, if any.
+	failovers       map[*Sender]*Sender
+	namespaceLabels NamespaceLabelsFunc
+	log             logr.Logger
 }
 
 // NewMultiSender creates a new MultiSender from a list of SenderConfig.
@@ -24,6 +34,7 @@ func NewMultiSender(configs []SenderConfig, log logr.Logger) (*MultiSender, erro
 	}
 
 	senders := make([]*Sender, 0, len(configs))
+	byName := make(map[string]*Sender, len(configs))
 	for _, cfg := range configs {
 		// Skip empty URLs
 		if cfg.URL == "" {
@@ -39,6 +50,10 @@ func NewMultiSender(configs []SenderConfig, log logr.Logger) (*MultiSender, erro
 		if err != nil {
 			return nil, err
 		}
+		if _, ok := byName[sender.config.Name]; ok {
+			return nil, fmt.Errorf("duplicate backend name %q", sender.config.Name)
+		}
+		byName[sender.config.Name] = sender
 		senders = append(senders, sender)
 	}
 
@@ -46,18 +61,97 @@ func NewMultiSender(configs []SenderConfig, log logr.Logger) (*MultiSender, erro
 		return nil, nil
 	}
 
+	failovers := make(map[*Sender]*Sender)
+	for _, sender := range senders {
+		name := sender.config.Failover
+		if name == "" {
+			continue
+		}
+		failover, ok := byName[name]
+		if !ok || failover == sender {
+			return nil, fmt.Errorf("backend %q: invalid failover backend %q", sender.config.Name, name)
+		}
+		failovers[sender] = failover
+	}
+
 	return &MultiSender{
-		senders: senders,
-		log:     log.WithName("multi-sender"),
+		senders:   senders,
+		failovers: failovers,
+		log:       log.WithName("multi-sender"),
 	}, nil
 }
 
-// SendAsync sends a DriftReport to all configured backends in parallel.
-// Each backend has independent deduplication tracking.
+// WithNamespaceLabels sets the function reading namespace labels for routes
+// with namespace selectors. Without it, namespace selectors match no labels.
+func (m *MultiSender) WithNamespaceLabels(fn NamespaceLabelsFunc) *MultiSender {
+	m.namespaceLabels = fn
+	return m
+}
+
+// SendAsync sends a DriftReport to the backends whose routes select it, in
+// parallel. Each backend has independent deduplication tracking.
 func (m *MultiSender) SendAsync(ctx context.Context, report *v1alpha1.DriftReport) {
+	if !m.needsNamespaceLabels() {
+		m.dispatch(report, nil)
+		return
+	}
+	// Read the namespace labels in the background, like sending
+	reportCopy := *report
+	go func() {
+		m.dispatch(&reportCopy, m.readNamespaceLabels(context.Background(), &reportCopy))
+	}()
+}
+
+// dispatch sends a report of a namespace with nsLabels to the backends
+// whose routes select it, and to the failover backends of the unhealthy
+// ones.
+func (m *MultiSender) dispatch(report *v1alpha1.DriftReport, nsLabels map[string]string) {
+	for _, sender := range m.senders {
+		if !sender.config.Route.matches(report, nsLabels) {
+			continue
+		}
+		failover := m.failovers[sender]
+		if failover == nil {
+			sender.SendAsync(context.Background(), report)
+			continue
+		}
+		if !sender.Healthy() {
+			backendFailovers.WithLabelValues(sender.config.Name).Inc()
+			failover.SendAsync(context.Background(), report)
+			sender.SendAsync(context.Background(), report)
+			continue
+		}
+		sender.sendAsync(report, func(failed *v1alpha1.DriftReport) {
+			m.log.Info("sending drift report to failover backend", "id", failed.Spec.ID,
+				"backend", sender.config.Name, "failover", failover.config.Name)
+			backendFailovers.WithLabelValues(sender.config.Name).Inc()
+			failover.SendAsync(context.Background(), failed)
+		})
+	}
+}
+
+func (m *MultiSender) needsNamespaceLabels() bool {
 	for _, sender := range m.senders {
-		sender.SendAsync(ctx, report)
+		if sender.config.Route.needsNamespaceLabels() {
+			return true
+		}
+	}
+	return false
+}
+
+// readNamespaceLabels returns the labels of the namespace of a report, nil
+// for cluster-scoped reports or if they cannot be read.
+func (m *MultiSender) readNamespaceLabels(ctx context.Context, report *v1alpha1.DriftReport) map[string]string {
+	namespace := reportNamespace(report)
+	if namespace == "" || m.namespaceLabels == nil {
+		return nil
+	}
+	nsLabels, err := m.namespaceLabels(ctx, namespace)
+	if err != nil {
+		m.log.Error(err, "failed to read namespace labels for routing drift report", "id", report.Spec.ID, "namespace", namespace)
+		return nil
 	}
+	return nsLabels
 }
 
 // IsEnabled returns true if at least one sender is configured.
@@ -86,6 +180,26 @@ func (m *MultiSender) StartCleanup(interval time.Duration) func() {
 	}
 }
 
+// Start runs the batching loops of all senders until ctx is canceled.
+func (m *MultiSender) Start(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for _, sender := range m.senders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = sender.Start(ctx)
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+// NeedLeaderElection returns false; every webhook replica reports the drift
+// it admits.
+func (m *MultiSender) NeedLeaderElection() bool {
+	return false
+}
+
 // Len returns the number of configured senders.
 func (m *MultiSender) Len() int {
 	return len(m.senders)
@@ -16,6 +16,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"k8s.io/apimachinery/pkg/labels"
+
 	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
 	ktesting "github.com/kausality-io/kausality/pkg/testing"
 )
@@ -310,3 +312,136 @@ func TestMultiSender_ReportWithNewObject(t *testing.T) {
 func TestMultiSender_ImplementsReportSender(t *testing.T) {
 	var _ ReportSender = (*MultiSender)(nil)
 }
+
+// countingBackend counts the reports it receives by ID, failing them while
+// failing is set.
+type countingBackend struct {
+	*httptest.Server
+	failing atomic.Bool
+	mu      sync.Mutex
+	ids     map[string]int
+}
+
+func newCountingBackend(t *testing.T) *countingBackend {
+	b := &countingBackend{ids: make(map[string]int)}
+	b.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if b.failing.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		var report v1alpha1.DriftReport
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &report)
+		b.mu.Lock()
+		b.ids[report.Spec.ID]++
+		b.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(v1alpha1.DriftReportResponse{Acknowledged: true})
+	}))
+	t.Cleanup(b.Close)
+	return b
+}
+
+func (b *countingBackend) count(id string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.ids[id]
+}
+
+func routedReport(id, namespace, operation string) *v1alpha1.DriftReport {
+	return &v1alpha1.DriftReport{Spec: v1alpha1.DriftReportSpec{
+		ID:      id,
+		Phase:   v1alpha1.DriftReportPhaseDetected,
+		Child:   v1alpha1.ObjectReference{APIVersion: "v1", Kind: "ConfigMap", Namespace: namespace, Name: "config"},
+		Request: v1alpha1.RequestContext{Operation: operation},
+	}}
+}
+
+func TestMultiSender_Routes(t *testing.T) {
+	local := newCountingBackend(t)
+	soc := newCountingBackend(t)
+	selector, err := labels.Parse("tier=production")
+	require.NoError(t, err)
+
+	ms, err := NewMultiSender([]SenderConfig{
+		{Name: "local", URL: local.URL},
+		{Name: "soc", URL: soc.URL, Route: &Route{NamespaceSelector: selector, MinSeverity: SeverityMedium}},
+	}, logr.Discard())
+	require.NoError(t, err)
+	ms.WithNamespaceLabels(func(_ context.Context, namespace string) (map[string]string, error) {
+		if namespace == "prod" {
+			return map[string]string{"tier": "production"}, nil
+		}
+		return nil, nil
+	})
+
+	ms.SendAsync(context.Background(), routedReport("prod-update", "prod", "UPDATE"))
+	ms.SendAsync(context.Background(), routedReport("prod-create", "prod", "CREATE"))
+	ms.SendAsync(context.Background(), routedReport("dev-delete", "dev", "DELETE"))
+	resolved := routedReport("prod-resolved", "prod", "CREATE")
+	resolved.Spec.Phase = v1alpha1.DriftReportPhaseResolved
+	ms.SendAsync(context.Background(), resolved)
+
+	ktesting.Eventually(t, func() (bool, string) {
+		for _, id := range []string{"prod-update", "prod-create", "dev-delete", "prod-resolved"} {
+			if local.count(id) != 1 {
+				return false, fmt.Sprintf("local received %s %d times", id, local.count(id))
+			}
+		}
+		if soc.count("prod-update") != 1 || soc.count("prod-resolved") != 1 {
+			return false, "soc did not receive the routed reports"
+		}
+		return true, ""
+	}, ktesting.Timeout, ktesting.PollInterval, "reports should be routed")
+	assert.Equal(t, 0, soc.count("prod-create"), "below the minimum severity")
+	assert.Equal(t, 0, soc.count("dev-delete"), "namespace not selected")
+}
+
+func TestMultiSender_Failover(t *testing.T) {
+	primary := newCountingBackend(t)
+	secondary := newCountingBackend(t)
+	ms, err := NewMultiSender([]SenderConfig{
+		{Name: "primary", URL: primary.URL, Failover: "secondary", RetryCount: 1, RetryInterval: time.Millisecond},
+		{Name: "secondary", URL: secondary.URL, Route: &Route{MinSeverity: SeverityHigh}},
+	}, logr.Discard())
+	require.NoError(t, err)
+	var sender *Sender
+	for s := range ms.failovers {
+		sender = s
+	}
+	require.NotNil(t, sender)
+
+	// Reports the primary fails are sent to the failover backend
+	primary.failing.Store(true)
+	for i := 0; i < unhealthyThreshold; i++ {
+		ms.SendAsync(context.Background(), routedReport(fmt.Sprintf("failed-%d", i), "prod", "UPDATE"))
+	}
+	ktesting.Eventually(t, func() (bool, string) {
+		for i := 0; i < unhealthyThreshold; i++ {
+			if secondary.count(fmt.Sprintf("failed-%d", i)) != 1 {
+				return false, fmt.Sprintf("failed-%d not failed over", i)
+			}
+		}
+		return !sender.Healthy(), "primary should be unhealthy"
+	}, ktesting.Timeout, ktesting.PollInterval, "failed reports should fail over")
+
+	// While unhealthy, reports go to both; the primary recovers with the next report it receives
+	primary.failing.Store(false)
+	ms.SendAsync(context.Background(), routedReport("recovering", "prod", "UPDATE"))
+	ktesting.Eventually(t, func() (bool, string) {
+		return primary.count("recovering") == 1 && secondary.count("recovering") == 1 && sender.Healthy(),
+			"report should reach both backends"
+	}, ktesting.Timeout, ktesting.PollInterval, "unhealthy backend should fail over")
+
+	ms.SendAsync(context.Background(), routedReport("healthy", "prod", "UPDATE"))
+	ktesting.Eventually(t, func() (bool, string) {
+		return primary.count("healthy") == 1, "primary should receive the report"
+	}, ktesting.Timeout, ktesting.PollInterval, "healthy backend should receive reports")
+	assert.Equal(t, 0, secondary.count("healthy"))
+}
+
+func TestNewMultiSender_InvalidFailover(t *testing.T) {
+	_, err := NewMultiSender([]SenderConfig{{Name: "a", URL: "http://a", Failover: "b"}}, logr.Discard())
+	assert.ErrorContains(t, err, `invalid failover backend "b"`)
+	_, err = NewMultiSender([]SenderConfig{{Name: "a", URL: "http://a"}, {Name: "a", URL: "http://b"}}, logr.Discard())
+	assert.ErrorContains(t, err, `duplicate backend name "a"`)
+}
@@ -0,0 +1,89 @@
+package callback
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+)
+
+// PolicyEffectSender sends the effect of policy changes to a backend
+// endpoint, e.g. a bot commenting on the pull requests of a policy
+// repository.
+type PolicyEffectSender struct {
+	config SenderConfig
+	client *http.Client
+}
+
+// NewPolicyEffectSender creates a new PolicyEffectSender with the given
+// configuration.
+func NewPolicyEffectSender(cfg SenderConfig) (*PolicyEffectSender, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("policy effect backend URL is required")
+	}
+	cfg = cfg.withDefaults()
+	client, err := newHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &PolicyEffectSender{config: cfg, client: client}, nil
+}
+
+// Send sends a PolicyEffectReport to the configured endpoint, retrying on
+// failure.
+func (s *PolicyEffectSender) Send(ctx context.Context, report *v1alpha1.PolicyEffectReport) error {
+	report.TypeMeta = metav1.TypeMeta{
+		APIVersion: v1alpha1.GroupName + "/" + v1alpha1.Version,
+		Kind:       "PolicyEffectReport",
+	}
+
+	body, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal policy effect report: %w", err)
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= s.config.RetryCount; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(s.config.RetryInterval):
+			}
+		}
+
+		lastErr = s.doSend(ctx, body)
+		if lastErr == nil {
+			return nil
+		}
+	}
+	return lastErr
+}
+
+// doSend performs a single send attempt.
+func (s *PolicyEffectSender) doSend(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("policy effect backend returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
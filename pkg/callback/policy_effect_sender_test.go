@@ -0,0 +1,60 @@
+package callback
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+)
+
+func TestPolicyEffectSender_Send(t *testing.T) {
+	var received v1alpha1.PolicyEffectReport
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	_, err := NewPolicyEffectSender(SenderConfig{})
+	require.Error(t, err, "URL is required")
+
+	sender, err := NewPolicyEffectSender(SenderConfig{URL: server.URL})
+	require.NoError(t, err)
+
+	report := &v1alpha1.PolicyEffectReport{Spec: v1alpha1.PolicyEffectReportSpec{
+		Policy:    "apps",
+		Operation: "UPDATE",
+		Lost:      1,
+		Changes:   []v1alpha1.CoverageChange{{Type: "Lost", Resource: "apps/deployments", Namespace: "prod", PreviousMode: "enforce"}},
+	}}
+	require.NoError(t, sender.Send(context.Background(), report))
+	assert.Equal(t, "PolicyEffectReport", received.Kind)
+	assert.Equal(t, "apps", received.Spec.Policy)
+	assert.Equal(t, report.Spec.Changes, received.Spec.Changes)
+}
+
+func TestPolicyEffectSender_SendRetriesAndFails(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	sender, err := NewPolicyEffectSender(SenderConfig{URL: server.URL, RetryCount: 2, RetryInterval: time.Millisecond})
+	require.NoError(t, err)
+
+	err = sender.Send(context.Background(), &v1alpha1.PolicyEffectReport{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 503")
+	assert.Equal(t, int32(3), attempts.Load())
+}
@@ -0,0 +1,110 @@
+package callback
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+)
+
+// maxOpenDrifts bounds the number of children with open drifts. The oldest
+// are forgotten first; their reports stay open at the receivers.
+const maxOpenDrifts = 10000
+
+// ResolutionTracker remembers children with open drift reports, so that a
+// Resolved report can be sent once the drift is resolved. It is in-memory
+// and per replica: drifts detected before a restart are not resolved.
+type ResolutionTracker struct {
+	mu    sync.Mutex
+	open  map[string]*openDrift // keyed by child
+	order []string              // oldest first, for eviction
+}
+
+// openDrift is a child with reported, unresolved drift.
+type openDrift struct {
+	// ids are the Detected report ids, oldest first
+	ids []string
+	// baseline is the child's spec before the first drift, nil if the drift created the child
+	baseline []byte
+}
+
+// NewResolutionTracker creates a new ResolutionTracker.
+func NewResolutionTracker() *ResolutionTracker {
+	return &ResolutionTracker{
+		open: make(map[string]*openDrift),
+	}
+}
+
+// Open records a Detected report. baseline is the JSON encoded spec of the
+// child before the drift; it is kept from the first open report of a child.
+func (t *ResolutionTracker) Open(report *v1alpha1.DriftReport, baseline []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := childKey(report.Spec.Child)
+	drift, ok := t.open[key]
+	if !ok {
+		drift = &openDrift{baseline: baseline}
+		t.open[key] = drift
+		t.order = append(t.order, key)
+		if len(t.order) > maxOpenDrifts {
+			delete(t.open, t.order[0])
+			t.order = t.order[1:]
+		}
+	}
+	for _, id := range drift.ids {
+		if id == report.Spec.ID {
+			return
+		}
+	}
+	drift.ids = append(drift.ids, report.Spec.ID)
+}
+
+// IsOpen returns true if the child has open drift.
+func (t *ResolutionTracker) IsOpen(child v1alpha1.ObjectReference) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, ok := t.open[childKey(child)]
+	return ok
+}
+
+// Converged returns true if the child has open drift and spec, JSON encoded,
+// equals its spec from before the drift.
+func (t *ResolutionTracker) Converged(child v1alpha1.ObjectReference, spec []byte) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	drift, ok := t.open[childKey(child)]
+	return ok && drift.baseline != nil && bytes.Equal(drift.baseline, spec)
+}
+
+// Close forgets the child's open drift and returns the ids of its Detected reports.
+func (t *ResolutionTracker) Close(child v1alpha1.ObjectReference) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := childKey(child)
+	drift, ok := t.open[key]
+	if !ok {
+		return nil
+	}
+	delete(t.open, key)
+	for i, k := range t.order {
+		if k == key {
+			t.order = append(t.order[:i], t.order[i+1:]...)
+			break
+		}
+	}
+	return drift.ids
+}
+
+// Len returns the number of children with open drift.
+func (t *ResolutionTracker) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.open)
+}
+
+// childKey identifies a child independent of its API version.
+func childKey(ref v1alpha1.ObjectReference) string {
+	return ref.Kind + "/" + ref.Namespace + "/" + ref.Name
+}
@@ -0,0 +1,69 @@
+package callback
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+)
+
+func detectedReport(id, childName string) *v1alpha1.DriftReport {
+	return &v1alpha1.DriftReport{Spec: v1alpha1.DriftReportSpec{
+		ID:     id,
+		Phase:  v1alpha1.DriftReportPhaseDetected,
+		Parent: v1alpha1.ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "app"},
+		Child:  v1alpha1.ObjectReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Namespace: "default", Name: childName},
+	}}
+}
+
+func TestResolutionTracker(t *testing.T) {
+	tracker := NewResolutionTracker()
+	child := detectedReport("", "app-abc").Spec.Child
+	other := detectedReport("", "app-def").Spec.Child
+
+	assert.False(t, tracker.IsOpen(child))
+	assert.Nil(t, tracker.Close(child))
+
+	tracker.Open(detectedReport("id-1", "app-abc"), []byte(`{"replicas":1}`))
+	tracker.Open(detectedReport("id-2", "app-abc"), []byte(`{"replicas":2}`))
+	tracker.Open(detectedReport("id-2", "app-abc"), nil)
+
+	assert.True(t, tracker.IsOpen(child))
+	assert.False(t, tracker.IsOpen(other))
+	assert.Equal(t, 1, tracker.Len())
+
+	// The baseline is the spec before the first drift
+	assert.True(t, tracker.Converged(child, []byte(`{"replicas":1}`)))
+	assert.False(t, tracker.Converged(child, []byte(`{"replicas":2}`)))
+	assert.False(t, tracker.Converged(other, []byte(`{"replicas":1}`)))
+
+	// API version does not matter
+	child.APIVersion = "extensions/v1beta1"
+	assert.Equal(t, []string{"id-1", "id-2"}, tracker.Close(child))
+	assert.False(t, tracker.IsOpen(child))
+	assert.Equal(t, 0, tracker.Len())
+}
+
+func TestResolutionTracker_CreatedByDrift(t *testing.T) {
+	tracker := NewResolutionTracker()
+	report := detectedReport("id-1", "app-abc")
+	tracker.Open(report, nil)
+
+	// Without a baseline, only deletion or reconciliation resolves the drift
+	assert.False(t, tracker.Converged(report.Spec.Child, nil))
+	assert.True(t, tracker.IsOpen(report.Spec.Child))
+}
+
+func TestResolutionTracker_Bounded(t *testing.T) {
+	tracker := NewResolutionTracker()
+	first := detectedReport("id-first", "first")
+	tracker.Open(first, nil)
+	for i := 0; i < maxOpenDrifts; i++ {
+		tracker.Open(detectedReport(fmt.Sprintf("id-%d", i), fmt.Sprintf("child-%d", i)), nil)
+	}
+
+	assert.Equal(t, maxOpenDrifts, tracker.Len())
+	assert.False(t, tracker.IsOpen(first.Spec.Child), "oldest child should be forgotten")
+}
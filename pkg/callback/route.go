@@ -0,0 +1,103 @@
+package callback
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+)
+
+// Severity ranks the drift of a report for routing.
+type Severity string
+
+const (
+	// SeverityLow is drift of dry-run requests, snoozed drift, and
+	// creations.
+	SeverityLow Severity = "low"
+	// SeverityMedium is drift of updates.
+	SeverityMedium Severity = "medium"
+	// SeverityHigh is drift of deletions.
+	SeverityHigh Severity = "high"
+)
+
+// Severities are the valid severities, lowest first.
+var Severities = []Severity{SeverityLow, SeverityMedium, SeverityHigh}
+
+// ReportSeverity returns the severity of a report: deletions outrank
+// updates, which outrank creations. Dry-run requests and snoozed drift
+// never changed anything yet, and are low.
+func ReportSeverity(report *v1alpha1.DriftReport) Severity {
+	spec := report.Spec
+	if spec.Request.DryRun || spec.Suppression != nil {
+		return SeverityLow
+	}
+	switch spec.Request.Operation {
+	case "DELETE":
+		return SeverityHigh
+	case "UPDATE":
+		return SeverityMedium
+	}
+	return SeverityLow
+}
+
+// ParseSeverity returns the severity named s.
+func ParseSeverity(s string) (Severity, error) {
+	if slices.Contains(Severities, Severity(s)) {
+		return Severity(s), nil
+	}
+	return "", fmt.Errorf("invalid severity %q: must be %q, %q or %q", s, SeverityLow, SeverityMedium, SeverityHigh)
+}
+
+// NamespaceLabelsFunc returns the labels of a namespace, for routing by
+// namespace labels.
+type NamespaceLabelsFunc func(ctx context.Context, namespace string) (map[string]string, error)
+
+// Route selects the reports sent to a backend. A nil Route selects all
+// reports.
+type Route struct {
+	// NamespaceSelector selects reports by the labels of the namespace of
+	// the child, or of the parent for cluster-scoped children. Reports of
+	// cluster-scoped parents and children match the selector against no
+	// labels. Nil selects all namespaces.
+	NamespaceSelector labels.Selector
+	// MinSeverity is the lowest severity of Detected and OrphanDetected
+	// reports sent. Resolved reports are sent regardless of their severity,
+	// so that every drift sent is also resolved. Empty sends all severities.
+	MinSeverity Severity
+}
+
+// matches reports whether the route selects a report of a namespace with
+// nsLabels.
+func (r *Route) matches(report *v1alpha1.DriftReport, nsLabels map[string]string) bool {
+	if r == nil {
+		return true
+	}
+	if r.NamespaceSelector != nil && !r.NamespaceSelector.Matches(labels.Set(nsLabels)) {
+		return false
+	}
+	if r.MinSeverity != "" && report.Spec.Phase != v1alpha1.DriftReportPhaseResolved {
+		return severityRank(ReportSeverity(report)) >= severityRank(r.MinSeverity)
+	}
+	return true
+}
+
+// needsNamespaceLabels reports whether the route matches namespace labels.
+func (r *Route) needsNamespaceLabels() bool {
+	return r != nil && r.NamespaceSelector != nil
+}
+
+func severityRank(s Severity) int {
+	return slices.Index(Severities, s)
+}
+
+// reportNamespace returns the namespace of the child of a report, or of its
+// parent for cluster-scoped children.
+func reportNamespace(report *v1alpha1.DriftReport) string {
+	if ns := report.Spec.Child.Namespace; ns != "" {
+		return ns
+	}
+	return report.Spec.Parent.Namespace
+}
@@ -0,0 +1,31 @@
+package callback
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+)
+
+func TestReportSeverity(t *testing.T) {
+	report := func(operation string, dryRun bool) *v1alpha1.DriftReport {
+		return &v1alpha1.DriftReport{Spec: v1alpha1.DriftReportSpec{Request: v1alpha1.RequestContext{Operation: operation, DryRun: dryRun}}}
+	}
+	assert.Equal(t, SeverityHigh, ReportSeverity(report("DELETE", false)))
+	assert.Equal(t, SeverityMedium, ReportSeverity(report("UPDATE", false)))
+	assert.Equal(t, SeverityLow, ReportSeverity(report("CREATE", false)))
+	assert.Equal(t, SeverityLow, ReportSeverity(report("DELETE", true)))
+
+	snoozed := report("DELETE", false)
+	snoozed.Spec.Suppression = &v1alpha1.Suppression{}
+	assert.Equal(t, SeverityLow, ReportSeverity(snoozed))
+}
+
+func TestParseSeverity(t *testing.T) {
+	s, err := ParseSeverity("medium")
+	assert.NoError(t, err)
+	assert.Equal(t, SeverityMedium, s)
+	_, err = ParseSeverity("critical")
+	assert.ErrorContains(t, err, `invalid severity "critical"`)
+}
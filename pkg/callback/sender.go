@@ -10,6 +10,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -29,32 +30,77 @@ type ReportSender interface {
 
 // SenderConfig configures the Sender.
 type SenderConfig struct {
+	// Name identifies the backend in logs, metrics and failovers. Defaults
+	// to the URL.
+	Name string
 	// URL is the webhook endpoint URL.
 	URL string
 	// CAFile is the path to the CA certificate file for TLS verification.
 	// If empty, system CA pool is used.
 	CAFile string
+	// TokenFile is the path to a file containing a bearer token sent with
+	// every request. If empty, requests are not authenticated.
+	TokenFile string
 	// Timeout is the request timeout. Default is 10 seconds.
 	Timeout time.Duration
 	// RetryCount is the number of retries on failure. Default is 3.
 	RetryCount int
 	// RetryInterval is the interval between retries. Default is 1 second.
 	RetryInterval time.Duration
+	// BatchInterval is the interval at which repeated reports of the same
+	// drift are sent as one update. Default is 30 seconds. Only used by
+	// Sender.
+	BatchInterval time.Duration
+	// Route selects the reports sent to the backend. Nil sends all reports.
+	// Only used by MultiSender.
+	Route *Route
+	// Failover is the name of the backend receiving the reports this
+	// backend failed to receive, and all reports while it is unhealthy.
+	// Only used by MultiSender.
+	Failover string
 	// Log is the logger. If nil, a noop logger is used.
 	Log logr.Logger
 }
 
 // Sender sends DriftReports to webhook endpoints.
+//
+// The first detection of a drift is sent right away. Repeated detections
+// within the tracker's TTL are folded into one update per drift, sent with
+// their occurrences every BatchInterval while Start runs.
 type Sender struct {
-	config  SenderConfig
-	client  *http.Client
-	tracker *Tracker
-	log     logr.Logger
+	config    SenderConfig
+	client    *http.Client
+	tracker   *Tracker
+	incidents *incidents
+	health    *health
+	log       logr.Logger
 }
 
 // NewSender creates a new Sender with the given configuration.
 func NewSender(cfg SenderConfig) (*Sender, error) {
-	// Apply defaults
+	cfg = cfg.withDefaults()
+	client, err := newHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	log := cfg.Log
+	if log.GetSink() == nil {
+		log = logr.Discard()
+	}
+
+	return &Sender{
+		config:    cfg,
+		client:    client,
+		tracker:   NewTracker(),
+		incidents: newIncidents(),
+		health:    newHealth(cfg.Name),
+		log:       log.WithName("drift-callback").WithValues("backend", cfg.Name),
+	}, nil
+}
+
+// withDefaults returns the config with defaults applied.
+func (cfg SenderConfig) withDefaults() SenderConfig {
 	if cfg.Timeout == 0 {
 		cfg.Timeout = 10 * time.Second
 	}
@@ -64,8 +110,17 @@ func NewSender(cfg SenderConfig) (*Sender, error) {
 	if cfg.RetryInterval == 0 {
 		cfg.RetryInterval = 1 * time.Second
 	}
+	if cfg.BatchInterval == 0 {
+		cfg.BatchInterval = DefaultBatchInterval
+	}
+	if cfg.Name == "" {
+		cfg.Name = cfg.URL
+	}
+	return cfg
+}
 
-	// Create TLS config
+// newHTTPClient creates an HTTP client honoring the CA file, token file and timeout.
+func newHTTPClient(cfg SenderConfig) (*http.Client, error) {
 	tlsConfig := &tls.Config{
 		MinVersion: tls.VersionTLS12,
 	}
@@ -82,43 +137,68 @@ func NewSender(cfg SenderConfig) (*Sender, error) {
 		tlsConfig.RootCAs = caCertPool
 	}
 
-	client := &http.Client{
-		Timeout: cfg.Timeout,
-		Transport: &http.Transport{
-			TLSClientConfig: tlsConfig,
-		},
+	var transport http.RoundTripper = &http.Transport{
+		TLSClientConfig: tlsConfig,
 	}
-
-	log := cfg.Log
-	if log.GetSink() == nil {
-		log = logr.Discard()
+	if cfg.TokenFile != "" {
+		token, err := os.ReadFile(cfg.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read token file: %w", err)
+		}
+		if len(strings.TrimSpace(string(token))) == 0 {
+			return nil, fmt.Errorf("token file %s is empty", cfg.TokenFile)
+		}
+		transport = &bearerTransport{token: strings.TrimSpace(string(token)), next: transport}
 	}
 
-	return &Sender{
-		config:  cfg,
-		client:  client,
-		tracker: NewTracker(),
-		log:     log.WithName("drift-callback"),
+	return &http.Client{
+		Timeout:   cfg.Timeout,
+		Transport: transport,
 	}, nil
 }
 
+// bearerTransport adds a bearer token to requests.
+type bearerTransport struct {
+	token string
+	next  http.RoundTripper
+}
+
+func (t *bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return t.next.RoundTrip(req)
+}
+
 // Send sends a DriftReport to the configured webhook endpoint.
+// Repeated Detected and OrphanDetected reports of the same drift are not sent
+// but folded into the next batched update.
 // This is a blocking call; use SendAsync for non-blocking behavior.
 func (s *Sender) Send(ctx context.Context, report *v1alpha1.DriftReport) error {
+	// Check for deduplication (not for resolutions)
+	if report.Spec.Phase != v1alpha1.DriftReportPhaseResolved {
+		now := time.Now()
+		if !s.tracker.Track(report.Spec.ID) {
+			s.log.V(1).Info("folding repeated drift report", "id", report.Spec.ID)
+			s.incidents.fold(report, now)
+			return nil
+		}
+		report.Spec.Occurrences = &v1alpha1.Occurrences{
+			Count:     1,
+			FirstSeen: metav1.NewTime(now),
+			LastSeen:  metav1.NewTime(now),
+		}
+	}
+	return s.send(ctx, report)
+}
+
+// send sends a DriftReport without deduplication, retrying on failure.
+func (s *Sender) send(ctx context.Context, report *v1alpha1.DriftReport) error {
 	// Set TypeMeta
 	report.TypeMeta = metav1.TypeMeta{
 		APIVersion: v1alpha1.GroupName + "/" + v1alpha1.Version,
 		Kind:       "DriftReport",
 	}
 
-	// Check for deduplication (only for Detected phase)
-	if report.Spec.Phase == v1alpha1.DriftReportPhaseDetected {
-		if !s.tracker.Track(report.Spec.ID) {
-			s.log.V(1).Info("skipping duplicate drift report", "id", report.Spec.ID)
-			return nil
-		}
-	}
-
 	// Marshal report
 	body, err := json.Marshal(report)
 	if err != nil {
@@ -143,10 +223,12 @@ func (s *Sender) Send(ctx context.Context, report *v1alpha1.DriftReport) error {
 
 		lastErr = s.doSend(ctx, body, report.Spec.ID)
 		if lastErr == nil {
+			s.health.succeeded(s.log)
 			return nil
 		}
 	}
 
+	s.health.failed(s.log, lastErr)
 	s.log.Error(lastErr, "failed to send drift report after retries",
 		"id", report.Spec.ID,
 		"retries", s.config.RetryCount,
@@ -203,6 +285,12 @@ func (s *Sender) doSend(ctx context.Context, body []byte, id string) error {
 // The report is sent in a goroutine and any errors are logged but not returned.
 // Uses a background context since the original request context may be canceled.
 func (s *Sender) SendAsync(_ context.Context, report *v1alpha1.DriftReport) {
+	s.sendAsync(report, nil)
+}
+
+// sendAsync sends a DriftReport asynchronously and calls failed with the
+// report if sending failed, unless failed is nil.
+func (s *Sender) sendAsync(report *v1alpha1.DriftReport, failed func(*v1alpha1.DriftReport)) {
 	// Make a copy to avoid concurrent modification when multiple senders run in parallel
 	reportCopy := *report
 	go func() {
@@ -210,14 +298,64 @@ func (s *Sender) SendAsync(_ context.Context, report *v1alpha1.DriftReport) {
 		// after the response is sent, but we still want to complete the HTTP request.
 		if err := s.Send(context.Background(), &reportCopy); err != nil {
 			s.log.Error(err, "async drift report send failed", "id", reportCopy.Spec.ID)
+			if failed != nil {
+				failed(report)
+			}
 		}
 	}()
 }
 
+// Healthy reports whether the backend received the last reports sent to
+// it, see health.
+func (s *Sender) Healthy() bool {
+	return s.health.healthy()
+}
+
 // MarkResolved marks a drift as resolved and removes it from the tracker.
-// This allows the same drift to be tracked again if it recurs.
+// This allows the same drift to be tracked again if it recurs. Repeated
+// detections not sent yet are dropped, so that no update reopens the drift
+// after its resolution.
 func (s *Sender) MarkResolved(id string) {
 	s.tracker.Remove(id)
+	s.incidents.remove(id)
+}
+
+// Start sends the batched updates of repeated reports every BatchInterval and
+// cleans up expired tracker entries, until ctx is canceled. Pending updates
+// are sent once more on shutdown.
+func (s *Sender) Start(ctx context.Context) error {
+	ticker := time.NewTicker(s.config.BatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			// The context is canceled, send with a fresh one bounded by the client timeout
+			s.flush(context.Background())
+			return nil
+		case <-ticker.C:
+			s.flush(ctx)
+			s.tracker.Cleanup()
+		}
+	}
+}
+
+// NeedLeaderElection returns false; every webhook replica reports the drift
+// it admits.
+func (s *Sender) NeedLeaderElection() bool {
+	return false
+}
+
+// flush sends one update per drift with repeated detections since the
+// previous update.
+func (s *Sender) flush(ctx context.Context) {
+	for _, report := range s.incidents.take() {
+		if err := s.send(ctx, report); err != nil {
+			s.log.Error(err, "failed to send batched drift report",
+				"id", report.Spec.ID,
+				"occurrences", report.Spec.Occurrences.Count,
+			)
+		}
+	}
 }
 
 // StartCleanup starts a background cleanup loop for the tracker.
@@ -3,9 +3,12 @@ package callback
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -337,6 +340,73 @@ func TestSender_MarkResolved(t *testing.T) {
 	assert.Equal(t, int32(2), callCount.Load())
 }
 
+func TestSender_BatchesRepeatedReports(t *testing.T) {
+	received := make(chan *v1alpha1.DriftReport, 10)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var report v1alpha1.DriftReport
+		_ = json.NewDecoder(r.Body).Decode(&report)
+		received <- &report
+		_ = json.NewEncoder(w).Encode(v1alpha1.DriftReportResponse{Acknowledged: true})
+	}))
+	defer server.Close()
+
+	sender, err := NewSender(SenderConfig{
+		URL: server.URL,
+		Log: logr.Discard(),
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	report := func(id, user string) *v1alpha1.DriftReport {
+		return &v1alpha1.DriftReport{Spec: v1alpha1.DriftReportSpec{
+			ID:      id,
+			Phase:   v1alpha1.DriftReportPhaseDetected,
+			Request: v1alpha1.RequestContext{User: user},
+		}}
+	}
+
+	// The first detection is sent right away
+	require.NoError(t, sender.Send(ctx, report("fight", "controller-a")))
+	first := <-received
+	require.NotNil(t, first.Spec.Occurrences)
+	assert.Equal(t, int32(1), first.Spec.Occurrences.Count)
+
+	// Repeated detections are folded until the next batch
+	for i := 0; i < 100; i++ {
+		require.NoError(t, sender.Send(ctx, report("fight", fmt.Sprintf("controller-%d", i))))
+	}
+	require.NoError(t, sender.Send(ctx, report("other", "controller-a")))
+	<-received
+	require.NoError(t, sender.Send(ctx, report("other", "controller-b")))
+	assert.Len(t, received, 0)
+
+	sender.flush(ctx)
+	require.Len(t, received, 2)
+	updates := map[string]*v1alpha1.DriftReport{}
+	for range 2 {
+		r := <-received
+		updates[r.Spec.ID] = r
+	}
+	require.Contains(t, updates, "fight")
+	update := updates["fight"]
+	assert.Equal(t, int32(100), update.Spec.Occurrences.Count)
+	assert.Equal(t, "controller-99", update.Spec.Request.User, "latest report")
+	assert.False(t, update.Spec.Occurrences.LastSeen.Before(&update.Spec.Occurrences.FirstSeen))
+	assert.Equal(t, int32(1), updates["other"].Spec.Occurrences.Count)
+
+	// Nothing is sent without new detections
+	sender.flush(ctx)
+	assert.Len(t, received, 0)
+
+	// Pending detections of resolved drifts are dropped
+	require.NoError(t, sender.Send(ctx, report("fight", "controller-a")))
+	sender.MarkResolved("fight")
+	assert.Equal(t, 0, sender.incidents.size())
+	sender.flush(ctx)
+	assert.Len(t, received, 0)
+}
+
 func TestSender_SendAsync(t *testing.T) {
 	received := make(chan *v1alpha1.DriftReport, 1)
 
@@ -396,3 +466,29 @@ func TestNewSender_InvalidCAFile(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to read CA file")
 }
+
+func TestSender_TokenFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(v1alpha1.DriftReportResponse{Acknowledged: true})
+	}))
+	defer server.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("s3cret\n"), 0o600))
+	sender, err := NewSender(SenderConfig{URL: server.URL, TokenFile: tokenFile, RetryCount: 1, RetryInterval: time.Millisecond, Log: logr.Discard()})
+	require.NoError(t, err)
+	require.NoError(t, sender.Send(context.Background(), &v1alpha1.DriftReport{Spec: v1alpha1.DriftReportSpec{ID: "id-1"}}))
+
+	unauthenticated, err := NewSender(SenderConfig{URL: server.URL, RetryCount: 1, RetryInterval: time.Millisecond, Log: logr.Discard()})
+	require.NoError(t, err)
+	err = unauthenticated.Send(context.Background(), &v1alpha1.DriftReport{Spec: v1alpha1.DriftReportSpec{ID: "id-2"}})
+	assert.ErrorContains(t, err, "status 401")
+
+	require.NoError(t, os.WriteFile(tokenFile, []byte("\n"), 0o600))
+	_, err = NewSender(SenderConfig{URL: server.URL, TokenFile: tokenFile})
+	assert.ErrorContains(t, err, "is empty")
+}
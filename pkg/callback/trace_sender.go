@@ -0,0 +1,152 @@
+package callback
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+)
+
+// traceQueueSize bounds the number of trace records waiting to be sent.
+// Records are dropped when the backend cannot keep up, so admission never
+// blocks on mirroring.
+const traceQueueSize = 1000
+
+// TraceMirror receives the trace written by every admitted mutation.
+type TraceMirror interface {
+	Mirror(record *v1alpha1.TraceRecord)
+}
+
+// TraceSender mirrors trace records to a backend endpoint, e.g. the
+// POST /api/v1/traces endpoint of kausality-backend-tui. Records are queued
+// and sent in order by a single worker, which runs until the context passed
+// to Start is canceled.
+type TraceSender struct {
+	config SenderConfig
+	client *http.Client
+	queue  chan *v1alpha1.TraceRecord
+	log    logr.Logger
+}
+
+// NewTraceSender creates a new TraceSender with the given configuration.
+func NewTraceSender(cfg SenderConfig) (*TraceSender, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("trace backend URL is required")
+	}
+	cfg = cfg.withDefaults()
+	client, err := newHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	log := cfg.Log
+	if log.GetSink() == nil {
+		log = logr.Discard()
+	}
+
+	return &TraceSender{
+		config: cfg,
+		client: client,
+		queue:  make(chan *v1alpha1.TraceRecord, traceQueueSize),
+		log:    log.WithName("trace-mirror"),
+	}, nil
+}
+
+// Mirror queues a trace record for sending. It never blocks; if the queue
+// is full the record is dropped and logged.
+func (s *TraceSender) Mirror(record *v1alpha1.TraceRecord) {
+	select {
+	case s.queue <- record:
+	default:
+		s.log.Info("trace mirror queue full, dropping record",
+			"kind", record.Spec.Object.Kind,
+			"namespace", record.Spec.Object.Namespace,
+			"name", record.Spec.Object.Name,
+		)
+	}
+}
+
+// Start sends queued records until ctx is canceled.
+func (s *TraceSender) Start(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case record := <-s.queue:
+			if err := s.Send(ctx, record); err != nil && ctx.Err() == nil {
+				s.log.Error(err, "failed to mirror trace",
+					"kind", record.Spec.Object.Kind,
+					"namespace", record.Spec.Object.Namespace,
+					"name", record.Spec.Object.Name,
+				)
+			}
+		}
+	}
+}
+
+// NeedLeaderElection returns false; every webhook replica mirrors the
+// requests it admits.
+func (s *TraceSender) NeedLeaderElection() bool {
+	return false
+}
+
+// Send sends a TraceRecord to the configured endpoint, retrying on failure.
+// This is a blocking call; use Mirror for non-blocking behavior.
+func (s *TraceSender) Send(ctx context.Context, record *v1alpha1.TraceRecord) error {
+	record.TypeMeta = metav1.TypeMeta{
+		APIVersion: v1alpha1.GroupName + "/" + v1alpha1.Version,
+		Kind:       "TraceRecord",
+	}
+
+	body, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal trace record: %w", err)
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= s.config.RetryCount; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(s.config.RetryInterval):
+			}
+		}
+
+		lastErr = s.doSend(ctx, body)
+		if lastErr == nil {
+			return nil
+		}
+	}
+	return lastErr
+}
+
+// doSend performs a single send attempt.
+func (s *TraceSender) doSend(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("trace backend returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
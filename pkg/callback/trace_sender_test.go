@@ -0,0 +1,126 @@
+package callback
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+)
+
+func testTraceRecord(name string) *v1alpha1.TraceRecord {
+	return &v1alpha1.TraceRecord{
+		Spec: v1alpha1.TraceRecordSpec{
+			Object: v1alpha1.ObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "ReplicaSet",
+				Namespace:  "default",
+				Name:       name,
+				UID:        types.UID("uid-" + name),
+			},
+			Trace:   runtime.RawExtension{Raw: []byte(`[{"apiVersion":"apps/v1","kind":"Deployment","name":"app","generation":1,"user":"alice"}]`)},
+			Request: v1alpha1.RequestContext{User: "alice", UID: "req-1", Operation: "UPDATE"},
+		},
+	}
+}
+
+func TestNewTraceSender_RequiresURL(t *testing.T) {
+	_, err := NewTraceSender(SenderConfig{})
+	require.Error(t, err)
+}
+
+func TestTraceSender_Send(t *testing.T) {
+	var received v1alpha1.TraceRecord
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	sender, err := NewTraceSender(SenderConfig{URL: server.URL, Log: logr.Discard()})
+	require.NoError(t, err)
+
+	require.NoError(t, sender.Send(context.Background(), testTraceRecord("app-abc")))
+	assert.Equal(t, "TraceRecord", received.Kind)
+	assert.Equal(t, "app-abc", received.Spec.Object.Name)
+	assert.JSONEq(t, `[{"apiVersion":"apps/v1","kind":"Deployment","name":"app","generation":1,"user":"alice"}]`, string(received.Spec.Trace.Raw))
+}
+
+func TestTraceSender_SendRetriesAndFails(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	sender, err := NewTraceSender(SenderConfig{
+		URL:           server.URL,
+		RetryCount:    2,
+		RetryInterval: time.Millisecond,
+		Log:           logr.Discard(),
+	})
+	require.NoError(t, err)
+
+	err = sender.Send(context.Background(), testTraceRecord("app-abc"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "503")
+	assert.Equal(t, int32(3), attempts.Load())
+}
+
+func TestTraceSender_MirrorSendsInOrder(t *testing.T) {
+	names := make(chan string, 3)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var record v1alpha1.TraceRecord
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&record))
+		names <- record.Spec.Object.Name
+	}))
+	defer server.Close()
+
+	sender, err := NewTraceSender(SenderConfig{URL: server.URL, Log: logr.Discard()})
+	require.NoError(t, err)
+	assert.False(t, sender.NeedLeaderElection())
+
+	// Queued before the worker starts
+	for _, name := range []string{"a", "b", "c"} {
+		sender.Mirror(testTraceRecord(name))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- sender.Start(ctx) }()
+
+	for _, want := range []string{"a", "b", "c"} {
+		select {
+		case got := <-names:
+			assert.Equal(t, want, got)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %q", want)
+		}
+	}
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestTraceSender_MirrorDropsWhenFull(t *testing.T) {
+	sender, err := NewTraceSender(SenderConfig{URL: "http://127.0.0.1:0", Log: logr.Discard()})
+	require.NoError(t, err)
+
+	for i := 0; i < traceQueueSize+10; i++ {
+		sender.Mirror(testTraceRecord("app"))
+	}
+	assert.Len(t, sender.queue, traceQueueSize)
+}
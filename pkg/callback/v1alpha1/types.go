@@ -22,6 +22,9 @@ const (
 	DriftReportPhaseDetected DriftReportPhase = "Detected"
 	// DriftReportPhaseResolved indicates drift was resolved.
 	DriftReportPhaseResolved DriftReportPhase = "Resolved"
+	// DriftReportPhaseOrphanDetected indicates a mutation of an object whose
+	// controller owner does not exist. Parent is the missing owner.
+	DriftReportPhaseOrphanDetected DriftReportPhase = "OrphanDetected"
 )
 
 // DriftReport is sent to webhook endpoints when drift is detected.
@@ -64,6 +67,158 @@ type DriftReportSpec struct {
 	// request contains admission request context.
 	// +required
 	Request RequestContext `json:"request"`
+
+	// parentSnapshot is the parent's state at decision time, kept with the
+	// report so drift can be investigated after the parent has changed.
+	// +optional
+	ParentSnapshot *ParentSnapshot `json:"parentSnapshot,omitempty"`
+
+	// labels are the allowlisted labels of the child's namespace and of the
+	// parent, for routing reports by e.g. team or environment.
+	// +optional
+	Labels *ReportLabels `json:"labels,omitempty"`
+
+	// resolution describes how the drift was resolved. Only set for the Resolved phase.
+	// +optional
+	Resolution *Resolution `json:"resolution,omitempty"`
+
+	// override is the kausality.io/override justification the mutation carried.
+	// In enforce mode, it allowed the drifting mutation instead of denying it.
+	// +optional
+	Override *Override `json:"override,omitempty"`
+
+	// suppression is the kausality.io/suppress window of the child. The drift
+	// was admitted as suppressed instead of being denied or warned about.
+	// +optional
+	Suppression *Suppression `json:"suppression,omitempty"`
+
+	// occurrences counts the detections of the drift folded into this report.
+	// Senders report the first detection of a drift right away and fold the
+	// repeated ones, e.g. of two controllers fighting over the child, into
+	// batched updates. Only set for the Detected and OrphanDetected phases;
+	// unset means one.
+	// +optional
+	Occurrences *Occurrences `json:"occurrences,omitempty"`
+}
+
+// Occurrences counts repeated detections of the same drift.
+type Occurrences struct {
+	// count is the number of detections since the previous report of the
+	// drift by the same sender.
+	// +required
+	Count int32 `json:"count"`
+
+	// firstSeen is when the first of them was detected.
+	// +required
+	FirstSeen metav1.Time `json:"firstSeen"`
+
+	// lastSeen is when the last of them was detected.
+	// +required
+	LastSeen metav1.Time `json:"lastSeen"`
+}
+
+// Suppression is a time window in which drift on a child is muted.
+type Suppression struct {
+	// until is when the suppression expires.
+	// +required
+	Until metav1.Time `json:"until"`
+
+	// user who applied the suppression.
+	// +optional
+	User string `json:"user,omitempty"`
+
+	// message explains why drift is suppressed.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// Override is a justification provided with a drifting mutation.
+type Override struct {
+	// justification explains why the mutation could not wait for an approval.
+	// +required
+	Justification string `json:"justification"`
+
+	// ticket references an external ticket tracking the override.
+	// +required
+	Ticket string `json:"ticket"`
+}
+
+// ResolutionKind describes how a drift was resolved.
+type ResolutionKind string
+
+const (
+	// ResolutionControllerCorrected indicates the controller reconciled the child
+	// after the parent changed, or restored the spec from before the drift.
+	ResolutionControllerCorrected ResolutionKind = "controller-corrected"
+	// ResolutionApproved indicates the drift was approved on the parent.
+	ResolutionApproved ResolutionKind = "approved"
+	// ResolutionManuallyReverted indicates someone other than the controller
+	// restored the spec from before the drift.
+	ResolutionManuallyReverted ResolutionKind = "manually-reverted"
+	// ResolutionObjectDeleted indicates the child was deleted.
+	ResolutionObjectDeleted ResolutionKind = "object-deleted"
+)
+
+// Resolution describes how a drift was resolved.
+type Resolution struct {
+	// kind is how the drift was resolved.
+	// +required
+	Kind ResolutionKind `json:"kind"`
+
+	// detectedIDs are the ids of the Detected reports this resolution closes.
+	// Empty if the drift was resolved without having been reported, e.g. approved up front.
+	// +optional
+	DetectedIDs []string `json:"detectedIDs,omitempty"`
+}
+
+// ReportLabels are labels of the objects around a drift, selected by the
+// reportLabels allowlist of the webhook configuration.
+type ReportLabels struct {
+	// namespace are labels of the child's namespace, or of the parent's
+	// namespace for cluster-scoped children.
+	// +optional
+	Namespace map[string]string `json:"namespace,omitempty"`
+
+	// parent are labels of the parent.
+	// +optional
+	Parent map[string]string `json:"parent,omitempty"`
+}
+
+// ParentSnapshot is a compact copy of the parent's state when drift was evaluated.
+type ParentSnapshot struct {
+	// capturedAt is when the snapshot was taken.
+	// +required
+	CapturedAt metav1.Time `json:"capturedAt"`
+
+	// uid is the unique identifier of the parent.
+	// +optional
+	UID types.UID `json:"uid,omitempty"`
+
+	// resourceVersion is the parent's resourceVersion the decision was based on.
+	// +optional
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+
+	// generation is the parent's metadata.generation.
+	// +optional
+	Generation int64 `json:"generation,omitempty"`
+
+	// observedGeneration is the parent's status.observedGeneration.
+	// Unset if the parent has no observedGeneration.
+	// +optional
+	ObservedGeneration *int64 `json:"observedGeneration,omitempty"`
+
+	// deletionTimestamp is set if the parent was being deleted.
+	// +optional
+	DeletionTimestamp *metav1.Time `json:"deletionTimestamp,omitempty"`
+
+	// conditions are the parent's status conditions.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// annotations are the parent's kausality.io/* annotations, e.g. controllers,
+	// phase, approvals, rejections, freeze and snooze.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
 }
 
 // ObjectReference identifies a Kubernetes object.
@@ -142,3 +297,210 @@ type DriftReportResponse struct {
 	// +optional
 	Error string `json:"error,omitempty"`
 }
+
+// TraceRecord mirrors a trace written by the webhook to a backend, so the
+// causal history of an object remains queryable after the object is deleted.
+// This is a transient type with no persistence, so it only has TypeMeta.
+type TraceRecord struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// spec contains the trace details.
+	// +required
+	Spec TraceRecordSpec `json:"spec"`
+}
+
+// TraceRecordSpec contains the trace written for a single admission request.
+type TraceRecordSpec struct {
+	// object references the traced object.
+	// The UID is empty on CREATE, because the API server assigns it after admission.
+	// +required
+	Object ObjectReference `json:"object"`
+
+	// trace is the kausality.io/trace annotation value written by this request.
+	// +required
+	Trace runtime.RawExtension `json:"trace"`
+
+	// request contains information about the admission request.
+	// +required
+	Request RequestContext `json:"request"`
+
+	// timestamp is when the webhook admitted the request.
+	// +required
+	Timestamp metav1.Time `json:"timestamp"`
+
+	// orphanedBy is the deleted controller owner of the object if this
+	// request orphaned it, i.e. the garbage collector removed the owner
+	// reference after the owner was deleted with orphan propagation. The
+	// trace is then the object's last trace caused by the owner.
+	// +optional
+	OrphanedBy *ObjectReference `json:"orphanedBy,omitempty"`
+
+	// deletion describes the deletion if this request deleted the object.
+	// The trace is then the object's final trace, kept as its tombstone.
+	// +optional
+	Deletion *Deletion `json:"deletion,omitempty"`
+}
+
+// Deletion describes who or what deleted an object.
+type Deletion struct {
+	// propagationPolicy is the propagation policy of the delete request,
+	// Orphan, Background or Foreground, if set.
+	// +optional
+	PropagationPolicy string `json:"propagationPolicy,omitempty"`
+
+	// garbageCollected is set if the garbage collector deleted the object
+	// because its owners were deleted.
+	// +optional
+	GarbageCollected bool `json:"garbageCollected,omitempty"`
+
+	// owner is the controller owner, or else the first owner, of an object
+	// deleted by the garbage collector: the deletion causing this one.
+	// +optional
+	Owner *ObjectReference `json:"owner,omitempty"`
+}
+
+// ArchiveEvent is the terminal event an ArchiveRecord is written for.
+type ArchiveEvent string
+
+const (
+	// ArchiveEventDenied indicates the webhook denied a mutation.
+	ArchiveEventDenied ArchiveEvent = "Denied"
+	// ArchiveEventResolved indicates a reported drift was resolved.
+	ArchiveEventResolved ArchiveEvent = "Resolved"
+	// ArchiveEventDeleted indicates an object was deleted.
+	ArchiveEventDeleted ArchiveEvent = "Deleted"
+)
+
+// ArchiveRecord is the evidence of a terminal event written to a trace
+// archive, so the causal history of denials, resolved drift and deleted
+// objects is retained beyond the lifetime of the cluster.
+// This is a transient type with no persistence, so it only has TypeMeta.
+type ArchiveRecord struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// spec contains the archived evidence.
+	// +required
+	Spec ArchiveRecordSpec `json:"spec"`
+}
+
+// ArchiveRecordSpec contains the evidence of a terminal event.
+type ArchiveRecordSpec struct {
+	// event is the terminal event archived.
+	// +required
+	Event ArchiveEvent `json:"event"`
+
+	// object references the object of the event.
+	// +required
+	Object ObjectReference `json:"object"`
+
+	// trace is the complete trace chain of the object at the event: the
+	// trace a denied mutation would have had, the trace the object carried
+	// when its drift was resolved, or the final trace of a deleted object.
+	// Unset if the object is not traced.
+	// +optional
+	Trace *runtime.RawExtension `json:"trace,omitempty"`
+
+	// request contains information about the admission request.
+	// +required
+	Request RequestContext `json:"request"`
+
+	// timestamp is when the webhook handled the request.
+	// +required
+	Timestamp metav1.Time `json:"timestamp"`
+
+	// denial is the status the mutation was denied with, including the
+	// reason, parent and drift id as causes. Only set for Denied events.
+	// +optional
+	Denial *metav1.Status `json:"denial,omitempty"`
+
+	// deniedObject is the denied state of the object. Only set for Denied events.
+	// +optional
+	DeniedObject *runtime.RawExtension `json:"deniedObject,omitempty"`
+
+	// report is the Resolved drift report. Only set for Resolved events.
+	// +optional
+	Report *DriftReportSpec `json:"report,omitempty"`
+
+	// deletion describes the deletion. Only set for Deleted events.
+	// +optional
+	Deletion *Deletion `json:"deletion,omitempty"`
+}
+
+// PolicyEffectReport is sent to a backend when a Kausality policy is
+// created, changed or deleted, with the change of coverage it caused, so
+// that reviewers of policy changes see their effective blast radius.
+// This is a transient type with no persistence, so it only has TypeMeta.
+type PolicyEffectReport struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// spec contains the effect of the policy change.
+	// +required
+	Spec PolicyEffectReportSpec `json:"spec"`
+}
+
+// PolicyEffectReportSpec contains the change of coverage caused by a
+// policy change.
+type PolicyEffectReportSpec struct {
+	// policy is the name of the Kausality policy.
+	// +required
+	Policy string `json:"policy"`
+
+	// generation is the generation of the policy after the change, or the
+	// last generation of a deleted policy.
+	// +required
+	Generation int64 `json:"generation"`
+
+	// operation is the change of the policy (CREATE, UPDATE, DELETE).
+	// +required
+	Operation string `json:"operation"`
+
+	// timestamp is when the effect was computed.
+	// +required
+	Timestamp metav1.Time `json:"timestamp"`
+
+	// gained is the number of resource and namespace pairs which became
+	// tracked.
+	// +required
+	Gained int32 `json:"gained"`
+
+	// lost is the number of resource and namespace pairs which are no
+	// longer tracked.
+	// +required
+	Lost int32 `json:"lost"`
+
+	// modeChanged is the number of tracked resource and namespace pairs
+	// whose mode changed.
+	// +required
+	ModeChanged int32 `json:"modeChanged"`
+
+	// changes lists all changes.
+	// +optional
+	Changes []CoverageChange `json:"changes,omitempty"`
+}
+
+// CoverageChange is the change of drift detection of a resource in a
+// namespace.
+type CoverageChange struct {
+	// type is how drift detection changed (Gained, Lost, ModeChanged).
+	// +required
+	Type string `json:"type"`
+
+	// resource is the resource as group/resource, or resource for the core
+	// group. "*" stands for the resources of the group not named otherwise.
+	// +required
+	Resource string `json:"resource"`
+
+	// namespace is the namespace of the resource. Empty for cluster-scoped
+	// resources.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// mode is the mode after the change, unset if tracking was lost.
+	// +optional
+	Mode string `json:"mode,omitempty"`
+
+	// previousMode is the mode before the change, unset if tracking was
+	// gained.
+	// +optional
+	PreviousMode string `json:"previousMode,omitempty"`
+}
@@ -3,6 +3,7 @@ package v1alpha1
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -10,6 +11,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
 )
 
 func TestDriftReport_JSONRoundTrip(t *testing.T) {
@@ -49,6 +51,20 @@ func TestDriftReport_JSONRoundTrip(t *testing.T) {
 				Operation:    "UPDATE",
 				DryRun:       true,
 			},
+			ParentSnapshot: &ParentSnapshot{
+				CapturedAt:         metav1.Date(2026, 1, 15, 10, 30, 0, 0, time.Local),
+				UID:                types.UID("parent-uid"),
+				ResourceVersion:    "12345",
+				Generation:         5,
+				ObservedGeneration: ptr.To[int64](5),
+				Conditions: []metav1.Condition{{
+					Type:               "Ready",
+					Status:             metav1.ConditionTrue,
+					LastTransitionTime: metav1.Date(2026, 1, 15, 10, 0, 0, 0, time.Local),
+					Reason:             "Reconciled",
+				}},
+				Annotations: map[string]string{"kausality.io/phase": "initialized"},
+			},
 		},
 	}
 
@@ -68,6 +84,7 @@ func TestDriftReport_JSONRoundTrip(t *testing.T) {
 	assert.Equal(t, report.Spec.Parent, decoded.Spec.Parent)
 	assert.Equal(t, report.Spec.Child, decoded.Spec.Child)
 	assert.Equal(t, report.Spec.Request, decoded.Spec.Request)
+	assert.Equal(t, report.Spec.ParentSnapshot, decoded.Spec.ParentSnapshot)
 }
 
 func TestDriftReportResponse_JSONRoundTrip(t *testing.T) {
@@ -0,0 +1,143 @@
+// Package certs provisions and rotates the webhook's serving certificate.
+//
+// A self-signed CA signs a serving certificate for the webhook service. Both
+// are stored in a kubernetes.io/tls Secret that is mounted into the webhook
+// pods, and the CA is injected as caBundle into the MutatingWebhookConfiguration.
+package certs
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// KeyPair is a PEM-encoded certificate and private key.
+type KeyPair struct {
+	Cert []byte
+	Key  []byte
+}
+
+// GenerateCA creates a self-signed CA valid for the given duration.
+func GenerateCA(commonName string, validity time.Duration, now time.Time) (*KeyPair, error) {
+	tmpl := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             now.Add(-time.Hour), // tolerate clock skew
+		NotAfter:              now.Add(validity),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	return sign(tmpl, nil, nil)
+}
+
+// GenerateServingCert creates a serving certificate for the DNS names, signed by the CA.
+func GenerateServingCert(ca *KeyPair, dnsNames []string, validity time.Duration, now time.Time) (*KeyPair, error) {
+	if len(dnsNames) == 0 {
+		return nil, fmt.Errorf("at least one DNS name is required")
+	}
+	caCert, err := ParseCertificate(ca.Cert)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CA certificate: %w", err)
+	}
+	caKey, err := parseKey(ca.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CA key: %w", err)
+	}
+
+	tmpl := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: dnsNames[0]},
+		DNSNames:    dnsNames,
+		NotBefore:   now.Add(-time.Hour),
+		NotAfter:    now.Add(validity),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	return sign(tmpl, caCert, caKey)
+}
+
+// ServiceDNSNames returns the DNS names under which a service is reachable in-cluster.
+func ServiceDNSNames(name, namespace string) []string {
+	return []string{
+		name,
+		name + "." + namespace,
+		name + "." + namespace + ".svc",
+		name + "." + namespace + ".svc.cluster.local",
+	}
+}
+
+// ParseCertificate parses the first certificate of a PEM bundle.
+func ParseCertificate(certPEM []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("no PEM certificate found")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// needsRenewal returns true if the certificate cannot be parsed or expires within renewBefore.
+func needsRenewal(certPEM []byte, renewBefore time.Duration, now time.Time) bool {
+	cert, err := ParseCertificate(certPEM)
+	if err != nil {
+		return true
+	}
+	return now.Add(renewBefore).After(cert.NotAfter)
+}
+
+// caBundle returns the PEM bundle of the current CA and, if still valid, the
+// previous one. Keeping the previous CA during rotation lets clients verify
+// webhook pods that still serve a certificate signed by it.
+func caBundle(current, previous []byte, now time.Time) []byte {
+	if len(previous) == 0 || bytes.Equal(current, previous) {
+		return current
+	}
+	prev, err := ParseCertificate(previous)
+	if err != nil || now.After(prev.NotAfter) {
+		return current
+	}
+	return append(append([]byte{}, current...), previous...)
+}
+
+func sign(tmpl, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*KeyPair, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+	tmpl.SerialNumber = serial
+
+	// Self-signed if no parent is given
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate: %w", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal key: %w", err)
+	}
+
+	return &KeyPair{
+		Cert: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		Key:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}, nil
+}
+
+func parseKey(keyPEM []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM key found")
+	}
+	return x509.ParseECPrivateKey(block.Bytes)
+}
@@ -0,0 +1,72 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateServingCert(t *testing.T) {
+	now := time.Now()
+	ca, err := GenerateCA("test-ca", 24*time.Hour, now)
+	require.NoError(t, err)
+
+	dnsNames := ServiceDNSNames("kausality-webhook", "kausality-system")
+	serving, err := GenerateServingCert(ca, dnsNames, time.Hour, now)
+	require.NoError(t, err)
+
+	// Key pair is usable for TLS
+	_, err = tls.X509KeyPair(serving.Cert, serving.Key)
+	require.NoError(t, err)
+
+	// Certificate verifies against the CA for the service DNS name
+	cert, err := ParseCertificate(serving.Cert)
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	require.True(t, pool.AppendCertsFromPEM(ca.Cert))
+	_, err = cert.Verify(x509.VerifyOptions{
+		DNSName:   "kausality-webhook.kausality-system.svc",
+		Roots:     pool,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, dnsNames, cert.DNSNames)
+}
+
+func TestGenerateServingCert_NoDNSNames(t *testing.T) {
+	ca, err := GenerateCA("test-ca", time.Hour, time.Now())
+	require.NoError(t, err)
+	_, err = GenerateServingCert(ca, nil, time.Hour, time.Now())
+	assert.Error(t, err)
+}
+
+func TestNeedsRenewal(t *testing.T) {
+	now := time.Now()
+	ca, err := GenerateCA("test-ca", 48*time.Hour, now)
+	require.NoError(t, err)
+
+	assert.False(t, needsRenewal(ca.Cert, 24*time.Hour, now))
+	assert.True(t, needsRenewal(ca.Cert, 72*time.Hour, now), "expires within renewBefore")
+	assert.True(t, needsRenewal(ca.Cert, 0, now.Add(49*time.Hour)), "expired")
+	assert.True(t, needsRenewal([]byte("garbage"), 0, now), "unparseable")
+}
+
+func TestCABundle(t *testing.T) {
+	now := time.Now()
+	current, err := GenerateCA("current", 48*time.Hour, now)
+	require.NoError(t, err)
+	previous, err := GenerateCA("previous", time.Hour, now)
+	require.NoError(t, err)
+
+	assert.Equal(t, current.Cert, caBundle(current.Cert, nil, now))
+	assert.Equal(t, current.Cert, caBundle(current.Cert, current.Cert, now))
+
+	bundle := caBundle(current.Cert, previous.Cert, now)
+	assert.Equal(t, [][]byte{current.Cert, previous.Cert}, splitPEM(bundle), "previous CA kept while valid")
+
+	assert.Equal(t, current.Cert, caBundle(current.Cert, previous.Cert, now.Add(2*time.Hour)), "expired previous CA dropped")
+}
@@ -0,0 +1,242 @@
+package certs
+
+import (
+	"bytes"
+	"context"
+	"encoding/pem"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// CACertKey is the secret key holding the CA bundle (current CA first).
+	CACertKey = "ca.crt"
+	// CAPrivateKeyKey is the secret key holding the current CA's private key.
+	CAPrivateKeyKey = "ca.key"
+
+	// CertManagerGroupVersion is the API served when cert-manager is installed.
+	CertManagerGroupVersion = "cert-manager.io/v1"
+
+	caCommonName = "kausality-ca"
+
+	// retryInterval is used instead of CheckInterval after a failed check.
+	retryInterval = 10 * time.Second
+)
+
+// Config configures the certificate Manager.
+type Config struct {
+	// SecretNamespace and SecretName identify the kubernetes.io/tls Secret
+	// holding tls.crt, tls.key, ca.crt and ca.key.
+	SecretNamespace string
+	SecretName      string
+
+	// ServiceNamespace and ServiceName identify the webhook service the
+	// serving certificate is issued for.
+	ServiceNamespace string
+	ServiceName      string
+
+	// CAValidity is the lifetime of a generated CA. Defaults to 5 years.
+	CAValidity time.Duration
+	// CertValidity is the lifetime of a generated serving certificate. Defaults to 1 year.
+	CertValidity time.Duration
+	// RenewBefore is how long before expiry a certificate is replaced. Defaults to 30 days.
+	RenewBefore time.Duration
+	// CheckInterval is how often the secret is checked for rotation. Defaults to 1 hour.
+	CheckInterval time.Duration
+
+	// OnCABundleChange is called with the CA bundle on start and whenever it changes,
+	// e.g. to inject it into the MutatingWebhookConfiguration. Failed calls are retried.
+	OnCABundleChange func(ctx context.Context, caBundle []byte) error
+}
+
+// Manager provisions and rotates the webhook certificates stored in a Secret.
+// It implements manager.Runnable and requires leader election.
+type Manager struct {
+	client client.Client
+	log    logr.Logger
+	cfg    Config
+	now    func() time.Time
+}
+
+// NewManager creates a certificate Manager with defaults applied to unset durations.
+func NewManager(c client.Client, log logr.Logger, cfg Config) *Manager {
+	if cfg.CAValidity == 0 {
+		cfg.CAValidity = 5 * 365 * 24 * time.Hour
+	}
+	if cfg.CertValidity == 0 {
+		cfg.CertValidity = 365 * 24 * time.Hour
+	}
+	if cfg.RenewBefore == 0 {
+		cfg.RenewBefore = 30 * 24 * time.Hour
+	}
+	if cfg.CheckInterval == 0 {
+		cfg.CheckInterval = time.Hour
+	}
+	return &Manager{
+		client: c,
+		log:    log.WithName("certs"),
+		cfg:    cfg,
+		now:    time.Now,
+	}
+}
+
+// Start ensures the certificates exist and re-checks them every CheckInterval
+// until the context is cancelled.
+func (m *Manager) Start(ctx context.Context) error {
+	var injected []byte
+	for {
+		interval := m.cfg.CheckInterval
+		bundle, err := m.Ensure(ctx)
+		if err != nil {
+			m.log.Error(err, "failed to ensure webhook certificates")
+			interval = retryInterval
+		} else if !bytes.Equal(bundle, injected) && m.cfg.OnCABundleChange != nil {
+			if err := m.cfg.OnCABundleChange(ctx, bundle); err != nil {
+				m.log.Error(err, "failed to apply CA bundle")
+				interval = retryInterval
+			} else {
+				injected = bundle
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Ensure creates the CA and serving certificate if missing, replaces them if
+// they are invalid or about to expire, and returns the CA bundle.
+func (m *Manager) Ensure(ctx context.Context) ([]byte, error) {
+	now := m.now()
+	key := client.ObjectKey{Namespace: m.cfg.SecretNamespace, Name: m.cfg.SecretName}
+
+	secret := &corev1.Secret{}
+	exists := true
+	if err := m.client.Get(ctx, key, secret); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to get secret %s: %w", key, err)
+		}
+		exists = false
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: m.cfg.SecretNamespace,
+				Name:      m.cfg.SecretName,
+				Labels:    map[string]string{"app.kubernetes.io/managed-by": "kausality"},
+			},
+			Type: corev1.SecretTypeTLS,
+		}
+	}
+	data := secret.Data
+	if data == nil {
+		data = make(map[string][]byte)
+	}
+
+	certs := splitPEM(data[CACertKey])
+	ca := &KeyPair{Key: data[CAPrivateKeyKey]}
+	var previousCA []byte
+	if len(certs) > 0 {
+		ca.Cert = certs[0]
+	}
+	if len(certs) > 1 {
+		previousCA = certs[1]
+	}
+
+	rotateCA := len(ca.Key) == 0 || needsRenewal(ca.Cert, m.cfg.RenewBefore, now)
+	if rotateCA {
+		m.log.Info("generating webhook CA", "secret", key)
+		newCA, err := GenerateCA(caCommonName, m.cfg.CAValidity, now)
+		if err != nil {
+			return nil, err
+		}
+		previousCA = ca.Cert
+		ca = newCA
+	}
+	bundle := caBundle(ca.Cert, previousCA, now)
+	changed := !exists || rotateCA || !bytes.Equal(bundle, data[CACertKey])
+
+	dnsNames := ServiceDNSNames(m.cfg.ServiceName, m.cfg.ServiceNamespace)
+	if rotateCA || !m.servingCertValid(data[corev1.TLSCertKey], ca.Cert, dnsNames, now) {
+		m.log.Info("generating webhook serving certificate", "secret", key, "dnsNames", dnsNames)
+		serving, err := GenerateServingCert(ca, dnsNames, m.cfg.CertValidity, now)
+		if err != nil {
+			return nil, err
+		}
+		data[corev1.TLSCertKey] = serving.Cert
+		data[corev1.TLSPrivateKeyKey] = serving.Key
+		changed = true
+	}
+
+	if !changed {
+		return bundle, nil
+	}
+
+	data[CACertKey] = bundle
+	data[CAPrivateKeyKey] = ca.Key
+	secret.Data = data
+
+	if exists {
+		if err := m.client.Update(ctx, secret); err != nil {
+			return nil, fmt.Errorf("failed to update secret %s: %w", key, err)
+		}
+	} else {
+		if err := m.client.Create(ctx, secret); err != nil {
+			return nil, fmt.Errorf("failed to create secret %s: %w", key, err)
+		}
+	}
+	return bundle, nil
+}
+
+// servingCertValid returns true if the certificate is signed by the CA, covers
+// the DNS names and does not need renewal.
+func (m *Manager) servingCertValid(certPEM, caPEM []byte, dnsNames []string, now time.Time) bool {
+	if needsRenewal(certPEM, m.cfg.RenewBefore, now) {
+		return false
+	}
+	cert, err := ParseCertificate(certPEM)
+	if err != nil {
+		return false
+	}
+	caCert, err := ParseCertificate(caPEM)
+	if err != nil {
+		return false
+	}
+	return cert.CheckSignatureFrom(caCert) == nil && slices.Equal(cert.DNSNames, dnsNames)
+}
+
+// CertManagerInstalled returns true if the cert-manager API is served by the cluster.
+func CertManagerInstalled(dc discovery.DiscoveryInterface) (bool, error) {
+	if _, err := dc.ServerResourcesForGroupVersion(CertManagerGroupVersion); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to discover %s: %w", CertManagerGroupVersion, err)
+	}
+	return true, nil
+}
+
+// splitPEM splits a PEM bundle into its individually encoded certificates.
+func splitPEM(bundle []byte) [][]byte {
+	var result [][]byte
+	for rest := bundle; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return result
+		}
+		if block.Type == "CERTIFICATE" {
+			result = append(result, pem.EncodeToMemory(block))
+		}
+	}
+}
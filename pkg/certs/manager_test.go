@@ -0,0 +1,150 @@
+package certs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTestManager(t *testing.T, objs ...client.Object) (*Manager, client.Client) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+	m := NewManager(c, logr.Discard(), Config{
+		SecretNamespace:  "kausality-system",
+		SecretName:       "kausality-webhook-cert",
+		ServiceNamespace: "kausality-system",
+		ServiceName:      "kausality-webhook",
+	})
+	return m, c
+}
+
+func getSecret(t *testing.T, c client.Client) *corev1.Secret {
+	secret := &corev1.Secret{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: "kausality-system", Name: "kausality-webhook-cert"}, secret))
+	return secret
+}
+
+func TestManager_Ensure_CreatesSecret(t *testing.T) {
+	m, c := newTestManager(t)
+
+	bundle, err := m.Ensure(context.Background())
+	require.NoError(t, err)
+
+	secret := getSecret(t, c)
+	assert.Equal(t, corev1.SecretTypeTLS, secret.Type)
+	assert.Equal(t, bundle, secret.Data[CACertKey])
+	for _, key := range []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey, CACertKey, CAPrivateKeyKey} {
+		assert.NotEmpty(t, secret.Data[key], key)
+	}
+
+	cert, err := ParseCertificate(secret.Data[corev1.TLSCertKey])
+	require.NoError(t, err)
+	assert.Contains(t, cert.DNSNames, "kausality-webhook.kausality-system.svc")
+}
+
+func TestManager_Ensure_Idempotent(t *testing.T) {
+	m, c := newTestManager(t)
+	ctx := context.Background()
+
+	first, err := m.Ensure(ctx)
+	require.NoError(t, err)
+	before := getSecret(t, c)
+
+	second, err := m.Ensure(ctx)
+	require.NoError(t, err)
+	after := getSecret(t, c)
+
+	assert.Equal(t, first, second)
+	assert.Equal(t, before.ResourceVersion, after.ResourceVersion, "secret not rewritten")
+}
+
+func TestManager_Ensure_RotatesServingCert(t *testing.T) {
+	m, c := newTestManager(t)
+	ctx := context.Background()
+
+	bundle, err := m.Ensure(ctx)
+	require.NoError(t, err)
+	oldCert := getSecret(t, c).Data[corev1.TLSCertKey]
+
+	// Serving cert (1y) is within RenewBefore, CA (5y) is not
+	m.now = func() time.Time { return time.Now().Add(340 * 24 * time.Hour) }
+	rotated, err := m.Ensure(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, bundle, rotated, "CA unchanged")
+	assert.NotEqual(t, oldCert, getSecret(t, c).Data[corev1.TLSCertKey])
+}
+
+func TestManager_Ensure_RotatesCA(t *testing.T) {
+	m, c := newTestManager(t)
+	ctx := context.Background()
+
+	_, err := m.Ensure(ctx)
+	require.NoError(t, err)
+	oldCA := getSecret(t, c).Data[CACertKey]
+
+	// CA expires within RenewBefore: new CA, old CA kept in the bundle
+	m.now = func() time.Time { return time.Now().Add(5*365*24*time.Hour - 10*24*time.Hour) }
+	bundle, err := m.Ensure(ctx)
+	require.NoError(t, err)
+
+	certs := splitPEM(bundle)
+	require.Len(t, certs, 2)
+	assert.NotEqual(t, oldCA, certs[0])
+	assert.Equal(t, oldCA, certs[1])
+
+	// New serving cert is signed by the new CA
+	secret := getSecret(t, c)
+	assert.True(t, m.servingCertValid(secret.Data[corev1.TLSCertKey], certs[0], ServiceDNSNames("kausality-webhook", "kausality-system"), m.now()))
+}
+
+func TestManager_Ensure_ReplacesExternalSecret(t *testing.T) {
+	// Secret without a CA key, e.g. generated by Helm
+	ca, err := GenerateCA("helm-ca", 24*time.Hour, time.Now())
+	require.NoError(t, err)
+	existing := &corev1.Secret{}
+	existing.Namespace = "kausality-system"
+	existing.Name = "kausality-webhook-cert"
+	existing.Data = map[string][]byte{CACertKey: ca.Cert}
+
+	m, c := newTestManager(t, existing)
+	bundle, err := m.Ensure(context.Background())
+	require.NoError(t, err)
+
+	certs := splitPEM(bundle)
+	require.Len(t, certs, 2)
+	assert.Equal(t, ca.Cert, certs[1], "previous CA kept while still valid")
+	assert.NotEmpty(t, getSecret(t, c).Data[CAPrivateKeyKey])
+}
+
+func TestManager_Start_InjectsBundle(t *testing.T) {
+	m, _ := newTestManager(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	injected := make(chan []byte, 1)
+	m.cfg.OnCABundleChange = func(_ context.Context, caBundle []byte) error {
+		injected <- caBundle
+		cancel()
+		return nil
+	}
+
+	require.NoError(t, m.Start(ctx))
+	select {
+	case b := <-injected:
+		assert.NotEmpty(t, b)
+	default:
+		t.Fatal("CA bundle was not injected")
+	}
+}
@@ -0,0 +1,49 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/client/clientset/versioned/fake"
+	"github.com/kausality-io/kausality/pkg/client/informers/externalversions"
+)
+
+func TestClientsetAndInformers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clientset := fake.NewSimpleClientset(&kausalityv1alpha1.Kausality{
+		ObjectMeta: metav1.ObjectMeta{Name: "apps"},
+		Spec:       kausalityv1alpha1.KausalitySpec{Mode: kausalityv1alpha1.ModeEnforce},
+	})
+	_, err := clientset.KausalityV1alpha1().KausalityPolicies("team-a").Create(ctx, &kausalityv1alpha1.KausalityPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "enforce"},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	factory := externalversions.NewSharedInformerFactory(clientset, 10*time.Minute)
+	kausalities := factory.Kausality().V1alpha1().Kausalities()
+	policies := factory.Kausality().V1alpha1().KausalityPolicies()
+	kausalities.Informer()
+	policies.Informer()
+	factory.Start(ctx.Done())
+	for typ, synced := range factory.WaitForCacheSync(ctx.Done()) {
+		require.True(t, synced, typ)
+	}
+
+	kausality, err := kausalities.Lister().Get("apps")
+	require.NoError(t, err)
+	assert.Equal(t, kausalityv1alpha1.ModeEnforce, kausality.Spec.Mode)
+
+	policy, err := policies.Lister().KausalityPolicies("team-a").Get("enforce")
+	require.NoError(t, err)
+	assert.Equal(t, "team-a", policy.Namespace)
+
+}
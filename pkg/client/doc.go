@@ -0,0 +1,10 @@
+// Package client holds the typed clientset, listers and informers of the
+// kausality.io API group, generated by hack/update-codegen.sh for the types
+// of api/ marked with +genclient:
+//
+//   - clientset/versioned: the clientset, and a fake clientset for tests,
+//   - listers/api/v1alpha1: listers reading from informer caches,
+//   - informers/externalversions: the shared informer factory.
+//
+// Run make gen after changing the API types.
+package client
@@ -2,8 +2,12 @@
 package config
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
+	"path"
+	"slices"
 	"strings"
 	"time"
 
@@ -12,6 +16,11 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+	"github.com/kausality-io/kausality/pkg/fieldpath"
 )
 
 // Config is the root configuration structure.
@@ -20,21 +29,630 @@ type Config struct {
 	// Backends configures drift report webhook endpoints.
 	// Reports are sent to all configured backends in parallel.
 	Backends []BackendConfig `yaml:"backends,omitempty"`
+	// TraceBackend configures an endpoint receiving the trace of every admitted
+	// mutation (POST /api/v1/traces), so traces outlive the objects carrying them.
+	TraceBackend *BackendConfig `yaml:"traceBackend,omitempty"`
+	// TicketValidation configures validation of kausality.io/trace-ticket references.
+	// When set, origin changes in enforce mode require a valid ticket.
+	TicketValidation *TicketValidationConfig `yaml:"ticketValidation,omitempty"`
+	// TraceSigning enables signing the hops the webhook appends to traces,
+	// so that forged or altered traces can be detected.
+	TraceSigning *TraceSigningConfig `yaml:"traceSigning,omitempty"`
+	// DriftStatus enables drift counters on parent objects
+	// (kausality.io/drift-count, kausality.io/last-drift-time).
+	DriftStatus *DriftStatusConfig `yaml:"driftStatus,omitempty"`
+	// ErrorHandling configures the admission response per class of internal
+	// error, e.g. when the parent of an object cannot be read.
+	ErrorHandling ErrorHandlingConfig `yaml:"errorHandling,omitempty"`
+	// Budget bounds the latency of admission requests, so that the webhook
+	// answers slow parent reads with errorHandling.budgetExhausted before the
+	// API server's webhook timeout applies the failurePolicy. If nil,
+	// requests are only bounded by the API server.
+	Budget *BudgetConfig `yaml:"budget,omitempty"`
+	// ResourceFilter admits requests for resources kausality does not handle
+	// without evaluating them, independently of the webhook rules, e.g.
+	// requests converted to a tracked version by matchPolicy Equivalent. If
+	// nil, all requests reaching the webhook are evaluated.
+	ResourceFilter *ResourceFilterConfig `yaml:"resourceFilter,omitempty"`
+	// References declares Secrets and ConfigMaps referenced by tracked
+	// resources, so that traces extend across references, not only ownership.
+	References []ReferenceConfig `yaml:"references,omitempty"`
+	// ConnectionSecrets lists Crossplane managed resource kinds whose
+	// connection Secrets (spec.writeConnectionSecretToRef) are tracked. The
+	// managed resource is the causal parent of its Secret: changes by other
+	// actors than its provider are drift.
+	ConnectionSecrets []ConnectionSecretConfig `yaml:"connectionSecrets,omitempty"`
+	// Crossplane adds Crossplane's resource references and Usages as
+	// parent edges, for objects without controller owner reference.
+	Crossplane *CrossplaneConfig `yaml:"crossplane,omitempty"`
+	// Flux adds the Flux Kustomizations and HelmReleases applying objects,
+	// from the objects' Flux labels, as parents of objects without
+	// controller owner reference.
+	Flux *FluxConfig `yaml:"flux,omitempty"`
+	// DecisionCache enables reusing drift denials for a short time, so
+	// controllers retrying a blocked update do not cause parent reads on
+	// every attempt.
+	DecisionCache *DecisionCacheConfig `yaml:"decisionCache,omitempty"`
+	// TraceSampling limits trace propagation for high-cardinality resources,
+	// e.g. Pods, to a sample of objects and to children of drifting parents.
+	// Resources without matching entry are always traced.
+	TraceSampling []TraceSamplingConfig `yaml:"traceSampling,omitempty"`
+	// Recreation enables continuing the trace of children deleted and
+	// recreated by their controller instead of updated.
+	Recreation *RecreationConfig `yaml:"recreation,omitempty"`
+	// Profiles describe Deployment-like parent kinds whose controller keeps
+	// changing children after observing a new generation, or refreshes them
+	// periodically, in addition to the built-in profiles of e.g. Argo
+	// Rollouts and External Secrets.
+	Profiles []ProfileConfig `yaml:"profiles,omitempty"`
+	// Lifecycle maps parent kinds to the signals of their lifecycle, taking
+	// precedence over the built-in mappings. Changes of the config file
+	// apply without restart.
+	Lifecycle []LifecycleConfig `yaml:"lifecycle,omitempty"`
+	// Policies declare tracked resources like Kausality objects, for
+	// webhooks running standalone, without the policy controller and CRDs.
+	// They are only read with --standalone and reloaded on change.
+	Policies []PolicyConfig `yaml:"policies,omitempty"`
+	// Audit configures the audit annotations of admission responses.
+	Audit *AuditConfig `yaml:"audit,omitempty"`
+	// ApprovalRequests enables ApprovalRequest objects for drift denied in
+	// enforce mode, which humans decide to approve or reject the drift.
+	ApprovalRequests *ApprovalRequestsConfig `yaml:"approvalRequests,omitempty"`
+	// ReportLabels copies allowlisted labels of the child's namespace and
+	// of the parent into drift reports, so receivers can route and slice
+	// reports by e.g. team or environment without reading the cluster.
+	ReportLabels *ReportLabelsConfig `yaml:"reportLabels,omitempty"`
+	// Tombstones records the final trace and deleting actor of deleted
+	// objects as Events on them, which outlive the objects for the event TTL
+	// of the API server. Deletions are mirrored to the traceBackend
+	// regardless.
+	Tombstones *TombstonesConfig `yaml:"tombstones,omitempty"`
+	// TraceArchive writes the trace chains and drift evidence of terminal
+	// events, denials, resolved drift and deletions, to long-term storage
+	// outliving the cluster, e.g. an S3 or GCS bucket.
+	TraceArchive *TraceArchiveConfig `yaml:"traceArchive,omitempty"`
+	// OriginEvents attaches the most relevant recent Event on the object or
+	// its controller owner to origin hops of controllers, e.g. the
+	// ScalingReplicaSet of a Deployment, telling why they acted.
+	OriginEvents *OriginEventsConfig `yaml:"originEvents,omitempty"`
+	// SelfProtection denies changes of kausality's own CRDs and Deployments,
+	// and of its bookkeeping annotations, by identities other than
+	// kausality and the designated ones, whatever the policies.
+	SelfProtection *SelfProtectionConfig `yaml:"selfProtection,omitempty"`
+}
+
+// PolicyConfig is a Kausality policy declared in the config file. Its fields
+// are those of the Kausality spec, next to the policy name.
+type PolicyConfig struct {
+	// Name identifies the policy in logs, warnings and drift reports.
+	Name string `json:"name"`
+
+	kausalityv1beta1.KausalitySpec `json:",inline"`
+}
+
+// UnmarshalYAML decodes the policy with the field names of the Kausality
+// CRD, which are its JSON names.
+func (p *PolicyConfig) UnmarshalYAML(node *yaml.Node) error {
+	var raw map[string]interface{}
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(p); err != nil {
+		return fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	return nil
+}
+
+// ProfileConfig describes a Deployment-like parent kind. While it
+// progresses, changes of its children by its controller are expected.
+type ProfileConfig struct {
+	// APIVersion of the parent, e.g. "apps.example.com/v1". Only the group is
+	// matched.
+	APIVersion string `yaml:"apiVersion"`
+	// Kind of the parent.
+	Kind string `yaml:"kind"`
+	// PhasePath is the path of a status field, e.g. ".status.phase".
+	PhasePath string `yaml:"phasePath,omitempty"`
+	// ProgressingPhases are the values of PhasePath while the parent progresses.
+	ProgressingPhases []string `yaml:"progressingPhases,omitempty"`
+	// RevisionPaths are pairs of paths, e.g. of the current and the stable
+	// revision, whose values differ while the parent progresses.
+	RevisionPaths [][]string `yaml:"revisionPaths,omitempty"`
+	// DoneConditions are condition types of which one is True once the
+	// parent is done. Until then, the parent progresses.
+	DoneConditions []string `yaml:"doneConditions,omitempty"`
+	// ChildAnnotations mark children the controller changes independent of
+	// the parent's generation.
+	ChildAnnotations []string `yaml:"childAnnotations,omitempty"`
+	// Refresh describes periodic rewrites of children by the controller
+	// while the parent is stable, e.g. of Secrets synced from a vault.
+	Refresh *RefreshConfig `yaml:"refresh,omitempty"`
+}
+
+// RefreshConfig describes periodic rewrites of children by a controller.
+type RefreshConfig struct {
+	// IntervalPath is the path of the refresh interval of the parent, a
+	// duration like "1h". An interval of zero disables refreshes.
+	IntervalPath string `yaml:"intervalPath,omitempty"`
+	// DefaultInterval applies if the parent has no interval. Without
+	// interval, refreshes are expected at any time.
+	DefaultInterval time.Duration `yaml:"defaultInterval,omitempty"`
+	// LastRefreshPath is the path of the RFC 3339 time of the last refresh,
+	// e.g. ".status.refreshTime".
+	LastRefreshPath string `yaml:"lastRefreshPath,omitempty"`
+	// ChildAnnotations mark the refreshed children, of which one must be
+	// set. Empty for all children.
+	ChildAnnotations []string `yaml:"childAnnotations,omitempty"`
+	// FieldManagers are the field managers of refresh writes, or patterns,
+	// e.g. "my-operator/*". Empty for any field manager.
+	FieldManagers []string `yaml:"fieldManagers,omitempty"`
+}
+
+// LifecycleConfig maps a parent kind to the signals of its lifecycle.
+type LifecycleConfig struct {
+	// Group of the parent, or a pattern, e.g. "*.example.org". Empty for
+	// the core group.
+	Group string `yaml:"group,omitempty"`
+	// Kind of the parent, or a pattern, e.g. "*".
+	Kind string `yaml:"kind"`
+	// Ready signals, of which one matches once the parent is initialized.
+	Ready []LifecycleSignalConfig `yaml:"ready,omitempty"`
+	// Reconciling signals, of which one matches while the controller keeps
+	// changing children independent of the parent's generation.
+	Reconciling []LifecycleSignalConfig `yaml:"reconciling,omitempty"`
+	// Deleting signals, of which one matches while the parent is deleted.
+	Deleting []LifecycleSignalConfig `yaml:"deleting,omitempty"`
+}
+
+// LifecycleSignalConfig matches a condition, or a field by value.
+type LifecycleSignalConfig struct {
+	// Condition is a condition type.
+	Condition string `yaml:"condition,omitempty"`
+	// Status of the condition, "True" by default.
+	Status string `yaml:"status,omitempty"`
+	// Reasons restrict the condition to these reasons.
+	Reasons []string `yaml:"reasons,omitempty"`
+	// Path is the path of a field, e.g. ".status.phase".
+	Path string `yaml:"path,omitempty"`
+	// Values of the field matching.
+	Values []string `yaml:"values,omitempty"`
+	// EqualsPath is a path whose value the field's value matches, e.g.
+	// ".spec.replicas".
+	EqualsPath string `yaml:"equalsPath,omitempty"`
+}
+
+// ConnectionSecretConfig identifies a Crossplane managed resource kind.
+type ConnectionSecretConfig struct {
+	// APIVersion of the managed resource, e.g. "rds.aws.crossplane.io/v1beta1".
+	APIVersion string `yaml:"apiVersion"`
+	// Kind of the managed resource, e.g. "DBInstance".
+	Kind string `yaml:"kind"`
+}
+
+// CrossplaneConfig declares the Crossplane kinds whose edges are parent
+// edges for drift detection and tracing.
+type CrossplaneConfig struct {
+	// Composites are composite resource kinds. A composite is the parent of
+	// the resources in its resourceRefs.
+	Composites []CrossplaneKindConfig `yaml:"composites,omitempty"`
+	// Usages are Usage kinds, e.g. protection.crossplane.io/v1beta1 Usage
+	// and ClusterUsage. The used resource (spec.of) is the parent of the
+	// using resource (spec.by).
+	Usages []CrossplaneKindConfig `yaml:"usages,omitempty"`
+}
+
+// FluxConfig configures drift detection of objects applied by Flux.
+type FluxConfig struct {
+	// Enabled makes Kustomizations and HelmReleases parents of the objects
+	// they apply.
+	Enabled bool `yaml:"enabled"`
+	// DefaultServiceAccount is the ServiceAccount Flux impersonates to
+	// apply objects of Kustomizations and HelmReleases without
+	// spec.serviceAccountName, i.e. the --default-service-account flag of
+	// kustomize-controller and helm-controller, e.g. "flux-applier". Its
+	// changes are the controller's.
+	DefaultServiceAccount string `yaml:"defaultServiceAccount,omitempty"`
+}
+
+// CrossplaneKindConfig identifies a Crossplane kind.
+type CrossplaneKindConfig struct {
+	// APIVersion of the kind, e.g. "platform.example.org/v1alpha1".
+	APIVersion string `yaml:"apiVersion"`
+	// Kind, e.g. "XDatabase".
+	Kind string `yaml:"kind"`
+}
+
+// ReferenceConfig declares Secrets or ConfigMaps referenced by a resource,
+// e.g. the connection Secret of a Crossplane managed resource. Mutations of a
+// referenced object by the controller of a reconciling referrer extend the
+// referrer's trace.
+type ReferenceConfig struct {
+	// APIVersion of the referencing resource, e.g. "database.example.org/v1".
+	APIVersion string `yaml:"apiVersion"`
+	// Kind of the referencing resource.
+	Kind string `yaml:"kind"`
+	// TargetKind is the kind of the referenced objects: "Secret" or "ConfigMap".
+	TargetKind string `yaml:"targetKind"`
+	// Paths are JSONPaths to references, e.g. ".spec.writeConnectionSecretToRef".
+	// A reference is a name in the referrer's namespace, or an object with
+	// name and optional namespace.
+	Paths []string `yaml:"paths"`
+}
+
+// ErrorHandlingConfig maps error classes to actions: "allow" admits the
+// mutation with a warning, "deny" rejects it, "error" fails it with a
+// retryable HTTP 500. Empty uses the class default.
+type ErrorHandlingConfig struct {
+	// ParentNotFound applies when the controller owner does not exist.
+	// Default is "allow".
+	ParentNotFound string `yaml:"parentNotFound,omitempty"`
+	// ParentForbidden applies when the webhook may not read the parent.
+	// Default is "allow".
+	ParentForbidden string `yaml:"parentForbidden,omitempty"`
+	// DecodeError applies when the request cannot be decoded. Default is "deny".
+	DecodeError string `yaml:"decodeError,omitempty"`
+	// PolicyUnavailable applies when the drift detection mode cannot be
+	// resolved. Default is "allow".
+	PolicyUnavailable string `yaml:"policyUnavailable,omitempty"`
+	// Internal applies to all other errors. Default is "error".
+	Internal string `yaml:"internal,omitempty"`
+	// BudgetExhausted applies when an admission request exceeds its latency
+	// budget. Default is "allow".
+	BudgetExhausted string `yaml:"budgetExhausted,omitempty"`
+}
+
+// BudgetConfig configures the latency budget of admission requests.
+type BudgetConfig struct {
+	// Timeout is the latency budget of an admission request. It should be
+	// below the timeoutSeconds of the webhook configuration.
+	Timeout time.Duration `yaml:"timeout"`
+	// ParentTimeout bounds each resolution of a parent. Default is half
+	// of Timeout.
+	ParentTimeout time.Duration `yaml:"parentTimeout,omitempty"`
+	// CallbackTimeout bounds dispatching drift reports and approval
+	// requests. Default is a quarter of Timeout.
+	CallbackTimeout time.Duration `yaml:"callbackTimeout,omitempty"`
+}
+
+// Parent returns the timeout of parent resolutions.
+func (b *BudgetConfig) Parent() time.Duration {
+	if b.ParentTimeout == 0 {
+		return b.Timeout / 2
+	}
+	return b.ParentTimeout
+}
+
+// Callback returns the timeout of dispatching drift reports.
+func (b *BudgetConfig) Callback() time.Duration {
+	if b.CallbackTimeout == 0 {
+		return b.Timeout / 4
+	}
+	return b.CallbackTimeout
+}
+
+// ResourceFilterConfig configures the resources the admission handler
+// evaluates.
+type ResourceFilterConfig struct {
+	// RequireTracked admits requests for resources tracked by no Kausality
+	// policy without evaluation, unless they are allowed. It has no effect
+	// without policies, i.e. with the legacy driftDetection config.
+	RequireTracked bool `yaml:"requireTracked,omitempty"`
+	// Allow lists resources evaluated although no policy tracks them.
+	Allow []ResourceRuleConfig `yaml:"allow,omitempty"`
+	// Deny lists resources never evaluated, taking precedence over policies
+	// and Allow. They match the resource of the request or the resource it
+	// was converted from.
+	Deny []ResourceRuleConfig `yaml:"deny,omitempty"`
+}
+
+// ResourceRuleConfig matches resources by API group, version and resource.
+type ResourceRuleConfig struct {
+	// APIGroups are the API groups of the resources. "" is the core group.
+	APIGroups []string `yaml:"apiGroups"`
+	// Versions are the API versions of the resources, all if empty.
+	Versions []string `yaml:"versions,omitempty"`
+	// Resources are the resources, e.g. "deployments". "*" matches all
+	// resources of the API groups.
+	Resources []string `yaml:"resources"`
+}
+
+// Matches returns whether the rule matches gvr.
+func (r *ResourceRuleConfig) Matches(gvr schema.GroupVersionResource) bool {
+	return slices.Contains(r.APIGroups, gvr.Group) &&
+		(len(r.Versions) == 0 || slices.Contains(r.Versions, gvr.Version)) &&
+		(slices.Contains(r.Resources, "*") || slices.Contains(r.Resources, gvr.Resource))
+}
+
+// Allows returns whether gvr is listed in Allow.
+func (f *ResourceFilterConfig) Allows(gvr schema.GroupVersionResource) bool {
+	return matchesAny(f.Allow, gvr)
+}
+
+// Denies returns whether gvr is listed in Deny.
+func (f *ResourceFilterConfig) Denies(gvr schema.GroupVersionResource) bool {
+	return matchesAny(f.Deny, gvr)
+}
+
+func matchesAny(rules []ResourceRuleConfig, gvr schema.GroupVersionResource) bool {
+	for i := range rules {
+		if rules[i].Matches(gvr) {
+			return true
+		}
+	}
+	return false
+}
+
+// DriftStatusConfig configures the drift counters written on parents.
+type DriftStatusConfig struct {
+	// Window is the quiet period after which a parent's drift counter restarts.
+	// Default is 24 hours.
+	Window time.Duration `yaml:"window,omitempty"`
+}
+
+// DecisionCacheConfig configures the drift denial cache.
+type DecisionCacheConfig struct {
+	// TTL is how long a denial is reused for identical attempts.
+	// Default is 5 seconds.
+	TTL time.Duration `yaml:"ttl,omitempty"`
+}
+
+// AuditConfig configures audit annotations.
+type AuditConfig struct {
+	// KeyPrefix is prepended to the audit annotation keys, e.g.
+	// "audit.kausality.io/" for "audit.kausality.io/decision", or
+	// "kausality-" for "kausality-decision". Prefixed keys must be qualified
+	// names. Default is "kausality.io/". The webhook defaults to "": the
+	// apiserver prefixes the keys of webhook responses with the webhook name
+	// and drops keys containing another "/".
+	KeyPrefix *string `yaml:"keyPrefix,omitempty"`
+}
+
+// ApprovalRequestsConfig configures the ApprovalRequests of denied drift.
+type ApprovalRequestsConfig struct {
+	// Namespace holds the ApprovalRequests of cluster-scoped parents, e.g.
+	// Crossplane composites. Requests of namespaced parents are created in
+	// their namespace. Without it, denied drift of children of cluster-scoped
+	// parents is not requested.
+	Namespace string `yaml:"namespace,omitempty"`
+	// TTL is how long requests are kept after they are decided or became
+	// stale. Default is 24 hours.
+	TTL time.Duration `yaml:"ttl,omitempty"`
+}
+
+// ReportLabelsConfig allowlists the labels included in drift reports. Keys
+// are patterns of path.Match, e.g. "team" or "example.com/*".
+type ReportLabelsConfig struct {
+	// Namespace are the label keys of the child's namespace, or of the
+	// parent's namespace for cluster-scoped children.
+	Namespace []string `yaml:"namespace,omitempty"`
+	// Parent are the label keys of the parent.
+	Parent []string `yaml:"parent,omitempty"`
+}
+
+// TombstonesConfig configures the Events recorded for deleted objects.
+type TombstonesConfig struct {
+	// ExcludeGarbageCollected skips the Events of objects deleted by the
+	// garbage collector, one per dependent of a deleted owner. Their
+	// deletions are still mirrored, with the owner as cause.
+	ExcludeGarbageCollected bool `yaml:"excludeGarbageCollected,omitempty"`
+}
+
+// TraceArchiveConfig configures the trace archive. Exactly one of
+// filesystem, s3 and gcs must be set.
+type TraceArchiveConfig struct {
+	// Prefix is prepended to the keys of archived records, e.g. the name of
+	// the cluster followed by a slash.
+	Prefix string `yaml:"prefix,omitempty"`
+	// Events are the terminal events archived: "Denied", "Resolved" and
+	// "Deleted". Resolved drift is only known with backends configured.
+	// Default is all events.
+	Events []string `yaml:"events,omitempty"`
+	// RetryCount is the number of retries of failed writes. Default is 3.
+	RetryCount int `yaml:"retryCount,omitempty"`
+	// RetryInterval is the interval between retries. Default is 1 second.
+	RetryInterval time.Duration `yaml:"retryInterval,omitempty"`
+	// Filesystem archives records as files below a directory.
+	Filesystem *FilesystemArchiveConfig `yaml:"filesystem,omitempty"`
+	// S3 archives records as objects in an S3 bucket.
+	S3 *S3ArchiveConfig `yaml:"s3,omitempty"`
+	// GCS archives records as objects in a GCS bucket.
+	GCS *GCSArchiveConfig `yaml:"gcs,omitempty"`
+}
+
+// FilesystemArchiveConfig configures a trace archive on the filesystem.
+type FilesystemArchiveConfig struct {
+	// Dir is the directory records are written below, e.g. the mount path
+	// of a persistent volume.
+	Dir string `yaml:"dir"`
+}
+
+// S3ArchiveConfig configures a trace archive in an S3 bucket. Credentials
+// are read from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+// AWS_SESSION_TOKEN.
+type S3ArchiveConfig struct {
+	// Bucket is the name of the bucket.
+	Bucket string `yaml:"bucket"`
+	// Region is the region of the bucket. Default is "us-east-1".
+	Region string `yaml:"region,omitempty"`
+	// Endpoint is the URL of an S3-compatible service, e.g. MinIO. If
+	// empty, AWS S3 is used.
+	Endpoint string `yaml:"endpoint,omitempty"`
+}
+
+// GCSArchiveConfig configures a trace archive in a GCS bucket.
+type GCSArchiveConfig struct {
+	// Bucket is the name of the bucket.
+	Bucket string `yaml:"bucket"`
+	// TokenFile is the path to a file containing an OAuth2 access token.
+	// If empty, tokens of the metadata server are used, e.g. of the Google
+	// service account bound by GKE Workload Identity.
+	TokenFile string `yaml:"tokenFile,omitempty"`
+}
+
+// RecreationConfig configures the detection of recreated children.
+type RecreationConfig struct {
+	// Window is how long after its deletion a child can be recreated and
+	// continue its trace. Default is 1 minute.
+	Window time.Duration `yaml:"window,omitempty"`
+}
+
+// SelfProtectionConfig configures the protection of kausality's own
+// resources. The CRDs of the kausality.io group are always protected.
+type SelfProtectionConfig struct {
+	// Namespace is the namespace kausality is installed in.
+	Namespace string `yaml:"namespace,omitempty"`
+	// Deployments are the names of kausality's Deployments in Namespace,
+	// e.g. of the webhook and the controller.
+	Deployments []string `yaml:"deployments,omitempty"`
+	// Users may change protected resources and remove bookkeeping
+	// annotations, in addition to kausality's own ServiceAccounts, e.g. the
+	// user upgrading the Helm release.
+	Users []string `yaml:"users,omitempty"`
+	// Groups are groups whose members may do so, e.g. a break-glass group.
+	Groups []string `yaml:"groups,omitempty"`
+}
+
+// OriginEventsConfig configures the Events attached to origin hops of
+// controllers.
+type OriginEventsConfig struct {
+	// Window is how recent Events must be to explain a change. Default is
+	// 5 minutes.
+	Window time.Duration `yaml:"window,omitempty"`
+	// Timeout bounds listing the Events of a request, which are left out
+	// when it expires. Default is 1 second.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+// TraceSamplingConfig configures the sampling of traces of resources.
+// Objects not sampled are still checked for drift, but get no trace and
+// summary annotations and are not mirrored to the trace backend.
+type TraceSamplingConfig struct {
+	// APIGroups are the API groups of the resources. "" is the core group.
+	APIGroups []string `yaml:"apiGroups"`
+	// Resources are the sampled resources, e.g. "pods". "*" matches all
+	// resources of the API groups.
+	Resources []string `yaml:"resources"`
+	// Rate is the fraction of objects traced, from 0 to 1. Objects are
+	// sampled by namespace and name, so an object is traced on all its
+	// mutations and by all replicas, or never.
+	Rate float64 `yaml:"rate"`
+	// RecentDrift traces objects whose parent had drift detected on its
+	// children within this duration, whatever the rate. Requires driftStatus,
+	// which records the last drift time on parents.
+	RecentDrift time.Duration `yaml:"recentDrift,omitempty"`
+}
+
+// Matches returns whether the entry samples resources of gvr.
+func (s *TraceSamplingConfig) Matches(gvr schema.GroupVersionResource) bool {
+	return slices.Contains(s.APIGroups, gvr.Group) &&
+		(slices.Contains(s.Resources, "*") || slices.Contains(s.Resources, gvr.Resource))
+}
+
+// TraceSigningConfig configures the signing of trace hops.
+type TraceSigningConfig struct {
+	// KeyFile is the path to the signing key: a PEM-encoded, unencrypted
+	// ECDSA or Ed25519 private key, or else an HMAC key shared with the
+	// verifiers.
+	KeyFile string `yaml:"keyFile"`
+}
+
+// TicketValidationConfig configures the external ticket system.
+type TicketValidationConfig struct {
+	// Provider is the ticket system: "jira" or "github".
+	Provider string `yaml:"provider"`
+	// URL is the API base URL. Required for Jira.
+	// Defaults to https://api.github.com for GitHub.
+	URL string `yaml:"url,omitempty"`
+	// Repository is the default "owner/repo" for GitHub references like "#42".
+	// It is always allowed.
+	Repository string `yaml:"repository,omitempty"`
+	// Repositories lists further "owner/repo" GitHub references may name.
+	// GitHub requires a repository or repositories.
+	Repositories []string `yaml:"repositories,omitempty"`
+	// Projects lists the Jira project keys tickets may belong to.
+	// Empty allows any project.
+	Projects []string `yaml:"projects,omitempty"`
+	// Username enables basic auth with the token as password (Jira only).
+	Username string `yaml:"username,omitempty"`
+	// TokenFile is the path to a file containing the API token.
+	TokenFile string `yaml:"tokenFile,omitempty"`
+	// AllowedStates lists ticket states that permit changes (case-insensitive).
+	// Empty allows any state.
+	AllowedStates []string `yaml:"allowedStates,omitempty"`
+	// Timeout is the request timeout. Default is 5 seconds.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// CacheTTL is how long validation results are reused. Default is 30
+	// seconds; negative disables the cache.
+	CacheTTL time.Duration `yaml:"cacheTTL,omitempty"`
 }
 
 // BackendConfig configures a drift report webhook endpoint.
 type BackendConfig struct {
+	// Name identifies the backend in logs, metrics and failovers. Defaults
+	// to the URL. Not used for the trace backend.
+	Name string `yaml:"name,omitempty"`
 	// URL is the webhook endpoint URL.
 	URL string `yaml:"url"`
 	// CAFile is the path to the CA certificate file for TLS verification.
 	// If empty, system CA pool is used.
 	CAFile string `yaml:"caFile,omitempty"`
+	// TokenFile is the path to a file containing a bearer token sent with
+	// every request, e.g. the backend's --webhook-token-file.
+	TokenFile string `yaml:"tokenFile,omitempty"`
 	// Timeout is the request timeout. Default is 10 seconds.
 	Timeout time.Duration `yaml:"timeout,omitempty"`
 	// RetryCount is the number of retries on failure. Default is 3.
 	RetryCount int `yaml:"retryCount,omitempty"`
 	// RetryInterval is the interval between retries. Default is 1 second.
 	RetryInterval time.Duration `yaml:"retryInterval,omitempty"`
+	// BatchInterval is the interval at which repeated reports of the same
+	// drift are sent as one update. Default is 30 seconds. Not used for the
+	// trace backend.
+	BatchInterval time.Duration `yaml:"batchInterval,omitempty"`
+	// Route selects the reports sent to the backend. If omitted, all
+	// reports are sent. Not used for the trace backend.
+	Route *BackendRouteConfig `yaml:"route,omitempty"`
+	// Failover names the backend receiving the reports this backend failed
+	// to receive after all retries, and all of its reports while it is
+	// unhealthy. Not used for the trace backend.
+	Failover string `yaml:"failover,omitempty"`
+}
+
+// BackendRouteConfig selects the drift reports sent to a backend.
+type BackendRouteConfig struct {
+	// NamespaceSelector selects reports by the labels of the namespace of
+	// the drifted child, or of its parent for cluster-scoped children.
+	NamespaceSelector *metav1.LabelSelector `yaml:"namespaceSelector,omitempty" json:"namespaceSelector,omitempty"`
+	// MinSeverity is the lowest severity of detected drift sent: "low",
+	// "medium" (updates) or "high" (deletions). Dry runs, snoozed drift and
+	// creations are low. Resolutions are always sent.
+	MinSeverity string `yaml:"minSeverity,omitempty" json:"minSeverity,omitempty"`
+}
+
+// UnmarshalYAML decodes the route with the JSON field names of the label
+// selector, as in Kubernetes objects.
+func (r *BackendRouteConfig) UnmarshalYAML(node *yaml.Node) error {
+	var raw map[string]interface{}
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	type plain BackendRouteConfig
+	if err := dec.Decode((*plain)(r)); err != nil {
+		return fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	return nil
 }
 
 // DriftDetectionConfig configures drift detection behavior.
@@ -90,6 +708,13 @@ const (
 	ModeEnforce = "enforce"
 )
 
+// Error action constants.
+const (
+	ErrorActionAllow = "allow"
+	ErrorActionDeny  = "deny"
+	ErrorActionError = "error"
+)
+
 // ModeAnnotation is the annotation key for runtime mode configuration.
 const ModeAnnotation = "kausality.io/mode"
 
@@ -99,7 +724,11 @@ func Load(path string) (*Config, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
+	return Parse(data)
+}
 
+// Parse reads configuration from YAML.
+func Parse(data []byte) (*Config, error) {
 	var cfg Config
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
@@ -136,9 +765,345 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	if err := validateBackends(c.Backends); err != nil {
+		return err
+	}
+
+	if tb := c.TraceBackend; tb != nil && tb.URL == "" {
+		return fmt.Errorf("traceBackend: url is required")
+	}
+
+	if tv := c.TicketValidation; tv != nil {
+		switch tv.Provider {
+		case "jira":
+			if tv.URL == "" {
+				return fmt.Errorf("ticketValidation: url is required for provider %q", tv.Provider)
+			}
+		case "github":
+			if tv.Repository == "" && len(tv.Repositories) == 0 {
+				return fmt.Errorf("ticketValidation: repository or repositories is required for provider %q", tv.Provider)
+			}
+		default:
+			return fmt.Errorf("ticketValidation: invalid provider %q: must be %q or %q", tv.Provider, "jira", "github")
+		}
+	}
+
+	if ts := c.TraceSigning; ts != nil && ts.KeyFile == "" {
+		return fmt.Errorf("traceSigning: keyFile is required")
+	}
+
+	for i, ts := range c.TraceSampling {
+		if len(ts.APIGroups) == 0 || len(ts.Resources) == 0 {
+			return fmt.Errorf("traceSampling[%d]: apiGroups and resources must not be empty", i)
+		}
+		if ts.Rate < 0 || ts.Rate > 1 {
+			return fmt.Errorf("traceSampling[%d]: rate must be between 0 and 1", i)
+		}
+		if ts.RecentDrift < 0 {
+			return fmt.Errorf("traceSampling[%d]: recentDrift must not be negative", i)
+		}
+		if ts.RecentDrift > 0 && c.DriftStatus == nil {
+			return fmt.Errorf("traceSampling[%d]: recentDrift requires driftStatus", i)
+		}
+	}
+
+	if ds := c.DriftStatus; ds != nil && ds.Window < 0 {
+		return fmt.Errorf("driftStatus: window must not be negative")
+	}
+
+	if b := c.Budget; b != nil {
+		if b.Timeout <= 0 {
+			return fmt.Errorf("budget: timeout must be positive")
+		}
+		if b.ParentTimeout < 0 || b.ParentTimeout > b.Timeout {
+			return fmt.Errorf("budget: parentTimeout must be between 0 and timeout")
+		}
+		if b.CallbackTimeout < 0 || b.CallbackTimeout > b.Timeout {
+			return fmt.Errorf("budget: callbackTimeout must be between 0 and timeout")
+		}
+	}
+
+	if rf := c.ResourceFilter; rf != nil {
+		for i, r := range rf.Allow {
+			if len(r.APIGroups) == 0 || len(r.Resources) == 0 {
+				return fmt.Errorf("resourceFilter.allow[%d]: apiGroups and resources must not be empty", i)
+			}
+		}
+		for i, r := range rf.Deny {
+			if len(r.APIGroups) == 0 || len(r.Resources) == 0 {
+				return fmt.Errorf("resourceFilter.deny[%d]: apiGroups and resources must not be empty", i)
+			}
+		}
+	}
+
+	if dc := c.DecisionCache; dc != nil && dc.TTL < 0 {
+		return fmt.Errorf("decisionCache: ttl must not be negative")
+	}
+
+	if rc := c.Recreation; rc != nil && rc.Window < 0 {
+		return fmt.Errorf("recreation: window must not be negative")
+	}
+
+	if oe := c.OriginEvents; oe != nil && (oe.Window < 0 || oe.Timeout < 0) {
+		return fmt.Errorf("originEvents: window and timeout must not be negative")
+	}
+
+	if sp := c.SelfProtection; sp != nil && len(sp.Deployments) > 0 && sp.Namespace == "" {
+		return fmt.Errorf("selfProtection: namespace is required with deployments")
+	}
+
+	if ar := c.ApprovalRequests; ar != nil && ar.TTL < 0 {
+		return fmt.Errorf("approvalRequests: ttl must not be negative")
+	}
+
+	if rl := c.ReportLabels; rl != nil {
+		for name, patterns := range map[string][]string{"namespace": rl.Namespace, "parent": rl.Parent} {
+			for _, pattern := range patterns {
+				if _, err := path.Match(pattern, ""); err != nil {
+					return fmt.Errorf("reportLabels: invalid %s pattern %q: %w", name, pattern, err)
+				}
+			}
+		}
+	}
+
+	if ta := c.TraceArchive; ta != nil {
+		if err := ta.validate(); err != nil {
+			return fmt.Errorf("traceArchive: %w", err)
+		}
+	}
+
+	if a := c.Audit; a != nil && a.KeyPrefix != nil && *a.KeyPrefix != "" {
+		if msgs := validation.IsQualifiedName(*a.KeyPrefix + kausalityv1alpha1.AuditKeyDecision); len(msgs) > 0 {
+			return fmt.Errorf("audit: invalid keyPrefix %q: %s", *a.KeyPrefix, strings.Join(msgs, ", "))
+		}
+	}
+
+	for i, ref := range c.References {
+		if ref.APIVersion == "" || ref.Kind == "" {
+			return fmt.Errorf("references[%d]: apiVersion and kind are required", i)
+		}
+		if ref.TargetKind != "Secret" && ref.TargetKind != "ConfigMap" {
+			return fmt.Errorf("references[%d]: invalid targetKind %q: must be %q or %q", i, ref.TargetKind, "Secret", "ConfigMap")
+		}
+		if len(ref.Paths) == 0 {
+			return fmt.Errorf("references[%d]: paths must not be empty", i)
+		}
+	}
+
+	for i, p := range c.Profiles {
+		if p.APIVersion == "" || p.Kind == "" {
+			return fmt.Errorf("profiles[%d]: apiVersion and kind are required", i)
+		}
+		if _, err := schema.ParseGroupVersion(p.APIVersion); err != nil {
+			return fmt.Errorf("profiles[%d]: invalid apiVersion: %w", i, err)
+		}
+		if p.PhasePath == "" && len(p.RevisionPaths) == 0 && len(p.DoneConditions) == 0 && len(p.ChildAnnotations) == 0 && p.Refresh == nil {
+			return fmt.Errorf("profiles[%d]: one of phasePath, revisionPaths, doneConditions, childAnnotations or refresh is required", i)
+		}
+		if p.PhasePath != "" && len(p.ProgressingPhases) == 0 {
+			return fmt.Errorf("profiles[%d]: progressingPhases must not be empty with phasePath", i)
+		}
+		for j, paths := range p.RevisionPaths {
+			if len(paths) != 2 {
+				return fmt.Errorf("profiles[%d]: revisionPaths[%d] must have two paths", i, j)
+			}
+		}
+		if r := p.Refresh; r != nil {
+			if r.DefaultInterval < 0 {
+				return fmt.Errorf("profiles[%d]: refresh.defaultInterval must not be negative", i)
+			}
+			for _, pattern := range r.FieldManagers {
+				if _, err := path.Match(pattern, ""); err != nil {
+					return fmt.Errorf("profiles[%d]: invalid refresh.fieldManagers pattern %q: %w", i, pattern, err)
+				}
+			}
+		}
+	}
+
+	for i, l := range c.Lifecycle {
+		if err := l.validate(); err != nil {
+			return fmt.Errorf("lifecycle[%d]: %w", i, err)
+		}
+	}
+
+	names := make(map[string]bool)
+	for i, p := range c.Policies {
+		if p.Name == "" {
+			return fmt.Errorf("policies[%d]: name is required", i)
+		}
+		if names[p.Name] {
+			return fmt.Errorf("policies[%d]: duplicate name %q", i, p.Name)
+		}
+		names[p.Name] = true
+		if !isValidMode(string(p.Mode)) {
+			return fmt.Errorf("policies[%d]: invalid mode %q: must be %q or %q", i, p.Mode, ModeLog, ModeEnforce)
+		}
+		if len(p.Resources) == 0 {
+			return fmt.Errorf("policies[%d]: resources must not be empty", i)
+		}
+		for j, rule := range p.Resources {
+			if len(rule.APIGroups) == 0 || len(rule.Resources) == 0 {
+				return fmt.Errorf("policies[%d]: resources[%d]: apiGroups and resources must not be empty", i, j)
+			}
+			for _, group := range rule.APIGroups {
+				if group == "*" {
+					return fmt.Errorf("policies[%d]: resources[%d]: apiGroups cannot contain '*', use explicit group names", i, j)
+				}
+			}
+			for _, path := range rule.FieldPaths {
+				if _, err := fieldpath.Parse(path); err != nil {
+					return fmt.Errorf("policies[%d]: resources[%d]: %w", i, j, err)
+				}
+			}
+		}
+		switch p.ObjectSelectorMatch {
+		case "", kausalityv1beta1.ObjectSelectorMatchOldOrNewObject, kausalityv1beta1.ObjectSelectorMatchNewObject:
+		default:
+			return fmt.Errorf("policies[%d]: invalid objectSelectorMatch %q: must be %q or %q", i, p.ObjectSelectorMatch,
+				kausalityv1beta1.ObjectSelectorMatchOldOrNewObject, kausalityv1beta1.ObjectSelectorMatchNewObject)
+		}
+		switch p.MissingParent {
+		case "", kausalityv1beta1.MissingParentAllow, kausalityv1beta1.MissingParentWarn, kausalityv1beta1.MissingParentDeny:
+		default:
+			return fmt.Errorf("policies[%d]: invalid missingParent %q: must be %q, %q or %q", i, p.MissingParent,
+				kausalityv1beta1.MissingParentAllow, kausalityv1beta1.MissingParentWarn, kausalityv1beta1.MissingParentDeny)
+		}
+		switch p.OwnerReferences {
+		case "", kausalityv1beta1.OwnerReferenceAllow, kausalityv1beta1.OwnerReferenceWarn, kausalityv1beta1.OwnerReferenceDeny:
+		default:
+			return fmt.Errorf("policies[%d]: invalid ownerReferences %q: must be %q, %q or %q", i, p.OwnerReferences,
+				kausalityv1beta1.OwnerReferenceAllow, kausalityv1beta1.OwnerReferenceWarn, kausalityv1beta1.OwnerReferenceDeny)
+		}
+		switch p.DriftAction {
+		case "", kausalityv1beta1.DriftActionDeny, kausalityv1beta1.DriftActionQuarantine:
+		default:
+			return fmt.Errorf("policies[%d]: invalid driftAction %q: must be %q or %q", i, p.DriftAction,
+				kausalityv1beta1.DriftActionDeny, kausalityv1beta1.DriftActionQuarantine)
+		}
+		if err := validateControllerIdentity(p.ControllerIdentity); err != nil {
+			return fmt.Errorf("policies[%d]: controllerIdentity: %w", i, err)
+		}
+		for j, o := range p.Overrides {
+			if !isValidMode(string(o.Mode)) {
+				return fmt.Errorf("policies[%d]: overrides[%d]: invalid mode %q: must be %q or %q", i, j, o.Mode, ModeLog, ModeEnforce)
+			}
+		}
+		for j, sched := range p.Schedules {
+			if !isValidMode(string(sched.Mode)) {
+				return fmt.Errorf("policies[%d]: schedules[%d]: invalid mode %q: must be %q or %q", i, j, sched.Mode, ModeLog, ModeEnforce)
+			}
+			if err := validateSchedule(sched); err != nil {
+				return fmt.Errorf("policies[%d]: schedules[%d]: %w", i, j, err)
+			}
+		}
+	}
+
+	for i, cs := range c.ConnectionSecrets {
+		if cs.APIVersion == "" || cs.Kind == "" {
+			return fmt.Errorf("connectionSecrets[%d]: apiVersion and kind are required", i)
+		}
+	}
+	if cp := c.Crossplane; cp != nil {
+		for name, kinds := range map[string][]CrossplaneKindConfig{"composites": cp.Composites, "usages": cp.Usages} {
+			for i, k := range kinds {
+				if k.APIVersion == "" || k.Kind == "" {
+					return fmt.Errorf("crossplane.%s[%d]: apiVersion and kind are required", name, i)
+				}
+				if _, err := schema.ParseGroupVersion(k.APIVersion); err != nil {
+					return fmt.Errorf("crossplane.%s[%d]: invalid apiVersion %q: %w", name, i, k.APIVersion, err)
+				}
+			}
+		}
+	}
+
+	if f := c.Flux; f != nil && f.DefaultServiceAccount != "" {
+		if errs := validation.IsDNS1123Subdomain(f.DefaultServiceAccount); len(errs) > 0 {
+			return fmt.Errorf("flux.defaultServiceAccount: invalid name %q: %s", f.DefaultServiceAccount, strings.Join(errs, ", "))
+		}
+	}
+
+	eh := c.ErrorHandling
+	for name, action := range map[string]string{
+		"parentNotFound":    eh.ParentNotFound,
+		"parentForbidden":   eh.ParentForbidden,
+		"decodeError":       eh.DecodeError,
+		"policyUnavailable": eh.PolicyUnavailable,
+		"internal":          eh.Internal,
+		"budgetExhausted":   eh.BudgetExhausted,
+	} {
+		switch action {
+		case "", ErrorActionAllow, ErrorActionDeny, ErrorActionError:
+		default:
+			return fmt.Errorf("errorHandling.%s: invalid action %q: must be %q, %q or %q", name, action, ErrorActionAllow, ErrorActionDeny, ErrorActionError)
+		}
+	}
+
 	return nil
 }
 
+// validate checks the patterns and signals of a lifecycle mapping.
+func (l LifecycleConfig) validate() error {
+	if l.Kind == "" {
+		return fmt.Errorf("kind is required")
+	}
+	for _, pattern := range []string{l.Group, l.Kind} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	if len(l.Ready) == 0 && len(l.Reconciling) == 0 && len(l.Deleting) == 0 {
+		return fmt.Errorf("one of ready, reconciling or deleting is required")
+	}
+	for name, signals := range map[string][]LifecycleSignalConfig{"ready": l.Ready, "reconciling": l.Reconciling, "deleting": l.Deleting} {
+		for i, s := range signals {
+			switch {
+			case (s.Condition == "") == (s.Path == ""):
+				return fmt.Errorf("%s[%d]: one of condition or path is required", name, i)
+			case s.Condition != "" && s.Status != "" && s.Status != "True" && s.Status != "False" && s.Status != "Unknown":
+				return fmt.Errorf("%s[%d]: invalid status %q: must be True, False or Unknown", name, i, s.Status)
+			case s.Path != "" && (len(s.Values) == 0) == (s.EqualsPath == ""):
+				return fmt.Errorf("%s[%d]: one of values or equalsPath is required with path", name, i)
+			}
+		}
+	}
+	return nil
+}
+
+// validate checks that exactly one backend of the trace archive is set,
+// with its required fields, and the archived events.
+func (t TraceArchiveConfig) validate() error {
+	backends := 0
+	for _, set := range []bool{t.Filesystem != nil, t.S3 != nil, t.GCS != nil} {
+		if set {
+			backends++
+		}
+	}
+	if backends != 1 {
+		return fmt.Errorf("exactly one of filesystem, s3 or gcs is required")
+	}
+	switch {
+	case t.Filesystem != nil && t.Filesystem.Dir == "":
+		return fmt.Errorf("filesystem: dir is required")
+	case t.S3 != nil && t.S3.Bucket == "":
+		return fmt.Errorf("s3: bucket is required")
+	case t.GCS != nil && t.GCS.Bucket == "":
+		return fmt.Errorf("gcs: bucket is required")
+	case t.RetryCount < 0 || t.RetryInterval < 0:
+		return fmt.Errorf("retryCount and retryInterval must not be negative")
+	}
+	for _, event := range t.Events {
+		if event != "Denied" && event != "Resolved" && event != "Deleted" {
+			return fmt.Errorf("invalid event %q: must be Denied, Resolved or Deleted", event)
+		}
+	}
+	return nil
+}
+
+// Archives returns whether the trace archive archives event.
+func (t *TraceArchiveConfig) Archives(event string) bool {
+	return t != nil && (len(t.Events) == 0 || slices.Contains(t.Events, event))
+}
+
 // GetModeForResource returns the drift detection mode for a specific resource.
 // Deprecated: Use GetModeForResourceContext for full selector support.
 func (c *Config) GetModeForResource(gvk schema.GroupVersionKind) string {
@@ -285,6 +1250,110 @@ func isValidMode(mode string) bool {
 	return mode == ModeLog || mode == ModeEnforce
 }
 
+// validateBackends checks the names, routes and failovers of the backends.
+// Backends without URL are ignored, as by the senders.
+func validateBackends(backends []BackendConfig) error {
+	names := make(map[string]bool, len(backends))
+	for _, b := range backends {
+		if b.URL == "" {
+			continue
+		}
+		name := b.Name
+		if name == "" {
+			name = b.URL
+		}
+		if names[name] {
+			return fmt.Errorf("backends: duplicate name %q", name)
+		}
+		names[name] = true
+	}
+	for i, b := range backends {
+		if b.URL == "" {
+			continue
+		}
+		if r := b.Route; r != nil {
+			if r.NamespaceSelector != nil {
+				if _, err := metav1.LabelSelectorAsSelector(r.NamespaceSelector); err != nil {
+					return fmt.Errorf("backends[%d]: invalid route namespaceSelector: %w", i, err)
+				}
+			}
+			switch r.MinSeverity {
+			case "", "low", "medium", "high":
+			default:
+				return fmt.Errorf("backends[%d]: invalid route minSeverity %q: must be \"low\", \"medium\" or \"high\"", i, r.MinSeverity)
+			}
+		}
+		if b.Failover != "" && (!names[b.Failover] || b.Failover == b.Name || b.Failover == b.URL) {
+			return fmt.Errorf("backends[%d]: failover %q must name another backend", i, b.Failover)
+		}
+	}
+	return nil
+}
+
+// validateControllerIdentity checks the classifiers of a policy.
+func validateControllerIdentity(identity *kausalityv1beta1.ControllerIdentity) error {
+	if identity == nil {
+		return nil
+	}
+	for _, c := range identity.Classifiers {
+		switch c {
+		case kausalityv1beta1.ClassifierUpdaterHash, kausalityv1beta1.ClassifierFieldManager, kausalityv1beta1.ClassifierServiceAccount:
+		default:
+			return fmt.Errorf("invalid classifier %q: must be %q, %q or %q", c,
+				kausalityv1beta1.ClassifierUpdaterHash, kausalityv1beta1.ClassifierFieldManager, kausalityv1beta1.ClassifierServiceAccount)
+		}
+	}
+	switch identity.Combination {
+	case "", kausalityv1beta1.ClassifierCombinationFirst, kausalityv1beta1.ClassifierCombinationAny, kausalityv1beta1.ClassifierCombinationAll:
+	default:
+		return fmt.Errorf("invalid combination %q: must be %q, %q or %q", identity.Combination,
+			kausalityv1beta1.ClassifierCombinationFirst, kausalityv1beta1.ClassifierCombinationAny, kausalityv1beta1.ClassifierCombinationAll)
+	}
+	for _, pattern := range identity.ServiceAccounts {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid serviceAccounts pattern %q: %w", pattern, err)
+		}
+	}
+	if len(identity.ServiceAccounts) > 0 && !slices.Contains(identity.Classifiers, kausalityv1beta1.ClassifierServiceAccount) {
+		return fmt.Errorf("serviceAccounts require the %q classifier", kausalityv1beta1.ClassifierServiceAccount)
+	}
+	return nil
+}
+
+// validateSchedule checks what the CRD schema checks for Kausality objects.
+func validateSchedule(sched kausalityv1beta1.ModeSchedule) error {
+	for _, value := range []string{sched.Start, sched.End} {
+		if _, err := time.Parse("15:04", value); err != nil || len(value) != len("15:04") {
+			return fmt.Errorf("invalid time of day %q: must be HH:MM", value)
+		}
+	}
+	if sched.Start == sched.End {
+		return fmt.Errorf("start and end must differ")
+	}
+	for _, day := range sched.Days {
+		valid := false
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			valid = valid || d.String() == string(day)
+		}
+		if !valid {
+			return fmt.Errorf("invalid day %q: must be a weekday like Monday", day)
+		}
+	}
+	if _, err := time.LoadLocation(sched.TimeZone); err != nil {
+		return fmt.Errorf("invalid timeZone %q: %w", sched.TimeZone, err)
+	}
+	return nil
+}
+
+// AuditKeyPrefix returns the configured audit annotation key prefix, or
+// kausalityv1alpha1.DefaultAuditKeyPrefix.
+func (c *Config) AuditKeyPrefix() string {
+	if c.Audit != nil && c.Audit.KeyPrefix != nil {
+		return *c.Audit.KeyPrefix
+	}
+	return kausalityv1alpha1.DefaultAuditKeyPrefix
+}
+
 // Default returns a default configuration with log mode.
 func Default() *Config {
 	return &Config{
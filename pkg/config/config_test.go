@@ -11,6 +11,7 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
 )
 
 func TestDefault(t *testing.T) {
@@ -18,6 +19,41 @@ func TestDefault(t *testing.T) {
 	assert.Equal(t, ModeLog, cfg.DriftDetection.DefaultMode)
 }
 
+func TestBudgetConfig(t *testing.T) {
+	b := &BudgetConfig{Timeout: 2 * time.Second}
+	assert.Equal(t, time.Second, b.Parent())
+	assert.Equal(t, 500*time.Millisecond, b.Callback())
+	b = &BudgetConfig{Timeout: 2 * time.Second, ParentTimeout: 1500 * time.Millisecond, CallbackTimeout: 100 * time.Millisecond}
+	assert.Equal(t, 1500*time.Millisecond, b.Parent())
+	assert.Equal(t, 100*time.Millisecond, b.Callback())
+
+	cfg, err := Parse([]byte("budget:\n  timeout: 2s\n  parentTimeout: 1s\nerrorHandling:\n  budgetExhausted: deny\n"))
+	require.NoError(t, err)
+	assert.Equal(t, &BudgetConfig{Timeout: 2 * time.Second, ParentTimeout: time.Second}, cfg.Budget)
+	assert.Equal(t, ErrorActionDeny, cfg.ErrorHandling.BudgetExhausted)
+}
+
+func TestResourceFilterConfig(t *testing.T) {
+	cfg, err := Parse([]byte(`resourceFilter:
+  requireTracked: true
+  allow:
+  - apiGroups: [""]
+    resources: ["secrets"]
+  deny:
+  - apiGroups: ["extensions"]
+    versions: ["v1beta1"]
+    resources: ["*"]
+`))
+	require.NoError(t, err)
+	f := cfg.ResourceFilter
+	require.NotNil(t, f)
+	assert.True(t, f.RequireTracked)
+	assert.True(t, f.Allows(schema.GroupVersionResource{Version: "v1", Resource: "secrets"}))
+	assert.False(t, f.Allows(schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}))
+	assert.True(t, f.Denies(schema.GroupVersionResource{Group: "extensions", Version: "v1beta1", Resource: "deployments"}))
+	assert.False(t, f.Denies(schema.GroupVersionResource{Group: "extensions", Version: "v1", Resource: "deployments"}))
+}
+
 func TestValidate(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -115,6 +151,462 @@ func TestValidate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "valid github ticket validation",
+			config: Config{
+				DriftDetection:   DriftDetectionConfig{DefaultMode: ModeEnforce},
+				TicketValidation: &TicketValidationConfig{Provider: "github", Repository: "org/repo"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "github ticket validation without repository",
+			config: Config{
+				DriftDetection:   DriftDetectionConfig{DefaultMode: ModeEnforce},
+				TicketValidation: &TicketValidationConfig{Provider: "github"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "jira ticket validation without url",
+			config: Config{
+				DriftDetection:   DriftDetectionConfig{DefaultMode: ModeEnforce},
+				TicketValidation: &TicketValidationConfig{Provider: "jira"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown ticket provider",
+			config: Config{
+				DriftDetection:   DriftDetectionConfig{DefaultMode: ModeEnforce},
+				TicketValidation: &TicketValidationConfig{Provider: "linear"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicate backend names",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				Backends:       []BackendConfig{{Name: "soc", URL: "https://a"}, {Name: "soc", URL: "https://b"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "backend failover to unknown backend",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				Backends:       []BackendConfig{{Name: "local", URL: "https://a", Failover: "soc"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "backend route with invalid severity",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				Backends:       []BackendConfig{{URL: "https://a", Route: &BackendRouteConfig{MinSeverity: "critical"}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "backend routes and failover",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				Backends: []BackendConfig{
+					{Name: "local", URL: "https://a", Failover: "soc"},
+					{Name: "soc", URL: "https://b", Route: &BackendRouteConfig{MinSeverity: "medium"}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "trace backend without url",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				TraceBackend:   &BackendConfig{Timeout: time.Second},
+			},
+			wantErr: true,
+		},
+		{
+			name: "drift status with default window",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				DriftStatus:    &DriftStatusConfig{},
+			},
+			wantErr: false,
+		},
+		{
+			name: "drift status with negative window",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				DriftStatus:    &DriftStatusConfig{Window: -time.Hour},
+			},
+			wantErr: true,
+		},
+		{
+			name: "error handling actions",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				ErrorHandling:  ErrorHandlingConfig{ParentNotFound: ErrorActionDeny, Internal: ErrorActionAllow},
+			},
+			wantErr: false,
+		},
+		{
+			name: "negative decision cache ttl",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				DecisionCache:  &DecisionCacheConfig{TTL: -time.Second},
+			},
+			wantErr: true,
+		},
+		{
+			name: "audit key prefix with domain",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				Audit:          &AuditConfig{KeyPrefix: ptr.To("audit.kausality.io/")},
+			},
+			wantErr: false,
+		},
+		{
+			name: "audit key prefix without domain",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				Audit:          &AuditConfig{KeyPrefix: ptr.To("kausality-")},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid audit key prefix",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				Audit:          &AuditConfig{KeyPrefix: ptr.To("audit/kausality.io/")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative recreation window",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				Recreation:     &RecreationConfig{Window: -time.Second},
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative origin events timeout",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				OriginEvents:   &OriginEventsConfig{Timeout: -time.Second},
+			},
+			wantErr: true,
+		},
+		{
+			name: "self-protection deployments without namespace",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				SelfProtection: &SelfProtectionConfig{Deployments: []string{"kausality-webhook"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative approval request ttl",
+			config: Config{
+				DriftDetection:   DriftDetectionConfig{DefaultMode: ModeLog},
+				ApprovalRequests: &ApprovalRequestsConfig{TTL: -time.Hour},
+			},
+			wantErr: true,
+		},
+		{
+			name: "report labels",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				ReportLabels:   &ReportLabelsConfig{Namespace: []string{"team", "env"}, Parent: []string{"example.com/*"}},
+			},
+		},
+		{
+			name: "invalid report label pattern",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				ReportLabels:   &ReportLabelsConfig{Parent: []string{"team["}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "trace archive",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				TraceArchive:   &TraceArchiveConfig{Events: []string{"Denied", "Deleted"}, S3: &S3ArchiveConfig{Bucket: "archive"}},
+			},
+		},
+		{
+			name: "trace archive without backend",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				TraceArchive:   &TraceArchiveConfig{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "trace archive with two backends",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				TraceArchive: &TraceArchiveConfig{
+					Filesystem: &FilesystemArchiveConfig{Dir: "/archive"},
+					GCS:        &GCSArchiveConfig{Bucket: "archive"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "trace archive without bucket",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				TraceArchive:   &TraceArchiveConfig{GCS: &GCSArchiveConfig{}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "trace archive with invalid event",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				TraceArchive:   &TraceArchiveConfig{Events: []string{"Detected"}, Filesystem: &FilesystemArchiveConfig{Dir: "/archive"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "trace signing without key file",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				TraceSigning:   &TraceSigningConfig{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "trace sampling",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				DriftStatus:    &DriftStatusConfig{},
+				TraceSampling: []TraceSamplingConfig{
+					{APIGroups: []string{""}, Resources: []string{"pods"}, Rate: 0.01, RecentDrift: 10 * time.Minute},
+				},
+			},
+		},
+		{
+			name: "trace sampling rate above 1",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				TraceSampling:  []TraceSamplingConfig{{APIGroups: []string{""}, Resources: []string{"pods"}, Rate: 2}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "trace sampling by recent drift without drift status",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				TraceSampling:  []TraceSamplingConfig{{APIGroups: []string{""}, Resources: []string{"pods"}, RecentDrift: time.Minute}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "profile",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				Profiles: []ProfileConfig{
+					{APIVersion: "flagger.app/v1beta1", Kind: "Canary", PhasePath: ".status.phase", ProgressingPhases: []string{"Progressing"}},
+				},
+			},
+		},
+		{
+			name: "profile without progressing phases",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				Profiles:       []ProfileConfig{{APIVersion: "flagger.app/v1beta1", Kind: "Canary", PhasePath: ".status.phase"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "refresh profile",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				Profiles: []ProfileConfig{{APIVersion: "vault.example.org/v1", Kind: "VaultSecret", Refresh: &RefreshConfig{
+					IntervalPath: ".spec.refreshAfter", DefaultInterval: time.Hour, FieldManagers: []string{"vault-operator/*"},
+				}}},
+			},
+		},
+		{
+			name: "refresh profile with invalid field manager",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				Profiles:       []ProfileConfig{{APIVersion: "vault.example.org/v1", Kind: "VaultSecret", Refresh: &RefreshConfig{FieldManagers: []string{"[vault"}}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "profile with one revision path",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				Profiles:       []ProfileConfig{{APIVersion: "flagger.app/v1beta1", Kind: "Canary", RevisionPaths: [][]string{{".status.lastAppliedSpec"}}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "reference",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				References: []ReferenceConfig{
+					{APIVersion: "database.example.org/v1", Kind: "PostgresInstance", TargetKind: "Secret", Paths: []string{".spec.writeConnectionSecretToRef"}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "reference to unsupported kind",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				References: []ReferenceConfig{
+					{APIVersion: "database.example.org/v1", Kind: "PostgresInstance", TargetKind: "Pod", Paths: []string{".spec.pod"}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "reference without paths",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				References:     []ReferenceConfig{{APIVersion: "database.example.org/v1", Kind: "PostgresInstance", TargetKind: "Secret"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "connection secret without kind",
+			config: Config{
+				DriftDetection:    DriftDetectionConfig{DefaultMode: ModeLog},
+				ConnectionSecrets: []ConnectionSecretConfig{{APIVersion: "rds.aws.crossplane.io/v1beta1"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "crossplane composites and usages",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				Crossplane: &CrossplaneConfig{
+					Composites: []CrossplaneKindConfig{{APIVersion: "platform.example.org/v1", Kind: "XNetwork"}},
+					Usages:     []CrossplaneKindConfig{{APIVersion: "protection.crossplane.io/v1beta1", Kind: "Usage"}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "crossplane usage without kind",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				Crossplane:     &CrossplaneConfig{Usages: []CrossplaneKindConfig{{APIVersion: "protection.crossplane.io/v1beta1"}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "flux with default service account",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				Flux:           &FluxConfig{Enabled: true, DefaultServiceAccount: "flux-applier"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "flux with invalid default service account",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				Flux:           &FluxConfig{Enabled: true, DefaultServiceAccount: "Flux Applier"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "lifecycle mappings",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				Lifecycle: []LifecycleConfig{{
+					Group:       "*.example.org",
+					Kind:        "*",
+					Ready:       []LifecycleSignalConfig{{Condition: "Ready"}, {Path: ".status.readyReplicas", EqualsPath: ".spec.replicas"}},
+					Reconciling: []LifecycleSignalConfig{{Path: ".status.phase", Values: []string{"Provisioning"}}},
+					Deleting:    []LifecycleSignalConfig{{Condition: "Ready", Status: "False", Reasons: []string{"Deleting"}}},
+				}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "lifecycle mapping without signals",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				Lifecycle:      []LifecycleConfig{{Group: "example.org", Kind: "Widget"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "lifecycle signal with condition and path",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				Lifecycle: []LifecycleConfig{{Kind: "Widget", Ready: []LifecycleSignalConfig{
+					{Condition: "Ready", Path: ".status.phase", Values: []string{"Ready"}},
+				}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "lifecycle path without values",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				Lifecycle:      []LifecycleConfig{{Kind: "Widget", Ready: []LifecycleSignalConfig{{Path: ".status.phase"}}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "lifecycle invalid group pattern",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				Lifecycle:      []LifecycleConfig{{Group: "[", Kind: "Widget", Ready: []LifecycleSignalConfig{{Condition: "Ready"}}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid error handling action",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				ErrorHandling:  ErrorHandlingConfig{ParentForbidden: "ignore"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid budget",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				ErrorHandling:  ErrorHandlingConfig{BudgetExhausted: ErrorActionDeny},
+				Budget:         &BudgetConfig{Timeout: 2 * time.Second, ParentTimeout: time.Second},
+			},
+			wantErr: false,
+		},
+		{
+			name: "budget without timeout",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				Budget:         &BudgetConfig{ParentTimeout: time.Second},
+			},
+			wantErr: true,
+		},
+		{
+			name: "parent timeout exceeding budget",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				Budget:         &BudgetConfig{Timeout: time.Second, ParentTimeout: 2 * time.Second},
+			},
+			wantErr: true,
+		},
+		{
+			name: "resource filter deny without resources",
+			config: Config{
+				DriftDetection: DriftDetectionConfig{DefaultMode: ModeLog},
+				ResourceFilter: &ResourceFilterConfig{Deny: []ResourceRuleConfig{{APIGroups: []string{"extensions"}}}},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -940,6 +1432,49 @@ backends:
 				assert.Equal(t, 2*time.Second, b.RetryInterval)
 			},
 		},
+		{
+			name: "backend route and failover",
+			content: `
+driftDetection:
+  defaultMode: log
+backends:
+  - name: local
+    url: http://kausality-backend:8080/webhook
+    failover: soc
+  - name: soc
+    url: https://soc.example.com/webhook
+    route:
+      namespaceSelector:
+        matchLabels:
+          tier: production
+      minSeverity: medium
+`,
+			wantBackends: 2,
+			checkBackend: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, "soc", cfg.Backends[0].Failover)
+				route := cfg.Backends[1].Route
+				require.NotNil(t, route)
+				require.NotNil(t, route.NamespaceSelector)
+				assert.Equal(t, map[string]string{"tier": "production"}, route.NamespaceSelector.MatchLabels)
+				assert.Equal(t, "medium", route.MinSeverity)
+			},
+		},
+		{
+			name: "trace backend",
+			content: `
+driftDetection:
+  defaultMode: log
+traceBackend:
+  url: http://kausality-backend:8080/api/v1/traces
+  timeout: 5s
+`,
+			wantBackends: 0,
+			checkBackend: func(t *testing.T, cfg *Config) {
+				require.NotNil(t, cfg.TraceBackend)
+				assert.Equal(t, "http://kausality-backend:8080/api/v1/traces", cfg.TraceBackend.URL)
+				assert.Equal(t, 5*time.Second, cfg.TraceBackend.Timeout)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -962,3 +1497,69 @@ backends:
 		})
 	}
 }
+
+func TestParse_Policies(t *testing.T) {
+	cfg, err := Parse([]byte(`
+policies:
+  - name: apps
+    resources:
+      - apiGroups: ["apps"]
+        resources: ["*"]
+        excluded: ["replicasets"]
+    namespaces:
+      selector:
+        matchLabels:
+          env: prod
+      excluded: ["kube-system"]
+    mode: log
+    schedules:
+      - days: ["Monday", "Friday"]
+        start: "22:00"
+        end: "02:00"
+        timeZone: Europe/Berlin
+        mode: enforce
+    overrides:
+      - namespaces: ["payments"]
+        mode: enforce
+    controllerIdentity:
+      classifiers: ["ServiceAccount", "UpdaterHash"]
+      serviceAccounts: ["system:serviceaccount:kube-system:*"]
+`))
+	require.NoError(t, err)
+	require.Len(t, cfg.Policies, 1)
+	p := cfg.Policies[0]
+	assert.Equal(t, "apps", p.Name)
+	assert.Equal(t, []string{"replicasets"}, p.Resources[0].Excluded)
+	require.NotNil(t, p.Namespaces)
+	assert.Equal(t, map[string]string{"env": "prod"}, p.Namespaces.Selector.MatchLabels)
+	assert.Equal(t, []string{"kube-system"}, p.Namespaces.Excluded)
+	require.Len(t, p.Overrides, 1)
+	assert.Equal(t, []string{"payments"}, p.Overrides[0].Namespaces)
+	require.Len(t, p.Schedules, 1)
+	assert.Equal(t, "Europe/Berlin", p.Schedules[0].TimeZone)
+	require.NotNil(t, p.ControllerIdentity)
+	assert.Equal(t, []string{"system:serviceaccount:kube-system:*"}, p.ControllerIdentity.ServiceAccounts)
+
+	for name, content := range map[string]string{
+		"missing name":                "policies:\n  - resources: [{apiGroups: [apps], resources: [deployments]}]\n    mode: log\n",
+		"duplicate name":              "policies:\n  - name: a\n    resources: [{apiGroups: [apps], resources: [deployments]}]\n    mode: log\n  - name: a\n    resources: [{apiGroups: [apps], resources: [deployments]}]\n    mode: log\n",
+		"invalid mode":                "policies:\n  - name: a\n    resources: [{apiGroups: [apps], resources: [deployments]}]\n    mode: block\n",
+		"no resources":                "policies:\n  - name: a\n    mode: log\n",
+		"wildcard group":              "policies:\n  - name: a\n    resources: [{apiGroups: ['*'], resources: [deployments]}]\n    mode: log\n",
+		"unknown field":               "policies:\n  - name: a\n    resources: [{apiGroups: [apps], resources: [deployments]}]\n    mode: log\n    namespace: prod\n",
+		"invalid override":            "policies:\n  - name: a\n    resources: [{apiGroups: [apps], resources: [deployments]}]\n    mode: log\n    overrides: [{namespaces: [prod], mode: block}]\n",
+		"invalid objectSelectorMatch": "policies:\n  - name: a\n    resources: [{apiGroups: [apps], resources: [deployments]}]\n    mode: log\n    objectSelectorMatch: OldObject\n",
+		"invalid classifier":          "policies:\n  - name: a\n    resources: [{apiGroups: [apps], resources: [deployments]}]\n    mode: log\n    controllerIdentity: {classifiers: [Username]}\n",
+		"unused serviceAccounts":      "policies:\n  - name: a\n    resources: [{apiGroups: [apps], resources: [deployments]}]\n    mode: log\n    controllerIdentity: {serviceAccounts: ['system:serviceaccount:*']}\n",
+		"invalid serviceAccounts":     "policies:\n  - name: a\n    resources: [{apiGroups: [apps], resources: [deployments]}]\n    mode: log\n    controllerIdentity: {classifiers: [ServiceAccount], serviceAccounts: ['system:[']}\n",
+		"invalid schedule":            "policies:\n  - name: a\n    resources: [{apiGroups: [apps], resources: [deployments]}]\n    mode: log\n    schedules: [{start: '9:00', end: '17:00', mode: enforce}]\n",
+		"empty schedule":              "policies:\n  - name: a\n    resources: [{apiGroups: [apps], resources: [deployments]}]\n    mode: log\n    schedules: [{start: '09:00', end: '09:00', mode: enforce}]\n",
+		"invalid day":                 "policies:\n  - name: a\n    resources: [{apiGroups: [apps], resources: [deployments]}]\n    mode: log\n    schedules: [{days: [Mon], start: '09:00', end: '17:00', mode: enforce}]\n",
+		"invalid timezone":            "policies:\n  - name: a\n    resources: [{apiGroups: [apps], resources: [deployments]}]\n    mode: log\n    schedules: [{start: '09:00', end: '17:00', timeZone: Mars/Olympus, mode: enforce}]\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse([]byte(content))
+			assert.Error(t, err)
+		})
+	}
+}
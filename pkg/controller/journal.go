@@ -0,0 +1,293 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// JournalLabel marks the ConfigMaps of record journals.
+	JournalLabel = "kausality.io/record-journal"
+	// journalNamePrefix is the name prefix of journal ConfigMaps.
+	journalNamePrefix = "kausality-records-"
+	// journalMaxEntries bounds the entries of a journal ConfigMap, far below
+	// the size limit of objects.
+	journalMaxEntries = 2000
+	// journalFlushInterval is how often replicas write their records.
+	journalFlushInterval = time.Second
+	// journalDrainInterval is how often the leader reads the journals.
+	journalDrainInterval = 2 * time.Second
+	// journalFlushTimeout bounds the last flush on shutdown.
+	journalFlushTimeout = 5 * time.Second
+)
+
+// dropJournalFull is the reason of records dropped because a journal is full.
+const dropJournalFull = "journal_full"
+
+// Journal persists the records of the Keeper in a ConfigMap per replica, so
+// that records received by replicas that are not leading, and records the
+// leader had not applied when it stopped, are not lost. Every replica
+// writes its records to its journal every second; the leader reads all
+// journals, applies their records and removes them once applied. Only
+// records received in the second before a replica crashes are lost.
+type Journal struct {
+	client    client.Client
+	reader    client.Reader
+	namespace string
+	name      string
+	log       logr.Logger
+
+	flushInterval time.Duration
+
+	mu        sync.Mutex
+	unflushed map[keeperKey]*keeperRecord
+}
+
+// journalEntry is a record of a journal ConfigMap.
+type journalEntry struct {
+	APIVersion string   `json:"apiVersion"`
+	Kind       string   `json:"kind"`
+	Namespace  string   `json:"namespace,omitempty"`
+	Name       string   `json:"name"`
+	Hashes     []string `json:"hashes,omitempty"`
+	Generation int64    `json:"generation,omitempty"`
+	Phase      string   `json:"phase,omitempty"`
+	Prewarm    bool     `json:"prewarm,omitempty"`
+	Ready      bool     `json:"ready,omitempty"`
+}
+
+// journalRecord is a record read from a journal.
+type journalRecord struct {
+	journal string
+	entry   string
+	value   string
+	key     keeperKey
+	rec     *keeperRecord
+}
+
+// NewJournal creates the journal of replica in namespace. Journals are
+// written with c and read with reader, which should not be cached. Add it
+// to a manager to flush it, and pass it to the Keeper with WithJournal.
+func NewJournal(c client.Client, reader client.Reader, namespace, replica string, log logr.Logger) *Journal {
+	return &Journal{
+		client:        c,
+		reader:        reader,
+		namespace:     namespace,
+		name:          journalNamePrefix + replica,
+		log:           log.WithName("record-journal"),
+		flushInterval: journalFlushInterval,
+		unflushed:     make(map[keeperKey]*keeperRecord),
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Every
+// replica writes the records it receives.
+func (j *Journal) NeedLeaderElection() bool {
+	return false
+}
+
+// Start flushes the journal periodically until the context is cancelled,
+// and once more on shutdown.
+func (j *Journal) Start(ctx context.Context) error {
+	j.log.Info("starting record journal", "namespace", j.namespace, "name", j.name)
+	ticker := time.NewTicker(j.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), journalFlushTimeout)
+			defer cancel()
+			if err := j.flush(flushCtx); err != nil {
+				j.log.Error(err, "failed to flush record journal on shutdown", "records", j.Len())
+			}
+			return nil
+		case <-ticker.C:
+			if err := j.flush(ctx); err != nil {
+				j.log.V(1).Info("failed to flush record journal, retrying", "error", err.Error())
+			}
+		}
+	}
+}
+
+// Len returns the number of objects with unflushed records.
+func (j *Journal) Len() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return len(j.unflushed)
+}
+
+// add buffers rec for the next flush. Returns false if rec is dropped.
+func (j *Journal) add(key keeperKey, rec *keeperRecord) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if existing, ok := j.unflushed[key]; ok {
+		existing.combine(rec)
+		return true
+	}
+	if len(j.unflushed) >= journalMaxEntries {
+		return false
+	}
+	j.unflushed[key] = rec.clone()
+	return true
+}
+
+// flush merges the buffered records into the journal ConfigMap. Records
+// that could not be written stay buffered.
+func (j *Journal) flush(ctx context.Context) error {
+	j.mu.Lock()
+	records := j.unflushed
+	j.unflushed = make(map[keeperKey]*keeperRecord)
+	j.mu.Unlock()
+	if len(records) == 0 {
+		return nil
+	}
+
+	if err := j.write(ctx, records); err != nil {
+		for key, rec := range records {
+			if !j.add(key, rec) {
+				annotationWritesDropped.WithLabelValues(rec.kind(), dropJournalFull).Inc()
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// write merges records into the journal ConfigMap, creating it if missing.
+func (j *Journal) write(ctx context.Context, records map[keeperKey]*keeperRecord) error {
+	cm := &corev1.ConfigMap{}
+	err := j.reader.Get(ctx, client.ObjectKey{Namespace: j.namespace, Name: j.name}, cm)
+	create := apierrors.IsNotFound(err)
+	switch {
+	case create:
+		cm = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+			Namespace: j.namespace,
+			Name:      j.name,
+			Labels:    map[string]string{JournalLabel: "true"},
+		}}
+	case err != nil:
+		return fmt.Errorf("failed to get journal: %w", err)
+	}
+	if cm.Data == nil {
+		cm.Data = make(map[string]string)
+	}
+
+	for key, rec := range records {
+		entry := journalEntryKey(key)
+		if value, ok := cm.Data[entry]; ok {
+			if _, existing, err := decodeJournalEntry(value); err == nil {
+				existing.combine(rec)
+				rec = existing
+			}
+		} else if len(cm.Data) >= journalMaxEntries {
+			annotationWritesDropped.WithLabelValues(rec.kind(), dropJournalFull).Inc()
+			continue
+		}
+		value, err := encodeJournalEntry(key, rec)
+		if err != nil {
+			return err
+		}
+		cm.Data[entry] = value
+	}
+
+	if create {
+		return j.client.Create(ctx, cm)
+	}
+	// Updates carry the resourceVersion read, so the leader removing
+	// entries meanwhile causes a conflict instead of lost records
+	return j.client.Update(ctx, cm)
+}
+
+// read returns the records of all journals.
+func (j *Journal) read(ctx context.Context) ([]journalRecord, error) {
+	var list corev1.ConfigMapList
+	if err := j.reader.List(ctx, &list, client.InNamespace(j.namespace), client.HasLabels{JournalLabel}); err != nil {
+		return nil, fmt.Errorf("failed to list journals: %w", err)
+	}
+	var records []journalRecord
+	for _, cm := range list.Items {
+		for entry, value := range cm.Data {
+			key, rec, err := decodeJournalEntry(value)
+			if err != nil {
+				j.log.Error(err, "dropping invalid journal entry", "journal", cm.Name, "entry", entry)
+				rec = nil
+			}
+			records = append(records, journalRecord{journal: cm.Name, entry: entry, value: value, key: key, rec: rec})
+		}
+		if len(cm.Data) == 0 && cm.Name != j.name {
+			// Journal of a former replica
+			err := j.client.Delete(ctx, &cm, client.Preconditions{ResourceVersion: &cm.ResourceVersion})
+			if client.IgnoreNotFound(err) != nil && !apierrors.IsConflict(err) {
+				j.log.V(1).Info("failed to delete empty journal", "journal", cm.Name, "error", err.Error())
+			}
+		}
+	}
+	return records, nil
+}
+
+// remove deletes the entries of journal whose values did not change since
+// they were read. Entries merged with newer records meanwhile are kept.
+func (j *Journal) remove(ctx context.Context, journal string, entries map[string]string) error {
+	cm := &corev1.ConfigMap{}
+	if err := j.reader.Get(ctx, client.ObjectKey{Namespace: j.namespace, Name: journal}, cm); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	removed := false
+	for entry, value := range entries {
+		if cm.Data[entry] == value {
+			delete(cm.Data, entry)
+			removed = true
+		}
+	}
+	if !removed {
+		return nil
+	}
+	return client.IgnoreNotFound(j.client.Update(ctx, cm))
+}
+
+// journalEntryKey returns the ConfigMap key of the entry of key.
+func journalEntryKey(key keeperKey) string {
+	sum := sha256.Sum256([]byte(key.GVK.String() + "/" + key.Namespace + "/" + key.Name))
+	return hex.EncodeToString(sum[:12])
+}
+
+func encodeJournalEntry(key keeperKey, rec *keeperRecord) (string, error) {
+	apiVersion, kind := key.GVK.ToAPIVersionAndKind()
+	data, err := json.Marshal(journalEntry{
+		APIVersion: apiVersion,
+		Kind:       kind,
+		Namespace:  key.Namespace,
+		Name:       key.Name,
+		Hashes:     rec.hashes,
+		Generation: rec.generation,
+		Phase:      rec.phase,
+		Prewarm:    rec.prewarm,
+		Ready:      rec.ready,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal journal entry: %w", err)
+	}
+	return string(data), nil
+}
+
+func decodeJournalEntry(value string) (keeperKey, *keeperRecord, error) {
+	var entry journalEntry
+	if err := json.Unmarshal([]byte(value), &entry); err != nil {
+		return keeperKey{}, nil, fmt.Errorf("failed to unmarshal journal entry: %w", err)
+	}
+	key := keeperKey{GVK: schema.FromAPIVersionAndKind(entry.APIVersion, entry.Kind), Namespace: entry.Namespace, Name: entry.Name}
+	return key, &keeperRecord{hashes: entry.Hashes, generation: entry.Generation, phase: entry.Phase, prewarm: entry.Prewarm, ready: entry.Ready}, nil
+}
@@ -0,0 +1,163 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	ktesting "github.com/kausality-io/kausality/pkg/testing"
+)
+
+func TestJournalEntry(t *testing.T) {
+	key := keeperKey{GVK: schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, Namespace: "default", Name: "app"}
+	rec := &keeperRecord{hashes: []string{"abc12"}, generation: 3, phase: PhaseValueInitialized, prewarm: true, ready: true}
+
+	value, err := encodeJournalEntry(key, rec)
+	require.NoError(t, err)
+	gotKey, gotRec, err := decodeJournalEntry(value)
+	require.NoError(t, err)
+	assert.Equal(t, key, gotKey)
+	assert.Equal(t, rec, gotRec)
+	assert.Regexp(t, `^[0-9a-f]{24}$`, journalEntryKey(key))
+
+	_, _, err = decodeJournalEntry("{")
+	assert.Error(t, err)
+}
+
+func TestKeeperRecord_Covers(t *testing.T) {
+	applied := &keeperRecord{hashes: []string{"abc12", "def34"}, generation: 3, phase: PhaseValueInitialized}
+	assert.True(t, applied.covers(&keeperRecord{hashes: []string{"def34"}, generation: 2}))
+	assert.True(t, applied.covers(&keeperRecord{phase: PhaseValueInitializing}), "initialized covers initializing")
+	assert.False(t, applied.covers(&keeperRecord{hashes: []string{"ghi56"}}))
+	assert.False(t, applied.covers(&keeperRecord{generation: 4}))
+	prewarmed := &keeperRecord{hashes: []string{"abc12"}, prewarm: true}
+	assert.False(t, prewarmed.covers(&keeperRecord{hashes: []string{"abc12"}}), "prewarms do not cover records")
+}
+
+// journalReplica is a webhook replica with a journal and a keeper.
+type journalReplica struct {
+	journal *Journal
+	keeper  *Keeper
+	stop    context.CancelFunc
+	stopped chan struct{}
+}
+
+// startJournalReplica starts the journal of a replica. Its keeper is only
+// started when it is elected leader.
+func startJournalReplica(c client.Client, name string) *journalReplica {
+	j := NewJournal(c, c, "kausality-system", name, logr.Discard())
+	j.flushInterval = 10 * time.Millisecond
+	k := NewKeeper(c, logr.Discard(), WithJournal(j))
+	k.drainInterval = 20 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &journalReplica{journal: j, keeper: k, stop: cancel, stopped: make(chan struct{})}
+	go func() {
+		defer close(r.stopped)
+		_ = j.Start(ctx)
+	}()
+	return r
+}
+
+// elect runs the keeper of the replica as leader until it is stopped.
+func (r *journalReplica) elect(t *testing.T) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	stopJournal := r.stop
+	r.stop = func() {
+		cancel()
+		stopJournal()
+	}
+	startKeeper(t, ctx, r.keeper)
+}
+
+// shutdown stops the replica and waits for the last flush of its journal.
+func (r *journalReplica) shutdown() {
+	r.stop()
+	<-r.stopped
+}
+
+func TestKeeper_JournalReplicas(t *testing.T) {
+	const n = 30
+	var objs []client.Object
+	for i := 0; i < n; i++ {
+		objs = append(objs, &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: fmt.Sprintf("app-%d", i), Generation: 1}})
+	}
+	// Parent writes fail while failing is set, as if the API server was unavailable
+	var failing atomic.Bool
+	c := newKeeperTestClient(t, interceptor.Funcs{
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			if _, ok := obj.(*corev1.ConfigMap); !ok && failing.Load() {
+				return apierrors.NewServiceUnavailable("unavailable")
+			}
+			return c.Patch(ctx, obj, patch, opts...)
+		},
+	}, objs...)
+	record := func(r *journalReplica, i int) {
+		r.keeper.RecordController(context.Background(), objs[i], fmt.Sprintf("controller-%d", i))
+	}
+
+	a := startJournalReplica(c, "a")
+	b := startJournalReplica(c, "b")
+	defer b.shutdown()
+	a.elect(t)
+
+	// Records received by the follower reach the leader
+	for i := 0; i < 10; i++ {
+		record(b, i)
+	}
+	assert.Equal(t, 0, b.keeper.Len(), "followers do not queue records")
+
+	// The leader stops before writing its records and the follower's later
+	// ones, e.g. because it is restarted while the API server is unavailable
+	failing.Store(true)
+	for i := 10; i < 20; i++ {
+		record(a, i)
+	}
+	for i := 20; i < n; i++ {
+		record(b, i)
+	}
+	a.shutdown()
+	failing.Store(false)
+
+	// A new replica is elected and drains all journals
+	c2 := startJournalReplica(c, "c")
+	defer c2.shutdown()
+	c2.elect(t)
+
+	ktesting.Eventually(t, func() (bool, string) {
+		for i := 0; i < n; i++ {
+			annotations := getAnnotations(t, c, fmt.Sprintf("app-%d", i))
+			if annotations[ControllersAnnotation] != HashUsername(fmt.Sprintf("controller-%d", i)) {
+				return false, fmt.Sprintf("app-%d: annotations: %v", i, annotations)
+			}
+		}
+		return true, ""
+	}, 10*time.Second, 10*time.Millisecond)
+
+	// Applied records are removed from the journals
+	ktesting.Eventually(t, func() (bool, string) {
+		var list corev1.ConfigMapList
+		require.NoError(t, c.List(context.Background(), &list, client.InNamespace("kausality-system"), client.HasLabels{JournalLabel}))
+		for _, cm := range list.Items {
+			if len(cm.Data) > 0 {
+				return false, fmt.Sprintf("journal %s has %d entries", cm.Name, len(cm.Data))
+			}
+		}
+		return true, ""
+	}, 10*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,671 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/priorityqueue"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// Recorder records controller identity and lifecycle phase on parent objects.
+// Implemented by Tracker (in-process, immediate) and Keeper (queued, leader-elected).
+type Recorder interface {
+	// RecordController adds the user's hash to the controllers annotation and
+	// records the object's generation as observed.
+	RecordController(ctx context.Context, obj client.Object, username string)
+	// RecordPhase sets the phase annotation. Never downgrades from initialized.
+	RecordPhase(ctx context.Context, obj client.Object, phase string)
+}
+
+// Prewarmer records the controller of a parent before the controller first
+// writes the parent's status, from the child it creates. Until then, drift
+// of the parent's children can only be told from the children's updaters.
+// Implemented by Tracker and Keeper.
+type Prewarmer interface {
+	// PrewarmController adds the user's hash to the controllers annotation
+	// unless the object has controllers already. No generation is recorded
+	// as observed.
+	PrewarmController(ctx context.Context, obj client.Object, username string)
+}
+
+var (
+	_ Recorder  = &Tracker{}
+	_ Recorder  = &Keeper{}
+	_ Prewarmer = &Tracker{}
+	_ Prewarmer = &Keeper{}
+)
+
+const (
+	// keeperWorkers is the number of concurrent workers applying records.
+	keeperWorkers = 4
+	// keeperMaxRetries is the number of attempts before a record is dropped.
+	keeperMaxRetries = 10
+	// keeperMaxPending bounds the number of objects with pending records.
+	keeperMaxPending = 10000
+	// keeperThrottleDelay is the backoff after API server throttling without
+	// a Retry-After hint.
+	keeperThrottleDelay = time.Second
+)
+
+// Queue priorities of records. Controller identity and observed generation
+// decide drift, the phase only whether an object is still initializing.
+const (
+	priorityPhase = 0
+	priorityDrift = 10
+)
+
+// Reasons of dropped annotation writes.
+const (
+	dropQueueFull        = "queue_full"
+	dropEvicted          = "evicted"
+	dropRetriesExhausted = "retries_exhausted"
+)
+
+var (
+	// annotationWritesDropped counts records that were never written.
+	annotationWritesDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kausality_annotation_writes_dropped_total",
+		Help: "Controller identity and phase annotation writes dropped, by record and reason.",
+	}, []string{"record", "reason"})
+	// annotationWritesThrottled counts writes rejected by API server throttling.
+	annotationWritesThrottled = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "kausality_annotation_writes_throttled_total",
+		Help: "Annotation writes rejected by API server throttling and retried.",
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(annotationWritesDropped, annotationWritesThrottled)
+}
+
+// Keeper applies controller identity and phase annotations to parent objects.
+//
+// The webhook enqueues records on admission; workers apply them with merge
+// patches guarded by resourceVersion, re-reading the object and retrying with
+// exponential backoff on conflicts and errors. Records for the same object are
+// merged while queued. Keeper requires leader election so that only one
+// replica writes parent annotations. Records are only queued while leading.
+// Without a Journal, replicas that are not the leader drop them instead of
+// queueing them for workers that never run, and the queue is in memory, so
+// records pending on restart or failover are lost as well; both are
+// recovered when a later status update of the parent reaches the leader. With
+// a Journal, every replica persists its records, and the leader drains the
+// journals of all replicas.
+//
+// Under API server throttling, writes degrade instead of failing: workers
+// take controller identity records before phase records, wait for the write
+// limiter shared with other writers of the webhook, and back off as the API
+// server asks without using up their retries. When the queue is full, a
+// controller identity record evicts a pending phase-only record. Dropped
+// records are counted in kausality_annotation_writes_dropped_total.
+type Keeper struct {
+	client  client.Client
+	log     logr.Logger
+	queue   priorityqueue.PriorityQueue[keeperKey]
+	limiter flowcontrol.RateLimiter
+
+	maxPending    int
+	throttleDelay time.Duration
+
+	// delay and trigger defer records, see WithDeferredRecords
+	delay   time.Duration
+	trigger RecordTrigger
+
+	// leading is set while Start runs, i.e. while this replica is the leader.
+	leading atomic.Bool
+
+	journal       *Journal
+	drainInterval time.Duration
+
+	mu      sync.Mutex
+	pending map[keeperKey]*keeperRecord
+	// applied holds the records applied in the last drain cycles, whose
+	// journal entries are removed by the next drain
+	applied map[keeperKey]appliedRecord
+	cycle   int
+}
+
+// appliedRecord is a record applied in a drain cycle.
+type appliedRecord struct {
+	rec   *keeperRecord
+	cycle int
+}
+
+// keeperKey identifies an object across kinds.
+type keeperKey struct {
+	GVK       schema.GroupVersionKind
+	Namespace string
+	Name      string
+}
+
+// keeperRecord is the desired annotation state for an object.
+type keeperRecord struct {
+	hashes     []string
+	generation int64 // 0 if no controller was recorded
+	phase      string
+	// prewarm is set if the hashes only apply to objects without controllers
+	prewarm bool
+	// ready is set if the controller had reconciled the generation, see
+	// RecordTriggerObservedGeneration
+	ready bool
+}
+
+// priority returns the queue priority of the record.
+func (r *keeperRecord) priority() int {
+	if len(r.hashes) > 0 || r.generation > 0 {
+		return priorityDrift
+	}
+	return priorityPhase
+}
+
+// kind returns the record label of dropped write metrics.
+func (r *keeperRecord) kind() string {
+	if r.priority() == priorityDrift {
+		return "controller"
+	}
+	return "phase"
+}
+
+// clone returns a copy of the record.
+func (r *keeperRecord) clone() *keeperRecord {
+	c := *r
+	c.hashes = append([]string(nil), r.hashes...)
+	return &c
+}
+
+// combine merges rec into the record. Recorded controllers make prewarmed
+// ones unconditional, the generation only moves forward and the phase is
+// never downgraded from initialized.
+func (r *keeperRecord) combine(rec *keeperRecord) {
+	switch {
+	case len(r.hashes) == 0:
+		r.prewarm = rec.prewarm
+	case len(rec.hashes) > 0:
+		r.prewarm = r.prewarm && rec.prewarm
+	}
+	for _, h := range rec.hashes {
+		if !ContainsHash(r.hashes, h) {
+			r.hashes = append(r.hashes, h)
+		}
+	}
+	switch {
+	case rec.generation > r.generation:
+		r.ready = rec.ready
+	case rec.generation == r.generation:
+		r.ready = r.ready || rec.ready
+	}
+	r.generation = max(r.generation, rec.generation)
+	if rec.phase != "" && r.phase != PhaseValueInitialized {
+		r.phase = rec.phase
+	}
+}
+
+// covers returns whether applying the record also applied rec.
+func (r *keeperRecord) covers(rec *keeperRecord) bool {
+	for _, h := range rec.hashes {
+		if !ContainsHash(r.hashes, h) {
+			return false
+		}
+	}
+	if len(rec.hashes) > 0 && r.prewarm && !rec.prewarm {
+		return false
+	}
+	return rec.generation <= r.generation &&
+		(rec.phase == "" || rec.phase == r.phase || r.phase == PhaseValueInitialized)
+}
+
+// KeeperOption configures a Keeper.
+type KeeperOption func(*Keeper)
+
+// WithWriteLimiter limits the rate of annotation writes on the client side.
+// Pass the same limiter to all bookkeeping writers of a webhook to share the
+// budget between them.
+func WithWriteLimiter(l flowcontrol.RateLimiter) KeeperOption {
+	return func(k *Keeper) {
+		k.limiter = l
+	}
+}
+
+// WithJournal persists records in j, so that the records of replicas that
+// are not leading, and those pending on restart, reach the leader.
+func WithJournal(j *Journal) KeeperOption {
+	return func(k *Keeper) {
+		k.journal = j
+	}
+}
+
+// WithDeferredRecords defers records like WithRecordDelay and
+// WithRecordTrigger do for the Tracker: controller records are applied
+// after delay, or with RecordTriggerObservedGeneration once a status update
+// reports the generation observed, waiting at most delay, DefaultRecordTimeout
+// if zero. Phase records follow the delay with RecordTriggerDelay only.
+func WithDeferredRecords(trigger RecordTrigger, delay time.Duration) KeeperOption {
+	return func(k *Keeper) {
+		k.trigger = trigger
+		k.delay = delay
+	}
+}
+
+// NewKeeper creates a Keeper. Add it to a manager to start its workers.
+func NewKeeper(c client.Client, log logr.Logger, opts ...KeeperOption) *Keeper {
+	log = log.WithName("annotation-keeper")
+	k := &Keeper{
+		client: c,
+		log:    log,
+		queue: priorityqueue.New("annotation-keeper", func(o *priorityqueue.Opts[keeperKey]) {
+			o.Log = log
+		}),
+		maxPending:    keeperMaxPending,
+		throttleDelay: keeperThrottleDelay,
+		drainInterval: journalDrainInterval,
+		trigger:       RecordTriggerDelay,
+		pending:       make(map[keeperKey]*keeperRecord),
+		applied:       make(map[keeperKey]appliedRecord),
+	}
+	for _, opt := range opts {
+		opt(k)
+	}
+	if k.trigger == RecordTriggerObservedGeneration && k.delay == 0 {
+		k.delay = DefaultRecordTimeout
+	}
+	return k
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable.
+func (k *Keeper) NeedLeaderElection() bool {
+	return true
+}
+
+// Start runs the workers until the context is cancelled.
+func (k *Keeper) Start(ctx context.Context) error {
+	k.log.Info("starting annotation keeper", "workers", keeperWorkers)
+	k.leading.Store(true)
+	defer k.leading.Store(false)
+
+	var wg sync.WaitGroup
+	for i := 0; i < keeperWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k.processNext(ctx) {
+			}
+		}()
+	}
+	if k.journal != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			k.drainJournals(ctx)
+		}()
+	}
+
+	<-ctx.Done()
+	k.queue.ShutDown()
+	wg.Wait()
+	return nil
+}
+
+// RecordController enqueues adding the user's hash to the controllers annotation.
+func (k *Keeper) RecordController(ctx context.Context, obj client.Object, username string) {
+	hash := HashUsername(username)
+	generation := obj.GetGeneration()
+
+	annotations := obj.GetAnnotations()
+	if ContainsHash(ParseHashes(annotations[ControllersAnnotation]), hash) &&
+		annotations[ObservedGenerationAnnotation] == strconv.FormatInt(generation, 10) {
+		return // Already recorded with current generation
+	}
+
+	k.enqueue(obj, &keeperRecord{hashes: []string{hash}, generation: generation, ready: caughtUp(obj)})
+}
+
+// PrewarmController enqueues adding the user's hash to the controllers
+// annotation, if the object has no controllers when it is applied.
+func (k *Keeper) PrewarmController(ctx context.Context, obj client.Object, username string) {
+	if obj.GetAnnotations()[ControllersAnnotation] != "" {
+		return
+	}
+	k.enqueue(obj, &keeperRecord{hashes: []string{HashUsername(username)}, prewarm: true})
+}
+
+// RecordPhase enqueues setting the phase annotation.
+func (k *Keeper) RecordPhase(ctx context.Context, obj client.Object, phase string) {
+	if obj.GetDeletionTimestamp() != nil {
+		return
+	}
+	current := obj.GetAnnotations()[PhaseAnnotation]
+	if current == PhaseValueInitialized || current == phase {
+		return
+	}
+
+	k.enqueue(obj, &keeperRecord{phase: phase})
+}
+
+// Len returns the number of objects with pending records.
+func (k *Keeper) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return len(k.pending)
+}
+
+func (k *Keeper) enqueue(obj client.Object, rec *keeperRecord) {
+	if !k.leading.Load() && k.journal == nil {
+		k.log.V(2).Info("not leading, dropping record", "namespace", obj.GetNamespace(), "name", obj.GetName())
+		return
+	}
+	gvk, err := k.client.GroupVersionKindFor(obj)
+	if err != nil {
+		k.log.Error(err, "failed to determine kind", "namespace", obj.GetNamespace(), "name", obj.GetName())
+		return
+	}
+	key := keeperKey{GVK: gvk, Namespace: obj.GetNamespace(), Name: obj.GetName()}
+
+	if k.journal != nil && !k.journal.add(key, rec) {
+		annotationWritesDropped.WithLabelValues(rec.kind(), dropJournalFull).Inc()
+	}
+	if !k.leading.Load() {
+		return
+	}
+
+	if !k.merge(key, rec) {
+		k.log.V(1).Info("dropping record, too many pending objects", "kind", gvk.Kind, "namespace", key.Namespace, "name", key.Name)
+		return
+	}
+	k.queue.AddWithOpts(priorityqueue.AddOpts{After: k.deferral(rec), Priority: ptr.To(rec.priority())}, key)
+}
+
+// deferral returns how long rec waits in the queue. Records of an object
+// merge, and the earliest of them decides when they are applied.
+func (k *Keeper) deferral(rec *keeperRecord) time.Duration {
+	switch {
+	case rec.priority() == priorityPhase && k.trigger != RecordTriggerDelay:
+		return 0
+	case rec.ready && k.trigger == RecordTriggerObservedGeneration:
+		return 0
+	}
+	return k.delay
+}
+
+// merge combines rec into the pending record for key. If the key is new and
+// the pending limit is reached, a controller identity record evicts a pending
+// phase-only record. Returns false if rec is dropped.
+func (k *Keeper) merge(key keeperKey, rec *keeperRecord) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	existing, ok := k.pending[key]
+	if !ok {
+		if len(k.pending) >= k.maxPending && !k.evictPhaseLocked(rec) {
+			annotationWritesDropped.WithLabelValues(rec.kind(), dropQueueFull).Inc()
+			return false
+		}
+		k.pending[key] = rec
+		return true
+	}
+	existing.combine(rec)
+	return true
+}
+
+// evictPhaseLocked drops a pending phase-only record to make room for rec,
+// if rec is a controller identity record. The evicted key stays queued and is
+// skipped by the workers. Returns true if a record was evicted.
+func (k *Keeper) evictPhaseLocked(rec *keeperRecord) bool {
+	if rec.priority() != priorityDrift {
+		return false
+	}
+	for key, pending := range k.pending {
+		if pending.priority() == priorityPhase {
+			delete(k.pending, key)
+			annotationWritesDropped.WithLabelValues(pending.kind(), dropEvicted).Inc()
+			return true
+		}
+	}
+	return false
+}
+
+func (k *Keeper) processNext(ctx context.Context) bool {
+	key, shutdown := k.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer k.queue.Done(key)
+
+	k.mu.Lock()
+	rec := k.pending[key]
+	delete(k.pending, key)
+	k.mu.Unlock()
+	if rec == nil {
+		k.queue.Forget(key)
+		return true
+	}
+
+	log := k.log.WithValues("kind", key.GVK.Kind, "namespace", key.Namespace, "name", key.Name)
+	if k.limiter != nil {
+		if err := k.limiter.Wait(ctx); err != nil {
+			// Shutting down, the record is lost like all queued ones
+			return true
+		}
+	}
+
+	err := k.apply(ctx, key, rec)
+	priority := ptr.To(rec.priority())
+	if delay, throttled := k.throttled(err); throttled {
+		// Back off as asked without using up retries, throttling is not a
+		// failure of the record
+		annotationWritesThrottled.Inc()
+		log.V(1).Info("throttled recording annotations, retrying", "after", delay)
+		if k.merge(key, rec) {
+			k.queue.AddWithOpts(priorityqueue.AddOpts{After: delay, Priority: priority}, key)
+		}
+		return true
+	}
+	switch {
+	case err == nil:
+		k.queue.Forget(key)
+		k.markApplied(key, rec)
+	case k.queue.NumRequeues(key) >= keeperMaxRetries:
+		log.Error(err, "giving up recording annotations", "attempts", keeperMaxRetries)
+		annotationWritesDropped.WithLabelValues(rec.kind(), dropRetriesExhausted).Inc()
+		k.queue.Forget(key)
+		k.markApplied(key, rec)
+	default:
+		if apierrors.IsConflict(err) {
+			log.V(1).Info("conflict recording annotations, retrying")
+		} else {
+			log.Error(err, "failed to record annotations, retrying")
+		}
+		if k.merge(key, rec) {
+			k.queue.AddWithOpts(priorityqueue.AddOpts{RateLimited: true, Priority: priority}, key)
+		}
+	}
+	return true
+}
+
+// markApplied remembers that rec was applied, or given up, so that the
+// next drains remove its journal entries.
+func (k *Keeper) markApplied(key keeperKey, rec *keeperRecord) {
+	if k.journal == nil {
+		return
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if existing, ok := k.applied[key]; ok {
+		existing.rec.combine(rec)
+		k.applied[key] = appliedRecord{rec: existing.rec, cycle: k.cycle}
+		return
+	}
+	if len(k.applied) < k.maxPending {
+		k.applied[key] = appliedRecord{rec: rec.clone(), cycle: k.cycle}
+	}
+}
+
+// drainJournals drains the journals of all replicas until the context is
+// cancelled.
+func (k *Keeper) drainJournals(ctx context.Context) {
+	ticker := time.NewTicker(k.drainInterval)
+	defer ticker.Stop()
+	for {
+		if err := k.drain(ctx); err != nil && ctx.Err() == nil {
+			k.log.Error(err, "failed to drain record journals")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain queues the journal records that are neither applied nor pending,
+// and removes the applied ones from the journals. Records applied before
+// the last two drains are forgotten; replicas flush more often than that.
+func (k *Keeper) drain(ctx context.Context) error {
+	records, err := k.journal.read(ctx)
+	if err != nil {
+		return err
+	}
+
+	var queue []journalRecord
+	remove := make(map[string]map[string]string)
+	k.mu.Lock()
+	for _, r := range records {
+		if r.rec != nil {
+			if applied, ok := k.applied[r.key]; !ok || !applied.rec.covers(r.rec) {
+				if pending, ok := k.pending[r.key]; !ok || !pending.covers(r.rec) {
+					queue = append(queue, r)
+				}
+				continue
+			}
+		}
+		if remove[r.journal] == nil {
+			remove[r.journal] = make(map[string]string)
+		}
+		remove[r.journal][r.entry] = r.value
+	}
+	k.cycle++
+	for key, applied := range k.applied {
+		if k.cycle-applied.cycle > 2 {
+			delete(k.applied, key)
+		}
+	}
+	k.mu.Unlock()
+
+	for _, r := range queue {
+		if k.merge(r.key, r.rec) {
+			k.queue.AddWithOpts(priorityqueue.AddOpts{After: k.deferral(r.rec), Priority: ptr.To(r.rec.priority())}, r.key)
+		}
+	}
+	for journal, entries := range remove {
+		if err := k.journal.remove(ctx, journal, entries); err != nil && !apierrors.IsConflict(err) {
+			return fmt.Errorf("failed to remove applied records from journal %s: %w", journal, err)
+		}
+	}
+	if len(queue) > 0 {
+		k.log.V(1).Info("drained record journals", "records", len(queue))
+	}
+	return nil
+}
+
+// throttled returns the backoff if err reports that the API server throttles
+// requests, preferring the server's Retry-After hint.
+func (k *Keeper) throttled(err error) (time.Duration, bool) {
+	if err == nil || (!apierrors.IsTooManyRequests(err) && !apierrors.IsServerTimeout(err)) {
+		return 0, false
+	}
+	if seconds, ok := apierrors.SuggestsClientDelay(err); ok && seconds > 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	return k.throttleDelay, true
+}
+
+// apply patches the object's annotations. The patch carries the object's
+// resourceVersion, so concurrent writers cause a conflict instead of lost updates.
+func (k *Keeper) apply(ctx context.Context, key keeperKey, rec *keeperRecord) error {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(key.GVK)
+	if err := k.client.Get(ctx, client.ObjectKey{Namespace: key.Namespace, Name: key.Name}, obj); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	base := obj.DeepCopy()
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	if !applyRecord(annotations, rec) {
+		return nil
+	}
+	obj.SetAnnotations(annotations)
+
+	if err := k.client.Patch(ctx, obj, client.MergeFromWithOptions(base, client.MergeFromWithOptimisticLock{})); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	k.log.V(1).Info("recorded annotations", "kind", key.GVK.Kind, "namespace", key.Namespace, "name", key.Name,
+		"hashes", rec.hashes, "observedGeneration", rec.generation, "phase", rec.phase)
+	return nil
+}
+
+// applyRecord updates annotations with the record. Hashes are added, unless
+// prewarmed for objects that have controllers meanwhile, the observed
+// generation only moves forward, and the phase is never downgraded from
+// initialized. Returns true if annotations changed.
+func applyRecord(annotations map[string]string, rec *keeperRecord) bool {
+	changed := false
+
+	if len(rec.hashes) > 0 && (!rec.prewarm || annotations[ControllersAnnotation] == "") {
+		hashes := ParseHashes(annotations[ControllersAnnotation])
+		added := false
+		for _, h := range rec.hashes {
+			if !ContainsHash(hashes, h) {
+				hashes = append(hashes, h)
+				added = true
+			}
+		}
+		if added {
+			if len(hashes) > MaxHashes {
+				hashes = hashes[len(hashes)-MaxHashes:]
+			}
+			annotations[ControllersAnnotation] = strings.Join(hashes, ",")
+			changed = true
+		}
+	}
+
+	if rec.generation > 0 {
+		recorded, err := strconv.ParseInt(annotations[ObservedGenerationAnnotation], 10, 64)
+		if err != nil || recorded < rec.generation {
+			annotations[ObservedGenerationAnnotation] = strconv.FormatInt(rec.generation, 10)
+			changed = true
+		}
+	}
+
+	if rec.phase != "" {
+		current := annotations[PhaseAnnotation]
+		if current != PhaseValueInitialized && current != rec.phase {
+			annotations[PhaseAnnotation] = rec.phase
+			changed = true
+		}
+	}
+
+	return changed
+}
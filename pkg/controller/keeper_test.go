@@ -0,0 +1,364 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/util/flowcontrol"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	ktesting "github.com/kausality-io/kausality/pkg/testing"
+)
+
+func TestApplyRecord(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		rec         keeperRecord
+		want        map[string]string
+		wantChanged bool
+	}{
+		{
+			name:        "adds hash and generation",
+			annotations: map[string]string{},
+			rec:         keeperRecord{hashes: []string{"abc12"}, generation: 3},
+			want:        map[string]string{ControllersAnnotation: "abc12", ObservedGenerationAnnotation: "3"},
+			wantChanged: true,
+		},
+		{
+			name:        "already recorded",
+			annotations: map[string]string{ControllersAnnotation: "abc12", ObservedGenerationAnnotation: "3"},
+			rec:         keeperRecord{hashes: []string{"abc12"}, generation: 3},
+			want:        map[string]string{ControllersAnnotation: "abc12", ObservedGenerationAnnotation: "3"},
+		},
+		{
+			name:        "generation never moves backwards",
+			annotations: map[string]string{ControllersAnnotation: "abc12", ObservedGenerationAnnotation: "5"},
+			rec:         keeperRecord{hashes: []string{"def34"}, generation: 3},
+			want:        map[string]string{ControllersAnnotation: "abc12,def34", ObservedGenerationAnnotation: "5"},
+			wantChanged: true,
+		},
+		{
+			name:        "sets phase",
+			annotations: map[string]string{},
+			rec:         keeperRecord{phase: PhaseValueInitializing},
+			want:        map[string]string{PhaseAnnotation: PhaseValueInitializing},
+			wantChanged: true,
+		},
+		{
+			name:        "never downgrades phase",
+			annotations: map[string]string{PhaseAnnotation: PhaseValueInitialized},
+			rec:         keeperRecord{phase: PhaseValueInitializing},
+			want:        map[string]string{PhaseAnnotation: PhaseValueInitialized},
+		},
+		{
+			name:        "prewarms controllers",
+			annotations: map[string]string{},
+			rec:         keeperRecord{hashes: []string{"abc12"}, prewarm: true},
+			want:        map[string]string{ControllersAnnotation: "abc12"},
+			wantChanged: true,
+		},
+		{
+			name:        "prewarm skips recorded controllers",
+			annotations: map[string]string{ControllersAnnotation: "def34"},
+			rec:         keeperRecord{hashes: []string{"abc12"}, prewarm: true},
+			want:        map[string]string{ControllersAnnotation: "def34"},
+		},
+		{
+			name:        "limits hashes",
+			annotations: map[string]string{ControllersAnnotation: "h0001,h0002,h0003,h0004,h0005"},
+			rec:         keeperRecord{hashes: []string{"h0006"}},
+			want:        map[string]string{ControllersAnnotation: "h0002,h0003,h0004,h0005,h0006"},
+			wantChanged: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed := applyRecord(tt.annotations, &tt.rec)
+			assert.Equal(t, tt.wantChanged, changed)
+			if diff := cmp.Diff(tt.want, tt.annotations); diff != "" {
+				t.Errorf("annotations mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestKeeper_Merge(t *testing.T) {
+	k := NewKeeper(nil, logr.Discard())
+	key := keeperKey{GVK: schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, Namespace: "default", Name: "app"}
+
+	require.True(t, k.merge(key, &keeperRecord{hashes: []string{"abc12"}, generation: 2}))
+	require.True(t, k.merge(key, &keeperRecord{hashes: []string{"def34", "abc12"}, generation: 1}))
+	require.True(t, k.merge(key, &keeperRecord{phase: PhaseValueInitialized}))
+	require.True(t, k.merge(key, &keeperRecord{phase: PhaseValueInitializing}))
+
+	assert.Equal(t, 1, k.Len())
+	rec := k.pending[key]
+	assert.Equal(t, []string{"abc12", "def34"}, rec.hashes)
+	assert.Equal(t, int64(2), rec.generation)
+	assert.Equal(t, PhaseValueInitialized, rec.phase, "initialized is not downgraded")
+}
+
+func TestKeeper_MergePrewarm(t *testing.T) {
+	k := NewKeeper(nil, logr.Discard())
+	key := keeperKey{GVK: schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, Namespace: "default", Name: "app"}
+
+	require.True(t, k.merge(key, &keeperRecord{phase: PhaseValueInitializing}))
+	require.True(t, k.merge(key, &keeperRecord{hashes: []string{"abc12"}, prewarm: true}))
+	assert.True(t, k.pending[key].prewarm, "phase records keep prewarmed hashes conditional")
+
+	require.True(t, k.merge(key, &keeperRecord{hashes: []string{"def34"}, generation: 1}))
+	assert.False(t, k.pending[key].prewarm, "recorded controllers make prewarmed hashes unconditional")
+	assert.Equal(t, []string{"abc12", "def34"}, k.pending[key].hashes)
+}
+
+func TestKeeper_EvictsPhaseRecords(t *testing.T) {
+	k := NewKeeper(nil, logr.Discard())
+	k.maxPending = 2
+	key := func(name string) keeperKey {
+		return keeperKey{GVK: schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, Namespace: "default", Name: name}
+	}
+	evicted := testutil.ToFloat64(annotationWritesDropped.WithLabelValues("phase", dropEvicted))
+	full := testutil.ToFloat64(annotationWritesDropped.WithLabelValues("phase", dropQueueFull))
+
+	require.True(t, k.merge(key("a"), &keeperRecord{phase: PhaseValueInitialized}))
+	require.True(t, k.merge(key("b"), &keeperRecord{hashes: []string{"abc12"}, generation: 1}))
+	assert.False(t, k.merge(key("c"), &keeperRecord{phase: PhaseValueInitialized}), "phase records do not evict")
+	require.True(t, k.merge(key("d"), &keeperRecord{hashes: []string{"def34"}, generation: 1}), "controller record evicts phase record")
+	assert.False(t, k.merge(key("e"), &keeperRecord{hashes: []string{"ghi56"}, generation: 1}), "controller records are not evicted")
+
+	assert.Equal(t, 2, k.Len())
+	assert.NotContains(t, k.pending, key("a"))
+	assert.Equal(t, evicted+1, testutil.ToFloat64(annotationWritesDropped.WithLabelValues("phase", dropEvicted)))
+	assert.Equal(t, full+1, testutil.ToFloat64(annotationWritesDropped.WithLabelValues("phase", dropQueueFull)))
+}
+
+func newKeeperTestClient(t *testing.T, funcs interceptor.Funcs, objs ...client.Object) client.WithWatch {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).WithInterceptorFuncs(funcs).Build()
+}
+
+func getAnnotations(t *testing.T, c client.Client, name string) map[string]string {
+	var deploy appsv1.Deployment
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: name}, &deploy))
+	return deploy.Annotations
+}
+
+// startKeeper runs k as if it had been elected leader, until ctx is done.
+func startKeeper(t *testing.T, ctx context.Context, k *Keeper) {
+	t.Helper()
+	go func() { _ = k.Start(ctx) }()
+	ktesting.Eventually(t, func() (bool, string) {
+		return k.leading.Load(), "keeper not started"
+	}, 5*time.Second, time.Millisecond)
+}
+
+func TestKeeper_RecordsAnnotations(t *testing.T) {
+	deploy := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "app", Generation: 4}}
+	c := newKeeperTestClient(t, interceptor.Funcs{}, deploy)
+	k := NewKeeper(c, logr.Discard())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	startKeeper(t, ctx, k)
+
+	k.RecordController(ctx, deploy, "deployment-controller")
+	k.RecordPhase(ctx, deploy, PhaseValueInitialized)
+
+	hash := HashUsername("deployment-controller")
+	ktesting.Eventually(t, func() (bool, string) {
+		annotations := getAnnotations(t, c, "app")
+		if annotations[ControllersAnnotation] != hash || annotations[ObservedGenerationAnnotation] != "4" || annotations[PhaseAnnotation] != PhaseValueInitialized {
+			return false, fmt.Sprintf("annotations: %v", annotations)
+		}
+		return true, ""
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestKeeper_DeferredRecords(t *testing.T) {
+	hash := HashUsername("deployment-controller")
+	recorded := func(c client.Client) (bool, string) {
+		annotations := getAnnotations(t, c, "app")
+		return annotations[ControllersAnnotation] == hash, fmt.Sprintf("annotations: %v", annotations)
+	}
+
+	t.Run("delay", func(t *testing.T) {
+		deploy := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "app", Generation: 1}}
+		c := newKeeperTestClient(t, interceptor.Funcs{}, deploy)
+		k := NewKeeper(c, logr.Discard(), WithDeferredRecords(RecordTriggerDelay, 300*time.Millisecond))
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		startKeeper(t, ctx, k)
+
+		start := time.Now()
+		k.RecordController(ctx, deploy, "deployment-controller")
+		ktesting.Eventually(t, func() (bool, string) { return recorded(c) }, 5*time.Second, 10*time.Millisecond)
+		assert.GreaterOrEqual(t, time.Since(start), 300*time.Millisecond)
+	})
+
+	t.Run("observed generation", func(t *testing.T) {
+		deploy := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "app", Generation: 2}}
+		c := newKeeperTestClient(t, interceptor.Funcs{}, deploy)
+		k := NewKeeper(c, logr.Discard(), WithDeferredRecords(RecordTriggerObservedGeneration, time.Minute))
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		startKeeper(t, ctx, k)
+
+		// The controller is still reconciling
+		deploy.Status.ObservedGeneration = 1
+		k.RecordController(ctx, deploy, "deployment-controller")
+		time.Sleep(200 * time.Millisecond)
+		ok, msg := recorded(c)
+		assert.False(t, ok, msg)
+
+		// The controller caught up
+		deploy.Status.ObservedGeneration = 2
+		k.RecordController(ctx, deploy, "deployment-controller")
+		ktesting.Eventually(t, func() (bool, string) { return recorded(c) }, 5*time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestKeeper_RetriesOnConflict(t *testing.T) {
+	deploy := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "app", Generation: 1}}
+
+	// Fail the first two patches with a conflict, as if another writer raced us
+	var patches atomic.Int32
+	c := newKeeperTestClient(t, interceptor.Funcs{
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			if patches.Add(1) <= 2 {
+				return apierrors.NewConflict(schema.GroupResource{Group: "apps", Resource: "deployments"}, obj.GetName(), fmt.Errorf("conflict"))
+			}
+			return c.Patch(ctx, obj, patch, opts...)
+		},
+	}, deploy)
+	k := NewKeeper(c, logr.Discard())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	startKeeper(t, ctx, k)
+
+	k.RecordController(ctx, deploy, "deployment-controller")
+
+	ktesting.Eventually(t, func() (bool, string) {
+		annotations := getAnnotations(t, c, "app")
+		if annotations[ControllersAnnotation] != HashUsername("deployment-controller") {
+			return false, fmt.Sprintf("annotations: %v", annotations)
+		}
+		return true, ""
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.GreaterOrEqual(t, patches.Load(), int32(3))
+}
+
+func TestKeeper_RetriesWhenThrottled(t *testing.T) {
+	deploy := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "app", Generation: 1}}
+
+	// Throttle more patches than retries are allowed
+	var patches atomic.Int32
+	c := newKeeperTestClient(t, interceptor.Funcs{
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			if patches.Add(1) <= keeperMaxRetries+2 {
+				return apierrors.NewTooManyRequests("throttled", 0)
+			}
+			return c.Patch(ctx, obj, patch, opts...)
+		},
+	}, deploy)
+	k := NewKeeper(c, logr.Discard(), WithWriteLimiter(flowcontrol.NewTokenBucketRateLimiter(1000, 10)))
+	k.throttleDelay = time.Millisecond
+	throttled := testutil.ToFloat64(annotationWritesThrottled)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	startKeeper(t, ctx, k)
+
+	k.RecordController(ctx, deploy, "deployment-controller")
+
+	ktesting.Eventually(t, func() (bool, string) {
+		annotations := getAnnotations(t, c, "app")
+		if annotations[ControllersAnnotation] != HashUsername("deployment-controller") {
+			return false, fmt.Sprintf("annotations: %v", annotations)
+		}
+		return true, ""
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, throttled+keeperMaxRetries+2, testutil.ToFloat64(annotationWritesThrottled))
+}
+
+func TestKeeper_SkipsRecorded(t *testing.T) {
+	hash := HashUsername("deployment-controller")
+	deploy := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "default", Name: "app", Generation: 2,
+		Annotations: map[string]string{
+			ControllersAnnotation:        hash,
+			ObservedGenerationAnnotation: "2",
+			PhaseAnnotation:              PhaseValueInitialized,
+		},
+	}}
+	k := NewKeeper(newKeeperTestClient(t, interceptor.Funcs{}, deploy), logr.Discard())
+	k.leading.Store(true)
+
+	k.RecordController(context.Background(), deploy, "deployment-controller")
+	k.RecordPhase(context.Background(), deploy, PhaseValueInitializing)
+
+	assert.Equal(t, 0, k.Len())
+}
+
+func TestKeeper_TwoReplicas(t *testing.T) {
+	deploy := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "app", Generation: 1}}
+	c := newKeeperTestClient(t, interceptor.Funcs{}, deploy)
+	leader := NewKeeper(c, logr.Discard())
+	follower := NewKeeper(c, logr.Discard())
+
+	leaderCtx, stopLeader := context.WithCancel(context.Background())
+	defer stopLeader()
+	startKeeper(t, leaderCtx, leader)
+
+	// The follower's workers never run, so it must not queue records
+	for i := 0; i < keeperMaxPending+1; i++ {
+		follower.RecordController(context.Background(), deploy, fmt.Sprintf("controller-%d", i))
+	}
+	assert.Equal(t, 0, follower.Len())
+	assert.Empty(t, getAnnotations(t, c, "app")[ControllersAnnotation])
+
+	// A later status update reaching the leader is recorded
+	leader.RecordController(context.Background(), deploy, "deployment-controller")
+	ktesting.Eventually(t, func() (bool, string) {
+		annotations := getAnnotations(t, c, "app")
+		return annotations[ControllersAnnotation] == HashUsername("deployment-controller"), fmt.Sprintf("annotations: %v", annotations)
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// On failover, the former leader stops queueing and the new leader records
+	stopLeader()
+	ktesting.Eventually(t, func() (bool, string) {
+		return !leader.leading.Load(), "leader still leading"
+	}, 5*time.Second, time.Millisecond)
+	followerCtx, stopFollower := context.WithCancel(context.Background())
+	defer stopFollower()
+	startKeeper(t, followerCtx, follower)
+
+	leader.RecordController(context.Background(), deploy, "other-controller")
+	assert.Equal(t, 0, leader.Len())
+	follower.RecordController(context.Background(), deploy, "replicaset-controller")
+	ktesting.Eventually(t, func() (bool, string) {
+		annotations := getAnnotations(t, c, "app")
+		want := HashUsername("deployment-controller") + "," + HashUsername("replicaset-controller")
+		return annotations[ControllersAnnotation] == want, fmt.Sprintf("annotations: %v", annotations)
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -12,9 +12,13 @@ import (
 	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
 
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	"github.com/kausality-io/kausality/api/v1alpha1"
 )
@@ -27,17 +31,69 @@ const (
 	MaxHashes                    = v1alpha1.MaxHashes
 )
 
+// FieldManager is the field manager of kausality's own writes, e.g. of
+// annotations on parents. The webhook admits writes of its own components
+// with this field manager without evaluating them.
+const FieldManager = "kausality"
+
+// RecordTrigger decides when the Tracker writes a pending controller record.
+type RecordTrigger string
+
 const (
-	// asyncUpdateDelay is the delay before async annotation updates.
-	// Set to 0 for immediate recording - necessary because status subresource
-	// patches to metadata don't persist (Kubernetes only updates .status).
-	asyncUpdateDelay = 0
+	// RecordTriggerDelay writes the record after the record delay. With a
+	// zero delay, the default, the record is written before the admission
+	// request returns. This is necessary for status updates because status
+	// subresource patches to metadata don't persist (Kubernetes only updates
+	// .status), so the annotations must be written by a direct API call
+	// before the next admission request arrives.
+	RecordTriggerDelay RecordTrigger = "Delay"
+	// RecordTriggerObservedGeneration writes the record once a status update
+	// reports status.observedGeneration caught up with the generation, i.e.
+	// when the controller has finished reconciling. The record delay bounds
+	// the wait, defaulting to DefaultRecordTimeout; on timeout the record is
+	// written anyway.
+	RecordTriggerObservedGeneration RecordTrigger = "ObservedGeneration"
 )
 
+// DefaultRecordTimeout bounds the wait of RecordTriggerObservedGeneration
+// when no record delay is configured.
+const DefaultRecordTimeout = 30 * time.Second
+
+// pendingRecordings is the number of controller records waiting for their trigger.
+var pendingRecordings = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "kausality_controller_recordings_pending",
+	Help: "Controller identity records of the in-process tracker waiting to be written.",
+})
+
+func init() {
+	metrics.Registry.MustRegister(pendingRecordings)
+}
+
+// TrackerOption configures a Tracker.
+type TrackerOption func(*Tracker)
+
+// WithRecordDelay sets how long controller records wait before they are
+// written, or the maximum wait of RecordTriggerObservedGeneration.
+func WithRecordDelay(d time.Duration) TrackerOption {
+	return func(t *Tracker) {
+		t.delay = d
+	}
+}
+
+// WithRecordTrigger sets when controller records are written. The default
+// is RecordTriggerDelay.
+func WithRecordTrigger(trigger RecordTrigger) TrackerOption {
+	return func(t *Tracker) {
+		t.trigger = trigger
+	}
+}
+
 // Tracker tracks controller identity via user hash annotations.
 type Tracker struct {
-	client client.Client
-	log    logr.Logger
+	client  client.Client
+	log     logr.Logger
+	delay   time.Duration
+	trigger RecordTrigger
 
 	// pending tracks async updates to batch
 	pending    map[string]string // objectKey -> hash to add
@@ -46,13 +102,21 @@ type Tracker struct {
 }
 
 // NewTracker creates a new controller Tracker.
-func NewTracker(c client.Client, log logr.Logger) *Tracker {
-	return &Tracker{
+func NewTracker(c client.Client, log logr.Logger, opts ...TrackerOption) *Tracker {
+	t := &Tracker{
 		client:     c,
 		log:        log.WithName("controller-tracker"),
+		trigger:    RecordTriggerDelay,
 		pending:    make(map[string]string),
 		pendingGen: make(map[string]int64),
 	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	if t.trigger == RecordTriggerObservedGeneration && t.delay == 0 {
+		t.delay = DefaultRecordTimeout
+	}
+	return t
 }
 
 // UserIdentifier returns the user identifier to use for hashing.
@@ -103,6 +167,16 @@ func RecordUpdater(obj client.Object, username string) map[string]string {
 	return annotations
 }
 
+// RecordController implements Recorder by calling RecordControllerAsync.
+func (t *Tracker) RecordController(ctx context.Context, obj client.Object, username string) {
+	t.RecordControllerAsync(ctx, obj, username)
+}
+
+// RecordPhase implements Recorder by calling RecordPhaseAsync.
+func (t *Tracker) RecordPhase(ctx context.Context, obj client.Object, phase string) {
+	t.RecordPhaseAsync(ctx, obj, phase)
+}
+
 // RecordControllerAsync schedules an async update to add the user hash
 // to the parent's controllers annotation and record the observed generation.
 func (t *Tracker) RecordControllerAsync(ctx context.Context, obj client.Object, username string) {
@@ -126,19 +200,80 @@ func (t *Tracker) RecordControllerAsync(ctx context.Context, obj client.Object,
 	_, alreadyPending := t.pending[key]
 	t.pending[key] = hash
 	t.pendingGen[key] = generation
+	pendingRecordings.Set(float64(len(t.pendingGen)))
 	t.pendingMu.Unlock()
 
-	if !alreadyPending {
-		if asyncUpdateDelay == 0 {
-			// Synchronous recording - necessary because status subresource
-			// patches to metadata don't persist, so we must update via direct API call
-			// before the next admission request arrives.
-			t.flushAfterDelay(ctx, obj, 0)
-		} else {
-			// Schedule the update with delay
-			go t.flushAfterDelay(ctx, obj, asyncUpdateDelay)
+	switch {
+	case t.trigger == RecordTriggerObservedGeneration && caughtUp(obj):
+		// The controller has reconciled this generation, no need to wait
+		t.flushAfterDelay(ctx, obj, 0)
+	case alreadyPending:
+		// The scheduled flush writes the merged record
+	case t.delay == 0:
+		t.flushAfterDelay(ctx, obj, 0)
+	default:
+		go t.flushAfterDelay(context.WithoutCancel(ctx), obj, t.delay)
+	}
+}
+
+// PrewarmController adds the user's hash to the controllers annotation, if
+// the object has no controllers when it is written. Like controller records,
+// it is written after the record delay.
+func (t *Tracker) PrewarmController(ctx context.Context, obj client.Object, username string) {
+	if obj.GetAnnotations()[ControllersAnnotation] != "" {
+		return
+	}
+	hash := HashUsername(username)
+	if t.delay == 0 {
+		t.prewarmAfterDelay(ctx, obj, hash, 0)
+		return
+	}
+	go t.prewarmAfterDelay(context.WithoutCancel(ctx), obj, hash, t.delay)
+}
+
+// prewarmAfterDelay waits and then adds the hash to the controllers
+// annotation of an object without controllers.
+func (t *Tracker) prewarmAfterDelay(ctx context.Context, obj client.Object, hash string, delay time.Duration) {
+	time.Sleep(delay)
+
+	current := obj.DeepCopyObject().(client.Object)
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		if err := t.client.Get(ctx, client.ObjectKeyFromObject(obj), current); err != nil {
+			return err
+		}
+		annotations := current.GetAnnotations()
+		if annotations[ControllersAnnotation] != "" {
+			return nil
+		}
+		if annotations == nil {
+			annotations = make(map[string]string)
 		}
+		annotations[ControllersAnnotation] = hash
+		current.SetAnnotations(annotations)
+		return t.client.Update(ctx, current)
+	})
+
+	log := t.log.WithValues("kind", objectTypeName(obj), "namespace", obj.GetNamespace(), "name", obj.GetName(), "hash", hash)
+	if err != nil {
+		log.Error(err, "failed to prewarm controllers annotation")
+	} else {
+		log.V(1).Info("prewarmed controller hash")
+	}
+}
+
+// caughtUp returns true if the status of obj reports its generation as
+// observed. Objects without status.observedGeneration never catch up.
+func caughtUp(obj client.Object) bool {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return false
+		}
+		u = &unstructured.Unstructured{Object: content}
 	}
+	observed, found, err := unstructured.NestedInt64(u.Object, "status", "observedGeneration")
+	return found && err == nil && observed >= obj.GetGeneration()
 }
 
 // flushAfterDelay waits and then updates the controller hash and observed generation annotations.
@@ -151,6 +286,7 @@ func (t *Tracker) flushAfterDelay(ctx context.Context, obj client.Object, delay
 	generation := t.pendingGen[key]
 	delete(t.pending, key)
 	delete(t.pendingGen, key)
+	pendingRecordings.Set(float64(len(t.pendingGen)))
 	t.pendingMu.Unlock()
 
 	if !ok {
@@ -287,8 +423,17 @@ func (t *Tracker) RecordPhaseAsync(ctx context.Context, obj client.Object, phase
 	t.pendingMu.Unlock()
 
 	if !alreadyPending {
-		go t.flushPhaseAfterDelay(ctx, obj, asyncUpdateDelay)
+		go t.flushPhaseAfterDelay(context.WithoutCancel(ctx), obj, t.phaseDelay())
+	}
+}
+
+// phaseDelay returns the delay of phase records. They follow the record
+// delay, but never wait for a trigger.
+func (t *Tracker) phaseDelay() time.Duration {
+	if t.trigger != RecordTriggerDelay {
+		return 0
 	}
+	return t.delay
 }
 
 // flushPhaseAfterDelay waits and then updates the phase annotation.
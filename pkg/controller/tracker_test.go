@@ -1,12 +1,22 @@
 package controller
 
 import (
+	"context"
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	ktesting "github.com/kausality-io/kausality/pkg/testing"
 )
 
 func TestUserIdentifier(t *testing.T) {
@@ -219,3 +229,66 @@ func TestIntersect(t *testing.T) {
 func TestMaxHashes(t *testing.T) {
 	require.Equal(t, 5, MaxHashes, "MaxHashes should be 5")
 }
+
+func TestTracker_RecordTrigger(t *testing.T) {
+	hash := HashUsername("deployment-controller")
+	ctx := context.Background()
+
+	t.Run("immediate by default", func(t *testing.T) {
+		deploy := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "app", Generation: 2}}
+		c := newKeeperTestClient(t, interceptor.Funcs{}, deploy)
+		NewTracker(c, logr.Discard()).RecordController(ctx, deploy, "deployment-controller")
+		assert.Equal(t, hash, getAnnotations(t, c, "app")[ControllersAnnotation])
+	})
+
+	t.Run("delay", func(t *testing.T) {
+		deploy := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "app", Generation: 2}}
+		c := newKeeperTestClient(t, interceptor.Funcs{}, deploy)
+		NewTracker(c, logr.Discard(), WithRecordDelay(50*time.Millisecond)).RecordController(ctx, deploy, "deployment-controller")
+		assert.Empty(t, getAnnotations(t, c, "app")[ControllersAnnotation], "written before the delay")
+		ktesting.Eventually(t, func() (bool, string) {
+			annotations := getAnnotations(t, c, "app")
+			return annotations[ControllersAnnotation] == hash, fmt.Sprintf("annotations: %v", annotations)
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("observed generation", func(t *testing.T) {
+		deploy := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "app", Generation: 2},
+			Status:     appsv1.DeploymentStatus{ObservedGeneration: 1},
+		}
+		c := newKeeperTestClient(t, interceptor.Funcs{}, deploy)
+		tracker := NewTracker(c, logr.Discard(), WithRecordTrigger(RecordTriggerObservedGeneration))
+		pending := testutil.ToFloat64(pendingRecordings)
+
+		// Still reconciling, the record waits
+		tracker.RecordController(ctx, deploy, "deployment-controller")
+		assert.Empty(t, getAnnotations(t, c, "app")[ControllersAnnotation])
+		assert.Equal(t, pending+1, testutil.ToFloat64(pendingRecordings))
+
+		// The status update reporting the generation as observed writes it
+		caughtUp := deploy.DeepCopy()
+		caughtUp.Status.ObservedGeneration = 2
+		tracker.RecordController(ctx, caughtUp, "deployment-controller")
+		annotations := getAnnotations(t, c, "app")
+		assert.Equal(t, hash, annotations[ControllersAnnotation])
+		assert.Equal(t, "2", annotations[ObservedGenerationAnnotation])
+		assert.Equal(t, pending, testutil.ToFloat64(pendingRecordings))
+	})
+}
+
+func TestTracker_PrewarmController(t *testing.T) {
+	ctx := context.Background()
+	deploy := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "app", Generation: 1}}
+	c := newKeeperTestClient(t, interceptor.Funcs{}, deploy)
+	tracker := NewTracker(c, logr.Discard())
+
+	tracker.PrewarmController(ctx, deploy, "deployment-controller")
+	annotations := getAnnotations(t, c, "app")
+	assert.Equal(t, HashUsername("deployment-controller"), annotations[ControllersAnnotation])
+	assert.Empty(t, annotations[ObservedGenerationAnnotation], "no generation is observed")
+
+	// Recorded controllers are kept
+	tracker.PrewarmController(ctx, deploy, "alice")
+	assert.Equal(t, HashUsername("deployment-controller"), getAnnotations(t, c, "app")[ControllersAnnotation])
+}
@@ -0,0 +1,128 @@
+package drift
+
+import (
+	"path"
+	"slices"
+)
+
+// Actor identifies who is mutating a child.
+type Actor struct {
+	// Username is the user identifier of the request.
+	Username string
+	// FieldManager is the field manager of the request, if the client set one.
+	FieldManager string
+	// ChildUpdaters are the updater hashes of the child, including the actor's.
+	ChildUpdaters []string
+}
+
+// Classifier decides whether an actor is the controller of a parent.
+type Classifier interface {
+	// IsController returns (isController, canDetermine). isController is only
+	// meaningful if canDetermine is true.
+	IsController(parentState *ParentState, actor Actor) (bool, bool)
+}
+
+// HashClassifier identifies the controller by the hashes of the users
+// updating the parent's status and the child, see IsControllerByHash.
+type HashClassifier struct{}
+
+// IsController implements Classifier.
+func (HashClassifier) IsController(parentState *ParentState, actor Actor) (bool, bool) {
+	return IsControllerByHash(parentState, actor.Username, actor.ChildUpdaters)
+}
+
+// FieldManagerClassifier identifies the controller by the field manager of
+// the request: the controller of a parent writes the parent's status, so its
+// field manager owns status fields in the parent's managedFields. It cannot
+// determine the controller for requests without field manager or parents
+// without status managers.
+type FieldManagerClassifier struct{}
+
+// IsController implements Classifier.
+func (FieldManagerClassifier) IsController(parentState *ParentState, actor Actor) (bool, bool) {
+	if actor.FieldManager == "" || len(parentState.StatusManagers) == 0 {
+		return false, false
+	}
+	return slices.Contains(parentState.StatusManagers, actor.FieldManager), true
+}
+
+// ServiceAccountClassifier identifies the controller by its user name,
+// matching the patterns of path.Match, e.g.
+// system:serviceaccount:crossplane-system:*. It cannot determine the
+// controller without patterns.
+type ServiceAccountClassifier struct {
+	Patterns []string
+}
+
+// IsController implements Classifier.
+func (c ServiceAccountClassifier) IsController(_ *ParentState, actor Actor) (bool, bool) {
+	if len(c.Patterns) == 0 {
+		return false, false
+	}
+	for _, pattern := range c.Patterns {
+		if ok, _ := path.Match(pattern, actor.Username); ok {
+			return true, true
+		}
+	}
+	return false, true
+}
+
+// Combination defines how the results of several classifiers are combined.
+type Combination string
+
+const (
+	// CombineFirst takes the result of the first classifier that can
+	// determine the controller.
+	CombineFirst Combination = "First"
+	// CombineAny identifies the actor as controller if any classifier that
+	// can determine the controller does.
+	CombineAny Combination = "Any"
+	// CombineAll identifies the actor as controller only if every classifier
+	// that can determine the controller does.
+	CombineAll Combination = "All"
+)
+
+// Combine returns a classifier combining classifiers. The controller cannot
+// be determined if none of them can determine it.
+func Combine(combination Combination, classifiers ...Classifier) Classifier {
+	if len(classifiers) == 1 {
+		return classifiers[0]
+	}
+	return combined{combination: combination, classifiers: classifiers}
+}
+
+type combined struct {
+	combination Combination
+	classifiers []Classifier
+}
+
+// IsController implements Classifier.
+func (c combined) IsController(parentState *ParentState, actor Actor) (bool, bool) {
+	determined := false
+	for _, classifier := range c.classifiers {
+		isController, ok := classifier.IsController(parentState, actor)
+		if !ok {
+			continue
+		}
+		switch {
+		case c.combination == CombineAny && isController:
+			return true, true
+		case c.combination == CombineAll && !isController:
+			return false, true
+		case c.combination != CombineAny && c.combination != CombineAll:
+			return isController, true
+		}
+		determined = true
+	}
+	// Any: no classifier identified the controller; All: every one did
+	return determined && c.combination == CombineAll, determined
+}
+
+// classify identifies the controller with the classifier, defaulting to
+// HashClassifier.
+func classify(classifier Classifier, parentState *ParentState, actor Actor) (bool, bool) {
+	if classifier == nil {
+		classifier = HashClassifier{}
+	}
+	return classifier.IsController(parentState, actor)
+}
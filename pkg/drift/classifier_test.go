@@ -0,0 +1,85 @@
+package drift
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/kausality-io/kausality/pkg/controller"
+)
+
+func TestFieldManagerClassifier(t *testing.T) {
+	parent := &unstructured.Unstructured{}
+	parent.SetAPIVersion("example.com/v1")
+	parent.SetKind("Database")
+	parent.SetName("db")
+	parent.SetManagedFields([]metav1.ManagedFieldsEntry{
+		{Manager: "kubectl-client-side-apply", Operation: metav1.ManagedFieldsOperationUpdate},
+		{Manager: "db-operator", Operation: metav1.ManagedFieldsOperationUpdate, Subresource: "status"},
+		{Manager: "db-operator", Operation: metav1.ManagedFieldsOperationApply, Subresource: "status"},
+	})
+	state := ParentStateFromObject(parent)
+	assert.Equal(t, []string{"db-operator"}, state.StatusManagers)
+
+	classifier := FieldManagerClassifier{}
+	isController, ok := classifier.IsController(state, Actor{FieldManager: "db-operator"})
+	assert.True(t, ok)
+	assert.True(t, isController)
+	isController, ok = classifier.IsController(state, Actor{FieldManager: "kubectl-client-side-apply"})
+	assert.True(t, ok)
+	assert.False(t, isController, "spec managers are not the controller")
+	_, ok = classifier.IsController(state, Actor{})
+	assert.False(t, ok, "request without field manager")
+	_, ok = classifier.IsController(&ParentState{}, Actor{FieldManager: "db-operator"})
+	assert.False(t, ok, "parent without status managers")
+}
+
+func TestServiceAccountClassifier(t *testing.T) {
+	classifier := ServiceAccountClassifier{Patterns: []string{"system:serviceaccount:crossplane-system:*", "system:serviceaccount:*:argocd-application-controller"}}
+
+	for user, want := range map[string]bool{
+		"system:serviceaccount:crossplane-system:provider-aws":       true,
+		"system:serviceaccount:argocd:argocd-application-controller": true,
+		"system:serviceaccount:default:builder":                      false,
+		"alice@example.com":                                          false,
+	} {
+		isController, ok := classifier.IsController(&ParentState{}, Actor{Username: user})
+		assert.True(t, ok, user)
+		assert.Equal(t, want, isController, user)
+	}
+
+	_, ok := ServiceAccountClassifier{}.IsController(&ParentState{}, Actor{Username: "alice@example.com"})
+	assert.False(t, ok, "no patterns")
+}
+
+func TestCombine(t *testing.T) {
+	const provider = "system:serviceaccount:crossplane-system:provider-aws"
+	// The parent's controllers annotation names someone else
+	state := &ParentState{Controllers: []string{controller.HashUsername("system:serviceaccount:kube-system:other")}}
+	serviceAccounts := ServiceAccountClassifier{Patterns: []string{"system:serviceaccount:crossplane-system:*"}}
+	actor := Actor{Username: provider}
+
+	tests := []struct {
+		name        string
+		classifier  Classifier
+		want, known bool
+	}{
+		{name: "first decides", classifier: Combine(CombineFirst, serviceAccounts, HashClassifier{}), want: true, known: true},
+		{name: "first skips undetermined", classifier: Combine(CombineFirst, FieldManagerClassifier{}, HashClassifier{}), want: false, known: true},
+		{name: "any", classifier: Combine(CombineAny, HashClassifier{}, serviceAccounts), want: true, known: true},
+		{name: "all", classifier: Combine(CombineAll, serviceAccounts, HashClassifier{}), want: false, known: true},
+		{name: "all of the determined", classifier: Combine(CombineAll, serviceAccounts, FieldManagerClassifier{}), want: true, known: true},
+		{name: "none determined", classifier: Combine(CombineAny, FieldManagerClassifier{}, ServiceAccountClassifier{}), known: false},
+		{name: "single", classifier: Combine(CombineAll, HashClassifier{}), want: false, known: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isController, ok := tt.classifier.IsController(state, actor)
+			assert.Equal(t, tt.known, ok)
+			assert.Equal(t, tt.want, isController)
+		})
+	}
+}
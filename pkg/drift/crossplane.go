@@ -0,0 +1,188 @@
+package drift
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CrossplaneEdgeField is the field index holding the children of Crossplane
+// composites and Usages, as "group/Kind/namespace/name".
+const CrossplaneEdgeField = "kausality.io/crossplane-children"
+
+// CrossplaneEdges finds the parents of objects without controller owner
+// reference in Crossplane's dependency graph, through field indexes on a
+// cache:
+//   - a composite resource is the parent of the resources in its
+//     resourceRefs (spec.crossplane.resourceRefs in Crossplane v2,
+//     spec.resourceRefs before), e.g. of composed resources in other
+//     namespaces than a cluster-scoped composite, which can't own them.
+//   - the used resource of a Usage (spec.of) is the parent of the using
+//     resource (spec.by). Like an owner, it is deleted after its dependent.
+//
+// Composites are preferred over Usages. CrossplaneEdges implements ParentEdges.
+type CrossplaneEdges struct {
+	reader     client.Reader
+	composites []schema.GroupVersionKind
+	usages     []schema.GroupVersionKind
+}
+
+var _ ParentEdges = &CrossplaneEdges{}
+
+// Usage kinds of Crossplane v2 (protection.crossplane.io) and v1.
+var (
+	UsageGVK        = schema.GroupVersionKind{Group: "protection.crossplane.io", Version: "v1beta1", Kind: "Usage"}
+	ClusterUsageGVK = schema.GroupVersionKind{Group: "protection.crossplane.io", Version: "v1beta1", Kind: "ClusterUsage"}
+	LegacyUsageGVK  = schema.GroupVersionKind{Group: "apiextensions.crossplane.io", Version: "v1beta1", Kind: "Usage"}
+)
+
+// NewCrossplaneEdges creates a CrossplaneEdges listing the composites and
+// Usages of the given kinds from reader, which must support
+// CrossplaneEdgeField (see RegisterIndexes).
+func NewCrossplaneEdges(reader client.Reader, composites, usages []schema.GroupVersionKind) *CrossplaneEdges {
+	return &CrossplaneEdges{reader: reader, composites: composites, usages: usages}
+}
+
+// RegisterIndexes registers CrossplaneEdgeField for all composite and Usage
+// kinds. It must be called before the cache is started.
+func (e *CrossplaneEdges) RegisterIndexes(ctx context.Context, indexer client.FieldIndexer) error {
+	for _, gvk := range e.composites {
+		if err := registerEdgeIndex(ctx, indexer, gvk, composedKeys); err != nil {
+			return err
+		}
+	}
+	for _, gvk := range e.usages {
+		if err := registerEdgeIndex(ctx, indexer, gvk, usingKeys); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func registerEdgeIndex(ctx context.Context, indexer client.FieldIndexer, gvk schema.GroupVersionKind, keys func(*unstructured.Unstructured) []string) error {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	if err := indexer.IndexField(ctx, obj, CrossplaneEdgeField, func(obj client.Object) []string {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			return nil
+		}
+		return keys(u)
+	}); err != nil {
+		return fmt.Errorf("failed to index children of %s: %w", gvk, err)
+	}
+	return nil
+}
+
+// ParentOf returns the composite composing obj, or else the resource used by
+// obj, or nil if there is none.
+func (e *CrossplaneEdges) ParentOf(ctx context.Context, obj client.Object) (*ParentRef, error) {
+	key := edgeKey(obj.GetObjectKind().GroupVersionKind().Group, obj.GetObjectKind().GroupVersionKind().Kind, obj.GetNamespace(), obj.GetName())
+
+	for _, gvk := range e.composites {
+		items, err := e.list(ctx, gvk, key)
+		if err != nil {
+			return nil, err
+		}
+		if len(items) > 0 {
+			return &ParentRef{
+				APIVersion: gvk.GroupVersion().String(),
+				Kind:       gvk.Kind,
+				Namespace:  items[0].GetNamespace(),
+				Name:       items[0].GetName(),
+			}, nil
+		}
+	}
+	for _, gvk := range e.usages {
+		items, err := e.list(ctx, gvk, key)
+		if err != nil {
+			return nil, err
+		}
+		for i := range items {
+			if of := usageRef(&items[i], "of"); of != nil {
+				return of, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// list returns the objects of gvk with a child key, sorted by namespace and name.
+func (e *CrossplaneEdges) list(ctx context.Context, gvk schema.GroupVersionKind, key string) ([]unstructured.Unstructured, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err := e.reader.List(ctx, list, client.MatchingFields{CrossplaneEdgeField: key}); err != nil {
+		return nil, fmt.Errorf("failed to list %s with child %s: %w", gvk.Kind, key, err)
+	}
+	sort.Slice(list.Items, func(a, b int) bool {
+		if list.Items[a].GetNamespace() != list.Items[b].GetNamespace() {
+			return list.Items[a].GetNamespace() < list.Items[b].GetNamespace()
+		}
+		return list.Items[a].GetName() < list.Items[b].GetName()
+	})
+	return list.Items, nil
+}
+
+// composedKeys returns the keys of the resources composed by a composite.
+// References without namespace are in the composite's namespace.
+func composedKeys(xr *unstructured.Unstructured) []string {
+	refs, ok, _ := unstructured.NestedSlice(xr.Object, "spec", "crossplane", "resourceRefs")
+	if !ok {
+		refs, _, _ = unstructured.NestedSlice(xr.Object, "spec", "resourceRefs")
+	}
+	var keys []string
+	for _, r := range refs {
+		ref, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		apiVersion, _, _ := unstructured.NestedString(ref, "apiVersion")
+		kind, _, _ := unstructured.NestedString(ref, "kind")
+		name, _, _ := unstructured.NestedString(ref, "name")
+		namespace, _, _ := unstructured.NestedString(ref, "namespace")
+		if namespace == "" {
+			namespace = xr.GetNamespace()
+		}
+		gv, err := schema.ParseGroupVersion(apiVersion)
+		if err != nil || apiVersion == "" || kind == "" || name == "" {
+			continue
+		}
+		keys = append(keys, edgeKey(gv.Group, kind, namespace, name))
+	}
+	return keys
+}
+
+// usingKeys returns the key of the using resource of a Usage.
+func usingKeys(usage *unstructured.Unstructured) []string {
+	by := usageRef(usage, "by")
+	if by == nil {
+		return nil
+	}
+	gv, _ := schema.ParseGroupVersion(by.APIVersion)
+	return []string{edgeKey(gv.Group, by.Kind, by.Namespace, by.Name)}
+}
+
+// usageRef returns the resource at spec.<field> of a Usage, or nil if it is
+// not resolved to a name yet, e.g. while a selector is pending. References
+// without namespace are in the Usage's namespace.
+func usageRef(usage *unstructured.Unstructured, field string) *ParentRef {
+	apiVersion, _, _ := unstructured.NestedString(usage.Object, "spec", field, "apiVersion")
+	kind, _, _ := unstructured.NestedString(usage.Object, "spec", field, "kind")
+	name, _, _ := unstructured.NestedString(usage.Object, "spec", field, "resourceRef", "name")
+	namespace, _, _ := unstructured.NestedString(usage.Object, "spec", field, "resourceRef", "namespace")
+	if _, err := schema.ParseGroupVersion(apiVersion); err != nil || apiVersion == "" || kind == "" || name == "" {
+		return nil
+	}
+	if namespace == "" {
+		namespace = usage.GetNamespace()
+	}
+	return &ParentRef{APIVersion: apiVersion, Kind: kind, Namespace: namespace, Name: name}
+}
+
+func edgeKey(group, kind, namespace, name string) string {
+	return group + "/" + kind + "/" + namespace + "/" + name
+}
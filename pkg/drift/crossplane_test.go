@@ -0,0 +1,121 @@
+package drift
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// builderIndexer registers field indexes on a fake client builder.
+type builderIndexer struct {
+	builder *fake.ClientBuilder
+}
+
+func (i builderIndexer) IndexField(_ context.Context, obj client.Object, field string, extract client.IndexerFunc) error {
+	i.builder.WithIndex(obj, field, extract)
+	return nil
+}
+
+func crossplaneObject(gvk schema.GroupVersionKind, namespace, name string, spec map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	obj.SetGroupVersionKind(gvk)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+func TestCrossplaneEdges(t *testing.T) {
+	xnetwork := schema.GroupVersionKind{Group: "platform.example.org", Version: "v1", Kind: "XNetwork"}
+	xcluster := schema.GroupVersionKind{Group: "platform.example.org", Version: "v1", Kind: "XCluster"}
+	vpc := schema.GroupVersionKind{Group: "ec2.aws.m.upbound.io", Version: "v1beta1", Kind: "VPC"}
+	release := schema.GroupVersionKind{Group: "helm.m.crossplane.io", Version: "v1beta1", Kind: "Release"}
+
+	// A cluster-scoped v2 composite composing a VPC in a namespace
+	network := crossplaneObject(xnetwork, "", "net", map[string]interface{}{
+		"crossplane": map[string]interface{}{"resourceRefs": []interface{}{
+			map[string]interface{}{"apiVersion": "ec2.aws.m.upbound.io/v1beta1", "kind": "VPC", "namespace": "team-a", "name": "net-vpc"},
+		}},
+	})
+	network.SetGeneration(3)
+	require.NoError(t, unstructured.SetNestedField(network.Object, int64(3), "status", "observedGeneration"))
+	// A v1 composite with spec.resourceRefs
+	cluster := crossplaneObject(xcluster, "", "prod", map[string]interface{}{
+		"resourceRefs": []interface{}{
+			map[string]interface{}{"apiVersion": "ec2.aws.upbound.io/v1beta1", "kind": "Subnet", "name": "prod-subnet"},
+		},
+	})
+	usage := crossplaneObject(UsageGVK, "team-a", "release-uses-vpc", map[string]interface{}{
+		"of": map[string]interface{}{"apiVersion": "ec2.aws.m.upbound.io/v1beta1", "kind": "VPC", "resourceRef": map[string]interface{}{"name": "net-vpc"}},
+		"by": map[string]interface{}{"apiVersion": "helm.m.crossplane.io/v1beta1", "kind": "Release", "resourceRef": map[string]interface{}{"name": "app"}},
+	})
+	// A Usage whose selector is not resolved yet
+	pending := crossplaneObject(UsageGVK, "team-a", "pending", map[string]interface{}{
+		"of": map[string]interface{}{"apiVersion": "ec2.aws.m.upbound.io/v1beta1", "kind": "VPC", "resourceSelector": map[string]interface{}{}},
+		"by": map[string]interface{}{"apiVersion": "helm.m.crossplane.io/v1beta1", "kind": "Release", "resourceRef": map[string]interface{}{"name": "other"}},
+	})
+	vpcObj := crossplaneObject(vpc, "team-a", "net-vpc", nil)
+
+	builder := fake.NewClientBuilder().WithScheme(runtime.NewScheme()).WithObjects(network, cluster, usage, pending, vpcObj)
+	edges := NewCrossplaneEdges(nil, []schema.GroupVersionKind{xnetwork, xcluster}, []schema.GroupVersionKind{UsageGVK})
+	require.NoError(t, edges.RegisterIndexes(context.Background(), builderIndexer{builder: builder}))
+	c := builder.Build()
+	edges.reader = c
+
+	tests := []struct {
+		name  string
+		child *unstructured.Unstructured
+		want  *ParentRef
+	}{
+		{
+			name:  "composed resource in another namespace",
+			child: crossplaneObject(vpc, "team-a", "net-vpc", nil),
+			want:  &ParentRef{APIVersion: "platform.example.org/v1", Kind: "XNetwork", Name: "net"},
+		},
+		{
+			name:  "v1 resourceRefs, matched by group regardless of version",
+			child: crossplaneObject(schema.GroupVersionKind{Group: "ec2.aws.upbound.io", Version: "v1beta2", Kind: "Subnet"}, "", "prod-subnet", nil),
+			want:  &ParentRef{APIVersion: "platform.example.org/v1", Kind: "XCluster", Name: "prod"},
+		},
+		{
+			name:  "using resource of a Usage",
+			child: crossplaneObject(release, "team-a", "app", nil),
+			want:  &ParentRef{APIVersion: "ec2.aws.m.upbound.io/v1beta1", Kind: "VPC", Namespace: "team-a", Name: "net-vpc"},
+		},
+		{name: "unresolved Usage", child: crossplaneObject(release, "team-a", "other", nil)},
+		{name: "same name in another namespace", child: crossplaneObject(vpc, "team-b", "net-vpc", nil)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref, err := edges.ParentOf(context.Background(), tt.child)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ref)
+		})
+	}
+
+	// The resolver falls back to the edges without controller owner reference
+	resolver := NewParentResolver(c)
+	state, err := resolver.ResolveParent(context.Background(), crossplaneObject(vpc, "team-a", "net-vpc", nil))
+	require.NoError(t, err)
+	assert.Nil(t, state, "no edges configured")
+	resolver.SetParentEdges(edges)
+	state, err = resolver.ResolveParent(context.Background(), crossplaneObject(vpc, "team-a", "net-vpc", nil))
+	require.NoError(t, err)
+	require.NotNil(t, state)
+	assert.Equal(t, ParentRef{APIVersion: "platform.example.org/v1", Kind: "XNetwork", Name: "net"}, state.Ref)
+	assert.Equal(t, int64(3), state.Generation)
+	assert.Equal(t, int64(3), state.ObservedGeneration)
+
+	// Composed resources of deleted composites have no parent
+	require.NoError(t, c.Delete(context.Background(), cluster))
+	state, err = resolver.ResolveParent(context.Background(), crossplaneObject(schema.GroupVersionKind{Group: "ec2.aws.upbound.io", Version: "v1beta1", Kind: "Subnet"}, "", "prod-subnet", nil))
+	require.NoError(t, err)
+	assert.Nil(t, state)
+}
@@ -3,7 +3,10 @@ package drift
 import (
 	"context"
 	"fmt"
+	"slices"
+	"time"
 
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/kausality-io/kausality/pkg/controller"
@@ -13,6 +16,15 @@ import (
 type Detector struct {
 	resolver          *ParentResolver
 	lifecycleDetector *LifecycleDetector
+	referenceParents  ReferenceParentFinder
+}
+
+// ReferenceParentFinder finds the causal parent of an object without
+// controller owner reference, e.g. the Crossplane managed resource whose
+// provider writes a connection Secret, possibly in another namespace.
+type ReferenceParentFinder interface {
+	// ReferenceParent returns the parent of obj, or nil if there is none.
+	ReferenceParent(ctx context.Context, obj client.Object) (*unstructured.Unstructured, error)
 }
 
 // NewDetector creates a new Detector.
@@ -33,6 +45,49 @@ func WithLifecycleDetector(ld *LifecycleDetector) DetectorOption {
 	}
 }
 
+// WithReferenceParents configures a finder for parents referencing objects
+// instead of owning them. Referenced objects are only written by the
+// controller of their parent: it may change them at any time, while changes by
+// other actors are drift.
+func WithReferenceParents(f ReferenceParentFinder) DetectorOption {
+	return func(d *Detector) {
+		d.referenceParents = f
+	}
+}
+
+// WithProfiles configures Deployment-like parent kinds in addition to
+// DefaultProfiles. Changes by their controller while they progress are
+// expected.
+func WithProfiles(profiles ...Profile) DetectorOption {
+	return func(d *Detector) {
+		d.resolver.profiles = append(d.resolver.profiles, profiles...)
+	}
+}
+
+// WithAggregatedAPIs configures which parents are served by aggregated API
+// servers, see ParentResolver.SetAggregatedAPIs.
+func WithAggregatedAPIs(a AggregatedAPIs) DetectorOption {
+	return func(d *Detector) {
+		d.resolver.SetAggregatedAPIs(a)
+	}
+}
+
+// WithParentEdges configures where to find the parents of objects without
+// controller owner reference, see ParentResolver.SetParentEdges.
+func WithParentEdges(e ParentEdges) DetectorOption {
+	return func(d *Detector) {
+		d.resolver.SetParentEdges(e)
+	}
+}
+
+// WithLifecycleRegistry configures the lifecycle mappings of parent kinds,
+// see ParentResolver.SetLifecycleRegistry.
+func WithLifecycleRegistry(l *LifecycleRegistry) DetectorOption {
+	return func(d *Detector) {
+		d.resolver.SetLifecycleRegistry(l)
+	}
+}
+
 // NewDetectorWithOptions creates a new Detector with options.
 func NewDetectorWithOptions(c client.Client, opts ...DetectorOption) *Detector {
 	d := NewDetector(c)
@@ -69,7 +124,7 @@ func (d *Detector) checkLifecycle(parentState *ParentState) (*DriftResult, bool)
 
 // checkGeneration checks generation vs observedGeneration for drift.
 // Must be called when request is from the controller.
-func checkGeneration(result *DriftResult, parentState *ParentState) *DriftResult {
+func checkGeneration(result *DriftResult, parentState *ParentState, actor Actor) *DriftResult {
 	if parentState.Generation != parentState.ObservedGeneration {
 		result.Allowed = true
 		result.DriftDetected = false
@@ -78,6 +133,20 @@ func checkGeneration(result *DriftResult, parentState *ParentState) *DriftResult
 		return result
 	}
 
+	if parentState.Progressing != "" {
+		result.Allowed = true
+		result.DriftDetected = false
+		result.Reason = fmt.Sprintf("expected change: parent is progressing (%s)", parentState.Progressing)
+		return result
+	}
+
+	if refresh := parentState.Refresh.Expects(actor.FieldManager, time.Now()); refresh != "" {
+		result.Allowed = true
+		result.DriftDetected = false
+		result.Reason = fmt.Sprintf("expected change: parent refreshes children (%s)", refresh)
+		return result
+	}
+
 	// Controller is updating but parent hasn't changed - drift
 	result.Allowed = true // Phase 1: logging only
 	result.DriftDetected = true
@@ -89,12 +158,25 @@ func checkGeneration(result *DriftResult, parentState *ParentState) *DriftResult
 // Detect checks whether a mutation would be considered drift.
 // It uses user hash tracking to identify if the request comes from the controller.
 // childUpdaters contains the current updater hashes from the child's annotation (before this update).
+// Errors resolving the parent are returned; use ClassOf to classify them.
 func (d *Detector) Detect(ctx context.Context, obj client.Object, username string, childUpdaters []string) (*DriftResult, error) {
+	return d.DetectActor(ctx, obj, Actor{Username: username, ChildUpdaters: childUpdaters}, nil)
+}
+
+// DetectActor checks whether a mutation by actor would be considered drift,
+// identifying the controller with classifier, or by user hash tracking if nil.
+func (d *Detector) DetectActor(ctx context.Context, obj client.Object, actor Actor, classifier Classifier) (*DriftResult, error) {
 	parentState, err := d.resolver.ResolveParent(ctx, obj)
 	if err != nil {
-		return &DriftResult{Allowed: false, Reason: fmt.Sprintf("failed to resolve parent: %v", err)}, nil
+		return nil, err
 	}
 	if parentState == nil {
+		if orphan := OrphanOf(obj); orphan != nil {
+			return &DriftResult{Allowed: true, Reason: fmt.Sprintf("orphaned: controller owner %s was deleted", orphan.Owner())}, nil
+		}
+		if d.referenceParents != nil {
+			return d.detectReferenced(ctx, obj, actor, classifier)
+		}
 		return &DriftResult{Allowed: true, Reason: "no controller owner reference"}, nil
 	}
 
@@ -103,26 +185,76 @@ func (d *Detector) Detect(ctx context.Context, obj client.Object, username strin
 		return result, nil
 	}
 
-	isController, canDetermine := IsControllerByHash(parentState, username, childUpdaters)
+	isController, canDetermine := classify(classifier, parentState, actor)
+	if parentState.Paused != "" {
+		return checkPaused(result, parentState, isController && canDetermine), nil
+	}
 	if !canDetermine {
 		result.Allowed = true
 		result.DriftDetected = false
-		result.Reason = "cannot determine controller identity (multiple updaters, no parent controllers annotation)"
+		result.Reason = undeterminedReason(classifier)
 		return result, nil
 	}
 	if !isController {
 		result.Allowed = true
 		result.DriftDetected = false
-		result.Reason = fmt.Sprintf("change by different actor (hash %s)", controller.HashUsername(username))
+		result.Reason = fmt.Sprintf("change by different actor (hash %s)", controller.HashUsername(actor.Username))
 		return result, nil
 	}
 
-	return checkGeneration(result, parentState), nil
+	return checkGeneration(result, parentState, actor), nil
+}
+
+// undeterminedReason explains why a classifier cannot determine the controller.
+func undeterminedReason(classifier Classifier) string {
+	if _, ok := classifier.(HashClassifier); ok || classifier == nil {
+		return "cannot determine controller identity (multiple updaters, no parent controllers annotation)"
+	}
+	return "cannot determine controller identity with the configured classifiers"
+}
+
+// detectReferenced checks a mutation of an object referenced by its parent.
+func (d *Detector) detectReferenced(ctx context.Context, obj client.Object, actor Actor, classifier Classifier) (*DriftResult, error) {
+	parent, err := d.referenceParents.ReferenceParent(ctx, obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find referencing parent: %w", err)
+	}
+	if parent == nil {
+		return &DriftResult{Allowed: true, Reason: "no controller owner reference"}, nil
+	}
+	parentState := ParentStateFromObject(parent)
+
+	result, done := d.checkLifecycle(parentState)
+	if done {
+		return result, nil
+	}
+
+	isController, canDetermine := classify(classifier, parentState, actor)
+	result.Allowed = true
+	switch {
+	case parentState.Paused != "":
+		result.DriftDetected = true
+		result.Reason = fmt.Sprintf("drift detected: referencing parent %s is paused (%s)", parentState.Ref.String(), parentState.Paused)
+	case !canDetermine:
+		result.Reason = undeterminedReason(classifier)
+	case isController:
+		// e.g. Crossplane providers publish connection details whenever they
+		// observe new ones, independent of the managed resource's generation
+		result.Reason = fmt.Sprintf("expected change: referenced object written by the controller of %s", parentState.Ref.String())
+	default:
+		result.DriftDetected = true
+		result.Reason = fmt.Sprintf("drift detected: referenced object changed by different actor than the controller of %s (hash %s)",
+			parentState.Ref.String(), controller.HashUsername(actor.Username))
+	}
+	return result, nil
 }
 
 // IsControllerByHash checks if the request comes from the controller using user hash tracking.
-// Returns (isController, canDetermine).
+// The parent's Appliers are the controller. Returns (isController, canDetermine).
 func IsControllerByHash(parentState *ParentState, username string, childUpdaters []string) (bool, bool) {
+	if slices.Contains(parentState.Appliers, username) {
+		return true, true
+	}
 	userHash := controller.HashUsername(username)
 
 	// When parent has controllers annotation, cross-validate
@@ -1,12 +1,17 @@
 package drift
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	"github.com/kausality-io/kausality/pkg/controller"
 )
@@ -370,7 +375,7 @@ func TestCheckGeneration(t *testing.T) {
 				ParentState: parentState,
 			}
 
-			got := checkGeneration(result, parentState)
+			got := checkGeneration(result, parentState, Actor{})
 			assert.Equal(t, tt.wantDrift, got.DriftDetected, "DriftDetected")
 			assert.Equal(t, tt.wantAllowed, got.Allowed, "Allowed")
 		})
@@ -410,3 +415,66 @@ func TestParentRef_String(t *testing.T) {
 		})
 	}
 }
+
+// staticParents returns the same referencing parent for every object.
+type staticParents struct {
+	parent *unstructured.Unstructured
+}
+
+func (s staticParents) ReferenceParent(context.Context, client.Object) (*unstructured.Unstructured, error) {
+	return s.parent, nil
+}
+
+func TestDetect_ReferenceParent(t *testing.T) {
+	providerUser := "system:serviceaccount:crossplane-system:provider-aws"
+	newManaged := func(generation int64, deleting bool) *unstructured.Unstructured {
+		mr := &unstructured.Unstructured{}
+		mr.SetAPIVersion("rds.aws.crossplane.io/v1beta1")
+		mr.SetKind("DBInstance")
+		mr.SetName("db")
+		mr.SetGeneration(generation)
+		mr.SetAnnotations(map[string]string{controller.ControllersAnnotation: controller.HashUsername(providerUser)})
+		if deleting {
+			mr.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+		}
+		_ = unstructured.SetNestedSlice(mr.Object, []interface{}{
+			map[string]interface{}{"type": "Synced", "status": "True", "observedGeneration": int64(1)},
+			map[string]interface{}{"type": "Ready", "status": "True", "observedGeneration": int64(1)},
+		}, "status", "conditions")
+		return mr
+	}
+	secret := &unstructured.Unstructured{}
+	secret.SetAPIVersion("v1")
+	secret.SetKind("Secret")
+	secret.SetNamespace("crossplane-system")
+	secret.SetName("db-conn")
+
+	tests := []struct {
+		name       string
+		parent     *unstructured.Unstructured
+		user       string
+		wantDrift  bool
+		wantParent bool
+	}{
+		{name: "no referencing parent", user: "alice"},
+		{name: "provider while stable", parent: newManaged(1, false), user: providerUser, wantParent: true},
+		{name: "provider while reconciling", parent: newManaged(2, false), user: providerUser, wantParent: true},
+		{name: "other actor", parent: newManaged(1, false), user: "alice", wantDrift: true, wantParent: true},
+		{name: "other actor while parent deleting", parent: newManaged(1, true), user: "alice", wantParent: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDetectorWithOptions(fake.NewClientBuilder().Build(), WithReferenceParents(staticParents{parent: tt.parent}))
+			result, err := d.Detect(context.Background(), secret, tt.user, []string{controller.HashUsername(tt.user)})
+			require.NoError(t, err)
+			assert.True(t, result.Allowed)
+			assert.Equal(t, tt.wantDrift, result.DriftDetected, result.Reason)
+			if !tt.wantParent {
+				assert.Nil(t, result.ParentRef)
+				return
+			}
+			require.NotNil(t, result.ParentRef)
+			assert.Equal(t, "DBInstance", result.ParentRef.Kind)
+		})
+	}
+}
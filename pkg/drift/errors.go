@@ -0,0 +1,68 @@
+package drift
+
+import (
+	"errors"
+)
+
+// ErrorClass classifies errors preventing drift detection, so that each class
+// can be mapped to an admission response.
+type ErrorClass string
+
+const (
+	// ErrorParentNotFound means the controller owner of the object does not exist,
+	// e.g. because it was deleted concurrently.
+	ErrorParentNotFound ErrorClass = "ParentNotFound"
+	// ErrorParentForbidden means the parent cannot be read, usually because of
+	// missing RBAC for the parent's resource.
+	ErrorParentForbidden ErrorClass = "ParentForbidden"
+	// ErrorDecode means the request or an owner reference could not be decoded.
+	ErrorDecode ErrorClass = "DecodeError"
+	// ErrorPolicyUnavailable means the drift detection mode cannot be resolved,
+	// e.g. because the namespace cannot be read for selector matching.
+	ErrorPolicyUnavailable ErrorClass = "PolicyUnavailable"
+	// ErrorInternal covers all other errors, e.g. API server unavailability.
+	ErrorInternal ErrorClass = "Internal"
+	// ErrorBudgetExhausted means the admission request exceeded its latency
+	// budget, e.g. because of slow parent reads.
+	ErrorBudgetExhausted ErrorClass = "BudgetExhausted"
+)
+
+// ErrorClasses lists all error classes.
+var ErrorClasses = []ErrorClass{
+	ErrorParentNotFound,
+	ErrorParentForbidden,
+	ErrorDecode,
+	ErrorPolicyUnavailable,
+	ErrorInternal,
+	ErrorBudgetExhausted,
+}
+
+// Error is an error of a known class.
+type Error struct {
+	Class ErrorClass
+	Err   error
+}
+
+// NewError wraps err with an error class.
+func NewError(class ErrorClass, err error) *Error {
+	return &Error{Class: class, Err: err}
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ClassOf returns the class of err, or ErrorInternal if it is unclassified.
+func ClassOf(err error) ErrorClass {
+	var classified *Error
+	if errors.As(err, &classified) {
+		return classified.Class
+	}
+	return ErrorInternal
+}
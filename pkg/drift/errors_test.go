@@ -0,0 +1,68 @@
+package drift
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestClassOf(t *testing.T) {
+	err := NewError(ErrorParentForbidden, fmt.Errorf("forbidden"))
+	assert.Equal(t, ErrorParentForbidden, ClassOf(err))
+	assert.Equal(t, ErrorParentForbidden, ClassOf(fmt.Errorf("wrapped: %w", err)))
+	assert.Equal(t, ErrorInternal, ClassOf(fmt.Errorf("unclassified")))
+	assert.Equal(t, "forbidden", err.Error())
+}
+
+func TestResolveParent_ErrorClasses(t *testing.T) {
+	deployments := schema.GroupResource{Group: "apps", Resource: "deployments"}
+	tests := []struct {
+		name       string
+		apiVersion string
+		getErr     error
+		want       ErrorClass
+	}{
+		{name: "not found", apiVersion: "apps/v1", getErr: apierrors.NewNotFound(deployments, "web"), want: ErrorParentNotFound},
+		{name: "forbidden", apiVersion: "apps/v1", getErr: apierrors.NewForbidden(deployments, "web", fmt.Errorf("no RBAC")), want: ErrorParentForbidden},
+		{name: "server error", apiVersion: "apps/v1", getErr: apierrors.NewServiceUnavailable("etcd down"), want: ErrorInternal},
+		{name: "invalid owner reference", apiVersion: "apps/v1/extra", want: ErrorDecode},
+	}
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+				Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+					return tt.getErr
+				},
+			}).Build()
+			rs := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      "web-abc",
+				OwnerReferences: []metav1.OwnerReference{
+					{APIVersion: tt.apiVersion, Kind: "Deployment", Name: "web", Controller: ptr.To(true)},
+				},
+			}}
+
+			_, err := NewParentResolver(c).ResolveParent(context.Background(), rs)
+			require.Error(t, err)
+			assert.Equal(t, tt.want, ClassOf(err))
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package drift
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Labels set by Flux on the objects it applies, naming the Kustomization or
+// HelmRelease applying them.
+const (
+	FluxKustomizationNameLabel      = "kustomize.toolkit.fluxcd.io/name"
+	FluxKustomizationNamespaceLabel = "kustomize.toolkit.fluxcd.io/namespace"
+	FluxHelmReleaseNameLabel        = "helm.toolkit.fluxcd.io/name"
+	FluxHelmReleaseNamespaceLabel   = "helm.toolkit.fluxcd.io/namespace"
+)
+
+// Flux kinds applying objects.
+var (
+	FluxKustomizationGVK = schema.GroupVersionKind{Group: "kustomize.toolkit.fluxcd.io", Version: "v1", Kind: "Kustomization"}
+	FluxHelmReleaseGVK   = schema.GroupVersionKind{Group: "helm.toolkit.fluxcd.io", Version: "v2", Kind: "HelmRelease"}
+)
+
+// FluxEdges finds the Flux Kustomization or HelmRelease applying an object
+// without controller owner reference from its Flux labels. Flux does not set
+// owner references on the objects it applies. The HelmRelease is preferred:
+// a Kustomization may apply a HelmRelease, which labels the objects of its
+// chart. FluxEdges implements ParentEdges.
+type FluxEdges struct{}
+
+var _ ParentEdges = FluxEdges{}
+
+// ParentOf implements ParentEdges.
+func (FluxEdges) ParentOf(_ context.Context, obj client.Object) (*ParentRef, error) {
+	labels := obj.GetLabels()
+	for _, parent := range []struct {
+		gvk             schema.GroupVersionKind
+		name, namespace string
+	}{
+		{FluxHelmReleaseGVK, FluxHelmReleaseNameLabel, FluxHelmReleaseNamespaceLabel},
+		{FluxKustomizationGVK, FluxKustomizationNameLabel, FluxKustomizationNamespaceLabel},
+	} {
+		name, namespace := labels[parent.name], labels[parent.namespace]
+		if name == "" || namespace == "" {
+			continue
+		}
+		return &ParentRef{
+			APIVersion: parent.gvk.GroupVersion().String(),
+			Kind:       parent.gvk.Kind,
+			Namespace:  namespace,
+			Name:       name,
+		}, nil
+	}
+	return nil, nil
+}
+
+// FluxProfiles describe Flux Kustomizations and HelmReleases. They apply new
+// revisions of their sources without generation change, while their
+// Reconciling condition is True. They apply objects as the ServiceAccount in
+// spec.serviceAccountName if set, or else as defaultServiceAccount if set
+// (the --default-service-account flag of the Flux controllers), whose
+// changes are the controller's.
+func FluxProfiles(defaultServiceAccount string) []Profile {
+	var profiles []Profile
+	for _, gvk := range []schema.GroupVersionKind{FluxKustomizationGVK, FluxHelmReleaseGVK} {
+		profiles = append(profiles, Profile{
+			Group:                 gvk.Group,
+			Kind:                  gvk.Kind,
+			ProgressingConditions: []string{"Reconciling"},
+			ServiceAccountPath:    ".spec.serviceAccountName",
+			DefaultServiceAccount: defaultServiceAccount,
+		})
+	}
+	return profiles
+}
+
+// ChainEdges returns ParentEdges asking edges in order, returning the first
+// parent found.
+func ChainEdges(edges ...ParentEdges) ParentEdges {
+	var result chainedEdges
+	for _, e := range edges {
+		if e != nil {
+			result = append(result, e)
+		}
+	}
+	switch len(result) {
+	case 0:
+		return nil
+	case 1:
+		return result[0]
+	}
+	return result
+}
+
+type chainedEdges []ParentEdges
+
+// ParentOf implements ParentEdges.
+func (c chainedEdges) ParentOf(ctx context.Context, obj client.Object) (*ParentRef, error) {
+	for _, e := range c {
+		ref, err := e.ParentOf(ctx, obj)
+		if err != nil || ref != nil {
+			return ref, err
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,187 @@
+package drift
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kausality-io/kausality/pkg/controller"
+)
+
+// staticEdges returns the same parent for every object.
+type staticEdges struct {
+	ref *ParentRef
+}
+
+func (e staticEdges) ParentOf(context.Context, client.Object) (*ParentRef, error) {
+	return e.ref, nil
+}
+
+func TestFluxEdges(t *testing.T) {
+	configMap := schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+	labeled := func(labels map[string]string) *unstructured.Unstructured {
+		obj := crossplaneObject(configMap, "apps", "settings", nil)
+		obj.SetLabels(labels)
+		return obj
+	}
+
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   *ParentRef
+	}{
+		{
+			name: "kustomization",
+			labels: map[string]string{
+				FluxKustomizationNameLabel:      "apps",
+				FluxKustomizationNamespaceLabel: "flux-system",
+			},
+			want: &ParentRef{APIVersion: "kustomize.toolkit.fluxcd.io/v1", Kind: "Kustomization", Namespace: "flux-system", Name: "apps"},
+		},
+		{
+			name: "helm release applied by a kustomization",
+			labels: map[string]string{
+				FluxHelmReleaseNameLabel:        "podinfo",
+				FluxHelmReleaseNamespaceLabel:   "apps",
+				FluxKustomizationNameLabel:      "apps",
+				FluxKustomizationNamespaceLabel: "flux-system",
+			},
+			want: &ParentRef{APIVersion: "helm.toolkit.fluxcd.io/v2", Kind: "HelmRelease", Namespace: "apps", Name: "podinfo"},
+		},
+		{
+			name:   "name without namespace",
+			labels: map[string]string{FluxKustomizationNameLabel: "apps"},
+		},
+		{
+			name: "not applied by flux",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FluxEdges{}.ParentOf(context.Background(), labeled(tt.labels))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestChainEdges(t *testing.T) {
+	first := &ParentRef{APIVersion: "v1", Kind: "Namespace", Name: "first"}
+	second := &ParentRef{APIVersion: "v1", Kind: "Namespace", Name: "second"}
+	obj := &unstructured.Unstructured{}
+
+	assert.Nil(t, ChainEdges(nil, nil))
+	assert.Equal(t, FluxEdges{}, ChainEdges(nil, FluxEdges{}))
+
+	got, err := ChainEdges(staticEdges{}, staticEdges{ref: first}, staticEdges{ref: second}).ParentOf(context.Background(), obj)
+	require.NoError(t, err)
+	assert.Equal(t, first, got)
+	got, err = ChainEdges(staticEdges{}, staticEdges{}).ParentOf(context.Background(), obj)
+	require.NoError(t, err)
+	assert.Nil(t, got)
+}
+
+// newKustomization returns a Flux Kustomization with generation ==
+// observedGeneration and the given conditions.
+func newKustomization(spec map[string]interface{}, conditions ...interface{}) *unstructured.Unstructured {
+	k := crossplaneObject(FluxKustomizationGVK, "flux-system", "apps", spec)
+	k.SetUID("kustomization-uid")
+	k.SetGeneration(2)
+	k.SetAnnotations(map[string]string{controller.PhaseAnnotation: controller.PhaseValueInitialized})
+	k.Object["status"] = map[string]interface{}{"observedGeneration": int64(2), "conditions": conditions}
+	return k
+}
+
+func condition(conditionType, status string) interface{} {
+	return map[string]interface{}{"type": conditionType, "status": status, "reason": "Test"}
+}
+
+func TestDetect_Flux(t *testing.T) {
+	const kustomizeController = "system:serviceaccount:flux-system:kustomize-controller"
+	const applier = "system:serviceaccount:flux-system:flux-applier"
+	const alice = "alice"
+
+	tests := []struct {
+		name       string
+		spec       map[string]interface{}
+		conditions []interface{}
+		defaultSA  string
+		user       string
+		wantDrift  bool
+		wantReason string
+	}{
+		{
+			name:       "reconciling a new revision",
+			conditions: []interface{}{condition("Ready", "Unknown"), condition("Reconciling", "True")},
+			user:       kustomizeController,
+			wantReason: "expected change: parent is progressing (condition Reconciling is True)",
+		},
+		{
+			name:       "ready",
+			conditions: []interface{}{condition("Ready", "True")},
+			user:       kustomizeController,
+			wantDrift:  true,
+		},
+		{
+			name:       "impersonated service account while reconciling",
+			spec:       map[string]interface{}{"serviceAccountName": "flux-applier"},
+			conditions: []interface{}{condition("Ready", "True"), condition("Reconciling", "True")},
+			user:       applier,
+		},
+		{
+			name:       "impersonated default service account when ready",
+			conditions: []interface{}{condition("Ready", "True")},
+			defaultSA:  "flux-applier",
+			user:       applier,
+			wantDrift:  true,
+		},
+		{
+			name:       "others while reconciling",
+			spec:       map[string]interface{}{"serviceAccountName": "flux-applier"},
+			conditions: []interface{}{condition("Reconciling", "True")},
+			user:       alice,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kustomization := newKustomization(tt.spec, tt.conditions...)
+			kustomization.GetAnnotations()[controller.ControllersAnnotation] = controller.HashUsername(kustomizeController)
+			cm := crossplaneObject(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, "apps", "settings", nil)
+			cm.SetLabels(map[string]string{
+				FluxKustomizationNameLabel:      "apps",
+				FluxKustomizationNamespaceLabel: "flux-system",
+			})
+
+			c := fake.NewClientBuilder().WithObjects(kustomization).Build()
+			d := NewDetectorWithOptions(c, WithProfiles(FluxProfiles(tt.defaultSA)...), WithParentEdges(FluxEdges{}))
+			result, err := d.Detect(context.Background(), cm, tt.user, []string{controller.HashUsername(tt.user)})
+			require.NoError(t, err)
+			require.NotNil(t, result.ParentState)
+			assert.Equal(t, "Kustomization", result.ParentState.Ref.Kind)
+			assert.Equal(t, tt.wantDrift, result.DriftDetected, result.Reason)
+			if tt.wantReason != "" {
+				assert.Equal(t, tt.wantReason, result.Reason)
+			}
+		})
+	}
+}
+
+func TestProfile_Appliers(t *testing.T) {
+	profile := FluxProfiles("flux-applier")[0]
+	assert.Equal(t, []string{"system:serviceaccount:flux-system:tenant"},
+		profile.Appliers(newKustomization(map[string]interface{}{"serviceAccountName": "tenant"})))
+	assert.Equal(t, []string{"system:serviceaccount:flux-system:flux-applier"}, profile.Appliers(newKustomization(nil)))
+	assert.Nil(t, FluxProfiles("")[0].Appliers(newKustomization(nil)))
+
+	state := &ParentState{Appliers: []string{"system:serviceaccount:flux-system:tenant"}, Controllers: []string{controller.HashUsername("other")}}
+	isController, ok := IsControllerByHash(state, "system:serviceaccount:flux-system:tenant", nil)
+	assert.True(t, ok)
+	assert.True(t, isController)
+}
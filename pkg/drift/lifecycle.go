@@ -18,14 +18,16 @@ func NewLifecycleDetector() *LifecycleDetector {
 	}
 }
 
-// DetectPhase determines the lifecycle phase of a parent object.
+// DetectPhase determines the lifecycle phase of a parent object. Parents
+// with lifecycle signals (see LifecycleMapping) are initialized once a ready
+// signal matches, instead of by the DetectionOrder.
 func (d *LifecycleDetector) DetectPhase(state *ParentState) LifecyclePhase {
 	if state == nil {
 		return PhaseInitialized
 	}
 
 	// Check deletion first - takes precedence
-	if state.DeletionTimestamp != nil {
+	if state.DeletionTimestamp != nil || (state.Lifecycle != nil && state.Lifecycle.Deleting != "") {
 		return PhaseDeleting
 	}
 
@@ -34,6 +36,13 @@ func (d *LifecycleDetector) DetectPhase(state *ParentState) LifecyclePhase {
 		return PhaseInitialized
 	}
 
+	if state.Lifecycle != nil {
+		if state.Lifecycle.Ready != "" {
+			return PhaseInitialized
+		}
+		return PhaseInitializing
+	}
+
 	// Check initialization using configured detection order
 	detectionOrder := d.DetectionOrder
 	if len(detectionOrder) == 0 {
@@ -0,0 +1,52 @@
+package drift
+
+import (
+	"slices"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kausality-io/kausality/api/v1alpha1"
+)
+
+// OrphanedAnnotation records the deleted controller owner of an orphaned object.
+const OrphanedAnnotation = v1alpha1.OrphanedAnnotation
+
+// Orphan records the deleted controller owner of an orphaned object.
+type Orphan = v1alpha1.Orphan
+
+// ReleasedOwner returns the controller owner reference of oldObj that newObj
+// no longer carries, or nil if the controller owner is unchanged.
+func ReleasedOwner(oldObj, newObj client.Object) *metav1.OwnerReference {
+	ref := findControllerOwnerRef(oldObj.GetOwnerReferences())
+	if ref == nil {
+		return nil
+	}
+	for _, r := range newObj.GetOwnerReferences() {
+		if r.UID == ref.UID {
+			return nil
+		}
+	}
+	return ref
+}
+
+// IsOrphaning returns true if an owner is being deleted with orphan
+// propagation: the garbage collector removes the owner references of its
+// dependents before deleting it.
+func IsOrphaning(owner client.Object) bool {
+	return owner.GetDeletionTimestamp() != nil && slices.Contains(owner.GetFinalizers(), metav1.FinalizerOrphanDependents)
+}
+
+// OrphanOf returns the orphan record of an object, or nil if it has none or
+// it is invalid.
+func OrphanOf(obj client.Object) *Orphan {
+	value := obj.GetAnnotations()[OrphanedAnnotation]
+	if value == "" {
+		return nil
+	}
+	orphan, err := v1alpha1.ParseOrphan(value)
+	if err != nil || orphan.UID == "" {
+		return nil
+	}
+	return orphan
+}
@@ -0,0 +1,80 @@
+package drift
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newOrphanTestChild(ownerUID types.UID, orphanedBy types.UID) *unstructured.Unstructured {
+	child := &unstructured.Unstructured{}
+	child.SetAPIVersion("apps/v1")
+	child.SetKind("ReplicaSet")
+	child.SetNamespace("default")
+	child.SetName("web-abc")
+	if ownerUID != "" {
+		child.SetOwnerReferences([]metav1.OwnerReference{{
+			APIVersion: "apps/v1", Kind: "Deployment", Name: "web", UID: ownerUID, Controller: ptr.To(true),
+		}})
+	}
+	if orphanedBy != "" {
+		orphan := &Orphan{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", UID: orphanedBy}
+		child.SetAnnotations(map[string]string{OrphanedAnnotation: orphan.String()})
+	}
+	return child
+}
+
+func TestReleasedOwner(t *testing.T) {
+	owned := newOrphanTestChild("deploy-uid", "")
+
+	ref := ReleasedOwner(owned, newOrphanTestChild("", ""))
+	require.NotNil(t, ref)
+	assert.Equal(t, types.UID("deploy-uid"), ref.UID)
+
+	assert.Nil(t, ReleasedOwner(owned, owned), "owner unchanged")
+	assert.Nil(t, ReleasedOwner(newOrphanTestChild("", ""), owned), "adoption releases nothing")
+}
+
+func TestIsOrphaning(t *testing.T) {
+	owner := &unstructured.Unstructured{}
+	assert.False(t, IsOrphaning(owner))
+
+	owner.SetFinalizers([]string{metav1.FinalizerOrphanDependents})
+	assert.False(t, IsOrphaning(owner), "not deleted yet")
+
+	owner.SetDeletionTimestamp(&metav1.Time{Time: time.Now()})
+	assert.True(t, IsOrphaning(owner))
+
+	owner.SetFinalizers([]string{metav1.FinalizerDeleteDependents})
+	assert.False(t, IsOrphaning(owner), "foreground deletion")
+}
+
+func TestDetect_Orphan(t *testing.T) {
+	d := NewDetector(fake.NewClientBuilder().Build())
+
+	// Orphaned child without owner reference
+	result, err := d.Detect(context.Background(), newOrphanTestChild("", "deploy-uid"), "alice", nil)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.False(t, result.DriftDetected)
+	assert.Equal(t, "orphaned: controller owner Deployment/web was deleted", result.Reason)
+
+	// Dangling reference to the deleted owner, e.g. restored from a backup
+	result, err = d.Detect(context.Background(), newOrphanTestChild("deploy-uid", "deploy-uid"), "alice", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "orphaned: controller owner Deployment/web was deleted", result.Reason)
+
+	// Adopted by a new owner that does not exist: the new owner is resolved
+	_, err = d.Detect(context.Background(), newOrphanTestChild("new-uid", "deploy-uid"), "alice", nil)
+	require.Error(t, err)
+	assert.Equal(t, ErrorParentNotFound, ClassOf(err))
+}
@@ -0,0 +1,68 @@
+package drift
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kausality-io/kausality/pkg/controller"
+)
+
+// OwnerReferenceViolation is why an owner reference added to an object is
+// not trusted.
+type OwnerReferenceViolation string
+
+const (
+	// OwnerNotFound is a reference to an owner that does not exist.
+	OwnerNotFound OwnerReferenceViolation = "OwnerNotFound"
+	// OwnerUIDMismatch is a reference to an owner with another UID, e.g.
+	// one that was deleted and recreated under the same name.
+	OwnerUIDMismatch OwnerReferenceViolation = "UIDMismatch"
+	// OwnerNotController is a reference added by another actor than the
+	// controller of the owner.
+	OwnerNotController OwnerReferenceViolation = "NotController"
+)
+
+// CheckOwnerReference checks an owner reference added to obj by actor: it
+// must resolve to an existing owner with the same UID, and actor must be the
+// controller of the owner, identified with classifier, or by user hash
+// tracking if nil. A reference whose controller cannot be determined is
+// trusted. It returns the violation and a description, or "" if the
+// reference is trusted. Errors other than ErrorParentNotFound are returned.
+func (d *Detector) CheckOwnerReference(ctx context.Context, obj client.Object, ref metav1.OwnerReference, actor Actor, classifier Classifier) (OwnerReferenceViolation, string, error) {
+	owner, err := d.resolver.ResolveOwner(ctx, obj, ref)
+	if ClassOf(err) == ErrorParentNotFound {
+		return OwnerNotFound, fmt.Sprintf("owner %s %s does not exist", ref.Kind, ref.Name), nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	if owner.GetUID() != ref.UID {
+		return OwnerUIDMismatch, fmt.Sprintf("owner %s %s has UID %s, not %s", ref.Kind, ref.Name, owner.GetUID(), ref.UID), nil
+	}
+
+	isController, canDetermine := classify(classifier, ParentStateFromObject(owner), actor)
+	if canDetermine && !isController {
+		return OwnerNotController, fmt.Sprintf("added by different actor than the controller of %s %s (hash %s)",
+			ref.Kind, ref.Name, controller.HashUsername(actor.Username)), nil
+	}
+	return "", "", nil
+}
+
+// AddedOwner returns the controller owner reference of newObj that oldObj
+// does not carry, or nil if the controller owner is unchanged. oldObj is nil
+// for creates.
+func AddedOwner(oldObj, newObj client.Object) *metav1.OwnerReference {
+	ref := findControllerOwnerRef(newObj.GetOwnerReferences())
+	if ref == nil || oldObj == nil {
+		return ref
+	}
+	for _, r := range oldObj.GetOwnerReferences() {
+		if r.UID == ref.UID {
+			return nil
+		}
+	}
+	return ref
+}
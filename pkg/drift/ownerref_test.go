@@ -0,0 +1,74 @@
+package drift
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kausality-io/kausality/pkg/controller"
+)
+
+func TestAddedOwner(t *testing.T) {
+	owned := newOrphanTestChild("deploy-uid", "")
+	unowned := newOrphanTestChild("", "")
+
+	ref := AddedOwner(nil, owned)
+	require.NotNil(t, ref, "created with owner")
+	assert.Equal(t, types.UID("deploy-uid"), ref.UID)
+	require.NotNil(t, AddedOwner(unowned, owned), "adopted")
+	require.NotNil(t, AddedOwner(newOrphanTestChild("other-uid", ""), owned), "owner replaced")
+	assert.Nil(t, AddedOwner(owned, owned), "owner unchanged")
+	assert.Nil(t, AddedOwner(owned, unowned), "released")
+	assert.Nil(t, AddedOwner(nil, unowned))
+}
+
+func TestCheckOwnerReference(t *testing.T) {
+	deploy := &unstructured.Unstructured{}
+	deploy.SetAPIVersion("apps/v1")
+	deploy.SetKind("Deployment")
+	deploy.SetNamespace("default")
+	deploy.SetName("web")
+	deploy.SetUID("deploy-uid")
+	deploy.SetAnnotations(map[string]string{controller.ControllersAnnotation: controller.HashUsername("deployment-controller")})
+	d := NewDetector(fake.NewClientBuilder().WithScheme(runtime.NewScheme()).WithObjects(deploy).Build())
+
+	ref := func(name string, uid types.UID) metav1.OwnerReference {
+		return metav1.OwnerReference{APIVersion: "apps/v1", Kind: "Deployment", Name: name, UID: uid, Controller: ptr.To(true)}
+	}
+	tests := []struct {
+		name      string
+		ref       metav1.OwnerReference
+		actor     Actor
+		violation OwnerReferenceViolation
+	}{
+		{name: "controller", ref: ref("web", "deploy-uid"), actor: Actor{Username: "deployment-controller"}},
+		{name: "other actor", ref: ref("web", "deploy-uid"), actor: Actor{Username: "mallory"}, violation: OwnerNotController},
+		{name: "missing owner", ref: ref("api", "api-uid"), actor: Actor{Username: "deployment-controller"}, violation: OwnerNotFound},
+		{name: "other UID", ref: ref("web", "old-uid"), actor: Actor{Username: "deployment-controller"}, violation: OwnerUIDMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violation, msg, err := d.CheckOwnerReference(context.Background(), newOrphanTestChild("", ""), tt.ref, tt.actor, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.violation, violation, msg)
+		})
+	}
+
+	// Without recorded controllers and with several updaters, the controller
+	// cannot be determined: the reference is trusted
+	deploy.SetAnnotations(nil)
+	d = NewDetector(fake.NewClientBuilder().WithScheme(runtime.NewScheme()).WithObjects(deploy).Build())
+	violation, _, err := d.CheckOwnerReference(context.Background(), newOrphanTestChild("", ""), ref("web", "deploy-uid"),
+		Actor{Username: "mallory", ChildUpdaters: []string{controller.HashUsername("alice"), controller.HashUsername("mallory")}}, nil)
+	require.NoError(t, err)
+	assert.Empty(t, violation)
+}
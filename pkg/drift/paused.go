@@ -0,0 +1,56 @@
+package drift
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// CrossplanePausedAnnotation pauses the reconciliation of Crossplane
+// composite and managed resources when set to "true".
+const CrossplanePausedAnnotation = "crossplane.io/paused"
+
+// fluxGroupSuffix is the API group suffix of Flux kinds, which are suspended
+// with spec.suspend.
+const fluxGroupSuffix = ".toolkit.fluxcd.io"
+
+// PausedReason returns why the controller of obj does not reconcile it, or ""
+// if it does:
+//
+//   - Deployments with spec.paused
+//   - Crossplane resources with the crossplane.io/paused annotation
+//   - Flux resources with spec.suspend
+func PausedReason(obj *unstructured.Unstructured) string {
+	gvk := obj.GroupVersionKind()
+	if gvk.Group == "apps" && gvk.Kind == "Deployment" {
+		if paused, _, _ := unstructured.NestedBool(obj.Object, "spec", "paused"); paused {
+			return "spec.paused"
+		}
+	}
+	if obj.GetAnnotations()[CrossplanePausedAnnotation] == "true" {
+		return CrossplanePausedAnnotation + " annotation"
+	}
+	if strings.HasSuffix(gvk.Group, fluxGroupSuffix) {
+		if suspended, _, _ := unstructured.NestedBool(obj.Object, "spec", "suspend"); suspended {
+			return "spec.suspend"
+		}
+	}
+	return ""
+}
+
+// checkPaused treats mutations of children of a paused parent as drift: its
+// controller does not reconcile, so nobody is expected to change them. Only
+// the controller applying a new parent generation is expected, e.g. scaling
+// a paused Deployment.
+func checkPaused(result *DriftResult, parentState *ParentState, isController bool) *DriftResult {
+	result.Allowed = true
+	if isController && parentState.Generation != parentState.ObservedGeneration {
+		result.Reason = fmt.Sprintf("expected change: paused parent generation (%d) != observedGeneration (%d)",
+			parentState.Generation, parentState.ObservedGeneration)
+		return result
+	}
+	result.DriftDetected = true
+	result.Reason = fmt.Sprintf("drift detected: parent is paused (%s)", parentState.Paused)
+	return result
+}
@@ -0,0 +1,101 @@
+package drift
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kausality-io/kausality/pkg/controller"
+)
+
+func newPausable(apiVersion, kind string, fields map[string]interface{}, annotations map[string]string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: fields}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace("default")
+	obj.SetName("app")
+	obj.SetAnnotations(annotations)
+	return obj
+}
+
+func TestPausedReason(t *testing.T) {
+	paused := func() map[string]interface{} {
+		return map[string]interface{}{"spec": map[string]interface{}{"paused": true}}
+	}
+	suspended := func() map[string]interface{} {
+		return map[string]interface{}{"spec": map[string]interface{}{"suspend": true}}
+	}
+
+	tests := []struct {
+		name   string
+		obj    *unstructured.Unstructured
+		expect string
+	}{
+		{name: "paused Deployment", obj: newPausable("apps/v1", "Deployment", paused(), nil), expect: "spec.paused"},
+		{name: "running Deployment", obj: newPausable("apps/v1", "Deployment", map[string]interface{}{}, nil)},
+		{name: "spec.paused of other kinds", obj: newPausable("example.org/v1", "App", paused(), nil)},
+		{
+			name:   "paused Crossplane resource",
+			obj:    newPausable("rds.aws.crossplane.io/v1beta1", "DBInstance", map[string]interface{}{}, map[string]string{CrossplanePausedAnnotation: "true"}),
+			expect: "crossplane.io/paused annotation",
+		},
+		{
+			name: "unpaused Crossplane resource",
+			obj:  newPausable("rds.aws.crossplane.io/v1beta1", "DBInstance", map[string]interface{}{}, map[string]string{CrossplanePausedAnnotation: "false"}),
+		},
+		{name: "suspended Flux Kustomization", obj: newPausable("kustomize.toolkit.fluxcd.io/v1", "Kustomization", suspended(), nil), expect: "spec.suspend"},
+		{name: "spec.suspend of other kinds", obj: newPausable("batch/v1", "CronJob", suspended(), nil)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expect, PausedReason(tt.obj))
+		})
+	}
+}
+
+func TestDetect_PausedParent(t *testing.T) {
+	deploymentController := "system:serviceaccount:kube-system:deployment-controller"
+	newDeployment := func(paused bool, generation int64) *unstructured.Unstructured {
+		d := newPausable("apps/v1", "Deployment", map[string]interface{}{
+			"spec":   map[string]interface{}{"paused": paused},
+			"status": map[string]interface{}{"observedGeneration": int64(1)},
+		}, map[string]string{
+			controller.PhaseAnnotation:       controller.PhaseValueInitialized,
+			controller.ControllersAnnotation: controller.HashUsername(deploymentController),
+		})
+		d.SetUID("app-uid")
+		d.SetGeneration(generation)
+		return d
+	}
+	rs := newPausable("apps/v1", "ReplicaSet", map[string]interface{}{}, nil)
+	rs.SetName("app-7d4b9")
+	rs.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "app", UID: "app-uid", Controller: ptr.To(true)}})
+
+	tests := []struct {
+		name      string
+		parent    *unstructured.Unstructured
+		user      string
+		wantDrift bool
+	}{
+		{name: "other actor while paused", parent: newDeployment(true, 1), user: "alice", wantDrift: true},
+		{name: "other actor while running", parent: newDeployment(false, 1), user: "alice"},
+		{name: "controller while paused", parent: newDeployment(true, 1), user: deploymentController, wantDrift: true},
+		{name: "controller applying new generation while paused", parent: newDeployment(true, 2), user: deploymentController},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDetector(fake.NewClientBuilder().WithRuntimeObjects(tt.parent).Build())
+			result, err := d.Detect(context.Background(), rs, tt.user, []string{controller.HashUsername(deploymentController)})
+			require.NoError(t, err)
+			assert.True(t, result.Allowed)
+			assert.Equal(t, tt.wantDrift, result.DriftDetected, result.Reason)
+		})
+	}
+}
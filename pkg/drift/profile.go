@@ -0,0 +1,223 @@
+package drift
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Profile describes a Deployment-like parent kind whose controller keeps
+// changing its children after observing a new generation, e.g. Argo Rollouts
+// scaling ReplicaSets step by step during a canary. While such a parent is
+// progressing, changes by its controller are expected even though
+// generation == observedGeneration.
+type Profile struct {
+	// Group and Kind of the parent, e.g. "argoproj.io" and "Rollout".
+	Group string
+	Kind  string
+	// PhasePath is the dot-separated path of a status field, e.g. ".status.phase".
+	PhasePath string
+	// ProgressingPhases are the values of PhasePath while the parent progresses.
+	ProgressingPhases []string
+	// RevisionPaths are pairs of paths whose values differ while the parent
+	// progresses from one revision to the next, e.g. the current and the
+	// stable pod template hash, or the number of updated and desired pods.
+	// Missing numbers are zero, missing strings empty.
+	RevisionPaths [][2]string
+	// DoneConditions are condition types of which one is True once the
+	// parent is done, e.g. "Complete" and "Failed" of a Job. Until then, the
+	// parent progresses.
+	DoneConditions []string
+	// ProgressingConditions are condition types that are True while the
+	// parent progresses, e.g. "Reconciling" of Flux Kustomizations.
+	ProgressingConditions []string
+	// ChildAnnotations mark children the controller changes independent of
+	// the parent's generation, e.g. ReplicaSets of a previous revision with a
+	// scale-down deadline, or Jobs created on schedule.
+	ChildAnnotations []string
+	// ServiceAccountPath is the path of the name of a ServiceAccount in the
+	// parent's namespace that the controller impersonates to apply children,
+	// e.g. ".spec.serviceAccountName" of Flux Kustomizations. Changes by it
+	// are the controller's.
+	ServiceAccountPath string
+	// DefaultServiceAccount is impersonated if ServiceAccountPath is unset.
+	DefaultServiceAccount string
+	// Refresh describes periodic rewrites of children by the controller
+	// while the parent is stable, nil if there are none.
+	Refresh *RefreshProfile
+}
+
+// CronJobScheduledTimestampAnnotation is set by the CronJob controller on
+// Jobs it creates on schedule, to the RFC 3339 schedule time.
+const CronJobScheduledTimestampAnnotation = "batch.kubernetes.io/cronjob-scheduled-timestamp"
+
+// ArgoRolloutsProfile describes Argo Rollouts. During canary and blueGreen
+// steps, including paused analysis and aborts, the current pod template hash
+// differs from the stable one. After promotion, the previous ReplicaSet is
+// scaled down once its scale-down deadline passes.
+var ArgoRolloutsProfile = Profile{
+	Group:             "argoproj.io",
+	Kind:              "Rollout",
+	PhasePath:         ".status.phase",
+	ProgressingPhases: []string{"Progressing", "Paused"},
+	RevisionPaths:     [][2]string{{".status.currentPodHash", ".status.stableRS"}},
+	ChildAnnotations:  []string{"argo-rollouts.argoproj.io/scale-down-deadline"},
+}
+
+// StatefulSetProfile describes StatefulSets. The controller updates pods in
+// reverse ordinal order, down to the partition of a staged rollout, while
+// the current revision differs from the update revision.
+var StatefulSetProfile = Profile{
+	Group:         "apps",
+	Kind:          "StatefulSet",
+	RevisionPaths: [][2]string{{".status.currentRevision", ".status.updateRevision"}},
+}
+
+// DaemonSetProfile describes DaemonSets. The controller replaces pods node by
+// node, surging a new pod before deleting the old one with maxSurge, until
+// all scheduled pods are updated and available.
+var DaemonSetProfile = Profile{
+	Group: "apps",
+	Kind:  "DaemonSet",
+	RevisionPaths: [][2]string{
+		{".status.updatedNumberScheduled", ".status.desiredNumberScheduled"},
+		{".status.numberAvailable", ".status.desiredNumberScheduled"},
+	},
+}
+
+// CronJobProfile describes CronJobs. They do not change when the controller
+// creates a Job on schedule, so scheduled Jobs are changed independent of the
+// CronJob's generation, including their deletion beyond the history limit.
+var CronJobProfile = Profile{
+	Group:            "batch",
+	Kind:             "CronJob",
+	ChildAnnotations: []string{CronJobScheduledTimestampAnnotation},
+}
+
+// JobProfile describes Jobs. The controller creates and updates pods until
+// the Job completes or fails, independent of its generation.
+var JobProfile = Profile{
+	Group:          "batch",
+	Kind:           "Job",
+	DoneConditions: []string{"Complete", "Failed"},
+}
+
+// DefaultProfiles are the profiles built into every ParentResolver.
+var DefaultProfiles = []Profile{ArgoRolloutsProfile, StatefulSetProfile, DaemonSetProfile, CronJobProfile, JobProfile, ExternalSecretsProfile, SealedSecretsProfile}
+
+// Matches returns true if the profile describes parents of the given kind.
+func (p *Profile) Matches(gk schema.GroupKind) bool {
+	return p.Group == gk.Group && p.Kind == gk.Kind
+}
+
+// Progressing returns why the parent is progressing for the child, or ""
+// if it is not.
+func (p *Profile) Progressing(parent *unstructured.Unstructured, child client.Object) string {
+	if p.PhasePath != "" {
+		if phase := nestedString(parent, p.PhasePath); phase != "" && slices.Contains(p.ProgressingPhases, phase) {
+			return fmt.Sprintf("%s is %s", p.PhasePath, phase)
+		}
+	}
+	for _, paths := range p.RevisionPaths {
+		a, b := nestedValue(parent, paths[0]), nestedValue(parent, paths[1])
+		if a != b {
+			return fmt.Sprintf("%s %s differs from %s %s", paths[0], orUnset(a), paths[1], orUnset(b))
+		}
+	}
+	if condition := trueCondition(parent, p.ProgressingConditions); condition != "" {
+		return fmt.Sprintf("condition %s is True", condition)
+	}
+	if len(p.DoneConditions) > 0 && trueCondition(parent, p.DoneConditions) == "" {
+		return fmt.Sprintf("none of the conditions %s is True", strings.Join(p.DoneConditions, ", "))
+	}
+	if child != nil {
+		for _, key := range p.ChildAnnotations {
+			if _, ok := child.GetAnnotations()[key]; ok {
+				return fmt.Sprintf("child is annotated %s", key)
+			}
+		}
+	}
+	return ""
+}
+
+// Appliers returns the user names the controller impersonates to apply the
+// parent's children, see ServiceAccountPath.
+func (p *Profile) Appliers(parent *unstructured.Unstructured) []string {
+	if p.ServiceAccountPath == "" || parent.GetNamespace() == "" {
+		return nil
+	}
+	name := nestedString(parent, p.ServiceAccountPath)
+	if name == "" {
+		name = p.DefaultServiceAccount
+	}
+	if name == "" {
+		return nil
+	}
+	return []string{"system:serviceaccount:" + parent.GetNamespace() + ":" + name}
+}
+
+// trueCondition returns the first of the condition types that is True on the
+// parent, or "".
+func trueCondition(parent *unstructured.Unstructured, types []string) string {
+	if len(types) == 0 {
+		return ""
+	}
+	status, _, _ := unstructured.NestedMap(parent.Object, "status")
+	for _, c := range ExtractConditions(status) {
+		if c.Status == metav1.ConditionTrue && slices.Contains(types, c.Type) {
+			return c.Type
+		}
+	}
+	return ""
+}
+
+// nestedString returns the string at a dot-separated path, or "" if there is none.
+func nestedString(obj *unstructured.Unstructured, path string) string {
+	s, _, _ := unstructured.NestedString(obj.Object, fieldsOf(path)...)
+	return s
+}
+
+// nestedValue returns the quoted string or the number at a dot-separated
+// path. Missing values compare equal to "" and 0.
+func nestedValue(obj *unstructured.Unstructured, path string) string {
+	v, _, _ := unstructured.NestedFieldNoCopy(obj.Object, fieldsOf(path)...)
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		if v == "" {
+			return ""
+		}
+		return strconv.Quote(v)
+	case int64:
+		if v == 0 {
+			return ""
+		}
+		return strconv.FormatInt(v, 10)
+	case float64:
+		if v == 0 {
+			return ""
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// orUnset renders a missing value.
+func orUnset(v string) string {
+	if v == "" {
+		return "unset"
+	}
+	return v
+}
+
+// fieldsOf splits a dot-separated path like ".status.phase".
+func fieldsOf(path string) []string {
+	return strings.Split(strings.TrimPrefix(path, "."), ".")
+}
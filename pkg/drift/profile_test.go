@@ -0,0 +1,236 @@
+package drift
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kausality-io/kausality/pkg/controller"
+)
+
+// newRollout returns an Argo Rollout with generation == observedGeneration.
+func newRollout(status map[string]interface{}) *unstructured.Unstructured {
+	r := &unstructured.Unstructured{Object: map[string]interface{}{"status": status}}
+	r.SetAPIVersion("argoproj.io/v1alpha1")
+	r.SetKind("Rollout")
+	r.SetNamespace("default")
+	r.SetName("web")
+	r.SetUID("rollout-uid")
+	r.SetGeneration(3)
+	r.SetAnnotations(map[string]string{
+		controller.ObservedGenerationAnnotation: "3",
+		controller.PhaseAnnotation:              controller.PhaseValueInitialized,
+	})
+	return r
+}
+
+func TestProfile_Progressing(t *testing.T) {
+	scaleDown := &unstructured.Unstructured{}
+	scaleDown.SetAnnotations(map[string]string{"argo-rollouts.argoproj.io/scale-down-deadline": "2026-01-24T10:30:00Z"})
+
+	tests := []struct {
+		name   string
+		status map[string]interface{}
+		child  *unstructured.Unstructured
+		want   string
+	}{
+		{
+			name:   "healthy",
+			status: map[string]interface{}{"phase": "Healthy", "currentPodHash": "abc", "stableRS": "abc"},
+		},
+		{
+			name:   "paused canary step",
+			status: map[string]interface{}{"phase": "Paused", "currentPodHash": "def", "stableRS": "abc"},
+			want:   ".status.phase is Paused",
+		},
+		{
+			name:   "aborted canary",
+			status: map[string]interface{}{"phase": "Degraded", "currentPodHash": "def", "stableRS": "abc"},
+			want:   `.status.currentPodHash "def" differs from .status.stableRS "abc"`,
+		},
+		{
+			name:   "no stable revision yet",
+			status: map[string]interface{}{"phase": "Healthy", "currentPodHash": "abc"},
+			want:   `.status.currentPodHash "abc" differs from .status.stableRS unset`,
+		},
+		{
+			name:   "previous revision scaled down after promotion",
+			status: map[string]interface{}{"phase": "Healthy", "currentPodHash": "abc", "stableRS": "abc"},
+			child:  scaleDown,
+			want:   "child is annotated argo-rollouts.argoproj.io/scale-down-deadline",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			child := tt.child
+			if child == nil {
+				child = &unstructured.Unstructured{}
+			}
+			assert.Equal(t, tt.want, ArgoRolloutsProfile.Progressing(newRollout(tt.status), child))
+		})
+	}
+}
+
+func TestProfile_ProgressingWorkloads(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile Profile
+		status  map[string]interface{}
+		want    string
+	}{
+		{
+			name:    "StatefulSet rolled out",
+			profile: StatefulSetProfile,
+			status:  map[string]interface{}{"currentRevision": "web-7d4b9", "updateRevision": "web-7d4b9", "replicas": int64(3), "updatedReplicas": int64(3)},
+		},
+		{
+			name:    "StatefulSet partitioned rollout",
+			profile: StatefulSetProfile,
+			status:  map[string]interface{}{"currentRevision": "web-7d4b9", "updateRevision": "web-5f6c8", "replicas": int64(3), "updatedReplicas": int64(1)},
+			want:    `.status.currentRevision "web-7d4b9" differs from .status.updateRevision "web-5f6c8"`,
+		},
+		{
+			name:    "DaemonSet rolled out",
+			profile: DaemonSetProfile,
+			status:  map[string]interface{}{"desiredNumberScheduled": int64(3), "updatedNumberScheduled": int64(3), "numberAvailable": int64(3)},
+		},
+		{
+			name:    "DaemonSet rollout started",
+			profile: DaemonSetProfile,
+			status:  map[string]interface{}{"desiredNumberScheduled": int64(3), "numberAvailable": int64(3)},
+			want:    ".status.updatedNumberScheduled unset differs from .status.desiredNumberScheduled 3",
+		},
+		{
+			name:    "DaemonSet surge pod not yet available",
+			profile: DaemonSetProfile,
+			status:  map[string]interface{}{"desiredNumberScheduled": int64(3), "updatedNumberScheduled": int64(3), "numberAvailable": int64(2)},
+			want:    ".status.numberAvailable 2 differs from .status.desiredNumberScheduled 3",
+		},
+		{
+			name:    "Job running",
+			profile: JobProfile,
+			status:  map[string]interface{}{"active": int64(1)},
+			want:    "none of the conditions Complete, Failed is True",
+		},
+		{
+			name:    "Job complete",
+			profile: JobProfile,
+			status: map[string]interface{}{"conditions": []interface{}{
+				map[string]interface{}{"type": "Complete", "status": "True"},
+			}},
+		},
+		{
+			name:    "CronJob",
+			profile: CronJobProfile,
+			status:  map[string]interface{}{"lastScheduleTime": "2026-01-24T10:00:00Z"},
+		},
+		{
+			name:    "DaemonSet without nodes",
+			profile: DaemonSetProfile,
+			status:  map[string]interface{}{"desiredNumberScheduled": int64(0)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent := &unstructured.Unstructured{Object: map[string]interface{}{"status": tt.status}}
+			assert.Equal(t, tt.want, tt.profile.Progressing(parent, &unstructured.Unstructured{}))
+		})
+	}
+}
+
+func TestProfile_ScheduledJob(t *testing.T) {
+	job := &unstructured.Unstructured{}
+	job.SetAnnotations(map[string]string{CronJobScheduledTimestampAnnotation: "2026-01-24T10:00:00Z"})
+	cronJob := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	assert.Equal(t, "child is annotated "+CronJobScheduledTimestampAnnotation, CronJobProfile.Progressing(cronJob, job))
+}
+
+func TestProfile_Matches(t *testing.T) {
+	assert.True(t, ArgoRolloutsProfile.Matches(schema.GroupKind{Group: "argoproj.io", Kind: "Rollout"}))
+	assert.False(t, ArgoRolloutsProfile.Matches(schema.GroupKind{Group: "apps", Kind: "Deployment"}))
+	assert.True(t, StatefulSetProfile.Matches(schema.GroupKind{Group: "apps", Kind: "StatefulSet"}))
+}
+
+func TestDetect_Profile(t *testing.T) {
+	user := "system:serviceaccount:argo-rollouts:argo-rollouts"
+	trueVal := true
+
+	tests := []struct {
+		name      string
+		status    map[string]interface{}
+		wantDrift bool
+	}{
+		{
+			name:   "canary step",
+			status: map[string]interface{}{"phase": "Progressing", "currentPodHash": "def", "stableRS": "abc"},
+		},
+		{
+			name:      "healthy rollout",
+			status:    map[string]interface{}{"phase": "Healthy", "currentPodHash": "abc", "stableRS": "abc"},
+			wantDrift: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rollout := newRollout(tt.status)
+			rs := &unstructured.Unstructured{}
+			rs.SetAPIVersion("apps/v1")
+			rs.SetKind("ReplicaSet")
+			rs.SetNamespace("default")
+			rs.SetName("web-def")
+			rs.SetOwnerReferences([]metav1.OwnerReference{{
+				APIVersion: "argoproj.io/v1alpha1", Kind: "Rollout", Name: "web", UID: "rollout-uid", Controller: &trueVal,
+			}})
+
+			d := NewDetector(fake.NewClientBuilder().WithObjects(rollout).Build())
+			result, err := d.Detect(context.Background(), rs, user, []string{controller.HashUsername(user)})
+			require.NoError(t, err)
+			assert.True(t, result.Allowed)
+			assert.Equal(t, tt.wantDrift, result.DriftDetected, result.Reason)
+		})
+	}
+}
+
+func TestDetect_ConfiguredProfile(t *testing.T) {
+	user := "system:serviceaccount:flagger:flagger"
+	trueVal := true
+
+	canary := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{"phase": "Progressing"},
+	}}
+	canary.SetAPIVersion("flagger.app/v1beta1")
+	canary.SetKind("Canary")
+	canary.SetNamespace("default")
+	canary.SetName("web")
+	canary.SetGeneration(2)
+	canary.SetAnnotations(map[string]string{
+		controller.ObservedGenerationAnnotation: "2",
+		controller.PhaseAnnotation:              controller.PhaseValueInitialized,
+	})
+	deploy := &unstructured.Unstructured{}
+	deploy.SetAPIVersion("apps/v1")
+	deploy.SetKind("Deployment")
+	deploy.SetNamespace("default")
+	deploy.SetName("web-primary")
+	deploy.SetOwnerReferences([]metav1.OwnerReference{{
+		APIVersion: "flagger.app/v1beta1", Kind: "Canary", Name: "web", Controller: &trueVal,
+	}})
+
+	c := fake.NewClientBuilder().WithObjects(canary).Build()
+	result, err := NewDetector(c).Detect(context.Background(), deploy, user, []string{controller.HashUsername(user)})
+	require.NoError(t, err)
+	assert.True(t, result.DriftDetected, "without profile: %s", result.Reason)
+
+	profile := Profile{Group: "flagger.app", Kind: "Canary", PhasePath: ".status.phase", ProgressingPhases: []string{"Progressing"}}
+	result, err = NewDetectorWithOptions(c, WithProfiles(profile)).Detect(context.Background(), deploy, user, []string{controller.HashUsername(user)})
+	require.NoError(t, err)
+	assert.False(t, result.DriftDetected, result.Reason)
+	assert.Equal(t, "expected change: parent is progressing (.status.phase is Progressing)", result.Reason)
+}
@@ -0,0 +1,208 @@
+package drift
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kausality-io/kausality/api/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/controller"
+)
+
+// ApprovedSpecAnnotation is re-exported from api/v1alpha1.
+const ApprovedSpecAnnotation = v1alpha1.ApprovedSpecAnnotation
+
+const (
+	// QuarantinedReason is the reason of events on reverted children.
+	QuarantinedReason = "Quarantined"
+	// quarantineWorkers is the number of concurrent workers reverting children.
+	quarantineWorkers = 2
+	// quarantineMaxRetries is the number of attempts before a revert is dropped.
+	quarantineMaxRetries = 10
+)
+
+// Quarantiner reverts children whose drift was denied to their approved spec.
+type Quarantiner interface {
+	Quarantine(ctx context.Context, child client.Object)
+}
+
+var _ Quarantiner = &Reverter{}
+
+// Reverter reverts quarantined children to the spec recorded in their
+// kausality.io/approved-spec annotation, and emits a Quarantined event on
+// them. Without it, a manual change stays in place as long as the drift of
+// the controller correcting it is denied. Children are queued and reverted
+// by workers with updates guarded by resourceVersion, under the field manager
+// controller.FieldManager. Reverter does not need leader election: reverting
+// is idempotent.
+type Reverter struct {
+	client   client.Client
+	recorder events.EventRecorder
+	log      logr.Logger
+	queue    workqueue.TypedRateLimitingInterface[quarantineKey]
+}
+
+// quarantineKey identifies a child across kinds.
+type quarantineKey struct {
+	GVK       schema.GroupVersionKind
+	Namespace string
+	Name      string
+}
+
+// NewReverter creates a Reverter emitting events with recorder. Add it to a
+// manager to start its workers.
+func NewReverter(c client.Client, recorder events.EventRecorder, log logr.Logger) *Reverter {
+	return &Reverter{
+		client:   c,
+		recorder: recorder,
+		log:      log.WithName("quarantine"),
+		queue: workqueue.NewTypedRateLimitingQueueWithConfig(
+			workqueue.DefaultTypedControllerRateLimiter[quarantineKey](),
+			workqueue.TypedRateLimitingQueueConfig[quarantineKey]{Name: "quarantine"},
+		),
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Every
+// replica reverts the children it quarantines.
+func (r *Reverter) NeedLeaderElection() bool {
+	return false
+}
+
+// Start runs the workers until the context is cancelled.
+func (r *Reverter) Start(ctx context.Context) error {
+	r.log.Info("starting quarantine reverter", "workers", quarantineWorkers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < quarantineWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r.processNext(ctx) {
+			}
+		}()
+	}
+
+	<-ctx.Done()
+	r.queue.ShutDown()
+	wg.Wait()
+	return nil
+}
+
+// Quarantine enqueues reverting the child to its approved spec.
+func (r *Reverter) Quarantine(ctx context.Context, child client.Object) {
+	gvk, err := r.client.GroupVersionKindFor(child)
+	if err != nil {
+		r.log.Error(err, "failed to determine kind", "namespace", child.GetNamespace(), "name", child.GetName())
+		return
+	}
+	r.queue.Add(quarantineKey{GVK: gvk, Namespace: child.GetNamespace(), Name: child.GetName()})
+}
+
+// Len returns the number of children waiting to be reverted.
+func (r *Reverter) Len() int {
+	return r.queue.Len()
+}
+
+func (r *Reverter) processNext(ctx context.Context) bool {
+	key, shutdown := r.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer r.queue.Done(key)
+
+	log := r.log.WithValues("kind", key.GVK.Kind, "namespace", key.Namespace, "name", key.Name)
+	err := r.revert(ctx, key)
+	switch {
+	case err == nil:
+		r.queue.Forget(key)
+	case r.queue.NumRequeues(key) >= quarantineMaxRetries:
+		log.Error(err, "giving up reverting quarantined child", "attempts", quarantineMaxRetries)
+		r.queue.Forget(key)
+	default:
+		if apierrors.IsConflict(err) {
+			log.V(1).Info("conflict reverting quarantined child, retrying")
+		} else {
+			log.Error(err, "failed to revert quarantined child, retrying")
+		}
+		r.queue.AddRateLimited(key)
+	}
+	return true
+}
+
+// revert updates the child's spec to its approved spec, unless it has none
+// or already has it.
+func (r *Reverter) revert(ctx context.Context, key quarantineKey) error {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(key.GVK)
+	if err := r.client.Get(ctx, client.ObjectKey{Namespace: key.Namespace, Name: key.Name}, obj); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if !DivergesFromApprovedSpec(obj) {
+		r.log.V(1).Info("child has its approved spec or none, not reverting", "kind", key.GVK.Kind, "namespace", key.Namespace, "name", key.Name)
+		return nil
+	}
+
+	var approved interface{}
+	if err := utiljson.Unmarshal([]byte(obj.GetAnnotations()[ApprovedSpecAnnotation]), &approved); err != nil {
+		r.log.Error(err, "invalid approved spec, not reverting", "kind", key.GVK.Kind, "namespace", key.Namespace, "name", key.Name)
+		return nil
+	}
+	obj.Object["spec"] = approved
+	if err := r.client.Update(ctx, obj, client.FieldOwner(controller.FieldManager)); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	r.log.Info("reverted quarantined child to its approved spec", "kind", key.GVK.Kind, "namespace", key.Namespace, "name", key.Name)
+	if r.recorder != nil {
+		r.recorder.Eventf(obj, nil, corev1.EventTypeWarning, QuarantinedReason, "Revert",
+			"Reverted spec to the last approved spec of the controller after denying its drift")
+	}
+	return nil
+}
+
+// ApprovedSpec returns the JSON encoded spec of obj as recorded in
+// ApprovedSpecAnnotation, or "" if obj has no spec.
+func ApprovedSpec(obj *unstructured.Unstructured) (string, error) {
+	spec, found, err := unstructured.NestedFieldNoCopy(obj.Object, "spec")
+	if err != nil || !found {
+		return "", err
+	}
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode spec: %w", err)
+	}
+	return string(data), nil
+}
+
+// DivergesFromApprovedSpec returns true if obj records an approved spec
+// other than its spec, e.g. because someone else than its controller changed
+// it since.
+func DivergesFromApprovedSpec(obj *unstructured.Unstructured) bool {
+	approved, ok := obj.GetAnnotations()[ApprovedSpecAnnotation]
+	if !ok {
+		return false
+	}
+	current, err := ApprovedSpec(obj)
+	if err != nil {
+		return false
+	}
+	return current != approved
+}
@@ -0,0 +1,69 @@
+package drift
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func quarantinedReplicaSet(replicas int64, approved string) *unstructured.Unstructured {
+	obj := crossplaneObject(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "ReplicaSet"}, "default", "web-abc",
+		map[string]interface{}{"replicas": replicas})
+	if approved != "" {
+		obj.SetAnnotations(map[string]string{ApprovedSpecAnnotation: approved})
+	}
+	return obj
+}
+
+func TestDivergesFromApprovedSpec(t *testing.T) {
+	assert.False(t, DivergesFromApprovedSpec(quarantinedReplicaSet(3, "")), "no approved spec")
+	assert.False(t, DivergesFromApprovedSpec(quarantinedReplicaSet(1, `{"replicas":1}`)))
+	assert.True(t, DivergesFromApprovedSpec(quarantinedReplicaSet(3, `{"replicas":1}`)))
+
+	spec, err := ApprovedSpec(quarantinedReplicaSet(2, ""))
+	require.NoError(t, err)
+	assert.Equal(t, `{"replicas":2}`, spec)
+}
+
+func TestReverter(t *testing.T) {
+	ctx := context.Background()
+	tampered := quarantinedReplicaSet(3, `{"replicas":1}`)
+	unapproved := quarantinedReplicaSet(3, "")
+	unapproved.SetName("web-def")
+	c := fake.NewClientBuilder().WithScheme(runtime.NewScheme()).WithObjects(tampered, unapproved).Build()
+	recorder := events.NewFakeRecorder(10)
+	r := NewReverter(c, recorder, logr.Discard())
+
+	r.Quarantine(ctx, tampered)
+	r.Quarantine(ctx, tampered)
+	assert.Equal(t, 1, r.Len(), "quarantines of a child are merged")
+	require.True(t, r.processNext(ctx))
+
+	got := &unstructured.Unstructured{}
+	got.SetGroupVersionKind(tampered.GroupVersionKind())
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(tampered), got))
+	replicas, _, _ := unstructured.NestedInt64(got.Object, "spec", "replicas")
+	assert.Equal(t, int64(1), replicas)
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, QuarantinedReason)
+
+	// Children without approved spec, or reverted already, are left alone
+	r.Quarantine(ctx, unapproved)
+	require.True(t, r.processNext(ctx))
+	r.Quarantine(ctx, got)
+	require.True(t, r.processNext(ctx))
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(unapproved), got))
+	replicas, _, _ = unstructured.NestedInt64(got.Object, "spec", "replicas")
+	assert.Equal(t, int64(3), replicas)
+	assert.Empty(t, recorder.Events)
+}
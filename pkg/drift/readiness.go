@@ -0,0 +1,275 @@
+package drift
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// LifecycleSignal matches a status condition or a field of a parent.
+type LifecycleSignal struct {
+	// Condition is a condition type. The signal matches if the condition
+	// has Status, True by default, and one of Reasons if set.
+	Condition string
+	Status    metav1.ConditionStatus
+	Reasons   []string
+	// Path is the dot-separated path of a field, e.g. ".status.phase". The
+	// signal matches if its value is one of Values or, with EqualsPath, the
+	// value at EqualsPath. Missing numbers are zero, missing strings empty.
+	Path       string
+	Values     []string
+	EqualsPath string
+}
+
+// LifecycleMapping maps a parent kind to the signals of its lifecycle that
+// are authoritative for it, instead of the generic conditions checked by
+// the LifecycleDetector.
+type LifecycleMapping struct {
+	// Group and Kind of the parent. Both may be patterns of path.Match,
+	// e.g. "*.upbound.io" and "*".
+	Group string
+	Kind  string
+	// Ready signals, of which one matches once the parent is initialized.
+	Ready []LifecycleSignal
+	// Reconciling signals, of which one matches while the controller keeps
+	// changing children independent of the parent's generation. The parent
+	// progresses meanwhile, like with a Profile.
+	Reconciling []LifecycleSignal
+	// Deleting signals, of which one matches while the parent is being
+	// deleted, in addition to its deletionTimestamp.
+	Deleting []LifecycleSignal
+}
+
+// LifecycleSignals describe the signals of a LifecycleMapping matching a
+// parent, or are empty if none matches.
+type LifecycleSignals struct {
+	Ready       string
+	Reconciling string
+	Deleting    string
+}
+
+// Built-in lifecycle mappings.
+var (
+	// DeploymentLifecycle: a Deployment is ready once Available, and rolls
+	// out while Progressing with a reason other than NewReplicaSetAvailable.
+	DeploymentLifecycle = LifecycleMapping{
+		Group:       "apps",
+		Kind:        "Deployment",
+		Ready:       []LifecycleSignal{{Condition: "Available"}},
+		Reconciling: []LifecycleSignal{{Condition: "Progressing", Reasons: []string{"NewReplicaSetCreated", "FoundNewReplicaSet", "ReplicaSetUpdated"}}},
+	}
+	// StatefulSetLifecycle: StatefulSets have no conditions. A StatefulSet
+	// is ready once all its replicas are.
+	StatefulSetLifecycle = LifecycleMapping{
+		Group: "apps",
+		Kind:  "StatefulSet",
+		Ready: []LifecycleSignal{{Path: ".status.readyReplicas", EqualsPath: ".spec.replicas"}},
+	}
+	// CrossplaneLifecycle: Crossplane resources are ready with their Ready
+	// condition, which has reason Deleting while they are deleted.
+	CrossplaneLifecycle = LifecycleMapping{
+		Group:    "*.crossplane.io",
+		Kind:     "*",
+		Ready:    []LifecycleSignal{{Condition: "Ready"}},
+		Deleting: []LifecycleSignal{{Condition: "Ready", Status: metav1.ConditionFalse, Reasons: []string{"Deleting"}}},
+	}
+	// UpboundLifecycle describes the managed resources of Upbound providers
+	// like CrossplaneLifecycle.
+	UpboundLifecycle = LifecycleMapping{
+		Group:    "*.upbound.io",
+		Kind:     "*",
+		Ready:    CrossplaneLifecycle.Ready,
+		Deleting: CrossplaneLifecycle.Deleting,
+	}
+	// ClusterAPILifecycle: a Cluster API Cluster is ready with its Available
+	// (v1beta2) or Ready (v1beta1) condition, and is provisioned and deleted
+	// in phases.
+	ClusterAPILifecycle = LifecycleMapping{
+		Group:       "cluster.x-k8s.io",
+		Kind:        "Cluster",
+		Ready:       []LifecycleSignal{{Condition: "Available"}, {Condition: "Ready"}},
+		Reconciling: []LifecycleSignal{{Path: ".status.phase", Values: []string{"Pending", "Provisioning"}}},
+		Deleting:    []LifecycleSignal{{Path: ".status.phase", Values: []string{"Deleting"}}},
+	}
+	// FluxKustomizationLifecycle: Flux Kustomizations are ready with their
+	// Ready condition, and apply while Reconciling.
+	FluxKustomizationLifecycle = LifecycleMapping{
+		Group:       FluxKustomizationGVK.Group,
+		Kind:        FluxKustomizationGVK.Kind,
+		Ready:       []LifecycleSignal{{Condition: "Ready"}},
+		Reconciling: []LifecycleSignal{{Condition: "Reconciling"}},
+	}
+	// FluxHelmReleaseLifecycle describes HelmReleases like
+	// FluxKustomizationLifecycle.
+	FluxHelmReleaseLifecycle = LifecycleMapping{
+		Group:       FluxHelmReleaseGVK.Group,
+		Kind:        FluxHelmReleaseGVK.Kind,
+		Ready:       FluxKustomizationLifecycle.Ready,
+		Reconciling: FluxKustomizationLifecycle.Reconciling,
+	}
+	// ArgoRolloutLifecycle: an Argo Rollout is ready once Healthy.
+	ArgoRolloutLifecycle = LifecycleMapping{
+		Group:       "argoproj.io",
+		Kind:        "Rollout",
+		Ready:       []LifecycleSignal{{Path: ".status.phase", Values: []string{"Healthy"}}},
+		Reconciling: []LifecycleSignal{{Path: ".status.phase", Values: []string{"Progressing"}}},
+	}
+	// ArgoApplicationLifecycle: an Argo CD Application is ready once
+	// Healthy, and syncs while its operation is Running.
+	ArgoApplicationLifecycle = LifecycleMapping{
+		Group:       "argoproj.io",
+		Kind:        "Application",
+		Ready:       []LifecycleSignal{{Path: ".status.health.status", Values: []string{"Healthy"}}},
+		Reconciling: []LifecycleSignal{{Path: ".status.operationState.phase", Values: []string{"Running"}}},
+	}
+)
+
+// DefaultLifecycleMappings are the mappings built into every
+// LifecycleRegistry.
+var DefaultLifecycleMappings = []LifecycleMapping{
+	DeploymentLifecycle,
+	StatefulSetLifecycle,
+	CrossplaneLifecycle,
+	UpboundLifecycle,
+	ClusterAPILifecycle,
+	FluxKustomizationLifecycle,
+	FluxHelmReleaseLifecycle,
+	ArgoRolloutLifecycle,
+	ArgoApplicationLifecycle,
+}
+
+// LifecycleRegistry holds the lifecycle mappings of parent kinds: the
+// configured ones, which can be replaced at runtime with Update, taking
+// precedence over DefaultLifecycleMappings. It is safe for concurrent use.
+type LifecycleRegistry struct {
+	mu         sync.RWMutex
+	configured []LifecycleMapping
+}
+
+// NewLifecycleRegistry creates a LifecycleRegistry with the given mappings
+// in addition to DefaultLifecycleMappings.
+func NewLifecycleRegistry(mappings ...LifecycleMapping) *LifecycleRegistry {
+	return &LifecycleRegistry{configured: mappings}
+}
+
+// Update replaces the configured mappings.
+func (r *LifecycleRegistry) Update(mappings []LifecycleMapping) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.configured = mappings
+}
+
+// MappingFor returns the first configured or default mapping matching the
+// kind, or nil if there is none.
+func (r *LifecycleRegistry) MappingFor(gk schema.GroupKind) *LifecycleMapping {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, mappings := range [][]LifecycleMapping{r.configured, DefaultLifecycleMappings} {
+		for i := range mappings {
+			if mappings[i].Matches(gk) {
+				m := mappings[i]
+				return &m
+			}
+		}
+	}
+	return nil
+}
+
+// Signals returns the signals of the parent's mapping matching it, or nil
+// if its kind has no mapping or the registry is nil.
+func (r *LifecycleRegistry) Signals(parent *unstructured.Unstructured) *LifecycleSignals {
+	if r == nil {
+		return nil
+	}
+	m := r.MappingFor(parent.GroupVersionKind().GroupKind())
+	if m == nil {
+		return nil
+	}
+	return m.Signals(parent)
+}
+
+// Matches returns true if the mapping describes parents of the given kind.
+func (m *LifecycleMapping) Matches(gk schema.GroupKind) bool {
+	group, _ := path.Match(m.Group, gk.Group)
+	kind, _ := path.Match(m.Kind, gk.Kind)
+	return group && kind
+}
+
+// Signals returns the signals of the mapping matching the parent.
+func (m *LifecycleMapping) Signals(parent *unstructured.Unstructured) *LifecycleSignals {
+	return &LifecycleSignals{
+		Ready:       firstMatch(parent, m.Ready),
+		Reconciling: firstMatch(parent, m.Reconciling),
+		Deleting:    firstMatch(parent, m.Deleting),
+	}
+}
+
+// firstMatch describes the first of the signals matching the parent, or
+// returns "".
+func firstMatch(parent *unstructured.Unstructured, signals []LifecycleSignal) string {
+	for _, s := range signals {
+		if desc := s.match(parent); desc != "" {
+			return desc
+		}
+	}
+	return ""
+}
+
+// match describes the signal if it matches the parent, or returns "".
+func (s LifecycleSignal) match(parent *unstructured.Unstructured) string {
+	if s.Condition != "" {
+		want := s.Status
+		if want == "" {
+			want = metav1.ConditionTrue
+		}
+		status, _, _ := unstructured.NestedMap(parent.Object, "status")
+		for _, c := range ExtractConditions(status) {
+			if c.Type != s.Condition || c.Status != want {
+				continue
+			}
+			if len(s.Reasons) == 0 {
+				return fmt.Sprintf("condition %s is %s", c.Type, c.Status)
+			}
+			if slices.Contains(s.Reasons, c.Reason) {
+				return fmt.Sprintf("condition %s is %s (%s)", c.Type, c.Status, c.Reason)
+			}
+		}
+		return ""
+	}
+	if s.Path == "" {
+		return ""
+	}
+	if s.EqualsPath != "" {
+		if nestedValue(parent, s.Path) == nestedValue(parent, s.EqualsPath) {
+			return fmt.Sprintf("%s equals %s", s.Path, s.EqualsPath)
+		}
+		return ""
+	}
+	if value := fieldString(parent, s.Path); value != "" && slices.Contains(s.Values, value) {
+		return fmt.Sprintf("%s is %s", s.Path, value)
+	}
+	return ""
+}
+
+// fieldString returns the string, number or bool at a dot-separated path as
+// string, or "" if there is none.
+func fieldString(obj *unstructured.Unstructured, path string) string {
+	v, _, _ := unstructured.NestedFieldNoCopy(obj.Object, fieldsOf(path)...)
+	switch v := v.(type) {
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
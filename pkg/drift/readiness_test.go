@@ -0,0 +1,158 @@
+package drift
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kausality-io/kausality/pkg/controller"
+)
+
+// lifecycleParent returns a parent of the kind with the given spec and status.
+func lifecycleParent(gvk schema.GroupVersionKind, spec, status map[string]interface{}) *unstructured.Unstructured {
+	obj := crossplaneObject(gvk, "default", "parent", spec)
+	obj.Object["status"] = status
+	return obj
+}
+
+func TestLifecycleRegistry_Signals(t *testing.T) {
+	deployment := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	statefulSet := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "StatefulSet"}
+	bucket := schema.GroupVersionKind{Group: "s3.aws.m.upbound.io", Version: "v1beta1", Kind: "Bucket"}
+	cluster := schema.GroupVersionKind{Group: "cluster.x-k8s.io", Version: "v1beta1", Kind: "Cluster"}
+	application := schema.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: "Application"}
+
+	tests := []struct {
+		name   string
+		parent *unstructured.Unstructured
+		want   *LifecycleSignals
+	}{
+		{
+			name: "rolling out deployment",
+			parent: lifecycleParent(deployment, nil, map[string]interface{}{"conditions": []interface{}{
+				map[string]interface{}{"type": "Available", "status": "True"},
+				map[string]interface{}{"type": "Progressing", "status": "True", "reason": "ReplicaSetUpdated"},
+			}}),
+			want: &LifecycleSignals{Ready: "condition Available is True", Reconciling: "condition Progressing is True (ReplicaSetUpdated)"},
+		},
+		{
+			name: "rolled out deployment",
+			parent: lifecycleParent(deployment, nil, map[string]interface{}{"conditions": []interface{}{
+				map[string]interface{}{"type": "Progressing", "status": "True", "reason": "NewReplicaSetAvailable"},
+			}}),
+			want: &LifecycleSignals{},
+		},
+		{
+			name:   "ready statefulset",
+			parent: lifecycleParent(statefulSet, map[string]interface{}{"replicas": int64(3)}, map[string]interface{}{"readyReplicas": int64(3)}),
+			want:   &LifecycleSignals{Ready: ".status.readyReplicas equals .spec.replicas"},
+		},
+		{
+			name:   "starting statefulset",
+			parent: lifecycleParent(statefulSet, map[string]interface{}{"replicas": int64(3)}, map[string]interface{}{"readyReplicas": int64(1)}),
+			want:   &LifecycleSignals{},
+		},
+		{
+			name: "deleted managed resource",
+			parent: lifecycleParent(bucket, nil, map[string]interface{}{"conditions": []interface{}{
+				map[string]interface{}{"type": "Ready", "status": "False", "reason": "Deleting"},
+			}}),
+			want: &LifecycleSignals{Deleting: "condition Ready is False (Deleting)"},
+		},
+		{
+			name:   "provisioning cluster",
+			parent: lifecycleParent(cluster, nil, map[string]interface{}{"phase": "Provisioning"}),
+			want:   &LifecycleSignals{Reconciling: ".status.phase is Provisioning"},
+		},
+		{
+			name: "syncing application",
+			parent: lifecycleParent(application, nil, map[string]interface{}{
+				"health":         map[string]interface{}{"status": "Healthy"},
+				"operationState": map[string]interface{}{"phase": "Running"},
+			}),
+			want: &LifecycleSignals{Ready: ".status.health.status is Healthy", Reconciling: ".status.operationState.phase is Running"},
+		},
+		{
+			name:   "unmapped kind",
+			parent: lifecycleParent(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}, nil, nil),
+		},
+	}
+	r := NewLifecycleRegistry()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, r.Signals(tt.parent))
+		})
+	}
+
+	var nilRegistry *LifecycleRegistry
+	assert.Nil(t, nilRegistry.Signals(tests[0].parent))
+}
+
+func TestLifecycleRegistry_Update(t *testing.T) {
+	widgets := schema.GroupKind{Group: "widgets.example.com", Kind: "Widget"}
+	deployments := schema.GroupKind{Group: "apps", Kind: "Deployment"}
+
+	r := NewLifecycleRegistry()
+	assert.Nil(t, r.MappingFor(widgets))
+	assert.Equal(t, "Deployment", r.MappingFor(deployments).Kind)
+
+	// Configured mappings take precedence over the defaults
+	r.Update([]LifecycleMapping{
+		{Group: "*.example.com", Kind: "*", Ready: []LifecycleSignal{{Condition: "Synced"}}},
+		{Group: "apps", Kind: "Deployment", Ready: []LifecycleSignal{{Condition: "Ready"}}},
+	})
+	require.NotNil(t, r.MappingFor(widgets))
+	assert.Equal(t, "*.example.com", r.MappingFor(widgets).Group)
+	assert.Equal(t, []LifecycleSignal{{Condition: "Ready"}}, r.MappingFor(deployments).Ready)
+
+	r.Update(nil)
+	assert.Nil(t, r.MappingFor(widgets))
+}
+
+func TestLifecycleDetector_DetectPhaseWithSignals(t *testing.T) {
+	d := NewLifecycleDetector()
+	ready := []metav1.Condition{{Type: ConditionTypeReady, Status: metav1.ConditionTrue}}
+
+	// Mapped parents ignore the generic conditions
+	assert.Equal(t, PhaseInitializing, d.DetectPhase(&ParentState{Conditions: ready, Lifecycle: &LifecycleSignals{}}))
+	assert.Equal(t, PhaseInitialized, d.DetectPhase(&ParentState{Lifecycle: &LifecycleSignals{Ready: "condition Available is True"}}))
+	assert.Equal(t, PhaseInitialized, d.DetectPhase(&ParentState{IsInitialized: true, Lifecycle: &LifecycleSignals{}}))
+	assert.Equal(t, PhaseDeleting, d.DetectPhase(&ParentState{IsInitialized: true, Lifecycle: &LifecycleSignals{Deleting: "condition Ready is False (Deleting)"}}))
+}
+
+func TestDetect_ReconcilingSignal(t *testing.T) {
+	user := "system:serviceaccount:kube-system:deployment-controller"
+	trueVal := true
+
+	deploy := lifecycleParent(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, nil, map[string]interface{}{
+		"observedGeneration": int64(2),
+		"conditions": []interface{}{
+			map[string]interface{}{"type": "Available", "status": "True"},
+			map[string]interface{}{"type": "Progressing", "status": "True", "reason": "ReplicaSetUpdated"},
+		},
+	})
+	deploy.SetName("web")
+	deploy.SetGeneration(2)
+	deploy.SetAnnotations(map[string]string{controller.PhaseAnnotation: controller.PhaseValueInitialized})
+	rs := crossplaneObject(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "ReplicaSet"}, "default", "web-abc", nil)
+	rs.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Controller: &trueVal}})
+
+	c := fake.NewClientBuilder().WithObjects(deploy).Build()
+	result, err := NewDetector(c).Detect(context.Background(), rs, user, []string{controller.HashUsername(user)})
+	require.NoError(t, err)
+	assert.False(t, result.DriftDetected, result.Reason)
+	assert.Equal(t, "expected change: parent is progressing (condition Progressing is True (ReplicaSetUpdated))", result.Reason)
+
+	// Without the mapping, the rollout is drift
+	r := NewLifecycleRegistry(LifecycleMapping{Group: "apps", Kind: "Deployment", Ready: []LifecycleSignal{{Condition: "Available"}}})
+	result, err = NewDetectorWithOptions(c, WithLifecycleRegistry(r)).Detect(context.Background(), rs, user, []string{controller.HashUsername(user)})
+	require.NoError(t, err)
+	assert.True(t, result.DriftDetected, result.Reason)
+}
@@ -0,0 +1,148 @@
+package drift
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// RefreshProfile describes a controller rewriting children periodically
+// while its parent is stable, e.g. the External Secrets Operator syncing a
+// Secret from an external store every refreshInterval. Its refresh writes
+// are expected although generation == observedGeneration.
+type RefreshProfile struct {
+	// IntervalPath is the path of the refresh interval of the parent, a
+	// duration like "1h", e.g. ".spec.refreshInterval". An interval of zero
+	// disables refreshes.
+	IntervalPath string
+	// DefaultInterval applies if the parent has no interval at IntervalPath.
+	// Without interval, refreshes are expected at any time.
+	DefaultInterval time.Duration
+	// LastRefreshPath is the path of the RFC 3339 time of the last refresh,
+	// e.g. ".status.refreshTime". Refreshes are expected once the interval
+	// since the last refresh has passed, less a tenth for jitter. Without
+	// last refresh, they are expected at any time.
+	LastRefreshPath string
+	// ChildAnnotations mark the children the controller refreshes, of which
+	// one must be set. Empty for all children.
+	ChildAnnotations []string
+	// FieldManagers are the field managers of refresh writes, patterns of
+	// path.Match. Empty for any field manager.
+	FieldManagers []string
+}
+
+// RefreshState is the refresh schedule of a parent for a child, see
+// RefreshProfile.
+type RefreshState struct {
+	// Interval is the refresh interval, zero if refreshes are not timed.
+	Interval time.Duration
+	// LastRefresh is the time of the last refresh, zero if unknown.
+	LastRefresh time.Time
+	// FieldManagers are the field managers of refresh writes.
+	FieldManagers []string
+}
+
+// ExternalSecretsProfile describes ExternalSecrets of the External Secrets
+// Operator. It rewrites the target Secret, annotated with the hash of its
+// data, every spec.refreshInterval (default one hour, "0" disables
+// refreshes) as field manager externalsecrets.external-secrets.io/<name>.
+var ExternalSecretsProfile = Profile{
+	Group: "external-secrets.io",
+	Kind:  "ExternalSecret",
+	Refresh: &RefreshProfile{
+		IntervalPath:     ".spec.refreshInterval",
+		DefaultInterval:  time.Hour,
+		LastRefreshPath:  ".status.refreshTime",
+		ChildAnnotations: []string{"reconcile.external-secrets.io/data-hash"},
+		FieldManagers:    []string{"externalsecrets.external-secrets.io/*", "external-secrets"},
+	},
+}
+
+// SealedSecretsProfile describes SealedSecrets of Bitnami's sealed-secrets
+// controller. It unseals the Secret again on every resync of its informer,
+// without recording when, as field manager "controller", the name of its
+// binary.
+var SealedSecretsProfile = Profile{
+	Group: "bitnami.com",
+	Kind:  "SealedSecret",
+	Refresh: &RefreshProfile{
+		FieldManagers: []string{"controller"},
+	},
+}
+
+// State returns the refresh schedule of the parent for the child, or nil if
+// the child is not refreshed.
+func (r *RefreshProfile) State(parent *unstructured.Unstructured, child client.Object) *RefreshState {
+	if len(r.ChildAnnotations) > 0 {
+		if child == nil || !hasAnyAnnotation(child, r.ChildAnnotations) {
+			return nil
+		}
+	}
+	state := &RefreshState{Interval: r.DefaultInterval, FieldManagers: r.FieldManagers}
+	if r.IntervalPath != "" {
+		if value := nestedString(parent, r.IntervalPath); value != "" {
+			interval, err := time.ParseDuration(value)
+			if err != nil || interval == 0 {
+				return nil
+			}
+			state.Interval = interval
+		}
+	}
+	if r.LastRefreshPath != "" {
+		if last, err := time.Parse(time.RFC3339, nestedString(parent, r.LastRefreshPath)); err == nil {
+			state.LastRefresh = last
+		}
+	}
+	return state
+}
+
+// Expects returns why a change by the parent's controller as fieldManager
+// at now is a refresh, or "" if it is not.
+func (s *RefreshState) Expects(fieldManager string, now time.Time) string {
+	if s == nil || !matchesAny(s.FieldManagers, fieldManager) {
+		return ""
+	}
+	if s.Interval == 0 || s.LastRefresh.IsZero() {
+		return "refresh by " + fieldManagerOrUnknown(fieldManager)
+	}
+	due := s.LastRefresh.Add(s.Interval - s.Interval/10)
+	if now.Before(due) {
+		return ""
+	}
+	return fmt.Sprintf("refresh due every %s since %s", s.Interval, s.LastRefresh.UTC().Format(time.RFC3339))
+}
+
+// fieldManagerOrUnknown returns the field manager for reasons.
+func fieldManagerOrUnknown(fieldManager string) string {
+	if fieldManager == "" {
+		return "unknown field manager"
+	}
+	return fieldManager
+}
+
+// matchesAny returns whether value matches one of the patterns of
+// path.Match, or there are none.
+func matchesAny(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAnyAnnotation returns whether obj has one of the annotation keys.
+func hasAnyAnnotation(obj client.Object, keys []string) bool {
+	for _, key := range keys {
+		if _, ok := obj.GetAnnotations()[key]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,181 @@
+package drift
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kausality-io/kausality/pkg/controller"
+)
+
+const dataHashAnnotation = "reconcile.external-secrets.io/data-hash"
+
+// newExternalSecret returns an ExternalSecret with generation ==
+// observedGeneration.
+func newExternalSecret(spec, status map[string]interface{}) *unstructured.Unstructured {
+	es := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec, "status": status}}
+	es.SetAPIVersion("external-secrets.io/v1beta1")
+	es.SetKind("ExternalSecret")
+	es.SetNamespace("default")
+	es.SetName("db")
+	es.SetUID("es-uid")
+	es.SetGeneration(1)
+	es.SetAnnotations(map[string]string{
+		controller.ObservedGenerationAnnotation: "1",
+		controller.PhaseAnnotation:              controller.PhaseValueInitialized,
+	})
+	return es
+}
+
+// newTargetSecret returns the Secret of the ExternalSecret newExternalSecret.
+func newTargetSecret(annotations map[string]string) *unstructured.Unstructured {
+	trueVal := true
+	secret := &unstructured.Unstructured{}
+	secret.SetAPIVersion("v1")
+	secret.SetKind("Secret")
+	secret.SetNamespace("default")
+	secret.SetName("db")
+	secret.SetAnnotations(annotations)
+	secret.SetOwnerReferences([]metav1.OwnerReference{{
+		APIVersion: "external-secrets.io/v1beta1", Kind: "ExternalSecret", Name: "db", UID: "es-uid", Controller: &trueVal,
+	}})
+	return secret
+}
+
+func TestRefreshProfile_State(t *testing.T) {
+	refreshed := newTargetSecret(map[string]string{dataHashAnnotation: "abc"})
+	lastRefresh := "2026-10-17T10:00:00Z"
+
+	tests := []struct {
+		name   string
+		spec   map[string]interface{}
+		status map[string]interface{}
+		child  *unstructured.Unstructured
+		want   *RefreshState
+	}{
+		{
+			name:  "default interval",
+			child: refreshed,
+			want:  &RefreshState{Interval: time.Hour},
+		},
+		{
+			name:   "interval and last refresh",
+			spec:   map[string]interface{}{"refreshInterval": "15m"},
+			status: map[string]interface{}{"refreshTime": lastRefresh},
+			child:  refreshed,
+			want:   &RefreshState{Interval: 15 * time.Minute, LastRefresh: time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:  "refreshes disabled",
+			spec:  map[string]interface{}{"refreshInterval": "0"},
+			child: refreshed,
+		},
+		{
+			name:  "invalid interval",
+			spec:  map[string]interface{}{"refreshInterval": "hourly"},
+			child: refreshed,
+		},
+		{
+			name:  "child not refreshed",
+			child: newTargetSecret(nil),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExternalSecretsProfile.Refresh.State(newExternalSecret(tt.spec, tt.status), tt.child)
+			if tt.want == nil {
+				assert.Nil(t, got)
+				return
+			}
+			require.NotNil(t, got)
+			assert.Equal(t, tt.want.Interval, got.Interval)
+			assert.True(t, tt.want.LastRefresh.Equal(got.LastRefresh), "last refresh %s", got.LastRefresh)
+		})
+	}
+}
+
+func TestRefreshState_Expects(t *testing.T) {
+	now := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+	manager := "externalsecrets.external-secrets.io/db"
+	scheduled := &RefreshState{Interval: time.Hour, FieldManagers: ExternalSecretsProfile.Refresh.FieldManagers}
+
+	var none *RefreshState
+	assert.Empty(t, none.Expects(manager, now))
+
+	untimed := *scheduled
+	assert.Equal(t, "refresh by "+manager, untimed.Expects(manager, now))
+	assert.Empty(t, untimed.Expects("kubectl-edit", now), "other field managers")
+
+	scheduled.LastRefresh = now.Add(-55 * time.Minute)
+	assert.Equal(t, "refresh due every 1h0m0s since 2026-10-17T09:05:00Z", scheduled.Expects(manager, now), "within jitter")
+	scheduled.LastRefresh = now.Add(-2 * time.Hour)
+	assert.NotEmpty(t, scheduled.Expects(manager, now), "overdue")
+	scheduled.LastRefresh = now.Add(-10 * time.Minute)
+	assert.Empty(t, scheduled.Expects(manager, now), "before the refresh is due")
+
+	sealed := SealedSecretsProfile.Refresh.State(&unstructured.Unstructured{}, nil)
+	require.NotNil(t, sealed)
+	assert.Equal(t, "refresh by controller", sealed.Expects("controller", now))
+}
+
+func TestDetect_Refresh(t *testing.T) {
+	user := "system:serviceaccount:external-secrets:external-secrets"
+	recent := time.Now().Add(-10 * time.Minute).UTC().Format(time.RFC3339)
+	due := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+
+	tests := []struct {
+		name         string
+		lastRefresh  string
+		annotations  map[string]string
+		fieldManager string
+		wantDrift    bool
+	}{
+		{
+			name:         "refresh due",
+			lastRefresh:  due,
+			annotations:  map[string]string{dataHashAnnotation: "abc"},
+			fieldManager: "externalsecrets.external-secrets.io/db",
+		},
+		{
+			name:         "refresh not due",
+			lastRefresh:  recent,
+			annotations:  map[string]string{dataHashAnnotation: "abc"},
+			fieldManager: "externalsecrets.external-secrets.io/db",
+			wantDrift:    true,
+		},
+		{
+			name:         "other field manager",
+			lastRefresh:  due,
+			annotations:  map[string]string{dataHashAnnotation: "abc"},
+			fieldManager: "kubectl-edit",
+			wantDrift:    true,
+		},
+		{
+			name:         "child not refreshed",
+			lastRefresh:  due,
+			fieldManager: "externalsecrets.external-secrets.io/db",
+			wantDrift:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			es := newExternalSecret(map[string]interface{}{"refreshInterval": "1h"}, map[string]interface{}{"refreshTime": tt.lastRefresh})
+			d := NewDetector(fake.NewClientBuilder().WithObjects(es).Build())
+			actor := Actor{Username: user, FieldManager: tt.fieldManager, ChildUpdaters: []string{controller.HashUsername(user)}}
+			result, err := d.DetectActor(context.Background(), newTargetSecret(tt.annotations), actor, nil)
+			require.NoError(t, err)
+			assert.True(t, result.Allowed)
+			assert.Equal(t, tt.wantDrift, result.DriftDetected, result.Reason)
+			if !tt.wantDrift {
+				assert.Contains(t, result.Reason, "expected change: parent refreshes children")
+			}
+		})
+	}
+}
@@ -3,8 +3,12 @@ package drift
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strconv"
+	"time"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -15,44 +19,222 @@ import (
 
 // ParentResolver resolves the controller parent of a Kubernetes object.
 type ParentResolver struct {
-	client client.Client
+	client     client.Client
+	profiles   []Profile
+	aggregated AggregatedAPIs
+	edges      ParentEdges
+	lifecycle  *LifecycleRegistry
 }
 
-// NewParentResolver creates a new ParentResolver.
-func NewParentResolver(c client.Client) *ParentResolver {
-	return &ParentResolver{client: c}
+// AggregatedAPIs tells which group versions are served by aggregated API
+// servers instead of the kube-apiserver, e.g. a *policy.AggregatedIndex.
+type AggregatedAPIs interface {
+	IsAggregated(gv schema.GroupVersion) bool
+}
+
+// ParentEdges finds the parent of an object without controller owner
+// reference in another dependency graph, e.g. a *CrossplaneEdges.
+type ParentEdges interface {
+	// ParentOf returns the parent of obj, or nil if there is none.
+	ParentOf(ctx context.Context, obj client.Object) (*ParentRef, error)
+}
+
+// AggregatedParentTimeout bounds reads of parents served by aggregated API
+// servers. The kube-apiserver proxies them to a server that may be slow or
+// unavailable, which must not use up the timeout of the admission request.
+const AggregatedParentTimeout = 3 * time.Second
+
+// NewParentResolver creates a new ParentResolver. The profiles describe
+// Deployment-like parent kinds in addition to DefaultProfiles.
+func NewParentResolver(c client.Client, profiles ...Profile) *ParentResolver {
+	return &ParentResolver{client: c, profiles: slices.Concat(DefaultProfiles, profiles), lifecycle: NewLifecycleRegistry()}
+}
+
+// SetAggregatedAPIs configures which parents are served by aggregated API
+// servers. They are read with AggregatedParentTimeout, and assumed to be in
+// the namespace of their child if their scope cannot be discovered.
+func (r *ParentResolver) SetAggregatedAPIs(a AggregatedAPIs) {
+	r.aggregated = a
+}
+
+// SetParentEdges configures where to find the parents of objects without
+// controller owner reference, e.g. composed resources of Crossplane
+// composites in other namespaces.
+func (r *ParentResolver) SetParentEdges(e ParentEdges) {
+	r.edges = e
+}
+
+// SetLifecycleRegistry configures the lifecycle mappings of parent kinds.
+// Defaults to DefaultLifecycleMappings.
+func (r *ParentResolver) SetLifecycleRegistry(l *LifecycleRegistry) {
+	r.lifecycle = l
 }
 
 // ResolveParent finds and fetches the controller parent of the given object.
-// It returns nil if no controller owner reference is found.
+// Without controller owner reference, the parent is found with the configured
+// ParentEdges. It returns nil if there is no parent, or if the controller owner
+// reference refers to the deleted owner recorded in the kausality.io/orphaned
+// annotation. Errors are
+// classified as ErrorParentNotFound, ErrorParentForbidden or ErrorDecode
+// where possible.
 func (r *ParentResolver) ResolveParent(ctx context.Context, obj client.Object) (*ParentState, error) {
 	// Find controller owner reference
 	ownerRef := findControllerOwnerRef(obj.GetOwnerReferences())
 	if ownerRef == nil {
+		return r.resolveEdgeParent(ctx, obj)
+	}
+	// A dangling reference to the deleted owner of an orphan, e.g. restored
+	// from a backup, is not a parent
+	if orphan := OrphanOf(obj); orphan != nil && orphan.UID == ownerRef.UID {
 		return nil, nil
 	}
 
-	// Parse API version to get group/version
-	gv, err := schema.ParseGroupVersion(ownerRef.APIVersion)
+	parent, err := r.ResolveOwner(ctx, obj, *ownerRef)
+	if err != nil {
+		return nil, err
+	}
+	return r.parentState(parent, *ownerRef, obj), nil
+}
+
+// ResolveOwner fetches the owner of obj referenced by ref, in obj's namespace
+// unless the owner is cluster-scoped. Errors are classified like those of
+// ResolveParent. The UID of the owner is not compared with ref.
+func (r *ParentResolver) ResolveOwner(ctx context.Context, obj client.Object, ref metav1.OwnerReference) (*unstructured.Unstructured, error) {
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return nil, NewError(ErrorDecode, fmt.Errorf("invalid API version %q: %w", ref.APIVersion, err))
+	}
+
+	owner := &unstructured.Unstructured{}
+	owner.SetGroupVersionKind(gv.WithKind(ref.Kind))
+
+	aggregated := r.aggregated != nil && r.aggregated.IsAggregated(gv)
+	if aggregated {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, AggregatedParentTimeout)
+		defer cancel()
+	}
+
+	namespace, err := r.parentNamespace(obj, owner, aggregated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scope of parent %s/%s: %w", ref.Kind, ref.Name, err)
+	}
+	if err := r.getParent(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, owner); err != nil {
+		return nil, err
+	}
+	return owner, nil
+}
+
+// resolveEdgeParent finds and fetches the parent of an object without
+// controller owner reference with the configured ParentEdges.
+func (r *ParentResolver) resolveEdgeParent(ctx context.Context, obj client.Object) (*ParentState, error) {
+	if r.edges == nil {
+		return nil, nil
+	}
+	ref, err := r.edges.ParentOf(ctx, obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find parent: %w", err)
+	}
+	if ref == nil {
+		return nil, nil
+	}
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
 	if err != nil {
-		return nil, fmt.Errorf("invalid API version %q: %w", ownerRef.APIVersion, err)
+		return nil, NewError(ErrorDecode, fmt.Errorf("invalid API version %q: %w", ref.APIVersion, err))
 	}
 
-	// Fetch the parent object
 	parent := &unstructured.Unstructured{}
-	parent.SetGroupVersionKind(gv.WithKind(ownerRef.Kind))
+	parent.SetGroupVersionKind(gv.WithKind(ref.Kind))
+	if r.aggregated != nil && r.aggregated.IsAggregated(gv) {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, AggregatedParentTimeout)
+		defer cancel()
+	}
+	if err := r.getParent(ctx, client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}, parent); err != nil {
+		return nil, err
+	}
+	return r.parentState(parent, metav1.OwnerReference{
+		APIVersion: ref.APIVersion,
+		Kind:       ref.Kind,
+		Name:       ref.Name,
+		UID:        parent.GetUID(),
+	}, obj), nil
+}
+
+// getParent reads a parent, classifying errors as ErrorParentNotFound or
+// ErrorParentForbidden.
+func (r *ParentResolver) getParent(ctx context.Context, key client.ObjectKey, parent *unstructured.Unstructured) error {
+	if err := r.client.Get(ctx, key, parent); err != nil {
+		err = fmt.Errorf("failed to get parent %s/%s: %w", parent.GetKind(), key.Name, err)
+		switch {
+		case apierrors.IsNotFound(err):
+			return NewError(ErrorParentNotFound, err)
+		case apierrors.IsForbidden(err):
+			return NewError(ErrorParentForbidden, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// parentState extracts the state of the parent of obj, referenced by ref.
+func (r *ParentResolver) parentState(parent *unstructured.Unstructured, ref metav1.OwnerReference, obj client.Object) *ParentState {
+	state := extractParentState(parent, ref)
+	if profile := r.profileFor(parent.GroupVersionKind().GroupKind()); profile != nil {
+		state.Progressing = profile.Progressing(parent, obj)
+		state.Appliers = profile.Appliers(parent)
+		if profile.Refresh != nil {
+			state.Refresh = profile.Refresh.State(parent, obj)
+		}
+	}
+	state.Lifecycle = r.lifecycle.Signals(parent)
+	if state.Progressing == "" && state.Lifecycle != nil {
+		state.Progressing = state.Lifecycle.Reconciling
+	}
+	return state
+}
 
-	// Use the same namespace as the child for namespaced resources
-	parentKey := client.ObjectKey{
-		Namespace: obj.GetNamespace(),
-		Name:      ownerRef.Name,
+// parentNamespace returns the namespace of the controller owner of obj:
+// obj's namespace, unless the owner is cluster-scoped, e.g. a Namespace or a
+// cluster-scoped Crossplane composite resource owning namespaced managed
+// resources. Owners of cluster-scoped objects are cluster-scoped. Kinds
+// unknown to the RESTMapper are assumed to be namespaced, as are aggregated
+// kinds whose discovery fails because their server is unavailable.
+func (r *ParentResolver) parentNamespace(obj client.Object, parent *unstructured.Unstructured, aggregated bool) (string, error) {
+	if obj.GetNamespace() == "" {
+		return "", nil
+	}
+	namespaced, err := r.client.IsObjectNamespaced(parent)
+	switch {
+	case meta.IsNoMatchError(err), aggregated && err != nil:
+		return obj.GetNamespace(), nil
+	case err != nil:
+		return "", err
+	case !namespaced:
+		return "", nil
 	}
+	return obj.GetNamespace(), nil
+}
 
-	if err := r.client.Get(ctx, parentKey, parent); err != nil {
-		return nil, fmt.Errorf("failed to get parent %s/%s: %w", ownerRef.Kind, ownerRef.Name, err)
+// profileFor returns the profile of a parent kind, or nil if there is none.
+func (r *ParentResolver) profileFor(gk schema.GroupKind) *Profile {
+	for i := range r.profiles {
+		if r.profiles[i].Matches(gk) {
+			return &r.profiles[i]
+		}
 	}
+	return nil
+}
 
-	return extractParentState(parent, *ownerRef), nil
+// ParentStateFromObject extracts the state of an object acting as parent of
+// another object without owning it, e.g. a resource referencing a Secret.
+func ParentStateFromObject(obj *unstructured.Unstructured) *ParentState {
+	return extractParentState(obj, metav1.OwnerReference{
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Name:       obj.GetName(),
+		UID:        obj.GetUID(),
+	})
 }
 
 // findControllerOwnerRef finds the owner reference with controller: true.
@@ -75,6 +257,7 @@ func extractParentState(parent *unstructured.Unstructured, ownerRef metav1.Owner
 			Name:       ownerRef.Name,
 		},
 		Generation: parent.GetGeneration(),
+		Paused:     PausedReason(parent),
 	}
 
 	// Extract status.observedGeneration, falling back to condition observedGeneration
@@ -94,6 +277,12 @@ func extractParentState(parent *unstructured.Unstructured, ownerRef metav1.Owner
 		}
 	}
 
+	for _, entry := range parent.GetManagedFields() {
+		if entry.Subresource == "status" && entry.Manager != "" && !slices.Contains(state.StatusManagers, entry.Manager) {
+			state.StatusManagers = append(state.StatusManagers, entry.Manager)
+		}
+	}
+
 	// Check for deletion timestamp
 	if parent.GetDeletionTimestamp() != nil {
 		state.DeletionTimestamp = parent.GetDeletionTimestamp()
@@ -121,6 +310,10 @@ func extractParentState(parent *unstructured.Unstructured, ownerRef metav1.Owner
 		if controllers := annotations[controller.ControllersAnnotation]; controllers != "" {
 			state.Controllers = controller.ParseHashes(controllers)
 		}
+
+		if t, err := time.Parse(time.RFC3339, annotations[LastDriftTimeAnnotation]); err == nil {
+			state.LastDriftTime = t
+		}
 	}
 
 	return state
@@ -1,6 +1,9 @@
 package drift
 
 import (
+	"context"
+	"fmt"
+	"slices"
 	"testing"
 	"time"
 
@@ -8,8 +11,18 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 
 	"github.com/kausality-io/kausality/pkg/controller"
 )
@@ -329,6 +342,76 @@ func TestParentRefFromOwnerRef(t *testing.T) {
 	}
 }
 
+func TestResolveParent_ClusterScopedOwner(t *testing.T) {
+	xnetwork := &unstructured.Unstructured{}
+	xnetwork.SetAPIVersion("example.org/v1")
+	xnetwork.SetKind("XNetwork")
+	xnetwork.SetName("net")
+	xnetwork.SetGeneration(2)
+	require.NoError(t, unstructured.SetNestedField(xnetwork.Object, int64(2), "status", "observedGeneration"))
+
+	deploy := &unstructured.Unstructured{}
+	deploy.SetAPIVersion("apps/v1")
+	deploy.SetKind("Deployment")
+	deploy.SetNamespace("team-a")
+	deploy.SetName("web")
+
+	unknown := &unstructured.Unstructured{}
+	unknown.SetAPIVersion("example.org/v1")
+	unknown.SetKind("Unmapped")
+	unknown.SetNamespace("team-a")
+	unknown.SetName("thing")
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "XNetwork"}, meta.RESTScopeRoot)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Namespace"), meta.RESTScopeRoot)
+	mapper.Add(appsv1.SchemeGroupVersion.WithKind("Deployment"), meta.RESTScopeNamespace)
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithRESTMapper(mapper).WithObjects(
+		xnetwork, deploy, unknown,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+	).Build()
+	resolver := NewParentResolver(c)
+
+	tests := []struct {
+		name      string
+		childNS   string
+		owner     metav1.OwnerReference
+		wantNS    string
+		wantGen   int64
+		wantError bool
+	}{
+		{name: "cluster-scoped XR of namespaced child", childNS: "team-a", owner: metav1.OwnerReference{APIVersion: "example.org/v1", Kind: "XNetwork", Name: "net"}, wantGen: 2},
+		{name: "cluster-scoped XR of cluster-scoped child", owner: metav1.OwnerReference{APIVersion: "example.org/v1", Kind: "XNetwork", Name: "net"}, wantGen: 2},
+		{name: "Namespace", childNS: "team-a", owner: metav1.OwnerReference{APIVersion: "v1", Kind: "Namespace", Name: "team-a"}},
+		{name: "namespaced owner", childNS: "team-a", owner: metav1.OwnerReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"}, wantNS: "team-a"},
+		{name: "kind unknown to the RESTMapper", childNS: "team-a", owner: metav1.OwnerReference{APIVersion: "example.org/v1", Kind: "Unmapped", Name: "thing"}, wantNS: "team-a"},
+		{name: "namespaced owner of cluster-scoped child", owner: metav1.OwnerReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"}, wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.owner.Controller = ptr.To(true)
+			child := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+				Namespace:       tt.childNS,
+				Name:            "child",
+				OwnerReferences: []metav1.OwnerReference{tt.owner},
+			}}
+
+			state, err := resolver.ResolveParent(context.Background(), child)
+			if tt.wantError {
+				assert.Equal(t, ErrorParentNotFound, ClassOf(err))
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, state)
+			assert.Equal(t, ParentRef{APIVersion: tt.owner.APIVersion, Kind: tt.owner.Kind, Namespace: tt.wantNS, Name: tt.owner.Name}, state.Ref)
+			assert.Equal(t, tt.wantGen, state.Generation)
+		})
+	}
+}
+
 func TestExtractConditionObservedGeneration(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -719,3 +802,63 @@ func TestExtractParentState_CrossplaneConditions(t *testing.T) {
 		})
 	}
 }
+
+// unavailableMapper fails discovery of a group, like the RESTMapper does for
+// an aggregated API whose server is unavailable.
+type unavailableMapper struct {
+	meta.RESTMapper
+	group string
+}
+
+func (m unavailableMapper) RESTMapping(gk schema.GroupKind, versions ...string) (*meta.RESTMapping, error) {
+	if gk.Group == m.group {
+		return nil, fmt.Errorf("unable to retrieve the complete list of server APIs: %s: the server is currently unable to handle the request", m.group)
+	}
+	return m.RESTMapper.RESTMapping(gk, versions...)
+}
+
+type aggregatedGroups []string
+
+func (a aggregatedGroups) IsAggregated(gv schema.GroupVersion) bool {
+	return slices.Contains(a, gv.Group)
+}
+
+func TestResolveParent_Aggregated(t *testing.T) {
+	backup := &unstructured.Unstructured{}
+	backup.SetAPIVersion("backup.example.com/v1")
+	backup.SetKind("Backup")
+	backup.SetNamespace("team-a")
+	backup.SetName("nightly")
+	backup.SetGeneration(3)
+
+	var deadline bool
+	c := fake.NewClientBuilder().
+		WithRESTMapper(unavailableMapper{RESTMapper: meta.NewDefaultRESTMapper(nil), group: "backup.example.com"}).
+		WithObjects(backup).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				_, deadline = ctx.Deadline()
+				return c.Get(ctx, key, obj, opts...)
+			},
+		}).Build()
+	child := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "team-a",
+		Name:      "child",
+		OwnerReferences: []metav1.OwnerReference{
+			{APIVersion: "backup.example.com/v1", Kind: "Backup", Name: "nightly", Controller: ptr.To(true)},
+		},
+	}}
+
+	// Without knowing the API is aggregated, discovery errors fail resolution
+	_, err := NewParentResolver(c).ResolveParent(context.Background(), child)
+	require.Error(t, err)
+
+	resolver := NewParentResolver(c)
+	resolver.SetAggregatedAPIs(aggregatedGroups{"backup.example.com"})
+	state, err := resolver.ResolveParent(context.Background(), child)
+	require.NoError(t, err)
+	require.NotNil(t, state)
+	assert.Equal(t, "team-a", state.Ref.Namespace, "assumed to be namespaced")
+	assert.Equal(t, int64(3), state.Generation)
+	assert.True(t, deadline, "read with timeout")
+}
@@ -0,0 +1,277 @@
+package drift
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kausality-io/kausality/api/v1alpha1"
+)
+
+// Annotation keys - re-exported from api/v1alpha1.
+const (
+	DriftCountAnnotation    = v1alpha1.DriftCountAnnotation
+	LastDriftTimeAnnotation = v1alpha1.LastDriftTimeAnnotation
+)
+
+const (
+	// DefaultStatusWindow is the quiet period after which a drift counter restarts.
+	DefaultStatusWindow = 24 * time.Hour
+	// statusWorkers is the number of concurrent workers writing counters.
+	statusWorkers = 2
+	// statusMaxRetries is the number of attempts before a delta is dropped.
+	statusMaxRetries = 10
+	// statusMaxPending bounds the number of parents with pending deltas.
+	statusMaxPending = 10000
+)
+
+// StatusRecorder records detected drift on parent objects.
+type StatusRecorder interface {
+	RecordDrift(ctx context.Context, parent client.Object)
+}
+
+var _ StatusRecorder = &StatusWriter{}
+
+// StatusWriter maintains a rolling drift counter and the last drift time in
+// annotations of parent objects, so that the noisiest parents can be listed
+// with kubectl or sorted by dashboards:
+//
+//	kubectl get deploy -o custom-columns='NAME:.metadata.name,DRIFTS:.metadata.annotations.kausality\.io/drift-count'
+//
+// The counter restarts when no drift was detected for the window. Drift is
+// queued per parent and written by workers with merge patches guarded by
+// resourceVersion, so concurrent webhook replicas add up instead of losing
+// increments. StatusWriter does not need leader election.
+type StatusWriter struct {
+	client  client.Client
+	log     logr.Logger
+	window  time.Duration
+	queue   workqueue.TypedRateLimitingInterface[statusKey]
+	limiter flowcontrol.RateLimiter
+	now     func() time.Time
+
+	mu      sync.Mutex
+	pending map[statusKey]*driftDelta
+}
+
+// statusKey identifies a parent across kinds.
+type statusKey struct {
+	GVK       schema.GroupVersionKind
+	Namespace string
+	Name      string
+}
+
+// driftDelta is the drift detected on a parent that is not yet written.
+type driftDelta struct {
+	count int
+	first time.Time
+	last  time.Time
+}
+
+// StatusWriterOption configures a StatusWriter.
+type StatusWriterOption func(*StatusWriter)
+
+// WithWriteLimiter limits the rate of drift status writes on the client side,
+// sharing the limiter with the webhook's other annotation writers.
+func WithWriteLimiter(l flowcontrol.RateLimiter) StatusWriterOption {
+	return func(w *StatusWriter) {
+		w.limiter = l
+	}
+}
+
+// NewStatusWriter creates a StatusWriter. A zero window uses DefaultStatusWindow.
+// Add it to a manager to start its workers.
+func NewStatusWriter(c client.Client, window time.Duration, log logr.Logger, opts ...StatusWriterOption) *StatusWriter {
+	if window <= 0 {
+		window = DefaultStatusWindow
+	}
+	w := &StatusWriter{
+		client: c,
+		log:    log.WithName("drift-status"),
+		window: window,
+		queue: workqueue.NewTypedRateLimitingQueueWithConfig(
+			workqueue.DefaultTypedControllerRateLimiter[statusKey](),
+			workqueue.TypedRateLimitingQueueConfig[statusKey]{Name: "drift-status"},
+		),
+		now:     time.Now,
+		pending: make(map[statusKey]*driftDelta),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Every
+// replica writes the drift it detects.
+func (w *StatusWriter) NeedLeaderElection() bool {
+	return false
+}
+
+// Start runs the workers until the context is cancelled.
+func (w *StatusWriter) Start(ctx context.Context) error {
+	w.log.Info("starting drift status writer", "workers", statusWorkers, "window", w.window)
+
+	var wg sync.WaitGroup
+	for i := 0; i < statusWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for w.processNext(ctx) {
+			}
+		}()
+	}
+
+	<-ctx.Done()
+	w.queue.ShutDown()
+	wg.Wait()
+	return nil
+}
+
+// RecordDrift enqueues counting one drift on the parent.
+func (w *StatusWriter) RecordDrift(ctx context.Context, parent client.Object) {
+	gvk, err := w.client.GroupVersionKindFor(parent)
+	if err != nil {
+		w.log.Error(err, "failed to determine kind", "namespace", parent.GetNamespace(), "name", parent.GetName())
+		return
+	}
+	key := statusKey{GVK: gvk, Namespace: parent.GetNamespace(), Name: parent.GetName()}
+
+	now := w.now()
+	if !w.merge(key, &driftDelta{count: 1, first: now, last: now}) {
+		w.log.V(1).Info("dropping drift, too many pending parents", "kind", gvk.Kind, "namespace", key.Namespace, "name", key.Name)
+		return
+	}
+	w.queue.Add(key)
+}
+
+// Len returns the number of parents with pending drift.
+func (w *StatusWriter) Len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.pending)
+}
+
+// merge adds delta to the pending delta for key. Returns false if the key is
+// new and the pending limit is reached.
+func (w *StatusWriter) merge(key statusKey, delta *driftDelta) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	existing, ok := w.pending[key]
+	if !ok {
+		if len(w.pending) >= statusMaxPending {
+			return false
+		}
+		w.pending[key] = delta
+		return true
+	}
+
+	existing.count += delta.count
+	if delta.first.Before(existing.first) {
+		existing.first = delta.first
+	}
+	if delta.last.After(existing.last) {
+		existing.last = delta.last
+	}
+	return true
+}
+
+func (w *StatusWriter) processNext(ctx context.Context) bool {
+	key, shutdown := w.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer w.queue.Done(key)
+
+	w.mu.Lock()
+	delta := w.pending[key]
+	delete(w.pending, key)
+	w.mu.Unlock()
+	if delta == nil {
+		w.queue.Forget(key)
+		return true
+	}
+
+	log := w.log.WithValues("kind", key.GVK.Kind, "namespace", key.Namespace, "name", key.Name)
+	if w.limiter != nil {
+		if err := w.limiter.Wait(ctx); err != nil {
+			return true
+		}
+	}
+	err := w.apply(ctx, key, delta)
+	switch {
+	case err == nil:
+		w.queue.Forget(key)
+	case w.queue.NumRequeues(key) >= statusMaxRetries:
+		log.Error(err, "giving up recording drift status", "attempts", statusMaxRetries)
+		w.queue.Forget(key)
+	default:
+		if apierrors.IsConflict(err) {
+			log.V(1).Info("conflict recording drift status, retrying")
+		} else {
+			log.Error(err, "failed to record drift status, retrying")
+		}
+		w.merge(key, delta)
+		w.queue.AddRateLimited(key)
+	}
+	return true
+}
+
+// apply patches the parent's drift annotations.
+func (w *StatusWriter) apply(ctx context.Context, key statusKey, delta *driftDelta) error {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(key.GVK)
+	if err := w.client.Get(ctx, client.ObjectKey{Namespace: key.Namespace, Name: key.Name}, obj); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	base := obj.DeepCopy()
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	applyDelta(annotations, delta, w.window)
+	obj.SetAnnotations(annotations)
+
+	if err := w.client.Patch(ctx, obj, client.MergeFromWithOptions(base, client.MergeFromWithOptimisticLock{})); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	w.log.V(1).Info("recorded drift status", "kind", key.GVK.Kind, "namespace", key.Namespace, "name", key.Name,
+		"driftCount", annotations[DriftCountAnnotation])
+	return nil
+}
+
+// applyDelta adds the delta to the drift annotations. The counter restarts
+// if the last recorded drift is more than window before the delta.
+func applyDelta(annotations map[string]string, delta *driftDelta, window time.Duration) {
+	count, err := strconv.Atoi(annotations[DriftCountAnnotation])
+	if err != nil || count < 0 {
+		count = 0
+	}
+	last, err := time.Parse(time.RFC3339, annotations[LastDriftTimeAnnotation])
+	if err != nil || delta.first.Sub(last) > window {
+		count = 0
+	}
+
+	annotations[DriftCountAnnotation] = strconv.Itoa(count + delta.count)
+	if delta.last.After(last) {
+		annotations[LastDriftTimeAnnotation] = delta.last.UTC().Format(time.RFC3339)
+	}
+}
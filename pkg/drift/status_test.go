@@ -0,0 +1,136 @@
+package drift
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	ktesting "github.com/kausality-io/kausality/pkg/testing"
+)
+
+func TestApplyDelta(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	window := time.Hour
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		delta       driftDelta
+		want        map[string]string
+	}{
+		{
+			name:        "first drift",
+			annotations: map[string]string{},
+			delta:       driftDelta{count: 1, first: now, last: now},
+			want:        map[string]string{DriftCountAnnotation: "1", LastDriftTimeAnnotation: "2026-03-01T12:00:00Z"},
+		},
+		{
+			name:        "adds within window",
+			annotations: map[string]string{DriftCountAnnotation: "4", LastDriftTimeAnnotation: "2026-03-01T11:30:00Z"},
+			delta:       driftDelta{count: 2, first: now, last: now.Add(time.Minute)},
+			want:        map[string]string{DriftCountAnnotation: "6", LastDriftTimeAnnotation: "2026-03-01T12:01:00Z"},
+		},
+		{
+			name:        "restarts after quiet window",
+			annotations: map[string]string{DriftCountAnnotation: "4", LastDriftTimeAnnotation: "2026-03-01T10:00:00Z"},
+			delta:       driftDelta{count: 1, first: now, last: now},
+			want:        map[string]string{DriftCountAnnotation: "1", LastDriftTimeAnnotation: "2026-03-01T12:00:00Z"},
+		},
+		{
+			name:        "invalid annotations restart",
+			annotations: map[string]string{DriftCountAnnotation: "many", LastDriftTimeAnnotation: "yesterday"},
+			delta:       driftDelta{count: 1, first: now, last: now},
+			want:        map[string]string{DriftCountAnnotation: "1", LastDriftTimeAnnotation: "2026-03-01T12:00:00Z"},
+		},
+		{
+			name:        "never moves last drift time backwards",
+			annotations: map[string]string{DriftCountAnnotation: "1", LastDriftTimeAnnotation: "2026-03-01T12:30:00Z"},
+			delta:       driftDelta{count: 1, first: now, last: now},
+			want:        map[string]string{DriftCountAnnotation: "2", LastDriftTimeAnnotation: "2026-03-01T12:30:00Z"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			applyDelta(tt.annotations, &tt.delta, window)
+			if diff := cmp.Diff(tt.want, tt.annotations); diff != "" {
+				t.Errorf("annotations mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStatusWriter_Merge(t *testing.T) {
+	w := NewStatusWriter(nil, 0, logr.Discard())
+	key := statusKey{GVK: schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, Namespace: "default", Name: "app"}
+	t1 := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Minute)
+
+	require.True(t, w.merge(key, &driftDelta{count: 1, first: t2, last: t2}))
+	require.True(t, w.merge(key, &driftDelta{count: 2, first: t1, last: t1}))
+
+	assert.Equal(t, 1, w.Len())
+	delta := w.pending[key]
+	assert.Equal(t, 3, delta.count)
+	assert.Equal(t, t1, delta.first)
+	assert.Equal(t, t2, delta.last)
+}
+
+func newStatusTestClient(t *testing.T, funcs interceptor.Funcs, objs ...client.Object) client.WithWatch {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).WithInterceptorFuncs(funcs).Build()
+}
+
+func TestStatusWriter_CountsDrift(t *testing.T) {
+	deploy := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "app"}}
+
+	// Fail the first patch with a conflict, as if another replica raced us
+	var patches atomic.Int32
+	c := newStatusTestClient(t, interceptor.Funcs{
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			if patches.Add(1) == 1 {
+				return apierrors.NewConflict(schema.GroupResource{Group: "apps", Resource: "deployments"}, obj.GetName(), fmt.Errorf("conflict"))
+			}
+			return c.Patch(ctx, obj, patch, opts...)
+		},
+	}, deploy)
+	w := NewStatusWriter(c, time.Hour, logr.Discard())
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	w.now = func() time.Time { return now }
+
+	// Queue before starting, so both drifts are merged into one delta
+	w.RecordDrift(context.Background(), deploy)
+	w.RecordDrift(context.Background(), deploy)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = w.Start(ctx) }()
+
+	ktesting.Eventually(t, func() (bool, string) {
+		var got appsv1.Deployment
+		require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(deploy), &got))
+		if got.Annotations[DriftCountAnnotation] != "2" || got.Annotations[LastDriftTimeAnnotation] != "2026-03-01T12:00:00Z" {
+			return false, fmt.Sprintf("annotations: %v", got.Annotations)
+		}
+		return true, ""
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.GreaterOrEqual(t, patches.Load(), int32(2))
+}
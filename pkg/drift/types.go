@@ -2,6 +2,8 @@
 package drift
 
 import (
+	"time"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -54,15 +56,39 @@ type ParentState struct {
 	// Controllers contains user hashes from kausality.io/controllers annotation.
 	// These are users who have updated the parent's status.
 	Controllers []string
+	// StatusManagers are the field managers owning fields of the parent's
+	// status subresource in its managedFields.
+	StatusManagers []string
+	// Appliers are the users the parent's controller impersonates to apply
+	// children, e.g. the ServiceAccount of a Flux Kustomization. They are
+	// identified as the controller.
+	Appliers []string
 	// DeletionTimestamp is set if the parent is being deleted.
 	DeletionTimestamp *metav1.Time
 	// Conditions are the parent's status conditions for lifecycle detection.
 	Conditions []metav1.Condition
+	// Lifecycle are the signals of the parent's LifecycleMapping matching
+	// it, or nil if its kind has no mapping.
+	Lifecycle *LifecycleSignals
 	// IsInitialized indicates whether the parent has completed initialization.
 	IsInitialized bool
 	// PhaseFromAnnotation is the value of kausality.io/phase annotation.
 	// Used to determine if phase needs to be recorded (lazy fetch optimization).
 	PhaseFromAnnotation string
+	// Progressing explains why a parent described by a Profile is still
+	// changing its children although generation == observedGeneration.
+	// Empty if it is not.
+	Progressing string
+	// Refresh is the refresh schedule of a parent described by a Profile
+	// with Refresh for the child, nil if the parent does not refresh it.
+	Refresh *RefreshState
+	// Paused explains why the parent's controller does not reconcile it,
+	// e.g. a paused Deployment. Empty if it does.
+	Paused string
+	// LastDriftTime is when drift was last detected on the parent's
+	// children, from the kausality.io/last-drift-time annotation written
+	// with drift status. Zero if unknown.
+	LastDriftTime time.Time
 }
 
 // LifecyclePhase represents the lifecycle phase of a parent object.
@@ -0,0 +1,275 @@
+// Package embed provides kausality as a k8s.io/apiserver admission plugin,
+// for generic control planes serving their own APIs, e.g. kcp-style
+// apiservers. It runs the same drift detection and tracing as the webhook,
+// in process, against the host's storage.
+package embed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	evanphxjsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/go-logr/logr"
+	jsonpatch "gomodules.xyz/jsonpatch/v2"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/warning"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	cradmission "sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	kausalityadmission "github.com/kausality-io/kausality/pkg/admission"
+	"github.com/kausality-io/kausality/pkg/callback"
+	"github.com/kausality-io/kausality/pkg/config"
+	"github.com/kausality-io/kausality/pkg/controller"
+	"github.com/kausality-io/kausality/pkg/policy"
+)
+
+// PluginName is the name of the admission plugin.
+const PluginName = "Kausality"
+
+// Config configures the admission plugin.
+type Config struct {
+	// Client reads parents and writes the controller and phase annotations on
+	// them. It must reach the host's storage, e.g. a loopback client of the
+	// apiserver, and serve all tracked kinds. Required.
+	Client client.Client
+	// Log is the logger.
+	Log logr.Logger
+	// PolicyResolver resolves the drift detection mode of resources. If nil,
+	// the policies of DriftConfig are used if it declares any, and its
+	// driftDetection section otherwise.
+	PolicyResolver policy.Resolver
+	// DriftConfig provides the same settings as the webhook config file.
+	// If nil, defaults to log mode for all resources.
+	DriftConfig *config.Config
+	// CallbackSender sends drift reports to webhook endpoints.
+	// If nil, drift callbacks are disabled.
+	CallbackSender callback.ReportSender
+	// TraceMirror receives the trace of every admitted mutation.
+	// If nil, traces are not mirrored.
+	TraceMirror callback.TraceMirror
+	// Recorder records controller identity and lifecycle phase on parents.
+	// If nil, they are written through Client during admission.
+	Recorder controller.Recorder
+}
+
+// Plugin implements k8s.io/apiserver admission.MutationInterface. It converts
+// admission attributes to admission requests of the kausality handler and
+// applies the returned patches to the object in place.
+type Plugin struct {
+	*admission.Handler
+	handler *kausalityadmission.Handler
+	log     logr.Logger
+}
+
+var _ admission.MutationInterface = &Plugin{}
+
+// NewAdmissionPlugin creates the admission plugin.
+func NewAdmissionPlugin(cfg Config) (*Plugin, error) {
+	if cfg.Client == nil {
+		return nil, errors.New("client is required")
+	}
+	resolver := cfg.PolicyResolver
+	if resolver == nil && cfg.DriftConfig != nil && len(cfg.DriftConfig.Policies) > 0 {
+		store := policy.NewStore(cfg.Client, cfg.Log)
+		store.Update(policy.PoliciesFromConfig(cfg.DriftConfig))
+		resolver = store
+	}
+
+	return &Plugin{
+		Handler: admission.NewHandler(admission.Create, admission.Update, admission.Delete, admission.Connect),
+		handler: kausalityadmission.NewHandler(kausalityadmission.Config{
+			Client:         cfg.Client,
+			Log:            cfg.Log,
+			DriftConfig:    cfg.DriftConfig,
+			PolicyResolver: resolver,
+			CallbackSender: cfg.CallbackSender,
+			TraceMirror:    cfg.TraceMirror,
+			Recorder:       cfg.Recorder,
+		}),
+		log: cfg.Log.WithName("kausality-admission"),
+	}, nil
+}
+
+// Register registers the admission plugin under PluginName. The plugin
+// configuration file of the apiserver is ignored.
+func Register(plugins *admission.Plugins, cfg Config) {
+	plugins.Register(PluginName, func(io.Reader) (admission.Interface, error) {
+		return NewAdmissionPlugin(cfg)
+	})
+}
+
+// Admit runs drift detection and tracing for the request. Drift in enforce
+// mode is returned as a Forbidden error with the causes of the webhook's
+// denials, internal errors configured to fail as an InternalError. Warnings are returned to the client and audit
+// annotations are added to the request's audit event.
+func (p *Plugin) Admit(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) error {
+	req, err := toRequest(a)
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+
+	resp := p.handler.Handle(ctx, req)
+
+	for key, value := range resp.AuditAnnotations {
+		if err := a.AddAnnotation(key, value); err != nil {
+			p.log.V(1).Info("failed to add audit annotation", "key", key, "error", err.Error())
+		}
+	}
+	for _, w := range resp.Warnings {
+		warning.AddWarning(ctx, "", w)
+	}
+
+	if !resp.Allowed {
+		msg := "denied by kausality"
+		if resp.Result != nil && resp.Result.Message != "" {
+			msg = resp.Result.Message
+		}
+		if resp.Result != nil && resp.Result.Code >= http.StatusInternalServerError {
+			return apierrors.NewInternalError(errors.New(msg))
+		}
+		err := admission.NewForbidden(a, errors.New(msg))
+		var statusErr *apierrors.StatusError
+		if resp.Result != nil && resp.Result.Details != nil && errors.As(err, &statusErr) && statusErr.ErrStatus.Details != nil {
+			// Keep the reason and remediation described by the handler
+			statusErr.ErrStatus.Details.Causes = resp.Result.Details.Causes
+			statusErr.ErrStatus.Details.RetryAfterSeconds = resp.Result.Details.RetryAfterSeconds
+		}
+		return err
+	}
+
+	if len(resp.Patches) > 0 {
+		if err := applyPatches(a.GetObject(), resp.Patches); err != nil {
+			return apierrors.NewInternalError(err)
+		}
+	}
+	return nil
+}
+
+// toRequest converts admission attributes to an admission request, as the
+// apiserver would send it to a webhook.
+func toRequest(a admission.Attributes) (cradmission.Request, error) {
+	object, err := toRaw(a.GetObject(), a)
+	if err != nil {
+		return cradmission.Request{}, fmt.Errorf("failed to convert object: %w", err)
+	}
+	oldObject, err := toRaw(a.GetOldObject(), a)
+	if err != nil {
+		return cradmission.Request{}, fmt.Errorf("failed to convert old object: %w", err)
+	}
+	options, err := toRaw(a.GetOperationOptions(), nil)
+	if err != nil {
+		return cradmission.Request{}, fmt.Errorf("failed to convert options: %w", err)
+	}
+
+	gvk := a.GetKind()
+	gvr := a.GetResource()
+	dryRun := a.IsDryRun()
+	req := admissionv1.AdmissionRequest{
+		UID:         uuid.NewUUID(),
+		Kind:        metav1.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind},
+		Resource:    metav1.GroupVersionResource{Group: gvr.Group, Version: gvr.Version, Resource: gvr.Resource},
+		SubResource: a.GetSubresource(),
+		Namespace:   a.GetNamespace(),
+		Name:        a.GetName(),
+		Operation:   admissionv1.Operation(a.GetOperation()),
+		Object:      object,
+		OldObject:   oldObject,
+		Options:     options,
+		DryRun:      &dryRun,
+	}
+	if user := a.GetUserInfo(); user != nil {
+		req.UserInfo = authenticationv1.UserInfo{
+			Username: user.GetName(),
+			UID:      user.GetUID(),
+			Groups:   user.GetGroups(),
+		}
+		for key, values := range user.GetExtra() {
+			if req.UserInfo.Extra == nil {
+				req.UserInfo.Extra = make(map[string]authenticationv1.ExtraValue)
+			}
+			req.UserInfo.Extra[key] = values
+		}
+	}
+	return cradmission.Request{AdmissionRequest: req}, nil
+}
+
+// toRaw serializes an object to JSON. Objects of the admitted kind get its
+// apiVersion and kind if they have none, as internal versions do not carry
+// them.
+func toRaw(obj runtime.Object, a admission.Attributes) (runtime.RawExtension, error) {
+	if obj == nil {
+		return runtime.RawExtension{}, nil
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return runtime.RawExtension{}, err
+	}
+	u := &unstructured.Unstructured{Object: content}
+	if a != nil && u.GetKind() == "" {
+		u.SetGroupVersionKind(a.GetKind())
+	}
+	raw, err := json.Marshal(u)
+	if err != nil {
+		return runtime.RawExtension{}, err
+	}
+	return runtime.RawExtension{Raw: raw}, nil
+}
+
+// applyPatches applies the handler's patches to the object. The handler only
+// changes metadata annotations and labels; other patches are rejected.
+func applyPatches(obj runtime.Object, patches []jsonpatch.JsonPatchOperation) error {
+	for _, patch := range patches {
+		if !strings.HasPrefix(patch.Path, "/metadata/annotations") && !strings.HasPrefix(patch.Path, "/metadata/labels") {
+			return fmt.Errorf("unsupported patch of %s", patch.Path)
+		}
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return fmt.Errorf("failed to access object metadata: %w", err)
+	}
+
+	data, err := json.Marshal(&metav1.ObjectMeta{Annotations: accessor.GetAnnotations(), Labels: accessor.GetLabels()})
+	if err != nil {
+		return err
+	}
+	data, err = json.Marshal(map[string]json.RawMessage{"metadata": data})
+	if err != nil {
+		return err
+	}
+	patchData, err := json.Marshal(patches)
+	if err != nil {
+		return err
+	}
+	decoded, err := evanphxjsonpatch.DecodePatch(patchData)
+	if err != nil {
+		return fmt.Errorf("failed to decode patch: %w", err)
+	}
+	patched, err := decoded.Apply(data)
+	if err != nil {
+		return fmt.Errorf("failed to apply patch: %w", err)
+	}
+
+	var result struct {
+		Metadata metav1.ObjectMeta `json:"metadata"`
+	}
+	if err := json.Unmarshal(patched, &result); err != nil {
+		return err
+	}
+	accessor.SetAnnotations(result.Metadata.Annotations)
+	accessor.SetLabels(result.Metadata.Labels)
+	return nil
+}
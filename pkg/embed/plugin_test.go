@@ -0,0 +1,128 @@
+package embed
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+	"github.com/kausality-io/kausality/pkg/config"
+	"github.com/kausality-io/kausality/pkg/controller"
+)
+
+const deploymentController = "system:serviceaccount:kube-system:deployment-controller"
+
+var (
+	configMapKind  = corev1.SchemeGroupVersion.WithKind("ConfigMap")
+	replicaSetKind = appsv1.SchemeGroupVersion.WithKind("ReplicaSet")
+)
+
+// newReplicaSet returns a ReplicaSet controlled by the Deployment "web",
+// without apiVersion and kind like objects of internal versions.
+func newReplicaSet(replicas int32, annotations map[string]string) *appsv1.ReplicaSet {
+	return &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "default",
+			Name:        "web-7d4b9",
+			Annotations: annotations,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "apps/v1", Kind: "Deployment", Name: "web", UID: "web-uid", Controller: ptr.To(true),
+			}},
+		},
+		Spec: appsv1.ReplicaSetSpec{Replicas: &replicas},
+	}
+}
+
+func newPlugin(t *testing.T, cfg Config, objs ...runtime.Object) *Plugin {
+	t.Helper()
+	cfg.Client = fake.NewClientBuilder().WithRuntimeObjects(objs...).Build()
+	cfg.Log = logr.Discard()
+	p, err := NewAdmissionPlugin(cfg)
+	require.NoError(t, err)
+	return p
+}
+
+func TestNewAdmissionPlugin_RequiresClient(t *testing.T) {
+	_, err := NewAdmissionPlugin(Config{Log: logr.Discard()})
+	assert.Error(t, err)
+}
+
+func TestAdmit_TracesCreate(t *testing.T) {
+	p := newPlugin(t, Config{})
+	assert.True(t, p.Handles(admission.Create))
+	assert.False(t, p.Handles(admission.Operation("PATCH")))
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "settings", Annotations: map[string]string{"team": "platform"}},
+		Data:       map[string]string{"key": "value"},
+	}
+	attrs := admission.NewAttributesRecord(cm, nil, configMapKind, "default", "settings",
+		corev1.SchemeGroupVersion.WithResource("configmaps"), "", admission.Create, &metav1.CreateOptions{}, false,
+		&user.DefaultInfo{Name: "alice"})
+
+	require.NoError(t, p.Admit(context.Background(), attrs, nil))
+	assert.Contains(t, cm.Annotations, "kausality.io/trace")
+	assert.Contains(t, cm.Annotations["kausality.io/trace"], `"user":"alice"`)
+	assert.Equal(t, "platform", cm.Annotations["team"])
+	assert.Equal(t, map[string]string{"key": "value"}, cm.Data)
+}
+
+func TestAdmit_DeniesDrift(t *testing.T) {
+	parent := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec":   map[string]interface{}{"replicas": int64(1)},
+		"status": map[string]interface{}{"observedGeneration": int64(1)},
+	}}
+	parent.SetAPIVersion("apps/v1")
+	parent.SetKind("Deployment")
+	parent.SetNamespace("default")
+	parent.SetName("web")
+	parent.SetUID("web-uid")
+	parent.SetGeneration(1)
+	parent.SetAnnotations(map[string]string{controller.PhaseAnnotation: controller.PhaseValueInitialized})
+
+	// Policies of the config file resolve the mode
+	cfg := Config{DriftConfig: &config.Config{
+		DriftDetection: config.DriftDetectionConfig{DefaultMode: config.ModeLog},
+		Policies: []config.PolicyConfig{{
+			Name: "apps",
+			KausalitySpec: kausalityv1beta1.KausalitySpec{
+				Resources: []kausalityv1beta1.ResourceRule{{APIGroups: []string{"apps"}, Resources: []string{"replicasets"}}},
+				Mode:      kausalityv1beta1.ModeEnforce,
+			},
+		}},
+	}}
+	p := newPlugin(t, cfg, parent)
+
+	rs := newReplicaSet(3, nil)
+	oldRS := newReplicaSet(1, map[string]string{controller.UpdatersAnnotation: controller.HashUsername(deploymentController)})
+	attrs := admission.NewAttributesRecord(rs, oldRS, replicaSetKind, "default", rs.Name,
+		appsv1.SchemeGroupVersion.WithResource("replicasets"), "", admission.Update, &metav1.UpdateOptions{}, false,
+		&user.DefaultInfo{Name: deploymentController})
+
+	err := p.Admit(context.Background(), attrs, nil)
+	require.Error(t, err)
+	assert.True(t, apierrors.IsForbidden(err), err.Error())
+	assert.Contains(t, err.Error(), "drift")
+	var statusErr *apierrors.StatusError
+	require.ErrorAs(t, err, &statusErr)
+	require.NotNil(t, statusErr.ErrStatus.Details)
+	assert.Contains(t, statusErr.ErrStatus.Details.Causes, metav1.StatusCause{
+		Type: kausalityv1alpha1.DenialCauseReason, Message: string(kausalityv1alpha1.DenialReasonDrift),
+	})
+	assert.Empty(t, rs.Annotations, "denied object is not patched")
+}
@@ -0,0 +1,105 @@
+// Package fieldpath parses and evaluates the field paths of resource rules,
+// which limit drift detection to changes of parts of the spec.
+package fieldpath
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// pathPattern is the syntax of a field path: dot-separated fields below
+// spec, each optionally selecting all ("[*]") or one ("[N]") list element.
+var pathPattern = regexp.MustCompile(`^spec(\.[^.\[\]]+(\[(\*|[0-9]+)\])?)+$`)
+
+// Path is a parsed field path, e.g. spec.template.spec.containers[*].image.
+type Path struct {
+	raw      string
+	segments []segment
+}
+
+// segment is a field of a path, optionally selecting list elements.
+type segment struct {
+	field string
+	// list is set if the segment selects list elements: all if index is
+	// negative, else the element at index.
+	list  bool
+	index int
+}
+
+// Parse parses a field path.
+func Parse(path string) (Path, error) {
+	if !pathPattern.MatchString(path) {
+		return Path{}, fmt.Errorf("invalid field path %q: must be fields below spec separated by dots, each optionally followed by [*] or [N]", path)
+	}
+	p := Path{raw: path}
+	for _, part := range strings.Split(path, ".") {
+		seg := segment{field: part}
+		if i := strings.IndexByte(part, '['); i >= 0 {
+			seg.field = part[:i]
+			seg.list = true
+			seg.index = -1
+			if selector := part[i+1 : len(part)-1]; selector != "*" {
+				index, err := strconv.Atoi(selector)
+				if err != nil {
+					return Path{}, fmt.Errorf("invalid field path %q: %w", path, err)
+				}
+				seg.index = index
+			}
+		}
+		p.segments = append(p.segments, seg)
+	}
+	return p, nil
+}
+
+// String returns the path as parsed.
+func (p Path) String() string {
+	return p.raw
+}
+
+// Values returns the values at the path in an object, in list order.
+// Missing fields and list elements are skipped.
+func (p Path) Values(obj map[string]interface{}) []interface{} {
+	values := []interface{}{obj}
+	for _, seg := range p.segments {
+		var next []interface{}
+		for _, v := range values {
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			field, ok := m[seg.field]
+			if !ok {
+				continue
+			}
+			if !seg.list {
+				next = append(next, field)
+				continue
+			}
+			list, ok := field.([]interface{})
+			if !ok {
+				continue
+			}
+			if seg.index < 0 {
+				next = append(next, list...)
+			} else if seg.index < len(list) {
+				next = append(next, list[seg.index])
+			}
+		}
+		values = next
+	}
+	return values
+}
+
+// Changed returns true if the values at any of the paths differ between the
+// old and the new object.
+func Changed(paths []Path, oldObj, newObj map[string]interface{}) bool {
+	for _, p := range paths {
+		if !reflect.DeepEqual(p.Values(oldObj), p.Values(newObj)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package fieldpath
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	for _, path := range []string{
+		"spec.replicas",
+		"spec.template.spec.containers[*].image",
+		"spec.template.spec.containers[0].securityContext",
+		"spec.selector.matchLabels.app.kubernetes.io/name",
+	} {
+		p, err := Parse(path)
+		require.NoError(t, err, path)
+		assert.Equal(t, path, p.String())
+	}
+
+	for _, path := range []string{
+		"",
+		"spec",
+		"status.replicas",
+		"metadata.labels",
+		"spec..replicas",
+		"spec.containers[]",
+		"spec.containers[-1]",
+		"spec.containers[*][*]",
+		".spec.replicas",
+	} {
+		_, err := Parse(path)
+		assert.Error(t, err, path)
+	}
+}
+
+func TestValues(t *testing.T) {
+	obj := object(t, `{"spec":{"replicas":3,"template":{"spec":{"containers":[
+		{"name":"app","image":"app:v1"},
+		{"name":"sidecar"},
+		{"name":"proxy","image":"proxy:v2"}
+	]}}}}`)
+
+	tests := []struct {
+		path string
+		want []interface{}
+	}{
+		{path: "spec.replicas", want: []interface{}{float64(3)}},
+		{path: "spec.template.spec.containers[*].image", want: []interface{}{"app:v1", "proxy:v2"}},
+		{path: "spec.template.spec.containers[2].name", want: []interface{}{"proxy"}},
+		{path: "spec.template.spec.containers[3].name"},
+		{path: "spec.paused"},
+		{path: "spec.replicas.value"},
+		{path: "spec.replicas[*]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			p, err := Parse(tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, p.Values(obj))
+		})
+	}
+}
+
+func TestChanged(t *testing.T) {
+	oldObj := object(t, `{"spec":{"replicas":3,"template":{"spec":{"containers":[{"name":"app","image":"app:v1"}]}}}}`)
+	images, err := Parse("spec.template.spec.containers[*].image")
+	require.NoError(t, err)
+	paths := []Path{images}
+
+	assert.False(t, Changed(paths, oldObj, object(t, `{"spec":{"replicas":5,"template":{"spec":{"containers":[{"name":"app","image":"app:v1"}]}}}}`)),
+		"replicas are not tracked")
+	assert.True(t, Changed(paths, oldObj, object(t, `{"spec":{"replicas":3,"template":{"spec":{"containers":[{"name":"app","image":"app:v2"}]}}}}`)))
+	assert.True(t, Changed(paths, oldObj, object(t, `{"spec":{"replicas":3,"template":{"spec":{"containers":[{"name":"app","image":"app:v1"},{"name":"b","image":"b:v1"}]}}}}`)),
+		"added container with an image")
+	assert.True(t, Changed(paths, oldObj, object(t, `{"spec":{"replicas":3}}`)), "removed containers")
+	assert.False(t, Changed(nil, oldObj, object(t, `{}`)))
+}
+
+func object(t *testing.T, data string) map[string]interface{} {
+	t.Helper()
+	var obj map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(data), &obj))
+	return obj
+}
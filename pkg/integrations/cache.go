@@ -0,0 +1,80 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/kausality-io/kausality/api/v1alpha1"
+)
+
+// maxCachedTickets bounds the number of cached validation results.
+const maxCachedTickets = 1000
+
+// cachedTicket is a remembered validation result.
+type cachedTicket struct {
+	ticket  *v1alpha1.TicketRef
+	err     error
+	expires time.Time
+}
+
+// cachingValidator reuses validation results for a short time, so that
+// repeated admissions referencing the same ticket, e.g. a rollout touching
+// many objects, do not each call the ticket system. Only valid tickets and
+// definite rejections are cached; transient errors are retried.
+type cachingValidator struct {
+	next    TicketValidator
+	ttl     time.Duration
+	now     func() time.Time
+	mu      sync.Mutex
+	entries map[string]cachedTicket
+}
+
+func newCachingValidator(next TicketValidator, ttl time.Duration) *cachingValidator {
+	return &cachingValidator{
+		next:    next,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]cachedTicket),
+	}
+}
+
+// Validate returns the cached result of ref, or validates it.
+func (c *cachingValidator) Validate(ctx context.Context, ref string) (*v1alpha1.TicketRef, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[ref]
+	c.mu.Unlock()
+	if ok && c.now().Before(entry.expires) {
+		if entry.ticket != nil {
+			ticket := *entry.ticket
+			return &ticket, nil
+		}
+		return nil, entry.err
+	}
+
+	ticket, err := c.next.Validate(ctx, ref)
+	if err != nil && !errors.Is(err, ErrTicketNotFound) && !errors.Is(err, ErrTicketStateNotAllowed) && !errors.Is(err, ErrTicketNotAllowed) {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if len(c.entries) >= maxCachedTickets {
+		for k, e := range c.entries {
+			if !now.Before(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	if len(c.entries) < maxCachedTickets {
+		entry := cachedTicket{err: err, expires: now.Add(c.ttl)}
+		if ticket != nil {
+			cached := *ticket
+			entry.ticket = &cached
+		}
+		c.entries[ref] = entry
+	}
+	return ticket, err
+}
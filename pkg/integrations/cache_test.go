@@ -0,0 +1,57 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kausality-io/kausality/api/v1alpha1"
+)
+
+// countingValidator returns the configured result and counts calls per ref.
+type countingValidator struct {
+	calls map[string]int
+	err   map[string]error
+}
+
+func (v *countingValidator) Validate(_ context.Context, ref string) (*v1alpha1.TicketRef, error) {
+	v.calls[ref]++
+	if err := v.err[ref]; err != nil {
+		return nil, err
+	}
+	return &v1alpha1.TicketRef{ID: ref, State: "open"}, nil
+}
+
+func TestCachingValidator(t *testing.T) {
+	next := &countingValidator{calls: map[string]int{}, err: map[string]error{
+		"closed": fmt.Errorf("%w: closed", ErrTicketStateNotAllowed),
+		"flaky":  errors.New("connection refused"),
+	}}
+	now := time.Now()
+	c := newCachingValidator(next, time.Minute)
+	c.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for range 3 {
+		ticket, err := c.Validate(ctx, "open")
+		require.NoError(t, err)
+		assert.Equal(t, "open", ticket.ID)
+		_, err = c.Validate(ctx, "closed")
+		assert.ErrorIs(t, err, ErrTicketStateNotAllowed)
+		_, err = c.Validate(ctx, "flaky")
+		assert.Error(t, err)
+	}
+	assert.Equal(t, 1, next.calls["open"], "valid tickets are cached")
+	assert.Equal(t, 1, next.calls["closed"], "rejections are cached")
+	assert.Equal(t, 3, next.calls["flaky"], "transient errors are not cached")
+
+	now = now.Add(time.Minute)
+	_, err := c.Validate(ctx, "open")
+	require.NoError(t, err)
+	assert.Equal(t, 2, next.calls["open"], "expired results are validated again")
+}
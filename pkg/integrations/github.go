@@ -0,0 +1,133 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/kausality-io/kausality/api/v1alpha1"
+)
+
+// defaultGitHubURL is the public GitHub API endpoint.
+const defaultGitHubURL = "https://api.github.com"
+
+// githubNamePattern matches GitHub owner and repository names.
+var githubNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// GitHubClient validates GitHub issue references via the GitHub REST API.
+// References can be "42", "#42", or "owner/repo#42"; only repositories of the
+// allowlist are looked up, so callers cannot reference issues of arbitrary
+// repositories or probe private ones with the configured token.
+type GitHubClient struct {
+	baseURL       string
+	repository    string
+	repositories  []string
+	token         string
+	allowedStates []string
+	client        *http.Client
+}
+
+// githubIssue is the subset of the GitHub issue response used for validation.
+type githubIssue struct {
+	Number  int    `json:"number"`
+	State   string `json:"state"`
+	HTMLURL string `json:"html_url"`
+}
+
+// Validate fetches the issue and checks its state against the allowed states.
+func (c *GitHubClient) Validate(ctx context.Context, ref string) (*v1alpha1.TicketRef, error) {
+	repo, number, err := c.parseRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	id := repo + "#" + strconv.Itoa(number)
+
+	owner, name, _ := strings.Cut(repo, "/")
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/issues/%d", c.baseURL, url.PathEscape(owner), url.PathEscape(name), number)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("github request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+		return nil, fmt.Errorf("%w: %s", ErrTicketNotFound, id)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("github returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var issue githubIssue
+	if err := json.NewDecoder(resp.Body).Decode(&issue); err != nil {
+		return nil, fmt.Errorf("failed to decode github issue: %w", err)
+	}
+
+	if err := checkState(id, issue.State, c.allowedStates); err != nil {
+		return nil, err
+	}
+
+	return &v1alpha1.TicketRef{
+		ID:    id,
+		State: issue.State,
+		URL:   issue.HTMLURL,
+	}, nil
+}
+
+// parseRef splits a reference into repository and issue number. The
+// repository must be on the allowlist.
+func (c *GitHubClient) parseRef(ref string) (string, int, error) {
+	repo := c.repository
+	numStr := ref
+	if idx := strings.LastIndex(ref, "#"); idx >= 0 {
+		if idx > 0 {
+			repo = ref[:idx]
+		}
+		numStr = ref[idx+1:]
+	}
+
+	number, err := strconv.Atoi(numStr)
+	if err != nil || number <= 0 {
+		return "", 0, fmt.Errorf("invalid github issue reference %q", ref)
+	}
+	if repo == "" {
+		return "", 0, fmt.Errorf("github issue reference %q has no owner/repo and no default repository is configured", ref)
+	}
+	owner, name, ok := strings.Cut(repo, "/")
+	if !ok || !githubNamePattern.MatchString(owner) || !githubNamePattern.MatchString(name) || name == "." || name == ".." {
+		return "", 0, fmt.Errorf("invalid github repository %q in reference %q: expected owner/repo", repo, ref)
+	}
+	if !c.allowed(repo) {
+		return "", 0, fmt.Errorf("%w: github repository %q is not allowed for tickets", ErrTicketNotAllowed, repo)
+	}
+	return repo, number, nil
+}
+
+// allowed returns true if the repository is the default repository or on
+// the allowlist. GitHub names are case-insensitive.
+func (c *GitHubClient) allowed(repo string) bool {
+	if strings.EqualFold(repo, c.repository) {
+		return true
+	}
+	for _, r := range c.repositories {
+		if strings.EqualFold(repo, r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// errAny marks test cases that expect an error of any kind.
+var errAny = errors.New("any error")
+
+func TestGitHubClient_Validate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/org/repo/issues/42":
+			_, _ = w.Write([]byte(`{"number":42,"state":"open","html_url":"https://github.com/org/repo/issues/42"}`))
+		case "/repos/other/repo/issues/7":
+			_, _ = w.Write([]byte(`{"number":7,"state":"closed","html_url":"https://github.com/other/repo/issues/7"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	v, err := NewTicketValidator(TicketConfig{
+		Provider:      ProviderGitHub,
+		URL:           server.URL,
+		Repository:    "org/repo",
+		Repositories:  []string{"Other/Repo"},
+		AllowedStates: []string{"open"},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		ref     string
+		wantID  string
+		wantErr error
+	}{
+		{name: "bare number", ref: "42", wantID: "org/repo#42"},
+		{name: "hash number", ref: "#42", wantID: "org/repo#42"},
+		{name: "full reference", ref: "org/repo#42", wantID: "org/repo#42"},
+		{name: "closed issue", ref: "other/repo#7", wantErr: ErrTicketStateNotAllowed},
+		{name: "not found", ref: "#1", wantErr: ErrTicketNotFound},
+		{name: "invalid number", ref: "#abc", wantErr: errAny},
+		{name: "invalid repository", ref: "repo#1", wantErr: errAny},
+		{name: "repository not allowed", ref: "evil/repo#42", wantErr: ErrTicketNotAllowed},
+		{name: "path in repository", ref: "org/../../user#42", wantErr: errAny},
+		{name: "escaped path in repository", ref: "org/%2e%2e#42", wantErr: errAny},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ticket, err := v.Validate(context.Background(), tt.ref)
+			if tt.wantErr != nil {
+				require.Error(t, err)
+				if tt.wantErr != errAny {
+					assert.ErrorIs(t, err, tt.wantErr)
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantID, ticket.ID)
+			assert.Equal(t, "open", ticket.State)
+			assert.Equal(t, "https://github.com/org/repo/issues/42", ticket.URL)
+		})
+	}
+}
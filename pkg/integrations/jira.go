@@ -0,0 +1,110 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/kausality-io/kausality/api/v1alpha1"
+)
+
+// jiraKeyPattern matches Jira issue keys like "PROJ-123".
+var jiraKeyPattern = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9_]*)-[0-9]+$`)
+
+// JiraClient validates Jira issue keys via the Jira REST API. If projects
+// are configured, only issues of those projects are looked up.
+type JiraClient struct {
+	baseURL       string
+	projects      []string
+	username      string
+	token         string
+	allowedStates []string
+	client        *http.Client
+}
+
+// jiraIssue is the subset of the Jira issue response used for validation.
+type jiraIssue struct {
+	Key    string `json:"key"`
+	Fields struct {
+		Status struct {
+			Name string `json:"name"`
+		} `json:"status"`
+	} `json:"fields"`
+}
+
+// Validate fetches the issue and checks its status against the allowed states.
+func (c *JiraClient) Validate(ctx context.Context, ref string) (*v1alpha1.TicketRef, error) {
+	m := jiraKeyPattern.FindStringSubmatch(ref)
+	if m == nil {
+		return nil, fmt.Errorf("invalid jira issue key %q: expected PROJECT-NUMBER", ref)
+	}
+	if !c.allowed(m[1]) {
+		return nil, fmt.Errorf("%w: jira project %q is not allowed for tickets", ErrTicketNotAllowed, m[1])
+	}
+
+	endpoint := c.baseURL + "/rest/api/2/issue/" + url.PathEscape(ref) + "?fields=status"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		if c.username != "" {
+			req.SetBasicAuth(c.username, c.token)
+		} else {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("jira request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrTicketNotFound, ref)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("jira returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var issue jiraIssue
+	if err := json.NewDecoder(resp.Body).Decode(&issue); err != nil {
+		return nil, fmt.Errorf("failed to decode jira issue: %w", err)
+	}
+	if issue.Key == "" {
+		issue.Key = ref
+	}
+
+	state := issue.Fields.Status.Name
+	if err := checkState(issue.Key, state, c.allowedStates); err != nil {
+		return nil, err
+	}
+
+	return &v1alpha1.TicketRef{
+		ID:    issue.Key,
+		State: state,
+		URL:   c.baseURL + "/browse/" + issue.Key,
+	}, nil
+}
+
+// allowed returns true if no projects are configured or the project is one
+// of them.
+func (c *JiraClient) allowed(project string) bool {
+	if len(c.projects) == 0 {
+		return true
+	}
+	for _, p := range c.projects {
+		if strings.EqualFold(project, p) {
+			return true
+		}
+	}
+	return false
+}
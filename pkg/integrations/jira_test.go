@@ -0,0 +1,114 @@
+package integrations
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJiraClient_Validate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		assert.Equal(t, "status", r.URL.Query().Get("fields"))
+		switch r.URL.Path {
+		case "/rest/api/2/issue/PROJ-1":
+			_, _ = w.Write([]byte(`{"key":"PROJ-1","fields":{"status":{"name":"In Progress"}}}`))
+		case "/rest/api/2/issue/PROJ-2":
+			_, _ = w.Write([]byte(`{"key":"PROJ-2","fields":{"status":{"name":"Done"}}}`))
+		case "/rest/api/2/issue/PROJ-5":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("secret\n"), 0600))
+
+	v, err := NewTicketValidator(TicketConfig{
+		Provider:      ProviderJira,
+		URL:           server.URL + "/",
+		TokenFile:     tokenFile,
+		Projects:      []string{"PROJ"},
+		AllowedStates: []string{"in progress", "Approved"},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		ref     string
+		wantErr error
+		want    string
+	}{
+		{name: "allowed state", ref: "PROJ-1", want: "In Progress"},
+		{name: "disallowed state", ref: "PROJ-2", wantErr: ErrTicketStateNotAllowed},
+		{name: "not found", ref: "PROJ-3", wantErr: ErrTicketNotFound},
+		{name: "server error", ref: "PROJ-5", wantErr: errAny},
+		{name: "project not allowed", ref: "OTHER-1", wantErr: ErrTicketNotAllowed},
+		{name: "invalid key", ref: "../PROJ-1", wantErr: errAny},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ticket, err := v.Validate(context.Background(), tt.ref)
+			if tt.wantErr != nil {
+				require.Error(t, err)
+				if tt.wantErr != errAny {
+					assert.ErrorIs(t, err, tt.wantErr)
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.ref, ticket.ID)
+			assert.Equal(t, tt.want, ticket.State)
+			assert.Equal(t, server.URL+"/browse/"+tt.ref, ticket.URL)
+		})
+	}
+}
+
+func TestJiraClient_BasicAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "bot@example.com", user)
+		assert.Equal(t, "secret", pass)
+		_, _ = w.Write([]byte(`{"key":"PROJ-1","fields":{"status":{"name":"Open"}}}`))
+	}))
+	defer server.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("secret"), 0600))
+
+	v, err := NewTicketValidator(TicketConfig{
+		Provider:  ProviderJira,
+		URL:       server.URL,
+		Username:  "bot@example.com",
+		TokenFile: tokenFile,
+	})
+	require.NoError(t, err)
+
+	ticket, err := v.Validate(context.Background(), "PROJ-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Open", ticket.State)
+}
+
+func TestNewTicketValidator_Errors(t *testing.T) {
+	_, err := NewTicketValidator(TicketConfig{Provider: ProviderJira})
+	assert.Error(t, err, "jira requires URL")
+
+	_, err = NewTicketValidator(TicketConfig{Provider: "linear"})
+	assert.Error(t, err, "unknown provider")
+
+	_, err = NewTicketValidator(TicketConfig{Provider: ProviderGitHub, TokenFile: "/nonexistent/token"})
+	assert.Error(t, err, "missing token file")
+
+	_, err = NewTicketValidator(TicketConfig{Provider: ProviderGitHub})
+	assert.Error(t, err, "github requires a repository")
+}
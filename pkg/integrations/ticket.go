@@ -0,0 +1,144 @@
+// Package integrations provides clients for external systems consulted during admission.
+package integrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kausality-io/kausality/api/v1alpha1"
+)
+
+// Ticket providers supported by NewTicketValidator.
+const (
+	ProviderJira   = "jira"
+	ProviderGitHub = "github"
+)
+
+var (
+	// ErrTicketNotFound is returned when the referenced ticket does not exist.
+	ErrTicketNotFound = errors.New("ticket not found")
+	// ErrTicketStateNotAllowed is returned when the ticket exists but is in a disallowed state.
+	ErrTicketStateNotAllowed = errors.New("ticket state not allowed")
+	// ErrTicketNotAllowed is returned when the ticket belongs to a repository
+	// or project that is not configured.
+	ErrTicketNotAllowed = errors.New("ticket not allowed")
+)
+
+// TicketValidator verifies that a ticket reference exists and is in an allowed state.
+type TicketValidator interface {
+	// Validate resolves the ticket reference and returns its metadata.
+	// Returns ErrTicketNotFound or ErrTicketStateNotAllowed (wrapped) for invalid tickets.
+	Validate(ctx context.Context, ref string) (*v1alpha1.TicketRef, error)
+}
+
+// TicketConfig configures a ticket validator.
+type TicketConfig struct {
+	// Provider is the ticket system: "jira" or "github".
+	Provider string
+	// URL is the API base URL. Required for Jira, defaults to https://api.github.com for GitHub.
+	URL string
+	// Repository is the default "owner/repo" for GitHub references without a repository.
+	// It is always allowed.
+	Repository string
+	// Repositories lists further "owner/repo" GitHub references may name.
+	// At least one repository is required for GitHub.
+	Repositories []string
+	// Projects lists the Jira project keys tickets may belong to.
+	// If empty, issues of any project are accepted.
+	Projects []string
+	// Username enables basic auth (username + token) instead of bearer auth. Jira only.
+	Username string
+	// TokenFile is the path to a file containing the API token.
+	// If empty, requests are unauthenticated.
+	TokenFile string
+	// AllowedStates lists ticket states that permit a change (case-insensitive).
+	// If empty, any existing ticket is accepted.
+	AllowedStates []string
+	// Timeout is the request timeout. Default is 5 seconds.
+	Timeout time.Duration
+	// CacheTTL is how long validation results are reused. Default is
+	// DefaultTicketCacheTTL; negative disables the cache.
+	CacheTTL time.Duration
+}
+
+// DefaultTicketCacheTTL is how long validation results are reused by default.
+const DefaultTicketCacheTTL = 30 * time.Second
+
+// NewTicketValidator creates a TicketValidator for the configured provider.
+func NewTicketValidator(cfg TicketConfig) (TicketValidator, error) {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+
+	token := ""
+	if cfg.TokenFile != "" {
+		data, err := os.ReadFile(cfg.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read token file: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+
+	httpClient := &http.Client{Timeout: cfg.Timeout}
+
+	var validator TicketValidator
+	switch cfg.Provider {
+	case ProviderJira:
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("jira ticket validation requires a URL")
+		}
+		validator = &JiraClient{
+			baseURL:       strings.TrimSuffix(cfg.URL, "/"),
+			projects:      cfg.Projects,
+			username:      cfg.Username,
+			token:         token,
+			allowedStates: cfg.AllowedStates,
+			client:        httpClient,
+		}
+	case ProviderGitHub:
+		if cfg.Repository == "" && len(cfg.Repositories) == 0 {
+			return nil, fmt.Errorf("github ticket validation requires a repository")
+		}
+		baseURL := cfg.URL
+		if baseURL == "" {
+			baseURL = defaultGitHubURL
+		}
+		validator = &GitHubClient{
+			baseURL:       strings.TrimSuffix(baseURL, "/"),
+			repository:    cfg.Repository,
+			repositories:  cfg.Repositories,
+			token:         token,
+			allowedStates: cfg.AllowedStates,
+			client:        httpClient,
+		}
+	default:
+		return nil, fmt.Errorf("unknown ticket provider %q: must be %q or %q", cfg.Provider, ProviderJira, ProviderGitHub)
+	}
+
+	if cfg.CacheTTL == 0 {
+		cfg.CacheTTL = DefaultTicketCacheTTL
+	}
+	if cfg.CacheTTL < 0 {
+		return validator, nil
+	}
+	return newCachingValidator(validator, cfg.CacheTTL), nil
+}
+
+// checkState returns ErrTicketStateNotAllowed if state is not in allowed.
+// An empty allowed list accepts any state.
+func checkState(id, state string, allowed []string) error {
+	if len(allowed) == 0 {
+		return nil
+	}
+	for _, s := range allowed {
+		if strings.EqualFold(s, state) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s is %q, allowed: %s", ErrTicketStateNotAllowed, id, state, strings.Join(allowed, ", "))
+}
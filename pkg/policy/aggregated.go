@@ -0,0 +1,125 @@
+package policy
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/go-logr/logr"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/kausality-io/kausality/pkg/drift"
+)
+
+// mutatingVerbs are the verbs intercepted by the webhook. Resources without
+// any of them, e.g. the read-only metrics.k8s.io resources served by
+// metrics-server, can't be webhooked.
+var mutatingVerbs = []string{"create", "update", "patch", "delete"}
+
+// webhookable returns true if mutations of the resource reach admission.
+func webhookable(r metav1.APIResource) bool {
+	for _, verb := range r.Verbs {
+		if slices.Contains(mutatingVerbs, verb) {
+			return true
+		}
+	}
+	return false
+}
+
+// AggregatedIndex tracks the group versions served by aggregated API servers,
+// i.e. registered by APIServices with a service. The kube-apiserver proxies
+// their requests; the aggregated server runs admission itself, calling the
+// webhook if it is built on k8s.io/apiserver. AggregatedIndex implements
+// drift.AggregatedAPIs.
+type AggregatedIndex struct {
+	mu sync.RWMutex
+	// groupVersions maps APIService names to the aggregated group version
+	// they register
+	groupVersions map[string]schema.GroupVersion
+}
+
+var _ drift.AggregatedAPIs = &AggregatedIndex{}
+
+// NewAggregatedIndex creates an empty AggregatedIndex.
+func NewAggregatedIndex() *AggregatedIndex {
+	return &AggregatedIndex{groupVersions: make(map[string]schema.GroupVersion)}
+}
+
+// IsAggregated returns true if gv is served by an aggregated API server.
+func (i *AggregatedIndex) IsAggregated(gv schema.GroupVersion) bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	for _, aggregated := range i.groupVersions {
+		if aggregated == gv {
+			return true
+		}
+	}
+	return false
+}
+
+// Update records the APIService obj. Local APIServices, served by the
+// kube-apiserver itself, are not aggregated.
+func (i *AggregatedIndex) Update(obj *unstructured.Unstructured) {
+	group, _, _ := unstructured.NestedString(obj.Object, "spec", "group")
+	version, _, _ := unstructured.NestedString(obj.Object, "spec", "version")
+	service, _, _ := unstructured.NestedMap(obj.Object, "spec", "service")
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if service == nil {
+		delete(i.groupVersions, obj.GetName())
+		return
+	}
+	i.groupVersions[obj.GetName()] = schema.GroupVersion{Group: group, Version: version}
+}
+
+// Delete forgets the APIService with the given name.
+func (i *AggregatedIndex) Delete(name string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	delete(i.groupVersions, name)
+}
+
+// aggregatedWatcher keeps an AggregatedIndex updated from APIServices.
+type aggregatedWatcher struct {
+	client client.Client
+	index  *AggregatedIndex
+	log    logr.Logger
+}
+
+// Reconcile updates the index for a changed APIService.
+func (w *aggregatedWatcher) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(apiServiceGVK)
+	if err := w.client.Get(ctx, req.NamespacedName, obj); err != nil {
+		if apierrors.IsNotFound(err) {
+			w.index.Delete(req.Name)
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	w.index.Update(obj)
+	w.log.V(1).Info("APIService changed", "name", req.Name)
+	return reconcile.Result{}, nil
+}
+
+// SetupAggregatedIndex keeps index updated from the APIServices of the cluster.
+func SetupAggregatedIndex(mgr ctrl.Manager, index *AggregatedIndex, log logr.Logger) error {
+	apiService := &unstructured.Unstructured{}
+	apiService.SetGroupVersionKind(apiServiceGVK)
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("aggregated-apis").
+		For(apiService).
+		// Every webhook replica resolves parents, leader or not
+		WithOptions(controller.Options{NeedLeaderElection: ptr.To(false)}).
+		Complete(&aggregatedWatcher{client: mgr.GetClient(), index: index, log: log.WithName("aggregated-apis")})
+}
@@ -0,0 +1,43 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestAggregatedIndex(t *testing.T) {
+	apiService := func(name, group, version string, service map[string]interface{}) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"spec": map[string]interface{}{"group": group, "version": version},
+		}}
+		obj.SetGroupVersionKind(apiServiceGVK)
+		obj.SetName(name)
+		if service != nil {
+			obj.Object["spec"].(map[string]interface{})["service"] = service
+		}
+		return obj
+	}
+	metrics := schema.GroupVersion{Group: "metrics.k8s.io", Version: "v1beta1"}
+	apps := schema.GroupVersion{Group: "apps", Version: "v1"}
+
+	index := NewAggregatedIndex()
+	index.Update(apiService("v1.apps", "apps", "v1", nil))
+	index.Update(apiService("v1beta1.metrics.k8s.io", "metrics.k8s.io", "v1beta1",
+		map[string]interface{}{"namespace": "kube-system", "name": "metrics-server"}))
+	assert.True(t, index.IsAggregated(metrics))
+	assert.False(t, index.IsAggregated(apps), "local APIService")
+	assert.False(t, index.IsAggregated(schema.GroupVersion{Group: "metrics.k8s.io", Version: "v1"}))
+
+	// Served locally again, e.g. after metrics-server was replaced by a CRD
+	index.Update(apiService("v1beta1.metrics.k8s.io", "metrics.k8s.io", "v1beta1", nil))
+	assert.False(t, index.IsAggregated(metrics))
+
+	index.Update(apiService("v1beta1.metrics.k8s.io", "metrics.k8s.io", "v1beta1",
+		map[string]interface{}{"namespace": "kube-system", "name": "metrics-server"}))
+	index.Delete("v1beta1.metrics.k8s.io")
+	assert.False(t, index.IsAggregated(metrics))
+}
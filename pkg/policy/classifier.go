@@ -0,0 +1,36 @@
+package policy
+
+import (
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+	"github.com/kausality-io/kausality/pkg/drift"
+)
+
+// classifierFor builds the classifier configured by a policy's controller
+// identity, nil for the default. Unknown classifiers are skipped.
+func classifierFor(identity *kausalityv1beta1.ControllerIdentity) drift.Classifier {
+	if identity == nil {
+		return nil
+	}
+	var classifiers []drift.Classifier
+	for _, t := range identity.Classifiers {
+		switch t {
+		case kausalityv1beta1.ClassifierUpdaterHash:
+			classifiers = append(classifiers, drift.HashClassifier{})
+		case kausalityv1beta1.ClassifierFieldManager:
+			classifiers = append(classifiers, drift.FieldManagerClassifier{})
+		case kausalityv1beta1.ClassifierServiceAccount:
+			classifiers = append(classifiers, drift.ServiceAccountClassifier{Patterns: identity.ServiceAccounts})
+		}
+	}
+	if len(classifiers) == 0 {
+		return nil
+	}
+	combination := drift.CombineFirst
+	switch identity.Combination {
+	case kausalityv1beta1.ClassifierCombinationAny:
+		combination = drift.CombineAny
+	case kausalityv1beta1.ClassifierCombinationAll:
+		combination = drift.CombineAll
+	}
+	return drift.Combine(combination, classifiers...)
+}
@@ -2,28 +2,40 @@
 package policy
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"net"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
 
 	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
-	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
 )
 
 const (
@@ -39,24 +51,30 @@ const (
 	// ConditionTypeWebhookConfigured indicates webhook rules are applied.
 	ConditionTypeWebhookConfigured = "WebhookConfigured"
 
-	// AggregationLabel is the label used for RBAC aggregation.
-	// ClusterRoles with this label are aggregated into the webhook-resources role.
-	AggregationLabel = "kausality.io/aggregate-to-webhook-resources"
+	// ConditionTypeRBACConfigured indicates the webhook's resource access
+	// ClusterRole covers the policy's resources.
+	ConditionTypeRBACConfigured = "RBACConfigured"
 
-	// ClusterRolePrefix is the prefix for generated per-policy ClusterRoles.
-	ClusterRolePrefix = "kausality-policy-"
+	// ConditionTypeDiscoverySynced indicates the wildcard resource rules of
+	// the policy were expanded via discovery. Its lastTransitionTime is when
+	// the expansion last changed, e.g. because a CRD was installed.
+	ConditionTypeDiscoverySynced = "DiscoverySynced"
 
-	// ManagedByLabel indicates a resource is managed by kausality.
-	ManagedByLabel = "app.kubernetes.io/managed-by"
-
-	// PolicyNameLabel indicates which policy owns the ClusterRole.
-	PolicyNameLabel = "kausality.io/policy"
+	// ConditionTypeWebhookHealthy indicates the API server can call the
+	// webhook: its service has ready endpoints and its certificates are
+	// valid. Without, requests fail or are admitted without drift detection.
+	ConditionTypeWebhookHealthy = "WebhookHealthy"
 
 	// DiscoveryResyncPeriod is how often policies are re-reconciled to pick up
-	// new CRDs that may have been registered after initial policy creation.
-	// This ensures wildcard resource rules ("*") eventually expand to include
-	// newly registered resources.
+	// resources missed by the CRD and APIService watches. This ensures
+	// wildcard resource rules ("*") eventually expand to include newly
+	// registered resources.
 	DiscoveryResyncPeriod = 5 * time.Minute
+
+	// ManagedByLabel and PolicyNameLabel marked the per-policy ClusterRoles
+	// generated by earlier versions, which are pruned.
+	ManagedByLabel  = "app.kubernetes.io/managed-by"
+	PolicyNameLabel = "kausality.io/policy"
 )
 
 // Controller reconciles Kausality resources.
@@ -69,11 +87,48 @@ type Controller struct {
 	// WebhookName is the name of the MutatingWebhookConfiguration to manage.
 	WebhookName string
 
+	// WebhookAPIVersion is the admissionregistration.k8s.io version serving
+	// the MutatingWebhookConfiguration: WebhookAPIVersionV1 (default) or
+	// WebhookAPIVersionV1beta1 on clusters before Kubernetes 1.16.
+	WebhookAPIVersion string
+
 	// WebhookServiceRef identifies the webhook service.
 	WebhookServiceRef WebhookServiceRef
 
+	// WebhookRoleName is the name of the ClusterRole granting the webhook
+	// access to tracked resources. Its rules are replaced with exactly the
+	// access needed by all policies. If empty, RBAC is not managed.
+	WebhookRoleName string
+
 	// ExcludedNamespaces are namespaces to exclude from webhook rules.
 	ExcludedNamespaces []string
+
+	// WebhookCertSecretName is the name of the kubernetes.io/tls Secret in
+	// the webhook service's namespace holding the serving certificate. If
+	// empty, only the caBundle is checked for webhook health.
+	WebhookCertSecretName string
+
+	// WebhookDialer connects to the webhook to check that it is reachable.
+	// If nil, reachability is not checked.
+	WebhookDialer func(ctx context.Context, network, address string) (net.Conn, error)
+
+	// CertExpiryWarning is how long before expiry certificates make the
+	// webhook unhealthy. Defaults to DefaultCertExpiryWarning.
+	CertExpiryWarning time.Duration
+
+	// Recorder emits Events on policies with the resources which gained or
+	// lost tracking, or changed mode, by their changes. If nil, no Events
+	// are emitted.
+	Recorder events.EventRecorder
+
+	// EffectReporter receives the resources which gained or lost tracking,
+	// or changed mode, by policy changes. If nil, no reports are sent.
+	EffectReporter EffectReporter
+
+	// observed are the policies as last observed, by name, nil until the
+	// first reconciliation.
+	observed   map[string]*observedPolicy
+	observedMu sync.Mutex
 }
 
 // WebhookServiceRef identifies the webhook service.
@@ -89,7 +144,7 @@ func (c *Controller) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	log := c.Log.WithValues("kausality", req.Name)
 
 	// Fetch the Kausality instance
-	var policy kausalityv1alpha1.Kausality
+	var policy kausalityv1beta1.Kausality
 	if err := c.Get(ctx, req.NamespacedName, &policy); err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
@@ -97,13 +152,19 @@ func (c *Controller) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	// Handle deletion
 	if !policy.DeletionTimestamp.IsZero() {
 		if controllerutil.ContainsFinalizer(&policy, FinalizerName) {
-			// ClusterRole is cleaned up by Kubernetes GC via owner reference
-
 			// Reconcile webhook to remove this policy's rules
 			if err := c.reconcileWebhook(ctx, log); err != nil {
 				return requeueOnConflict(err)
 			}
 
+			// Record which resources lost tracking by the deletion
+			c.recordEffect(ctx, log, &policy)
+
+			// Revoke webhook access no longer needed by other policies
+			if err := c.reconcileRBAC(ctx, log); err != nil {
+				return requeueOnConflict(err)
+			}
+
 			// Remove finalizer
 			controllerutil.RemoveFinalizer(&policy, FinalizerName)
 			if err := c.Update(ctx, &policy); err != nil {
@@ -133,27 +194,51 @@ func (c *Controller) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return requeueOnConflict(err)
 	}
 
-	// Reconcile RBAC for this policy
-	if err := c.reconcileClusterRole(ctx, log, &policy); err != nil {
-		if !apierrors.IsConflict(err) {
-			log.Error(err, "failed to reconcile RBAC")
-		}
-		// Don't fail the whole reconciliation for RBAC errors, but requeue on conflict
-		if apierrors.IsConflict(err) {
-			return ctrl.Result{Requeue: true}, nil
+	c.setCondition(&policy, ConditionTypeWebhookConfigured, metav1.ConditionTrue, "RulesApplied", "Webhook rules updated")
+	c.setDiscoveryCondition(&policy)
+
+	// Record which resources gained or lost tracking by the policy change,
+	// for reviewers of policy changes
+	if effect := c.recordEffect(ctx, log, &policy); effect != nil {
+		policy.Status.Effect = effect
+	}
+
+	// Reconcile the webhook's access to tracked resources
+	if err := c.reconcileRBAC(ctx, log); err != nil {
+		c.setCondition(&policy, ConditionTypeRBACConfigured, metav1.ConditionFalse, "ReconcileFailed", err.Error())
+		c.setCondition(&policy, ConditionTypeReady, metav1.ConditionFalse, "RBACNotConfigured", "Webhook RBAC configuration failed")
+		if statusErr := c.Status().Update(ctx, &policy); statusErr != nil {
+			if !apierrors.IsConflict(statusErr) {
+				log.Error(statusErr, "failed to update status")
+			}
 		}
+		return requeueOnConflict(err)
+	}
+	if c.WebhookRoleName != "" {
+		c.setCondition(&policy, ConditionTypeRBACConfigured, metav1.ConditionTrue, "RulesApplied", "Webhook RBAC rules updated")
 	}
 
+	// Check that the API server can call the webhook, which otherwise looks
+	// like no drift anywhere
+	health := c.checkWebhookHealth(ctx)
+	c.setHealthCondition(&policy, health)
+
 	// Update status
-	c.setCondition(&policy, ConditionTypeWebhookConfigured, metav1.ConditionTrue, "RulesApplied", "Webhook rules updated")
-	c.setCondition(&policy, ConditionTypeReady, metav1.ConditionTrue, "Reconciled", "Policy is active")
+	requeueAfter := DiscoveryResyncPeriod
+	if health.Healthy() {
+		c.setCondition(&policy, ConditionTypeReady, metav1.ConditionTrue, "Reconciled", "Policy is active")
+	} else {
+		log.Info("webhook is unhealthy", "reason", health.Reason, "problems", health.Problems)
+		c.setCondition(&policy, ConditionTypeReady, metav1.ConditionFalse, "WebhookUnhealthy", health.Message())
+		requeueAfter = WebhookHealthRetryPeriod
+	}
 	if err := c.Status().Update(ctx, &policy); err != nil {
 		return requeueOnConflict(err)
 	}
 
 	// Requeue to periodically re-expand wildcard resources via discovery.
 	// This ensures new CRDs registered after policy creation are picked up.
-	return ctrl.Result{RequeueAfter: DiscoveryResyncPeriod}, nil
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
 }
 
 // requeueOnConflict returns a requeue result without error for conflict errors,
@@ -168,51 +253,118 @@ func requeueOnConflict(err error) (ctrl.Result, error) {
 // reconcileWebhook updates the MutatingWebhookConfiguration based on all Kausality policies.
 func (c *Controller) reconcileWebhook(ctx context.Context, log logr.Logger) error {
 	// List all Kausality policies
-	var policies kausalityv1alpha1.KausalityList
+	var policies kausalityv1beta1.KausalityList
 	if err := c.List(ctx, &policies); err != nil {
 		return fmt.Errorf("failed to list policies: %w", err)
 	}
 
+	// Get the webhook configuration
+	webhook, err := c.getWebhookConfiguration(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Update the webhook rules
+	if len(webhook.Webhooks) == 0 {
+		return fmt.Errorf("webhook configuration %q has no webhooks defined", c.WebhookName)
+	}
+	primary := webhook.Webhooks[0]
+	defaultPolicy := admissionregistrationv1.Fail
+	if primary.FailurePolicy != nil {
+		defaultPolicy = *primary.FailurePolicy
+	}
+
 	// Aggregate rules from all policies
-	rules, err := c.aggregateRules(policies.Items)
+	rules, err := c.aggregateRules(policies.Items, defaultPolicy)
 	if err != nil {
 		return fmt.Errorf("failed to aggregate rules: %w", err)
 	}
 
-	log.Info("aggregated webhook rules", "ruleCount", len(rules), "policyCount", len(policies.Items))
+	log.Info("aggregated webhook rules", "ruleCount", len(rules[defaultPolicy]), "policyCount", len(policies.Items))
 
-	// Get or create the webhook configuration
-	var webhook admissionregistrationv1.MutatingWebhookConfiguration
-	webhookKey := client.ObjectKey{Name: c.WebhookName}
-	if err := c.Get(ctx, webhookKey, &webhook); err != nil {
-		return fmt.Errorf("failed to get webhook configuration %q: %w", c.WebhookName, err)
+	// The first webhook gets the rules with its failure policy, rules with
+	// the other failure policy go to a copy of it
+	primary.Rules = rules[defaultPolicy]
+	primary.NamespaceSelector = c.buildNamespaceSelector()
+	managed := map[string]bool{}
+	var extra []admissionregistrationv1.MutatingWebhook
+	for _, failurePolicy := range []admissionregistrationv1.FailurePolicyType{admissionregistrationv1.Fail, admissionregistrationv1.Ignore} {
+		name := failurePolicyWebhookName(failurePolicy, primary.Name)
+		managed[name] = true
+		if failurePolicy == defaultPolicy || len(rules[failurePolicy]) == 0 {
+			continue
+		}
+		wh := *primary.DeepCopy()
+		wh.Name = name
+		wh.FailurePolicy = &failurePolicy
+		wh.Rules = rules[failurePolicy]
+		extra = append(extra, wh)
+	}
+	webhooks := []admissionregistrationv1.MutatingWebhook{primary}
+	for _, wh := range webhook.Webhooks[1:] {
+		if !managed[wh.Name] {
+			webhooks = append(webhooks, wh)
+		}
 	}
+	webhook.Webhooks = append(webhooks, extra...)
 
-	// Update the webhook rules
-	if len(webhook.Webhooks) == 0 {
-		return fmt.Errorf("webhook configuration %q has no webhooks defined", c.WebhookName)
+	if err := c.updateWebhookConfiguration(ctx, webhook); err != nil {
+		return fmt.Errorf("failed to update webhook configuration: %w", err)
 	}
 
-	// Update the first webhook's rules
-	webhook.Webhooks[0].Rules = rules
-	webhook.Webhooks[0].NamespaceSelector = c.buildNamespaceSelector()
+	return c.reconcileConversion(ctx, primary.ClientConfig.CABundle)
+}
 
-	if err := c.Update(ctx, &webhook); err != nil {
-		return fmt.Errorf("failed to update webhook configuration: %w", err)
+// InjectCABundle sets the caBundle of all webhooks in the MutatingWebhookConfiguration
+// and of the Kausality conversion webhook.
+// It is used when certificates are provisioned by the controller rather than
+// cert-manager, whose CA injector otherwise owns the field.
+func (c *Controller) InjectCABundle(ctx context.Context, caBundle []byte) error {
+	webhook, err := c.getWebhookConfiguration(ctx)
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	for i := range webhook.Webhooks {
+		if !bytes.Equal(webhook.Webhooks[i].ClientConfig.CABundle, caBundle) {
+			webhook.Webhooks[i].ClientConfig.CABundle = caBundle
+			changed = true
+		}
+	}
+	if err := c.reconcileConversion(ctx, caBundle); err != nil {
+		return err
+	}
+	if !changed {
+		return nil
 	}
 
+	if err := c.updateWebhookConfiguration(ctx, webhook); err != nil {
+		return fmt.Errorf("failed to inject CA bundle into webhook configuration: %w", err)
+	}
+	c.Log.Info("injected CA bundle into webhook configuration", "webhook", c.WebhookName)
 	return nil
 }
 
-// aggregateRules builds webhook rules from all Kausality policies.
-func (c *Controller) aggregateRules(policies []kausalityv1alpha1.Kausality) ([]admissionregistrationv1.RuleWithOperations, error) {
-	// Collect all resource rules, deduplicating by apiGroup+resource
-	type resourceKey struct {
-		apiGroup string
-		resource string
+// aggregateRules builds webhook rules from all Kausality policies, grouped by
+// failure policy. Rules without a failure policy get defaultPolicy. A
+// resource matched with different failure policies is intercepted once, with
+// Fail.
+func (c *Controller) aggregateRules(policies []kausalityv1beta1.Kausality, defaultPolicy admissionregistrationv1.FailurePolicyType) (map[admissionregistrationv1.FailurePolicyType][]admissionregistrationv1.RuleWithOperations, error) {
+	// Collect resource paths (resources and resource/subresource) per apiGroup
+	// and operation set, deduplicating across policies
+	type pathKey struct {
+		apiGroup   string
+		operations int // index into ruleOperations
+		path       string
+	}
+	failurePolicies := make(map[pathKey]admissionregistrationv1.FailurePolicyType)
+	add := func(apiGroup string, operations int, path string, failurePolicy admissionregistrationv1.FailurePolicyType) {
+		key := pathKey{apiGroup: apiGroup, operations: operations, path: path}
+		if existing, ok := failurePolicies[key]; !ok || existing != admissionregistrationv1.Fail {
+			failurePolicies[key] = failurePolicy
+		}
 	}
-	seen := make(map[resourceKey]bool)
-	var allResources []resourceKey
 
 	for _, policy := range policies {
 		// Skip policies being deleted
@@ -225,90 +377,115 @@ func (c *Controller) aggregateRules(policies []kausalityv1alpha1.Kausality) ([]a
 			if err != nil {
 				return nil, fmt.Errorf("failed to expand resources for policy %q: %w", policy.Name, err)
 			}
+			subresources := EffectiveSubresources(rule)
+			failurePolicy := defaultPolicy
+			if rule.FailurePolicy != nil {
+				failurePolicy = admissionregistrationv1.FailurePolicyType(*rule.FailurePolicy)
+			}
 
 			for _, apiGroup := range rule.APIGroups {
 				for _, resource := range resources {
-					key := resourceKey{apiGroup: apiGroup, resource: resource}
-					if !seen[key] {
-						seen[key] = true
-						allResources = append(allResources, key)
+					add(apiGroup, opsSpec, resource, failurePolicy)
+					for _, sub := range subresources {
+						if ops, ok := subresourceOperations(sub); ok {
+							add(apiGroup, ops, resource+"/"+sub.Name, failurePolicy)
+						}
 					}
 				}
 			}
 		}
 	}
 
-	// Group resources by apiGroup for efficient webhook rules
-	groupedResources := make(map[string][]string)
-	for _, res := range allResources {
-		groupedResources[res.apiGroup] = append(groupedResources[res.apiGroup], res.resource)
+	type ruleKey struct {
+		failurePolicy admissionregistrationv1.FailurePolicyType
+		apiGroup      string
+		operations    int
+	}
+	seen := make(map[ruleKey][]string)
+	for key, failurePolicy := range failurePolicies {
+		rk := ruleKey{failurePolicy: failurePolicy, apiGroup: key.apiGroup, operations: key.operations}
+		seen[rk] = append(seen[rk], key.path)
 	}
 
 	// Sort for deterministic output
-	var apiGroups []string
-	for g := range groupedResources {
-		apiGroups = append(apiGroups, g)
+	var keys []ruleKey
+	for key := range seen {
+		keys = append(keys, key)
 	}
-	sort.Strings(apiGroups)
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].apiGroup != keys[j].apiGroup {
+			return keys[i].apiGroup < keys[j].apiGroup
+		}
+		return keys[i].operations < keys[j].operations
+	})
 
 	// Build webhook rules
-	var rules []admissionregistrationv1.RuleWithOperations
-	fail := admissionregistrationv1.Fail
+	rules := make(map[admissionregistrationv1.FailurePolicyType][]admissionregistrationv1.RuleWithOperations)
 	allScopes := admissionregistrationv1.AllScopes
 
-	for _, apiGroup := range apiGroups {
-		resources := groupedResources[apiGroup]
+	for _, key := range keys {
+		resources := seen[key]
 		sort.Strings(resources)
 
-		// Spec changes rule (CREATE, UPDATE, DELETE)
-		rules = append(rules, admissionregistrationv1.RuleWithOperations{
-			Operations: []admissionregistrationv1.OperationType{
-				admissionregistrationv1.Create,
-				admissionregistrationv1.Update,
-				admissionregistrationv1.Delete,
-			},
+		rules[key.failurePolicy] = append(rules[key.failurePolicy], admissionregistrationv1.RuleWithOperations{
+			Operations: ruleOperations[key.operations],
 			Rule: admissionregistrationv1.Rule{
-				APIGroups:   []string{apiGroup},
+				APIGroups:   []string{key.apiGroup},
 				APIVersions: []string{"*"},
 				Resources:   resources,
 				Scope:       &allScopes,
 			},
 		})
-
-		// Status subresource rule (UPDATE only) - for controller identification
-		var statusResources []string
-		for _, r := range resources {
-			statusResources = append(statusResources, r+"/status")
-		}
-		rules = append(rules, admissionregistrationv1.RuleWithOperations{
-			Operations: []admissionregistrationv1.OperationType{
-				admissionregistrationv1.Update,
-			},
-			Rule: admissionregistrationv1.Rule{
-				APIGroups:   []string{apiGroup},
-				APIVersions: []string{"*"},
-				Resources:   statusResources,
-				Scope:       &allScopes,
-			},
-		})
 	}
 
-	_ = fail // Will be used when we configure failurePolicy
 	return rules, nil
 }
 
-// expandResources expands a ResourceRule, resolving "*" via discovery.
-func (c *Controller) expandResources(rule kausalityv1alpha1.ResourceRule) ([]string, error) {
-	// Check if we need to expand wildcards
-	hasWildcard := false
-	for _, r := range rule.Resources {
-		if r == "*" {
-			hasWildcard = true
-			break
+// failurePolicyWebhookName returns the name of the webhook managed for rules
+// whose failure policy differs from the primary webhook, e.g.
+// "ignore.mutating.webhook.kausality.io".
+func failurePolicyWebhookName(failurePolicy admissionregistrationv1.FailurePolicyType, primary string) string {
+	return strings.ToLower(string(failurePolicy)) + "." + primary
+}
+
+// Operation sets of webhook rules, in rule order per apiGroup.
+const (
+	// opsSpec covers spec changes of the resource itself
+	opsSpec = iota
+	// opsController covers subresources identifying controllers, e.g. status
+	opsController
+	// opsTrack covers tracked subresources, e.g. scale or ephemeralcontainers
+	opsTrack
+	// opsConnect covers tracked CONNECT subresources, e.g. exec
+	opsConnect
+)
+
+var ruleOperations = [][]admissionregistrationv1.OperationType{
+	opsSpec:       {admissionregistrationv1.Create, admissionregistrationv1.Update, admissionregistrationv1.Delete},
+	opsController: {admissionregistrationv1.Update},
+	opsTrack:      {admissionregistrationv1.Create, admissionregistrationv1.Update},
+	opsConnect:    {admissionregistrationv1.Connect},
+}
+
+// subresourceOperations returns the operation set intercepted for a
+// subresource, or false if it is ignored.
+func subresourceOperations(sub kausalityv1beta1.SubresourceRule) (int, bool) {
+	switch sub.Handling {
+	case kausalityv1beta1.SubresourceHandlingController:
+		return opsController, true
+	case kausalityv1beta1.SubresourceHandlingTrack:
+		if IsConnectSubresource(sub.Name) {
+			return opsConnect, true
 		}
+		return opsTrack, true
+	default:
+		return 0, false
 	}
+}
 
-	if !hasWildcard {
+// expandResources expands a ResourceRule, resolving "*" via discovery.
+func (c *Controller) expandResources(rule kausalityv1beta1.ResourceRule) ([]string, error) {
+	if !hasWildcard(rule) {
 		// No wildcard, return as-is (minus excluded)
 		return filterExcluded(rule.Resources, rule.Excluded), nil
 	}
@@ -326,6 +503,48 @@ func (c *Controller) expandResources(rule kausalityv1alpha1.ResourceRule) ([]str
 	return filterExcluded(allResources, rule.Excluded), nil
 }
 
+// setDiscoveryCondition records the expansion of the policy's wildcard rules.
+// Policies without wildcard rules have no DiscoverySynced condition.
+func (c *Controller) setDiscoveryCondition(policy *kausalityv1beta1.Kausality) {
+	if !hasWildcards(policy) {
+		return
+	}
+	discovered := 0
+	for _, rule := range policy.Spec.Resources {
+		if !hasWildcard(rule) {
+			continue
+		}
+		resources, err := c.expandResources(rule)
+		if err != nil {
+			c.setCondition(policy, ConditionTypeDiscoverySynced, metav1.ConditionFalse, "DiscoveryFailed", err.Error())
+			return
+		}
+		discovered += len(resources)
+	}
+	c.setCondition(policy, ConditionTypeDiscoverySynced, metav1.ConditionTrue, "Synced",
+		fmt.Sprintf("Wildcard rules expanded to %d resources", discovered))
+}
+
+// hasWildcard returns true if the rule's resources are expanded via discovery.
+func hasWildcard(rule kausalityv1beta1.ResourceRule) bool {
+	for _, r := range rule.Resources {
+		if r == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// hasWildcards returns true if any rule of the policy has a wildcard.
+func hasWildcards(policy *kausalityv1beta1.Kausality) bool {
+	for _, rule := range policy.Spec.Resources {
+		if hasWildcard(rule) {
+			return true
+		}
+	}
+	return false
+}
+
 // discoverResources returns all resources for an API group.
 func (c *Controller) discoverResources(apiGroup string) ([]string, error) {
 	// Get all API resources for the group
@@ -357,6 +576,10 @@ func (c *Controller) discoverResources(apiGroup string) ([]string, error) {
 			if strings.Contains(r.Name, "/") {
 				continue
 			}
+			// Skip read-only resources, e.g. of aggregated metrics APIs
+			if !webhookable(r) {
+				continue
+			}
 			resources = append(resources, r.Name)
 		}
 	}
@@ -392,7 +615,7 @@ func (c *Controller) buildNamespaceSelector() *metav1.LabelSelector {
 }
 
 // setCondition sets a condition on the Kausality resource.
-func (c *Controller) setCondition(policy *kausalityv1alpha1.Kausality, condType string, status metav1.ConditionStatus, reason, message string) {
+func (c *Controller) setCondition(policy *kausalityv1beta1.Kausality, condType string, status metav1.ConditionStatus, reason, message string) {
 	now := metav1.Now()
 
 	// Find existing condition
@@ -423,113 +646,234 @@ func (c *Controller) setCondition(policy *kausalityv1alpha1.Kausality, condType
 	})
 }
 
+// apiServiceGVK is the kind of aggregated API registrations, watched as
+// unstructured objects to avoid depending on the aggregator's types.
+var apiServiceGVK = schema.GroupVersionKind{Group: "apiregistration.k8s.io", Version: "v1", Kind: "APIService"}
+
 // SetupWithManager sets up the controller with the Manager.
 func (c *Controller) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&kausalityv1alpha1.Kausality{}).
-		// Watch CRDs to re-expand wildcards when new resources are registered
+	apiService := &unstructured.Unstructured{}
+	apiService.SetGroupVersionKind(apiServiceGVK)
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&kausalityv1beta1.Kausality{}).
+		// Watch CRDs and aggregated APIs to re-expand wildcards as soon as
+		// new resources are served
 		Watches(&apiextensionsv1.CustomResourceDefinition{},
-			handler.EnqueueRequestsFromMapFunc(c.mapCRDToKausalityPolicies)).
-		Complete(c)
+			handler.EnqueueRequestsFromMapFunc(c.mapCRDToKausalityPolicies),
+			builder.WithPredicates(servedChanged(crdServed))).
+		Watches(apiService,
+			handler.EnqueueRequestsFromMapFunc(c.mapAPIServiceToKausalityPolicies),
+			builder.WithPredicates(servedChanged(apiServiceServed)))
+
+	// Watch the webhook configuration to restore its rules and namespace
+	// selector when others change them: API servers do not call webhooks
+	// for webhook configurations, so the webhook cannot protect it
+	if c.WebhookAPIVersion != WebhookAPIVersionV1beta1 {
+		b = b.Watches(&admissionregistrationv1.MutatingWebhookConfiguration{},
+			handler.EnqueueRequestsFromMapFunc(c.mapWebhookObjectToKausalityPolicies),
+			builder.WithPredicates(predicate.NewPredicateFuncs(func(obj client.Object) bool {
+				return obj.GetName() == c.WebhookName
+			}), predicate.GenerationChangedPredicate{}))
+	}
+
+	// Watch the webhook service, its endpoints and certificate to record
+	// the webhook health as soon as it changes
+	webhookObjects := []client.Object{&corev1.Service{}, &corev1.Endpoints{}} //nolint:staticcheck // see checkService
+	if c.WebhookCertSecretName != "" {
+		webhookObjects = append(webhookObjects, &corev1.Secret{})
+	}
+	if c.WebhookServiceRef.Name != "" {
+		for _, obj := range webhookObjects {
+			b = b.Watches(obj, handler.EnqueueRequestsFromMapFunc(c.mapWebhookObjectToKausalityPolicies),
+				builder.WithPredicates(predicate.NewPredicateFuncs(c.isWebhookObject)))
+		}
+	}
+	return b.Complete(c)
+}
+
+// servedChanged passes creations, deletions and updates that change whether
+// an API is served, or its group. A new CRD is not served until it is
+// established, which is an update.
+func servedChanged(served func(client.Object) (string, bool)) predicate.Funcs {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldGroup, oldServed := served(e.ObjectOld)
+			newGroup, newServed := served(e.ObjectNew)
+			return oldGroup != newGroup || oldServed != newServed
+		},
+		GenericFunc: func(event.GenericEvent) bool { return false },
+	}
+}
+
+// crdServed returns the group of a CRD and whether it is established.
+func crdServed(obj client.Object) (string, bool) {
+	crd, ok := obj.(*apiextensionsv1.CustomResourceDefinition)
+	if !ok {
+		return "", false
+	}
+	for _, cond := range crd.Status.Conditions {
+		if cond.Type == apiextensionsv1.Established {
+			return crd.Spec.Group, cond.Status == apiextensionsv1.ConditionTrue
+		}
+	}
+	return crd.Spec.Group, false
 }
 
-// mapCRDToKausalityPolicies returns all Kausality policies when a CRD changes.
-// This triggers re-reconciliation which re-expands wildcard resources.
+// apiServiceServed returns the group of an APIService and whether it is available.
+func apiServiceServed(obj client.Object) (string, bool) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return "", false
+	}
+	group, _, _ := unstructured.NestedString(u.Object, "spec", "group")
+	conditions, _, _ := unstructured.NestedSlice(u.Object, "status", "conditions")
+	for _, c := range conditions {
+		cond, _ := c.(map[string]interface{})
+		if cond["type"] == "Available" {
+			return group, cond["status"] == string(metav1.ConditionTrue)
+		}
+	}
+	return group, false
+}
+
+// mapCRDToKausalityPolicies returns the policies with wildcard rules for the
+// group of a changed CRD. This triggers re-reconciliation which re-expands
+// wildcard resources.
 func (c *Controller) mapCRDToKausalityPolicies(ctx context.Context, obj client.Object) []reconcile.Request {
-	var policies kausalityv1alpha1.KausalityList
+	group, _ := crdServed(obj)
+	return c.policiesForGroup(ctx, group, "crd", obj.GetName())
+}
+
+// mapAPIServiceToKausalityPolicies returns the policies with wildcard rules
+// for the group of a changed aggregated API.
+func (c *Controller) mapAPIServiceToKausalityPolicies(ctx context.Context, obj client.Object) []reconcile.Request {
+	group, _ := apiServiceServed(obj)
+	return c.policiesForGroup(ctx, group, "apiService", obj.GetName())
+}
+
+// policiesForGroup returns the policies with a wildcard rule for group.
+func (c *Controller) policiesForGroup(ctx context.Context, group, sourceKind, sourceName string) []reconcile.Request {
+	var policies kausalityv1beta1.KausalityList
 	if err := c.List(ctx, &policies); err != nil {
-		c.Log.Error(err, "failed to list Kausality policies for CRD watch")
+		c.Log.Error(err, "failed to list Kausality policies for discovery watch")
 		return nil
 	}
 
 	var requests []reconcile.Request
 	for _, policy := range policies.Items {
-		requests = append(requests, reconcile.Request{
-			NamespacedName: client.ObjectKeyFromObject(&policy),
-		})
+		for _, rule := range policy.Spec.Resources {
+			if hasWildcard(rule) && slices.Contains(rule.APIGroups, group) {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: client.ObjectKeyFromObject(&policy),
+				})
+				break
+			}
+		}
 	}
 
 	if len(requests) > 0 {
-		crd, _ := obj.(*apiextensionsv1.CustomResourceDefinition)
-		if crd != nil {
-			c.Log.V(1).Info("CRD changed, requeueing policies", "crd", crd.Name, "policies", len(requests))
-		}
+		c.Log.V(1).Info("API changed, requeueing policies", sourceKind, sourceName, "group", group, "policies", len(requests))
 	}
-
 	return requests
 }
 
-// reconcileClusterRole creates or updates a ClusterRole for the policy.
-// The ClusterRole grants read/write access to resources defined in the policy
-// and is labeled for aggregation into the main resource-access role.
-func (c *Controller) reconcileClusterRole(ctx context.Context, log logr.Logger, policy *kausalityv1alpha1.Kausality) error {
-	roleName := ClusterRolePrefix + policy.Name
+// reconcileRBAC updates the webhook's resource access ClusterRole based on all
+// Kausality policies. The role is created by the deployment; the controller only
+// replaces its rules, so it needs no write access to other ClusterRoles apart
+// from deleting legacy ones and, with the escalate verb, none on the tracked
+// resources themselves.
+func (c *Controller) reconcileRBAC(ctx context.Context, log logr.Logger) error {
+	if c.WebhookRoleName == "" {
+		return nil
+	}
 
-	// Build RBAC rules from policy resources
-	rules, err := c.buildRBACRules(policy)
+	var policies kausalityv1beta1.KausalityList
+	if err := c.List(ctx, &policies); err != nil {
+		return fmt.Errorf("failed to list policies: %w", err)
+	}
+
+	rules, err := c.buildRBACRules(policies.Items)
 	if err != nil {
 		return fmt.Errorf("failed to build RBAC rules: %w", err)
 	}
 
-	// Try to get existing ClusterRole
-	var existing rbacv1.ClusterRole
-	err = c.Get(ctx, client.ObjectKey{Name: roleName}, &existing)
-	if err != nil && !apierrors.IsNotFound(err) {
-		return fmt.Errorf("failed to get ClusterRole: %w", err)
+	if err := c.pruneLegacyRBAC(ctx, log); err != nil {
+		return err
 	}
 
-	desired := rbacv1.ClusterRole{
-		ObjectMeta: metav1.ObjectMeta{
-			Name: roleName,
-			Labels: map[string]string{
-				AggregationLabel: "true",
-				ManagedByLabel:   "kausality",
-				PolicyNameLabel:  policy.Name,
-			},
-			OwnerReferences: []metav1.OwnerReference{
-				{
-					APIVersion: kausalityv1alpha1.GroupVersion.String(),
-					Kind:       "Kausality",
-					Name:       policy.Name,
-					UID:        policy.UID,
-				},
-			},
-		},
-		Rules: rules,
+	var role rbacv1.ClusterRole
+	if err := c.Get(ctx, client.ObjectKey{Name: c.WebhookRoleName}, &role); err != nil {
+		return fmt.Errorf("failed to get ClusterRole %q: %w", c.WebhookRoleName, err)
 	}
 
-	if apierrors.IsNotFound(err) {
-		// Create new ClusterRole
-		if err := c.Create(ctx, &desired); err != nil {
-			return fmt.Errorf("failed to create ClusterRole: %w", err)
-		}
-		log.Info("created ClusterRole", "name", roleName, "rules", len(rules))
-	} else {
-		// Update existing ClusterRole
-		existing.Labels = desired.Labels
-		existing.Rules = desired.Rules
-		if err := c.Update(ctx, &existing); err != nil {
-			return fmt.Errorf("failed to update ClusterRole: %w", err)
+	// An aggregation rule would make Kubernetes overwrite the rules, e.g. a
+	// role left over from deployments using per-policy ClusterRoles
+	if role.AggregationRule == nil && apiequality.Semantic.DeepEqual(role.Rules, rules) {
+		return nil
+	}
+	role.AggregationRule = nil
+	role.Rules = rules
+	if err := c.Update(ctx, &role); err != nil {
+		return fmt.Errorf("failed to update ClusterRole %q: %w", c.WebhookRoleName, err)
+	}
+	log.Info("updated webhook ClusterRole", "name", c.WebhookRoleName, "rules", len(rules), "policyCount", len(policies.Items))
+
+	return nil
+}
+
+// pruneLegacyRBAC deletes the per-policy ClusterRoles, and any bindings of
+// them, generated by earlier versions. They are no longer aggregated into the
+// webhook role, but would otherwise linger until their policy is deleted.
+func (c *Controller) pruneLegacyRBAC(ctx context.Context, log logr.Logger) error {
+	selector := client.MatchingLabels{ManagedByLabel: "kausality"}
+	legacy := client.HasLabels{PolicyNameLabel}
+
+	var roles rbacv1.ClusterRoleList
+	if err := c.List(ctx, &roles, selector, legacy); err != nil {
+		return fmt.Errorf("failed to list legacy ClusterRoles: %w", err)
+	}
+	for i := range roles.Items {
+		if err := c.Delete(ctx, &roles.Items[i]); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete legacy ClusterRole %q: %w", roles.Items[i].Name, err)
 		}
-		log.Info("updated ClusterRole", "name", roleName, "rules", len(rules))
+		log.Info("deleted legacy per-policy ClusterRole", "name", roles.Items[i].Name)
 	}
 
+	var bindings rbacv1.ClusterRoleBindingList
+	if err := c.List(ctx, &bindings, selector, legacy); err != nil {
+		return fmt.Errorf("failed to list legacy ClusterRoleBindings: %w", err)
+	}
+	for i := range bindings.Items {
+		if err := c.Delete(ctx, &bindings.Items[i]); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete legacy ClusterRoleBinding %q: %w", bindings.Items[i].Name, err)
+		}
+		log.Info("deleted legacy per-policy ClusterRoleBinding", "name", bindings.Items[i].Name)
+	}
 	return nil
 }
 
-// buildRBACRules builds RBAC PolicyRules from a Kausality policy.
-func (c *Controller) buildRBACRules(policy *kausalityv1alpha1.Kausality) ([]rbacv1.PolicyRule, error) {
+// buildRBACRules builds the RBAC PolicyRules the webhook needs for all
+// Kausality policies: read access to resolve parents and write access to
+// update annotations.
+func (c *Controller) buildRBACRules(policies []kausalityv1beta1.Kausality) ([]rbacv1.PolicyRule, error) {
 	// Collect resources by API group
 	groupedResources := make(map[string][]string)
 
-	for _, rule := range policy.Spec.Resources {
-		resources, err := c.expandResources(rule)
-		if err != nil {
-			return nil, fmt.Errorf("failed to expand resources: %w", err)
+	for _, policy := range policies {
+		// Skip policies being deleted
+		if !policy.DeletionTimestamp.IsZero() {
+			continue
 		}
 
-		for _, apiGroup := range rule.APIGroups {
-			groupedResources[apiGroup] = append(groupedResources[apiGroup], resources...)
+		for _, rule := range policy.Spec.Resources {
+			resources, err := c.expandResources(rule)
+			if err != nil {
+				return nil, fmt.Errorf("failed to expand resources for policy %q: %w", policy.Name, err)
+			}
+
+			for _, apiGroup := range rule.APIGroups {
+				groupedResources[apiGroup] = append(groupedResources[apiGroup], resources...)
+			}
 		}
 	}
 
@@ -1,12 +1,29 @@
 package policy
 
 import (
+	"context"
 	"testing"
 
+	"github.com/go-logr/logr"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
 	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
 )
 
 func TestFilterExcluded(t *testing.T) {
@@ -65,7 +82,7 @@ func TestFilterExcluded(t *testing.T) {
 func TestExpandResources_NoWildcard(t *testing.T) {
 	c := &Controller{}
 
-	rule := kausalityv1alpha1.ResourceRule{
+	rule := kausalityv1beta1.ResourceRule{
 		APIGroups: []string{"apps"},
 		Resources: []string{"deployments", "statefulsets"},
 		Excluded:  []string{},
@@ -79,7 +96,7 @@ func TestExpandResources_NoWildcard(t *testing.T) {
 func TestExpandResources_NoWildcardWithExclusions(t *testing.T) {
 	c := &Controller{}
 
-	rule := kausalityv1alpha1.ResourceRule{
+	rule := kausalityv1beta1.ResourceRule{
 		APIGroups: []string{"apps"},
 		Resources: []string{"deployments", "replicasets", "statefulsets"},
 		Excluded:  []string{"replicasets"},
@@ -132,3 +149,352 @@ func TestBuildNamespaceSelector(t *testing.T) {
 		})
 	}
 }
+
+func TestInjectCABundle(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	webhook := &admissionregistrationv1.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "kausality"},
+		Webhooks: []admissionregistrationv1.MutatingWebhook{
+			{Name: "mutating.webhook.kausality.io"},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(webhook).Build()
+	controller := &Controller{Client: c, Log: logr.Discard(), WebhookName: "kausality"}
+	ctx := context.Background()
+
+	require.NoError(t, controller.InjectCABundle(ctx, []byte("ca-bundle")))
+
+	var got admissionregistrationv1.MutatingWebhookConfiguration
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "kausality"}, &got))
+	assert.Equal(t, []byte("ca-bundle"), got.Webhooks[0].ClientConfig.CABundle)
+
+	// Unchanged bundle does not update the object
+	require.NoError(t, controller.InjectCABundle(ctx, []byte("ca-bundle")))
+	var again admissionregistrationv1.MutatingWebhookConfiguration
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "kausality"}, &again))
+	assert.Equal(t, got.ResourceVersion, again.ResourceVersion)
+
+	// Missing webhook configuration is an error
+	controller.WebhookName = "missing"
+	assert.Error(t, controller.InjectCABundle(ctx, []byte("ca-bundle")))
+}
+
+func TestAggregateRules_Subresources(t *testing.T) {
+	c := &Controller{}
+
+	policies := []kausalityv1beta1.Kausality{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "apps"},
+			Spec: kausalityv1beta1.KausalitySpec{
+				Resources: []kausalityv1beta1.ResourceRule{{
+					APIGroups: []string{"apps"},
+					Resources: []string{"deployments"},
+					Subresources: []kausalityv1beta1.SubresourceRule{
+						{Name: "scale", Handling: kausalityv1beta1.SubresourceHandlingTrack},
+					},
+				}},
+				Mode: kausalityv1beta1.ModeLog,
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "pods"},
+			Spec: kausalityv1beta1.KausalitySpec{
+				Resources: []kausalityv1beta1.ResourceRule{{
+					APIGroups: []string{""},
+					Resources: []string{"pods"},
+					Subresources: []kausalityv1beta1.SubresourceRule{
+						{Name: "status", Handling: kausalityv1beta1.SubresourceHandlingIgnore},
+						{Name: "ephemeralcontainers", Handling: kausalityv1beta1.SubresourceHandlingTrack},
+						{Name: "exec", Handling: kausalityv1beta1.SubresourceHandlingTrack},
+					},
+				}},
+				Mode: kausalityv1beta1.ModeLog,
+			},
+		},
+	}
+
+	rules, err := c.aggregateRules(policies, admissionregistrationv1.Fail)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+
+	type rule struct {
+		apiGroup   string
+		operations []admissionregistrationv1.OperationType
+		resources  []string
+	}
+	var got []rule
+	for _, r := range rules[admissionregistrationv1.Fail] {
+		got = append(got, rule{apiGroup: r.APIGroups[0], operations: r.Operations, resources: r.Resources})
+	}
+
+	createUpdate := []admissionregistrationv1.OperationType{admissionregistrationv1.Create, admissionregistrationv1.Update}
+	assert.Equal(t, []rule{
+		{"", ruleOperations[opsSpec], []string{"pods"}},
+		{"", createUpdate, []string{"pods/ephemeralcontainers"}},
+		{"", []admissionregistrationv1.OperationType{admissionregistrationv1.Connect}, []string{"pods/exec"}},
+		{"apps", ruleOperations[opsSpec], []string{"deployments"}},
+		{"apps", []admissionregistrationv1.OperationType{admissionregistrationv1.Update}, []string{"deployments/status"}},
+		{"apps", createUpdate, []string{"deployments/scale"}},
+	}, got)
+}
+
+func TestReconcileWebhook_FailurePolicy(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, kausalityv1beta1.AddToScheme(scheme))
+	require.NoError(t, apiextensionsv1.AddToScheme(scheme))
+	fail := admissionregistrationv1.Fail
+	ignore := kausalityv1beta1.FailurePolicyIgnore
+	webhook := &admissionregistrationv1.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "kausality"},
+		Webhooks: []admissionregistrationv1.MutatingWebhook{
+			{Name: "mutating.webhook.kausality.io", FailurePolicy: &fail, ClientConfig: admissionregistrationv1.WebhookClientConfig{CABundle: []byte("ca-bundle")}},
+			{Name: "other.kausality.io"},
+		},
+	}
+	policy := &kausalityv1beta1.Kausality{
+		ObjectMeta: metav1.ObjectMeta{Name: "apps"},
+		Spec: kausalityv1beta1.KausalitySpec{
+			Resources: []kausalityv1beta1.ResourceRule{
+				{APIGroups: []string{"apps"}, Resources: []string{"deployments"}},
+				{APIGroups: []string{"apps"}, Resources: []string{"daemonsets", "deployments"}, FailurePolicy: &ignore},
+			},
+		},
+	}
+	crd := &apiextensionsv1.CustomResourceDefinition{ObjectMeta: metav1.ObjectMeta{Name: KausalityCRDName}}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(webhook, policy, crd).Build()
+	controller := &Controller{
+		Client:            c,
+		Log:               logr.Discard(),
+		WebhookName:       "kausality",
+		WebhookServiceRef: WebhookServiceRef{Namespace: "kausality-system", Name: "kausality-webhook", Port: 443},
+	}
+	ctx := context.Background()
+
+	require.NoError(t, controller.reconcileWebhook(ctx, logr.Discard()))
+
+	var got admissionregistrationv1.MutatingWebhookConfiguration
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "kausality"}, &got))
+	require.Len(t, got.Webhooks, 3)
+	assert.Equal(t, "other.kausality.io", got.Webhooks[1].Name)
+	// Deployments are matched with both failure policies: Fail wins
+	assert.Equal(t, []string{"deployments"}, got.Webhooks[0].Rules[0].Resources)
+	assert.Equal(t, "ignore.mutating.webhook.kausality.io", got.Webhooks[2].Name)
+	assert.Equal(t, admissionregistrationv1.Ignore, *got.Webhooks[2].FailurePolicy)
+	assert.Equal(t, []string{"daemonsets"}, got.Webhooks[2].Rules[0].Resources)
+	assert.Equal(t, []byte("ca-bundle"), got.Webhooks[2].ClientConfig.CABundle)
+
+	var gotCRD apiextensionsv1.CustomResourceDefinition
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: KausalityCRDName}, &gotCRD))
+	require.NotNil(t, gotCRD.Spec.Conversion)
+	assert.Equal(t, apiextensionsv1.WebhookConverter, gotCRD.Spec.Conversion.Strategy)
+	assert.Equal(t, "kausality-webhook", gotCRD.Spec.Conversion.Webhook.ClientConfig.Service.Name)
+	assert.Equal(t, ConversionPath, *gotCRD.Spec.Conversion.Webhook.ClientConfig.Service.Path)
+	assert.Equal(t, []byte("ca-bundle"), gotCRD.Spec.Conversion.Webhook.ClientConfig.CABundle)
+
+	// Without rules for Ignore, the extra webhook is removed
+	var current kausalityv1beta1.Kausality
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "apps"}, &current))
+	current.Spec.Resources = current.Spec.Resources[:1]
+	require.NoError(t, c.Update(ctx, &current))
+	require.NoError(t, controller.reconcileWebhook(ctx, logr.Discard()))
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "kausality"}, &got))
+	require.Len(t, got.Webhooks, 2)
+	assert.Equal(t, "other.kausality.io", got.Webhooks[1].Name)
+}
+
+func TestBuildRBACRules(t *testing.T) {
+	c := &Controller{}
+	deleting := metav1.Now()
+
+	policies := []kausalityv1beta1.Kausality{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "apps"},
+			Spec: kausalityv1beta1.KausalitySpec{Resources: []kausalityv1beta1.ResourceRule{
+				{APIGroups: []string{"apps"}, Resources: []string{"replicasets", "deployments"}},
+			}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "overlap"},
+			Spec: kausalityv1beta1.KausalitySpec{Resources: []kausalityv1beta1.ResourceRule{
+				{APIGroups: []string{"apps"}, Resources: []string{"deployments", "statefulsets"}, Excluded: []string{"statefulsets"}},
+				{APIGroups: []string{""}, Resources: []string{"configmaps"}},
+			}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "deleting", DeletionTimestamp: &deleting},
+			Spec: kausalityv1beta1.KausalitySpec{Resources: []kausalityv1beta1.ResourceRule{
+				{APIGroups: []string{""}, Resources: []string{"secrets"}},
+			}},
+		},
+	}
+
+	rules, err := c.buildRBACRules(policies)
+	require.NoError(t, err)
+	assert.Equal(t, []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get", "list", "watch"}},
+		{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"update", "patch"}},
+		{APIGroups: []string{"apps"}, Resources: []string{"deployments", "replicasets"}, Verbs: []string{"get", "list", "watch"}},
+		{APIGroups: []string{"apps"}, Resources: []string{"deployments", "replicasets"}, Verbs: []string{"update", "patch"}},
+	}, rules)
+}
+
+func TestReconcileRBAC(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, kausalityv1alpha1.AddToScheme(scheme))
+	require.NoError(t, kausalityv1beta1.AddToScheme(scheme))
+
+	policy := &kausalityv1beta1.Kausality{
+		ObjectMeta: metav1.ObjectMeta{Name: "apps"},
+		Spec: kausalityv1beta1.KausalitySpec{Resources: []kausalityv1beta1.ResourceRule{
+			{APIGroups: []string{"apps"}, Resources: []string{"deployments"}},
+		}},
+	}
+	// A role from a deployment using per-policy ClusterRoles still aggregates
+	role := &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{Name: "kausality-webhook-resources"},
+		AggregationRule: &rbacv1.AggregationRule{ClusterRoleSelectors: []metav1.LabelSelector{
+			{MatchLabels: map[string]string{"kausality.io/aggregate-to-webhook-resources": "true"}},
+		}},
+	}
+	// Per-policy roles and bindings of earlier versions are pruned, others are kept
+	legacyLabels := map[string]string{ManagedByLabel: "kausality", PolicyNameLabel: "apps"}
+	legacyRole := &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "kausality-policy-apps", Labels: legacyLabels}}
+	legacyBinding := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "kausality-policy-apps", Labels: legacyLabels},
+		RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: "kausality-policy-apps"},
+	}
+	otherRole := &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "other", Labels: map[string]string{PolicyNameLabel: "apps"}}}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(policy, role, legacyRole, legacyBinding, otherRole).Build()
+	controller := &Controller{Client: c, Log: logr.Discard(), WebhookRoleName: "kausality-webhook-resources"}
+	ctx := context.Background()
+
+	require.NoError(t, controller.reconcileRBAC(ctx, logr.Discard()))
+	assert.True(t, apierrors.IsNotFound(c.Get(ctx, client.ObjectKeyFromObject(legacyRole), &rbacv1.ClusterRole{})))
+	assert.True(t, apierrors.IsNotFound(c.Get(ctx, client.ObjectKeyFromObject(legacyBinding), &rbacv1.ClusterRoleBinding{})))
+	assert.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(otherRole), &rbacv1.ClusterRole{}))
+
+	var got rbacv1.ClusterRole
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "kausality-webhook-resources"}, &got))
+	assert.Nil(t, got.AggregationRule)
+	assert.Equal(t, []rbacv1.PolicyRule{
+		{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{"get", "list", "watch"}},
+		{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{"update", "patch"}},
+	}, got.Rules)
+
+	// Unchanged rules do not update the role
+	require.NoError(t, controller.reconcileRBAC(ctx, logr.Discard()))
+	var again rbacv1.ClusterRole
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "kausality-webhook-resources"}, &again))
+	assert.Equal(t, got.ResourceVersion, again.ResourceVersion)
+
+	// Removing the last policy revokes all access
+	require.NoError(t, c.Delete(ctx, policy))
+	require.NoError(t, controller.reconcileRBAC(ctx, logr.Discard()))
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "kausality-webhook-resources"}, &got))
+	assert.Empty(t, got.Rules)
+
+	// The role is created by the deployment, a missing role is an error
+	controller.WebhookRoleName = "missing"
+	assert.Error(t, controller.reconcileRBAC(ctx, logr.Discard()))
+}
+
+func TestMapCRDToKausalityPolicies(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, kausalityv1beta1.AddToScheme(scheme))
+	policies := []client.Object{
+		&kausalityv1beta1.Kausality{
+			ObjectMeta: metav1.ObjectMeta{Name: "wildcard"},
+			Spec: kausalityv1beta1.KausalitySpec{Resources: []kausalityv1beta1.ResourceRule{
+				{APIGroups: []string{"apps"}, Resources: []string{"deployments"}},
+				{APIGroups: []string{"aws.upbound.io"}, Resources: []string{"*"}},
+			}},
+		},
+		&kausalityv1beta1.Kausality{
+			ObjectMeta: metav1.ObjectMeta{Name: "explicit"},
+			Spec: kausalityv1beta1.KausalitySpec{Resources: []kausalityv1beta1.ResourceRule{
+				{APIGroups: []string{"aws.upbound.io"}, Resources: []string{"buckets"}},
+			}},
+		},
+		&kausalityv1beta1.Kausality{
+			ObjectMeta: metav1.ObjectMeta{Name: "other-group"},
+			Spec: kausalityv1beta1.KausalitySpec{Resources: []kausalityv1beta1.ResourceRule{
+				{APIGroups: []string{"gcp.upbound.io"}, Resources: []string{"*"}},
+			}},
+		},
+	}
+	c := &Controller{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(policies...).Build(),
+		Log:    logr.Discard(),
+	}
+
+	crd := &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "vpcs.aws.upbound.io"},
+		Spec:       apiextensionsv1.CustomResourceDefinitionSpec{Group: "aws.upbound.io"},
+	}
+	requests := c.mapCRDToKausalityPolicies(context.Background(), crd)
+	require.Len(t, requests, 1)
+	assert.Equal(t, "wildcard", requests[0].Name)
+
+	apiService := &unstructured.Unstructured{}
+	apiService.SetGroupVersionKind(apiServiceGVK)
+	apiService.SetName("v1beta1.gcp.upbound.io")
+	require.NoError(t, unstructured.SetNestedField(apiService.Object, "gcp.upbound.io", "spec", "group"))
+	requests = c.mapAPIServiceToKausalityPolicies(context.Background(), apiService)
+	require.Len(t, requests, 1)
+	assert.Equal(t, "other-group", requests[0].Name)
+}
+
+func TestServedChanged(t *testing.T) {
+	crd := func(established apiextensionsv1.ConditionStatus) *apiextensionsv1.CustomResourceDefinition {
+		return &apiextensionsv1.CustomResourceDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "vpcs.aws.upbound.io"},
+			Spec:       apiextensionsv1.CustomResourceDefinitionSpec{Group: "aws.upbound.io"},
+			Status: apiextensionsv1.CustomResourceDefinitionStatus{Conditions: []apiextensionsv1.CustomResourceDefinitionCondition{
+				{Type: apiextensionsv1.Established, Status: established},
+			}},
+		}
+	}
+	p := servedChanged(crdServed)
+
+	assert.True(t, p.Create(event.CreateEvent{Object: crd(apiextensionsv1.ConditionFalse)}))
+	assert.True(t, p.Delete(event.DeleteEvent{Object: crd(apiextensionsv1.ConditionTrue)}))
+	assert.True(t, p.Update(event.UpdateEvent{ObjectOld: crd(apiextensionsv1.ConditionFalse), ObjectNew: crd(apiextensionsv1.ConditionTrue)}),
+		"established")
+	relabeled := crd(apiextensionsv1.ConditionTrue)
+	relabeled.Labels = map[string]string{"team": "infra"}
+	assert.False(t, p.Update(event.UpdateEvent{ObjectOld: crd(apiextensionsv1.ConditionTrue), ObjectNew: relabeled}),
+		"served resources unchanged")
+}
+
+func TestSetDiscoveryCondition(t *testing.T) {
+	dc := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}
+	verbs := metav1.Verbs{"get", "list", "watch", "create", "update", "patch", "delete"}
+	dc.Resources = []*metav1.APIResourceList{{
+		GroupVersion: "aws.upbound.io/v1beta1",
+		APIResources: []metav1.APIResource{{Name: "vpcs", Verbs: verbs}, {Name: "vpcs/status", Verbs: verbs}, {Name: "subnets", Verbs: verbs}},
+	}, {
+		// Read-only resources of an aggregated API can't be webhooked
+		GroupVersion: "metrics.k8s.io/v1beta1",
+		APIResources: []metav1.APIResource{{Name: "pods", Verbs: metav1.Verbs{"get", "list"}}, {Name: "nodes", Verbs: metav1.Verbs{"get", "list"}}},
+	}}
+	c := &Controller{DiscoveryClient: dc}
+
+	policy := &kausalityv1beta1.Kausality{Spec: kausalityv1beta1.KausalitySpec{Resources: []kausalityv1beta1.ResourceRule{
+		{APIGroups: []string{"aws.upbound.io", "metrics.k8s.io"}, Resources: []string{"*"}},
+	}}}
+	c.setDiscoveryCondition(policy)
+	require.Len(t, policy.Status.Conditions, 1)
+	cond := policy.Status.Conditions[0]
+	assert.Equal(t, ConditionTypeDiscoverySynced, cond.Type)
+	assert.Equal(t, metav1.ConditionTrue, cond.Status)
+	assert.Equal(t, "Wildcard rules expanded to 2 resources", cond.Message)
+
+	explicit := &kausalityv1beta1.Kausality{Spec: kausalityv1beta1.KausalitySpec{Resources: []kausalityv1beta1.ResourceRule{
+		{APIGroups: []string{"apps"}, Resources: []string{"deployments"}},
+	}}}
+	c.setDiscoveryCondition(explicit)
+	assert.Empty(t, explicit.Status.Conditions, "no wildcard rules")
+}
@@ -0,0 +1,71 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// KausalityCRDName is the name of the Kausality CRD, which is served in
+	// v1alpha1 and v1beta1.
+	KausalityCRDName = "kausalities.kausality.io"
+
+	// ConversionPath is the path of the conversion webhook.
+	ConversionPath = "/convert"
+)
+
+// reconcileConversion points the Kausality CRD at the conversion webhook of
+// the webhook service. Without a CA bundle or CRD there is nothing to do:
+// the conversion is configured once certificates are provisioned.
+func (c *Controller) reconcileConversion(ctx context.Context, caBundle []byte) error {
+	if len(caBundle) == 0 || c.WebhookServiceRef.Name == "" {
+		return nil
+	}
+
+	var crd apiextensionsv1.CustomResourceDefinition
+	if err := c.Get(ctx, client.ObjectKey{Name: KausalityCRDName}, &crd); err != nil {
+		if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get CRD %q: %w", KausalityCRDName, err)
+	}
+
+	port := c.WebhookServiceRef.Port
+	if port == 0 {
+		port = 443
+	}
+	conversion := &apiextensionsv1.CustomResourceConversion{
+		Strategy: apiextensionsv1.WebhookConverter,
+		Webhook: &apiextensionsv1.WebhookConversion{
+			ClientConfig: &apiextensionsv1.WebhookClientConfig{
+				Service: &apiextensionsv1.ServiceReference{
+					Namespace: c.WebhookServiceRef.Namespace,
+					Name:      c.WebhookServiceRef.Name,
+					Path:      ptr.To(ConversionPath),
+					Port:      ptr.To(port),
+				},
+				CABundle: bytes.Clone(caBundle),
+			},
+			ConversionReviewVersions: []string{"v1"},
+		},
+	}
+	if apiequality.Semantic.DeepEqual(crd.Spec.Conversion, conversion) {
+		return nil
+	}
+
+	patch := client.MergeFrom(crd.DeepCopy())
+	crd.Spec.Conversion = conversion
+	if err := c.Patch(ctx, &crd, patch); err != nil {
+		return fmt.Errorf("failed to configure conversion of CRD %q: %w", KausalityCRDName, err)
+	}
+	c.Log.Info("configured conversion webhook", "crd", KausalityCRDName)
+	return nil
+}
@@ -0,0 +1,24 @@
+package policy
+
+const (
+	// ClaimNamespaceLabel is set by Crossplane on composite resources (XRs)
+	// bound to a Claim, and propagated to the resources they compose.
+	ClaimNamespaceLabel = "crossplane.io/claim-namespace"
+
+	// ClaimNameLabel is set by Crossplane alongside ClaimNamespaceLabel.
+	ClaimNameLabel = "crossplane.io/claim-name"
+)
+
+// EffectiveNamespace returns the namespace whose annotations, labels and
+// policies govern the mode of an object.
+//
+// Namespaced objects, including Crossplane Claims, use their own namespace.
+// Cluster-scoped XRs and managed resources composed for a Claim carry the
+// crossplane.io/claim-namespace label and inherit from the Claim's namespace,
+// so a single namespace-level mode covers the whole composition.
+func EffectiveNamespace(namespace string, objLabels map[string]string) string {
+	if namespace != "" {
+		return namespace
+	}
+	return objLabels[ClaimNamespaceLabel]
+}
@@ -0,0 +1,42 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEffectiveNamespace(t *testing.T) {
+	tests := []struct {
+		name      string
+		namespace string
+		labels    map[string]string
+		want      string
+	}{
+		{
+			name:      "namespaced object uses its namespace",
+			namespace: "team-a",
+			want:      "team-a",
+		},
+		{
+			name:      "namespaced object ignores claim label",
+			namespace: "team-a",
+			labels:    map[string]string{ClaimNamespaceLabel: "team-b"},
+			want:      "team-a",
+		},
+		{
+			name:   "cluster-scoped XR inherits claim namespace",
+			labels: map[string]string{ClaimNamespaceLabel: "team-a", ClaimNameLabel: "db"},
+			want:   "team-a",
+		},
+		{
+			name: "cluster-scoped object without claim",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, EffectiveNamespace(tt.namespace, tt.labels))
+		})
+	}
+}
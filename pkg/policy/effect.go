@@ -0,0 +1,410 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+	callbackv1alpha1 "github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+)
+
+const (
+	// ReasonCoverageChanged is the reason of Events on policies whose change
+	// made resources gain or lose tracking, or change mode.
+	ReasonCoverageChanged = "CoverageChanged"
+
+	// ReasonCoverageUnchanged is the reason of Events on policies whose
+	// change left the tracking and mode of all resources unchanged.
+	ReasonCoverageUnchanged = "CoverageUnchanged"
+
+	// maxEffectChanges bounds the changes recorded in the status of a policy.
+	maxEffectChanges = 100
+
+	// maxEventChanges bounds the changes named in the Event of a policy.
+	maxEventChanges = 3
+
+	// maxEventNote is the maximum length of the note of an Event.
+	maxEventNote = 1024
+)
+
+// EffectReporter receives the effect of policy changes, e.g. a
+// callback.PolicyEffectSender.
+type EffectReporter interface {
+	Send(ctx context.Context, report *callbackv1alpha1.PolicyEffectReport) error
+}
+
+// observedPolicy is a policy as last observed by the controller, with the
+// effect of its last change.
+type observedPolicy struct {
+	policy kausalityv1beta1.Kausality
+	effect *kausalityv1beta1.PolicyEffect
+}
+
+// coverageResource is a resource whose coverage is compared, in the
+// namespaces of the cluster, as cluster-scoped resource, or both if its
+// scope is unknown.
+type coverageResource struct {
+	schema.GroupResource
+	namespaced, clusterScoped bool
+}
+
+// recordEffect computes the effect of the change of policy since the
+// controller last observed it, emits it as Event and sends it to the
+// EffectReporter. It returns the effect of the last change of the policy,
+// or nil if none was observed or the policy is deleted. Changes made before
+// the first reconciliation of the controller, e.g. while it was down, are
+// not observed.
+func (c *Controller) recordEffect(ctx context.Context, log logr.Logger, policy *kausalityv1beta1.Kausality) *kausalityv1beta1.PolicyEffect {
+	before, changed, err := c.observe(ctx, policy)
+	if err != nil {
+		log.Error(err, "failed to observe policy change")
+		return nil
+	}
+	if !changed {
+		return c.lastEffect(policy.Name)
+	}
+
+	deleting := !policy.DeletionTimestamp.IsZero()
+	after := policy
+	operation := "UPDATE"
+	switch {
+	case deleting:
+		after, operation = nil, "DELETE"
+	case before == nil:
+		operation = "CREATE"
+	}
+	changes, err := c.effectChanges(ctx, policy.Name, before, after)
+	if err != nil {
+		log.Error(err, "failed to compute the effect of the policy change")
+		return nil
+	}
+
+	effect := &kausalityv1beta1.PolicyEffect{
+		ObservedGeneration: policy.Generation,
+		Time:               metav1.Now(),
+	}
+	for _, change := range changes {
+		switch change.Type {
+		case kausalityv1beta1.CoverageGained:
+			effect.Gained++
+		case kausalityv1beta1.CoverageLost:
+			effect.Lost++
+		case kausalityv1beta1.CoverageModeChanged:
+			effect.ModeChanged++
+		}
+	}
+	effect.Changes = changes[:min(len(changes), maxEffectChanges)]
+	log.Info("policy change affected coverage", "operation", operation,
+		"gained", effect.Gained, "lost", effect.Lost, "modeChanged", effect.ModeChanged)
+
+	if c.Recorder != nil {
+		eventType, reason := corev1.EventTypeNormal, ReasonCoverageChanged
+		switch {
+		case effect.Lost > 0:
+			eventType = corev1.EventTypeWarning
+		case len(changes) == 0:
+			reason = ReasonCoverageUnchanged
+		}
+		c.Recorder.Eventf(policy, nil, eventType, reason, "Reconcile", "%s", effectNote(effect))
+	}
+	if c.EffectReporter != nil {
+		report := effectReport(policy, operation, effect, changes)
+		go func() {
+			if err := c.EffectReporter.Send(context.WithoutCancel(ctx), report); err != nil {
+				log.Error(err, "failed to report the effect of the policy change")
+			}
+		}()
+	}
+
+	if deleting {
+		return nil
+	}
+	c.observedMu.Lock()
+	defer c.observedMu.Unlock()
+	if observed, ok := c.observed[policy.Name]; ok {
+		observed.effect = effect
+	}
+	return effect
+}
+
+// observe records policy as observed. It returns its previously observed
+// version, nil if it was created since, and whether it changed: its
+// generation differs, or it is deleted. The first call observes all
+// policies as they are.
+func (c *Controller) observe(ctx context.Context, policy *kausalityv1beta1.Kausality) (*kausalityv1beta1.Kausality, bool, error) {
+	c.observedMu.Lock()
+	defer c.observedMu.Unlock()
+
+	if c.observed == nil {
+		var policies kausalityv1beta1.KausalityList
+		if err := c.List(ctx, &policies); err != nil {
+			return nil, false, fmt.Errorf("failed to list policies: %w", err)
+		}
+		c.observed = make(map[string]*observedPolicy, len(policies.Items))
+		for _, p := range policies.Items {
+			if p.DeletionTimestamp.IsZero() {
+				c.observed[p.Name] = &observedPolicy{policy: p}
+			}
+		}
+		return nil, false, nil
+	}
+
+	previous, ok := c.observed[policy.Name]
+	if !policy.DeletionTimestamp.IsZero() {
+		if !ok {
+			return nil, false, nil
+		}
+		delete(c.observed, policy.Name)
+		return &previous.policy, true, nil
+	}
+	if ok && previous.policy.Generation == policy.Generation {
+		return nil, false, nil
+	}
+	c.observed[policy.Name] = &observedPolicy{policy: *policy.DeepCopy()}
+	if !ok {
+		return nil, true, nil
+	}
+	return &previous.policy, true, nil
+}
+
+// lastEffect returns the effect of the last observed change of a policy.
+func (c *Controller) lastEffect(name string) *kausalityv1beta1.PolicyEffect {
+	c.observedMu.Lock()
+	defer c.observedMu.Unlock()
+	if observed, ok := c.observed[name]; ok {
+		return observed.effect
+	}
+	return nil
+}
+
+// effectChanges returns how replacing the policy before by after changes
+// the tracking and mode of the resources named by either, in the namespaces
+// not excluded from the webhook. before is nil for created policies, after
+// for deleted ones.
+func (c *Controller) effectChanges(ctx context.Context, name string, before, after *kausalityv1beta1.Kausality) ([]kausalityv1beta1.CoverageChange, error) {
+	var policies kausalityv1beta1.KausalityList
+	if err := c.List(ctx, &policies); err != nil {
+		return nil, fmt.Errorf("failed to list policies: %w", err)
+	}
+	var others []kausalityv1beta1.Kausality
+	for _, p := range policies.Items {
+		if p.Name != name && p.DeletionTimestamp.IsZero() {
+			others = append(others, p)
+		}
+	}
+
+	var namespaceList corev1.NamespaceList
+	if err := c.List(ctx, &namespaceList); err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	var namespaces []corev1.Namespace
+	for _, ns := range namespaceList.Items {
+		if !slices.Contains(c.ExcludedNamespaces, ns.Name) {
+			namespaces = append(namespaces, ns)
+		}
+	}
+
+	return coverageChanges(before, after, others, c.coverageResources(before, after), namespaces, time.Now()), nil
+}
+
+// coverageChanges returns how replacing the policy before by after, among
+// others, changes the tracking and mode of objects without labels of
+// resources in namespaces, sorted by resource and namespace.
+func coverageChanges(before, after *kausalityv1beta1.Kausality, others []kausalityv1beta1.Kausality, resources []coverageResource, namespaces []corev1.Namespace, now time.Time) []kausalityv1beta1.CoverageChange {
+	storeBefore, storeAfter := effectStore(others, before, now), effectStore(others, after, now)
+	sort.Slice(namespaces, func(i, j int) bool {
+		return namespaces[i].Name < namespaces[j].Name
+	})
+
+	changes := []kausalityv1beta1.CoverageChange{}
+	compare := func(gr schema.GroupResource, namespace string, labels, annotations map[string]string) {
+		ctx := ResourceContext{GVR: gr.WithVersion(""), Namespace: namespace, NamespaceLabels: labels}
+		trackedBefore, trackedAfter := storeBefore.IsTracked(ctx), storeAfter.IsTracked(ctx)
+		modeBefore := kausalityv1beta1.Mode(storeBefore.ResolveMode(ctx, nil, annotations))
+		modeAfter := kausalityv1beta1.Mode(storeAfter.ResolveMode(ctx, nil, annotations))
+		change := kausalityv1beta1.CoverageChange{Resource: resourceName(gr), Namespace: namespace}
+		switch {
+		case !trackedBefore && trackedAfter:
+			change.Type, change.Mode = kausalityv1beta1.CoverageGained, modeAfter
+		case trackedBefore && !trackedAfter:
+			change.Type, change.PreviousMode = kausalityv1beta1.CoverageLost, modeBefore
+		case trackedBefore && modeBefore != modeAfter:
+			change.Type, change.Mode, change.PreviousMode = kausalityv1beta1.CoverageModeChanged, modeAfter, modeBefore
+		default:
+			return
+		}
+		changes = append(changes, change)
+	}
+	for _, r := range resources {
+		if r.clusterScoped {
+			compare(r.GroupResource, "", nil, nil)
+		}
+		if r.namespaced {
+			for _, ns := range namespaces {
+				compare(r.GroupResource, ns.Name, ns.Labels, ns.Annotations)
+			}
+		}
+	}
+	return changes
+}
+
+// effectStore returns a store of the others and policy, if not nil,
+// resolving modes at now.
+func effectStore(others []kausalityv1beta1.Kausality, policy *kausalityv1beta1.Kausality, now time.Time) *Store {
+	policies := append([]kausalityv1beta1.Kausality(nil), others...)
+	if policy != nil {
+		policies = append(policies, *policy)
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		return policies[i].Name < policies[j].Name
+	})
+	store := NewStore(nil, logr.Discard())
+	store.Update(policies)
+	store.SetClock(func() time.Time { return now })
+	return store
+}
+
+// coverageResources returns the resources named by the resource rules of
+// the policies, sorted. Wildcards are expanded via discovery, or else kept
+// as "*". Resources not served are compared in both scopes.
+func (c *Controller) coverageResources(policies ...*kausalityv1beta1.Kausality) []coverageResource {
+	served := c.servedResources()
+	set := map[schema.GroupResource]bool{}
+	for _, p := range policies {
+		if p == nil {
+			continue
+		}
+		for _, rule := range p.Spec.Resources {
+			for _, group := range rule.APIGroups {
+				for _, r := range append(append([]string(nil), rule.Resources...), rule.Excluded...) {
+					if r != "*" || served == nil {
+						set[schema.GroupResource{Group: group, Resource: r}] = true
+						continue
+					}
+					for gr := range served {
+						if gr.Group == group {
+							set[gr] = true
+						}
+					}
+				}
+			}
+		}
+	}
+
+	result := make([]coverageResource, 0, len(set))
+	for gr := range set {
+		namespaced, ok := served[gr]
+		result = append(result, coverageResource{GroupResource: gr, namespaced: namespaced || !ok, clusterScoped: !namespaced || !ok})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return resourceName(result[i].GroupResource) < resourceName(result[j].GroupResource)
+	})
+	return result
+}
+
+// servedResources returns the resources served by the cluster which can be
+// webhooked, and whether they are namespaced. It returns nil without
+// discovery.
+func (c *Controller) servedResources() map[schema.GroupResource]bool {
+	if c.DiscoveryClient == nil {
+		return nil
+	}
+	_, apiResourceLists, err := c.DiscoveryClient.ServerGroupsAndResources()
+	if err != nil && apiResourceLists == nil {
+		return nil
+	}
+	served := map[schema.GroupResource]bool{}
+	for _, resourceList := range apiResourceLists {
+		gv, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, r := range resourceList.APIResources {
+			if strings.Contains(r.Name, "/") || !webhookable(r) {
+				continue
+			}
+			served[gv.WithResource(r.Name).GroupResource()] = r.Namespaced
+		}
+	}
+	return served
+}
+
+// effectReport returns the report of the effect of a policy change.
+func effectReport(policy *kausalityv1beta1.Kausality, operation string, effect *kausalityv1beta1.PolicyEffect, changes []kausalityv1beta1.CoverageChange) *callbackv1alpha1.PolicyEffectReport {
+	report := &callbackv1alpha1.PolicyEffectReport{Spec: callbackv1alpha1.PolicyEffectReportSpec{
+		Policy:      policy.Name,
+		Generation:  policy.Generation,
+		Operation:   operation,
+		Timestamp:   effect.Time,
+		Gained:      effect.Gained,
+		Lost:        effect.Lost,
+		ModeChanged: effect.ModeChanged,
+	}}
+	for _, change := range changes {
+		report.Spec.Changes = append(report.Spec.Changes, callbackv1alpha1.CoverageChange{
+			Type:         string(change.Type),
+			Resource:     change.Resource,
+			Namespace:    change.Namespace,
+			Mode:         string(change.Mode),
+			PreviousMode: string(change.PreviousMode),
+		})
+	}
+	return report
+}
+
+// effectNote returns the note of the Event of an effect, naming its first
+// changes.
+func effectNote(effect *kausalityv1beta1.PolicyEffect) string {
+	total := int(effect.Gained + effect.Lost + effect.ModeChanged)
+	if total == 0 {
+		return "No resource gained or lost tracking or changed mode"
+	}
+	note := fmt.Sprintf("%d resource and namespace pairs gained tracking, %d lost tracking, %d changed mode",
+		effect.Gained, effect.Lost, effect.ModeChanged)
+	var named []string
+	for _, change := range effect.Changes[:min(len(effect.Changes), maxEventChanges)] {
+		named = append(named, describeChange(change))
+	}
+	note += ": " + strings.Join(named, ", ")
+	if total > len(named) {
+		note += fmt.Sprintf(" and %d more", total-len(named))
+	}
+	if len(note) > maxEventNote {
+		note = note[:maxEventNote-3] + "..."
+	}
+	return note
+}
+
+// describeChange returns a coverage change in words.
+func describeChange(change kausalityv1beta1.CoverageChange) string {
+	where := change.Resource + " in " + change.Namespace
+	if change.Namespace == "" {
+		where = change.Resource + " (cluster-scoped)"
+	}
+	switch change.Type {
+	case kausalityv1beta1.CoverageGained:
+		return fmt.Sprintf("%s gained tracking in %s mode", where, change.Mode)
+	case kausalityv1beta1.CoverageLost:
+		return fmt.Sprintf("%s lost tracking in %s mode", where, change.PreviousMode)
+	default:
+		return fmt.Sprintf("%s changed from %s to %s mode", where, change.PreviousMode, change.Mode)
+	}
+}
+
+// resourceName returns "group/resource", or "resource" for the core group.
+func resourceName(gr schema.GroupResource) string {
+	if gr.Group == "" {
+		return gr.Resource
+	}
+	return gr.Group + "/" + gr.Resource
+}
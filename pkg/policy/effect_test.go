@@ -0,0 +1,160 @@
+package policy
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+	callbackv1alpha1 "github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+)
+
+// effectPolicy returns a policy tracking deployments in mode, in the
+// namespaces selected by names, or all if empty.
+func effectPolicy(name string, mode kausalityv1beta1.Mode, names ...string) *kausalityv1beta1.Kausality {
+	policy := &kausalityv1beta1.Kausality{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Generation: 1},
+		Spec: kausalityv1beta1.KausalitySpec{
+			Resources: []kausalityv1beta1.ResourceRule{{APIGroups: []string{"apps"}, Resources: []string{"deployments"}}},
+			Mode:      mode,
+		},
+	}
+	if len(names) > 0 {
+		policy.Spec.Namespaces = &kausalityv1beta1.NamespaceSelector{Names: names}
+	}
+	return policy
+}
+
+func TestCoverageChanges(t *testing.T) {
+	namespaces := []corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "prod"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "dev"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "pinned", Annotations: map[string]string{ModeAnnotation: "log"}}},
+	}
+	deployments := []coverageResource{{GroupResource: schema.GroupResource{Group: "apps", Resource: "deployments"}, namespaced: true}}
+	change := func(changeType kausalityv1beta1.CoverageChangeType, namespace string, mode, previous kausalityv1beta1.Mode) kausalityv1beta1.CoverageChange {
+		return kausalityv1beta1.CoverageChange{Type: changeType, Resource: "apps/deployments", Namespace: namespace, Mode: mode, PreviousMode: previous}
+	}
+
+	tests := []struct {
+		name          string
+		before, after *kausalityv1beta1.Kausality
+		others        []kausalityv1beta1.Kausality
+		want          []kausalityv1beta1.CoverageChange
+	}{
+		{
+			name:  "created policy gains tracking",
+			after: effectPolicy("apps", kausalityv1beta1.ModeLog, "prod", "dev"),
+			want: []kausalityv1beta1.CoverageChange{
+				change(kausalityv1beta1.CoverageGained, "dev", kausalityv1beta1.ModeLog, ""),
+				change(kausalityv1beta1.CoverageGained, "prod", kausalityv1beta1.ModeLog, ""),
+			},
+		},
+		{
+			name:   "narrowed namespaces lose tracking",
+			before: effectPolicy("apps", kausalityv1beta1.ModeEnforce, "prod", "dev"),
+			after:  effectPolicy("apps", kausalityv1beta1.ModeEnforce, "prod"),
+			want:   []kausalityv1beta1.CoverageChange{change(kausalityv1beta1.CoverageLost, "dev", "", kausalityv1beta1.ModeEnforce)},
+		},
+		{
+			name:   "mode changes except where annotated",
+			before: effectPolicy("apps", kausalityv1beta1.ModeLog),
+			after:  effectPolicy("apps", kausalityv1beta1.ModeEnforce),
+			want: []kausalityv1beta1.CoverageChange{
+				change(kausalityv1beta1.CoverageModeChanged, "dev", kausalityv1beta1.ModeEnforce, kausalityv1beta1.ModeLog),
+				change(kausalityv1beta1.CoverageModeChanged, "prod", kausalityv1beta1.ModeEnforce, kausalityv1beta1.ModeLog),
+			},
+		},
+		{
+			name:   "deleted policy covered by another",
+			before: effectPolicy("apps", kausalityv1beta1.ModeLog, "prod"),
+			others: []kausalityv1beta1.Kausality{*effectPolicy("all", kausalityv1beta1.ModeLog)},
+			want:   []kausalityv1beta1.CoverageChange{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := coverageChanges(tt.before, tt.after, tt.others, deployments, namespaces, time.Now())
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// effectReporterFunc reports effects with a function.
+type effectReporterFunc func(report *callbackv1alpha1.PolicyEffectReport)
+
+func (f effectReporterFunc) Send(_ context.Context, report *callbackv1alpha1.PolicyEffectReport) error {
+	f(report)
+	return nil
+}
+
+func TestRecordEffect(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, kausalityv1beta1.AddToScheme(scheme))
+	policy := effectPolicy("apps", kausalityv1beta1.ModeEnforce, "prod", "dev")
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(policy,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "prod"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+	).Build()
+	recorder := events.NewFakeRecorder(10)
+	reports := make(chan *callbackv1alpha1.PolicyEffectReport, 10)
+	controller := &Controller{
+		Client:             c,
+		Log:                logr.Discard(),
+		ExcludedNamespaces: []string{"kube-system"},
+		Recorder:           recorder,
+		EffectReporter:     effectReporterFunc(func(report *callbackv1alpha1.PolicyEffectReport) { reports <- report }),
+	}
+	ctx := context.Background()
+
+	// The first reconciliation observes the policies as they are
+	assert.Nil(t, controller.recordEffect(ctx, logr.Discard(), policy))
+	assert.Empty(t, recorder.Events)
+
+	// Dropping dev loses tracking there
+	updated := effectPolicy("apps", kausalityv1beta1.ModeEnforce, "prod")
+	updated.Generation = 2
+	effect := controller.recordEffect(ctx, logr.Discard(), updated)
+	require.NotNil(t, effect)
+	assert.Equal(t, int64(2), effect.ObservedGeneration)
+	assert.Equal(t, int32(1), effect.Lost)
+	assert.Equal(t, []kausalityv1beta1.CoverageChange{{
+		Type: kausalityv1beta1.CoverageLost, Resource: "apps/deployments", Namespace: "dev", PreviousMode: kausalityv1beta1.ModeEnforce,
+	}}, effect.Changes)
+	event := <-recorder.Events
+	assert.True(t, strings.HasPrefix(event, "Warning CoverageChanged"), event)
+	assert.Contains(t, event, "apps/deployments in dev lost tracking in enforce mode")
+	report := <-reports
+	assert.Equal(t, "UPDATE", report.Spec.Operation)
+	assert.Equal(t, "apps", report.Spec.Policy)
+	assert.Len(t, report.Spec.Changes, 1)
+
+	// Later reconciliations of the same generation return the same effect
+	assert.Same(t, effect, controller.recordEffect(ctx, logr.Discard(), updated))
+	assert.Empty(t, recorder.Events)
+
+	// Deleting the policy loses tracking in prod
+	updated.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	assert.Nil(t, controller.recordEffect(ctx, logr.Discard(), updated))
+	report = <-reports
+	assert.Equal(t, "DELETE", report.Spec.Operation)
+	assert.Equal(t, int32(1), report.Spec.Lost)
+	assert.Equal(t, "prod", report.Spec.Changes[0].Namespace)
+	assert.Len(t, recorder.Events, 1)
+}
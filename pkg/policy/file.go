@@ -0,0 +1,108 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+	"github.com/kausality-io/kausality/pkg/config"
+)
+
+// DefaultFileCheckInterval is how often a FileWatcher checks the config file.
+const DefaultFileCheckInterval = 10 * time.Second
+
+// PoliciesFromConfig returns the policies declared in the config file as
+// Kausality objects, sorted by name.
+func PoliciesFromConfig(cfg *config.Config) []kausalityv1beta1.Kausality {
+	policies := make([]kausalityv1beta1.Kausality, 0, len(cfg.Policies))
+	for _, p := range cfg.Policies {
+		policies = append(policies, kausalityv1beta1.Kausality{
+			ObjectMeta: metav1.ObjectMeta{Name: p.Name},
+			Spec:       *p.KausalitySpec.DeepCopy(),
+		})
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		return policies[i].Name < policies[j].Name
+	})
+	return policies
+}
+
+// FileWatcher keeps the Store in sync with the policies of a config file,
+// for webhooks running standalone, without the policy controller and CRDs.
+// It polls the file, so it follows ConfigMap updates swapping the mounted
+// file. It implements manager.Runnable and runs on every replica.
+type FileWatcher struct {
+	path     string
+	store    *Store
+	log      logr.Logger
+	interval time.Duration
+	// data is the content of the file last loaded into the store
+	data []byte
+}
+
+// NewFileWatcher creates a FileWatcher checking the file every
+// DefaultFileCheckInterval.
+func NewFileWatcher(path string, store *Store, log logr.Logger) *FileWatcher {
+	return &FileWatcher{
+		path:     path,
+		store:    store,
+		log:      log.WithName("policy-file-watcher"),
+		interval: DefaultFileCheckInterval,
+	}
+}
+
+// NeedLeaderElection returns false: every replica needs up-to-date policies.
+func (w *FileWatcher) NeedLeaderElection() bool {
+	return false
+}
+
+// Start reloads the policies whenever the file changes, until the context is
+// cancelled. An invalid file is logged and the previous policies are kept.
+func (w *FileWatcher) Start(ctx context.Context) error {
+	for {
+		if _, err := w.Reload(); err != nil {
+			w.log.Error(err, "failed to reload policies, keeping previous policies", "path", w.path)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(w.interval):
+		}
+	}
+}
+
+// Reload loads the policies of the file into the store if the file changed
+// since the last successful reload, and returns whether it did.
+func (w *FileWatcher) Reload() (bool, error) {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if w.data != nil && bytes.Equal(data, w.data) {
+		return false, nil
+	}
+
+	cfg, err := config.Parse(data)
+	if err != nil {
+		return false, err
+	}
+	policies := PoliciesFromConfig(cfg)
+	w.store.Update(policies)
+	w.data = data
+
+	names := make([]string, len(policies))
+	for i, p := range policies {
+		names[i] = p.Name
+	}
+	w.log.Info("loaded policies", "path", w.path, "policies", names)
+	return true, nil
+}
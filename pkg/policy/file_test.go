@@ -0,0 +1,90 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+)
+
+func TestFileWatcher_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(content string) {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+	deployments := ResourceContext{
+		GVR:       schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+		Namespace: "prod",
+	}
+	configMaps := ResourceContext{
+		GVR:       schema.GroupVersionResource{Version: "v1", Resource: "configmaps"},
+		Namespace: "prod",
+	}
+
+	write(`
+backends:
+  - url: http://backend:8080/webhook
+policies:
+  - name: apps
+    resources:
+      - apiGroups: ["apps"]
+        resources: ["deployments"]
+    namespaces:
+      names: ["prod"]
+    mode: enforce
+`)
+	store := NewStore(nil, logr.Discard())
+	w := NewFileWatcher(path, store, logr.Discard())
+
+	reloaded, err := w.Reload()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+	assert.True(t, store.IsTracked(deployments))
+	assert.False(t, store.IsTracked(configMaps))
+	assert.Equal(t, kausalityv1alpha1.ModeEnforce, store.ResolveMode(deployments, nil, nil))
+
+	reloaded, err = w.Reload()
+	require.NoError(t, err)
+	assert.False(t, reloaded, "unchanged file")
+
+	write(`
+policies:
+  - name: apps
+    resources:
+      - apiGroups: ["apps"]
+        resources: ["deployments"]
+    mode: log
+  - name: core
+    resources:
+      - apiGroups: [""]
+        resources: ["configmaps"]
+    mode: log
+`)
+	reloaded, err = w.Reload()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+	assert.True(t, store.IsTracked(configMaps))
+	assert.Equal(t, kausalityv1alpha1.ModeLog, store.ResolveMode(deployments, nil, nil))
+
+	write(`
+policies:
+  - name: apps
+    resources: []
+    mode: enforce
+`)
+	_, err = w.Reload()
+	require.Error(t, err)
+	assert.True(t, store.IsTracked(configMaps), "invalid file keeps previous policies")
+
+	require.NoError(t, os.Remove(path))
+	_, err = w.Reload()
+	require.Error(t, err)
+	assert.True(t, store.IsTracked(configMaps), "missing file keeps previous policies")
+}
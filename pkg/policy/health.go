@@ -0,0 +1,375 @@
+package policy
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+)
+
+const (
+	// DefaultCertExpiryWarning is how long before expiry the CA bundle or
+	// the serving certificate make the webhook unhealthy, if the controller
+	// has no CertExpiryWarning. Rotation by the controller or cert-manager
+	// happens earlier, so certificates this close to expiry are not rotated.
+	DefaultCertExpiryWarning = 14 * 24 * time.Hour
+
+	// WebhookHealthRetryPeriod is how often an unhealthy webhook is checked
+	// again. Not every recovery is an event of a watched object, e.g. a
+	// port becoming reachable.
+	WebhookHealthRetryPeriod = 30 * time.Second
+)
+
+// Condition reasons of ConditionTypeWebhookHealthy.
+const (
+	WebhookHealthyReason             = "Healthy"
+	WebhookNotConfiguredReason       = "WebhookNotConfigured"
+	WebhookServiceNotFoundReason     = "ServiceNotFound"
+	WebhookPortNotServedReason       = "PortNotServed"
+	WebhookNoReadyEndpointsReason    = "NoReadyEndpoints"
+	WebhookUnreachableReason         = "Unreachable"
+	WebhookCABundleInvalidReason     = "CABundleInvalid"
+	WebhookCertificateExpiringReason = "CertificateExpiring"
+	WebhookCertificateInvalidReason  = "CertificateInvalid"
+)
+
+var (
+	webhookHealthy = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kausality_webhook_healthy",
+		Help: "Whether the webhook configuration can be served (1) or not (0), by the reason of the last check.",
+	}, []string{"webhook", "reason"})
+	webhookReadyEndpoints = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kausality_webhook_ready_endpoints",
+		Help: "Ready endpoints of the webhook service serving the webhook port.",
+	}, []string{"webhook"})
+	webhookCertificateExpiry = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kausality_webhook_certificate_expiry_timestamp_seconds",
+		Help: "Expiry of the webhook's CA bundle (ca) and serving certificate (serving), in seconds since the epoch.",
+	}, []string{"webhook", "certificate"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(webhookHealthy, webhookReadyEndpoints, webhookCertificateExpiry)
+}
+
+// WebhookHealth is the result of checking whether the API server can call
+// the webhook. An unhealthy webhook fails requests, or, with failurePolicy
+// Ignore, silently admits them without drift detection.
+type WebhookHealth struct {
+	// Reason is WebhookHealthyReason, or the reason of the first problem.
+	Reason string
+	// Problems describe why the webhook cannot be served, empty if healthy.
+	Problems []string
+	// ReadyEndpoints is the number of ready endpoints of the webhook service.
+	ReadyEndpoints int
+	// CAExpiry is when the latest expiring certificate of the CA bundle
+	// expires, zero if there is none.
+	CAExpiry time.Time
+	// ServingExpiry is when the serving certificate expires, zero if it was
+	// not checked.
+	ServingExpiry time.Time
+}
+
+// Healthy returns whether the webhook has no problems.
+func (h *WebhookHealth) Healthy() bool {
+	return len(h.Problems) == 0
+}
+
+// Message describes the health for the condition message.
+func (h *WebhookHealth) Message() string {
+	if !h.Healthy() {
+		return strings.Join(h.Problems, "; ")
+	}
+	msg := fmt.Sprintf("%d ready endpoints", h.ReadyEndpoints)
+	if !h.CAExpiry.IsZero() {
+		msg += ", CA bundle valid until " + h.CAExpiry.UTC().Format(time.RFC3339)
+	}
+	return msg
+}
+
+// problem records a problem with its condition reason.
+func (h *WebhookHealth) problem(reason, format string, args ...interface{}) {
+	if h.Healthy() {
+		h.Reason = reason
+	}
+	h.Problems = append(h.Problems, fmt.Sprintf(format, args...))
+}
+
+// checkWebhookHealth checks the service, endpoints, port and certificates
+// of the first webhook of the managed configuration, whose client config
+// all managed webhooks share.
+func (c *Controller) checkWebhookHealth(ctx context.Context) *WebhookHealth {
+	health := &WebhookHealth{Reason: WebhookHealthyReason}
+	defer c.recordWebhookHealth(health)
+
+	webhook, err := c.getWebhookConfiguration(ctx)
+	if err != nil {
+		health.problem(WebhookNotConfiguredReason, "%v", err)
+		return health
+	}
+	if len(webhook.Webhooks) == 0 {
+		health.problem(WebhookNotConfiguredReason, "webhook configuration %q has no webhooks defined", c.WebhookName)
+		return health
+	}
+	clientConfig := webhook.Webhooks[0].ClientConfig
+	now := time.Now()
+	roots := c.checkCABundle(health, clientConfig.CABundle, now)
+
+	var address, serverName string
+	switch {
+	case clientConfig.Service != nil:
+		ref := clientConfig.Service
+		port := int32(443)
+		if ref.Port != nil {
+			port = *ref.Port
+		}
+		serverName = ref.Name + "." + ref.Namespace + ".svc"
+		address = net.JoinHostPort(serverName, strconv.Itoa(int(port)))
+		if !c.checkService(ctx, health, ref.Namespace, ref.Name, port) {
+			return health
+		}
+	case clientConfig.URL != nil:
+		u, err := url.Parse(*clientConfig.URL)
+		if err != nil {
+			health.problem(WebhookNotConfiguredReason, "invalid webhook URL: %v", err)
+			return health
+		}
+		serverName, address = u.Hostname(), u.Host
+		if u.Port() == "" {
+			address = net.JoinHostPort(serverName, "443")
+		}
+	default:
+		health.problem(WebhookNotConfiguredReason, "webhook %q has neither service nor URL", webhook.Webhooks[0].Name)
+		return health
+	}
+
+	if roots != nil {
+		c.checkServingCert(ctx, health, roots, serverName, now)
+	}
+	if c.WebhookDialer != nil {
+		dialCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		conn, err := c.WebhookDialer(dialCtx, "tcp", address)
+		if err != nil {
+			health.problem(WebhookUnreachableReason, "webhook %s is unreachable: %v", address, err)
+		} else {
+			_ = conn.Close()
+		}
+	}
+	return health
+}
+
+// checkService checks that the webhook service serves port and has ready
+// endpoints for it. It returns false if the service does not exist.
+func (c *Controller) checkService(ctx context.Context, health *WebhookHealth, namespace, name string, port int32) bool {
+	key := client.ObjectKey{Namespace: namespace, Name: name}
+	var svc corev1.Service
+	if err := c.Get(ctx, key, &svc); err != nil {
+		if apierrors.IsNotFound(err) {
+			health.problem(WebhookServiceNotFoundReason, "webhook service %s not found", key)
+		} else {
+			health.problem(WebhookServiceNotFoundReason, "failed to get webhook service %s: %v", key, err)
+		}
+		return false
+	}
+
+	var servicePort *corev1.ServicePort
+	for i := range svc.Spec.Ports {
+		if svc.Spec.Ports[i].Port == port {
+			servicePort = &svc.Spec.Ports[i]
+			break
+		}
+	}
+	if servicePort == nil {
+		health.problem(WebhookPortNotServedReason, "webhook service %s has no port %d", key, port)
+		return true
+	}
+	// Services of type ExternalName have no endpoints
+	if svc.Spec.Type == corev1.ServiceTypeExternalName {
+		return true
+	}
+
+	// Endpoints are read instead of EndpointSlices, which clusters before
+	// Kubernetes 1.21 do not serve
+	var endpoints corev1.Endpoints //nolint:staticcheck // see above
+	if err := c.Get(ctx, key, &endpoints); client.IgnoreNotFound(err) != nil {
+		health.problem(WebhookNoReadyEndpointsReason, "failed to get endpoints of webhook service %s: %v", key, err)
+		return true
+	}
+	for _, subset := range endpoints.Subsets {
+		for _, p := range subset.Ports {
+			if p.Name == servicePort.Name {
+				health.ReadyEndpoints += len(subset.Addresses)
+				break
+			}
+		}
+	}
+	if health.ReadyEndpoints == 0 {
+		health.problem(WebhookNoReadyEndpointsReason, "webhook service %s has no ready endpoints for port %d", key, port)
+	}
+	return true
+}
+
+// checkCABundle checks that the CA bundle has a certificate not expiring
+// within the warning period, and returns its certificates, or nil if there
+// are none.
+func (c *Controller) checkCABundle(health *WebhookHealth, caBundle []byte, now time.Time) *x509.CertPool {
+	if len(caBundle) == 0 {
+		health.problem(WebhookCABundleInvalidReason, "webhook has no caBundle")
+		return nil
+	}
+	roots := x509.NewCertPool()
+	found := false
+	for rest := caBundle; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+		roots.AddCert(cert)
+		found = true
+		// During rotation, the bundle holds the previous CA until it expires
+		if cert.NotAfter.After(health.CAExpiry) {
+			health.CAExpiry = cert.NotAfter
+		}
+	}
+	if !found {
+		health.problem(WebhookCABundleInvalidReason, "webhook caBundle has no valid PEM certificate")
+		return nil
+	}
+	c.checkExpiry(health, "CA bundle", health.CAExpiry, now)
+	return roots
+}
+
+// checkServingCert checks that the serving certificate of WebhookCertSecret
+// is signed by the CA bundle for serverName and not expiring within the
+// warning period. Certificates provisioned elsewhere are not checked.
+func (c *Controller) checkServingCert(ctx context.Context, health *WebhookHealth, roots *x509.CertPool, serverName string, now time.Time) {
+	if c.WebhookCertSecretName == "" {
+		return
+	}
+	key := client.ObjectKey{Namespace: c.WebhookServiceRef.Namespace, Name: c.WebhookCertSecretName}
+	var secret corev1.Secret
+	if err := c.Get(ctx, key, &secret); err != nil {
+		if !apierrors.IsNotFound(err) {
+			health.problem(WebhookCertificateInvalidReason, "failed to get webhook certificate secret %s: %v", key, err)
+		}
+		return
+	}
+	block, _ := pem.Decode(secret.Data[corev1.TLSCertKey])
+	if block == nil {
+		health.problem(WebhookCertificateInvalidReason, "webhook certificate secret %s has no PEM certificate", key)
+		return
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		health.problem(WebhookCertificateInvalidReason, "failed to parse serving certificate of %s: %v", key, err)
+		return
+	}
+	health.ServingExpiry = cert.NotAfter
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:       roots,
+		DNSName:     serverName,
+		CurrentTime: now,
+		KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}); err != nil {
+		health.problem(WebhookCertificateInvalidReason, "serving certificate of %s is not trusted by the caBundle: %v", key, err)
+		return
+	}
+	c.checkExpiry(health, "serving certificate", cert.NotAfter, now)
+}
+
+// checkExpiry records a problem if a certificate expires within the warning
+// period.
+func (c *Controller) checkExpiry(health *WebhookHealth, name string, expiry, now time.Time) {
+	warning := c.CertExpiryWarning
+	if warning == 0 {
+		warning = DefaultCertExpiryWarning
+	}
+	switch {
+	case !now.Before(expiry):
+		health.problem(WebhookCertificateExpiringReason, "%s expired at %s", name, expiry.UTC().Format(time.RFC3339))
+	case now.Add(warning).After(expiry):
+		health.problem(WebhookCertificateExpiringReason, "%s expires at %s", name, expiry.UTC().Format(time.RFC3339))
+	}
+}
+
+// recordWebhookHealth exports the health as metrics.
+func (c *Controller) recordWebhookHealth(health *WebhookHealth) {
+	webhookHealthy.DeletePartialMatch(prometheus.Labels{"webhook": c.WebhookName})
+	healthy := 0.0
+	if health.Healthy() {
+		healthy = 1
+	}
+	webhookHealthy.WithLabelValues(c.WebhookName, health.Reason).Set(healthy)
+	webhookReadyEndpoints.WithLabelValues(c.WebhookName).Set(float64(health.ReadyEndpoints))
+	for certificate, expiry := range map[string]time.Time{"ca": health.CAExpiry, "serving": health.ServingExpiry} {
+		if expiry.IsZero() {
+			webhookCertificateExpiry.DeleteLabelValues(c.WebhookName, certificate)
+			continue
+		}
+		webhookCertificateExpiry.WithLabelValues(c.WebhookName, certificate).Set(float64(expiry.Unix()))
+	}
+}
+
+// setHealthCondition records the webhook health on a policy.
+func (c *Controller) setHealthCondition(policy *kausalityv1beta1.Kausality, health *WebhookHealth) {
+	status := metav1.ConditionTrue
+	if !health.Healthy() {
+		status = metav1.ConditionFalse
+	}
+	c.setCondition(policy, ConditionTypeWebhookHealthy, status, health.Reason, health.Message())
+}
+
+// isWebhookObject returns whether obj is the webhook service, its
+// endpoints or its certificate secret.
+func (c *Controller) isWebhookObject(obj client.Object) bool {
+	if obj.GetNamespace() != c.WebhookServiceRef.Namespace {
+		return false
+	}
+	switch obj.(type) {
+	case *corev1.Secret:
+		return c.WebhookCertSecretName != "" && obj.GetName() == c.WebhookCertSecretName
+	default:
+		return c.WebhookServiceRef.Name != "" && obj.GetName() == c.WebhookServiceRef.Name
+	}
+}
+
+// mapWebhookObjectToKausalityPolicies returns all policies: the webhook
+// health is recorded on each of them, and each reconciles the webhook
+// configuration.
+func (c *Controller) mapWebhookObjectToKausalityPolicies(ctx context.Context, obj client.Object) []reconcile.Request {
+	var policies kausalityv1beta1.KausalityList
+	if err := c.List(ctx, &policies); err != nil {
+		c.Log.Error(err, "failed to list Kausality policies for webhook health watch")
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(policies.Items))
+	for _, policy := range policies.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&policy)})
+	}
+	return requests
+}
@@ -0,0 +1,291 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+	"github.com/kausality-io/kausality/pkg/certs"
+)
+
+// webhookFixture is a healthy webhook: its configuration, service,
+// endpoints and certificate secret.
+type webhookFixture struct {
+	webhook   *admissionregistrationv1.MutatingWebhookConfiguration
+	service   *corev1.Service
+	endpoints *corev1.Endpoints //nolint:staticcheck // see checkService
+	secret    *corev1.Secret
+}
+
+func newWebhookFixture(t *testing.T, caValidity, certValidity time.Duration) *webhookFixture {
+	t.Helper()
+	now := time.Now()
+	ca, err := certs.GenerateCA("kausality-ca", caValidity, now)
+	require.NoError(t, err)
+	serving, err := certs.GenerateServingCert(ca, certs.ServiceDNSNames("kausality-webhook", "kausality-system"), certValidity, now)
+	require.NoError(t, err)
+
+	return &webhookFixture{
+		webhook: &admissionregistrationv1.MutatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: "kausality"},
+			Webhooks: []admissionregistrationv1.MutatingWebhook{{
+				Name: "mutating.webhook.kausality.io",
+				ClientConfig: admissionregistrationv1.WebhookClientConfig{
+					Service:  &admissionregistrationv1.ServiceReference{Namespace: "kausality-system", Name: "kausality-webhook", Port: ptr.To[int32](443)},
+					CABundle: ca.Cert,
+				},
+			}},
+		},
+		service: &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "kausality-system", Name: "kausality-webhook"},
+			Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "https", Port: 443}}},
+		},
+		endpoints: &corev1.Endpoints{ //nolint:staticcheck // see checkService
+			ObjectMeta: metav1.ObjectMeta{Namespace: "kausality-system", Name: "kausality-webhook"},
+			Subsets: []corev1.EndpointSubset{{ //nolint:staticcheck // see checkService
+				Addresses:         []corev1.EndpointAddress{{IP: "10.0.0.1"}, {IP: "10.0.0.2"}}, //nolint:staticcheck // see checkService
+				NotReadyAddresses: []corev1.EndpointAddress{{IP: "10.0.0.3"}},                   //nolint:staticcheck // see checkService
+				Ports:             []corev1.EndpointPort{{Name: "https", Port: 9443}},           //nolint:staticcheck // see checkService
+			}},
+		},
+		secret: &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "kausality-system", Name: "kausality-webhook-cert"},
+			Data:       map[string][]byte{corev1.TLSCertKey: serving.Cert},
+		},
+	}
+}
+
+func (f *webhookFixture) controller(t *testing.T, objs ...client.Object) *Controller {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, kausalityv1beta1.AddToScheme(scheme))
+	require.NoError(t, apiextensionsv1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(objs...).
+		WithStatusSubresource(&kausalityv1beta1.Kausality{}).
+		Build()
+	return &Controller{
+		Client:                c,
+		Log:                   logr.Discard(),
+		WebhookName:           "kausality",
+		WebhookServiceRef:     WebhookServiceRef{Namespace: "kausality-system", Name: "kausality-webhook", Port: 443},
+		WebhookCertSecretName: "kausality-webhook-cert",
+	}
+}
+
+func (f *webhookFixture) objects() []client.Object {
+	return []client.Object{f.webhook, f.service, f.endpoints, f.secret}
+}
+
+func TestCheckWebhookHealth(t *testing.T) {
+	const year = 365 * 24 * time.Hour
+	ctx := context.Background()
+
+	t.Run("healthy", func(t *testing.T) {
+		f := newWebhookFixture(t, 5*year, year)
+		var dialed string
+		c := f.controller(t, f.objects()...)
+		c.WebhookDialer = func(ctx context.Context, network, address string) (net.Conn, error) {
+			dialed = address
+			client, server := net.Pipe()
+			_ = server.Close()
+			return client, nil
+		}
+		health := c.checkWebhookHealth(ctx)
+		assert.True(t, health.Healthy(), health.Problems)
+		assert.Equal(t, WebhookHealthyReason, health.Reason)
+		assert.Equal(t, 2, health.ReadyEndpoints)
+		assert.False(t, health.CAExpiry.IsZero())
+		assert.False(t, health.ServingExpiry.IsZero())
+		assert.Equal(t, "kausality-webhook.kausality-system.svc:443", dialed)
+		assert.Contains(t, health.Message(), "2 ready endpoints")
+	})
+
+	tests := []struct {
+		name       string
+		modify     func(f *webhookFixture) []client.Object
+		dialErr    error
+		wantReason string
+	}{
+		{
+			name: "service not found",
+			modify: func(f *webhookFixture) []client.Object {
+				return []client.Object{f.webhook, f.secret}
+			},
+			wantReason: WebhookServiceNotFoundReason,
+		},
+		{
+			name: "port not served",
+			modify: func(f *webhookFixture) []client.Object {
+				f.service.Spec.Ports[0].Port = 8443
+				return f.objects()
+			},
+			wantReason: WebhookPortNotServedReason,
+		},
+		{
+			name: "no ready endpoints",
+			modify: func(f *webhookFixture) []client.Object {
+				f.endpoints.Subsets[0].Addresses = nil
+				return f.objects()
+			},
+			wantReason: WebhookNoReadyEndpointsReason,
+		},
+		{
+			name: "no endpoints",
+			modify: func(f *webhookFixture) []client.Object {
+				return []client.Object{f.webhook, f.service, f.secret}
+			},
+			wantReason: WebhookNoReadyEndpointsReason,
+		},
+		{
+			name: "missing caBundle",
+			modify: func(f *webhookFixture) []client.Object {
+				f.webhook.Webhooks[0].ClientConfig.CABundle = nil
+				return f.objects()
+			},
+			wantReason: WebhookCABundleInvalidReason,
+		},
+		{
+			name: "invalid caBundle",
+			modify: func(f *webhookFixture) []client.Object {
+				f.webhook.Webhooks[0].ClientConfig.CABundle = []byte("not a certificate")
+				return f.objects()
+			},
+			wantReason: WebhookCABundleInvalidReason,
+		},
+		{
+			name: "serving certificate of another CA",
+			modify: func(f *webhookFixture) []client.Object {
+				other := newWebhookFixture(t, 5*year, year)
+				f.secret.Data = other.secret.Data
+				return f.objects()
+			},
+			wantReason: WebhookCertificateInvalidReason,
+		},
+		{
+			name: "unreachable",
+			modify: func(f *webhookFixture) []client.Object {
+				return f.objects()
+			},
+			dialErr:    errors.New("connection refused"),
+			wantReason: WebhookUnreachableReason,
+		},
+		{
+			name: "no webhooks",
+			modify: func(f *webhookFixture) []client.Object {
+				f.webhook.Webhooks = nil
+				return f.objects()
+			},
+			wantReason: WebhookNotConfiguredReason,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newWebhookFixture(t, 5*year, year)
+			c := f.controller(t, tt.modify(f)...)
+			if tt.dialErr != nil {
+				c.WebhookDialer = func(context.Context, string, string) (net.Conn, error) {
+					return nil, tt.dialErr
+				}
+			}
+			health := c.checkWebhookHealth(ctx)
+			assert.False(t, health.Healthy())
+			assert.Equal(t, tt.wantReason, health.Reason, health.Problems)
+			assert.Equal(t, health.Message(), health.Problems[0])
+		})
+	}
+
+	t.Run("certificates near expiry", func(t *testing.T) {
+		f := newWebhookFixture(t, 5*year, 7*24*time.Hour)
+		health := f.controller(t, f.objects()...).checkWebhookHealth(ctx)
+		assert.Equal(t, WebhookCertificateExpiringReason, health.Reason)
+		assert.Contains(t, health.Message(), "serving certificate expires at")
+
+		f = newWebhookFixture(t, 10*24*time.Hour, 7*24*time.Hour)
+		c := f.controller(t, f.objects()...)
+		health = c.checkWebhookHealth(ctx)
+		assert.Contains(t, health.Message(), "CA bundle expires at")
+		c.CertExpiryWarning = 24 * time.Hour
+		assert.True(t, c.checkWebhookHealth(ctx).Healthy(), "within the configured warning period")
+	})
+
+	t.Run("certificates provisioned elsewhere", func(t *testing.T) {
+		f := newWebhookFixture(t, 5*year, year)
+		health := f.controller(t, f.webhook, f.service, f.endpoints).checkWebhookHealth(ctx)
+		assert.True(t, health.Healthy(), health.Problems)
+		assert.True(t, health.ServingExpiry.IsZero())
+	})
+}
+
+func TestReconcile_WebhookHealth(t *testing.T) {
+	f := newWebhookFixture(t, 5*365*24*time.Hour, 365*24*time.Hour)
+	policy := &kausalityv1beta1.Kausality{
+		ObjectMeta: metav1.ObjectMeta{Name: "apps"},
+		Spec: kausalityv1beta1.KausalitySpec{Resources: []kausalityv1beta1.ResourceRule{
+			{APIGroups: []string{"apps"}, Resources: []string{"deployments"}},
+		}},
+	}
+	c := f.controller(t, f.webhook, f.service, f.secret, policy)
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(policy)}
+
+	// Without endpoints, the policy is not ready and checked again soon
+	result, err := c.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, WebhookHealthRetryPeriod, result.RequeueAfter)
+	var got kausalityv1beta1.Kausality
+	require.NoError(t, c.Get(ctx, req.NamespacedName, &got))
+	healthy := meta.FindStatusCondition(got.Status.Conditions, ConditionTypeWebhookHealthy)
+	require.NotNil(t, healthy)
+	assert.Equal(t, metav1.ConditionFalse, healthy.Status)
+	assert.Equal(t, WebhookNoReadyEndpointsReason, healthy.Reason)
+	ready := meta.FindStatusCondition(got.Status.Conditions, ConditionTypeReady)
+	require.NotNil(t, ready)
+	assert.Equal(t, metav1.ConditionFalse, ready.Status)
+	assert.Equal(t, "WebhookUnhealthy", ready.Reason)
+
+	require.NoError(t, c.Create(ctx, f.endpoints))
+	result, err = c.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, DiscoveryResyncPeriod, result.RequeueAfter)
+	require.NoError(t, c.Get(ctx, req.NamespacedName, &got))
+	assert.True(t, meta.IsStatusConditionTrue(got.Status.Conditions, ConditionTypeWebhookHealthy))
+	assert.True(t, meta.IsStatusConditionTrue(got.Status.Conditions, ConditionTypeReady))
+}
+
+func TestMapWebhookObjectToKausalityPolicies(t *testing.T) {
+	f := newWebhookFixture(t, time.Hour, time.Hour)
+	c := f.controller(t,
+		&kausalityv1beta1.Kausality{ObjectMeta: metav1.ObjectMeta{Name: "apps"}},
+		&kausalityv1beta1.Kausality{ObjectMeta: metav1.ObjectMeta{Name: "core"}},
+	)
+
+	assert.True(t, c.isWebhookObject(f.service))
+	assert.True(t, c.isWebhookObject(f.endpoints))
+	assert.True(t, c.isWebhookObject(f.secret))
+	assert.False(t, c.isWebhookObject(&corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "kausality-system", Name: "other"}}))
+	assert.False(t, c.isWebhookObject(&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "kausality-webhook-cert"}}))
+	assert.False(t, c.isWebhookObject(&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "kausality-system", Name: "kausality-webhook"}}))
+
+	assert.Len(t, c.mapWebhookObjectToKausalityPolicies(context.Background(), f.service), 2)
+}
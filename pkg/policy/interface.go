@@ -1,7 +1,12 @@
 package policy
 
 import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
 	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+	"github.com/kausality-io/kausality/pkg/drift"
+	"github.com/kausality-io/kausality/pkg/fieldpath"
 )
 
 // Resolver resolves drift detection mode for resources.
@@ -15,6 +20,37 @@ type Resolver interface {
 
 	// IsTracked returns true if the resource is tracked by any policy.
 	IsTracked(ctx ResourceContext) bool
+
+	// TracksResource returns true if any policy's resource rules match the
+	// GVR, ignoring namespace and object selectors.
+	TracksResource(gvr schema.GroupVersionResource) bool
+
+	// SubresourceHandling returns how requests to a subresource of the resource are handled.
+	SubresourceHandling(ctx ResourceContext, subresource string) kausalityv1alpha1.SubresourceHandling
+
+	// DriftExclusion returns the name of the policy excluding the object from
+	// drift evaluation, or "" if it is evaluated.
+	DriftExclusion(ctx ResourceContext, objectAnnotations map[string]string) string
+
+	// FieldPaths returns the field paths drift detection of the resource is
+	// limited to, nil for the whole spec.
+	FieldPaths(ctx ResourceContext) []fieldpath.Path
+
+	// Classifier returns the classifier identifying the controller of the
+	// resource's parent, nil for the default.
+	Classifier(ctx ResourceContext) drift.Classifier
+
+	// MissingParent returns how mutations of the resource are handled if its
+	// controller owner does not exist, "" for the webhook's error handling.
+	MissingParent(ctx ResourceContext) kausalityv1beta1.MissingParentAction
+
+	// OwnerReferences returns how controller owner references added to the
+	// resource are validated, "" if they are not.
+	OwnerReferences(ctx ResourceContext) kausalityv1beta1.OwnerReferenceAction
+
+	// DriftAction returns how drift of the resource is handled in enforce
+	// mode, "" to deny it.
+	DriftAction(ctx ResourceContext) kausalityv1beta1.DriftAction
 }
 
 // StaticResolver provides a fixed mode for all resources.
@@ -47,3 +83,44 @@ func (r *StaticResolver) ResolveMode(ctx ResourceContext, objectAnnotations, nam
 func (r *StaticResolver) IsTracked(ctx ResourceContext) bool {
 	return true
 }
+
+// TracksResource always returns true - static resolver tracks everything.
+func (r *StaticResolver) TracksResource(gvr schema.GroupVersionResource) bool {
+	return true
+}
+
+// SubresourceHandling returns the default handling: status identifies
+// controllers, other subresources are tracked.
+func (r *StaticResolver) SubresourceHandling(ctx ResourceContext, subresource string) kausalityv1alpha1.SubresourceHandling {
+	return DefaultSubresourceHandling(subresource)
+}
+
+// DriftExclusion returns "" - static resolver evaluates everything.
+func (r *StaticResolver) DriftExclusion(ctx ResourceContext, objectAnnotations map[string]string) string {
+	return ""
+}
+
+// FieldPaths returns nil - static resolver tracks the whole spec.
+func (r *StaticResolver) FieldPaths(ctx ResourceContext) []fieldpath.Path {
+	return nil
+}
+
+// Classifier returns nil - static resolver uses the default classifier.
+func (r *StaticResolver) Classifier(ctx ResourceContext) drift.Classifier {
+	return nil
+}
+
+// MissingParent returns "" - static resolver uses the webhook's error handling.
+func (r *StaticResolver) MissingParent(ctx ResourceContext) kausalityv1beta1.MissingParentAction {
+	return ""
+}
+
+// OwnerReferences returns "" - static resolver does not validate owner references.
+func (r *StaticResolver) OwnerReferences(ctx ResourceContext) kausalityv1beta1.OwnerReferenceAction {
+	return ""
+}
+
+// DriftAction returns "" - static resolver denies drift without quarantine.
+func (r *StaticResolver) DriftAction(ctx ResourceContext) kausalityv1beta1.DriftAction {
+	return ""
+}
@@ -10,6 +10,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
 )
 
 // TestPrecedence_NamespaceSpecificity tests that more specific namespace
@@ -67,25 +68,25 @@ func TestPrecedence_NamespaceSpecificity(t *testing.T) {
 	s := &Store{}
 
 	t.Run("explicit names wins over selector", func(t *testing.T) {
-		s.policies = []kausalityv1alpha1.Kausality{selectorPolicy, explicitNamesPolicy}
+		s.policies = hubPolicies(t, []kausalityv1alpha1.Kausality{selectorPolicy, explicitNamesPolicy})
 		mode := s.ResolveMode(ctx, nil, nil)
 		assert.Equal(t, kausalityv1alpha1.ModeEnforce, mode, "explicit names policy should win")
 	})
 
 	t.Run("explicit names wins over all-namespaces", func(t *testing.T) {
-		s.policies = []kausalityv1alpha1.Kausality{allNamespacesPolicy, explicitNamesPolicy}
+		s.policies = hubPolicies(t, []kausalityv1alpha1.Kausality{allNamespacesPolicy, explicitNamesPolicy})
 		mode := s.ResolveMode(ctx, nil, nil)
 		assert.Equal(t, kausalityv1alpha1.ModeEnforce, mode, "explicit names policy should win")
 	})
 
 	t.Run("selector wins over all-namespaces", func(t *testing.T) {
-		s.policies = []kausalityv1alpha1.Kausality{allNamespacesPolicy, selectorPolicy}
+		s.policies = hubPolicies(t, []kausalityv1alpha1.Kausality{allNamespacesPolicy, selectorPolicy})
 		mode := s.ResolveMode(ctx, nil, nil)
 		assert.Equal(t, kausalityv1alpha1.ModeLog, mode, "selector policy should win")
 	})
 
 	t.Run("all three policies - explicit names wins", func(t *testing.T) {
-		s.policies = []kausalityv1alpha1.Kausality{allNamespacesPolicy, selectorPolicy, explicitNamesPolicy}
+		s.policies = hubPolicies(t, []kausalityv1alpha1.Kausality{allNamespacesPolicy, selectorPolicy, explicitNamesPolicy})
 		mode := s.ResolveMode(ctx, nil, nil)
 		assert.Equal(t, kausalityv1alpha1.ModeEnforce, mode, "explicit names policy should win")
 	})
@@ -124,13 +125,13 @@ func TestPrecedence_ResourceSpecificity(t *testing.T) {
 	s := &Store{}
 
 	t.Run("explicit resource wins over wildcard", func(t *testing.T) {
-		s.policies = []kausalityv1alpha1.Kausality{wildcardResourcePolicy, explicitResourcePolicy}
+		s.policies = hubPolicies(t, []kausalityv1alpha1.Kausality{wildcardResourcePolicy, explicitResourcePolicy})
 		mode := s.ResolveMode(ctx, nil, nil)
 		assert.Equal(t, kausalityv1alpha1.ModeEnforce, mode, "explicit resource policy should win")
 	})
 
 	t.Run("order doesn't matter - explicit still wins", func(t *testing.T) {
-		s.policies = []kausalityv1alpha1.Kausality{explicitResourcePolicy, wildcardResourcePolicy}
+		s.policies = hubPolicies(t, []kausalityv1alpha1.Kausality{explicitResourcePolicy, wildcardResourcePolicy})
 		mode := s.ResolveMode(ctx, nil, nil)
 		assert.Equal(t, kausalityv1alpha1.ModeEnforce, mode, "explicit resource policy should win")
 	})
@@ -169,13 +170,13 @@ func TestPrecedence_AlphabeticalTieBreaker(t *testing.T) {
 
 	t.Run("alphabetically earlier wins when specificity equal", func(t *testing.T) {
 		// Note: policies are sorted alphabetically by name in the store
-		s.policies = []kausalityv1alpha1.Kausality{policyA, policyZ}
+		s.policies = hubPolicies(t, []kausalityv1alpha1.Kausality{policyA, policyZ})
 		mode := s.ResolveMode(ctx, nil, nil)
 		assert.Equal(t, kausalityv1alpha1.ModeEnforce, mode, "aaa-policy should win (alphabetically first)")
 	})
 
 	t.Run("order in slice doesn't matter - alphabetical wins", func(t *testing.T) {
-		s.policies = []kausalityv1alpha1.Kausality{policyZ, policyA}
+		s.policies = hubPolicies(t, []kausalityv1alpha1.Kausality{policyZ, policyA})
 		mode := s.ResolveMode(ctx, nil, nil)
 		// Since specificity is equal and both match, the first one checked wins.
 		// The store iterates in order, so we need to check the actual behavior.
@@ -225,7 +226,7 @@ func TestPrecedence_OverrideEvaluationOrder(t *testing.T) {
 	}
 
 	s := &Store{}
-	s.policies = []kausalityv1alpha1.Kausality{policy}
+	s.policies = hubPolicies(t, []kausalityv1alpha1.Kausality{policy})
 
 	t.Run("first matching override wins", func(t *testing.T) {
 		mode := s.ResolveMode(ctx, nil, nil)
@@ -289,7 +290,7 @@ func TestPrecedence_CombinedScenarios(t *testing.T) {
 	}
 
 	s := &Store{}
-	s.policies = []kausalityv1alpha1.Kausality{platformBaseline, teamPayments, teamOrders}
+	s.policies = hubPolicies(t, []kausalityv1alpha1.Kausality{platformBaseline, teamPayments, teamOrders})
 
 	tests := []struct {
 		name     string
@@ -376,7 +377,7 @@ func TestPrecedence_AnnotationOverridesPolicy(t *testing.T) {
 	}
 
 	s := &Store{}
-	s.policies = []kausalityv1alpha1.Kausality{enforcePolicy}
+	s.policies = hubPolicies(t, []kausalityv1alpha1.Kausality{enforcePolicy})
 
 	t.Run("object annotation overrides policy", func(t *testing.T) {
 		mode := s.ResolveMode(ctx, map[string]string{ModeAnnotation: "log"}, nil)
@@ -438,7 +439,7 @@ func TestPrecedence_ObjectSelectorAddsSpecificity(t *testing.T) {
 	s := &Store{}
 
 	t.Run("object selector adds specificity", func(t *testing.T) {
-		s.policies = []kausalityv1alpha1.Kausality{noSelectorPolicy, withSelectorPolicy}
+		s.policies = hubPolicies(t, []kausalityv1alpha1.Kausality{noSelectorPolicy, withSelectorPolicy})
 		mode := s.ResolveMode(ctx, nil, nil)
 		assert.Equal(t, kausalityv1alpha1.ModeEnforce, mode, "policy with object selector should win")
 	})
@@ -449,7 +450,7 @@ func TestPrecedence_ObjectSelectorAddsSpecificity(t *testing.T) {
 			Namespace: "default",
 			// No ObjectLabels
 		}
-		s.policies = []kausalityv1alpha1.Kausality{noSelectorPolicy, withSelectorPolicy}
+		s.policies = hubPolicies(t, []kausalityv1alpha1.Kausality{noSelectorPolicy, withSelectorPolicy})
 		mode := s.ResolveMode(unlabeledCtx, nil, nil)
 		assert.Equal(t, kausalityv1alpha1.ModeLog, mode, "should fall back to policy without selector")
 	})
@@ -563,8 +564,61 @@ func TestPrecedence_SpecificityScoreCalculation(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			score := s.calculateSpecificity(&tt.policy, ctx)
+			policy := hubPolicies(t, []kausalityv1alpha1.Kausality{tt.policy})[0]
+			score := s.calculateSpecificity(&policy, ctx)
 			require.Equal(t, tt.expectedScore, score, tt.scoreBreakdown)
 		})
 	}
 }
+
+// TestPrecedence_MixedVersions tests that policies created through v1alpha1
+// and v1beta1 are ranked by the same specificity rules.
+func TestPrecedence_MixedVersions(t *testing.T) {
+	ctx := ResourceContext{
+		GVR:             schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+		Namespace:       "production",
+		OldObjectLabels: map[string]string{"protected": "true"},
+	}
+
+	// v1alpha1 policy with an object selector, which only matches the new object
+	alphaPolicy := hubPolicies(t, []kausalityv1alpha1.Kausality{{
+		ObjectMeta: metav1.ObjectMeta{Name: "alpha"},
+		Spec: kausalityv1alpha1.KausalitySpec{
+			Resources: []kausalityv1alpha1.ResourceRule{
+				{APIGroups: []string{"apps"}, Resources: []string{"deployments"}},
+			},
+			ObjectSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"protected": "true"},
+			},
+			Mode: kausalityv1alpha1.ModeEnforce,
+		},
+	}})[0]
+
+	// Less specific v1beta1 policy, matching the old object too
+	betaPolicy := kausalityv1beta1.Kausality{
+		ObjectMeta: metav1.ObjectMeta{Name: "beta"},
+		Spec: kausalityv1beta1.KausalitySpec{
+			Resources: []kausalityv1beta1.ResourceRule{
+				{APIGroups: []string{"apps"}, Resources: []string{"*"}},
+			},
+			ObjectSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"protected": "true"},
+			},
+			Mode: kausalityv1beta1.ModeLog,
+		},
+	}
+
+	s := &Store{}
+
+	t.Run("old labels only match the v1beta1 policy", func(t *testing.T) {
+		s.policies = []kausalityv1beta1.Kausality{alphaPolicy, betaPolicy}
+		assert.Equal(t, kausalityv1alpha1.ModeLog, s.ResolveMode(ctx, nil, nil))
+	})
+
+	t.Run("more specific v1alpha1 policy wins on new labels", func(t *testing.T) {
+		s.policies = []kausalityv1beta1.Kausality{betaPolicy, alphaPolicy}
+		newCtx := ctx
+		newCtx.ObjectLabels = map[string]string{"protected": "true"}
+		assert.Equal(t, kausalityv1alpha1.ModeEnforce, s.ResolveMode(newCtx, nil, nil))
+	})
+}
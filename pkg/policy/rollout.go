@@ -0,0 +1,13 @@
+package policy
+
+import "hash/fnv"
+
+// RolloutBucket returns the rollout bucket of an object, in [0, 100). An
+// override with rolloutPercentage p applies to the objects in buckets below p,
+// so raising p only adds objects. Objects created with generateName have no
+// name at admission and share the bucket of their namespace until updated.
+func RolloutBucket(namespace, name string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(namespace + "/" + name))
+	return int(h.Sum32() % 100)
+}
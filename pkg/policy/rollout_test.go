@@ -0,0 +1,25 @@
+package policy
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRolloutBucket(t *testing.T) {
+	assert.Equal(t, RolloutBucket("prod", "web"), RolloutBucket("prod", "web"), "deterministic")
+	assert.NotEqual(t, RolloutBucket("prod", "web"), RolloutBucket("web", "prod"))
+
+	// Buckets are spread evenly enough for percentages to be meaningful
+	below := 0
+	for i := 0; i < 1000; i++ {
+		bucket := RolloutBucket("default", fmt.Sprintf("object-%d", i))
+		assert.GreaterOrEqual(t, bucket, 0)
+		assert.Less(t, bucket, 100)
+		if bucket < 10 {
+			below++
+		}
+	}
+	assert.InDelta(t, 100, below, 50)
+}
@@ -0,0 +1,75 @@
+package policy
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+)
+
+// locations caches loaded time zones: loading reads the zoneinfo database.
+var locations sync.Map
+
+// ScheduleOpen returns whether the window of a schedule is open at now. A
+// window whose end is before its start closes on the day after it opened.
+func ScheduleOpen(schedule kausalityv1beta1.ModeSchedule, now time.Time) (bool, error) {
+	start, err := minuteOfDay(schedule.Start)
+	if err != nil {
+		return false, err
+	}
+	end, err := minuteOfDay(schedule.End)
+	if err != nil {
+		return false, err
+	}
+	if start == end {
+		return false, fmt.Errorf("start and end of schedule are both %s", schedule.Start)
+	}
+	loc, err := location(schedule.TimeZone)
+	if err != nil {
+		return false, err
+	}
+
+	now = now.In(loc)
+	minute := now.Hour()*60 + now.Minute()
+	opensOn := func(day time.Weekday) bool {
+		return len(schedule.Days) == 0 || slices.Contains(schedule.Days, kausalityv1beta1.Weekday(day.String()))
+	}
+	switch {
+	case start < end:
+		return opensOn(now.Weekday()) && start <= minute && minute < end, nil
+	case minute >= start:
+		return opensOn(now.Weekday()), nil
+	case minute < end:
+		// Opened yesterday
+		return opensOn((now.Weekday() + 6) % 7), nil
+	default:
+		return false, nil
+	}
+}
+
+// minuteOfDay parses HH:MM.
+func minuteOfDay(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q, must be HH:MM", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// location returns the time zone of a schedule, UTC if empty.
+func location(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q: %w", name, err)
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
@@ -0,0 +1,54 @@
+package policy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+)
+
+func TestScheduleOpen(t *testing.T) {
+	// 2026-10-16 is a Friday
+	at := func(value string) time.Time {
+		ts, err := time.Parse(time.RFC3339, value)
+		require.NoError(t, err)
+		return ts
+	}
+	weekdays := []kausalityv1beta1.Weekday{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday"}
+
+	tests := []struct {
+		name     string
+		schedule kausalityv1beta1.ModeSchedule
+		now      string
+		want     bool
+		wantErr  bool
+	}{
+		{name: "inside", schedule: kausalityv1beta1.ModeSchedule{Start: "09:00", End: "17:00"}, now: "2026-10-16T12:00:00Z", want: true},
+		{name: "at start", schedule: kausalityv1beta1.ModeSchedule{Start: "09:00", End: "17:00"}, now: "2026-10-16T09:00:00Z", want: true},
+		{name: "at end", schedule: kausalityv1beta1.ModeSchedule{Start: "09:00", End: "17:00"}, now: "2026-10-16T17:00:00Z"},
+		{name: "weekday", schedule: kausalityv1beta1.ModeSchedule{Days: weekdays, Start: "09:00", End: "17:00"}, now: "2026-10-16T12:00:00Z", want: true},
+		{name: "weekend", schedule: kausalityv1beta1.ModeSchedule{Days: weekdays, Start: "09:00", End: "17:00"}, now: "2026-10-17T12:00:00Z"},
+		{name: "time zone", schedule: kausalityv1beta1.ModeSchedule{Start: "09:00", End: "17:00", TimeZone: "America/New_York"}, now: "2026-10-16T12:00:00Z"},
+		{name: "overnight before midnight", schedule: kausalityv1beta1.ModeSchedule{Days: []kausalityv1beta1.Weekday{"Friday"}, Start: "22:00", End: "02:00"}, now: "2026-10-16T23:00:00Z", want: true},
+		{name: "overnight after midnight", schedule: kausalityv1beta1.ModeSchedule{Days: []kausalityv1beta1.Weekday{"Friday"}, Start: "22:00", End: "02:00"}, now: "2026-10-17T01:00:00Z", want: true},
+		{name: "overnight opened the day before", schedule: kausalityv1beta1.ModeSchedule{Days: []kausalityv1beta1.Weekday{"Friday"}, Start: "22:00", End: "02:00"}, now: "2026-10-16T01:00:00Z"},
+		{name: "overnight closed", schedule: kausalityv1beta1.ModeSchedule{Start: "22:00", End: "02:00"}, now: "2026-10-16T12:00:00Z"},
+		{name: "invalid time", schedule: kausalityv1beta1.ModeSchedule{Start: "9am", End: "17:00"}, now: "2026-10-16T12:00:00Z", wantErr: true},
+		{name: "empty window", schedule: kausalityv1beta1.ModeSchedule{Start: "09:00", End: "09:00"}, now: "2026-10-16T12:00:00Z", wantErr: true},
+		{name: "invalid time zone", schedule: kausalityv1beta1.ModeSchedule{Start: "09:00", End: "17:00", TimeZone: "Mars/Olympus"}, now: "2026-10-16T12:00:00Z", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ScheduleOpen(tt.schedule, at(tt.now))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -4,15 +4,20 @@ import (
 	"context"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
 
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+	"github.com/kausality-io/kausality/pkg/drift"
+	"github.com/kausality-io/kausality/pkg/fieldpath"
 )
 
 // Store caches Kausality policies and resolves modes for resources.
@@ -20,7 +25,12 @@ type Store struct {
 	client   client.Client
 	log      logr.Logger
 	mu       sync.RWMutex
-	policies []kausalityv1alpha1.Kausality
+	policies []kausalityv1beta1.Kausality
+	// namespacePolicies are the namespaced KausalityPolicies, sorted by
+	// namespace and name
+	namespacePolicies []kausalityv1alpha1.KausalityPolicy
+	// now returns the time schedules are evaluated at, time.Now if nil
+	now func() time.Time
 }
 
 // NewStore creates a new policy store.
@@ -31,18 +41,24 @@ func NewStore(c client.Client, log logr.Logger) *Store {
 	}
 }
 
-// Refresh reloads all Kausality policies from the API server.
+// Refresh reloads all Kausality policies and KausalityPolicies from the API
+// server. A missing KausalityPolicy CRD, e.g. after an upgrade without CRDs,
+// is treated as no KausalityPolicies.
 func (s *Store) Refresh(ctx context.Context) error {
-	var list kausalityv1alpha1.KausalityList
+	var list kausalityv1beta1.KausalityList
 	if err := s.client.List(ctx, &list); err != nil {
 		return err
 	}
+	var namespaceList kausalityv1alpha1.KausalityPolicyList
+	if err := s.client.List(ctx, &namespaceList); err != nil && !meta.IsNoMatchError(err) {
+		return err
+	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	// Filter out deleting policies and sort by name for determinism
-	s.policies = make([]kausalityv1alpha1.Kausality, 0, len(list.Items))
+	s.policies = make([]kausalityv1beta1.Kausality, 0, len(list.Items))
 	for _, p := range list.Items {
 		if p.DeletionTimestamp.IsZero() {
 			s.policies = append(s.policies, p)
@@ -51,8 +67,15 @@ func (s *Store) Refresh(ctx context.Context) error {
 	sort.Slice(s.policies, func(i, j int) bool {
 		return s.policies[i].Name < s.policies[j].Name
 	})
+	s.namespacePolicies = make([]kausalityv1alpha1.KausalityPolicy, 0, len(namespaceList.Items))
+	for _, p := range namespaceList.Items {
+		if p.DeletionTimestamp.IsZero() {
+			s.namespacePolicies = append(s.namespacePolicies, p)
+		}
+	}
+	sortNamespacePolicies(s.namespacePolicies)
 
-	s.log.V(1).Info("refreshed policies", "count", len(s.policies))
+	s.log.V(1).Info("refreshed policies", "count", len(s.policies), "namespaced", len(s.namespacePolicies))
 	return nil
 }
 
@@ -64,11 +87,19 @@ type ResourceContext struct {
 	// Namespace is the object's namespace (empty for cluster-scoped).
 	Namespace string
 
+	// Name is the object's name, used to select objects for rollouts.
+	Name string
+
 	// NamespaceLabels are the labels on the namespace.
 	NamespaceLabels map[string]string
 
 	// ObjectLabels are the labels on the object.
 	ObjectLabels map[string]string
+
+	// OldObjectLabels are the labels on the object before an update, nil
+	// without an old object. Object selectors of Kausality policies match
+	// either, unless the policy only matches the new object.
+	OldObjectLabels map[string]string
 }
 
 // ModeAnnotation is the annotation key for runtime mode override.
@@ -76,6 +107,8 @@ const ModeAnnotation = "kausality.io/mode"
 
 // ResolveMode returns the drift detection mode for a resource.
 // Precedence: object annotation > namespace annotation > CRD policy > default (log).
+// KausalityPolicies in the resource's namespace can tighten the mode of the
+// CRD policy to enforce.
 func (s *Store) ResolveMode(ctx ResourceContext, objectAnnotations, namespaceAnnotations map[string]string) kausalityv1alpha1.Mode {
 	// 1. Check object annotation
 	if mode := objectAnnotations[ModeAnnotation]; isValidMode(mode) {
@@ -91,7 +124,77 @@ func (s *Store) ResolveMode(ctx ResourceContext, objectAnnotations, namespaceAnn
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	var bestPolicy *kausalityv1alpha1.Kausality
+	bestPolicy := s.bestPolicy(ctx)
+	if bestPolicy == nil {
+		// No matching policy - default to log
+		return kausalityv1alpha1.ModeLog
+	}
+
+	// 4. Check overrides within the matching policy
+	mode := s.resolveOverrides(bestPolicy, ctx)
+
+	// 5. Namespaced policies can only tighten the mode
+	if mode != kausalityv1alpha1.ModeEnforce && s.tighteningPolicy(ctx) != nil {
+		return kausalityv1alpha1.ModeEnforce
+	}
+	return mode
+}
+
+// TighteningPolicy returns a copy of the KausalityPolicy that raises the mode
+// of the resource from the CRD policy's log to enforce, or nil if none does.
+// Annotations are not considered.
+func (s *Store) TighteningPolicy(ctx ResourceContext) *kausalityv1alpha1.KausalityPolicy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	bestPolicy := s.bestPolicy(ctx)
+	if bestPolicy == nil || s.resolveOverrides(bestPolicy, ctx) == kausalityv1alpha1.ModeEnforce {
+		return nil
+	}
+	if policy := s.tighteningPolicy(ctx); policy != nil {
+		return policy.DeepCopy()
+	}
+	return nil
+}
+
+// tighteningPolicy returns the first enforcing KausalityPolicy of the
+// resource's namespace matching the resource. The caller must hold the read lock.
+func (s *Store) tighteningPolicy(ctx ResourceContext) *kausalityv1alpha1.KausalityPolicy {
+	if ctx.Namespace == "" {
+		return nil
+	}
+	for i := range s.namespacePolicies {
+		policy := &s.namespacePolicies[i]
+		if policy.Namespace != ctx.Namespace || policy.Spec.Mode != kausalityv1alpha1.ModeEnforce {
+			continue
+		}
+		if len(policy.Spec.Resources) > 0 && !s.resourcesMatch(namespacePolicyRules(policy.Spec.Resources), ctx.GVR) {
+			continue
+		}
+		if !s.objectSelectorMatches(policy.Spec.ObjectSelector, ctx.ObjectLabels) {
+			continue
+		}
+		return policy
+	}
+	return nil
+}
+
+// MatchingPolicy returns a copy of the most specific policy matching the
+// resource, or nil if none matches.
+func (s *Store) MatchingPolicy(ctx ResourceContext) *kausalityv1beta1.Kausality {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if policy := s.bestPolicy(ctx); policy != nil {
+		return policy.DeepCopy()
+	}
+	return nil
+}
+
+// bestPolicy returns the matching policy with highest specificity.
+// The caller must hold the read lock.
+func (s *Store) bestPolicy(ctx ResourceContext) *kausalityv1beta1.Kausality {
+	var bestPolicy *kausalityv1beta1.Kausality
 	var bestSpecificity int
 
 	for i := range s.policies {
@@ -106,15 +209,127 @@ func (s *Store) ResolveMode(ctx ResourceContext, objectAnnotations, namespaceAnn
 			bestSpecificity = specificity
 		}
 	}
+	return bestPolicy
+}
+
+// DriftExclusion returns the name of the policy excluding the object from
+// drift evaluation, or "" if it is evaluated. Only the exclusions of the most
+// specific matching policy apply, like its mode.
+func (s *Store) DriftExclusion(ctx ResourceContext, objectAnnotations map[string]string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
+	bestPolicy := s.bestPolicy(ctx)
 	if bestPolicy == nil {
-		// No matching policy - default to log
-		return kausalityv1alpha1.ModeLog
+		return ""
 	}
+	for _, exclusion := range bestPolicy.Spec.DriftExclusions {
+		if s.exclusionMatches(exclusion, ctx.ObjectLabels, objectAnnotations) {
+			return bestPolicy.Name
+		}
+	}
+	return ""
+}
 
-	// 4. Check overrides within the matching policy
-	mode := s.resolveOverrides(bestPolicy, ctx)
-	return mode
+// FieldPaths returns the field paths drift detection of the resource is
+// limited to, nil for the whole spec. Like the mode, they come from the most
+// specific matching policy; if any of its rules matching the resource has
+// no field paths, the whole spec is tracked. Invalid paths are ignored.
+func (s *Store) FieldPaths(ctx ResourceContext) []fieldpath.Path {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	bestPolicy := s.bestPolicy(ctx)
+	if bestPolicy == nil {
+		return nil
+	}
+	var paths []fieldpath.Path
+	for _, rule := range bestPolicy.Spec.Resources {
+		if !s.ruleMatches(rule, ctx.GVR) {
+			continue
+		}
+		if len(rule.FieldPaths) == 0 {
+			return nil
+		}
+		for _, raw := range rule.FieldPaths {
+			p, err := fieldpath.Parse(raw)
+			if err != nil {
+				s.log.V(1).Info("ignoring invalid field path", "policy", bestPolicy.Name, "error", err.Error())
+				continue
+			}
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// Classifier returns the classifier identifying the controller of the
+// resource's parent, configured by the controller identity of the most
+// specific matching policy; nil for the default.
+func (s *Store) Classifier(ctx ResourceContext) drift.Classifier {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	bestPolicy := s.bestPolicy(ctx)
+	if bestPolicy == nil {
+		return nil
+	}
+	return classifierFor(bestPolicy.Spec.ControllerIdentity)
+}
+
+// MissingParent returns how mutations of the resource are handled if its
+// controller owner does not exist, configured by the most specific matching
+// policy; "" for the webhook's error handling.
+func (s *Store) MissingParent(ctx ResourceContext) kausalityv1beta1.MissingParentAction {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	bestPolicy := s.bestPolicy(ctx)
+	if bestPolicy == nil {
+		return ""
+	}
+	return bestPolicy.Spec.MissingParent
+}
+
+// OwnerReferences returns how controller owner references added to the
+// resource are validated, configured by the most specific matching policy;
+// "" if they are not.
+func (s *Store) OwnerReferences(ctx ResourceContext) kausalityv1beta1.OwnerReferenceAction {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	bestPolicy := s.bestPolicy(ctx)
+	if bestPolicy == nil {
+		return ""
+	}
+	return bestPolicy.Spec.OwnerReferences
+}
+
+// DriftAction returns how drift of the resource is handled in enforce mode,
+// configured by the most specific matching policy; "" to deny it.
+func (s *Store) DriftAction(ctx ResourceContext) kausalityv1beta1.DriftAction {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	bestPolicy := s.bestPolicy(ctx)
+	if bestPolicy == nil {
+		return ""
+	}
+	return bestPolicy.Spec.DriftAction
+}
+
+// exclusionMatches checks if the object matches all criteria of an exclusion.
+func (s *Store) exclusionMatches(exclusion kausalityv1beta1.DriftExclusion, objLabels, objAnnotations map[string]string) bool {
+	if exclusion.LabelSelector == nil && len(exclusion.Annotations) == 0 {
+		return false
+	}
+	for key, value := range exclusion.Annotations {
+		actual, ok := objAnnotations[key]
+		if !ok || (value != "" && actual != value) {
+			return false
+		}
+	}
+	return s.objectSelectorMatches(exclusion.LabelSelector, objLabels)
 }
 
 // IsTracked returns true if the resource is tracked by any Kausality policy.
@@ -130,8 +345,22 @@ func (s *Store) IsTracked(ctx ResourceContext) bool {
 	return false
 }
 
+// TracksResource returns true if any policy's resource rules match the GVR,
+// ignoring namespace and object selectors.
+func (s *Store) TracksResource(gvr schema.GroupVersionResource) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for i := range s.policies {
+		if s.resourcesMatch(s.policies[i].Spec.Resources, gvr) {
+			return true
+		}
+	}
+	return false
+}
+
 // policyMatches checks if a policy matches the resource context.
-func (s *Store) policyMatches(policy *kausalityv1alpha1.Kausality, ctx ResourceContext) bool {
+func (s *Store) policyMatches(policy *kausalityv1beta1.Kausality, ctx ResourceContext) bool {
 	// Check resources
 	if !s.resourcesMatch(policy.Spec.Resources, ctx.GVR) {
 		return false
@@ -142,16 +371,31 @@ func (s *Store) policyMatches(policy *kausalityv1alpha1.Kausality, ctx ResourceC
 		return false
 	}
 
-	// Check object selector
-	if !s.objectSelectorMatches(policy.Spec.ObjectSelector, ctx.ObjectLabels) {
+	// Check object selector against the labels before and after the mutation
+	oldLabels := ctx.OldObjectLabels
+	if policy.Spec.ObjectSelectorMatch == kausalityv1beta1.ObjectSelectorMatchNewObject {
+		oldLabels = nil
+	}
+	if !s.objectSelectorMatches(policy.Spec.ObjectSelector, ctx.ObjectLabels) &&
+		(oldLabels == nil || !s.objectSelectorMatches(policy.Spec.ObjectSelector, oldLabels)) {
 		return false
 	}
 
 	return true
 }
 
+// namespacePolicyRules returns the resource rules of a KausalityPolicy as
+// rules of a Kausality policy.
+func namespacePolicyRules(rules []kausalityv1alpha1.ResourceRule) []kausalityv1beta1.ResourceRule {
+	result := make([]kausalityv1beta1.ResourceRule, len(rules))
+	for i, rule := range rules {
+		result[i] = kausalityv1beta1.ResourceRule{APIGroups: rule.APIGroups, Resources: rule.Resources, Excluded: rule.Excluded}
+	}
+	return result
+}
+
 // resourcesMatch checks if any resource rule matches.
-func (s *Store) resourcesMatch(rules []kausalityv1alpha1.ResourceRule, gvr schema.GroupVersionResource) bool {
+func (s *Store) resourcesMatch(rules []kausalityv1beta1.ResourceRule, gvr schema.GroupVersionResource) bool {
 	for _, rule := range rules {
 		if s.ruleMatches(rule, gvr) {
 			return true
@@ -161,7 +405,7 @@ func (s *Store) resourcesMatch(rules []kausalityv1alpha1.ResourceRule, gvr schem
 }
 
 // ruleMatches checks if a single resource rule matches.
-func (s *Store) ruleMatches(rule kausalityv1alpha1.ResourceRule, gvr schema.GroupVersionResource) bool {
+func (s *Store) ruleMatches(rule kausalityv1beta1.ResourceRule, gvr schema.GroupVersionResource) bool {
 	// Check API group
 	groupMatches := false
 	for _, g := range rule.APIGroups {
@@ -197,7 +441,7 @@ func (s *Store) ruleMatches(rule kausalityv1alpha1.ResourceRule, gvr schema.Grou
 }
 
 // namespacesMatch checks if the namespace matches the selector.
-func (s *Store) namespacesMatch(selector *kausalityv1alpha1.NamespaceSelector, namespace string, nsLabels map[string]string) bool {
+func (s *Store) namespacesMatch(selector *kausalityv1beta1.NamespaceSelector, namespace string, nsLabels map[string]string) bool {
 	// No selector = all namespaces
 	if selector == nil {
 		return true
@@ -247,7 +491,7 @@ func (s *Store) objectSelectorMatches(selector *metav1.LabelSelector, objLabels
 
 // calculateSpecificity returns a score for policy specificity.
 // Higher score = more specific = wins in conflicts.
-func (s *Store) calculateSpecificity(policy *kausalityv1alpha1.Kausality, ctx ResourceContext) int {
+func (s *Store) calculateSpecificity(policy *kausalityv1beta1.Kausality, ctx ResourceContext) int {
 	score := 0
 
 	// Namespace specificity: explicit names > selector > all
@@ -282,20 +526,38 @@ func (s *Store) calculateSpecificity(policy *kausalityv1alpha1.Kausality, ctx Re
 	return score
 }
 
-// resolveOverrides finds the applicable mode from policy overrides.
-func (s *Store) resolveOverrides(policy *kausalityv1alpha1.Kausality, ctx ResourceContext) kausalityv1alpha1.Mode {
+// resolveOverrides finds the applicable mode from policy overrides, falling
+// back to the open schedule and the policy mode.
+func (s *Store) resolveOverrides(policy *kausalityv1beta1.Kausality, ctx ResourceContext) kausalityv1alpha1.Mode {
 	// Evaluate overrides in order; first match wins
 	for _, override := range policy.Spec.Overrides {
 		if s.overrideMatches(override, ctx) {
-			return override.Mode
+			return kausalityv1alpha1.Mode(override.Mode)
 		}
 	}
 
-	return policy.Spec.Mode
+	// Evaluate schedules in order; first open window wins
+	for _, schedule := range policy.Spec.Schedules {
+		open, err := ScheduleOpen(schedule, s.time())
+		if err != nil {
+			s.log.V(1).Info("ignoring invalid schedule", "policy", policy.Name, "error", err.Error())
+			continue
+		}
+		if open {
+			return kausalityv1alpha1.Mode(schedule.Mode)
+		}
+	}
+
+	return kausalityv1alpha1.Mode(policy.Spec.Mode)
 }
 
 // overrideMatches checks if an override applies to the context.
-func (s *Store) overrideMatches(override kausalityv1alpha1.ModeOverride, ctx ResourceContext) bool {
+func (s *Store) overrideMatches(override kausalityv1beta1.ModeOverride, ctx ResourceContext) bool {
+	// Check rollout (if specified)
+	if override.RolloutPercentage != nil && RolloutBucket(ctx.Namespace, ctx.Name) >= int(*override.RolloutPercentage) {
+		return false
+	}
+
 	// Check API groups (if specified)
 	if len(override.APIGroups) > 0 {
 		matches := false
@@ -341,6 +603,24 @@ func (s *Store) overrideMatches(override kausalityv1alpha1.ModeOverride, ctx Res
 	return true
 }
 
+// time returns the time schedules are evaluated at.
+func (s *Store) time() time.Time {
+	if s.now == nil {
+		return time.Now()
+	}
+	return s.now()
+}
+
+// sortNamespacePolicies sorts KausalityPolicies by namespace and name.
+func sortNamespacePolicies(policies []kausalityv1alpha1.KausalityPolicy) {
+	sort.Slice(policies, func(i, j int) bool {
+		if policies[i].Namespace != policies[j].Namespace {
+			return policies[i].Namespace < policies[j].Namespace
+		}
+		return policies[i].Name < policies[j].Name
+	})
+}
+
 // isValidMode checks if a mode string is valid.
 func isValidMode(mode string) bool {
 	return mode == string(kausalityv1alpha1.ModeLog) || mode == string(kausalityv1alpha1.ModeEnforce)
@@ -2,13 +2,19 @@ package policy
 
 import (
 	"testing"
+	"time"
 
+	"github.com/go-logr/logr"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
 
 	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+	"github.com/kausality-io/kausality/pkg/drift"
 )
 
 func TestRuleMatches(t *testing.T) {
@@ -16,13 +22,13 @@ func TestRuleMatches(t *testing.T) {
 
 	tests := []struct {
 		name string
-		rule kausalityv1alpha1.ResourceRule
+		rule kausalityv1beta1.ResourceRule
 		gvr  schema.GroupVersionResource
 		want bool
 	}{
 		{
 			name: "exact match",
-			rule: kausalityv1alpha1.ResourceRule{
+			rule: kausalityv1beta1.ResourceRule{
 				APIGroups: []string{"apps"},
 				Resources: []string{"deployments"},
 			},
@@ -31,7 +37,7 @@ func TestRuleMatches(t *testing.T) {
 		},
 		{
 			name: "wildcard resource",
-			rule: kausalityv1alpha1.ResourceRule{
+			rule: kausalityv1beta1.ResourceRule{
 				APIGroups: []string{"apps"},
 				Resources: []string{"*"},
 			},
@@ -40,7 +46,7 @@ func TestRuleMatches(t *testing.T) {
 		},
 		{
 			name: "wrong group",
-			rule: kausalityv1alpha1.ResourceRule{
+			rule: kausalityv1beta1.ResourceRule{
 				APIGroups: []string{"apps"},
 				Resources: []string{"deployments"},
 			},
@@ -49,7 +55,7 @@ func TestRuleMatches(t *testing.T) {
 		},
 		{
 			name: "wrong resource",
-			rule: kausalityv1alpha1.ResourceRule{
+			rule: kausalityv1beta1.ResourceRule{
 				APIGroups: []string{"apps"},
 				Resources: []string{"deployments"},
 			},
@@ -58,7 +64,7 @@ func TestRuleMatches(t *testing.T) {
 		},
 		{
 			name: "excluded resource",
-			rule: kausalityv1alpha1.ResourceRule{
+			rule: kausalityv1beta1.ResourceRule{
 				APIGroups: []string{"apps"},
 				Resources: []string{"*"},
 				Excluded:  []string{"replicasets"},
@@ -68,7 +74,7 @@ func TestRuleMatches(t *testing.T) {
 		},
 		{
 			name: "core group",
-			rule: kausalityv1alpha1.ResourceRule{
+			rule: kausalityv1beta1.ResourceRule{
 				APIGroups: []string{""},
 				Resources: []string{"configmaps"},
 			},
@@ -90,7 +96,7 @@ func TestNamespacesMatch(t *testing.T) {
 
 	tests := []struct {
 		name      string
-		selector  *kausalityv1alpha1.NamespaceSelector
+		selector  *kausalityv1beta1.NamespaceSelector
 		namespace string
 		nsLabels  map[string]string
 		want      bool
@@ -103,7 +109,7 @@ func TestNamespacesMatch(t *testing.T) {
 		},
 		{
 			name: "explicit name match",
-			selector: &kausalityv1alpha1.NamespaceSelector{
+			selector: &kausalityv1beta1.NamespaceSelector{
 				Names: []string{"production", "staging"},
 			},
 			namespace: "production",
@@ -111,7 +117,7 @@ func TestNamespacesMatch(t *testing.T) {
 		},
 		{
 			name: "explicit name no match",
-			selector: &kausalityv1alpha1.NamespaceSelector{
+			selector: &kausalityv1beta1.NamespaceSelector{
 				Names: []string{"production", "staging"},
 			},
 			namespace: "development",
@@ -119,7 +125,7 @@ func TestNamespacesMatch(t *testing.T) {
 		},
 		{
 			name: "excluded namespace",
-			selector: &kausalityv1alpha1.NamespaceSelector{
+			selector: &kausalityv1beta1.NamespaceSelector{
 				Excluded: []string{"kube-system"},
 			},
 			namespace: "kube-system",
@@ -127,7 +133,7 @@ func TestNamespacesMatch(t *testing.T) {
 		},
 		{
 			name: "excluded takes precedence over names",
-			selector: &kausalityv1alpha1.NamespaceSelector{
+			selector: &kausalityv1beta1.NamespaceSelector{
 				Names:    []string{"production", "kube-system"},
 				Excluded: []string{"kube-system"},
 			},
@@ -136,7 +142,7 @@ func TestNamespacesMatch(t *testing.T) {
 		},
 		{
 			name: "label selector match",
-			selector: &kausalityv1alpha1.NamespaceSelector{
+			selector: &kausalityv1beta1.NamespaceSelector{
 				Selector: &metav1.LabelSelector{
 					MatchLabels: map[string]string{"env": "production"},
 				},
@@ -147,7 +153,7 @@ func TestNamespacesMatch(t *testing.T) {
 		},
 		{
 			name: "label selector no match",
-			selector: &kausalityv1alpha1.NamespaceSelector{
+			selector: &kausalityv1beta1.NamespaceSelector{
 				Selector: &metav1.LabelSelector{
 					MatchLabels: map[string]string{"env": "production"},
 				},
@@ -262,7 +268,8 @@ func TestCalculateSpecificity(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := s.calculateSpecificity(&tt.policy, ctx)
+			policy := hubPolicies(t, []kausalityv1alpha1.Kausality{tt.policy})[0]
+			got := s.calculateSpecificity(&policy, ctx)
 			assert.Equal(t, tt.want, got)
 		})
 	}
@@ -273,13 +280,13 @@ func TestOverrideMatches(t *testing.T) {
 
 	tests := []struct {
 		name     string
-		override kausalityv1alpha1.ModeOverride
+		override kausalityv1beta1.ModeOverride
 		ctx      ResourceContext
 		want     bool
 	}{
 		{
 			name:     "empty override matches all",
-			override: kausalityv1alpha1.ModeOverride{Mode: kausalityv1alpha1.ModeEnforce},
+			override: kausalityv1beta1.ModeOverride{Mode: kausalityv1beta1.ModeEnforce},
 			ctx: ResourceContext{
 				GVR:       schema.GroupVersionResource{Group: "apps", Resource: "deployments"},
 				Namespace: "production",
@@ -288,9 +295,9 @@ func TestOverrideMatches(t *testing.T) {
 		},
 		{
 			name: "namespace match",
-			override: kausalityv1alpha1.ModeOverride{
+			override: kausalityv1beta1.ModeOverride{
 				Namespaces: []string{"production"},
-				Mode:       kausalityv1alpha1.ModeEnforce,
+				Mode:       kausalityv1beta1.ModeEnforce,
 			},
 			ctx: ResourceContext{
 				GVR:       schema.GroupVersionResource{Group: "apps", Resource: "deployments"},
@@ -300,9 +307,9 @@ func TestOverrideMatches(t *testing.T) {
 		},
 		{
 			name: "namespace no match",
-			override: kausalityv1alpha1.ModeOverride{
+			override: kausalityv1beta1.ModeOverride{
 				Namespaces: []string{"production"},
-				Mode:       kausalityv1alpha1.ModeEnforce,
+				Mode:       kausalityv1beta1.ModeEnforce,
 			},
 			ctx: ResourceContext{
 				GVR:       schema.GroupVersionResource{Group: "apps", Resource: "deployments"},
@@ -312,10 +319,10 @@ func TestOverrideMatches(t *testing.T) {
 		},
 		{
 			name: "resource match",
-			override: kausalityv1alpha1.ModeOverride{
+			override: kausalityv1beta1.ModeOverride{
 				APIGroups: []string{"apps"},
 				Resources: []string{"statefulsets"},
-				Mode:      kausalityv1alpha1.ModeEnforce,
+				Mode:      kausalityv1beta1.ModeEnforce,
 			},
 			ctx: ResourceContext{
 				GVR:       schema.GroupVersionResource{Group: "apps", Resource: "statefulsets"},
@@ -325,10 +332,10 @@ func TestOverrideMatches(t *testing.T) {
 		},
 		{
 			name: "resource no match",
-			override: kausalityv1alpha1.ModeOverride{
+			override: kausalityv1beta1.ModeOverride{
 				APIGroups: []string{"apps"},
 				Resources: []string{"statefulsets"},
-				Mode:      kausalityv1alpha1.ModeEnforce,
+				Mode:      kausalityv1beta1.ModeEnforce,
 			},
 			ctx: ResourceContext{
 				GVR:       schema.GroupVersionResource{Group: "apps", Resource: "deployments"},
@@ -338,11 +345,11 @@ func TestOverrideMatches(t *testing.T) {
 		},
 		{
 			name: "combined namespace + resource match",
-			override: kausalityv1alpha1.ModeOverride{
+			override: kausalityv1beta1.ModeOverride{
 				APIGroups:  []string{"apps"},
 				Resources:  []string{"deployments"},
 				Namespaces: []string{"production"},
-				Mode:       kausalityv1alpha1.ModeEnforce,
+				Mode:       kausalityv1beta1.ModeEnforce,
 			},
 			ctx: ResourceContext{
 				GVR:       schema.GroupVersionResource{Group: "apps", Resource: "deployments"},
@@ -350,6 +357,32 @@ func TestOverrideMatches(t *testing.T) {
 			},
 			want: true,
 		},
+		{
+			name: "rollout includes bucket below percentage",
+			override: kausalityv1beta1.ModeOverride{
+				RolloutPercentage: ptr.To[int32](int32(RolloutBucket("production", "web")) + 1),
+				Mode:              kausalityv1beta1.ModeEnforce,
+			},
+			ctx: ResourceContext{
+				GVR:       schema.GroupVersionResource{Group: "apps", Resource: "deployments"},
+				Namespace: "production",
+				Name:      "web",
+			},
+			want: true,
+		},
+		{
+			name: "rollout excludes bucket at percentage",
+			override: kausalityv1beta1.ModeOverride{
+				RolloutPercentage: ptr.To[int32](int32(RolloutBucket("production", "web"))),
+				Mode:              kausalityv1beta1.ModeEnforce,
+			},
+			ctx: ResourceContext{
+				GVR:       schema.GroupVersionResource{Group: "apps", Resource: "deployments"},
+				Namespace: "production",
+				Name:      "web",
+			},
+			want: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -383,3 +416,310 @@ func TestResolveMode_AnnotationPrecedence(t *testing.T) {
 	mode = s.ResolveMode(ctx, nil, nil)
 	assert.Equal(t, kausalityv1alpha1.ModeLog, mode)
 }
+
+func TestTracksResource(t *testing.T) {
+	s := NewStore(nil, logr.Discard())
+	s.Update(hubPolicies(t, []kausalityv1alpha1.Kausality{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "apps"},
+			Spec: kausalityv1alpha1.KausalitySpec{
+				Resources: []kausalityv1alpha1.ResourceRule{{
+					APIGroups: []string{"apps"},
+					Resources: []string{"*"},
+					Excluded:  []string{"daemonsets"},
+				}},
+				Namespaces: &kausalityv1alpha1.NamespaceSelector{Names: []string{"production"}},
+			},
+		},
+	}))
+
+	assert.True(t, s.TracksResource(schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}),
+		"namespace selector is ignored")
+	assert.False(t, s.TracksResource(schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "daemonsets"}))
+	assert.False(t, s.TracksResource(schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}))
+}
+
+func TestResolveMode_NamespacePolicy(t *testing.T) {
+	appsRule := []kausalityv1alpha1.ResourceRule{{APIGroups: []string{"apps"}, Resources: []string{"deployments"}}}
+	s := NewStore(nil, logr.Discard())
+	s.Update(hubPolicies(t, []kausalityv1alpha1.Kausality{{
+		ObjectMeta: metav1.ObjectMeta{Name: "apps"},
+		Spec: kausalityv1alpha1.KausalitySpec{
+			Resources: []kausalityv1alpha1.ResourceRule{{APIGroups: []string{"apps"}, Resources: []string{"*"}}},
+			Mode:      kausalityv1alpha1.ModeLog,
+			Overrides: []kausalityv1alpha1.ModeOverride{{Namespaces: []string{"strict"}, Mode: kausalityv1alpha1.ModeEnforce}},
+		},
+	}}))
+	s.UpdateNamespacePolicies([]kausalityv1alpha1.KausalityPolicy{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "payments", Name: "deployments"},
+			Spec: kausalityv1alpha1.KausalityPolicySpec{
+				Resources:      appsRule,
+				ObjectSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "critical"}},
+				Mode:           kausalityv1alpha1.ModeEnforce,
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "strict", Name: "loosen"},
+			Spec:       kausalityv1alpha1.KausalityPolicySpec{Mode: kausalityv1alpha1.ModeLog},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "payments", Name: "configmaps"},
+			Spec: kausalityv1alpha1.KausalityPolicySpec{
+				Resources: []kausalityv1alpha1.ResourceRule{{APIGroups: []string{""}, Resources: []string{"configmaps"}}},
+				Mode:      kausalityv1alpha1.ModeEnforce,
+			},
+		},
+	})
+	critical := map[string]string{"tier": "critical"}
+
+	tests := []struct {
+		name       string
+		ctx        ResourceContext
+		want       kausalityv1alpha1.Mode
+		wantPolicy string
+	}{
+		{
+			name:       "tightened",
+			ctx:        ResourceContext{GVR: schema.GroupVersionResource{Group: "apps", Resource: "deployments"}, Namespace: "payments", ObjectLabels: critical},
+			want:       kausalityv1alpha1.ModeEnforce,
+			wantPolicy: "deployments",
+		},
+		{
+			name: "object selector does not match",
+			ctx:  ResourceContext{GVR: schema.GroupVersionResource{Group: "apps", Resource: "deployments"}, Namespace: "payments"},
+			want: kausalityv1alpha1.ModeLog,
+		},
+		{
+			name: "other namespace",
+			ctx:  ResourceContext{GVR: schema.GroupVersionResource{Group: "apps", Resource: "deployments"}, Namespace: "other", ObjectLabels: critical},
+			want: kausalityv1alpha1.ModeLog,
+		},
+		{
+			name: "not tracked by a cluster policy",
+			ctx:  ResourceContext{GVR: schema.GroupVersionResource{Resource: "configmaps"}, Namespace: "payments"},
+			want: kausalityv1alpha1.ModeLog,
+		},
+		{
+			name: "cannot loosen",
+			ctx:  ResourceContext{GVR: schema.GroupVersionResource{Group: "apps", Resource: "deployments"}, Namespace: "strict"},
+			want: kausalityv1alpha1.ModeEnforce,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, s.ResolveMode(tt.ctx, nil, nil))
+			policy := s.TighteningPolicy(tt.ctx)
+			if tt.wantPolicy == "" {
+				assert.Nil(t, policy)
+				return
+			}
+			if assert.NotNil(t, policy) {
+				assert.Equal(t, tt.wantPolicy, policy.Name)
+			}
+		})
+	}
+
+	// Annotations keep precedence
+	ctx := tests[0].ctx
+	assert.Equal(t, kausalityv1alpha1.ModeLog, s.ResolveMode(ctx, map[string]string{ModeAnnotation: "log"}, nil))
+}
+
+func TestDriftExclusion(t *testing.T) {
+	s := NewStore(nil, logr.Discard())
+	s.Update(hubPolicies(t, []kausalityv1alpha1.Kausality{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "apps"},
+			Spec: kausalityv1alpha1.KausalitySpec{
+				Resources: []kausalityv1alpha1.ResourceRule{{APIGroups: []string{"apps"}, Resources: []string{"*"}}},
+				Mode:      kausalityv1alpha1.ModeLog,
+				DriftExclusions: []kausalityv1alpha1.DriftExclusion{
+					{LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"canary": "true"}}},
+					{Annotations: map[string]string{"example.com/dual-managed": ""}},
+					{
+						LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+						Annotations:   map[string]string{"example.com/owner": "hpa"},
+					},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "prod-apps"},
+			Spec: kausalityv1alpha1.KausalitySpec{
+				Resources:  []kausalityv1alpha1.ResourceRule{{APIGroups: []string{"apps"}, Resources: []string{"deployments"}}},
+				Namespaces: &kausalityv1alpha1.NamespaceSelector{Names: []string{"prod"}},
+				Mode:       kausalityv1alpha1.ModeEnforce,
+			},
+		},
+	}))
+	deployments := schema.GroupVersionResource{Group: "apps", Resource: "deployments"}
+
+	tests := []struct {
+		name        string
+		namespace   string
+		labels      map[string]string
+		annotations map[string]string
+		want        string
+	}{
+		{name: "no match"},
+		{name: "label selector", labels: map[string]string{"canary": "true"}, want: "apps"},
+		{name: "annotation with any value", annotations: map[string]string{"example.com/dual-managed": "yes"}, want: "apps"},
+		{name: "all criteria", labels: map[string]string{"team": "a"}, annotations: map[string]string{"example.com/owner": "hpa"}, want: "apps"},
+		{name: "annotation value differs", labels: map[string]string{"team": "a"}, annotations: map[string]string{"example.com/owner": "keda"}},
+		{name: "more specific policy without exclusions", namespace: "prod", labels: map[string]string{"canary": "true"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := ResourceContext{GVR: deployments, Namespace: tt.namespace, ObjectLabels: tt.labels}
+			assert.Equal(t, tt.want, s.DriftExclusion(ctx, tt.annotations))
+		})
+	}
+}
+
+func TestResolveMode_Schedules(t *testing.T) {
+	s := NewStore(nil, logr.Discard())
+	s.Update([]kausalityv1beta1.Kausality{{
+		ObjectMeta: metav1.ObjectMeta{Name: "apps"},
+		Spec: kausalityv1beta1.KausalitySpec{
+			Resources: []kausalityv1beta1.ResourceRule{{APIGroups: []string{"apps"}, Resources: []string{"*"}}},
+			Mode:      kausalityv1beta1.ModeLog,
+			Schedules: []kausalityv1beta1.ModeSchedule{
+				{Start: "bogus", End: "17:00", Mode: kausalityv1beta1.ModeLog},
+				{Start: "09:00", End: "17:00", Mode: kausalityv1beta1.ModeEnforce},
+			},
+			Overrides: []kausalityv1beta1.ModeOverride{
+				{Namespaces: []string{"dev"}, Mode: kausalityv1beta1.ModeLog},
+			},
+		},
+	}})
+	ctx := ResourceContext{GVR: schema.GroupVersionResource{Group: "apps", Resource: "deployments"}, Namespace: "prod"}
+
+	s.now = func() time.Time { return time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC) }
+	assert.Equal(t, kausalityv1alpha1.ModeEnforce, s.ResolveMode(ctx, nil, nil), "invalid schedule skipped, open window wins")
+	dev := ctx
+	dev.Namespace = "dev"
+	assert.Equal(t, kausalityv1alpha1.ModeLog, s.ResolveMode(dev, nil, nil), "overrides take precedence")
+
+	s.now = func() time.Time { return time.Date(2026, 10, 16, 20, 0, 0, 0, time.UTC) }
+	assert.Equal(t, kausalityv1alpha1.ModeLog, s.ResolveMode(ctx, nil, nil), "spec.mode outside windows")
+}
+
+func TestResolveMode_OldObjectLabels(t *testing.T) {
+	s := NewStore(nil, logr.Discard())
+	s.Update([]kausalityv1beta1.Kausality{{
+		ObjectMeta: metav1.ObjectMeta{Name: "critical"},
+		Spec: kausalityv1beta1.KausalitySpec{
+			Resources:      []kausalityv1beta1.ResourceRule{{APIGroups: []string{"apps"}, Resources: []string{"deployments"}}},
+			ObjectSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "critical"}},
+			Mode:           kausalityv1beta1.ModeEnforce,
+		},
+	}})
+	ctx := ResourceContext{GVR: schema.GroupVersionResource{Group: "apps", Resource: "deployments"}, Namespace: "prod"}
+
+	assert.Equal(t, kausalityv1alpha1.ModeLog, s.ResolveMode(ctx, nil, nil))
+	// Removing the label does not escape the policy
+	ctx.OldObjectLabels = map[string]string{"tier": "critical"}
+	assert.Equal(t, kausalityv1alpha1.ModeEnforce, s.ResolveMode(ctx, nil, nil))
+	ctx.ObjectLabels, ctx.OldObjectLabels = map[string]string{"tier": "critical"}, map[string]string{}
+	assert.Equal(t, kausalityv1alpha1.ModeEnforce, s.ResolveMode(ctx, nil, nil))
+
+	// v1alpha1 object selectors only match the new object
+	s.Update(hubPolicies(t, []kausalityv1alpha1.Kausality{{
+		ObjectMeta: metav1.ObjectMeta{Name: "critical"},
+		Spec: kausalityv1alpha1.KausalitySpec{
+			Resources:      []kausalityv1alpha1.ResourceRule{{APIGroups: []string{"apps"}, Resources: []string{"deployments"}}},
+			ObjectSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "critical"}},
+			Mode:           kausalityv1alpha1.ModeEnforce,
+		},
+	}}))
+	assert.Equal(t, kausalityv1alpha1.ModeEnforce, s.ResolveMode(ctx, nil, nil))
+	ctx.ObjectLabels, ctx.OldObjectLabels = map[string]string{}, map[string]string{"tier": "critical"}
+	assert.Equal(t, kausalityv1alpha1.ModeLog, s.ResolveMode(ctx, nil, nil))
+}
+
+// hubPolicies converts v1alpha1 policies to the hub version held by the store.
+func hubPolicies(t *testing.T, policies []kausalityv1alpha1.Kausality) []kausalityv1beta1.Kausality {
+	t.Helper()
+	hubs := make([]kausalityv1beta1.Kausality, len(policies))
+	for i := range policies {
+		require.NoError(t, policies[i].ConvertTo(&hubs[i]))
+	}
+	return hubs
+}
+
+func TestFieldPaths(t *testing.T) {
+	s := NewStore(nil, logr.Discard())
+	s.Update([]kausalityv1beta1.Kausality{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "apps"},
+			Spec: kausalityv1beta1.KausalitySpec{
+				Resources: []kausalityv1beta1.ResourceRule{
+					{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, FieldPaths: []string{"spec.template.spec.containers[*].image", "status.replicas"}},
+					{APIGroups: []string{"apps"}, Resources: []string{"*"}, Excluded: []string{"deployments", "statefulsets"}, FieldPaths: []string{"spec.replicas"}},
+					{APIGroups: []string{"apps"}, Resources: []string{"statefulsets"}},
+				},
+				Mode: kausalityv1beta1.ModeLog,
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+			Spec: kausalityv1beta1.KausalitySpec{
+				Resources:  []kausalityv1beta1.ResourceRule{{APIGroups: []string{"apps"}, Resources: []string{"deployments"}}},
+				Namespaces: &kausalityv1beta1.NamespaceSelector{Names: []string{"prod"}},
+				Mode:       kausalityv1beta1.ModeEnforce,
+			},
+		},
+	})
+	paths := func(resource, namespace string) []string {
+		var result []string
+		for _, p := range s.FieldPaths(ResourceContext{GVR: schema.GroupVersionResource{Group: "apps", Resource: resource}, Namespace: namespace}) {
+			result = append(result, p.String())
+		}
+		return result
+	}
+
+	assert.Equal(t, []string{"spec.template.spec.containers[*].image"}, paths("deployments", "dev"), "invalid paths are ignored")
+	assert.Equal(t, []string{"spec.replicas"}, paths("replicasets", "dev"))
+	assert.Nil(t, paths("statefulsets", "dev"), "rule without field paths")
+	assert.Nil(t, paths("deployments", "prod"), "more specific policy without field paths")
+	assert.Nil(t, s.FieldPaths(ResourceContext{GVR: schema.GroupVersionResource{Resource: "configmaps"}, Namespace: "dev"}), "no matching policy")
+}
+
+func TestClassifier(t *testing.T) {
+	s := NewStore(nil, logr.Discard())
+	s.Update([]kausalityv1beta1.Kausality{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "apps"},
+			Spec: kausalityv1beta1.KausalitySpec{
+				Resources: []kausalityv1beta1.ResourceRule{{APIGroups: []string{"apps"}, Resources: []string{"deployments"}}},
+				Mode:      kausalityv1beta1.ModeLog,
+				ControllerIdentity: &kausalityv1beta1.ControllerIdentity{
+					Classifiers:     []kausalityv1beta1.ClassifierType{kausalityv1beta1.ClassifierServiceAccount, kausalityv1beta1.ClassifierUpdaterHash},
+					Combination:     kausalityv1beta1.ClassifierCombinationAll,
+					ServiceAccounts: []string{"system:serviceaccount:kube-system:*"},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+			Spec: kausalityv1beta1.KausalitySpec{
+				Resources:  []kausalityv1beta1.ResourceRule{{APIGroups: []string{"apps"}, Resources: []string{"deployments"}}},
+				Namespaces: &kausalityv1beta1.NamespaceSelector{Names: []string{"prod"}},
+				Mode:       kausalityv1beta1.ModeEnforce,
+				ControllerIdentity: &kausalityv1beta1.ControllerIdentity{
+					Classifiers: []kausalityv1beta1.ClassifierType{kausalityv1beta1.ClassifierFieldManager},
+				},
+			},
+		},
+	})
+	deployments := func(namespace string) ResourceContext {
+		return ResourceContext{GVR: schema.GroupVersionResource{Group: "apps", Resource: "deployments"}, Namespace: namespace}
+	}
+
+	assert.Equal(t, drift.Combine(drift.CombineAll,
+		drift.ServiceAccountClassifier{Patterns: []string{"system:serviceaccount:kube-system:*"}},
+		drift.HashClassifier{},
+	), s.Classifier(deployments("dev")))
+	assert.Equal(t, drift.FieldManagerClassifier{}, s.Classifier(deployments("prod")), "most specific policy")
+	assert.Nil(t, s.Classifier(ResourceContext{GVR: schema.GroupVersionResource{Resource: "configmaps"}, Namespace: "dev"}), "no matching policy")
+}
@@ -0,0 +1,99 @@
+package policy
+
+import (
+	"sort"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+)
+
+// DefaultSubresources are intercepted for every tracked resource unless a
+// ResourceRule overrides them.
+var DefaultSubresources = []kausalityv1beta1.SubresourceRule{
+	{Name: "status", Handling: kausalityv1beta1.SubresourceHandlingController},
+}
+
+// connectSubresources are reached via CONNECT rather than CREATE or UPDATE.
+var connectSubresources = map[string]bool{
+	"exec":        true,
+	"attach":      true,
+	"portforward": true,
+	"proxy":       true,
+}
+
+// IsConnectSubresource returns true if the subresource is reached via CONNECT.
+func IsConnectSubresource(subresource string) bool {
+	return connectSubresources[subresource]
+}
+
+// DefaultSubresourceHandling is the handling of a subresource that no policy
+// configures: status identifies controllers, others are tracked. Requests only
+// reach the webhook for subresources it is registered for.
+func DefaultSubresourceHandling(subresource string) kausalityv1alpha1.SubresourceHandling {
+	for _, sub := range DefaultSubresources {
+		if sub.Name == subresource {
+			return kausalityv1alpha1.SubresourceHandling(sub.Handling)
+		}
+	}
+	return kausalityv1alpha1.SubresourceHandlingTrack
+}
+
+// EffectiveSubresources returns the subresources of a rule merged with the
+// defaults, sorted by name. Ignored subresources are included.
+func EffectiveSubresources(rule kausalityv1beta1.ResourceRule) []kausalityv1beta1.SubresourceRule {
+	byName := make(map[string]kausalityv1beta1.SubresourceHandling)
+	for _, sub := range DefaultSubresources {
+		byName[sub.Name] = sub.Handling
+	}
+	for _, sub := range rule.Subresources {
+		byName[sub.Name] = sub.Handling
+	}
+
+	result := make([]kausalityv1beta1.SubresourceRule, 0, len(byName))
+	for name, handling := range byName {
+		result = append(result, kausalityv1beta1.SubresourceRule{Name: name, Handling: handling})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// handlingRank orders handlings when policies disagree: the webhook rules are
+// the union of all policies, so the handling that intercepts most wins.
+var handlingRank = map[kausalityv1beta1.SubresourceHandling]int{
+	kausalityv1beta1.SubresourceHandlingIgnore:     0,
+	kausalityv1beta1.SubresourceHandlingController: 1,
+	kausalityv1beta1.SubresourceHandlingTrack:      2,
+}
+
+// SubresourceHandling returns how requests to a subresource of the resource
+// are handled. If several policies configure it, track wins over controller
+// over ignore. Without any policy for the resource, DefaultSubresourceHandling applies.
+func (s *Store) SubresourceHandling(ctx ResourceContext, subresource string) kausalityv1alpha1.SubresourceHandling {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result kausalityv1beta1.SubresourceHandling
+	for i := range s.policies {
+		for _, rule := range s.policies[i].Spec.Resources {
+			if !s.ruleMatches(rule, ctx.GVR) {
+				continue
+			}
+			handling := kausalityv1beta1.SubresourceHandlingIgnore
+			for _, sub := range EffectiveSubresources(rule) {
+				if sub.Name == subresource {
+					handling = sub.Handling
+				}
+			}
+			if result == "" || handlingRank[handling] > handlingRank[result] {
+				result = handling
+			}
+		}
+	}
+
+	if result == "" {
+		return DefaultSubresourceHandling(subresource)
+	}
+	return kausalityv1alpha1.SubresourceHandling(result)
+}
@@ -0,0 +1,70 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+)
+
+func TestEffectiveSubresources(t *testing.T) {
+	assert.Equal(t, []kausalityv1beta1.SubresourceRule{
+		{Name: "status", Handling: kausalityv1beta1.SubresourceHandlingController},
+	}, EffectiveSubresources(kausalityv1beta1.ResourceRule{}))
+
+	assert.Equal(t, []kausalityv1beta1.SubresourceRule{
+		{Name: "scale", Handling: kausalityv1beta1.SubresourceHandlingTrack},
+		{Name: "status", Handling: kausalityv1beta1.SubresourceHandlingIgnore},
+	}, EffectiveSubresources(kausalityv1beta1.ResourceRule{
+		Subresources: []kausalityv1beta1.SubresourceRule{
+			{Name: "status", Handling: kausalityv1beta1.SubresourceHandlingIgnore},
+			{Name: "scale", Handling: kausalityv1beta1.SubresourceHandlingTrack},
+		},
+	}))
+}
+
+func TestStore_SubresourceHandling(t *testing.T) {
+	deployments := ResourceContext{GVR: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}}
+	pods := ResourceContext{GVR: schema.GroupVersionResource{Version: "v1", Resource: "pods"}}
+
+	policyWith := func(name, group string, subresources ...kausalityv1beta1.SubresourceRule) kausalityv1beta1.Kausality {
+		return kausalityv1beta1.Kausality{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: kausalityv1beta1.KausalitySpec{
+				Resources: []kausalityv1beta1.ResourceRule{{
+					APIGroups:    []string{group},
+					Resources:    []string{"*"},
+					Subresources: subresources,
+				}},
+				Mode: kausalityv1beta1.ModeLog,
+			},
+		}
+	}
+
+	s := NewStore(nil, logr.Discard())
+	s.Update([]kausalityv1beta1.Kausality{
+		policyWith("apps-scale", "apps", kausalityv1beta1.SubresourceRule{Name: "scale", Handling: kausalityv1beta1.SubresourceHandlingTrack}),
+		policyWith("apps-quiet", "apps",
+			kausalityv1beta1.SubresourceRule{Name: "status", Handling: kausalityv1beta1.SubresourceHandlingIgnore},
+			kausalityv1beta1.SubresourceRule{Name: "scale", Handling: kausalityv1beta1.SubresourceHandlingIgnore},
+		),
+		policyWith("pods", "", kausalityv1beta1.SubresourceRule{Name: "status", Handling: kausalityv1beta1.SubresourceHandlingIgnore}),
+	})
+
+	// Policies disagreeing: the handling intercepting most wins
+	assert.Equal(t, kausalityv1alpha1.SubresourceHandlingTrack, s.SubresourceHandling(deployments, "scale"))
+	assert.Equal(t, kausalityv1alpha1.SubresourceHandlingController, s.SubresourceHandling(deployments, "status"))
+	// Not configured by the matching policies
+	assert.Equal(t, kausalityv1alpha1.SubresourceHandlingIgnore, s.SubresourceHandling(deployments, "rollback"))
+	assert.Equal(t, kausalityv1alpha1.SubresourceHandlingIgnore, s.SubresourceHandling(pods, "status"))
+	// No policy for the resource
+	unmatched := ResourceContext{GVR: schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}}
+	assert.Equal(t, kausalityv1alpha1.SubresourceHandlingController, s.SubresourceHandling(unmatched, "status"))
+	assert.Equal(t, kausalityv1alpha1.SubresourceHandlingTrack, s.SubresourceHandling(unmatched, "scale"))
+}
@@ -2,18 +2,23 @@ package policy
 
 import (
 	"context"
+	"time"
 
 	"github.com/go-logr/logr"
 
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
 )
 
-// Watcher watches Kausality CRDs and keeps the Store updated in realtime.
+// Watcher watches Kausality and KausalityPolicy CRDs and keeps the Store updated in realtime.
 type Watcher struct {
 	client client.Client
 	store  *Store
@@ -29,7 +34,7 @@ func NewWatcher(c client.Client, store *Store, log logr.Logger) *Watcher {
 	}
 }
 
-// Reconcile is called when any Kausality resource changes.
+// Reconcile is called when any Kausality or KausalityPolicy resource changes.
 // It refreshes the entire policy store to keep it in sync.
 func (w *Watcher) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
 	w.log.V(1).Info("policy changed, refreshing store", "name", req.Name)
@@ -46,7 +51,8 @@ func (w *Watcher) Reconcile(ctx context.Context, req reconcile.Request) (reconci
 func (w *Watcher) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		Named("policy-watcher").
-		For(&kausalityv1alpha1.Kausality{}).
+		For(&kausalityv1beta1.Kausality{}).
+		Watches(&kausalityv1alpha1.KausalityPolicy{}, &handler.EnqueueRequestForObject{}).
 		Complete(w)
 }
 
@@ -67,18 +73,38 @@ func SetupWatcher(mgr ctrl.Manager, store *Store, log logr.Logger) error {
 	}
 	return ctrl.NewControllerManagedBy(mgr).
 		Named("policy-watcher").
-		For(&kausalityv1alpha1.Kausality{}).
+		For(&kausalityv1beta1.Kausality{}).
+		Watches(&kausalityv1alpha1.KausalityPolicy{}, &handler.EnqueueRequestForObject{}).
+		// Every webhook replica needs an up-to-date store, leader or not
+		WithOptions(controller.Options{NeedLeaderElection: ptr.To(false)}).
 		Complete(w)
 }
 
 // InMemoryStore provides a way to manually trigger updates for testing.
-func (s *Store) Update(policies []kausalityv1alpha1.Kausality) {
+func (s *Store) Update(policies []kausalityv1beta1.Kausality) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.policies = policies
 	s.log.V(1).Info("policies updated", "count", len(policies))
 }
 
+// UpdateNamespacePolicies replaces the KausalityPolicies, e.g. for testing.
+func (s *Store) UpdateNamespacePolicies(policies []kausalityv1alpha1.KausalityPolicy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.namespacePolicies = append([]kausalityv1alpha1.KausalityPolicy(nil), policies...)
+	sortNamespacePolicies(s.namespacePolicies)
+	s.log.V(1).Info("namespaced policies updated", "count", len(policies))
+}
+
+// SetClock sets the time mode schedules are evaluated at, e.g. for testing
+// policies at a given time. Nil evaluates them at the current time.
+func (s *Store) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
 // OnChange can be called by the watcher when policies change.
 // It's a convenience method that fetches and updates in one call.
 func (s *Store) OnChange(ctx context.Context, _ types.NamespacedName) error {
@@ -0,0 +1,84 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Versions of admissionregistration.k8s.io serving the MutatingWebhookConfiguration.
+const (
+	WebhookAPIVersionV1      = "v1"
+	WebhookAPIVersionV1beta1 = "v1beta1"
+)
+
+// DetectWebhookAPIVersion returns WebhookAPIVersionV1 if the cluster serves
+// admissionregistration.k8s.io/v1, and WebhookAPIVersionV1beta1 otherwise.
+func DetectWebhookAPIVersion(dc discovery.DiscoveryInterface) (string, error) {
+	if _, err := dc.ServerResourcesForGroupVersion(admissionregistrationv1.SchemeGroupVersion.String()); err != nil {
+		if apierrors.IsNotFound(err) {
+			return WebhookAPIVersionV1beta1, nil
+		}
+		return "", fmt.Errorf("failed to discover %s: %w", admissionregistrationv1.SchemeGroupVersion, err)
+	}
+	return WebhookAPIVersionV1, nil
+}
+
+// getWebhookConfiguration reads the managed MutatingWebhookConfiguration.
+// With WebhookAPIVersionV1beta1, it is read as v1beta1 and converted: both
+// versions have the same schema.
+func (c *Controller) getWebhookConfiguration(ctx context.Context) (*admissionregistrationv1.MutatingWebhookConfiguration, error) {
+	key := client.ObjectKey{Name: c.WebhookName}
+	webhook := &admissionregistrationv1.MutatingWebhookConfiguration{}
+	if c.WebhookAPIVersion != WebhookAPIVersionV1beta1 {
+		if err := c.Get(ctx, key, webhook); err != nil {
+			return nil, fmt.Errorf("failed to get webhook configuration %q: %w", c.WebhookName, err)
+		}
+		return webhook, nil
+	}
+
+	var legacy admissionregistrationv1beta1.MutatingWebhookConfiguration
+	if err := c.Get(ctx, key, &legacy); err != nil {
+		return nil, fmt.Errorf("failed to get webhook configuration %q: %w", c.WebhookName, err)
+	}
+	if err := convertWebhookConfiguration(&legacy, webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// updateWebhookConfiguration writes a MutatingWebhookConfiguration read by
+// getWebhookConfiguration in the version it was read.
+func (c *Controller) updateWebhookConfiguration(ctx context.Context, webhook *admissionregistrationv1.MutatingWebhookConfiguration) error {
+	if c.WebhookAPIVersion != WebhookAPIVersionV1beta1 {
+		return c.Update(ctx, webhook)
+	}
+
+	var legacy admissionregistrationv1beta1.MutatingWebhookConfiguration
+	if err := convertWebhookConfiguration(webhook, &legacy); err != nil {
+		return err
+	}
+	return c.Update(ctx, &legacy)
+}
+
+// convertWebhookConfiguration converts between the v1 and v1beta1
+// MutatingWebhookConfiguration through their common JSON representation.
+func convertWebhookConfiguration(in, out client.Object) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to convert webhook configuration: %w", err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to convert webhook configuration: %w", err)
+	}
+	// The type of out determines its version, not the apiVersion of in
+	out.GetObjectKind().SetGroupVersionKind(schema.GroupVersionKind{})
+	return nil
+}
@@ -0,0 +1,73 @@
+package policy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
+)
+
+func TestDetectWebhookAPIVersion(t *testing.T) {
+	dc := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}
+	version, err := DetectWebhookAPIVersion(dc)
+	require.NoError(t, err)
+	assert.Equal(t, WebhookAPIVersionV1beta1, version)
+
+	dc.Resources = []*metav1.APIResourceList{{GroupVersion: "admissionregistration.k8s.io/v1"}}
+	version, err = DetectWebhookAPIVersion(dc)
+	require.NoError(t, err)
+	assert.Equal(t, WebhookAPIVersionV1, version)
+}
+
+func TestReconcileWebhook_V1beta1(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, kausalityv1alpha1.AddToScheme(scheme))
+	require.NoError(t, kausalityv1beta1.AddToScheme(scheme))
+	sideEffects := admissionregistrationv1beta1.SideEffectClassNone
+	webhook := &admissionregistrationv1beta1.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "kausality"},
+		Webhooks: []admissionregistrationv1beta1.MutatingWebhook{{
+			Name:                    "mutating.webhook.kausality.io",
+			SideEffects:             &sideEffects,
+			AdmissionReviewVersions: []string{"v1", "v1beta1"},
+		}},
+	}
+	policy := &kausalityv1beta1.Kausality{
+		ObjectMeta: metav1.ObjectMeta{Name: "apps"},
+		Spec: kausalityv1beta1.KausalitySpec{
+			Resources: []kausalityv1beta1.ResourceRule{{APIGroups: []string{"apps"}, Resources: []string{"deployments"}}},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(webhook, policy).Build()
+	controller := &Controller{Client: c, Log: logr.Discard(), WebhookName: "kausality", WebhookAPIVersion: WebhookAPIVersionV1beta1}
+	ctx := context.Background()
+
+	require.NoError(t, controller.reconcileWebhook(ctx, logr.Discard()))
+	require.NoError(t, controller.InjectCABundle(ctx, []byte("ca-bundle")))
+
+	var got admissionregistrationv1beta1.MutatingWebhookConfiguration
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "kausality"}, &got))
+	require.Len(t, got.Webhooks, 1)
+	assert.Equal(t, []string{"v1", "v1beta1"}, got.Webhooks[0].AdmissionReviewVersions)
+	assert.Equal(t, []byte("ca-bundle"), got.Webhooks[0].ClientConfig.CABundle)
+	require.NotEmpty(t, got.Webhooks[0].Rules)
+	assert.Equal(t, []string{"apps"}, got.Webhooks[0].Rules[0].APIGroups)
+	assert.Contains(t, got.Webhooks[0].Rules[0].Resources, "deployments")
+	assert.Equal(t, admissionregistrationv1beta1.OperationType(admissionregistrationv1.Create), got.Webhooks[0].Rules[0].Operations[0])
+}
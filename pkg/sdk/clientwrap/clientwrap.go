@@ -0,0 +1,323 @@
+// Package clientwrap wraps the controller-runtime client of a controller
+// cooperating with kausality. The wrapped client
+//
+//   - sets a field manager on all writes that do not set one,
+//   - copies the trace labels (kausality.io/trace-* annotations) of the
+//     object being reconciled onto the objects the controller creates, so
+//     that e.g. the ticket of a change stays visible on every hop,
+//   - returns denials of kausality as *DenialError, with the remediation
+//     described by the webhook.
+//
+// The object being reconciled is taken from the context, as in pkg/sdk/provider:
+//
+//	c := clientwrap.New(mgr.GetClient(), clientwrap.WithFieldManager("my-controller"))
+//	ctx = provider.WithTrace(ctx, parent)
+//	err := c.Create(ctx, child)
+//	if denial, ok := clientwrap.AsDenial(err); ok && denial.Reason == clientwrap.DenialDrift { ... }
+package clientwrap
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/sdk/provider"
+	"github.com/kausality-io/kausality/pkg/trace"
+)
+
+// DenialReason classifies mutations denied by kausality.
+type DenialReason = kausalityv1alpha1.DenialReason
+
+const (
+	// DenialDrift is drift in enforce mode without approval.
+	DenialDrift = kausalityv1alpha1.DenialReasonDrift
+	// DenialRejected is drift matching a rejection on the parent.
+	DenialRejected = kausalityv1alpha1.DenialReasonRejected
+	// DenialFrozen is a mutation of a child of a frozen parent.
+	DenialFrozen = kausalityv1alpha1.DenialReasonFrozen
+	// DenialTicket is an origin change without valid ticket.
+	DenialTicket = kausalityv1alpha1.DenialReasonTicket
+	// DenialInvalidAnnotation is a write of invalid approvals or rejections.
+	DenialInvalidAnnotation = kausalityv1alpha1.DenialReasonInvalidAnnotation
+	// DenialMissingParent is a mutation of an object whose controller owner
+	// does not exist.
+	DenialMissingParent = kausalityv1alpha1.DenialReasonMissingParent
+	// DenialOwnerReference is an invalid controller owner reference.
+	DenialOwnerReference = kausalityv1alpha1.DenialReasonOwnerReference
+)
+
+// denialMessages maps the messages of kausality denials to their reason, for
+// denials without status details.
+var denialMessages = []struct {
+	message string
+	reason  DenialReason
+}{
+	{"drift detected: ", DenialDrift},
+	{"drift rejected: ", DenialRejected},
+	{"mutation blocked: parent ", DenialFrozen},
+	{"mutation blocked: controller owner ", DenialMissingParent},
+	{"invalid controller owner reference ", DenialOwnerReference},
+}
+
+// DenialError is a mutation denied by kausality. It wraps the Forbidden
+// error of the apiserver, so apierrors.IsForbidden still holds.
+type DenialError struct {
+	// Reason classifies the denial.
+	Reason DenialReason
+	// Message is the message of kausality, without the prefix of the apiserver.
+	Message string
+	// DriftID is the ID of the drift report, if the denial is caused by drift.
+	DriftID string
+	// Parent is the parent of the object as "<apiVersion>/<kind>:<namespace>/<name>".
+	Parent string
+	// ApprovalExample is a value of the parent's kausality.io/approvals
+	// annotation allowing the mutation once, for DenialDrift.
+	ApprovalExample string
+	// Docs is a URL of the documentation of the remediation.
+	Docs string
+	// RetryAfter is the delay suggested before retrying, or zero.
+	RetryAfter time.Duration
+
+	err error
+}
+
+// Error implements error.
+func (e *DenialError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the error of the apiserver.
+func (e *DenialError) Unwrap() error {
+	return e.err
+}
+
+// AsDenial returns the DenialError in err's chain, if any.
+func AsDenial(err error) (*DenialError, bool) {
+	var denial *DenialError
+	if errors.As(err, &denial) {
+		return denial, true
+	}
+	return nil, false
+}
+
+// IsDenial returns whether err is a mutation denied by kausality.
+func IsDenial(err error) bool {
+	_, ok := AsDenial(err)
+	return ok
+}
+
+// toDenial returns err as *DenialError if it is a kausality denial, and err
+// unchanged otherwise. Denials are recognized by the causes of their status
+// details, or by their message.
+func toDenial(err error) error {
+	if err == nil || !apierrors.IsForbidden(err) {
+		return err
+	}
+	msg := err.Error()
+	denial := &DenialError{err: err}
+	for _, d := range denialMessages {
+		if i := strings.Index(msg, d.message); i >= 0 {
+			denial.Reason = d.reason
+			denial.Message = msg[i:]
+			break
+		}
+	}
+
+	var status apierrors.APIStatus
+	if errors.As(err, &status) {
+		if details := status.Status().Details; details != nil {
+			for _, cause := range details.Causes {
+				switch string(cause.Type) {
+				case kausalityv1alpha1.DenialCauseReason:
+					denial.Reason = DenialReason(cause.Message)
+				case kausalityv1alpha1.DenialCauseDriftID:
+					denial.DriftID = cause.Message
+				case kausalityv1alpha1.DenialCauseParent:
+					denial.Parent = cause.Message
+				case kausalityv1alpha1.DenialCauseApprovalExample:
+					denial.ApprovalExample = cause.Message
+				case kausalityv1alpha1.DenialCauseDocs:
+					denial.Docs = cause.Message
+				}
+			}
+			denial.RetryAfter = time.Duration(details.RetryAfterSeconds) * time.Second
+		}
+	}
+	if denial.Reason == "" {
+		return err
+	}
+	if denial.Message == "" {
+		denial.Message = msg
+	}
+	return denial
+}
+
+// Option configures the wrapped client.
+type Option func(*wrapper)
+
+// WithFieldManager sets the field manager of writes not setting one.
+func WithFieldManager(name string) Option {
+	return func(w *wrapper) {
+		w.fieldManager = name
+	}
+}
+
+// New wraps c.
+func New(c client.Client, opts ...Option) client.Client {
+	w := &wrapper{Client: c}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+type wrapper struct {
+	client.Client
+	fieldManager string
+}
+
+// Create implements client.Writer.
+func (w *wrapper) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	propagateTraceLabels(ctx, obj)
+	if w.fieldManager != "" {
+		opts = append([]client.CreateOption{client.FieldOwner(w.fieldManager)}, opts...)
+	}
+	return toDenial(w.Client.Create(ctx, obj, opts...))
+}
+
+// Update implements client.Writer.
+func (w *wrapper) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	if w.fieldManager != "" {
+		opts = append([]client.UpdateOption{client.FieldOwner(w.fieldManager)}, opts...)
+	}
+	return toDenial(w.Client.Update(ctx, obj, opts...))
+}
+
+// Patch implements client.Writer.
+func (w *wrapper) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if w.fieldManager != "" {
+		opts = append([]client.PatchOption{client.FieldOwner(w.fieldManager)}, opts...)
+	}
+	return toDenial(w.Client.Patch(ctx, obj, patch, opts...))
+}
+
+// Apply implements client.Writer.
+func (w *wrapper) Apply(ctx context.Context, obj runtime.ApplyConfiguration, opts ...client.ApplyOption) error {
+	if w.fieldManager != "" {
+		opts = append([]client.ApplyOption{client.FieldOwner(w.fieldManager)}, opts...)
+	}
+	return toDenial(w.Client.Apply(ctx, obj, opts...))
+}
+
+// Delete implements client.Writer.
+func (w *wrapper) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	return toDenial(w.Client.Delete(ctx, obj, opts...))
+}
+
+// DeleteAllOf implements client.Writer.
+func (w *wrapper) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	return toDenial(w.Client.DeleteAllOf(ctx, obj, opts...))
+}
+
+// Status implements client.StatusClient.
+func (w *wrapper) Status() client.SubResourceWriter {
+	return &subResourceWriter{SubResourceWriter: w.Client.Status(), fieldManager: w.fieldManager}
+}
+
+// SubResource implements client.SubResourceClientConstructor.
+func (w *wrapper) SubResource(subResource string) client.SubResourceClient {
+	c := w.Client.SubResource(subResource)
+	return &subResourceClient{
+		SubResourceClient: c,
+		writer:            &subResourceWriter{SubResourceWriter: c, fieldManager: w.fieldManager},
+	}
+}
+
+type subResourceClient struct {
+	client.SubResourceClient
+	writer *subResourceWriter
+}
+
+// Create implements client.SubResourceWriter.
+func (c *subResourceClient) Create(ctx context.Context, obj client.Object, subResource client.Object, opts ...client.SubResourceCreateOption) error {
+	return c.writer.Create(ctx, obj, subResource, opts...)
+}
+
+// Update implements client.SubResourceWriter.
+func (c *subResourceClient) Update(ctx context.Context, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+	return c.writer.Update(ctx, obj, opts...)
+}
+
+// Patch implements client.SubResourceWriter.
+func (c *subResourceClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+	return c.writer.Patch(ctx, obj, patch, opts...)
+}
+
+// Apply implements client.SubResourceWriter.
+func (c *subResourceClient) Apply(ctx context.Context, obj runtime.ApplyConfiguration, opts ...client.SubResourceApplyOption) error {
+	return c.writer.Apply(ctx, obj, opts...)
+}
+
+type subResourceWriter struct {
+	client.SubResourceWriter
+	fieldManager string
+}
+
+// Create implements client.SubResourceWriter.
+func (w *subResourceWriter) Create(ctx context.Context, obj client.Object, subResource client.Object, opts ...client.SubResourceCreateOption) error {
+	if w.fieldManager != "" {
+		opts = append([]client.SubResourceCreateOption{client.FieldOwner(w.fieldManager)}, opts...)
+	}
+	return toDenial(w.SubResourceWriter.Create(ctx, obj, subResource, opts...))
+}
+
+// Update implements client.SubResourceWriter.
+func (w *subResourceWriter) Update(ctx context.Context, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+	if w.fieldManager != "" {
+		opts = append([]client.SubResourceUpdateOption{client.FieldOwner(w.fieldManager)}, opts...)
+	}
+	return toDenial(w.SubResourceWriter.Update(ctx, obj, opts...))
+}
+
+// Patch implements client.SubResourceWriter.
+func (w *subResourceWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+	if w.fieldManager != "" {
+		opts = append([]client.SubResourcePatchOption{client.FieldOwner(w.fieldManager)}, opts...)
+	}
+	return toDenial(w.SubResourceWriter.Patch(ctx, obj, patch, opts...))
+}
+
+// Apply implements client.SubResourceWriter.
+func (w *subResourceWriter) Apply(ctx context.Context, obj runtime.ApplyConfiguration, opts ...client.SubResourceApplyOption) error {
+	if w.fieldManager != "" {
+		opts = append([]client.SubResourceApplyOption{client.FieldOwner(w.fieldManager)}, opts...)
+	}
+	return toDenial(w.SubResourceWriter.Apply(ctx, obj, opts...))
+}
+
+// propagateTraceLabels copies the labels of the last hop of the context's
+// trace, i.e. those of the object being reconciled, onto obj as
+// kausality.io/trace-* annotations. Annotations set on obj win.
+func propagateTraceLabels(ctx context.Context, obj client.Object) {
+	t := provider.FromContext(ctx)
+	if len(t) == 0 || len(t[len(t)-1].Labels) == 0 {
+		return
+	}
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	for key, value := range t[len(t)-1].Labels {
+		annotation := trace.TraceMetadataPrefix + key
+		if _, ok := annotations[annotation]; !ok {
+			annotations[annotation] = value
+		}
+	}
+	obj.SetAnnotations(annotations)
+}
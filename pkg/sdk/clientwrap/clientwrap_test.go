@@ -0,0 +1,125 @@
+package clientwrap
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/sdk/provider"
+	"github.com/kausality-io/kausality/pkg/trace"
+)
+
+var configMaps = schema.GroupResource{Resource: "configmaps"}
+
+func webhookDenial(msg string) error {
+	return apierrors.NewForbidden(configMaps, "settings",
+		errors.New(`admission webhook "mutating.webhook.kausality.io" denied the request: `+msg))
+}
+
+func TestCreate_FieldManagerAndTraceLabels(t *testing.T) {
+	var fieldManagers []string
+	fc := fake.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			fieldManagers = append(fieldManagers, (&client.CreateOptions{}).ApplyOptions(opts).FieldManager)
+			return c.Create(ctx, obj, opts...)
+		},
+	}).Build()
+	c := New(fc, WithFieldManager("my-controller"))
+
+	parent := &metav1.ObjectMeta{Annotations: map[string]string{trace.TraceAnnotation: trace.Trace{
+		{APIVersion: "example.org/v1", Kind: "App", Name: "web", User: "alice", Labels: map[string]string{"ticket": "JIRA-1", "pr": "42"}},
+	}.String()}}
+	ctx := provider.WithTrace(context.Background(), parent)
+
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "default", Name: "settings",
+		Annotations: map[string]string{trace.TraceMetadataPrefix + "pr": "43"},
+	}}
+	require.NoError(t, c.Create(ctx, cm))
+	assert.Equal(t, "JIRA-1", cm.Annotations[trace.TraceMetadataPrefix+"ticket"])
+	assert.Equal(t, "43", cm.Annotations[trace.TraceMetadataPrefix+"pr"], "annotations of the object win")
+
+	// Explicit field managers win
+	untraced := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "other"}}
+	require.NoError(t, c.Create(context.Background(), untraced, client.FieldOwner("explicit")))
+	assert.Empty(t, untraced.Annotations)
+
+	assert.Equal(t, []string{"my-controller", "explicit"}, fieldManagers)
+}
+
+func TestDenials(t *testing.T) {
+	deny := func(msg string) client.Client {
+		return New(fake.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+			Update: func(context.Context, client.WithWatch, client.Object, ...client.UpdateOption) error {
+				return webhookDenial(msg)
+			},
+			SubResourceUpdate: func(context.Context, client.Client, string, client.Object, ...client.SubResourceUpdateOption) error {
+				return webhookDenial(msg)
+			},
+		}).Build())
+	}
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "settings"}}
+
+	tests := []struct {
+		message string
+		reason  DenialReason
+	}{
+		{"drift detected: no approval found for this mutation", DenialDrift},
+		{"drift rejected: rejected by alice", DenialRejected},
+		{"mutation blocked: parent frozen by alice: incident", DenialFrozen},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.reason), func(t *testing.T) {
+			err := deny(tt.message).Update(context.Background(), cm)
+			denial, ok := AsDenial(err)
+			require.True(t, ok, err)
+			assert.Equal(t, tt.reason, denial.Reason)
+			assert.Equal(t, tt.message, denial.Message)
+			assert.True(t, apierrors.IsForbidden(err))
+
+			err = deny(tt.message).SubResource("scale").Update(context.Background(), cm)
+			assert.True(t, IsDenial(err))
+		})
+	}
+
+	// Other errors are returned unchanged
+	err := deny("forbidden by another webhook").Update(context.Background(), cm)
+	assert.False(t, IsDenial(err))
+	assert.True(t, apierrors.IsForbidden(err))
+}
+
+func TestDenials_StatusDetails(t *testing.T) {
+	denial := apierrors.NewForbidden(configMaps, "settings",
+		errors.New(`admission webhook "mutating.webhook.kausality.io" denied the request: ticket PROJ-1 is closed`))
+	denial.ErrStatus.Details.RetryAfterSeconds = 30
+	denial.ErrStatus.Details.Causes = []metav1.StatusCause{
+		{Type: kausalityv1alpha1.DenialCauseReason, Message: string(kausalityv1alpha1.DenialReasonTicket)},
+		{Type: kausalityv1alpha1.DenialCauseDocs, Message: "https://example.org/docs"},
+	}
+	c := New(fake.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(context.Context, client.WithWatch, client.Object, ...client.CreateOption) error {
+			return denial
+		},
+	}).Build())
+
+	err := c.Create(context.Background(), &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "settings"}})
+	got, ok := AsDenial(err)
+	require.True(t, ok, err)
+	assert.Equal(t, DenialTicket, got.Reason)
+	assert.Equal(t, "https://example.org/docs", got.Docs)
+	assert.Equal(t, 30*time.Second, got.RetryAfter)
+	assert.Equal(t, err.Error(), got.Message)
+}
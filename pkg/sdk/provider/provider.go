@@ -0,0 +1,188 @@
+// Package provider lets Crossplane providers and custom controllers carry the
+// kausality trace of the resource they reconcile into outbound cloud API
+// calls, e.g. as a User-Agent suffix or as session tags, so that cloud audit
+// logs can be correlated with the originating Kubernetes change.
+//
+// The webhook records the trace on every admitted object; this package only
+// reads it. It has no dependencies on cloud SDKs:
+//
+//	ctx = provider.WithTrace(ctx, mg)
+//	httpClient := &http.Client{Transport: provider.NewTransport(http.DefaultTransport)}
+//	tags := provider.SessionTags(provider.FromContext(ctx))
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kausality-io/kausality/pkg/trace"
+)
+
+// Session tag keys set by SessionTags.
+const (
+	TagOriginUser = "kausality:origin-user"
+	TagOriginKind = "kausality:origin-kind"
+	TagOriginName = "kausality:origin-name"
+	TagRequestUID = "kausality:request-uid"
+	TagTicket     = "kausality:ticket"
+)
+
+const (
+	// userAgentProduct is the product token of the User-Agent suffix.
+	userAgentProduct = "kausality"
+	// maxTagValueLength is the AWS limit for session tag values.
+	maxTagValueLength = 256
+)
+
+type traceKey struct{}
+
+// FromObject returns the trace recorded on obj, or nil if it has none.
+func FromObject(obj metav1.Object) (trace.Trace, error) {
+	t, err := trace.Parse(obj.GetAnnotations()[trace.TraceAnnotation])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s annotation: %w", trace.TraceAnnotation, err)
+	}
+	return t, nil
+}
+
+// WithTrace returns a context carrying the trace recorded on obj. An invalid
+// or missing trace leaves the context unchanged, so reconciliation never fails
+// because of tracing.
+func WithTrace(ctx context.Context, obj metav1.Object) context.Context {
+	t, err := FromObject(obj)
+	if err != nil || len(t) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, traceKey{}, t)
+}
+
+// FromContext returns the trace stored by WithTrace, or nil.
+func FromContext(ctx context.Context) trace.Trace {
+	t, _ := ctx.Value(traceKey{}).(trace.Trace)
+	return t
+}
+
+// UserAgentSuffix renders the origin of a trace as a User-Agent comment, e.g.
+// "kausality (origin=alice; kind=Deployment; name=web; request=abc-123; ticket=JIRA-1)".
+// It returns "" for an empty trace.
+func UserAgentSuffix(t trace.Trace) string {
+	origin := t.Origin()
+	if origin == nil {
+		return ""
+	}
+
+	parts := []string{"origin=" + userAgentValue(origin.User)}
+	if origin.Kind != "" {
+		parts = append(parts, "kind="+userAgentValue(origin.Kind))
+	}
+	if origin.Name != "" {
+		parts = append(parts, "name="+userAgentValue(origin.Name))
+	}
+	if origin.RequestUID != "" {
+		parts = append(parts, "request="+userAgentValue(origin.RequestUID))
+	}
+	if ticket := originTicket(origin); ticket != "" {
+		parts = append(parts, "ticket="+userAgentValue(ticket))
+	}
+	return fmt.Sprintf("%s (%s)", userAgentProduct, strings.Join(parts, "; "))
+}
+
+// SessionTags returns the origin of a trace as session tags, e.g. for AWS STS
+// AssumeRole. Values are restricted to the characters and length AWS accepts.
+// It returns nil for an empty trace.
+func SessionTags(t trace.Trace) map[string]string {
+	origin := t.Origin()
+	if origin == nil {
+		return nil
+	}
+
+	tags := map[string]string{}
+	set := func(key, value string) {
+		if value != "" {
+			tags[key] = tagValue(value)
+		}
+	}
+	set(TagOriginUser, origin.User)
+	set(TagOriginKind, origin.Kind)
+	set(TagOriginName, origin.Name)
+	set(TagRequestUID, origin.RequestUID)
+	set(TagTicket, originTicket(origin))
+	return tags
+}
+
+// NewTransport wraps base so that requests whose context carries a trace (see
+// WithTrace) get the UserAgentSuffix appended to their User-Agent header.
+// A nil base uses http.DefaultTransport.
+func NewTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base}
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	suffix := UserAgentSuffix(FromContext(req.Context()))
+	if suffix == "" {
+		return t.base.RoundTrip(req)
+	}
+
+	// RoundTrippers must not modify the request
+	req = req.Clone(req.Context())
+	if ua := req.Header.Get("User-Agent"); ua != "" {
+		suffix = ua + " " + suffix
+	}
+	req.Header.Set("User-Agent", suffix)
+	return t.base.RoundTrip(req)
+}
+
+// originTicket returns the validated ticket of the origin, falling back to
+// the kausality.io/trace-ticket label.
+func originTicket(origin *trace.Hop) string {
+	if origin.Ticket != nil {
+		return origin.Ticket.ID
+	}
+	return origin.Labels["ticket"]
+}
+
+// userAgentValue replaces characters that would break a User-Agent comment.
+func userAgentValue(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '(' || r == ')' || r == ';' || r == '\\':
+			return '_'
+		case r < 0x21 || r > 0x7e:
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+// tagValue replaces characters not allowed in session tag values and
+// truncates to the maximum length.
+func tagValue(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case strings.ContainsRune(" _.:/=+-@", r):
+			return r
+		}
+		return '_'
+	}, s)
+	if len(s) > maxTagValueLength {
+		s = s[:maxTagValueLength]
+	}
+	return s
+}
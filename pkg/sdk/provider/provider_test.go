@@ -0,0 +1,108 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kausality-io/kausality/pkg/trace"
+)
+
+func testTrace() trace.Trace {
+	return trace.Trace{
+		{APIVersion: "example.org/v1alpha1", Kind: "Database", Name: "orders", Generation: 2, User: "alice@example.com", RequestUID: "req-1", Labels: map[string]string{"ticket": "JIRA-1"}},
+		{APIVersion: "example.org/v1alpha1", Kind: "XDatabase", Name: "orders-x7k2p", Generation: 2, User: "system:serviceaccount:crossplane-system:crossplane"},
+	}
+}
+
+func objectWithTrace(value string) *metav1.ObjectMeta {
+	return &metav1.ObjectMeta{Name: "orders-db", Annotations: map[string]string{trace.TraceAnnotation: value}}
+}
+
+func TestFromObject(t *testing.T) {
+	got, err := FromObject(objectWithTrace(testTrace().String()))
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, "alice@example.com", got.Origin().User)
+
+	got, err = FromObject(&metav1.ObjectMeta{Name: "untraced"})
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	_, err = FromObject(objectWithTrace("not json"))
+	require.Error(t, err)
+}
+
+func TestWithTrace(t *testing.T) {
+	ctx := WithTrace(context.Background(), objectWithTrace(testTrace().String()))
+	assert.Len(t, FromContext(ctx), 2)
+
+	// Invalid traces are ignored
+	ctx = WithTrace(context.Background(), objectWithTrace("not json"))
+	assert.Nil(t, FromContext(ctx))
+}
+
+func TestUserAgentSuffix(t *testing.T) {
+	assert.Equal(t,
+		"kausality (origin=alice@example.com; kind=Database; name=orders; request=req-1; ticket=JIRA-1)",
+		UserAgentSuffix(testTrace()))
+	assert.Empty(t, UserAgentSuffix(nil))
+
+	// Validated tickets win over labels, unsafe characters are replaced
+	tr := trace.Trace{{Kind: "Database", Name: "orders", User: "Alice (admin); x", Ticket: &trace.TicketRef{ID: "org/repo#42"}}}
+	assert.Equal(t,
+		"kausality (origin=Alice__admin___x; kind=Database; name=orders; ticket=org/repo#42)",
+		UserAgentSuffix(tr))
+}
+
+func TestSessionTags(t *testing.T) {
+	assert.Equal(t, map[string]string{
+		TagOriginUser: "alice@example.com",
+		TagOriginKind: "Database",
+		TagOriginName: "orders",
+		TagRequestUID: "req-1",
+		TagTicket:     "JIRA-1",
+	}, SessionTags(testTrace()))
+	assert.Nil(t, SessionTags(nil))
+
+	tags := SessionTags(trace.Trace{{User: "bob#" + strings.Repeat("x", 300)}})
+	assert.Len(t, tags, 1)
+	assert.Len(t, tags[TagOriginUser], maxTagValueLength)
+	assert.True(t, strings.HasPrefix(tags[TagOriginUser], "bob_x"))
+}
+
+func TestTransport(t *testing.T) {
+	var userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTransport(nil)}
+	ctx := WithTrace(context.Background(), objectWithTrace(testTrace().String()))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("User-Agent", "provider-aws/v1.0")
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, "provider-aws/v1.0 "+UserAgentSuffix(testTrace()), userAgent)
+	assert.Equal(t, "provider-aws/v1.0", req.Header.Get("User-Agent"), "request must not be modified")
+
+	// Without a trace the User-Agent is untouched
+	req, err = http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("User-Agent", "provider-aws/v1.0")
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, "provider-aws/v1.0", userAgent)
+}
@@ -0,0 +1,101 @@
+package harness
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+	ktesting "github.com/kausality-io/kausality/pkg/testing"
+	"github.com/kausality-io/kausality/pkg/trace"
+)
+
+// ExpectDriftBlocked asserts that err is the admission denial kausality
+// returns for drift in enforce mode.
+func ExpectDriftBlocked(t testing.TB, err error) {
+	t.Helper()
+
+	if err == nil {
+		t.Fatalf("expected the write to be blocked as drift, but it succeeded")
+	}
+	if !apierrors.IsForbidden(err) || !strings.Contains(err.Error(), "drift") {
+		t.Fatalf("expected the write to be blocked as drift, got: %v", err)
+	}
+}
+
+// ExpectDriftReported waits until a drift report for the child has been sent
+// and returns the first one.
+func (e *Env) ExpectDriftReported(t testing.TB, child client.Object) v1alpha1.DriftReport {
+	t.Helper()
+
+	var found v1alpha1.DriftReport
+	ktesting.Eventually(t, func() (bool, string) {
+		for _, report := range e.Reports() {
+			c := report.Spec.Child
+			if report.Spec.Phase == v1alpha1.DriftReportPhaseDetected &&
+				c.Namespace == child.GetNamespace() && c.Name == child.GetName() {
+				found = report
+				return true, ""
+			}
+		}
+		return false, fmt.Sprintf("no drift reported for %s/%s among %d report(s)", child.GetNamespace(), child.GetName(), len(e.Reports()))
+	}, ktesting.Timeout, ktesting.PollInterval, "waiting for drift report")
+	return found
+}
+
+// ExpectNoDrift asserts that no drift is reported for the given duration,
+// e.g. while the controller reconciles a stable parent.
+func (e *Env) ExpectNoDrift(t testing.TB, duration time.Duration) {
+	t.Helper()
+
+	deadline := time.Now().Add(duration)
+	for {
+		for _, report := range e.Reports() {
+			if report.Spec.Phase != v1alpha1.DriftReportPhaseDetected {
+				continue
+			}
+			c, p := report.Spec.Child, report.Spec.Parent
+			t.Fatalf("unexpected drift: %s %s %s/%s by %q (parent %s %s/%s)",
+				report.Spec.Request.Operation, c.Kind, c.Namespace, c.Name, report.Spec.Request.User,
+				p.Kind, p.Namespace, p.Name)
+		}
+		if time.Now().After(deadline) {
+			return
+		}
+		time.Sleep(ktesting.PollInterval)
+	}
+}
+
+// ExpectTraceHops waits until the object's trace has hops of the given kinds,
+// origin first, and returns the trace.
+func (e *Env) ExpectTraceHops(t testing.TB, obj client.Object, kinds ...string) trace.Trace {
+	t.Helper()
+
+	var got trace.Trace
+	key := client.ObjectKeyFromObject(obj)
+	ktesting.Eventually(t, func() (bool, string) {
+		current := obj.DeepCopyObject().(client.Object)
+		if err := e.Client.Get(context.Background(), key, current); err != nil {
+			return false, fmt.Sprintf("failed to get %s: %v", key, err)
+		}
+		tr, err := trace.GetTraceFromObject(current)
+		if err != nil {
+			return false, fmt.Sprintf("invalid trace on %s: %v", key, err)
+		}
+		gotKinds := make([]string, 0, len(tr))
+		for _, hop := range tr {
+			gotKinds = append(gotKinds, hop.Kind)
+		}
+		if strings.Join(gotKinds, ",") != strings.Join(kinds, ",") {
+			return false, fmt.Sprintf("trace of %s has hops %v, want %v", key, gotKinds, kinds)
+		}
+		got = tr
+		return true, ""
+	}, ktesting.Timeout, ktesting.PollInterval, "waiting for trace hops")
+	return got
+}
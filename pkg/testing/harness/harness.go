@@ -0,0 +1,312 @@
+// Package harness runs the kausality admission webhook against a controller
+// in envtest, so controller authors can verify in their own CI that their
+// operators do not cause drift.
+//
+// A typical test starts the environment with the controller under test,
+// creates a parent object, waits for it to become stable, and asserts on
+// drift and trace propagation:
+//
+//	env := harness.StartKausalityEnv(t, harness.Options{
+//		Setup: func(mgr manager.Manager) error {
+//			return (&MyReconciler{Client: mgr.GetClient()}).SetupWithManager(mgr)
+//		},
+//	})
+//	env.ExpectTraceHops(t, child, "MyParent", "ConfigMap")
+//	env.ExpectNoDrift(t, 5*time.Second)
+//
+// Running the tests requires the envtest binaries, see
+// sigs.k8s.io/controller-runtime/tools/setup-envtest.
+package harness
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/admission"
+	"github.com/kausality-io/kausality/pkg/callback/v1alpha1"
+	"github.com/kausality-io/kausality/pkg/policy"
+)
+
+// ControllerUser is the user the controller under test is authenticated as.
+// It is distinct from the user of Env.Client, so kausality can tell the
+// controller's writes apart from writes made by the test.
+const ControllerUser = "kausality-harness:controller"
+
+// webhookPath is the path the admission handler is served on.
+const webhookPath = "/mutate"
+
+// Options configures StartKausalityEnv.
+type Options struct {
+	// Scheme is used by all clients and the controller's manager.
+	// Defaults to the client-go scheme.
+	Scheme *runtime.Scheme
+	// CRDDirectoryPaths are directories containing CRDs to install, e.g. the
+	// parent types reconciled by the controller under test.
+	CRDDirectoryPaths []string
+	// Rules select the resources intercepted by kausality. Defaults to
+	// DefaultRules. Use RulesFor to intercept custom resources.
+	Rules []admissionregistrationv1.RuleWithOperations
+	// Mode is the drift detection mode. Defaults to enforce.
+	Mode kausalityv1alpha1.Mode
+	// Setup registers the controller under test with the manager. The manager
+	// runs as ControllerUser. If nil, no manager is started.
+	Setup func(mgr manager.Manager) error
+}
+
+// Env is a running envtest API server with the kausality webhook installed.
+type Env struct {
+	// Config is the admin rest config for the API server.
+	Config *rest.Config
+	// Client acts as a user outside the controller, e.g. to create parents.
+	Client client.Client
+	// ControllerClient is authenticated as ControllerUser, the same user as
+	// the controller under test, e.g. to emulate a controller write.
+	ControllerClient client.Client
+	// Namespace is a namespace created for the test.
+	Namespace string
+
+	reports *reportRecorder
+}
+
+// DefaultRules intercepts the core and apps resources, including status updates.
+func DefaultRules() []admissionregistrationv1.RuleWithOperations {
+	return append(
+		RulesFor("apps", "v1", "deployments", "replicasets", "statefulsets", "daemonsets"),
+		RulesFor("", "v1", "configmaps", "secrets", "services")...,
+	)
+}
+
+// RulesFor intercepts the resources of a group version. Status updates are
+// intercepted too, which kausality needs to identify controllers.
+func RulesFor(group, version string, resources ...string) []admissionregistrationv1.RuleWithOperations {
+	statusResources := make([]string, 0, len(resources))
+	for _, r := range resources {
+		statusResources = append(statusResources, r+"/status")
+	}
+	return []admissionregistrationv1.RuleWithOperations{
+		{
+			Operations: []admissionregistrationv1.OperationType{
+				admissionregistrationv1.Create,
+				admissionregistrationv1.Update,
+				admissionregistrationv1.Delete,
+			},
+			Rule: admissionregistrationv1.Rule{
+				APIGroups:   []string{group},
+				APIVersions: []string{version},
+				Resources:   resources,
+			},
+		},
+		{
+			Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Update},
+			Rule: admissionregistrationv1.Rule{
+				APIGroups:   []string{group},
+				APIVersions: []string{version},
+				Resources:   statusResources,
+			},
+		},
+	}
+}
+
+// StartKausalityEnv starts envtest with the kausality webhook and, if
+// opts.Setup is set, a manager running the controller under test. Everything
+// is stopped when the test finishes.
+func StartKausalityEnv(t testing.TB, opts Options) *Env {
+	t.Helper()
+
+	if opts.Scheme == nil {
+		opts.Scheme = runtime.NewScheme()
+		if err := clientgoscheme.AddToScheme(opts.Scheme); err != nil {
+			t.Fatalf("failed to build scheme: %v", err)
+		}
+	}
+	if len(opts.Rules) == 0 {
+		opts.Rules = DefaultRules()
+	}
+	if opts.Mode == "" {
+		opts.Mode = kausalityv1alpha1.ModeEnforce
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	testEnv := &envtest.Environment{
+		CRDDirectoryPaths:     opts.CRDDirectoryPaths,
+		ErrorIfCRDPathMissing: len(opts.CRDDirectoryPaths) > 0,
+		WebhookInstallOptions: envtest.WebhookInstallOptions{
+			MutatingWebhooks: []*admissionregistrationv1.MutatingWebhookConfiguration{webhookConfiguration(opts.Rules)},
+		},
+	}
+	cfg, err := testEnv.Start()
+	if err != nil {
+		cancel()
+		t.Fatalf("failed to start envtest: %v", err)
+	}
+	t.Cleanup(func() {
+		cancel()
+		if err := testEnv.Stop(); err != nil {
+			t.Logf("failed to stop envtest: %v", err)
+		}
+	})
+
+	env := &Env{Config: cfg, reports: &reportRecorder{}}
+	if env.Client, err = client.New(cfg, client.Options{Scheme: opts.Scheme}); err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	controllerUser, err := testEnv.AddUser(envtest.User{Name: ControllerUser, Groups: []string{"system:masters"}}, cfg)
+	if err != nil {
+		t.Fatalf("failed to add controller user: %v", err)
+	}
+	if env.ControllerClient, err = client.New(controllerUser.Config(), client.Options{Scheme: opts.Scheme}); err != nil {
+		t.Fatalf("failed to create controller client: %v", err)
+	}
+
+	if err := startWebhook(ctx, &testEnv.WebhookInstallOptions, env, opts.Mode); err != nil {
+		t.Fatalf("failed to start kausality webhook: %v", err)
+	}
+
+	env.Namespace = fmt.Sprintf("kausality-harness-%d", time.Now().UnixNano())
+	if err := env.Client.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: env.Namespace}}); err != nil {
+		t.Fatalf("failed to create namespace: %v", err)
+	}
+
+	if opts.Setup != nil {
+		if err := startManager(ctx, controllerUser.Config(), opts); err != nil {
+			t.Fatalf("failed to start controller manager: %v", err)
+		}
+	}
+
+	return env
+}
+
+// Reports returns the drift reports sent so far, oldest first.
+func (e *Env) Reports() []v1alpha1.DriftReport {
+	return e.reports.list()
+}
+
+// webhookConfiguration builds the MutatingWebhookConfiguration envtest installs.
+func webhookConfiguration(rules []admissionregistrationv1.RuleWithOperations) *admissionregistrationv1.MutatingWebhookConfiguration {
+	return &admissionregistrationv1.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "kausality-harness"},
+		Webhooks: []admissionregistrationv1.MutatingWebhook{{
+			Name:                    "mutate.admission.kausality.io",
+			AdmissionReviewVersions: []string{"v1"},
+			SideEffects:             ptr.To(admissionregistrationv1.SideEffectClassNone),
+			FailurePolicy:           ptr.To(admissionregistrationv1.Fail),
+			MatchPolicy:             ptr.To(admissionregistrationv1.Equivalent),
+			ClientConfig: admissionregistrationv1.WebhookClientConfig{
+				Service: &admissionregistrationv1.ServiceReference{Path: ptr.To(webhookPath)},
+			},
+			Rules: rules,
+		}},
+	}
+}
+
+// startWebhook serves the admission handler on the address envtest
+// configured and waits until it accepts connections.
+func startWebhook(ctx context.Context, opts *envtest.WebhookInstallOptions, env *Env, mode kausalityv1alpha1.Mode) error {
+	server := webhook.NewServer(webhook.Options{
+		Host:    opts.LocalServingHost,
+		Port:    opts.LocalServingPort,
+		CertDir: opts.LocalServingCertDir,
+	})
+	handler := admission.NewHandler(admission.Config{
+		Client:         env.Client,
+		Log:            log.Log.WithName("kausality-harness"),
+		PolicyResolver: policy.NewStaticResolver(mode),
+		CallbackSender: env.reports,
+	})
+	server.Register(webhookPath, &webhook.Admission{Handler: handler})
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Start(ctx)
+	}()
+
+	dialer := &net.Dialer{Timeout: time.Second}
+	addr := net.JoinHostPort(opts.LocalServingHost, fmt.Sprint(opts.LocalServingPort))
+	timeout := time.After(10 * time.Second)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-errCh:
+			return err
+		case <-timeout:
+			return fmt.Errorf("timed out waiting for webhook server on %s", addr)
+		case <-ticker.C:
+			conn, err := tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{InsecureSkipVerify: true}) //nolint:gosec // local test server
+			if err == nil {
+				_ = conn.Close()
+				return nil
+			}
+		}
+	}
+}
+
+// startManager runs the controller under test and waits for its caches to sync.
+func startManager(ctx context.Context, cfg *rest.Config, opts Options) error {
+	mgr, err := manager.New(cfg, manager.Options{
+		Scheme:                 opts.Scheme,
+		Metrics:                metricsserver.Options{BindAddress: "0"},
+		HealthProbeBindAddress: "0",
+	})
+	if err != nil {
+		return err
+	}
+	if err := opts.Setup(mgr); err != nil {
+		return fmt.Errorf("setup failed: %w", err)
+	}
+
+	go func() {
+		if err := mgr.Start(ctx); err != nil {
+			log.Log.Error(err, "controller manager stopped")
+		}
+	}()
+	if !mgr.GetCache().WaitForCacheSync(ctx) {
+		return fmt.Errorf("caches did not sync")
+	}
+	return nil
+}
+
+// reportRecorder is an in-memory callback.ReportSender.
+type reportRecorder struct {
+	mu      sync.Mutex
+	reports []v1alpha1.DriftReport
+}
+
+func (r *reportRecorder) SendAsync(_ context.Context, report *v1alpha1.DriftReport) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reports = append(r.reports, *report)
+}
+
+func (r *reportRecorder) IsEnabled() bool { return true }
+
+func (r *reportRecorder) MarkResolved(string) {}
+
+func (r *reportRecorder) StartCleanup(time.Duration) func() { return func() {} }
+
+func (r *reportRecorder) list() []v1alpha1.DriftReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]v1alpha1.DriftReport(nil), r.reports...)
+}
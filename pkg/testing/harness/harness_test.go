@@ -0,0 +1,118 @@
+//go:build envtest
+// +build envtest
+
+package harness_test
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/kausality-io/kausality/pkg/controller"
+	ktesting "github.com/kausality-io/kausality/pkg/testing"
+	"github.com/kausality-io/kausality/pkg/testing/harness"
+)
+
+// configReconciler mirrors each Deployment's replicas into an owned ConfigMap
+// and reports the generation it observed, like a well-behaved controller.
+type configReconciler struct {
+	client client.Client
+}
+
+func (r *configReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var deploy appsv1.Deployment
+	if err := r.client.Get(ctx, req.NamespacedName, &deploy); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: deploy.Namespace, Name: deploy.Name + "-config"}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.client, cm, func() error {
+		cm.Data = map[string]string{"replicas": strconv.Itoa(int(ptr.Deref(deploy.Spec.Replicas, 1)))}
+		return controllerutil.SetControllerReference(&deploy, cm, r.client.Scheme())
+	}); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if deploy.Status.ObservedGeneration != deploy.Generation {
+		deploy.Status.ObservedGeneration = deploy.Generation
+		if err := r.client.Status().Update(ctx, &deploy); err != nil && !apierrors.IsConflict(err) {
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{}, nil
+}
+
+func TestHarness(t *testing.T) {
+	ctx := context.Background()
+	env := harness.StartKausalityEnv(t, harness.Options{
+		Setup: func(mgr manager.Manager) error {
+			return ctrl.NewControllerManagedBy(mgr).
+				For(&appsv1.Deployment{}).
+				Owns(&corev1.ConfigMap{}).
+				Complete(&configReconciler{client: mgr.GetClient()})
+		},
+	})
+
+	deploy := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: env.Namespace, Name: "app"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: ptr.To(int32(2)),
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "app"}},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "app"}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "nginx"}}},
+			},
+		},
+	}
+	if err := env.Client.Create(ctx, deploy); err != nil {
+		t.Fatalf("failed to create deployment: %v", err)
+	}
+
+	// The controller's write carries the trace of the user's change
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: env.Namespace, Name: "app-config"}}
+	tr := env.ExpectTraceHops(t, cm, "Deployment", "ConfigMap")
+	if tr.Origin().User == harness.ControllerUser {
+		t.Errorf("expected the origin to be the test user, got %q", tr.Origin().User)
+	}
+
+	// Wait until kausality has recorded the controller on the stable parent
+	ktesting.EventuallyObject(t, func() (*appsv1.Deployment, error) {
+		var d appsv1.Deployment
+		err := env.Client.Get(ctx, client.ObjectKeyFromObject(deploy), &d)
+		return &d, err
+	}, func(d *appsv1.Deployment) (bool, string) {
+		if d.Status.ObservedGeneration != d.Generation {
+			return false, fmt.Sprintf("observedGeneration=%d, generation=%d", d.Status.ObservedGeneration, d.Generation)
+		}
+		if d.Annotations[controller.ControllersAnnotation] != controller.HashUsername(harness.ControllerUser) {
+			return false, fmt.Sprintf("controllers annotation is %q", d.Annotations[controller.ControllersAnnotation])
+		}
+		return true, ""
+	}, ktesting.LongTimeout, ktesting.PollInterval, "waiting for stable parent")
+
+	env.ExpectNoDrift(t, 2*time.Second)
+
+	// A controller write while the parent is stable is drift
+	if err := env.ControllerClient.Get(ctx, client.ObjectKeyFromObject(cm), cm); err != nil {
+		t.Fatalf("failed to get configmap: %v", err)
+	}
+	cm.Data["replicas"] = "5"
+	harness.ExpectDriftBlocked(t, env.ControllerClient.Update(ctx, cm))
+
+	report := env.ExpectDriftReported(t, cm)
+	if report.Spec.Parent.Name != "app" {
+		t.Errorf("expected parent app, got %s", report.Spec.Parent.Name)
+	}
+}
@@ -0,0 +1,264 @@
+// Package scenario builds drift detection scenarios for unit tests: a parent
+// and a controlled child in a given state, the admission request mutating
+// the child, and a fake client holding the parent.
+//
+//	s := scenario.NewParentChild().
+//		StableParent().
+//		WithControllers(controller.HashUsername(scenario.ControllerUser)).
+//		ChildSpecChange(map[string]interface{}{"replicas": int64(3)})
+//	resp := handler.Handle(ctx, s.Request(scenario.ControllerUser))
+//
+// By default the parent is a Deployment "parent" and the child a ReplicaSet
+// "child" in namespace "default", the parent is reconciling, and the child's
+// spec is unchanged.
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/kausality-io/kausality/pkg/controller"
+)
+
+// ControllerUser is the default user of the parent's controller.
+const ControllerUser = "system:serviceaccount:kube-system:deployment-controller"
+
+// Default kinds of the parent and the child.
+var (
+	DeploymentGVK = schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	ReplicaSetGVK = schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "ReplicaSet"}
+)
+
+// Scenario is a parent with a controlled child. Its methods modify and
+// return the scenario, so that they can be chained.
+type Scenario struct {
+	parent   *unstructured.Unstructured
+	child    *unstructured.Unstructured
+	oldChild *unstructured.Unstructured
+	op       admissionv1.Operation
+}
+
+// NewParentChild returns a Deployment "parent", reconciling at generation 2,
+// controlling a ReplicaSet "child" in namespace "default". The request is an
+// UPDATE of the child that leaves its spec unchanged.
+func NewParentChild() *Scenario {
+	parent := Object(DeploymentGVK, "default", "parent", map[string]interface{}{"replicas": int64(1)})
+	parent.SetUID("parent-uid")
+	parent.SetGeneration(2)
+	parent.Object["status"] = map[string]interface{}{"observedGeneration": int64(1)}
+
+	s := &Scenario{parent: parent, op: admissionv1.Update}
+	s.child = Object(ReplicaSetGVK, "default", "child", map[string]interface{}{"replicas": int64(1)})
+	s.child.SetUID("child-uid")
+	s.oldChild = s.child.DeepCopy()
+	s.setOwner()
+	return s
+}
+
+// Object returns an unstructured object with the given spec, which may be nil.
+func Object(gvk schema.GroupVersionKind, namespace, name string, spec map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	obj.SetGroupVersionKind(gvk)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	if spec != nil {
+		obj.Object["spec"] = spec
+	}
+	return obj
+}
+
+// OwnerReference returns a controller owner reference to obj.
+func OwnerReference(obj client.Object) metav1.OwnerReference {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	return metav1.OwnerReference{
+		APIVersion: gvk.GroupVersion().String(),
+		Kind:       gvk.Kind,
+		Name:       obj.GetName(),
+		UID:        obj.GetUID(),
+		Controller: ptr.To(true),
+	}
+}
+
+// WithParent replaces the parent's kind and name. The child keeps its
+// namespace, unless the parent is cluster-scoped.
+func (s *Scenario) WithParent(gvk schema.GroupVersionKind, name string) *Scenario {
+	s.parent.SetGroupVersionKind(gvk)
+	s.parent.SetName(name)
+	s.setOwner()
+	return s
+}
+
+// WithChild replaces the child's kind and name.
+func (s *Scenario) WithChild(gvk schema.GroupVersionKind, name string) *Scenario {
+	for _, obj := range []*unstructured.Unstructured{s.child, s.oldChild} {
+		obj.SetGroupVersionKind(gvk)
+		obj.SetName(name)
+	}
+	return s
+}
+
+// InNamespace moves the parent and the child to namespace.
+func (s *Scenario) InNamespace(namespace string) *Scenario {
+	for _, obj := range []*unstructured.Unstructured{s.parent, s.child, s.oldChild} {
+		obj.SetNamespace(namespace)
+	}
+	return s
+}
+
+// StableParent makes the parent stable and initialized: its generation is
+// observed, so changes of the child by the controller are drift.
+func (s *Scenario) StableParent() *Scenario {
+	s.parent.Object["status"] = map[string]interface{}{"observedGeneration": s.parent.GetGeneration()}
+	return s.WithParentAnnotations(map[string]string{controller.PhaseAnnotation: controller.PhaseValueInitialized})
+}
+
+// ReconcilingParent makes the parent reconciling: its generation is not
+// observed yet, so changes of the child by the controller are expected.
+func (s *Scenario) ReconcilingParent() *Scenario {
+	s.parent.Object["status"] = map[string]interface{}{"observedGeneration": s.parent.GetGeneration() - 1}
+	return s
+}
+
+// DeletingParent marks the parent as being deleted.
+func (s *Scenario) DeletingParent() *Scenario {
+	s.parent.SetDeletionTimestamp(ptr.To(metav1.Now()))
+	s.parent.SetFinalizers([]string{"kausality.io/test"})
+	return s
+}
+
+// WithControllers sets the controllers annotation of the parent to hashes,
+// see controller.HashUsername.
+func (s *Scenario) WithControllers(hashes ...string) *Scenario {
+	return s.WithParentAnnotations(map[string]string{controller.ControllersAnnotation: strings.Join(hashes, ",")})
+}
+
+// WithUpdaters sets the updaters annotation of the child to hashes, see
+// controller.HashUsername. A single updater identifies the controller.
+func (s *Scenario) WithUpdaters(hashes ...string) *Scenario {
+	return s.WithChildAnnotations(map[string]string{controller.UpdatersAnnotation: strings.Join(hashes, ",")})
+}
+
+// WithParentAnnotations adds annotations to the parent.
+func (s *Scenario) WithParentAnnotations(annotations map[string]string) *Scenario {
+	addAnnotations(s.parent, annotations)
+	return s
+}
+
+// WithChildAnnotations adds annotations to the child, before and after the
+// request.
+func (s *Scenario) WithChildAnnotations(annotations map[string]string) *Scenario {
+	addAnnotations(s.child, annotations)
+	addAnnotations(s.oldChild, annotations)
+	return s
+}
+
+// ChildSpecChange sets the child's spec after the request.
+func (s *Scenario) ChildSpecChange(spec map[string]interface{}) *Scenario {
+	s.child.Object["spec"] = spec
+	return s
+}
+
+// ChildCreate makes the request a CREATE of the child with spec.
+func (s *Scenario) ChildCreate(spec map[string]interface{}) *Scenario {
+	s.op = admissionv1.Create
+	s.child.Object["spec"] = spec
+	return s
+}
+
+// ChildDelete makes the request a DELETE of the child.
+func (s *Scenario) ChildDelete() *Scenario {
+	s.op = admissionv1.Delete
+	return s
+}
+
+// Parent returns the parent.
+func (s *Scenario) Parent() *unstructured.Unstructured {
+	return s.parent
+}
+
+// Child returns the child after the request.
+func (s *Scenario) Child() *unstructured.Unstructured {
+	return s.child
+}
+
+// OldChild returns the child before the request.
+func (s *Scenario) OldChild() *unstructured.Unstructured {
+	return s.oldChild
+}
+
+// Client returns a fake client holding the parent and objs.
+func (s *Scenario) Client(objs ...client.Object) client.WithWatch {
+	return fake.NewClientBuilder().
+		WithScheme(runtime.NewScheme()).
+		WithObjects(append([]client.Object{s.parent.DeepCopy()}, objs...)...).
+		Build()
+}
+
+// Request returns the admission request of username mutating the child.
+func (s *Scenario) Request(username string) admission.Request {
+	gvk := s.child.GroupVersionKind()
+	req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		UID:       types.UID(fmt.Sprintf("%s-%s", s.op, s.child.GetName())),
+		Operation: s.op,
+		Kind:      metav1.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind},
+		Namespace: s.child.GetNamespace(),
+		Name:      s.child.GetName(),
+		UserInfo:  authenticationv1.UserInfo{Username: username, UID: username + "-uid"},
+	}}
+	switch s.op {
+	case admissionv1.Create:
+		req.Object = raw(s.child)
+	case admissionv1.Delete:
+		req.OldObject = raw(s.oldChild)
+	default:
+		req.Object = raw(s.child)
+		req.OldObject = raw(s.oldChild)
+	}
+	return req
+}
+
+// setOwner points the child's controller owner reference at the parent.
+func (s *Scenario) setOwner() {
+	if s.child == nil {
+		return
+	}
+	ref := OwnerReference(s.parent)
+	for _, obj := range []*unstructured.Unstructured{s.child, s.oldChild} {
+		obj.SetOwnerReferences([]metav1.OwnerReference{ref})
+		if s.parent.GetNamespace() != "" {
+			obj.SetNamespace(s.parent.GetNamespace())
+		}
+	}
+}
+
+func addAnnotations(obj *unstructured.Unstructured, annotations map[string]string) {
+	merged := obj.GetAnnotations()
+	if merged == nil {
+		merged = map[string]string{}
+	}
+	for k, v := range annotations {
+		merged[k] = v
+	}
+	obj.SetAnnotations(merged)
+}
+
+func raw(obj *unstructured.Unstructured) runtime.RawExtension {
+	data, err := json.Marshal(obj.Object)
+	if err != nil {
+		panic(err)
+	}
+	return runtime.RawExtension{Raw: data}
+}
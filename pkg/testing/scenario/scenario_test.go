@@ -0,0 +1,73 @@
+package scenario
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kausality-io/kausality/pkg/controller"
+)
+
+func TestParentChild(t *testing.T) {
+	hash := controller.HashUsername(ControllerUser)
+	s := NewParentChild().
+		InNamespace("team-a").
+		StableParent().
+		WithControllers(hash).
+		WithUpdaters(hash).
+		ChildSpecChange(map[string]interface{}{"replicas": int64(3)})
+
+	parent := s.Parent()
+	assert.Equal(t, "team-a", parent.GetNamespace())
+	assert.Equal(t, controller.PhaseValueInitialized, parent.GetAnnotations()[controller.PhaseAnnotation])
+	assert.Equal(t, hash, parent.GetAnnotations()[controller.ControllersAnnotation])
+	observed, _, _ := unstructured.NestedInt64(parent.Object, "status", "observedGeneration")
+	assert.Equal(t, parent.GetGeneration(), observed)
+
+	owner := s.Child().GetOwnerReferences()
+	require.Len(t, owner, 1)
+	assert.Equal(t, parent.GetUID(), owner[0].UID)
+	assert.True(t, *owner[0].Controller)
+
+	req := s.Request(ControllerUser)
+	assert.Equal(t, admissionv1.Update, req.Operation)
+	assert.Equal(t, "ReplicaSet", req.Kind.Kind)
+	assert.Equal(t, "team-a", req.Namespace)
+	assert.Equal(t, ControllerUser, req.UserInfo.Username)
+	var oldChild, child unstructured.Unstructured
+	require.NoError(t, oldChild.UnmarshalJSON(req.OldObject.Raw))
+	require.NoError(t, child.UnmarshalJSON(req.Object.Raw))
+	oldReplicas, _, _ := unstructured.NestedInt64(oldChild.Object, "spec", "replicas")
+	replicas, _, _ := unstructured.NestedInt64(child.Object, "spec", "replicas")
+	assert.Equal(t, int64(1), oldReplicas)
+	assert.Equal(t, int64(3), replicas)
+	assert.Equal(t, hash, oldChild.GetAnnotations()[controller.UpdatersAnnotation])
+
+	got := &unstructured.Unstructured{}
+	got.SetGroupVersionKind(DeploymentGVK)
+	require.NoError(t, s.Client().Get(context.Background(), client.ObjectKeyFromObject(parent), got))
+	assert.Equal(t, parent.GetUID(), got.GetUID())
+}
+
+func TestParentChild_Operations(t *testing.T) {
+	req := NewParentChild().ChildCreate(map[string]interface{}{"replicas": int64(1)}).Request("alice")
+	assert.Equal(t, admissionv1.Create, req.Operation)
+	assert.NotEmpty(t, req.Object.Raw)
+	assert.Empty(t, req.OldObject.Raw)
+
+	req = NewParentChild().ChildDelete().Request("alice")
+	assert.Equal(t, admissionv1.Delete, req.Operation)
+	assert.Empty(t, req.Object.Raw)
+	assert.NotEmpty(t, req.OldObject.Raw)
+
+	s := NewParentChild().ReconcilingParent().DeletingParent()
+	assert.NotNil(t, s.Parent().GetDeletionTimestamp())
+	observed, _, _ := unstructured.NestedInt64(s.Parent().Object, "status", "observedGeneration")
+	assert.Less(t, observed, s.Parent().GetGeneration())
+}
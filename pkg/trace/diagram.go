@@ -0,0 +1,117 @@
+package trace
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Diagram formats supported by RenderDiagram.
+const (
+	DiagramMermaid = "mermaid"
+	DiagramDot     = "dot"
+)
+
+// RenderDiagram renders the causal chain as a Mermaid flowchart or Graphviz digraph.
+// Siblings are other children written by the same parent reconcile; they are
+// drawn as dashed edges from the second-to-last hop (the parent of the traced object).
+// Siblings are ignored if the trace has fewer than two hops. Edges to hops
+// reached through a reference are labeled with the reference path.
+func RenderDiagram(format string, t Trace, siblings []Hop) (string, error) {
+	if len(t) == 0 {
+		return "", fmt.Errorf("trace is empty")
+	}
+	if len(t) < 2 {
+		siblings = nil
+	}
+
+	switch format {
+	case DiagramMermaid:
+		return renderMermaid(t, siblings), nil
+	case DiagramDot:
+		return renderDot(t, siblings), nil
+	default:
+		return "", fmt.Errorf("unknown diagram format %q: must be %q or %q", format, DiagramMermaid, DiagramDot)
+	}
+}
+
+func renderMermaid(t Trace, siblings []Hop) string {
+	var b strings.Builder
+	b.WriteString("flowchart TD\n")
+	for i, hop := range t {
+		fmt.Fprintf(&b, "    h%d[\"%s\"]\n", i, mermaidEscape(hopLabel(hop, "<br/>")))
+	}
+	for i := 1; i < len(t); i++ {
+		if ref := t[i].Reference; ref != "" {
+			fmt.Fprintf(&b, "    h%d -->|\"%s\"| h%d\n", i-1, mermaidEscape(referenceLabel(ref)), i)
+			continue
+		}
+		fmt.Fprintf(&b, "    h%d --> h%d\n", i-1, i)
+	}
+	parent := len(t) - 2
+	for i, hop := range siblings {
+		fmt.Fprintf(&b, "    s%d[\"%s\"]\n", i, mermaidEscape(hopLabel(hop, "<br/>")))
+		fmt.Fprintf(&b, "    h%d -.-> s%d\n", parent, i)
+	}
+	fmt.Fprintf(&b, "    style h%d stroke-width:3px\n", len(t)-1)
+	return b.String()
+}
+
+func renderDot(t Trace, siblings []Hop) string {
+	var b strings.Builder
+	b.WriteString("digraph trace {\n")
+	b.WriteString("    rankdir=TB;\n")
+	b.WriteString("    node [shape=box];\n")
+	for i, hop := range t {
+		attrs := ""
+		if i == len(t)-1 {
+			attrs = ", penwidth=3"
+		}
+		fmt.Fprintf(&b, "    h%d [label=\"%s\"%s];\n", i, dotEscape(hopLabel(hop, "\n")), attrs)
+	}
+	for i := 1; i < len(t); i++ {
+		if ref := t[i].Reference; ref != "" {
+			fmt.Fprintf(&b, "    h%d -> h%d [label=\"%s\"];\n", i-1, i, dotEscape(referenceLabel(ref)))
+			continue
+		}
+		fmt.Fprintf(&b, "    h%d -> h%d;\n", i-1, i)
+	}
+	parent := len(t) - 2
+	for i, hop := range siblings {
+		fmt.Fprintf(&b, "    s%d [label=\"%s\", style=dashed];\n", i, dotEscape(hopLabel(hop, "\n")))
+		fmt.Fprintf(&b, "    h%d -> s%d [style=dashed];\n", parent, i)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// hopLabel returns "Kind/name", "gen N", and the user, joined by sep.
+func hopLabel(hop Hop, sep string) string {
+	parts := []string{hop.Kind + "/" + hop.Name, fmt.Sprintf("gen %d", hop.Generation)}
+	if hop.User != "" {
+		parts = append(parts, hop.User)
+	}
+	if ticket := hop.Labels[TicketLabel]; ticket != "" {
+		parts = append(parts, "ticket "+ticket)
+	}
+	if hop.ScheduledAt != nil {
+		parts = append(parts, "scheduled "+hop.ScheduledAt.UTC().Format(time.RFC3339))
+	}
+	return strings.Join(parts, sep)
+}
+
+// referenceLabel labels edges to hops reached through a reference rather
+// than ownership.
+func referenceLabel(path string) string {
+	return "references via " + path
+}
+
+func mermaidEscape(s string) string {
+	return strings.ReplaceAll(s, `"`, "#quot;")
+}
+
+func dotEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return strings.ReplaceAll(s, "\n", `\n`)
+}
@@ -0,0 +1,106 @@
+package trace
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRenderDiagram(t *testing.T) {
+	tr := Trace{
+		{Kind: "Deployment", Name: "prod", Generation: 5, User: "hans@example.com", Labels: map[string]string{TicketLabel: "PROJ-1"}},
+		{Kind: "ReplicaSet", Name: "prod-abc", Generation: 1, User: "deployment-controller"},
+	}
+	siblings := []Hop{
+		{Kind: "ReplicaSet", Name: "prod-def", Generation: 2, User: "deployment-controller"},
+	}
+
+	tests := []struct {
+		name   string
+		format string
+		want   string
+	}{
+		{
+			name:   "mermaid",
+			format: DiagramMermaid,
+			want: `flowchart TD
+    h0["Deployment/prod<br/>gen 5<br/>hans@example.com<br/>ticket PROJ-1"]
+    h1["ReplicaSet/prod-abc<br/>gen 1<br/>deployment-controller"]
+    h0 --> h1
+    s0["ReplicaSet/prod-def<br/>gen 2<br/>deployment-controller"]
+    h0 -.-> s0
+    style h1 stroke-width:3px
+`,
+		},
+		{
+			name:   "dot",
+			format: DiagramDot,
+			want: `digraph trace {
+    rankdir=TB;
+    node [shape=box];
+    h0 [label="Deployment/prod\ngen 5\nhans@example.com\nticket PROJ-1"];
+    h1 [label="ReplicaSet/prod-abc\ngen 1\ndeployment-controller", penwidth=3];
+    h0 -> h1;
+    s0 [label="ReplicaSet/prod-def\ngen 2\ndeployment-controller", style=dashed];
+    h0 -> s0 [style=dashed];
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderDiagram(tt.format, tr, siblings)
+			require.NoError(t, err)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("RenderDiagram() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRenderDiagram_Errors(t *testing.T) {
+	_, err := RenderDiagram(DiagramMermaid, nil, nil)
+	assert.Error(t, err, "empty trace")
+
+	_, err = RenderDiagram("svg", Trace{{Kind: "Deployment", Name: "prod"}}, nil)
+	assert.Error(t, err, "unknown format")
+}
+
+func TestRenderDiagram_SingleHopIgnoresSiblings(t *testing.T) {
+	got, err := RenderDiagram(DiagramMermaid, Trace{{Kind: "Deployment", Name: "prod"}}, []Hop{{Kind: "ReplicaSet", Name: "other"}})
+	require.NoError(t, err)
+	assert.NotContains(t, got, "other")
+}
+
+func TestRenderDiagram_ReferenceEdges(t *testing.T) {
+	tr := Trace{
+		{Kind: "PostgresInstance", Name: "db", Generation: 3},
+		{Kind: "Secret", Name: "db-conn", Reference: ".spec.writeConnectionSecretToRef"},
+	}
+
+	got, err := RenderDiagram(DiagramMermaid, tr, nil)
+	require.NoError(t, err)
+	assert.Contains(t, got, `h0 -->|"references via .spec.writeConnectionSecretToRef"| h1`)
+
+	got, err = RenderDiagram(DiagramDot, tr, nil)
+	require.NoError(t, err)
+	assert.Contains(t, got, `h0 -> h1 [label="references via .spec.writeConnectionSecretToRef"];`)
+}
+
+func TestRenderDiagram_ScheduledJob(t *testing.T) {
+	scheduled := metav1.NewTime(time.Date(2026, 1, 24, 10, 0, 0, 0, time.UTC))
+	tr := Trace{
+		{Kind: "CronJob", Name: "backup", Generation: 2},
+		{Kind: "Job", Name: "backup-29485800", Generation: 1, ScheduledAt: &scheduled},
+	}
+
+	got, err := RenderDiagram(DiagramDot, tr, nil)
+	require.NoError(t, err)
+	assert.Contains(t, got, `h1 [label="Job/backup-29485800\ngen 1\nscheduled 2026-01-24T10:00:00Z", penwidth=3];`)
+}
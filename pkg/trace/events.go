@@ -0,0 +1,190 @@
+package trace
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultEventWindow is how recent Events must be to explain the origin
+	// change of a controller.
+	DefaultEventWindow = 5 * time.Minute
+
+	// DefaultEventTimeout bounds listing the Events of an origin change.
+	DefaultEventTimeout = time.Second
+
+	// EventInvolvedObjectUIDField is the field selector of the UID of the
+	// object an Event is about.
+	EventInvolvedObjectUIDField = "involvedObject.uid"
+
+	// maxEventMessage bounds the length of Event messages in hops.
+	maxEventMessage = 256
+)
+
+// EventEnricher attaches the most relevant recent Event to origin hops of
+// controllers, giving the reason of a change where the user identity only
+// tells which controller made it, e.g. the ScalingReplicaSet of a Deployment
+// or the FailedGetResourceMetric of a HorizontalPodAutoscaler.
+//
+// Events about the object and about its controller owner are considered.
+// Events reported by the acting controller rank first, then warnings, then
+// the most recent.
+type EventEnricher struct {
+	reader  client.Reader
+	window  time.Duration
+	timeout time.Duration
+	now     func() time.Time
+}
+
+// NewEventEnricher creates an EventEnricher listing Events with reader,
+// which must support the involvedObject.uid field selector, e.g. the
+// manager's API reader. Events older than window are ignored, and listing
+// is abandoned after timeout. Zero values mean DefaultEventWindow and
+// DefaultEventTimeout.
+func NewEventEnricher(reader client.Reader, window, timeout time.Duration) *EventEnricher {
+	if window == 0 {
+		window = DefaultEventWindow
+	}
+	if timeout == 0 {
+		timeout = DefaultEventTimeout
+	}
+	return &EventEnricher{reader: reader, window: window, timeout: timeout, now: time.Now}
+}
+
+// Enrich sets the Event of the origin hop of t if its user is a controller,
+// a service account or system user, and a recent Event explains the change
+// of obj. Traces extending a parent's trace are left unchanged.
+func (e *EventEnricher) Enrich(ctx context.Context, obj client.Object, t Trace) error {
+	if len(t) != 1 || !isControllerUser(t[0].User) {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	subjects := []eventSubject{{obj.GetObjectKind().GroupVersionKind().Kind, obj.GetName(), obj.GetUID()}}
+	if owner := metav1.GetControllerOf(obj); owner != nil {
+		subjects = append(subjects, eventSubject{owner.Kind, owner.Name, owner.UID})
+	}
+
+	var best *corev1.Event
+	bestKind, bestName := "", ""
+	for _, c := range subjects {
+		if c.uid == "" {
+			continue
+		}
+		events := &corev1.EventList{}
+		if err := e.reader.List(ctx, events, client.InNamespace(eventNamespace(obj)),
+			client.MatchingFields{EventInvolvedObjectUIDField: string(c.uid)}); err != nil {
+			return fmt.Errorf("failed to list events of %s %s: %w", c.kind, c.name, err)
+		}
+		for i := range events.Items {
+			ev := &events.Items[i]
+			if e.now().Sub(lastSeen(ev)) > e.window {
+				continue
+			}
+			if best == nil || outranks(ev, best, t[0].User) {
+				best, bestKind, bestName = ev, c.kind, c.name
+			}
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	t[0].Event = &EventRef{
+		Kind:                bestKind,
+		Name:                bestName,
+		Type:                best.Type,
+		Reason:              best.Reason,
+		Message:             truncate(best.Message, maxEventMessage),
+		ReportingController: reportingController(best),
+		LastSeen:            metav1.Time{Time: lastSeen(best)},
+	}
+	return nil
+}
+
+// eventSubject is an object whose Events may explain a change.
+type eventSubject struct {
+	kind, name string
+	uid        types.UID
+}
+
+// outranks returns whether a is more relevant than b to explain a change of
+// user.
+func outranks(a, b *corev1.Event, user string) bool {
+	if ra, rb := reportedBy(a, user), reportedBy(b, user); ra != rb {
+		return ra
+	}
+	if wa, wb := a.Type == corev1.EventTypeWarning, b.Type == corev1.EventTypeWarning; wa != wb {
+		return wa
+	}
+	return lastSeen(a).After(lastSeen(b))
+}
+
+// isControllerUser returns whether user is a service account or system
+// user, e.g. system:kube-controller-manager, rather than a human or CI.
+func isControllerUser(user string) bool {
+	return strings.HasPrefix(user, "system:")
+}
+
+// reportedBy returns whether the Event was reported by the controller of
+// user: the reporting component equals the name of its service account,
+// e.g. deployment-controller of
+// system:serviceaccount:kube-system:deployment-controller.
+func reportedBy(ev *corev1.Event, user string) bool {
+	component := reportingController(ev)
+	if component == "" {
+		return false
+	}
+	name := user[strings.LastIndex(user, ":")+1:]
+	return component == name || strings.HasSuffix(component, "/"+name)
+}
+
+// reportingController returns the component that reported the Event.
+func reportingController(ev *corev1.Event) string {
+	if ev.ReportingController != "" {
+		return ev.ReportingController
+	}
+	return ev.Source.Component
+}
+
+// lastSeen returns when the Event last occurred.
+func lastSeen(ev *corev1.Event) time.Time {
+	switch {
+	case ev.Series != nil && !ev.Series.LastObservedTime.IsZero():
+		return ev.Series.LastObservedTime.Time
+	case !ev.LastTimestamp.IsZero():
+		return ev.LastTimestamp.Time
+	case !ev.EventTime.IsZero():
+		return ev.EventTime.Time
+	default:
+		return ev.CreationTimestamp.Time
+	}
+}
+
+// eventNamespace returns the namespace of the Events of obj. Events of
+// cluster-scoped objects are recorded in the default namespace.
+func eventNamespace(obj client.Object) string {
+	if ns := obj.GetNamespace(); ns != "" {
+		return ns
+	}
+	return metav1.NamespaceDefault
+}
+
+// truncate shortens s to n bytes and an ellipsis, without splitting runes.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "…"
+}
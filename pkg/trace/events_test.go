@@ -0,0 +1,151 @@
+package trace
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// newTestEvent returns an Event about the object of uid, last seen age ago.
+func newTestEvent(name, uid, eventType, reason, component string, age time.Duration, now time.Time) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Namespace: "default", Name: name},
+		InvolvedObject: corev1.ObjectReference{UID: types.UID(uid)},
+		Type:           eventType,
+		Reason:         reason,
+		Message:        reason + " message",
+		Source:         corev1.EventSource{Component: component},
+		LastTimestamp:  metav1.Time{Time: now.Add(-age)},
+	}
+}
+
+// newEventTestObject returns a ReplicaSet owned by the Deployment "web".
+func newEventTestObject() *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("apps/v1")
+	obj.SetKind("ReplicaSet")
+	obj.SetNamespace("default")
+	obj.SetName("web-abc")
+	obj.SetUID("uid-rs")
+	obj.SetOwnerReferences([]metav1.OwnerReference{{
+		APIVersion: "apps/v1", Kind: "Deployment", Name: "web", UID: "uid-deploy", Controller: ptr.To(true),
+	}})
+	return obj
+}
+
+func TestEventEnricher_Enrich(t *testing.T) {
+	now := time.Now()
+	ctrl := "system:serviceaccount:kube-system:deployment-controller"
+
+	tests := []struct {
+		name       string
+		events     []*corev1.Event
+		user       string
+		trace      func(user string) Trace
+		wantReason string
+		wantKind   string
+	}{
+		{
+			name: "event of the parent reported by the actor",
+			events: []*corev1.Event{
+				newTestEvent("a", "uid-deploy", corev1.EventTypeNormal, "ScalingReplicaSet", "deployment-controller", time.Minute, now),
+				newTestEvent("b", "uid-rs", corev1.EventTypeNormal, "SuccessfulCreate", "replicaset-controller", 10*time.Second, now),
+			},
+			user:       ctrl,
+			wantReason: "ScalingReplicaSet",
+			wantKind:   "Deployment",
+		},
+		{
+			name: "warnings rank before normal events",
+			events: []*corev1.Event{
+				newTestEvent("a", "uid-deploy", corev1.EventTypeNormal, "ScalingReplicaSet", "other", 10*time.Second, now),
+				newTestEvent("b", "uid-rs", corev1.EventTypeWarning, "FailedCreate", "other", time.Minute, now),
+			},
+			user:       ctrl,
+			wantReason: "FailedCreate",
+			wantKind:   "ReplicaSet",
+		},
+		{
+			name: "most recent event",
+			events: []*corev1.Event{
+				newTestEvent("a", "uid-deploy", corev1.EventTypeNormal, "Older", "other", time.Minute, now),
+				newTestEvent("b", "uid-deploy", corev1.EventTypeNormal, "Newer", "other", 10*time.Second, now),
+			},
+			user:       ctrl,
+			wantReason: "Newer",
+			wantKind:   "Deployment",
+		},
+		{
+			name: "events outside the window are ignored",
+			events: []*corev1.Event{
+				newTestEvent("a", "uid-deploy", corev1.EventTypeWarning, "ScalingReplicaSet", "deployment-controller", time.Hour, now),
+			},
+			user: ctrl,
+		},
+		{
+			name: "human users are not enriched",
+			events: []*corev1.Event{
+				newTestEvent("a", "uid-deploy", corev1.EventTypeNormal, "ScalingReplicaSet", "deployment-controller", time.Minute, now),
+			},
+			user: "alice",
+		},
+		{
+			name: "traces extending a parent's trace are not enriched",
+			events: []*corev1.Event{
+				newTestEvent("a", "uid-deploy", corev1.EventTypeNormal, "ScalingReplicaSet", "deployment-controller", time.Minute, now),
+			},
+			user: ctrl,
+			trace: func(user string) Trace {
+				return Trace{NewHop("apps/v1", "Deployment", "web", 1, "alice", "req-0"), NewHop("apps/v1", "ReplicaSet", "web-abc", 1, user, "req-1")}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := fake.NewClientBuilder().WithIndex(&corev1.Event{}, EventInvolvedObjectUIDField, func(o client.Object) []string {
+				return []string{string(o.(*corev1.Event).InvolvedObject.UID)}
+			})
+			for _, ev := range tt.events {
+				builder = builder.WithObjects(ev)
+			}
+			e := NewEventEnricher(builder.Build(), 0, 0)
+			e.now = func() time.Time { return now }
+
+			tr := Trace{NewHop("apps/v1", "ReplicaSet", "web-abc", 1, tt.user, "req-1")}
+			if tt.trace != nil {
+				tr = tt.trace(tt.user)
+			}
+			require.NoError(t, e.Enrich(context.Background(), newEventTestObject(), tr))
+
+			if tt.wantReason == "" {
+				for _, hop := range tr {
+					assert.Nil(t, hop.Event)
+				}
+				return
+			}
+			require.NotNil(t, tr[0].Event)
+			assert.Equal(t, tt.wantReason, tr[0].Event.Reason)
+			assert.Equal(t, tt.wantKind, tr[0].Event.Kind)
+			assert.Equal(t, tt.wantReason+" message", tr[0].Event.Message)
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "short", truncate("short", 10))
+	assert.Equal(t, "abc…", truncate("abcdef", 3))
+	// "ä" is two bytes and not split
+	assert.Equal(t, "a…", truncate("aäb", 2))
+}
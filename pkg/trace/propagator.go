@@ -3,7 +3,9 @@ package trace
 import (
 	"context"
 	"fmt"
+	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
@@ -14,8 +16,12 @@ import (
 
 // Propagator handles trace creation and propagation.
 type Propagator struct {
-	client   client.Client
-	resolver *drift.ParentResolver
+	client     client.Client
+	resolver   *drift.ParentResolver
+	references ReferenceFinder
+	aggregated drift.AggregatedAPIs
+	edges      drift.ParentEdges
+	lifecycle  *drift.LifecycleRegistry
 }
 
 // NewPropagator creates a new Propagator.
@@ -26,6 +32,73 @@ func NewPropagator(c client.Client) *Propagator {
 	}
 }
 
+// PropagatorOption configures a Propagator.
+type PropagatorOption func(*Propagator)
+
+// WithReferenceFinder extends traces across references: mutations of objects
+// without controller owner extend the trace of a reconciling object
+// referencing them.
+func WithReferenceFinder(f ReferenceFinder) PropagatorOption {
+	return func(p *Propagator) {
+		p.references = f
+	}
+}
+
+// WithProfiles configures Deployment-like parent kinds in addition to
+// drift.DefaultProfiles. Changes by their controller while they progress
+// extend their trace.
+func WithProfiles(profiles ...drift.Profile) PropagatorOption {
+	return func(p *Propagator) {
+		p.resolver = drift.NewParentResolver(p.client, profiles...)
+		if p.aggregated != nil {
+			p.resolver.SetAggregatedAPIs(p.aggregated)
+		}
+		if p.edges != nil {
+			p.resolver.SetParentEdges(p.edges)
+		}
+		if p.lifecycle != nil {
+			p.resolver.SetLifecycleRegistry(p.lifecycle)
+		}
+	}
+}
+
+// WithAggregatedAPIs configures which parents are served by aggregated API
+// servers, see drift.ParentResolver.SetAggregatedAPIs.
+func WithAggregatedAPIs(a drift.AggregatedAPIs) PropagatorOption {
+	return func(p *Propagator) {
+		p.aggregated = a
+		p.resolver.SetAggregatedAPIs(a)
+	}
+}
+
+// WithParentEdges configures where to find the parents of objects without
+// controller owner reference, see drift.ParentResolver.SetParentEdges.
+func WithParentEdges(e drift.ParentEdges) PropagatorOption {
+	return func(p *Propagator) {
+		p.edges = e
+		p.resolver.SetParentEdges(e)
+	}
+}
+
+// WithLifecycleRegistry configures the lifecycle mappings of parent kinds,
+// see drift.ParentResolver.SetLifecycleRegistry. Changes by the controller
+// of a reconciling parent extend its trace.
+func WithLifecycleRegistry(l *drift.LifecycleRegistry) PropagatorOption {
+	return func(p *Propagator) {
+		p.lifecycle = l
+		p.resolver.SetLifecycleRegistry(l)
+	}
+}
+
+// NewPropagatorWithOptions creates a new Propagator with options.
+func NewPropagatorWithOptions(c client.Client, opts ...PropagatorOption) *Propagator {
+	p := NewPropagator(c)
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
 // PropagationResult contains the result of trace propagation.
 type PropagationResult struct {
 	// Trace is the trace to set on the object.
@@ -34,12 +107,25 @@ type PropagationResult struct {
 	IsOrigin bool
 	// ParentTrace is the parent's trace (nil if origin).
 	ParentTrace Trace
+	// NewHops is the number of hops at the end of Trace created by the
+	// propagation: the object's hop, and the parent's if it had no trace.
+	NewHops int
 }
 
 // Propagate determines the trace for a mutated object.
 // For origins (no parent, parent not reconciling, or different actor), creates a new trace.
 // For controller hops (controller reconciling parent), extends parent's trace.
+// Objects without controller owner are hops of a reconciling object referencing
+// them, if a ReferenceFinder is configured.
 func (p *Propagator) Propagate(ctx context.Context, obj client.Object, user string, childUpdaters []string, requestUID string) (*PropagationResult, error) {
+	return p.PropagateAt(ctx, obj, obj.GetGeneration(), user, childUpdaters, requestUID)
+}
+
+// PropagateAt is Propagate recording generation in the hop of obj. At
+// admission, the apiserver has not yet set the generation of the mutation, so
+// the webhook passes the generation obj is persisted with: the generation
+// children observe when extending the trace.
+func (p *Propagator) PropagateAt(ctx context.Context, obj client.Object, generation int64, user string, childUpdaters []string, requestUID string) (*PropagationResult, error) {
 	// Resolve parent state
 	parentState, err := p.resolver.ResolveParent(ctx, obj)
 	if err != nil {
@@ -49,6 +135,16 @@ func (p *Propagator) Propagate(ctx context.Context, obj client.Object, user stri
 	// Determine if this is an origin or a hop
 	isOrigin := p.isOrigin(parentState, user, childUpdaters)
 
+	// Without owner, the object may be a hop of an object referencing it
+	var referrer *Referrer
+	if parentState == nil && p.references != nil {
+		referrer, parentState, err = p.reconcilingReferrer(ctx, obj, user, childUpdaters)
+		if err != nil {
+			return nil, err
+		}
+		isOrigin = referrer == nil
+	}
+
 	// Get GVK info
 	gvk := obj.GetObjectKind().GroupVersionKind()
 	apiVersion := gvk.GroupVersion().String()
@@ -59,6 +155,7 @@ func (p *Propagator) Propagate(ctx context.Context, obj client.Object, user stri
 
 	result := &PropagationResult{
 		IsOrigin: isOrigin,
+		NewHops:  1,
 	}
 
 	// Extract trace labels from this object's annotations
@@ -66,16 +163,28 @@ func (p *Propagator) Propagate(ctx context.Context, obj client.Object, user stri
 
 	if isOrigin {
 		// Create new trace starting with this object
-		result.Trace = Trace{
-			NewHopWithLabels(apiVersion, gvk.Kind, obj.GetName(), obj.GetGeneration(), user, requestUID, labels),
-		}
+		hop := NewHopWithLabels(apiVersion, gvk.Kind, obj.GetName(), generation, user, requestUID, labels)
+		hop.ActorKind = ActorKindUser
+		result.Trace = Trace{hop}
 	} else {
 		// Get parent's trace
-		parentTrace, err := p.getParentTrace(ctx, parentState)
+		var parentTrace Trace
+		if referrer != nil {
+			parentTrace, err = GetTraceFromObject(referrer.Object)
+		} else {
+			parentTrace, err = p.getParentTrace(ctx, parentState)
+		}
 		if err != nil {
 			return nil, fmt.Errorf("failed to get parent trace: %w", err)
 		}
 
+		// A trace recorded for an older generation of the parent was not
+		// written by the change the parent reconciles, e.g. the webhook was
+		// bypassed. Its origin is not the origin of this hop.
+		if isStale(parentTrace, parentState) {
+			parentTrace = nil
+		}
+
 		// If parent has no trace, synthesize one from parentState
 		if len(parentTrace) == 0 && parentState != nil {
 			parentHop := NewHop(
@@ -87,22 +196,68 @@ func (p *Propagator) Propagate(ctx context.Context, obj client.Object, user stri
 				"", // requestUID unknown
 			)
 			parentTrace = Trace{parentHop}
+			result.NewHops++
 		}
 		result.ParentTrace = parentTrace
 
 		// Extend trace with new hop (each hop has its own labels, no inheritance)
-		hop := NewHopWithLabels(apiVersion, gvk.Kind, obj.GetName(), obj.GetGeneration(), user, requestUID, labels)
+		hop := NewHopWithLabels(apiVersion, gvk.Kind, obj.GetName(), generation, user, requestUID, labels)
+		hop.ActorKind = ActorKindController
+		if referrer != nil {
+			hop.Reference = referrer.Path
+		}
+		hop.ScheduledAt = scheduledAt(obj)
 		result.Trace = parentTrace.Append(hop)
 	}
 
 	return result, nil
 }
 
+// isStale returns whether the last hop of the parent's trace was recorded for
+// an older generation than the parent's current one. Hops without generation
+// are never stale.
+func isStale(parentTrace Trace, parentState *drift.ParentState) bool {
+	if len(parentTrace) == 0 || parentState == nil {
+		return false
+	}
+	last := parentTrace[len(parentTrace)-1]
+	return last.Generation != 0 && last.Generation < parentState.Generation
+}
+
+// scheduledAt returns the schedule time of a Job created by a CronJob, or nil.
+func scheduledAt(obj client.Object) *metav1.Time {
+	if gvk := obj.GetObjectKind().GroupVersionKind(); gvk.Group != "batch" || gvk.Kind != "Job" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, obj.GetAnnotations()[drift.CronJobScheduledTimestampAnnotation])
+	if err != nil {
+		return nil
+	}
+	return &metav1.Time{Time: t}
+}
+
+// reconcilingReferrer returns the first object referencing obj whose
+// reconciliation caused the mutation, i.e. for which the mutation is not an
+// origin, and its state. Returns nil if there is none.
+func (p *Propagator) reconcilingReferrer(ctx context.Context, obj client.Object, user string, childUpdaters []string) (*Referrer, *drift.ParentState, error) {
+	referrers, err := p.references.Referrers(ctx, obj)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find referrers: %w", err)
+	}
+	for i := range referrers {
+		state := drift.ParentStateFromObject(referrers[i].Object)
+		if !p.isOrigin(state, user, childUpdaters) {
+			return &referrers[i], state, nil
+		}
+	}
+	return nil, nil, nil
+}
+
 // isOrigin determines if this mutation starts a new trace.
 // Origin conditions:
 // - No controller ownerReference
 // - Request is from a different actor (not the controller)
-// - Parent has generation == observedGeneration (not reconciling)
+// - Parent has generation == observedGeneration (not reconciling) and is not progressing
 // - Parent has no observedGeneration and user is not confirmed as controller
 func (p *Propagator) isOrigin(parentState *drift.ParentState, username string, childUpdaters []string) bool {
 	if parentState == nil {
@@ -117,7 +272,7 @@ func (p *Propagator) isOrigin(parentState *drift.ParentState, username string, c
 
 	// If parent has observedGeneration, use it
 	if parentState.HasObservedGeneration {
-		if parentState.Generation == parentState.ObservedGeneration {
+		if parentState.Generation == parentState.ObservedGeneration && parentState.Progressing == "" {
 			return true // parent stable = origin
 		}
 		return false // parent reconciling, user is/might be controller = extend
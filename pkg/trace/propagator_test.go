@@ -1,9 +1,16 @@
 package trace
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	"github.com/kausality-io/kausality/pkg/controller"
 	"github.com/kausality-io/kausality/pkg/drift"
@@ -55,6 +62,19 @@ func TestPropagator_isOrigin(t *testing.T) {
 			childUpdaters: []string{controllerHash},
 			wantOrigin:    false,
 		},
+		{
+			name: "has obsGen + stable + progressing + is controller - extend",
+			parentState: &drift.ParentState{
+				HasObservedGeneration: true,
+				Generation:            5,
+				ObservedGeneration:    5,
+				Controllers:           []string{controllerHash},
+				Progressing:           ".status.phase is Paused",
+			},
+			username:      controllerUser,
+			childUpdaters: []string{controllerHash},
+			wantOrigin:    false,
+		},
 		{
 			name: "has obsGen + reconciling + different actor with parent controllers - origin",
 			parentState: &drift.ParentState{
@@ -120,3 +140,114 @@ func TestPropagator_isOrigin(t *testing.T) {
 		})
 	}
 }
+
+func TestPropagate_ScheduledJob(t *testing.T) {
+	cronJobUser := "system:serviceaccount:kube-system:cronjob-controller"
+	trueVal := true
+
+	cronJob := &unstructured.Unstructured{}
+	cronJob.SetAPIVersion("batch/v1")
+	cronJob.SetKind("CronJob")
+	cronJob.SetNamespace("default")
+	cronJob.SetName("backup")
+	cronJob.SetGeneration(2)
+	cronJob.SetAnnotations(map[string]string{
+		TraceAnnotation:                         Trace{{APIVersion: "batch/v1", Kind: "CronJob", Name: "backup", Generation: 2, User: "alice"}}.String(),
+		controller.ControllersAnnotation:        controller.HashUsername(cronJobUser),
+		controller.ObservedGenerationAnnotation: "2",
+	})
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		wantOrigin  bool
+	}{
+		{name: "created on schedule", annotations: map[string]string{drift.CronJobScheduledTimestampAnnotation: "2026-01-24T10:00:00Z"}},
+		{name: "without schedule time", wantOrigin: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &unstructured.Unstructured{}
+			job.SetAPIVersion("batch/v1")
+			job.SetKind("Job")
+			job.SetNamespace("default")
+			job.SetName("backup-29485800")
+			job.SetAnnotations(tt.annotations)
+			job.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "batch/v1", Kind: "CronJob", Name: "backup", Controller: &trueVal}})
+
+			p := NewPropagator(fake.NewClientBuilder().WithObjects(cronJob).Build())
+			result, err := p.Propagate(context.Background(), job, cronJobUser, nil, "req-1")
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantOrigin, result.IsOrigin)
+			if tt.wantOrigin {
+				assert.Nil(t, result.Trace[0].ScheduledAt)
+				assert.Equal(t, ActorKindUser, result.Trace[0].ActorKind)
+				return
+			}
+			require.Len(t, result.Trace, 2)
+			assert.Equal(t, "CronJob", result.Trace[0].Kind)
+			require.NotNil(t, result.Trace[1].ScheduledAt)
+			assert.Equal(t, time.Date(2026, 1, 24, 10, 0, 0, 0, time.UTC), result.Trace[1].ScheduledAt.UTC())
+		})
+	}
+}
+
+func TestPropagateAt_ParentTrace(t *testing.T) {
+	controllerUser := "system:serviceaccount:kube-system:deployment-controller"
+	trueVal := true
+
+	tests := []struct {
+		name          string
+		parentHopGen  int64
+		wantParentHop Hop
+		wantNewHops   int
+	}{
+		{
+			name:          "recorded at admission",
+			parentHopGen:  3,
+			wantParentHop: Hop{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Generation: 3, User: "alice"},
+			wantNewHops:   1,
+		},
+		{
+			name:          "stale",
+			parentHopGen:  2,
+			wantParentHop: Hop{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Generation: 3},
+			wantNewHops:   2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deploy := &unstructured.Unstructured{}
+			deploy.SetAPIVersion("apps/v1")
+			deploy.SetKind("Deployment")
+			deploy.SetNamespace("default")
+			deploy.SetName("web")
+			deploy.SetGeneration(3)
+			deploy.SetAnnotations(map[string]string{
+				TraceAnnotation:                  Trace{{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Generation: tt.parentHopGen, User: "alice"}}.String(),
+				controller.ControllersAnnotation: controller.HashUsername(controllerUser),
+			})
+			require.NoError(t, unstructured.SetNestedField(deploy.Object, int64(2), "status", "observedGeneration"))
+
+			rs := &unstructured.Unstructured{}
+			rs.SetAPIVersion("apps/v1")
+			rs.SetKind("ReplicaSet")
+			rs.SetNamespace("default")
+			rs.SetName("web-abc")
+			rs.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Controller: &trueVal}})
+
+			p := NewPropagator(fake.NewClientBuilder().WithObjects(deploy).Build())
+			result, err := p.PropagateAt(context.Background(), rs, 1, controllerUser, nil, "req-1")
+			require.NoError(t, err)
+			require.False(t, result.IsOrigin)
+			require.Len(t, result.Trace, 2)
+			assert.Equal(t, tt.wantNewHops, result.NewHops)
+
+			parentHop := result.Trace[0]
+			parentHop.Timestamp = metav1.Time{}
+			assert.Equal(t, tt.wantParentHop, parentHop)
+			assert.Equal(t, int64(1), result.Trace[1].Generation)
+			assert.Equal(t, ActorKindController, result.Trace[1].ActorKind)
+		})
+	}
+}
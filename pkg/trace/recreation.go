@@ -0,0 +1,178 @@
+package trace
+
+import (
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultRecreationWindow is how long a deleted object can be recreated by
+// its controller and continue its trace.
+const DefaultRecreationWindow = time.Minute
+
+// maxDeletions bounds the number of deleted objects remembered.
+const maxDeletions = 10000
+
+// templateHashLabels identify the template a controller created an object
+// from. Objects recreated from the same template, e.g. pods of a ReplicaSet
+// with generated names, replace each other.
+var templateHashLabels = []string{"pod-template-hash", "controller-revision-hash"}
+
+// RecreationIndex remembers recently deleted objects with controller owner,
+// so that an object recreated by the same controller owner continues the
+// trace of its predecessor instead of starting a new one.
+//
+// An object is recreated if it has the same kind, namespace and controller
+// owner as a deleted object, and the same template hash label or, without
+// one, the same name. Deleted objects are matched once, oldest first.
+type RecreationIndex struct {
+	mu      sync.Mutex
+	window  time.Duration
+	now     func() time.Time
+	deleted map[recreationKey][]deletion
+	count   int
+}
+
+// recreationKey identifies objects replacing each other.
+type recreationKey struct {
+	ownerUID   types.UID
+	apiVersion string
+	kind       string
+	namespace  string
+	// identity is the template hash or name of the object
+	identity string
+}
+
+// deletion is a deleted object and its trace.
+type deletion struct {
+	predecessor Predecessor
+	trace       Trace
+}
+
+// NewRecreationIndex creates a RecreationIndex matching recreations within
+// the window. Zero means DefaultRecreationWindow.
+func NewRecreationIndex(window time.Duration) *RecreationIndex {
+	if window == 0 {
+		window = DefaultRecreationWindow
+	}
+	return &RecreationIndex{
+		window:  window,
+		now:     time.Now,
+		deleted: make(map[recreationKey][]deletion),
+	}
+}
+
+// Deleted records the deletion of an object with its current trace. Objects
+// without controller owner are ignored.
+func (r *RecreationIndex) Deleted(obj client.Object) {
+	key, ok := recreationKeyOf(obj)
+	if !ok {
+		return
+	}
+	t, err := GetTraceFromObject(obj)
+	if err != nil {
+		t = nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	if r.count >= maxDeletions {
+		r.expire(now)
+	}
+	if r.count >= maxDeletions {
+		return
+	}
+	r.deleted[key] = append(r.deleted[key], deletion{
+		predecessor: Predecessor{Name: obj.GetName(), UID: obj.GetUID(), DeletedAt: metav1.NewTime(now)},
+		trace:       t,
+	})
+	r.count++
+}
+
+// Link continues the trace of a created object from its predecessor, if the
+// object recreates one deleted within the window. The created object's hop
+// records the predecessor. An origin trace is continued from the
+// predecessor's trace, replacing the predecessor's own hop, so that repeated
+// recreation does not grow the trace. Returns the predecessor, or nil if the
+// object is not a recreation.
+func (r *RecreationIndex) Link(obj client.Object, result *PropagationResult) *Predecessor {
+	key, ok := recreationKeyOf(obj)
+	if !ok || len(result.Trace) == 0 {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	var d *deletion
+	for len(r.deleted[key]) > 0 {
+		candidate := r.deleted[key][0]
+		r.deleted[key] = r.deleted[key][1:]
+		r.count--
+		if now.Sub(candidate.predecessor.DeletedAt.Time) <= r.window {
+			d = &candidate
+			break
+		}
+	}
+	if len(r.deleted[key]) == 0 {
+		delete(r.deleted, key)
+	}
+	if d == nil {
+		return nil
+	}
+
+	hop := result.Trace[len(result.Trace)-1]
+	hop.Predecessor = &d.predecessor
+	if result.IsOrigin && len(d.trace) > 0 {
+		result.Trace = d.trace[:len(d.trace)-1].Append(hop)
+	} else {
+		result.Trace = result.Trace[:len(result.Trace)-1].Append(hop)
+	}
+	return &d.predecessor
+}
+
+// expire forgets deletions older than the window. The caller must hold the lock.
+func (r *RecreationIndex) expire(now time.Time) {
+	for key, deletions := range r.deleted {
+		i := 0
+		for i < len(deletions) && now.Sub(deletions[i].predecessor.DeletedAt.Time) > r.window {
+			i++
+		}
+		r.count -= i
+		if i == len(deletions) {
+			delete(r.deleted, key)
+		} else {
+			r.deleted[key] = deletions[i:]
+		}
+	}
+}
+
+// recreationKeyOf returns the recreation key of an object, or false if it has
+// no controller owner or neither template hash nor name.
+func recreationKeyOf(obj client.Object) (recreationKey, bool) {
+	owner := metav1.GetControllerOf(obj)
+	if owner == nil {
+		return recreationKey{}, false
+	}
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	key := recreationKey{
+		ownerUID:   owner.UID,
+		apiVersion: gvk.GroupVersion().String(),
+		kind:       gvk.Kind,
+		namespace:  obj.GetNamespace(),
+		identity:   obj.GetName(),
+	}
+	for _, label := range templateHashLabels {
+		if hash := obj.GetLabels()[label]; hash != "" {
+			key.identity = label + "=" + hash
+			break
+		}
+	}
+	return key, key.identity != ""
+}
@@ -0,0 +1,141 @@
+package trace
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+)
+
+// newRecreationTestObject returns a ConfigMap owned by the Deployment "web".
+func newRecreationTestObject(name string, uid types.UID, labels map[string]string, trace Trace) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("ConfigMap")
+	obj.SetNamespace("default")
+	obj.SetName(name)
+	obj.SetUID(uid)
+	obj.SetLabels(labels)
+	obj.SetOwnerReferences([]metav1.OwnerReference{{
+		APIVersion: "apps/v1", Kind: "Deployment", Name: "web", UID: "web-uid", Controller: ptr.To(true),
+	}})
+	if trace != nil {
+		obj.SetAnnotations(map[string]string{TraceAnnotation: trace.String()})
+	}
+	return obj
+}
+
+func TestRecreationIndex_Link(t *testing.T) {
+	now := time.Now()
+	deploymentHop := NewHop("apps/v1", "Deployment", "web", 3, "alice", "req-1")
+	predecessorTrace := Trace{deploymentHop, NewHop("v1", "ConfigMap", "web-config", 1, "deployment-controller", "req-2")}
+	ctrl := "deployment-controller"
+
+	tests := []struct {
+		name            string
+		deleted         *unstructured.Unstructured
+		created         *unstructured.Unstructured
+		result          PropagationResult
+		after           time.Duration
+		wantPredecessor bool
+		wantTrace       []string
+	}{
+		{
+			name:            "same name, origin continues the predecessor's trace",
+			deleted:         newRecreationTestObject("web-config", "old-uid", nil, predecessorTrace),
+			created:         newRecreationTestObject("web-config", "", nil, nil),
+			result:          PropagationResult{IsOrigin: true, Trace: Trace{NewHop("v1", "ConfigMap", "web-config", 1, ctrl, "req-3")}},
+			wantPredecessor: true,
+			wantTrace:       []string{"alice", ctrl},
+		},
+		{
+			name:            "template hash with generated name",
+			deleted:         newRecreationTestObject("web-abc", "old-uid", map[string]string{"pod-template-hash": "abc"}, predecessorTrace),
+			created:         newRecreationTestObject("", "", map[string]string{"pod-template-hash": "abc"}, nil),
+			result:          PropagationResult{IsOrigin: true, Trace: Trace{NewHop("v1", "ConfigMap", "", 1, ctrl, "req-3")}},
+			wantPredecessor: true,
+			wantTrace:       []string{"alice", ctrl},
+		},
+		{
+			name:            "hop keeps the parent's trace",
+			deleted:         newRecreationTestObject("web-config", "old-uid", nil, predecessorTrace),
+			created:         newRecreationTestObject("web-config", "", nil, nil),
+			result:          PropagationResult{Trace: Trace{NewHop("apps/v1", "Deployment", "web", 4, "bob", "req-4"), NewHop("v1", "ConfigMap", "web-config", 1, ctrl, "req-5")}},
+			wantPredecessor: true,
+			wantTrace:       []string{"bob", ctrl},
+		},
+		{
+			name:      "other name",
+			deleted:   newRecreationTestObject("web-config", "old-uid", nil, predecessorTrace),
+			created:   newRecreationTestObject("web-other", "", nil, nil),
+			result:    PropagationResult{IsOrigin: true, Trace: Trace{NewHop("v1", "ConfigMap", "web-other", 1, ctrl, "req-3")}},
+			wantTrace: []string{ctrl},
+		},
+		{
+			name:      "outside the window",
+			deleted:   newRecreationTestObject("web-config", "old-uid", nil, predecessorTrace),
+			created:   newRecreationTestObject("web-config", "", nil, nil),
+			result:    PropagationResult{IsOrigin: true, Trace: Trace{NewHop("v1", "ConfigMap", "web-config", 1, ctrl, "req-3")}},
+			after:     2 * DefaultRecreationWindow,
+			wantTrace: []string{ctrl},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx := NewRecreationIndex(0)
+			idx.now = func() time.Time { return now }
+			idx.Deleted(tt.deleted)
+			idx.now = func() time.Time { return now.Add(tt.after) }
+
+			result := tt.result
+			predecessor := idx.Link(tt.created, &result)
+
+			var users []string
+			for _, hop := range result.Trace {
+				users = append(users, hop.User)
+			}
+			assert.Equal(t, tt.wantTrace, users)
+			last := result.Trace[len(result.Trace)-1]
+			if !tt.wantPredecessor {
+				assert.Nil(t, predecessor)
+				assert.Nil(t, last.Predecessor)
+				return
+			}
+			require.NotNil(t, predecessor)
+			assert.Equal(t, types.UID("old-uid"), predecessor.UID)
+			require.NotNil(t, last.Predecessor)
+			assert.Equal(t, tt.deleted.GetName(), last.Predecessor.Name)
+
+			// Each deletion is linked once
+			result = tt.result
+			assert.Nil(t, idx.Link(tt.created, &result))
+		})
+	}
+}
+
+func TestRecreationIndex_RepeatedRecreationDoesNotGrowTrace(t *testing.T) {
+	idx := NewRecreationIndex(0)
+	trace := Trace{NewHop("apps/v1", "Deployment", "web", 3, "alice", "req-1"), NewHop("v1", "ConfigMap", "web-config", 1, "ctrl", "req-2")}
+	for i := 0; i < 3; i++ {
+		idx.Deleted(newRecreationTestObject("web-config", types.UID("uid"), nil, trace))
+		result := PropagationResult{IsOrigin: true, Trace: Trace{NewHop("v1", "ConfigMap", "web-config", 1, "ctrl", "req")}}
+		require.NotNil(t, idx.Link(newRecreationTestObject("web-config", "", nil, nil), &result))
+		trace = result.Trace
+	}
+	assert.Len(t, trace, 2)
+	assert.Equal(t, "alice", trace.Origin().User)
+}
+
+func TestRecreationIndex_IgnoresUnowned(t *testing.T) {
+	idx := NewRecreationIndex(0)
+	obj := newRecreationTestObject("web-config", "old-uid", nil, nil)
+	obj.SetOwnerReferences(nil)
+	idx.Deleted(obj)
+	assert.Empty(t, idx.deleted)
+}
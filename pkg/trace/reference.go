@@ -0,0 +1,235 @@
+package trace
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ReferenceIndexField is the field index holding the Secrets and ConfigMaps
+// an object references, as "Kind/namespace/name".
+const ReferenceIndexField = "kausality.io/references"
+
+// ConnectionSecretPath is the reference of a Crossplane managed resource to
+// the Secret its provider writes connection details to.
+const ConnectionSecretPath = ".spec.writeConnectionSecretToRef"
+
+// ReferenceRule declares that objects of a kind reference Secrets or
+// ConfigMaps by name, e.g. the connection Secret of a Crossplane managed
+// resource or the credentials Secret of a provider config.
+type ReferenceRule struct {
+	// Referrer is the kind of the referencing objects.
+	Referrer schema.GroupVersionKind
+	// TargetKind is the kind of the referenced objects: "Secret" or "ConfigMap".
+	TargetKind string
+	// Paths are JSONPaths to references, e.g. ".spec.writeConnectionSecretToRef".
+	// A reference is a name in the referrer's namespace, or an object with
+	// name and optional namespace.
+	Paths []string
+	// Parent makes referrers the causal parents of the referenced objects for
+	// drift detection: mutations by other actors than the referrer's
+	// controller are drift.
+	Parent bool
+}
+
+// ConnectionSecretRule returns the rule for the connection Secrets of a
+// Crossplane managed resource kind. The managed resource is their parent.
+func ConnectionSecretRule(managed schema.GroupVersionKind) ReferenceRule {
+	return ReferenceRule{Referrer: managed, TargetKind: "Secret", Paths: []string{ConnectionSecretPath}, Parent: true}
+}
+
+// Referrer is an object referencing a mutated object.
+type Referrer struct {
+	// Object is the referencing object.
+	Object *unstructured.Unstructured
+	// Path is the JSONPath of the reference.
+	Path string
+	// Parent is true if the reference makes Object the causal parent.
+	Parent bool
+}
+
+// ReferenceFinder finds the objects referencing an object.
+type ReferenceFinder interface {
+	// Referrers returns the objects referencing obj, sorted by kind, namespace and name.
+	Referrers(ctx context.Context, obj client.Object) ([]Referrer, error)
+}
+
+// ReferenceIndex finds referencing objects through field indexes on a cache.
+type ReferenceIndex struct {
+	reader client.Reader
+	// rules by referrer, in configuration order
+	rules     map[schema.GroupVersionKind][]ReferenceRule
+	referrers []schema.GroupVersionKind
+}
+
+// NewReferenceIndex creates a ReferenceIndex listing referrers from reader,
+// which must support ReferenceIndexField (see RegisterIndexes).
+func NewReferenceIndex(reader client.Reader, rules []ReferenceRule) (*ReferenceIndex, error) {
+	idx := &ReferenceIndex{
+		reader: reader,
+		rules:  make(map[schema.GroupVersionKind][]ReferenceRule),
+	}
+	for i, rule := range rules {
+		if rule.TargetKind != "Secret" && rule.TargetKind != "ConfigMap" {
+			return nil, fmt.Errorf("reference rule %d: invalid target kind %q: must be Secret or ConfigMap", i, rule.TargetKind)
+		}
+		for _, path := range rule.Paths {
+			if _, err := parseReferencePath(path); err != nil {
+				return nil, fmt.Errorf("reference rule %d: invalid path %q: %w", i, path, err)
+			}
+		}
+		if _, ok := idx.rules[rule.Referrer]; !ok {
+			idx.referrers = append(idx.referrers, rule.Referrer)
+		}
+		idx.rules[rule.Referrer] = append(idx.rules[rule.Referrer], rule)
+	}
+	return idx, nil
+}
+
+// RegisterIndexes registers ReferenceIndexField for all referrer kinds.
+// It must be called before the cache is started.
+func (i *ReferenceIndex) RegisterIndexes(ctx context.Context, indexer client.FieldIndexer) error {
+	for _, gvk := range i.referrers {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gvk)
+		rules := i.rules[gvk]
+		if err := indexer.IndexField(ctx, obj, ReferenceIndexField, func(obj client.Object) []string {
+			u, ok := obj.(*unstructured.Unstructured)
+			if !ok {
+				return nil
+			}
+			var keys []string
+			for _, rule := range rules {
+				for _, path := range rule.Paths {
+					keys = append(keys, references(u, rule.TargetKind, path)...)
+				}
+			}
+			return keys
+		}); err != nil {
+			return fmt.Errorf("failed to index references of %s: %w", gvk, err)
+		}
+	}
+	return nil
+}
+
+// Referrers returns the objects referencing obj, a Secret or ConfigMap.
+func (i *ReferenceIndex) Referrers(ctx context.Context, obj client.Object) ([]Referrer, error) {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	if gvk.Group != "" || (gvk.Kind != "Secret" && gvk.Kind != "ConfigMap") {
+		return nil, nil
+	}
+	key := referenceKey(gvk.Kind, obj.GetNamespace(), obj.GetName())
+
+	var referrers []Referrer
+	for _, referrerGVK := range i.referrers {
+		var paths []string
+		parents := make(map[string]bool)
+		for _, rule := range i.rules[referrerGVK] {
+			if rule.TargetKind != gvk.Kind {
+				continue
+			}
+			paths = append(paths, rule.Paths...)
+			for _, path := range rule.Paths {
+				parents[path] = parents[path] || rule.Parent
+			}
+		}
+		if len(paths) == 0 {
+			continue
+		}
+
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(referrerGVK.GroupVersion().WithKind(referrerGVK.Kind + "List"))
+		if err := i.reader.List(ctx, list, client.MatchingFields{ReferenceIndexField: key}); err != nil {
+			return nil, fmt.Errorf("failed to list %s referencing %s: %w", referrerGVK.Kind, key, err)
+		}
+		sort.Slice(list.Items, func(a, b int) bool {
+			if list.Items[a].GetNamespace() != list.Items[b].GetNamespace() {
+				return list.Items[a].GetNamespace() < list.Items[b].GetNamespace()
+			}
+			return list.Items[a].GetName() < list.Items[b].GetName()
+		})
+		for j := range list.Items {
+			item := &list.Items[j]
+			// The index does not record the path, so find the first one matching
+			for _, path := range paths {
+				if slices.Contains(references(item, gvk.Kind, path), key) {
+					referrers = append(referrers, Referrer{Object: item, Path: path, Parent: parents[path]})
+					break
+				}
+			}
+		}
+	}
+	return referrers, nil
+}
+
+// ReferenceParent returns the first referrer of obj that is its causal parent,
+// or nil if there is none.
+func (i *ReferenceIndex) ReferenceParent(ctx context.Context, obj client.Object) (*unstructured.Unstructured, error) {
+	referrers, err := i.Referrers(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range referrers {
+		if r.Parent {
+			return r.Object, nil
+		}
+	}
+	return nil, nil
+}
+
+// references returns the keys of the objects of targetKind referenced at path.
+func references(obj *unstructured.Unstructured, targetKind, path string) []string {
+	jp, err := parseReferencePath(path)
+	if err != nil {
+		return nil
+	}
+	results, err := jp.FindResults(obj.Object)
+	if err != nil {
+		return nil
+	}
+
+	var keys []string
+	for _, values := range results {
+		for _, value := range values {
+			namespace, name := obj.GetNamespace(), ""
+			switch ref := value.Interface().(type) {
+			case string:
+				name = ref
+			case map[string]interface{}:
+				name, _ = ref["name"].(string)
+				if ns, _ := ref["namespace"].(string); ns != "" {
+					namespace = ns
+				}
+			}
+			// Secrets and ConfigMaps are namespaced
+			if name == "" || namespace == "" {
+				continue
+			}
+			keys = append(keys, referenceKey(targetKind, namespace, name))
+		}
+	}
+	return keys
+}
+
+// parseReferencePath parses a JSONPath, with or without surrounding braces.
+func parseReferencePath(path string) (*jsonpath.JSONPath, error) {
+	if !strings.HasPrefix(path, "{") {
+		path = "{" + path + "}"
+	}
+	jp := jsonpath.New("reference").AllowMissingKeys(true)
+	if err := jp.Parse(path); err != nil {
+		return nil, err
+	}
+	return jp, nil
+}
+
+func referenceKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
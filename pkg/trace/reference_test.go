@@ -0,0 +1,198 @@
+package trace
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kausality-io/kausality/pkg/controller"
+)
+
+var (
+	instanceGVK = schema.GroupVersionKind{Group: "database.example.org", Version: "v1", Kind: "PostgresInstance"}
+	secretGVK   = schema.GroupVersionKind{Version: "v1", Kind: "Secret"}
+)
+
+// fakeIndexer registers field indexes on a fake client builder.
+type fakeIndexer struct {
+	builder *fake.ClientBuilder
+}
+
+func (f *fakeIndexer) IndexField(_ context.Context, obj client.Object, field string, fn client.IndexerFunc) error {
+	f.builder = f.builder.WithIndex(obj, field, fn)
+	return nil
+}
+
+func newInstance(namespace, name string, generation, observedGeneration int64, spec map[string]interface{}, annotations map[string]string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	obj.SetGroupVersionKind(instanceGVK)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	obj.SetGeneration(generation)
+	obj.SetAnnotations(annotations)
+	_ = unstructured.SetNestedField(obj.Object, observedGeneration, "status", "observedGeneration")
+	return obj
+}
+
+func newSecret(namespace, name string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(secretGVK)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+func newReferenceIndex(t *testing.T, objs ...client.Object) (*ReferenceIndex, client.Client) {
+	t.Helper()
+	idx, err := NewReferenceIndex(nil, []ReferenceRule{
+		{Referrer: instanceGVK, TargetKind: "Secret", Paths: []string{".spec.writeConnectionSecretToRef", ".spec.credentials[*].secretName"}},
+		{Referrer: instanceGVK, TargetKind: "ConfigMap", Paths: []string{"{.spec.configMapName}"}},
+	})
+	require.NoError(t, err)
+
+	indexer := &fakeIndexer{builder: fake.NewClientBuilder().WithScheme(runtime.NewScheme())}
+	require.NoError(t, idx.RegisterIndexes(context.Background(), indexer))
+	c := indexer.builder.WithObjects(objs...).Build()
+	idx.reader = c
+	return idx, c
+}
+
+func TestReferenceIndex_Referrers(t *testing.T) {
+	conn := newInstance("default", "db", 1, 1, map[string]interface{}{
+		"writeConnectionSecretToRef": map[string]interface{}{"name": "db-conn", "namespace": "crossplane-system"},
+	}, nil)
+	creds := newInstance("default", "other", 1, 1, map[string]interface{}{
+		"credentials": []interface{}{
+			map[string]interface{}{"secretName": "unrelated"},
+			map[string]interface{}{"secretName": "db-conn"},
+		},
+	}, nil)
+	sameNamespace := newInstance("crossplane-system", "local", 1, 1, map[string]interface{}{
+		"credentials":   []interface{}{map[string]interface{}{"secretName": "db-conn"}},
+		"configMapName": "db-conn",
+	}, nil)
+	idx, _ := newReferenceIndex(t, conn, creds, sameNamespace)
+	ctx := context.Background()
+
+	referrers, err := idx.Referrers(ctx, newSecret("crossplane-system", "db-conn"))
+	require.NoError(t, err)
+	var got []string
+	for _, r := range referrers {
+		got = append(got, r.Object.GetNamespace()+"/"+r.Object.GetName()+" "+r.Path)
+	}
+	assert.Equal(t, []string{
+		"crossplane-system/local .spec.credentials[*].secretName",
+		"default/db .spec.writeConnectionSecretToRef",
+	}, got)
+
+	referrers, err = idx.Referrers(ctx, newSecret("default", "db-conn"))
+	require.NoError(t, err)
+	require.Len(t, referrers, 1)
+	assert.Equal(t, "other", referrers[0].Object.GetName())
+
+	// Kinds are indexed separately
+	cm := &unstructured.Unstructured{}
+	cm.SetGroupVersionKind(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"})
+	cm.SetNamespace("crossplane-system")
+	cm.SetName("db-conn")
+	referrers, err = idx.Referrers(ctx, cm)
+	require.NoError(t, err)
+	require.Len(t, referrers, 1)
+	assert.Equal(t, "{.spec.configMapName}", referrers[0].Path)
+
+	// Other kinds are never referenced
+	referrers, err = idx.Referrers(ctx, conn)
+	require.NoError(t, err)
+	assert.Empty(t, referrers)
+}
+
+func TestNewReferenceIndex_Invalid(t *testing.T) {
+	_, err := NewReferenceIndex(nil, []ReferenceRule{{Referrer: instanceGVK, TargetKind: "Pod", Paths: []string{".spec.podName"}}})
+	assert.Error(t, err)
+
+	_, err = NewReferenceIndex(nil, []ReferenceRule{{Referrer: instanceGVK, TargetKind: "Secret", Paths: []string{".spec[unterminated"}}})
+	assert.Error(t, err)
+}
+
+func TestPropagate_AcrossReference(t *testing.T) {
+	providerUser := "system:serviceaccount:crossplane-system:provider-sql"
+	providerHash := controller.HashUsername(providerUser)
+	parentTrace := Trace{{APIVersion: "database.example.org/v1", Kind: "PostgresInstance", Name: "db", Generation: 2, User: "alice"}}
+	spec := map[string]interface{}{"writeConnectionSecretToRef": map[string]interface{}{"name": "db-conn"}}
+
+	tests := []struct {
+		name          string
+		generation    int64
+		user          string
+		wantOrigin    bool
+		wantReference string
+	}{
+		{name: "referrer reconciling", generation: 2, user: providerUser, wantReference: ".spec.writeConnectionSecretToRef"},
+		{name: "referrer stable", generation: 1, user: providerUser, wantOrigin: true},
+		{name: "other actor", generation: 2, user: "bob", wantOrigin: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instance := newInstance("default", "db", tt.generation, 1, spec, map[string]string{
+				TraceAnnotation:                  parentTrace.String(),
+				controller.ControllersAnnotation: providerHash,
+			})
+			idx, c := newReferenceIndex(t, instance)
+			p := NewPropagatorWithOptions(c, WithReferenceFinder(idx))
+
+			result, err := p.Propagate(context.Background(), newSecret("default", "db-conn"), tt.user, nil, "req-1")
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantOrigin, result.IsOrigin)
+			if tt.wantOrigin {
+				require.Len(t, result.Trace, 1)
+				return
+			}
+			require.Len(t, result.Trace, 2)
+			assert.Equal(t, "PostgresInstance", result.Trace[0].Kind)
+			assert.Equal(t, "Secret", result.Trace[1].Kind)
+			assert.Equal(t, tt.wantReference, result.Trace[1].Reference)
+		})
+	}
+}
+
+func TestReferenceIndex_ReferenceParent(t *testing.T) {
+	managedGVK := schema.GroupVersionKind{Group: "rds.aws.crossplane.io", Version: "v1beta1", Kind: "DBInstance"}
+	managed := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"writeConnectionSecretToRef": map[string]interface{}{"name": "db-conn", "namespace": "crossplane-system"},
+		},
+	}}
+	managed.SetGroupVersionKind(managedGVK)
+	managed.SetName("db")
+	// Referrers without parent rule do not make a parent
+	referrer := newInstance("crossplane-system", "other", 1, 1, map[string]interface{}{
+		"credentials": []interface{}{map[string]interface{}{"secretName": "db-conn"}},
+	}, nil)
+
+	idx, err := NewReferenceIndex(nil, []ReferenceRule{
+		{Referrer: instanceGVK, TargetKind: "Secret", Paths: []string{".spec.credentials[*].secretName"}},
+		ConnectionSecretRule(managedGVK),
+	})
+	require.NoError(t, err)
+	indexer := &fakeIndexer{builder: fake.NewClientBuilder().WithScheme(runtime.NewScheme())}
+	require.NoError(t, idx.RegisterIndexes(context.Background(), indexer))
+	idx.reader = indexer.builder.WithObjects(managed, referrer).Build()
+
+	parent, err := idx.ReferenceParent(context.Background(), newSecret("crossplane-system", "db-conn"))
+	require.NoError(t, err)
+	require.NotNil(t, parent)
+	assert.Equal(t, "DBInstance", parent.GetKind())
+	assert.Equal(t, "db", parent.GetName())
+
+	parent, err = idx.ReferenceParent(context.Background(), newSecret("default", "db-conn"))
+	require.NoError(t, err)
+	assert.Nil(t, parent)
+}
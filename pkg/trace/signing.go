@@ -0,0 +1,301 @@
+package trace
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Signature algorithms of hops.
+const (
+	SignatureHMAC    = "hmac-sha256"
+	SignatureECDSA   = "ecdsa-sha256"
+	SignatureEd25519 = "ed25519"
+)
+
+// Signer signs the hops the webhook appends to traces, so that traces forged
+// or altered by anyone with update rights on an object can be told apart.
+// Each signature covers the hop and the signature of the previous hop, so
+// removing, reordering or inserting hops breaks the chain. The hop of the
+// object itself also carries the object's namespace and UID, so that a
+// signed trace copied to another object does not verify.
+type Signer struct {
+	algorithm string
+	sign      func(data []byte) ([]byte, error)
+}
+
+// NewHMACSigner returns a Signer using HMAC-SHA256 with a key shared with
+// the verifiers.
+func NewHMACSigner(key []byte) *Signer {
+	return &Signer{algorithm: SignatureHMAC, sign: func(data []byte) ([]byte, error) {
+		return hmacSum(key, data), nil
+	}}
+}
+
+// NewSigner returns a Signer using an ECDSA or Ed25519 private key. Verifiers
+// only need its public key.
+func NewSigner(key crypto.Signer) (*Signer, error) {
+	switch key := key.(type) {
+	case *ecdsa.PrivateKey:
+		return &Signer{algorithm: SignatureECDSA, sign: func(data []byte) ([]byte, error) {
+			digest := sha256.Sum256(data)
+			return ecdsa.SignASN1(rand.Reader, key, digest[:])
+		}}, nil
+	case ed25519.PrivateKey:
+		return &Signer{algorithm: SignatureEd25519, sign: func(data []byte) ([]byte, error) {
+			return ed25519.Sign(key, data), nil
+		}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported signing key %T: must be ECDSA or Ed25519", key)
+	}
+}
+
+// LoadSigner reads a signing key from a file: a PEM-encoded, unencrypted
+// ECDSA or Ed25519 private key, or else an HMAC key.
+func LoadSigner(path string) (*Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+	if block, _ := pem.Decode(data); block != nil {
+		key, err := parsePrivateKey(block)
+		if err != nil {
+			return nil, err
+		}
+		return NewSigner(key)
+	}
+	key, err := hmacKey(data)
+	if err != nil {
+		return nil, err
+	}
+	return NewHMACSigner(key), nil
+}
+
+// Subject identifies the object a trace belongs to, whose hop is the final
+// hop of the trace.
+type Subject struct {
+	Kind      string
+	Namespace string
+	Name      string
+	// UID is empty for objects being created.
+	UID types.UID
+	// Generation is the generation the object is persisted with.
+	Generation int64
+}
+
+// Sign signs the last n hops of t in place, e.g. the hops appended by a
+// propagation, see PropagationResult.NewHops. The final hop is bound to the
+// namespace and UID of subject.
+func (s *Signer) Sign(t Trace, n int, subject Subject) error {
+	if len(t) > 0 && n > 0 {
+		t[len(t)-1].Namespace = subject.Namespace
+		t[len(t)-1].UID = subject.UID
+	}
+	for i := max(len(t)-n, 0); i < len(t); i++ {
+		sig, err := s.sign(signedData(t, i))
+		if err != nil {
+			return fmt.Errorf("failed to sign hop %d: %w", i, err)
+		}
+		t[i].Signature = s.algorithm + ":" + base64.StdEncoding.EncodeToString(sig)
+	}
+	return nil
+}
+
+// Verifier verifies the hop signatures of traces.
+type Verifier struct {
+	algorithm string
+	verify    func(data, sig []byte) bool
+}
+
+// NewHMACVerifier returns a Verifier of HMAC-SHA256 signatures.
+func NewHMACVerifier(key []byte) *Verifier {
+	return &Verifier{algorithm: SignatureHMAC, verify: func(data, sig []byte) bool {
+		return hmac.Equal(sig, hmacSum(key, data))
+	}}
+}
+
+// NewVerifier returns a Verifier of signatures by the private key of an
+// ECDSA or Ed25519 public key.
+func NewVerifier(key crypto.PublicKey) (*Verifier, error) {
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		return &Verifier{algorithm: SignatureECDSA, verify: func(data, sig []byte) bool {
+			digest := sha256.Sum256(data)
+			return ecdsa.VerifyASN1(key, digest[:], sig)
+		}}, nil
+	case ed25519.PublicKey:
+		return &Verifier{algorithm: SignatureEd25519, verify: func(data, sig []byte) bool {
+			return ed25519.Verify(key, data, sig)
+		}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported verification key %T: must be ECDSA or Ed25519", key)
+	}
+}
+
+// LoadVerifier reads a verification key from a file: a PEM-encoded ECDSA or
+// Ed25519 public key (e.g. cosign.pub) or private key, or else an HMAC key.
+func LoadVerifier(path string) (*Verifier, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read verification key: %w", err)
+	}
+	if block, _ := pem.Decode(data); block != nil {
+		if block.Type == "PUBLIC KEY" {
+			key, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse public key: %w", err)
+			}
+			return NewVerifier(key)
+		}
+		key, err := parsePrivateKey(block)
+		if err != nil {
+			return nil, err
+		}
+		return NewVerifier(key.Public())
+	}
+	key, err := hmacKey(data)
+	if err != nil {
+		return nil, err
+	}
+	return NewHMACVerifier(key), nil
+}
+
+// HopSignature is the verification result of a hop's signature.
+type HopSignature string
+
+const (
+	// HopSignatureValid is a signature by the verifier's key.
+	HopSignatureValid HopSignature = "valid"
+	// HopSignatureInvalid is a signature by another key or algorithm, or of
+	// a hop or chain that was altered.
+	HopSignatureInvalid HopSignature = "invalid"
+	// HopSignatureMissing is a hop without signature, e.g. written before
+	// signing was enabled or by someone other than the webhook.
+	HopSignatureMissing HopSignature = "missing"
+)
+
+// Verification is the verification result of a trace.
+type Verification struct {
+	// Valid is true if all hops have valid signatures and the final hop is
+	// the hop of the object.
+	Valid bool `json:"valid"`
+	// Hops are the results of the hops, origin first.
+	Hops []HopSignature `json:"hops"`
+	// Mismatch tells why the final hop is not the hop of the object, e.g.
+	// because the trace was copied from another object.
+	Mismatch string `json:"mismatch,omitempty"`
+}
+
+// Verify verifies the signatures of all hops of t, and that its final hop
+// is the hop of subject: its kind, namespace, name and generation, and its
+// UID unless the hop created the object.
+func (v *Verifier) Verify(t Trace, subject Subject) Verification {
+	result := Verification{Valid: len(t) > 0, Hops: make([]HopSignature, len(t))}
+	for i := range t {
+		result.Hops[i] = v.verifyHop(t, i)
+		if result.Hops[i] != HopSignatureValid {
+			result.Valid = false
+		}
+	}
+	if len(t) > 0 {
+		result.Mismatch = mismatch(t[len(t)-1], subject)
+		if result.Mismatch != "" {
+			result.Valid = false
+		}
+	}
+	return result
+}
+
+// mismatch returns why hop is not the hop of subject, or "".
+func mismatch(hop Hop, subject Subject) string {
+	switch {
+	case hop.Kind != subject.Kind || hop.Name != subject.Name:
+		return fmt.Sprintf("final hop is %s %s, not %s %s", hop.Kind, hop.Name, subject.Kind, subject.Name)
+	case hop.Namespace != subject.Namespace:
+		return fmt.Sprintf("final hop is in namespace %q, not %q", hop.Namespace, subject.Namespace)
+	case hop.Generation != subject.Generation:
+		return fmt.Sprintf("final hop is of generation %d, not %d", hop.Generation, subject.Generation)
+	case hop.UID != subject.UID && (hop.UID != "" || hop.Operation != "CREATE"):
+		return fmt.Sprintf("final hop is of UID %q, not %q", hop.UID, subject.UID)
+	}
+	return ""
+}
+
+func (v *Verifier) verifyHop(t Trace, i int) HopSignature {
+	if t[i].Signature == "" {
+		return HopSignatureMissing
+	}
+	algorithm, encoded, ok := strings.Cut(t[i].Signature, ":")
+	if !ok || algorithm != v.algorithm {
+		return HopSignatureInvalid
+	}
+	sig, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || !v.verify(signedData(t, i), sig) {
+		return HopSignatureInvalid
+	}
+	return HopSignatureValid
+}
+
+// signedData returns the data signed for hop i: the signature of the
+// previous hop and the JSON of the hop without signature.
+func signedData(t Trace, i int) []byte {
+	var prev string
+	if i > 0 {
+		prev = t[i-1].Signature
+	}
+	hop := t[i]
+	hop.Signature = ""
+	// Hops only hold strings, numbers, times and string maps, which always marshal
+	data, _ := json.Marshal(hop)
+	return append([]byte(prev+"\n"), data...)
+}
+
+func hmacSum(key, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// hmacKey returns the HMAC key of a key file, without trailing newline.
+func hmacKey(data []byte) ([]byte, error) {
+	key := []byte(strings.TrimSpace(string(data)))
+	if len(key) == 0 {
+		return nil, fmt.Errorf("signing key is empty")
+	}
+	return key, nil
+}
+
+func parsePrivateKey(block *pem.Block) (crypto.Signer, error) {
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key: %w", err)
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key %T", key)
+		}
+		return signer, nil
+	case "EC PRIVATE KEY":
+		key, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key: %w", err)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q: must be an unencrypted private key", block.Type)
+	}
+}
@@ -0,0 +1,216 @@
+package trace
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// replicaSet is the subject of signedTrace.
+var replicaSet = Subject{Kind: "ReplicaSet", Namespace: "default", Name: "web-abc", UID: "rs-uid", Generation: 1}
+
+func signedTrace(t *testing.T, signer *Signer) Trace {
+	t.Helper()
+	tr := Trace{NewHop("apps/v1", "Deployment", "web", 2, "alice", "uid-1")}
+	require.NoError(t, signer.Sign(tr, 1, Subject{Namespace: "default", UID: "deploy-uid"}))
+	tr = tr.Append(NewHop("apps/v1", "ReplicaSet", "web-abc", 1, "deployment-controller", "uid-2"))
+	require.NoError(t, signer.Sign(tr, 1, replicaSet))
+	return tr
+}
+
+func TestSigner_HMAC(t *testing.T) {
+	key := []byte("cluster-key")
+	tr := signedTrace(t, NewHMACSigner(key))
+	assert.Contains(t, tr[0].Signature, SignatureHMAC+":")
+
+	verifier := NewHMACVerifier(key)
+	assert.Equal(t, Verification{Valid: true, Hops: []HopSignature{HopSignatureValid, HopSignatureValid}}, verifier.Verify(tr, replicaSet))
+	assert.Equal(t, "default", tr[1].Namespace)
+	assert.Equal(t, "rs-uid", string(tr[1].UID))
+
+	// The trace survives the annotation round trip
+	parsed, err := Parse(tr.String())
+	require.NoError(t, err)
+	assert.True(t, verifier.Verify(parsed, replicaSet).Valid)
+
+	// Another key
+	assert.Equal(t, []HopSignature{HopSignatureInvalid, HopSignatureInvalid}, NewHMACVerifier([]byte("other")).Verify(tr, replicaSet).Hops)
+}
+
+func TestVerifier_Subject(t *testing.T) {
+	signer := NewHMACSigner([]byte("cluster-key"))
+	verifier := NewHMACVerifier([]byte("cluster-key"))
+
+	tests := []struct {
+		name         string
+		subject      func(Subject) Subject
+		wantMismatch string
+	}{
+		{
+			name:    "the object",
+			subject: func(s Subject) Subject { return s },
+		},
+		{
+			name:         "copied to a recreated object",
+			subject:      func(s Subject) Subject { s.UID = "other-uid"; return s },
+			wantMismatch: `final hop is of UID "rs-uid", not "other-uid"`,
+		},
+		{
+			name:         "copied to another namespace",
+			subject:      func(s Subject) Subject { s.Namespace = "prod"; return s },
+			wantMismatch: `final hop is in namespace "default", not "prod"`,
+		},
+		{
+			name:         "copied to another object",
+			subject:      func(s Subject) Subject { s.Name = "other"; return s },
+			wantMismatch: "final hop is ReplicaSet web-abc, not ReplicaSet other",
+		},
+		{
+			name:         "object changed without trace",
+			subject:      func(s Subject) Subject { s.Generation = 2; return s },
+			wantMismatch: "final hop is of generation 1, not 2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := verifier.Verify(signedTrace(t, signer), tt.subject(replicaSet))
+			assert.Equal(t, []HopSignature{HopSignatureValid, HopSignatureValid}, v.Hops, "signatures stay valid")
+			assert.Equal(t, tt.wantMismatch, v.Mismatch)
+			assert.Equal(t, tt.wantMismatch == "", v.Valid)
+		})
+	}
+
+	// Rebinding the final hop breaks its signature
+	tr := signedTrace(t, signer)
+	tr[1].UID = "other-uid"
+	v := verifier.Verify(tr, Subject{Kind: "ReplicaSet", Namespace: "default", Name: "web-abc", UID: "other-uid", Generation: 1})
+	assert.Equal(t, []HopSignature{HopSignatureValid, HopSignatureInvalid}, v.Hops)
+
+	// Hops creating objects are signed before the UID is assigned
+	created := Trace{NewHop("v1", "ConfigMap", "config", 0, "alice", "req-1")}
+	created[0].Operation = "CREATE"
+	require.NoError(t, signer.Sign(created, 1, Subject{Namespace: "default"}))
+	assert.True(t, verifier.Verify(created, Subject{Kind: "ConfigMap", Namespace: "default", Name: "config", UID: "cm-uid"}).Valid)
+	created[0].Operation = "UPDATE"
+	require.NoError(t, signer.Sign(created, 1, Subject{Namespace: "default"}))
+	assert.False(t, verifier.Verify(created, Subject{Kind: "ConfigMap", Namespace: "default", Name: "config", UID: "cm-uid"}).Valid)
+}
+
+func TestVerifier_Tampering(t *testing.T) {
+	signer := NewHMACSigner([]byte("cluster-key"))
+	verifier := NewHMACVerifier([]byte("cluster-key"))
+
+	tests := []struct {
+		name   string
+		tamper func(Trace) Trace
+		want   []HopSignature
+	}{
+		{
+			name:   "forged origin user",
+			tamper: func(tr Trace) Trace { tr[0].User = "mallory"; return tr },
+			want:   []HopSignature{HopSignatureInvalid, HopSignatureValid},
+		},
+		{
+			name:   "replaced origin",
+			tamper: func(tr Trace) Trace { tr[0] = NewHop("v1", "ConfigMap", "x", 1, "mallory", ""); return tr },
+			want:   []HopSignature{HopSignatureMissing, HopSignatureInvalid},
+		},
+		{
+			name:   "removed origin",
+			tamper: func(tr Trace) Trace { return tr[1:] },
+			want:   []HopSignature{HopSignatureInvalid},
+		},
+		{
+			name: "inserted hop",
+			tamper: func(tr Trace) Trace {
+				return Trace{tr[0], NewHop("v1", "ConfigMap", "x", 1, "mallory", ""), tr[1]}
+			},
+			want: []HopSignature{HopSignatureValid, HopSignatureMissing, HopSignatureInvalid},
+		},
+		{
+			name:   "malformed signature",
+			tamper: func(tr Trace) Trace { tr[1].Signature = "garbage"; return tr },
+			want:   []HopSignature{HopSignatureValid, HopSignatureInvalid},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := verifier.Verify(tt.tamper(signedTrace(t, signer)), replicaSet)
+			assert.False(t, v.Valid)
+			assert.Equal(t, tt.want, v.Hops)
+		})
+	}
+
+	assert.False(t, verifier.Verify(nil, replicaSet).Valid)
+}
+
+func TestLoadSigner(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, data, 0o600))
+		return path
+	}
+	pemFile := func(name, typ string, der []byte) string {
+		return write(name, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}))
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ecPrivate, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	require.NoError(t, err)
+	ecPublic, err := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	require.NoError(t, err)
+
+	edPublicKey, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	edPrivate, err := x509.MarshalPKCS8PrivateKey(edKey)
+	require.NoError(t, err)
+	edPublic, err := x509.MarshalPKIXPublicKey(edPublicKey)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		signing   string
+		verifying string
+		algorithm string
+	}{
+		{"hmac", write("hmac", []byte("cluster-key\n")), write("hmac-copy", []byte("cluster-key")), SignatureHMAC},
+		{"ecdsa", pemFile("ec.key", "PRIVATE KEY", ecPrivate), pemFile("ec.pub", "PUBLIC KEY", ecPublic), SignatureECDSA},
+		{"ecdsa private key as verification key", pemFile("ec2.key", "PRIVATE KEY", ecPrivate), pemFile("ec3.key", "PRIVATE KEY", ecPrivate), SignatureECDSA},
+		{"ed25519", pemFile("ed.key", "PRIVATE KEY", edPrivate), pemFile("ed.pub", "PUBLIC KEY", edPublic), SignatureEd25519},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := LoadSigner(tt.signing)
+			require.NoError(t, err)
+			verifier, err := LoadVerifier(tt.verifying)
+			require.NoError(t, err)
+
+			tr := signedTrace(t, signer)
+			assert.Contains(t, tr[0].Signature, tt.algorithm+":")
+			assert.True(t, verifier.Verify(tr, replicaSet).Valid)
+		})
+	}
+
+	// Signatures of another algorithm are invalid
+	verifier, err := LoadVerifier(pemFile("ec4.pub", "PUBLIC KEY", ecPublic))
+	require.NoError(t, err)
+	assert.False(t, verifier.Verify(signedTrace(t, NewHMACSigner([]byte("cluster-key"))), replicaSet).Valid)
+
+	_, err = LoadSigner(write("empty", []byte("\n")))
+	assert.Error(t, err)
+	_, err = LoadSigner(pemFile("encrypted.key", "ENCRYPTED PRIVATE KEY", []byte("x")))
+	assert.Error(t, err)
+	_, err = LoadSigner(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,67 @@
+package trace
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// driftIncidentsPattern matches the drift suffix of a summary.
+var driftIncidentsPattern = regexp.MustCompile(`, (\d+) drift incidents?$`)
+
+// Summary renders a one-line causal summary of the trace for the
+// SummaryAnnotation, e.g. "updated by Deployment/web gen 7 via user alice, 2 drift incidents".
+// The verb is "created" or "updated"; the drift suffix is omitted if driftIncidents is 0.
+// Objects reached through a reference are "updated by reference from PostgresInstance/db ...".
+func Summary(t Trace, verb string, driftIncidents int) string {
+	var b strings.Builder
+	b.WriteString(verb)
+
+	switch origin := t.Origin(); {
+	case origin == nil:
+		b.WriteString(" by unknown")
+	case len(t) == 1:
+		fmt.Fprintf(&b, " by user %s", userOrUnknown(origin.User))
+	default:
+		parent := t[len(t)-2]
+		if t[len(t)-1].Reference != "" {
+			b.WriteString(" by reference from")
+		} else {
+			b.WriteString(" by")
+		}
+		fmt.Fprintf(&b, " %s/%s gen %d via user %s", parent.Kind, parent.Name, parent.Generation, userOrUnknown(origin.User))
+		if len(t) > 2 {
+			fmt.Fprintf(&b, " (%d hops)", len(t))
+		}
+	}
+
+	switch driftIncidents {
+	case 0:
+	case 1:
+		b.WriteString(", 1 drift incident")
+	default:
+		fmt.Fprintf(&b, ", %d drift incidents", driftIncidents)
+	}
+	return b.String()
+}
+
+// DriftIncidents returns the number of drift incidents recorded in a summary.
+func DriftIncidents(summary string) int {
+	m := driftIncidentsPattern.FindStringSubmatch(summary)
+	if m == nil {
+		return 0
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+func userOrUnknown(user string) string {
+	if user == "" {
+		return "unknown"
+	}
+	return user
+}
@@ -0,0 +1,80 @@
+package trace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummary(t *testing.T) {
+	deployment := Hop{Kind: "Deployment", Name: "web", Generation: 7, User: "alice"}
+	replicaSet := Hop{Kind: "ReplicaSet", Name: "web-abc", Generation: 2, User: "system:serviceaccount:kube-system:deployment-controller"}
+	pod := Hop{Kind: "Pod", Name: "web-abc-xyz", User: "system:serviceaccount:kube-system:replicaset-controller"}
+
+	tests := []struct {
+		name           string
+		trace          Trace
+		verb           string
+		driftIncidents int
+		want           string
+	}{
+		{
+			name:  "origin",
+			trace: Trace{deployment},
+			verb:  "updated",
+			want:  "updated by user alice",
+		},
+		{
+			name:  "controller hop",
+			trace: Trace{deployment, replicaSet},
+			verb:  "created",
+			want:  "created by Deployment/web gen 7 via user alice",
+		},
+		{
+			name:  "nested hops",
+			trace: Trace{deployment, replicaSet, pod},
+			verb:  "created",
+			want:  "created by ReplicaSet/web-abc gen 2 via user alice (3 hops)",
+		},
+		{
+			name:  "reference hop",
+			trace: Trace{{Kind: "PostgresInstance", Name: "db", Generation: 3, User: "alice"}, {Kind: "Secret", Name: "db-conn", Reference: ".spec.writeConnectionSecretToRef"}},
+			verb:  "updated",
+			want:  "updated by reference from PostgresInstance/db gen 3 via user alice",
+		},
+		{
+			name:           "one drift incident",
+			trace:          Trace{deployment, replicaSet},
+			verb:           "updated",
+			driftIncidents: 1,
+			want:           "updated by Deployment/web gen 7 via user alice, 1 drift incident",
+		},
+		{
+			name:           "drift incidents",
+			trace:          Trace{{Kind: "Deployment", Name: "web", Generation: 7}, replicaSet},
+			verb:           "updated",
+			driftIncidents: 2,
+			want:           "updated by Deployment/web gen 7 via user unknown, 2 drift incidents",
+		},
+		{
+			name: "empty trace",
+			verb: "updated",
+			want: "updated by unknown",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Summary(tt.trace, tt.verb, tt.driftIncidents)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.driftIncidents, DriftIncidents(got), "round trip")
+		})
+	}
+}
+
+func TestDriftIncidents(t *testing.T) {
+	assert.Equal(t, 0, DriftIncidents(""))
+	assert.Equal(t, 0, DriftIncidents("updated by user alice"))
+	assert.Equal(t, 12, DriftIncidents("updated by Deployment/web gen 7 via user alice, 12 drift incidents"))
+	assert.Equal(t, 0, DriftIncidents("updated by user alice, 2 drift incidents and more"))
+}
@@ -9,18 +9,42 @@ import (
 const (
 	TraceAnnotation     = v1alpha1.TraceAnnotation
 	TraceMetadataPrefix = v1alpha1.TraceMetadataPrefix
+	TicketAnnotation    = v1alpha1.TraceTicketAnnotation
+	TicketLabel         = v1alpha1.TraceTicketLabel
+	SummaryAnnotation   = v1alpha1.SummaryAnnotation
+)
+
+// Trace annotation schema versions - re-exported from api/v1alpha1.
+const (
+	SchemaV1 = v1alpha1.TraceSchemaV1
+	SchemaV2 = v1alpha1.TraceSchemaV2
+)
+
+// Actor kinds - re-exported from api/v1alpha1.
+const (
+	ActorKindUser       = v1alpha1.ActorKindUser
+	ActorKindController = v1alpha1.ActorKindController
 )
 
 // Types - re-exported from api/v1alpha1.
 type (
-	Trace = v1alpha1.Trace
-	Hop   = v1alpha1.Hop
+	Trace       = v1alpha1.Trace
+	Hop         = v1alpha1.Hop
+	TicketRef   = v1alpha1.TicketRef
+	EventRef    = v1alpha1.EventRef
+	Predecessor = v1alpha1.Predecessor
+	Envelope    = v1alpha1.TraceEnvelope
+	ActorKind   = v1alpha1.ActorKind
 )
 
 // Parse parses a trace from its JSON representation.
 // Re-exported from api/v1alpha1.ParseTrace.
 var Parse = v1alpha1.ParseTrace
 
+// ParseAnnotation parses a kausality.io/trace annotation of any schema version.
+// Re-exported from api/v1alpha1.ParseTraceAnnotation.
+var ParseAnnotation = v1alpha1.ParseTraceAnnotation
+
 // NewHop creates a new Hop with the current timestamp.
 var NewHop = v1alpha1.NewHop
 
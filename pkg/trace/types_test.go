@@ -50,6 +50,21 @@ func TestTrace_Parse(t *testing.T) {
 			]`,
 			want: 2,
 		},
+		{
+			name:  "v2 envelope",
+			input: `{"version": "v2", "traceID": "abc", "hops": [{"apiVersion": "apps/v1", "kind": "Deployment", "name": "d1", "generation": 1, "user": "u1", "actorKind": "User", "operation": "UPDATE"}]}`,
+			want:  1,
+		},
+		{
+			name:  "later version with unknown fields",
+			input: `{"version": "v3", "future": true, "hops": [{"apiVersion": "apps/v1", "kind": "Deployment", "name": "d1", "generation": 1, "user": "u1", "cost": 3}]}`,
+			want:  1,
+		},
+		{
+			name:    "envelope without version",
+			input:   `{"hops": []}`,
+			wantErr: true,
+		},
 		{
 			name:    "invalid json",
 			input:   "not json",
@@ -86,6 +101,39 @@ func TestTrace_String(t *testing.T) {
 	assert.Equal(t, "Deployment", parsed[0].Kind)
 }
 
+func TestTrace_Annotation(t *testing.T) {
+	ts := metav1.Time{Time: time.Date(2026, 1, 24, 10, 30, 0, 0, time.UTC)}
+	origin := Hop{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Generation: 5, User: "hans@example.com",
+		ActorKind: ActorKindUser, Operation: "UPDATE", RequestUID: "req-1", Timestamp: ts}
+	trace := Trace{origin}
+
+	envelope, err := ParseAnnotation(trace.Annotation())
+	require.NoError(t, err)
+	assert.Equal(t, SchemaV2, envelope.Version)
+	assert.Equal(t, trace.ID(), envelope.TraceID)
+	if diff := cmp.Diff([]Hop(trace), envelope.Hops); diff != "" {
+		t.Errorf("hops differ (-want +got):\n%s", diff)
+	}
+
+	// Annotations of schema v1 are upgraded on the next write
+	v1, err := ParseAnnotation(trace.String())
+	require.NoError(t, err)
+	assert.Equal(t, SchemaV1, v1.Version)
+	assert.Empty(t, v1.TraceID)
+	assert.Equal(t, trace.Annotation(), Trace(v1.Hops).Annotation())
+
+	// Extended traces keep the ID of their origin
+	extended := trace.Append(Hop{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-abc", Generation: 1, ActorKind: ActorKindController})
+	assert.Len(t, trace.ID(), 32)
+	assert.Equal(t, trace.ID(), extended.ID())
+	other := Trace{origin}
+	other[0].RequestUID = "req-2"
+	assert.NotEqual(t, trace.ID(), other.ID())
+
+	assert.Empty(t, Trace(nil).ID())
+	assert.Equal(t, `{"version":"v2","hops":[]}`, Trace(nil).Annotation())
+}
+
 func TestTrace_Origin(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -4,7 +4,6 @@ package crossplane
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"testing"
 	"time"
@@ -18,6 +17,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/rand"
 
 	ktesting "github.com/kausality-io/kausality/pkg/testing"
+	"github.com/kausality-io/kausality/pkg/trace"
 )
 
 // =============================================================================
@@ -191,8 +191,8 @@ func TestTraceExtendOnCompositionReconcile(t *testing.T) {
 			return false, "no trace annotation"
 		}
 
-		var hops []map[string]interface{}
-		if err := json.Unmarshal([]byte(traceStr), &hops); err != nil {
+		hops, err := trace.Parse(traceStr)
+		if err != nil {
 			return false, fmt.Sprintf("parse error: %v", err)
 		}
 
@@ -202,8 +202,8 @@ func TestTraceExtendOnCompositionReconcile(t *testing.T) {
 
 		// Log the trace hops for debugging
 		for i, hop := range hops {
-			kind, _ := hop["kind"].(string)
-			name, _ := hop["name"].(string)
+			kind := hop.Kind
+			name := hop.Name
 			t.Logf("  Hop %d: %s/%s", i, kind, name)
 		}
 
@@ -353,8 +353,8 @@ func TestTraceOriginOnDirectNopResourceEdit(t *testing.T) {
 		if !found || traceStr == "" {
 			return false, "no trace annotation"
 		}
-		var hops []map[string]interface{}
-		if err := json.Unmarshal([]byte(traceStr), &hops); err != nil {
+		hops, err := trace.Parse(traceStr)
+		if err != nil {
 			return false, fmt.Sprintf("parse error: %v", err)
 		}
 		if len(hops) < 2 {
@@ -399,8 +399,8 @@ func TestTraceOriginOnDirectNopResourceEdit(t *testing.T) {
 			return false, "no trace annotation"
 		}
 
-		var hops []map[string]interface{}
-		if err := json.Unmarshal([]byte(traceStr), &hops); err != nil {
+		hops, err := trace.Parse(traceStr)
+		if err != nil {
 			return false, fmt.Sprintf("parse error: %v", err)
 		}
 
@@ -409,7 +409,7 @@ func TestTraceOriginOnDirectNopResourceEdit(t *testing.T) {
 		}
 
 		// The single hop should be for the NopResource
-		hopKind, _ := hops[0]["kind"].(string)
+		hopKind := hops[0].Kind
 		assert.Equal(t, "NopResource", hopKind, "origin hop should be NopResource")
 
 		return true, "NopResource has 1-hop origin trace (fresh origin)"
@@ -4,7 +4,6 @@ package kubernetes
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"testing"
 
@@ -17,6 +16,7 @@ import (
 	"k8s.io/client-go/util/retry"
 
 	ktesting "github.com/kausality-io/kausality/pkg/testing"
+	"github.com/kausality-io/kausality/pkg/trace"
 )
 
 // =============================================================================
@@ -106,8 +106,8 @@ func TestTraceExtendOnControllerReconcile(t *testing.T) {
 			return false, fmt.Sprintf("no trace annotation on replicaset %s", rs.Name)
 		}
 
-		var hops []map[string]interface{}
-		if err := json.Unmarshal([]byte(traceStr), &hops); err != nil {
+		hops, err := trace.Parse(traceStr)
+		if err != nil {
 			return false, fmt.Sprintf("failed to parse trace: %v", err)
 		}
 
@@ -116,13 +116,13 @@ func TestTraceExtendOnControllerReconcile(t *testing.T) {
 		}
 
 		// First hop should be the Deployment (origin)
-		firstKind, _ := hops[0]["kind"].(string)
+		firstKind := hops[0].Kind
 		if firstKind != "Deployment" {
 			return false, fmt.Sprintf("first hop kind=%s, expected Deployment", firstKind)
 		}
 
 		// Last hop should be the ReplicaSet
-		lastKind, _ := hops[len(hops)-1]["kind"].(string)
+		lastKind := hops[len(hops)-1].Kind
 		if lastKind != "ReplicaSet" {
 			return false, fmt.Sprintf("last hop kind=%s, expected ReplicaSet", lastKind)
 		}
@@ -214,8 +214,8 @@ func TestTraceOriginOnDirectUserEdit(t *testing.T) {
 			return false, "no trace annotation yet"
 		}
 
-		var hops []map[string]interface{}
-		if err := json.Unmarshal([]byte(traceStr), &hops); err != nil {
+		hops, err := trace.Parse(traceStr)
+		if err != nil {
 			return false, fmt.Sprintf("failed to parse trace: %v", err)
 		}
 		if len(hops) < 2 {
@@ -264,8 +264,8 @@ func TestTraceOriginOnDirectUserEdit(t *testing.T) {
 			return false, "no trace annotation"
 		}
 
-		var hops []map[string]interface{}
-		if err := json.Unmarshal([]byte(traceStr), &hops); err != nil {
+		hops, err := trace.Parse(traceStr)
+		if err != nil {
 			return false, fmt.Sprintf("failed to parse trace: %v", err)
 		}
 
@@ -274,7 +274,7 @@ func TestTraceOriginOnDirectUserEdit(t *testing.T) {
 		}
 
 		// The single hop should be for the ReplicaSet (this object)
-		hopKind, _ := hops[0]["kind"].(string)
+		hopKind := hops[0].Kind
 		if hopKind != "ReplicaSet" {
 			return false, fmt.Sprintf("origin hop kind=%s, expected ReplicaSet", hopKind)
 		}
@@ -360,8 +360,8 @@ func TestTraceOriginOnUpdate(t *testing.T) {
 		if traceStr == "" {
 			return false, "no trace"
 		}
-		var hops []map[string]interface{}
-		if err := json.Unmarshal([]byte(traceStr), &hops); err != nil || len(hops) < 2 {
+		hops, err := trace.Parse(traceStr)
+		if err != nil || len(hops) < 2 {
 			return false, fmt.Sprintf("expected >=2 hops, got trace: %s", traceStr)
 		}
 		return true, fmt.Sprintf("initial: %d-hop trace", len(hops))
@@ -392,8 +392,8 @@ func TestTraceOriginOnUpdate(t *testing.T) {
 			return false, fmt.Sprintf("error: %v", err)
 		}
 		traceStr := rs.Annotations["kausality.io/trace"]
-		var hops []map[string]interface{}
-		if err := json.Unmarshal([]byte(traceStr), &hops); err != nil {
+		hops, err := trace.Parse(traceStr)
+		if err != nil {
 			return false, fmt.Sprintf("parse error: %v", err)
 		}
 		if len(hops) != 1 {
@@ -444,8 +444,8 @@ func TestTraceOriginOnUpdate(t *testing.T) {
 			if traceStr == "" {
 				return false, fmt.Sprintf("no trace on active replicaset %s", rs.Name)
 			}
-			var hops []map[string]interface{}
-			if err := json.Unmarshal([]byte(traceStr), &hops); err != nil {
+			hops, err := trace.Parse(traceStr)
+			if err != nil {
 				return false, fmt.Sprintf("parse error: %v", err)
 			}
 			if len(hops) < 2 {
@@ -453,7 +453,7 @@ func TestTraceOriginOnUpdate(t *testing.T) {
 			}
 
 			// Verify first hop is Deployment
-			firstKind, _ := hops[0]["kind"].(string)
+			firstKind := hops[0].Kind
 			assert.Equal(t, "Deployment", firstKind, "first hop should be Deployment")
 
 			return true, fmt.Sprintf("active RS %s has %d-hop trace", rs.Name, len(hops))
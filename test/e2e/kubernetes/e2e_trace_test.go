@@ -4,7 +4,6 @@ package kubernetes
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"testing"
 
@@ -15,6 +14,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/rand"
 
 	ktesting "github.com/kausality-io/kausality/pkg/testing"
+	"github.com/kausality-io/kausality/pkg/trace"
 )
 
 // =============================================================================
@@ -112,9 +112,9 @@ func TestTracePropagation(t *testing.T) {
 			return false, fmt.Sprintf("no trace annotation yet on replicaset %s", rs.Name)
 		}
 
-		// Parse the trace as an array of hops
-		var hops []map[string]interface{}
-		if err := json.Unmarshal([]byte(traceAnnotation), &hops); err != nil {
+		// Parse the hops of the trace
+		hops, err := trace.Parse(traceAnnotation)
+		if err != nil {
 			return false, fmt.Sprintf("failed to parse trace annotation: %v", err)
 		}
 
@@ -126,14 +126,11 @@ func TestTracePropagation(t *testing.T) {
 		foundTicket := false
 		foundPR := false
 		for _, hop := range hops {
-			labels, ok := hop["labels"].(map[string]interface{})
-			if ok {
-				if _, hasTicket := labels["ticket"]; hasTicket {
-					foundTicket = true
-				}
-				if _, hasPR := labels["pr"]; hasPR {
-					foundPR = true
-				}
+			if _, hasTicket := hop.Labels["ticket"]; hasTicket {
+				foundTicket = true
+			}
+			if _, hasPR := hop.Labels["pr"]; hasPR {
+				foundPR = true
 			}
 		}
 
@@ -18,6 +18,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	kausalityv1alpha1 "github.com/kausality-io/kausality/api/v1alpha1"
+	kausalityv1beta1 "github.com/kausality-io/kausality/api/v1beta1"
 )
 
 const (
@@ -54,6 +55,7 @@ func TestMain(m *testing.M) {
 	// Create controller-runtime client for Kausality CRDs
 	scheme := runtime.NewScheme()
 	_ = kausalityv1alpha1.AddToScheme(scheme)
+	_ = kausalityv1beta1.AddToScheme(scheme)
 	_ = corev1.AddToScheme(scheme)
 
 	kausalityClient, err = client.New(config, client.Options{Scheme: scheme})